@@ -0,0 +1,863 @@
+// Package app assembles the intrapay server: database, repositories, service,
+// routes and middleware. cmd/server runs it from environment configuration;
+// other binaries and tests can embed it with their own dependencies.
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/nehciyy/intrapay/internal/api"
+	"github.com/nehciyy/intrapay/internal/chaos"
+	"github.com/nehciyy/intrapay/internal/clock"
+	"github.com/nehciyy/intrapay/internal/db"
+	"github.com/nehciyy/intrapay/internal/digest"
+	"github.com/nehciyy/intrapay/internal/eventschema"
+	"github.com/nehciyy/intrapay/internal/expiry"
+	"github.com/nehciyy/intrapay/internal/forward"
+	"github.com/nehciyy/intrapay/internal/fx"
+	"github.com/nehciyy/intrapay/internal/idgen"
+	"github.com/nehciyy/intrapay/internal/intent"
+	"github.com/nehciyy/intrapay/internal/invariant"
+	"github.com/nehciyy/intrapay/internal/metering"
+	"github.com/nehciyy/intrapay/internal/metrics"
+	"github.com/nehciyy/intrapay/internal/middleware"
+	"github.com/nehciyy/intrapay/internal/migrate"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/money"
+	"github.com/nehciyy/intrapay/internal/outbox"
+	"github.com/nehciyy/intrapay/internal/region"
+	"github.com/nehciyy/intrapay/internal/repository"
+	"github.com/nehciyy/intrapay/internal/revaluation"
+	"github.com/nehciyy/intrapay/internal/security"
+	"github.com/nehciyy/intrapay/internal/selfcheck"
+	"github.com/nehciyy/intrapay/internal/service"
+	"github.com/nehciyy/intrapay/internal/signing"
+	"github.com/nehciyy/intrapay/internal/slo"
+	"github.com/nehciyy/intrapay/internal/webhook"
+	"github.com/nehciyy/intrapay/internal/workerpool"
+	"github.com/nehciyy/intrapay/migrations"
+)
+
+// sloExportInterval is how often the SLO gauges are brought up to date.
+const sloExportInterval = 15 * time.Second
+
+// fxRateTimeout bounds each request to the exchange rate provider.
+const fxRateTimeout = 5 * time.Second
+
+// readinessPath serves the self-check report, without authentication, on both
+// listeners.
+const readinessPath = "/readyz"
+
+// readinessPingTimeout bounds the database ping of each readiness probe.
+const readinessPingTimeout = 2 * time.Second
+
+// selfCheckTimeout bounds the startup self-check.
+const selfCheckTimeout = 30 * time.Second
+
+// A sequence that has used sequenceWarnAt of its range is logged; one at
+// sequenceFailAt stops the server from starting.
+const (
+	sequenceWarnAt = 0.75
+	sequenceFailAt = 0.99
+)
+
+// A server clock more than clockSkewWarning from the database's is logged; one
+// more than clockSkewLimit apart stops the server from starting.
+const (
+	clockSkewWarning = time.Second
+	clockSkewLimit   = time.Minute
+)
+
+// App is a configured intrapay server.
+type App struct {
+	cfg    Config
+	logger *log.Logger
+	clock  clock.Clock
+
+	db              *sql.DB
+	accountRepo     repository.AccountRepository
+	transactionRepo repository.TransactionRepository
+	service         service.Service
+	checker         *invariant.Checker
+	meter           *metering.Meter
+	sweeper         *expiry.Sweeper
+	relay           *outbox.Relay
+	slo             *slo.Tracker
+	fence           *region.Fence
+	revaluation     *revaluation.Job
+	intents         *intent.Job
+	digests         *digest.Job
+	forward         *forward.Queue
+	security        *security.Stream
+	webhooks        *workerpool.Pool
+	readiness       selfcheck.Report
+	router          *mux.Router
+	admin           *mux.Router
+}
+
+// New builds an App from cfg. Dependencies not supplied through options are
+// created from cfg: the database is opened with db.InitDB and the repositories
+// are the Postgres implementations.
+func New(cfg Config, opts ...Option) (*App, error) {
+	a := &App{cfg: cfg, logger: log.Default(), clock: clock.System}
+	for _, opt := range opts {
+		opt(a)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	// Rounding policies come first: the currency takes its minor units from
+	// them.
+	if err := money.SetDefaultMode(cfg.RoundingMode); err != nil {
+		return nil, err
+	}
+	for code, p := range cfg.RoundingPolicies {
+		if err := money.SetPolicy(code, p); err != nil {
+			return nil, fmt.Errorf("rounding policy of %s: %w", code, err)
+		}
+	}
+	if cfg.Currency != "" {
+		if err := models.SetCurrency(cfg.Currency); err != nil {
+			return nil, err
+		}
+	}
+
+	// Fault injection for resilience testing; never enable in production
+	var injector *chaos.Injector
+	if cfg.Chaos.Enabled {
+		a.logger.Printf("WARNING: chaos mode enabled, injecting faults: %+v", cfg.Chaos)
+		injector = chaos.New(cfg.Chaos, a.clock.Now().UnixNano())
+	}
+
+	// Reads hinted read-only go to the replica, if one is configured, or else to
+	// the primary as the read-only role, if one is configured, when the database
+	// is opened here.
+	var replica, reader *sql.DB
+	opened := a.db == nil
+	if opened {
+		var wrappers []db.ConnectorWrapper
+		if injector != nil {
+			wrappers = append(wrappers, injector.WrapConnector)
+		}
+		database, err := db.InitDB(wrappers...)
+		if err != nil {
+			return nil, err
+		}
+		if err := metrics.RegisterDBStats(database); err != nil {
+			return nil, err
+		}
+		a.db = database
+
+		if replica, err = db.InitReplica(wrappers...); err != nil {
+			return nil, err
+		}
+		if replica != nil {
+			if err := metrics.RegisterReplicaDBStats(replica); err != nil {
+				return nil, err
+			}
+			a.logger.Println("read replica enabled: read-only requests may be served from DATABASE_REPLICA_URL")
+		}
+
+		if reader, err = db.InitReader(wrappers...); err != nil {
+			return nil, err
+		}
+		if reader != nil {
+			if err := metrics.RegisterReaderDBStats(reader); err != nil {
+				return nil, err
+			}
+			a.logger.Println("read-only role enabled: read-only requests are served as the DATABASE_READ_URL role")
+		}
+	}
+
+	// Slow query and lock-wait logging, disabled unless a threshold is set
+	queryLogger := repository.NewQueryLogger(a.db, cfg.SlowQueryThreshold)
+	if queryLogger != nil {
+		queryLogger.Logger = a.logger
+	}
+	queryLog := repository.WithQueryLogger(queryLogger)
+	// Reads are routed, timed out and retried by the hints of their context.
+	routing := []repository.Option{queryLog, repository.WithReplica(replica), repository.WithReader(reader), repository.WithHintPolicy(cfg.QueryHints)}
+	// Tenant rows are additionally isolated by the database's row-level
+	// security policies, which only bind roles that do not bypass them.
+	tenancy := []repository.Option{queryLog}
+	if cfg.RowLevelSecurity {
+		tenancy = append(tenancy, repository.WithRowLevelSecurity())
+	}
+	// The policies hide webhooks and reimbursements from sessions not scoped to
+	// a tenant whether or not the option is on, so each role the server opened
+	// a connection as must bypass them. An injected database is checked only
+	// with the option.
+	if opened || cfg.RowLevelSecurity {
+		roles := []struct {
+			env string
+			db  *sql.DB
+		}{{"DATABASE_URL", a.db}, {"DATABASE_REPLICA_URL", replica}, {"DATABASE_READ_URL", reader}}
+		for _, role := range roles {
+			if role.db == nil {
+				continue
+			}
+			if err := a.checkRowSecurity(role.env, role.db, cfg.RowLevelSecurity); err != nil {
+				return nil, fmt.Errorf("row-level security: %w", err)
+			}
+		}
+		if cfg.RowLevelSecurity {
+			a.logger.Println("row-level security enabled: tenant webhooks and reimbursements are isolated by the database")
+		}
+	}
+	postgresAccounts := repository.NewPostgresAccountRepository(a.db, routing...)
+
+	// Generated transaction IDs are stored in transaction_ref and returned in
+	// place of the serial key; the sequence strategy exposes the serial key itself.
+	transactionOpts := routing
+	if cfg.TransactionIDStrategy != "" && cfg.TransactionIDStrategy != idgen.StrategySequence {
+		ids, err := idgen.New(cfg.TransactionIDStrategy, nil, cfg.IDNode)
+		if err != nil {
+			return nil, fmt.Errorf("transaction IDs: %w", err)
+		}
+		transactionOpts = append(transactionOpts, repository.WithIDGenerator(ids))
+	}
+	accountIDs, err := idgen.New(cfg.AccountIDStrategy, postgresAccounts.NextAccountID, cfg.IDNode)
+	if err != nil {
+		return nil, fmt.Errorf("account IDs: %w", err)
+	}
+	if !idgen.Numeric(accountIDs) {
+		return nil, fmt.Errorf("account IDs: strategy %q does not produce integer IDs", cfg.AccountIDStrategy)
+	}
+
+	var ledger *repository.PostgresLedgerRepository
+	if cfg.LedgerShadowMode {
+		a.logger.Println("ledger shadow mode enabled: mirroring writes to ledger_entries")
+		ledger = repository.NewPostgresLedgerRepository(a.db, transactionOpts...)
+	}
+	if a.accountRepo == nil {
+		a.accountRepo = postgresAccounts
+		if ledger != nil {
+			a.accountRepo = repository.NewShadowAccountRepository(a.accountRepo, ledger)
+		}
+		if injector != nil {
+			a.accountRepo = chaos.WrapAccountRepository(a.accountRepo, injector)
+		}
+	}
+	if a.transactionRepo == nil {
+		a.transactionRepo = repository.NewPostgresTransactionRepository(a.db, transactionOpts...)
+		if ledger != nil {
+			a.transactionRepo = repository.NewShadowTransactionRepository(a.transactionRepo, ledger)
+		}
+		if injector != nil {
+			a.transactionRepo = chaos.WrapTransactionRepository(a.transactionRepo, injector)
+		}
+	}
+
+	// Bulk ingestion logs transactions as the transaction repository does, and
+	// in ledger shadow mode writes their ledger entries too.
+	ingestOpts := slices.Clip(transactionOpts)
+	if ledger != nil {
+		ingestOpts = append(ingestOpts, repository.WithLedgerEntries())
+	}
+
+	// Sampled per-transfer and periodic global ledger invariant checks
+	a.checker = invariant.NewChecker(cfg.InvariantSampleRate, postgresAccounts)
+
+	// Per-key and per-tenant call and volume counts for chargeback, with quotas
+	usageRepo := repository.NewPostgresUsageRepository(a.db, queryLog)
+	quotaRepo := repository.NewPostgresQuotaRepository(a.db, queryLog)
+	a.meter = metering.New(usageRepo, cfg.UsageQuotas, a.clock, a.logger, metering.WithQuotaStore(quotaRepo))
+
+	// TTL sweeper for pending actions nobody decided on in time
+	pendingActions := repository.NewPostgresPendingActionRepository(a.db, queryLog)
+	a.sweeper = expiry.New(a.clock, a.logger, pendingActionPolicies(cfg.PendingActionTTLs, pendingActions)...)
+
+	// Events are written to the outbox with each transfer; the relay publishes
+	// them, if a publisher is configured, and delivers them to webhooks unless
+	// it is turned off.
+	outboxRepo := repository.NewPostgresOutboxRepository(a.db, queryLog)
+	var publisher outbox.EventPublisher
+	if cfg.OutboxPublisher != "off" {
+		if publisher, err = newEventPublisher(cfg, a.logger); err != nil {
+			return nil, err
+		}
+	}
+
+	// Security events are kept apart from the business audit trail and, with a
+	// webhook configured, forwarded to it for a SIEM.
+	securityRepo := repository.NewPostgresSecurityEventRepository(a.db, queryLog)
+	var securityOpts []security.Option
+	if cfg.SecurityWebhookURL != "" {
+		securityOpts = append(securityOpts, security.WithWebhook(webhook.NewClient(cfg.WebhookTimeout), cfg.SecurityWebhookURL))
+	}
+	a.security = security.NewStream(securityRepo, a.logger, securityOpts...)
+
+	// Webhook events are delivered in the background of the requests raising
+	// them; Run drains the deliveries still queued when it shuts down.
+	a.webhooks = workerpool.New(context.Background(), "webhooks", cfg.WebhookWorkers, cfg.WebhookQueueSize, a.logger)
+
+	// The migrations define the indexes the index advisory expects, and the
+	// schema the self-check compares the database with.
+	all, err := migrate.Load(migrations.FS)
+	if err != nil {
+		return nil, err
+	}
+	expectedIndexes, err := migrate.ExpectedIndexes(all)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceOpts := []service.Option{
+		service.WithInvariantChecker(a.checker),
+		service.WithClock(a.clock),
+		service.WithHintPolicy(cfg.QueryHints),
+		service.WithAccountIDGenerator(accountIDs),
+		service.WithUsageRepository(usageRepo),
+		service.WithQuotaRepository(quotaRepo),
+		service.WithAccountLimit(cfg.AccountLimit),
+		service.WithTopUps(repository.NewPostgresTopUpRepository(a.db, queryLog)),
+		service.WithSpendingTokens(repository.NewPostgresSpendingTokenRepository(a.db, queryLog)),
+		service.WithCashback(repository.NewPostgresCashbackRepository(a.db, queryLog)),
+		service.WithTransferIntents(repository.NewPostgresTransferIntentRepository(a.db, queryLog)),
+		service.WithForwardedTransfers(repository.NewPostgresForwardedTransferRepository(a.db, queryLog)),
+		service.WithTreasury(repository.NewPostgresTreasuryRepository(a.db, routing...)),
+		service.WithTransactionTags(repository.NewPostgresTransactionTagRepository(a.db, routing...)),
+		service.WithSandbox(repository.NewPostgresSandboxRepository(a.db, queryLog)),
+		service.WithTimeline(repository.NewPostgresTimelineRepository(a.db, routing...)),
+		service.WithImpersonationAudit(repository.NewPostgresImpersonationRepository(a.db, queryLog)),
+		service.WithSecurityEvents(securityRepo),
+		service.WithIndexAdvisory(repository.NewPostgresIndexAdvisoryRepository(a.db, queryLog), expectedIndexes),
+		service.WithTransferFreezes(repository.NewPostgresTransferFreezeRepository(a.db, queryLog)),
+		service.WithAmountBounds(repository.NewPostgresAmountBoundsRepository(a.db, queryLog)),
+		service.WithPendingActionRepository(pendingActions),
+		service.WithTransactionAttemptRepository(repository.NewPostgresTransactionAttemptRepository(a.db, queryLog)),
+		service.WithIngestRepository(repository.NewPostgresIngestRepository(ingestOpts...)),
+		service.WithOutboxRepository(outboxRepo),
+		service.WithWebhooks(repository.NewPostgresWebhookRepository(a.db, tenancy...), webhook.NewClient(cfg.WebhookTimeout)),
+		service.WithWebhookWorkers(a.webhooks),
+		service.WithNotificationDigests(repository.NewPostgresNotificationRepository(a.db, queryLog)),
+	}
+	if cfg.ConditionalDebit {
+		serviceOpts = append(serviceOpts, service.WithConditionalDebit())
+	}
+	if len(cfg.Coalescing.Accounts) > 0 {
+		serviceOpts = append(serviceOpts, service.WithCoalescing(cfg.Coalescing))
+		a.logger.Printf("write coalescing enabled: transfers to accounts %v are posted together every %s, up to %d at a time", cfg.Coalescing.Accounts, cfg.Coalescing.Window, cfg.Coalescing.MaxBatch)
+	}
+	if cfg.SuspenseAccountID != 0 {
+		serviceOpts = append(serviceOpts, service.WithSuspenseAccount(cfg.SuspenseAccountID, repository.NewPostgresSuspenseRepository(a.db, queryLog)))
+	}
+	if cfg.ReimbursementAccountID != 0 {
+		serviceOpts = append(serviceOpts, service.WithReimbursements(cfg.ReimbursementAccountID, repository.NewPostgresReimbursementRepository(a.db, tenancy...)))
+	}
+	// Active-passive deployments: only the active region writes. The database
+	// enforces the fence; the cached copy turns writes away early.
+	if cfg.Region != "" {
+		regionRepo := repository.NewPostgresRegionRepository(a.db, queryLog)
+		a.fence = region.New(cfg.Region, regionRepo, a.logger)
+		serviceOpts = append(serviceOpts, service.WithRegion(cfg.Region, regionRepo))
+		a.logger.Printf("region %s: writes are accepted only while it is the active region", cfg.Region)
+	}
+	if cfg.FXRateProviderURL != "" {
+		provider, err := fx.NewHTTPProvider(cfg.FXRateProviderURL, fxRateTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid FX_RATE_PROVIDER_URL: %w", err)
+		}
+		serviceOpts = append(serviceOpts, service.WithFXRates(fx.NewCache(provider, cfg.FXRateCacheTTL, cfg.FXRateMaxAge, a.clock)))
+		a.logger.Printf("live exchange rates enabled: cached for %s, stale after %s", cfg.FXRateCacheTTL, cfg.FXRateMaxAge)
+	}
+	if cfg.Revaluation.BaseCurrency != "" {
+		serviceOpts = append(serviceOpts, service.WithRevaluation(cfg.Revaluation, repository.NewPostgresRevaluationRepository(a.db, routing...)))
+	}
+	a.service = service.NewService(a.db, a.accountRepo, a.transactionRepo, serviceOpts...)
+	if publisher != nil {
+		a.relay = outbox.NewRelay(outboxRepo, publisher, cfg.OutboxBatchSize, a.logger,
+			outbox.WithValidator(eventschema.Validate), outbox.WithWebhooks(a.service.NotifyEventWebhooks))
+	}
+	// Top-ups and cashback are recorded with the transfers setting them off;
+	// those still pending a while later, e.g. after a crash, are resumed.
+	a.intents = intent.New(a.service, a.clock, cfg.TransferIntentResumeInterval, a.logger)
+	a.digests = digest.New(a.service, a.logger)
+	if cfg.ForwardQueueDir != "" {
+		if a.forward, err = forward.Open(cfg.ForwardQueueDir, a.service, a.clock, cfg.ForwardQueueMaxEntries, cfg.ForwardQueueMaxAge, a.logger); err != nil {
+			return nil, fmt.Errorf("store-and-forward: %w", err)
+		}
+		a.logger.Printf("store-and-forward enabled: up to %d transfers are queued in %s while the database is unreachable, for up to %s", cfg.ForwardQueueMaxEntries, cfg.ForwardQueueDir, cfg.ForwardQueueMaxAge)
+	}
+	if cfg.Revaluation.BaseCurrency != "" {
+		loc := cfg.Revaluation.Location
+		if loc == nil {
+			loc = time.UTC
+		}
+		a.revaluation = revaluation.New(a.service, a.clock, loc, a.logger)
+		a.logger.Printf("revaluation enabled: %s balances are marked to %s at the close of each day in %s", models.CurrentCurrency().Code, cfg.Revaluation.BaseCurrency, loc)
+	}
+
+	if err := cfg.SLO.Validate(); err != nil {
+		return nil, err
+	}
+	a.slo = slo.New(cfg.SLO, a.clock)
+	server := &api.Server{Service: a.service, SLO: a.slo, Security: a.security, Forward: a.forward}
+	if cfg.ResponseSigningKeyFile != "" {
+		if server.Signer, err = signing.LoadSigner(cfg.ResponseSigningKeyFile, a.clock); err != nil {
+			return nil, fmt.Errorf("response signing: %w", err)
+		}
+		a.logger.Printf("response signing enabled: key %s", server.Signer.KeyID())
+	}
+	if a.router == nil {
+		a.router = mux.NewRouter()
+	}
+	registerRoutes(a.router, server)
+	a.admin = mux.NewRouter()
+	registerAdminRoutes(a.admin, a.router, server)
+
+	// Configurable stages run first (after impersonation, below), then metrics,
+	// compression, test mode and fault injection.
+	cfg.Middleware.Security = a.security
+	// One Lockout serves both chains, so failing on one endpoint counts towards
+	// a lockout from the other.
+	if cfg.Middleware.Lockouts == nil && cfg.Middleware.Lockout.Threshold > 0 {
+		cfg.Middleware.Lockouts = middleware.NewLockout(cfg.Middleware.Lockout, a.clock, a.logger, a.security)
+	}
+	chain, err := middleware.FromConfig(cfg.Middleware, a.logger, a.clock,
+		middleware.Stage{Name: middleware.StageMetering, Middleware: a.meter.Middleware})
+	if err != nil {
+		return nil, err
+	}
+	chain.Append(
+		middleware.Stage{Name: "instrument", Middleware: api.Instrument},
+		middleware.Stage{Name: "compress", Middleware: api.Compress(cfg.CompressionMinSize)},
+		middleware.Stage{Name: "sandbox", Middleware: server.Sandbox},
+	)
+	if a.fence != nil {
+		chain.Append(middleware.Stage{Name: "region", Middleware: api.PassiveRegion(a.fence.Active)})
+	}
+	if injector != nil {
+		chain.Append(middleware.Stage{Name: "chaos", Middleware: injector.Middleware})
+	}
+	// Impersonation runs before everything else: it authenticates with the admin
+	// token, which only an admin token of its own enables, and turns the request
+	// into the customer's before auth and metering see it.
+	chain.Prepend(middleware.Stage{Name: "impersonation", Middleware: server.Impersonate(cfg.AdminAuthToken)})
+	a.logger.Printf("middleware: %s", strings.Join(chain.Names(), " -> "))
+	a.router.Use(chain.Then)
+
+	// Admin endpoints have their own token and are neither metered nor rate
+	// limited. Without a token of their own they take the public one, so they are
+	// never less protected than the customer API.
+	adminToken := cfg.AdminAuthToken
+	if adminToken == "" {
+		adminToken = cfg.Middleware.AuthToken
+	}
+	adminChain, err := middleware.FromConfig(middleware.Config{
+		Stages:    []string{middleware.StageAuth, middleware.StageLogging, middleware.StageRecovery, middleware.StageTimeout},
+		AuthToken: adminToken,
+		Lockouts:  cfg.Middleware.Lockouts,
+		Security:  a.security,
+		Timeout:   cfg.Middleware.Timeout,
+	}, a.logger, a.clock)
+	if err != nil {
+		return nil, err
+	}
+	adminChain.Append(
+		middleware.Stage{Name: "instrument", Middleware: api.Instrument},
+		middleware.Stage{Name: "compress", Middleware: api.Compress(cfg.CompressionMinSize)},
+	)
+	a.logger.Printf("admin middleware: %s", strings.Join(adminChain.Names(), " -> "))
+	a.admin.Use(adminChain.Then)
+
+	// The self-check fails fast on what would otherwise fail later, and its
+	// report is served on /readyz.
+	checks := []selfcheck.Check{a.configCheck()}
+	if opened {
+		checks = append(checks,
+			selfcheck.SchemaVersion(a.db, all, cfg.AllowSchemaSkew),
+			selfcheck.Indexes(a.db, all),
+			selfcheck.Sequences(a.db, sequenceWarnAt, sequenceFailAt),
+			selfcheck.Clock(a.db, a.clock, clockSkewWarning, clockSkewLimit),
+		)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), selfCheckTimeout)
+	defer cancel()
+	a.readiness = selfcheck.Run(ctx, a.clock, checks...)
+	for _, w := range a.readiness.Warnings() {
+		a.logger.Printf("WARNING: self-check %s: %s; %s", w.Name, w.Message, w.Action)
+	}
+	if err := a.readiness.Err(); err != nil {
+		return nil, err
+	}
+	a.logger.Printf("self-check passed: %d checks, %d warnings", len(a.readiness.Checks), len(a.readiness.Warnings()))
+
+	return a, nil
+}
+
+// configCheck checks that the accounts the configuration names exist, which
+// Config.Validate cannot know.
+// checkRowSecurity refuses the role of the database at env unless it sees the
+// rows of every tenant in a session not scoped to one: it is a member of
+// intrapay_rls_bypass, a superuser or has BYPASSRLS. With the option on, the
+// DATABASE_URL role bypassing the policies is only warned about.
+func (a *App) checkRowSecurity(env string, database *sql.DB, enabled bool) error {
+	bypass, err := repository.BypassesRowSecurity(database)
+	if err != nil {
+		return fmt.Errorf("%s: %w", env, err)
+	}
+	if bypass {
+		if enabled && env == "DATABASE_URL" {
+			a.logger.Println("WARNING: row-level security enabled, but the database role is a superuser or has BYPASSRLS, so the policies do not apply")
+		}
+		return nil
+	}
+	member, err := repository.IsRowSecurityBypassMember(database)
+	if err != nil {
+		return fmt.Errorf("%s: %w", env, err)
+	}
+	if !member {
+		return fmt.Errorf("the %s role must be a member of intrapay_rls_bypass for the requests and jobs not scoped to a tenant", env)
+	}
+	return nil
+}
+
+func (a *App) configCheck() selfcheck.Check {
+	accounts := []struct {
+		setting string
+		id      int64
+	}{
+		{"SUSPENSE_ACCOUNT_ID", a.cfg.SuspenseAccountID},
+		{"REIMBURSEMENT_ACCOUNT_ID", a.cfg.ReimbursementAccountID},
+		{"FX_GAIN_ACCOUNT_ID", a.cfg.Revaluation.GainAccountID},
+		{"FX_LOSS_ACCOUNT_ID", a.cfg.Revaluation.LossAccountID},
+	}
+	return selfcheck.Check{Name: "config", Run: func(ctx context.Context) selfcheck.Result {
+		var missing, settings []string
+		for _, acc := range accounts {
+			if acc.id == 0 {
+				continue
+			}
+			exists, err := a.accountRepo.AccountExists(ctx, acc.id)
+			if err != nil {
+				return selfcheck.Failure("check that the database is reachable", "looking up account %d of %s: %v", acc.id, acc.setting, err)
+			}
+			if !exists {
+				missing = append(missing, fmt.Sprintf("%s %d", acc.setting, acc.id))
+				settings = append(settings, acc.setting)
+			}
+		}
+		if len(missing) > 0 {
+			return selfcheck.Failure("create the accounts, or correct or unset "+strings.Join(settings, ", "),
+				"no such account: %s", strings.Join(missing, ", "))
+		}
+		return selfcheck.Pass("valid")
+	}}
+}
+
+// ready returns the startup self-check report with whether the database is
+// still reachable.
+func (a *App) ready(ctx context.Context) selfcheck.Report {
+	ctx, cancel := context.WithTimeout(ctx, readinessPingTimeout)
+	defer cancel()
+	ping := selfcheck.Pass("reachable")
+	if err := a.db.PingContext(ctx); err != nil {
+		ping = selfcheck.Failure("check the database and the network to it", "unreachable: %v", err)
+	}
+	return a.readiness.With("database", ping)
+}
+
+// newEventPublisher returns the outbox publisher cfg.OutboxPublisher names, and
+// for none a publisher that leaves the relay delivering events to webhooks alone.
+func newEventPublisher(cfg Config, logger *log.Logger) (outbox.EventPublisher, error) {
+	switch cfg.OutboxPublisher {
+	case "":
+		return outbox.NopPublisher{}, nil
+	case "log":
+		return outbox.LogPublisher{Logger: logger}, nil
+	case "amqp":
+		return outbox.NewAMQPPublisher(cfg.AMQP)
+	}
+	return nil, fmt.Errorf("outbox: unknown publisher %q, expected log, amqp or off", cfg.OutboxPublisher)
+}
+
+// pendingActionPolicies returns an expiry policy per pending action kind with a
+// TTL, in kind order.
+func pendingActionPolicies(ttls map[models.PendingActionKind]time.Duration, repo repository.PendingActionRepository) []expiry.Policy {
+	policies := make([]expiry.Policy, 0, len(ttls))
+	for kind, ttl := range ttls {
+		policies = append(policies, expiry.Policy{
+			Name: "pending_action:" + string(kind),
+			TTL:  ttl,
+			Expire: func(cutoff time.Time) (int64, error) {
+				return repo.ExpirePendingActions(kind, cutoff)
+			},
+		})
+	}
+	sort.Slice(policies, func(i, j int) bool { return policies[i].Name < policies[j].Name })
+	return policies
+}
+
+func registerRoutes(router *mux.Router, server *api.Server) {
+	router.HandleFunc("/accounts", server.CreateAccount).Methods("POST")
+	router.HandleFunc("/accounts", server.ListAccounts).Methods("GET")
+	router.HandleFunc("/accounts/{id}", server.GetAccount).Methods("GET")
+	router.HandleFunc("/accounts/{id}", server.HeadAccount).Methods("HEAD")
+	router.HandleFunc("/accounts/{id}", server.DeleteAccount).Methods("DELETE")
+	router.HandleFunc("/accounts/{id}/exists", server.AccountExists).Methods("GET")
+	router.HandleFunc("/accounts/{id}/display-name", server.SetDisplayName).Methods("PUT")
+	router.HandleFunc("/accounts/{id}/notifications", server.GetNotificationPreference).Methods("GET")
+	router.HandleFunc("/accounts/{id}/notifications", server.SetNotificationPreference).Methods("PUT")
+	router.HandleFunc("/accounts/{id}/transactions", server.ListAccountTransactions).Methods("GET")
+	router.HandleFunc("/accounts/{id}/summary", server.GetAccountSummary).Methods("GET")
+	router.HandleFunc("/accounts/{id}/tags", server.ListAccountTags).Methods("GET")
+	router.HandleFunc("/accounts/{id}/timeline", server.AccountTimeline).Methods("GET")
+	router.HandleFunc("/accounts/{id}/tokens", server.CreateSpendingToken).Methods("POST")
+	router.HandleFunc("/accounts/{id}/tokens", server.ListSpendingTokens).Methods("GET")
+	createTransaction := http.Handler(http.HandlerFunc(server.CreateTransaction))
+	if server.Signer != nil {
+		createTransaction = api.Sign(server.Signer)(createTransaction)
+		router.HandleFunc("/.well-known/jwks.json", server.JWKS).Methods("GET")
+	}
+	router.Handle("/transactions", createTransaction).Methods("POST")
+	router.HandleFunc("/transactions", server.ListTransactions).Methods("GET")
+	router.HandleFunc("/transactions/inbound", server.ReceivePayment).Methods("POST")
+	router.HandleFunc("/transactions/lookup", server.LookupTransactions).Methods("POST")
+	router.HandleFunc("/transactions/batch", server.CreateTransactionBatch).Methods("POST")
+	router.HandleFunc("/transactions/forwarded/{id}", server.GetForwardedTransfer).Methods("GET")
+	router.HandleFunc("/transactions/{id}", server.GetTransaction).Methods("GET")
+	router.HandleFunc("/transactions/{id}", server.SetTransactionTags).Methods("PATCH")
+	router.HandleFunc("/tokens/{id}", server.GetSpendingToken).Methods("GET")
+	router.HandleFunc("/tokens/{id}/revoke", server.RevokeSpendingToken).Methods("POST")
+	router.HandleFunc("/tokens/{id}/debits", server.DebitWithToken).Methods("POST")
+	router.HandleFunc("/reimbursements", server.CreateReimbursement).Methods("POST")
+	router.HandleFunc("/reimbursements/{id}", server.GetReimbursement).Methods("GET")
+	router.HandleFunc("/payroll", server.CommitPayroll).Methods("POST")
+	router.HandleFunc("/payroll/preview", server.PreviewPayroll).Methods("POST")
+	router.HandleFunc("/sync/transactions", server.SyncTransactions).Methods("GET")
+	router.HandleFunc("/reason-codes", server.ListReasonCodes).Methods("GET")
+	router.HandleFunc("/fx/rates", server.GetFXRates).Methods("GET")
+	router.HandleFunc("/webhooks", server.CreateWebhook).Methods("POST")
+	router.HandleFunc("/webhooks", server.ListWebhooks).Methods("GET")
+	router.HandleFunc("/webhooks/{id}", server.GetWebhook).Methods("GET")
+	router.HandleFunc("/webhooks/{id}", server.DeleteWebhook).Methods("DELETE")
+	router.HandleFunc("/webhooks/{id}/ping", server.PingWebhook).Methods("POST")
+	router.HandleFunc("/events", server.ListEvents).Methods("GET")
+	router.HandleFunc("/events/schemas", server.ListEventSchemas).Methods("GET")
+	router.HandleFunc("/events/schemas/{type}/{version}", server.GetEventSchema).Methods("GET")
+	ledgerPath, ledger := server.LedgerService()
+	router.PathPrefix(ledgerPath).Handler(ledger).Methods("POST")
+	router.Handle("/metrics", metrics.Handler()).Methods("GET")
+	router.Handle("/transactions", api.Options(router, http.HandlerFunc(server.TransactionCapabilities))).Methods("OPTIONS")
+	router.Methods("OPTIONS").Handler(api.Options(router, nil))
+	router.NotFoundHandler = api.NotFound()
+	router.MethodNotAllowedHandler = api.MethodNotAllowed(router)
+}
+
+// adminPrefix is the path every admin endpoint lives under.
+const adminPrefix = "/admin/"
+
+// registerAdminRoutes registers the admin endpoints on router. /admin/routes
+// lists the routes of both router and public.
+func registerAdminRoutes(router, public *mux.Router, server *api.Server) {
+	router.HandleFunc("/admin/accounts/{id}", server.GetAccountDetails).Methods("GET")
+	router.HandleFunc("/admin/accounts/{id}/recompute", server.RecomputeAccountBalance).Methods("POST")
+	router.HandleFunc("/admin/accounts/{id}/restore", server.RestoreAccount).Methods("POST")
+	router.HandleFunc("/admin/accounts/{id}/transactions", server.ListAccountTransactionDetails).Methods("GET")
+	router.HandleFunc("/admin/accounts/{id}/timeline", server.AccountTimelineDetails).Methods("GET")
+	router.HandleFunc("/admin/accounts/{id}/top-up", server.GetTopUpRule).Methods("GET")
+	router.HandleFunc("/admin/accounts/{id}/top-up", server.SetTopUpRule).Methods("PUT")
+	router.HandleFunc("/admin/accounts/{id}/top-up", server.DeleteTopUpRule).Methods("DELETE")
+	router.HandleFunc("/admin/treasury/positions", server.GetTreasuryPositions).Methods("GET")
+	router.HandleFunc("/admin/treasury/largest-accounts", server.GetLargestAccounts).Methods("GET")
+	router.HandleFunc("/admin/treasury/flows", server.GetDailyFlows).Methods("GET")
+	router.HandleFunc("/admin/fx/rates/{date}", server.PutFXRate).Methods("PUT")
+	router.HandleFunc("/admin/revaluations", server.CreateRevaluation).Methods("POST")
+	router.HandleFunc("/admin/revaluations", server.ListRevaluations).Methods("GET")
+	router.HandleFunc("/admin/revaluations/{id}", server.GetRevaluation).Methods("GET")
+	router.HandleFunc("/admin/sandbox/purge", server.PurgeTestData).Methods("POST")
+	router.HandleFunc("/admin/campaigns", server.CreateCashbackCampaign).Methods("POST")
+	router.HandleFunc("/admin/campaigns", server.ListCashbackCampaigns).Methods("GET")
+	router.HandleFunc("/admin/campaigns/{id}", server.GetCashbackCampaign).Methods("GET")
+	router.HandleFunc("/admin/campaigns/{id}", server.UpdateCashbackCampaign).Methods("PUT")
+	router.HandleFunc("/admin/campaigns/{id}", server.DeleteCashbackCampaign).Methods("DELETE")
+	router.HandleFunc("/admin/usage", server.GetUsage).Methods("GET")
+	router.HandleFunc("/admin/quotas", server.ListQuotas).Methods("GET")
+	router.HandleFunc("/admin/quotas/{scope}/{subject}", server.GetQuota).Methods("GET")
+	router.HandleFunc("/admin/quotas/{scope}/{subject}", server.SetQuota).Methods("PUT")
+	router.HandleFunc("/admin/quotas/{scope}/{subject}", server.DeleteQuota).Methods("DELETE")
+	router.HandleFunc("/admin/account-limits/{tenant}", server.GetAccountLimit).Methods("GET")
+	router.HandleFunc("/admin/account-limits/{tenant}", server.SetAccountLimit).Methods("PUT")
+	router.HandleFunc("/admin/account-limits/{tenant}", server.DeleteAccountLimit).Methods("DELETE")
+	router.HandleFunc("/admin/amount-bounds", server.ListAmountBounds).Methods("GET")
+	router.HandleFunc("/admin/amount-bounds/{tenant}", server.SetAmountBounds).Methods("PUT")
+	router.HandleFunc("/admin/amount-bounds/{tenant}", server.DeleteAmountBounds).Methods("DELETE")
+	router.HandleFunc("/admin/pending-actions", server.ListPendingActions).Methods("GET")
+	router.HandleFunc("/admin/pending-actions/{id}/claim", server.ClaimPendingAction).Methods("POST")
+	router.HandleFunc("/admin/pending-actions/{id}/assign", server.AssignPendingAction).Methods("POST")
+	router.HandleFunc("/admin/suspense", server.ListSuspenseItems).Methods("GET")
+	router.HandleFunc("/admin/suspense/{id}/repost", server.RepostSuspenseItem).Methods("POST")
+	router.HandleFunc("/admin/reimbursements/{id}/approve", server.ApproveReimbursement).Methods("POST")
+	router.HandleFunc("/admin/reimbursements/{id}/reject", server.RejectReimbursement).Methods("POST")
+	router.HandleFunc("/admin/transaction-attempts", server.ListTransactionAttempts).Methods("GET")
+	router.HandleFunc("/admin/transaction-attempts/stats", server.TransactionAttemptStats).Methods("GET")
+	router.HandleFunc("/admin/outbox/relay", server.GetOutboxRelay).Methods("GET")
+	router.HandleFunc("/admin/outbox/relay/pause", server.PauseOutboxRelay).Methods("POST")
+	router.HandleFunc("/admin/outbox/relay/resume", server.ResumeOutboxRelay).Methods("POST")
+	router.HandleFunc("/admin/outbox/relay/replay", server.ReplayOutbox).Methods("POST")
+	router.HandleFunc("/admin/slo", server.GetSLO).Methods("GET")
+	router.HandleFunc("/admin/region", server.GetRegion).Methods("GET")
+	router.HandleFunc("/admin/region/promote", server.PromoteRegion).Methods("POST")
+	router.HandleFunc("/admin/requests/{request_id}", server.LookupRequest).Methods("GET")
+	router.HandleFunc("/admin/impersonations", server.ListImpersonations).Methods("GET")
+	router.HandleFunc("/admin/security-events", server.ListSecurityEvents).Methods("GET")
+	router.HandleFunc("/admin/index-advisory", server.GetIndexAdvisory).Methods("GET")
+	router.HandleFunc("/admin/freezes", server.FreezeTransfers).Methods("POST")
+	router.HandleFunc("/admin/freezes", server.ListTransferFreezes).Methods("GET")
+	router.HandleFunc("/admin/freezes/lift", server.LiftTransferFreezes).Methods("POST")
+	router.HandleFunc("/admin/routes", api.Routes(public, router)).Methods("GET")
+	router.Methods("OPTIONS").Handler(api.Options(router, nil))
+	router.NotFoundHandler = api.NotFound()
+	router.MethodNotAllowedHandler = api.MethodNotAllowed(router)
+}
+
+// Handler returns the HTTP handler serving the intrapay API. Unless the admin
+// endpoints have a listener of their own (Config.AdminAddr), it also serves them
+// under /admin/.
+func (a *App) Handler() http.Handler {
+	return api.RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == readinessPath:
+			api.Readiness(a.ready).ServeHTTP(w, r)
+		case a.cfg.AdminAddr == "" && strings.HasPrefix(r.URL.Path, adminPrefix):
+			a.admin.ServeHTTP(w, r)
+		default:
+			a.router.ServeHTTP(w, r)
+		}
+	}))
+}
+
+// AdminHandler returns the HTTP handler serving only the admin endpoints, and
+// /readyz.
+func (a *App) AdminHandler() http.Handler {
+	return api.RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == readinessPath {
+			api.Readiness(a.ready).ServeHTTP(w, r)
+			return
+		}
+		a.admin.ServeHTTP(w, r)
+	}))
+}
+
+// Service returns the business logic behind the API, for tools that act on the
+// same database without going through HTTP, such as cmd/reprocess.
+func (a *App) Service() service.Service {
+	return a.service
+}
+
+// Run serves HTTP on cfg.Addr, and on cfg.AdminAddr if set, until ctx is
+// cancelled. Alongside the servers it runs the background loops:
+//   - invariant checks, usage flushes, expiry sweeps and SLO exports
+//   - the security event stream
+//   - transfer intent resumption and notification digests
+//   - the outbox relay, unless OutboxPublisher is "off"
+//   - the region fence refresh, with a region configured
+//   - balance revaluation, with a base currency configured
+//   - the forward queue replay, with store-and-forward enabled
+//
+// When ctx is cancelled or a listener fails, Run shuts down gracefully within
+// cfg.ShutdownTimeout. The servers finish the requests in flight first. Then
+// the loops finish what they are doing and stop: the usage meter flushes its
+// counts, the security stream records the events queued, and the outbox relay
+// moves its position past the events it published. Last, the webhook
+// deliveries still queued are made and the forward queue is closed.
+func (a *App) Run(ctx context.Context) error {
+	// The loops outlive ctx, so that they still flush and record what the
+	// requests in flight leave behind.
+	loopCtx, stopLoops := context.WithCancel(context.WithoutCancel(ctx))
+	defer stopLoops()
+	loops := []workerpool.Task{
+		func(ctx context.Context) { a.checker.Run(ctx, a.cfg.InvariantCheckInterval) },
+		func(ctx context.Context) { a.meter.Run(ctx, a.cfg.UsageFlushInterval) },
+		func(ctx context.Context) { a.sweeper.Run(ctx, a.cfg.ExpirySweepInterval) },
+		func(ctx context.Context) { a.slo.Run(ctx, sloExportInterval) },
+		func(ctx context.Context) { a.security.Run(ctx) },
+		func(ctx context.Context) { a.intents.Run(ctx, a.cfg.TransferIntentResumeInterval) },
+		func(ctx context.Context) { a.digests.Run(ctx, a.cfg.NotificationDigestInterval) },
+	}
+	if a.relay != nil {
+		loops = append(loops, func(ctx context.Context) { a.relay.Run(ctx, a.cfg.OutboxRelayInterval) })
+	}
+	if a.fence != nil {
+		loops = append(loops, func(ctx context.Context) { a.fence.Run(ctx, a.cfg.RegionFenceInterval) })
+	}
+	if a.revaluation != nil {
+		loops = append(loops, func(ctx context.Context) { a.revaluation.Run(ctx, a.cfg.RevaluationInterval) })
+	}
+	if a.forward != nil {
+		loops = append(loops, func(ctx context.Context) { a.forward.Run(ctx, a.cfg.ForwardReplayInterval) })
+	}
+	// Every loop has a worker of its own for as long as it runs.
+	schedulers := workerpool.New(loopCtx, "schedulers", len(loops), len(loops), a.logger)
+	for _, loop := range loops {
+		if err := schedulers.Submit(loop); err != nil {
+			return err
+		}
+	}
+
+	servers := []*http.Server{{Addr: a.cfg.Addr, Handler: a.Handler(), ErrorLog: a.logger}}
+	if a.cfg.AdminAddr != "" {
+		servers = append(servers, &http.Server{Addr: a.cfg.AdminAddr, Handler: a.AdminHandler(), ErrorLog: a.logger})
+	}
+	errCh := make(chan error, len(servers))
+	for i, srv := range servers {
+		name := "intrapay server"
+		if i > 0 {
+			name = "intrapay admin server"
+		}
+		go func() {
+			a.logger.Println(name, "is running on", srv.Addr)
+			errCh <- srv.ListenAndServe()
+		}()
+	}
+
+	// A listener that fails takes the others down with it.
+	var err error
+	running := len(servers)
+	select {
+	case err = <-errCh:
+		running--
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), a.cfg.ShutdownTimeout)
+	defer cancel()
+	for _, srv := range servers {
+		if shutdownErr := srv.Shutdown(shutdownCtx); shutdownErr != nil && err == nil {
+			err = fmt.Errorf("shutdown: %w", shutdownErr)
+		}
+	}
+	for ; running > 0; running-- {
+		if serveErr := <-errCh; !errors.Is(serveErr, http.ErrServerClosed) && err == nil {
+			err = serveErr
+		}
+	}
+
+	stopLoops()
+	for _, pool := range []*workerpool.Pool{schedulers, a.webhooks} {
+		if drainErr := pool.Drain(shutdownCtx); drainErr != nil {
+			a.logger.Printf("shutdown: %v", drainErr)
+		}
+	}
+	if a.forward != nil {
+		// The transfers still queued are replayed on the next start.
+		if closeErr := a.forward.Close(); closeErr != nil {
+			a.logger.Printf("shutdown: close forward queue: %v", closeErr)
+		}
+	}
+	a.logger.Println("intrapay server stopped")
+	return err
+}
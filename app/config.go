@@ -0,0 +1,678 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/chaos"
+	"github.com/nehciyy/intrapay/internal/db"
+	"github.com/nehciyy/intrapay/internal/idgen"
+	"github.com/nehciyy/intrapay/internal/metering"
+	"github.com/nehciyy/intrapay/internal/middleware"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/money"
+	"github.com/nehciyy/intrapay/internal/outbox"
+	"github.com/nehciyy/intrapay/internal/service"
+	"github.com/nehciyy/intrapay/internal/slo"
+)
+
+// Config holds the server settings. Database connection and pool settings are
+// read by db.InitDB from DATABASE_URL and DB_*, and those of the optional read
+// replica by db.InitReplica from DATABASE_REPLICA_URL, unless a database is
+// injected with WithDB.
+type Config struct {
+	// Addr is the listen address, e.g. ":8080".
+	Addr string
+	// AdminAddr is a separate listen address for the /admin endpoints, e.g.
+	// "10.0.0.5:9090"; empty serves them on Addr.
+	AdminAddr string
+	// ShutdownTimeout bounds how long Run takes to shut down once its context
+	// is cancelled: to finish the requests in flight, stop the background
+	// loops and make the webhook deliveries still queued. Work left at the
+	// deadline is abandoned.
+	ShutdownTimeout time.Duration
+	// AdminAuthToken is the bearer token for the /admin endpoints; empty uses
+	// Middleware.AuthToken.
+	AdminAuthToken string
+	// Currency is the ISO 4217 code all accounts are held in; empty keeps USD.
+	Currency string
+	// RoundingMode is how computed amounts are rounded in currencies without a
+	// rounding policy of their own.
+	RoundingMode money.Mode
+	// RoundingPolicies overrides the minor units and rounding mode of
+	// currencies by ISO 4217 code.
+	RoundingPolicies map[string]money.Policy
+	// SlowQueryThreshold enables slow query logging; zero disables it.
+	SlowQueryThreshold time.Duration
+	// QueryHints sets the statement timeouts of read-only and critical
+	// operations and how often idempotent reads are retried.
+	QueryHints db.Policy
+	// LedgerShadowMode mirrors writes to ledger_entries and compares balances.
+	LedgerShadowMode bool
+	// InvariantSampleRate is the fraction of transfers (0 to 1) checked before commit.
+	InvariantSampleRate float64
+	// InvariantCheckInterval is how often the global balance sum is verified.
+	InvariantCheckInterval time.Duration
+	// ConditionalDebit debits with a single conditional UPDATE.
+	ConditionalDebit bool
+	// RowLevelSecurity scopes the transactions on a tenant's webhooks and
+	// reimbursements to the tenant, for the database's row-level security
+	// policies to enforce.
+	RowLevelSecurity bool
+	// AllowSchemaSkew starts the server even when the database schema is behind
+	// the migrations, logging the difference instead of refusing to start.
+	AllowSchemaSkew bool
+	// CompressionMinSize is the smallest response body that is compressed.
+	CompressionMinSize int
+	// TransactionIDStrategy picks how public transaction IDs are generated:
+	// "ulid" (the default), "uuidv7", "snowflake" or "sequence" (the serial key).
+	TransactionIDStrategy string
+	// AccountIDStrategy picks how IDs are generated for accounts created without
+	// one: "sequence" or "snowflake".
+	AccountIDStrategy string
+	// IDNode distinguishes instances generating snowflake IDs (0 to 1023).
+	IDNode int64
+	// Middleware selects and configures the HTTP middleware stages.
+	Middleware middleware.Config
+	// UsageFlushInterval is how often metered usage is written to api_usage.
+	UsageFlushInterval time.Duration
+	// UsageQuotas are the monthly call and volume limits per API key; zero
+	// means unlimited.
+	UsageQuotas metering.Quotas
+	// AccountLimit is the most open accounts a tenant may have unless it has an
+	// account limit of its own; zero means unlimited.
+	AccountLimit int64
+	// SuspenseAccountID is the account inbound payments are parked on when their
+	// destination is unknown or closed; zero rejects such payments instead.
+	SuspenseAccountID int64
+	// ReimbursementAccountID is the company account approved expense
+	// reimbursements are paid from; zero disables reimbursements.
+	ReimbursementAccountID int64
+	// ExpirySweepInterval is how often the TTL sweeper expires stale pending entities.
+	ExpirySweepInterval time.Duration
+	// PendingActionTTLs expires the open pending actions of a kind once they are
+	// older than its TTL. Kinds without a TTL never expire.
+	PendingActionTTLs map[models.PendingActionKind]time.Duration
+	// OutboxPublisher picks where the outbox relay publishes events: "log",
+	// "amqp", or empty for nowhere. The relay delivers the events to webhooks
+	// either way, unless it is "off", which does not run the relay; one
+	// instance running the relay is enough. Events are written to the outbox
+	// in any case.
+	OutboxPublisher string
+	// AMQP configures the "amqp" outbox publisher.
+	AMQP outbox.AMQPConfig
+	// OutboxRelayInterval is how often the relay polls the outbox.
+	OutboxRelayInterval time.Duration
+	// OutboxBatchSize is the most events the relay reads per round trip.
+	OutboxBatchSize int
+	// WebhookTimeout bounds each request to a webhook URL, including the
+	// registration handshake.
+	WebhookTimeout time.Duration
+	// WebhookWorkers is how many webhook deliveries run at once, in the
+	// background of the requests that raise their events.
+	WebhookWorkers int
+	// WebhookQueueSize is how many webhook deliveries may wait for a worker;
+	// beyond it deliveries are made in the request that raised them.
+	WebhookQueueSize int
+	// SecurityWebhookURL is where security events are forwarded as they are
+	// recorded, for a SIEM to ingest. Empty only records them.
+	SecurityWebhookURL string
+	// ResponseSigningKeyFile is a PEM file holding the Ed25519 private key that
+	// transaction creation responses are signed with. Empty disables signing.
+	ResponseSigningKeyFile string
+	// SLO sets the transfer endpoint's service level objectives.
+	SLO slo.Config
+	// Region names this server's region in an active-passive deployment; its
+	// writes are rejected unless it is the active region. Empty runs unfenced.
+	// db.InitDB reads the same REGION setting to tag database sessions.
+	Region string
+	// RegionFenceInterval is how often the region fence is read to learn
+	// whether this region is active.
+	RegionFenceInterval time.Duration
+	// Revaluation marks balances to a base currency at end-of-day rates; an
+	// empty BaseCurrency disables it.
+	Revaluation service.RevaluationConfig
+	// RevaluationInterval is how often the last closed day is revalued if it
+	// has not been yet.
+	RevaluationInterval time.Duration
+	// TransferIntentResumeInterval is how often the top-ups and cashback still
+	// pending after a transfer committed are resumed, and how long they are
+	// left pending first: on a restart, those a crash interrupted.
+	TransferIntentResumeInterval time.Duration
+	// NotificationDigestInterval is how often the daily digests of the last
+	// closed day are sent to the accounts that want them and were not sent
+	// theirs yet.
+	NotificationDigestInterval time.Duration
+	// ForwardQueueDir is the directory of the store-and-forward queue, which
+	// accepts transfers while the database is unreachable and replays them
+	// once it is back (see forward.Queue). Empty disables it.
+	ForwardQueueDir string
+	// ForwardQueueMaxEntries is the most transfers the queue holds; the
+	// transfers requested while it is full fail as they would without it.
+	ForwardQueueMaxEntries int
+	// ForwardQueueMaxAge is how long a transfer may wait on the queue; one
+	// older by the time it is replayed is expired rather than made.
+	ForwardQueueMaxAge time.Duration
+	// ForwardReplayInterval is how often the queue is replayed.
+	ForwardReplayInterval time.Duration
+	// FXRateProviderURL is a Frankfurter-compatible API live exchange rates are
+	// fetched from (see fx.HTTPProvider). Empty disables live rates.
+	FXRateProviderURL string
+	// FXRateCacheTTL is how long a live rate is served before it is fetched
+	// again.
+	FXRateCacheTTL time.Duration
+	// FXRateMaxAge is how long after it was published a live rate turns stale;
+	// stale rates are flagged and not converted at.
+	FXRateMaxAge time.Duration
+	// Coalescing posts the transfers to hot accounts that arrive within a
+	// window together; no Accounts disables it.
+	Coalescing service.CoalescingConfig
+	// Chaos configures fault injection; never enable in production.
+	Chaos chaos.Config
+}
+
+// DefaultConfig returns the settings used when no environment overrides are set.
+func DefaultConfig() Config {
+	return Config{
+		Addr:                         ":8080",
+		ShutdownTimeout:              10 * time.Second,
+		RoundingMode:                 money.HalfUp,
+		InvariantSampleRate:          0.01,
+		InvariantCheckInterval:       5 * time.Minute,
+		CompressionMinSize:           1024,
+		TransactionIDStrategy:        idgen.StrategyULID,
+		AccountIDStrategy:            idgen.StrategySequence,
+		Middleware:                   middleware.DefaultConfig(),
+		UsageFlushInterval:           time.Minute,
+		ExpirySweepInterval:          time.Minute,
+		OutboxRelayInterval:          time.Second,
+		OutboxBatchSize:              100,
+		AMQP:                         outbox.DefaultAMQPConfig(),
+		WebhookTimeout:               5 * time.Second,
+		WebhookWorkers:               8,
+		WebhookQueueSize:             1000,
+		SLO:                          slo.DefaultConfig(),
+		RegionFenceInterval:          5 * time.Second,
+		RevaluationInterval:          time.Hour,
+		TransferIntentResumeInterval: time.Minute,
+		NotificationDigestInterval:   time.Hour,
+		ForwardQueueMaxEntries:       10000,
+		ForwardQueueMaxAge:           15 * time.Minute,
+		ForwardReplayInterval:        5 * time.Second,
+		FXRateCacheTTL:               5 * time.Minute,
+		FXRateMaxAge:                 48 * time.Hour,
+		Coalescing:                   service.CoalescingConfig{Window: 5 * time.Millisecond, MaxBatch: 100},
+	}
+}
+
+// ConfigFromEnv reads PORT, ADMIN_ADDR, ADMIN_AUTH_TOKEN, SHUTDOWN_TIMEOUT, CURRENCY, ROUNDING_MODE,
+// ROUNDING_POLICIES, SLOW_QUERY_THRESHOLD,
+// the query hint settings (see db.PolicyFromEnv), LEDGER_SHADOW_MODE,
+// INVARIANT_SAMPLE_RATE, INVARIANT_CHECK_INTERVAL, CONDITIONAL_DEBIT, ROW_LEVEL_SECURITY,
+// ALLOW_SCHEMA_SKEW, COMPRESSION_MIN_SIZE, TRANSACTION_ID_STRATEGY, ACCOUNT_ID_STRATEGY, ID_NODE, the
+// middleware settings (see middleware.ConfigFromEnv), USAGE_FLUSH_INTERVAL,
+// USAGE_MONTHLY_CALL_QUOTA, USAGE_MONTHLY_VOLUME_QUOTA, ACCOUNT_LIMIT, SUSPENSE_ACCOUNT_ID,
+// REIMBURSEMENT_ACCOUNT_ID, EXPIRY_SWEEP_INTERVAL, PENDING_ACTION_TTLS, OUTBOX_PUBLISHER,
+// OUTBOX_RELAY_INTERVAL, OUTBOX_BATCH_SIZE, the AMQP_* settings, WEBHOOK_TIMEOUT,
+// WEBHOOK_WORKERS, WEBHOOK_QUEUE_SIZE, SECURITY_WEBHOOK_URL, RESPONSE_SIGNING_KEY_FILE, the SLO_* settings, REGION, REGION_FENCE_INTERVAL,
+// the revaluation settings (see revaluationConfigFromEnv), FX_RATE_PROVIDER_URL,
+// FX_RATE_CACHE_TTL, FX_RATE_MAX_AGE, TRANSFER_INTENT_RESUME_INTERVAL,
+// NOTIFICATION_DIGEST_INTERVAL, FORWARD_QUEUE_DIR, FORWARD_QUEUE_MAX_ENTRIES, FORWARD_QUEUE_MAX_AGE,
+// FORWARD_REPLAY_INTERVAL, the coalescing settings (see coalescingConfigFromEnv) and the CHAOS_* settings on
+// top of DefaultConfig.
+func ConfigFromEnv() (Config, error) {
+	cfg := DefaultConfig()
+	var err error
+
+	if v := os.Getenv("PORT"); v != "" {
+		cfg.Addr = ":" + v
+	}
+	cfg.AdminAddr = os.Getenv("ADMIN_ADDR")
+	cfg.AdminAuthToken = os.Getenv("ADMIN_AUTH_TOKEN")
+	if v := os.Getenv("SHUTDOWN_TIMEOUT"); v != "" {
+		if cfg.ShutdownTimeout, err = time.ParseDuration(v); err != nil || cfg.ShutdownTimeout <= 0 {
+			return cfg, fmt.Errorf("invalid SHUTDOWN_TIMEOUT %q: must be a positive duration", v)
+		}
+	}
+	cfg.Currency = os.Getenv("CURRENCY")
+	if v := os.Getenv("ROUNDING_MODE"); v != "" {
+		if cfg.RoundingMode, err = money.ParseMode(v); err != nil {
+			return cfg, fmt.Errorf("invalid ROUNDING_MODE: %w", err)
+		}
+	}
+	if v := os.Getenv("ROUNDING_POLICIES"); v != "" {
+		if cfg.RoundingPolicies, err = parseRoundingPolicies(v); err != nil {
+			return cfg, fmt.Errorf("invalid ROUNDING_POLICIES: %w", err)
+		}
+	}
+	if v := os.Getenv("SLOW_QUERY_THRESHOLD"); v != "" {
+		if cfg.SlowQueryThreshold, err = time.ParseDuration(v); err != nil {
+			return cfg, fmt.Errorf("invalid SLOW_QUERY_THRESHOLD %q: %w", v, err)
+		}
+	}
+	if cfg.QueryHints, err = db.PolicyFromEnv(); err != nil {
+		return cfg, err
+	}
+	cfg.LedgerShadowMode, _ = strconv.ParseBool(os.Getenv("LEDGER_SHADOW_MODE"))
+	if v := os.Getenv("INVARIANT_SAMPLE_RATE"); v != "" {
+		if cfg.InvariantSampleRate, err = strconv.ParseFloat(v, 64); err != nil {
+			return cfg, fmt.Errorf("invalid INVARIANT_SAMPLE_RATE %q: %w", v, err)
+		}
+	}
+	if v := os.Getenv("INVARIANT_CHECK_INTERVAL"); v != "" {
+		if cfg.InvariantCheckInterval, err = time.ParseDuration(v); err != nil {
+			return cfg, fmt.Errorf("invalid INVARIANT_CHECK_INTERVAL %q: %w", v, err)
+		}
+	}
+	cfg.ConditionalDebit, _ = strconv.ParseBool(os.Getenv("CONDITIONAL_DEBIT"))
+	cfg.RowLevelSecurity, _ = strconv.ParseBool(os.Getenv("ROW_LEVEL_SECURITY"))
+	cfg.AllowSchemaSkew, _ = strconv.ParseBool(os.Getenv("ALLOW_SCHEMA_SKEW"))
+	if v := os.Getenv("COMPRESSION_MIN_SIZE"); v != "" {
+		if cfg.CompressionMinSize, err = strconv.Atoi(v); err != nil {
+			return cfg, fmt.Errorf("invalid COMPRESSION_MIN_SIZE %q: %w", v, err)
+		}
+	}
+	if v := os.Getenv("TRANSACTION_ID_STRATEGY"); v != "" {
+		cfg.TransactionIDStrategy = v
+	}
+	if v := os.Getenv("ACCOUNT_ID_STRATEGY"); v != "" {
+		cfg.AccountIDStrategy = v
+	}
+	if v := os.Getenv("ID_NODE"); v != "" {
+		if cfg.IDNode, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return cfg, fmt.Errorf("invalid ID_NODE %q: %w", v, err)
+		}
+	}
+	if cfg.Middleware, err = middleware.ConfigFromEnv(); err != nil {
+		return cfg, err
+	}
+	if v := os.Getenv("USAGE_FLUSH_INTERVAL"); v != "" {
+		if cfg.UsageFlushInterval, err = time.ParseDuration(v); err != nil || cfg.UsageFlushInterval <= 0 {
+			return cfg, fmt.Errorf("invalid USAGE_FLUSH_INTERVAL %q: must be a positive duration", v)
+		}
+	}
+	if v := os.Getenv("USAGE_MONTHLY_CALL_QUOTA"); v != "" {
+		if cfg.UsageQuotas.MonthlyCalls, err = strconv.ParseInt(v, 10, 64); err != nil || cfg.UsageQuotas.MonthlyCalls < 0 {
+			return cfg, fmt.Errorf("invalid USAGE_MONTHLY_CALL_QUOTA %q: must be a non-negative integer", v)
+		}
+	}
+	if v := os.Getenv("USAGE_MONTHLY_VOLUME_QUOTA"); v != "" {
+		if cfg.UsageQuotas.MonthlyVolume, err = strconv.ParseFloat(v, 64); err != nil || cfg.UsageQuotas.MonthlyVolume < 0 {
+			return cfg, fmt.Errorf("invalid USAGE_MONTHLY_VOLUME_QUOTA %q: must be a non-negative number", v)
+		}
+	}
+	if v := os.Getenv("ACCOUNT_LIMIT"); v != "" {
+		if cfg.AccountLimit, err = strconv.ParseInt(v, 10, 64); err != nil || cfg.AccountLimit < 0 {
+			return cfg, fmt.Errorf("invalid ACCOUNT_LIMIT %q: must be a non-negative integer", v)
+		}
+	}
+	if v := os.Getenv("SUSPENSE_ACCOUNT_ID"); v != "" {
+		if cfg.SuspenseAccountID, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return cfg, fmt.Errorf("invalid SUSPENSE_ACCOUNT_ID %q: %w", v, err)
+		}
+	}
+	if v := os.Getenv("REIMBURSEMENT_ACCOUNT_ID"); v != "" {
+		if cfg.ReimbursementAccountID, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return cfg, fmt.Errorf("invalid REIMBURSEMENT_ACCOUNT_ID %q: %w", v, err)
+		}
+	}
+	if v := os.Getenv("EXPIRY_SWEEP_INTERVAL"); v != "" {
+		if cfg.ExpirySweepInterval, err = time.ParseDuration(v); err != nil || cfg.ExpirySweepInterval <= 0 {
+			return cfg, fmt.Errorf("invalid EXPIRY_SWEEP_INTERVAL %q: must be a positive duration", v)
+		}
+	}
+	if v := os.Getenv("PENDING_ACTION_TTLS"); v != "" {
+		if cfg.PendingActionTTLs, err = parsePendingActionTTLs(v); err != nil {
+			return cfg, fmt.Errorf("invalid PENDING_ACTION_TTLS %q: %w", v, err)
+		}
+	}
+	cfg.OutboxPublisher = os.Getenv("OUTBOX_PUBLISHER")
+	if v := os.Getenv("OUTBOX_RELAY_INTERVAL"); v != "" {
+		if cfg.OutboxRelayInterval, err = time.ParseDuration(v); err != nil || cfg.OutboxRelayInterval <= 0 {
+			return cfg, fmt.Errorf("invalid OUTBOX_RELAY_INTERVAL %q: must be a positive duration", v)
+		}
+	}
+	if v := os.Getenv("OUTBOX_BATCH_SIZE"); v != "" {
+		if cfg.OutboxBatchSize, err = strconv.Atoi(v); err != nil || cfg.OutboxBatchSize <= 0 {
+			return cfg, fmt.Errorf("invalid OUTBOX_BATCH_SIZE %q: must be a positive integer", v)
+		}
+	}
+	cfg.AMQP.URL = os.Getenv("AMQP_URL")
+	if v := os.Getenv("AMQP_EXCHANGE"); v != "" {
+		cfg.AMQP.Exchange = v
+	}
+	if v := os.Getenv("AMQP_EXCHANGE_TYPE"); v != "" {
+		cfg.AMQP.ExchangeType = v
+	}
+	if v := os.Getenv("AMQP_ROUTING_KEY"); v != "" {
+		cfg.AMQP.RoutingKey = v
+	}
+	if v := os.Getenv("AMQP_CONFIRM_TIMEOUT"); v != "" {
+		if cfg.AMQP.ConfirmTimeout, err = time.ParseDuration(v); err != nil || cfg.AMQP.ConfirmTimeout <= 0 {
+			return cfg, fmt.Errorf("invalid AMQP_CONFIRM_TIMEOUT %q: must be a positive duration", v)
+		}
+	}
+	if v := os.Getenv("WEBHOOK_TIMEOUT"); v != "" {
+		if cfg.WebhookTimeout, err = time.ParseDuration(v); err != nil || cfg.WebhookTimeout <= 0 {
+			return cfg, fmt.Errorf("invalid WEBHOOK_TIMEOUT %q: must be a positive duration", v)
+		}
+	}
+	if v := os.Getenv("WEBHOOK_WORKERS"); v != "" {
+		if cfg.WebhookWorkers, err = strconv.Atoi(v); err != nil || cfg.WebhookWorkers <= 0 {
+			return cfg, fmt.Errorf("invalid WEBHOOK_WORKERS %q: must be a positive integer", v)
+		}
+	}
+	if v := os.Getenv("WEBHOOK_QUEUE_SIZE"); v != "" {
+		if cfg.WebhookQueueSize, err = strconv.Atoi(v); err != nil || cfg.WebhookQueueSize < 0 {
+			return cfg, fmt.Errorf("invalid WEBHOOK_QUEUE_SIZE %q: must be a non-negative integer", v)
+		}
+	}
+	if v := os.Getenv("SECURITY_WEBHOOK_URL"); v != "" {
+		if u, err := url.Parse(v); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return cfg, fmt.Errorf("invalid SECURITY_WEBHOOK_URL %q: must be an http or https URL", v)
+		}
+		cfg.SecurityWebhookURL = v
+	}
+	cfg.ResponseSigningKeyFile = os.Getenv("RESPONSE_SIGNING_KEY_FILE")
+	if cfg.SLO, err = sloConfigFromEnv(cfg.SLO); err != nil {
+		return cfg, err
+	}
+	if cfg.Region = os.Getenv("REGION"); cfg.Region != "" && !models.ValidRegion(cfg.Region) {
+		return cfg, fmt.Errorf("invalid REGION %q: must be lowercase letters, digits and hyphens", cfg.Region)
+	}
+	if v := os.Getenv("REGION_FENCE_INTERVAL"); v != "" {
+		if cfg.RegionFenceInterval, err = time.ParseDuration(v); err != nil || cfg.RegionFenceInterval <= 0 {
+			return cfg, fmt.Errorf("invalid REGION_FENCE_INTERVAL %q: must be a positive duration", v)
+		}
+	}
+	if err := revaluationConfigFromEnv(&cfg); err != nil {
+		return cfg, err
+	}
+	cfg.FXRateProviderURL = os.Getenv("FX_RATE_PROVIDER_URL")
+	if v := os.Getenv("FX_RATE_CACHE_TTL"); v != "" {
+		if cfg.FXRateCacheTTL, err = time.ParseDuration(v); err != nil || cfg.FXRateCacheTTL <= 0 {
+			return cfg, fmt.Errorf("invalid FX_RATE_CACHE_TTL %q: must be a positive duration", v)
+		}
+	}
+	if v := os.Getenv("FX_RATE_MAX_AGE"); v != "" {
+		if cfg.FXRateMaxAge, err = time.ParseDuration(v); err != nil || cfg.FXRateMaxAge <= 0 {
+			return cfg, fmt.Errorf("invalid FX_RATE_MAX_AGE %q: must be a positive duration", v)
+		}
+	}
+	if v := os.Getenv("TRANSFER_INTENT_RESUME_INTERVAL"); v != "" {
+		if cfg.TransferIntentResumeInterval, err = time.ParseDuration(v); err != nil || cfg.TransferIntentResumeInterval <= 0 {
+			return cfg, fmt.Errorf("invalid TRANSFER_INTENT_RESUME_INTERVAL %q: must be a positive duration", v)
+		}
+	}
+	if v := os.Getenv("NOTIFICATION_DIGEST_INTERVAL"); v != "" {
+		if cfg.NotificationDigestInterval, err = time.ParseDuration(v); err != nil || cfg.NotificationDigestInterval <= 0 {
+			return cfg, fmt.Errorf("invalid NOTIFICATION_DIGEST_INTERVAL %q: must be a positive duration", v)
+		}
+	}
+	cfg.ForwardQueueDir = os.Getenv("FORWARD_QUEUE_DIR")
+	if v := os.Getenv("FORWARD_QUEUE_MAX_ENTRIES"); v != "" {
+		if cfg.ForwardQueueMaxEntries, err = strconv.Atoi(v); err != nil || cfg.ForwardQueueMaxEntries <= 0 {
+			return cfg, fmt.Errorf("invalid FORWARD_QUEUE_MAX_ENTRIES %q: must be a positive integer", v)
+		}
+	}
+	if v := os.Getenv("FORWARD_QUEUE_MAX_AGE"); v != "" {
+		if cfg.ForwardQueueMaxAge, err = time.ParseDuration(v); err != nil || cfg.ForwardQueueMaxAge <= 0 {
+			return cfg, fmt.Errorf("invalid FORWARD_QUEUE_MAX_AGE %q: must be a positive duration", v)
+		}
+	}
+	if v := os.Getenv("FORWARD_REPLAY_INTERVAL"); v != "" {
+		if cfg.ForwardReplayInterval, err = time.ParseDuration(v); err != nil || cfg.ForwardReplayInterval <= 0 {
+			return cfg, fmt.Errorf("invalid FORWARD_REPLAY_INTERVAL %q: must be a positive duration", v)
+		}
+	}
+	if err := coalescingConfigFromEnv(&cfg); err != nil {
+		return cfg, err
+	}
+	if cfg.Chaos, err = chaos.ConfigFromEnv(); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+// Validate checks the settings that are only wrong together, or that a Config
+// built in code rather than by ConfigFromEnv may get wrong, and reports every
+// problem it finds by the environment variable that sets it.
+func (cfg Config) Validate() error {
+	var errs []error
+	if cfg.InvariantSampleRate < 0 || cfg.InvariantSampleRate > 1 {
+		errs = append(errs, fmt.Errorf("invalid INVARIANT_SAMPLE_RATE %v: must be between 0 and 1", cfg.InvariantSampleRate))
+	}
+	for name, d := range map[string]time.Duration{
+		"SHUTDOWN_TIMEOUT":                cfg.ShutdownTimeout,
+		"INVARIANT_CHECK_INTERVAL":        cfg.InvariantCheckInterval,
+		"USAGE_FLUSH_INTERVAL":            cfg.UsageFlushInterval,
+		"EXPIRY_SWEEP_INTERVAL":           cfg.ExpirySweepInterval,
+		"OUTBOX_RELAY_INTERVAL":           cfg.OutboxRelayInterval,
+		"REGION_FENCE_INTERVAL":           cfg.RegionFenceInterval,
+		"REVALUATION_INTERVAL":            cfg.RevaluationInterval,
+		"TRANSFER_INTENT_RESUME_INTERVAL": cfg.TransferIntentResumeInterval,
+		"NOTIFICATION_DIGEST_INTERVAL":    cfg.NotificationDigestInterval,
+	} {
+		if d <= 0 {
+			errs = append(errs, fmt.Errorf("invalid %s %s: must be a positive duration", name, d))
+		}
+	}
+	if cfg.OutboxBatchSize <= 0 {
+		errs = append(errs, fmt.Errorf("invalid OUTBOX_BATCH_SIZE %d: must be positive", cfg.OutboxBatchSize))
+	}
+	if cfg.WebhookWorkers <= 0 {
+		errs = append(errs, fmt.Errorf("invalid WEBHOOK_WORKERS %d: must be positive", cfg.WebhookWorkers))
+	}
+	if cfg.WebhookQueueSize < 0 {
+		errs = append(errs, fmt.Errorf("invalid WEBHOOK_QUEUE_SIZE %d: must not be negative", cfg.WebhookQueueSize))
+	}
+	// Port 0 picks a free port for each listener.
+	if _, port, _ := net.SplitHostPort(cfg.AdminAddr); port != "0" && cfg.AdminAddr != "" && cfg.AdminAddr == cfg.Addr {
+		errs = append(errs, fmt.Errorf("invalid ADMIN_ADDR %q: must differ from the API's listen address, or be empty to serve both on it", cfg.AdminAddr))
+	}
+	if cfg.SuspenseAccountID != 0 && cfg.SuspenseAccountID == cfg.ReimbursementAccountID {
+		errs = append(errs, fmt.Errorf("invalid REIMBURSEMENT_ACCOUNT_ID %d: the suspense account cannot also pay reimbursements", cfg.ReimbursementAccountID))
+	}
+	if cfg.ForwardQueueDir != "" {
+		if cfg.ForwardQueueMaxEntries <= 0 {
+			errs = append(errs, fmt.Errorf("invalid FORWARD_QUEUE_MAX_ENTRIES %d: must be positive", cfg.ForwardQueueMaxEntries))
+		}
+		if cfg.ForwardQueueMaxAge <= 0 {
+			errs = append(errs, fmt.Errorf("invalid FORWARD_QUEUE_MAX_AGE %s: must be a positive duration", cfg.ForwardQueueMaxAge))
+		}
+		if cfg.ForwardReplayInterval <= 0 {
+			errs = append(errs, fmt.Errorf("invalid FORWARD_REPLAY_INTERVAL %s: must be a positive duration", cfg.ForwardReplayInterval))
+		}
+	}
+	if cfg.FXRateProviderURL != "" && cfg.FXRateCacheTTL > cfg.FXRateMaxAge {
+		errs = append(errs, fmt.Errorf("invalid FX_RATE_CACHE_TTL %s: longer than FX_RATE_MAX_AGE %s, so cached rates turn stale before they are fetched again", cfg.FXRateCacheTTL, cfg.FXRateMaxAge))
+	}
+	if len(cfg.Coalescing.Accounts) > 0 {
+		if cfg.Coalescing.Window <= 0 {
+			errs = append(errs, fmt.Errorf("invalid COALESCE_WINDOW %s: must be a positive duration", cfg.Coalescing.Window))
+		}
+		if cfg.Coalescing.MaxBatch < 2 {
+			errs = append(errs, fmt.Errorf("invalid COALESCE_MAX_BATCH %d: must be at least 2", cfg.Coalescing.MaxBatch))
+		}
+	}
+	// Sort for a stable message: the intervals come from a map.
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+	return errors.Join(errs...)
+}
+
+// revaluationConfigFromEnv reads BASE_CURRENCY, FX_GAIN_ACCOUNT_ID,
+// FX_LOSS_ACCOUNT_ID, REVALUATION_TIMEZONE and REVALUATION_INTERVAL into cfg.
+// The account IDs are required with a base currency other than CURRENCY.
+func revaluationConfigFromEnv(cfg *Config) error {
+	v := os.Getenv("BASE_CURRENCY")
+	if v == "" {
+		return nil
+	}
+	base, err := models.LookupCurrency(v)
+	if err != nil {
+		return fmt.Errorf("invalid BASE_CURRENCY: %w", err)
+	}
+	currency := models.CurrentCurrency()
+	if cfg.Currency != "" {
+		if currency, err = models.LookupCurrency(cfg.Currency); err != nil {
+			return fmt.Errorf("invalid CURRENCY: %w", err)
+		}
+	}
+	if base.Code == currency.Code {
+		return fmt.Errorf("invalid BASE_CURRENCY %q: accounts are held in it already", v)
+	}
+	cfg.Revaluation.BaseCurrency = base.Code
+	for name, id := range map[string]*int64{"FX_GAIN_ACCOUNT_ID": &cfg.Revaluation.GainAccountID, "FX_LOSS_ACCOUNT_ID": &cfg.Revaluation.LossAccountID} {
+		if *id, err = strconv.ParseInt(os.Getenv(name), 10, 64); err != nil || *id <= 0 {
+			return fmt.Errorf("invalid %s %q: an account ID is required with BASE_CURRENCY", name, os.Getenv(name))
+		}
+	}
+	if v := os.Getenv("REVALUATION_TIMEZONE"); v != "" {
+		if cfg.Revaluation.Location, err = time.LoadLocation(v); err != nil {
+			return fmt.Errorf("invalid REVALUATION_TIMEZONE %q: %w", v, err)
+		}
+	}
+	if v := os.Getenv("REVALUATION_INTERVAL"); v != "" {
+		if cfg.RevaluationInterval, err = time.ParseDuration(v); err != nil || cfg.RevaluationInterval <= 0 {
+			return fmt.Errorf("invalid REVALUATION_INTERVAL %q: must be a positive duration", v)
+		}
+	}
+	return nil
+}
+
+// coalescingConfigFromEnv reads COALESCE_ACCOUNTS (comma-separated account
+// IDs), COALESCE_WINDOW, COALESCE_MAX_BATCH and COALESCE_MAX_AMOUNT into cfg.
+func coalescingConfigFromEnv(cfg *Config) error {
+	v := os.Getenv("COALESCE_ACCOUNTS")
+	if v == "" {
+		return nil
+	}
+	for _, s := range strings.Split(v, ",") {
+		id, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+		if err != nil || id <= 0 {
+			return fmt.Errorf("invalid COALESCE_ACCOUNTS %q: must be comma-separated account IDs", v)
+		}
+		cfg.Coalescing.Accounts = append(cfg.Coalescing.Accounts, id)
+	}
+	var err error
+	if v := os.Getenv("COALESCE_WINDOW"); v != "" {
+		if cfg.Coalescing.Window, err = time.ParseDuration(v); err != nil || cfg.Coalescing.Window <= 0 {
+			return fmt.Errorf("invalid COALESCE_WINDOW %q: must be a positive duration", v)
+		}
+	}
+	if v := os.Getenv("COALESCE_MAX_BATCH"); v != "" {
+		if cfg.Coalescing.MaxBatch, err = strconv.Atoi(v); err != nil || cfg.Coalescing.MaxBatch < 2 {
+			return fmt.Errorf("invalid COALESCE_MAX_BATCH %q: must be at least 2", v)
+		}
+	}
+	if v := os.Getenv("COALESCE_MAX_AMOUNT"); v != "" {
+		amount, err := models.ParseAmount(v)
+		if err != nil || amount < 0 {
+			return fmt.Errorf("invalid COALESCE_MAX_AMOUNT %q: must be a non-negative amount", v)
+		}
+		cfg.Coalescing.MaxAmount = float64(amount)
+	}
+	return nil
+}
+
+// sloConfigFromEnv reads SLO_SUCCESS_TARGET, SLO_LATENCY_TARGET,
+// SLO_LATENCY_THRESHOLD, SLO_PERIOD and SLO_WINDOWS (comma-separated durations)
+// on top of cfg.
+func sloConfigFromEnv(cfg slo.Config) (slo.Config, error) {
+	var err error
+	if v := os.Getenv("SLO_SUCCESS_TARGET"); v != "" {
+		if cfg.SuccessTarget, err = strconv.ParseFloat(v, 64); err != nil {
+			return cfg, fmt.Errorf("invalid SLO_SUCCESS_TARGET %q: %w", v, err)
+		}
+	}
+	if v := os.Getenv("SLO_LATENCY_TARGET"); v != "" {
+		if cfg.LatencyTarget, err = strconv.ParseFloat(v, 64); err != nil {
+			return cfg, fmt.Errorf("invalid SLO_LATENCY_TARGET %q: %w", v, err)
+		}
+	}
+	if v := os.Getenv("SLO_LATENCY_THRESHOLD"); v != "" {
+		if cfg.LatencyThreshold, err = time.ParseDuration(v); err != nil {
+			return cfg, fmt.Errorf("invalid SLO_LATENCY_THRESHOLD %q: %w", v, err)
+		}
+	}
+	if v := os.Getenv("SLO_PERIOD"); v != "" {
+		if cfg.Period, err = time.ParseDuration(v); err != nil {
+			return cfg, fmt.Errorf("invalid SLO_PERIOD %q: %w", v, err)
+		}
+	}
+	if v := os.Getenv("SLO_WINDOWS"); v != "" {
+		cfg.Windows = nil
+		for _, w := range strings.Split(v, ",") {
+			d, err := time.ParseDuration(strings.TrimSpace(w))
+			if err != nil {
+				return cfg, fmt.Errorf("invalid SLO_WINDOWS %q: %w", v, err)
+			}
+			cfg.Windows = append(cfg.Windows, d)
+		}
+	}
+	return cfg, cfg.Validate()
+}
+
+// parseRoundingPolicies parses a comma-separated list of
+// code=minor_units:mode entries, e.g. "JPY=0:truncate,EUR=2:half_even".
+func parseRoundingPolicies(v string) (map[string]money.Policy, error) {
+	policies := make(map[string]money.Policy)
+	for _, entry := range strings.Split(v, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		code, policy, ok := strings.Cut(entry, "=")
+		units, mode, ok2 := strings.Cut(policy, ":")
+		if !ok || !ok2 {
+			return nil, fmt.Errorf("%q: expected <currency>=<minor units>:<rounding mode>", entry)
+		}
+		currency, err := models.LookupCurrency(code)
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", entry, err)
+		}
+		p := money.Policy{}
+		if p.MinorUnits, err = strconv.Atoi(strings.TrimSpace(units)); err != nil || p.MinorUnits < 0 || p.MinorUnits > money.MaxMinorUnits {
+			return nil, fmt.Errorf("%q: minor units must be between 0 and %d", entry, money.MaxMinorUnits)
+		}
+		if p.Mode, err = money.ParseMode(mode); err != nil {
+			return nil, fmt.Errorf("%q: %w", entry, err)
+		}
+		policies[currency.Code] = p
+	}
+	return policies, nil
+}
+
+// parsePendingActionTTLs parses a comma-separated list of kind=duration pairs,
+// e.g. "transfer_approval=72h,sar_review=720h".
+func parsePendingActionTTLs(v string) (map[models.PendingActionKind]time.Duration, error) {
+	ttls := make(map[models.PendingActionKind]time.Duration)
+	for _, pair := range strings.Split(v, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(pair, "=")
+		kind := models.PendingActionKind(strings.TrimSpace(name))
+		if !ok || !kind.Valid() {
+			return nil, fmt.Errorf("%q: expected <kind>=<duration> with a pending action kind", pair)
+		}
+		// A suspense item holds funds until it is reposted; expiring its action
+		// would hide them without moving them. A reimbursement would likewise
+		// stay pending with nobody asked to decide it.
+		if kind == models.PendingActionSuspense || kind == models.PendingActionReimbursement {
+			return nil, fmt.Errorf("%q: %s actions cannot expire", pair, kind)
+		}
+		ttl, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || ttl <= 0 {
+			return nil, fmt.Errorf("%q: TTL must be a positive duration", pair)
+		}
+		ttls[kind] = ttl
+	}
+	return ttls, nil
+}
@@ -0,0 +1,45 @@
+package app
+
+import (
+	"database/sql"
+	"log"
+
+	"github.com/gorilla/mux"
+	"github.com/nehciyy/intrapay/internal/clock"
+	"github.com/nehciyy/intrapay/internal/repository"
+)
+
+// Option customises an App.
+type Option func(*App)
+
+// WithLogger sets the logger for server lifecycle and query logging.
+func WithLogger(l *log.Logger) Option {
+	return func(a *App) { a.logger = l }
+}
+
+// WithClock replaces the system clock used by the service, e.g. for retry backoff.
+// Any value with Now() time.Time and Sleep(time.Duration) methods will do.
+func WithClock(c clock.Clock) Option {
+	return func(a *App) { a.clock = c }
+}
+
+// WithDB uses an existing database handle instead of connecting with db.InitDB.
+// The caller owns db and its pool settings.
+func WithDB(db *sql.DB) Option {
+	return func(a *App) { a.db = db }
+}
+
+// WithRepositories replaces the Postgres repositories; a nil repository keeps the
+// default. Shadow mode and chaos decorators are not applied to injected repositories.
+func WithRepositories(accounts repository.AccountRepository, transactions repository.TransactionRepository) Option {
+	return func(a *App) {
+		a.accountRepo = accounts
+		a.transactionRepo = transactions
+	}
+}
+
+// WithRouter registers the intrapay routes on router, so an embedding binary can
+// mount its own routes next to them.
+func WithRouter(router *mux.Router) Option {
+	return func(a *App) { a.router = router }
+}
@@ -0,0 +1,138 @@
+// Command backfill runs the backfills of expand/contract schema changes and
+// reports their progress:
+//
+//	backfill -list
+//	backfill -job transactions.amount_minor -batch 1000 -pause 50ms
+//
+// A run processes the job from where the last one left off until it catches up
+// with the table, and can be stopped with Ctrl-C at any point. Before the
+// contract migration of a change, once no server runs the previous release,
+// run the job again with -restart so it also covers rows that release wrote
+// behind an earlier run, and check that -list shows nothing pending.
+//
+// The settings are read from the environment (and .env) like the server's;
+// CURRENCY must match the server's for amounts in minor units.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"log"
+	"maps"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/joho/godotenv"
+
+	"github.com/nehciyy/intrapay/app"
+	"github.com/nehciyy/intrapay/internal/backfill"
+	"github.com/nehciyy/intrapay/internal/db"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository"
+)
+
+func main() {
+	list := flag.Bool("list", false, "print the progress of every backfill")
+	name := flag.String("job", "", "the backfill to run")
+	batch := flag.Int("batch", 1000, "rows per batch")
+	pause := flag.Duration("pause", 50*time.Millisecond, "wait between batches")
+	restart := flag.Bool("restart", false, "start the backfill over from the first row")
+	flag.Parse()
+
+	if _, exists := os.LookupEnv("DATABASE_URL"); !exists {
+		if err := godotenv.Load(); err != nil {
+			log.Println("Warning: no .env file found, proceeding without it")
+		}
+	}
+	cfg, err := app.ConfigFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if cfg.Currency != "" {
+		if err := models.SetCurrency(cfg.Currency); err != nil {
+			log.Fatal(err)
+		}
+	}
+	database, err := db.InitDB()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer database.Close()
+	repo := repository.NewPostgresBackfillRepository(database)
+	available := jobs(repo)
+
+	if *list {
+		printJSON(progress(repo, available))
+		return
+	}
+	job, ok := available[*name]
+	if !ok {
+		log.Fatalf("name the backfill to run with -job: one of %s", strings.Join(slices.Sorted(maps.Keys(available)), ", "))
+	}
+	if *restart {
+		if err := repo.ResetBackfill(job.Name); err != nil && !errors.Is(err, repository.ErrNotFound) {
+			log.Fatal(err)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	runner := backfill.NewRunner(database, repo, *batch, log.Default(), backfill.WithPause(*pause))
+	result, err := runner.Run(ctx, job)
+	if result != nil {
+		printJSON(result)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// jobs returns the backfills the migrations call for, by name.
+func jobs(repo *repository.PostgresBackfillRepository) map[string]backfill.Job {
+	scale := models.CurrentCurrency().Scale()
+	return map[string]backfill.Job{
+		"transactions.amount_minor": {
+			Name: "transactions.amount_minor",
+			Step: func(tx *sql.Tx, after int64, limit int) (int64, int64, error) {
+				return repo.BackfillTransactionAmountMinorTx(tx, after, limit, scale)
+			},
+			Pending: repo.CountTransactionsMissingAmountMinor,
+		},
+	}
+}
+
+// progress returns the progress of every backfill that has run, with the rows
+// still pending for those that are known.
+func progress(repo *repository.PostgresBackfillRepository, available map[string]backfill.Job) []*models.Backfill {
+	backfills, err := repo.ListBackfills()
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, b := range backfills {
+		job, ok := available[b.Name]
+		if !ok || job.Pending == nil {
+			continue
+		}
+		n, err := job.Pending()
+		if err != nil {
+			log.Fatal(err)
+		}
+		b.Pending = &n
+	}
+	return backfills
+}
+
+func printJSON(v any) {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,82 @@
+// Command backup writes a logical backup of the ledger, consistent as of a
+// single snapshot, for restoring with cmd/restore:
+//
+//	backup -o intrapay-2024-05-01.jsonl.gz
+//
+// The backup is gzipped when the file name ends in .gz. The manifest, with the row count and checksum of each
+// table, is printed to standard error. DATABASE_URL is read from the
+// environment (and .env) like the server's.
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"flag"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/joho/godotenv"
+
+	"github.com/nehciyy/intrapay/internal/backup"
+	"github.com/nehciyy/intrapay/internal/db"
+)
+
+func main() {
+	output := flag.String("o", "", "file to write the backup to")
+	flag.Parse()
+	if *output == "" {
+		log.Fatal("name the file to write the backup to with -o")
+	}
+
+	if _, exists := os.LookupEnv("DATABASE_URL"); !exists {
+		if err := godotenv.Load(); err != nil {
+			log.Println("Warning: no .env file found, proceeding without it")
+		}
+	}
+	database, err := db.InitDB()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer database.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Written under a temporary name so an interrupted run leaves no file that
+	// looks like a complete backup.
+	out, err := os.Create(*output + ".partial")
+	if err != nil {
+		log.Fatal(err)
+	}
+	var w io.WriteCloser = out
+	if strings.HasSuffix(*output, ".gz") {
+		w = gzip.NewWriter(out)
+	}
+
+	manifest, err := backup.Dump(ctx, database, w)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if w != out {
+		if err := w.Close(); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if err := out.Close(); err != nil {
+		log.Fatal(err)
+	}
+	if err := os.Rename(*output+".partial", *output); err != nil {
+		log.Fatal(err)
+	}
+
+	enc := json.NewEncoder(os.Stderr)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(manifest); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,292 @@
+// Command bench runs the benchmark suites and prints a JSON report of them
+// that later runs can be compared with, to catch performance regressions in
+// the transfer path before a release:
+//
+//	bench -out base.json                      # on the last release
+//	bench -baseline base.json -out head.json  # on the release candidate
+//
+// It runs go test -bench on the packages given, by default those with
+// benchmarks, -count times and reports the median time, bytes and allocations
+// per operation of every benchmark. Medians of a few runs are steadier than a
+// single run, but compare reports only from the same machine.
+//
+// With -baseline the report also compares every benchmark with the baseline's.
+// A benchmark matching -gate that is more than -threshold slower, or allocates
+// at least once more per operation, is a regression, and the command exits
+// with status 1 after printing the report. The transfer benchmarks run against PostgreSQL
+// too when BENCH_DATABASE_URL is set, e.g. to the database of compose.yaml;
+// they open accounts in it, so never point it at a database that matters.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// defaultPackages are the packages with benchmarks.
+var defaultPackages = []string{"./internal/money", "./internal/service", "./internal/api"}
+
+// report is what the command prints.
+type report struct {
+	GoVersion   string       `json:"go_version"`
+	OS          string       `json:"os"`
+	Arch        string       `json:"arch"`
+	CPU         string       `json:"cpu,omitempty"`
+	Count       int          `json:"count"`
+	Benchmarks  []result     `json:"benchmarks"`
+	Comparisons []comparison `json:"comparisons,omitempty"`
+	Regressions int          `json:"regressions,omitempty"`
+}
+
+// result is the median of the runs of a benchmark.
+type result struct {
+	Package     string  `json:"package"`
+	Name        string  `json:"name"`
+	Runs        int     `json:"runs"`
+	NsPerOp     float64 `json:"ns_per_op"`
+	BytesPerOp  float64 `json:"bytes_per_op"`
+	AllocsPerOp float64 `json:"allocs_per_op"`
+}
+
+func (r result) key() string { return r.Package + "." + r.Name }
+
+// comparison is a benchmark of the report against the baseline's. Delta is the
+// change in time per operation, e.g. 0.05 for 5% slower.
+type comparison struct {
+	Package        string  `json:"package"`
+	Name           string  `json:"name"`
+	BaselineNsOp   float64 `json:"baseline_ns_per_op"`
+	NsPerOp        float64 `json:"ns_per_op"`
+	Delta          float64 `json:"delta"`
+	BaselineAllocs float64 `json:"baseline_allocs_per_op"`
+	AllocsPerOp    float64 `json:"allocs_per_op"`
+	Gated          bool    `json:"gated"`
+	Regressed      bool    `json:"regressed"`
+}
+
+func main() {
+	bench := flag.String("bench", ".", "run only the benchmarks matching this regular expression")
+	count := flag.Int("count", 5, "how many times to run each benchmark")
+	benchtime := flag.String("benchtime", "", "go test -benchtime of each run; empty keeps its default")
+	baseline := flag.String("baseline", "", "report of an earlier run to compare with")
+	gate := flag.String("gate", "CreateTransaction", "regressions of the benchmarks matching this regular expression fail the command")
+	threshold := flag.Float64("threshold", 0.10, "how much slower a gated benchmark may get, as a fraction of the baseline")
+	out := flag.String("out", "-", "file to write the report to; - writes standard output")
+	flag.Parse()
+
+	if *count <= 0 {
+		log.Fatal("-count must be positive")
+	}
+	gated, err := regexp.Compile(*gate)
+	if err != nil {
+		log.Fatalf("invalid -gate: %v", err)
+	}
+	var base *report
+	if *baseline != "" {
+		if base, err = readReport(*baseline); err != nil {
+			log.Fatal(err)
+		}
+	}
+	packages := flag.Args()
+	if len(packages) == 0 {
+		packages = defaultPackages
+	}
+
+	args := []string{"test", "-run", "^$", "-bench", *bench, "-benchmem", "-count", strconv.Itoa(*count)}
+	if *benchtime != "" {
+		args = append(args, "-benchtime", *benchtime)
+	}
+	cmd := exec.Command("go", append(args, packages...)...)
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = os.Stderr
+	log.Printf("running go %s", strings.Join(cmd.Args[1:], " "))
+	if err := cmd.Run(); err != nil {
+		os.Stderr.Write(output.Bytes())
+		log.Fatalf("benchmarks failed: %v", err)
+	}
+
+	r, err := parse(&output)
+	if err != nil {
+		log.Fatal(err)
+	}
+	r.Count = *count
+	if base != nil {
+		r.compare(base, gated, *threshold)
+	}
+	if err := writeReport(*out, r); err != nil {
+		log.Fatal(err)
+	}
+	if r.Regressions > 0 {
+		log.Printf("%d benchmarks regressed against %s", r.Regressions, *baseline)
+		os.Exit(1)
+	}
+}
+
+// parse reads the output of go test -bench and returns the median of the runs
+// of every benchmark, in the order they first ran.
+func parse(in io.Reader) (*report, error) {
+	r := &report{GoVersion: runtime.Version(), OS: runtime.GOOS, Arch: runtime.GOARCH}
+	var pkg string
+	var order []string
+	samples := make(map[string][]result)
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "pkg: "):
+			pkg = strings.TrimPrefix(line, "pkg: ")
+		case strings.HasPrefix(line, "cpu: "):
+			r.CPU = strings.TrimPrefix(line, "cpu: ")
+		case strings.HasPrefix(line, "Benchmark"):
+			res, ok := parseResult(line)
+			if !ok {
+				continue
+			}
+			res.Package = pkg
+			if _, seen := samples[res.key()]; !seen {
+				order = append(order, res.key())
+			}
+			samples[res.key()] = append(samples[res.key()], res)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(order) == 0 {
+		return nil, fmt.Errorf("no benchmarks ran")
+	}
+	for _, key := range order {
+		runs := samples[key]
+		r.Benchmarks = append(r.Benchmarks, result{
+			Package:     runs[0].Package,
+			Name:        runs[0].Name,
+			Runs:        len(runs),
+			NsPerOp:     median(runs, func(r result) float64 { return r.NsPerOp }),
+			BytesPerOp:  median(runs, func(r result) float64 { return r.BytesPerOp }),
+			AllocsPerOp: median(runs, func(r result) float64 { return r.AllocsPerOp }),
+		})
+	}
+	return r, nil
+}
+
+// parseResult parses a result line such as
+//
+//	BenchmarkPolicyOf-8   9945651   126.2 ns/op   8 B/op   1 allocs/op
+//
+// ok is false for other lines starting with a benchmark's name, such as its
+// log output.
+func parseResult(line string) (res result, ok bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 4 {
+		return res, false
+	}
+	if _, err := strconv.Atoi(fields[1]); err != nil {
+		return res, false
+	}
+	res.Name = fields[0]
+	// Drop the -GOMAXPROCS suffix, so that runs with other settings compare.
+	if i := strings.LastIndex(res.Name, "-"); i > 0 {
+		if _, err := strconv.Atoi(res.Name[i+1:]); err == nil {
+			res.Name = res.Name[:i]
+		}
+	}
+	for i := 2; i+1 < len(fields); i += 2 {
+		v, err := strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return res, false
+		}
+		switch fields[i+1] {
+		case "ns/op":
+			res.NsPerOp, ok = v, true
+		case "B/op":
+			res.BytesPerOp = v
+		case "allocs/op":
+			res.AllocsPerOp = v
+		}
+	}
+	return res, ok
+}
+
+func median(runs []result, value func(result) float64) float64 {
+	values := make([]float64, len(runs))
+	for i, r := range runs {
+		values[i] = value(r)
+	}
+	slices.Sort(values)
+	if n := len(values); n%2 == 0 {
+		return (values[n/2-1] + values[n/2]) / 2
+	}
+	return values[len(values)/2]
+}
+
+// compare compares the benchmarks of r with those of base. Benchmarks new
+// since base are not compared.
+func (r *report) compare(base *report, gated *regexp.Regexp, threshold float64) {
+	baseline := make(map[string]result, len(base.Benchmarks))
+	for _, b := range base.Benchmarks {
+		baseline[b.key()] = b
+	}
+	for _, b := range r.Benchmarks {
+		prev, ok := baseline[b.key()]
+		if !ok || prev.NsPerOp == 0 {
+			continue
+		}
+		c := comparison{
+			Package:        b.Package,
+			Name:           b.Name,
+			BaselineNsOp:   prev.NsPerOp,
+			NsPerOp:        b.NsPerOp,
+			Delta:          b.NsPerOp/prev.NsPerOp - 1,
+			BaselineAllocs: prev.AllocsPerOp,
+			AllocsPerOp:    b.AllocsPerOp,
+			Gated:          gated.MatchString(b.Name),
+		}
+		c.Regressed = c.Gated && (c.Delta > threshold || c.AllocsPerOp >= c.BaselineAllocs+1)
+		if c.Regressed {
+			r.Regressions++
+			log.Printf("regression: %s is %+.1f%% at %.0f ns/op and %.0f allocs/op, was %.0f ns/op and %.0f allocs/op",
+				b.Name, c.Delta*100, c.NsPerOp, c.AllocsPerOp, c.BaselineNsOp, c.BaselineAllocs)
+		}
+		r.Comparisons = append(r.Comparisons, c)
+	}
+}
+
+func readReport(name string) (*report, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	var r report
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("reading baseline %s: %w", name, err)
+	}
+	return &r, nil
+}
+
+func writeReport(name string, r *report) error {
+	w := os.Stdout
+	if name != "-" {
+		f, err := os.Create(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
@@ -0,0 +1,201 @@
+// Command import posts the transfers of a CSV file in bulk, e.g. a migration
+// from another ledger or a day of inbound payments settled outside the API,
+// and prints a JSON report:
+//
+//	import -file transfers.csv
+//	import -batch 5000 < transfers.csv
+//
+// The file starts with a header naming its columns: source_account_id,
+// destination_account_id and amount, and optionally reference. A reference
+// becomes the transaction ID of its transfer, and transfers whose reference
+// was imported before are skipped, so with references an import that failed
+// part-way can simply be run again.
+//
+// Each batch of -batch transfers is posted in one database transaction,
+// staged with COPY, and posted whole or not at all: a batch with a transfer
+// that cannot be posted, e.g. for insufficient funds, stops the import, and
+// the report lists why by line of the file. The settings are read from the
+// environment (and .env) like the server's. The command exits with status 1
+// if a batch was not posted.
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/joho/godotenv"
+
+	"github.com/nehciyy/intrapay/app"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/service"
+)
+
+// report is what the command prints. The lines of Rejected are those of the
+// file.
+type report struct {
+	Batches  int                      `json:"batches"`
+	Ingested int                      `json:"ingested"`
+	Skipped  int                      `json:"skipped"`
+	Rejected []models.IngestLineError `json:"rejected,omitempty"`
+	Error    string                   `json:"error,omitempty"`
+}
+
+// row is a transfer read from the file, with the line it is on.
+type row struct {
+	line     int
+	transfer models.IngestTransfer
+}
+
+func main() {
+	file := flag.String("file", "-", "CSV file of the transfers; - reads standard input")
+	batch := flag.Int("batch", 10000, "how many transfers to post per database transaction")
+	flag.Parse()
+
+	if *batch <= 0 || *batch > service.MaxIngestTransfers {
+		log.Fatalf("-batch must be between 1 and %d", service.MaxIngestTransfers)
+	}
+	in := os.Stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		in = f
+	}
+	reader, err := newReader(in)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if _, exists := os.LookupEnv("DATABASE_URL"); !exists {
+		if err := godotenv.Load(); err != nil {
+			log.Println("Warning: no .env file found, proceeding without it")
+		}
+	}
+	cfg, err := app.ConfigFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	a, err := app.New(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	r := ingest(a.Service(), reader, *batch)
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(r); err != nil {
+		log.Fatal(err)
+	}
+	if r.Error != "" {
+		os.Exit(1)
+	}
+}
+
+// ingest posts the transfers of reader in batches of size until the file ends
+// or a batch is not posted.
+func ingest(svc service.Service, reader *reader, size int) report {
+	var r report
+	for {
+		rows, err := reader.next(size)
+		if err != nil {
+			r.Error = err.Error()
+			return r
+		}
+		if len(rows) == 0 {
+			log.Printf("imported %d transfers in %d batches, %d imported before", r.Ingested, r.Batches, r.Skipped)
+			return r
+		}
+		transfers := make([]models.IngestTransfer, len(rows))
+		for i, row := range rows {
+			transfers[i] = row.transfer
+		}
+		result, err := svc.IngestTransfers(transfers)
+		var rejected *service.IngestRejectedError
+		if errors.As(err, &rejected) {
+			for _, e := range rejected.Errors {
+				e.Line = rows[e.Line].line
+				r.Rejected = append(r.Rejected, e)
+			}
+		}
+		if err != nil {
+			r.Error = fmt.Sprintf("batch of lines %d to %d: %v", rows[0].line, rows[len(rows)-1].line, err)
+			return r
+		}
+		r.Batches++
+		r.Ingested += result.Ingested
+		r.Skipped += result.Skipped
+		log.Printf("lines %d to %d: %d imported, %d skipped", rows[0].line, rows[len(rows)-1].line, result.Ingested, result.Skipped)
+	}
+}
+
+// reader reads the transfers of a CSV file by the columns its header names.
+type reader struct {
+	csv     *csv.Reader
+	columns map[string]int
+}
+
+func newReader(in io.Reader) (*reader, error) {
+	r := &reader{csv: csv.NewReader(in), columns: make(map[string]int)}
+	r.csv.ReuseRecord = true
+	header, err := r.csv.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading the header: %w", err)
+	}
+	for i, name := range header {
+		r.columns[name] = i
+	}
+	for _, name := range []string{"source_account_id", "destination_account_id", "amount"} {
+		if _, ok := r.columns[name]; !ok {
+			return nil, fmt.Errorf("the header has no %s column", name)
+		}
+	}
+	return r, nil
+}
+
+// next reads up to n transfers, or none at the end of the file.
+func (r *reader) next(n int) ([]row, error) {
+	var rows []row
+	for len(rows) < n {
+		record, err := r.csv.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := r.csv.FieldPos(0)
+		t, err := r.transfer(record)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		rows = append(rows, row{line: line, transfer: t})
+	}
+	return rows, nil
+}
+
+func (r *reader) transfer(record []string) (models.IngestTransfer, error) {
+	var t models.IngestTransfer
+	var err error
+	if t.SourceAccountID, err = strconv.ParseInt(record[r.columns["source_account_id"]], 10, 64); err != nil {
+		return t, fmt.Errorf("invalid source_account_id: %w", err)
+	}
+	if t.DestinationAccountID, err = strconv.ParseInt(record[r.columns["destination_account_id"]], 10, 64); err != nil {
+		return t, fmt.Errorf("invalid destination_account_id: %w", err)
+	}
+	if t.Amount, err = models.ParseAmount(record[r.columns["amount"]]); err != nil {
+		return t, err
+	}
+	if i, ok := r.columns["reference"]; ok {
+		t.Reference = record[i]
+	}
+	return t, nil
+}
@@ -0,0 +1,102 @@
+// Command migrate applies the migrations the database has not had yet:
+//
+//	migrate
+//	migrate -plan
+//	migrate -baseline 40
+//
+// With -plan it applies nothing and prints the SQL it would run, followed by
+// how the live schema differs from the one the migrations leave behind:
+// "-" for tables and columns missing, "~" for columns of another type and "+"
+// for those no migration knows of. The server refuses to start on a schema
+// with anything missing or of another type, unless run with -allow-skew.
+//
+// A database set up before migrations were recorded has tables but no
+// schema_migrations table; -baseline N records migrations 1 to N as applied
+// without running them, once the schema is known to have them.
+//
+// The database is read from DATABASE_URL (and .env) like the server's.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/joho/godotenv"
+
+	"github.com/nehciyy/intrapay/internal/db"
+	"github.com/nehciyy/intrapay/internal/migrate"
+	"github.com/nehciyy/intrapay/migrations"
+)
+
+func main() {
+	plan := flag.Bool("plan", false, "print the pending SQL and the schema diff without applying anything")
+	baseline := flag.Int("baseline", 0, "record the migrations up to this version as applied without running them")
+	flag.Parse()
+
+	if _, exists := os.LookupEnv("DATABASE_URL"); !exists {
+		if err := godotenv.Load(); err != nil {
+			log.Println("Warning: no .env file found, proceeding without it")
+		}
+	}
+	all, err := migrate.Load(migrations.FS)
+	if err != nil {
+		log.Fatal(err)
+	}
+	database, err := db.InitDB()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer database.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if *baseline > 0 {
+		if err := migrate.Baseline(ctx, database, all, *baseline); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("recorded migrations up to %d as applied", *baseline)
+		return
+	}
+	pending, err := migrate.Pending(ctx, database, all)
+	untracked := errors.Is(err, migrate.ErrUntracked)
+	if untracked && !*plan {
+		log.Fatalf("%v; check the schema with -plan and record what it has with -baseline N", err)
+	}
+	if err != nil && !untracked {
+		log.Fatal(err)
+	}
+
+	if *plan {
+		if untracked {
+			fmt.Println("-- no record of the migrations applied: compare the schema below and record it with -baseline N")
+		}
+		for _, m := range pending {
+			fmt.Printf("-- %s\n%s\n", m.Name, m.SQL)
+		}
+		if len(pending) == 0 && !untracked {
+			fmt.Println("-- no pending migrations")
+		}
+		diff, err := migrate.Check(ctx, database, all)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Print("\n-- schema diff\n", diff)
+		return
+	}
+	if err := migrate.Apply(ctx, database, pending); err != nil {
+		log.Fatal(err)
+	}
+	for _, m := range pending {
+		log.Printf("applied %s", m.Name)
+	}
+	if len(pending) == 0 {
+		log.Println("no pending migrations")
+	}
+}
@@ -0,0 +1,181 @@
+// Command reprocess replays transfers that were rejected, e.g. while an incident
+// made the database refuse them, and prints a JSON report of what each replay
+// did. Attempts are selected by ID or by the filters of
+// GET /admin/transaction-attempts:
+//
+//	reprocess -ids 12,13,14
+//	reprocess -code concurrency_conflict -since 2024-05-01T10:00:00Z -until 2024-05-01T11:00:00Z -apply
+//
+// Without -apply nothing is transferred and the report lists the attempts that
+// would be replayed. Each attempt is replayed at most once however often the
+// command runs, so a run that was interrupted or failed part-way can simply be
+// repeated. The client may have retried a rejected transfer itself, though:
+// review the dry run before applying it.
+//
+// The settings are read from the environment (and .env) like the server's. The
+// command exits with status 1 if any replay failed for a reason other than a
+// rejection.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+
+	"github.com/nehciyy/intrapay/app"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/service"
+)
+
+// pageSize is how many attempts are read per page when selecting by filter.
+const pageSize = 100
+
+// report is what the command prints.
+type report struct {
+	Apply   bool                              `json:"apply"`
+	Counts  map[models.ReplayOutcome]int      `json:"counts"`
+	Replays []models.TransactionAttemptReplay `json:"replays"`
+}
+
+func main() {
+	ids := flag.String("ids", "", "comma-separated IDs of the transaction attempts to replay")
+	code := flag.String("code", "", "replay attempts rejected with this reason code")
+	apiKey := flag.String("api-key", "", "replay attempts made with this API key")
+	tenant := flag.String("tenant", "", "replay attempts made by this tenant")
+	account := flag.Int64("account", 0, "replay attempts from or to this account")
+	since := flag.String("since", "", "replay attempts made at or after this RFC 3339 time")
+	until := flag.String("until", "", "replay attempts made before this RFC 3339 time")
+	limit := flag.Int("limit", 1000, "the most attempts a filter selects")
+	apply := flag.Bool("apply", false, "transfer the funds; without it the run is a dry run")
+	flag.Parse()
+
+	filter := models.TransactionAttemptFilter{
+		Code:      models.ReasonCode(*code),
+		APIKey:    *apiKey,
+		Tenant:    *tenant,
+		AccountID: *account,
+	}
+	var err error
+	if filter.Since, err = parseTime("since", *since); err != nil {
+		log.Fatal(err)
+	}
+	if filter.Until, err = parseTime("until", *until); err != nil {
+		log.Fatal(err)
+	}
+	attemptIDs, err := parseIDs(*ids)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(attemptIDs) == 0 && filter == (models.TransactionAttemptFilter{}) {
+		log.Fatal("select the attempts to replay with -ids or at least one filter")
+	}
+
+	if _, exists := os.LookupEnv("DATABASE_URL"); !exists {
+		if err := godotenv.Load(); err != nil {
+			log.Println("Warning: no .env file found, proceeding without it")
+		}
+	}
+	cfg, err := app.ConfigFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	a, err := app.New(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	svc := a.Service()
+
+	if len(attemptIDs) == 0 {
+		if attemptIDs, err = selectAttempts(svc, filter, *limit); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	r := replay(svc, attemptIDs, *apply)
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(r); err != nil {
+		log.Fatal(err)
+	}
+	if r.Counts[models.ReplayFailed] > 0 {
+		os.Exit(1)
+	}
+}
+
+// selectAttempts returns the IDs of up to limit attempts matching filter, oldest
+// first.
+func selectAttempts(svc service.Service, filter models.TransactionAttemptFilter, limit int) ([]int64, error) {
+	var ids []int64
+	cursor := ""
+	for len(ids) < limit {
+		page, err := svc.ListTransactionAttempts(filter, cursor, min(pageSize, limit-len(ids)))
+		if err != nil {
+			return nil, fmt.Errorf("list transaction attempts: %w", err)
+		}
+		for _, a := range page.Attempts {
+			ids = append(ids, a.ID)
+		}
+		if !page.HasMore {
+			return ids, nil
+		}
+		cursor = page.NextCursor
+	}
+	log.Printf("more than %d attempts match; run again with a higher -limit to replay the rest", limit)
+	return ids, nil
+}
+
+// replay replays each attempt in turn. A replay that fails is reported and the
+// run carries on with the next.
+func replay(svc service.Service, ids []int64, apply bool) report {
+	r := report{Apply: apply, Counts: map[models.ReplayOutcome]int{}, Replays: []models.TransactionAttemptReplay{}}
+	for _, id := range ids {
+		result, err := svc.ReplayTransactionAttempt(id, apply)
+		if err != nil {
+			result = &models.TransactionAttemptReplay{AttemptID: id, Outcome: models.ReplayFailed, Error: err.Error()}
+		}
+		log.Printf("attempt %d: %s %s", id, result.Outcome, result.TransactionID)
+		r.Counts[result.Outcome]++
+		r.Replays = append(r.Replays, *result)
+	}
+	return r
+}
+
+// parseIDs parses a comma-separated list of attempt IDs, dropping repeats.
+func parseIDs(s string) ([]int64, error) {
+	var ids []int64
+	seen := map[int64]bool{}
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid -ids: %q is not an attempt ID", field)
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func parseTime(name, s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, errors.New("invalid -" + name + ": expected an RFC 3339 time")
+	}
+	return t, nil
+}
@@ -0,0 +1,80 @@
+// Command restore loads a backup taken by cmd/backup into a database whose
+// migrations have been applied and whose ledger tables are empty:
+//
+//	restore -i intrapay-2024-05-01.jsonl.gz -dry-run
+//	restore -i intrapay-2024-05-01.jsonl.gz
+//
+// A file whose name ends in .gz is read gzipped. The restore runs in a single
+// transaction and commits only if every table matches the checksum in the
+// backup and every account balance agrees with the restored transaction log as
+// it did when the backup was taken; otherwise nothing is written and the
+// command exits with status 1. With -dry-run the restore is verified and then
+// rolled back. The manifest is printed on success. DATABASE_URL is read from
+// the environment (and .env) like the server's.
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"flag"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/joho/godotenv"
+
+	"github.com/nehciyy/intrapay/internal/backup"
+	"github.com/nehciyy/intrapay/internal/db"
+)
+
+func main() {
+	input := flag.String("i", "", "backup file to restore")
+	dryRun := flag.Bool("dry-run", false, "verify the restore, then roll it back")
+	flag.Parse()
+	if *input == "" {
+		log.Fatal("name the backup to restore with -i")
+	}
+
+	f, err := os.Open(*input)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if strings.HasSuffix(*input, ".gz") {
+		if r, err = gzip.NewReader(f); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if _, exists := os.LookupEnv("DATABASE_URL"); !exists {
+		if err := godotenv.Load(); err != nil {
+			log.Println("Warning: no .env file found, proceeding without it")
+		}
+	}
+	database, err := db.InitDB()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer database.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	manifest, err := backup.Restore(ctx, database, r, *dryRun)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *dryRun {
+		log.Println("dry run: the backup verified and was rolled back")
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(manifest); err != nil {
+		log.Fatal(err)
+	}
+}
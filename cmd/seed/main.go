@@ -0,0 +1,167 @@
+// Command seed populates a database with accounts and randomized transfers
+// between them, e.g. for a staging environment or a demo:
+//
+//	seed -accounts 50 -transactions 2000 -seed 42
+//	seed -accounts 10 -first-id 900001 -test
+//
+// The same -seed generates the same balances and the same transfers, so two
+// runs against empty databases leave them alike. Without -seed a seed is drawn
+// at random and printed in the report to repeat the run with. Accounts take the
+// IDs -first-id, -first-id+1, ... or, without it, IDs generated by the server.
+// With -test the accounts are test-mode accounts, which POST
+// /admin/sandbox/purge removes again.
+//
+// Transfers are drawn between the accounts seeded by the run; those the ledger
+// refuses, e.g. for insufficient funds, are counted by reason code in the
+// report. The settings are read from the environment (and .env) like the
+// server's. The command exits with status 1 if any account or transfer failed
+// for a reason other than a rejection.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"math/rand"
+	"os"
+	"time"
+
+	"github.com/joho/godotenv"
+
+	"github.com/nehciyy/intrapay/app"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/money"
+	"github.com/nehciyy/intrapay/internal/sandbox"
+	"github.com/nehciyy/intrapay/internal/service"
+)
+
+// report is what the command prints.
+type report struct {
+	Seed         int64                     `json:"seed"`
+	Livemode     bool                      `json:"livemode"`
+	Accounts     []int64                   `json:"accounts"`
+	Transactions int                       `json:"transactions"`
+	Rejected     map[models.ReasonCode]int `json:"rejected"`
+	Failed       int                       `json:"failed"`
+}
+
+// options are what the flags ask for.
+type options struct {
+	accounts, transactions int
+	firstID                int64
+	maxBalance, maxAmount  float64
+	tenant                 string
+}
+
+func main() {
+	var opts options
+	flag.IntVar(&opts.accounts, "accounts", 20, "how many accounts to open")
+	flag.IntVar(&opts.transactions, "transactions", 200, "how many transfers to make between them")
+	flag.Int64Var(&opts.firstID, "first-id", 0, "ID of the first account; without it the server generates the IDs")
+	flag.Float64Var(&opts.maxBalance, "max-balance", 1000, "the largest opening balance")
+	flag.Float64Var(&opts.maxAmount, "max-amount", 100, "the largest transfer")
+	flag.StringVar(&opts.tenant, "tenant", "default", "tenant the accounts belong to")
+	seed := flag.Int64("seed", 0, "seed of the random balances and transfers; without it one is drawn at random")
+	test := flag.Bool("test", false, "open test-mode accounts")
+	flag.Parse()
+
+	if opts.accounts < 0 || opts.transactions < 0 || opts.maxBalance < 0 || opts.maxAmount <= 0 {
+		log.Fatal("-accounts, -transactions and -max-balance must not be negative, and -max-amount must be positive")
+	}
+	if opts.accounts < 2 && opts.transactions > 0 {
+		log.Fatal("transfers need at least two accounts: raise -accounts")
+	}
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+
+	if _, exists := os.LookupEnv("DATABASE_URL"); !exists {
+		if err := godotenv.Load(); err != nil {
+			log.Println("Warning: no .env file found, proceeding without it")
+		}
+	}
+	cfg, err := app.ConfigFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	a, err := app.New(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if *test {
+		ctx = sandbox.WithTestMode(ctx)
+	}
+	r := seedLedger(ctx, a.Service(), rand.New(rand.NewSource(*seed)), opts)
+	r.Seed = *seed
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(r); err != nil {
+		log.Fatal(err)
+	}
+	if r.Failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// seedLedger opens the accounts and makes the transfers opts asks for, drawing
+// balances, accounts and amounts from rng. The draws do not depend on which
+// transfers the ledger refuses, so once the accounts are open a seed always
+// makes the same transfers.
+func seedLedger(ctx context.Context, svc service.Service, rng *rand.Rand, opts options) report {
+	r := report{Livemode: sandbox.Livemode(ctx), Accounts: []int64{}, Rejected: map[models.ReasonCode]int{}}
+	policy := money.PolicyOf(models.CurrentCurrency().Code)
+	amount := func(max float64) float64 {
+		units := policy.ToMinor(max)
+		if units <= 0 {
+			return 0
+		}
+		return float64(1+rng.Int63n(units)) / float64(policy.Scale())
+	}
+
+	for i := range opts.accounts {
+		balance := amount(opts.maxBalance)
+		accountID := opts.firstID + int64(i)
+		if opts.firstID == 0 {
+			var err error
+			if accountID, err = svc.NewAccountID(); err != nil {
+				log.Printf("account %d: %v", i+1, err)
+				r.Failed++
+				continue
+			}
+		}
+		if _, err := svc.CreateAccount(ctx, accountID, balance, opts.tenant, ""); err != nil {
+			log.Printf("account %d: %v", accountID, err)
+			r.Failed++
+			continue
+		}
+		r.Accounts = append(r.Accounts, accountID)
+	}
+	if len(r.Accounts) < 2 {
+		return r
+	}
+
+	for range opts.transactions {
+		from := rng.Intn(len(r.Accounts))
+		to := rng.Intn(len(r.Accounts) - 1)
+		if to >= from {
+			to++
+		}
+		source, dest, value := r.Accounts[from], r.Accounts[to], amount(opts.maxAmount)
+		_, err := svc.CreateTransaction(ctx, source, dest, value, nil)
+		switch code := service.RejectionReason(err); {
+		case err == nil:
+			r.Transactions++
+		case code != "":
+			r.Rejected[code]++
+		default:
+			log.Printf("transfer of %.2f from %d to %d: %v", value, source, dest, err)
+			r.Failed++
+		}
+	}
+	log.Printf("seeded %d accounts and %d transfers, %d refused", len(r.Accounts), r.Transactions, opts.transactions-r.Transactions-r.Failed)
+	return r
+}
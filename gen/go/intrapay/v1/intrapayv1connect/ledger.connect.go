@@ -0,0 +1,206 @@
+// Code generated by protoc-gen-connect-go. DO NOT EDIT.
+//
+// Source: intrapay/v1/ledger.proto
+
+package intrapayv1connect
+
+import (
+	connect "connectrpc.com/connect"
+	context "context"
+	errors "errors"
+	v1 "github.com/nehciyy/intrapay/gen/go/intrapay/v1"
+	http "net/http"
+	strings "strings"
+)
+
+// This is a compile-time assertion to ensure that this generated file and the connect package are
+// compatible. If you get a compiler error that this constant is not defined, this code was
+// generated with a version of connect newer than the one compiled into your binary. You can fix the
+// problem by either regenerating this code with an older version of connect or updating the connect
+// version compiled into your binary.
+const _ = connect.IsAtLeastVersion1_13_0
+
+const (
+	// LedgerServiceName is the fully-qualified name of the LedgerService service.
+	LedgerServiceName = "intrapay.v1.LedgerService"
+)
+
+// These constants are the fully-qualified names of the RPCs defined in this package. They're
+// exposed at runtime as Spec.Procedure and as the final two segments of the HTTP route.
+//
+// Note that these are different from the fully-qualified method names used by
+// google.golang.org/protobuf/reflect/protoreflect. To convert from these constants to
+// reflection-formatted method names, remove the leading slash and convert the remaining slash to a
+// period.
+const (
+	// LedgerServiceCreateAccountProcedure is the fully-qualified name of the LedgerService's
+	// CreateAccount RPC.
+	LedgerServiceCreateAccountProcedure = "/intrapay.v1.LedgerService/CreateAccount"
+	// LedgerServiceGetAccountProcedure is the fully-qualified name of the LedgerService's GetAccount
+	// RPC.
+	LedgerServiceGetAccountProcedure = "/intrapay.v1.LedgerService/GetAccount"
+	// LedgerServiceCreateTransactionProcedure is the fully-qualified name of the LedgerService's
+	// CreateTransaction RPC.
+	LedgerServiceCreateTransactionProcedure = "/intrapay.v1.LedgerService/CreateTransaction"
+	// LedgerServiceGetTransactionProcedure is the fully-qualified name of the LedgerService's
+	// GetTransaction RPC.
+	LedgerServiceGetTransactionProcedure = "/intrapay.v1.LedgerService/GetTransaction"
+)
+
+// LedgerServiceClient is a client for the intrapay.v1.LedgerService service.
+type LedgerServiceClient interface {
+	// CreateAccount opens an account with an initial balance, like POST /accounts.
+	CreateAccount(context.Context, *connect.Request[v1.CreateAccountRequest]) (*connect.Response[v1.CreateAccountResponse], error)
+	// GetAccount returns an open account, like GET /accounts/{id}.
+	GetAccount(context.Context, *connect.Request[v1.GetAccountRequest]) (*connect.Response[v1.GetAccountResponse], error)
+	// CreateTransaction transfers between accounts, like POST /transactions.
+	CreateTransaction(context.Context, *connect.Request[v1.CreateTransactionRequest]) (*connect.Response[v1.CreateTransactionResponse], error)
+	// GetTransaction returns a transaction by its public ID or serial key, like
+	// GET /transactions/{id}.
+	GetTransaction(context.Context, *connect.Request[v1.GetTransactionRequest]) (*connect.Response[v1.GetTransactionResponse], error)
+}
+
+// NewLedgerServiceClient constructs a client for the intrapay.v1.LedgerService service. By default,
+// it uses the Connect protocol with the binary Protobuf Codec, asks for gzipped responses, and
+// sends uncompressed requests. To use the gRPC or gRPC-Web protocols, supply the connect.WithGRPC()
+// or connect.WithGRPCWeb() options.
+//
+// The URL supplied here should be the base URL for the Connect or gRPC server (for example,
+// http://api.acme.com or https://acme.com/grpc).
+func NewLedgerServiceClient(httpClient connect.HTTPClient, baseURL string, opts ...connect.ClientOption) LedgerServiceClient {
+	baseURL = strings.TrimRight(baseURL, "/")
+	ledgerServiceMethods := v1.File_intrapay_v1_ledger_proto.Services().ByName("LedgerService").Methods()
+	return &ledgerServiceClient{
+		createAccount: connect.NewClient[v1.CreateAccountRequest, v1.CreateAccountResponse](
+			httpClient,
+			baseURL+LedgerServiceCreateAccountProcedure,
+			connect.WithSchema(ledgerServiceMethods.ByName("CreateAccount")),
+			connect.WithClientOptions(opts...),
+		),
+		getAccount: connect.NewClient[v1.GetAccountRequest, v1.GetAccountResponse](
+			httpClient,
+			baseURL+LedgerServiceGetAccountProcedure,
+			connect.WithSchema(ledgerServiceMethods.ByName("GetAccount")),
+			connect.WithClientOptions(opts...),
+		),
+		createTransaction: connect.NewClient[v1.CreateTransactionRequest, v1.CreateTransactionResponse](
+			httpClient,
+			baseURL+LedgerServiceCreateTransactionProcedure,
+			connect.WithSchema(ledgerServiceMethods.ByName("CreateTransaction")),
+			connect.WithClientOptions(opts...),
+		),
+		getTransaction: connect.NewClient[v1.GetTransactionRequest, v1.GetTransactionResponse](
+			httpClient,
+			baseURL+LedgerServiceGetTransactionProcedure,
+			connect.WithSchema(ledgerServiceMethods.ByName("GetTransaction")),
+			connect.WithClientOptions(opts...),
+		),
+	}
+}
+
+// ledgerServiceClient implements LedgerServiceClient.
+type ledgerServiceClient struct {
+	createAccount     *connect.Client[v1.CreateAccountRequest, v1.CreateAccountResponse]
+	getAccount        *connect.Client[v1.GetAccountRequest, v1.GetAccountResponse]
+	createTransaction *connect.Client[v1.CreateTransactionRequest, v1.CreateTransactionResponse]
+	getTransaction    *connect.Client[v1.GetTransactionRequest, v1.GetTransactionResponse]
+}
+
+// CreateAccount calls intrapay.v1.LedgerService.CreateAccount.
+func (c *ledgerServiceClient) CreateAccount(ctx context.Context, req *connect.Request[v1.CreateAccountRequest]) (*connect.Response[v1.CreateAccountResponse], error) {
+	return c.createAccount.CallUnary(ctx, req)
+}
+
+// GetAccount calls intrapay.v1.LedgerService.GetAccount.
+func (c *ledgerServiceClient) GetAccount(ctx context.Context, req *connect.Request[v1.GetAccountRequest]) (*connect.Response[v1.GetAccountResponse], error) {
+	return c.getAccount.CallUnary(ctx, req)
+}
+
+// CreateTransaction calls intrapay.v1.LedgerService.CreateTransaction.
+func (c *ledgerServiceClient) CreateTransaction(ctx context.Context, req *connect.Request[v1.CreateTransactionRequest]) (*connect.Response[v1.CreateTransactionResponse], error) {
+	return c.createTransaction.CallUnary(ctx, req)
+}
+
+// GetTransaction calls intrapay.v1.LedgerService.GetTransaction.
+func (c *ledgerServiceClient) GetTransaction(ctx context.Context, req *connect.Request[v1.GetTransactionRequest]) (*connect.Response[v1.GetTransactionResponse], error) {
+	return c.getTransaction.CallUnary(ctx, req)
+}
+
+// LedgerServiceHandler is an implementation of the intrapay.v1.LedgerService service.
+type LedgerServiceHandler interface {
+	// CreateAccount opens an account with an initial balance, like POST /accounts.
+	CreateAccount(context.Context, *connect.Request[v1.CreateAccountRequest]) (*connect.Response[v1.CreateAccountResponse], error)
+	// GetAccount returns an open account, like GET /accounts/{id}.
+	GetAccount(context.Context, *connect.Request[v1.GetAccountRequest]) (*connect.Response[v1.GetAccountResponse], error)
+	// CreateTransaction transfers between accounts, like POST /transactions.
+	CreateTransaction(context.Context, *connect.Request[v1.CreateTransactionRequest]) (*connect.Response[v1.CreateTransactionResponse], error)
+	// GetTransaction returns a transaction by its public ID or serial key, like
+	// GET /transactions/{id}.
+	GetTransaction(context.Context, *connect.Request[v1.GetTransactionRequest]) (*connect.Response[v1.GetTransactionResponse], error)
+}
+
+// NewLedgerServiceHandler builds an HTTP handler from the service implementation. It returns the
+// path on which to mount the handler and the handler itself.
+//
+// By default, handlers support the Connect, gRPC, and gRPC-Web protocols with the binary Protobuf
+// and JSON codecs. They also support gzip compression.
+func NewLedgerServiceHandler(svc LedgerServiceHandler, opts ...connect.HandlerOption) (string, http.Handler) {
+	ledgerServiceMethods := v1.File_intrapay_v1_ledger_proto.Services().ByName("LedgerService").Methods()
+	ledgerServiceCreateAccountHandler := connect.NewUnaryHandler(
+		LedgerServiceCreateAccountProcedure,
+		svc.CreateAccount,
+		connect.WithSchema(ledgerServiceMethods.ByName("CreateAccount")),
+		connect.WithHandlerOptions(opts...),
+	)
+	ledgerServiceGetAccountHandler := connect.NewUnaryHandler(
+		LedgerServiceGetAccountProcedure,
+		svc.GetAccount,
+		connect.WithSchema(ledgerServiceMethods.ByName("GetAccount")),
+		connect.WithHandlerOptions(opts...),
+	)
+	ledgerServiceCreateTransactionHandler := connect.NewUnaryHandler(
+		LedgerServiceCreateTransactionProcedure,
+		svc.CreateTransaction,
+		connect.WithSchema(ledgerServiceMethods.ByName("CreateTransaction")),
+		connect.WithHandlerOptions(opts...),
+	)
+	ledgerServiceGetTransactionHandler := connect.NewUnaryHandler(
+		LedgerServiceGetTransactionProcedure,
+		svc.GetTransaction,
+		connect.WithSchema(ledgerServiceMethods.ByName("GetTransaction")),
+		connect.WithHandlerOptions(opts...),
+	)
+	return "/intrapay.v1.LedgerService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case LedgerServiceCreateAccountProcedure:
+			ledgerServiceCreateAccountHandler.ServeHTTP(w, r)
+		case LedgerServiceGetAccountProcedure:
+			ledgerServiceGetAccountHandler.ServeHTTP(w, r)
+		case LedgerServiceCreateTransactionProcedure:
+			ledgerServiceCreateTransactionHandler.ServeHTTP(w, r)
+		case LedgerServiceGetTransactionProcedure:
+			ledgerServiceGetTransactionHandler.ServeHTTP(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// UnimplementedLedgerServiceHandler returns CodeUnimplemented from all methods.
+type UnimplementedLedgerServiceHandler struct{}
+
+func (UnimplementedLedgerServiceHandler) CreateAccount(context.Context, *connect.Request[v1.CreateAccountRequest]) (*connect.Response[v1.CreateAccountResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("intrapay.v1.LedgerService.CreateAccount is not implemented"))
+}
+
+func (UnimplementedLedgerServiceHandler) GetAccount(context.Context, *connect.Request[v1.GetAccountRequest]) (*connect.Response[v1.GetAccountResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("intrapay.v1.LedgerService.GetAccount is not implemented"))
+}
+
+func (UnimplementedLedgerServiceHandler) CreateTransaction(context.Context, *connect.Request[v1.CreateTransactionRequest]) (*connect.Response[v1.CreateTransactionResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("intrapay.v1.LedgerService.CreateTransaction is not implemented"))
+}
+
+func (UnimplementedLedgerServiceHandler) GetTransaction(context.Context, *connect.Request[v1.GetTransactionRequest]) (*connect.Response[v1.GetTransactionResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("intrapay.v1.LedgerService.GetTransaction is not implemented"))
+}
@@ -0,0 +1,741 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository"
+	"github.com/nehciyy/intrapay/internal/service"
+)
+
+// RecomputeAccountBalance rebuilds an account balance from the transaction log and
+// reports the drift. An empty body performs a dry run.
+func (s *Server) RecomputeAccountBalance(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "invalid account ID", http.StatusBadRequest)
+		return
+	}
+
+	req := &models.RecomputeBalanceRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := s.Service.RecomputeBalance(id, req.Apply, req.ReasonCode, req.Reason)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, service.ErrInvalidReasonCode):
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	json.NewEncoder(w).Encode(result)
+}
+
+// GetAccountDetails returns an account row. Soft-deleted accounts are only
+// returned with ?include_deleted=true.
+func (s *Server) GetAccountDetails(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "invalid account ID", http.StatusBadRequest)
+		return
+	}
+
+	includeDeleted := false
+	if v := r.URL.Query().Get("include_deleted"); v != "" {
+		if includeDeleted, err = strconv.ParseBool(v); err != nil {
+			http.Error(w, "invalid include_deleted flag", http.StatusBadRequest)
+			return
+		}
+	}
+
+	account, err := s.Service.GetAccountDetails(id, includeDeleted)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, repository.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	if notModified(w, r, account.UpdatedAt) {
+		return
+	}
+
+	writeResponse(w, r, account)
+}
+
+// ListAccountTransactionDetails is ListAccountTransactions with counterparty
+// display names in full, for closed accounts too.
+func (s *Server) ListAccountTransactionDetails(w http.ResponseWriter, r *http.Request) {
+	s.listAccountTransactions(w, r, true)
+}
+
+// RestoreAccount undoes a soft delete and returns the restored account.
+func (s *Server) RestoreAccount(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "invalid account ID", http.StatusBadRequest)
+		return
+	}
+
+	account, err := s.Service.RestoreAccount(id)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, repository.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	json.NewEncoder(w).Encode(account)
+}
+
+// GetUsage reports the metered API calls and transfer volume per API key and
+// tenant for ?period=YYYY-MM, the current UTC month by default.
+func (s *Server) GetUsage(w http.ResponseWriter, r *http.Request) {
+	period := r.URL.Query().Get("period")
+	if period == "" {
+		period = time.Now().UTC().Format(models.UsagePeriodLayout)
+	} else if _, err := time.Parse(models.UsagePeriodLayout, period); err != nil {
+		http.Error(w, "invalid period, expected YYYY-MM", http.StatusBadRequest)
+		return
+	}
+
+	usage, err := s.Service.UsageReport(period)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeResponse(w, r, usage)
+}
+
+// quotaSubject reads the {scope} and {subject} route variables.
+func quotaSubject(r *http.Request) (models.QuotaScope, string, bool) {
+	vars := mux.Vars(r)
+	scope := models.QuotaScope(vars["scope"])
+	return scope, vars["subject"], scope.Valid()
+}
+
+// ListQuotas returns every quota set for an API key or tenant.
+func (s *Server) ListQuotas(w http.ResponseWriter, r *http.Request) {
+	quotas, err := s.Service.ListQuotas()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeResponse(w, r, quotas)
+}
+
+// GetQuota returns the quota of an API key or tenant with its usage this month.
+func (s *Server) GetQuota(w http.ResponseWriter, r *http.Request) {
+	scope, subject, ok := quotaSubject(r)
+	if !ok {
+		http.Error(w, "invalid scope, expected api_key or tenant", http.StatusBadRequest)
+		return
+	}
+
+	status, err := s.Service.GetQuota(scope, subject)
+	if err != nil {
+		code := http.StatusInternalServerError
+		if errors.Is(err, repository.ErrNotFound) {
+			code = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	writeResponse(w, r, status)
+}
+
+// SetQuota creates or replaces the quota of an API key or tenant.
+func (s *Server) SetQuota(w http.ResponseWriter, r *http.Request) {
+	scope, subject, ok := quotaSubject(r)
+	if !ok {
+		http.Error(w, "invalid scope, expected api_key or tenant", http.StatusBadRequest)
+		return
+	}
+
+	req := &models.SetQuotaRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	quota, err := s.Service.SetQuota(models.Quota{
+		Scope:         scope,
+		Subject:       subject,
+		MonthlyCalls:  req.MonthlyCalls,
+		MonthlyVolume: req.MonthlyVolume,
+	})
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInvalidQuota) {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	json.NewEncoder(w).Encode(quota)
+}
+
+// DeleteQuota removes the quota of an API key or tenant.
+func (s *Server) DeleteQuota(w http.ResponseWriter, r *http.Request) {
+	scope, subject, ok := quotaSubject(r)
+	if !ok {
+		http.Error(w, "invalid scope, expected api_key or tenant", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.Service.DeleteQuota(scope, subject); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, repository.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetAccountLimit returns the account limit in force for a tenant with the
+// number of accounts it has open.
+func (s *Server) GetAccountLimit(w http.ResponseWriter, r *http.Request) {
+	status, err := s.Service.GetAccountLimit(mux.Vars(r)["tenant"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeResponse(w, r, status)
+}
+
+// SetAccountLimit overrides the server-wide account limit for a tenant.
+func (s *Server) SetAccountLimit(w http.ResponseWriter, r *http.Request) {
+	req := &models.SetAccountLimitRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	limit, err := s.Service.SetAccountLimit(mux.Vars(r)["tenant"], req.MaxAccounts)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInvalidAccountLimit) {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	json.NewEncoder(w).Encode(limit)
+}
+
+// ListAmountBounds returns the global amount bounds and those of every tenant
+// that has its own.
+func (s *Server) ListAmountBounds(w http.ResponseWriter, r *http.Request) {
+	bounds, err := s.Service.ListAmountBounds()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeResponse(w, r, bounds)
+}
+
+// SetAmountBounds sets the bounds on the amount of a single transfer for a
+// tenant, or the global bounds for the tenant "*".
+func (s *Server) SetAmountBounds(w http.ResponseWriter, r *http.Request) {
+	req := &models.SetAmountBoundsRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	bounds, err := s.Service.SetAmountBounds(mux.Vars(r)["tenant"], *req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInvalidAmountBounds) {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	json.NewEncoder(w).Encode(bounds)
+}
+
+// DeleteAmountBounds removes the amount bounds of a tenant, or the global
+// bounds for the tenant "*".
+func (s *Server) DeleteAmountBounds(w http.ResponseWriter, r *http.Request) {
+	if err := s.Service.DeleteAmountBounds(mux.Vars(r)["tenant"]); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, repository.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeleteAccountLimit removes the account limit of a tenant, which falls back to
+// the server-wide default.
+func (s *Server) DeleteAccountLimit(w http.ResponseWriter, r *http.Request) {
+	if err := s.Service.DeleteAccountLimit(mux.Vars(r)["tenant"]); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, repository.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetTopUpRule returns the top-up rule of an account.
+func (s *Server) GetTopUpRule(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "invalid account ID", http.StatusBadRequest)
+		return
+	}
+
+	rule, err := s.Service.GetTopUpRule(id)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, repository.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	json.NewEncoder(w).Encode(rule)
+}
+
+// SetTopUpRule creates or replaces the top-up rule of an account.
+func (s *Server) SetTopUpRule(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "invalid account ID", http.StatusBadRequest)
+		return
+	}
+
+	req := &models.SetTopUpRuleRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rule, err := s.Service.SetTopUpRule(id, *req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, service.ErrInvalidTopUpRule):
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	json.NewEncoder(w).Encode(rule)
+}
+
+// DeleteTopUpRule stops the automatic top-ups of an account.
+func (s *Server) DeleteTopUpRule(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "invalid account ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.Service.DeleteTopUpRule(id); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, repository.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListPendingActions serves the feed of open actions awaiting a human decision,
+// oldest first. ?kind= and ?assignee= narrow the feed; ?unassigned=true lists
+// only the actions nobody has claimed.
+func (s *Server) ListPendingActions(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := models.PendingActionFilter{
+		Kind:     models.PendingActionKind(q.Get("kind")),
+		Assignee: q.Get("assignee"),
+	}
+	if filter.Kind != "" && !filter.Kind.Valid() {
+		http.Error(w, "invalid kind, expected transfer_approval, dispute, dead_letter, sar_review, suspense or reimbursement", http.StatusBadRequest)
+		return
+	}
+	if v := q.Get("unassigned"); v != "" {
+		var err error
+		if filter.Unassigned, err = strconv.ParseBool(v); err != nil {
+			http.Error(w, "invalid unassigned flag", http.StatusBadRequest)
+			return
+		}
+	}
+
+	pageReq, err := parsePageRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := s.Service.ListPendingActions(filter, pageReq.Cursor, pageReq.Limit)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInvalidCursor) {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	var total *int64
+	if pageReq.IncludeTotal {
+		count, err := s.Service.CountPendingActions(filter)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		total = &count
+	}
+
+	setPageHeaders(w, r, page.NextCursor, page.HasMore, total)
+	writeResponse(w, r, page.Actions)
+}
+
+// ClaimPendingAction assigns an unassigned action to the caller named in the
+// body. Claiming an action held by someone else is a conflict.
+func (s *Server) ClaimPendingAction(w http.ResponseWriter, r *http.Request) {
+	s.assignPendingAction(w, r, s.Service.ClaimPendingAction)
+}
+
+// AssignPendingAction hands an action to the assignee named in the body,
+// taking it from whoever holds it. An empty assignee releases the action.
+func (s *Server) AssignPendingAction(w http.ResponseWriter, r *http.Request) {
+	s.assignPendingAction(w, r, s.Service.AssignPendingAction)
+}
+
+func (s *Server) assignPendingAction(w http.ResponseWriter, r *http.Request, assign func(id int64, assignee string) (*models.PendingAction, error)) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "invalid pending action ID", http.StatusBadRequest)
+		return
+	}
+
+	req := &models.AssignRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	action, err := assign(id, req.Assignee)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrMissingAssignee):
+			status = http.StatusBadRequest
+		case errors.Is(err, repository.ErrNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, repository.ErrAlreadyClaimed):
+			status = http.StatusConflict
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	json.NewEncoder(w).Encode(action)
+}
+
+// ListSuspenseItems lists the inbound payments parked on the suspense account
+// that have not been reposted yet, oldest first.
+func (s *Server) ListSuspenseItems(w http.ResponseWriter, r *http.Request) {
+	pageReq, err := parsePageRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := s.Service.ListSuspenseItems(pageReq.Cursor, pageReq.Limit)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInvalidCursor) {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	setPageHeaders(w, r, page.NextCursor, page.HasMore, nil)
+	writeResponse(w, r, page.Items)
+}
+
+// RepostSuspenseItem moves a parked payment to the account in the body, or to the
+// account it was intended for when the body is empty.
+func (s *Server) RepostSuspenseItem(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "invalid suspense item ID", http.StatusBadRequest)
+		return
+	}
+
+	req := &models.RepostRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	item, err := s.Service.RepostSuspenseItem(id, req.AccountID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, service.ErrDestinationNotFound), errors.Is(err, service.ErrLivemodeMismatch):
+			status = http.StatusUnprocessableEntity
+		case errors.Is(err, repository.ErrAlreadyResolved):
+			status = http.StatusConflict
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	json.NewEncoder(w).Encode(item)
+}
+
+// ApproveReimbursement pays a pending reimbursement from the reimbursement
+// account. A payment the ledger refuses, e.g. for insufficient funds, is
+// answered like a refused transfer and leaves the reimbursement pending.
+func (s *Server) ApproveReimbursement(w http.ResponseWriter, r *http.Request) {
+	s.decideReimbursement(w, r, s.Service.ApproveReimbursement)
+}
+
+// RejectReimbursement closes a pending reimbursement without paying it.
+func (s *Server) RejectReimbursement(w http.ResponseWriter, r *http.Request) {
+	s.decideReimbursement(w, r, s.Service.RejectReimbursement)
+}
+
+func (s *Server) decideReimbursement(w http.ResponseWriter, r *http.Request, decide func(id int64, d models.ReimbursementDecision) (*models.Reimbursement, error)) {
+	id, ok := reimbursementID(w, r)
+	if !ok {
+		return
+	}
+
+	req := &models.ReimbursementDecision{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	reimbursement, err := decide(id, *req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrMissingDecider):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, repository.ErrNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, repository.ErrAlreadyResolved):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			writeTransferError(w, r, err)
+		}
+		return
+	}
+
+	json.NewEncoder(w).Encode(reimbursement)
+}
+
+// ListTransactionAttempts lists rejected transfers, oldest first. ?code=,
+// ?api_key=, ?tenant= and ?account_id= (either side of the transfer) narrow the
+// listing; ?since= and ?until= (RFC 3339) bound it in time.
+func (s *Server) ListTransactionAttempts(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseAttemptFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	pageReq, err := parsePageRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := s.Service.ListTransactionAttempts(filter, pageReq.Cursor, pageReq.Limit)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInvalidCursor) {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	var total *int64
+	if pageReq.IncludeTotal {
+		count, err := s.Service.CountTransactionAttempts(filter)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		total = &count
+	}
+
+	setPageHeaders(w, r, page.NextCursor, page.HasMore, total)
+	writeResponse(w, r, page.Attempts)
+}
+
+// TransactionAttemptStats counts rejected transfers and sums their amounts per
+// ?group_by=reason_code (the default), api_key or tenant. It takes the same
+// filters as ListTransactionAttempts.
+func (s *Server) TransactionAttemptStats(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseAttemptFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	stats, err := s.Service.TransactionAttemptStats(models.AttemptGrouping(r.URL.Query().Get("group_by")), filter)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInvalidGrouping) {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	writeResponse(w, r, stats)
+}
+
+// LookupRequest returns what was recorded for the request whose X-Request-ID,
+// as quoted in the request_id of its error response, is {request_id}.
+func (s *Server) LookupRequest(w http.ResponseWriter, r *http.Request) {
+	record, err := s.Service.LookupRequest(mux.Vars(r)["request_id"])
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, repository.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	writeResponse(w, r, record)
+}
+
+// parseAttemptFilter reads the transaction attempt filters from the query string.
+func parseAttemptFilter(r *http.Request) (models.TransactionAttemptFilter, error) {
+	q := r.URL.Query()
+	filter := models.TransactionAttemptFilter{
+		Code:   models.ReasonCode(q.Get("code")),
+		APIKey: q.Get("api_key"),
+		Tenant: q.Get("tenant"),
+	}
+	if filter.Code != "" {
+		if info, ok := filter.Code.Info(); !ok || info.Category != models.ReasonCategoryRejection {
+			return filter, errors.New("invalid code, expected a rejection reason code (see /reason-codes)")
+		}
+	}
+	if v := q.Get("account_id"); v != "" {
+		var err error
+		if filter.AccountID, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return filter, errors.New("invalid account_id")
+		}
+	}
+	for name, t := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if v := q.Get(name); v != "" {
+			var err error
+			if *t, err = time.Parse(time.RFC3339Nano, v); err != nil {
+				return filter, errors.New("invalid " + name + ", expected RFC 3339 timestamp")
+			}
+		}
+	}
+	return filter, nil
+}
+
+// GetOutboxRelay reports the outbox relay's high-water mark, the newest event
+// written and whether the relay is paused.
+func (s *Server) GetOutboxRelay(w http.ResponseWriter, r *http.Request) {
+	status, err := s.Service.OutboxRelayStatus()
+	writeRelayStatus(w, r, status, err)
+}
+
+// PauseOutboxRelay stops publishing after the current batch. Events keep being
+// written to the outbox.
+func (s *Server) PauseOutboxRelay(w http.ResponseWriter, r *http.Request) {
+	status, err := s.Service.PauseOutboxRelay()
+	writeRelayStatus(w, r, status, err)
+}
+
+// ResumeOutboxRelay continues publishing from the high-water mark.
+func (s *Server) ResumeOutboxRelay(w http.ResponseWriter, r *http.Request) {
+	status, err := s.Service.ResumeOutboxRelay()
+	writeRelayStatus(w, r, status, err)
+}
+
+// ReplayOutbox moves the high-water mark to the after_id in the body, so every
+// event after it is published again.
+func (s *Server) ReplayOutbox(w http.ResponseWriter, r *http.Request) {
+	req := &models.ReplayRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	status, err := s.Service.ReplayOutbox(req.AfterID)
+	writeRelayStatus(w, r, status, err)
+}
+
+// writeRelayStatus answers with the relay status, or with the error of the call
+// that produced it.
+func writeRelayStatus(w http.ResponseWriter, r *http.Request, status *models.OutboxRelayStatus, err error) {
+	if err != nil {
+		code := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInvalidOffset) {
+			code = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), code)
+		return
+	}
+	writeResponse(w, r, status)
+}
+
+// GetSLO reports the transfer endpoint's service level indicators over each
+// rolling window and the error budget left of every objective.
+func (s *Server) GetSLO(w http.ResponseWriter, r *http.Request) {
+	if s.SLO == nil {
+		http.Error(w, "SLO tracking is not enabled", http.StatusNotFound)
+		return
+	}
+	writeResponse(w, r, s.SLO.Report())
+}
@@ -0,0 +1,968 @@
+package api_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/nehciyy/intrapay/internal/api"
+	"github.com/nehciyy/intrapay/internal/clock"
+	"github.com/nehciyy/intrapay/internal/metrics"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository"
+	"github.com/nehciyy/intrapay/internal/service"
+	"github.com/nehciyy/intrapay/internal/slo"
+)
+
+func recomputeRouter(server *api.Server) *mux.Router {
+	router := mux.NewRouter()
+	router.HandleFunc("/admin/accounts/{id}/recompute", server.RecomputeAccountBalance)
+	return router
+}
+
+func TestRecomputeAccountBalance_DryRun(t *testing.T) {
+	server := &api.Server{
+		Service: &mockService{
+			RecomputeBalanceFn: func(id int64, apply bool, code models.ReasonCode, reason string) (*models.BalanceRecompute, error) {
+				if apply {
+					t.Error("expected dry run")
+				}
+				return &models.BalanceRecompute{AccountID: id, StoredBalance: 110, ComputedBalance: 100, Delta: 10}, nil
+			},
+		},
+	}
+
+	req := httptest.NewRequest("POST", "/admin/accounts/7/recompute", nil)
+	rr := httptest.NewRecorder()
+	recomputeRouter(server).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	var resp models.BalanceRecompute
+	json.NewDecoder(rr.Body).Decode(&resp)
+	if resp.AccountID != 7 || resp.Delta != 10 || resp.AdjustmentID != "" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+}
+
+func TestRecomputeAccountBalance_Apply(t *testing.T) {
+	server := &api.Server{
+		Service: &mockService{
+			RecomputeBalanceFn: func(id int64, apply bool, code models.ReasonCode, reason string) (*models.BalanceRecompute, error) {
+				if !apply || code != models.ReasonManualAdjustmentCorrection || reason != "drift in INC-12" {
+					t.Errorf("unexpected apply=%v code=%q reason=%q", apply, code, reason)
+				}
+				return &models.BalanceRecompute{AccountID: id, Delta: 10, AdjustmentID: "3", ReasonCode: code}, nil
+			},
+		},
+	}
+
+	req := httptest.NewRequest("POST", "/admin/accounts/7/recompute", strings.NewReader(`{"apply": true, "reason_code": "manual_adjustment_correction", "reason": "drift in INC-12"}`))
+	rr := httptest.NewRecorder()
+	recomputeRouter(server).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	var resp models.BalanceRecompute
+	json.NewDecoder(rr.Body).Decode(&resp)
+	if resp.AdjustmentID != "3" || resp.ReasonCode != models.ReasonManualAdjustmentCorrection {
+		t.Errorf("expected adjustment 3 with its reason code, got %+v", resp)
+	}
+}
+
+func TestRecomputeAccountBalance_InvalidReasonCode(t *testing.T) {
+	server := &api.Server{
+		Service: &mockService{
+			RecomputeBalanceFn: func(id int64, apply bool, code models.ReasonCode, reason string) (*models.BalanceRecompute, error) {
+				return nil, fmt.Errorf("%w %q for an adjustment", service.ErrInvalidReasonCode, code)
+			},
+		},
+	}
+
+	req := httptest.NewRequest("POST", "/admin/accounts/7/recompute", strings.NewReader(`{"apply": true, "reason_code": "insufficient_funds"}`))
+	rr := httptest.NewRecorder()
+	recomputeRouter(server).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+}
+
+func TestRecomputeAccountBalance_NotFound(t *testing.T) {
+	server := &api.Server{
+		Service: &mockService{
+			RecomputeBalanceFn: func(id int64, apply bool, code models.ReasonCode, reason string) (*models.BalanceRecompute, error) {
+				return nil, fmt.Errorf("account with ID %d %w", id, repository.ErrNotFound)
+			},
+		},
+	}
+
+	req := httptest.NewRequest("POST", "/admin/accounts/999/recompute", nil)
+	rr := httptest.NewRecorder()
+	recomputeRouter(server).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", rr.Code)
+	}
+}
+
+func TestGetAccountDetails_IncludeDeleted(t *testing.T) {
+	deletedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	server := &api.Server{
+		Service: &mockService{
+			GetAccountDetailsFn: func(id int64, includeDeleted bool) (*models.Account, error) {
+				if !includeDeleted {
+					return nil, fmt.Errorf("account with ID %d %w", id, repository.ErrNotFound)
+				}
+				return &models.Account{AccountID: id, Balance: 50, DeletedAt: &deletedAt}, nil
+			},
+		},
+	}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/admin/accounts/{id}", server.GetAccountDetails)
+
+	tests := []struct {
+		name         string
+		url          string
+		expectedCode int
+	}{
+		{name: "Hidden By Default", url: "/admin/accounts/7", expectedCode: http.StatusNotFound},
+		{name: "Included", url: "/admin/accounts/7?include_deleted=true", expectedCode: http.StatusOK},
+		{name: "Invalid Flag", url: "/admin/accounts/7?include_deleted=maybe", expectedCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest("GET", tt.url, nil))
+
+			if rr.Code != tt.expectedCode {
+				t.Fatalf("expected %d, got %d", tt.expectedCode, rr.Code)
+			}
+			if rr.Code != http.StatusOK {
+				return
+			}
+			var resp models.Account
+			json.NewDecoder(rr.Body).Decode(&resp)
+			if resp.DeletedAt == nil || !resp.DeletedAt.Equal(deletedAt) {
+				t.Errorf("expected deleted_at %v, got %+v", deletedAt, resp)
+			}
+		})
+	}
+}
+
+func TestRestoreAccount(t *testing.T) {
+	tests := []struct {
+		name         string
+		restoreErr   error
+		expectedCode int
+	}{
+		{name: "Restored", expectedCode: http.StatusOK},
+		{name: "Not Deleted", restoreErr: fmt.Errorf("deleted account with ID 7 %w", repository.ErrNotFound), expectedCode: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &api.Server{
+				Service: &mockService{
+					RestoreAccountFn: func(id int64) (*models.Account, error) {
+						if tt.restoreErr != nil {
+							return nil, tt.restoreErr
+						}
+						return &models.Account{AccountID: id, Balance: 50}, nil
+					},
+				},
+			}
+
+			router := mux.NewRouter()
+			router.HandleFunc("/admin/accounts/{id}/restore", server.RestoreAccount)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest("POST", "/admin/accounts/7/restore", nil))
+
+			if rr.Code != tt.expectedCode {
+				t.Errorf("expected %d, got %d", tt.expectedCode, rr.Code)
+			}
+		})
+	}
+}
+
+func TestGetUsage(t *testing.T) {
+	var gotPeriod string
+	server := &api.Server{
+		Service: &mockService{
+			UsageReportFn: func(period string) ([]models.Usage, error) {
+				gotPeriod = period
+				return []models.Usage{{Period: period, APIKey: "key-1", Tenant: "payroll", Calls: 120, Volume: 2500}}, nil
+			},
+		},
+	}
+
+	rr := httptest.NewRecorder()
+	server.GetUsage(rr, httptest.NewRequest("GET", "/admin/usage?period=2026-03", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	var resp []models.Usage
+	json.NewDecoder(rr.Body).Decode(&resp)
+	if gotPeriod != "2026-03" || len(resp) != 1 || resp[0].Calls != 120 || resp[0].Tenant != "payroll" {
+		t.Errorf("unexpected response for period %q: %+v", gotPeriod, resp)
+	}
+
+	rr = httptest.NewRecorder()
+	server.GetUsage(rr, httptest.NewRequest("GET", "/admin/usage", nil))
+	if want := time.Now().UTC().Format(models.UsagePeriodLayout); gotPeriod != want {
+		t.Errorf("expected default period %q, got %q", want, gotPeriod)
+	}
+
+	rr = httptest.NewRecorder()
+	server.GetUsage(rr, httptest.NewRequest("GET", "/admin/usage?period=March", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid period, got %d", rr.Code)
+	}
+}
+
+func quotaRouter(server *api.Server) *mux.Router {
+	router := mux.NewRouter()
+	router.HandleFunc("/admin/quotas", server.ListQuotas).Methods("GET")
+	router.HandleFunc("/admin/quotas/{scope}/{subject}", server.GetQuota).Methods("GET")
+	router.HandleFunc("/admin/quotas/{scope}/{subject}", server.SetQuota).Methods("PUT")
+	router.HandleFunc("/admin/quotas/{scope}/{subject}", server.DeleteQuota).Methods("DELETE")
+	return router
+}
+
+func TestSetQuota(t *testing.T) {
+	var got models.Quota
+	server := &api.Server{
+		Service: &mockService{
+			SetQuotaFn: func(q models.Quota) (*models.Quota, error) {
+				if q.MonthlyCalls < 0 {
+					return nil, fmt.Errorf("%w: limits must not be negative", service.ErrInvalidQuota)
+				}
+				got = q
+				return &q, nil
+			},
+		},
+	}
+
+	tests := []struct {
+		name, url, body string
+		expectedCode    int
+	}{
+		{name: "Set", url: "/admin/quotas/tenant/payroll", body: `{"monthly_calls": 1000, "monthly_volume": "50000.00"}`, expectedCode: http.StatusOK},
+		{name: "Unknown scope", url: "/admin/quotas/team/payroll", body: `{"monthly_calls": 1000}`, expectedCode: http.StatusBadRequest},
+		{name: "Negative", url: "/admin/quotas/api_key/key-1", body: `{"monthly_calls": -1}`, expectedCode: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			quotaRouter(server).ServeHTTP(rr, httptest.NewRequest("PUT", tt.url, strings.NewReader(tt.body)))
+			if rr.Code != tt.expectedCode {
+				t.Errorf("expected %d, got %d", tt.expectedCode, rr.Code)
+			}
+		})
+	}
+
+	want := models.Quota{Scope: models.QuotaScopeTenant, Subject: "payroll", MonthlyCalls: 1000, MonthlyVolume: 50000}
+	if got != want {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestGetQuota(t *testing.T) {
+	server := &api.Server{
+		Service: &mockService{
+			GetQuotaFn: func(scope models.QuotaScope, subject string) (*models.QuotaStatus, error) {
+				if subject != "key-1" {
+					return nil, fmt.Errorf("quota of %s %s %w", scope, subject, repository.ErrNotFound)
+				}
+				return &models.QuotaStatus{
+					Quota:     models.Quota{Scope: scope, Subject: subject, MonthlyCalls: 1000},
+					Period:    "2026-03",
+					UsedCalls: 250,
+				}, nil
+			},
+			DeleteQuotaFn: func(scope models.QuotaScope, subject string) error {
+				if subject != "key-1" {
+					return fmt.Errorf("quota of %s %s %w", scope, subject, repository.ErrNotFound)
+				}
+				return nil
+			},
+		},
+	}
+
+	rr := httptest.NewRecorder()
+	quotaRouter(server).ServeHTTP(rr, httptest.NewRequest("GET", "/admin/quotas/api_key/key-1", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	var resp models.QuotaStatus
+	json.NewDecoder(rr.Body).Decode(&resp)
+	if resp.Subject != "key-1" || resp.MonthlyCalls != 1000 || resp.UsedCalls != 250 {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+
+	rr = httptest.NewRecorder()
+	quotaRouter(server).ServeHTTP(rr, httptest.NewRequest("GET", "/admin/quotas/api_key/key-2", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	quotaRouter(server).ServeHTTP(rr, httptest.NewRequest("DELETE", "/admin/quotas/api_key/key-1", nil))
+	if rr.Code != http.StatusNoContent {
+		t.Errorf("expected 204, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	quotaRouter(server).ServeHTTP(rr, httptest.NewRequest("DELETE", "/admin/quotas/api_key/key-2", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", rr.Code)
+	}
+}
+
+func TestAccountLimits(t *testing.T) {
+	server := &api.Server{
+		Service: &mockService{
+			SetAccountLimitFn: func(tenant string, maxAccounts int64) (*models.AccountLimit, error) {
+				if maxAccounts < 0 {
+					return nil, fmt.Errorf("%w: must not be negative", service.ErrInvalidAccountLimit)
+				}
+				return &models.AccountLimit{Tenant: tenant, MaxAccounts: maxAccounts}, nil
+			},
+			GetAccountLimitFn: func(tenant string) (*models.AccountLimitStatus, error) {
+				return &models.AccountLimitStatus{Tenant: tenant, MaxAccounts: 500, Override: true, OpenAccounts: 42}, nil
+			},
+			DeleteAccountLimitFn: func(tenant string) error {
+				if tenant != "payroll" {
+					return fmt.Errorf("account limit of tenant %s %w", tenant, repository.ErrNotFound)
+				}
+				return nil
+			},
+		},
+	}
+	router := mux.NewRouter()
+	router.HandleFunc("/admin/account-limits/{tenant}", server.GetAccountLimit).Methods("GET")
+	router.HandleFunc("/admin/account-limits/{tenant}", server.SetAccountLimit).Methods("PUT")
+	router.HandleFunc("/admin/account-limits/{tenant}", server.DeleteAccountLimit).Methods("DELETE")
+
+	tests := []struct {
+		name, method, url, body string
+		expectedCode            int
+	}{
+		{"Set", "PUT", "/admin/account-limits/payroll", `{"max_accounts": 500}`, http.StatusOK},
+		{"Negative", "PUT", "/admin/account-limits/payroll", `{"max_accounts": -1}`, http.StatusBadRequest},
+		{"Invalid JSON", "PUT", "/admin/account-limits/payroll", `{`, http.StatusBadRequest},
+		{"Get", "GET", "/admin/account-limits/payroll", "", http.StatusOK},
+		{"Delete", "DELETE", "/admin/account-limits/payroll", "", http.StatusNoContent},
+		{"Delete Missing", "DELETE", "/admin/account-limits/billing", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.body)))
+			if rr.Code != tt.expectedCode {
+				t.Errorf("expected %d, got %d", tt.expectedCode, rr.Code)
+			}
+		})
+	}
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/admin/account-limits/payroll", nil))
+	var resp models.AccountLimitStatus
+	json.NewDecoder(rr.Body).Decode(&resp)
+	if resp != (models.AccountLimitStatus{Tenant: "payroll", MaxAccounts: 500, Override: true, OpenAccounts: 42}) {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+}
+
+func TestAmountBounds(t *testing.T) {
+	server := &api.Server{
+		Service: &mockService{
+			SetAmountBoundsFn: func(tenant string, req models.SetAmountBoundsRequest) (*models.AmountBounds, error) {
+				if req.MaxAmount != 0 && req.MaxAmount < req.MinAmount {
+					return nil, fmt.Errorf("%w: maximum %s is below the minimum %s", service.ErrInvalidAmountBounds, req.MaxAmount, req.MinAmount)
+				}
+				return &models.AmountBounds{Tenant: tenant, MinAmount: req.MinAmount, MaxAmount: req.MaxAmount}, nil
+			},
+			ListAmountBoundsFn: func() ([]models.AmountBounds, error) {
+				return []models.AmountBounds{{Tenant: "*", MinAmount: 0.01, MaxAmount: 1000000}, {Tenant: "payroll", MaxAmount: 50000}}, nil
+			},
+			DeleteAmountBoundsFn: func(tenant string) error {
+				if tenant != "payroll" {
+					return fmt.Errorf("amount bounds of tenant %s %w", tenant, repository.ErrNotFound)
+				}
+				return nil
+			},
+		},
+	}
+	router := mux.NewRouter()
+	router.HandleFunc("/admin/amount-bounds", server.ListAmountBounds).Methods("GET")
+	router.HandleFunc("/admin/amount-bounds/{tenant}", server.SetAmountBounds).Methods("PUT")
+	router.HandleFunc("/admin/amount-bounds/{tenant}", server.DeleteAmountBounds).Methods("DELETE")
+
+	tests := []struct {
+		name, method, url, body string
+		expectedCode            int
+	}{
+		{"Set global", "PUT", "/admin/amount-bounds/*", `{"min_amount": "0.01", "max_amount": "1000000.00"}`, http.StatusOK},
+		{"Set tenant", "PUT", "/admin/amount-bounds/payroll", `{"max_amount": "50000.00"}`, http.StatusOK},
+		{"Maximum below minimum", "PUT", "/admin/amount-bounds/payroll", `{"min_amount": "10.00", "max_amount": "5.00"}`, http.StatusBadRequest},
+		{"Too precise", "PUT", "/admin/amount-bounds/payroll", `{"min_amount": "0.001"}`, http.StatusBadRequest},
+		{"List", "GET", "/admin/amount-bounds", "", http.StatusOK},
+		{"Delete", "DELETE", "/admin/amount-bounds/payroll", "", http.StatusNoContent},
+		{"Delete Missing", "DELETE", "/admin/amount-bounds/billing", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.body)))
+			if rr.Code != tt.expectedCode {
+				t.Errorf("expected %d, got %d: %s", tt.expectedCode, rr.Code, rr.Body)
+			}
+		})
+	}
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("PUT", "/admin/amount-bounds/*", strings.NewReader(`{"min_amount": "0.01", "max_amount": "1000000.00"}`)))
+	var resp map[string]any
+	json.NewDecoder(rr.Body).Decode(&resp)
+	if resp["tenant"] != "*" || resp["min_amount"] != "0.01" || resp["max_amount"] != "1000000.00" {
+		t.Errorf("unexpected response: %v", resp)
+	}
+}
+
+func TestTransactionCapabilities_AmountBounds(t *testing.T) {
+	server := &api.Server{
+		Service: &mockService{
+			AmountBoundsOfFn: func(tenant string) models.AmountBounds {
+				if tenant != "payroll" {
+					t.Errorf("expected the caller's tenant, got %q", tenant)
+				}
+				return models.AmountBounds{Tenant: tenant, MinAmount: 0.01, MaxAmount: 50000}
+			},
+		},
+	}
+
+	req := httptest.NewRequest("OPTIONS", "/transactions", nil)
+	req.Header.Set("X-Tenant-ID", "payroll")
+	rr := httptest.NewRecorder()
+	server.TransactionCapabilities(rr, req)
+
+	var resp map[string]any
+	json.NewDecoder(rr.Body).Decode(&resp)
+	if resp["min_amount"] != "0.01" || resp["max_amount"] != "50000.00" {
+		t.Errorf("unexpected response: %v", resp)
+	}
+}
+
+func TestTopUpRules(t *testing.T) {
+	server := &api.Server{
+		Service: &mockService{
+			SetTopUpRuleFn: func(accountID int64, req models.SetTopUpRuleRequest) (*models.TopUpRule, error) {
+				switch {
+				case accountID == 9:
+					return nil, fmt.Errorf("account with ID %d %w", accountID, repository.ErrNotFound)
+				case req.Amount <= 0:
+					return nil, fmt.Errorf("%w: amount must be positive", service.ErrInvalidTopUpRule)
+				}
+				return &models.TopUpRule{AccountID: accountID, Threshold: req.Threshold, Amount: req.Amount, FundingAccountID: req.FundingAccountID}, nil
+			},
+			GetTopUpRuleFn: func(accountID int64) (*models.TopUpRule, error) {
+				return &models.TopUpRule{AccountID: accountID, Threshold: 100, Amount: 500, FundingAccountID: 2}, nil
+			},
+			DeleteTopUpRuleFn: func(accountID int64) error {
+				if accountID != 1 {
+					return fmt.Errorf("top-up rule of account %d %w", accountID, repository.ErrNotFound)
+				}
+				return nil
+			},
+		},
+	}
+	router := mux.NewRouter()
+	router.HandleFunc("/admin/accounts/{id}/top-up", server.GetTopUpRule).Methods("GET")
+	router.HandleFunc("/admin/accounts/{id}/top-up", server.SetTopUpRule).Methods("PUT")
+	router.HandleFunc("/admin/accounts/{id}/top-up", server.DeleteTopUpRule).Methods("DELETE")
+
+	tests := []struct {
+		name, method, url, body string
+		expectedCode            int
+	}{
+		{"Set", "PUT", "/admin/accounts/1/top-up", `{"threshold": "100.00", "amount": "500.00", "funding_account_id": 2}`, http.StatusOK},
+		{"Zero Amount", "PUT", "/admin/accounts/1/top-up", `{"threshold": "100.00", "amount": "0", "funding_account_id": 2}`, http.StatusBadRequest},
+		{"Missing Account", "PUT", "/admin/accounts/9/top-up", `{"threshold": "100.00", "amount": "500.00", "funding_account_id": 2}`, http.StatusNotFound},
+		{"Invalid ID", "PUT", "/admin/accounts/abc/top-up", `{}`, http.StatusBadRequest},
+		{"Get", "GET", "/admin/accounts/1/top-up", "", http.StatusOK},
+		{"Delete", "DELETE", "/admin/accounts/1/top-up", "", http.StatusNoContent},
+		{"Delete Missing", "DELETE", "/admin/accounts/2/top-up", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.body)))
+			if rr.Code != tt.expectedCode {
+				t.Errorf("expected %d, got %d", tt.expectedCode, rr.Code)
+			}
+		})
+	}
+}
+
+func pendingActionRouter(server *api.Server) *mux.Router {
+	router := mux.NewRouter()
+	router.HandleFunc("/admin/pending-actions", server.ListPendingActions).Methods("GET")
+	router.HandleFunc("/admin/pending-actions/{id}/claim", server.ClaimPendingAction).Methods("POST")
+	router.HandleFunc("/admin/pending-actions/{id}/assign", server.AssignPendingAction).Methods("POST")
+	return router
+}
+
+func TestListPendingActions(t *testing.T) {
+	var gotFilter models.PendingActionFilter
+	server := &api.Server{
+		Service: &mockService{
+			ListPendingActionsFn: func(filter models.PendingActionFilter, cursor string, limit int) (*models.PendingActionPage, error) {
+				if cursor == "bad" {
+					return nil, service.ErrInvalidCursor
+				}
+				gotFilter = filter
+				return &models.PendingActionPage{
+					Actions:    []models.PendingAction{{ID: 7, Kind: models.PendingActionDispute, Reference: "dsp-1"}},
+					NextCursor: "next",
+					HasMore:    limit == 1,
+				}, nil
+			},
+			CountPendingActionsFn: func(filter models.PendingActionFilter) (int64, error) {
+				return 3, nil
+			},
+		},
+	}
+
+	rr := httptest.NewRecorder()
+	pendingActionRouter(server).ServeHTTP(rr, httptest.NewRequest("GET", "/admin/pending-actions?kind=dispute&unassigned=true&limit=1&include_total=true", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	if want := (models.PendingActionFilter{Kind: models.PendingActionDispute, Unassigned: true}); gotFilter != want {
+		t.Errorf("expected filter %+v, got %+v", want, gotFilter)
+	}
+	if got := rr.Header().Get("X-Total-Count"); got != "3" {
+		t.Errorf("expected X-Total-Count 3, got %q", got)
+	}
+	if link := rr.Header().Get("Link"); !strings.Contains(link, `cursor=next`) || !strings.Contains(link, `kind=dispute`) {
+		t.Errorf("expected a next link keeping the filters, got %q", link)
+	}
+	var actions []models.PendingAction
+	json.NewDecoder(rr.Body).Decode(&actions)
+	if len(actions) != 1 || actions[0].Reference != "dsp-1" {
+		t.Errorf("unexpected response: %+v", actions)
+	}
+
+	for _, url := range []string{
+		"/admin/pending-actions?kind=refund",
+		"/admin/pending-actions?unassigned=maybe",
+		"/admin/pending-actions?cursor=bad",
+	} {
+		rr := httptest.NewRecorder()
+		pendingActionRouter(server).ServeHTTP(rr, httptest.NewRequest("GET", url, nil))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", url, rr.Code)
+		}
+	}
+}
+
+func TestClaimPendingAction(t *testing.T) {
+	claim := func(id int64, assignee string) (*models.PendingAction, error) {
+		switch {
+		case assignee == "":
+			return nil, service.ErrMissingAssignee
+		case id != 7:
+			return nil, fmt.Errorf("open pending action %d %w", id, repository.ErrNotFound)
+		case assignee != "alice":
+			return nil, fmt.Errorf("pending action %d %w by alice", id, repository.ErrAlreadyClaimed)
+		}
+		return &models.PendingAction{ID: id, Kind: models.PendingActionTransferApproval, Assignee: assignee}, nil
+	}
+	server := &api.Server{
+		Service: &mockService{
+			ClaimPendingActionFn: claim,
+			AssignPendingActionFn: func(id int64, assignee string) (*models.PendingAction, error) {
+				return &models.PendingAction{ID: id, Assignee: assignee}, nil
+			},
+		},
+	}
+
+	tests := []struct {
+		name, url, body string
+		expectedCode    int
+	}{
+		{name: "Claim", url: "/admin/pending-actions/7/claim", body: `{"assignee": "alice"}`, expectedCode: http.StatusOK},
+		{name: "Held by someone else", url: "/admin/pending-actions/7/claim", body: `{"assignee": "bob"}`, expectedCode: http.StatusConflict},
+		{name: "Missing assignee", url: "/admin/pending-actions/7/claim", body: `{}`, expectedCode: http.StatusBadRequest},
+		{name: "Unknown action", url: "/admin/pending-actions/8/claim", body: `{"assignee": "alice"}`, expectedCode: http.StatusNotFound},
+		{name: "Invalid ID", url: "/admin/pending-actions/abc/claim", body: `{"assignee": "alice"}`, expectedCode: http.StatusBadRequest},
+		{name: "Reassign", url: "/admin/pending-actions/7/assign", body: `{"assignee": "bob"}`, expectedCode: http.StatusOK},
+		{name: "Release", url: "/admin/pending-actions/7/assign", expectedCode: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			pendingActionRouter(server).ServeHTTP(rr, httptest.NewRequest("POST", tt.url, strings.NewReader(tt.body)))
+			if rr.Code != tt.expectedCode {
+				t.Errorf("expected %d, got %d: %s", tt.expectedCode, rr.Code, rr.Body)
+			}
+		})
+	}
+}
+
+func suspenseRouter(server *api.Server) *mux.Router {
+	router := mux.NewRouter()
+	router.HandleFunc("/admin/suspense", server.ListSuspenseItems).Methods("GET")
+	router.HandleFunc("/admin/suspense/{id}/repost", server.RepostSuspenseItem).Methods("POST")
+	return router
+}
+
+func TestListSuspenseItems(t *testing.T) {
+	server := &api.Server{
+		Service: &mockService{
+			ListSuspenseItemsFn: func(cursor string, limit int) (*models.SuspenseItemPage, error) {
+				return &models.SuspenseItemPage{
+					Items:      []models.SuspenseItem{{ID: 9, IntendedAccountID: 42, Amount: 50, Reason: models.SuspenseAccountClosed}},
+					NextCursor: "next",
+					HasMore:    true,
+				}, nil
+			},
+		},
+	}
+
+	rr := httptest.NewRecorder()
+	suspenseRouter(server).ServeHTTP(rr, httptest.NewRequest("GET", "/admin/suspense?limit=1", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	if link := rr.Header().Get("Link"); !strings.Contains(link, `rel="next"`) {
+		t.Errorf("expected a next link, got %q", link)
+	}
+	var items []models.SuspenseItem
+	json.NewDecoder(rr.Body).Decode(&items)
+	if len(items) != 1 || items[0].Reason != models.SuspenseAccountClosed {
+		t.Errorf("unexpected response: %+v", items)
+	}
+}
+
+func TestRepostSuspenseItem(t *testing.T) {
+	var gotAccount int64
+	server := &api.Server{
+		Service: &mockService{
+			RepostSuspenseItemFn: func(id, accountID int64) (*models.SuspenseItem, error) {
+				switch {
+				case id != 9:
+					return nil, fmt.Errorf("suspense item %d %w", id, repository.ErrNotFound)
+				case accountID == 404:
+					return nil, fmt.Errorf("destination account %d %w", accountID, service.ErrDestinationNotFound)
+				case accountID == 409:
+					return nil, fmt.Errorf("suspense item %d %w", id, repository.ErrAlreadyResolved)
+				}
+				gotAccount = accountID
+				return &models.SuspenseItem{ID: id, RepostedTo: &accountID}, nil
+			},
+		},
+	}
+
+	tests := []struct {
+		name, url, body string
+		expectedCode    int
+	}{
+		{name: "Intended account", url: "/admin/suspense/9/repost", expectedCode: http.StatusOK},
+		{name: "Other account", url: "/admin/suspense/9/repost", body: `{"account_id": 43}`, expectedCode: http.StatusOK},
+		{name: "Unknown item", url: "/admin/suspense/8/repost", expectedCode: http.StatusNotFound},
+		{name: "Unknown account", url: "/admin/suspense/9/repost", body: `{"account_id": 404}`, expectedCode: http.StatusUnprocessableEntity},
+		{name: "Already reposted", url: "/admin/suspense/9/repost", body: `{"account_id": 409}`, expectedCode: http.StatusConflict},
+		{name: "Invalid ID", url: "/admin/suspense/abc/repost", expectedCode: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			suspenseRouter(server).ServeHTTP(rr, httptest.NewRequest("POST", tt.url, strings.NewReader(tt.body)))
+			if rr.Code != tt.expectedCode {
+				t.Errorf("expected %d, got %d: %s", tt.expectedCode, rr.Code, rr.Body)
+			}
+		})
+	}
+	if gotAccount != 43 {
+		t.Errorf("expected the last repost to go to account 43, got %d", gotAccount)
+	}
+}
+
+func transactionAttemptRouter(server *api.Server) *mux.Router {
+	router := mux.NewRouter()
+	router.HandleFunc("/admin/transaction-attempts", server.ListTransactionAttempts).Methods("GET")
+	router.HandleFunc("/admin/transaction-attempts/stats", server.TransactionAttemptStats).Methods("GET")
+	return router
+}
+
+func TestListTransactionAttempts(t *testing.T) {
+	var gotFilter models.TransactionAttemptFilter
+	server := &api.Server{
+		Service: &mockService{
+			ListTransactionAttemptsFn: func(filter models.TransactionAttemptFilter, cursor string, limit int) (*models.TransactionAttemptPage, error) {
+				gotFilter = filter
+				return &models.TransactionAttemptPage{
+					Attempts: []models.TransactionAttempt{{ID: 4, SourceAccountID: 1, DestinationAccountID: 2, Amount: 50, Code: models.ReasonInsufficientFunds}},
+				}, nil
+			},
+			CountTransactionAttemptsFn: func(filter models.TransactionAttemptFilter) (int64, error) {
+				return 1, nil
+			},
+		},
+	}
+
+	rr := httptest.NewRecorder()
+	transactionAttemptRouter(server).ServeHTTP(rr, httptest.NewRequest("GET", "/admin/transaction-attempts?code=insufficient_funds&tenant=payroll&account_id=1&since=2026-03-01T00:00:00Z&include_total=true", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	want := models.TransactionAttemptFilter{
+		Code:      models.ReasonInsufficientFunds,
+		Tenant:    "payroll",
+		AccountID: 1,
+		Since:     time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+	}
+	if gotFilter != want {
+		t.Errorf("expected filter %+v, got %+v", want, gotFilter)
+	}
+	if got := rr.Header().Get("X-Total-Count"); got != "1" {
+		t.Errorf("expected X-Total-Count 1, got %q", got)
+	}
+
+	for _, url := range []string{
+		"/admin/transaction-attempts?code=manual_adjustment_correction",
+		"/admin/transaction-attempts?code=bogus",
+		"/admin/transaction-attempts?account_id=abc",
+		"/admin/transaction-attempts?until=yesterday",
+	} {
+		rr := httptest.NewRecorder()
+		transactionAttemptRouter(server).ServeHTTP(rr, httptest.NewRequest("GET", url, nil))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", url, rr.Code)
+		}
+	}
+}
+
+func TestTransactionAttemptStats(t *testing.T) {
+	server := &api.Server{
+		Service: &mockService{
+			TransactionAttemptStatsFn: func(groupBy models.AttemptGrouping, filter models.TransactionAttemptFilter) ([]models.TransactionAttemptStat, error) {
+				if groupBy != models.GroupByAPIKey {
+					return nil, fmt.Errorf("%w %q", service.ErrInvalidGrouping, groupBy)
+				}
+				return []models.TransactionAttemptStat{{Key: "key-1", Attempts: 3, TotalAmount: 150}}, nil
+			},
+		},
+	}
+
+	rr := httptest.NewRecorder()
+	transactionAttemptRouter(server).ServeHTTP(rr, httptest.NewRequest("GET", "/admin/transaction-attempts/stats?group_by=api_key", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	var stats []models.TransactionAttemptStat
+	json.NewDecoder(rr.Body).Decode(&stats)
+	if len(stats) != 1 || stats[0].Key != "key-1" || stats[0].Attempts != 3 {
+		t.Errorf("unexpected response: %+v", stats)
+	}
+
+	rr = httptest.NewRecorder()
+	transactionAttemptRouter(server).ServeHTTP(rr, httptest.NewRequest("GET", "/admin/transaction-attempts/stats?group_by=account", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+}
+
+func outboxRouter(server *api.Server) *mux.Router {
+	router := mux.NewRouter()
+	router.HandleFunc("/admin/outbox/relay", server.GetOutboxRelay).Methods("GET")
+	router.HandleFunc("/admin/outbox/relay/pause", server.PauseOutboxRelay).Methods("POST")
+	router.HandleFunc("/admin/outbox/relay/replay", server.ReplayOutbox).Methods("POST")
+	return router
+}
+
+func TestOutboxRelay(t *testing.T) {
+	status := models.OutboxRelayStatus{LastEventID: 3, LatestEventID: 9}
+	server := &api.Server{
+		Service: &mockService{
+			PauseOutboxRelayFn: func() (*models.OutboxRelayStatus, error) {
+				paused := status
+				paused.Paused = true
+				return &paused, nil
+			},
+			ReplayOutboxFn: func(afterID int64) (*models.OutboxRelayStatus, error) {
+				if afterID < 0 {
+					return nil, fmt.Errorf("%w %d", service.ErrInvalidOffset, afterID)
+				}
+				replayed := status
+				replayed.LastEventID = afterID
+				return &replayed, nil
+			},
+		},
+	}
+
+	rr := httptest.NewRecorder()
+	outboxRouter(server).ServeHTTP(rr, httptest.NewRequest("POST", "/admin/outbox/relay/pause", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	var got models.OutboxRelayStatus
+	json.NewDecoder(rr.Body).Decode(&got)
+	if !got.Paused {
+		t.Errorf("expected a paused relay, got %+v", got)
+	}
+
+	rr = httptest.NewRecorder()
+	outboxRouter(server).ServeHTTP(rr, httptest.NewRequest("POST", "/admin/outbox/relay/replay", strings.NewReader(`{"after_id": 1}`)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	got = models.OutboxRelayStatus{}
+	json.NewDecoder(rr.Body).Decode(&got)
+	if got.LastEventID != 1 {
+		t.Errorf("expected the relay at 1, got %+v", got)
+	}
+
+	for _, body := range []string{`{"after_id": -1}`, `{"after_id": "x"}`} {
+		rr = httptest.NewRecorder()
+		outboxRouter(server).ServeHTTP(rr, httptest.NewRequest("POST", "/admin/outbox/relay/replay", strings.NewReader(body)))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", body, rr.Code)
+		}
+	}
+}
+
+func TestGetSLO(t *testing.T) {
+	tracker := slo.New(slo.DefaultConfig(), clock.System)
+	tracker.Record(metrics.OutcomeSuccess, time.Millisecond)
+	server := &api.Server{SLO: tracker}
+
+	rr := httptest.NewRecorder()
+	server.GetSLO(rr, httptest.NewRequest("GET", "/admin/slo", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	var got models.SLOReport
+	json.NewDecoder(rr.Body).Decode(&got)
+	if got.Period != "30d" || len(got.Objectives) != 2 {
+		t.Fatalf("expected both objectives over 30d, got %+v", got)
+	}
+	if w := got.Objectives[0].Windows[0]; w.Total != 1 || w.SLI != 1 {
+		t.Errorf("expected the recorded transfer as good, got %+v", w)
+	}
+
+	rr = httptest.NewRecorder()
+	(&api.Server{}).GetSLO(rr, httptest.NewRequest("GET", "/admin/slo", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404 without a tracker, got %d", rr.Code)
+	}
+}
+
+func TestLookupRequest(t *testing.T) {
+	server := &api.Server{
+		Service: &mockService{
+			LookupRequestFn: func(requestID string) (*models.RequestRecord, error) {
+				if requestID != "req-1" {
+					return nil, fmt.Errorf("request %q %w", requestID, repository.ErrNotFound)
+				}
+				return &models.RequestRecord{RequestID: requestID, TransactionAttempts: []models.TransactionAttempt{{ID: 4, Code: models.ReasonInsufficientFunds}}}, nil
+			},
+		},
+	}
+	router := mux.NewRouter()
+	router.HandleFunc("/admin/requests/{request_id}", server.LookupRequest)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/admin/requests/req-1", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	var got models.RequestRecord
+	json.NewDecoder(rr.Body).Decode(&got)
+	if len(got.TransactionAttempts) != 1 || got.TransactionAttempts[0].ID != 4 {
+		t.Errorf("expected attempt 4, got %+v", got)
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/admin/requests/req-2", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", rr.Code)
+	}
+}
+
+func regionRouter(server *api.Server) *mux.Router {
+	router := mux.NewRouter()
+	router.HandleFunc("/admin/region", server.GetRegion).Methods("GET")
+	router.HandleFunc("/admin/region/promote", server.PromoteRegion).Methods("POST")
+	return router
+}
+
+func TestRegion(t *testing.T) {
+	fence := models.RegionFence{ActiveRegion: "us-east-1", Epoch: 3}
+	server := &api.Server{
+		Service: &mockService{
+			RegionStatusFn: func() (*models.RegionStatus, error) {
+				return &models.RegionStatus{Region: "eu-west-1", RegionFence: fence}, nil
+			},
+			PromoteRegionFn: func(region string, epoch int64) (*models.RegionStatus, error) {
+				switch {
+				case !models.ValidRegion(region):
+					return nil, fmt.Errorf("%w %q", service.ErrInvalidRegion, region)
+				case epoch != fence.Epoch:
+					return nil, fmt.Errorf("%w: the fence is no longer at epoch %d", service.ErrStaleEpoch, epoch)
+				}
+				return &models.RegionStatus{Region: "eu-west-1", Active: region == "eu-west-1",
+					RegionFence: models.RegionFence{ActiveRegion: region, Epoch: epoch + 1}}, nil
+			},
+		},
+	}
+
+	rr := httptest.NewRecorder()
+	regionRouter(server).ServeHTTP(rr, httptest.NewRequest("GET", "/admin/region", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	var got models.RegionStatus
+	json.NewDecoder(rr.Body).Decode(&got)
+	if got.Active || got.ActiveRegion != "us-east-1" || got.Epoch != 3 {
+		t.Errorf("unexpected status: %+v", got)
+	}
+
+	rr = httptest.NewRecorder()
+	regionRouter(server).ServeHTTP(rr, httptest.NewRequest("POST", "/admin/region/promote", strings.NewReader(`{"region": "eu-west-1", "epoch": 3}`)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	got = models.RegionStatus{}
+	json.NewDecoder(rr.Body).Decode(&got)
+	if !got.Active || got.Epoch != 4 {
+		t.Errorf("expected eu-west-1 active at epoch 4, got %+v", got)
+	}
+
+	tests := []struct {
+		body     string
+		expected int
+	}{
+		{`{"region": "eu-west-1", "epoch": 2}`, http.StatusConflict},
+		{`{"region": "EU West", "epoch": 3}`, http.StatusBadRequest},
+		{`{"region": "eu-west-1", "epoch": "3"}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		rr = httptest.NewRecorder()
+		regionRouter(server).ServeHTTP(rr, httptest.NewRequest("POST", "/admin/region/promote", strings.NewReader(tt.body)))
+		if rr.Code != tt.expected {
+			t.Errorf("%s: expected %d, got %d", tt.body, tt.expected, rr.Code)
+		}
+	}
+}
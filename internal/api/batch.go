@@ -0,0 +1,93 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/nehciyy/intrapay/internal/metering"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/service"
+)
+
+// batchRejection is the body of a refused atomic batch: the error with the
+// outcome of every transfer.
+type batchRejection struct {
+	errorResponse
+	Result *models.TransactionBatchResult `json:"result"`
+}
+
+// CreateTransactionBatch posts a batch of transfers, atomically or each on its
+// own, and answers with the outcome of every transfer: 201 when all of them
+// were posted, 207 when some transfers of a batch that is not atomic failed,
+// and 422 when an atomic batch was refused.
+func (s *Server) CreateTransactionBatch(w http.ResponseWriter, r *http.Request) {
+	req := &models.TransactionBatchRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var total models.Amount
+	for _, t := range req.Transfers {
+		total += t.Amount
+	}
+	var quotaErr *metering.QuotaError
+	if err := metering.CheckVolume(r.Context(), float64(total)); errors.As(err, &quotaErr) {
+		writeError(w, r, http.StatusTooManyRequests, errorResponse{Error: quotaErr.Error(), Code: quotaErr.Code})
+		return
+	}
+
+	result, err := s.Service.CreateTransactionBatch(r.Context(), *req)
+	var rejected *service.BatchRejectedError
+	switch {
+	case errors.Is(err, service.ErrInvalidBatch), errors.Is(err, service.ErrInvalidTags):
+		// Refused before any transfer was attempted.
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.As(err, &rejected):
+		s.recordBatchRejections(r, req.Transfers, rejected.Result)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(batchRejection{
+			errorResponse: errorResponse{
+				Error:     err.Error(),
+				Status:    http.StatusUnprocessableEntity,
+				Code:      rejected.Code(),
+				RequestID: r.Header.Get(RequestIDHeader),
+			},
+			Result: rejected.Result,
+		})
+		return
+	case err != nil:
+		writeTransferError(w, r, err)
+		return
+	}
+	s.recordBatchRejections(r, req.Transfers, result)
+	var created models.Amount
+	for _, res := range result.Results {
+		if res.Status == models.BatchTransferCreated {
+			created += req.Transfers[res.Transfer].Amount
+		}
+	}
+	metering.AddVolume(r.Context(), float64(created))
+
+	w.Header().Set("Content-Type", "application/json")
+	if result.Failed > 0 {
+		w.WriteHeader(http.StatusMultiStatus)
+	} else {
+		w.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(w).Encode(result)
+}
+
+// recordBatchRejections records the transfers of result refused with a reason
+// code, as CreateTransaction records a refused transfer.
+func (s *Server) recordBatchRejections(r *http.Request, transfers []models.TransactionRequest, result *models.TransactionBatchResult) {
+	for _, res := range result.Results {
+		if res.Status == models.BatchTransferFailed {
+			s.recordRejection(r, transfers[res.Transfer], res.Code, errors.New(res.Error))
+		}
+	}
+}
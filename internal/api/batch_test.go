@@ -0,0 +1,107 @@
+package api_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/nehciyy/intrapay/internal/api"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/service"
+)
+
+// batchResult fails every transfer of req to account 404, rejecting an atomic
+// batch as a whole if any does.
+func batchResult(req models.TransactionBatchRequest) (*models.TransactionBatchResult, error) {
+	if len(req.Transfers) == 0 {
+		return nil, fmt.Errorf("%w: no transfers", service.ErrInvalidBatch)
+	}
+	result := &models.TransactionBatchResult{Atomic: req.Atomic}
+	for i, t := range req.Transfers {
+		if t.DestinationAccountID == 404 {
+			result.Results = append(result.Results, models.BatchTransferResult{
+				Transfer: i, Status: models.BatchTransferFailed, Code: models.ReasonDestinationNotFound, Error: "account 404 not found",
+			})
+			result.Failed++
+			continue
+		}
+		result.Results = append(result.Results, models.BatchTransferResult{Transfer: i, Status: models.BatchTransferCreated, TransactionID: fmt.Sprintf("txn_%d", i)})
+		result.Created++
+	}
+	if req.Atomic && result.Failed > 0 {
+		for i, r := range result.Results {
+			if r.Status == models.BatchTransferCreated {
+				result.Results[i] = models.BatchTransferResult{Transfer: i, Status: models.BatchTransferSkipped}
+			}
+		}
+		result.Created = 0
+		return nil, &service.BatchRejectedError{Result: result}
+	}
+	return result, nil
+}
+
+func TestCreateTransactionBatch(t *testing.T) {
+	var attempts []models.TransactionAttempt
+	server := &api.Server{
+		Service: &mockService{
+			CreateTransactionBatchFn: batchResult,
+			RecordTransactionAttemptFn: func(a models.TransactionAttempt) {
+				attempts = append(attempts, a)
+			},
+		},
+	}
+	router := mux.NewRouter()
+	router.HandleFunc("/transactions/batch", server.CreateTransactionBatch).Methods("POST")
+
+	const transfers = `[{"source_account_id": 1, "destination_account_id": 2, "amount": "10.00"}, {"source_account_id": 1, "destination_account_id": 404, "amount": "5.00"}]`
+	tests := []struct {
+		name string
+		body string
+		code int
+	}{
+		{"All created", `{"transfers": [{"source_account_id": 1, "destination_account_id": 2, "amount": "10.00"}]}`, http.StatusCreated},
+		{"Some failed", `{"transfers": ` + transfers + `}`, http.StatusMultiStatus},
+		{"Atomic rejected", `{"atomic": true, "transfers": ` + transfers + `}`, http.StatusUnprocessableEntity},
+		{"Empty batch", `{"atomic": true, "transfers": []}`, http.StatusBadRequest},
+		{"Malformed body", `{"transfers": `, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest("POST", "/transactions/batch", strings.NewReader(tt.body)))
+			if rr.Code != tt.code {
+				t.Errorf("expected %d, got %d: %s", tt.code, rr.Code, rr.Body.String())
+			}
+		})
+	}
+
+	attempts = nil
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("POST", "/transactions/batch", strings.NewReader(`{"transfers": `+transfers+`}`)))
+	var result models.TransactionBatchResult
+	json.NewDecoder(rr.Body).Decode(&result)
+	if result.Created != 1 || result.Failed != 1 || result.Results[0].TransactionID != "txn_0" || result.Results[1].Code != models.ReasonDestinationNotFound {
+		t.Errorf("expected transfer 0 created and transfer 1 failed, got %+v", result)
+	}
+	if len(attempts) != 1 || attempts[0].DestinationAccountID != 404 {
+		t.Errorf("expected the failed transfer to be recorded, got %+v", attempts)
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("POST", "/transactions/batch", strings.NewReader(`{"atomic": true, "transfers": `+transfers+`}`)))
+	var rejection struct {
+		Code   models.ReasonCode              `json:"code"`
+		Result *models.TransactionBatchResult `json:"result"`
+	}
+	json.NewDecoder(rr.Body).Decode(&rejection)
+	if rejection.Code != models.ReasonDestinationNotFound {
+		t.Errorf("expected code %q, got %q", models.ReasonDestinationNotFound, rejection.Code)
+	}
+	if rejection.Result == nil || rejection.Result.Results[0].Status != models.BatchTransferSkipped {
+		t.Errorf("expected transfer 0 skipped, got %+v", rejection.Result)
+	}
+}
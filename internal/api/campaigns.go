@@ -0,0 +1,114 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository"
+	"github.com/nehciyy/intrapay/internal/service"
+)
+
+// CreateCashbackCampaign starts a cashback campaign. It is answered with 201
+// and the campaign.
+func (s *Server) CreateCashbackCampaign(w http.ResponseWriter, r *http.Request) {
+	req := &models.CashbackCampaignRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	campaign, err := s.Service.CreateCashbackCampaign(*req)
+	if err != nil {
+		writeCampaignError(w, err)
+		return
+	}
+
+	w.Header().Set("Location", "/admin/campaigns/"+strconv.FormatInt(campaign.ID, 10))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(campaign)
+}
+
+// ListCashbackCampaigns returns every cashback campaign, ended and upcoming
+// ones included.
+func (s *Server) ListCashbackCampaigns(w http.ResponseWriter, r *http.Request) {
+	campaigns, err := s.Service.ListCashbackCampaigns()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeResponse(w, r, campaigns)
+}
+
+// GetCashbackCampaign returns a cashback campaign with what it has paid so far.
+func (s *Server) GetCashbackCampaign(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "invalid campaign ID", http.StatusBadRequest)
+		return
+	}
+
+	campaign, err := s.Service.GetCashbackCampaign(id)
+	if err != nil {
+		writeCampaignError(w, err)
+		return
+	}
+
+	writeResponse(w, r, campaign)
+}
+
+// UpdateCashbackCampaign replaces the terms of a cashback campaign.
+func (s *Server) UpdateCashbackCampaign(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "invalid campaign ID", http.StatusBadRequest)
+		return
+	}
+
+	req := &models.CashbackCampaignRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	campaign, err := s.Service.UpdateCashbackCampaign(id, *req)
+	if err != nil {
+		writeCampaignError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(campaign)
+}
+
+// DeleteCashbackCampaign stops a cashback campaign.
+func (s *Server) DeleteCashbackCampaign(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "invalid campaign ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.Service.DeleteCashbackCampaign(id); err != nil {
+		writeCampaignError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeCampaignError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, service.ErrInvalidCashbackCampaign):
+		status = http.StatusBadRequest
+	case errors.Is(err, repository.ErrNotFound):
+		status = http.StatusNotFound
+	}
+	http.Error(w, err.Error(), status)
+}
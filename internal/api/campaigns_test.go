@@ -0,0 +1,98 @@
+package api_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/nehciyy/intrapay/internal/api"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository"
+	"github.com/nehciyy/intrapay/internal/service"
+)
+
+func TestCashbackCampaigns(t *testing.T) {
+	campaign := func(id int64, req models.CashbackCampaignRequest) *models.CashbackCampaign {
+		return &models.CashbackCampaign{ID: id, Name: req.Name, Percentage: req.Percentage, Cap: req.Cap, FundingAccountID: req.FundingAccountID}
+	}
+	validate := func(req models.CashbackCampaignRequest) error {
+		if req.Percentage <= 0 || req.Percentage > 100 {
+			return fmt.Errorf("%w: percentage must be greater than 0 and at most 100", service.ErrInvalidCashbackCampaign)
+		}
+		return nil
+	}
+	notFound := func(id int64) error { return fmt.Errorf("cashback campaign %d %w", id, repository.ErrNotFound) }
+	server := &api.Server{
+		Service: &mockService{
+			CreateCashbackCampaignFn: func(req models.CashbackCampaignRequest) (*models.CashbackCampaign, error) {
+				if err := validate(req); err != nil {
+					return nil, err
+				}
+				return campaign(3, req), nil
+			},
+			UpdateCashbackCampaignFn: func(id int64, req models.CashbackCampaignRequest) (*models.CashbackCampaign, error) {
+				if err := validate(req); err != nil {
+					return nil, err
+				}
+				if id != 3 {
+					return nil, notFound(id)
+				}
+				return campaign(id, req), nil
+			},
+			GetCashbackCampaignFn: func(id int64) (*models.CashbackCampaign, error) {
+				if id != 3 {
+					return nil, notFound(id)
+				}
+				return &models.CashbackCampaign{ID: 3}, nil
+			},
+			ListCashbackCampaignsFn: func() ([]models.CashbackCampaign, error) {
+				return []models.CashbackCampaign{{ID: 3}}, nil
+			},
+			DeleteCashbackCampaignFn: func(id int64) error {
+				if id != 3 {
+					return notFound(id)
+				}
+				return nil
+			},
+		},
+	}
+	router := mux.NewRouter()
+	router.HandleFunc("/admin/campaigns", server.CreateCashbackCampaign).Methods("POST")
+	router.HandleFunc("/admin/campaigns", server.ListCashbackCampaigns).Methods("GET")
+	router.HandleFunc("/admin/campaigns/{id}", server.GetCashbackCampaign).Methods("GET")
+	router.HandleFunc("/admin/campaigns/{id}", server.UpdateCashbackCampaign).Methods("PUT")
+	router.HandleFunc("/admin/campaigns/{id}", server.DeleteCashbackCampaign).Methods("DELETE")
+
+	body := `{"name": "Summer", "percentage": 1.5, "cap": "10.00", "funding_account_id": 9, "starts_at": "2026-06-01T00:00:00Z", "ends_at": "2026-07-01T00:00:00Z"}`
+	tests := []struct {
+		name, method, url, body string
+		expectedCode            int
+	}{
+		{"Create", "POST", "/admin/campaigns", body, http.StatusCreated},
+		{"Invalid Percentage", "POST", "/admin/campaigns", `{"name": "Summer", "percentage": 0}`, http.StatusBadRequest},
+		{"Malformed", "POST", "/admin/campaigns", `{`, http.StatusBadRequest},
+		{"List", "GET", "/admin/campaigns", "", http.StatusOK},
+		{"Get", "GET", "/admin/campaigns/3", "", http.StatusOK},
+		{"Get Missing", "GET", "/admin/campaigns/4", "", http.StatusNotFound},
+		{"Invalid ID", "GET", "/admin/campaigns/abc", "", http.StatusBadRequest},
+		{"Update", "PUT", "/admin/campaigns/3", body, http.StatusOK},
+		{"Update Missing", "PUT", "/admin/campaigns/4", body, http.StatusNotFound},
+		{"Delete", "DELETE", "/admin/campaigns/3", "", http.StatusNoContent},
+		{"Delete Missing", "DELETE", "/admin/campaigns/4", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.body)))
+			if rr.Code != tt.expectedCode {
+				t.Errorf("expected %d, got %d: %s", tt.expectedCode, rr.Code, rr.Body.String())
+			}
+			if tt.name == "Create" && rr.Header().Get("Location") != "/admin/campaigns/3" {
+				t.Errorf("expected Location /admin/campaigns/3, got %q", rr.Header().Get("Location"))
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := []struct {
+		header   string
+		expected string
+	}{
+		{"", ""},
+		{"br", ""},
+		{"gzip", "gzip"},
+		{"deflate", "deflate"},
+		{"deflate, gzip", "gzip"},
+		{"gzip;q=0.5, deflate", "deflate"},
+		{"gzip;q=0", ""},
+		{"*", "gzip"},
+		{"GZIP", "gzip"},
+	}
+
+	for _, tt := range tests {
+		if got := negotiateEncoding(tt.header); got != tt.expected {
+			t.Errorf("negotiateEncoding(%q) = %q, expected %q", tt.header, got, tt.expected)
+		}
+	}
+}
+
+func TestCompress(t *testing.T) {
+	large := strings.Repeat(`{"id":"1","amount":10}`, 100)
+	tests := []struct {
+		name             string
+		acceptEncoding   string
+		body             string
+		status           int
+		expectedEncoding string
+	}{
+		{name: "Gzip", acceptEncoding: "gzip", body: large, status: http.StatusOK, expectedEncoding: "gzip"},
+		{name: "Deflate", acceptEncoding: "deflate", body: large, status: http.StatusOK, expectedEncoding: "deflate"},
+		{name: "Below Threshold", acceptEncoding: "gzip", body: `{"ok":true}`, status: http.StatusOK},
+		{name: "Not Accepted", body: large, status: http.StatusOK},
+		{name: "Error Status Kept", acceptEncoding: "gzip", body: large, status: http.StatusNotFound, expectedEncoding: "gzip"},
+		{name: "No Content", acceptEncoding: "gzip", status: http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := Compress(1024)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				// Write in chunks to cross the threshold mid-body.
+				for i := 0; i < len(tt.body); i += 300 {
+					io.WriteString(w, tt.body[i:min(i+300, len(tt.body))])
+				}
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/transactions", nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.status {
+				t.Fatalf("expected status %d, got %d", tt.status, rr.Code)
+			}
+			if got := rr.Header().Get("Content-Encoding"); got != tt.expectedEncoding {
+				t.Fatalf("expected Content-Encoding %q, got %q", tt.expectedEncoding, got)
+			}
+			if rr.Header().Get("Vary") != "Accept-Encoding" {
+				t.Errorf("expected Vary: Accept-Encoding")
+			}
+
+			var body io.Reader = rr.Body
+			switch tt.expectedEncoding {
+			case "gzip":
+				zr, err := gzip.NewReader(rr.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				body = zr
+			case "deflate":
+				body = flate.NewReader(rr.Body)
+			}
+			decoded, err := io.ReadAll(body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(decoded) != tt.body {
+				t.Errorf("body mismatch: got %d bytes, expected %d", len(decoded), len(tt.body))
+			}
+		})
+	}
+}
+
+func TestCompress_SniffsContentType(t *testing.T) {
+	handler := Compress(10)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "<html><body>"+strings.Repeat("x", 100)+"</body></html>")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if ct := rr.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+		t.Errorf("expected sniffed text/html, got %q", ct)
+	}
+}
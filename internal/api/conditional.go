@@ -0,0 +1,52 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// notModified answers a conditional GET for a resource last changed at modified.
+// It sets the ETag and Last-Modified validators and, when If-None-Match or
+// If-Modified-Since shows the client's copy is current, writes 304 Not Modified
+// and reports true so the handler can skip encoding the body.
+//
+// The ETag is weak and derived from updated_at alone, which the database bumps
+// on every write to the row; the body differs by Accept, hence Vary. If-None-Match
+// takes precedence over If-Modified-Since, whose one-second resolution can miss
+// a change made within the second of the cached copy.
+func notModified(w http.ResponseWriter, r *http.Request, modified time.Time) bool {
+	etag := `W/"` + strconv.FormatInt(modified.UnixMicro(), 36) + `"`
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	w.Header().Add("Vary", "Accept")
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		if !etagMatches(inm, etag) {
+			return false
+		}
+	} else if ims, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err != nil || modified.Truncate(time.Second).After(ims) {
+		return false
+	}
+
+	// 304 carries the validators but no body or content headers.
+	w.Header().Del("Content-Type")
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// etagMatches reports whether an If-None-Match header lists etag, comparing
+// weakly as RFC 9110 requires for If-None-Match.
+func etagMatches(header, etag string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
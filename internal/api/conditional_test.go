@@ -0,0 +1,68 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNotModified(t *testing.T) {
+	modified := time.Date(2024, 5, 1, 12, 30, 0, 250000000, time.UTC)
+	rr := httptest.NewRecorder()
+	notModified(rr, httptest.NewRequest("GET", "/accounts/1", nil), modified)
+	etag := rr.Header().Get("ETag")
+	if etag == "" || rr.Header().Get("Last-Modified") != "Wed, 01 May 2024 12:30:00 GMT" {
+		t.Fatalf("unexpected validators: %v", rr.Header())
+	}
+
+	tests := []struct {
+		name     string
+		method   string
+		header   string
+		value    string
+		expected bool
+	}{
+		{"No Preconditions", "GET", "", "", false},
+		{"Matching ETag", "GET", "If-None-Match", etag, true},
+		{"Strong Form Of ETag", "GET", "If-None-Match", etag[2:], true},
+		{"ETag In List", "GET", "If-None-Match", `W/"other", ` + etag, true},
+		{"Any ETag", "GET", "If-None-Match", "*", true},
+		{"Stale ETag", "GET", "If-None-Match", `W/"other"`, false},
+		{"Modified Since", "GET", "If-Modified-Since", "Wed, 01 May 2024 12:29:59 GMT", false},
+		{"Not Modified Since", "GET", "If-Modified-Since", "Wed, 01 May 2024 12:30:00 GMT", true},
+		{"Invalid Date", "GET", "If-Modified-Since", "yesterday", false},
+		{"Not A Read", "POST", "If-None-Match", etag, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "/accounts/1", nil)
+			if tt.header != "" {
+				r.Header.Set(tt.header, tt.value)
+			}
+			rr := httptest.NewRecorder()
+			if got := notModified(rr, r, modified); got != tt.expected {
+				t.Fatalf("notModified = %v, expected %v", got, tt.expected)
+			}
+			if tt.expected && rr.Code != http.StatusNotModified {
+				t.Errorf("expected 304, got %d", rr.Code)
+			}
+		})
+	}
+
+	// If-None-Match takes precedence over If-Modified-Since.
+	r := httptest.NewRequest("GET", "/accounts/1", nil)
+	r.Header.Set("If-None-Match", `W/"other"`)
+	r.Header.Set("If-Modified-Since", "Wed, 01 May 2024 12:30:00 GMT")
+	if notModified(httptest.NewRecorder(), r, modified) {
+		t.Errorf("expected a stale ETag to win over a current date")
+	}
+
+	// A later change yields a new ETag.
+	rr = httptest.NewRecorder()
+	notModified(rr, httptest.NewRequest("GET", "/accounts/1", nil), modified.Add(time.Microsecond))
+	if rr.Header().Get("ETag") == etag {
+		t.Errorf("expected a new ETag after a change")
+	}
+}
@@ -0,0 +1,208 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	intrapayv1 "github.com/nehciyy/intrapay/gen/go/intrapay/v1"
+	"github.com/nehciyy/intrapay/gen/go/intrapay/v1/intrapayv1connect"
+	"github.com/nehciyy/intrapay/internal/db"
+	"github.com/nehciyy/intrapay/internal/metering"
+	"github.com/nehciyy/intrapay/internal/metrics"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository"
+	"github.com/nehciyy/intrapay/internal/service"
+)
+
+// ReasonCodeHeader carries the reason code of a rejected RPC in the error's
+// metadata, as the code field of a REST error body does.
+const ReasonCodeHeader = "Intrapay-Reason-Code"
+
+// LedgerService returns the path and handler of intrapay.v1.LedgerService, the
+// protobuf contract of the core of the API, served with connect-go over the
+// Connect, gRPC and gRPC-Web protocols. Each RPC makes the service call of its
+// REST counterpart, so both answer alike; mount it behind the same middleware.
+func (s *Server) LedgerService(opts ...connect.HandlerOption) (string, http.Handler) {
+	return intrapayv1connect.NewLedgerServiceHandler(ledgerService{s}, opts...)
+}
+
+type ledgerService struct {
+	s *Server
+}
+
+func (l ledgerService) CreateAccount(ctx context.Context, req *connect.Request[intrapayv1.CreateAccountRequest]) (*connect.Response[intrapayv1.CreateAccountResponse], error) {
+	var balance models.Amount
+	if req.Msg.InitialBalance != "" {
+		var err error
+		if balance, err = models.ParseAmount(req.Msg.InitialBalance); err != nil {
+			return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		}
+	}
+	accountID := req.Msg.AccountId
+	// Zero asks the server to generate an ID.
+	if accountID == 0 {
+		id, err := l.s.Service.NewAccountID()
+		if err != nil {
+			return nil, connect.NewError(connect.CodeInternal, err)
+		}
+		accountID = id
+	}
+
+	apiKey, tenant := metering.CallerOf(req.Header())
+	if apiKey == metering.AnonymousKey {
+		apiKey = ""
+	}
+	account, err := l.s.Service.CreateAccount(ctx, accountID, float64(balance), tenant, apiKey)
+	if errors.Is(err, service.ErrAccountLimitExceeded) {
+		return nil, rejectionError(connect.CodeResourceExhausted, models.ReasonAccountLimitExceeded, err)
+	}
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+	return connect.NewResponse(&intrapayv1.CreateAccountResponse{Account: accountMessage(account)}), nil
+}
+
+func (l ledgerService) GetAccount(ctx context.Context, req *connect.Request[intrapayv1.GetAccountRequest]) (*connect.Response[intrapayv1.GetAccountResponse], error) {
+	account, err := l.s.Service.GetAccount(db.WithHints(ctx, db.ReadOnly|db.Idempotent), req.Msg.AccountId)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeNotFound, err)
+	}
+	return connect.NewResponse(&intrapayv1.GetAccountResponse{Account: accountMessage(account)}), nil
+}
+
+func (l ledgerService) CreateTransaction(ctx context.Context, req *connect.Request[intrapayv1.CreateTransactionRequest]) (*connect.Response[intrapayv1.CreateTransactionResponse], error) {
+	amount, err := models.ParseAmount(req.Msg.Amount)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+	transfer := models.TransactionRequest{
+		SourceAccountID:      req.Msg.SourceAccountId,
+		DestinationAccountID: req.Msg.DestinationAccountId,
+		Amount:               amount,
+		Tags:                 req.Msg.Tags,
+	}
+
+	var quotaErr *metering.QuotaError
+	if err := metering.CheckVolume(ctx, float64(amount)); errors.As(err, &quotaErr) {
+		l.recordRejection(req.Header(), transfer, quotaErr.Code, quotaErr)
+		return nil, rejectionError(connect.CodeResourceExhausted, quotaErr.Code, quotaErr)
+	}
+
+	if l.s.Forward != nil && l.s.Forward.Len() > 0 {
+		// A transfer made now would overtake those waiting on the
+		// store-and-forward queue, which only the REST API can accept into it.
+		return nil, connect.NewError(connect.CodeUnavailable, errors.New("transfers are waiting on the store-and-forward queue; retry shortly or use POST /transactions"))
+	}
+
+	start := time.Now()
+	transactionID, err := l.s.Service.CreateTransaction(ctx, transfer.SourceAccountID, transfer.DestinationAccountID, float64(amount), transfer.Tags)
+	if errors.Is(err, service.ErrInvalidTags) {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+	outcome, elapsed := transactionOutcome(err), time.Since(start)
+	metrics.ObserveTransaction(outcome, elapsed, req.Header().Get(RequestIDHeader))
+	if l.s.SLO != nil {
+		l.s.SLO.Record(outcome, elapsed)
+	}
+	if err != nil {
+		code := service.RejectionReason(err)
+		l.recordRejection(req.Header(), transfer, code, err)
+		return nil, transferError(code, err)
+	}
+	metering.AddVolume(ctx, float64(amount))
+	return connect.NewResponse(&intrapayv1.CreateTransactionResponse{TransactionId: transactionID}), nil
+}
+
+func (l ledgerService) GetTransaction(ctx context.Context, req *connect.Request[intrapayv1.GetTransactionRequest]) (*connect.Response[intrapayv1.GetTransactionResponse], error) {
+	transaction, err := l.s.Service.GetTransaction(db.WithHints(ctx, db.ReadOnly|db.Idempotent), req.Msg.Id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, connect.NewError(connect.CodeNotFound, err)
+	}
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+	return connect.NewResponse(&intrapayv1.GetTransactionResponse{Transaction: transactionMessage(transaction)}), nil
+}
+
+// recordRejection stores a refused transfer with the caller that requested it,
+// as the REST handler does. Failures that are not rejections are not recorded.
+func (l ledgerService) recordRejection(h http.Header, req models.TransactionRequest, code models.ReasonCode, err error) {
+	if code == "" {
+		return
+	}
+	apiKey, tenant := metering.CallerOf(h)
+	l.s.Service.RecordTransactionAttempt(models.TransactionAttempt{
+		SourceAccountID:      req.SourceAccountID,
+		DestinationAccountID: req.DestinationAccountID,
+		Amount:               req.Amount,
+		Code:                 code,
+		Error:                err.Error(),
+		APIKey:               apiKey,
+		Tenant:               tenant,
+		RequestID:            h.Get(RequestIDHeader),
+	})
+}
+
+// transferError maps a failed transfer to the Connect code of the REST status
+// writeTransferError answers it with.
+func transferError(code models.ReasonCode, err error) error {
+	switch code {
+	case "":
+		return connect.NewError(connect.CodeInternal, err)
+	case models.ReasonConcurrencyConflict:
+		return rejectionError(connect.CodeAborted, code, err)
+	case models.ReasonRegionPassive:
+		return rejectionError(connect.CodeUnavailable, code, err)
+	case models.ReasonAccountNotFound, models.ReasonDestinationNotFound, models.ReasonTokenNotFound:
+		return rejectionError(connect.CodeNotFound, code, err)
+	case models.ReasonTransfersFrozen:
+		return rejectionError(connect.CodePermissionDenied, code, err)
+	}
+	return rejectionError(connect.CodeFailedPrecondition, code, err)
+}
+
+func rejectionError(c connect.Code, code models.ReasonCode, err error) error {
+	e := connect.NewError(c, err)
+	e.Meta().Set(ReasonCodeHeader, string(code))
+	return e
+}
+
+func accountMessage(a *models.Account) *intrapayv1.Account {
+	m := &intrapayv1.Account{
+		AccountId:        a.AccountID,
+		DisplayName:      a.DisplayName,
+		Balance:          a.Balance.String(),
+		AvailableBalance: a.AvailableBalance.String(),
+		Status:           intrapayv1.AccountStatus_ACCOUNT_STATUS_ACTIVE,
+		CreatedAt:        timestamppb.New(a.CreatedAt),
+		UpdatedAt:        timestamppb.New(a.UpdatedAt),
+		Livemode:         a.Livemode,
+	}
+	if a.Status == models.AccountStatusDeleted {
+		m.Status = intrapayv1.AccountStatus_ACCOUNT_STATUS_DELETED
+	}
+	if a.DeletedAt != nil {
+		m.DeletedAt = timestamppb.New(*a.DeletedAt)
+	}
+	return m
+}
+
+func transactionMessage(t *models.Transaction) *intrapayv1.Transaction {
+	return &intrapayv1.Transaction{
+		Id:                   t.ID,
+		TransactionRef:       t.Ref,
+		Kind:                 string(t.Kind),
+		SourceAccountId:      t.SourceAccountID,
+		DestinationAccountId: t.DestinationAccountID,
+		Amount:               t.Amount.String(),
+		CreatedAt:            timestamppb.New(t.CreatedAt),
+		UpdatedAt:            timestamppb.New(t.UpdatedAt),
+		Tags:                 t.Tags,
+		Livemode:             t.Livemode,
+	}
+}
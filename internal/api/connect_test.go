@@ -0,0 +1,173 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	intrapayv1 "github.com/nehciyy/intrapay/gen/go/intrapay/v1"
+	"github.com/nehciyy/intrapay/gen/go/intrapay/v1/intrapayv1connect"
+	"github.com/nehciyy/intrapay/internal/api"
+	"github.com/nehciyy/intrapay/internal/db"
+	"github.com/nehciyy/intrapay/internal/metering"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository"
+	"github.com/nehciyy/intrapay/internal/service"
+)
+
+func TestLedgerService(t *testing.T) {
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	var gotTenant, gotOwner string
+	var attempts []models.TransactionAttempt
+	mock := &mockService{
+		NewAccountIDFn: func() (int64, error) { return 77, nil },
+		CreateAccountFn: func(id int64, balance float64, tenant, owner string) (*models.Account, error) {
+			gotTenant, gotOwner = tenant, owner
+			return &models.Account{AccountID: id, Balance: models.Amount(balance), AvailableBalance: models.Amount(balance), Status: models.AccountStatusActive, CreatedAt: created, UpdatedAt: created}, nil
+		},
+		GetAccountFn: func(id int64) (*models.Account, error) {
+			if id != 1 {
+				return nil, fmt.Errorf("account %d %w", id, repository.ErrNotFound)
+			}
+			deleted := created.Add(time.Hour)
+			return &models.Account{AccountID: 1, Balance: 5, Status: models.AccountStatusDeleted, DeletedAt: &deleted}, nil
+		},
+		CreateTransactionFn: func(from, to int64, amount float64, tags []string) (string, error) {
+			switch {
+			case from == 9:
+				return "", fmt.Errorf("account %d %w", from, repository.ErrNotFound)
+			case amount > 100:
+				return "", service.ErrInsufficientBalance
+			}
+			return "42", nil
+		},
+		RecordTransactionAttemptFn: func(a models.TransactionAttempt) { attempts = append(attempts, a) },
+		GetTransactionFn: func(id string) (*models.Transaction, error) {
+			if id != "42" {
+				return nil, fmt.Errorf("transaction %s %w", id, repository.ErrNotFound)
+			}
+			return &models.Transaction{ID: "42", Kind: models.TransactionTransfer, SourceAccountID: 1, DestinationAccountID: 2, Amount: 12.5, Tags: []string{"rent"}}, nil
+		},
+	}
+	server := &api.Server{Service: mock}
+	mux := http.NewServeMux()
+	mux.Handle(server.LedgerService())
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+	client := intrapayv1connect.NewLedgerServiceClient(ts.Client(), ts.URL)
+	ctx := context.Background()
+
+	t.Run("CreateAccount", func(t *testing.T) {
+		req := connect.NewRequest(&intrapayv1.CreateAccountRequest{InitialBalance: "10.25"})
+		req.Header().Set(metering.APIKeyHeader, "key_1")
+		req.Header().Set(metering.TenantHeader, "acme")
+		res, err := client.CreateAccount(ctx, req)
+		require.NoError(t, err)
+		assert.Equal(t, int64(77), res.Msg.Account.AccountId, "zero asks for a generated ID")
+		assert.Equal(t, "10.25", res.Msg.Account.Balance)
+		assert.Equal(t, intrapayv1.AccountStatus_ACCOUNT_STATUS_ACTIVE, res.Msg.Account.Status)
+		assert.True(t, res.Msg.Account.CreatedAt.AsTime().Equal(created))
+		assert.Equal(t, "acme", gotTenant)
+		assert.Equal(t, "key_1", gotOwner)
+
+		_, err = client.CreateAccount(ctx, connect.NewRequest(&intrapayv1.CreateAccountRequest{InitialBalance: "ten"}))
+		assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+	})
+
+	t.Run("GetAccount", func(t *testing.T) {
+		res, err := client.GetAccount(ctx, connect.NewRequest(&intrapayv1.GetAccountRequest{AccountId: 1}))
+		require.NoError(t, err)
+		assert.Equal(t, intrapayv1.AccountStatus_ACCOUNT_STATUS_DELETED, res.Msg.Account.Status)
+		assert.NotNil(t, res.Msg.Account.DeletedAt)
+		assert.Equal(t, db.ReadOnly|db.Idempotent, mock.hints)
+
+		_, err = client.GetAccount(ctx, connect.NewRequest(&intrapayv1.GetAccountRequest{AccountId: 2}))
+		assert.Equal(t, connect.CodeNotFound, connect.CodeOf(err))
+	})
+
+	t.Run("CreateTransaction", func(t *testing.T) {
+		res, err := client.CreateTransaction(ctx, connect.NewRequest(&intrapayv1.CreateTransactionRequest{SourceAccountId: 1, DestinationAccountId: 2, Amount: "12.50"}))
+		require.NoError(t, err)
+		assert.Equal(t, "42", res.Msg.TransactionId)
+
+		for _, tc := range []struct {
+			name   string
+			from   int64
+			amount string
+			code   connect.Code
+			reason models.ReasonCode
+		}{
+			{"insufficient funds", 1, "500", connect.CodeFailedPrecondition, models.ReasonInsufficientFunds},
+			{"unknown account", 9, "1", connect.CodeNotFound, models.ReasonAccountNotFound},
+		} {
+			attempts = nil
+			req := connect.NewRequest(&intrapayv1.CreateTransactionRequest{SourceAccountId: tc.from, DestinationAccountId: 2, Amount: tc.amount})
+			req.Header().Set(api.RequestIDHeader, "req_1")
+			_, err := client.CreateTransaction(ctx, req)
+			var connectErr *connect.Error
+			require.True(t, errors.As(err, &connectErr), tc.name)
+			assert.Equal(t, tc.code, connectErr.Code(), tc.name)
+			assert.Equal(t, string(tc.reason), connectErr.Meta().Get(api.ReasonCodeHeader), tc.name)
+			require.Len(t, attempts, 1, tc.name)
+			assert.Equal(t, tc.reason, attempts[0].Code, tc.name)
+			assert.Equal(t, "req_1", attempts[0].RequestID, tc.name)
+		}
+	})
+
+	t.Run("GetTransaction", func(t *testing.T) {
+		res, err := client.GetTransaction(ctx, connect.NewRequest(&intrapayv1.GetTransactionRequest{Id: "42"}))
+		require.NoError(t, err)
+		assert.Equal(t, "12.50", res.Msg.Transaction.Amount)
+		assert.Equal(t, []string{"rent"}, res.Msg.Transaction.Tags)
+
+		_, err = client.GetTransaction(ctx, connect.NewRequest(&intrapayv1.GetTransactionRequest{Id: "43"}))
+		assert.Equal(t, connect.CodeNotFound, connect.CodeOf(err))
+	})
+}
+
+// TestLedgerContract_MatchesREST holds the hand-written REST bodies to the
+// protobuf contract: each message has the fields of its REST counterpart, by
+// JSON name, so a field added to one and not the other fails here.
+func TestLedgerContract_MatchesREST(t *testing.T) {
+	for _, tc := range []struct {
+		message proto.Message
+		rest    any
+		// restOnly are the REST fields the contract leaves out.
+		restOnly []string
+	}{
+		{&intrapayv1.Account{}, models.Account{}, nil},
+		{&intrapayv1.Transaction{}, models.Transaction{}, []string{"counterparty", "rounding"}},
+		{&intrapayv1.CreateAccountRequest{}, models.CreateAccountRequest{}, nil},
+		{&intrapayv1.CreateTransactionRequest{}, models.TransactionRequest{}, nil},
+	} {
+		var fields []string
+		descriptor := tc.message.ProtoReflect().Descriptor().Fields()
+		for i := 0; i < descriptor.Len(); i++ {
+			fields = append(fields, string(descriptor.Get(i).Name()))
+		}
+		var tags []string
+		typ := reflect.TypeOf(tc.rest)
+		for i := 0; i < typ.NumField(); i++ {
+			name, _, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+			if !slices.Contains(tc.restOnly, name) {
+				tags = append(tags, name)
+			}
+		}
+		sort.Strings(fields)
+		sort.Strings(tags)
+		assert.Equal(t, tags, fields, "%s and %s", tc.message.ProtoReflect().Descriptor().FullName(), typ)
+	}
+}
@@ -0,0 +1,294 @@
+package api
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/vmihailenco/msgpack/v5"
+
+	"github.com/nehciyy/intrapay/internal/models"
+)
+
+// Encoder writes a response value in one media type.
+type Encoder func(w io.Writer, v interface{}) error
+
+type registeredEncoder struct {
+	mediaType string
+	encode    Encoder
+}
+
+// encoders is the response encoder registry, in order of server preference.
+// JSON comes first so it wins ties and wildcard Accept headers.
+var encoders []registeredEncoder
+
+// RegisterEncoder adds or replaces the encoder for a media type.
+func RegisterEncoder(mediaType string, e Encoder) {
+	for i, re := range encoders {
+		if re.mediaType == mediaType {
+			encoders[i].encode = e
+			return
+		}
+	}
+	encoders = append(encoders, registeredEncoder{mediaType: mediaType, encode: e})
+}
+
+func init() {
+	RegisterEncoder("application/json", encodeJSON)
+	RegisterEncoder("text/csv", encodeCSV)
+	RegisterEncoder("application/msgpack", encodeMsgpack)
+}
+
+// negotiateEncoder picks the registered encoder best matching an Accept header.
+// An empty header means JSON. ok is false when nothing acceptable is registered.
+func negotiateEncoder(accept string) (registeredEncoder, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return encoders[0], true
+	}
+
+	best, bestQ := registeredEncoder{}, 0.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		if q <= bestQ {
+			continue
+		}
+		for _, re := range encoders {
+			if mediaTypeMatches(mediaType, re.mediaType) {
+				best, bestQ = re, q
+				break
+			}
+		}
+	}
+	return best, bestQ > 0
+}
+
+func mediaTypeMatches(pattern, mediaType string) bool {
+	if pattern == "*/*" || pattern == mediaType {
+		return true
+	}
+	prefix, ok := strings.CutSuffix(pattern, "/*")
+	return ok && strings.HasPrefix(mediaType, prefix+"/")
+}
+
+// writeResponse encodes v in the media type negotiated from the Accept header,
+// honouring ?fields= for partial responses.
+func writeResponse(w http.ResponseWriter, r *http.Request, v interface{}) {
+	enc, ok := negotiateEncoder(r.Header.Get("Accept"))
+	if !ok {
+		http.Error(w, "not acceptable, supported types: "+supportedMediaTypes(), http.StatusNotAcceptable)
+		return
+	}
+
+	selected, err := selectFields(v, requestedFields(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Encode into a buffer so an encoding failure can still be reported as a 500.
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if err := enc.encode(buf, selected); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", enc.mediaType)
+	w.Write(buf.Bytes())
+}
+
+// maxPooledBuffer is the largest response buffer kept for reuse. A page of
+// 1000 transactions takes about 300 KiB as JSON; rarer, larger responses are
+// left to the garbage collector rather than pinned in the pool.
+const maxPooledBuffer = 1 << 20
+
+// buffers holds the response buffers of writeResponse, so that list responses
+// do not grow a fresh buffer to their full size on every request.
+var buffers = sync.Pool{New: func() interface{} { return new(bytes.Buffer) }}
+
+func getBuffer() *bytes.Buffer {
+	return buffers.Get().(*bytes.Buffer)
+}
+
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBuffer {
+		return
+	}
+	buf.Reset()
+	buffers.Put(buf)
+}
+
+func supportedMediaTypes() string {
+	types := make([]string, len(encoders))
+	for i, re := range encoders {
+		types[i] = re.mediaType
+	}
+	return strings.Join(types, ", ")
+}
+
+func encodeJSON(w io.Writer, v interface{}) error {
+	switch list := v.(type) {
+	case []models.Transaction:
+		return encodeJSONList(w, list)
+	case []models.Account:
+		return encodeJSONList(w, list)
+	}
+	return json.NewEncoder(w).Encode(v)
+}
+
+// encodeJSONList encodes a list of items that marshal themselves as
+// json.Encoder does, but writes what each item marshals to as it is: the
+// encoder would validate and compact it again, which took half the time of
+// encoding a page of transactions. w is the response buffer, so the many small
+// writes are cheap.
+func encodeJSONList[T json.Marshaler](w io.Writer, items []T) error {
+	if items == nil {
+		_, err := io.WriteString(w, "null\n")
+		return err
+	}
+	io.WriteString(w, "[")
+	for i, item := range items {
+		b, err := item.MarshalJSON()
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			io.WriteString(w, ",")
+		}
+		w.Write(b)
+	}
+	_, err := io.WriteString(w, "]\n")
+	return err
+}
+
+// encodeMsgpack encodes the JSON representation of v, so msgpack clients see the
+// same field names and value formats (e.g. RFC 3339 timestamps) as JSON clients.
+func encodeMsgpack(w io.Writer, v interface{}) error {
+	generic, err := toGeneric(v)
+	if err != nil {
+		return err
+	}
+	return msgpack.NewEncoder(w).Encode(generic)
+}
+
+// encodeCSV writes an object or list of objects as CSV with a header row. Columns
+// follow the JSON field order of the first record; nested values are written as JSON.
+func encodeCSV(w io.Writer, v interface{}) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	records, columns, err := decodeRecords(raw)
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		return nil
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(columns); err != nil {
+		return err
+	}
+	row := make([]string, len(columns))
+	for _, rec := range records {
+		for i, col := range columns {
+			row[i] = csvValue(rec[col])
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// decodeRecords decodes a JSON object or array of objects, returning the records
+// and the keys of the first record in document order.
+func decodeRecords(raw []byte) ([]map[string]json.RawMessage, []string, error) {
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		trimmed = append(append([]byte{'['}, trimmed...), ']')
+	}
+
+	var records []map[string]json.RawMessage
+	if string(trimmed) == "null" {
+		return nil, nil, nil
+	}
+	if err := json.Unmarshal(trimmed, &records); err != nil {
+		return nil, nil, errors.New("csv encoding requires an object or a list of objects")
+	}
+	if len(records) == 0 {
+		return records, nil, nil
+	}
+
+	// Maps lose key order, so read the first record's keys from the token stream.
+	dec := json.NewDecoder(bytes.NewReader(trimmed))
+	var columns []string
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		switch tok {
+		case json.Delim('['), json.Delim('{'):
+			depth++
+			continue
+		case json.Delim(']'), json.Delim('}'):
+			depth--
+			if depth == 1 {
+				return records, columns, nil
+			}
+			continue
+		}
+		if key, ok := tok.(string); ok && depth == 2 {
+			columns = append(columns, key)
+			// Skip the value, whatever its shape.
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+}
+
+func csvValue(raw json.RawMessage) string {
+	if len(raw) == 0 || string(raw) == "null" {
+		return ""
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	return string(raw)
+}
+
+// toGeneric converts v to the maps, slices and scalars of its JSON representation.
+func toGeneric(v interface{}) (interface{}, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var generic interface{}
+	if err := json.Unmarshal(raw, &generic); err != nil {
+		return nil, fmt.Errorf("decode %T: %w", v, err)
+	}
+	return generic, nil
+}
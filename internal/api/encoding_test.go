@@ -0,0 +1,138 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func TestNegotiateEncoder(t *testing.T) {
+	tests := []struct {
+		accept   string
+		expected string
+	}{
+		{accept: "", expected: "application/json"},
+		{accept: "*/*", expected: "application/json"},
+		{accept: "text/csv", expected: "text/csv"},
+		{accept: "text/*", expected: "text/csv"},
+		{accept: "application/msgpack", expected: "application/msgpack"},
+		{accept: "text/csv;q=0.5, application/msgpack", expected: "application/msgpack"},
+		{accept: "text/html, application/json;q=0.1", expected: "application/json"},
+		{accept: "text/html", expected: ""},
+		{accept: "text/csv;q=0", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			enc, ok := negotiateEncoder(tt.accept)
+			assert.Equal(t, tt.expected != "", ok)
+			assert.Equal(t, tt.expected, enc.mediaType)
+		})
+	}
+}
+
+func TestWriteResponse(t *testing.T) {
+	created := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	txns := []models.Transaction{
+		{ID: "1", Kind: models.TransactionTransfer, SourceAccountID: 1, DestinationAccountID: 2, Amount: 5, CreatedAt: created, UpdatedAt: created, Livemode: true},
+		{ID: "2", Kind: models.TransactionTopUp, SourceAccountID: 2, DestinationAccountID: 1, Amount: 7.5, CreatedAt: created, UpdatedAt: created, Livemode: true},
+	}
+
+	write := func(url, accept string, v interface{}) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", url, nil)
+		req.Header.Set("Accept", accept)
+		rr := httptest.NewRecorder()
+		writeResponse(rr, req, v)
+		return rr
+	}
+
+	t.Run("CSV List", func(t *testing.T) {
+		rr := write("/transactions", "text/csv", txns)
+		assert.Equal(t, "text/csv", rr.Header().Get("Content-Type"))
+		assert.Equal(t, "id,kind,source_account_id,destination_account_id,amount,created_at,updated_at,livemode,currency,minor_units\n"+
+			"1,transfer,1,2,5.00,2024-05-01T12:30:00Z,2024-05-01T12:30:00Z,true,USD,2\n"+
+			"2,top_up,2,1,7.50,2024-05-01T12:30:00Z,2024-05-01T12:30:00Z,true,USD,2\n", rr.Body.String())
+	})
+
+	t.Run("CSV Fields", func(t *testing.T) {
+		rr := write("/transactions?fields=id,amount", "text/csv", txns)
+		assert.Equal(t, "amount,id\n5.00,1\n7.50,2\n", rr.Body.String())
+	})
+
+	t.Run("CSV Object", func(t *testing.T) {
+		rr := write("/accounts/1", "text/csv", models.Account{AccountID: 1, Balance: 10, AvailableBalance: 10, Status: models.AccountStatusActive, CreatedAt: created, UpdatedAt: created, Livemode: true})
+		assert.Equal(t, "account_id,balance,available_balance,status,created_at,updated_at,livemode,currency,minor_units\n1,10.00,10.00,active,2024-05-01T12:30:00Z,2024-05-01T12:30:00Z,true,USD,2\n", rr.Body.String())
+	})
+
+	t.Run("CSV Empty", func(t *testing.T) {
+		rr := write("/transactions", "text/csv", []models.Transaction(nil))
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Empty(t, rr.Body.String())
+	})
+
+	t.Run("Msgpack", func(t *testing.T) {
+		rr := write("/transactions", "application/msgpack", txns)
+		assert.Equal(t, "application/msgpack", rr.Header().Get("Content-Type"))
+
+		var got []map[string]interface{}
+		require.NoError(t, msgpack.NewDecoder(bytes.NewReader(rr.Body.Bytes())).Decode(&got))
+		require.Len(t, got, 2)
+		assert.Equal(t, "2", got[1]["id"])
+		assert.Equal(t, "7.50", got[1]["amount"])
+		assert.Equal(t, "2024-05-01T12:30:00Z", got[1]["created_at"])
+	})
+
+	t.Run("JSON Lists", func(t *testing.T) {
+		// The lists written item by item must read exactly as json.Encoder
+		// writes them, HTML escaping included.
+		tagged := append([]models.Transaction{{ID: "3", Tags: []string{"<b>&"}, CreatedAt: created, UpdatedAt: created}}, txns...)
+		accounts := []models.Account{{AccountID: 1, DisplayName: "Jane & <John>", Balance: 10, CreatedAt: created, UpdatedAt: created}, {AccountID: 2}}
+		for _, v := range []interface{}{tagged, []models.Transaction{}, []models.Transaction(nil), accounts, []models.Account{}} {
+			var expected bytes.Buffer
+			require.NoError(t, json.NewEncoder(&expected).Encode(v))
+			rr := write("/transactions", "application/json", v)
+			assert.Equal(t, expected.String(), rr.Body.String())
+		}
+	})
+
+	t.Run("Pooled Buffers", func(t *testing.T) {
+		write("/transactions", "application/json", txns)
+		rr := write("/transactions", "application/json", []int{1})
+		assert.Equal(t, "[1]\n", rr.Body.String(), "a reused buffer must not carry the previous response")
+	})
+
+	t.Run("Not Acceptable", func(t *testing.T) {
+		rr := write("/transactions", "application/xml", txns)
+		assert.Equal(t, http.StatusNotAcceptable, rr.Code)
+		assert.Contains(t, rr.Body.String(), "text/csv")
+	})
+}
+
+// BenchmarkWriteResponse measures encoding a page of 1000 transactions, the
+// largest a list endpoint serves, in each media type.
+func BenchmarkWriteResponse(b *testing.B) {
+	created := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	txns := make([]models.Transaction, 1000)
+	for i := range txns {
+		txns[i] = models.Transaction{ID: strconv.Itoa(i + 1), Kind: models.TransactionTransfer, SourceAccountID: 1, DestinationAccountID: 2, Amount: models.Amount(i) + 0.25, CreatedAt: created, UpdatedAt: created, Livemode: true}
+	}
+	for name, accept := range map[string]string{"json": "application/json", "csv": "text/csv", "msgpack": "application/msgpack"} {
+		b.Run(name, func(b *testing.B) {
+			req := httptest.NewRequest("GET", "/transactions", nil)
+			req.Header.Set("Accept", accept)
+			b.ReportAllocs()
+			for range b.N {
+				writeResponse(httptest.NewRecorder(), req, txns)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/nehciyy/intrapay/internal/eventschema"
+	"github.com/nehciyy/intrapay/internal/service"
+)
+
+// ListEvents serves the event log after ?after_id= (default 0, the beginning),
+// oldest first, for consumers catching up on events they missed. It pages by
+// event ID rather than by cursor: pass next_after_id from each response, or the
+// ID of the last event processed, to continue. ?limit= works as for other lists.
+func (s *Server) ListEvents(w http.ResponseWriter, r *http.Request) {
+	pageReq, err := parsePageRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var afterID int64
+	if v := r.URL.Query().Get("after_id"); v != "" {
+		if afterID, err = strconv.ParseInt(v, 10, 64); err != nil {
+			http.Error(w, "invalid after_id, expected an event ID", http.StatusBadRequest)
+			return
+		}
+	}
+
+	page, err := s.Service.ListEvents(afterID, pageReq.Limit)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInvalidOffset) {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	links := []string{fmt.Sprintf(`<%s>; rel="first"`, eventsPageURL(r, 0))}
+	if page.HasMore {
+		links = append(links, fmt.Sprintf(`<%s>; rel="next"`, eventsPageURL(r, page.NextAfterID)))
+	}
+	w.Header().Set("Link", strings.Join(links, ", "))
+	writeResponse(w, r, page)
+}
+
+// eventsPageURL returns the request path and query with after_id replaced.
+func eventsPageURL(r *http.Request, afterID int64) string {
+	q := r.URL.Query()
+	q.Del("after_id")
+	if afterID > 0 {
+		q.Set("after_id", strconv.FormatInt(afterID, 10))
+	}
+	u := *r.URL
+	u.Scheme, u.Host = "", ""
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// ListEventSchemas serves the catalog of event payload schemas, every version of
+// every event type, with the URL each is served at.
+func (s *Server) ListEventSchemas(w http.ResponseWriter, r *http.Request) {
+	writeResponse(w, r, eventschema.List())
+}
+
+// GetEventSchema serves the JSON schema of one version of an event type. A
+// published version never changes, so it may be cached indefinitely.
+func (s *Server) GetEventSchema(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	version, err := strconv.Atoi(vars["version"])
+	if err != nil {
+		writeError(w, r, http.StatusNotFound, errorResponse{Error: "no schema version " + vars["version"]})
+		return
+	}
+	raw, err := eventschema.Get(vars["type"], version)
+	if err != nil {
+		writeError(w, r, http.StatusNotFound, errorResponse{Error: err.Error()})
+		return
+	}
+	w.Header().Set("Content-Type", "application/schema+json")
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.Write(raw)
+}
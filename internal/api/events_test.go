@@ -0,0 +1,101 @@
+package api_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/nehciyy/intrapay/internal/api"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/service"
+)
+
+func eventSchemaRouter(server *api.Server) *mux.Router {
+	router := mux.NewRouter()
+	router.HandleFunc("/events/schemas", server.ListEventSchemas).Methods("GET")
+	router.HandleFunc("/events/schemas/{type}/{version}", server.GetEventSchema).Methods("GET")
+	return router
+}
+
+func TestEventSchemas(t *testing.T) {
+	router := eventSchemaRouter(&api.Server{})
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/events/schemas", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	var schemas []models.EventSchema
+	json.NewDecoder(rr.Body).Decode(&schemas)
+	if len(schemas) == 0 {
+		t.Fatal("expected schemas to be listed")
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", schemas[0].URL, nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("%s: expected 200, got %d", schemas[0].URL, rr.Code)
+	}
+	if ct := rr.Header().Get("Content-Type"); ct != "application/schema+json" {
+		t.Errorf("expected application/schema+json, got %q", ct)
+	}
+	if !json.Valid(rr.Body.Bytes()) {
+		t.Errorf("expected a JSON schema, got %s", rr.Body.String())
+	}
+
+	for _, url := range []string{"/events/schemas/transfer.completed/99", "/events/schemas/transfer.completed/v1", "/events/schemas/transfer.unknown/1"} {
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", url, nil))
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("%s: expected 404, got %d", url, rr.Code)
+		}
+	}
+}
+
+func TestListEvents(t *testing.T) {
+	server := &api.Server{
+		Service: &mockService{
+			ListEventsFn: func(afterID int64, limit int) (*models.EventPage, error) {
+				if afterID < 0 {
+					return nil, fmt.Errorf("%w %d", service.ErrInvalidOffset, afterID)
+				}
+				if afterID != 40 || limit != 2 {
+					t.Errorf("unexpected page request: after_id %d, limit %d", afterID, limit)
+				}
+				return &models.EventPage{
+					Events:      []models.Event{{ID: 41, Type: models.EventTransferCompleted}, {ID: 43, Type: models.EventTransferCompleted}},
+					NextAfterID: 43,
+					HasMore:     true,
+				}, nil
+			},
+		},
+	}
+	router := mux.NewRouter()
+	router.HandleFunc("/events", server.ListEvents).Methods("GET")
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/events?after_id=40&limit=2", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	var page models.EventPage
+	json.NewDecoder(rr.Body).Decode(&page)
+	if len(page.Events) != 2 || page.NextAfterID != 43 || !page.HasMore {
+		t.Errorf("unexpected page: %+v", page)
+	}
+	if link := rr.Header().Get("Link"); !strings.Contains(link, `</events?after_id=43&limit=2>; rel="next"`) {
+		t.Errorf("expected a next link after event 43, got %q", link)
+	}
+
+	for _, url := range []string{"/events?after_id=-1", "/events?after_id=x", "/events?limit=0"} {
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", url, nil))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", url, rr.Code)
+		}
+	}
+}
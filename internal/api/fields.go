@@ -0,0 +1,65 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// requestedFields parses ?fields=a,b into a set. A nil set means all fields.
+func requestedFields(r *http.Request) map[string]bool {
+	v := r.URL.Query().Get("fields")
+	if v == "" {
+		return nil
+	}
+	fields := make(map[string]bool)
+	for _, f := range strings.Split(v, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			fields[f] = true
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return fields
+}
+
+// selectFields reduces v, a JSON object or list of objects, to the given fields.
+// Unknown field names are ignored so clients can ask for attributes that newer
+// servers add without breaking against older ones.
+func selectFields(v interface{}, fields map[string]bool) (interface{}, error) {
+	if fields == nil {
+		return v, nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return nil, err
+	}
+
+	switch d := decoded.(type) {
+	case map[string]interface{}:
+		return filterObject(d, fields), nil
+	case []interface{}:
+		for i, item := range d {
+			if obj, ok := item.(map[string]interface{}); ok {
+				d[i] = filterObject(obj, fields)
+			}
+		}
+		return d, nil
+	default:
+		return decoded, nil
+	}
+}
+
+func filterObject(obj map[string]interface{}, fields map[string]bool) map[string]interface{} {
+	for k := range obj {
+		if !fields[k] {
+			delete(obj, k)
+		}
+	}
+	return obj
+}
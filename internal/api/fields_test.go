@@ -0,0 +1,57 @@
+package api
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestedFields(t *testing.T) {
+	tests := []struct {
+		url      string
+		expected map[string]bool
+	}{
+		{url: "/accounts/1", expected: nil},
+		{url: "/accounts/1?fields=", expected: nil},
+		{url: "/accounts/1?fields=,", expected: nil},
+		{url: "/accounts/1?fields=balance", expected: map[string]bool{"balance": true}},
+		{url: "/accounts/1?fields=account_id,%20balance", expected: map[string]bool{"account_id": true, "balance": true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			assert.Equal(t, tt.expected, requestedFields(httptest.NewRequest("GET", tt.url, nil)))
+		})
+	}
+}
+
+func TestSelectFields(t *testing.T) {
+	fields := map[string]bool{"id": true, "amount": true, "unknown": true}
+	now := time.Now()
+
+	t.Run("Object", func(t *testing.T) {
+		got, err := selectFields(models.Account{AccountID: 1, Balance: 10, CreatedAt: now}, map[string]bool{"balance": true})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"balance": "10.00"}, got)
+	})
+
+	t.Run("List", func(t *testing.T) {
+		got, err := selectFields([]models.Transaction{{ID: "1", Amount: 5, CreatedAt: now}, {ID: "2", Amount: 7}}, fields)
+		require.NoError(t, err)
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{"id": "1", "amount": "5.00"},
+			map[string]interface{}{"id": "2", "amount": "7.00"},
+		}, got)
+	})
+
+	t.Run("All Fields", func(t *testing.T) {
+		account := models.Account{AccountID: 1}
+		got, err := selectFields(account, nil)
+		require.NoError(t, err)
+		assert.Equal(t, account, got)
+	})
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/nehciyy/intrapay/internal/metering"
+	"github.com/nehciyy/intrapay/internal/middleware"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository"
+)
+
+// forwardTransaction accepts req into the store-and-forward queue and answers
+// 202 with its status, to be followed at the Location returned. err is the
+// failure of req when it was forwarded because the database is unreachable,
+// and nil when it was forwarded to wait behind the transfers already queued.
+// If the queue cannot take it, e.g. because it is full, err is answered as it
+// is, or a 503 when there is none.
+func (s *Server) forwardTransaction(w http.ResponseWriter, r *http.Request, req models.TransactionRequest, err error) {
+	apiKey, tenant := metering.Caller(r)
+	status, qErr := s.Forward.Enqueue(models.ForwardedTransfer{
+		Request:        req,
+		APIKey:         apiKey,
+		Tenant:         tenant,
+		RequestID:      r.Header.Get(RequestIDHeader),
+		IdempotencyKey: r.Header.Get(middleware.IdempotencyKeyHeader),
+	})
+	if qErr != nil && err == nil {
+		http.Error(w, qErr.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	if qErr != nil {
+		writeTransferError(w, r, err)
+		return
+	}
+	writeForwarded(w, status)
+}
+
+// writeForwarded answers 202 with the status of a forwarded transfer, to be
+// followed at the Location returned.
+func writeForwarded(w http.ResponseWriter, status *models.ForwardedTransferStatus) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/transactions/forwarded/"+status.ID)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(status)
+}
+
+// GetForwardedTransfer returns the status of a transfer accepted into the
+// store-and-forward queue: queued while it waits on the queue of this server,
+// then its outcome. A transfer is known here only once it has been replayed if
+// it was accepted by another server.
+func (s *Server) GetForwardedTransfer(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if s.Forward == nil {
+		writeError(w, r, http.StatusNotFound, errorResponse{Error: "store-and-forward is not enabled"})
+		return
+	}
+	if status, ok := s.Forward.Status(r.Context(), id); ok {
+		writeResponse(w, r, status)
+		return
+	}
+	status, err := s.Service.GetForwardedTransfer(r.Context(), id)
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, r, http.StatusNotFound, errorResponse{Error: "forwarded transfer " + id + " not found"})
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeResponse(w, r, status)
+}
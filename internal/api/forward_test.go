@@ -0,0 +1,132 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+	"github.com/nehciyy/intrapay/internal/api"
+	"github.com/nehciyy/intrapay/internal/clock"
+	"github.com/nehciyy/intrapay/internal/forward"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository"
+)
+
+func TestForwardedTransfers(t *testing.T) {
+	var attempted int
+	svc := &mockService{
+		CreateTransactionFn: func(from, to int64, amount float64, tags []string) (string, error) {
+			attempted++
+			if attempted > 1 {
+				return "8", nil
+			}
+			return "", fmt.Errorf("failed to begin transaction: %w", &pq.Error{Code: "57P03"})
+		},
+		GetForwardedTransferFn: func(id string) (*models.ForwardedTransferStatus, error) {
+			if id == "01J9" {
+				return &models.ForwardedTransferStatus{ID: id, Status: models.ForwardCompleted, TransactionID: "7"}, nil
+			}
+			return nil, fmt.Errorf("forwarded transfer %s %w", id, repository.ErrNotFound)
+		},
+		FindForwardedTransferFn: func(apiKey, idempotencyKey string) (*models.ForwardedTransferStatus, error) {
+			if idempotencyKey == "replayed" {
+				return &models.ForwardedTransferStatus{ID: "01J9", Status: models.ForwardCompleted, TransactionID: "7"}, nil
+			}
+			return nil, fmt.Errorf("forwarded transfer %s %w", idempotencyKey, repository.ErrNotFound)
+		},
+		ReplayForwardedTransferFn: func(f models.ForwardedTransfer) (*models.ForwardedTransferStatus, error) {
+			return &models.ForwardedTransferStatus{ID: f.ID, Status: models.ForwardCompleted}, nil
+		},
+	}
+	queue, err := forward.Open(t.TempDir(), svc, clock.NewFake(time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)), 2, time.Hour, log.New(&bytes.Buffer{}, "", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer queue.Close()
+	server := &api.Server{Service: svc, Forward: queue}
+	router := mux.NewRouter()
+	router.HandleFunc("/transactions", server.CreateTransaction).Methods("POST")
+	router.HandleFunc("/transactions/forwarded/{id}", server.GetForwardedTransfer).Methods("GET")
+
+	const body = `{"source_account_id": 1, "destination_account_id": 2, "amount": "10.00"}`
+	post := func(idempotencyKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/transactions", strings.NewReader(body))
+		if idempotencyKey != "" {
+			req.Header.Set("Idempotency-Key", idempotencyKey)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+	rr := post("key_1")
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("expected 202 while the database is unreachable, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var accepted models.ForwardedTransferStatus
+	json.NewDecoder(rr.Body).Decode(&accepted)
+	if accepted.Status != models.ForwardQueued || rr.Header().Get("Location") != "/transactions/forwarded/"+accepted.ID {
+		t.Errorf("expected a queued transfer at its Location, got %+v at %q", accepted, rr.Header().Get("Location"))
+	}
+
+	// The database is back, but a transfer is still queued: new ones queue
+	// behind it rather than overtake it.
+	if rr := post(""); rr.Code != http.StatusAccepted || attempted != 1 {
+		t.Errorf("expected 202 without a transfer attempted while the queue is not empty, got %d after %d attempts", rr.Code, attempted)
+	}
+	rr = post("key_1")
+	var retried models.ForwardedTransferStatus
+	json.NewDecoder(rr.Body).Decode(&retried)
+	if rr.Code != http.StatusAccepted || retried.ID != accepted.ID {
+		t.Errorf("expected a retry with the same Idempotency-Key to answer the queued transfer %s, got %d with %q", accepted.ID, rr.Code, retried.ID)
+	}
+	if rr := post(""); rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 with the queue full, got %d", rr.Code)
+	}
+
+	tests := []struct {
+		id     string
+		code   int
+		status models.ForwardStatus
+	}{
+		{accepted.ID, http.StatusOK, models.ForwardQueued},
+		{"01J9", http.StatusOK, models.ForwardCompleted},
+		{"01JA", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/transactions/forwarded/"+tt.id, nil))
+		if rr.Code != tt.code {
+			t.Errorf("%s: expected %d, got %d", tt.id, tt.code, rr.Code)
+			continue
+		}
+		var status models.ForwardedTransferStatus
+		json.NewDecoder(rr.Body).Decode(&status)
+		if status.Status != tt.status {
+			t.Errorf("%s: expected status %q, got %q", tt.id, tt.status, status.Status)
+		}
+	}
+
+	// A retry of a transfer replayed since, here or by another server, is
+	// answered with its outcome, whether queued transfers wait or not.
+	for _, drained := range []bool{false, true} {
+		if drained {
+			if _, err := queue.Replay(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		rr := post("replayed")
+		var status models.ForwardedTransferStatus
+		json.NewDecoder(rr.Body).Decode(&status)
+		if rr.Code != http.StatusAccepted || status.ID != "01J9" || status.Status != models.ForwardCompleted || attempted != 1 {
+			t.Errorf("drained %v: expected the outcome of 01J9 without a transfer attempted, got %d with %+v after %d attempts", drained, rr.Code, status, attempted)
+		}
+	}
+}
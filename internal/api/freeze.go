@@ -0,0 +1,90 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository"
+	"github.com/nehciyy/intrapay/internal/service"
+)
+
+// FreezeTransfers is the break-glass switch: it suspends the outgoing transfers
+// of a tenant's accounts, or of every account when the body names no tenant,
+// until the freeze expires after its duration or is lifted.
+func (s *Server) FreezeTransfers(w http.ResponseWriter, r *http.Request) {
+	req := &models.TransferFreezeRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	freeze, err := s.Service.FreezeTransfers(*req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInvalidFreeze) {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	s.emitSecurityEvent(r, models.SecurityTransfersFrozen, freeze.FrozenBy, freeze.Tenant,
+		fmt.Sprintf("freeze %d until %s: %s", freeze.ID, freeze.ExpiresAt.Format(time.RFC3339), freeze.Reason))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(freeze)
+}
+
+// LiftTransferFreezes lifts the freezes in force of the tenant in the body, or
+// of every account when it names none, and answers with the freezes lifted.
+func (s *Server) LiftTransferFreezes(w http.ResponseWriter, r *http.Request) {
+	req := &models.LiftFreezeRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	lifted, err := s.Service.LiftTransferFreezes(*req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrInvalidFreeze):
+			status = http.StatusBadRequest
+		case errors.Is(err, repository.ErrNotFound):
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	for _, f := range lifted {
+		s.emitSecurityEvent(r, models.SecurityFreezeLifted, f.LiftedBy, f.Tenant, fmt.Sprintf("freeze %d: %s", f.ID, f.Reason))
+	}
+
+	writeResponse(w, r, lifted)
+}
+
+// ListTransferFreezes lists the freezes in force or, with ?history=true, the
+// most recent freezes whether or not they are still in force.
+func (s *Server) ListTransferFreezes(w http.ResponseWriter, r *http.Request) {
+	history := false
+	if v := r.URL.Query().Get("history"); v != "" {
+		var err error
+		if history, err = strconv.ParseBool(v); err != nil {
+			http.Error(w, "invalid history, expected true or false", http.StatusBadRequest)
+			return
+		}
+	}
+
+	freezes, err := s.Service.ListTransferFreezes(history)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeResponse(w, r, freezes)
+}
@@ -0,0 +1,82 @@
+package api_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/nehciyy/intrapay/internal/api"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository"
+	"github.com/nehciyy/intrapay/internal/service"
+)
+
+func TestTransferFreezes(t *testing.T) {
+	created := time.Date(2026, 3, 14, 9, 0, 0, 0, time.UTC)
+	freeze := models.TransferFreeze{ID: 1, Tenant: models.FreezeAllTenants, Reason: "suspected compromise", FrozenBy: "alice", CreatedAt: created, ExpiresAt: created.Add(time.Hour)}
+	var gotHistory bool
+	server := &api.Server{
+		Service: &mockService{
+			FreezeTransfersFn: func(req models.TransferFreezeRequest) (*models.TransferFreeze, error) {
+				if req.Duration != "1h" {
+					return nil, fmt.Errorf("%w: duration must be positive and at most 24h0m0s", service.ErrInvalidFreeze)
+				}
+				return &freeze, nil
+			},
+			LiftTransferFreezesFn: func(req models.LiftFreezeRequest) ([]models.TransferFreeze, error) {
+				if req.Tenant == "acme" {
+					return nil, fmt.Errorf("no freeze for tenant acme %w", repository.ErrNotFound)
+				}
+				return []models.TransferFreeze{freeze}, nil
+			},
+			ListTransferFreezesFn: func(history bool) ([]models.TransferFreeze, error) {
+				gotHistory = history
+				return []models.TransferFreeze{freeze}, nil
+			},
+		},
+	}
+	router := mux.NewRouter()
+	router.HandleFunc("/admin/freezes", server.FreezeTransfers).Methods("POST")
+	router.HandleFunc("/admin/freezes", server.ListTransferFreezes).Methods("GET")
+	router.HandleFunc("/admin/freezes/lift", server.LiftTransferFreezes).Methods("POST")
+
+	tests := []struct {
+		name, method, url, body string
+		expectedCode            int
+		expectedHistory         bool
+	}{
+		{"Freeze", "POST", "/admin/freezes", `{"duration": "1h", "reason": "suspected compromise", "operator": "alice"}`, http.StatusCreated, false},
+		{"Invalid Freeze", "POST", "/admin/freezes", `{"duration": "48h", "reason": "x", "operator": "alice"}`, http.StatusBadRequest, false},
+		{"Malformed Freeze", "POST", "/admin/freezes", `{`, http.StatusBadRequest, false},
+		{"Lift", "POST", "/admin/freezes/lift", `{"operator": "bob"}`, http.StatusOK, false},
+		{"Lift Unfrozen", "POST", "/admin/freezes/lift", `{"tenant": "acme", "operator": "bob"}`, http.StatusNotFound, false},
+		{"List", "GET", "/admin/freezes", "", http.StatusOK, false},
+		{"History", "GET", "/admin/freezes?history=true", "", http.StatusOK, true},
+		{"Invalid History", "GET", "/admin/freezes?history=maybe", "", http.StatusBadRequest, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotHistory = false
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.body)))
+			if rr.Code != tt.expectedCode {
+				t.Fatalf("expected %d, got %d: %s", tt.expectedCode, rr.Code, rr.Body.String())
+			}
+			if gotHistory != tt.expectedHistory {
+				t.Errorf("expected history %v, got %v", tt.expectedHistory, gotHistory)
+			}
+		})
+	}
+
+	t.Run("Body", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("POST", "/admin/freezes", strings.NewReader(`{"duration": "1h", "reason": "suspected compromise", "operator": "alice"}`)))
+		if body := rr.Body.String(); !strings.Contains(body, `"tenant":"*"`) || !strings.Contains(body, `"expires_at":"2026-03-14T10:00:00Z"`) {
+			t.Errorf("unexpected body %s", body)
+		}
+	})
+}
@@ -1,17 +1,54 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/nehciyy/intrapay/internal/db"
+	"github.com/nehciyy/intrapay/internal/forward"
+	"github.com/nehciyy/intrapay/internal/metering"
+	"github.com/nehciyy/intrapay/internal/metrics"
+	"github.com/nehciyy/intrapay/internal/middleware"
 	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository"
+	"github.com/nehciyy/intrapay/internal/security"
 	"github.com/nehciyy/intrapay/internal/service"
+	"github.com/nehciyy/intrapay/internal/signing"
+	"github.com/nehciyy/intrapay/internal/slo"
 )
 
 type Server struct {
 	Service service.Service
+	// Signer, when set, signs transaction creation responses; see Sign.
+	Signer *signing.Signer
+	// SLO, when set, counts transfers against the service level objectives.
+	SLO *slo.Tracker
+	// Security, when set, receives the security events of the handlers.
+	Security *security.Stream
+	// Forward, when set, accepts the transfers requested while the database
+	// is unreachable; see forwardTransaction.
+	Forward *forward.Queue
+}
+
+// readOnly returns the context of r hinted as a read-only, idempotent
+// operation: the repositories may serve it from a replica, a little behind the
+// primary, and retry it after a transient error.
+func readOnly(r *http.Request) context.Context {
+	return db.WithHints(r.Context(), db.ReadOnly|db.Idempotent)
+}
+
+// callerKey returns the API key r was made with, or "" if none.
+func callerKey(r *http.Request) string {
+	if apiKey, _ := metering.Caller(r); apiKey != metering.AnonymousKey {
+		return apiKey
+	}
+	return ""
 }
 
 func (s *Server) CreateAccount(w http.ResponseWriter, r *http.Request) {
@@ -22,12 +59,31 @@ func (s *Server) CreateAccount(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := s.Service.CreateAccount(req.AccountID, req.InitialBalance); err != nil {
+	// An omitted account_id asks the server to generate one.
+	if req.AccountID == 0 {
+		id, err := s.Service.NewAccountID()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		req.AccountID = id
+	}
+
+	// The account counts against the account limit of the caller's tenant, and
+	// belongs to the caller's API key.
+	_, tenant := metering.Caller(r)
+	account, err := s.Service.CreateAccount(r.Context(), req.AccountID, float64(req.InitialBalance), tenant, callerKey(r))
+	if errors.Is(err, service.ErrAccountLimitExceeded) {
+		writeError(w, r, http.StatusUnprocessableEntity, errorResponse{Error: err.Error(), Code: models.ReasonAccountLimitExceeded})
+		return
+	}
+	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(account)
 }
 
 func (s *Server) GetAccount(w http.ResponseWriter, r *http.Request) {
@@ -37,16 +93,256 @@ func (s *Server) GetAccount(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	balance, err := s.Service.GetAccount(id)
+	account, err := s.Service.GetAccount(readOnly(r), id)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
+	if notModified(w, r, account.UpdatedAt) {
+		return
+	}
 
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"account_id": id,
-		"balance":    balance,
-	})
+	writeResponse(w, r, account)
+}
+
+// ListAccounts pages through the open accounts in the order of their IDs,
+// filtered by ?min_balance= and ?max_balance=, both inclusive.
+func (s *Server) ListAccounts(w http.ResponseWriter, r *http.Request) {
+	var filter models.AccountFilter
+	for name, bound := range map[string]**models.Amount{"min_balance": &filter.MinBalance, "max_balance": &filter.MaxBalance} {
+		if v := r.URL.Query().Get(name); v != "" {
+			amount, err := models.ParseAmount(v)
+			if err != nil {
+				http.Error(w, "invalid "+name+", expected a decimal amount", http.StatusBadRequest)
+				return
+			}
+			*bound = &amount
+		}
+	}
+
+	pageReq, err := parsePageRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := s.Service.ListAccounts(readOnly(r), filter, pageReq.Cursor, pageReq.Limit)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInvalidCursor) || errors.Is(err, service.ErrInvalidAccountFilter) {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	setPageHeaders(w, r, page.NextCursor, page.HasMore, nil)
+	writeResponse(w, r, page.Accounts)
+}
+
+// SetDisplayName sets the name the account is shown by to the other side of its
+// transfers and returns the account.
+func (s *Server) SetDisplayName(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "invalid account ID", http.StatusBadRequest)
+		return
+	}
+
+	req := &models.DisplayNameRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	account, err := s.Service.SetDisplayName(r.Context(), id, req.DisplayName)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrInvalidDisplayName):
+			status = http.StatusBadRequest
+		case errors.Is(err, repository.ErrNotFound):
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	writeResponse(w, r, account)
+}
+
+// GetAccountSummary returns the balances of an account with its month-to-date
+// transfer totals, for account home screens. ?timezone= (an IANA name, UTC by
+// default) sets where the month starts.
+func (s *Server) GetAccountSummary(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "invalid account ID", http.StatusBadRequest)
+		return
+	}
+
+	loc, err := parseTimezone(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	summary, err := s.Service.AccountSummary(readOnly(r), id, loc)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, repository.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	writeResponse(w, r, summary)
+}
+
+// parseTimezone reads the IANA time zone totals are bucketed in from ?timezone=,
+// UTC by default. Timestamps in responses stay in UTC whatever the zone.
+func parseTimezone(r *http.Request) (*time.Location, error) {
+	name := r.URL.Query().Get("timezone")
+	if name == "" {
+		return time.UTC, nil
+	}
+	// LoadLocation also takes "Local", which would depend on the server.
+	loc, err := time.LoadLocation(name)
+	if err != nil || name == "Local" {
+		return nil, fmt.Errorf("invalid timezone %q, expected an IANA name such as Europe/Berlin", name)
+	}
+	return loc, nil
+}
+
+// ListAccountTransactions returns the transfers an account sent or received,
+// oldest-updated first and paginated by cursor, each with its counterparty.
+// Counterparty display names are masked; see ListAccountTransactionDetails.
+func (s *Server) ListAccountTransactions(w http.ResponseWriter, r *http.Request) {
+	s.listAccountTransactions(w, r, false)
+}
+
+func (s *Server) listAccountTransactions(w http.ResponseWriter, r *http.Request, unredacted bool) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "invalid account ID", http.StatusBadRequest)
+		return
+	}
+
+	pageReq, err := parsePageRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := s.Service.ListAccountTransactions(readOnly(r), id, pageReq.Cursor, pageReq.Limit, unredacted)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrInvalidCursor):
+			status = http.StatusBadRequest
+		case errors.Is(err, repository.ErrNotFound):
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	setPageHeaders(w, r, page.NextCursor, page.HasMore, nil)
+	writeResponse(w, r, page.Transactions)
+}
+
+// HeadAccount answers 200 if the account exists and 404 otherwise, without a body.
+func (s *Server) HeadAccount(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	exists, err := s.Service.AccountExists(readOnly(r), id)
+	switch {
+	case err != nil:
+		w.WriteHeader(http.StatusInternalServerError)
+	case !exists:
+		w.WriteHeader(http.StatusNotFound)
+	default:
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// accountExistence is the body of GET /accounts/{id}/exists.
+type accountExistence struct {
+	AccountID int64 `json:"account_id"`
+	Exists    bool  `json:"exists"`
+}
+
+// AccountExists is a cheap existence check for high-volume validators. It always
+// answers 200 with the result in the body.
+func (s *Server) AccountExists(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "invalid account ID", http.StatusBadRequest)
+		return
+	}
+
+	exists, err := s.Service.AccountExists(readOnly(r), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(accountExistence{AccountID: id, Exists: exists})
+}
+
+// DeleteAccount soft deletes an account. It can be restored through the admin API.
+func (s *Server) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "invalid account ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.Service.DeleteAccount(r.Context(), id); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, repository.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// TransactionCapabilities is the OPTIONS /transactions body describing the
+// transfers POST /transactions accepts. The amount bounds are those of the
+// caller's tenant.
+func (s *Server) TransactionCapabilities(w http.ResponseWriter, r *http.Request) {
+	caps := models.TransactionCapabilities{
+		Currencies: []models.Currency{models.CurrentCurrency()},
+	}
+	_, tenant := metering.Caller(r)
+	bounds := s.Service.AmountBoundsOf(tenant)
+	if bounds.MinAmount != 0 {
+		caps.MinAmount = &bounds.MinAmount
+	}
+	if bounds.MaxAmount != 0 {
+		caps.MaxAmount = &bounds.MaxAmount
+	}
+	writeResponse(w, r, caps)
+}
+
+// ListReasonCodes serves the catalog of reason codes returned in error responses
+// and stored on adjustments.
+func (s *Server) ListReasonCodes(w http.ResponseWriter, r *http.Request) {
+	writeResponse(w, r, models.ReasonCatalog())
+}
+
+// transactionCreated is the body of a created transfer.
+type transactionCreated struct {
+	Message       string `json:"message"`
+	TransactionID string `json:"transaction_id"`
 }
 
 func (s *Server) CreateTransaction(w http.ResponseWriter, r *http.Request) {
@@ -57,15 +353,324 @@ func (s *Server) CreateTransaction(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	transactionID, err := s.Service.CreateTransaction(req.SourceAccountID, req.DestinationAccountID, req.Amount)
+	var quotaErr *metering.QuotaError
+	if err := metering.CheckVolume(r.Context(), float64(req.Amount)); errors.As(err, &quotaErr) {
+		s.recordRejection(r, *req, quotaErr.Code, quotaErr)
+		writeError(w, r, http.StatusTooManyRequests, errorResponse{Error: quotaErr.Error(), Code: quotaErr.Code})
+		return
+	}
+	if s.Forward != nil && s.Forward.Len() > 0 {
+		// Transfers are waiting on the store-and-forward queue: this one waits
+		// behind them, so that they are made in the order they were requested.
+		s.forwardTransaction(w, r, *req, nil)
+		return
+	}
+	if key := r.Header.Get(middleware.IdempotencyKeyHeader); key != "" && s.Forward != nil {
+		// A retry of a transfer accepted into a store-and-forward queue, here
+		// or by another server, and since replayed is answered with its outcome
+		// rather than made again.
+		apiKey, _ := metering.Caller(r)
+		if status, err := s.Service.FindForwardedTransfer(apiKey, key); err == nil {
+			writeForwarded(w, status)
+			return
+		}
+	}
+
+	start := time.Now()
+	transactionID, err := s.Service.CreateTransaction(r.Context(), req.SourceAccountID, req.DestinationAccountID, float64(req.Amount), req.Tags)
+	if errors.Is(err, service.ErrInvalidTags) {
+		// Refused before any transfer was attempted.
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	outcome, elapsed := transactionOutcome(err), time.Since(start)
+	metrics.ObserveTransaction(outcome, elapsed, traceID(r))
+	if s.SLO != nil {
+		s.SLO.Record(outcome, elapsed)
+	}
+	if repository.IsUnavailable(err) && s.Forward != nil {
+		s.forwardTransaction(w, r, *req, err)
+		return
+	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.recordRejection(r, *req, service.RejectionReason(err), err)
+		writeTransferError(w, r, err)
 		return
 	}
+	metering.AddVolume(r.Context(), float64(req.Amount))
 
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(map[string]string{
-		"message":        "Transaction successfully processed",
-		"transaction_id": transactionID,
+	json.NewEncoder(w).Encode(transactionCreated{Message: "Transaction successfully processed", TransactionID: transactionID})
+}
+
+// writeTransferError answers a failed transfer. Rejections are reported with
+// their reason code; anything else is a 500.
+func writeTransferError(w http.ResponseWriter, r *http.Request, err error) {
+	code := service.RejectionReason(err)
+	switch code {
+	case "":
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	case models.ReasonConcurrencyConflict:
+		writeRetryable(w, r, http.StatusConflict, err.Error(), code, retryExhaustedBackoff)
+	case models.ReasonRegionPassive:
+		writeRetryable(w, r, http.StatusServiceUnavailable, err.Error(), code, regionPassiveBackoff)
+	case models.ReasonAccountNotFound, models.ReasonDestinationNotFound, models.ReasonTokenNotFound:
+		writeError(w, r, http.StatusNotFound, errorResponse{Error: err.Error(), Code: code})
+	case models.ReasonTransfersFrozen:
+		writeError(w, r, http.StatusForbidden, errorResponse{Error: err.Error(), Code: code})
+	default:
+		writeError(w, r, http.StatusUnprocessableEntity, errorResponse{Error: err.Error(), Code: code})
+	}
+}
+
+// recordRejection stores a refused transfer with the caller that requested it
+// and names its reason code as the outcome in the access log. Failures that are
+// not rejections, e.g. of the database, have no reason code and are not recorded.
+func (s *Server) recordRejection(r *http.Request, req models.TransactionRequest, code models.ReasonCode, err error) {
+	if code == "" {
+		return
+	}
+	middleware.SetOutcome(r, string(code))
+	apiKey, tenant := metering.Caller(r)
+	s.Service.RecordTransactionAttempt(models.TransactionAttempt{
+		SourceAccountID:      req.SourceAccountID,
+		DestinationAccountID: req.DestinationAccountID,
+		Amount:               req.Amount,
+		Code:                 code,
+		Error:                err.Error(),
+		APIKey:               apiKey,
+		Tenant:               tenant,
+		RequestID:            r.Header.Get(RequestIDHeader),
 	})
 }
+
+// ReceivePayment credits an inbound payment. A payment whose destination is
+// unknown or closed is parked on the suspense account and answered with 202
+// Accepted and the suspense item.
+func (s *Server) ReceivePayment(w http.ResponseWriter, r *http.Request) {
+	req := &models.InboundPaymentRequest{}
+
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var quotaErr *metering.QuotaError
+	if err := metering.CheckVolume(r.Context(), float64(req.Amount)); errors.As(err, &quotaErr) {
+		s.recordRejection(r, req.TransactionRequest, quotaErr.Code, quotaErr)
+		writeError(w, r, http.StatusTooManyRequests, errorResponse{Error: quotaErr.Error(), Code: quotaErr.Code})
+		return
+	}
+
+	start := time.Now()
+	payment, err := s.Service.ReceivePayment(r.Context(), req.SourceAccountID, req.DestinationAccountID, float64(req.Amount), req.Reference)
+	metrics.ObserveTransaction(transactionOutcome(err), time.Since(start), traceID(r))
+	if err != nil {
+		s.recordRejection(r, req.TransactionRequest, service.RejectionReason(err), err)
+		writeTransferError(w, r, err)
+		return
+	}
+	metering.AddVolume(r.Context(), float64(req.Amount))
+
+	if payment.SuspenseItem != nil {
+		w.WriteHeader(http.StatusAccepted)
+	} else {
+		w.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(w).Encode(payment)
+}
+
+// GetTransaction returns one transaction, looked up by its transaction_ref or
+// its serial ID.
+func (s *Server) GetTransaction(w http.ResponseWriter, r *http.Request) {
+	transaction, err := s.Service.GetTransaction(readOnly(r), mux.Vars(r)["id"])
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, repository.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	if notModified(w, r, transaction.UpdatedAt) {
+		return
+	}
+
+	writeResponse(w, r, transaction)
+}
+
+// SetTransactionTags replaces the tags of a transaction with the body's "tags"
+// and returns the transaction. An empty list removes them all.
+func (s *Server) SetTransactionTags(w http.ResponseWriter, r *http.Request) {
+	req := &models.TransactionTagsRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	transaction, err := s.Service.SetTransactionTags(r.Context(), mux.Vars(r)["id"], req.Tags)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrInvalidTags):
+			status = http.StatusBadRequest
+		case errors.Is(err, repository.ErrNotFound):
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	writeResponse(w, r, transaction)
+}
+
+// ListAccountTags returns the tags of an account's transfers with how many
+// transfers carry each, in lexical order.
+func (s *Server) ListAccountTags(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "invalid account ID", http.StatusBadRequest)
+		return
+	}
+
+	tags, err := s.Service.ListAccountTags(readOnly(r), id)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, repository.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	writeResponse(w, r, tags)
+}
+
+// LookupTransactions returns the transactions named in the body's "ids", each a
+// transaction_ref or serial ID, with those not found listed apart, so that
+// reconciliation jobs need not fetch them one by one.
+func (s *Server) LookupTransactions(w http.ResponseWriter, r *http.Request) {
+	req := &models.TransactionLookupRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	lookup, err := s.Service.LookupTransactions(readOnly(r), req.IDs)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInvalidLookup) {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	writeResponse(w, r, lookup)
+}
+
+// ListTransactions returns transactions oldest-updated first, paginated by cursor
+// (see pagination.go). ?updated_since= (RFC 3339) restricts the listing to
+// transactions changed after that time, and ?tag= to those marked with a tag.
+func (s *Server) ListTransactions(w http.ResponseWriter, r *http.Request) {
+	filter := models.TransactionFilter{Tag: r.URL.Query().Get("tag")}
+	if v := r.URL.Query().Get("updated_since"); v != "" {
+		var err error
+		if filter.UpdatedSince, err = time.Parse(time.RFC3339Nano, v); err != nil {
+			http.Error(w, "invalid updated_since, expected RFC 3339 timestamp", http.StatusBadRequest)
+			return
+		}
+	}
+
+	pageReq, err := parsePageRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := s.Service.ListTransactions(readOnly(r), filter, pageReq.Cursor, pageReq.Limit)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInvalidCursor) || errors.Is(err, service.ErrInvalidTags) {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	var total *int64
+	if pageReq.IncludeTotal {
+		count, err := s.Service.CountTransactions(readOnly(r), filter)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		total = &count
+	}
+
+	setPageHeaders(w, r, page.NextCursor, page.HasMore, total)
+	writeResponse(w, r, page.Transactions)
+}
+
+// transactionChanges is the body of the change feed. Transactions holds the
+// transactions as ?fields= selected them.
+type transactionChanges struct {
+	Transactions interface{} `json:"transactions"`
+	NextCursor   string      `json:"next_cursor"`
+	HasMore      bool        `json:"has_more"`
+}
+
+// SyncTransactions serves the transaction change feed. Pass the next_cursor from
+// the previous response as ?cursor= to receive only changes since then. Unlike
+// plain listings the cursor is also returned in the body, since feed clients
+// keep polling from it after reaching the end.
+func (s *Server) SyncTransactions(w http.ResponseWriter, r *http.Request) {
+	pageReq, err := parsePageRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	changes, err := s.Service.SyncTransactions(readOnly(r), pageReq.Cursor, pageReq.Limit)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInvalidCursor) {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	// ?fields= applies to the transactions; the cursor envelope is always returned.
+	transactions, err := selectFields(changes.Transactions, requestedFields(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	setPageHeaders(w, r, changes.NextCursor, changes.HasMore, nil)
+	json.NewEncoder(w).Encode(transactionChanges{Transactions: transactions, NextCursor: changes.NextCursor, HasMore: changes.HasMore})
+}
+
+// transactionOutcome maps a CreateTransaction error to its metrics outcome label.
+func transactionOutcome(err error) string {
+	switch {
+	case err == nil:
+		return metrics.OutcomeSuccess
+	case errors.Is(err, service.ErrInsufficientBalance):
+		return metrics.OutcomeInsufficientFunds
+	case errors.Is(err, service.ErrRetriesExhausted):
+		return metrics.OutcomeRetryExhausted
+	case errors.Is(err, service.ErrDestinationNotFound), errors.Is(err, service.ErrLivemodeMismatch):
+		return metrics.OutcomeDestNotFound
+	case errors.Is(err, repository.ErrNotFound):
+		return metrics.OutcomeSourceNotFound
+	case repository.IsRegionFenced(err):
+		return metrics.OutcomeRegionPassive
+	case errors.Is(err, service.ErrTransfersFrozen):
+		return metrics.OutcomeTransfersFrozen
+	case errors.Is(err, service.ErrAmountOutOfBounds):
+		return metrics.OutcomeAmountOutOfBounds
+	default:
+		return metrics.OutcomeError
+	}
+}
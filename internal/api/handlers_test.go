@@ -2,203 +2,1609 @@ package api_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"log"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/lib/pq"
 	"github.com/nehciyy/intrapay/internal/api"
+	"github.com/nehciyy/intrapay/internal/clock"
+	"github.com/nehciyy/intrapay/internal/db"
+	"github.com/nehciyy/intrapay/internal/metering"
 	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository"
+	"github.com/nehciyy/intrapay/internal/sandbox"
+	"github.com/nehciyy/intrapay/internal/service"
 )
 
 type mockService struct {
-	CreateAccountFn     func(id int64, balance float64) error
-	GetAccountFn        func(id int64) (float64, error)
-	CreateTransactionFn func(from, to int64, amount float64) (string, error)
+	CreateAccountFn     func(id int64, balance float64, tenant, owner string) (*models.Account, error)
+	NewAccountIDFn      func() (int64, error)
+	GetAccountFn        func(id int64) (*models.Account, error)
+	AccountExistsFn     func(id int64) (bool, error)
+	ListAccountsFn      func(filter models.AccountFilter, cursor string, limit int) (*models.AccountPage, error)
+	CreateTransactionFn func(from, to int64, amount float64, tags []string) (string, error)
+	RecomputeBalanceFn  func(id int64, apply bool, code models.ReasonCode, reason string) (*models.BalanceRecompute, error)
+	DeleteAccountFn     func(id int64) error
+	RestoreAccountFn    func(id int64) (*models.Account, error)
+	GetAccountDetailsFn func(id int64, includeDeleted bool) (*models.Account, error)
+	GetTransactionFn    func(id string) (*models.Transaction, error)
+	ListTransactionsFn  func(filter models.TransactionFilter, cursor string, limit int) (*models.TransactionPage, error)
+	CountTransactionsFn func(filter models.TransactionFilter) (int64, error)
+	SyncTransactionsFn  func(cursor string, limit int) (*models.TransactionPage, error)
+	UsageReportFn       func(period string) ([]models.Usage, error)
+	SetQuotaFn          func(q models.Quota) (*models.Quota, error)
+	GetQuotaFn          func(scope models.QuotaScope, subject string) (*models.QuotaStatus, error)
+	ListQuotasFn        func() ([]models.Quota, error)
+	DeleteQuotaFn       func(scope models.QuotaScope, subject string) error
+
+	SetAccountLimitFn    func(tenant string, maxAccounts int64) (*models.AccountLimit, error)
+	GetAccountLimitFn    func(tenant string) (*models.AccountLimitStatus, error)
+	DeleteAccountLimitFn func(tenant string) error
+
+	SetTopUpRuleFn    func(accountID int64, req models.SetTopUpRuleRequest) (*models.TopUpRule, error)
+	GetTopUpRuleFn    func(accountID int64) (*models.TopUpRule, error)
+	DeleteTopUpRuleFn func(accountID int64) error
+
+	TreasuryPositionsFn func() (*models.TreasuryPositions, error)
+	LargestAccountsFn   func(limit int) ([]models.TreasuryAccount, error)
+	DailyFlowsFn        func(days int, loc *time.Location) (*models.TreasuryFlows, error)
+
+	PutFXRateFn        func(date string, req models.FXRateRequest) (*models.FXRate, error)
+	RevalueFn          func(date string) (*models.Revaluation, error)
+	GetRevaluationFn   func(id int64) (*models.Revaluation, error)
+	ListRevaluationsFn func(limit int) ([]models.Revaluation, error)
+	PurgeTestDataFn    func() (*models.SandboxPurge, error)
+	FXRatesFn          func(currencies []string) ([]models.FXQuote, error)
+
+	CreateCashbackCampaignFn func(req models.CashbackCampaignRequest) (*models.CashbackCampaign, error)
+	UpdateCashbackCampaignFn func(id int64, req models.CashbackCampaignRequest) (*models.CashbackCampaign, error)
+	GetCashbackCampaignFn    func(id int64) (*models.CashbackCampaign, error)
+	ListCashbackCampaignsFn  func() ([]models.CashbackCampaign, error)
+	DeleteCashbackCampaignFn func(id int64) error
+
+	CreateSpendingTokenFn func(accountID int64, req models.SpendingTokenRequest) (*models.SpendingToken, error)
+	GetSpendingTokenFn    func(id string) (*models.SpendingToken, error)
+	ListSpendingTokensFn  func(accountID int64) ([]models.SpendingToken, error)
+	RevokeSpendingTokenFn func(id string) (*models.SpendingToken, error)
+	DebitWithTokenFn      func(id string, req models.TokenDebitRequest) (*models.TokenDebit, error)
+
+	LookupTransactionsFn func(ids []string) (*models.TransactionLookup, error)
+
+	SetTransactionTagsFn func(id string, tags []string) (*models.Transaction, error)
+	ListAccountTagsFn    func(accountID int64) ([]models.TransactionTag, error)
+	AccountTimelineFn    func(id int64, cursor string, limit int, includeDeleted bool) (*models.AccountTimeline, error)
+
+	SetDisplayNameFn          func(id int64, name string) (*models.Account, error)
+	AccountSummaryFn          func(id int64, loc *time.Location) (*models.AccountSummary, error)
+	ListAccountTransactionsFn func(id int64, cursor string, limit int, unredacted bool) (*models.TransactionPage, error)
+
+	ListPendingActionsFn  func(filter models.PendingActionFilter, cursor string, limit int) (*models.PendingActionPage, error)
+	CountPendingActionsFn func(filter models.PendingActionFilter) (int64, error)
+	ClaimPendingActionFn  func(id int64, assignee string) (*models.PendingAction, error)
+	AssignPendingActionFn func(id int64, assignee string) (*models.PendingAction, error)
+
+	PreviewPayrollFn         func(req models.PayrollRequest) (*models.PayrollPreview, error)
+	CommitPayrollFn          func(req models.PayrollRequest) (*models.PayrollResult, error)
+	IngestTransfersFn        func(transfers []models.IngestTransfer) (*models.IngestResult, error)
+	CreateTransactionBatchFn func(req models.TransactionBatchRequest) (*models.TransactionBatchResult, error)
+
+	ReceivePaymentFn     func(sourceID, destID int64, amount float64, reference string) (*models.InboundPayment, error)
+	ListSuspenseItemsFn  func(cursor string, limit int) (*models.SuspenseItemPage, error)
+	RepostSuspenseItemFn func(id, accountID int64) (*models.SuspenseItem, error)
+
+	SubmitReimbursementFn  func(tenant string, req models.ReimbursementRequest) (*models.Reimbursement, error)
+	GetReimbursementFn     func(id int64, tenant string) (*models.Reimbursement, error)
+	ApproveReimbursementFn func(id int64, d models.ReimbursementDecision) (*models.Reimbursement, error)
+	RejectReimbursementFn  func(id int64, d models.ReimbursementDecision) (*models.Reimbursement, error)
+
+	RecordTransactionAttemptFn func(a models.TransactionAttempt)
+	ListTransactionAttemptsFn  func(filter models.TransactionAttemptFilter, cursor string, limit int) (*models.TransactionAttemptPage, error)
+	CountTransactionAttemptsFn func(filter models.TransactionAttemptFilter) (int64, error)
+	TransactionAttemptStatsFn  func(groupBy models.AttemptGrouping, filter models.TransactionAttemptFilter) ([]models.TransactionAttemptStat, error)
+	LookupRequestFn            func(requestID string) (*models.RequestRecord, error)
+	ReplayTransactionAttemptFn func(id int64, apply bool) (*models.TransactionAttemptReplay, error)
+	ResumeTransferIntentsFn    func(before time.Time) (int, error)
+
+	ReplayForwardedTransferFn func(f models.ForwardedTransfer) (*models.ForwardedTransferStatus, error)
+	ExpireForwardedTransferFn func(f models.ForwardedTransfer) (*models.ForwardedTransferStatus, error)
+	GetForwardedTransferFn    func(id string) (*models.ForwardedTransferStatus, error)
+	FindForwardedTransferFn   func(apiKey, idempotencyKey string) (*models.ForwardedTransferStatus, error)
+
+	RecordImpersonationFn func(i models.Impersonation) error
+	FreezeTransfersFn     func(req models.TransferFreezeRequest) (*models.TransferFreeze, error)
+	LiftTransferFreezesFn func(req models.LiftFreezeRequest) ([]models.TransferFreeze, error)
+	ListTransferFreezesFn func(history bool) ([]models.TransferFreeze, error)
+	AmountBoundsOfFn      func(tenant string) models.AmountBounds
+	SetAmountBoundsFn     func(tenant string, req models.SetAmountBoundsRequest) (*models.AmountBounds, error)
+	ListAmountBoundsFn    func() ([]models.AmountBounds, error)
+	DeleteAmountBoundsFn  func(tenant string) error
+	ListImpersonationsFn  func(filter models.ImpersonationFilter, cursor string, limit int) (*models.ImpersonationPage, error)
+	ListSecurityEventsFn  func(afterID int64, limit int) (*models.SecurityEventPage, error)
+	IndexAdvisoryFn       func() (*models.IndexAdvisory, error)
+
+	OutboxRelayStatusFn func() (*models.OutboxRelayStatus, error)
+	PauseOutboxRelayFn  func() (*models.OutboxRelayStatus, error)
+	ResumeOutboxRelayFn func() (*models.OutboxRelayStatus, error)
+	ReplayOutboxFn      func(afterID int64) (*models.OutboxRelayStatus, error)
+	ListEventsFn        func(afterID int64, limit int) (*models.EventPage, error)
+
+	RegisterWebhookFn func(tenant, apiKey string, req models.WebhookRequest) (*models.Webhook, error)
+	GetWebhookFn      func(id int64, tenant string) (*models.Webhook, error)
+	ListWebhooksFn    func(tenant string) ([]models.Webhook, error)
+	DeleteWebhookFn   func(id int64, tenant string) error
+	PingWebhookFn     func(id int64, tenant string) (*models.WebhookPing, error)
+
+	GetNotificationPreferenceFn func(tenant, apiKey string, accountID int64) (*models.NotificationPreference, error)
+	SetNotificationPreferenceFn func(tenant, apiKey string, accountID int64, req models.NotificationPreferenceRequest) (*models.NotificationPreference, error)
+	SendDailyDigestsFn          func() (int, error)
+
+	RegionStatusFn  func() (*models.RegionStatus, error)
+	PromoteRegionFn func(region string, epoch int64) (*models.RegionStatus, error)
+
+	// hints are the database hints of the context of the last read.
+	hints db.Hint
+	// livemode is the mode of the context of the last account read or transfer.
+	livemode bool
+}
+
+func (m *mockService) CreateAccount(ctx context.Context, id int64, balance float64, tenant, owner string) (*models.Account, error) {
+	return m.CreateAccountFn(id, balance, tenant, owner)
+}
+
+func (m *mockService) NewAccountID() (int64, error) {
+	return m.NewAccountIDFn()
+}
+
+func (m *mockService) GetAccount(ctx context.Context, id int64) (*models.Account, error) {
+	m.hints, m.livemode = db.HintsFrom(ctx), sandbox.Livemode(ctx)
+	return m.GetAccountFn(id)
+}
+
+func (m *mockService) AccountExists(ctx context.Context, id int64) (bool, error) {
+	m.hints = db.HintsFrom(ctx)
+	return m.AccountExistsFn(id)
+}
+
+func (m *mockService) ListAccounts(ctx context.Context, filter models.AccountFilter, cursor string, limit int) (*models.AccountPage, error) {
+	m.hints = db.HintsFrom(ctx)
+	return m.ListAccountsFn(filter, cursor, limit)
+}
+
+func (m *mockService) CreateTransaction(ctx context.Context, from, to int64, amount float64, tags []string) (string, error) {
+	m.livemode = sandbox.Livemode(ctx)
+	return m.CreateTransactionFn(from, to, amount, tags)
+}
+
+func (m *mockService) RecomputeBalance(id int64, apply bool, code models.ReasonCode, reason string) (*models.BalanceRecompute, error) {
+	return m.RecomputeBalanceFn(id, apply, code, reason)
+}
+
+func (m *mockService) DeleteAccount(ctx context.Context, id int64) error {
+	return m.DeleteAccountFn(id)
+}
+
+func (m *mockService) RestoreAccount(id int64) (*models.Account, error) {
+	return m.RestoreAccountFn(id)
+}
+
+func (m *mockService) GetAccountDetails(id int64, includeDeleted bool) (*models.Account, error) {
+	return m.GetAccountDetailsFn(id, includeDeleted)
+}
+
+func (m *mockService) SyncTransactions(ctx context.Context, cursor string, limit int) (*models.TransactionPage, error) {
+	m.hints = db.HintsFrom(ctx)
+	return m.SyncTransactionsFn(cursor, limit)
+}
+
+func (m *mockService) UsageReport(period string) ([]models.Usage, error) {
+	return m.UsageReportFn(period)
+}
+
+func (m *mockService) SetQuota(q models.Quota) (*models.Quota, error) {
+	return m.SetQuotaFn(q)
+}
+
+func (m *mockService) GetQuota(scope models.QuotaScope, subject string) (*models.QuotaStatus, error) {
+	return m.GetQuotaFn(scope, subject)
+}
+
+func (m *mockService) ListQuotas() ([]models.Quota, error) {
+	return m.ListQuotasFn()
+}
+
+func (m *mockService) DeleteQuota(scope models.QuotaScope, subject string) error {
+	return m.DeleteQuotaFn(scope, subject)
+}
+
+func (m *mockService) SetAccountLimit(tenant string, maxAccounts int64) (*models.AccountLimit, error) {
+	return m.SetAccountLimitFn(tenant, maxAccounts)
+}
+
+func (m *mockService) GetAccountLimit(tenant string) (*models.AccountLimitStatus, error) {
+	return m.GetAccountLimitFn(tenant)
+}
+
+func (m *mockService) DeleteAccountLimit(tenant string) error {
+	return m.DeleteAccountLimitFn(tenant)
+}
+
+func (m *mockService) SetTopUpRule(accountID int64, req models.SetTopUpRuleRequest) (*models.TopUpRule, error) {
+	return m.SetTopUpRuleFn(accountID, req)
+}
+
+func (m *mockService) GetTopUpRule(accountID int64) (*models.TopUpRule, error) {
+	return m.GetTopUpRuleFn(accountID)
+}
+
+func (m *mockService) DeleteTopUpRule(accountID int64) error {
+	return m.DeleteTopUpRuleFn(accountID)
+}
+
+func (m *mockService) TreasuryPositions(ctx context.Context) (*models.TreasuryPositions, error) {
+	return m.TreasuryPositionsFn()
+}
+
+func (m *mockService) LargestAccounts(ctx context.Context, limit int) ([]models.TreasuryAccount, error) {
+	return m.LargestAccountsFn(limit)
+}
+
+func (m *mockService) DailyFlows(ctx context.Context, days int, loc *time.Location) (*models.TreasuryFlows, error) {
+	return m.DailyFlowsFn(days, loc)
+}
+
+func (m *mockService) PutFXRate(date string, req models.FXRateRequest) (*models.FXRate, error) {
+	return m.PutFXRateFn(date, req)
+}
+
+func (m *mockService) Revalue(date string) (*models.Revaluation, error) {
+	return m.RevalueFn(date)
+}
+
+func (m *mockService) GetRevaluation(ctx context.Context, id int64) (*models.Revaluation, error) {
+	return m.GetRevaluationFn(id)
+}
+
+func (m *mockService) ListRevaluations(ctx context.Context, limit int) ([]models.Revaluation, error) {
+	return m.ListRevaluationsFn(limit)
+}
+
+func (m *mockService) PurgeTestData() (*models.SandboxPurge, error) {
+	return m.PurgeTestDataFn()
+}
+
+func (m *mockService) FXRates(ctx context.Context, currencies []string) ([]models.FXQuote, error) {
+	return m.FXRatesFn(currencies)
+}
+
+func (m *mockService) CreateCashbackCampaign(req models.CashbackCampaignRequest) (*models.CashbackCampaign, error) {
+	return m.CreateCashbackCampaignFn(req)
+}
+
+func (m *mockService) UpdateCashbackCampaign(id int64, req models.CashbackCampaignRequest) (*models.CashbackCampaign, error) {
+	return m.UpdateCashbackCampaignFn(id, req)
+}
+
+func (m *mockService) GetCashbackCampaign(id int64) (*models.CashbackCampaign, error) {
+	return m.GetCashbackCampaignFn(id)
+}
+
+func (m *mockService) ListCashbackCampaigns() ([]models.CashbackCampaign, error) {
+	return m.ListCashbackCampaignsFn()
+}
+
+func (m *mockService) DeleteCashbackCampaign(id int64) error {
+	return m.DeleteCashbackCampaignFn(id)
+}
+
+func (m *mockService) CreateSpendingToken(ctx context.Context, accountID int64, req models.SpendingTokenRequest) (*models.SpendingToken, error) {
+	return m.CreateSpendingTokenFn(accountID, req)
+}
+
+func (m *mockService) GetSpendingToken(ctx context.Context, id string) (*models.SpendingToken, error) {
+	return m.GetSpendingTokenFn(id)
+}
+
+func (m *mockService) ListSpendingTokens(ctx context.Context, accountID int64) ([]models.SpendingToken, error) {
+	return m.ListSpendingTokensFn(accountID)
+}
+
+func (m *mockService) RevokeSpendingToken(ctx context.Context, id string) (*models.SpendingToken, error) {
+	return m.RevokeSpendingTokenFn(id)
+}
+
+func (m *mockService) DebitWithToken(ctx context.Context, id string, req models.TokenDebitRequest) (*models.TokenDebit, error) {
+	return m.DebitWithTokenFn(id, req)
+}
+
+func (m *mockService) ListPendingActions(filter models.PendingActionFilter, cursor string, limit int) (*models.PendingActionPage, error) {
+	return m.ListPendingActionsFn(filter, cursor, limit)
+}
+
+func (m *mockService) CountPendingActions(filter models.PendingActionFilter) (int64, error) {
+	return m.CountPendingActionsFn(filter)
+}
+
+func (m *mockService) ClaimPendingAction(id int64, assignee string) (*models.PendingAction, error) {
+	return m.ClaimPendingActionFn(id, assignee)
+}
+
+func (m *mockService) AssignPendingAction(id int64, assignee string) (*models.PendingAction, error) {
+	return m.AssignPendingActionFn(id, assignee)
+}
+
+func (m *mockService) PreviewPayroll(ctx context.Context, req models.PayrollRequest) (*models.PayrollPreview, error) {
+	return m.PreviewPayrollFn(req)
+}
+
+func (m *mockService) CommitPayroll(ctx context.Context, req models.PayrollRequest) (*models.PayrollResult, error) {
+	return m.CommitPayrollFn(req)
+}
+
+func (m *mockService) IngestTransfers(transfers []models.IngestTransfer) (*models.IngestResult, error) {
+	return m.IngestTransfersFn(transfers)
+}
+
+func (m *mockService) CreateTransactionBatch(ctx context.Context, req models.TransactionBatchRequest) (*models.TransactionBatchResult, error) {
+	m.livemode = sandbox.Livemode(ctx)
+	return m.CreateTransactionBatchFn(req)
+}
+
+func (m *mockService) SubmitReimbursement(ctx context.Context, tenant string, req models.ReimbursementRequest) (*models.Reimbursement, error) {
+	return m.SubmitReimbursementFn(tenant, req)
+}
+
+func (m *mockService) GetReimbursement(ctx context.Context, id int64, tenant string) (*models.Reimbursement, error) {
+	return m.GetReimbursementFn(id, tenant)
+}
+
+func (m *mockService) ApproveReimbursement(id int64, d models.ReimbursementDecision) (*models.Reimbursement, error) {
+	return m.ApproveReimbursementFn(id, d)
+}
+
+func (m *mockService) RejectReimbursement(id int64, d models.ReimbursementDecision) (*models.Reimbursement, error) {
+	return m.RejectReimbursementFn(id, d)
+}
+
+func (m *mockService) ReceivePayment(ctx context.Context, sourceID, destID int64, amount float64, reference string) (*models.InboundPayment, error) {
+	return m.ReceivePaymentFn(sourceID, destID, amount, reference)
+}
+
+func (m *mockService) ListSuspenseItems(cursor string, limit int) (*models.SuspenseItemPage, error) {
+	return m.ListSuspenseItemsFn(cursor, limit)
+}
+
+func (m *mockService) RepostSuspenseItem(id, accountID int64) (*models.SuspenseItem, error) {
+	return m.RepostSuspenseItemFn(id, accountID)
+}
+
+func (m *mockService) RecordTransactionAttempt(a models.TransactionAttempt) {
+	if m.RecordTransactionAttemptFn != nil {
+		m.RecordTransactionAttemptFn(a)
+	}
+}
+
+func (m *mockService) ListTransactionAttempts(filter models.TransactionAttemptFilter, cursor string, limit int) (*models.TransactionAttemptPage, error) {
+	return m.ListTransactionAttemptsFn(filter, cursor, limit)
+}
+
+func (m *mockService) CountTransactionAttempts(filter models.TransactionAttemptFilter) (int64, error) {
+	return m.CountTransactionAttemptsFn(filter)
+}
+
+func (m *mockService) TransactionAttemptStats(groupBy models.AttemptGrouping, filter models.TransactionAttemptFilter) ([]models.TransactionAttemptStat, error) {
+	return m.TransactionAttemptStatsFn(groupBy, filter)
+}
+
+func (m *mockService) LookupRequest(requestID string) (*models.RequestRecord, error) {
+	return m.LookupRequestFn(requestID)
+}
+
+func (m *mockService) ReplayTransactionAttempt(id int64, apply bool) (*models.TransactionAttemptReplay, error) {
+	return m.ReplayTransactionAttemptFn(id, apply)
+}
+
+func (m *mockService) ResumeTransferIntents(before time.Time) (int, error) {
+	return m.ResumeTransferIntentsFn(before)
+}
+
+func (m *mockService) ReplayForwardedTransfer(f models.ForwardedTransfer) (*models.ForwardedTransferStatus, error) {
+	return m.ReplayForwardedTransferFn(f)
+}
+
+func (m *mockService) ExpireForwardedTransfer(f models.ForwardedTransfer) (*models.ForwardedTransferStatus, error) {
+	return m.ExpireForwardedTransferFn(f)
+}
+
+func (m *mockService) GetForwardedTransfer(ctx context.Context, id string) (*models.ForwardedTransferStatus, error) {
+	return m.GetForwardedTransferFn(id)
+}
+
+func (m *mockService) FindForwardedTransfer(apiKey, idempotencyKey string) (*models.ForwardedTransferStatus, error) {
+	return m.FindForwardedTransferFn(apiKey, idempotencyKey)
+}
+
+func (m *mockService) RecordImpersonation(i models.Impersonation) error {
+	return m.RecordImpersonationFn(i)
+}
+
+func (m *mockService) FreezeTransfers(req models.TransferFreezeRequest) (*models.TransferFreeze, error) {
+	return m.FreezeTransfersFn(req)
+}
+
+func (m *mockService) LiftTransferFreezes(req models.LiftFreezeRequest) ([]models.TransferFreeze, error) {
+	return m.LiftTransferFreezesFn(req)
+}
+
+func (m *mockService) ListTransferFreezes(history bool) ([]models.TransferFreeze, error) {
+	return m.ListTransferFreezesFn(history)
+}
+
+func (m *mockService) AmountBoundsOf(tenant string) models.AmountBounds {
+	return m.AmountBoundsOfFn(tenant)
+}
+
+func (m *mockService) SetAmountBounds(tenant string, req models.SetAmountBoundsRequest) (*models.AmountBounds, error) {
+	return m.SetAmountBoundsFn(tenant, req)
+}
+
+func (m *mockService) ListAmountBounds() ([]models.AmountBounds, error) {
+	return m.ListAmountBoundsFn()
+}
+
+func (m *mockService) DeleteAmountBounds(tenant string) error {
+	return m.DeleteAmountBoundsFn(tenant)
+}
+
+func (m *mockService) ListImpersonations(filter models.ImpersonationFilter, cursor string, limit int) (*models.ImpersonationPage, error) {
+	return m.ListImpersonationsFn(filter, cursor, limit)
+}
+
+func (m *mockService) ListSecurityEvents(afterID int64, limit int) (*models.SecurityEventPage, error) {
+	return m.ListSecurityEventsFn(afterID, limit)
+}
+
+func (m *mockService) IndexAdvisory(ctx context.Context) (*models.IndexAdvisory, error) {
+	return m.IndexAdvisoryFn()
+}
+
+func (m *mockService) OutboxRelayStatus() (*models.OutboxRelayStatus, error) {
+	return m.OutboxRelayStatusFn()
+}
+
+func (m *mockService) PauseOutboxRelay() (*models.OutboxRelayStatus, error) {
+	return m.PauseOutboxRelayFn()
+}
+
+func (m *mockService) ResumeOutboxRelay() (*models.OutboxRelayStatus, error) {
+	return m.ResumeOutboxRelayFn()
+}
+
+func (m *mockService) ReplayOutbox(afterID int64) (*models.OutboxRelayStatus, error) {
+	return m.ReplayOutboxFn(afterID)
+}
+
+func (m *mockService) ListEvents(afterID int64, limit int) (*models.EventPage, error) {
+	return m.ListEventsFn(afterID, limit)
+}
+
+func (m *mockService) RegisterWebhook(ctx context.Context, tenant, apiKey string, req models.WebhookRequest) (*models.Webhook, error) {
+	return m.RegisterWebhookFn(tenant, apiKey, req)
+}
+
+func (m *mockService) GetWebhook(id int64, tenant string) (*models.Webhook, error) {
+	return m.GetWebhookFn(id, tenant)
+}
+
+func (m *mockService) ListWebhooks(tenant string) ([]models.Webhook, error) {
+	return m.ListWebhooksFn(tenant)
+}
+
+func (m *mockService) DeleteWebhook(id int64, tenant string) error {
+	return m.DeleteWebhookFn(id, tenant)
+}
+
+func (m *mockService) PingWebhook(id int64, tenant string) (*models.WebhookPing, error) {
+	return m.PingWebhookFn(id, tenant)
+}
+
+func (m *mockService) NotifyEventWebhooks(e models.Event) {}
+
+func (m *mockService) GetNotificationPreference(ctx context.Context, tenant, apiKey string, accountID int64) (*models.NotificationPreference, error) {
+	return m.GetNotificationPreferenceFn(tenant, apiKey, accountID)
+}
+
+func (m *mockService) SetNotificationPreference(ctx context.Context, tenant, apiKey string, accountID int64, req models.NotificationPreferenceRequest) (*models.NotificationPreference, error) {
+	return m.SetNotificationPreferenceFn(tenant, apiKey, accountID, req)
+}
+
+func (m *mockService) SendDailyDigests() (int, error) {
+	return m.SendDailyDigestsFn()
+}
+
+func (m *mockService) RegionStatus() (*models.RegionStatus, error) {
+	return m.RegionStatusFn()
+}
+
+func (m *mockService) PromoteRegion(region string, epoch int64) (*models.RegionStatus, error) {
+	return m.PromoteRegionFn(region, epoch)
+}
+
+func (m *mockService) GetTransaction(ctx context.Context, id string) (*models.Transaction, error) {
+	m.hints = db.HintsFrom(ctx)
+	return m.GetTransactionFn(id)
+}
+
+func (m *mockService) SetDisplayName(ctx context.Context, id int64, name string) (*models.Account, error) {
+	return m.SetDisplayNameFn(id, name)
+}
+
+func (m *mockService) AccountSummary(ctx context.Context, id int64, loc *time.Location) (*models.AccountSummary, error) {
+	m.hints = db.HintsFrom(ctx)
+	return m.AccountSummaryFn(id, loc)
+}
+
+func (m *mockService) ListAccountTransactions(ctx context.Context, id int64, cursor string, limit int, unredacted bool) (*models.TransactionPage, error) {
+	m.hints = db.HintsFrom(ctx)
+	return m.ListAccountTransactionsFn(id, cursor, limit, unredacted)
+}
+
+func (m *mockService) LookupTransactions(ctx context.Context, ids []string) (*models.TransactionLookup, error) {
+	m.hints = db.HintsFrom(ctx)
+	return m.LookupTransactionsFn(ids)
+}
+
+func (m *mockService) ListTransactions(ctx context.Context, filter models.TransactionFilter, cursor string, limit int) (*models.TransactionPage, error) {
+	m.hints = db.HintsFrom(ctx)
+	return m.ListTransactionsFn(filter, cursor, limit)
+}
+
+func (m *mockService) CountTransactions(ctx context.Context, filter models.TransactionFilter) (int64, error) {
+	m.hints = db.HintsFrom(ctx)
+	return m.CountTransactionsFn(filter)
+}
+
+func (m *mockService) SetTransactionTags(ctx context.Context, id string, tags []string) (*models.Transaction, error) {
+	return m.SetTransactionTagsFn(id, tags)
+}
+
+func (m *mockService) ListAccountTags(ctx context.Context, accountID int64) ([]models.TransactionTag, error) {
+	m.hints = db.HintsFrom(ctx)
+	return m.ListAccountTagsFn(accountID)
+}
+
+func (m *mockService) AccountTimeline(ctx context.Context, accountID int64, cursor string, limit int, includeDeleted bool) (*models.AccountTimeline, error) {
+	m.hints = db.HintsFrom(ctx)
+	return m.AccountTimelineFn(accountID, cursor, limit, includeDeleted)
+}
+
+
+// --- CreateAccount Tests ---
+func TestCreateAccount_Success(t *testing.T) {
+	server := &api.Server{
+		Service: &mockService{
+			CreateAccountFn: func(id int64, balance float64, tenant, owner string) (*models.Account, error) {
+				return &models.Account{AccountID: id, Balance: models.Amount(balance), Status: models.AccountStatusActive}, nil
+			},
+		},
+	}
+	body := models.CreateAccountRequest{AccountID: 123, InitialBalance: 100.0}
+	jsonBody, _ := json.Marshal(body)
+	req := httptest.NewRequest("POST", "/accounts", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+
+	server.CreateAccount(resp, req)
+
+	if resp.Code != http.StatusCreated {
+		t.Errorf("expected status 201 Created, got %d", resp.Code)
+	}
+	var account map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&account)
+	if account["account_id"] != float64(123) || account["balance"] != "100.00" || account["status"] != "active" {
+		t.Errorf("expected the created account in the body, got %+v", account)
+	}
+}
+
+func TestCreateAccount_GeneratedID(t *testing.T) {
+	var created int64
+	server := &api.Server{
+		Service: &mockService{
+			NewAccountIDFn: func() (int64, error) { return 9001, nil },
+			CreateAccountFn: func(id int64, balance float64, tenant, owner string) (*models.Account, error) {
+				created = id
+				return &models.Account{AccountID: id, Balance: models.Amount(balance)}, nil
+			},
+		},
+	}
+	req := httptest.NewRequest("POST", "/accounts", strings.NewReader(`{"initial_balance": "10.00"}`))
+	resp := httptest.NewRecorder()
+
+	server.CreateAccount(resp, req)
+
+	if resp.Code != http.StatusCreated || created != 9001 {
+		t.Fatalf("expected 201 creating account 9001, got %d creating %d", resp.Code, created)
+	}
+	var body map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&body)
+	if body["account_id"] != float64(9001) {
+		t.Errorf("expected account_id 9001 in body, got %+v", body)
+	}
+}
+
+func TestCreateAccount_AccountLimit(t *testing.T) {
+	var tenant, owner string
+	server := &api.Server{
+		Service: &mockService{
+			CreateAccountFn: func(id int64, balance float64, t, o string) (*models.Account, error) {
+				tenant, owner = t, o
+				return nil, fmt.Errorf("%w: tenant %s has 10 open accounts, the most allowed", service.ErrAccountLimitExceeded, t)
+			},
+		},
+	}
+	req := httptest.NewRequest("POST", "/accounts", strings.NewReader(`{"account_id": 123}`))
+	req.Header.Set("X-Tenant-ID", "payroll")
+	req.Header.Set("X-API-Key", "key_payroll")
+	resp := httptest.NewRecorder()
+
+	server.CreateAccount(resp, req)
+
+	if resp.Code != http.StatusUnprocessableEntity || tenant != "payroll" || owner != "key_payroll" {
+		t.Fatalf("expected 422 for tenant payroll and owner key_payroll, got %d for %q and %q", resp.Code, tenant, owner)
+	}
+	var body map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&body)
+	if body["code"] != string(models.ReasonAccountLimitExceeded) {
+		t.Errorf("expected code account_limit_exceeded, got %+v", body)
+	}
+}
+
+func TestCreateAccount_InvalidJSON(t *testing.T) {
+	server := &api.Server{Service: &mockService{}}
+	req := httptest.NewRequest("POST", "/accounts", strings.NewReader("invalid json"))
+	resp := httptest.NewRecorder()
+
+	server.CreateAccount(resp, req)
+
+	if resp.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", resp.Code)
+	}
+}
+
+// --- GetAccount Tests ---
+
+func TestGetAccount_Success(t *testing.T) {
+	server := &api.Server{
+		Service: &mockService{
+			GetAccountFn: func(id int64) (*models.Account, error) {
+				return &models.Account{AccountID: id, Balance: 200.50}, nil
+			},
+		},
+	}
+
+	req := httptest.NewRequest("GET", "/accounts/123", nil)
+	rr := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/accounts/{id}", server.GetAccount)
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", rr.Code)
+	}
+
+	var resp map[string]interface{}
+	json.NewDecoder(rr.Body).Decode(&resp)
+	if resp["account_id"] != float64(123) || resp["balance"] != "200.50" || resp["currency"] != "USD" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+}
+
+func TestGetAccount_InvalidID(t *testing.T) {
+	server := &api.Server{Service: &mockService{}}
+	req := httptest.NewRequest("GET", "/accounts/abc", nil)
+	rr := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/accounts/{id}", server.GetAccount)
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+}
+
+func TestGetAccount_NotModified(t *testing.T) {
+	updatedAt := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	server := &api.Server{
+		Service: &mockService{
+			GetAccountFn: func(id int64) (*models.Account, error) {
+				return &models.Account{AccountID: id, Balance: 200.50, UpdatedAt: updatedAt}, nil
+			},
+		},
+	}
+	router := mux.NewRouter()
+	router.HandleFunc("/accounts/{id}", server.GetAccount)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/accounts/123", nil))
+	etag := rr.Header().Get("ETag")
+	if rr.Code != http.StatusOK || etag == "" {
+		t.Fatalf("expected 200 with an ETag, got %d", rr.Code)
+	}
+
+	req := httptest.NewRequest("GET", "/accounts/123", nil)
+	req.Header.Set("If-None-Match", etag)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusNotModified || rr.Body.Len() != 0 {
+		t.Errorf("expected an empty 304, got %d with %q", rr.Code, rr.Body.String())
+	}
+
+	updatedAt = updatedAt.Add(time.Second)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected 200 after a change, got %d", rr.Code)
+	}
+}
+
+func TestGetAccount_NotFound(t *testing.T) {
+	server := &api.Server{
+		Service: &mockService{
+			GetAccountFn: func(id int64) (*models.Account, error) {
+				return nil, errors.New("not found")
+			},
+		},
+	}
+
+	req := httptest.NewRequest("GET", "/accounts/999", nil)
+	rr := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/accounts/{id}", server.GetAccount)
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", rr.Code)
+	}
+}
+// --- Existence Check Tests ---
+
+func TestHeadAccount(t *testing.T) {
+	tests := []struct {
+		name         string
+		url          string
+		exists       bool
+		existsErr    error
+		expectedCode int
+	}{
+		{name: "Exists", url: "/accounts/123", exists: true, expectedCode: http.StatusOK},
+		{name: "Missing", url: "/accounts/999", expectedCode: http.StatusNotFound},
+		{name: "Error", url: "/accounts/123", existsErr: errors.New("db down"), expectedCode: http.StatusInternalServerError},
+		{name: "Invalid ID", url: "/accounts/abc", expectedCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &api.Server{
+				Service: &mockService{
+					AccountExistsFn: func(id int64) (bool, error) { return tt.exists, tt.existsErr },
+				},
+			}
+
+			router := mux.NewRouter()
+			router.HandleFunc("/accounts/{id}", server.HeadAccount).Methods("HEAD")
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest("HEAD", tt.url, nil))
+
+			if rr.Code != tt.expectedCode {
+				t.Errorf("expected %d, got %d", tt.expectedCode, rr.Code)
+			}
+			if rr.Body.Len() != 0 {
+				t.Errorf("expected empty body, got %q", rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestAccountExists(t *testing.T) {
+	for _, exists := range []bool{true, false} {
+		server := &api.Server{
+			Service: &mockService{
+				AccountExistsFn: func(id int64) (bool, error) { return exists, nil },
+			},
+		}
+
+		router := mux.NewRouter()
+		router.HandleFunc("/accounts/{id}/exists", server.AccountExists)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/accounts/123/exists", nil))
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", rr.Code)
+		}
+		var resp map[string]interface{}
+		json.NewDecoder(rr.Body).Decode(&resp)
+		if resp["account_id"] != float64(123) || resp["exists"] != exists {
+			t.Errorf("unexpected response: %+v", resp)
+		}
+	}
+}
+
+// --- DeleteAccount Tests ---
+
+func TestDeleteAccount(t *testing.T) {
+	tests := []struct {
+		name         string
+		url          string
+		deleteErr    error
+		expectedCode int
+	}{
+		{name: "Deleted", url: "/accounts/123", expectedCode: http.StatusNoContent},
+		{name: "Not Found", url: "/accounts/999", deleteErr: fmt.Errorf("account with ID 999 %w", repository.ErrNotFound), expectedCode: http.StatusNotFound},
+		{name: "Invalid ID", url: "/accounts/abc", expectedCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &api.Server{
+				Service: &mockService{
+					DeleteAccountFn: func(id int64) error { return tt.deleteErr },
+				},
+			}
+
+			router := mux.NewRouter()
+			router.HandleFunc("/accounts/{id}", server.DeleteAccount)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest("DELETE", tt.url, nil))
+
+			if rr.Code != tt.expectedCode {
+				t.Errorf("expected %d, got %d", tt.expectedCode, rr.Code)
+			}
+		})
+	}
+}
+
+// --- CreateTransaction Tests ---
+
+func TestCreateTransaction_Success(t *testing.T) {
+	server := &api.Server{
+		Service: &mockService{
+			CreateTransactionFn: func(from, to int64, amount float64, tags []string) (string, error) {
+				return "tx123", nil
+			},
+		},
+	}
+
+	reqBody := models.TransactionRequest{
+		SourceAccountID:      1,
+		DestinationAccountID: 2,
+		Amount:               50.0,
+	}
+	jsonBody, _ := json.Marshal(reqBody)
+	req := httptest.NewRequest("POST", "/transactions", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+
+	server.CreateTransaction(rr, req)
+
+	if rr.Code != http.StatusCreated {
+		t.Errorf("expected 201, got %d", rr.Code)
+	}
+
+	var resp map[string]string
+	json.NewDecoder(rr.Body).Decode(&resp)
+	if resp["transaction_id"] != "tx123" {
+		t.Errorf("expected tx123, got %s", resp["transaction_id"])
+	}
 }
 
-func (m *mockService) CreateAccount(id int64, balance float64) error {
-	return m.CreateAccountFn(id, balance)
-}
 
-func (m *mockService) GetAccount(id int64) (float64, error) {
-	return m.GetAccountFn(id)
+func TestCreateTransaction_StringAmount(t *testing.T) {
+	var got float64
+	server := &api.Server{
+		Service: &mockService{
+			CreateTransactionFn: func(from, to int64, amount float64, tags []string) (string, error) {
+				got = amount
+				return "tx123", nil
+			},
+		},
+	}
+
+	body := `{"source_account_id": 1, "destination_account_id": 2, "amount": "50.25"}`
+	rr := httptest.NewRecorder()
+	server.CreateTransaction(rr, httptest.NewRequest("POST", "/transactions", strings.NewReader(body)))
+
+	if rr.Code != http.StatusCreated || got != 50.25 {
+		t.Errorf("expected 201 with amount 50.25, got %d with %v", rr.Code, got)
+	}
+
+	body = `{"source_account_id": 1, "destination_account_id": 2, "amount": "50.255"}`
+	rr = httptest.NewRecorder()
+	server.CreateTransaction(rr, httptest.NewRequest("POST", "/transactions", strings.NewReader(body)))
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for sub-cent amount, got %d", rr.Code)
+	}
 }
 
-func (m *mockService) CreateTransaction(from, to int64, amount float64) (string, error) {
-	return m.CreateTransactionFn(from, to, amount)
+func TestReceivePayment(t *testing.T) {
+	var gotReference string
+	server := &api.Server{
+		Service: &mockService{
+			ReceivePaymentFn: func(sourceID, destID int64, amount float64, reference string) (*models.InboundPayment, error) {
+				gotReference = reference
+				if destID == 2 {
+					return &models.InboundPayment{TransactionID: "tx123"}, nil
+				}
+				return &models.InboundPayment{
+					TransactionID: "tx124",
+					SuspenseItem:  &models.SuspenseItem{ID: 9, IntendedAccountID: destID, Reason: models.SuspenseAccountNotFound},
+				}, nil
+			},
+		},
+	}
+
+	body := `{"source_account_id": 1, "destination_account_id": 2, "amount": "50.00", "reference": "INV-1"}`
+	rr := httptest.NewRecorder()
+	server.ReceivePayment(rr, httptest.NewRequest("POST", "/transactions/inbound", strings.NewReader(body)))
+	if rr.Code != http.StatusCreated {
+		t.Errorf("expected 201, got %d", rr.Code)
+	}
+	if gotReference != "INV-1" {
+		t.Errorf("expected reference INV-1, got %q", gotReference)
+	}
+
+	body = `{"source_account_id": 1, "destination_account_id": 3, "amount": "50.00"}`
+	rr = httptest.NewRecorder()
+	server.ReceivePayment(rr, httptest.NewRequest("POST", "/transactions/inbound", strings.NewReader(body)))
+	if rr.Code != http.StatusAccepted {
+		t.Errorf("expected 202 for a parked payment, got %d", rr.Code)
+	}
+	var resp models.InboundPayment
+	json.NewDecoder(rr.Body).Decode(&resp)
+	if resp.TransactionID != "tx124" || resp.SuspenseItem == nil || resp.SuspenseItem.ID != 9 {
+		t.Errorf("unexpected response: %+v", resp)
+	}
 }
 
+// noUsage is a metering store with no recorded usage.
+type noUsage struct{}
 
-// --- CreateAccount Tests ---
-func TestCreateAccount_Success(t *testing.T) {
+func (noUsage) AddUsage(models.Usage) error                          { return nil }
+func (noUsage) GetKeyUsage(string, string) (int64, float64, error)    { return 0, 0, nil }
+func (noUsage) GetTenantUsage(string, string) (int64, float64, error) { return 0, 0, nil }
+
+func TestCreateTransaction_VolumeQuota(t *testing.T) {
 	server := &api.Server{
 		Service: &mockService{
-			CreateAccountFn: func(id int64, balance float64) error {
-				return nil
+			CreateTransactionFn: func(from, to int64, amount float64, tags []string) (string, error) {
+				return "tx123", nil
 			},
 		},
 	}
-	body := models.CreateAccountRequest{AccountID: 123, InitialBalance: 100.0}
-	jsonBody, _ := json.Marshal(body)
-	req := httptest.NewRequest("POST", "/accounts", bytes.NewBuffer(jsonBody))
-	req.Header.Set("Content-Type", "application/json")
-	resp := httptest.NewRecorder()
+	meter := metering.New(noUsage{}, metering.Quotas{MonthlyVolume: 100}, clock.NewFake(time.Now()), log.New(io.Discard, "", 0))
+	handler := meter.Middleware(http.HandlerFunc(server.CreateTransaction))
 
-	server.CreateAccount(resp, req)
+	transfer := func(amount string) *httptest.ResponseRecorder {
+		body := `{"source_account_id": 1, "destination_account_id": 2, "amount": "` + amount + `"}`
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("POST", "/transactions", strings.NewReader(body)))
+		return rr
+	}
 
-	if resp.Code != http.StatusCreated {
-		t.Errorf("expected status 201 Created, got %d", resp.Code)
+	if rr := transfer("60"); rr.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", rr.Code)
+	}
+	rr := transfer("60")
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 over the volume quota, got %d", rr.Code)
+	}
+	var resp map[string]any
+	json.NewDecoder(rr.Body).Decode(&resp)
+	if resp["code"] != string(metering.CodeVolumeQuotaExceeded) {
+		t.Errorf("expected code %q, got %+v", metering.CodeVolumeQuotaExceeded, resp)
+	}
+	if rr := transfer("40"); rr.Code != http.StatusCreated {
+		t.Errorf("expected 201 within the remaining volume, got %d", rr.Code)
 	}
 }
 
-func TestCreateAccount_InvalidJSON(t *testing.T) {
+func TestCreateTransaction_InvalidJSON(t *testing.T) {
 	server := &api.Server{Service: &mockService{}}
-	req := httptest.NewRequest("POST", "/accounts", strings.NewReader("invalid json"))
-	resp := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/transactions", strings.NewReader("invalid"))
+	rr := httptest.NewRecorder()
 
-	server.CreateAccount(resp, req)
+	server.CreateTransaction(rr, req)
 
-	if resp.Code != http.StatusBadRequest {
-		t.Errorf("expected 400, got %d", resp.Code)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
 	}
 }
 
-// --- GetAccount Tests ---
-
-func TestGetAccount_Success(t *testing.T) {
+func TestCreateTransaction_Failure(t *testing.T) {
 	server := &api.Server{
 		Service: &mockService{
-			GetAccountFn: func(id int64) (float64, error) {
-				return 200.50, nil
+			CreateTransactionFn: func(from, to int64, amount float64, tags []string) (string, error) {
+				return "", errors.New("failed to process transaction")
 			},
 		},
 	}
 
-	req := httptest.NewRequest("GET", "/accounts/123", nil)
+	reqBody := models.TransactionRequest{
+		SourceAccountID:      1,
+		DestinationAccountID: 2,
+		Amount:               50.0,
+	}
+	jsonBody, _ := json.Marshal(reqBody)
+	req := httptest.NewRequest("POST", "/transactions", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
 	rr := httptest.NewRecorder()
 
-	router := mux.NewRouter()
-	router.HandleFunc("/accounts/{id}", server.GetAccount)
-	router.ServeHTTP(rr, req)
+	server.CreateTransaction(rr, req)
 
-	if rr.Code != http.StatusOK {
-		t.Errorf("expected 200, got %d", rr.Code)
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500, got %d", rr.Code)
+	}
+}
+func TestCreateTransaction_RetriesExhausted(t *testing.T) {
+	server := &api.Server{
+		Service: &mockService{
+			CreateTransactionFn: func(from, to int64, amount float64, tags []string) (string, error) {
+				return "", service.ErrRetriesExhausted
+			},
+		},
 	}
 
+	body := `{"source_account_id": 1, "destination_account_id": 2, "amount": "50.00"}`
+	rr := httptest.NewRecorder()
+	server.CreateTransaction(rr, httptest.NewRequest("POST", "/transactions", strings.NewReader(body)))
+
+	if rr.Code != http.StatusConflict {
+		t.Errorf("expected 409, got %d", rr.Code)
+	}
+	if got := rr.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("expected Retry-After 1, got %q", got)
+	}
 	var resp map[string]interface{}
 	json.NewDecoder(rr.Body).Decode(&resp)
-	if resp["account_id"] != float64(123) || resp["balance"] != 200.50 {
-		t.Errorf("unexpected response: %+v", resp)
+	if resp["retry_in_ms"] != float64(250) {
+		t.Errorf("expected retry_in_ms 250, got %+v", resp)
 	}
 }
 
-func TestGetAccount_InvalidID(t *testing.T) {
+func TestCreateTransaction_Rejected(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		expectedCode int
+		reason       models.ReasonCode
+	}{
+		{"Insufficient funds", fmt.Errorf("%w in account %d", service.ErrInsufficientBalance, 1), http.StatusUnprocessableEntity, models.ReasonInsufficientFunds},
+		{"Unknown destination", fmt.Errorf("destination account %d %w", 2, service.ErrDestinationNotFound), http.StatusNotFound, models.ReasonDestinationNotFound},
+		{"Unknown source", fmt.Errorf("account with ID %d %w", 1, repository.ErrNotFound), http.StatusNotFound, models.ReasonAccountNotFound},
+		{"Passive region", &pq.Error{Code: "25006", Message: "region eu-west-1 is passive, the active region is us-east-1"}, http.StatusServiceUnavailable, models.ReasonRegionPassive},
+		{"Transfers frozen", fmt.Errorf("%w for all tenants until 2026-03-14T10:00:00Z: suspected compromise", service.ErrTransfersFrozen), http.StatusForbidden, models.ReasonTransfersFrozen},
+		{"Amount out of bounds", fmt.Errorf("%w: 0.001 is below the minimum of 0.01 for all tenants", service.ErrAmountOutOfBounds), http.StatusUnprocessableEntity, models.ReasonLimitExceeded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &api.Server{
+				Service: &mockService{
+					CreateTransactionFn: func(from, to int64, amount float64, tags []string) (string, error) {
+						return "", tt.err
+					},
+				},
+			}
+
+			body := `{"source_account_id": 1, "destination_account_id": 2, "amount": "50.00"}`
+			rr := httptest.NewRecorder()
+			server.CreateTransaction(rr, httptest.NewRequest("POST", "/transactions", strings.NewReader(body)))
+
+			if rr.Code != tt.expectedCode {
+				t.Errorf("expected %d, got %d", tt.expectedCode, rr.Code)
+			}
+			var resp struct {
+				Code models.ReasonCode `json:"code"`
+			}
+			json.NewDecoder(rr.Body).Decode(&resp)
+			if resp.Code != tt.reason {
+				t.Errorf("expected reason code %q, got %q", tt.reason, resp.Code)
+			}
+		})
+	}
+}
+
+func TestCreateTransaction_RecordsRejection(t *testing.T) {
+	var recorded []models.TransactionAttempt
+	server := &api.Server{
+		Service: &mockService{
+			CreateTransactionFn: func(from, to int64, amount float64, tags []string) (string, error) {
+				if from == 9 {
+					return "", errors.New("connection refused")
+				}
+				return "", fmt.Errorf("%w in account %d", service.ErrInsufficientBalance, from)
+			},
+			RecordTransactionAttemptFn: func(a models.TransactionAttempt) {
+				recorded = append(recorded, a)
+			},
+		},
+	}
+
+	req := httptest.NewRequest("POST", "/transactions", strings.NewReader(`{"source_account_id": 1, "destination_account_id": 2, "amount": "50.00"}`))
+	req.Header.Set(metering.APIKeyHeader, "key-1")
+	server.CreateTransaction(httptest.NewRecorder(), req)
+	server.CreateTransaction(httptest.NewRecorder(), httptest.NewRequest("POST", "/transactions", strings.NewReader(`{"source_account_id": 9, "destination_account_id": 2, "amount": "50.00"}`)))
+
+	want := models.TransactionAttempt{
+		SourceAccountID:      1,
+		DestinationAccountID: 2,
+		Amount:               50,
+		Code:                 models.ReasonInsufficientFunds,
+		Error:                "insufficient balance in account 1",
+		APIKey:               "key-1",
+		Tenant:               metering.DefaultTenant,
+	}
+	if len(recorded) != 1 || recorded[0] != want {
+		t.Errorf("expected only the rejection to be recorded as %+v, got %+v", want, recorded)
+	}
+}
+
+func TestListReasonCodes(t *testing.T) {
 	server := &api.Server{Service: &mockService{}}
-	req := httptest.NewRequest("GET", "/accounts/abc", nil)
 	rr := httptest.NewRecorder()
+	server.ListReasonCodes(rr, httptest.NewRequest("GET", "/reason-codes", nil))
 
-	router := mux.NewRouter()
-	router.HandleFunc("/accounts/{id}", server.GetAccount)
-	router.ServeHTTP(rr, req)
-
-	if rr.Code != http.StatusBadRequest {
-		t.Errorf("expected 400, got %d", rr.Code)
+	var catalog []models.ReasonCodeInfo
+	json.NewDecoder(rr.Body).Decode(&catalog)
+	if len(catalog) == 0 {
+		t.Fatal("expected a non-empty catalog")
+	}
+	seen := make(map[models.ReasonCode]bool)
+	for _, info := range catalog {
+		if seen[info.Code] {
+			t.Errorf("duplicate code %q", info.Code)
+		}
+		seen[info.Code] = true
+		if info.Description == "" {
+			t.Errorf("code %q has no description", info.Code)
+		}
+	}
+	for _, code := range []models.ReasonCode{models.ReasonInsufficientFunds, models.ReasonCallQuotaExceeded, models.ReasonManualAdjustmentCorrection} {
+		if !seen[code] {
+			t.Errorf("expected %q in the catalog", code)
+		}
 	}
 }
 
-func TestGetAccount_NotFound(t *testing.T) {
+// --- ListTransactions Tests ---
+
+func TestLookupTransactions(t *testing.T) {
+	var gotIDs []string
 	server := &api.Server{
 		Service: &mockService{
-			GetAccountFn: func(id int64) (float64, error) {
-				return 0, errors.New("not found")
+			LookupTransactionsFn: func(ids []string) (*models.TransactionLookup, error) {
+				gotIDs = ids
+				if len(ids) == 0 {
+					return nil, fmt.Errorf("%w: no transaction IDs", service.ErrInvalidLookup)
+				}
+				return &models.TransactionLookup{
+					Transactions: []models.Transaction{{ID: "01JNHZ8Q5X4T0Y2M3K6W9V1R7B", Amount: 10}},
+					NotFound:     []string{"missing"},
+				}, nil
 			},
 		},
 	}
 
-	req := httptest.NewRequest("GET", "/accounts/999", nil)
 	rr := httptest.NewRecorder()
+	body := `{"ids":["01JNHZ8Q5X4T0Y2M3K6W9V1R7B","missing"]}`
+	server.LookupTransactions(rr, httptest.NewRequest("POST", "/transactions/lookup", strings.NewReader(body)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	if len(gotIDs) != 2 || gotIDs[1] != "missing" {
+		t.Errorf("unexpected IDs passed to the service: %v", gotIDs)
+	}
+	var resp struct {
+		Transactions []map[string]interface{} `json:"transactions"`
+		NotFound     []string                 `json:"not_found"`
+	}
+	json.NewDecoder(rr.Body).Decode(&resp)
+	if len(resp.Transactions) != 1 || resp.Transactions[0]["id"] != "01JNHZ8Q5X4T0Y2M3K6W9V1R7B" {
+		t.Errorf("unexpected transactions: %+v", resp.Transactions)
+	}
+	if len(resp.NotFound) != 1 || resp.NotFound[0] != "missing" {
+		t.Errorf("unexpected not_found: %v", resp.NotFound)
+	}
+
+	for _, body := range []string{`{"ids":[]}`, `not json`} {
+		rr := httptest.NewRecorder()
+		server.LookupTransactions(rr, httptest.NewRequest("POST", "/transactions/lookup", strings.NewReader(body)))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", body, rr.Code)
+		}
+	}
+}
+
+func TestGetTransaction(t *testing.T) {
+	svc := &mockService{
+		GetTransactionFn: func(id string) (*models.Transaction, error) {
+			if id != "01JNHZ8Q5X4T0Y2M3K6W9V1R7B" && id != "42" {
+				return nil, fmt.Errorf("transaction %s %w", id, repository.ErrNotFound)
+			}
+			return &models.Transaction{ID: "01JNHZ8Q5X4T0Y2M3K6W9V1R7B", Ref: "01JNHZ8Q5X4T0Y2M3K6W9V1R7B", Amount: 10}, nil
+		},
+	}
+	server := &api.Server{Service: svc}
 
 	router := mux.NewRouter()
-	router.HandleFunc("/accounts/{id}", server.GetAccount)
-	router.ServeHTTP(rr, req)
+	router.HandleFunc("/transactions/{id}", server.GetTransaction)
+
+	for _, id := range []string{"01JNHZ8Q5X4T0Y2M3K6W9V1R7B", "42"} {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/transactions/"+id, nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d", id, rr.Code)
+		}
+		var resp map[string]interface{}
+		json.NewDecoder(rr.Body).Decode(&resp)
+		if resp["id"] != "01JNHZ8Q5X4T0Y2M3K6W9V1R7B" || resp["transaction_ref"] != "01JNHZ8Q5X4T0Y2M3K6W9V1R7B" {
+			t.Errorf("%s: unexpected response: %+v", id, resp)
+		}
+	}
+	if svc.hints != db.ReadOnly|db.Idempotent {
+		t.Errorf("expected the read hinted read_only|idempotent, got %s", svc.hints)
+	}
 
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/transactions/missing", nil))
 	if rr.Code != http.StatusNotFound {
 		t.Errorf("expected 404, got %d", rr.Code)
 	}
 }
-// --- CreateTransaction Tests ---
 
-func TestCreateTransaction_Success(t *testing.T) {
+func TestSetDisplayName(t *testing.T) {
 	server := &api.Server{
 		Service: &mockService{
-			CreateTransactionFn: func(from, to int64, amount float64) (string, error) {
-				return "tx123", nil
+			SetDisplayNameFn: func(id int64, name string) (*models.Account, error) {
+				switch {
+				case id != 1:
+					return nil, fmt.Errorf("account with ID %d %w", id, repository.ErrNotFound)
+				case len(name) > 64:
+					return nil, fmt.Errorf("%w: too long", service.ErrInvalidDisplayName)
+				}
+				return &models.Account{AccountID: id, DisplayName: name}, nil
 			},
 		},
 	}
+	router := mux.NewRouter()
+	router.HandleFunc("/accounts/{id}/display-name", server.SetDisplayName)
 
-	reqBody := models.TransactionRequest{
-		SourceAccountID:      1,
-		DestinationAccountID: 2,
-		Amount:               50.0,
+	tests := []struct {
+		url          string
+		body         string
+		expectedCode int
+	}{
+		{"/accounts/1/display-name", `{"display_name":"Jane Doe"}`, http.StatusOK},
+		{"/accounts/2/display-name", `{"display_name":"Jane Doe"}`, http.StatusNotFound},
+		{"/accounts/1/display-name", `{"display_name":"` + strings.Repeat("x", 65) + `"}`, http.StatusBadRequest},
+		{"/accounts/x/display-name", `{"display_name":"Jane Doe"}`, http.StatusBadRequest},
 	}
-	jsonBody, _ := json.Marshal(reqBody)
-	req := httptest.NewRequest("POST", "/transactions", bytes.NewBuffer(jsonBody))
-	req.Header.Set("Content-Type", "application/json")
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("PUT", tt.url, strings.NewReader(tt.body)))
+		if rr.Code != tt.expectedCode {
+			t.Errorf("%s %s: expected %d, got %d", tt.url, tt.body, tt.expectedCode, rr.Code)
+		}
+	}
+}
+
+func TestGetAccountSummary(t *testing.T) {
+	var gotLoc *time.Location
+	server := &api.Server{
+		Service: &mockService{
+			AccountSummaryFn: func(id int64, loc *time.Location) (*models.AccountSummary, error) {
+				gotLoc = loc
+				if id != 1 {
+					return nil, fmt.Errorf("account with ID %d %w", id, repository.ErrNotFound)
+				}
+				return &models.AccountSummary{
+					AccountID:        1,
+					Balance:          110,
+					AvailableBalance: 110,
+					MonthToDate:      models.AccountFlows{Incoming: 150, IncomingCount: 3, Outgoing: 40, OutgoingCount: 1},
+				}, nil
+			},
+		},
+	}
+	router := mux.NewRouter()
+	router.HandleFunc("/accounts/{id}/summary", server.GetAccountSummary)
+
 	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/accounts/1/summary", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	var resp map[string]interface{}
+	json.NewDecoder(rr.Body).Decode(&resp)
+	mtd, _ := resp["month_to_date"].(map[string]interface{})
+	if resp["balance"] != "110.00" || resp["held"] != "0.00" || resp["currency"] != "USD" || mtd["incoming"] != "150.00" || mtd["outgoing_count"] != 1.0 {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	if gotLoc != time.UTC {
+		t.Errorf("expected UTC by default, got %v", gotLoc)
+	}
 
-	server.CreateTransaction(rr, req)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/accounts/1/summary?timezone=Europe/Berlin", nil))
+	if rr.Code != http.StatusOK || gotLoc.String() != "Europe/Berlin" {
+		t.Errorf("expected 200 in Europe/Berlin, got %d in %v", rr.Code, gotLoc)
+	}
 
-	if rr.Code != http.StatusCreated {
-		t.Errorf("expected 201, got %d", rr.Code)
+	for _, tz := range []string{"Mars/Olympus", "Local"} {
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/accounts/1/summary?timezone="+tz, nil))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", tz, rr.Code)
+		}
 	}
 
-	var resp map[string]string
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/accounts/2/summary", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", rr.Code)
+	}
+}
+
+func TestListAccountTransactions(t *testing.T) {
+	var gotUnredacted bool
+	server := &api.Server{
+		Service: &mockService{
+			ListAccountTransactionsFn: func(id int64, cursor string, limit int, unredacted bool) (*models.TransactionPage, error) {
+				gotUnredacted = unredacted
+				if id != 1 {
+					return nil, fmt.Errorf("account with ID %d %w", id, repository.ErrNotFound)
+				}
+				return &models.TransactionPage{Transactions: []models.Transaction{{
+					ID:           "7",
+					Counterparty: &models.Counterparty{AccountID: 2, DisplayName: "J*** D***", Redacted: true},
+				}}}, nil
+			},
+		},
+	}
+	router := mux.NewRouter()
+	router.HandleFunc("/accounts/{id}/transactions", server.ListAccountTransactions)
+	router.HandleFunc("/admin/accounts/{id}/transactions", server.ListAccountTransactionDetails)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/accounts/1/transactions", nil))
+	if rr.Code != http.StatusOK || gotUnredacted {
+		t.Fatalf("expected a redacted 200, got %d (unredacted %v)", rr.Code, gotUnredacted)
+	}
+	var resp []map[string]interface{}
 	json.NewDecoder(rr.Body).Decode(&resp)
-	if resp["transaction_id"] != "tx123" {
-		t.Errorf("expected tx123, got %s", resp["transaction_id"])
+	if len(resp) != 1 {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+	if c, _ := resp[0]["counterparty"].(map[string]interface{}); c["display_name"] != "J*** D***" || c["redacted"] != true {
+		t.Errorf("unexpected counterparty: %+v", resp[0]["counterparty"])
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/admin/accounts/1/transactions", nil))
+	if rr.Code != http.StatusOK || !gotUnredacted {
+		t.Errorf("expected an unredacted 200, got %d (unredacted %v)", rr.Code, gotUnredacted)
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/accounts/2/transactions", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", rr.Code)
 	}
 }
 
+func TestListAccounts(t *testing.T) {
+	var gotFilter models.AccountFilter
+	svc := &mockService{
+		ListAccountsFn: func(filter models.AccountFilter, cursor string, limit int) (*models.AccountPage, error) {
+			gotFilter = filter
+			if cursor == "bad" {
+				return nil, fmt.Errorf("%w: bad", service.ErrInvalidCursor)
+			}
+			return &models.AccountPage{Accounts: []models.Account{{AccountID: 7, Balance: 150}}, NextCursor: "next", HasMore: true}, nil
+		},
+	}
+	server := &api.Server{Service: svc}
 
-func TestCreateTransaction_InvalidJSON(t *testing.T) {
-	server := &api.Server{Service: &mockService{}}
-	req := httptest.NewRequest("POST", "/transactions", strings.NewReader("invalid"))
 	rr := httptest.NewRecorder()
+	server.ListAccounts(rr, httptest.NewRequest("GET", "/accounts?min_balance=100.50&limit=1", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	if gotFilter.MinBalance == nil || *gotFilter.MinBalance != 100.5 || gotFilter.MaxBalance != nil {
+		t.Errorf("unexpected filter: %+v", gotFilter)
+	}
+	if svc.hints != db.ReadOnly|db.Idempotent {
+		t.Errorf("expected the read hinted read_only|idempotent, got %s", svc.hints)
+	}
+	if link := rr.Header().Get("Link"); link != `</accounts?limit=1&min_balance=100.50>; rel="first", </accounts?cursor=next&limit=1&min_balance=100.50>; rel="next"` {
+		t.Errorf("unexpected Link: %s", link)
+	}
+	var resp []models.Account
+	json.NewDecoder(rr.Body).Decode(&resp)
+	if len(resp) != 1 || resp[0].AccountID != 7 {
+		t.Errorf("unexpected response: %+v", resp)
+	}
 
-	server.CreateTransaction(rr, req)
+	for _, url := range []string{"/accounts?max_balance=lots", "/accounts?cursor=bad"} {
+		rr = httptest.NewRecorder()
+		server.ListAccounts(rr, httptest.NewRequest("GET", url, nil))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", url, rr.Code)
+		}
+	}
+}
 
-	if rr.Code != http.StatusBadRequest {
-		t.Errorf("expected 400, got %d", rr.Code)
+func TestListTransactions(t *testing.T) {
+	since := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name           string
+		url            string
+		hasMore        bool
+		expectedCode   int
+		expectedSince  time.Time
+		expectedCursor string
+		expectedLimit  int
+		expectedLink   string
+		expectedTotal  string
+	}{
+		{
+			name:          "Defaults",
+			url:           "/transactions",
+			expectedCode:  http.StatusOK,
+			expectedLimit: 100,
+			expectedLink:  `</transactions>; rel="first"`,
+		},
+		{
+			name:           "Next Page",
+			url:            "/transactions?updated_since=2024-05-01T12:00:00Z&limit=1&cursor=c1",
+			hasMore:        true,
+			expectedCode:   http.StatusOK,
+			expectedSince:  since,
+			expectedCursor: "c1",
+			expectedLimit:  1,
+			expectedLink:   `</transactions?limit=1&updated_since=2024-05-01T12%3A00%3A00Z>; rel="first", </transactions?cursor=next&limit=1&updated_since=2024-05-01T12%3A00%3A00Z>; rel="next"`,
+		},
+		{
+			name:          "Include Total",
+			url:           "/transactions?include_total=true",
+			expectedCode:  http.StatusOK,
+			expectedLimit: 100,
+			expectedLink:  `</transactions?include_total=true>; rel="first"`,
+			expectedTotal: "7",
+		},
+		{name: "Invalid Timestamp", url: "/transactions?updated_since=yesterday", expectedCode: http.StatusBadRequest},
+		{name: "Limit Too Large", url: "/transactions?limit=5000", expectedCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &api.Server{
+				Service: &mockService{
+					ListTransactionsFn: func(filter models.TransactionFilter, cursor string, limit int) (*models.TransactionPage, error) {
+						if !filter.UpdatedSince.Equal(tt.expectedSince) || cursor != tt.expectedCursor || limit != tt.expectedLimit {
+							t.Errorf("unexpected updated_since=%v cursor=%q limit=%d", filter.UpdatedSince, cursor, limit)
+						}
+						return &models.TransactionPage{
+							Transactions: []models.Transaction{{ID: "1", CreatedAt: since, UpdatedAt: since}},
+							NextCursor:   "next",
+							HasMore:      tt.hasMore,
+						}, nil
+					},
+					CountTransactionsFn: func(filter models.TransactionFilter) (int64, error) { return 7, nil },
+				},
+			}
+
+			rr := httptest.NewRecorder()
+			server.ListTransactions(rr, httptest.NewRequest("GET", tt.url, nil))
+
+			if rr.Code != tt.expectedCode {
+				t.Fatalf("expected %d, got %d", tt.expectedCode, rr.Code)
+			}
+			if rr.Code != http.StatusOK {
+				return
+			}
+			if link := rr.Header().Get("Link"); link != tt.expectedLink {
+				t.Errorf("expected Link %s, got %s", tt.expectedLink, link)
+			}
+			if total := rr.Header().Get("X-Total-Count"); total != tt.expectedTotal {
+				t.Errorf("expected X-Total-Count %q, got %q", tt.expectedTotal, total)
+			}
+			var resp []models.Transaction
+			json.NewDecoder(rr.Body).Decode(&resp)
+			if len(resp) != 1 || !resp[0].UpdatedAt.Equal(since) {
+				t.Errorf("unexpected response: %+v", resp)
+			}
+		})
 	}
 }
 
-func TestCreateTransaction_Failure(t *testing.T) {
+// --- SyncTransactions Tests ---
+
+func TestSyncTransactions(t *testing.T) {
+	tests := []struct {
+		name         string
+		url          string
+		syncErr      error
+		expectedCode int
+	}{
+		{name: "First Page", url: "/sync/transactions", expectedCode: http.StatusOK},
+		{name: "With Cursor", url: "/sync/transactions?cursor=abc", expectedCode: http.StatusOK},
+		{name: "Invalid Cursor", url: "/sync/transactions?cursor=%21", syncErr: service.ErrInvalidCursor, expectedCode: http.StatusBadRequest},
+		{name: "Invalid Limit", url: "/sync/transactions?limit=0", expectedCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &api.Server{
+				Service: &mockService{
+					SyncTransactionsFn: func(cursor string, limit int) (*models.TransactionPage, error) {
+						if tt.syncErr != nil {
+							return nil, tt.syncErr
+						}
+						return &models.TransactionPage{Transactions: []models.Transaction{{ID: "1"}}, NextCursor: cursor + "-next"}, nil
+					},
+				},
+			}
+
+			rr := httptest.NewRecorder()
+			server.SyncTransactions(rr, httptest.NewRequest("GET", tt.url, nil))
+
+			if rr.Code != tt.expectedCode {
+				t.Fatalf("expected %d, got %d", tt.expectedCode, rr.Code)
+			}
+			if rr.Code != http.StatusOK {
+				return
+			}
+			var resp models.TransactionPage
+			json.NewDecoder(rr.Body).Decode(&resp)
+			if len(resp.Transactions) != 1 || !strings.HasSuffix(resp.NextCursor, "-next") {
+				t.Errorf("unexpected response: %+v", resp)
+			}
+		})
+	}
+}
+
+func TestGetAccount_Fields(t *testing.T) {
 	server := &api.Server{
 		Service: &mockService{
-			CreateTransactionFn: func(from, to int64, amount float64) (string, error) {
-				return "", errors.New("failed to process transaction")
+			GetAccountFn: func(id int64) (*models.Account, error) {
+				return &models.Account{AccountID: id, Balance: 200.50, CreatedAt: time.Now()}, nil
 			},
 		},
 	}
 
-	reqBody := models.TransactionRequest{
-		SourceAccountID:      1,
-		DestinationAccountID: 2,
-		Amount:               50.0,
-	}
-	jsonBody, _ := json.Marshal(reqBody)
-	req := httptest.NewRequest("POST", "/transactions", bytes.NewBuffer(jsonBody))
-	req.Header.Set("Content-Type", "application/json")
+	router := mux.NewRouter()
+	router.HandleFunc("/accounts/{id}", server.GetAccount)
 	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/accounts/123?fields=balance", nil))
 
-	server.CreateTransaction(rr, req)
-
-	if rr.Code != http.StatusInternalServerError {
-		t.Errorf("expected 500, got %d", rr.Code)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
 	}
-}
\ No newline at end of file
+	var resp map[string]interface{}
+	json.NewDecoder(rr.Body).Decode(&resp)
+	if len(resp) != 1 || resp["balance"] != "200.50" {
+		t.Errorf("expected only balance, got %+v", resp)
+	}
+}
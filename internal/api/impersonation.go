@@ -0,0 +1,129 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/impersonation"
+	"github.com/nehciyy/intrapay/internal/metering"
+	"github.com/nehciyy/intrapay/internal/middleware"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/service"
+)
+
+// Impersonate serves the requests that name a customer's API key in
+// impersonation.KeyHeader as that customer's requests, so support sees exactly
+// what the customer's client sees without being given its credentials. The
+// request must carry "Authorization: Bearer <adminToken>" instead of the public
+// token, and name the operator in impersonation.OperatorHeader. Only reads are
+// served; anything else is refused with 403 impersonation_read_only. Each
+// request is audited before it is served, and refused with 503 if it cannot
+// be. Without an adminToken impersonation is refused. Requests without the
+// header pass through untouched.
+func (s *Server) Impersonate(adminToken string) func(http.Handler) http.Handler {
+	var admin *middleware.Authenticator
+	if adminToken != "" {
+		admin = middleware.NewAuthenticator(adminToken)
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			apiKey := r.Header.Get(impersonation.KeyHeader)
+			if apiKey == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if admin == nil {
+				writeError(w, r, http.StatusForbidden, errorResponse{Error: "impersonation is not enabled"})
+				return
+			}
+			if !admin.Check(r.Header.Get("Authorization")) {
+				s.emitSecurityEvent(r, models.SecurityAuthFailure, r.Header.Get(impersonation.OperatorHeader), "", "impersonation of "+apiKey+": "+r.Method+" "+r.URL.Path)
+				w.Header().Set("WWW-Authenticate", `Bearer realm="intrapay-admin"`)
+				writeError(w, r, http.StatusUnauthorized, errorResponse{Error: "impersonation requires the admin token"})
+				return
+			}
+			operator := strings.TrimSpace(r.Header.Get(impersonation.OperatorHeader))
+			if operator == "" {
+				writeError(w, r, http.StatusBadRequest, errorResponse{Error: impersonation.OperatorHeader + " must name the operator"})
+				return
+			}
+			if !readsOnly(r) {
+				writeError(w, r, http.StatusForbidden, errorResponse{Error: "impersonated requests are read-only", Code: models.ReasonImpersonationReadOnly})
+				return
+			}
+
+			r = r.Clone(impersonation.WithOperator(r.Context(), operator))
+			r.Header.Set(metering.APIKeyHeader, apiKey)
+			_, tenant := metering.Caller(r)
+			err := s.Service.RecordImpersonation(models.Impersonation{
+				Operator:  operator,
+				APIKey:    apiKey,
+				Tenant:    tenant,
+				Method:    r.Method,
+				Path:      r.URL.RequestURI(),
+				Reason:    r.Header.Get(impersonation.ReasonHeader),
+				RequestID: r.Header.Get(RequestIDHeader),
+			})
+			if err != nil {
+				writeError(w, r, http.StatusServiceUnavailable, errorResponse{Error: "impersonation could not be audited: " + err.Error()})
+				return
+			}
+			s.emitSecurityEvent(r, models.SecurityImpersonation, operator, tenant, "as "+apiKey+": "+r.Method+" "+r.URL.RequestURI())
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// readsOnly reports whether r only reads: a GET, HEAD or OPTIONS, or one of the
+// POST endpoints that only read.
+func readsOnly(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	case http.MethodPost:
+		return readOnlyPosts[r.URL.Path]
+	}
+	return false
+}
+
+// ListImpersonations pages through the audit trail of impersonated requests,
+// oldest first, filtered by ?operator=, ?api_key=, ?tenant=, ?since= and
+// ?until=.
+func (s *Server) ListImpersonations(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := models.ImpersonationFilter{
+		Operator: q.Get("operator"),
+		APIKey:   q.Get("api_key"),
+		Tenant:   q.Get("tenant"),
+	}
+	for name, t := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if v := q.Get(name); v != "" {
+			var err error
+			if *t, err = time.Parse(time.RFC3339Nano, v); err != nil {
+				http.Error(w, "invalid "+name+", expected RFC 3339 timestamp", http.StatusBadRequest)
+				return
+			}
+		}
+	}
+
+	pageReq, err := parsePageRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := s.Service.ListImpersonations(filter, pageReq.Cursor, pageReq.Limit)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInvalidCursor) {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	setPageHeaders(w, r, page.NextCursor, page.HasMore, nil)
+	writeResponse(w, r, page.Impersonations)
+}
@@ -0,0 +1,164 @@
+package api_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nehciyy/intrapay/internal/api"
+	"github.com/nehciyy/intrapay/internal/impersonation"
+	"github.com/nehciyy/intrapay/internal/metering"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/service"
+)
+
+func TestImpersonate(t *testing.T) {
+	var recorded []models.Impersonation
+	auditDown := false
+	server := &api.Server{
+		Service: &mockService{
+			RecordImpersonationFn: func(i models.Impersonation) error {
+				if auditDown {
+					return errors.New("connection refused")
+				}
+				recorded = append(recorded, i)
+				return nil
+			},
+		},
+	}
+	var gotKey, gotOperator string
+	served := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotKey, _ = metering.Caller(r)
+		gotOperator, _ = impersonation.Operator(r.Context())
+	})
+	h := server.Impersonate("admin-secret")(served)
+
+	tests := []struct {
+		name, method, url, key, token, operator string
+		auditDown                               bool
+		expectedCode                            int
+		expectedKey, expectedOperator           string
+	}{
+		{"Not Impersonated", "POST", "/transactions", "", "public", "", false, http.StatusOK, "cust_1", ""},
+		{"Read", "GET", "/accounts/1?fields=balance", "cust_2", "admin-secret", "alice", false, http.StatusOK, "cust_2", "alice"},
+		{"Read-Only Post", "POST", "/transactions/lookup", "cust_2", "admin-secret", "alice", false, http.StatusOK, "cust_2", "alice"},
+		{"Write", "POST", "/transactions", "cust_2", "admin-secret", "alice", false, http.StatusForbidden, "", ""},
+		{"Public Token", "GET", "/accounts/1", "cust_2", "public", "alice", false, http.StatusUnauthorized, "", ""},
+		{"No Operator", "GET", "/accounts/1", "cust_2", "admin-secret", " ", false, http.StatusBadRequest, "", ""},
+		{"Audit Down", "GET", "/accounts/1", "cust_2", "admin-secret", "alice", true, http.StatusServiceUnavailable, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorded, auditDown = nil, tt.auditDown
+			gotKey, gotOperator = "", ""
+			req := httptest.NewRequest(tt.method, tt.url, nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			req.Header.Set(metering.APIKeyHeader, "cust_1")
+			req.Header.Set(impersonation.KeyHeader, tt.key)
+			req.Header.Set(impersonation.OperatorHeader, tt.operator)
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+			if rr.Code != tt.expectedCode {
+				t.Fatalf("expected %d, got %d: %s", tt.expectedCode, rr.Code, rr.Body.String())
+			}
+			if gotKey != tt.expectedKey || gotOperator != tt.expectedOperator {
+				t.Errorf("expected to be served as %q by %q, got %q by %q", tt.expectedKey, tt.expectedOperator, gotKey, gotOperator)
+			}
+			if audited := len(recorded) == 1; audited != (tt.expectedOperator != "") {
+				t.Errorf("expected only served impersonations to be audited, got %v", recorded)
+			}
+		})
+	}
+
+	t.Run("Audit Record", func(t *testing.T) {
+		recorded, auditDown = nil, false
+		req := httptest.NewRequest("GET", "/accounts/1/timeline?limit=5", nil)
+		req.Header.Set("Authorization", "Bearer admin-secret")
+		req.Header.Set(metering.TenantHeader, "payroll")
+		req.Header.Set(impersonation.KeyHeader, "cust_2")
+		req.Header.Set(impersonation.OperatorHeader, "alice")
+		req.Header.Set(impersonation.ReasonHeader, "ticket 4812")
+		req.Header.Set(api.RequestIDHeader, "req-1")
+		h.ServeHTTP(httptest.NewRecorder(), req)
+
+		expected := models.Impersonation{
+			Operator:  "alice",
+			APIKey:    "cust_2",
+			Tenant:    "payroll",
+			Method:    "GET",
+			Path:      "/accounts/1/timeline?limit=5",
+			Reason:    "ticket 4812",
+			RequestID: "req-1",
+		}
+		if len(recorded) != 1 || recorded[0] != expected {
+			t.Errorf("expected %+v, got %+v", expected, recorded)
+		}
+	})
+
+	t.Run("Write Reason Code", func(t *testing.T) {
+		req := httptest.NewRequest("DELETE", "/accounts/1", nil)
+		req.Header.Set("Authorization", "Bearer admin-secret")
+		req.Header.Set(impersonation.KeyHeader, "cust_2")
+		req.Header.Set(impersonation.OperatorHeader, "alice")
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		if !strings.Contains(rr.Body.String(), `"code":"impersonation_read_only"`) {
+			t.Errorf("expected impersonation_read_only, got %s", rr.Body.String())
+		}
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/accounts/1", nil)
+		req.Header.Set("Authorization", "Bearer ")
+		req.Header.Set(impersonation.KeyHeader, "cust_2")
+		req.Header.Set(impersonation.OperatorHeader, "alice")
+		rr := httptest.NewRecorder()
+		server.Impersonate("")(served).ServeHTTP(rr, req)
+		if rr.Code != http.StatusForbidden {
+			t.Errorf("expected %d without an admin token, got %d", http.StatusForbidden, rr.Code)
+		}
+	})
+}
+
+func TestListImpersonations(t *testing.T) {
+	var gotFilter models.ImpersonationFilter
+	server := &api.Server{
+		Service: &mockService{
+			ListImpersonationsFn: func(filter models.ImpersonationFilter, cursor string, limit int) (*models.ImpersonationPage, error) {
+				if cursor == "bad" {
+					return nil, service.ErrInvalidCursor
+				}
+				gotFilter = filter
+				return &models.ImpersonationPage{
+					Impersonations: []models.Impersonation{{ID: 1, Operator: "alice", APIKey: "cust_2", Tenant: "default", Method: "GET", Path: "/accounts/1"}},
+					NextCursor:     "next",
+					HasMore:        true,
+				}, nil
+			},
+		},
+	}
+
+	tests := []struct {
+		name, url    string
+		expectedCode int
+	}{
+		{"List", "/admin/impersonations?operator=alice&api_key=cust_2&since=2026-03-01T00:00:00Z", http.StatusOK},
+		{"Invalid Since", "/admin/impersonations?since=yesterday", http.StatusBadRequest},
+		{"Invalid Cursor", "/admin/impersonations?cursor=bad", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			server.ListImpersonations(rr, httptest.NewRequest("GET", tt.url, nil))
+			if rr.Code != tt.expectedCode {
+				t.Fatalf("expected %d, got %d: %s", tt.expectedCode, rr.Code, rr.Body.String())
+			}
+		})
+	}
+
+	if gotFilter.Operator != "alice" || gotFilter.APIKey != "cust_2" || gotFilter.Since.IsZero() {
+		t.Errorf("unexpected filter %+v", gotFilter)
+	}
+}
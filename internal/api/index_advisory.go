@@ -0,0 +1,15 @@
+package api
+
+import "net/http"
+
+// GetIndexAdvisory serves the indexes the database lacks and the tables and
+// statements read mostly by sequential scans.
+func (s *Server) GetIndexAdvisory(w http.ResponseWriter, r *http.Request) {
+	report, err := s.Service.IndexAdvisory(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeResponse(w, r, report)
+}
@@ -0,0 +1,40 @@
+package api_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nehciyy/intrapay/internal/api"
+	"github.com/nehciyy/intrapay/internal/models"
+)
+
+func TestGetIndexAdvisory(t *testing.T) {
+	report := &models.IndexAdvisory{
+		MissingIndexes: []models.MissingIndex{{Name: "transactions_source_created_idx", Table: "transactions"}},
+		Tables:         []models.TableScans{{Table: "transactions", SeqScans: 40, SeqRowsRead: 4_000_000, IndexScans: 3, LiveRows: 100_000, SeqScanHeavy: true}},
+	}
+	var err error
+	server := &api.Server{Service: &mockService{
+		IndexAdvisoryFn: func() (*models.IndexAdvisory, error) { return report, err },
+	}}
+
+	rr := httptest.NewRecorder()
+	server.GetIndexAdvisory(rr, httptest.NewRequest("GET", "/admin/index-advisory", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	var got map[string]any
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+	assert.Equal(t, "transactions_source_created_idx", got["missing_indexes"].([]any)[0].(map[string]any)["name"])
+	assert.Equal(t, true, got["tables"].([]any)[0].(map[string]any)["seq_scan_heavy"])
+	assert.Nil(t, got["statements"])
+
+	err = errors.New("the index advisory is not enabled")
+	rr = httptest.NewRecorder()
+	server.GetIndexAdvisory(rr, httptest.NewRequest("GET", "/admin/index-advisory", nil))
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+}
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/nehciyy/intrapay/internal/metrics"
+)
+
+// statusRecorder captures the status code written by a handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// Instrument records request latency per route template, method and status code.
+func Instrument(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		route := "unmatched"
+		if current := mux.CurrentRoute(r); current != nil {
+			if tpl, err := current.GetPathTemplate(); err == nil {
+				route = tpl
+			}
+		}
+		metrics.ObserveHTTPRequest(route, r.Method, strconv.Itoa(rec.status), time.Since(start), traceID(r))
+	})
+}
+
+// RequestIDHeader carries the ID of a request, in both directions.
+const RequestIDHeader = "X-Request-ID"
+
+// RequestID gives every request an ID: the caller's X-Request-ID when it sends a
+// usable one, else the trace ID of its traceparent, else a random one. The ID is
+// set on the request, so handlers and the access log see it, and echoed in the
+// response's X-Request-ID header. Error envelopes quote it as request_id.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			if id = traceparentID(r); id == "" {
+				id = newRequestID()
+			}
+			r.Header.Set(RequestIDHeader, id)
+		}
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// validRequestID accepts up to 128 letters, digits and ".", "_", ":" or "-", so
+// IDs are safe to log and to put in a URL.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.ContainsRune("._:-", c)) {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// traceparentID returns the trace ID of a W3C traceparent header, or "".
+func traceparentID(r *http.Request) string {
+	// traceparent: version-traceid-parentid-flags
+	if parts := strings.Split(r.Header.Get("traceparent"), "-"); len(parts) == 4 && len(parts[1]) == 32 {
+		return parts[1]
+	}
+	return ""
+}
+
+// traceID extracts the trace ID from a W3C traceparent header, falling back to X-Request-ID.
+func traceID(r *http.Request) string {
+	if id := traceparentID(r); id != "" {
+		return id
+	}
+	return r.Header.Get(RequestIDHeader)
+}
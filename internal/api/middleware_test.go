@@ -0,0 +1,131 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lib/pq"
+
+	"github.com/nehciyy/intrapay/internal/metrics"
+	"github.com/nehciyy/intrapay/internal/repository"
+	"github.com/nehciyy/intrapay/internal/service"
+)
+
+func TestTraceID(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/accounts/1", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	req.Header.Set("X-Request-ID", "req-1")
+	if got := traceID(req); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("expected traceparent trace ID, got %q", got)
+	}
+
+	req.Header.Del("traceparent")
+	if got := traceID(req); got != "req-1" {
+		t.Errorf("expected X-Request-ID fallback, got %q", got)
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	var seen string
+	h := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = r.Header.Get(RequestIDHeader)
+		writeError(w, r, http.StatusNotFound, errorResponse{})
+	}))
+
+	tests := []struct {
+		name     string
+		header   string
+		expected string
+	}{
+		{name: "Caller's ID", header: "req-1", expected: "req-1"},
+		{name: "Unsafe ID Replaced", header: "req 1\n", expected: "4bf92f3577b34da6a3ce929d0e0e4736"},
+		{name: "Trace ID", expected: "4bf92f3577b34da6a3ce929d0e0e4736"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/accounts/1", nil)
+			req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+			if tt.header != "" {
+				req.Header.Set(RequestIDHeader, tt.header)
+			}
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+
+			if seen != tt.expected || rr.Header().Get(RequestIDHeader) != tt.expected {
+				t.Errorf("expected request ID %q on the request and response, got %q and %q", tt.expected, seen, rr.Header().Get(RequestIDHeader))
+			}
+			var body errorResponse
+			json.NewDecoder(rr.Body).Decode(&body)
+			if body.RequestID != tt.expected {
+				t.Errorf("expected request_id %q in the error envelope, got %q", tt.expected, body.RequestID)
+			}
+		})
+	}
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/accounts/1", nil))
+	if id := rr.Header().Get(RequestIDHeader); len(id) != 32 {
+		t.Errorf("expected a generated 32 character ID, got %q", id)
+	}
+}
+
+func TestTransactionOutcome(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected string
+	}{
+		{nil, metrics.OutcomeSuccess},
+		{fmt.Errorf("%w in account %d", service.ErrInsufficientBalance, 1), metrics.OutcomeInsufficientFunds},
+		{service.ErrRetriesExhausted, metrics.OutcomeRetryExhausted},
+		{fmt.Errorf("destination account %d %w", 2, service.ErrDestinationNotFound), metrics.OutcomeDestNotFound},
+		{fmt.Errorf("account with ID %d %w", 1, repository.ErrNotFound), metrics.OutcomeSourceNotFound},
+		{&pq.Error{Code: "25006"}, metrics.OutcomeRegionPassive},
+		{errors.New("boom"), metrics.OutcomeError},
+	}
+
+	for _, tt := range tests {
+		if got := transactionOutcome(tt.err); got != tt.expected {
+			t.Errorf("transactionOutcome(%v) = %q, expected %q", tt.err, got, tt.expected)
+		}
+	}
+}
+
+func TestPassiveRegion(t *testing.T) {
+	active := false
+	h := PassiveRegion(func() bool { return active })(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		method   string
+		path     string
+		expected int
+	}{
+		{http.MethodGet, "/accounts/1", http.StatusNoContent},
+		{http.MethodHead, "/accounts/1", http.StatusNoContent},
+		{http.MethodPost, "/transactions/lookup", http.StatusNoContent},
+		{http.MethodPost, "/transactions", http.StatusServiceUnavailable},
+		{http.MethodDelete, "/accounts/1", http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.path, nil))
+		if rr.Code != tt.expected {
+			t.Errorf("%s %s: expected %d, got %d", tt.method, tt.path, tt.expected, rr.Code)
+		}
+		if rr.Code == http.StatusServiceUnavailable && rr.Header().Get("Retry-After") != "5" {
+			t.Errorf("%s %s: expected Retry-After 5, got %q", tt.method, tt.path, rr.Header().Get("Retry-After"))
+		}
+	}
+
+	active = true
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/transactions", nil))
+	if rr.Code != http.StatusNoContent {
+		t.Errorf("expected writes to pass in the active region, got %d", rr.Code)
+	}
+}
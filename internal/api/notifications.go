@@ -0,0 +1,74 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/nehciyy/intrapay/internal/metering"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository"
+	"github.com/nehciyy/intrapay/internal/service"
+)
+
+// Notification preferences belong to the owner of the account, the API key
+// that opened it (X-API-Key); other callers are answered with 403, and those of
+// other tenants with 404.
+
+// GetNotificationPreference returns how the notifications of an account are
+// sent to its webhooks.
+func (s *Server) GetNotificationPreference(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "invalid account ID", http.StatusBadRequest)
+		return
+	}
+
+	_, tenant := metering.Caller(r)
+	pref, err := s.Service.GetNotificationPreference(r.Context(), tenant, callerKey(r), id)
+	if err != nil {
+		writeNotificationError(w, err)
+		return
+	}
+	writeResponse(w, r, pref)
+}
+
+// SetNotificationPreference sets whether the notifications of an account are
+// sent as they happen or summed up in a daily digest.
+func (s *Server) SetNotificationPreference(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "invalid account ID", http.StatusBadRequest)
+		return
+	}
+
+	req := &models.NotificationPreferenceRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	_, tenant := metering.Caller(r)
+	pref, err := s.Service.SetNotificationPreference(r.Context(), tenant, callerKey(r), id, *req)
+	if err != nil {
+		writeNotificationError(w, err)
+		return
+	}
+	writeResponse(w, r, pref)
+}
+
+func writeNotificationError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, service.ErrInvalidNotificationMode):
+		status = http.StatusBadRequest
+	case errors.Is(err, repository.ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, service.ErrNotAccountOwner):
+		status = http.StatusForbidden
+	}
+	http.Error(w, err.Error(), status)
+}
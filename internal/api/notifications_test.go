@@ -0,0 +1,94 @@
+package api_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/nehciyy/intrapay/internal/api"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository"
+	"github.com/nehciyy/intrapay/internal/service"
+)
+
+func TestNotificationPreference(t *testing.T) {
+	// Account 42 is key_alice's; account 404 is not found.
+	owned := func(apiKey string, accountID int64) error {
+		switch {
+		case accountID == 404:
+			return fmt.Errorf("account with ID %d %w", accountID, repository.ErrNotFound)
+		case apiKey != "key_alice":
+			return fmt.Errorf("%w %d", service.ErrNotAccountOwner, accountID)
+		}
+		return nil
+	}
+	server := &api.Server{
+		Service: &mockService{
+			GetNotificationPreferenceFn: func(tenant, apiKey string, accountID int64) (*models.NotificationPreference, error) {
+				if err := owned(apiKey, accountID); err != nil {
+					return nil, err
+				}
+				return &models.NotificationPreference{AccountID: accountID, Mode: models.NotificationImmediate}, nil
+			},
+			SetNotificationPreferenceFn: func(tenant, apiKey string, accountID int64, req models.NotificationPreferenceRequest) (*models.NotificationPreference, error) {
+				if req.Mode != models.NotificationImmediate && req.Mode != models.NotificationDailyDigest {
+					return nil, fmt.Errorf("%w: unknown mode", service.ErrInvalidNotificationMode)
+				}
+				if err := owned(apiKey, accountID); err != nil {
+					return nil, err
+				}
+				return &models.NotificationPreference{AccountID: accountID, Mode: req.Mode}, nil
+			},
+		},
+	}
+	router := mux.NewRouter()
+	router.HandleFunc("/accounts/{id}/notifications", server.GetNotificationPreference).Methods("GET")
+	router.HandleFunc("/accounts/{id}/notifications", server.SetNotificationPreference).Methods("PUT")
+
+	req := httptest.NewRequest("PUT", "/accounts/42/notifications", strings.NewReader(`{"mode": "daily_digest"}`))
+	req.Header.Set("X-API-Key", "key_alice")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var pref models.NotificationPreference
+	json.NewDecoder(rr.Body).Decode(&pref)
+	if pref.Mode != models.NotificationDailyDigest {
+		t.Errorf("expected mode daily_digest, got %q", pref.Mode)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		apiKey string
+		code   int
+	}{
+		{"Get", "GET", "/accounts/42/notifications", "", "key_alice", http.StatusOK},
+		{"Not the owner", "PUT", "/accounts/42/notifications", `{"mode": "daily_digest"}`, "key_bob", http.StatusForbidden},
+		{"Anonymous", "GET", "/accounts/42/notifications", "", "", http.StatusForbidden},
+		{"Unknown account", "GET", "/accounts/404/notifications", "", "key_alice", http.StatusNotFound},
+		{"Unknown mode", "PUT", "/accounts/42/notifications", `{"mode": "weekly"}`, "key_alice", http.StatusBadRequest},
+		{"Malformed body", "PUT", "/accounts/42/notifications", `{"mode": `, "key_alice", http.StatusBadRequest},
+		{"Invalid account ID", "GET", "/accounts/abc/notifications", "", "key_alice", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.apiKey != "" {
+				req.Header.Set("X-API-Key", tt.apiKey)
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			if rr.Code != tt.code {
+				t.Errorf("expected %d, got %d: %s", tt.code, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
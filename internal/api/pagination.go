@@ -0,0 +1,80 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Pagination convention shared by every list endpoint:
+//
+//   - ?limit= sets the page size (default 100, maximum 1000).
+//   - ?cursor= is the opaque position returned for the previous page; omit it
+//     for the first page.
+//   - Links to the first and next pages are returned in an RFC 5988 Link header,
+//     built from the request URL so filters carry over. The last page has no
+//     rel="next" link.
+//   - ?include_total=true adds X-Total-Count, the number of items matching the
+//     filters across all pages. It costs an extra COUNT query, so it is opt-in.
+const (
+	defaultListLimit = 100
+	maxListLimit     = 1000
+)
+
+var errInvalidLimit = fmt.Errorf("invalid limit, expected 1-%d", maxListLimit)
+
+// pageRequest holds the pagination parameters of a list request.
+type pageRequest struct {
+	Cursor       string
+	Limit        int
+	IncludeTotal bool
+}
+
+func parsePageRequest(r *http.Request) (pageRequest, error) {
+	q := r.URL.Query()
+	page := pageRequest{Cursor: q.Get("cursor"), Limit: defaultListLimit}
+
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > maxListLimit {
+			return page, errInvalidLimit
+		}
+		page.Limit = n
+	}
+	if v := q.Get("include_total"); v != "" {
+		var err error
+		if page.IncludeTotal, err = strconv.ParseBool(v); err != nil {
+			return page, errors.New("invalid include_total flag")
+		}
+	}
+	return page, nil
+}
+
+// setPageHeaders writes the Link header and, when total is non-nil, X-Total-Count.
+// It must be called before the body is written.
+func setPageHeaders(w http.ResponseWriter, r *http.Request, nextCursor string, hasMore bool, total *int64) {
+	links := []string{fmt.Sprintf(`<%s>; rel="first"`, pageURL(r, ""))}
+	if hasMore && nextCursor != "" {
+		links = append(links, fmt.Sprintf(`<%s>; rel="next"`, pageURL(r, nextCursor)))
+	}
+	w.Header().Set("Link", strings.Join(links, ", "))
+
+	if total != nil {
+		w.Header().Set("X-Total-Count", strconv.FormatInt(*total, 10))
+	}
+}
+
+// pageURL returns the request path and query with the cursor replaced.
+func pageURL(r *http.Request, cursor string) string {
+	q := r.URL.Query()
+	q.Del("cursor")
+	if cursor != "" {
+		q.Set("cursor", cursor)
+	}
+	u := *r.URL
+	u.Scheme, u.Host = "", ""
+	u.RawQuery = q.Encode()
+	return u.String()
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePageRequest(t *testing.T) {
+	tests := []struct {
+		name        string
+		url         string
+		expected    pageRequest
+		expectedErr bool
+	}{
+		{name: "Defaults", url: "/things", expected: pageRequest{Limit: defaultListLimit}},
+		{name: "All Set", url: "/things?cursor=abc&limit=5&include_total=true", expected: pageRequest{Cursor: "abc", Limit: 5, IncludeTotal: true}},
+		{name: "Zero Limit", url: "/things?limit=0", expectedErr: true},
+		{name: "Limit Above Max", url: "/things?limit=1001", expectedErr: true},
+		{name: "Bad Total Flag", url: "/things?include_total=yes", expectedErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parsePageRequest(httptest.NewRequest("GET", tt.url, nil))
+			if tt.expectedErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestSetPageHeaders(t *testing.T) {
+	r := httptest.NewRequest("GET", "http://example.com/things?cursor=old&limit=2", nil)
+
+	rr := httptest.NewRecorder()
+	total := int64(5)
+	setPageHeaders(rr, r, "new", true, &total)
+	assert.Equal(t, `</things?limit=2>; rel="first", </things?cursor=new&limit=2>; rel="next"`, rr.Header().Get("Link"))
+	assert.Equal(t, "5", rr.Header().Get("X-Total-Count"))
+
+	rr = httptest.NewRecorder()
+	setPageHeaders(rr, r, "new", false, nil)
+	assert.Equal(t, `</things?limit=2>; rel="first"`, rr.Header().Get("Link"))
+	assert.Empty(t, rr.Header().Get("X-Total-Count"))
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/nehciyy/intrapay/internal/metering"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/service"
+)
+
+// payrollRejection is the body of a refused payroll commit: the error with the
+// diagnostics of the batch.
+type payrollRejection struct {
+	errorResponse
+	Preview *models.PayrollPreview `json:"preview"`
+}
+
+// PreviewPayroll validates a payroll batch without posting it. The preview is
+// answered with 200 whether or not the batch is valid; see its valid field.
+func (s *Server) PreviewPayroll(w http.ResponseWriter, r *http.Request) {
+	req := &models.PayrollRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Read from the primary: a preview against a lagging replica could pass a
+	// batch the commit then refuses.
+	preview, err := s.Service.PreviewPayroll(r.Context(), *req)
+	if err != nil {
+		writePayrollError(w, r, err)
+		return
+	}
+
+	writeResponse(w, r, preview)
+}
+
+// CommitPayroll posts a payroll batch as a whole. A batch that fails validation
+// is refused with 422 and the diagnostics of every failing line.
+func (s *Server) CommitPayroll(w http.ResponseWriter, r *http.Request) {
+	req := &models.PayrollRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var total models.Amount
+	for _, line := range req.Lines {
+		total += line.Amount
+	}
+	var quotaErr *metering.QuotaError
+	if err := metering.CheckVolume(r.Context(), float64(total)); errors.As(err, &quotaErr) {
+		writeError(w, r, http.StatusTooManyRequests, errorResponse{Error: quotaErr.Error(), Code: quotaErr.Code})
+		return
+	}
+
+	result, err := s.Service.CommitPayroll(r.Context(), *req)
+	if err != nil {
+		writePayrollError(w, r, err)
+		return
+	}
+	metering.AddVolume(r.Context(), float64(result.Total))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(result)
+}
+
+func writePayrollError(w http.ResponseWriter, r *http.Request, err error) {
+	var rejected *service.PayrollRejectedError
+	switch {
+	case errors.Is(err, service.ErrInvalidPayroll):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.As(err, &rejected):
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(payrollRejection{
+			errorResponse: errorResponse{
+				Error:     err.Error(),
+				Status:    http.StatusUnprocessableEntity,
+				Code:      rejected.Code(),
+				RequestID: r.Header.Get(RequestIDHeader),
+			},
+			Preview: rejected.Preview,
+		})
+	default:
+		writeTransferError(w, r, err)
+	}
+}
@@ -0,0 +1,110 @@
+package api_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/nehciyy/intrapay/internal/api"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/service"
+)
+
+func payrollRouter(server *api.Server) *mux.Router {
+	router := mux.NewRouter()
+	router.HandleFunc("/payroll", server.CommitPayroll).Methods("POST")
+	router.HandleFunc("/payroll/preview", server.PreviewPayroll).Methods("POST")
+	return router
+}
+
+// payrollPreview passes every line of req except those paying account 404.
+func payrollPreview(req models.PayrollRequest) (*models.PayrollPreview, error) {
+	if len(req.Lines) == 0 {
+		return nil, fmt.Errorf("%w: no lines", service.ErrInvalidPayroll)
+	}
+	preview := &models.PayrollPreview{EmployerAccountID: req.EmployerAccountID, Lines: len(req.Lines), LineErrors: []models.PayrollLineError{}}
+	for i, line := range req.Lines {
+		preview.Total += line.Amount
+		if line.AccountID == 404 {
+			preview.LineErrors = append(preview.LineErrors, models.PayrollLineError{
+				Line: i, AccountID: line.AccountID, Code: models.ReasonDestinationNotFound, Error: "account 404 not found",
+			})
+		}
+	}
+	preview.Valid = len(preview.LineErrors) == 0
+	return preview, nil
+}
+
+func TestPayroll(t *testing.T) {
+	server := &api.Server{
+		Service: &mockService{
+			PreviewPayrollFn: payrollPreview,
+			CommitPayrollFn: func(req models.PayrollRequest) (*models.PayrollResult, error) {
+				preview, err := payrollPreview(req)
+				if err != nil {
+					return nil, err
+				}
+				if !preview.Valid {
+					return nil, &service.PayrollRejectedError{Preview: preview}
+				}
+				result := &models.PayrollResult{EmployerAccountID: req.EmployerAccountID, Total: preview.Total}
+				for i := range req.Lines {
+					result.TransactionIDs = append(result.TransactionIDs, fmt.Sprintf("txn_%d", i))
+				}
+				return result, nil
+			},
+		},
+	}
+
+	const valid = `{"employer_account_id": 1, "lines": [{"account_id": 2, "amount": "1000.00"}, {"account_id": 3, "amount": "1500.00"}]}`
+	const invalid = `{"employer_account_id": 1, "lines": [{"account_id": 2, "amount": "1000.00"}, {"account_id": 404, "amount": "1500.00"}]}`
+	tests := []struct {
+		name string
+		url  string
+		body string
+		code int
+	}{
+		{"Preview valid batch", "/payroll/preview", valid, http.StatusOK},
+		{"Preview invalid batch", "/payroll/preview", invalid, http.StatusOK},
+		{"Preview empty batch", "/payroll/preview", `{"employer_account_id": 1, "lines": []}`, http.StatusBadRequest},
+		{"Commit valid batch", "/payroll", valid, http.StatusCreated},
+		{"Commit invalid batch", "/payroll", invalid, http.StatusUnprocessableEntity},
+		{"Commit empty batch", "/payroll", `{"employer_account_id": 1}`, http.StatusBadRequest},
+		{"Malformed body", "/payroll", `{"lines": `, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			payrollRouter(server).ServeHTTP(rr, httptest.NewRequest("POST", tt.url, strings.NewReader(tt.body)))
+			if rr.Code != tt.code {
+				t.Errorf("expected %d, got %d: %s", tt.code, rr.Code, rr.Body.String())
+			}
+		})
+	}
+
+	rr := httptest.NewRecorder()
+	payrollRouter(server).ServeHTTP(rr, httptest.NewRequest("POST", "/payroll", strings.NewReader(valid)))
+	var result models.PayrollResult
+	json.NewDecoder(rr.Body).Decode(&result)
+	if result.Total != 2500 || len(result.TransactionIDs) != 2 {
+		t.Errorf("expected a total of 2500.00 over 2 transactions, got %+v", result)
+	}
+
+	rr = httptest.NewRecorder()
+	payrollRouter(server).ServeHTTP(rr, httptest.NewRequest("POST", "/payroll", strings.NewReader(invalid)))
+	var rejection struct {
+		Code    models.ReasonCode      `json:"code"`
+		Preview *models.PayrollPreview `json:"preview"`
+	}
+	json.NewDecoder(rr.Body).Decode(&rejection)
+	if rejection.Code != models.ReasonDestinationNotFound {
+		t.Errorf("expected code %q, got %q", models.ReasonDestinationNotFound, rejection.Code)
+	}
+	if rejection.Preview == nil || len(rejection.Preview.LineErrors) != 1 || rejection.Preview.LineErrors[0].Line != 1 {
+		t.Errorf("expected a diagnostic for line 1, got %+v", rejection.Preview)
+	}
+}
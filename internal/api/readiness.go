@@ -0,0 +1,24 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/nehciyy/intrapay/internal/selfcheck"
+)
+
+// Readiness serves the self-check report returned by report: 200 while every
+// check passes, possibly with warnings, and 503 while one fails, for load
+// balancers and orchestrators to route by. It needs no authentication.
+func Readiness(report func(ctx context.Context) selfcheck.Report) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rep := report(r.Context())
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		if !rep.Ready {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(rep)
+	}
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/service"
+)
+
+// regionPassiveBackoff is how long clients are asked to wait after writing to a
+// passive region. A failover takes a while; clients with an endpoint in the
+// active region should switch to it instead.
+const regionPassiveBackoff = 5 * time.Second
+
+// readOnlyPosts are POST endpoints that only read, so a passive region serves them.
+var readOnlyPosts = map[string]bool{
+	"/transactions/lookup": true,
+}
+
+// PassiveRegion rejects writes with 503 region_passive while active reports that
+// this server's region is not the active one. Reads are served either way.
+func PassiveRegion(active func() bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case r.Method == http.MethodGet, r.Method == http.MethodHead, r.Method == http.MethodOptions:
+			case r.Method == http.MethodPost && readOnlyPosts[r.URL.Path]:
+			case !active():
+				writeRetryable(w, r, http.StatusServiceUnavailable, "this region is passive and does not accept writes",
+					models.ReasonRegionPassive, regionPassiveBackoff)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// GetRegion reports the region fence: which region is active, its fencing token
+// and whether it is this server's region.
+func (s *Server) GetRegion(w http.ResponseWriter, r *http.Request) {
+	status, err := s.Service.RegionStatus()
+	writeRegionStatus(w, r, status, err)
+}
+
+// PromoteRegion makes the region in the body the active region, provided the
+// epoch in the body is still the fencing token. It answers once the writes in
+// flight have finished; every later write from another region is rejected.
+func (s *Server) PromoteRegion(w http.ResponseWriter, r *http.Request) {
+	req := &models.PromoteRegionRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	status, err := s.Service.PromoteRegion(req.Region, req.Epoch)
+	writeRegionStatus(w, r, status, err)
+}
+
+// writeRegionStatus answers with the region status, or with the error of the
+// call that produced it.
+func writeRegionStatus(w http.ResponseWriter, r *http.Request, status *models.RegionStatus, err error) {
+	if err != nil {
+		code := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrInvalidRegion):
+			code = http.StatusBadRequest
+		case errors.Is(err, service.ErrStaleEpoch):
+			code = http.StatusConflict
+		}
+		http.Error(w, err.Error(), code)
+		return
+	}
+	writeResponse(w, r, status)
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/nehciyy/intrapay/internal/metering"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository"
+	"github.com/nehciyy/intrapay/internal/service"
+)
+
+// Reimbursements belong to the caller's tenant (X-Tenant-ID); those of other
+// tenants are answered with 404. They are approved or rejected through the
+// admin API.
+
+// CreateReimbursement requests the reimbursement of an expense to an employee
+// account. It is answered with 201 and the reimbursement, pending approval.
+func (s *Server) CreateReimbursement(w http.ResponseWriter, r *http.Request) {
+	req := &models.ReimbursementRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	_, tenant := metering.Caller(r)
+	reimbursement, err := s.Service.SubmitReimbursement(r.Context(), tenant, *req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrInvalidReimbursement):
+			status = http.StatusBadRequest
+		case errors.Is(err, repository.ErrNotFound):
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.Header().Set("Location", "/reimbursements/"+strconv.FormatInt(reimbursement.ID, 10))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(reimbursement)
+}
+
+func (s *Server) GetReimbursement(w http.ResponseWriter, r *http.Request) {
+	id, ok := reimbursementID(w, r)
+	if !ok {
+		return
+	}
+	_, tenant := metering.Caller(r)
+	reimbursement, err := s.Service.GetReimbursement(r.Context(), id, tenant)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, repository.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	writeResponse(w, r, reimbursement)
+}
+
+func reimbursementID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "invalid reimbursement ID", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
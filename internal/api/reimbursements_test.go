@@ -0,0 +1,118 @@
+package api_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/nehciyy/intrapay/internal/api"
+	"github.com/nehciyy/intrapay/internal/metering"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository"
+	"github.com/nehciyy/intrapay/internal/service"
+)
+
+func reimbursementRouter(server *api.Server) *mux.Router {
+	router := mux.NewRouter()
+	router.HandleFunc("/reimbursements", server.CreateReimbursement).Methods("POST")
+	router.HandleFunc("/reimbursements/{id}", server.GetReimbursement).Methods("GET")
+	router.HandleFunc("/admin/reimbursements/{id}/approve", server.ApproveReimbursement).Methods("POST")
+	router.HandleFunc("/admin/reimbursements/{id}/reject", server.RejectReimbursement).Methods("POST")
+	return router
+}
+
+func TestCreateReimbursement(t *testing.T) {
+	server := &api.Server{
+		Service: &mockService{
+			SubmitReimbursementFn: func(tenant string, req models.ReimbursementRequest) (*models.Reimbursement, error) {
+				switch {
+				case req.Amount <= 0:
+					return nil, fmt.Errorf("%w: amount must be positive", service.ErrInvalidReimbursement)
+				case req.AccountID == 404:
+					return nil, fmt.Errorf("account with ID %d %w", req.AccountID, repository.ErrNotFound)
+				}
+				return &models.Reimbursement{ID: 7, Tenant: tenant, AccountID: req.AccountID, Amount: req.Amount, Status: models.ReimbursementPending}, nil
+			},
+		},
+	}
+
+	req := httptest.NewRequest("POST", "/reimbursements", strings.NewReader(`{"account_id": 42, "amount": "89.50", "description": "Train", "receipt": {"merchant": "Rail Co"}}`))
+	req.Header.Set(metering.TenantHeader, "payroll")
+	rr := httptest.NewRecorder()
+	reimbursementRouter(server).ServeHTTP(rr, req)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", rr.Code)
+	}
+	if loc := rr.Header().Get("Location"); loc != "/reimbursements/7" {
+		t.Errorf("expected Location /reimbursements/7, got %q", loc)
+	}
+	var reimbursement models.Reimbursement
+	json.NewDecoder(rr.Body).Decode(&reimbursement)
+	if reimbursement.Tenant != "payroll" || reimbursement.Status != models.ReimbursementPending {
+		t.Errorf("expected a pending reimbursement of the caller's tenant, got %+v", reimbursement)
+	}
+
+	for body, want := range map[string]int{
+		`{"account_id": 42, "amount": "0", "description": "Train"}`:   http.StatusBadRequest,
+		`{"account_id": 404, "amount": "10", "description": "Train"}`: http.StatusNotFound,
+		`{"account_id": `: http.StatusBadRequest,
+	} {
+		rr = httptest.NewRecorder()
+		reimbursementRouter(server).ServeHTTP(rr, httptest.NewRequest("POST", "/reimbursements", strings.NewReader(body)))
+		if rr.Code != want {
+			t.Errorf("%s: expected %d, got %d", body, want, rr.Code)
+		}
+	}
+}
+
+func TestDecideReimbursement(t *testing.T) {
+	decide := func(status models.ReimbursementStatus) func(id int64, d models.ReimbursementDecision) (*models.Reimbursement, error) {
+		return func(id int64, d models.ReimbursementDecision) (*models.Reimbursement, error) {
+			switch {
+			case d.DecidedBy == "":
+				return nil, service.ErrMissingDecider
+			case id == 404:
+				return nil, fmt.Errorf("reimbursement %d %w", id, repository.ErrNotFound)
+			case id == 409:
+				return nil, fmt.Errorf("reimbursement %d %w", id, repository.ErrAlreadyResolved)
+			case id == 422:
+				return nil, fmt.Errorf("%w in account 99", service.ErrInsufficientBalance)
+			}
+			return &models.Reimbursement{ID: id, Status: status, DecidedBy: d.DecidedBy}, nil
+		}
+	}
+	server := &api.Server{
+		Service: &mockService{
+			ApproveReimbursementFn: decide(models.ReimbursementApproved),
+			RejectReimbursementFn:  decide(models.ReimbursementRejected),
+		},
+	}
+
+	tests := []struct {
+		name string
+		url  string
+		body string
+		code int
+	}{
+		{"Approve", "/admin/reimbursements/7/approve", `{"decided_by": "alice"}`, http.StatusOK},
+		{"Reject", "/admin/reimbursements/7/reject", `{"decided_by": "alice", "note": "duplicate"}`, http.StatusOK},
+		{"Missing decider", "/admin/reimbursements/7/approve", ``, http.StatusBadRequest},
+		{"Invalid ID", "/admin/reimbursements/abc/approve", `{"decided_by": "alice"}`, http.StatusBadRequest},
+		{"Not found", "/admin/reimbursements/404/approve", `{"decided_by": "alice"}`, http.StatusNotFound},
+		{"Already decided", "/admin/reimbursements/409/reject", `{"decided_by": "alice"}`, http.StatusConflict},
+		{"Insufficient funds", "/admin/reimbursements/422/approve", `{"decided_by": "alice"}`, http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			reimbursementRouter(server).ServeHTTP(rr, httptest.NewRequest("POST", tt.url, strings.NewReader(tt.body)))
+			if rr.Code != tt.code {
+				t.Errorf("expected %d, got %d: %s", tt.code, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package api
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/models"
+)
+
+// retryExhaustedBackoff is how long clients are asked to wait after a transfer lost
+// every serialization retry. Contention on a hot account usually clears quickly.
+const retryExhaustedBackoff = 250 * time.Millisecond
+
+// writeRetryable rejects a request that is worth retrying later: rate limited (429),
+// unavailable (503) or conflicting (409). Retry-After carries the delay in whole
+// seconds as HTTP requires, and retry_in_ms the precise hint. code may be empty.
+func writeRetryable(w http.ResponseWriter, r *http.Request, status int, msg string, code models.ReasonCode, retryIn time.Duration) {
+	seconds := int64(math.Ceil(retryIn.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	w.Header().Set("Retry-After", strconv.FormatInt(seconds, 10))
+	writeError(w, r, status, errorResponse{Error: msg, Code: code, RetryInMs: retryIn.Milliseconds()})
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository"
+	"github.com/nehciyy/intrapay/internal/service"
+)
+
+const defaultRevaluations = 30
+
+// PutFXRate sets the exchange rate of the ledger currency in the base currency
+// at the close of the day in the path.
+func (s *Server) PutFXRate(w http.ResponseWriter, r *http.Request) {
+	req := &models.FXRateRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rate, err := s.Service.PutFXRate(mux.Vars(r)["date"], *req)
+	if err != nil {
+		writeRevaluationError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rate)
+}
+
+// CreateRevaluation revalues the balances held at the close of a day. It is
+// answered with 201 and the revaluation.
+func (s *Server) CreateRevaluation(w http.ResponseWriter, r *http.Request) {
+	req := &models.RevaluationRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rv, err := s.Service.Revalue(req.Date)
+	if err != nil {
+		writeRevaluationError(w, err)
+		return
+	}
+
+	w.Header().Set("Location", "/admin/revaluations/"+strconv.FormatInt(rv.ID, 10))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(rv)
+}
+
+// ListRevaluations returns the ?limit= latest revaluations, 30 by default,
+// latest first.
+func (s *Server) ListRevaluations(w http.ResponseWriter, r *http.Request) {
+	limit, err := intParam(r, "limit", defaultRevaluations)
+	if err != nil {
+		http.Error(w, "invalid limit", http.StatusBadRequest)
+		return
+	}
+
+	revaluations, err := s.Service.ListRevaluations(readOnly(r), limit)
+	if err != nil {
+		writeRevaluationError(w, err)
+		return
+	}
+
+	writeResponse(w, r, revaluations)
+}
+
+// GetRevaluation returns a revaluation with its totals by account type.
+func (s *Server) GetRevaluation(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "invalid revaluation ID", http.StatusBadRequest)
+		return
+	}
+
+	rv, err := s.Service.GetRevaluation(readOnly(r), id)
+	if err != nil {
+		writeRevaluationError(w, err)
+		return
+	}
+
+	writeResponse(w, r, rv)
+}
+
+// GetFXRates returns the live rates of the ledger currency in the
+// comma-separated ?currencies=, the base currency by default. Rates older than
+// the staleness threshold are flagged "stale".
+func (s *Server) GetFXRates(w http.ResponseWriter, r *http.Request) {
+	var currencies []string
+	if v := r.URL.Query().Get("currencies"); v != "" {
+		currencies = strings.Split(v, ",")
+	}
+
+	quotes, err := s.Service.FXRates(r.Context(), currencies)
+	if err != nil {
+		writeRevaluationError(w, err)
+		return
+	}
+
+	writeResponse(w, r, quotes)
+}
+
+func writeRevaluationError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, service.ErrInvalidFXRate), errors.Is(err, service.ErrInvalidRevaluation):
+		status = http.StatusBadRequest
+	case errors.Is(err, service.ErrNoFXRate), errors.Is(err, service.ErrStaleFXRate):
+		status = http.StatusUnprocessableEntity
+	case errors.Is(err, service.ErrFXRateUnavailable):
+		status = http.StatusServiceUnavailable
+	case errors.Is(err, repository.ErrAlreadyRevalued):
+		status = http.StatusConflict
+	case errors.Is(err, repository.ErrNotFound):
+		status = http.StatusNotFound
+	}
+	http.Error(w, err.Error(), status)
+}
@@ -0,0 +1,159 @@
+package api_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/nehciyy/intrapay/internal/api"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository"
+	"github.com/nehciyy/intrapay/internal/service"
+)
+
+func TestRevaluations(t *testing.T) {
+	previous := 1.08
+	revaluation := &models.Revaluation{
+		ID: 7, Date: "2026-06-14", Currency: "EUR", BaseCurrency: "JPY", Rate: 170.25, PreviousRate: &previous,
+		Balance: 1000, BaseValue: 170250, Gain: 12, Loss: 40.6,
+		Positions: []models.RevaluationPosition{{AccountType: models.AccountTypeCustomer, Accounts: 2, Balance: 1000, BaseValue: 170250, GainLoss: 28.6}},
+	}
+	server := &api.Server{
+		Service: &mockService{
+			PutFXRateFn: func(date string, req models.FXRateRequest) (*models.FXRate, error) {
+				if req.Rate <= 0 {
+					return nil, fmt.Errorf("%w: rate must be positive", service.ErrInvalidFXRate)
+				}
+				return &models.FXRate{Currency: "EUR", BaseCurrency: "JPY", Date: date, Rate: req.Rate}, nil
+			},
+			RevalueFn: func(date string) (*models.Revaluation, error) {
+				switch date {
+				case "2026-06-14":
+					return revaluation, nil
+				case "2026-06-13":
+					return nil, fmt.Errorf("%s %w", date, repository.ErrAlreadyRevalued)
+				case "2026-06-15":
+					return nil, fmt.Errorf("%w: EUR/JPY of %s", service.ErrNoFXRate, date)
+				}
+				return nil, fmt.Errorf("%w: date must be YYYY-MM-DD", service.ErrInvalidRevaluation)
+			},
+			GetRevaluationFn: func(id int64) (*models.Revaluation, error) {
+				if id != 7 {
+					return nil, fmt.Errorf("revaluation %d %w", id, repository.ErrNotFound)
+				}
+				return revaluation, nil
+			},
+			ListRevaluationsFn: func(limit int) ([]models.Revaluation, error) {
+				return []models.Revaluation{*revaluation}, nil
+			},
+		},
+	}
+	router := mux.NewRouter()
+	router.HandleFunc("/admin/fx/rates/{date}", server.PutFXRate).Methods("PUT")
+	router.HandleFunc("/admin/revaluations", server.CreateRevaluation).Methods("POST")
+	router.HandleFunc("/admin/revaluations", server.ListRevaluations).Methods("GET")
+	router.HandleFunc("/admin/revaluations/{id}", server.GetRevaluation).Methods("GET")
+
+	tests := []struct {
+		name, method, url, body string
+		expectedCode            int
+	}{
+		{"Put Rate", "PUT", "/admin/fx/rates/2026-06-14", `{"rate": 170.25}`, http.StatusOK},
+		{"Invalid Rate", "PUT", "/admin/fx/rates/2026-06-14", `{"rate": 0}`, http.StatusBadRequest},
+		{"Revalue", "POST", "/admin/revaluations", `{"date": "2026-06-14"}`, http.StatusCreated},
+		{"Already Revalued", "POST", "/admin/revaluations", `{"date": "2026-06-13"}`, http.StatusConflict},
+		{"No Rate", "POST", "/admin/revaluations", `{"date": "2026-06-15"}`, http.StatusUnprocessableEntity},
+		{"Invalid Date", "POST", "/admin/revaluations", `{"date": "14/06/2026"}`, http.StatusBadRequest},
+		{"List", "GET", "/admin/revaluations", "", http.StatusOK},
+		{"Invalid Limit", "GET", "/admin/revaluations?limit=x", "", http.StatusBadRequest},
+		{"Get", "GET", "/admin/revaluations/7", "", http.StatusOK},
+		{"Get Missing", "GET", "/admin/revaluations/8", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.body)))
+			if rr.Code != tt.expectedCode {
+				t.Errorf("expected %d, got %d: %s", tt.expectedCode, rr.Code, rr.Body.String())
+			}
+		})
+	}
+
+	// Base currency amounts take the base currency's minor units: none for JPY.
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/admin/revaluations/7", nil))
+	var body map[string]interface{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body["base_value"] != "170250" || body["net"] != "-29" || body["gain"] != "12" {
+		t.Errorf("expected base amounts in whole yen, got %v", body)
+	}
+	positions := body["positions"].([]interface{})
+	if p := positions[0].(map[string]interface{}); p["gain_loss"] != "29" {
+		t.Errorf("expected the customer gain_loss in whole yen, got %v", p)
+	}
+}
+
+func TestFXRates(t *testing.T) {
+	server := &api.Server{
+		Service: &mockService{
+			FXRatesFn: func(currencies []string) ([]models.FXQuote, error) {
+				if len(currencies) == 0 {
+					currencies = []string{"EUR"}
+				}
+				quotes := make([]models.FXQuote, len(currencies))
+				for i, c := range currencies {
+					switch c {
+					case "EUR", "GBP":
+						quotes[i] = models.FXQuote{Currency: "USD", BaseCurrency: c, Rate: 0.9, Stale: c == "GBP"}
+					case "JPY":
+						return nil, fmt.Errorf("%w: USD/JPY: connection refused", service.ErrFXRateUnavailable)
+					default:
+						return nil, fmt.Errorf("%w: unknown currency %q", service.ErrInvalidFXRate, c)
+					}
+				}
+				return quotes, nil
+			},
+		},
+	}
+	router := mux.NewRouter()
+	router.HandleFunc("/fx/rates", server.GetFXRates).Methods("GET")
+
+	tests := []struct {
+		name, url    string
+		expectedCode int
+		expectedLen  int
+	}{
+		{"Base Currency", "/fx/rates", http.StatusOK, 1},
+		{"Currencies", "/fx/rates?currencies=EUR,GBP", http.StatusOK, 2},
+		{"Invalid Currency", "/fx/rates?currencies=EURO", http.StatusBadRequest, 0},
+		{"Provider Down", "/fx/rates?currencies=JPY", http.StatusServiceUnavailable, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest("GET", tt.url, nil))
+			if rr.Code != tt.expectedCode {
+				t.Fatalf("expected %d, got %d: %s", tt.expectedCode, rr.Code, rr.Body.String())
+			}
+			if tt.expectedCode != http.StatusOK {
+				return
+			}
+			var quotes []map[string]interface{}
+			if err := json.Unmarshal(rr.Body.Bytes(), &quotes); err != nil {
+				t.Fatal(err)
+			}
+			if len(quotes) != tt.expectedLen {
+				t.Errorf("expected %d rates, got %v", tt.expectedLen, quotes)
+			}
+			if _, ok := quotes[0]["stale"]; !ok {
+				t.Errorf("expected rates to carry the stale flag, got %v", quotes[0])
+			}
+		})
+	}
+}
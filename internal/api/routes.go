@@ -0,0 +1,139 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/nehciyy/intrapay/internal/models"
+)
+
+// errorResponse is the JSON envelope for router-level, retryable and quota errors
+// and for rejected transfers.
+type errorResponse struct {
+	Error  string `json:"error"`
+	Status int    `json:"status"`
+	// Code is the reason code of a rejection; see GET /reason-codes.
+	Code           models.ReasonCode `json:"code,omitempty"`
+	AllowedMethods []string          `json:"allowed_methods,omitempty"`
+	RetryInMs      int64             `json:"retry_in_ms,omitempty"`
+	// RequestID identifies the request, for quoting to support; see RequestID.
+	RequestID string `json:"request_id,omitempty"`
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, status int, resp errorResponse) {
+	resp.Status = status
+	resp.RequestID = r.Header.Get(RequestIDHeader)
+	if resp.Error == "" {
+		resp.Error = http.StatusText(status)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// NotFound answers requests that match no route.
+func NotFound() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, r, http.StatusNotFound, errorResponse{Error: "no route for " + r.URL.Path})
+	})
+}
+
+// MethodNotAllowed answers requests whose path matches a route of router but whose
+// method does not. The methods that would match are listed in the body and in the
+// Allow header.
+func MethodNotAllowed(router *mux.Router) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		allowed := allowedMethods(router, r)
+		if onlyOptions(allowed) {
+			writeError(w, r, http.StatusNotFound, errorResponse{Error: "no route for " + r.URL.Path})
+			return
+		}
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		writeError(w, r, http.StatusMethodNotAllowed, errorResponse{
+			Error:          r.Method + " not allowed on " + r.URL.Path,
+			AllowedMethods: allowed,
+		})
+	})
+}
+
+// allowedMethods returns the sorted methods router accepts for the path of r.
+func allowedMethods(router *mux.Router, r *http.Request) []string {
+	seen := make(map[string]bool)
+	router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+		for _, method := range methods {
+			probe := r.Clone(r.Context())
+			probe.Method = method
+			if route.Match(probe, &mux.RouteMatch{}) {
+				seen[method] = true
+			}
+		}
+		return nil
+	})
+
+	allowed := make([]string, 0, len(seen))
+	for method := range seen {
+		allowed = append(allowed, method)
+	}
+	sort.Strings(allowed)
+	return allowed
+}
+
+// Options answers OPTIONS on any path of router with an Allow header listing the
+// methods the path accepts. next, if non-nil, writes the body, e.g. a capability
+// document; otherwise the response is 204.
+func Options(router *mux.Router, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		allowed := allowedMethods(router, r)
+		if onlyOptions(allowed) {
+			writeError(w, r, http.StatusNotFound, errorResponse{Error: "no route for " + r.URL.Path})
+			return
+		}
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		if next == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// onlyOptions reports whether the catch-all OPTIONS route is the only match, i.e.
+// nothing actually lives at the path.
+func onlyOptions(allowed []string) bool {
+	return len(allowed) == 0 || (len(allowed) == 1 && allowed[0] == http.MethodOptions)
+}
+
+// RouteInfo describes one registered route.
+type RouteInfo struct {
+	Path    string   `json:"path"`
+	Methods []string `json:"methods,omitempty"`
+	Name    string   `json:"name,omitempty"`
+}
+
+// Routes lists the routes registered on routers, in registration order. It is an
+// admin debugging aid.
+func Routes(routers ...*mux.Router) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		routes := []RouteInfo{}
+		for _, router := range routers {
+			router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+				path, err := route.GetPathTemplate()
+				if err != nil {
+					return nil
+				}
+				methods, _ := route.GetMethods()
+				routes = append(routes, RouteInfo{Path: path, Methods: methods, Name: route.GetName()})
+				return nil
+			})
+		}
+		writeResponse(w, r, routes)
+	}
+}
@@ -0,0 +1,106 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/nehciyy/intrapay/internal/api"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func routesRouter() *mux.Router {
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	router := mux.NewRouter()
+	router.HandleFunc("/accounts", ok).Methods("POST")
+	router.HandleFunc("/accounts/{id}", ok).Methods("GET")
+	router.HandleFunc("/accounts/{id}", ok).Methods("DELETE")
+	router.HandleFunc("/admin/routes", api.Routes(router)).Methods("GET")
+	server := &api.Server{Service: &mockService{
+		AmountBoundsOfFn: func(tenant string) models.AmountBounds { return models.AmountBounds{Tenant: tenant} },
+	}}
+	router.Handle("/transactions", api.Options(router, http.HandlerFunc(server.TransactionCapabilities))).Methods("OPTIONS")
+	router.HandleFunc("/transactions", ok).Methods("POST")
+	router.Methods("OPTIONS").Handler(api.Options(router, nil))
+	router.NotFoundHandler = api.NotFound()
+	router.MethodNotAllowedHandler = api.MethodNotAllowed(router)
+	return router
+}
+
+func TestNotFound(t *testing.T) {
+	rr := httptest.NewRecorder()
+	routesRouter().ServeHTTP(rr, httptest.NewRequest("GET", "/nope", nil))
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	var resp map[string]interface{}
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+	assert.Equal(t, "no route for /nope", resp["error"])
+	assert.EqualValues(t, http.StatusNotFound, resp["status"])
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	rr := httptest.NewRecorder()
+	routesRouter().ServeHTTP(rr, httptest.NewRequest("PUT", "/accounts/1", nil))
+
+	assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+	assert.Equal(t, "DELETE, GET, OPTIONS", rr.Header().Get("Allow"))
+	var resp struct {
+		Error          string   `json:"error"`
+		AllowedMethods []string `json:"allowed_methods"`
+	}
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+	assert.Equal(t, "PUT not allowed on /accounts/1", resp.Error)
+	assert.Equal(t, []string{"DELETE", "GET", "OPTIONS"}, resp.AllowedMethods)
+}
+
+func TestRoutes(t *testing.T) {
+	rr := httptest.NewRecorder()
+	routesRouter().ServeHTTP(rr, httptest.NewRequest("GET", "/admin/routes", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var routes []api.RouteInfo
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&routes))
+	assert.Equal(t, []api.RouteInfo{
+		{Path: "/accounts", Methods: []string{"POST"}},
+		{Path: "/accounts/{id}", Methods: []string{"GET"}},
+		{Path: "/accounts/{id}", Methods: []string{"DELETE"}},
+		{Path: "/admin/routes", Methods: []string{"GET"}},
+		{Path: "/transactions", Methods: []string{"OPTIONS"}},
+		{Path: "/transactions", Methods: []string{"POST"}},
+	}, routes)
+}
+
+func TestOptions(t *testing.T) {
+	router := routesRouter()
+
+	t.Run("Resource", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("OPTIONS", "/accounts/1", nil))
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		assert.Equal(t, "DELETE, GET, OPTIONS", rr.Header().Get("Allow"))
+	})
+
+	t.Run("Transactions", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("OPTIONS", "/transactions", nil))
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "OPTIONS, POST", rr.Header().Get("Allow"))
+
+		var resp map[string]interface{}
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+		assert.Equal(t, []interface{}{map[string]interface{}{"currency": "USD", "minor_units": 2.0}}, resp["currencies"])
+		assert.Nil(t, resp["max_amount"])
+		assert.Equal(t, false, resp["idempotency"])
+	})
+
+	t.Run("Unknown Path", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("OPTIONS", "/nope", nil))
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
@@ -0,0 +1,34 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/nehciyy/intrapay/internal/metering"
+	"github.com/nehciyy/intrapay/internal/sandbox"
+)
+
+// Sandbox serves the requests of test-mode API keys in test mode: it marks
+// their context so that the service opens test-mode accounts for them, lists
+// and syncs test-mode transactions only, and answers as if it did not exist for
+// any live account, transaction, spending token or reimbursement they name.
+// Live requests pass through untouched.
+func (s *Server) Sandbox(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if apiKey, _ := metering.Caller(r); sandbox.IsTestKey(apiKey) {
+			r = r.WithContext(sandbox.WithTestMode(r.Context()))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// PurgeTestData deletes all test-mode data: the test-mode accounts and
+// transactions and every row that refers to them. It answers with how many
+// accounts and transactions were deleted.
+func (s *Server) PurgeTestData(w http.ResponseWriter, r *http.Request) {
+	purge, err := s.Service.PurgeTestData()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeResponse(w, r, purge)
+}
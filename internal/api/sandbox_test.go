@@ -0,0 +1,98 @@
+package api_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/nehciyy/intrapay/internal/api"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/sandbox"
+)
+
+func TestSandbox(t *testing.T) {
+	var livemode bool
+	mock := &mockService{
+		GetAccountFn: func(id int64) (*models.Account, error) {
+			return &models.Account{AccountID: id}, nil
+		},
+		CreateTransactionFn: func(from, to int64, amount float64, tags []string) (string, error) {
+			return "8", nil
+		},
+		CreateTransactionBatchFn: func(req models.TransactionBatchRequest) (*models.TransactionBatchResult, error) {
+			return &models.TransactionBatchResult{Created: len(req.Transfers), Results: []models.BatchTransferResult{}}, nil
+		},
+	}
+	server := &api.Server{Service: mock}
+	router := mux.NewRouter()
+	router.HandleFunc("/accounts", func(w http.ResponseWriter, r *http.Request) {
+		livemode = sandbox.Livemode(r.Context())
+	}).Methods("POST")
+	router.HandleFunc("/accounts/{id}", server.GetAccount).Methods("GET")
+	router.HandleFunc("/transactions", server.CreateTransaction).Methods("POST")
+	router.HandleFunc("/transactions/batch", server.CreateTransactionBatch).Methods("POST")
+	ledgerPath, ledger := server.LedgerService()
+	router.PathPrefix(ledgerPath).Handler(ledger).Methods("POST")
+	router.Use(server.Sandbox)
+
+	t.Run("Test Mode Context", func(t *testing.T) {
+		for key, want := range map[string]bool{"test_1": false, "live_1": true, "": true} {
+			req := httptest.NewRequest("POST", "/accounts", nil)
+			req.Header.Set("X-API-Key", key)
+			router.ServeHTTP(httptest.NewRecorder(), req)
+			if livemode != want {
+				t.Errorf("key %q: expected livemode %v, got %v", key, want, livemode)
+			}
+		}
+	})
+
+	// The service refuses live resources to test-mode callers; the handlers,
+	// REST and Connect alike, hand it the request's mode.
+	tests := []struct {
+		name, method, url, body string
+		contentType             string
+		expectedCode            int
+	}{
+		{"Account", "GET", "/accounts/1", "", "", http.StatusOK},
+		{"Transfer", "POST", "/transactions", `{"source_account_id": 1, "destination_account_id": 2, "amount": "5.00"}`, "", http.StatusCreated},
+		{"Batch", "POST", "/transactions/batch", `{"transfers": [{"source_account_id": 1, "destination_account_id": 2, "amount": "5.00"}]}`, "", http.StatusCreated},
+		{"Connect Transfer", "POST", ledgerPath + "CreateTransaction", `{"source_account_id": 1, "destination_account_id": 2, "amount": "5.00"}`, "application/json", http.StatusOK},
+	}
+	for _, tt := range tests {
+		for key, want := range map[string]bool{"test_1": false, "live_1": true} {
+			t.Run(tt.name+" "+key, func(t *testing.T) {
+				mock.livemode = !want
+				req := httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+				req.Header.Set("X-API-Key", key)
+				if tt.contentType != "" {
+					req.Header.Set("Content-Type", tt.contentType)
+				}
+				rr := httptest.NewRecorder()
+				router.ServeHTTP(rr, req)
+				if rr.Code != tt.expectedCode {
+					t.Errorf("expected %d, got %d: %s", tt.expectedCode, rr.Code, rr.Body.String())
+				}
+				if mock.livemode != want {
+					t.Errorf("expected the service called with livemode %v", want)
+				}
+			})
+		}
+	}
+}
+
+func TestPurgeTestData(t *testing.T) {
+	server := &api.Server{
+		Service: &mockService{
+			PurgeTestDataFn: func() (*models.SandboxPurge, error) {
+				return &models.SandboxPurge{Accounts: 3, Transactions: 12}, nil
+			},
+		},
+	}
+	rr := httptest.NewRecorder()
+	server.PurgeTestData(rr, httptest.NewRequest("POST", "/admin/sandbox/purge", nil))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"transactions":12`) {
+		t.Errorf("expected 200 with the counts, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
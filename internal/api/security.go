@@ -0,0 +1,60 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/nehciyy/intrapay/internal/middleware"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/service"
+)
+
+// emitSecurityEvent emits an event of type typ caused by actor while serving
+// r, with the address and request ID of r.
+func (s *Server) emitSecurityEvent(r *http.Request, typ, actor, tenant, detail string) {
+	s.Security.Emit(models.SecurityEvent{
+		Type:      typ,
+		Actor:     actor,
+		Tenant:    tenant,
+		Address:   middleware.ClientAddress(r),
+		RequestID: r.Header.Get(RequestIDHeader),
+		Detail:    detail,
+	})
+}
+
+// ListSecurityEvents serves the security event log after ?after_id= (default
+// 0, the beginning), oldest first. It pages like ListEvents.
+func (s *Server) ListSecurityEvents(w http.ResponseWriter, r *http.Request) {
+	pageReq, err := parsePageRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var afterID int64
+	if v := r.URL.Query().Get("after_id"); v != "" {
+		if afterID, err = strconv.ParseInt(v, 10, 64); err != nil {
+			http.Error(w, "invalid after_id, expected an event ID", http.StatusBadRequest)
+			return
+		}
+	}
+
+	page, err := s.Service.ListSecurityEvents(afterID, pageReq.Limit)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInvalidOffset) {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	links := []string{fmt.Sprintf(`<%s>; rel="first"`, eventsPageURL(r, 0))}
+	if page.HasMore {
+		links = append(links, fmt.Sprintf(`<%s>; rel="next"`, eventsPageURL(r, page.NextAfterID)))
+	}
+	w.Header().Set("Link", strings.Join(links, ", "))
+	writeResponse(w, r, page)
+}
@@ -0,0 +1,113 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/nehciyy/intrapay/internal/api"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/security"
+	"github.com/nehciyy/intrapay/internal/service"
+)
+
+type securityStore struct {
+	events []models.SecurityEvent
+}
+
+func (s *securityStore) InsertSecurityEvent(e models.SecurityEvent) (*models.SecurityEvent, error) {
+	s.events = append(s.events, e)
+	return &e, nil
+}
+
+func TestSecurityEvents_Emitted(t *testing.T) {
+	created := time.Date(2026, 3, 14, 9, 0, 0, 0, time.UTC)
+	freeze := models.TransferFreeze{ID: 7, Tenant: "acme", Reason: "suspected compromise", FrozenBy: "alice", CreatedAt: created, ExpiresAt: created.Add(time.Hour)}
+	store := &securityStore{}
+	server := &api.Server{
+		Service: &mockService{
+			FreezeTransfersFn: func(req models.TransferFreezeRequest) (*models.TransferFreeze, error) {
+				return &freeze, nil
+			},
+			RevokeSpendingTokenFn: func(id string) (*models.SpendingToken, error) {
+				return &models.SpendingToken{ID: id, AccountID: 1}, nil
+			},
+		},
+		Security: security.NewStream(store, log.New(&bytes.Buffer{}, "", 0)),
+	}
+	router := mux.NewRouter()
+	router.HandleFunc("/admin/freezes", server.FreezeTransfers).Methods("POST")
+	router.HandleFunc("/tokens/{id}/revoke", server.RevokeSpendingToken).Methods("POST")
+
+	req := httptest.NewRequest("POST", "/admin/freezes", strings.NewReader(`{"tenant": "acme", "duration": "1h", "reason": "suspected compromise", "operator": "alice"}`))
+	req.Header.Set(api.RequestIDHeader, "req-1")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	req = httptest.NewRequest("POST", "/tokens/tok_1/revoke", nil)
+	req.Header.Set("X-API-Key", "key-1")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	server.Security.Run(ctx)
+
+	if len(store.events) != 2 {
+		t.Fatalf("expected 2 events, got %+v", store.events)
+	}
+	frozen := store.events[0]
+	if frozen.Type != models.SecurityTransfersFrozen || frozen.Actor != "alice" || frozen.Tenant != "acme" || frozen.RequestID != "req-1" || frozen.Address != "192.0.2.1" {
+		t.Errorf("unexpected freeze event: %+v", frozen)
+	}
+	revoked := store.events[1]
+	if revoked.Type != models.SecurityTokenRevoked || revoked.Actor != "key-1" || !strings.Contains(revoked.Detail, "tok_1") {
+		t.Errorf("unexpected revocation event: %+v", revoked)
+	}
+}
+
+func TestListSecurityEvents(t *testing.T) {
+	server := &api.Server{
+		Service: &mockService{
+			ListSecurityEventsFn: func(afterID int64, limit int) (*models.SecurityEventPage, error) {
+				if afterID < 0 {
+					return nil, fmt.Errorf("%w %d", service.ErrInvalidOffset, afterID)
+				}
+				return &models.SecurityEventPage{
+					Events:      []models.SecurityEvent{{ID: 3, Type: models.SecurityAuthLockout}},
+					NextAfterID: 3,
+					HasMore:     true,
+				}, nil
+			},
+		},
+	}
+	router := mux.NewRouter()
+	router.HandleFunc("/admin/security-events", server.ListSecurityEvents).Methods("GET")
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/admin/security-events?limit=1", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	var page models.SecurityEventPage
+	json.NewDecoder(rr.Body).Decode(&page)
+	if len(page.Events) != 1 || page.NextAfterID != 3 || !page.HasMore {
+		t.Errorf("unexpected page: %+v", page)
+	}
+	if link := rr.Header().Get("Link"); !strings.Contains(link, `</admin/security-events?after_id=3&limit=1>; rel="next"`) {
+		t.Errorf("expected a next link after event 3, got %q", link)
+	}
+
+	for _, url := range []string{"/admin/security-events?after_id=-1", "/admin/security-events?after_id=x"} {
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", url, nil))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", url, rr.Code)
+		}
+	}
+}
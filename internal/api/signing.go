@@ -0,0 +1,58 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"github.com/nehciyy/intrapay/internal/signing"
+)
+
+// Sign returns middleware that signs response bodies with signer, sending the
+// detached JWS in the X-JWS-Signature header. The body is buffered so the
+// signature can precede it; it covers the exact bytes the handler wrote, before
+// any content encoding.
+func Sign(signer *signing.Signer) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sw := &signWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(sw, r)
+
+			sig, err := signer.Sign(sw.body.Bytes())
+			if err != nil {
+				http.Error(w, "failed to sign response", http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set(signing.Header, sig)
+			w.WriteHeader(sw.status)
+			w.Write(sw.body.Bytes())
+		})
+	}
+}
+
+// signWriter holds back the status and body of a response until it is signed.
+type signWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (sw *signWriter) WriteHeader(code int) {
+	if !sw.wroteHeader {
+		sw.status, sw.wroteHeader = code, true
+	}
+}
+
+func (sw *signWriter) Write(b []byte) (int, error) {
+	sw.wroteHeader = true
+	return sw.body.Write(b)
+}
+
+// JWKS serves the public key response signatures are verified with, as a JWK
+// Set. It is only routed when response signing is configured.
+func (s *Server) JWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	w.Header().Set("Content-Type", "application/jwk-set+json")
+	json.NewEncoder(w).Encode(s.Signer.JWKS())
+}
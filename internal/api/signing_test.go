@@ -0,0 +1,66 @@
+package api_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nehciyy/intrapay/internal/api"
+	"github.com/nehciyy/intrapay/internal/clock"
+	"github.com/nehciyy/intrapay/internal/signing"
+)
+
+func TestSign_CreateTransaction(t *testing.T) {
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &api.Server{
+		Service: &mockService{
+			CreateTransactionFn: func(from, to int64, amount float64, tags []string) (string, error) { return "42", nil },
+		},
+		Signer: signing.NewSigner(key, clock.System),
+	}
+	handler := api.Sign(server.Signer)(http.HandlerFunc(server.CreateTransaction))
+
+	req := httptest.NewRequest("POST", "/transactions", strings.NewReader(`{"source_account_id":1,"destination_account_id":2,"amount":"10.00"}`))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+	}
+	sig := rr.Header().Get(signing.Header)
+	if sig == "" {
+		t.Fatalf("expected a %s header", signing.Header)
+	}
+	if err := signing.Verify(sig, rr.Body.Bytes(), pub); err != nil {
+		t.Errorf("signature does not verify against the body: %v", err)
+	}
+	if err := signing.Verify(sig, []byte(`{"transaction_id":"43"}`), pub); err == nil {
+		t.Error("expected the signature not to verify against another body")
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	_, key, _ := ed25519.GenerateKey(rand.Reader)
+	server := &api.Server{Signer: signing.NewSigner(key, clock.System)}
+
+	rr := httptest.NewRecorder()
+	server.JWKS(rr, httptest.NewRequest("GET", "/.well-known/jwks.json", nil))
+
+	if ct := rr.Header().Get("Content-Type"); ct != "application/jwk-set+json" {
+		t.Errorf("expected Content-Type application/jwk-set+json, got %q", ct)
+	}
+	var jwks signing.JWKS
+	if err := json.NewDecoder(rr.Body).Decode(&jwks); err != nil {
+		t.Fatal(err)
+	}
+	if len(jwks.Keys) != 1 || jwks.Keys[0].Kid != server.Signer.KeyID() {
+		t.Errorf("expected the signer's key %s, got %+v", server.Signer.KeyID(), jwks.Keys)
+	}
+}
@@ -0,0 +1,103 @@
+package api_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/nehciyy/intrapay/internal/api"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository"
+	"github.com/nehciyy/intrapay/internal/service"
+)
+
+func TestTransactionTags(t *testing.T) {
+	var gotTags []string
+	var gotFilter models.TransactionFilter
+	server := &api.Server{
+		Service: &mockService{
+			CreateTransactionFn: func(from, to int64, amount float64, tags []string) (string, error) {
+				gotTags = tags
+				if len(tags) > 0 && tags[0] == "Not a tag" {
+					return "", fmt.Errorf("%w: %q", service.ErrInvalidTags, tags[0])
+				}
+				return "42", nil
+			},
+			SetTransactionTagsFn: func(id string, tags []string) (*models.Transaction, error) {
+				gotTags = tags
+				if id != "42" {
+					return nil, fmt.Errorf("transaction %s %w", id, repository.ErrNotFound)
+				}
+				if len(tags) > 0 && tags[0] == "Not a tag" {
+					return nil, fmt.Errorf("%w: %q", service.ErrInvalidTags, tags[0])
+				}
+				return &models.Transaction{ID: id, Tags: tags}, nil
+			},
+			ListTransactionsFn: func(filter models.TransactionFilter, cursor string, limit int) (*models.TransactionPage, error) {
+				gotFilter = filter
+				if filter.Tag == "Not a tag" {
+					return nil, fmt.Errorf("%w: %q", service.ErrInvalidTags, filter.Tag)
+				}
+				return &models.TransactionPage{Transactions: []models.Transaction{}}, nil
+			},
+			ListAccountTagsFn: func(accountID int64) ([]models.TransactionTag, error) {
+				if accountID != 1 {
+					return nil, fmt.Errorf("account %d %w", accountID, repository.ErrNotFound)
+				}
+				return []models.TransactionTag{{Tag: "migration", Transactions: 3}}, nil
+			},
+		},
+	}
+	router := mux.NewRouter()
+	router.HandleFunc("/transactions", server.CreateTransaction).Methods("POST")
+	router.HandleFunc("/transactions", server.ListTransactions).Methods("GET")
+	router.HandleFunc("/transactions/{id}", server.SetTransactionTags).Methods("PATCH")
+	router.HandleFunc("/accounts/{id}/tags", server.ListAccountTags).Methods("GET")
+
+	tests := []struct {
+		name, method, url, body string
+		expectedCode            int
+		expectedTags            []string
+	}{
+		{"Create Tagged", "POST", "/transactions", `{"source_account_id": 1, "destination_account_id": 2, "amount": "5.00", "tags": ["migration"]}`, http.StatusCreated, []string{"migration"}},
+		{"Create Invalid Tag", "POST", "/transactions", `{"source_account_id": 1, "destination_account_id": 2, "amount": "5.00", "tags": ["Not a tag"]}`, http.StatusBadRequest, []string{"Not a tag"}},
+		{"Retag", "PATCH", "/transactions/42", `{"tags": ["correction", "test"]}`, http.StatusOK, []string{"correction", "test"}},
+		{"Untag", "PATCH", "/transactions/42", `{"tags": []}`, http.StatusOK, []string{}},
+		{"Retag Invalid", "PATCH", "/transactions/42", `{"tags": ["Not a tag"]}`, http.StatusBadRequest, []string{"Not a tag"}},
+		{"Retag Not Found", "PATCH", "/transactions/7", `{"tags": ["test"]}`, http.StatusNotFound, []string{"test"}},
+		{"Retag Bad Body", "PATCH", "/transactions/42", `{"tags": "test"}`, http.StatusBadRequest, nil},
+		{"Account Tags", "GET", "/accounts/1/tags", "", http.StatusOK, nil},
+		{"Account Tags Not Found", "GET", "/accounts/2/tags", "", http.StatusNotFound, nil},
+		{"Account Tags Invalid ID", "GET", "/accounts/x/tags", "", http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotTags = nil
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.body)))
+			if rr.Code != tt.expectedCode {
+				t.Errorf("expected %d, got %d: %s", tt.expectedCode, rr.Code, rr.Body.String())
+			}
+			if !reflect.DeepEqual(gotTags, tt.expectedTags) {
+				t.Errorf("expected tags %v, got %v", tt.expectedTags, gotTags)
+			}
+		})
+	}
+
+	t.Run("List By Tag", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/transactions?tag=migration", nil))
+		if rr.Code != http.StatusOK || gotFilter.Tag != "migration" {
+			t.Errorf("expected 200 filtered by migration, got %d with %+v", rr.Code, gotFilter)
+		}
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/transactions?tag=Not+a+tag", nil))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 for an invalid tag, got %d", rr.Code)
+		}
+	})
+}
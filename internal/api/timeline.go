@@ -0,0 +1,55 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/nehciyy/intrapay/internal/repository"
+	"github.com/nehciyy/intrapay/internal/service"
+)
+
+// AccountTimeline returns what happened to an open account in one feed, oldest
+// first: its opening, transfers, adjustments, closures and restores, and the
+// spending tokens issued and revoked on it. It is paginated like the other
+// listings.
+func (s *Server) AccountTimeline(w http.ResponseWriter, r *http.Request) {
+	s.accountTimeline(w, r, false)
+}
+
+// AccountTimelineDetails is AccountTimeline for closed accounts too.
+func (s *Server) AccountTimelineDetails(w http.ResponseWriter, r *http.Request) {
+	s.accountTimeline(w, r, true)
+}
+
+func (s *Server) accountTimeline(w http.ResponseWriter, r *http.Request, includeDeleted bool) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "invalid account ID", http.StatusBadRequest)
+		return
+	}
+
+	pageReq, err := parsePageRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	timeline, err := s.Service.AccountTimeline(readOnly(r), id, pageReq.Cursor, pageReq.Limit, includeDeleted)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrInvalidCursor):
+			status = http.StatusBadRequest
+		case errors.Is(err, repository.ErrNotFound):
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	setPageHeaders(w, r, timeline.NextCursor, timeline.HasMore, nil)
+	writeResponse(w, r, timeline)
+}
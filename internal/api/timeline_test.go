@@ -0,0 +1,90 @@
+package api_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/nehciyy/intrapay/internal/api"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository"
+	"github.com/nehciyy/intrapay/internal/service"
+)
+
+func TestAccountTimeline(t *testing.T) {
+	var gotDeleted bool
+	amount := models.Amount(-30)
+	server := &api.Server{
+		Service: &mockService{
+			AccountTimelineFn: func(id int64, cursor string, limit int, includeDeleted bool) (*models.AccountTimeline, error) {
+				gotDeleted = includeDeleted
+				switch {
+				case cursor == "bad":
+					return nil, service.ErrInvalidCursor
+				case id != 1:
+					return nil, fmt.Errorf("account %d %w", id, repository.ErrNotFound)
+				}
+				return &models.AccountTimeline{
+					AccountID: id,
+					Events: []models.AccountEvent{{
+						Type:                  models.AccountEventDebit,
+						OccurredAt:            time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC),
+						Amount:                &amount,
+						TransactionID:         "7",
+						Kind:                  models.TransactionTransfer,
+						CounterpartyAccountID: 2,
+					}},
+					NextCursor: "next",
+					HasMore:    limit == 1,
+				}, nil
+			},
+		},
+	}
+	router := mux.NewRouter()
+	router.HandleFunc("/accounts/{id}/timeline", server.AccountTimeline).Methods("GET")
+	router.HandleFunc("/admin/accounts/{id}/timeline", server.AccountTimelineDetails).Methods("GET")
+
+	tests := []struct {
+		name, url       string
+		expectedCode    int
+		expectedDeleted bool
+	}{
+		{"Timeline", "/accounts/1/timeline", http.StatusOK, false},
+		{"Admin Timeline", "/admin/accounts/1/timeline", http.StatusOK, true},
+		{"Not Found", "/accounts/2/timeline", http.StatusNotFound, false},
+		{"Invalid ID", "/accounts/x/timeline", http.StatusBadRequest, false},
+		{"Invalid Cursor", "/accounts/1/timeline?cursor=bad", http.StatusBadRequest, false},
+		{"Invalid Limit", "/accounts/1/timeline?limit=0", http.StatusBadRequest, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotDeleted = false
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest("GET", tt.url, nil))
+			if rr.Code != tt.expectedCode {
+				t.Fatalf("expected %d, got %d: %s", tt.expectedCode, rr.Code, rr.Body.String())
+			}
+			if gotDeleted != tt.expectedDeleted {
+				t.Errorf("expected includeDeleted %v, got %v", tt.expectedDeleted, gotDeleted)
+			}
+		})
+	}
+
+	t.Run("Body and Links", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/accounts/1/timeline?limit=1", nil))
+		body := rr.Body.String()
+		for _, want := range []string{`"type":"transaction.debit"`, `"amount":"-30.00"`, `"transaction_id":"7"`, `"counterparty_account_id":2`, `"has_more":true`} {
+			if !strings.Contains(body, want) {
+				t.Errorf("expected %s in %s", want, body)
+			}
+		}
+		if link := rr.Header().Get("Link"); !strings.Contains(link, `cursor=next`) {
+			t.Errorf("expected a next link, got %q", link)
+		}
+	})
+}
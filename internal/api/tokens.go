@@ -0,0 +1,131 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/nehciyy/intrapay/internal/metering"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository"
+	"github.com/nehciyy/intrapay/internal/service"
+)
+
+// CreateSpendingToken issues a spending token that debits the account within
+// its own limit, expiry and merchant categories. It is answered with 201 and
+// the token.
+func (s *Server) CreateSpendingToken(w http.ResponseWriter, r *http.Request) {
+	accountID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "invalid account ID", http.StatusBadRequest)
+		return
+	}
+
+	req := &models.SpendingTokenRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	token, err := s.Service.CreateSpendingToken(r.Context(), accountID, *req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrInvalidSpendingToken):
+			status = http.StatusBadRequest
+		case errors.Is(err, repository.ErrNotFound):
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.Header().Set("Location", "/tokens/"+token.ID)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(token)
+}
+
+// ListSpendingTokens lists the spending tokens of an account, revoked and
+// expired ones included.
+func (s *Server) ListSpendingTokens(w http.ResponseWriter, r *http.Request) {
+	accountID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "invalid account ID", http.StatusBadRequest)
+		return
+	}
+
+	tokens, err := s.Service.ListSpendingTokens(r.Context(), accountID)
+	if err != nil {
+		writeTokenError(w, err)
+		return
+	}
+	writeResponse(w, r, tokens)
+}
+
+func (s *Server) GetSpendingToken(w http.ResponseWriter, r *http.Request) {
+	token, err := s.Service.GetSpendingToken(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeTokenError(w, err)
+		return
+	}
+	writeResponse(w, r, token)
+}
+
+// RevokeSpendingToken stops a spending token from debiting its account and
+// returns it with revoked_at set.
+func (s *Server) RevokeSpendingToken(w http.ResponseWriter, r *http.Request) {
+	token, err := s.Service.RevokeSpendingToken(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeTokenError(w, err)
+		return
+	}
+	apiKey, tenant := metering.Caller(r)
+	s.emitSecurityEvent(r, models.SecurityTokenRevoked, apiKey, tenant, fmt.Sprintf("spending token %s of account %d", token.ID, token.AccountID))
+	json.NewEncoder(w).Encode(token)
+}
+
+// DebitWithToken transfers from the account of a spending token. A debit the
+// token does not allow is refused with 422 and its reason code before the
+// account is touched; it is answered with 201, the transaction ID and the token
+// with the debit added to what it has spent.
+func (s *Server) DebitWithToken(w http.ResponseWriter, r *http.Request) {
+	req := &models.TokenDebitRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var quotaErr *metering.QuotaError
+	if err := metering.CheckVolume(r.Context(), float64(req.Amount)); errors.As(err, &quotaErr) {
+		writeError(w, r, http.StatusTooManyRequests, errorResponse{Error: quotaErr.Error(), Code: quotaErr.Code})
+		return
+	}
+
+	debit, err := s.Service.DebitWithToken(r.Context(), mux.Vars(r)["id"], *req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidTokenDebit) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeTransferError(w, r, err)
+		return
+	}
+	metering.AddVolume(r.Context(), float64(req.Amount))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(debit)
+}
+
+func writeTokenError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if errors.Is(err, repository.ErrNotFound) {
+		status = http.StatusNotFound
+	}
+	http.Error(w, err.Error(), status)
+}
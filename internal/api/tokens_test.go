@@ -0,0 +1,129 @@
+package api_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/nehciyy/intrapay/internal/api"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository"
+	"github.com/nehciyy/intrapay/internal/service"
+)
+
+func tokenRouter(server *api.Server) *mux.Router {
+	router := mux.NewRouter()
+	router.HandleFunc("/accounts/{id}/tokens", server.CreateSpendingToken).Methods("POST")
+	router.HandleFunc("/accounts/{id}/tokens", server.ListSpendingTokens).Methods("GET")
+	router.HandleFunc("/tokens/{id}", server.GetSpendingToken).Methods("GET")
+	router.HandleFunc("/tokens/{id}/revoke", server.RevokeSpendingToken).Methods("POST")
+	router.HandleFunc("/tokens/{id}/debits", server.DebitWithToken).Methods("POST")
+	return router
+}
+
+func TestCreateSpendingToken(t *testing.T) {
+	server := &api.Server{
+		Service: &mockService{
+			CreateSpendingTokenFn: func(accountID int64, req models.SpendingTokenRequest) (*models.SpendingToken, error) {
+				switch {
+				case req.Limit <= 0:
+					return nil, fmt.Errorf("%w: limit must be positive", service.ErrInvalidSpendingToken)
+				case accountID == 404:
+					return nil, fmt.Errorf("account with ID %d %w", accountID, repository.ErrNotFound)
+				}
+				return &models.SpendingToken{ID: "tok_1", AccountID: accountID, Limit: req.Limit, MerchantCategories: req.MerchantCategories}, nil
+			},
+		},
+	}
+
+	rr := httptest.NewRecorder()
+	tokenRouter(server).ServeHTTP(rr, httptest.NewRequest("POST", "/accounts/42/tokens", strings.NewReader(`{"limit": "250.00", "merchant_categories": ["5812"]}`)))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", rr.Code)
+	}
+	if loc := rr.Header().Get("Location"); loc != "/tokens/tok_1" {
+		t.Errorf("expected Location /tokens/tok_1, got %q", loc)
+	}
+	var token models.SpendingToken
+	json.NewDecoder(rr.Body).Decode(&token)
+	if token.AccountID != 42 || token.Limit != 250 {
+		t.Errorf("expected a token of account 42 with a limit of 250.00, got %+v", token)
+	}
+
+	for url, want := range map[string]int{
+		"/accounts/abc/tokens": http.StatusBadRequest,
+		"/accounts/404/tokens": http.StatusNotFound,
+	} {
+		rr = httptest.NewRecorder()
+		tokenRouter(server).ServeHTTP(rr, httptest.NewRequest("POST", url, strings.NewReader(`{"limit": "10"}`)))
+		if rr.Code != want {
+			t.Errorf("%s: expected %d, got %d", url, want, rr.Code)
+		}
+	}
+	rr = httptest.NewRecorder()
+	tokenRouter(server).ServeHTTP(rr, httptest.NewRequest("POST", "/accounts/42/tokens", strings.NewReader(`{"limit": "0"}`)))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a zero limit, got %d", rr.Code)
+	}
+}
+
+func TestDebitWithToken(t *testing.T) {
+	server := &api.Server{
+		Service: &mockService{
+			DebitWithTokenFn: func(id string, req models.TokenDebitRequest) (*models.TokenDebit, error) {
+				switch {
+				case id == "tok_x":
+					return nil, fmt.Errorf("spending token %s %w", id, service.ErrTokenNotFound)
+				case req.Amount <= 0:
+					return nil, fmt.Errorf("%w: amount must be positive", service.ErrInvalidTokenDebit)
+				case req.MerchantCategory != "5812":
+					return nil, fmt.Errorf("%w: %q for spending token %s", service.ErrMerchantCategoryNotAllowed, req.MerchantCategory, id)
+				case req.Amount > 100:
+					return nil, fmt.Errorf("%w: 100.00 left on %s", service.ErrTokenLimitExceeded, id)
+				}
+				return &models.TokenDebit{TransactionID: "tx-1", Token: &models.SpendingToken{ID: id, Limit: 100, Spent: req.Amount}}, nil
+			},
+			RevokeSpendingTokenFn: func(id string) (*models.SpendingToken, error) {
+				return nil, fmt.Errorf("spending token %s %w", id, repository.ErrNotFound)
+			},
+		},
+	}
+
+	tests := []struct {
+		name string
+		url  string
+		body string
+		code int
+		want models.ReasonCode
+	}{
+		{"Debit", "/tokens/tok_1/debits", `{"destination_account_id": 7, "amount": "25.00", "merchant_category": "5812"}`, http.StatusCreated, ""},
+		{"Merchant category", "/tokens/tok_1/debits", `{"destination_account_id": 7, "amount": "25.00", "merchant_category": "7995"}`, http.StatusUnprocessableEntity, models.ReasonMerchantCategoryNotAllowed},
+		{"Limit", "/tokens/tok_1/debits", `{"destination_account_id": 7, "amount": "250.00", "merchant_category": "5812"}`, http.StatusUnprocessableEntity, models.ReasonTokenLimitExceeded},
+		{"Unknown token", "/tokens/tok_x/debits", `{"destination_account_id": 7, "amount": "25.00", "merchant_category": "5812"}`, http.StatusNotFound, models.ReasonTokenNotFound},
+		{"Zero amount", "/tokens/tok_1/debits", `{"destination_account_id": 7, "amount": "0", "merchant_category": "5812"}`, http.StatusBadRequest, ""},
+		{"Revoke unknown token", "/tokens/tok_x/revoke", ``, http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			tokenRouter(server).ServeHTTP(rr, httptest.NewRequest("POST", tt.url, strings.NewReader(tt.body)))
+			if rr.Code != tt.code {
+				t.Fatalf("expected %d, got %d: %s", tt.code, rr.Code, rr.Body.String())
+			}
+			if tt.want == "" {
+				return
+			}
+			var body struct {
+				Code models.ReasonCode `json:"code"`
+			}
+			json.NewDecoder(rr.Body).Decode(&body)
+			if body.Code != tt.want {
+				t.Errorf("expected code %q, got %q", tt.want, body.Code)
+			}
+		})
+	}
+}
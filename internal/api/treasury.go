@@ -0,0 +1,84 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/nehciyy/intrapay/internal/service"
+)
+
+const (
+	defaultLargestAccounts = 10
+	defaultFlowDays        = 30
+)
+
+// GetTreasuryPositions serves the money held across all accounts by currency
+// and account type, with the float on the suspense and reimbursement accounts.
+func (s *Server) GetTreasuryPositions(w http.ResponseWriter, r *http.Request) {
+	positions, err := s.Service.TreasuryPositions(readOnly(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeResponse(w, r, positions)
+}
+
+// GetLargestAccounts serves the ?limit= open accounts holding the most money,
+// 10 by default.
+func (s *Server) GetLargestAccounts(w http.ResponseWriter, r *http.Request) {
+	limit, err := intParam(r, "limit", defaultLargestAccounts)
+	if err != nil {
+		http.Error(w, "invalid limit", http.StatusBadRequest)
+		return
+	}
+
+	accounts, err := s.Service.LargestAccounts(readOnly(r), limit)
+	if err != nil {
+		writeTreasuryError(w, err)
+		return
+	}
+
+	writeResponse(w, r, accounts)
+}
+
+// GetDailyFlows serves the money into and out of each account type per day over
+// the last ?days= days, 30 by default, in the ?timezone= calendar.
+func (s *Server) GetDailyFlows(w http.ResponseWriter, r *http.Request) {
+	days, err := intParam(r, "days", defaultFlowDays)
+	if err != nil {
+		http.Error(w, "invalid days", http.StatusBadRequest)
+		return
+	}
+	loc, err := parseTimezone(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	flows, err := s.Service.DailyFlows(readOnly(r), days, loc)
+	if err != nil {
+		writeTreasuryError(w, err)
+		return
+	}
+
+	writeResponse(w, r, flows)
+}
+
+// intParam reads the integer query parameter name, def when it is missing.
+func intParam(r *http.Request, name string, def int) (int, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return def, nil
+	}
+	return strconv.Atoi(v)
+}
+
+func writeTreasuryError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if errors.Is(err, service.ErrInvalidTreasuryQuery) {
+		status = http.StatusBadRequest
+	}
+	http.Error(w, err.Error(), status)
+}
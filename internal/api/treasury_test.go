@@ -0,0 +1,72 @@
+package api_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/nehciyy/intrapay/internal/api"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/service"
+)
+
+func TestTreasuryReports(t *testing.T) {
+	var gotLimit, gotDays int
+	var gotLoc *time.Location
+	server := &api.Server{
+		Service: &mockService{
+			TreasuryPositionsFn: func() (*models.TreasuryPositions, error) {
+				return &models.TreasuryPositions{}, nil
+			},
+			LargestAccountsFn: func(limit int) ([]models.TreasuryAccount, error) {
+				gotLimit = limit
+				if limit > service.MaxLargestAccounts {
+					return nil, fmt.Errorf("%w: limit must be between 1 and %d", service.ErrInvalidTreasuryQuery, service.MaxLargestAccounts)
+				}
+				return []models.TreasuryAccount{}, nil
+			},
+			DailyFlowsFn: func(days int, loc *time.Location) (*models.TreasuryFlows, error) {
+				gotDays, gotLoc = days, loc
+				return &models.TreasuryFlows{}, nil
+			},
+		},
+	}
+	router := mux.NewRouter()
+	router.HandleFunc("/admin/treasury/positions", server.GetTreasuryPositions).Methods("GET")
+	router.HandleFunc("/admin/treasury/largest-accounts", server.GetLargestAccounts).Methods("GET")
+	router.HandleFunc("/admin/treasury/flows", server.GetDailyFlows).Methods("GET")
+
+	tests := []struct {
+		name, url    string
+		expectedCode int
+	}{
+		{"Positions", "/admin/treasury/positions", http.StatusOK},
+		{"Largest Accounts", "/admin/treasury/largest-accounts?limit=5", http.StatusOK},
+		{"Limit Too Large", "/admin/treasury/largest-accounts?limit=1000", http.StatusBadRequest},
+		{"Invalid Limit", "/admin/treasury/largest-accounts?limit=abc", http.StatusBadRequest},
+		{"Flows", "/admin/treasury/flows?days=7&timezone=Asia/Tokyo", http.StatusOK},
+		{"Invalid Days", "/admin/treasury/flows?days=x", http.StatusBadRequest},
+		{"Invalid Timezone", "/admin/treasury/flows?timezone=Mars/Olympus", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest("GET", tt.url, nil))
+			if rr.Code != tt.expectedCode {
+				t.Errorf("expected %d, got %d: %s", tt.expectedCode, rr.Code, rr.Body.String())
+			}
+		})
+	}
+	if gotDays != 7 || gotLoc.String() != "Asia/Tokyo" {
+		t.Errorf("expected 7 days in Asia/Tokyo, got %d in %v", gotDays, gotLoc)
+	}
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/admin/treasury/largest-accounts", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/admin/treasury/flows", nil))
+	if gotLimit != 10 || gotDays != 30 {
+		t.Errorf("expected defaults of 10 accounts and 30 days, got %d and %d", gotLimit, gotDays)
+	}
+}
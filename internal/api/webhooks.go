@@ -0,0 +1,123 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/nehciyy/intrapay/internal/metering"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository"
+	"github.com/nehciyy/intrapay/internal/service"
+)
+
+// Webhooks belong to the caller's tenant (X-Tenant-ID); those of other tenants
+// are answered with 404.
+
+// CreateWebhook registers a webhook once its URL has echoed the verification
+// challenge. A URL that does not is answered with 422 and nothing is stored. A
+// webhook subscribed to an account the caller's API key did not open is
+// answered with 403.
+func (s *Server) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	req := &models.WebhookRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	_, tenant := metering.Caller(r)
+	webhook, err := s.Service.RegisterWebhook(r.Context(), tenant, callerKey(r), *req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrInvalidWebhook):
+			status = http.StatusBadRequest
+		case errors.Is(err, repository.ErrNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, service.ErrNotAccountOwner):
+			status = http.StatusForbidden
+		case errors.Is(err, service.ErrWebhookVerification):
+			status = http.StatusUnprocessableEntity
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.Header().Set("Location", "/webhooks/"+strconv.FormatInt(webhook.ID, 10))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(webhook)
+}
+
+func (s *Server) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	_, tenant := metering.Caller(r)
+	webhooks, err := s.Service.ListWebhooks(tenant)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeResponse(w, r, webhooks)
+}
+
+func (s *Server) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	id, ok := webhookID(w, r)
+	if !ok {
+		return
+	}
+	_, tenant := metering.Caller(r)
+	webhook, err := s.Service.GetWebhook(id, tenant)
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	writeResponse(w, r, webhook)
+}
+
+func (s *Server) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id, ok := webhookID(w, r)
+	if !ok {
+		return
+	}
+	_, tenant := metering.Caller(r)
+	if err := s.Service.DeleteWebhook(id, tenant); err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// PingWebhook sends a webhook.ping event to the webhook's URL and returns the
+// outcome. The response is 200 whether or not the URL accepted it.
+func (s *Server) PingWebhook(w http.ResponseWriter, r *http.Request) {
+	id, ok := webhookID(w, r)
+	if !ok {
+		return
+	}
+	_, tenant := metering.Caller(r)
+	ping, err := s.Service.PingWebhook(id, tenant)
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	writeResponse(w, r, ping)
+}
+
+func webhookID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "invalid webhook ID", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
+
+func writeWebhookError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if errors.Is(err, repository.ErrNotFound) {
+		status = http.StatusNotFound
+	}
+	http.Error(w, err.Error(), status)
+}
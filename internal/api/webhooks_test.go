@@ -0,0 +1,142 @@
+package api_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/nehciyy/intrapay/internal/api"
+	"github.com/nehciyy/intrapay/internal/metering"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository"
+	"github.com/nehciyy/intrapay/internal/service"
+)
+
+func webhookRouter(server *api.Server) *mux.Router {
+	router := mux.NewRouter()
+	router.HandleFunc("/webhooks", server.CreateWebhook).Methods("POST")
+	router.HandleFunc("/webhooks/{id}", server.GetWebhook).Methods("GET")
+	router.HandleFunc("/webhooks/{id}", server.DeleteWebhook).Methods("DELETE")
+	router.HandleFunc("/webhooks/{id}/ping", server.PingWebhook).Methods("POST")
+	return router
+}
+
+func TestCreateWebhook(t *testing.T) {
+	server := &api.Server{
+		Service: &mockService{
+			RegisterWebhookFn: func(tenant, apiKey string, req models.WebhookRequest) (*models.Webhook, error) {
+				if req.AccountID != nil {
+					switch {
+					case *req.AccountID == 404:
+						return nil, fmt.Errorf("account with ID %d %w", *req.AccountID, repository.ErrNotFound)
+					case apiKey != "key_alice":
+						return nil, fmt.Errorf("%w %d", service.ErrNotAccountOwner, *req.AccountID)
+					}
+				}
+				switch req.URL {
+				case "not a url":
+					return nil, fmt.Errorf("%w: url must be an absolute http or https URL", service.ErrInvalidWebhook)
+				case "https://example.com/typo":
+					return nil, fmt.Errorf("%w: did not echo the challenge", service.ErrWebhookVerification)
+				}
+				return &models.Webhook{ID: 7, Tenant: tenant, URL: req.URL, EventTypes: req.EventTypes}, nil
+			},
+		},
+	}
+
+	req := httptest.NewRequest("POST", "/webhooks", strings.NewReader(`{"url": "https://example.com/hooks", "event_types": ["transfer.completed"]}`))
+	req.Header.Set(metering.TenantHeader, "payroll")
+	rr := httptest.NewRecorder()
+	webhookRouter(server).ServeHTTP(rr, req)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", rr.Code)
+	}
+	if loc := rr.Header().Get("Location"); loc != "/webhooks/7" {
+		t.Errorf("expected Location /webhooks/7, got %q", loc)
+	}
+	var webhook models.Webhook
+	json.NewDecoder(rr.Body).Decode(&webhook)
+	if webhook.Tenant != "payroll" {
+		t.Errorf("expected the webhook to belong to the caller's tenant, got %q", webhook.Tenant)
+	}
+
+	for body, want := range map[string]int{
+		`{"url": "not a url"}`:                http.StatusBadRequest,
+		`{"url": "https://example.com/typo"}`: http.StatusUnprocessableEntity,
+		`{"url": `:                            http.StatusBadRequest,
+		`{"url": "https://example.com/hooks", "account_id": 42}`:  http.StatusForbidden,
+		`{"url": "https://example.com/hooks", "account_id": 404}`: http.StatusNotFound,
+	} {
+		rr = httptest.NewRecorder()
+		webhookRouter(server).ServeHTTP(rr, httptest.NewRequest("POST", "/webhooks", strings.NewReader(body)))
+		if rr.Code != want {
+			t.Errorf("%s: expected %d, got %d", body, want, rr.Code)
+		}
+	}
+
+	// The owner of an account may subscribe to it.
+	req = httptest.NewRequest("POST", "/webhooks", strings.NewReader(`{"url": "https://example.com/hooks", "account_id": 42}`))
+	req.Header.Set("X-API-Key", "key_alice")
+	rr = httptest.NewRecorder()
+	webhookRouter(server).ServeHTTP(rr, req)
+	if rr.Code != http.StatusCreated {
+		t.Errorf("expected 201 for the account's owner, got %d", rr.Code)
+	}
+}
+
+func TestPingWebhook(t *testing.T) {
+	server := &api.Server{
+		Service: &mockService{
+			PingWebhookFn: func(id int64, tenant string) (*models.WebhookPing, error) {
+				if tenant != "payroll" {
+					return nil, fmt.Errorf("webhook %d %w", id, repository.ErrNotFound)
+				}
+				return &models.WebhookPing{WebhookID: id, Delivered: false, StatusCode: 410, Error: "unexpected status 410 Gone"}, nil
+			},
+		},
+	}
+
+	req := httptest.NewRequest("POST", "/webhooks/7/ping", nil)
+	req.Header.Set(metering.TenantHeader, "payroll")
+	rr := httptest.NewRecorder()
+	webhookRouter(server).ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200 for a failed ping, got %d", rr.Code)
+	}
+	var ping models.WebhookPing
+	json.NewDecoder(rr.Body).Decode(&ping)
+	if ping.Delivered || ping.StatusCode != 410 {
+		t.Errorf("unexpected ping result: %+v", ping)
+	}
+
+	rr = httptest.NewRecorder()
+	webhookRouter(server).ServeHTTP(rr, httptest.NewRequest("POST", "/webhooks/7/ping", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for another tenant's webhook, got %d", rr.Code)
+	}
+}
+
+func TestDeleteWebhook(t *testing.T) {
+	server := &api.Server{
+		Service: &mockService{
+			DeleteWebhookFn: func(id int64, tenant string) error {
+				if id != 7 {
+					return fmt.Errorf("webhook %d %w", id, repository.ErrNotFound)
+				}
+				return nil
+			},
+		},
+	}
+
+	for url, want := range map[string]int{"/webhooks/7": http.StatusNoContent, "/webhooks/8": http.StatusNotFound, "/webhooks/x": http.StatusBadRequest} {
+		rr := httptest.NewRecorder()
+		webhookRouter(server).ServeHTTP(rr, httptest.NewRequest("DELETE", url, nil))
+		if rr.Code != want {
+			t.Errorf("%s: expected %d, got %d", url, want, rr.Code)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+// Package backfill runs backfills, the batch jobs of expand/contract schema
+// changes. A change that replaces a column is rolled out in three steps so the
+// old and new releases can run side by side:
+//
+//  1. expand: a migration adds the new column, nullable, and the new release
+//     writes it alongside the old one;
+//  2. backfill: a Job fills in the new column for the rows written before the
+//     new release, or by servers still running the old one;
+//  3. contract: once the backfill has caught up after the last old server is
+//     gone, a migration makes the new column NOT NULL, and a later release
+//     stops reading and writing the old one.
+//
+// A Runner processes a job in batches of rows in key order, each batch in its
+// own transaction together with the job's progress, so a run can be stopped at
+// any time and resumed where it left off.
+package backfill
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/metrics"
+	"github.com/nehciyy/intrapay/internal/models"
+)
+
+// ErrConcurrentRun is returned when another run advanced the backfill while a
+// batch was in progress. Only one run of a backfill should be active at a time.
+var ErrConcurrentRun = errors.New("backfill advanced by another run")
+
+// reportInterval is how often a run logs its progress.
+const reportInterval = 10 * time.Second
+
+// markBackfill flags the transaction of a batch so the updated_at trigger
+// leaves the rows it fills in alone.
+const markBackfill = `SELECT set_config('intrapay.backfill', 'on', true)`
+
+// Store keeps the progress of backfills.
+type Store interface {
+	StartBackfill(name string) (*models.Backfill, error)
+	AdvanceBackfillTx(tx *sql.Tx, name string, from, to, rows int64) (bool, error)
+	CompleteBackfill(name string) error
+}
+
+// Step processes the batch of up to limit rows with keys above after, in tx. It
+// returns the last key of the batch, 0 when there are no rows left, and how many
+// rows it filled in. A step must be idempotent: rows that already hold a value
+// are left alone.
+type Step func(tx *sql.Tx, after int64, limit int) (last int64, updated int64, err error)
+
+// Job is a backfill.
+type Job struct {
+	// Name identifies the job's progress and its rows_updated_total metric,
+	// conventionally table.column.
+	Name string
+	Step Step
+	// Pending, if set, counts the rows still to fill in, for progress reports.
+	Pending func() (int64, error)
+}
+
+// Runner runs backfills.
+type Runner struct {
+	db        *sql.DB
+	store     Store
+	batchSize int
+	logger    *log.Logger
+	pause     time.Duration
+}
+
+// Option configures a Runner.
+type Option func(*Runner)
+
+// WithPause makes the runner wait d between batches, leaving the database room
+// for live traffic.
+func WithPause(d time.Duration) Option {
+	return func(r *Runner) { r.pause = d }
+}
+
+// NewRunner creates a Runner processing batchSize rows per transaction.
+func NewRunner(db *sql.DB, store Store, batchSize int, logger *log.Logger, opts ...Option) *Runner {
+	r := &Runner{db: db, store: store, batchSize: batchSize, logger: logger}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Run processes job from where it last left off until it catches up with the
+// table, ctx is cancelled or a batch fails, and returns its progress. Rows
+// written after a run caught up are picked up by the next run.
+func (r *Runner) Run(ctx context.Context, job Job) (*models.Backfill, error) {
+	progress, err := r.store.StartBackfill(job.Name)
+	if err != nil {
+		return nil, fmt.Errorf("start backfill %s: %w", job.Name, err)
+	}
+	r.report(job, progress)
+	reported := time.Now()
+	for {
+		last, updated, err := r.step(ctx, job, progress.LastID)
+		if err != nil {
+			return progress, fmt.Errorf("backfill %s after %d: %w", job.Name, progress.LastID, err)
+		}
+		if last == 0 {
+			if err := r.store.CompleteBackfill(job.Name); err != nil {
+				return progress, fmt.Errorf("complete backfill %s: %w", job.Name, err)
+			}
+			completed := time.Now().UTC()
+			progress.CompletedAt = &completed
+			r.report(job, progress)
+			return progress, nil
+		}
+		progress.LastID = last
+		progress.RowsUpdated += updated
+		metrics.BackfillRows.WithLabelValues(job.Name).Add(float64(updated))
+		if time.Since(reported) >= reportInterval {
+			r.report(job, progress)
+			reported = time.Now()
+		}
+
+		select {
+		case <-ctx.Done():
+			return progress, ctx.Err()
+		case <-time.After(r.pause):
+		}
+	}
+}
+
+// step processes the batch after after and moves the progress past it in one
+// transaction. It returns 0 when there are no rows left.
+func (r *Runner) step(ctx context.Context, job Job, after int64) (int64, int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, markBackfill); err != nil {
+		return 0, 0, err
+	}
+	last, updated, err := job.Step(tx, after, r.batchSize)
+	if err != nil || last == 0 {
+		return 0, 0, err
+	}
+	moved, err := r.store.AdvanceBackfillTx(tx, job.Name, after, last, updated)
+	if err != nil {
+		return 0, 0, err
+	}
+	if !moved {
+		return 0, 0, ErrConcurrentRun
+	}
+	return last, updated, tx.Commit()
+}
+
+// report logs the progress of job, counting the rows still pending if the job
+// can.
+func (r *Runner) report(job Job, progress *models.Backfill) {
+	pending := ""
+	if job.Pending != nil {
+		n, err := job.Pending()
+		if err != nil {
+			r.logger.Printf("backfill %s: count pending rows: %v", job.Name, err)
+		} else {
+			progress.Pending = &n
+			pending = fmt.Sprintf(", %d pending", n)
+		}
+	}
+	state := "in progress"
+	if progress.CompletedAt != nil {
+		state = "caught up"
+	}
+	r.logger.Printf("backfill %s: %s, %d rows updated up to %d%s", job.Name, state, progress.RowsUpdated, progress.LastID, pending)
+}
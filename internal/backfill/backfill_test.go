@@ -0,0 +1,164 @@
+package backfill
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"log"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nehciyy/intrapay/internal/models"
+)
+
+var discard = log.New(io.Discard, "", 0)
+
+// memStore keeps the progress of one backfill in memory.
+type memStore struct {
+	progress  models.Backfill
+	completed bool
+	// moveTo, when set, moves the progress as if by another run.
+	moveTo *int64
+}
+
+func (s *memStore) StartBackfill(name string) (*models.Backfill, error) {
+	s.progress.Name = name
+	p := s.progress
+	return &p, nil
+}
+
+func (s *memStore) AdvanceBackfillTx(tx *sql.Tx, name string, from, to, rows int64) (bool, error) {
+	if s.moveTo != nil {
+		s.progress.LastID = *s.moveTo
+	}
+	if s.progress.LastID != from {
+		return false, nil
+	}
+	s.progress.LastID = to
+	s.progress.RowsUpdated += rows
+	return true, nil
+}
+
+func (s *memStore) CompleteBackfill(name string) error {
+	s.completed = true
+	return nil
+}
+
+// table is a column being backfilled: filled[key] reports whether the row with
+// that key holds a value.
+type table struct {
+	keys   []int64
+	filled map[int64]bool
+}
+
+func (t *table) job() Job {
+	return Job{
+		Name: "transactions.amount_minor",
+		Step: func(tx *sql.Tx, after int64, limit int) (int64, int64, error) {
+			var last, updated int64
+			for _, k := range t.keys {
+				if k <= after || limit == 0 {
+					continue
+				}
+				if !t.filled[k] {
+					t.filled[k] = true
+					updated++
+				}
+				last = k
+				limit--
+			}
+			return last, updated, nil
+		},
+		Pending: func() (int64, error) {
+			var n int64
+			for _, k := range t.keys {
+				if !t.filled[k] {
+					n++
+				}
+			}
+			return n, nil
+		},
+	}
+}
+
+// expectBatches expects n committed batch transactions.
+func expectBatches(mock sqlmock.Sqlmock, n int) {
+	for range n {
+		mock.ExpectBegin()
+		mock.ExpectExec("set_config").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+	}
+}
+
+func TestRunner_Run(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	rows := &table{keys: []int64{1, 2, 3, 5, 8}, filled: map[int64]bool{3: true}}
+	store := &memStore{}
+	runner := NewRunner(db, store, 2, discard)
+
+	expectBatches(mock, 3)
+	// The last transaction finds no rows left and is rolled back.
+	mock.ExpectBegin()
+	mock.ExpectExec("set_config").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	progress, err := runner.Run(context.Background(), rows.job())
+	require.NoError(t, err)
+	assert.Equal(t, int64(8), progress.LastID)
+	assert.Equal(t, int64(4), progress.RowsUpdated)
+	assert.NotNil(t, progress.CompletedAt)
+	require.NotNil(t, progress.Pending)
+	assert.Equal(t, int64(0), *progress.Pending)
+	assert.True(t, store.completed)
+	assert.Equal(t, models.Backfill{Name: "transactions.amount_minor", LastID: 8, RowsUpdated: 4}, store.progress)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRunner_Resume(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	// A previous run filled in up to key 3; a row written since by the old
+	// release is picked up.
+	rows := &table{keys: []int64{1, 2, 3, 5}, filled: map[int64]bool{1: true, 2: true, 3: true}}
+	store := &memStore{progress: models.Backfill{LastID: 3, RowsUpdated: 3}}
+	runner := NewRunner(db, store, 10, discard)
+
+	expectBatches(mock, 1)
+	mock.ExpectBegin()
+	mock.ExpectExec("set_config").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	progress, err := runner.Run(context.Background(), rows.job())
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), progress.LastID)
+	assert.Equal(t, int64(4), progress.RowsUpdated)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRunner_ConcurrentRun(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	rows := &table{keys: []int64{1, 2, 3}, filled: map[int64]bool{}}
+	moved := int64(2)
+	store := &memStore{moveTo: &moved}
+	runner := NewRunner(db, store, 2, discard)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("set_config").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	_, err = runner.Run(context.Background(), rows.job())
+	assert.ErrorIs(t, err, ErrConcurrentRun)
+	assert.False(t, store.completed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
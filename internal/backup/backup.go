@@ -0,0 +1,464 @@
+// Package backup takes logical backups of the ledger and restores them, for
+// disaster recovery when the database's own backups are unavailable or a
+// ledger must be moved to a new database.
+//
+// A backup is a stream of JSON lines: a header, then for each table of Tables
+// a line naming its columns followed by one line per row holding the row's
+// values as text, and finally a Manifest. All of it is read from a single
+// snapshot, so the tables are consistent with one another. The manifest records
+// the row count and a SHA-256 checksum of each table, the sequence values, and
+// the accounts whose balance already differed from their transaction log when
+// the backup was taken.
+//
+// Restore loads a backup into a database whose schema is migrated and whose
+// ledger tables are empty, in one transaction. It commits only if every table
+// matches its checksum and every account balance agrees with the restored
+// transaction log as it did in the backup.
+package backup
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+var (
+	// ErrCorrupt is returned for a backup that is malformed, truncated or does
+	// not match its checksums.
+	ErrCorrupt = errors.New("corrupt backup")
+	// ErrNotEmpty is returned when restoring into a database that already holds
+	// ledger data.
+	ErrNotEmpty = errors.New("target database is not empty")
+	// ErrVerification is returned when the restored balances do not agree with
+	// the restored transaction log as they did in the backup.
+	ErrVerification = errors.New("restored ledger failed verification")
+)
+
+// Format and Version identify the backup format in its header.
+const (
+	Format  = "intrapay-backup"
+	Version = 1
+)
+
+// Table is a table included in backups.
+type Table struct {
+	Name string
+	// Key orders the rows, so the same data always yields the same checksum.
+	Key string
+}
+
+// Tables are the tables a backup holds, each after the tables it references.
+var Tables = []Table{
+	{Name: "accounts", Key: "account_id"},
+	{Name: "transactions", Key: "id"},
+	{Name: "balance_adjustments", Key: "id"},
+	{Name: "ledger_entries", Key: "id"},
+	{Name: "suspense_items", Key: "id"},
+}
+
+// Manifest summarises a backup. It is the last line of the backup.
+type Manifest struct {
+	CreatedAt time.Time        `json:"created_at"`
+	Tables    []TableSummary   `json:"tables"`
+	Sequences map[string]int64 `json:"sequences"`
+	// Drift lists the accounts whose balance differed from the balance rebuilt
+	// from their opening balance, transactions and adjustments.
+	Drift []Drift `json:"drift"`
+}
+
+// TableSummary is the row count and checksum of a table in a backup.
+type TableSummary struct {
+	Name   string `json:"name"`
+	Rows   int64  `json:"rows"`
+	SHA256 string `json:"sha256"`
+}
+
+// Drift is an account whose balance differs from the balance rebuilt from its
+// transaction log. Amounts are kept as the database prints them so they compare
+// exactly.
+type Drift struct {
+	AccountID int64  `json:"account_id"`
+	Balance   string `json:"balance"`
+	Computed  string `json:"computed"`
+}
+
+// header is the first line of a backup.
+type header struct {
+	Format  string `json:"format"`
+	Version int    `json:"version"`
+}
+
+// record is a line of a backup after the header other than a row: a table's
+// columns or the manifest.
+type record struct {
+	Table    string    `json:"table,omitempty"`
+	Columns  []string  `json:"columns,omitempty"`
+	Manifest *Manifest `json:"manifest,omitempty"`
+}
+
+const listColumns = `SELECT column_name FROM information_schema.columns
+WHERE table_schema = current_schema() AND table_name = $1 ORDER BY ordinal_position`
+
+const listSequences = `SELECT sequencename, last_value FROM pg_sequences
+WHERE schemaname = current_schema() AND last_value IS NOT NULL ORDER BY sequencename`
+
+// listDrift rebuilds every account's balance as ComputeBalance does.
+const listDrift = `SELECT account_id, balance::text, computed::text FROM (
+	SELECT a.account_id, a.balance, (a.opening_balance
+		+ COALESCE((SELECT SUM(amount) FROM transactions WHERE destination_account_id = a.account_id), 0)
+		- COALESCE((SELECT SUM(amount) FROM transactions WHERE source_account_id = a.account_id), 0)
+		+ COALESCE((SELECT SUM(amount) FROM balance_adjustments WHERE account_id = a.account_id), 0))::numeric AS computed
+	FROM accounts a
+) d WHERE balance <> computed ORDER BY account_id`
+
+// setSequence moves a sequence forward to the backed-up value, never back.
+const setSequence = `SELECT setval(quote_ident($1)::regclass, GREATEST($2::bigint,
+	COALESCE((SELECT last_value FROM pg_sequences WHERE schemaname = current_schema() AND sequencename = $1), 0)))`
+
+// Dump writes a backup of the ledger tables to w and returns its manifest.
+func Dump(ctx context.Context, db *sql.DB, w io.Writer) (*Manifest, error) {
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	bw := bufio.NewWriter(w)
+	if err := writeLine(bw, header{Format: Format, Version: Version}); err != nil {
+		return nil, err
+	}
+	manifest := &Manifest{CreatedAt: time.Now().UTC(), Tables: []TableSummary{}}
+	for _, t := range Tables {
+		summary, err := dumpTable(ctx, tx, bw, t)
+		if err != nil {
+			return nil, fmt.Errorf("dump %s: %w", t.Name, err)
+		}
+		manifest.Tables = append(manifest.Tables, *summary)
+	}
+	if manifest.Sequences, err = sequences(ctx, tx); err != nil {
+		return nil, err
+	}
+	if manifest.Drift, err = drift(ctx, tx); err != nil {
+		return nil, err
+	}
+	if err := writeLine(bw, record{Manifest: manifest}); err != nil {
+		return nil, err
+	}
+	if err := bw.Flush(); err != nil {
+		return nil, err
+	}
+	return manifest, tx.Commit()
+}
+
+// dumpTable writes the column line and the rows of t.
+func dumpTable(ctx context.Context, tx *sql.Tx, w io.Writer, t Table) (*TableSummary, error) {
+	columns, err := tableColumns(ctx, tx, t.Name)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeLine(w, record{Table: t.Name, Columns: columns}); err != nil {
+		return nil, err
+	}
+
+	values := make([]string, len(columns))
+	for i, c := range columns {
+		values[i] = pq.QuoteIdentifier(c) + "::text"
+	}
+	rows, err := tx.QueryContext(ctx, "SELECT ARRAY["+strings.Join(values, ", ")+"] FROM "+
+		pq.QuoteIdentifier(t.Name)+" ORDER BY "+pq.QuoteIdentifier(t.Key))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sum := newChecksum(t.Name)
+	for rows.Next() {
+		var fields []sql.NullString
+		if err := rows.Scan(pq.Array(&fields)); err != nil {
+			return nil, err
+		}
+		row := make([]*string, len(fields))
+		for i, f := range fields {
+			if f.Valid {
+				row[i] = &f.String
+			}
+		}
+		line, err := json.Marshal(row)
+		if err != nil {
+			return nil, err
+		}
+		line = append(line, '\n')
+		if _, err := w.Write(line); err != nil {
+			return nil, err
+		}
+		sum.add(line)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return sum.summary(), nil
+}
+
+func tableColumns(ctx context.Context, tx *sql.Tx, table string) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, listColumns, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var columns []string
+	for rows.Next() {
+		var c string
+		if err := rows.Scan(&c); err != nil {
+			return nil, err
+		}
+		columns = append(columns, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("table %s not found", table)
+	}
+	return columns, nil
+}
+
+func sequences(ctx context.Context, tx *sql.Tx) (map[string]int64, error) {
+	rows, err := tx.QueryContext(ctx, listSequences)
+	if err != nil {
+		return nil, fmt.Errorf("list sequences: %w", err)
+	}
+	defer rows.Close()
+	values := map[string]int64{}
+	for rows.Next() {
+		var name string
+		var value int64
+		if err := rows.Scan(&name, &value); err != nil {
+			return nil, err
+		}
+		values[name] = value
+	}
+	return values, rows.Err()
+}
+
+func drift(ctx context.Context, tx *sql.Tx) ([]Drift, error) {
+	rows, err := tx.QueryContext(ctx, listDrift)
+	if err != nil {
+		return nil, fmt.Errorf("list balance drift: %w", err)
+	}
+	defer rows.Close()
+	accounts := []Drift{}
+	for rows.Next() {
+		var d Drift
+		if err := rows.Scan(&d.AccountID, &d.Balance, &d.Computed); err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, d)
+	}
+	return accounts, rows.Err()
+}
+
+// Restore loads the backup read from r into db and returns its manifest. With
+// dryRun set the restore is verified and then rolled back, leaving db as it
+// was.
+func Restore(ctx context.Context, db *sql.DB, r io.Reader, dryRun bool) (*Manifest, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	for _, t := range Tables {
+		var exists bool
+		if err := tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM "+pq.QuoteIdentifier(t.Name)+")").Scan(&exists); err != nil {
+			return nil, fmt.Errorf("check %s: %w", t.Name, err)
+		}
+		if exists {
+			return nil, fmt.Errorf("%w: %s has rows", ErrNotEmpty, t.Name)
+		}
+	}
+
+	manifest, loaded, err := load(ctx, tx, bufio.NewReader(r))
+	if err != nil {
+		return nil, err
+	}
+	if err := verify(ctx, tx, manifest, loaded); err != nil {
+		return nil, err
+	}
+	for _, name := range slices.Sorted(maps.Keys(manifest.Sequences)) {
+		if _, err := tx.ExecContext(ctx, setSequence, name, manifest.Sequences[name]); err != nil {
+			return nil, fmt.Errorf("set sequence %s: %w", name, err)
+		}
+	}
+	if dryRun {
+		return manifest, tx.Rollback()
+	}
+	return manifest, tx.Commit()
+}
+
+// load copies the rows of the backup into their tables and returns the
+// manifest along with the summaries of the tables as loaded.
+func load(ctx context.Context, tx *sql.Tx, r *bufio.Reader) (*Manifest, []TableSummary, error) {
+	line, err := r.ReadBytes('\n')
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: no header", ErrCorrupt)
+	}
+	var h header
+	if err := json.Unmarshal(line, &h); err != nil || h.Format != Format {
+		return nil, nil, fmt.Errorf("%w: not an %s file", ErrCorrupt, Format)
+	}
+	if h.Version != Version {
+		return nil, nil, fmt.Errorf("%w: unsupported version %d", ErrCorrupt, h.Version)
+	}
+
+	var (
+		loaded  = []TableSummary{}
+		table   *copier
+		columns int
+	)
+	for {
+		line, err := r.ReadBytes('\n')
+		if errors.Is(err, io.EOF) && len(line) == 0 {
+			return nil, nil, fmt.Errorf("%w: truncated, no manifest", ErrCorrupt)
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, nil, err
+		}
+
+		if line[0] == '[' {
+			if table == nil {
+				return nil, nil, fmt.Errorf("%w: row outside a table", ErrCorrupt)
+			}
+			var row []*string
+			if err := json.Unmarshal(line, &row); err != nil || len(row) != columns {
+				return nil, nil, fmt.Errorf("%w: malformed row in %s", ErrCorrupt, table.name)
+			}
+			if err := table.add(ctx, line, row); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+
+		var rec record
+		if err := json.Unmarshal(line, &rec); err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", ErrCorrupt, err)
+		}
+		if table != nil {
+			summary, err := table.close(ctx)
+			if err != nil {
+				return nil, nil, err
+			}
+			loaded = append(loaded, *summary)
+			table = nil
+		}
+		switch {
+		case rec.Manifest != nil:
+			return rec.Manifest, loaded, nil
+		case rec.Table != "":
+			if !slices.ContainsFunc(Tables, func(t Table) bool { return t.Name == rec.Table }) {
+				return nil, nil, fmt.Errorf("%w: unexpected table %q", ErrCorrupt, rec.Table)
+			}
+			if table, err = newCopier(ctx, tx, rec.Table, rec.Columns); err != nil {
+				return nil, nil, err
+			}
+			columns = len(rec.Columns)
+		default:
+			return nil, nil, fmt.Errorf("%w: unexpected line", ErrCorrupt)
+		}
+	}
+}
+
+// verify compares the loaded tables with the manifest, and the balances
+// rebuilt from the loaded transaction log with those of the backup.
+func verify(ctx context.Context, tx *sql.Tx, manifest *Manifest, loaded []TableSummary) error {
+	if !slices.Equal(loaded, manifest.Tables) {
+		return fmt.Errorf("%w: tables do not match the manifest: loaded %v, expected %v", ErrCorrupt, loaded, manifest.Tables)
+	}
+	restored, err := drift(ctx, tx)
+	if err != nil {
+		return err
+	}
+	if !slices.Equal(restored, manifest.Drift) {
+		return fmt.Errorf("%w: accounts whose balance differs from their transactions: %v, expected %v", ErrVerification, restored, manifest.Drift)
+	}
+	return nil
+}
+
+// copier loads the rows of one table with COPY.
+type copier struct {
+	name string
+	stmt *sql.Stmt
+	sum  *checksum
+}
+
+func newCopier(ctx context.Context, tx *sql.Tx, table string, columns []string) (*copier, error) {
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn(table, columns...))
+	if err != nil {
+		return nil, fmt.Errorf("restore %s: %w", table, err)
+	}
+	return &copier{name: table, stmt: stmt, sum: newChecksum(table)}, nil
+}
+
+func (c *copier) add(ctx context.Context, line []byte, row []*string) error {
+	args := make([]any, len(row))
+	for i, v := range row {
+		if v != nil {
+			args[i] = *v
+		}
+	}
+	if _, err := c.stmt.ExecContext(ctx, args...); err != nil {
+		return fmt.Errorf("restore %s: %w", c.name, err)
+	}
+	c.sum.add(line)
+	return nil
+}
+
+// close flushes the rows buffered by COPY.
+func (c *copier) close(ctx context.Context) (*TableSummary, error) {
+	defer c.stmt.Close()
+	if _, err := c.stmt.ExecContext(ctx); err != nil {
+		return nil, fmt.Errorf("restore %s: %w", c.name, err)
+	}
+	return c.sum.summary(), nil
+}
+
+// checksum counts the row lines of a table and hashes them.
+type checksum struct {
+	name string
+	rows int64
+	h    hash.Hash
+}
+
+func newChecksum(name string) *checksum {
+	return &checksum{name: name, h: sha256.New()}
+}
+
+func (c *checksum) add(line []byte) {
+	c.rows++
+	c.h.Write(line)
+}
+
+func (c *checksum) summary() *TableSummary {
+	return &TableSummary{Name: c.name, Rows: c.rows, SHA256: hex.EncodeToString(c.h.Sum(nil))}
+}
+
+func writeLine(w io.Writer, v any) error {
+	line, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(line, '\n'))
+	return err
+}
@@ -0,0 +1,203 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fixture is the ledger the tests back up: the columns and rows of each table.
+var fixture = map[string]struct {
+	columns []string
+	rows    [][]driver.Value
+}{
+	"accounts": {
+		columns: []string{"account_id", "balance", "deleted_at"},
+		rows: [][]driver.Value{
+			{"1", "70.00000", nil},
+			{"2", "130.00000", "2024-05-01 12:00:00+00"},
+		},
+	},
+	"transactions": {
+		columns: []string{"id", "source_account_id", "destination_account_id", "amount"},
+		rows:    [][]driver.Value{{"1", "1", "2", "30.00000"}},
+	},
+}
+
+var drifted = Drift{AccountID: 2, Balance: "130.00000", Computed: "129.00000"}
+
+func expectDump(mock sqlmock.Sqlmock) {
+	mock.ExpectBegin()
+	for _, table := range Tables {
+		f, ok := fixture[table.Name]
+		if !ok {
+			f.columns = []string{"id"}
+		}
+		columns := sqlmock.NewRows([]string{"column_name"})
+		for _, c := range f.columns {
+			columns.AddRow(c)
+		}
+		mock.ExpectQuery("information_schema.columns").WithArgs(table.Name).WillReturnRows(columns)
+
+		rows := sqlmock.NewRows([]string{"array"})
+		for _, r := range f.rows {
+			fields := make([]string, len(r))
+			for i, v := range r {
+				if v == nil {
+					fields[i] = "NULL"
+				} else {
+					fields[i] = `"` + v.(string) + `"`
+				}
+			}
+			rows.AddRow("{" + strings.Join(fields, ",") + "}")
+		}
+		mock.ExpectQuery(`SELECT ARRAY\[.*\] FROM "` + table.Name + `"`).WillReturnRows(rows)
+	}
+	mock.ExpectQuery("FROM pg_sequences").
+		WillReturnRows(sqlmock.NewRows([]string{"sequencename", "last_value"}).
+			AddRow("account_id_seq", 3).AddRow("transactions_id_seq", 1))
+	mock.ExpectQuery("WHERE balance <> computed").
+		WillReturnRows(sqlmock.NewRows([]string{"account_id", "balance", "computed"}).
+			AddRow(drifted.AccountID, drifted.Balance, drifted.Computed))
+	mock.ExpectCommit()
+}
+
+// expectLoad expects the tables to be checked empty and the rows copied in,
+// matching the values copied unless the backup was tampered with.
+func expectLoad(mock sqlmock.Sqlmock, tampered bool) {
+	mock.ExpectBegin()
+	for _, table := range Tables {
+		mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM "` + table.Name + `"\)`).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	}
+	for _, table := range Tables {
+		prepare := mock.ExpectPrepare(`COPY "` + table.Name + `"`).WillBeClosed()
+		for _, r := range fixture[table.Name].rows {
+			exec := prepare.ExpectExec().WillReturnResult(sqlmock.NewResult(0, 1))
+			if !tampered {
+				exec.WithArgs(r...)
+			}
+		}
+		prepare.ExpectExec().WithoutArgs().WillReturnResult(sqlmock.NewResult(0, int64(len(fixture[table.Name].rows))))
+	}
+}
+
+func dump(t *testing.T) []byte {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	expectDump(mock)
+
+	var buf bytes.Buffer
+	manifest, err := Dump(context.Background(), db, &buf)
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	require.Len(t, manifest.Tables, len(Tables))
+	assert.Equal(t, TableSummary{Name: "accounts", Rows: 2, SHA256: manifest.Tables[0].SHA256}, manifest.Tables[0])
+	assert.Equal(t, int64(1), manifest.Tables[1].Rows)
+	assert.Equal(t, map[string]int64{"account_id_seq": 3, "transactions_id_seq": 1}, manifest.Sequences)
+	assert.Equal(t, []Drift{drifted}, manifest.Drift)
+	return buf.Bytes()
+}
+
+func TestDumpAndRestore(t *testing.T) {
+	backup := dump(t)
+	lines := strings.Split(strings.TrimSpace(string(backup)), "\n")
+	assert.Equal(t, `{"format":"intrapay-backup","version":1}`, lines[0])
+	assert.Equal(t, `{"table":"accounts","columns":["account_id","balance","deleted_at"]}`, lines[1])
+	assert.Equal(t, `["2","130.00000","2024-05-01 12:00:00+00"]`, lines[3])
+
+	t.Run("Restore", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		expectLoad(mock, false)
+		mock.ExpectQuery("WHERE balance <> computed").
+			WillReturnRows(sqlmock.NewRows([]string{"account_id", "balance", "computed"}).
+				AddRow(drifted.AccountID, drifted.Balance, drifted.Computed))
+		mock.ExpectExec("SELECT setval").WithArgs("account_id_seq", 3).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("SELECT setval").WithArgs("transactions_id_seq", 1).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		manifest, err := Restore(context.Background(), db, bytes.NewReader(backup), false)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), manifest.Tables[0].Rows)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Dry Run", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		expectLoad(mock, false)
+		mock.ExpectQuery("WHERE balance <> computed").
+			WillReturnRows(sqlmock.NewRows([]string{"account_id", "balance", "computed"}).
+				AddRow(drifted.AccountID, drifted.Balance, drifted.Computed))
+		mock.ExpectExec("SELECT setval").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("SELECT setval").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectRollback()
+
+		_, err = Restore(context.Background(), db, bytes.NewReader(backup), true)
+		require.NoError(t, err)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Balances Disagree", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		expectLoad(mock, false)
+		mock.ExpectQuery("WHERE balance <> computed").
+			WillReturnRows(sqlmock.NewRows([]string{"account_id", "balance", "computed"}))
+		mock.ExpectRollback()
+
+		_, err = Restore(context.Background(), db, bytes.NewReader(backup), false)
+		assert.ErrorIs(t, err, ErrVerification)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Tampered", func(t *testing.T) {
+		tampered := bytes.Replace(backup, []byte(`"30.00000"`), []byte(`"3000.00000"`), 1)
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		expectLoad(mock, true)
+		mock.ExpectRollback()
+
+		_, err = Restore(context.Background(), db, bytes.NewReader(tampered), false)
+		assert.ErrorIs(t, err, ErrCorrupt)
+	})
+
+	t.Run("Truncated", func(t *testing.T) {
+		truncated := backup[:bytes.LastIndex(backup[:len(backup)-1], []byte("\n"))+1]
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		expectLoad(mock, false)
+		mock.ExpectRollback()
+
+		_, err = Restore(context.Background(), db, bytes.NewReader(truncated), false)
+		assert.ErrorIs(t, err, ErrCorrupt)
+	})
+}
+
+func TestRestore_NotEmpty(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM "accounts"\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectRollback()
+
+	_, err = Restore(context.Background(), db, strings.NewReader(""), false)
+	assert.ErrorIs(t, err, ErrNotEmpty)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,105 @@
+// Package chaos injects latency and failures into the HTTP, repository and
+// driver layers so retry and rollback behaviour can be exercised under
+// realistic fault conditions. It is meant for test and staging environments
+// only and does nothing unless CHAOS_ENABLED is set.
+package chaos
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrConnectionDropped is returned by decorated repositories when a dropped
+// connection is injected.
+var ErrConnectionDropped = errors.New("chaos: connection dropped")
+
+// Config controls which faults are injected and how often. Rates are
+// probabilities in the range [0, 1].
+type Config struct {
+	Enabled                  bool
+	Latency                  time.Duration
+	LatencyRate              float64
+	SerializationFailureRate float64
+	CommitErrorRate          float64
+	DropRate                 float64
+}
+
+// ConfigFromEnv reads CHAOS_ENABLED, CHAOS_LATENCY, CHAOS_LATENCY_RATE,
+// CHAOS_SERIALIZATION_FAILURE_RATE, CHAOS_COMMIT_ERROR_RATE and CHAOS_DROP_RATE.
+func ConfigFromEnv() (Config, error) {
+	var cfg Config
+	if v := os.Getenv("CHAOS_ENABLED"); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid CHAOS_ENABLED %q: %w", v, err)
+		}
+		cfg.Enabled = enabled
+	}
+	if v := os.Getenv("CHAOS_LATENCY"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid CHAOS_LATENCY %q: %w", v, err)
+		}
+		cfg.Latency = d
+	}
+
+	rates := []struct {
+		key  string
+		dest *float64
+	}{
+		{"CHAOS_LATENCY_RATE", &cfg.LatencyRate},
+		{"CHAOS_SERIALIZATION_FAILURE_RATE", &cfg.SerializationFailureRate},
+		{"CHAOS_COMMIT_ERROR_RATE", &cfg.CommitErrorRate},
+		{"CHAOS_DROP_RATE", &cfg.DropRate},
+	}
+	for _, r := range rates {
+		v := os.Getenv(r.key)
+		if v == "" {
+			continue
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f < 0 || f > 1 {
+			return cfg, fmt.Errorf("invalid %s %q: must be between 0 and 1", r.key, v)
+		}
+		*r.dest = f
+	}
+	return cfg, nil
+}
+
+// Injector decides, per call, whether a fault should be injected.
+type Injector struct {
+	cfg Config
+
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+// New creates an Injector. The seed makes fault sequences reproducible.
+func New(cfg Config, seed int64) *Injector {
+	return &Injector{cfg: cfg, rnd: rand.New(rand.NewSource(seed))}
+}
+
+func (i *Injector) roll(rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.rnd.Float64() < rate
+}
+
+// delay sleeps for the configured latency at the configured rate.
+func (i *Injector) delay() {
+	if i.cfg.Latency > 0 && i.roll(i.cfg.LatencyRate) {
+		time.Sleep(i.cfg.Latency)
+	}
+}
+
+func (i *Injector) dropped() bool {
+	return i.roll(i.cfg.DropRate)
+}
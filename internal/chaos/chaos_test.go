@@ -0,0 +1,145 @@
+package chaos
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository"
+)
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("CHAOS_ENABLED", "true")
+	t.Setenv("CHAOS_LATENCY", "50ms")
+	t.Setenv("CHAOS_SERIALIZATION_FAILURE_RATE", "0.25")
+	t.Setenv("CHAOS_DROP_RATE", "")
+
+	cfg, err := ConfigFromEnv()
+	require.NoError(t, err)
+	assert.True(t, cfg.Enabled)
+	assert.Equal(t, 0.25, cfg.SerializationFailureRate)
+	assert.Zero(t, cfg.DropRate)
+
+	t.Setenv("CHAOS_COMMIT_ERROR_RATE", "1.5")
+	_, err = ConfigFromEnv()
+	assert.Error(t, err)
+}
+
+// stubAccountRepo embeds the interface so only the methods under test need stubbing.
+type stubAccountRepo struct {
+	repository.AccountRepository
+	calls int
+}
+
+func (s *stubAccountRepo) CreateAccount(int64, float64, string, string, bool) (*models.Account, error) {
+	s.calls++
+	return &models.Account{}, nil
+}
+func (s *stubAccountRepo) GetAccount(_ context.Context, id int64, _ bool) (*models.Account, error) {
+	s.calls++
+	return &models.Account{AccountID: id, Balance: 10}, nil
+}
+func (s *stubAccountRepo) AccountExists(context.Context, int64) (bool, error) {
+	s.calls++
+	return true, nil
+}
+
+var _ repository.AccountRepository = (*AccountRepository)(nil)
+var _ repository.TransactionRepository = (*TransactionRepository)(nil)
+
+func TestAccountRepository_Drop(t *testing.T) {
+	next := &stubAccountRepo{}
+
+	dropping := WrapAccountRepository(next, New(Config{Enabled: true, DropRate: 1}, 1))
+	_, err := dropping.GetAccount(context.Background(), 1, false)
+	assert.ErrorIs(t, err, ErrConnectionDropped)
+	assert.Zero(t, next.calls)
+
+	passing := WrapAccountRepository(next, New(Config{Enabled: true}, 1))
+	account, err := passing.GetAccount(context.Background(), 1, false)
+	assert.NoError(t, err)
+	assert.Equal(t, models.Amount(10), account.Balance)
+	assert.Equal(t, 1, next.calls)
+}
+
+func TestMiddleware_Drop(t *testing.T) {
+	inj := New(Config{Enabled: true, DropRate: 1}, 1)
+	handler := inj.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("handler should not be reached")
+	}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/accounts/1", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+}
+
+// dsnConnector adapts a registered driver to driver.Connector.
+type dsnConnector struct {
+	dsn string
+	drv driver.Driver
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) { return c.drv.Open(c.dsn) }
+func (c dsnConnector) Driver() driver.Driver                        { return c.drv }
+
+func openWrapped(t *testing.T, dsn string, inj *Injector) (*sql.DB, sqlmock.Sqlmock) {
+	base, mock, err := sqlmock.NewWithDSN(dsn)
+	require.NoError(t, err)
+	db := sql.OpenDB(inj.WrapConnector(dsnConnector{dsn: dsn, drv: base.Driver()}))
+	t.Cleanup(func() {
+		db.Close()
+		base.Close()
+	})
+	return db, mock
+}
+
+func TestWrapConnector_SerializationFailure(t *testing.T) {
+	db, mock := openWrapped(t, "chaos_serialization", New(Config{Enabled: true, SerializationFailureRate: 1}, 1))
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	err = tx.Commit()
+	assert.True(t, repository.IsSerializationFailure(err), "expected serialization failure, got %v", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWrapConnector_CommitError(t *testing.T) {
+	db, mock := openWrapped(t, "chaos_commit", New(Config{Enabled: true, CommitErrorRate: 1}, 1))
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	err = tx.Commit()
+	assert.True(t, errors.Is(err, ErrCommitFailed))
+	assert.False(t, repository.IsSerializationFailure(err))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWrapConnector_Passthrough(t *testing.T) {
+	db, mock := openWrapped(t, "chaos_passthrough", New(Config{Enabled: true}, 1))
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE accounts").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	_, err = tx.Exec("UPDATE accounts SET balance = balance + 1")
+	require.NoError(t, err)
+	assert.NoError(t, tx.Commit())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,102 @@
+package chaos
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// ErrCommitFailed is returned by Commit when a generic commit error is injected.
+var ErrCommitFailed = errors.New("chaos: commit failed")
+
+// WrapConnector returns a connector whose transactions fail to commit at the
+// configured rates, either with a Postgres serialization failure (SQLSTATE 40001,
+// which the service retries) or with a generic commit error (which it does not).
+// A failed commit rolls the underlying transaction back so no writes leak.
+func (i *Injector) WrapConnector(c driver.Connector) driver.Connector {
+	return &connector{Connector: c, inj: i}
+}
+
+type connector struct {
+	driver.Connector
+	inj *Injector
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &chaosConn{Conn: conn, inj: c.inj}, nil
+}
+
+type chaosConn struct {
+	driver.Conn
+	inj *Injector
+}
+
+func (c *chaosConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	var (
+		tx  driver.Tx
+		err error
+	)
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		tx, err = b.BeginTx(ctx, opts)
+	} else {
+		tx, err = c.Conn.Begin() //nolint:staticcheck // fallback for drivers without BeginTx
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &chaosTx{Tx: tx, inj: c.inj}, nil
+}
+
+func (c *chaosConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if q, ok := c.Conn.(driver.QueryerContext); ok {
+		return q.QueryContext(ctx, query, args)
+	}
+	return nil, driver.ErrSkip
+}
+
+func (c *chaosConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if e, ok := c.Conn.(driver.ExecerContext); ok {
+		return e.ExecContext(ctx, query, args)
+	}
+	return nil, driver.ErrSkip
+}
+
+func (c *chaosConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *chaosConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+type chaosTx struct {
+	driver.Tx
+	inj *Injector
+}
+
+func (t *chaosTx) Commit() error {
+	switch {
+	case t.inj.roll(t.inj.cfg.SerializationFailureRate):
+		t.Tx.Rollback()
+		return &pq.Error{
+			Code:    "40001",
+			Message: "chaos: could not serialize access due to concurrent update",
+		}
+	case t.inj.roll(t.inj.cfg.CommitErrorRate):
+		t.Tx.Rollback()
+		return ErrCommitFailed
+	}
+	return t.Tx.Commit()
+}
@@ -0,0 +1,23 @@
+package chaos
+
+import "net/http"
+
+// Middleware delays requests and drops connections at the configured rates.
+// A dropped connection is closed without a response when the writer supports
+// hijacking, otherwise a 503 is returned.
+func (i *Injector) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		i.delay()
+		if i.dropped() {
+			if hj, ok := w.(http.Hijacker); ok {
+				if conn, _, err := hj.Hijack(); err == nil {
+					conn.Close()
+					return
+				}
+			}
+			http.Error(w, "chaos: connection dropped", http.StatusServiceUnavailable)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
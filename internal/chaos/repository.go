@@ -0,0 +1,212 @@
+package chaos
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository"
+)
+
+// AccountRepository decorates a repository.AccountRepository with injected latency
+// and dropped connections.
+type AccountRepository struct {
+	next repository.AccountRepository
+	inj  *Injector
+}
+
+// WrapAccountRepository returns next decorated with the injector's faults.
+func WrapAccountRepository(next repository.AccountRepository, inj *Injector) *AccountRepository {
+	return &AccountRepository{next: next, inj: inj}
+}
+
+func (r *AccountRepository) fault() error {
+	r.inj.delay()
+	if r.inj.dropped() {
+		return ErrConnectionDropped
+	}
+	return nil
+}
+
+func (r *AccountRepository) CreateAccount(accountID int64, initialBalance float64, tenant, owner string, livemode bool) (*models.Account, error) {
+	if err := r.fault(); err != nil {
+		return nil, err
+	}
+	return r.next.CreateAccount(accountID, initialBalance, tenant, owner, livemode)
+}
+
+func (r *AccountRepository) AccountExists(ctx context.Context, accountID int64) (bool, error) {
+	if err := r.fault(); err != nil {
+		return false, err
+	}
+	return r.next.AccountExists(ctx, accountID)
+}
+
+func (r *AccountRepository) GetAccount(ctx context.Context, accountID int64, includeDeleted bool) (*models.Account, error) {
+	if err := r.fault(); err != nil {
+		return nil, err
+	}
+	return r.next.GetAccount(ctx, accountID, includeDeleted)
+}
+
+func (r *AccountRepository) ListAccounts(ctx context.Context, filter models.AccountFilter, afterID int64, limit int) ([]models.Account, error) {
+	if err := r.fault(); err != nil {
+		return nil, err
+	}
+	return r.next.ListAccounts(ctx, filter, afterID, limit)
+}
+
+func (r *AccountRepository) DeleteAccount(accountID int64) error {
+	if err := r.fault(); err != nil {
+		return err
+	}
+	return r.next.DeleteAccount(accountID)
+}
+
+func (r *AccountRepository) RestoreAccount(accountID int64) error {
+	if err := r.fault(); err != nil {
+		return err
+	}
+	return r.next.RestoreAccount(accountID)
+}
+
+func (r *AccountRepository) SetDisplayName(accountID int64, name string) error {
+	if err := r.fault(); err != nil {
+		return err
+	}
+	return r.next.SetDisplayName(accountID, name)
+}
+
+func (r *AccountRepository) CountAccounts(tenant string) (int64, error) {
+	if err := r.fault(); err != nil {
+		return 0, err
+	}
+	return r.next.CountAccounts(tenant)
+}
+
+// TransactionRepository decorates a repository.TransactionRepository with injected
+// latency and dropped connections. Latency on GetAccountBalanceTx lengthens the
+// time row locks are held, which is what provokes contention in practice.
+type TransactionRepository struct {
+	next repository.TransactionRepository
+	inj  *Injector
+}
+
+// WrapTransactionRepository returns next decorated with the injector's faults.
+func WrapTransactionRepository(next repository.TransactionRepository, inj *Injector) *TransactionRepository {
+	return &TransactionRepository{next: next, inj: inj}
+}
+
+func (r *TransactionRepository) fault() error {
+	r.inj.delay()
+	if r.inj.dropped() {
+		return ErrConnectionDropped
+	}
+	return nil
+}
+
+func (r *TransactionRepository) GetAccountBalanceTx(tx *sql.Tx, accountID int64) (float64, error) {
+	if err := r.fault(); err != nil {
+		return 0, err
+	}
+	return r.next.GetAccountBalanceTx(tx, accountID)
+}
+
+func (r *TransactionRepository) AccountExistsTx(tx *sql.Tx, accountID int64) (bool, error) {
+	if err := r.fault(); err != nil {
+		return false, err
+	}
+	return r.next.AccountExistsTx(tx, accountID)
+}
+
+func (r *TransactionRepository) UpdateBalanceTx(tx *sql.Tx, accountID int64, delta float64) error {
+	if err := r.fault(); err != nil {
+		return err
+	}
+	return r.next.UpdateBalanceTx(tx, accountID, delta)
+}
+
+func (r *TransactionRepository) DebitBalanceTx(tx *sql.Tx, accountID int64, amount float64) (float64, error) {
+	if err := r.fault(); err != nil {
+		return 0, err
+	}
+	return r.next.DebitBalanceTx(tx, accountID, amount)
+}
+
+func (r *TransactionRepository) InsertTransactionLogTx(tx *sql.Tx, sourceID, destID int64, amount float64) (string, error) {
+	if err := r.fault(); err != nil {
+		return "", err
+	}
+	return r.next.InsertTransactionLogTx(tx, sourceID, destID, amount)
+}
+
+func (r *TransactionRepository) InsertTransactionLogsTx(tx *sql.Tx, logs []repository.TransactionLog) ([]string, error) {
+	if err := r.fault(); err != nil {
+		return nil, err
+	}
+	return r.next.InsertTransactionLogsTx(tx, logs)
+}
+
+func (r *TransactionRepository) GetTransaction(ctx context.Context, id string) (*models.Transaction, error) {
+	if err := r.fault(); err != nil {
+		return nil, err
+	}
+	return r.next.GetTransaction(ctx, id)
+}
+
+func (r *TransactionRepository) GetTransactions(ctx context.Context, ids []string) (map[string]models.Transaction, error) {
+	if err := r.fault(); err != nil {
+		return nil, err
+	}
+	return r.next.GetTransactions(ctx, ids)
+}
+
+func (r *TransactionRepository) ListTransactions(ctx context.Context, filter models.TransactionFilter, after repository.ChangeCursor, limit int) ([]models.Transaction, repository.ChangeCursor, error) {
+	if err := r.fault(); err != nil {
+		return nil, after, err
+	}
+	return r.next.ListTransactions(ctx, filter, after, limit)
+}
+
+func (r *TransactionRepository) ListAccountTransactions(ctx context.Context, accountID int64, after repository.ChangeCursor, limit int) ([]models.Transaction, repository.ChangeCursor, error) {
+	if err := r.fault(); err != nil {
+		return nil, after, err
+	}
+	return r.next.ListAccountTransactions(ctx, accountID, after, limit)
+}
+
+func (r *TransactionRepository) SumAccountFlows(ctx context.Context, accountID int64, since time.Time) (*models.AccountFlows, error) {
+	if err := r.fault(); err != nil {
+		return nil, err
+	}
+	return r.next.SumAccountFlows(ctx, accountID, since)
+}
+
+func (r *TransactionRepository) CountTransactions(ctx context.Context, filter models.TransactionFilter) (int64, error) {
+	if err := r.fault(); err != nil {
+		return 0, err
+	}
+	return r.next.CountTransactions(ctx, filter)
+}
+
+func (r *TransactionRepository) ListTransactionChanges(ctx context.Context, after repository.ChangeCursor, livemode bool, limit int) ([]models.Transaction, repository.ChangeCursor, error) {
+	if err := r.fault(); err != nil {
+		return nil, after, err
+	}
+	return r.next.ListTransactionChanges(ctx, after, livemode, limit)
+}
+
+func (r *TransactionRepository) ComputeBalanceTx(tx *sql.Tx, accountID int64) (float64, error) {
+	if err := r.fault(); err != nil {
+		return 0, err
+	}
+	return r.next.ComputeBalanceTx(tx, accountID)
+}
+
+func (r *TransactionRepository) InsertAdjustmentTx(tx *sql.Tx, accountID int64, amount float64, code models.ReasonCode, reason string) (string, error) {
+	if err := r.fault(); err != nil {
+		return "", err
+	}
+	return r.next.InsertAdjustmentTx(tx, accountID, amount, code, reason)
+}
@@ -2,29 +2,210 @@ package db
 
 import (
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
+
+	"github.com/nehciyy/intrapay/internal/models"
 )
 
-func InitDB() (*sql.DB, error) {
+// ConnectorWrapper decorates the Postgres driver connector, e.g. for fault injection.
+type ConnectorWrapper func(driver.Connector) driver.Connector
+
+func InitDB(wrappers ...ConnectorWrapper) (*sql.DB, error) {
 	dataSource := os.Getenv("DATABASE_URL")
-    if dataSource == "" {
-        return nil, fmt.Errorf("DATABASE_URL is not set")
-    }
+	if dataSource == "" {
+		return nil, fmt.Errorf("DATABASE_URL is not set")
+	}
+
+	db, err := open(dataSource, "DATABASE_URL", wrappers)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Println("Connected to PostgreSQL successfully")
+	return db, nil
+}
+
+// InitReplica opens the read replica at DATABASE_REPLICA_URL, with the same pool
+// settings as InitDB. Without DATABASE_REPLICA_URL it returns nil: reads stay
+// on the primary.
+func InitReplica(wrappers ...ConnectorWrapper) (*sql.DB, error) {
+	dataSource := os.Getenv("DATABASE_REPLICA_URL")
+	if dataSource == "" {
+		return nil, nil
+	}
+
+	db, err := open(dataSource, "DATABASE_REPLICA_URL", wrappers)
+	if err != nil {
+		return nil, fmt.Errorf("replica: %w", err)
+	}
+
+	fmt.Println("Connected to PostgreSQL read replica successfully")
+	return db, nil
+}
+
+// InitReader opens DATABASE_READ_URL, a connection to the primary as a role
+// that may only read, with the same pool settings as InitDB. Its sessions are
+// read-only too, so a write fails even if the role was granted more. Without
+// DATABASE_READ_URL it returns nil: reads use the DATABASE_URL role.
+func InitReader(wrappers ...ConnectorWrapper) (*sql.DB, error) {
+	dataSource := os.Getenv("DATABASE_READ_URL")
+	if dataSource == "" {
+		return nil, nil
+	}
+
+	db, err := open(dataSource, "DATABASE_READ_URL", wrappers, readOnly)
+	if err != nil {
+		return nil, fmt.Errorf("reader: %w", err)
+	}
+
+	fmt.Println("Connected to PostgreSQL as the read-only role successfully")
+	return db, nil
+}
+
+// open connects to dataSource, read from the environment variable key, with
+// the pool settings of PoolConfigFromEnv. Each of settings is applied to the
+// data source after the time zone and region.
+func open(dataSource, key string, wrappers []ConnectorWrapper, settings ...func(string) string) (*sql.DB, error) {
+	pool, err := PoolConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
 
-    db, err := sql.Open("postgres", dataSource)
-    if err != nil {
-        return nil, fmt.Errorf("failed to open DB: %w", err)
-    }
+	if dataSource, err = inUTC(dataSource); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	if region := os.Getenv("REGION"); region != "" {
+		if dataSource, err = inRegion(dataSource, region); err != nil {
+			return nil, err
+		}
+	}
+	for _, set := range settings {
+		dataSource = set(dataSource)
+	}
 
-    if err := db.Ping(); err != nil {
-        return nil, fmt.Errorf("failed to connect to DB: %w", err)
-    }
+	var connector driver.Connector
+	connector, err = pq.NewConnector(dataSource)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open DB: %w", err)
+	}
+	for _, wrap := range wrappers {
+		connector = wrap(connector)
+	}
 
-    fmt.Println("Connected to PostgreSQL successfully")
+	db := sql.OpenDB(connector)
+	pool.Apply(db)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to DB: %w", err)
+	}
 	return db, nil
 }
 
+// inUTC sets the session time zone of the data source to UTC, so timestamptz
+// values are read back, and returned by the API, in UTC whatever the server's
+// default. A time zone in dataSource is overridden.
+func inUTC(dataSource string) (string, error) {
+	if strings.HasPrefix(dataSource, "postgres://") || strings.HasPrefix(dataSource, "postgresql://") {
+		var err error
+		if dataSource, err = pq.ParseURL(dataSource); err != nil {
+			return "", err
+		}
+	}
+	return strings.TrimSpace(dataSource + " timezone=UTC"), nil
+}
+
+// inRegion names region in the intrapay.region setting of every session, so
+// the region fence rejects its writes while region is passive. dataSource must
+// be in key=value form, as inUTC returns it.
+func inRegion(dataSource, region string) (string, error) {
+	if !models.ValidRegion(region) {
+		return "", fmt.Errorf("invalid REGION %q: must be lowercase letters, digits and hyphens", region)
+	}
+	return dataSource + " intrapay.region=" + region, nil
+}
+
+// readOnly makes every transaction of the sessions of dataSource read-only.
+// dataSource must be in key=value form, as inUTC returns it.
+func readOnly(dataSource string) string {
+	return dataSource + " default_transaction_read_only=on"
+}
+
+// PoolConfig holds connection pool limits. Zero values keep the database/sql defaults.
+type PoolConfig struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+}
+
+// PoolConfigFromEnv reads DB_MAX_OPEN_CONNS, DB_MAX_IDLE_CONNS, DB_CONN_MAX_LIFETIME
+// and DB_CONN_MAX_IDLE_TIME.
+func PoolConfigFromEnv() (PoolConfig, error) {
+	var (
+		cfg PoolConfig
+		err error
+	)
+	if cfg.MaxOpenConns, err = intEnv("DB_MAX_OPEN_CONNS"); err != nil {
+		return cfg, err
+	}
+	if cfg.MaxIdleConns, err = intEnv("DB_MAX_IDLE_CONNS"); err != nil {
+		return cfg, err
+	}
+	if cfg.ConnMaxLifetime, err = durationEnv("DB_CONN_MAX_LIFETIME"); err != nil {
+		return cfg, err
+	}
+	if cfg.ConnMaxIdleTime, err = durationEnv("DB_CONN_MAX_IDLE_TIME"); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+// Apply sets the configured limits on db.
+func (c PoolConfig) Apply(db *sql.DB) {
+	if c.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(c.MaxOpenConns)
+	}
+	if c.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(c.MaxIdleConns)
+	}
+	if c.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(c.ConnMaxLifetime)
+	}
+	if c.ConnMaxIdleTime > 0 {
+		db.SetConnMaxIdleTime(c.ConnMaxIdleTime)
+	}
+}
+
+func intEnv(key string) (int, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid %s %q: must be a non-negative integer", key, v)
+	}
+	return n, nil
+}
+
+func durationEnv(key string) (time.Duration, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid %s %q: must be a non-negative duration", key, v)
+	}
+	return d, nil
+}
+
 var ErrMissingDSN = sql.ErrConnDone
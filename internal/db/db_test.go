@@ -3,6 +3,7 @@ package db_test
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/nehciyy/intrapay/internal/db"
 )
@@ -22,4 +23,24 @@ func TestInitDB(t *testing.T) {
 	if err := dbConn.Ping(); err != nil {
 		t.Fatalf("DB ping failed: %v", err)
 	}
-}
\ No newline at end of file
+}
+func TestPoolConfigFromEnv(t *testing.T) {
+	t.Setenv("DB_MAX_OPEN_CONNS", "25")
+	t.Setenv("DB_MAX_IDLE_CONNS", "5")
+	t.Setenv("DB_CONN_MAX_LIFETIME", "30m")
+	t.Setenv("DB_CONN_MAX_IDLE_TIME", "")
+
+	cfg, err := db.PoolConfigFromEnv()
+	if err != nil {
+		t.Fatalf("PoolConfigFromEnv failed: %v", err)
+	}
+	expected := db.PoolConfig{MaxOpenConns: 25, MaxIdleConns: 5, ConnMaxLifetime: 30 * time.Minute}
+	if cfg != expected {
+		t.Errorf("expected %+v, got %+v", expected, cfg)
+	}
+
+	t.Setenv("DB_MAX_OPEN_CONNS", "lots")
+	if _, err := db.PoolConfigFromEnv(); err == nil {
+		t.Error("expected error for invalid DB_MAX_OPEN_CONNS")
+	}
+}
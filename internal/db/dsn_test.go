@@ -0,0 +1,50 @@
+package db
+
+import "testing"
+
+func TestInUTC(t *testing.T) {
+	tests := []struct {
+		dataSource string
+		expected   string
+	}{
+		{"host=db user=intrapay sslmode=disable", "host=db user=intrapay sslmode=disable timezone=UTC"},
+		{"host=db timezone=Europe/Berlin", "host=db timezone=Europe/Berlin timezone=UTC"},
+		{"postgres://intrapay@db:5432/intrapay?sslmode=disable", "dbname='intrapay' host='db' port='5432' sslmode='disable' user='intrapay' timezone=UTC"},
+	}
+
+	for _, tt := range tests {
+		got, err := inUTC(tt.dataSource)
+		if err != nil {
+			t.Fatalf("inUTC(%q): %v", tt.dataSource, err)
+		}
+		if got != tt.expected {
+			t.Errorf("inUTC(%q) = %q, expected %q", tt.dataSource, got, tt.expected)
+		}
+	}
+
+	if _, err := inUTC("postgres://db:notaport/intrapay"); err == nil {
+		t.Error("expected an error for an invalid URL")
+	}
+}
+
+func TestInRegion(t *testing.T) {
+	got, err := inRegion("host=db timezone=UTC", "eu-west-1")
+	if err != nil {
+		t.Fatalf("inRegion: %v", err)
+	}
+	if expected := "host=db timezone=UTC intrapay.region=eu-west-1"; got != expected {
+		t.Errorf("inRegion = %q, expected %q", got, expected)
+	}
+
+	for _, region := range []string{"EU", "eu west", "eu-west-1 sslmode=disable", "-eu"} {
+		if _, err := inRegion("host=db", region); err == nil {
+			t.Errorf("expected an error for region %q", region)
+		}
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	if got, expected := readOnly("host=db timezone=UTC"), "host=db timezone=UTC default_transaction_read_only=on"; got != expected {
+		t.Errorf("readOnly = %q, expected %q", got, expected)
+	}
+}
@@ -0,0 +1,132 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Hint tells the repositories how an operation may be run. Handlers and the
+// service attach hints to the context of a request with WithHints; the
+// repositories route, retry and time out statements by them.
+type Hint uint8
+
+const (
+	// ReadOnly operations write nothing and may read from a replica, seeing
+	// data a little behind the primary.
+	ReadOnly Hint = 1 << iota
+	// Idempotent operations may be retried after a transient error such as a
+	// dropped connection or a serialization failure.
+	Idempotent
+	// Critical operations, such as transfers, run under the critical statement
+	// timeout instead of the read-only one.
+	Critical
+)
+
+var hintNames = []string{"read_only", "idempotent", "critical"}
+
+// String lists the hints in h, e.g. "read_only|idempotent", or "none".
+func (h Hint) String() string {
+	var names []string
+	for i, name := range hintNames {
+		if h&(1<<i) != 0 {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, "|")
+}
+
+// Has reports whether h includes every hint in o.
+func (h Hint) Has(o Hint) bool {
+	return h&o == o
+}
+
+type hintsKey struct{}
+
+// WithHints returns ctx with h added to the hints it already carries.
+func WithHints(ctx context.Context, h Hint) context.Context {
+	return context.WithValue(ctx, hintsKey{}, HintsFrom(ctx)|h)
+}
+
+// HintsFrom returns the hints attached to ctx; none without WithHints.
+func HintsFrom(ctx context.Context) Hint {
+	h, _ := ctx.Value(hintsKey{}).(Hint)
+	return h
+}
+
+// Policy turns hints into statement timeouts and retries. The zero Policy sets
+// no timeouts and retries nothing.
+type Policy struct {
+	// ReadOnlyTimeout bounds statements of read-only operations; zero leaves
+	// them to the server's statement_timeout.
+	ReadOnlyTimeout time.Duration
+	// CriticalTimeout bounds statements of critical operations; zero leaves
+	// them to the server's statement_timeout.
+	CriticalTimeout time.Duration
+	// Retries is how many times an idempotent operation is retried after a
+	// transient error.
+	Retries int
+}
+
+// PolicyFromEnv reads DB_READ_ONLY_STATEMENT_TIMEOUT, DB_CRITICAL_STATEMENT_TIMEOUT
+// and DB_IDEMPOTENT_RETRIES.
+func PolicyFromEnv() (Policy, error) {
+	var (
+		p   Policy
+		err error
+	)
+	if p.ReadOnlyTimeout, err = durationEnv("DB_READ_ONLY_STATEMENT_TIMEOUT"); err != nil {
+		return p, err
+	}
+	if p.CriticalTimeout, err = durationEnv("DB_CRITICAL_STATEMENT_TIMEOUT"); err != nil {
+		return p, err
+	}
+	if p.Retries, err = intEnv("DB_IDEMPOTENT_RETRIES"); err != nil {
+		return p, err
+	}
+	return p, nil
+}
+
+// Timeout returns the statement timeout for an operation with hints h, or
+// zero for none. Critical wins over read-only.
+func (p Policy) Timeout(h Hint) time.Duration {
+	switch {
+	case h.Has(Critical):
+		return p.CriticalTimeout
+	case h.Has(ReadOnly):
+		return p.ReadOnlyTimeout
+	}
+	return 0
+}
+
+// Attempts returns how many times an operation with hints h may be tried.
+func (p Policy) Attempts(h Hint) int {
+	if h.Has(Idempotent) {
+		return 1 + p.Retries
+	}
+	return 1
+}
+
+// setStatementTimeout sets statement_timeout for the rest of a transaction.
+const setStatementTimeout = `SELECT set_config('statement_timeout', $1, true)`
+
+// BeginTx starts a transaction on db and, if the hints of ctx call for a
+// statement timeout, sets it for the statements of the transaction.
+func (p Policy) BeginTx(ctx context.Context, db *sql.DB, opts *sql.TxOptions) (*sql.Tx, error) {
+	tx, err := db.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	if d := p.Timeout(HintsFrom(ctx)); d > 0 {
+		if _, err := tx.ExecContext(ctx, setStatementTimeout, strconv.FormatInt(d.Milliseconds(), 10)); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+	return tx, nil
+}
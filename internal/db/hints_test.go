@@ -0,0 +1,101 @@
+package db_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"github.com/nehciyy/intrapay/internal/db"
+)
+
+func TestWithHints(t *testing.T) {
+	ctx := context.Background()
+	if h := db.HintsFrom(ctx); h != 0 || h.String() != "none" {
+		t.Errorf("expected no hints, got %s", h)
+	}
+
+	ctx = db.WithHints(ctx, db.ReadOnly)
+	ctx = db.WithHints(ctx, db.Idempotent)
+	h := db.HintsFrom(ctx)
+	if !h.Has(db.ReadOnly|db.Idempotent) || h.Has(db.Critical) {
+		t.Errorf("expected read_only|idempotent, got %s", h)
+	}
+	if h.String() != "read_only|idempotent" {
+		t.Errorf("unexpected String: %q", h.String())
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	p := db.Policy{ReadOnlyTimeout: time.Second, CriticalTimeout: 5 * time.Second, Retries: 2}
+	tests := []struct {
+		hints    db.Hint
+		timeout  time.Duration
+		attempts int
+	}{
+		{0, 0, 1},
+		{db.ReadOnly, time.Second, 1},
+		{db.ReadOnly | db.Idempotent, time.Second, 3},
+		{db.ReadOnly | db.Critical, 5 * time.Second, 1},
+	}
+	for _, tt := range tests {
+		if got := p.Timeout(tt.hints); got != tt.timeout {
+			t.Errorf("%s: expected timeout %s, got %s", tt.hints, tt.timeout, got)
+		}
+		if got := p.Attempts(tt.hints); got != tt.attempts {
+			t.Errorf("%s: expected %d attempts, got %d", tt.hints, tt.attempts, got)
+		}
+	}
+}
+
+func TestPolicyFromEnv(t *testing.T) {
+	t.Setenv("DB_READ_ONLY_STATEMENT_TIMEOUT", "2s")
+	t.Setenv("DB_CRITICAL_STATEMENT_TIMEOUT", "")
+	t.Setenv("DB_IDEMPOTENT_RETRIES", "1")
+
+	p, err := db.PolicyFromEnv()
+	if err != nil {
+		t.Fatalf("PolicyFromEnv failed: %v", err)
+	}
+	expected := db.Policy{ReadOnlyTimeout: 2 * time.Second, Retries: 1}
+	if p != expected {
+		t.Errorf("expected %+v, got %+v", expected, p)
+	}
+
+	t.Setenv("DB_IDEMPOTENT_RETRIES", "-1")
+	if _, err := db.PolicyFromEnv(); err == nil {
+		t.Error("expected error for negative DB_IDEMPOTENT_RETRIES")
+	}
+}
+
+func TestPolicy_BeginTx(t *testing.T) {
+	conn, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	p := db.Policy{CriticalTimeout: 1500 * time.Millisecond}
+
+	// No timeout for the hints: the transaction is left alone.
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	tx, err := p.BeginTx(db.WithHints(context.Background(), db.ReadOnly), conn, nil)
+	if err != nil {
+		t.Fatalf("BeginTx failed: %v", err)
+	}
+	tx.Rollback()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`set_config\('statement_timeout', \$1, true\)`).WithArgs("1500").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+	tx, err = p.BeginTx(db.WithHints(context.Background(), db.Critical), conn, nil)
+	if err != nil {
+		t.Fatalf("BeginTx failed: %v", err)
+	}
+	tx.Rollback()
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
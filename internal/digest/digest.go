@@ -0,0 +1,55 @@
+// Package digest sends the daily digests of the accounts whose notifications
+// are summed up once a day. Once a day has closed, in the time zone of an
+// account, the job sends the digest of that day; days missed while it was not
+// running are caught up on, and an account sent its digest of a day already is
+// not sent another, so the job can run every interval, and on every server.
+package digest
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Sender sends the digests not sent yet of the days closed by now, and returns
+// how many it sent.
+type Sender interface {
+	SendDailyDigests() (int, error)
+}
+
+// Job sends the digests of the closed days.
+type Job struct {
+	sender Sender
+	logger *log.Logger
+}
+
+// New creates a Job.
+func New(sender Sender, logger *log.Logger) *Job {
+	return &Job{sender: sender, logger: logger}
+}
+
+// SendClosedDays sends the digests of the closed days that were not sent yet.
+func (j *Job) SendClosedDays() error {
+	n, err := j.sender.SendDailyDigests()
+	if n > 0 {
+		j.logger.Printf("digest: sent %d daily digests", n)
+	}
+	return err
+}
+
+// Run sends the digests of the closed days every interval until ctx is
+// cancelled.
+func (j *Job) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := j.SendClosedDays(); err != nil {
+			j.logger.Printf("digest: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
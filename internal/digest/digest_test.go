@@ -0,0 +1,37 @@
+package digest
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeSender counts the runs it is asked to send digests in, sends n of them
+// and fails with err.
+type fakeSender struct {
+	runs int
+	n    int
+	err  error
+}
+
+func (s *fakeSender) SendDailyDigests() (int, error) {
+	s.runs++
+	return s.n, s.err
+}
+
+func TestSendClosedDays(t *testing.T) {
+	var logs bytes.Buffer
+	sender := &fakeSender{n: 3}
+	job := New(sender, log.New(&logs, "", 0))
+
+	assert.NoError(t, job.SendClosedDays())
+	assert.Equal(t, 1, sender.runs)
+	assert.Contains(t, logs.String(), "sent 3 daily digests")
+
+	sender.n, sender.err = 1, errors.New("connection refused")
+	assert.Error(t, job.SendClosedDays(), "the digests left are sent on the next run")
+	assert.Contains(t, logs.String(), "sent 1 daily digests")
+}
@@ -0,0 +1,149 @@
+// Package eventschema is the registry of the JSON schemas event payloads conform
+// to. Each event type has numbered versions, stored as schemas/<type>/<version>.json;
+// events carry the version they were written with, and the relay validates each
+// against its schema before publishing it.
+//
+// A published version never changes. Changes consumers of the current version
+// could not read go into a new version, and writers move to it by bumping the
+// version constant in models.
+package eventschema
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+
+	"github.com/nehciyy/intrapay/internal/models"
+)
+
+// ErrUnknownSchema is returned for an event type or version with no schema.
+var ErrUnknownSchema = errors.New("unknown event schema")
+
+//go:embed schemas
+var files embed.FS
+
+type schema struct {
+	info     models.EventSchema
+	raw      json.RawMessage
+	compiled *jsonschema.Schema
+}
+
+// registry holds the schemas by type and version.
+var registry = mustLoad()
+
+func mustLoad() map[string]map[int]*schema {
+	r, err := load(files)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+func load(fsys fs.FS) (map[string]map[int]*schema, error) {
+	paths, err := fs.Glob(fsys, "schemas/*/*.json")
+	if err != nil {
+		return nil, err
+	}
+	r := make(map[string]map[int]*schema)
+	for _, p := range paths {
+		eventType := path.Base(path.Dir(p))
+		version, err := strconv.Atoi(strings.TrimSuffix(path.Base(p), ".json"))
+		if err != nil || version < 1 {
+			return nil, fmt.Errorf("eventschema: %s: versions are numbered from 1", p)
+		}
+		raw, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return nil, err
+		}
+		url := SchemaURL(eventType, version)
+		c := jsonschema.NewCompiler()
+		if err := c.AddResource(url, bytes.NewReader(raw)); err != nil {
+			return nil, fmt.Errorf("eventschema: %s: %w", p, err)
+		}
+		compiled, err := c.Compile(url)
+		if err != nil {
+			return nil, fmt.Errorf("eventschema: %s: %w", p, err)
+		}
+		if r[eventType] == nil {
+			r[eventType] = make(map[int]*schema)
+		}
+		r[eventType][version] = &schema{
+			info:     models.EventSchema{Type: eventType, Version: version, URL: url},
+			raw:      raw,
+			compiled: compiled,
+		}
+	}
+	for _, versions := range r {
+		versions[latest(versions)].info.Latest = true
+	}
+	return r, nil
+}
+
+func latest(versions map[int]*schema) int {
+	n := 0
+	for v := range versions {
+		n = max(n, v)
+	}
+	return n
+}
+
+// SchemaURL is the path the schema of version of eventType is served at.
+func SchemaURL(eventType string, version int) string {
+	return fmt.Sprintf("/events/schemas/%s/%d", eventType, version)
+}
+
+// List returns every registered schema, by type and then version.
+func List() []models.EventSchema {
+	var list []models.EventSchema
+	for _, versions := range registry {
+		for _, s := range versions {
+			list = append(list, s.info)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Type != list[j].Type {
+			return list[i].Type < list[j].Type
+		}
+		return list[i].Version < list[j].Version
+	})
+	return list
+}
+
+// Get returns the JSON schema of version of eventType.
+func Get(eventType string, version int) (json.RawMessage, error) {
+	s, ok := registry[eventType][version]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s version %d", ErrUnknownSchema, eventType, version)
+	}
+	return s.raw, nil
+}
+
+// Latest returns the newest schema version of eventType, or 0 if it has none.
+func Latest(eventType string) int {
+	return latest(registry[eventType])
+}
+
+// Validate checks the payload of e against the schema of its type and version.
+func Validate(e models.Event) error {
+	s, ok := registry[e.Type][e.SchemaVersion]
+	if !ok {
+		return fmt.Errorf("%w: %s version %d", ErrUnknownSchema, e.Type, e.SchemaVersion)
+	}
+	var payload any
+	if err := json.Unmarshal(e.Payload, &payload); err != nil {
+		return fmt.Errorf("%s payload: %w", e.Type, err)
+	}
+	if err := s.compiled.Validate(payload); err != nil {
+		return fmt.Errorf("%s payload does not match schema version %d: %w", e.Type, e.SchemaVersion, err)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package eventschema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nehciyy/intrapay/internal/models"
+)
+
+func TestLatest(t *testing.T) {
+	// Writers must use a registered version, and should use the newest one.
+	assert.Equal(t, models.TransferCompletedVersion, Latest(models.EventTransferCompleted))
+	assert.Zero(t, Latest("transfer.unknown"))
+}
+
+func TestList(t *testing.T) {
+	list := List()
+	require.NotEmpty(t, list)
+	assert.Contains(t, list, models.EventSchema{
+		Type: models.EventTransferCompleted, Version: 1, Latest: true, URL: "/events/schemas/transfer.completed/1",
+	})
+	for _, s := range list {
+		raw, err := Get(s.Type, s.Version)
+		require.NoError(t, err)
+		assert.True(t, json.Valid(raw), s.URL)
+	}
+
+	_, err := Get(models.EventTransferCompleted, 99)
+	assert.ErrorIs(t, err, ErrUnknownSchema)
+}
+
+func TestValidate(t *testing.T) {
+	payload, err := json.Marshal(models.TransferCompleted{TransactionID: "7", SourceAccountID: 1, DestinationAccountID: 2, Amount: 50})
+	require.NoError(t, err)
+	event := models.Event{Type: models.EventTransferCompleted, SchemaVersion: models.TransferCompletedVersion, Payload: payload}
+	assert.NoError(t, Validate(event), "the payload the service writes matches its schema")
+
+	topUp, err := json.Marshal(models.TransferCompleted{TransactionID: "8", SourceAccountID: 2, DestinationAccountID: 1, Amount: 100, Kind: models.TransactionTopUp})
+	require.NoError(t, err)
+	assert.NoError(t, Validate(models.Event{Type: models.EventTransferCompleted, SchemaVersion: models.TransferCompletedVersion, Payload: topUp}))
+	cashback, err := json.Marshal(models.TransferCompleted{TransactionID: "9", SourceAccountID: 9, DestinationAccountID: 1, Amount: 2.25, Kind: models.TransactionCashback})
+	require.NoError(t, err)
+	assert.NoError(t, Validate(models.Event{Type: models.EventTransferCompleted, SchemaVersion: models.TransferCompletedVersion, Payload: cashback}))
+
+	reimbursement, err := json.Marshal(models.ReimbursementStatusChanged{ReimbursementID: 7, AccountID: 42, Amount: 89.5, Status: models.ReimbursementApproved, TransactionID: "9"})
+	require.NoError(t, err)
+	assert.NoError(t, Validate(models.Event{Type: models.EventReimbursementStatusChanged, SchemaVersion: models.ReimbursementStatusChangedVersion, Payload: reimbursement}))
+
+	digest, err := json.Marshal(models.AccountDailyDigest{AccountID: 42, Date: "2026-03-14", Transactions: 3, Incoming: 20, IncomingCount: 1, Outgoing: 45.5, OutgoingCount: 2, Net: -25.5})
+	require.NoError(t, err)
+	assert.NoError(t, Validate(models.Event{Type: models.EventAccountDailyDigest, SchemaVersion: models.AccountDailyDigestVersion, Payload: digest}))
+
+	for name, e := range map[string]models.Event{
+		"missing field":   {Type: models.EventTransferCompleted, SchemaVersion: 1, Payload: json.RawMessage(`{"transaction_id":"7","source_account_id":1,"amount":"50.00"}`)},
+		"wrong type":      {Type: models.EventTransferCompleted, SchemaVersion: 1, Payload: json.RawMessage(`{"transaction_id":"7","source_account_id":1,"destination_account_id":2,"amount":50}`)},
+		"unknown kind":    {Type: models.EventTransferCompleted, SchemaVersion: 1, Payload: json.RawMessage(`{"transaction_id":"7","source_account_id":1,"destination_account_id":2,"amount":"50.00","kind":"refund"}`)},
+		"not JSON":        {Type: models.EventTransferCompleted, SchemaVersion: 1, Payload: json.RawMessage(`{`)},
+		"unknown version": {Type: models.EventTransferCompleted, SchemaVersion: 2, Payload: payload},
+		"unknown type":    {Type: "transfer.unknown", SchemaVersion: 1, Payload: payload},
+	} {
+		assert.Error(t, Validate(e), name)
+	}
+}
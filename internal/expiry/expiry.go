@@ -0,0 +1,86 @@
+// Package expiry runs the TTL sweeper that expires pending entities nobody acted
+// on in time. Each kind of entity plugs in a Policy; the sweeper only computes
+// cutoffs, so the state transition and any release of reserved funds stay with
+// the code that owns the entity.
+package expiry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/clock"
+	"github.com/nehciyy/intrapay/internal/metrics"
+)
+
+// ExpireFunc expires the entities that have been pending since before cutoff and
+// returns how many it expired.
+type ExpireFunc func(cutoff time.Time) (int64, error)
+
+// Policy expires one kind of pending entity once it has been pending for TTL.
+type Policy struct {
+	// Name identifies the policy in logs and in the expired_total metric.
+	Name   string
+	TTL    time.Duration
+	Expire ExpireFunc
+}
+
+// Sweeper applies its policies on every sweep.
+type Sweeper struct {
+	policies []Policy
+	clock    clock.Clock
+	logger   *log.Logger
+}
+
+// New creates a Sweeper. Policies with a non-positive TTL never expire anything
+// and are left out.
+func New(clk clock.Clock, logger *log.Logger, policies ...Policy) *Sweeper {
+	s := &Sweeper{clock: clk, logger: logger}
+	for _, p := range policies {
+		if p.TTL > 0 {
+			s.policies = append(s.policies, p)
+		}
+	}
+	return s
+}
+
+// Sweep applies every policy once. A failing policy does not stop the others;
+// their errors are joined.
+func (s *Sweeper) Sweep() error {
+	now := s.clock.Now()
+	var errs []error
+	for _, p := range s.policies {
+		n, err := p.Expire(now.Add(-p.TTL))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("expire %s: %w", p.Name, err))
+			continue
+		}
+		if n > 0 {
+			metrics.Expired.WithLabelValues(p.Name).Add(float64(n))
+			s.logger.Printf("expiry: expired %d %s older than %s", n, p.Name, p.TTL)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Run sweeps every interval until ctx is cancelled. It returns at once when
+// there are no policies.
+func (s *Sweeper) Run(ctx context.Context, interval time.Duration) {
+	if len(s.policies) == 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Sweep(); err != nil {
+				s.logger.Printf("expiry: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package expiry
+
+import (
+	"errors"
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nehciyy/intrapay/internal/clock"
+)
+
+var discard = log.New(io.Discard, "", 0)
+
+func TestSweeper_Sweep(t *testing.T) {
+	now := time.Date(2026, 3, 14, 12, 0, 0, 0, time.UTC)
+	var cutoffs []time.Time
+	expire := func(cutoff time.Time) (int64, error) {
+		cutoffs = append(cutoffs, cutoff)
+		return 1, nil
+	}
+	s := New(clock.NewFake(now), discard,
+		Policy{Name: "holds", TTL: time.Hour, Expire: expire},
+		Policy{Name: "never", TTL: 0, Expire: expire},
+		Policy{Name: "approvals", TTL: 72 * time.Hour, Expire: expire},
+	)
+
+	assert.NoError(t, s.Sweep())
+	assert.Equal(t, []time.Time{now.Add(-time.Hour), now.Add(-72 * time.Hour)}, cutoffs, "policies without a TTL are skipped")
+}
+
+func TestSweeper_Sweep_Error(t *testing.T) {
+	var swept []string
+	policy := func(name string, err error) Policy {
+		return Policy{Name: name, TTL: time.Hour, Expire: func(time.Time) (int64, error) {
+			swept = append(swept, name)
+			return 0, err
+		}}
+	}
+	s := New(clock.NewFake(time.Now()), discard,
+		policy("holds", errors.New("connection refused")),
+		policy("approvals", nil),
+	)
+
+	err := s.Sweep()
+	assert.ErrorContains(t, err, "expire holds: connection refused")
+	assert.Equal(t, []string{"holds", "approvals"}, swept, "a failing policy does not stop the others")
+}
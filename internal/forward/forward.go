@@ -0,0 +1,392 @@
+// Package forward is the store-and-forward queue: a durable local queue that
+// accepts transfers while the database is unreachable and replays them, in
+// the order they were accepted, once it is back.
+//
+// The queue is a log on local disk, synced on every write: a line for each
+// transfer accepted and one for each taken off the queue with an outcome. A
+// restarted server rebuilds the queue from the log and compacts it. The log is
+// emptied whenever the queue drains.
+//
+// A transfer is taken off the queue only once its outcome is recorded in the
+// database, and the outcome of a completed transfer is recorded in its own
+// database transaction, so a transfer is made at most once however often a
+// crash makes it replayed. The queue holds at most a maximum number of
+// transfers, and those that waited for longer than a maximum age are expired
+// rather than made.
+package forward
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/clock"
+	"github.com/nehciyy/intrapay/internal/idgen"
+	"github.com/nehciyy/intrapay/internal/metrics"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/sandbox"
+)
+
+// logName is the name of the log in the queue's directory.
+const logName = "forward.log"
+
+// ErrFull is returned when accepting a transfer into a queue that holds as
+// many as it may.
+var ErrFull = errors.New("forward queue is full")
+
+// Replayer makes the transfers taken off the queue and records their outcomes.
+// An error from either of the first two methods leaves the transfer on the
+// queue, to be tried again. FindForwardedTransfer returns the recorded outcome
+// of the transfer an API key requested with an Idempotency-Key.
+type Replayer interface {
+	ReplayForwardedTransfer(f models.ForwardedTransfer) (*models.ForwardedTransferStatus, error)
+	ExpireForwardedTransfer(f models.ForwardedTransfer) (*models.ForwardedTransferStatus, error)
+	FindForwardedTransfer(apiKey, idempotencyKey string) (*models.ForwardedTransferStatus, error)
+}
+
+// entry is a line of the log: Transfer accepted, or the transfer ID taken off
+// the queue.
+type entry struct {
+	Transfer *models.ForwardedTransfer `json:"transfer,omitempty"`
+	Done     string                    `json:"done,omitempty"`
+}
+
+// Queue is a store-and-forward queue. It is safe for concurrent use.
+type Queue struct {
+	replayer   Replayer
+	clock      clock.Clock
+	ids        *idgen.ULID
+	maxEntries int
+	maxAge     time.Duration
+	logger     *log.Logger
+
+	mu      sync.Mutex
+	file    *os.File
+	pending []models.ForwardedTransfer
+	// torn is set while the log may end in part of an entry that could not be
+	// cut off again.
+	torn bool
+
+	// replaying serializes Replay, so that transfers are replayed one at a
+	// time, in order.
+	replaying sync.Mutex
+}
+
+// Open opens the queue kept in dir, creating dir if need be, holding at most
+// maxEntries transfers and expiring those older than maxAge.
+func Open(dir string, replayer Replayer, clk clock.Clock, maxEntries int, maxAge time.Duration, logger *log.Logger) (*Queue, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create forward queue directory: %w", err)
+	}
+	q := &Queue{
+		replayer:   replayer,
+		clock:      clk,
+		ids:        idgen.NewULID(clk),
+		maxEntries: maxEntries,
+		maxAge:     maxAge,
+		logger:     logger,
+	}
+	path := filepath.Join(dir, logName)
+	if err := q.load(path); err != nil {
+		return nil, err
+	}
+	if err := compact(path, q.pending); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open forward queue: %w", err)
+	}
+	q.file = file
+	metrics.ForwardQueueDepth.Set(float64(len(q.pending)))
+	if len(q.pending) > 0 {
+		logger.Printf("forward queue: %d transfers to replay", len(q.pending))
+	}
+	return q, nil
+}
+
+// load rebuilds the pending transfers from the log at path. A line cut short
+// by a crash or a failed write while it was written is skipped: the transfer it
+// accepted was never acknowledged, and those written after it were.
+func (q *Queue) load(path string) error {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("open forward queue: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			q.logger.Printf("forward queue: ignoring a torn entry in the log: %v", err)
+			continue
+		}
+		switch {
+		case e.Transfer != nil:
+			q.pending = append(q.pending, *e.Transfer)
+		case e.Done != "":
+			q.remove(e.Done)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read forward queue: %w", err)
+	}
+	return nil
+}
+
+// compact replaces the log at path with one accepting pending alone.
+func compact(path string, pending []models.ForwardedTransfer) error {
+	tmp := path + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return fmt.Errorf("compact forward queue: %w", err)
+	}
+	w := bufio.NewWriter(file)
+	enc := json.NewEncoder(w)
+	for i := range pending {
+		if err := enc.Encode(entry{Transfer: &pending[i]}); err != nil {
+			file.Close()
+			return fmt.Errorf("compact forward queue: %w", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		file.Close()
+		return fmt.Errorf("compact forward queue: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("compact forward queue: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("compact forward queue: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("compact forward queue: %w", err)
+	}
+	return syncDir(filepath.Dir(path))
+}
+
+// syncDir makes the entries of dir, such as a file renamed into it, durable.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+// Enqueue accepts the transfer f, requested while the database was
+// unreachable, and returns its status. f is given its ID and the time it was
+// accepted; it is on disk by the time Enqueue returns. A transfer with the
+// idempotency key of one its API key already has waiting on the queue, or
+// with a recorded outcome, is a retry of it: it is not accepted again, and the
+// status of the first is returned. A retry accepted anyway, because the outcome
+// could not be read, takes the outcome of the first when replayed.
+func (q *Queue) Enqueue(f models.ForwardedTransfer) (*models.ForwardedTransferStatus, error) {
+	if f.IdempotencyKey != "" {
+		if status, ok := q.pendingRetry(f); ok {
+			return status, nil
+		}
+		// The database is read without holding the queue, as it may well be
+		// unreachable.
+		if status, err := q.replayer.FindForwardedTransfer(f.APIKey, f.IdempotencyKey); err == nil {
+			return status, nil
+		}
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.pending) >= q.maxEntries {
+		return nil, ErrFull
+	}
+	id, err := q.ids.NewID()
+	if err != nil {
+		return nil, err
+	}
+	f.ID, f.AcceptedAt = id, q.clock.Now().UTC()
+	if err := q.write(entry{Transfer: &f}); err != nil {
+		return nil, err
+	}
+	q.pending = append(q.pending, f)
+	metrics.ForwardQueueDepth.Set(float64(len(q.pending)))
+	return queued(f), nil
+}
+
+// pendingRetry returns the status of the transfer waiting on the queue that f
+// retries, if any: one with the idempotency key and API key of f.
+func (q *Queue) pendingRetry(f models.ForwardedTransfer) (*models.ForwardedTransferStatus, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, p := range q.pending {
+		if p.IdempotencyKey == f.IdempotencyKey && p.APIKey == f.APIKey {
+			return queued(p), true
+		}
+	}
+	return nil, false
+}
+
+// queued returns the status of f while it waits on the queue.
+func queued(f models.ForwardedTransfer) *models.ForwardedTransferStatus {
+	return &models.ForwardedTransferStatus{ID: f.ID, Status: models.ForwardQueued, AcceptedAt: f.AcceptedAt}
+}
+
+// Status returns the status of the transfer id if it is waiting on the queue.
+// In test mode, transfers accepted from live API keys are not found.
+func (q *Queue) Status(ctx context.Context, id string) (*models.ForwardedTransferStatus, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, f := range q.pending {
+		if f.ID == id && (sandbox.Livemode(ctx) || sandbox.IsTestKey(f.APIKey)) {
+			return queued(f), true
+		}
+	}
+	return nil, false
+}
+
+// Len returns how many transfers are waiting on the queue.
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending)
+}
+
+// Replay replays the transfers on the queue in order, expiring those that
+// waited for longer than the maximum age, and returns how many it took off the
+// queue. It stops at the first transfer that cannot be replayed yet, which
+// stays at the head of the queue.
+func (q *Queue) Replay() (int, error) {
+	q.replaying.Lock()
+	defer q.replaying.Unlock()
+
+	var n int
+	for {
+		q.mu.Lock()
+		if len(q.pending) == 0 {
+			q.mu.Unlock()
+			return n, nil
+		}
+		f := q.pending[0]
+		q.mu.Unlock()
+
+		replay := q.replayer.ReplayForwardedTransfer
+		if q.clock.Now().Sub(f.AcceptedAt) > q.maxAge {
+			replay = q.replayer.ExpireForwardedTransfer
+		}
+		status, err := replay(f)
+		if err != nil {
+			return n, fmt.Errorf("replay %s: %w", f.ID, err)
+		}
+		if err := q.ack(f.ID); err != nil {
+			return n, err
+		}
+		metrics.ForwardedTransfers.WithLabelValues(string(status.Status)).Inc()
+		n++
+	}
+}
+
+// ack takes the transfer id, which has an outcome, off the queue. If that
+// fails, the transfer is replayed again, and comes back with the outcome it
+// has.
+func (q *Queue) ack(id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if err := q.write(entry{Done: id}); err != nil {
+		return err
+	}
+	q.remove(id)
+	metrics.ForwardQueueDepth.Set(float64(len(q.pending)))
+	if len(q.pending) > 0 {
+		return nil
+	}
+	// Drained: the log has nothing left worth keeping.
+	if err := q.file.Truncate(0); err != nil {
+		return fmt.Errorf("truncate forward queue: %w", err)
+	}
+	return nil
+}
+
+// remove drops the transfer id from the pending transfers.
+func (q *Queue) remove(id string) {
+	for i, f := range q.pending {
+		if f.ID == id {
+			q.pending = append(q.pending[:i], q.pending[i+1:]...)
+			return
+		}
+	}
+}
+
+// write appends e to the log and syncs it. An entry that cannot be written
+// whole and synced is cut off again, so that it is not replayed and the entries
+// written after it are read back; if even that fails, the next entry starts on
+// a line of its own.
+func (q *Queue) write(e entry) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	info, err := q.file.Stat()
+	if err != nil {
+		return fmt.Errorf("write forward queue: %w", err)
+	}
+	if q.torn {
+		line = append([]byte{'\n'}, line...)
+	}
+	if _, err := q.file.Write(append(line, '\n')); err != nil {
+		return q.cut(info.Size(), fmt.Errorf("write forward queue: %w", err))
+	}
+	if err := q.file.Sync(); err != nil {
+		return q.cut(info.Size(), fmt.Errorf("sync forward queue: %w", err))
+	}
+	q.torn = false
+	return nil
+}
+
+// cut truncates the log back to size after an entry failed with err, and
+// returns err.
+func (q *Queue) cut(size int64, err error) error {
+	if tErr := q.file.Truncate(size); tErr != nil {
+		q.logger.Printf("forward queue: cannot cut off a failed entry: %v", tErr)
+		q.torn = true
+	}
+	return err
+}
+
+// Run replays the queue at once and then every interval until ctx is
+// cancelled.
+func (q *Queue) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		n, err := q.Replay()
+		if n > 0 {
+			q.logger.Printf("forward queue: replayed %d transfers, %d left", n, q.Len())
+		}
+		if err != nil {
+			q.logger.Printf("forward queue: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Close closes the log.
+func (q *Queue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.file.Close()
+}
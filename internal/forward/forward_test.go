@@ -0,0 +1,191 @@
+package forward
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nehciyy/intrapay/internal/clock"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/sandbox"
+)
+
+// fakeReplayer records the transfers it replays and expires, failing while err
+// is set. outcomes are the recorded outcomes by idempotency key.
+type fakeReplayer struct {
+	replayed []int64
+	expired  []int64
+	err      error
+	outcomes map[string]*models.ForwardedTransferStatus
+}
+
+func (r *fakeReplayer) FindForwardedTransfer(apiKey, idempotencyKey string) (*models.ForwardedTransferStatus, error) {
+	if status, ok := r.outcomes[idempotencyKey]; ok {
+		return status, nil
+	}
+	return nil, errors.New("not found")
+}
+
+func (r *fakeReplayer) ReplayForwardedTransfer(f models.ForwardedTransfer) (*models.ForwardedTransferStatus, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	r.replayed = append(r.replayed, f.Request.SourceAccountID)
+	return &models.ForwardedTransferStatus{ID: f.ID, Status: models.ForwardCompleted}, nil
+}
+
+func (r *fakeReplayer) ExpireForwardedTransfer(f models.ForwardedTransfer) (*models.ForwardedTransferStatus, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	r.expired = append(r.expired, f.Request.SourceAccountID)
+	return &models.ForwardedTransferStatus{ID: f.ID, Status: models.ForwardExpired}, nil
+}
+
+func transferFrom(sourceID int64) models.ForwardedTransfer {
+	return models.ForwardedTransfer{Request: models.TransactionRequest{SourceAccountID: sourceID, DestinationAccountID: 99, Amount: 10}}
+}
+
+func TestQueue(t *testing.T) {
+	dir := t.TempDir()
+	clk := clock.NewFake(time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC))
+	replayer := &fakeReplayer{err: errors.New("connection refused")}
+	logger := log.New(&bytes.Buffer{}, "", 0)
+
+	q, err := Open(dir, replayer, clk, 3, time.Hour, logger)
+	require.NoError(t, err)
+	var ids []string
+	for _, sourceID := range []int64{1, 2, 3} {
+		status, err := q.Enqueue(transferFrom(sourceID))
+		require.NoError(t, err)
+		assert.Equal(t, models.ForwardQueued, status.Status)
+		ids = append(ids, status.ID)
+	}
+	_, err = q.Enqueue(transferFrom(4))
+	assert.ErrorIs(t, err, ErrFull)
+
+	n, err := q.Replay()
+	assert.Error(t, err, "the database is still unreachable")
+	assert.Zero(t, n)
+	status, ok := q.Status(context.Background(), ids[0])
+	assert.True(t, ok, "a transfer that cannot be replayed yet stays queued")
+	assert.Equal(t, ids[0], status.ID)
+
+	// A restart rebuilds the queue from the log.
+	require.NoError(t, q.Close())
+	q, err = Open(dir, replayer, clk, 3, time.Hour, logger)
+	require.NoError(t, err)
+	assert.Equal(t, 3, q.Len())
+
+	replayer.err = nil
+	clk.Advance(2 * time.Hour)
+	n, err = q.Replay()
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.Equal(t, []int64{1, 2, 3}, replayer.expired, "transfers older than the maximum age are expired, in order")
+
+	status, err = q.Enqueue(transferFrom(4))
+	require.NoError(t, err, "the queue has room again")
+	n, err = q.Replay()
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []int64{4}, replayer.replayed)
+	_, ok = q.Status(context.Background(), status.ID)
+	assert.False(t, ok)
+
+	info, err := os.Stat(filepath.Join(dir, logName))
+	require.NoError(t, err)
+	assert.Zero(t, info.Size(), "a drained queue empties its log")
+	require.NoError(t, q.Close())
+}
+
+func TestQueueAcknowledged(t *testing.T) {
+	dir := t.TempDir()
+	clk := clock.NewFake(time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC))
+	replayer := &fakeReplayer{}
+	logger := log.New(&bytes.Buffer{}, "", 0)
+
+	q, err := Open(dir, replayer, clk, 10, time.Hour, logger)
+	require.NoError(t, err)
+	for _, sourceID := range []int64{1, 2} {
+		_, err := q.Enqueue(transferFrom(sourceID))
+		require.NoError(t, err)
+	}
+	// Take the first transfer off the queue without draining it.
+	require.NoError(t, q.ack(q.pending[0].ID))
+
+	// A failed write that could not be cut off leaves an entry torn, and the
+	// next is written on a line of its own.
+	_, err = q.file.WriteString(`{"transfer": {"id": "01J`)
+	require.NoError(t, err)
+	q.torn = true
+	_, err = q.Enqueue(transferFrom(3))
+	require.NoError(t, err)
+	require.NoError(t, q.Close())
+
+	// A crash while the last entry was written leaves it torn too.
+	file, err := os.OpenFile(filepath.Join(dir, logName), os.O_WRONLY|os.O_APPEND, 0o600)
+	require.NoError(t, err)
+	_, err = file.WriteString(`{"transfer": {"id": "01J`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	q, err = Open(dir, replayer, clk, 10, time.Hour, logger)
+	require.NoError(t, err)
+	n, err := q.Replay()
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []int64{2, 3}, replayer.replayed, "acknowledged and torn transfers are not replayed, those after them are")
+	require.NoError(t, q.Close())
+}
+
+func TestQueueIdempotencyKey(t *testing.T) {
+	dir := t.TempDir()
+	clk := clock.NewFake(time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC))
+	logger := log.New(&bytes.Buffer{}, "", 0)
+
+	replayer := &fakeReplayer{outcomes: map[string]*models.ForwardedTransferStatus{
+		"replayed-1": {ID: "01J9", Status: models.ForwardCompleted, TransactionID: "7"},
+	}}
+	q, err := Open(dir, replayer, clk, 2, time.Hour, logger)
+	require.NoError(t, err)
+	keyed := func(apiKey, idempotencyKey string) models.ForwardedTransfer {
+		f := transferFrom(1)
+		f.APIKey, f.IdempotencyKey = apiKey, idempotencyKey
+		return f
+	}
+	first, err := q.Enqueue(keyed("key_a", "retry-1"))
+	require.NoError(t, err)
+	_, err = q.Enqueue(keyed("key_b", "retry-1"))
+	require.NoError(t, err, "idempotency keys are the API key's own")
+	require.NoError(t, q.Close())
+
+	// The keys are kept in the log, and a retry is answered even when full.
+	q, err = Open(dir, replayer, clk, 2, time.Hour, logger)
+	require.NoError(t, err)
+	retried, err := q.Enqueue(keyed("key_a", "retry-1"))
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, retried.ID)
+	assert.Equal(t, 2, q.Len())
+	_, err = q.Enqueue(keyed("key_a", ""))
+	assert.ErrorIs(t, err, ErrFull)
+
+	// So is a retry of a transfer replayed since, here or by another server.
+	replayed, err := q.Enqueue(keyed("key_a", "replayed-1"))
+	require.NoError(t, err)
+	assert.Equal(t, models.ForwardCompleted, replayed.Status)
+	assert.Equal(t, 2, q.Len())
+
+	// Test mode does not see the transfers of live keys.
+	_, ok := q.Status(sandbox.WithTestMode(context.Background()), first.ID)
+	assert.False(t, ok)
+	require.NoError(t, q.Close())
+}
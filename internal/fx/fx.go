@@ -0,0 +1,149 @@
+// Package fx fetches live exchange rates from an external provider and caches
+// them. Rates are refetched once they are older than the cache TTL; when the
+// provider cannot be reached the cached rate is served on, and flagged stale
+// once the time it was published at is older than the staleness threshold, so
+// that callers can refuse it rather than convert at an old rate.
+package fx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/clock"
+	"github.com/nehciyy/intrapay/internal/metrics"
+	"github.com/nehciyy/intrapay/internal/models"
+)
+
+// maxResponseBody bounds how much of a provider response is read.
+const maxResponseBody = 64 << 10
+
+// ErrUnavailable is returned for a rate that cannot be fetched and is not cached.
+var ErrUnavailable = errors.New("exchange rate unavailable")
+
+// Provider fetches the latest rate of currency in base: one unit of currency is
+// worth rate units of base as of asOf.
+type Provider interface {
+	Rate(ctx context.Context, currency, base string) (rate float64, asOf time.Time, err error)
+}
+
+// HTTPProvider fetches rates from a Frankfurter-compatible API: GET
+// {URL}?from=USD&to=EUR is answered with
+// {"base": "USD", "date": "2026-06-12", "rates": {"EUR": 0.92}}. A "timestamp"
+// field, in Unix seconds, dates the rates in preference to "date".
+type HTTPProvider struct {
+	url  string
+	http *http.Client
+}
+
+// NewHTTPProvider creates an HTTPProvider for the API at rawURL whose requests
+// give up after timeout.
+func NewHTTPProvider(rawURL string, timeout time.Duration) (*HTTPProvider, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid rate provider URL %q", rawURL)
+	}
+	return &HTTPProvider{url: rawURL, http: &http.Client{Timeout: timeout}}, nil
+}
+
+func (p *HTTPProvider) Rate(ctx context.Context, currency, base string) (float64, time.Time, error) {
+	u, _ := url.Parse(p.url)
+	q := u.Query()
+	q.Set("from", currency)
+	q.Set("to", base)
+	u.RawQuery = q.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := p.http.Do(req)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, time.Time{}, fmt.Errorf("rate provider answered %s", resp.Status)
+	}
+
+	var body struct {
+		Date      string             `json:"date"`
+		Timestamp int64              `json:"timestamp"`
+		Rates     map[string]float64 `json:"rates"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseBody)).Decode(&body); err != nil {
+		return 0, time.Time{}, fmt.Errorf("decode rate provider response: %w", err)
+	}
+	rate, ok := body.Rates[base]
+	if !ok || rate <= 0 {
+		return 0, time.Time{}, fmt.Errorf("rate provider has no %s/%s rate", currency, base)
+	}
+	if body.Timestamp > 0 {
+		return rate, time.Unix(body.Timestamp, 0).UTC(), nil
+	}
+	asOf, err := time.Parse(time.DateOnly, body.Date)
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("rate provider response is undated: %q", body.Date)
+	}
+	return rate, asOf, nil
+}
+
+// Cache serves the rates of a Provider, fetching each currency pair at most once
+// per TTL.
+type Cache struct {
+	provider Provider
+	ttl      time.Duration
+	maxAge   time.Duration
+	clock    clock.Clock
+
+	mu      sync.Mutex
+	entries map[[2]string]*entry
+}
+
+type entry struct {
+	quote models.FXQuote
+	// checkedAt is when the provider was last asked, successfully or not.
+	checkedAt time.Time
+}
+
+// NewCache creates a Cache that refetches rates older than ttl and flags those
+// published more than maxAge ago as stale.
+func NewCache(p Provider, ttl, maxAge time.Duration, clk clock.Clock) *Cache {
+	return &Cache{provider: p, ttl: ttl, maxAge: maxAge, clock: clk, entries: map[[2]string]*entry{}}
+}
+
+// Quote returns the rate of currency in base. A rate fetched within the TTL is
+// served from the cache; otherwise it is fetched again, and if that fails the
+// cached rate is served on until the next TTL. A pair never fetched is
+// ErrUnavailable when the provider fails.
+func (c *Cache) Quote(ctx context.Context, currency, base string) (*models.FXQuote, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := [2]string{currency, base}
+	now := c.clock.Now()
+	e := c.entries[key]
+	if e == nil || now.Sub(e.checkedAt) >= c.ttl {
+		rate, asOf, err := c.provider.Rate(ctx, currency, base)
+		switch {
+		case err == nil:
+			metrics.FXRateFetches.WithLabelValues("fetched").Inc()
+			e = &entry{quote: models.FXQuote{Currency: currency, BaseCurrency: base, Rate: rate, AsOf: asOf, FetchedAt: now}}
+			c.entries[key] = e
+		case e == nil:
+			metrics.FXRateFetches.WithLabelValues("failed").Inc()
+			return nil, fmt.Errorf("%w: %s/%s: %v", ErrUnavailable, currency, base, err)
+		default:
+			metrics.FXRateFetches.WithLabelValues("failed").Inc()
+		}
+		e.checkedAt = now
+	}
+	quote := e.quote
+	quote.Stale = now.Sub(quote.AsOf) > c.maxAge
+	return &quote, nil
+}
@@ -0,0 +1,115 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nehciyy/intrapay/internal/clock"
+)
+
+func TestHTTPProvider(t *testing.T) {
+	var query string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		switch r.URL.Query().Get("to") {
+		case "EUR":
+			w.Write([]byte(`{"amount": 1.0, "base": "USD", "date": "2026-06-12", "rates": {"EUR": 0.92}}`))
+		case "GBP":
+			w.Write([]byte(`{"base": "USD", "timestamp": 1781269200, "rates": {"GBP": 0.79}}`))
+		case "JPY":
+			w.Write([]byte(`{"base": "USD", "date": "2026-06-12", "rates": {}}`))
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	p, err := NewHTTPProvider(srv.URL+"/latest?amount=1", time.Second)
+	require.NoError(t, err)
+
+	rate, asOf, err := p.Rate(context.Background(), "USD", "EUR")
+	require.NoError(t, err)
+	assert.Equal(t, 0.92, rate)
+	assert.Equal(t, time.Date(2026, 6, 12, 0, 0, 0, 0, time.UTC), asOf)
+	assert.Equal(t, "amount=1&from=USD&to=EUR", query, "the URL's own query is kept")
+
+	rate, asOf, err = p.Rate(context.Background(), "USD", "GBP")
+	require.NoError(t, err)
+	assert.Equal(t, 0.79, rate)
+	assert.Equal(t, time.Unix(1781269200, 0).UTC(), asOf, "a timestamp dates the rate in preference to the date")
+
+	_, _, err = p.Rate(context.Background(), "USD", "JPY")
+	assert.Error(t, err, "a response without the rate")
+	_, _, err = p.Rate(context.Background(), "USD", "CHF")
+	assert.Error(t, err)
+
+	_, err = NewHTTPProvider("rates.example.com", time.Second)
+	assert.Error(t, err)
+}
+
+// fakeProvider serves rate as of asOf, or fails with err, and counts requests.
+type fakeProvider struct {
+	rate  float64
+	asOf  time.Time
+	err   error
+	calls int
+}
+
+func (p *fakeProvider) Rate(ctx context.Context, currency, base string) (float64, time.Time, error) {
+	p.calls++
+	return p.rate, p.asOf, p.err
+}
+
+func TestCache(t *testing.T) {
+	now := time.Date(2026, 6, 15, 12, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(now)
+	p := &fakeProvider{err: errors.New("connection refused")}
+	c := NewCache(p, 5*time.Minute, 24*time.Hour, clk)
+
+	_, err := c.Quote(context.Background(), "USD", "EUR")
+	assert.ErrorIs(t, err, ErrUnavailable, "nothing cached to fall back on")
+
+	p.rate, p.asOf, p.err = 0.92, now.Add(-time.Hour), nil
+	q, err := c.Quote(context.Background(), "USD", "EUR")
+	require.NoError(t, err)
+	assert.Equal(t, 0.92, q.Rate)
+	assert.Equal(t, now, q.FetchedAt)
+	assert.False(t, q.Stale)
+	calls := p.calls
+
+	clk.Advance(time.Minute)
+	p.rate = 0.93
+	q, err = c.Quote(context.Background(), "USD", "EUR")
+	require.NoError(t, err)
+	assert.Equal(t, 0.92, q.Rate, "served from the cache within the TTL")
+	assert.Equal(t, calls, p.calls)
+
+	clk.Advance(5 * time.Minute)
+	q, err = c.Quote(context.Background(), "USD", "EUR")
+	require.NoError(t, err)
+	assert.Equal(t, 0.93, q.Rate, "refetched after the TTL")
+
+	// The provider goes down: the cached rate is served on, and flagged once it
+	// is older than the staleness threshold.
+	p.err = errors.New("connection refused")
+	clk.Advance(time.Hour)
+	q, err = c.Quote(context.Background(), "USD", "EUR")
+	require.NoError(t, err)
+	assert.Equal(t, 0.93, q.Rate)
+	assert.False(t, q.Stale)
+	clk.Advance(24 * time.Hour)
+	q, err = c.Quote(context.Background(), "USD", "EUR")
+	require.NoError(t, err)
+	assert.True(t, q.Stale)
+
+	calls = p.calls
+	_, err = c.Quote(context.Background(), "USD", "EUR")
+	require.NoError(t, err)
+	assert.Equal(t, calls, p.calls, "a failed fetch is not retried within the TTL")
+}
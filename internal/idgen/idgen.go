@@ -0,0 +1,166 @@
+// Package idgen provides the identifier strategies a deployment can choose for
+// transaction IDs and server-generated account IDs.
+package idgen
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/oklog/ulid/v2"
+
+	"github.com/nehciyy/intrapay/internal/clock"
+)
+
+// Generator creates identifiers.
+type Generator interface {
+	NewID() (string, error)
+}
+
+// Strategy names accepted by New.
+const (
+	StrategySequence  = "sequence"
+	StrategyUUIDv7    = "uuidv7"
+	StrategySnowflake = "snowflake"
+	StrategyULID      = "ulid"
+)
+
+// New returns the generator for strategy. next backs the sequence strategy and
+// node identifies this instance for snowflake IDs.
+func New(strategy string, next func() (int64, error), node int64) (Generator, error) {
+	switch strategy {
+	case StrategySequence, "":
+		return Sequence(next), nil
+	case StrategyUUIDv7:
+		return UUIDv7{}, nil
+	case StrategySnowflake:
+		return NewSnowflake(node, clock.System)
+	case StrategyULID:
+		return NewULID(clock.System), nil
+	default:
+		return nil, fmt.Errorf("unknown ID strategy %q: want %s, %s, %s or %s", strategy, StrategySequence, StrategyUUIDv7, StrategySnowflake, StrategyULID)
+	}
+}
+
+// Numeric reports whether g only produces decimal int64 IDs, as account IDs require.
+func Numeric(g Generator) bool {
+	switch g.(type) {
+	case Sequence, *Snowflake:
+		return true
+	default:
+		return false
+	}
+}
+
+// Sequence draws IDs from a database sequence. A nil Sequence produces empty IDs,
+// meaning the database assigns its own serial key.
+type Sequence func() (int64, error)
+
+func (s Sequence) NewID() (string, error) {
+	if s == nil {
+		return "", nil
+	}
+	id, err := s()
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(id, 10), nil
+}
+
+// UUIDv7 generates time-ordered UUIDs (RFC 9562), which index well and need no
+// coordination between instances.
+type UUIDv7 struct{}
+
+func (UUIDv7) NewID() (string, error) {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return "", err
+	}
+	return id.String(), nil
+}
+
+// ULID generates lexicographically sortable 26-character IDs. IDs issued within
+// the same millisecond increase monotonically.
+type ULID struct {
+	clock clock.Clock
+
+	mu      sync.Mutex
+	entropy *ulid.MonotonicEntropy
+}
+
+// NewULID creates a ULID generator timestamped by c.
+func NewULID(c clock.Clock) *ULID {
+	return &ULID{clock: c, entropy: ulid.Monotonic(rand.Reader, 0)}
+}
+
+func (g *ULID) NewID() (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	id, err := ulid.New(ulid.Timestamp(g.clock.Now()), g.entropy)
+	if err != nil {
+		return "", err
+	}
+	return id.String(), nil
+}
+
+const (
+	nodeBits     = 10
+	sequenceBits = 12
+	maxNode      = 1<<nodeBits - 1
+	maxSequence  = 1<<sequenceBits - 1
+)
+
+// snowflakeEpoch is the zero point of snowflake timestamps.
+var snowflakeEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// ErrClockMovedBackwards is returned when the clock falls behind the last issued ID.
+var ErrClockMovedBackwards = errors.New("idgen: clock moved backwards")
+
+// Snowflake generates 63-bit IDs from a millisecond timestamp, a node number and
+// a per-millisecond sequence, so instances with distinct nodes never collide.
+type Snowflake struct {
+	node  int64
+	clock clock.Clock
+
+	mu     sync.Mutex
+	lastMs int64
+	seq    int64
+}
+
+// NewSnowflake creates a Snowflake generator for node (0 to 1023).
+func NewSnowflake(node int64, c clock.Clock) (*Snowflake, error) {
+	if node < 0 || node > maxNode {
+		return nil, fmt.Errorf("snowflake node %d out of range 0-%d", node, maxNode)
+	}
+	return &Snowflake{node: node, clock: c}, nil
+}
+
+func (s *Snowflake) NewID() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ms := s.clock.Now().Sub(snowflakeEpoch).Milliseconds()
+	if ms < s.lastMs {
+		return "", ErrClockMovedBackwards
+	}
+	if ms == s.lastMs {
+		s.seq = (s.seq + 1) & maxSequence
+		if s.seq == 0 {
+			// Sequence exhausted for this millisecond: wait for the next one.
+			for ms <= s.lastMs {
+				s.clock.Sleep(time.Millisecond)
+				ms = s.clock.Now().Sub(snowflakeEpoch).Milliseconds()
+			}
+		}
+	} else {
+		s.seq = 0
+	}
+	s.lastMs = ms
+
+	id := ms<<(nodeBits+sequenceBits) | s.node<<sequenceBits | s.seq
+	return strconv.FormatInt(id, 10), nil
+}
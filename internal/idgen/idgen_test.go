@@ -0,0 +1,82 @@
+package idgen
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/oklog/ulid/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nehciyy/intrapay/internal/clock"
+)
+
+func TestSequence(t *testing.T) {
+	next := int64(41)
+	seq := Sequence(func() (int64, error) { next++; return next, nil })
+
+	id, err := seq.NewID()
+	require.NoError(t, err)
+	assert.Equal(t, "42", id)
+
+	id, err = Sequence(nil).NewID()
+	require.NoError(t, err)
+	assert.Empty(t, id)
+}
+
+func TestUUIDv7(t *testing.T) {
+	id, err := UUIDv7{}.NewID()
+	require.NoError(t, err)
+	parsed, err := uuid.Parse(id)
+	require.NoError(t, err)
+	assert.Equal(t, uuid.Version(7), parsed.Version())
+}
+
+func TestULID(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	gen := NewULID(clock.NewFake(now))
+
+	first, err := gen.NewID()
+	require.NoError(t, err)
+	second, err := gen.NewID()
+	require.NoError(t, err)
+
+	parsed, err := ulid.ParseStrict(first)
+	require.NoError(t, err)
+	assert.Equal(t, now, ulid.Time(parsed.Time()).UTC())
+	assert.Less(t, first, second, "same millisecond stays sortable")
+}
+
+func TestSnowflake(t *testing.T) {
+	clk := clock.NewFake(snowflakeEpoch.Add(time.Second))
+	gen, err := NewSnowflake(5, clk)
+	require.NoError(t, err)
+
+	first, err := gen.NewID()
+	require.NoError(t, err)
+	second, err := gen.NewID()
+	require.NoError(t, err)
+
+	a, _ := strconv.ParseInt(first, 10, 64)
+	b, _ := strconv.ParseInt(second, 10, 64)
+	assert.Equal(t, int64(1000)<<22|5<<12, a)
+	assert.Equal(t, a+1, b, "same millisecond bumps the sequence")
+
+	clk.Advance(-time.Second)
+	_, err = gen.NewID()
+	assert.ErrorIs(t, err, ErrClockMovedBackwards)
+
+	_, err = NewSnowflake(1024, clk)
+	assert.Error(t, err)
+}
+
+func TestNew(t *testing.T) {
+	for _, strategy := range []string{"", StrategySequence, StrategyUUIDv7, StrategySnowflake, StrategyULID} {
+		_, err := New(strategy, nil, 1)
+		assert.NoError(t, err, strategy)
+	}
+	_, err := New("random", nil, 1)
+	assert.Error(t, err)
+}
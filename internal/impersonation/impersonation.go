@@ -0,0 +1,32 @@
+// Package impersonation marks the requests support staff make "as" a customer.
+// An operator holding the admin token names the customer's API key in
+// KeyHeader and themselves in OperatorHeader; the request is then served as if
+// the customer's client had made it, except that it may only read. Every such
+// request is audited before it is served.
+package impersonation
+
+import "context"
+
+const (
+	// KeyHeader names the API key of the customer to impersonate. The tenant is
+	// taken from X-Tenant-ID as for any other request.
+	KeyHeader = "X-Impersonate-Key"
+	// OperatorHeader names the operator impersonating the customer.
+	OperatorHeader = "X-Impersonator"
+	// ReasonHeader optionally says why, e.g. a support ticket.
+	ReasonHeader = "X-Impersonation-Reason"
+)
+
+type operatorKey struct{}
+
+// WithOperator returns ctx marked as the context of a request operator makes
+// as a customer.
+func WithOperator(ctx context.Context, operator string) context.Context {
+	return context.WithValue(ctx, operatorKey{}, operator)
+}
+
+// Operator returns the operator impersonating the customer in ctx, if any.
+func Operator(ctx context.Context) (string, bool) {
+	operator, ok := ctx.Value(operatorKey{}).(string)
+	return operator, ok
+}
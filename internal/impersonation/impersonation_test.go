@@ -0,0 +1,17 @@
+package impersonation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOperator(t *testing.T) {
+	_, ok := Operator(context.Background())
+	assert.False(t, ok)
+
+	operator, ok := Operator(WithOperator(context.Background(), "alice@support"))
+	assert.True(t, ok)
+	assert.Equal(t, "alice@support", operator)
+}
@@ -0,0 +1,61 @@
+// Package intent resumes the transfer intents left pending: the top-ups and
+// cashback recorded with transfers that committed, but whose follow-up
+// transfer was never made, typically because the server stopped in between.
+// On a restart the job's first run makes them; every run after that picks up
+// those whose follow-up failed in the meantime.
+package intent
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/clock"
+)
+
+// Resumer makes the follow-up transfers of the intents recorded before a point
+// in time that are still pending, and returns how many it resumed.
+type Resumer interface {
+	ResumeTransferIntents(before time.Time) (int, error)
+}
+
+// Job resumes pending transfer intents.
+type Job struct {
+	resumer Resumer
+	clock   clock.Clock
+	grace   time.Duration
+	logger  *log.Logger
+}
+
+// New creates a Job resuming the intents pending for longer than grace. The
+// follow-up of an intent is made right after the transfer that records it
+// commits; grace keeps the job from racing it.
+func New(resumer Resumer, clk clock.Clock, grace time.Duration, logger *log.Logger) *Job {
+	return &Job{resumer: resumer, clock: clk, grace: grace, logger: logger}
+}
+
+// ResumePending resumes the intents pending for longer than the grace period.
+func (j *Job) ResumePending() error {
+	n, err := j.resumer.ResumeTransferIntents(j.clock.Now().Add(-j.grace))
+	if n > 0 {
+		j.logger.Printf("transfer intents: resumed %d pending for over %s", n, j.grace)
+	}
+	return err
+}
+
+// Run resumes pending intents at once and then every interval until ctx is
+// cancelled.
+func (j *Job) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := j.ResumePending(); err != nil {
+			j.logger.Printf("transfer intents: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package intent
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nehciyy/intrapay/internal/clock"
+)
+
+// fakeResumer records the cutoffs it is asked to resume before.
+type fakeResumer struct {
+	cutoffs []time.Time
+	resumed int
+	err     error
+}
+
+func (r *fakeResumer) ResumeTransferIntents(before time.Time) (int, error) {
+	r.cutoffs = append(r.cutoffs, before)
+	return r.resumed, r.err
+}
+
+func TestResumePending(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	var logs bytes.Buffer
+	resumer := &fakeResumer{}
+	job := New(resumer, clock.NewFake(now), time.Minute, log.New(&logs, "", 0))
+
+	assert.NoError(t, job.ResumePending())
+	assert.Equal(t, []time.Time{now.Add(-time.Minute)}, resumer.cutoffs, "intents younger than the grace period are left alone")
+	assert.Empty(t, logs.String(), "nothing resumed, nothing logged")
+
+	resumer.resumed = 3
+	assert.NoError(t, job.ResumePending())
+	assert.Contains(t, logs.String(), "resumed 3 pending for over 1m0s")
+
+	resumer.resumed, resumer.err = 1, errors.New("connection refused")
+	assert.EqualError(t, job.ResumePending(), "connection refused")
+	assert.Contains(t, logs.String(), "resumed 1 pending", "what was resumed before the error is logged")
+}
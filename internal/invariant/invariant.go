@@ -0,0 +1,136 @@
+// Package invariant verifies ledger invariants at runtime: every transfer must
+// conserve the combined balance of the two accounts it touches, and the sum of
+// all balances must equal the sum of opening balances plus adjustments.
+package invariant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/metrics"
+)
+
+// ErrViolation is wrapped by every invariant failure.
+var ErrViolation = errors.New("invariant violation")
+
+// tolerance absorbs float rounding on NUMERIC(20, 5) values.
+const tolerance = 1e-6
+
+// TotalsReader returns the global balance sum and the expected sum derived from
+// opening balances plus adjustments.
+type TotalsReader interface {
+	GetBalanceTotals() (total float64, expected float64, err error)
+}
+
+// Checker runs sampled per-transfer checks and periodic global checks.
+type Checker struct {
+	sampleRate float64
+	totals     TotalsReader
+
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+// NewChecker creates a Checker that verifies sampleRate of transfers (0 to 1).
+// totals may be nil, in which case only per-transfer checks run.
+func NewChecker(sampleRate float64, totals TotalsReader) *Checker {
+	return &Checker{
+		sampleRate: sampleRate,
+		totals:     totals,
+		rnd:        rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Sample reports whether the current transfer should be checked.
+func (c *Checker) Sample() bool {
+	if c == nil || c.sampleRate <= 0 {
+		return false
+	}
+	if c.sampleRate >= 1 {
+		return true
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rnd.Float64() < c.sampleRate
+}
+
+// Transfer holds the balances of both accounts before and after a transfer,
+// read inside the same database transaction.
+type Transfer struct {
+	SourceID, DestID     int64
+	Amount               float64
+	PreSource, PreDest   float64
+	PostSource, PostDest float64
+}
+
+// CheckTransfer verifies that the transfer moved exactly Amount from source to
+// destination and that the combined balance is unchanged.
+func (c *Checker) CheckTransfer(t Transfer) error {
+	expectedSource := t.PreSource - t.Amount
+	if t.SourceID == t.DestID {
+		expectedSource = t.PreSource
+	}
+
+	var err error
+	switch {
+	case !equal(t.PreSource+t.PreDest, t.PostSource+t.PostDest):
+		err = fmt.Errorf("%w: transfer %d->%d changed combined balance from %.5f to %.5f",
+			ErrViolation, t.SourceID, t.DestID, t.PreSource+t.PreDest, t.PostSource+t.PostDest)
+	case !equal(expectedSource, t.PostSource):
+		err = fmt.Errorf("%w: transfer %d->%d left source balance %.5f, expected %.5f",
+			ErrViolation, t.SourceID, t.DestID, t.PostSource, expectedSource)
+	}
+	record("transfer", err)
+	return err
+}
+
+// CheckGlobal verifies that the sum of balances matches opening balances plus adjustments.
+func (c *Checker) CheckGlobal() error {
+	if c == nil || c.totals == nil {
+		return nil
+	}
+	total, expected, err := c.totals.GetBalanceTotals()
+	if err != nil {
+		return fmt.Errorf("failed to read balance totals: %w", err)
+	}
+	if !equal(total, expected) {
+		err = fmt.Errorf("%w: sum of balances %.5f does not match opening balances plus adjustments %.5f",
+			ErrViolation, total, expected)
+	}
+	record("global", err)
+	return err
+}
+
+// Run performs CheckGlobal every interval until ctx is cancelled.
+func (c *Checker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.CheckGlobal(); err != nil && !errors.Is(err, ErrViolation) {
+				log.Printf("invariant check failed: %v", err)
+			}
+		}
+	}
+}
+
+func record(check string, err error) {
+	metrics.InvariantChecks.WithLabelValues(check).Inc()
+	if err != nil {
+		metrics.InvariantViolations.WithLabelValues(check).Inc()
+		log.Printf("ALERT: %v", err)
+	}
+}
+
+func equal(a, b float64) bool {
+	return math.Abs(a-b) <= tolerance
+}
@@ -0,0 +1,74 @@
+package invariant
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type stubTotals struct {
+	total, expected float64
+	err             error
+}
+
+func (s stubTotals) GetBalanceTotals() (float64, float64, error) {
+	return s.total, s.expected, s.err
+}
+
+func TestChecker_Sample(t *testing.T) {
+	var nilChecker *Checker
+	assert.False(t, nilChecker.Sample())
+	assert.False(t, NewChecker(0, nil).Sample())
+	assert.True(t, NewChecker(1, nil).Sample())
+}
+
+func TestChecker_CheckTransfer(t *testing.T) {
+	c := NewChecker(1, nil)
+	tests := []struct {
+		name      string
+		transfer  Transfer
+		violation bool
+	}{
+		{
+			name:     "Conserved",
+			transfer: Transfer{SourceID: 1, DestID: 2, Amount: 30, PreSource: 100, PreDest: 50, PostSource: 70, PostDest: 80},
+		},
+		{
+			name:      "Money created",
+			transfer:  Transfer{SourceID: 1, DestID: 2, Amount: 30, PreSource: 100, PreDest: 50, PostSource: 70, PostDest: 110},
+			violation: true,
+		},
+		{
+			name:      "Wrong amount moved",
+			transfer:  Transfer{SourceID: 1, DestID: 2, Amount: 30, PreSource: 100, PreDest: 50, PostSource: 60, PostDest: 90},
+			violation: true,
+		},
+		{
+			name:     "Self transfer",
+			transfer: Transfer{SourceID: 1, DestID: 1, Amount: 30, PreSource: 100, PreDest: 100, PostSource: 100, PostDest: 100},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := c.CheckTransfer(tt.transfer)
+			if tt.violation {
+				assert.ErrorIs(t, err, ErrViolation)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestChecker_CheckGlobal(t *testing.T) {
+	assert.NoError(t, NewChecker(1, stubTotals{total: 500, expected: 500}).CheckGlobal())
+	assert.ErrorIs(t, NewChecker(1, stubTotals{total: 510, expected: 500}).CheckGlobal(), ErrViolation)
+
+	err := NewChecker(1, stubTotals{err: errors.New("db down")}).CheckGlobal()
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrViolation)
+
+	assert.NoError(t, NewChecker(1, nil).CheckGlobal())
+}
@@ -0,0 +1,430 @@
+// Package metering counts API calls and transfer volume per API key and tenant
+// for internal chargeback. Counts are aggregated in memory and flushed to the
+// usage store periodically, so metering adds no query to the request path.
+package metering
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/clock"
+	"github.com/nehciyy/intrapay/internal/impersonation"
+	"github.com/nehciyy/intrapay/internal/models"
+)
+
+// Headers identifying the caller. They are expected to be set by the API
+// gateway; requests without them are metered as AnonymousKey and DefaultTenant.
+const (
+	APIKeyHeader = "X-API-Key"
+	TenantHeader = "X-Tenant-ID"
+)
+
+const (
+	AnonymousKey  = "anonymous"
+	DefaultTenant = "default"
+)
+
+// ErrQuotaExceeded is matched by every QuotaError.
+var ErrQuotaExceeded = errors.New("monthly quota exceeded")
+
+// Reason codes identifying the exceeded quota in QuotaError and in 429 responses.
+const (
+	CodeCallQuotaExceeded   = models.ReasonCallQuotaExceeded
+	CodeVolumeQuotaExceeded = models.ReasonVolumeQuotaExceeded
+)
+
+// QuotaError is returned when a call or transfer would exceed a monthly quota.
+type QuotaError struct {
+	Code    models.ReasonCode
+	Scope   models.QuotaScope
+	Subject string
+	Limit   float64
+	Used    float64
+}
+
+func (e *QuotaError) Error() string {
+	kind := "call"
+	if e.Code == CodeVolumeQuotaExceeded {
+		kind = "volume"
+	}
+	return fmt.Sprintf("monthly %s quota of %s %s exceeded: limit %g, used %g", kind, e.Scope, e.Subject, e.Limit, e.Used)
+}
+
+func (e *QuotaError) Is(target error) bool { return target == ErrQuotaExceeded }
+
+// Store persists usage counters.
+type Store interface {
+	AddUsage(u models.Usage) error
+	GetKeyUsage(period, apiKey string) (calls int64, volume float64, err error)
+	GetTenantUsage(period, tenant string) (calls int64, volume float64, err error)
+}
+
+// QuotaStore lists the quotas set for individual API keys and tenants.
+type QuotaStore interface {
+	ListQuotas() ([]models.Quota, error)
+}
+
+// Quotas are monthly limits. Zero means unlimited.
+type Quotas struct {
+	MonthlyCalls  int64
+	MonthlyVolume float64
+}
+
+type counts struct {
+	calls  int64
+	volume float64
+}
+
+type row struct {
+	period, apiKey, tenant string
+}
+
+// subject is an API key or a tenant.
+type subject struct {
+	scope models.QuotaScope
+	id    string
+}
+
+func (r row) has(s subject) bool {
+	if s.scope == models.QuotaScopeTenant {
+		return r.tenant == s.id
+	}
+	return r.apiKey == s.id
+}
+
+type subjectPeriod struct {
+	period string
+	subject
+}
+
+// limit is the quota that applies to a subject.
+type limit struct {
+	subject
+	Quotas
+}
+
+// Meter aggregates usage and enforces quotas.
+type Meter struct {
+	store    Store
+	quotaSrc QuotaStore
+	defaults Quotas
+	clock    clock.Clock
+	logger   *log.Logger
+
+	mu      sync.Mutex
+	pending map[row]*counts
+	// used holds month-to-date totals per subject for quota checks: the stored
+	// total when the subject was first seen in the period plus everything
+	// recorded since.
+	used map[subjectPeriod]*counts
+	// quotas are the per-subject quotas loaded from quotaSrc.
+	quotas map[subject]Quotas
+}
+
+// Option configures a Meter.
+type Option func(*Meter)
+
+// WithQuotaStore enforces the quotas set for individual API keys and tenants,
+// reloaded by ReloadQuotas. An API key's own quota replaces the default quotas.
+func WithQuotaStore(s QuotaStore) Option {
+	return func(m *Meter) { m.quotaSrc = s }
+}
+
+// New creates a Meter flushing to store. defaults apply to every API key
+// without a quota of its own.
+func New(store Store, defaults Quotas, clk clock.Clock, logger *log.Logger, opts ...Option) *Meter {
+	m := &Meter{
+		store:    store,
+		defaults: defaults,
+		clock:    clk,
+		logger:   logger,
+		pending:  make(map[row]*counts),
+		used:     make(map[subjectPeriod]*counts),
+		quotas:   make(map[subject]Quotas),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// ReloadQuotas replaces the per-subject quotas with those in the quota store.
+func (m *Meter) ReloadQuotas() error {
+	if m.quotaSrc == nil {
+		return nil
+	}
+	list, err := m.quotaSrc.ListQuotas()
+	if err != nil {
+		return fmt.Errorf("load quotas: %w", err)
+	}
+	quotas := make(map[subject]Quotas, len(list))
+	for _, q := range list {
+		quotas[subject{q.Scope, q.Subject}] = Quotas{MonthlyCalls: q.MonthlyCalls, MonthlyVolume: float64(q.MonthlyVolume)}
+	}
+	m.mu.Lock()
+	m.quotas = quotas
+	m.mu.Unlock()
+	return nil
+}
+
+// limits returns the quotas that apply to r: its API key's own quota or the
+// defaults, and its tenant's quota if it has one.
+func (m *Meter) limits(r row) []limit {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := subject{models.QuotaScopeAPIKey, r.apiKey}
+	q, ok := m.quotas[key]
+	if !ok {
+		q = m.defaults
+	}
+	limits := []limit{{key, q}}
+	tenant := subject{models.QuotaScopeTenant, r.tenant}
+	if q, ok := m.quotas[tenant]; ok {
+		limits = append(limits, limit{tenant, q})
+	}
+	return limits
+}
+
+type contextKey struct{}
+
+// caller is the metering identity of a request.
+type caller struct {
+	meter *Meter
+	row   row
+}
+
+// Middleware counts each request against the caller's API key and tenant and
+// rejects it with 429 once the monthly call quota of either is used up.
+// Impersonated requests are support's, not the customer's, so they are neither
+// counted nor held to the customer's quota.
+func (m *Meter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := impersonation.Operator(r.Context()); ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		apiKey, tenant := Caller(r)
+		c := &caller{meter: m, row: row{
+			period: m.clock.Now().UTC().Format(models.UsagePeriodLayout),
+			apiKey: apiKey,
+			tenant: tenant,
+		}}
+		if err := m.record(c.row); err != nil {
+			writeQuotaError(w, r, err)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, c)))
+	})
+}
+
+// writeQuotaError answers 429 in the API's JSON error envelope.
+func writeQuotaError(w http.ResponseWriter, r *http.Request, err *QuotaError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusTooManyRequests)
+	json.NewEncoder(w).Encode(struct {
+		Error     string            `json:"error"`
+		Status    int               `json:"status"`
+		Code      models.ReasonCode `json:"code"`
+		RequestID string            `json:"request_id,omitempty"`
+	}{err.Error(), http.StatusTooManyRequests, err.Code, r.Header.Get("X-Request-ID")})
+}
+
+// Caller returns the API key and tenant r is metered against, whether or not
+// metering is enabled.
+func Caller(r *http.Request) (apiKey, tenant string) {
+	return CallerOf(r.Header)
+}
+
+// CallerOf returns the API key and tenant of a request with headers h, for
+// handlers that are not given the request itself.
+func CallerOf(h http.Header) (apiKey, tenant string) {
+	return headerOr(h, APIKeyHeader, AnonymousKey), headerOr(h, TenantHeader, DefaultTenant)
+}
+
+func headerOr(h http.Header, name, fallback string) string {
+	if v := h.Get(name); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// CheckVolume returns a *QuotaError if moving amount would take the caller's API
+// key or tenant over its monthly volume quota. It does nothing for unmetered
+// requests.
+func CheckVolume(ctx context.Context, amount float64) error {
+	c, ok := ctx.Value(contextKey{}).(*caller)
+	if !ok {
+		return nil
+	}
+	for _, l := range c.meter.limits(c.row) {
+		if l.MonthlyVolume <= 0 {
+			continue
+		}
+		if used, ok := c.meter.monthToDate(c.row.period, l.subject); ok && used.volume+amount > l.MonthlyVolume {
+			return &QuotaError{Code: CodeVolumeQuotaExceeded, Scope: l.scope, Subject: l.id, Limit: l.MonthlyVolume, Used: used.volume}
+		}
+	}
+	return nil
+}
+
+// AddVolume records a completed transfer of amount against the caller.
+func AddVolume(ctx context.Context, amount float64) {
+	c, ok := ctx.Value(contextKey{}).(*caller)
+	if !ok {
+		return
+	}
+	c.meter.add(c.row, counts{volume: amount})
+}
+
+// record counts a call, first checking the call quotas.
+func (m *Meter) record(r row) *QuotaError {
+	for _, l := range m.limits(r) {
+		if l.MonthlyCalls <= 0 {
+			continue
+		}
+		if used, ok := m.monthToDate(r.period, l.subject); ok && used.calls >= l.MonthlyCalls {
+			return &QuotaError{Code: CodeCallQuotaExceeded, Scope: l.scope, Subject: l.id, Limit: float64(l.MonthlyCalls), Used: float64(used.calls)}
+		}
+	}
+	m.add(r, counts{calls: 1})
+	return nil
+}
+
+func (m *Meter) add(r row, n counts) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p, ok := m.pending[r]
+	if !ok {
+		p = &counts{}
+		m.pending[r] = p
+	}
+	p.calls += n.calls
+	p.volume += n.volume
+	for _, s := range []subject{{models.QuotaScopeAPIKey, r.apiKey}, {models.QuotaScopeTenant, r.tenant}} {
+		if u, ok := m.used[subjectPeriod{r.period, s}]; ok {
+			u.calls += n.calls
+			u.volume += n.volume
+		}
+	}
+}
+
+// monthToDate returns the subject's month-to-date totals, loading the stored
+// total the first time the subject is seen in the period. Totals are approximate
+// across instances: usage another instance has not flushed yet is not included.
+// It reports false if the stored total cannot be read, in which case quotas are
+// not enforced rather than failing the request.
+func (m *Meter) monthToDate(period string, s subject) (counts, bool) {
+	k := subjectPeriod{period, s}
+	m.mu.Lock()
+	u, ok := m.used[k]
+	m.mu.Unlock()
+	if ok {
+		return *u, true
+	}
+
+	load := m.store.GetKeyUsage
+	if s.scope == models.QuotaScopeTenant {
+		load = m.store.GetTenantUsage
+	}
+	calls, volume, err := load(period, s.id)
+	if err != nil {
+		m.logger.Printf("metering: load usage of %s %s: %v", s.scope, s.id, err)
+		return counts{}, false
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if u, ok := m.used[k]; ok {
+		return *u, true
+	}
+	// Stored totals exclude what this instance has not flushed yet.
+	u = &counts{calls: calls, volume: volume}
+	for r, p := range m.pending {
+		if r.period == period && r.has(s) {
+			u.calls += p.calls
+			u.volume += p.volume
+		}
+	}
+	m.used[k] = u
+	return *u, true
+}
+
+// Flush writes the pending counters to the store. Counters that fail to write
+// are kept for the next flush.
+func (m *Meter) Flush() error {
+	m.mu.Lock()
+	pending := m.pending
+	m.pending = make(map[row]*counts)
+	// Totals of past periods are no longer needed for quota checks.
+	current := m.clock.Now().UTC().Format(models.UsagePeriodLayout)
+	for k := range m.used {
+		if k.period != current {
+			delete(m.used, k)
+		}
+	}
+	m.mu.Unlock()
+
+	var errs []error
+	for r, c := range pending {
+		err := m.store.AddUsage(models.Usage{
+			Period: r.period,
+			APIKey: r.apiKey,
+			Tenant: r.tenant,
+			Calls:  c.calls,
+			Volume: models.Amount(c.volume),
+		})
+		if err != nil {
+			errs = append(errs, err)
+			m.requeue(r, *c)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// requeue returns unflushed counters to pending without touching the totals.
+func (m *Meter) requeue(r row, c counts) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, ok := m.pending[r]
+	if !ok {
+		p = &counts{}
+		m.pending[r] = p
+	}
+	p.calls += c.calls
+	p.volume += c.volume
+}
+
+// Run reloads quotas and flushes every interval until ctx is cancelled, then
+// flushes once more. Quota changes therefore take effect within one interval.
+func (m *Meter) Run(ctx context.Context, interval time.Duration) {
+	if err := m.ReloadQuotas(); err != nil {
+		m.logger.Printf("metering: %v", err)
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			if err := m.Flush(); err != nil {
+				m.logger.Printf("metering: final flush failed: %v", err)
+			}
+			return
+		case <-ticker.C:
+			if err := m.ReloadQuotas(); err != nil {
+				m.logger.Printf("metering: %v", err)
+			}
+			if err := m.Flush(); err != nil {
+				m.logger.Printf("metering: flush failed: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package metering
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nehciyy/intrapay/internal/clock"
+	"github.com/nehciyy/intrapay/internal/impersonation"
+	"github.com/nehciyy/intrapay/internal/models"
+)
+
+// memStore keeps usage in memory, keyed by period, API key and tenant.
+type memStore struct {
+	rows map[row]counts
+	err  error
+}
+
+func newMemStore() *memStore { return &memStore{rows: make(map[row]counts)} }
+
+func (s *memStore) AddUsage(u models.Usage) error {
+	if s.err != nil {
+		return s.err
+	}
+	r := row{u.Period, u.APIKey, u.Tenant}
+	c := s.rows[r]
+	c.calls += u.Calls
+	c.volume += float64(u.Volume)
+	s.rows[r] = c
+	return nil
+}
+
+func (s *memStore) GetKeyUsage(period, apiKey string) (int64, float64, error) {
+	return s.total(period, subject{models.QuotaScopeAPIKey, apiKey})
+}
+
+func (s *memStore) GetTenantUsage(period, tenant string) (int64, float64, error) {
+	return s.total(period, subject{models.QuotaScopeTenant, tenant})
+}
+
+func (s *memStore) total(period string, sub subject) (int64, float64, error) {
+	if s.err != nil {
+		return 0, 0, s.err
+	}
+	var total counts
+	for r, c := range s.rows {
+		if r.period == period && r.has(sub) {
+			total.calls += c.calls
+			total.volume += c.volume
+		}
+	}
+	return total.calls, total.volume, nil
+}
+
+type quotaList []models.Quota
+
+func (l quotaList) ListQuotas() ([]models.Quota, error) { return l, nil }
+
+var discard = log.New(io.Discard, "", 0)
+
+// transfer mimics the transaction handler, checking and recording a transfer of amount.
+func transfer(amount float64) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := CheckVolume(r.Context(), amount); err != nil {
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+			return
+		}
+		AddVolume(r.Context(), amount)
+	})
+}
+
+func call(h http.Handler, apiKey, tenant string) int {
+	req := httptest.NewRequest("POST", "/transactions", nil)
+	if apiKey != "" {
+		req.Header.Set(APIKeyHeader, apiKey)
+	}
+	if tenant != "" {
+		req.Header.Set(TenantHeader, tenant)
+	}
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	return rr.Code
+}
+
+func TestMeter_Flush(t *testing.T) {
+	store := newMemStore()
+	m := New(store, Quotas{}, clock.NewFake(time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC)), discard)
+	h := m.Middleware(transfer(100))
+
+	call(h, "key-1", "payroll")
+	call(h, "key-1", "payroll")
+	call(h, "key-1", "treasury")
+	call(h, "", "")
+	assert.Empty(t, store.rows, "nothing is written before a flush")
+
+	require.NoError(t, m.Flush())
+	assert.Equal(t, map[row]counts{
+		{"2026-03", "key-1", "payroll"}:          {calls: 2, volume: 200},
+		{"2026-03", "key-1", "treasury"}:         {calls: 1, volume: 100},
+		{"2026-03", AnonymousKey, DefaultTenant}: {calls: 1, volume: 100},
+	}, store.rows)
+
+	call(h, "key-1", "payroll")
+	require.NoError(t, m.Flush())
+	assert.Equal(t, counts{calls: 3, volume: 300}, store.rows[row{"2026-03", "key-1", "payroll"}], "later flushes add to the stored totals")
+}
+
+func TestMeter_Flush_Requeue(t *testing.T) {
+	store := newMemStore()
+	m := New(store, Quotas{}, clock.NewFake(time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC)), discard)
+	h := m.Middleware(transfer(10))
+
+	call(h, "key-1", "payroll")
+	store.err = errors.New("connection refused")
+	assert.Error(t, m.Flush())
+
+	store.err = nil
+	call(h, "key-1", "payroll")
+	require.NoError(t, m.Flush())
+	assert.Equal(t, counts{calls: 2, volume: 20}, store.rows[row{"2026-03", "key-1", "payroll"}])
+}
+
+func TestMeter_CallQuota(t *testing.T) {
+	clk := clock.NewFake(time.Date(2026, 3, 31, 12, 0, 0, 0, time.UTC))
+	store := newMemStore()
+	store.rows[row{"2026-03", "key-1", "payroll"}] = counts{calls: 1}
+	m := New(store, Quotas{MonthlyCalls: 3}, clk, discard)
+	h := m.Middleware(transfer(0))
+
+	assert.Equal(t, http.StatusOK, call(h, "key-1", "payroll"), "stored usage counts towards the quota")
+	assert.Equal(t, http.StatusOK, call(h, "key-1", "treasury"), "the quota spans tenants")
+	assert.Equal(t, http.StatusTooManyRequests, call(h, "key-1", "payroll"))
+	assert.Equal(t, http.StatusOK, call(h, "key-2", "payroll"), "quotas are per key")
+
+	require.NoError(t, m.Flush())
+	clk.Advance(24 * time.Hour)
+	assert.Equal(t, http.StatusOK, call(h, "key-1", "payroll"), "the quota resets each month")
+}
+
+func TestMeter_Impersonated(t *testing.T) {
+	clk := clock.NewFake(time.Date(2026, 3, 31, 12, 0, 0, 0, time.UTC))
+	store := newMemStore()
+	m := New(store, Quotas{MonthlyCalls: 1}, clk, discard)
+	h := m.Middleware(transfer(0))
+
+	req := httptest.NewRequest("GET", "/accounts/1", nil)
+	req.Header.Set(APIKeyHeader, "key-1")
+	req = req.WithContext(impersonation.WithOperator(req.Context(), "alice"))
+	for i := 0; i < 2; i++ {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code, "impersonated calls are not held to the quota")
+	}
+
+	assert.Equal(t, http.StatusOK, call(h, "key-1", ""), "nor counted towards it")
+	require.NoError(t, m.Flush())
+	calls, _, err := store.GetKeyUsage("2026-03", "key-1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), calls)
+}
+
+func TestMeter_VolumeQuota(t *testing.T) {
+	m := New(newMemStore(), Quotas{MonthlyVolume: 250}, clock.NewFake(time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC)), discard)
+	h := m.Middleware(transfer(100))
+
+	assert.Equal(t, http.StatusOK, call(h, "key-1", "payroll"))
+	assert.Equal(t, http.StatusOK, call(h, "key-1", "payroll"))
+	assert.Equal(t, http.StatusTooManyRequests, call(h, "key-1", "payroll"))
+
+	req := httptest.NewRequest("POST", "/transactions", nil)
+	req.Header.Set(APIKeyHeader, "key-1")
+	rr := httptest.NewRecorder()
+	m.Middleware(transfer(50)).ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code, "a transfer that fits the remaining volume is allowed")
+}
+
+func TestMeter_QuotaStore(t *testing.T) {
+	quotas := quotaList{
+		{Scope: models.QuotaScopeAPIKey, Subject: "batch", MonthlyCalls: 0},
+		{Scope: models.QuotaScopeTenant, Subject: "payroll", MonthlyCalls: 2},
+		{Scope: models.QuotaScopeTenant, Subject: "treasury", MonthlyVolume: 100},
+	}
+	m := New(newMemStore(), Quotas{MonthlyCalls: 1}, clock.NewFake(time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC)), discard, WithQuotaStore(quotas))
+	require.NoError(t, m.ReloadQuotas())
+	h := m.Middleware(transfer(60))
+
+	assert.Equal(t, http.StatusOK, call(h, "batch", "payroll"))
+	assert.Equal(t, http.StatusOK, call(h, "batch", "payroll"), "the key's own quota replaces the default")
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/transactions", nil)
+	req.Header.Set(APIKeyHeader, "batch")
+	req.Header.Set(TenantHeader, "payroll")
+	h.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code, "the tenant quota spans keys")
+	assert.JSONEq(t, `{"error":"monthly call quota of tenant payroll exceeded: limit 2, used 2","status":429,"code":"call_quota_exceeded"}`, rr.Body.String())
+
+	assert.Equal(t, http.StatusOK, call(h, "key-1", ""))
+	assert.Equal(t, http.StatusTooManyRequests, call(h, "key-1", ""), "keys without a quota get the default")
+
+	assert.Equal(t, http.StatusOK, call(h, "batch", "treasury"))
+	assert.Equal(t, http.StatusTooManyRequests, call(h, "batch", "treasury"))
+}
+
+func TestCheckVolume_QuotaError(t *testing.T) {
+	m := New(newMemStore(), Quotas{MonthlyVolume: 50}, clock.NewFake(time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC)), discard)
+	var err error
+	m.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err = CheckVolume(r.Context(), 75)
+	})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/transactions", nil))
+
+	var quotaErr *QuotaError
+	require.ErrorAs(t, err, &quotaErr)
+	assert.ErrorIs(t, err, ErrQuotaExceeded)
+	assert.Equal(t, &QuotaError{Code: CodeVolumeQuotaExceeded, Scope: models.QuotaScopeAPIKey, Subject: AnonymousKey, Limit: 50}, quotaErr)
+}
+
+func TestMeter_StoreDown(t *testing.T) {
+	store := newMemStore()
+	store.err = errors.New("connection refused")
+	m := New(store, Quotas{MonthlyCalls: 1, MonthlyVolume: 1}, clock.NewFake(time.Now()), discard)
+
+	assert.Equal(t, http.StatusOK, call(m.Middleware(transfer(100)), "key-1", ""), "quotas are not enforced when usage cannot be read")
+}
+
+func TestCheckVolume_Unmetered(t *testing.T) {
+	req := httptest.NewRequest("POST", "/transactions", nil)
+	assert.NoError(t, CheckVolume(req.Context(), 1e9))
+	AddVolume(req.Context(), 1e9)
+}
+
+func TestMeter_Run(t *testing.T) {
+	store := newMemStore()
+	m := New(store, Quotas{}, clock.NewFake(time.Now()), discard)
+	call(m.Middleware(transfer(0)), "key-1", "payroll")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		m.Run(ctx, time.Hour)
+		close(done)
+	}()
+	cancel()
+	<-done
+
+	assert.Len(t, store.rows, 1, "cancelling Run flushes pending usage")
+}
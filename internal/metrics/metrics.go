@@ -0,0 +1,318 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Transaction outcomes used as the "outcome" label on transaction metrics.
+const (
+	OutcomeSuccess           = "success"
+	OutcomeInsufficientFunds = "insufficient_funds"
+	OutcomeRetryExhausted    = "retry_exhausted"
+	OutcomeDestNotFound      = "dest_not_found"
+	OutcomeSourceNotFound    = "source_not_found"
+	OutcomeRegionPassive     = "region_passive"
+	OutcomeTransfersFrozen   = "transfers_frozen"
+	OutcomeAmountOutOfBounds = "amount_out_of_bounds"
+	OutcomeError             = "error"
+)
+
+var (
+	// HTTPRequestDuration tracks latency of every routed HTTP request.
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "intrapay",
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of HTTP requests by route template, method and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "code"})
+
+	// TransactionDuration tracks latency of CreateTransaction broken down by outcome.
+	TransactionDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "intrapay",
+		Subsystem: "service",
+		Name:      "transaction_duration_seconds",
+		Help:      "Latency of CreateTransaction by outcome.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"outcome"})
+
+	// ShadowComparisons counts shadow-mode ledger comparisons by result (match, mismatch, error).
+	ShadowComparisons = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "intrapay",
+		Subsystem: "shadow",
+		Name:      "comparisons_total",
+		Help:      "Shadow ledger comparisons by operation and result.",
+	}, []string{"operation", "result"})
+
+	// InvariantChecks counts runtime invariant checks by kind (transfer, global).
+	InvariantChecks = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "intrapay",
+		Subsystem: "invariant",
+		Name:      "checks_total",
+		Help:      "Runtime ledger invariant checks performed.",
+	}, []string{"check"})
+
+	// InvariantViolations counts failed invariant checks. Any increase should page.
+	InvariantViolations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "intrapay",
+		Subsystem: "invariant",
+		Name:      "violations_total",
+		Help:      "Runtime ledger invariant violations detected.",
+	}, []string{"check"})
+
+	// Expired counts pending entities expired by the TTL sweeper, by policy.
+	Expired = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "intrapay",
+		Subsystem: "expiry",
+		Name:      "expired_total",
+		Help:      "Pending entities expired by the TTL sweeper.",
+	}, []string{"policy"})
+
+	// TopUps counts automatic top-ups by result (executed, skipped, failed). A
+	// top-up is skipped when a concurrent one already refilled the account.
+	TopUps = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "intrapay",
+		Subsystem: "account",
+		Name:      "top_ups_total",
+		Help:      "Automatic top-ups of accounts below their threshold, by result.",
+	}, []string{"result"})
+
+	// Cashback counts cashback credits of campaigns by result (paid, failed).
+	Cashback = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "intrapay",
+		Subsystem: "cashback",
+		Name:      "credits_total",
+		Help:      "Cashback credits of campaigns to accounts that made qualifying transfers, by result.",
+	}, []string{"result"})
+
+	// TransferIntentsResumed counts the top-ups and cashback found pending
+	// after the transfer setting them off committed, e.g. because the server
+	// crashed in between, and resumed, by kind (top_up, cashback).
+	TransferIntentsResumed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "intrapay",
+		Subsystem: "transfer_intents",
+		Name:      "resumed_total",
+		Help:      "Pending transfer intents resumed after the transfer setting them off committed, by kind.",
+	}, []string{"kind"})
+
+	// ForwardQueueDepth tracks the transfers accepted into the store-and-forward
+	// queue during a database outage that are still waiting to be replayed.
+	ForwardQueueDepth = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "intrapay",
+		Subsystem: "forward_queue",
+		Name:      "depth",
+		Help:      "Transfers in the store-and-forward queue waiting to be replayed.",
+	})
+
+	// ForwardedTransfers counts the transfers taken off the store-and-forward
+	// queue, by outcome (completed, rejected, expired).
+	ForwardedTransfers = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "intrapay",
+		Subsystem: "forward_queue",
+		Name:      "replayed_total",
+		Help:      "Transfers taken off the store-and-forward queue, by outcome.",
+	}, []string{"outcome"})
+
+	// CoalescedBatchSize tracks how many transfers to a hot account each
+	// coalesced posting carries.
+	CoalescedBatchSize = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "intrapay",
+		Subsystem: "coalescing",
+		Name:      "batch_size",
+		Help:      "Transfers posted together by write coalescing, per batch.",
+		Buckets:   []float64{1, 2, 5, 10, 25, 50, 100, 250, 500},
+	})
+
+	// Revaluations counts revaluations of balances to the base currency by
+	// result (revalued, failed).
+	Revaluations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "intrapay",
+		Subsystem: "revaluation",
+		Name:      "runs_total",
+		Help:      "Revaluations of balances to the base currency, by result.",
+	}, []string{"result"})
+
+	// FXRateFetches counts requests for live exchange rates to the rate
+	// provider by result (fetched, failed).
+	FXRateFetches = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "intrapay",
+		Subsystem: "fx",
+		Name:      "rate_fetches_total",
+		Help:      "Requests for live exchange rates to the rate provider, by result.",
+	}, []string{"result"})
+
+	// BackfillRows counts the rows backfills filled in, by backfill.
+	BackfillRows = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "intrapay",
+		Subsystem: "backfill",
+		Name:      "rows_updated_total",
+		Help:      "Rows filled in by backfills.",
+	}, []string{"backfill"})
+
+	// OutboxPublished counts outbox events handed to the publisher by result
+	// (published, failed), and those held back for not matching their schema
+	// (invalid).
+	OutboxPublished = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "intrapay",
+		Subsystem: "outbox",
+		Name:      "published_total",
+		Help:      "Outbox events handed to the event publisher, by result.",
+	}, []string{"result"})
+
+	// SLOEvents counts transfers against each service level objective by result
+	// (good, bad), for computing SLIs over any window in Prometheus.
+	SLOEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "intrapay",
+		Subsystem: "slo",
+		Name:      "events_total",
+		Help:      "Transfers counted against each service level objective, by result.",
+	}, []string{"objective", "result"})
+
+	// SLOIndicator is the share of good transfers per objective over each
+	// rolling window, as served by GET /admin/slo.
+	SLOIndicator = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "intrapay",
+		Subsystem: "slo",
+		Name:      "sli",
+		Help:      "Share of good transfers per objective over a rolling window.",
+	}, []string{"objective", "window"})
+
+	// SLOBurnRate is how fast each objective spends its error budget over each
+	// rolling window; 1 spends exactly the budget over the SLO period.
+	SLOBurnRate = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "intrapay",
+		Subsystem: "slo",
+		Name:      "burn_rate",
+		Help:      "Error budget burn rate per objective over a rolling window.",
+	}, []string{"objective", "window"})
+
+	// SLOErrorBudgetRemaining is the share of each objective's error budget left
+	// over the SLO period.
+	SLOErrorBudgetRemaining = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "intrapay",
+		Subsystem: "slo",
+		Name:      "error_budget_remaining",
+		Help:      "Share of the error budget left over the SLO period, per objective.",
+	}, []string{"objective"})
+
+	// DBRoutedQueries counts repository reads by the hints they ran with and
+	// the connection they were routed to (primary, replica, reader).
+	DBRoutedQueries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "intrapay",
+		Subsystem: "db",
+		Name:      "routed_queries_total",
+		Help:      "Repository reads by routing hints and target connection.",
+	}, []string{"hints", "target"})
+
+	// DBQueryRetries counts idempotent repository reads retried after a
+	// transient error, by query.
+	DBQueryRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "intrapay",
+		Subsystem: "db",
+		Name:      "query_retries_total",
+		Help:      "Idempotent repository reads retried after a transient error.",
+	}, []string{"query"})
+
+	// WorkerPoolTasks counts the tasks of each worker pool by outcome
+	// (completed, panicked, rejected).
+	WorkerPoolTasks = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "intrapay",
+		Subsystem: "worker_pool",
+		Name:      "tasks_total",
+		Help:      "Tasks submitted to worker pools by pool and outcome.",
+	}, []string{"pool", "outcome"})
+
+	// WorkerPoolQueued is how many tasks wait for a worker of each pool.
+	WorkerPoolQueued = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "intrapay",
+		Subsystem: "worker_pool",
+		Name:      "queued",
+		Help:      "Tasks waiting for a worker, by pool.",
+	}, []string{"pool"})
+
+	// WorkerPoolBusy is how many workers of each pool are running a task.
+	WorkerPoolBusy = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "intrapay",
+		Subsystem: "worker_pool",
+		Name:      "busy_workers",
+		Help:      "Workers running a task, by pool.",
+	}, []string{"pool"})
+
+	// WorkerPoolTaskDuration tracks how long the tasks of each pool run.
+	WorkerPoolTaskDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "intrapay",
+		Subsystem: "worker_pool",
+		Name:      "task_duration_seconds",
+		Help:      "Run time of worker pool tasks by pool.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"pool"})
+
+	// RegionActive is 1 while this server's region is the active region of an
+	// active-passive deployment and 0 while it is passive.
+	RegionActive = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "intrapay",
+		Subsystem: "region",
+		Name:      "active",
+		Help:      "Whether this server's region is the active region (1) or passive (0).",
+	}, []string{"region"})
+
+	// RegionEpoch is the fencing token of the region fence as last read.
+	RegionEpoch = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "intrapay",
+		Subsystem: "region",
+		Name:      "epoch",
+		Help:      "Fencing token of the region fence, bumped by every promotion.",
+	})
+)
+
+// ObserveTransaction records a CreateTransaction call. When traceID is set it is
+// attached as an exemplar so latency buckets can be linked back to a trace.
+func ObserveTransaction(outcome string, elapsed time.Duration, traceID string) {
+	observe(TransactionDuration.WithLabelValues(outcome), elapsed, traceID)
+}
+
+// ObserveHTTPRequest records a single HTTP request against its route template.
+func ObserveHTTPRequest(route, method, code string, elapsed time.Duration, traceID string) {
+	observe(HTTPRequestDuration.WithLabelValues(route, method, code), elapsed, traceID)
+}
+
+func observe(o prometheus.Observer, elapsed time.Duration, traceID string) {
+	if eo, ok := o.(prometheus.ExemplarObserver); ok && traceID != "" {
+		eo.ObserveWithExemplar(elapsed.Seconds(), prometheus.Labels{"trace_id": traceID})
+		return
+	}
+	o.Observe(elapsed.Seconds())
+}
+
+// RegisterDBStats exposes sql.DBStats (open, in-use and idle connections, wait
+// count and wait duration) for the given pool.
+func RegisterDBStats(db *sql.DB) error {
+	return prometheus.Register(collectors.NewDBStatsCollector(db, "intrapay"))
+}
+
+// RegisterReplicaDBStats exposes sql.DBStats for the read replica pool, under
+// db_name "intrapay_replica".
+func RegisterReplicaDBStats(db *sql.DB) error {
+	return prometheus.Register(collectors.NewDBStatsCollector(db, "intrapay_replica"))
+}
+
+// RegisterReaderDBStats exposes sql.DBStats for the pool of the read-only role,
+// under db_name "intrapay_reader".
+func RegisterReaderDBStats(db *sql.DB) error {
+	return prometheus.Register(collectors.NewDBStatsCollector(db, "intrapay_reader"))
+}
+
+// Handler serves the default registry. OpenMetrics is enabled because exemplars
+// are only exposed in that format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{
+		EnableOpenMetrics: true,
+	})
+}
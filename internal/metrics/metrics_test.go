@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func transactionHistogram(t *testing.T, outcome string) *dto.Histogram {
+	m := &dto.Metric{}
+	require.NoError(t, TransactionDuration.WithLabelValues(outcome).(prometheus.Metric).Write(m))
+	return m.GetHistogram()
+}
+
+func TestObserveTransaction(t *testing.T) {
+	TransactionDuration.Reset()
+
+	ObserveTransaction(OutcomeSuccess, 10*time.Millisecond, "4bf92f3577b34da6a3ce929d0e0e4736")
+	ObserveTransaction(OutcomeSuccess, 20*time.Millisecond, "")
+	ObserveTransaction(OutcomeInsufficientFunds, 5*time.Millisecond, "")
+
+	success := transactionHistogram(t, OutcomeSuccess)
+	assert.Equal(t, uint64(2), success.GetSampleCount())
+
+	var exemplars []string
+	for _, b := range success.GetBucket() {
+		if ex := b.GetExemplar(); ex != nil {
+			for _, l := range ex.GetLabel() {
+				exemplars = append(exemplars, l.GetName()+"="+l.GetValue())
+			}
+		}
+	}
+	assert.Equal(t, []string{"trace_id=4bf92f3577b34da6a3ce929d0e0e4736"}, exemplars)
+
+	assert.Equal(t, uint64(1), transactionHistogram(t, OutcomeInsufficientFunds).GetSampleCount())
+}
+
+func TestRegisterDBStats(t *testing.T) {
+	db, _, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	require.NoError(t, RegisterDBStats(db))
+	defer prometheus.Unregister(collectors.NewDBStatsCollector(db, "intrapay"))
+
+	families, err := prometheus.DefaultGatherer.Gather()
+	require.NoError(t, err)
+	names := map[string]bool{}
+	for _, f := range families {
+		names[f.GetName()] = true
+	}
+	for _, name := range []string{
+		"go_sql_in_use_connections",
+		"go_sql_idle_connections",
+		"go_sql_wait_count_total",
+		"go_sql_wait_duration_seconds_total",
+	} {
+		assert.True(t, names[name], "missing %s", name)
+	}
+}
@@ -0,0 +1,161 @@
+package middleware
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/clock"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/security"
+)
+
+// LockoutConfig configures how the auth stage locks out callers that keep
+// failing to authenticate.
+type LockoutConfig struct {
+	// Threshold is how many consecutive failures lock a caller out; zero
+	// disables lockouts.
+	Threshold int
+	// Duration is how long the first lockout lasts. Each lockout after it, while
+	// the caller keeps failing, lasts twice as long as the last.
+	Duration time.Duration
+	// MaxDuration caps how long a lockout lasts. A caller that has not failed
+	// for MaxDuration since its last lockout ended starts over.
+	MaxDuration time.Duration
+}
+
+// DefaultLockoutConfig leaves lockouts off. Callers are locked out by the
+// address their connection comes from, which is only theirs when no proxy or
+// load balancer stands in front of the server, so lockouts are opted into
+// with a Threshold; a minute for the first lockout and up to an hour if the
+// caller keeps failing are the durations they then default to.
+func DefaultLockoutConfig() LockoutConfig {
+	return LockoutConfig{Duration: time.Minute, MaxDuration: time.Hour}
+}
+
+// Lockout tracks the consecutive authentication failures of callers, each
+// named by its address, and locks out those that fail too often. Transports
+// sharing the server's auth share a Lockout.
+type Lockout struct {
+	cfg    LockoutConfig
+	clock  clock.Clock
+	logger *log.Logger
+	events *security.Stream
+
+	mu      sync.Mutex
+	callers map[string]*lockoutState
+	swept   time.Time
+}
+
+type lockoutState struct {
+	failures int
+	lockouts int
+	last     time.Time
+	until    time.Time
+}
+
+// NewLockout creates a Lockout logging each lockout to logger and emitting it
+// to events.
+func NewLockout(cfg LockoutConfig, clk clock.Clock, logger *log.Logger, events *security.Stream) *Lockout {
+	return &Lockout{cfg: cfg, clock: clk, logger: logger, events: events, callers: make(map[string]*lockoutState)}
+}
+
+// Locked returns how long until every one of callers may try again, or zero if
+// none is locked out.
+func (l *Lockout) Locked(callers ...string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	var wait time.Duration
+	for _, c := range callers {
+		if s, ok := l.callers[c]; ok && s.until.After(now) {
+			wait = max(wait, s.until.Sub(now))
+		}
+	}
+	return wait
+}
+
+// Fail records a failed authentication by each of callers, and locks out those
+// it takes to the threshold.
+func (l *Lockout) Fail(callers ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	l.sweep(now)
+	for _, c := range callers {
+		s, ok := l.callers[c]
+		if !ok || l.quiet(s, now) {
+			s = &lockoutState{}
+			l.callers[c] = s
+		}
+		s.failures++
+		s.last = now
+		if s.failures < l.cfg.Threshold {
+			continue
+		}
+		d := l.cfg.Duration
+		for i := 0; i < s.lockouts && d < l.cfg.MaxDuration; i++ {
+			d *= 2
+		}
+		d = min(d, l.cfg.MaxDuration)
+		s.lockouts++
+		s.failures = 0
+		s.until = now.Add(d)
+		detail := fmt.Sprintf("for %s after %d consecutive failures (lockout %d)", d, l.cfg.Threshold, s.lockouts)
+		l.logger.Printf("security: auth lockout of %s %s", c, detail)
+		l.events.Emit(models.SecurityEvent{Type: models.SecurityAuthLockout, Actor: c, Detail: detail})
+	}
+}
+
+// Succeed forgets the failures and lockouts of callers.
+func (l *Lockout) Succeed(callers ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, c := range callers {
+		delete(l.callers, c)
+	}
+}
+
+// quiet reports whether s has neither failed nor been locked out for
+// MaxDuration, after which its failures and lockouts are forgotten.
+func (l *Lockout) quiet(s *lockoutState, now time.Time) bool {
+	return now.Sub(s.last) >= l.cfg.MaxDuration && now.Sub(s.until) >= l.cfg.MaxDuration
+}
+
+// sweep drops the quiet callers, at most once per Duration, so callers that go
+// away are not kept forever.
+func (l *Lockout) sweep(now time.Time) {
+	if now.Sub(l.swept) < l.cfg.Duration {
+		return
+	}
+	l.swept = now
+	for c, s := range l.callers {
+		if l.quiet(s, now) {
+			delete(l.callers, c)
+		}
+	}
+}
+
+// lockoutCaller names the caller a request is counted against: its address.
+// Nothing else a failing request carries can be trusted, e.g. the API key it
+// names is not authenticated, and counting against it would let anyone lock
+// out any key.
+func lockoutCaller(r *http.Request) string {
+	return "address " + ClientAddress(r)
+}
+
+// ClientAddress returns the address r's connection comes from, without its
+// port.
+func ClientAddress(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,235 @@
+// Package middleware assembles the cross-cutting HTTP middleware (auth, usage
+// metering, rate limiting, access logging, panic recovery and timeouts) into a
+// single ordered chain whose stages are enabled by configuration. The decisions
+// of the auth and rate limit stages are made by an Authenticator and a Limiter,
+// which carry no HTTP, so that any other transport serving the API enforces
+// them through the same code.
+package middleware
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/clock"
+	"github.com/nehciyy/intrapay/internal/security"
+)
+
+// Middleware wraps a handler.
+type Middleware func(http.Handler) http.Handler
+
+// Stage is a named middleware in a Chain.
+type Stage struct {
+	Name       string
+	Middleware Middleware
+}
+
+// Chain is an ordered list of stages. The first stage is the outermost: it sees
+// the request first and the response last.
+type Chain struct {
+	stages []Stage
+}
+
+// NewChain creates a chain running stages in the given order.
+func NewChain(stages ...Stage) *Chain {
+	return &Chain{stages: stages}
+}
+
+// Append adds stages after (inside) the existing ones.
+func (c *Chain) Append(stages ...Stage) *Chain {
+	c.stages = append(c.stages, stages...)
+	return c
+}
+
+// Prepend adds stages before (outside) the existing ones.
+func (c *Chain) Prepend(stages ...Stage) *Chain {
+	c.stages = append(append([]Stage(nil), stages...), c.stages...)
+	return c
+}
+
+// Names lists the stages in the order they run.
+func (c *Chain) Names() []string {
+	names := make([]string, len(c.stages))
+	for i, s := range c.stages {
+		names[i] = s.Name
+	}
+	return names
+}
+
+// Then wraps h with every stage of the chain.
+func (c *Chain) Then(h http.Handler) http.Handler {
+	for i := len(c.stages) - 1; i >= 0; i-- {
+		h = c.stages[i].Middleware(h)
+	}
+	return h
+}
+
+// Stage names accepted in Config.Stages.
+const (
+	StageAuth      = "auth"
+	StageMetering  = "metering"
+	StageRateLimit = "ratelimit"
+	StageLogging   = "logging"
+	StageRecovery  = "recovery"
+	StageTimeout   = "timeout"
+)
+
+// DefaultStages is the standard order of the configurable stages.
+var DefaultStages = []string{StageAuth, StageMetering, StageRateLimit, StageLogging, StageRecovery, StageTimeout}
+
+// Config selects and configures the middleware stages.
+type Config struct {
+	// Stages lists the enabled stages in the order they run.
+	Stages []string
+	// AuthToken is the bearer token clients must send; empty skips the auth stage.
+	AuthToken string
+	// Lockout configures how the auth stage locks out callers that keep failing
+	// to authenticate.
+	Lockout LockoutConfig
+	// Lockouts, when set, is the Lockout the auth stage uses instead of one of
+	// its own built from Lockout, so that several chains can share one.
+	Lockouts *Lockout
+	// Security, when set, receives the failed authentications and lockouts of
+	// the auth stage.
+	Security *security.Stream
+	// RateLimit is the number of requests per second allowed across the server;
+	// zero skips the rate limit stage.
+	RateLimit float64
+	// RateBurst is how many requests may exceed RateLimit momentarily.
+	RateBurst int
+	// Timeout bounds how long a handler may take; zero skips the timeout stage.
+	Timeout time.Duration
+	// LogSampleRate is the share of successful reads the logging stage logs;
+	// zero logs them all, like 1. Writes and errors are always logged.
+	LogSampleRate float64
+}
+
+// DefaultConfig enables every stage in DefaultStages with a 30 second timeout.
+func DefaultConfig() Config {
+	return Config{
+		Stages:  append([]string(nil), DefaultStages...),
+		Lockout: DefaultLockoutConfig(),
+		Timeout: 30 * time.Second,
+	}
+}
+
+// ConfigFromEnv reads MIDDLEWARE (a comma-separated list of stage names, in
+// order), AUTH_TOKEN, AUTH_LOCKOUT_THRESHOLD, AUTH_LOCKOUT_DURATION,
+// AUTH_LOCKOUT_MAX_DURATION, RATE_LIMIT, RATE_LIMIT_BURST, REQUEST_TIMEOUT and
+// LOG_SAMPLE_RATE on top of DefaultConfig.
+func ConfigFromEnv() (Config, error) {
+	cfg := DefaultConfig()
+	var err error
+
+	if v, ok := os.LookupEnv("MIDDLEWARE"); ok {
+		cfg.Stages = nil
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				cfg.Stages = append(cfg.Stages, name)
+			}
+		}
+	}
+	cfg.AuthToken = os.Getenv("AUTH_TOKEN")
+	if v := os.Getenv("AUTH_LOCKOUT_THRESHOLD"); v != "" {
+		if cfg.Lockout.Threshold, err = strconv.Atoi(v); err != nil || cfg.Lockout.Threshold < 0 {
+			return cfg, fmt.Errorf("invalid AUTH_LOCKOUT_THRESHOLD %q: must be a non-negative integer", v)
+		}
+	}
+	if v := os.Getenv("AUTH_LOCKOUT_DURATION"); v != "" {
+		if cfg.Lockout.Duration, err = time.ParseDuration(v); err != nil || cfg.Lockout.Duration <= 0 {
+			return cfg, fmt.Errorf("invalid AUTH_LOCKOUT_DURATION %q: must be a positive duration", v)
+		}
+	}
+	if v := os.Getenv("AUTH_LOCKOUT_MAX_DURATION"); v != "" {
+		if cfg.Lockout.MaxDuration, err = time.ParseDuration(v); err != nil || cfg.Lockout.MaxDuration <= 0 {
+			return cfg, fmt.Errorf("invalid AUTH_LOCKOUT_MAX_DURATION %q: must be a positive duration", v)
+		}
+	}
+	if cfg.Lockout.MaxDuration < cfg.Lockout.Duration {
+		return cfg, fmt.Errorf("AUTH_LOCKOUT_MAX_DURATION %s is shorter than AUTH_LOCKOUT_DURATION %s", cfg.Lockout.MaxDuration, cfg.Lockout.Duration)
+	}
+	if v := os.Getenv("RATE_LIMIT"); v != "" {
+		if cfg.RateLimit, err = strconv.ParseFloat(v, 64); err != nil || cfg.RateLimit < 0 {
+			return cfg, fmt.Errorf("invalid RATE_LIMIT %q: must be a non-negative number", v)
+		}
+	}
+	if v := os.Getenv("RATE_LIMIT_BURST"); v != "" {
+		if cfg.RateBurst, err = strconv.Atoi(v); err != nil || cfg.RateBurst < 0 {
+			return cfg, fmt.Errorf("invalid RATE_LIMIT_BURST %q: must be a non-negative integer", v)
+		}
+	}
+	if v := os.Getenv("REQUEST_TIMEOUT"); v != "" {
+		if cfg.Timeout, err = time.ParseDuration(v); err != nil || cfg.Timeout < 0 {
+			return cfg, fmt.Errorf("invalid REQUEST_TIMEOUT %q: must be a non-negative duration", v)
+		}
+	}
+	if v := os.Getenv("LOG_SAMPLE_RATE"); v != "" {
+		if cfg.LogSampleRate, err = strconv.ParseFloat(v, 64); err != nil || cfg.LogSampleRate <= 0 || cfg.LogSampleRate > 1 {
+			return cfg, fmt.Errorf("invalid LOG_SAMPLE_RATE %q: must be greater than 0 and at most 1", v)
+		}
+	}
+	return cfg, nil
+}
+
+// FromConfig builds the chain of enabled stages. Stages whose settings leave
+// them inert (auth without a token, a zero rate limit or timeout) are left out.
+// The auth stage locks callers out with cfg.Lockouts if set, and otherwise
+// with a Lockout of its own unless cfg.Lockout.Threshold is zero.
+// Stages implemented outside this package, such as metering, are taken from
+// provided; a listed stage that is not provided is left out if it is one of
+// DefaultStages and an error otherwise.
+func FromConfig(cfg Config, logger *log.Logger, clk clock.Clock, provided ...Stage) (*Chain, error) {
+	external := make(map[string]Middleware, len(provided))
+	for _, s := range provided {
+		external[s.Name] = s.Middleware
+	}
+
+	chain := NewChain()
+	seen := make(map[string]bool)
+	for _, name := range cfg.Stages {
+		if seen[name] {
+			return nil, fmt.Errorf("middleware stage %q listed twice", name)
+		}
+		seen[name] = true
+
+		var m Middleware
+		switch name {
+		case StageAuth:
+			if cfg.AuthToken != "" {
+				lockout := cfg.Lockouts
+				if lockout == nil && cfg.Lockout.Threshold > 0 {
+					lockout = NewLockout(cfg.Lockout, clk, logger, cfg.Security)
+				}
+				m = LockoutAuth(cfg.AuthToken, lockout, cfg.Security)
+			}
+		case StageRateLimit:
+			if cfg.RateLimit > 0 {
+				m = RateLimit(cfg.RateLimit, cfg.RateBurst, clk)
+			}
+		case StageLogging:
+			m = Logging(logger, clk, cfg.LogSampleRate)
+		case StageRecovery:
+			m = Recovery(logger)
+		case StageTimeout:
+			if cfg.Timeout > 0 {
+				m = Timeout(cfg.Timeout)
+			}
+		case StageMetering:
+			m = external[name]
+		default:
+			p, ok := external[name]
+			if !ok {
+				return nil, fmt.Errorf("unknown middleware stage %q: want one of %s", name, strings.Join(DefaultStages, ", "))
+			}
+			m = p
+		}
+		if m != nil {
+			chain.Append(Stage{Name: name, Middleware: m})
+		}
+	}
+	return chain, nil
+}
@@ -0,0 +1,330 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nehciyy/intrapay/internal/clock"
+	"github.com/nehciyy/intrapay/internal/impersonation"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/security"
+)
+
+var discard = log.New(io.Discard, "", 0)
+
+func ok() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func serve(h http.Handler, req *http.Request) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestChain_Order(t *testing.T) {
+	var order []string
+	stage := func(name string) Stage {
+		return Stage{Name: name, Middleware: func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, r)
+			})
+		}}
+	}
+
+	chain := NewChain(stage("a"), stage("b")).Append(stage("c"))
+	serve(chain.Then(ok()), httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, []string{"a", "b", "c"}, order)
+	assert.Equal(t, []string{"a", "b", "c"}, chain.Names())
+
+	order = nil
+	chain.Prepend(stage("z"))
+	serve(chain.Then(ok()), httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, []string{"z", "a", "b", "c"}, order)
+}
+
+func TestFromConfig(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+
+	chain, err := FromConfig(DefaultConfig(), discard, clk)
+	require.NoError(t, err)
+	assert.Equal(t, []string{StageLogging, StageRecovery, StageTimeout}, chain.Names(), "inert stages are skipped")
+
+	cfg := Config{Stages: []string{StageTimeout, StageAuth, StageRateLimit}, AuthToken: "secret", RateLimit: 1, Timeout: time.Second}
+	chain, err = FromConfig(cfg, discard, clk)
+	require.NoError(t, err)
+	assert.Equal(t, []string{StageTimeout, StageAuth, StageRateLimit}, chain.Names())
+
+	_, err = FromConfig(Config{Stages: []string{"cors"}}, discard, clk)
+	assert.Error(t, err)
+	metering := Stage{Name: StageMetering, Middleware: Recovery(discard)}
+	cors := Stage{Name: "cors", Middleware: Recovery(discard)}
+	chain, err = FromConfig(Config{Stages: []string{"cors", StageMetering, StageLogging}}, discard, clk, metering, cors)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cors", StageMetering, StageLogging}, chain.Names(), "provided stages are placed by name")
+	_, err = FromConfig(Config{Stages: []string{StageLogging, StageLogging}}, discard, clk)
+	assert.Error(t, err)
+
+	lockout := NewLockout(LockoutConfig{Threshold: 1, Duration: time.Minute, MaxDuration: time.Hour}, clk, discard, nil)
+	shared := Config{Stages: []string{StageAuth}, AuthToken: "secret", Lockouts: lockout}
+	first, err := FromConfig(shared, discard, clk)
+	require.NoError(t, err)
+	second, err := FromConfig(shared, discard, clk)
+	require.NoError(t, err)
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer wrong")
+	assert.Equal(t, http.StatusUnauthorized, serve(first.Then(ok()), req).Code)
+	assert.Equal(t, http.StatusTooManyRequests, serve(second.Then(ok()), req).Code, "chains given one Lockout share it")
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("MIDDLEWARE", "recovery, logging")
+	t.Setenv("RATE_LIMIT", "50")
+	t.Setenv("REQUEST_TIMEOUT", "5s")
+
+	cfg, err := ConfigFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, []string{StageRecovery, StageLogging}, cfg.Stages)
+	assert.Equal(t, 50.0, cfg.RateLimit)
+	assert.Equal(t, 5*time.Second, cfg.Timeout)
+
+	t.Setenv("RATE_LIMIT", "-1")
+	_, err = ConfigFromEnv()
+	assert.Error(t, err)
+
+	t.Setenv("RATE_LIMIT", "")
+	t.Setenv("LOG_SAMPLE_RATE", "0.1")
+	cfg, err = ConfigFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, 0.1, cfg.LogSampleRate)
+	t.Setenv("LOG_SAMPLE_RATE", "0")
+	_, err = ConfigFromEnv()
+	assert.Error(t, err)
+
+	t.Setenv("LOG_SAMPLE_RATE", "")
+	assert.Zero(t, DefaultConfig().Lockout.Threshold, "lockouts are opted into")
+	t.Setenv("AUTH_LOCKOUT_THRESHOLD", "5")
+	t.Setenv("AUTH_LOCKOUT_DURATION", "30s")
+	cfg, err = ConfigFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, LockoutConfig{Threshold: 5, Duration: 30 * time.Second, MaxDuration: time.Hour}, cfg.Lockout)
+	t.Setenv("AUTH_LOCKOUT_MAX_DURATION", "10s")
+	_, err = ConfigFromEnv()
+	assert.Error(t, err, "the longest lockout is shorter than the first")
+}
+
+func TestAuth(t *testing.T) {
+	h := Auth("secret")(ok())
+
+	req := httptest.NewRequest("GET", "/", nil)
+	assert.Equal(t, http.StatusUnauthorized, serve(h, req).Code)
+
+	req.Header.Set("Authorization", "Bearer wrong")
+	assert.Equal(t, http.StatusUnauthorized, serve(h, req).Code)
+
+	req.Header.Set("Authorization", "Bearer secret")
+	assert.Equal(t, http.StatusOK, serve(h, req).Code)
+
+	req = httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer admin-secret")
+	req = req.WithContext(impersonation.WithOperator(req.Context(), "alice"))
+	assert.Equal(t, http.StatusOK, serve(h, req).Code, "impersonated requests were authenticated by the impersonation stage")
+}
+
+func TestLockoutAuth(t *testing.T) {
+	var buf bytes.Buffer
+	clk := clock.NewFake(time.Date(2026, 3, 14, 9, 30, 0, 0, time.UTC))
+	lockout := NewLockout(LockoutConfig{Threshold: 3, Duration: time.Minute, MaxDuration: 3 * time.Minute}, clk, log.New(&buf, "", 0), nil)
+	h := LockoutAuth("secret", lockout, nil)(ok())
+
+	request := func(key, token string) *http.Request {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("X-API-Key", key)
+		req.Header.Set("Authorization", "Bearer "+token)
+		return req
+	}
+	fail := func(n int) {
+		t.Helper()
+		for range n {
+			require.Equal(t, http.StatusUnauthorized, serve(h, request("key-1", "wrong")).Code)
+		}
+	}
+
+	fail(2)
+	assert.Equal(t, http.StatusOK, serve(h, request("key-1", "secret")).Code)
+	fail(2)
+	assert.Equal(t, http.StatusOK, serve(h, request("key-1", "secret")).Code, "a success resets the count")
+
+	fail(3)
+	rr := serve(h, request("key-1", "wrong"))
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.Equal(t, "60", rr.Header().Get("Retry-After"))
+	assert.Contains(t, buf.String(), "security: auth lockout of address 192.0.2.1 for 1m0s")
+	assert.NotContains(t, buf.String(), "key-1", "the API key a failing request names is not trusted")
+	assert.Equal(t, http.StatusOK, serve(h, request("key-1", "secret")).Code, "the token is never refused")
+	assert.Equal(t, http.StatusTooManyRequests, serve(h, request("key-1", "wrong")).Code, "nor does it end the lockout")
+
+	other := request("key-1", "wrong")
+	other.RemoteAddr = "198.51.100.4:1234"
+	assert.Equal(t, http.StatusUnauthorized, serve(h, other).Code, "other addresses are not locked out")
+
+	clk.Advance(time.Minute)
+	fail(3)
+	assert.Equal(t, "120", serve(h, request("key-1", "wrong")).Header().Get("Retry-After"), "lockouts escalate")
+	clk.Advance(2 * time.Minute)
+	fail(3)
+	assert.Equal(t, "180", serve(h, request("key-1", "wrong")).Header().Get("Retry-After"), "up to the longest")
+
+	clk.Advance(6 * time.Minute)
+	fail(3)
+	assert.Equal(t, "60", serve(h, request("key-1", "wrong")).Header().Get("Retry-After"), "quiet callers start over")
+
+	clk.Advance(time.Minute)
+	fail(1)
+}
+
+type securityStore struct {
+	events []models.SecurityEvent
+}
+
+func (s *securityStore) InsertSecurityEvent(e models.SecurityEvent) (*models.SecurityEvent, error) {
+	s.events = append(s.events, e)
+	return &e, nil
+}
+
+func TestLockoutAuth_SecurityEvents(t *testing.T) {
+	store := &securityStore{}
+	events := security.NewStream(store, discard)
+	clk := clock.NewFake(time.Date(2026, 3, 14, 9, 30, 0, 0, time.UTC))
+	lockout := NewLockout(LockoutConfig{Threshold: 2, Duration: time.Minute, MaxDuration: time.Hour}, clk, discard, events)
+	h := LockoutAuth("secret", lockout, events)(ok())
+
+	for range 2 {
+		req := httptest.NewRequest("POST", "/transactions", nil)
+		req.Header.Set("X-API-Key", "key-1")
+		req.Header.Set("X-Request-ID", "req-1")
+		req.Header.Set("Authorization", "Bearer wrong")
+		serve(h, req)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	events.Run(ctx)
+
+	require.Len(t, store.events, 3)
+	assert.Equal(t, models.SecurityEvent{
+		Type:      models.SecurityAuthFailure,
+		Actor:     "key-1",
+		Address:   "192.0.2.1",
+		RequestID: "req-1",
+		Detail:    "POST /transactions",
+	}, store.events[0])
+	assert.Equal(t, models.SecurityAuthLockout, store.events[2].Type)
+	assert.Equal(t, "address 192.0.2.1", store.events[2].Actor)
+}
+
+func TestRateLimit(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	h := RateLimit(2, 2, clk)(ok())
+	req := httptest.NewRequest("GET", "/", nil)
+
+	assert.Equal(t, http.StatusOK, serve(h, req).Code)
+	assert.Equal(t, http.StatusOK, serve(h, req).Code)
+	rr := serve(h, req)
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.Equal(t, "1", rr.Header().Get("Retry-After"))
+
+	clk.Advance(500 * time.Millisecond)
+	assert.Equal(t, http.StatusOK, serve(h, req).Code, "refilled one token")
+	assert.Equal(t, http.StatusTooManyRequests, serve(h, req).Code)
+}
+
+func TestAuthenticator(t *testing.T) {
+	a := NewAuthenticator("secret")
+	assert.True(t, a.Check("Bearer secret"))
+	assert.False(t, a.Check("bearer secret"))
+	assert.False(t, a.Check("secret"))
+	assert.False(t, a.Check(""))
+}
+
+func TestLimiter(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	l := NewLimiter(4, 0, clk)
+	assert.Zero(t, l.Take(), "a burst below one admits one request")
+	assert.Equal(t, 250*time.Millisecond, l.Take())
+	clk.Advance(250 * time.Millisecond)
+	assert.Zero(t, l.Take())
+}
+
+func TestLogging(t *testing.T) {
+	var buf bytes.Buffer
+	clk := clock.NewFake(time.Date(2026, 3, 14, 9, 30, 0, 0, time.UTC))
+	h := Logging(log.New(&buf, "", 0), clk, 1)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clk.Advance(1500 * time.Microsecond)
+		SetOutcome(r, "insufficient_funds")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		io.WriteString(w, `{"error":"insufficient balance"}`)
+	}))
+
+	req := httptest.NewRequest("POST", "/transactions?fields=id", nil)
+	req.Header.Set("X-API-Key", "key-1")
+	req.Header.Set("X-Tenant-ID", "acme")
+	req.Header.Set(IdempotencyKeyHeader, "8c1f")
+	serve(h, req)
+	assert.Equal(t, `access: {"time":"2026-03-14T09:30:00Z","method":"POST","path":"/transactions?fields=id","status":422,"outcome":"insufficient_funds","duration_ms":1.5,"bytes":32,"subject":"key-1","tenant":"acme","idempotency_key":"8c1f"}`+"\n", buf.String())
+}
+
+func TestLogging_Sampling(t *testing.T) {
+	var buf bytes.Buffer
+	status := http.StatusOK
+	h := Logging(log.New(&buf, "", 0), clock.NewFake(time.Now()), 0.25)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+
+	for i := 0; i < 8; i++ {
+		serve(h, httptest.NewRequest("GET", "/accounts/1", nil))
+	}
+	assert.Equal(t, 2, strings.Count(buf.String(), "\n"), "one in four successful reads is logged")
+	assert.Contains(t, buf.String(), `"sample_rate":0.25`)
+
+	buf.Reset()
+	serve(h, httptest.NewRequest("POST", "/transactions", nil))
+	status = http.StatusNotFound
+	serve(h, httptest.NewRequest("GET", "/accounts/2", nil))
+	serve(h, httptest.NewRequest("GET", "/accounts/3", nil))
+	assert.Equal(t, 3, strings.Count(buf.String(), "\n"), "writes and errors are always logged")
+	assert.Contains(t, buf.String(), `"outcome":"client_error"`)
+	assert.NotContains(t, buf.String(), "sample_rate")
+}
+
+func TestRecovery(t *testing.T) {
+	var buf bytes.Buffer
+	h := Recovery(log.New(&buf, "", 0))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	rr := serve(h, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.True(t, strings.HasPrefix(buf.String(), "panic serving GET /: boom"))
+}
+
+func TestTimeout(t *testing.T) {
+	h := Timeout(10 * time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+
+	rr := serve(h, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+}
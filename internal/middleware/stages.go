@@ -0,0 +1,291 @@
+package middleware
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"math"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/clock"
+	"github.com/nehciyy/intrapay/internal/impersonation"
+	"github.com/nehciyy/intrapay/internal/metering"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/security"
+)
+
+// Authenticator checks the credentials a client presents, whatever carries
+// them: the Auth stage takes them from the Authorization header, and any other
+// transport of the API must check them here too so the two cannot disagree.
+type Authenticator struct {
+	token []byte
+}
+
+// NewAuthenticator creates an Authenticator accepting the bearer token token.
+func NewAuthenticator(token string) *Authenticator {
+	return &Authenticator{token: []byte(token)}
+}
+
+// Check reports whether authorization, a value of the form "Bearer <token>",
+// carries the token.
+func (a *Authenticator) Check(authorization string) bool {
+	got, ok := strings.CutPrefix(authorization, "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(got), a.token) == 1
+}
+
+// Auth rejects requests that do not carry "Authorization: Bearer <token>" with
+// 401. Impersonated requests carry the admin token instead, which the stage
+// that marked them checked.
+func Auth(token string) Middleware {
+	return LockoutAuth(token, nil, nil)
+}
+
+// LockoutAuth is Auth counting failures against lockout, when not nil, by the
+// address of the caller: until its lockout ends, the requests of a caller
+// locked out that fail are answered with 429 and a Retry-After header rather
+// than 401. A request with the token is never refused, so neither a caller
+// sharing the address nor the lockout itself keeps it out. Each failure is
+// emitted to events.
+func LockoutAuth(token string, lockout *Lockout, events *security.Stream) Middleware {
+	a := NewAuthenticator(token)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, ok := impersonation.Operator(r.Context()); ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+			var caller string
+			var wait time.Duration
+			if lockout != nil {
+				caller = lockoutCaller(r)
+				wait = lockout.Locked(caller)
+			}
+			if a.Check(r.Header.Get("Authorization")) {
+				// A success while locked out leaves the lockout be, so that
+				// another caller behind the same address cannot end it.
+				if lockout != nil && wait == 0 {
+					lockout.Succeed(caller)
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+			if wait > 0 {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				http.Error(w, "too many failed authentication attempts", http.StatusTooManyRequests)
+				return
+			}
+			events.Emit(models.SecurityEvent{
+				Type:      models.SecurityAuthFailure,
+				Actor:     r.Header.Get(metering.APIKeyHeader),
+				Address:   ClientAddress(r),
+				RequestID: r.Header.Get("X-Request-ID"),
+				Detail:    r.Method + " " + r.URL.Path,
+			})
+			if lockout != nil {
+				lockout.Fail(caller)
+			}
+			w.Header().Set("WWW-Authenticate", `Bearer realm="intrapay"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+		})
+	}
+}
+
+// RateLimit admits rate requests per second on average, with bursts of up to
+// burst requests, and answers the rest with 429 and a Retry-After header.
+func RateLimit(rate float64, burst int, clk clock.Clock) Middleware {
+	l := NewLimiter(rate, burst, clk)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if wait := l.Take(); wait > 0 {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Limiter is the token bucket of the RateLimit stage, refilled at rate tokens
+// per second up to its capacity. Transports sharing the server's rate limit
+// share a Limiter.
+type Limiter struct {
+	rate     float64
+	capacity float64
+	clock    clock.Clock
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewLimiter creates a full Limiter admitting rate requests per second with
+// bursts of up to burst requests.
+func NewLimiter(rate float64, burst int, clk clock.Clock) *Limiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &Limiter{rate: rate, capacity: float64(burst), tokens: float64(burst), clock: clk, last: clk.Now()}
+}
+
+// Take consumes a token, or returns how long until one is available.
+func (l *Limiter) Take() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	l.tokens = math.Min(l.capacity, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0
+	}
+	return time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+}
+
+// statusRecorder captures the status code and body size written by a handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += int64(n)
+	return n, err
+}
+
+// IdempotencyKeyHeader is the request header carrying a client's idempotency key.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// accessEntry is a line of the access log.
+type accessEntry struct {
+	Time           time.Time `json:"time"`
+	Method         string    `json:"method"`
+	Path           string    `json:"path"`
+	Status         int       `json:"status"`
+	Outcome        string    `json:"outcome"`
+	DurationMs     float64   `json:"duration_ms"`
+	Bytes          int64     `json:"bytes"`
+	Subject        string    `json:"subject"`
+	Tenant         string    `json:"tenant"`
+	IdempotencyKey string    `json:"idempotency_key,omitempty"`
+	RequestID      string    `json:"request_id,omitempty"`
+	// Impersonator is the operator who made the request as Subject.
+	Impersonator string `json:"impersonator,omitempty"`
+	// SampleRate is set on sampled lines: each stands for 1/SampleRate requests.
+	SampleRate float64 `json:"sample_rate,omitempty"`
+}
+
+type outcomeKey struct{}
+
+// SetOutcome records the outcome code of r, such as the reason a transfer was
+// rejected, for its access log line. It does nothing when r is not logged.
+func SetOutcome(r *http.Request, code string) {
+	if o, ok := r.Context().Value(outcomeKey{}).(*atomic.Pointer[string]); ok {
+		o.Store(&code)
+	}
+}
+
+// Logging writes one JSON access log line per request. Successful reads (GET,
+// HEAD and OPTIONS answered below 400) are sampled at sampleRate, rounded to
+// one in n, so busy polling does not drown the log; writes and errors are
+// always logged. A sampleRate of zero or above one logs every request.
+func Logging(logger *log.Logger, clk clock.Clock, sampleRate float64) Middleware {
+	every := int64(1)
+	if sampleRate > 0 && sampleRate < 1 {
+		every = int64(math.Round(1 / sampleRate))
+	}
+	var reads atomic.Int64
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := clk.Now()
+			outcome := new(atomic.Pointer[string])
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r.WithContext(context.WithValue(r.Context(), outcomeKey{}, outcome)))
+
+			entry := accessEntry{
+				Time:           start.UTC(),
+				Method:         r.Method,
+				Path:           r.URL.RequestURI(),
+				Status:         rec.status,
+				Outcome:        statusOutcome(rec.status),
+				DurationMs:     float64(clk.Now().Sub(start).Microseconds()) / 1000,
+				Bytes:          rec.bytes,
+				IdempotencyKey: r.Header.Get(IdempotencyKeyHeader),
+				RequestID:      r.Header.Get("X-Request-ID"),
+			}
+			entry.Subject, entry.Tenant = metering.Caller(r)
+			entry.Impersonator, _ = impersonation.Operator(r.Context())
+			if code := outcome.Load(); code != nil {
+				entry.Outcome = *code
+			}
+			if every > 1 && isRead(r.Method) && rec.status < http.StatusBadRequest {
+				if reads.Add(1)%every != 1 {
+					return
+				}
+				entry.SampleRate = 1 / float64(every)
+			}
+			line, err := json.Marshal(entry)
+			if err != nil {
+				logger.Printf("access: %v", err)
+				return
+			}
+			logger.Printf("access: %s", line)
+		})
+	}
+}
+
+// statusOutcome is the outcome of a response no handler named one for.
+func statusOutcome(status int) string {
+	switch {
+	case status >= http.StatusInternalServerError:
+		return "server_error"
+	case status >= http.StatusBadRequest:
+		return "client_error"
+	}
+	return "ok"
+}
+
+func isRead(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// Recovery turns a panicking handler into a 500 response and logs the stack.
+func Recovery(logger *log.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				if p := recover(); p != nil {
+					if p == http.ErrAbortHandler {
+						panic(p)
+					}
+					logger.Printf("panic serving %s %s: %v\n%s", r.Method, r.URL.Path, p, debug.Stack())
+					http.Error(w, "internal server error", http.StatusInternalServerError)
+				}
+			}()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Timeout answers 503 when a handler runs longer than d. The handler's request
+// context is cancelled at the deadline.
+func Timeout(d time.Duration) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.TimeoutHandler(next, d, "request timed out")
+	}
+}
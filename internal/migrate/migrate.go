@@ -0,0 +1,179 @@
+// Package migrate applies the numbered SQL migrations to a database, records
+// which it has applied in the schema_migrations table, and compares the live
+// schema with the one the migrations leave behind.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrUntracked is returned by Pending for a database whose tables were created
+// without recording the migrations that created them.
+var ErrUntracked = errors.New("migrate: the database has tables but no record of the migrations applied")
+
+// Migration is one numbered SQL file, such as 041_schema_migrations.sql.
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+// Load reads the migrations in fsys, ordered by version.
+func Load(fsys fs.FS) ([]Migration, error) {
+	names, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+	migrations := make([]Migration, 0, len(names))
+	seen := make(map[int]string)
+	for _, name := range names {
+		prefix, _, ok := strings.Cut(name, "_")
+		version, err := strconv.Atoi(prefix)
+		if !ok || err != nil || version <= 0 {
+			return nil, fmt.Errorf("migrate: %s is not named NNN_name.sql", name)
+		}
+		if other, ok := seen[version]; ok {
+			return nil, fmt.Errorf("migrate: %s and %s have the same version", other, name)
+		}
+		seen[version] = name
+		b, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, Migration{Version: version, Name: name, SQL: string(b)})
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+const createTable = `CREATE TABLE IF NOT EXISTS schema_migrations (
+  version INTEGER PRIMARY KEY,
+  applied_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+)`
+
+const recordVersion = `INSERT INTO schema_migrations (version) VALUES ($1) ON CONFLICT DO NOTHING`
+
+// Applied returns the versions recorded as applied. tracked is false when the
+// database has no schema_migrations table yet.
+func Applied(ctx context.Context, db *sql.DB) (versions map[int]bool, tracked bool, err error) {
+	if err := db.QueryRowContext(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&tracked); err != nil {
+		return nil, false, err
+	}
+	versions = make(map[int]bool)
+	if !tracked {
+		return versions, false, nil
+	}
+	rows, err := db.QueryContext(ctx, `SELECT version FROM schema_migrations`)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var v int
+		if err := rows.Scan(&v); err != nil {
+			return nil, false, err
+		}
+		versions[v] = true
+	}
+	return versions, true, rows.Err()
+}
+
+// Pending returns the migrations not yet applied to db, in order. A database
+// with tables but no schema_migrations table was set up before migrations were
+// recorded; Pending returns ErrUntracked for it rather than every migration,
+// and Baseline records what it has.
+func Pending(ctx context.Context, db *sql.DB, migrations []Migration) ([]Migration, error) {
+	applied, tracked, err := Applied(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	if !tracked {
+		var tables int
+		if err := db.QueryRowContext(ctx, `SELECT count(*) FROM information_schema.tables WHERE table_schema = current_schema()`).Scan(&tables); err != nil {
+			return nil, err
+		}
+		if tables > 0 {
+			return nil, ErrUntracked
+		}
+	}
+	var pending []Migration
+	for _, m := range migrations {
+		if !applied[m.Version] {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// Apply runs each migration in its own transaction, recording it as applied in
+// the same transaction, and stops at the first that fails.
+func Apply(ctx context.Context, db *sql.DB, migrations []Migration) error {
+	if _, err := db.ExecContext(ctx, createTable); err != nil {
+		return err
+	}
+	for _, m := range migrations {
+		if err := apply(ctx, db, m); err != nil {
+			return fmt.Errorf("migrate: %s: %w", m.Name, err)
+		}
+	}
+	return nil
+}
+
+func apply(ctx context.Context, db *sql.DB, m Migration) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, m.SQL); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, recordVersion, m.Version); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Baseline records the migrations up to and including version as applied
+// without running them, for a database whose schema already has them.
+func Baseline(ctx context.Context, db *sql.DB, migrations []Migration, version int) error {
+	if _, err := db.ExecContext(ctx, createTable); err != nil {
+		return err
+	}
+	for _, m := range migrations {
+		if m.Version > version {
+			break
+		}
+		if _, err := db.ExecContext(ctx, recordVersion, m.Version); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Check compares the live schema of db, tables, columns and indexes, with the
+// one migrations leave behind.
+func Check(ctx context.Context, db *sql.DB, migrations []Migration) (Diff, error) {
+	expected, indexes, err := expected(migrations)
+	if err != nil {
+		return Diff{}, err
+	}
+	live, err := Live(ctx, db)
+	if err != nil {
+		return Diff{}, err
+	}
+	liveIndexes, err := LiveIndexes(ctx, db)
+	if err != nil {
+		return Diff{}, err
+	}
+	d := Compare(expected, live)
+	d.CompareIndexes(indexes, liveIndexes)
+	return d, nil
+}
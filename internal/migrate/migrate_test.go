@@ -0,0 +1,224 @@
+package migrate
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nehciyy/intrapay/migrations"
+)
+
+func TestLoad(t *testing.T) {
+	all, err := Load(migrations.FS)
+	require.NoError(t, err)
+	require.NotEmpty(t, all)
+	for i, m := range all {
+		assert.Equal(t, i+1, m.Version, m.Name)
+	}
+
+	_, err = Load(fstest.MapFS{"init.sql": {}})
+	assert.Error(t, err)
+	_, err = Load(fstest.MapFS{"001_a.sql": {}, "001_b.sql": {}})
+	assert.Error(t, err)
+}
+
+func TestExpected_Migrations(t *testing.T) {
+	all, err := Load(migrations.FS)
+	require.NoError(t, err)
+	s, err := Expected(all)
+	require.NoError(t, err)
+
+	assert.Equal(t, "int4", s["transactions"]["id"])
+	assert.Equal(t, "timestamptz", s["transactions"]["created_at"])
+	assert.Equal(t, "_text", s["transactions"]["tags"])
+	assert.Equal(t, "text", s["accounts"]["tenant"])
+	assert.Equal(t, "bool", s["accounts"]["livemode"])
+	assert.Equal(t, "numeric", s["accounts"]["balance"])
+	assert.Equal(t, "jsonb", s["outbox_events"]["payload"])
+	assert.Contains(t, s, "schema_migrations")
+}
+
+func TestExpected_Statements(t *testing.T) {
+	s, err := Expected([]Migration{
+		{Name: "001_a.sql", SQL: `
+-- a comment; with a semicolon
+CREATE TABLE IF NOT EXISTS a (
+  id BIGSERIAL PRIMARY KEY,
+  amount NUMERIC(20, 2) NOT NULL CHECK (amount > 0),
+  at TIMESTAMP WITH TIME ZONE,
+  ratio DOUBLE PRECISION,
+  ids BIGINT[] DEFAULT '{}',
+  note TEXT DEFAULT 'a;b',
+  CONSTRAINT a_amount CHECK (amount < 100),
+  UNIQUE (note)
+);
+CREATE TABLE b (id INTEGER);
+CREATE FUNCTION f() RETURNS trigger AS $$ BEGIN; ALTER TABLE a DROP COLUMN id; END $$ LANGUAGE plpgsql;`},
+		{Name: "002_b.sql", SQL: `
+ALTER TABLE a ADD COLUMN IF NOT EXISTS tenant TEXT, DROP COLUMN ratio, ADD CONSTRAINT c UNIQUE (tenant);
+ALTER TABLE a ALTER COLUMN at TYPE TIMESTAMP;
+ALTER TABLE a RENAME COLUMN note TO memo;
+ALTER TABLE b RENAME TO c;
+DROP TABLE IF EXISTS c;
+CREATE INDEX a_tenant ON a (tenant);`},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, Schema{"a": {
+		"id":     "int8",
+		"amount": "numeric",
+		"at":     "timestamp",
+		"ids":    "_int8",
+		"memo":   "text",
+		"tenant": "text",
+	}}, s)
+}
+
+func TestExpectedIndexes(t *testing.T) {
+	ix, err := ExpectedIndexes([]Migration{
+		{Name: "001_a.sql", SQL: `
+CREATE TABLE a (id BIGINT);
+CREATE TABLE b (id BIGINT);
+CREATE UNIQUE INDEX a_id_idx ON a (id) WHERE id > 0;
+CREATE INDEX CONCURRENTLY IF NOT EXISTS b_id_idx ON ONLY b USING GIN (id);
+CREATE INDEX ON a (id);
+CREATE INDEX old_idx ON a (id);`},
+		{Name: "002_b.sql", SQL: `
+ALTER INDEX old_idx RENAME TO new_idx;
+ALTER TABLE a RENAME TO c;
+DROP TABLE b;`},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, Indexes{"a_id_idx": "c", "new_idx": "c"}, ix)
+
+	all, err := Load(migrations.FS)
+	require.NoError(t, err)
+	ix, err = ExpectedIndexes(all)
+	require.NoError(t, err)
+	assert.Equal(t, "transactions", ix["transactions_tags_idx"])
+	assert.Equal(t, "pending_actions", ix["pending_actions_open_ref_idx"])
+	assert.Equal(t, "accounts", ix["accounts_largest_idx"])
+}
+
+func TestCompare(t *testing.T) {
+	expected := Schema{
+		"accounts": {"account_id": "int8", "tenant": "text", "created_at": "timestamptz"},
+		"webhooks": {"id": "int8"},
+	}
+
+	d := Compare(expected, Schema{
+		"accounts": {"account_id": "int8", "tenant": "text", "created_at": "timestamptz"},
+		"webhooks": {"id": "int8"},
+	})
+	assert.False(t, d.Behind())
+	assert.Equal(t, "schema matches the migrations\n", d.String())
+
+	d = Compare(expected, Schema{
+		"accounts": {"account_id": "int8", "created_at": "timestamp", "legacy": "text"},
+		"old":      {"id": "int4"},
+	})
+	assert.True(t, d.Behind())
+	assert.Equal(t, []string{"column accounts.tenant", "table webhooks"}, d.Missing)
+	assert.Equal(t, []string{"column accounts.created_at is timestamp, expected timestamptz"}, d.Mismatched)
+	assert.Equal(t, []string{"column accounts.legacy", "table old"}, d.Extra)
+	assert.Equal(t, "- column accounts.tenant\n- table webhooks\n~ column accounts.created_at is timestamp, expected timestamptz\n+ column accounts.legacy\n+ table old\n", d.String())
+
+	// Tables and columns added by a newer release are not behind.
+	d = Compare(expected, Schema{
+		"accounts": {"account_id": "int8", "tenant": "text", "created_at": "timestamptz", "new": "text"},
+		"webhooks": {"id": "int8"},
+	})
+	assert.False(t, d.Behind())
+}
+
+func TestCheck(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery("FROM information_schema.columns").
+		WillReturnRows(sqlmock.NewRows([]string{"table_name", "column_name", "udt_name"}).
+			AddRow("a", "id", "int8"))
+	mock.ExpectQuery("FROM pg_indexes").
+		WillReturnRows(sqlmock.NewRows([]string{"indexname", "tablename"}).
+			AddRow("a_pkey", "a").
+			AddRow("a_id_idx", "a"))
+
+	d, err := Check(context.Background(), db, []Migration{
+		{Version: 1, Name: "001_a.sql", SQL: "CREATE TABLE a (id BIGINT PRIMARY KEY, tenant TEXT); CREATE INDEX a_id_idx ON a (id); CREATE INDEX a_tenant_idx ON a (tenant);"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"column a.tenant"}, d.Missing)
+	assert.Equal(t, []string{"index a_tenant_idx on a"}, d.MissingIndexes)
+	assert.Equal(t, "- column a.tenant\n- index a_tenant_idx on a\n", d.String())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPending(t *testing.T) {
+	all := []Migration{{Version: 1, Name: "001_a.sql"}, {Version: 2, Name: "002_b.sql"}}
+
+	t.Run("Tracked", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		mock.ExpectQuery("to_regclass").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		mock.ExpectQuery("SELECT version FROM schema_migrations").
+			WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+
+		pending, err := Pending(context.Background(), db, all)
+		require.NoError(t, err)
+		assert.Equal(t, all[1:], pending)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Empty Database", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		mock.ExpectQuery("to_regclass").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+		mock.ExpectQuery("FROM information_schema.tables").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+		pending, err := Pending(context.Background(), db, all)
+		require.NoError(t, err)
+		assert.Equal(t, all, pending)
+	})
+
+	t.Run("Untracked", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		mock.ExpectQuery("to_regclass").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+		mock.ExpectQuery("FROM information_schema.tables").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(12))
+
+		_, err = Pending(context.Background(), db, all)
+		assert.ErrorIs(t, err, ErrUntracked)
+	})
+}
+
+func TestApply(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectBegin()
+	mock.ExpectExec("CREATE TABLE a").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO schema_migrations").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec("ALTER TABLE a").WillReturnError(assert.AnError)
+	mock.ExpectRollback()
+
+	err = Apply(context.Background(), db, []Migration{
+		{Version: 1, Name: "001_a.sql", SQL: "CREATE TABLE a (id BIGINT);"},
+		{Version: 2, Name: "002_b.sql", SQL: "ALTER TABLE a ADD COLUMN b TEXT;"},
+		{Version: 3, Name: "003_c.sql", SQL: "ALTER TABLE a ADD COLUMN c TEXT;"},
+	})
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.ErrorContains(t, err, "002_b.sql")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
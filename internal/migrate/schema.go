@@ -0,0 +1,577 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Schema is the columns of each table, by table name, with the name of the
+// column's type as Postgres reports it (information_schema udt_name): int8,
+// timestamptz, _text for TEXT[], and so on.
+type Schema map[string]map[string]string
+
+// Indexes is the table of each named index, by index name.
+type Indexes map[string]string
+
+// Expected returns the schema migrations leave behind, read from their CREATE
+// TABLE, ALTER TABLE and DROP TABLE statements. Everything else, such as
+// indexes, triggers and policies, is left out.
+func Expected(migrations []Migration) (Schema, error) {
+	s, _, err := expected(migrations)
+	return s, err
+}
+
+// ExpectedIndexes returns the indexes migrations create by name with CREATE
+// INDEX and have not dropped since. Those behind primary keys and unique
+// constraints are left out.
+func ExpectedIndexes(migrations []Migration) (Indexes, error) {
+	_, ix, err := expected(migrations)
+	return ix, err
+}
+
+func expected(migrations []Migration) (Schema, Indexes, error) {
+	s, ix := make(Schema), make(Indexes)
+	for _, m := range migrations {
+		for _, stmt := range splitStatements(m.SQL) {
+			tokens := tokenize(stmt)
+			if err := s.apply(tokens); err != nil {
+				return nil, nil, fmt.Errorf("%s: %w", m.Name, err)
+			}
+			ix.apply(tokens)
+		}
+	}
+	return s, ix, nil
+}
+
+// liveColumns lists the columns of the tables of the current schema.
+const liveColumns = `SELECT c.table_name, c.column_name, c.udt_name
+FROM information_schema.columns c
+JOIN information_schema.tables t ON t.table_schema = c.table_schema AND t.table_name = c.table_name
+WHERE c.table_schema = current_schema() AND t.table_type = 'BASE TABLE'`
+
+// Live reads the schema of the database db is connected to.
+func Live(ctx context.Context, db *sql.DB) (Schema, error) {
+	rows, err := db.QueryContext(ctx, liveColumns)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	s := make(Schema)
+	for rows.Next() {
+		var table, column, typ string
+		if err := rows.Scan(&table, &column, &typ); err != nil {
+			return nil, err
+		}
+		s.addColumn(table, column, typ)
+	}
+	return s, rows.Err()
+}
+
+// LiveIndexes reads the indexes of the database db is connected to.
+func LiveIndexes(ctx context.Context, db *sql.DB) (Indexes, error) {
+	rows, err := db.QueryContext(ctx, `SELECT indexname, tablename FROM pg_indexes WHERE schemaname = current_schema()`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ix := make(Indexes)
+	for rows.Next() {
+		var name, table string
+		if err := rows.Scan(&name, &table); err != nil {
+			return nil, err
+		}
+		ix[name] = table
+	}
+	return ix, rows.Err()
+}
+
+// Diff is how a live schema differs from the expected one.
+type Diff struct {
+	// Missing are the tables and columns the live schema lacks.
+	Missing []string
+	// Mismatched are the columns whose type is not the expected one.
+	Mismatched []string
+	// Extra are the tables and columns no migration knows of, such as those of
+	// a newer release's migrations.
+	Extra []string
+	// MissingIndexes are the indexes the live schema lacks, or has on another
+	// table. Queries still work without them, only slower.
+	MissingIndexes []string
+}
+
+// Compare returns how live differs from expected.
+func Compare(expected, live Schema) Diff {
+	var d Diff
+	for _, table := range sortedKeys(expected) {
+		liveColumns, ok := live[table]
+		if !ok {
+			d.Missing = append(d.Missing, "table "+table)
+			continue
+		}
+		for _, column := range sortedKeys(expected[table]) {
+			typ := expected[table][column]
+			liveType, ok := liveColumns[column]
+			switch {
+			case !ok:
+				d.Missing = append(d.Missing, "column "+table+"."+column)
+			case liveType != typ:
+				d.Mismatched = append(d.Mismatched, fmt.Sprintf("column %s.%s is %s, expected %s", table, column, liveType, typ))
+			}
+		}
+		for _, column := range sortedKeys(liveColumns) {
+			if _, ok := expected[table][column]; !ok {
+				d.Extra = append(d.Extra, "column "+table+"."+column)
+			}
+		}
+	}
+	for _, table := range sortedKeys(live) {
+		if _, ok := expected[table]; !ok {
+			d.Extra = append(d.Extra, "table "+table)
+		}
+	}
+	return d
+}
+
+// CompareIndexes sets d.MissingIndexes to the indexes of expected that live
+// lacks, or has on another table. Indexes no migration knows of are not extra:
+// Postgres creates one for every primary key and unique constraint.
+func (d *Diff) CompareIndexes(expected, live Indexes) {
+	d.MissingIndexes = nil
+	for _, name := range sortedKeys(expected) {
+		if live[name] != expected[name] {
+			d.MissingIndexes = append(d.MissingIndexes, "index "+name+" on "+expected[name])
+		}
+	}
+}
+
+// Behind reports whether the live schema lacks a table or column the
+// migrations create, which the server's queries may fail on. Extra tables and
+// columns are not behind: a newer release may have added them. Neither are
+// missing indexes, which only slow queries down.
+func (d Diff) Behind() bool {
+	return len(d.Missing) > 0 || len(d.Mismatched) > 0
+}
+
+// String renders d one difference per line: "-" for missing, "~" for
+// mismatched and "+" for extra.
+func (d Diff) String() string {
+	if len(d.Missing)+len(d.Mismatched)+len(d.Extra)+len(d.MissingIndexes) == 0 {
+		return "schema matches the migrations\n"
+	}
+	var b strings.Builder
+	for _, lines := range []struct {
+		prefix string
+		items  []string
+	}{{"-", d.Missing}, {"-", d.MissingIndexes}, {"~", d.Mismatched}, {"+", d.Extra}} {
+		for _, item := range lines.items {
+			fmt.Fprintf(&b, "%s %s\n", lines.prefix, item)
+		}
+	}
+	return b.String()
+}
+
+func (s Schema) addColumn(table, column, typ string) {
+	if s[table] == nil {
+		s[table] = make(map[string]string)
+	}
+	s[table][column] = typ
+}
+
+// apply applies the statement of tokens to s.
+func (s Schema) apply(tokens []string) error {
+	switch {
+	case hasPrefix(tokens, "CREATE", "TABLE"):
+		return s.createTable(skipIf(tokens[2:], "IF", "NOT", "EXISTS"))
+	case hasPrefix(tokens, "ALTER", "TABLE"):
+		rest := skipIf(skipIf(tokens[2:], "IF", "EXISTS"), "ONLY")
+		if len(rest) == 0 {
+			return fmt.Errorf("ALTER TABLE without a table")
+		}
+		table := ident(rest[0])
+		for _, action := range splitTopLevel(rest[1:]) {
+			if err := s.alterTable(table, action); err != nil {
+				return err
+			}
+		}
+	case hasPrefix(tokens, "DROP", "TABLE"):
+		for _, name := range splitTopLevel(skipIf(tokens[2:], "IF", "EXISTS")) {
+			if len(name) > 0 {
+				delete(s, ident(name[0]))
+			}
+		}
+	}
+	return nil
+}
+
+// apply applies the statement of tokens to ix.
+func (ix Indexes) apply(tokens []string) {
+	switch {
+	case hasPrefix(tokens, "CREATE", "INDEX") || hasPrefix(tokens, "CREATE", "UNIQUE", "INDEX"):
+		rest := skipIf(tokens[1:], "UNIQUE")[1:]
+		rest = skipIf(skipIf(rest, "CONCURRENTLY"), "IF", "NOT", "EXISTS")
+		if len(rest) < 3 || !strings.EqualFold(rest[1], "ON") {
+			return // unnamed
+		}
+		ix[ident(rest[0])] = ident(skipIf(rest[2:], "ONLY")[0])
+	case hasPrefix(tokens, "DROP", "INDEX"):
+		rest := skipIf(skipIf(tokens[2:], "CONCURRENTLY"), "IF", "EXISTS")
+		for _, name := range splitTopLevel(rest) {
+			if len(name) > 0 {
+				delete(ix, ident(name[0]))
+			}
+		}
+	case hasPrefix(tokens, "ALTER", "INDEX"):
+		rest := skipIf(tokens[2:], "IF", "EXISTS")
+		if len(rest) == 4 && hasPrefix(rest[1:], "RENAME", "TO") {
+			if table, ok := ix[ident(rest[0])]; ok {
+				delete(ix, ident(rest[0]))
+				ix[ident(rest[3])] = table
+			}
+		}
+	case hasPrefix(tokens, "ALTER", "TABLE"):
+		rest := skipIf(skipIf(tokens[2:], "IF", "EXISTS"), "ONLY")
+		if len(rest) == 4 && hasPrefix(rest[1:], "RENAME", "TO") {
+			ix.moveTable(ident(rest[0]), ident(rest[3]))
+		}
+	case hasPrefix(tokens, "DROP", "TABLE"):
+		for _, name := range splitTopLevel(skipIf(tokens[2:], "IF", "EXISTS")) {
+			if len(name) > 0 {
+				ix.moveTable(ident(name[0]), "")
+			}
+		}
+	}
+}
+
+// moveTable moves the indexes of table to renamed, or drops them if renamed is
+// empty.
+func (ix Indexes) moveTable(table, renamed string) {
+	for name, t := range ix {
+		switch {
+		case t != table:
+		case renamed == "":
+			delete(ix, name)
+		default:
+			ix[name] = renamed
+		}
+	}
+}
+
+func (s Schema) createTable(tokens []string) error {
+	if len(tokens) < 2 || tokens[1] != "(" {
+		return fmt.Errorf("CREATE TABLE without columns")
+	}
+	table := ident(tokens[0])
+	body, _ := parenthesized(tokens[1:])
+	s[table] = make(map[string]string)
+	for _, item := range splitTopLevel(body) {
+		if len(item) < 2 || isConstraint(item[0]) {
+			continue
+		}
+		s.addColumn(table, ident(item[0]), columnType(item[1:]))
+	}
+	return nil
+}
+
+func (s Schema) alterTable(table string, action []string) error {
+	if len(action) == 0 {
+		return nil
+	}
+	columns, ok := s[table]
+	if !ok {
+		return fmt.Errorf("ALTER TABLE of unknown table %s", table)
+	}
+	switch strings.ToUpper(action[0]) {
+	case "ADD":
+		rest := action[1:]
+		if len(rest) > 0 && isConstraint(rest[0]) {
+			return nil
+		}
+		rest = skipIf(skipIf(rest, "COLUMN"), "IF", "NOT", "EXISTS")
+		if len(rest) < 2 {
+			return fmt.Errorf("ADD COLUMN without a type on %s", table)
+		}
+		columns[ident(rest[0])] = columnType(rest[1:])
+	case "DROP":
+		rest := action[1:]
+		if len(rest) > 0 && isConstraint(rest[0]) {
+			return nil
+		}
+		rest = skipIf(skipIf(rest, "COLUMN"), "IF", "EXISTS")
+		if len(rest) > 0 {
+			delete(columns, ident(rest[0]))
+		}
+	case "ALTER":
+		rest := skipIf(action[1:], "COLUMN")
+		if len(rest) < 2 {
+			return nil
+		}
+		column := ident(rest[0])
+		typ := skipIf(skipIf(rest[1:], "SET", "DATA"), "TYPE")
+		if len(typ) < len(rest[1:]) && len(typ) > 0 {
+			columns[column] = columnType(typ)
+		}
+	case "RENAME":
+		rest := action[1:]
+		if len(rest) == 2 && strings.EqualFold(rest[0], "TO") {
+			delete(s, table)
+			s[ident(rest[1])] = columns
+			return nil
+		}
+		rest = skipIf(rest, "COLUMN")
+		if len(rest) == 3 && strings.EqualFold(rest[1], "TO") {
+			columns[ident(rest[2])] = columns[ident(rest[0])]
+			delete(columns, ident(rest[0]))
+		}
+	}
+	return nil
+}
+
+// typeNames maps SQL type names to the names Postgres reports.
+var typeNames = map[string]string{
+	"SMALLINT": "int2", "INT2": "int2", "SMALLSERIAL": "int2",
+	"INT": "int4", "INTEGER": "int4", "INT4": "int4", "SERIAL": "int4",
+	"BIGINT": "int8", "INT8": "int8", "BIGSERIAL": "int8",
+	"BOOLEAN": "bool", "BOOL": "bool",
+	"NUMERIC": "numeric", "DECIMAL": "numeric",
+	"REAL": "float4", "FLOAT4": "float4", "FLOAT8": "float8",
+	"TEXT": "text", "VARCHAR": "varchar", "CHAR": "bpchar",
+	"DATE": "date", "TIMESTAMP": "timestamp", "TIMESTAMPTZ": "timestamptz",
+	"TIME": "time", "TIMETZ": "timetz", "INTERVAL": "interval",
+	"JSON": "json", "JSONB": "jsonb", "UUID": "uuid", "BYTEA": "bytea", "INET": "inet",
+}
+
+// columnType returns the type name Postgres reports for the column definition
+// of tokens, which starts with the type.
+func columnType(tokens []string) string {
+	word := strings.ToUpper(tokens[0])
+	rest := tokens[1:]
+	if word == "DOUBLE" || word == "CHARACTER" {
+		// DOUBLE PRECISION, CHARACTER VARYING
+		if len(rest) > 0 {
+			word += " " + strings.ToUpper(rest[0])
+			rest = rest[1:]
+		}
+	}
+	array := strings.HasSuffix(word, "[]")
+	word = strings.TrimSuffix(word, "[]")
+	if len(rest) > 0 && rest[0] == "(" {
+		_, rest = parenthesized(rest)
+	}
+	if len(rest) > 0 && strings.HasPrefix(rest[0], "[]") {
+		array = true
+	}
+	if (word == "TIMESTAMP" || word == "TIME") && hasPrefix(rest, "WITH", "TIME", "ZONE") {
+		word += "TZ"
+	}
+
+	name, ok := typeNames[word]
+	switch {
+	case word == "DOUBLE PRECISION":
+		name = "float8"
+	case word == "CHARACTER VARYING":
+		name = "varchar"
+	case word == "CHARACTER":
+		name = "bpchar"
+	case !ok:
+		name = strings.ToLower(word)
+	}
+	if array {
+		return "_" + name
+	}
+	return name
+}
+
+// isConstraint reports whether word starts a table constraint rather than a
+// column.
+func isConstraint(word string) bool {
+	switch strings.ToUpper(word) {
+	case "CONSTRAINT", "PRIMARY", "UNIQUE", "CHECK", "FOREIGN", "EXCLUDE", "LIKE":
+		return true
+	}
+	return false
+}
+
+// splitStatements splits sql into its statements, without comments. Semicolons
+// in strings, quoted identifiers and dollar-quoted function bodies do not end a
+// statement.
+func splitStatements(sql string) []string {
+	var statements []string
+	var b strings.Builder
+	flush := func() {
+		if stmt := strings.TrimSpace(b.String()); stmt != "" {
+			statements = append(statements, stmt)
+		}
+		b.Reset()
+	}
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case c == '-' && strings.HasPrefix(sql[i:], "--"):
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				i = len(sql)
+			} else {
+				i += end
+			}
+			b.WriteByte(' ')
+		case c == '/' && strings.HasPrefix(sql[i:], "/*"):
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				i = len(sql)
+			} else {
+				i += end + 3
+			}
+			b.WriteByte(' ')
+		case c == '\'' || c == '"':
+			end := i + 1
+			for end < len(sql) && sql[end] != c {
+				end++
+			}
+			b.WriteString(sql[i:min(end+1, len(sql))])
+			i = end
+		case c == '$':
+			tagEnd := strings.IndexByte(sql[i+1:], '$')
+			if tagEnd < 0 || !isTag(sql[i+1:i+1+tagEnd]) {
+				b.WriteByte(c)
+				continue
+			}
+			tag := sql[i : i+tagEnd+2]
+			end := strings.Index(sql[i+len(tag):], tag)
+			if end < 0 {
+				b.WriteString(sql[i:])
+				i = len(sql)
+				continue
+			}
+			b.WriteString(sql[i : i+len(tag)+end+len(tag)])
+			i += len(tag) + end + len(tag) - 1
+		case c == ';':
+			flush()
+		default:
+			b.WriteByte(c)
+		}
+	}
+	flush()
+	return statements
+}
+
+// isTag reports whether s can be the tag of a dollar quote, as in $$ or
+// $body$.
+func isTag(s string) bool {
+	for _, r := range s {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// tokenize splits a statement into words, strings and the punctuation ( ) and
+// ,.
+func tokenize(stmt string) []string {
+	var tokens []string
+	for i := 0; i < len(stmt); {
+		c := stmt[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(' || c == ')' || c == ',':
+			tokens = append(tokens, string(c))
+			i++
+		case c == '\'' || c == '"':
+			end := i + 1
+			for end < len(stmt) && stmt[end] != c {
+				end++
+			}
+			tokens = append(tokens, stmt[i:min(end+1, len(stmt))])
+			i = end + 1
+		default:
+			end := i
+			for end < len(stmt) && !strings.ContainsRune(" \t\n\r(),", rune(stmt[end])) {
+				end++
+			}
+			tokens = append(tokens, stmt[i:end])
+			i = end
+		}
+	}
+	return tokens
+}
+
+// parenthesized returns the tokens inside the parentheses tokens starts with,
+// and those after the closing one.
+func parenthesized(tokens []string) (inside, after []string) {
+	depth := 0
+	for i, t := range tokens {
+		switch t {
+		case "(":
+			depth++
+		case ")":
+			depth--
+			if depth == 0 {
+				return tokens[1:i], tokens[i+1:]
+			}
+		}
+	}
+	return tokens[1:], nil
+}
+
+// splitTopLevel splits tokens at the commas outside parentheses.
+func splitTopLevel(tokens []string) [][]string {
+	var items [][]string
+	depth, start := 0, 0
+	for i, t := range tokens {
+		switch t {
+		case "(":
+			depth++
+		case ")":
+			depth--
+		case ",":
+			if depth == 0 {
+				items = append(items, tokens[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(items, tokens[start:])
+}
+
+// hasPrefix reports whether tokens start with words, ignoring case.
+func hasPrefix(tokens []string, words ...string) bool {
+	if len(tokens) < len(words) {
+		return false
+	}
+	for i, w := range words {
+		if !strings.EqualFold(tokens[i], w) {
+			return false
+		}
+	}
+	return true
+}
+
+// skipIf drops words from the start of tokens, if they are there.
+func skipIf(tokens []string, words ...string) []string {
+	if hasPrefix(tokens, words...) {
+		return tokens[len(words):]
+	}
+	return tokens
+}
+
+// ident returns the name of an identifier: quoted as written, unquoted in
+// lower case.
+func ident(token string) string {
+	if strings.HasPrefix(token, `"`) {
+		return strings.Trim(token, `"`)
+	}
+	return strings.ToLower(token)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
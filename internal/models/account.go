@@ -0,0 +1,92 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AccountStatus is the lifecycle state of an account.
+type AccountStatus string
+
+const (
+	AccountStatusActive  AccountStatus = "active"
+	AccountStatusDeleted AccountStatus = "deleted"
+)
+
+// Account is an account row. DeletedAt is set once the account has been soft deleted.
+// AvailableBalance is the part of Balance that transfers may spend; it is not
+// stored but set by the service layer. Livemode is false for the sandbox accounts
+// opened with test-mode API keys.
+type Account struct {
+	AccountID        int64         `json:"account_id"`
+	DisplayName      string        `json:"display_name,omitempty"`
+	Balance          Amount        `json:"balance"`
+	AvailableBalance Amount        `json:"available_balance"`
+	Status           AccountStatus `json:"status"`
+	CreatedAt        time.Time     `json:"created_at"`
+	UpdatedAt        time.Time     `json:"updated_at"`
+	DeletedAt        *time.Time    `json:"deleted_at,omitempty"`
+	Livemode         bool          `json:"livemode"`
+}
+
+// AccountFilter narrows an account listing to the open accounts whose balance is
+// at least MinBalance and at most MaxBalance, each if set. Listings hold either
+// live accounts or, with TestMode set, test-mode ones.
+type AccountFilter struct {
+	MinBalance *Amount
+	MaxBalance *Amount
+	TestMode   bool
+}
+
+// AccountPage is one page of an account listing, in the order of account IDs.
+type AccountPage struct {
+	Accounts   []Account `json:"accounts"`
+	NextCursor string    `json:"next_cursor"`
+	HasMore    bool      `json:"has_more"`
+}
+
+// AccountSummary is the overview of an account shown on its home screen. Held is
+// the part of Balance that is not available to spend; MonthToDate starts at the
+// beginning of the month in Timezone.
+type AccountSummary struct {
+	AccountID        int64        `json:"account_id"`
+	DisplayName      string       `json:"display_name,omitempty"`
+	Balance          Amount       `json:"balance"`
+	AvailableBalance Amount       `json:"available_balance"`
+	Held             Amount       `json:"held"`
+	Timezone         string       `json:"timezone"`
+	MonthToDate      AccountFlows `json:"month_to_date"`
+}
+
+// MarshalJSON adds the currency and its minor units next to the amounts.
+func (s AccountSummary) MarshalJSON() ([]byte, error) {
+	type summary AccountSummary
+	return json.Marshal(struct {
+		summary
+		Currency
+	}{summary(s), CurrentCurrency()})
+}
+
+// AccountFlows totals the transfers into and out of an account since a point in
+// time.
+type AccountFlows struct {
+	Since         time.Time `json:"since"`
+	Incoming      Amount    `json:"incoming"`
+	IncomingCount int64     `json:"incoming_count"`
+	Outgoing      Amount    `json:"outgoing"`
+	OutgoingCount int64     `json:"outgoing_count"`
+}
+
+// DisplayNameRequest sets the display name of an account.
+type DisplayNameRequest struct {
+	DisplayName string `json:"display_name"`
+}
+
+// MarshalJSON adds the currency and its minor units next to the balance.
+func (a Account) MarshalJSON() ([]byte, error) {
+	type account Account
+	return json.Marshal(struct {
+		account
+		Currency
+	}{account(a), CurrentCurrency()})
+}
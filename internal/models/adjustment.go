@@ -0,0 +1,13 @@
+package models
+
+// BalanceRecompute reports the result of rebuilding an account balance from its
+// transaction log.
+type BalanceRecompute struct {
+	AccountID       int64  `json:"account_id"`
+	StoredBalance   Amount `json:"stored_balance"`
+	ComputedBalance Amount `json:"computed_balance"`
+	// Delta is StoredBalance - ComputedBalance; zero means no drift.
+	Delta        Amount     `json:"delta"`
+	AdjustmentID string     `json:"adjustment_id,omitempty"`
+	ReasonCode   ReasonCode `json:"reason_code,omitempty"`
+}
@@ -0,0 +1,121 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/nehciyy/intrapay/internal/money"
+)
+
+// Currency is the ISO 4217 currency balances are held in and the number of
+// decimal places (minor units) amounts are quoted with.
+type Currency struct {
+	Code       string `json:"currency"`
+	MinorUnits int    `json:"minor_units"`
+}
+
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// currency is process-wide: IntraPay accounts all share one currency.
+var currency = Currency{Code: "USD", MinorUnits: 2}
+
+// SetCurrency sets the currency amounts are formatted and validated against.
+// It must be called before serving requests.
+func SetCurrency(code string) error {
+	c, err := LookupCurrency(code)
+	if err != nil {
+		return err
+	}
+	currency = c
+	return nil
+}
+
+// LookupCurrency returns the currency with ISO 4217 code code, with the minor
+// units of its rounding policy.
+func LookupCurrency(code string) (Currency, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if !currencyCode.MatchString(code) {
+		return Currency{}, fmt.Errorf("invalid currency code %q", code)
+	}
+	return Currency{Code: code, MinorUnits: money.PolicyOf(code).MinorUnits}, nil
+}
+
+// CurrentCurrency returns the configured currency.
+func CurrentCurrency() Currency {
+	return currency
+}
+
+// Policy returns the currency's rounding policy.
+func (c Currency) Policy() money.Policy {
+	return money.PolicyOf(c.Code)
+}
+
+// Format formats v with the currency's minor-unit precision, e.g. "1.50" for
+// 1.5 in USD.
+func (c Currency) Format(v float64) string {
+	return strconv.FormatFloat(v, 'f', c.MinorUnits, 64)
+}
+
+// Scale is the number of minor units in one major unit, e.g. 100 for USD.
+func (c Currency) Scale() int64 {
+	scale := int64(1)
+	for range c.MinorUnits {
+		scale *= 10
+	}
+	return scale
+}
+
+// Amount is a monetary amount. It is encoded as an exact decimal string with the
+// currency's minor-unit precision (e.g. "100.00"), and decodes from either a
+// string or a JSON number. Formatting never depends on locale: no grouping
+// separators, and "." as the decimal point.
+type Amount float64
+
+var decimalAmount = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+
+func (a Amount) String() string {
+	return strconv.FormatFloat(float64(a), 'f', currency.MinorUnits, 64)
+}
+
+// Minor returns the amount in minor units of the currency, e.g. 1050 for "10.50"
+// in USD.
+func (a Amount) Minor() int64 {
+	return int64(math.Round(float64(a) * float64(currency.Scale())))
+}
+
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.String())
+}
+
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = strings.TrimSpace(unquoted)
+	}
+	amount, err := ParseAmount(s)
+	if err != nil {
+		return err
+	}
+	*a = amount
+	return nil
+}
+
+// ParseAmount parses a plain decimal such as "100.5". Amounts with more decimal
+// places than the currency allows are rejected rather than rounded.
+func ParseAmount(s string) (Amount, error) {
+	if !decimalAmount.MatchString(s) {
+		return 0, fmt.Errorf("invalid amount %q: must be a decimal number", s)
+	}
+	if i := strings.IndexByte(s, '.'); i >= 0 && len(strings.TrimRight(s[i+1:], "0")) > currency.MinorUnits {
+		return 0, fmt.Errorf("invalid amount %q: %s allows at most %d decimal places", s, currency.Code, currency.MinorUnits)
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q: %w", s, err)
+	}
+	return Amount(f), nil
+}
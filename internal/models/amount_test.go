@@ -0,0 +1,62 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAmountJSON(t *testing.T) {
+	raw, err := json.Marshal(Amount(100))
+	require.NoError(t, err)
+	assert.Equal(t, `"100.00"`, string(raw))
+
+	tests := []struct {
+		input    string
+		expected Amount
+		wantErr  bool
+	}{
+		{input: `"100.00"`, expected: 100},
+		{input: `"0.5"`, expected: 0.5},
+		{input: `12.34`, expected: 12.34},
+		{input: `"1.230"`, expected: 1.23},
+		{input: `"1.234"`, wantErr: true},
+		{input: `"1,000.00"`, wantErr: true},
+		{input: `"abc"`, wantErr: true},
+		{input: `1e3`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			var a Amount
+			err := json.Unmarshal([]byte(tt.input), &a)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, a)
+		})
+	}
+}
+
+func TestSetCurrency(t *testing.T) {
+	defer SetCurrency("USD")
+
+	require.NoError(t, SetCurrency("jpy"))
+	assert.Equal(t, Currency{Code: "JPY", MinorUnits: 0}, CurrentCurrency())
+	assert.Equal(t, "1500", Amount(1500).String())
+	assert.Equal(t, int64(1500), Amount(1500).Minor())
+
+	_, err := ParseAmount("1500.5")
+	assert.Error(t, err)
+	assert.Error(t, SetCurrency("dollars"))
+}
+
+func TestAmountMinor(t *testing.T) {
+	assert.Equal(t, int64(100), CurrentCurrency().Scale())
+	assert.Equal(t, int64(1050), Amount(10.5).Minor())
+	assert.Equal(t, int64(29), Amount(0.29).Minor(), "rounded, not truncated")
+	assert.Equal(t, int64(-1), Amount(-0.01).Minor())
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// Backfill is the progress of a backfill: every row up to LastID has been
+// processed and RowsUpdated of them filled in. CompletedAt is when a run last
+// caught up with the table.
+type Backfill struct {
+	Name        string `json:"name"`
+	LastID      int64  `json:"last_id"`
+	RowsUpdated int64  `json:"rows_updated"`
+	// Pending is how many rows still need filling in, when it was counted.
+	Pending     *int64     `json:"pending,omitempty"`
+	StartedAt   time.Time  `json:"started_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
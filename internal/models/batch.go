@@ -0,0 +1,39 @@
+package models
+
+// TransactionBatchRequest is the body of POST /transactions/batch: transfers
+// requested together, posted all together in one database transaction or not
+// at all if Atomic is set, else each on its own.
+type TransactionBatchRequest struct {
+	Atomic    bool                 `json:"atomic"`
+	Transfers []TransactionRequest `json:"transfers"`
+}
+
+// BatchTransferStatus is the outcome of one transfer of a batch.
+type BatchTransferStatus string
+
+const (
+	BatchTransferCreated BatchTransferStatus = "created"
+	BatchTransferFailed  BatchTransferStatus = "failed"
+	// BatchTransferSkipped is a transfer of an atomic batch that was not
+	// posted because others of the batch failed.
+	BatchTransferSkipped BatchTransferStatus = "skipped"
+)
+
+// BatchTransferResult is the outcome of transfer Transfer, counted from 0, of
+// a batch: the transaction it was posted as, or why it failed.
+type BatchTransferResult struct {
+	Transfer      int                 `json:"transfer"`
+	Status        BatchTransferStatus `json:"status"`
+	TransactionID string              `json:"transaction_id,omitempty"`
+	Code          ReasonCode          `json:"code,omitempty"`
+	Error         string              `json:"error,omitempty"`
+}
+
+// TransactionBatchResult is the outcome of a batch of transfers, with a result
+// per transfer in the order of the batch.
+type TransactionBatchResult struct {
+	Atomic  bool                  `json:"atomic"`
+	Created int                   `json:"created"`
+	Failed  int                   `json:"failed"`
+	Results []BatchTransferResult `json:"results"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// AmountBoundsAllTenants is the tenant of the global amount bounds, which
+// apply to the transfers of every account.
+const AmountBoundsAllTenants = "*"
+
+// AmountBounds are the smallest and largest amounts a single transfer out of
+// the accounts of Tenant may move. A zero MaxAmount sets no ceiling.
+type AmountBounds struct {
+	Tenant    string    `json:"tenant"`
+	MinAmount Amount    `json:"min_amount"`
+	MaxAmount Amount    `json:"max_amount"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SetAmountBoundsRequest is the body of PUT /admin/amount-bounds/{tenant}.
+type SetAmountBoundsRequest struct {
+	MinAmount Amount `json:"min_amount"`
+	MaxAmount Amount `json:"max_amount"`
+}
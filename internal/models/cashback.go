@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// CashbackCampaign credits the account that made a qualifying transfer with
+// Percentage of its amount, at most Cap, from FundingAccountID. A transfer
+// qualifies when it is made from StartsAt until EndsAt by one of
+// EligibleAccountIDs, or by any account while that is empty. Paid is what the
+// campaign has credited so far.
+type CashbackCampaign struct {
+	ID                 int64     `json:"id"`
+	Name               string    `json:"name"`
+	Percentage         float64   `json:"percentage"`
+	Cap                Amount    `json:"cap"`
+	FundingAccountID   int64     `json:"funding_account_id"`
+	EligibleAccountIDs []int64   `json:"eligible_account_ids"`
+	StartsAt           time.Time `json:"starts_at"`
+	EndsAt             time.Time `json:"ends_at"`
+	Paid               Amount    `json:"paid"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+// CashbackCampaignRequest is the body of POST /admin/campaigns and
+// PUT /admin/campaigns/{id}. Empty EligibleAccountIDs makes every account
+// eligible.
+type CashbackCampaignRequest struct {
+	Name               string    `json:"name"`
+	Percentage         float64   `json:"percentage"`
+	Cap                Amount    `json:"cap"`
+	FundingAccountID   int64     `json:"funding_account_id"`
+	EligibleAccountIDs []int64   `json:"eligible_account_ids"`
+	StartsAt           time.Time `json:"starts_at"`
+	EndsAt             time.Time `json:"ends_at"`
+}
@@ -0,0 +1,110 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Event types written to the outbox.
+const (
+	EventTransferCompleted          = "transfer.completed"
+	EventReimbursementStatusChanged = "reimbursement.status_changed"
+	EventAccountDailyDigest         = "account.daily_digest"
+)
+
+// The schema versions events are written with. A version is bumped for changes
+// consumers of the previous one could not read; adding optional fields is not
+// such a change.
+const (
+	TransferCompletedVersion          = 1
+	ReimbursementStatusChangedVersion = 1
+	AccountDailyDigestVersion         = 1
+)
+
+// Aggregate types events belong to. Events of one aggregate are published in
+// the order they were committed.
+const (
+	AggregateAccount       = "account"
+	AggregateReimbursement = "reimbursement"
+)
+
+// Event is an outbox event, and the envelope it is published in. ID is its offset
+// in the outbox, stable across deliveries and replays; SchemaVersion is the
+// version of the Type's schema Payload conforms to.
+type Event struct {
+	ID            int64           `json:"id"`
+	Type          string          `json:"type"`
+	SchemaVersion int             `json:"schema_version"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   string          `json:"aggregate_id"`
+	Payload       json.RawMessage `json:"payload"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+// TransferCompleted is the payload of a transfer.completed event. Kind is left
+// out for client transfers and set for transactions the server posts, such as
+// top-ups.
+type TransferCompleted struct {
+	TransactionID        string          `json:"transaction_id"`
+	SourceAccountID      int64           `json:"source_account_id"`
+	DestinationAccountID int64           `json:"destination_account_id"`
+	Amount               Amount          `json:"amount"`
+	Kind                 TransactionKind `json:"kind,omitempty"`
+}
+
+// ReimbursementStatusChanged is the payload of a reimbursement.status_changed
+// event, written when a reimbursement is requested and when it is decided.
+type ReimbursementStatusChanged struct {
+	ReimbursementID int64               `json:"reimbursement_id"`
+	AccountID       int64               `json:"account_id"`
+	Amount          Amount              `json:"amount"`
+	Status          ReimbursementStatus `json:"status"`
+	TransactionID   string              `json:"transaction_id,omitempty"`
+	Note            string              `json:"note,omitempty"`
+}
+
+// AccountDailyDigest is the payload of an account.daily_digest event: the
+// transactions of an account on a day, summed up for an account whose
+// notifications are sent as a daily digest.
+type AccountDailyDigest struct {
+	AccountID     int64  `json:"account_id"`
+	Date          string `json:"date"`
+	Transactions  int64  `json:"transactions"`
+	Incoming      Amount `json:"incoming"`
+	IncomingCount int64  `json:"incoming_count"`
+	Outgoing      Amount `json:"outgoing"`
+	OutgoingCount int64  `json:"outgoing_count"`
+	Net           Amount `json:"net"`
+}
+
+// OutboxRelayStatus is the position of the outbox relay. Every event up to
+// LastEventID has been published; LatestEventID is the newest event written.
+type OutboxRelayStatus struct {
+	LastEventID   int64     `json:"last_event_id"`
+	LatestEventID int64     `json:"latest_event_id"`
+	Paused        bool      `json:"paused"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// EventPage is a page of the event log. NextAfterID is the after_id of the next
+// page: the ID of the last event, or the requested after_id when there is none.
+type EventPage struct {
+	Events      []Event `json:"events"`
+	NextAfterID int64   `json:"next_after_id"`
+	HasMore     bool    `json:"has_more"`
+}
+
+// EventSchema describes the JSON schema of one version of an event type's payload.
+type EventSchema struct {
+	Type    string `json:"type"`
+	Version int    `json:"version"`
+	// Latest marks the version events of Type are currently written with.
+	Latest bool   `json:"latest"`
+	URL    string `json:"url"`
+}
+
+// ReplayRequest is the body of the outbox replay endpoint: events after
+// AfterID are published again.
+type ReplayRequest struct {
+	AfterID int64 `json:"after_id"`
+}
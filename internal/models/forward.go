@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// ForwardStatus is where a transfer accepted into the store-and-forward queue
+// stands.
+type ForwardStatus string
+
+const (
+	// ForwardQueued is a transfer waiting in the queue for the database.
+	ForwardQueued    ForwardStatus = "queued"
+	ForwardCompleted ForwardStatus = "completed"
+	ForwardRejected  ForwardStatus = "rejected"
+	// ForwardExpired is a transfer that waited in the queue for longer than
+	// its maximum age and was dropped without being attempted.
+	ForwardExpired ForwardStatus = "expired"
+)
+
+// ForwardedTransfer is a transfer accepted while the database was unreachable,
+// as held by the store-and-forward queue until it can be replayed. The caller
+// that requested it is kept to record it as a transaction attempt if it is
+// rejected, and its Idempotency-Key to accept a retry of it only once.
+type ForwardedTransfer struct {
+	ID             string             `json:"id"`
+	Request        TransactionRequest `json:"request"`
+	AcceptedAt     time.Time          `json:"accepted_at"`
+	APIKey         string             `json:"api_key,omitempty"`
+	Tenant         string             `json:"tenant,omitempty"`
+	RequestID      string             `json:"request_id,omitempty"`
+	IdempotencyKey string             `json:"idempotency_key,omitempty"`
+}
+
+// ForwardedTransferStatus is the outcome of a forwarded transfer, or Queued if
+// it has none yet. TransactionID is set once it completed, Code and Error once
+// it was rejected. An outcome is stored with the Idempotency-Key of the
+// transfer, if it has one, and the API key it belongs to.
+type ForwardedTransferStatus struct {
+	ID             string        `json:"id"`
+	Status         ForwardStatus `json:"status"`
+	AcceptedAt     time.Time     `json:"accepted_at"`
+	ReplayedAt     *time.Time    `json:"replayed_at,omitempty"`
+	TransactionID  string        `json:"transaction_id,omitempty"`
+	Code           ReasonCode    `json:"code,omitempty"`
+	Error          string        `json:"error,omitempty"`
+	APIKey         string        `json:"-"`
+	IdempotencyKey string        `json:"-"`
+}
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// FreezeAllTenants is the tenant of a freeze of every account's transfers.
+const FreezeAllTenants = "*"
+
+// TransferFreeze suspends the outgoing transfers of Tenant's accounts, or of
+// every account for FreezeAllTenants, from CreatedAt until ExpiresAt unless it
+// is lifted first.
+type TransferFreeze struct {
+	ID        int64      `json:"id"`
+	Tenant    string     `json:"tenant"`
+	Reason    string     `json:"reason"`
+	FrozenBy  string     `json:"frozen_by"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	LiftedAt  *time.Time `json:"lifted_at,omitempty"`
+	LiftedBy  string     `json:"lifted_by,omitempty"`
+}
+
+// InForce reports whether the freeze suspends transfers at now.
+func (f TransferFreeze) InForce(now time.Time) bool {
+	return f.LiftedAt == nil && now.Before(f.ExpiresAt)
+}
+
+// TransferFreezeRequest freezes the outgoing transfers of Tenant, or of every
+// tenant when it is empty or FreezeAllTenants, for Duration, a
+// time.ParseDuration string.
+type TransferFreezeRequest struct {
+	Tenant   string `json:"tenant"`
+	Duration string `json:"duration"`
+	Reason   string `json:"reason"`
+	Operator string `json:"operator"`
+}
+
+// LiftFreezeRequest lifts the freezes of Tenant, or the freezes of every
+// tenant when it is empty or FreezeAllTenants.
+type LiftFreezeRequest struct {
+	Tenant   string `json:"tenant"`
+	Operator string `json:"operator"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// Impersonation is the audit record of a request an operator made as a
+// customer: the customer's APIKey and Tenant, the request's Method and Path, and
+// the Reason the operator gave, if any.
+type Impersonation struct {
+	ID        int64     `json:"id"`
+	Operator  string    `json:"operator"`
+	APIKey    string    `json:"api_key"`
+	Tenant    string    `json:"tenant"`
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+	Reason    string    `json:"reason,omitempty"`
+	RequestID string    `json:"request_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ImpersonationFilter narrows the impersonation audit listing. Zero fields match
+// all, and Until is exclusive.
+type ImpersonationFilter struct {
+	Operator string
+	APIKey   string
+	Tenant   string
+	Since    time.Time
+	Until    time.Time
+}
+
+// ImpersonationPage is one page of the impersonation audit trail.
+type ImpersonationPage struct {
+	Impersonations []Impersonation `json:"impersonations"`
+	NextCursor     string          `json:"next_cursor"`
+	HasMore        bool            `json:"has_more"`
+}
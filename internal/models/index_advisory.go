@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// IndexAdvisory reports the indexes the migrations create that the database
+// lacks, and the tables and statements that read the most rows without one.
+type IndexAdvisory struct {
+	AsOf           time.Time      `json:"as_of"`
+	MissingIndexes []MissingIndex `json:"missing_indexes"`
+	Tables         []TableScans   `json:"tables"`
+	// Statements are the statements reading the most blocks per call, with
+	// the pg_stat_statements extension installed; nil without it.
+	Statements []StatementScans `json:"statements"`
+}
+
+// MissingIndex is an index a migration creates that the database lacks.
+type MissingIndex struct {
+	Name  string `json:"name"`
+	Table string `json:"table"`
+}
+
+// TableScans counts the scans of a table since the database's statistics were
+// last reset.
+type TableScans struct {
+	Table       string `json:"table"`
+	SeqScans    int64  `json:"seq_scans"`
+	SeqRowsRead int64  `json:"seq_rows_read"`
+	IndexScans  int64  `json:"index_scans"`
+	LiveRows    int64  `json:"live_rows"`
+	// SeqScanHeavy flags a table read mostly by sequential scans of many rows
+	// each, which a query filtering it without a matching index causes.
+	SeqScanHeavy bool `json:"seq_scan_heavy"`
+}
+
+// StatementScans is what a statement has read since pg_stat_statements was last
+// reset.
+type StatementScans struct {
+	Query string `json:"query"`
+	Calls int64  `json:"calls"`
+	// BlocksPerCall is the shared blocks read or hit per call; a statement
+	// scanning a table reads every block of it.
+	BlocksPerCall float64 `json:"blocks_per_call"`
+	RowsPerCall   float64 `json:"rows_per_call"`
+	MeanMillis    float64 `json:"mean_ms"`
+}
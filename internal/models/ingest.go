@@ -0,0 +1,40 @@
+package models
+
+// IngestTransfer is a transfer of a bulk ingestion, such as a line of an
+// imported file. Reference, if set, becomes the transaction ID of the transfer;
+// a transfer whose reference was ingested before is skipped, so an ingestion
+// that failed can be run again as it is.
+type IngestTransfer struct {
+	SourceAccountID      int64  `json:"source_account_id"`
+	DestinationAccountID int64  `json:"destination_account_id"`
+	Amount               Amount `json:"amount"`
+	Reference            string `json:"reference,omitempty"`
+}
+
+// IngestAccount is an account a bulk ingestion moves money from or to, as it
+// stood when the ingestion locked it.
+type IngestAccount struct {
+	AccountID int64
+	Balance   float64
+	Open      bool
+	Livemode  bool
+}
+
+// IngestLineError tells why transfer Line, counted from 0, of a bulk ingestion
+// cannot be posted. For insufficient funds Line is the first transfer from the
+// account that cannot cover them all.
+type IngestLineError struct {
+	Line      int        `json:"line"`
+	AccountID int64      `json:"account_id"`
+	Code      ReasonCode `json:"code"`
+	Error     string     `json:"error"`
+}
+
+// IngestResult is a committed bulk ingestion. TransactionIDs holds the
+// transaction of every transfer in the order of the transfers, including the
+// Skipped ones ingested before, whose ID is their reference.
+type IngestResult struct {
+	Ingested       int      `json:"ingested"`
+	Skipped        int      `json:"skipped"`
+	TransactionIDs []string `json:"transaction_ids"`
+}
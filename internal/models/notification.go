@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// NotificationMode is how the notifications of an account, sent to the webhooks
+// subscribed to it, are delivered.
+type NotificationMode string
+
+const (
+	// NotificationImmediate sends each event as it happens. It is the mode of an
+	// account that never set one.
+	NotificationImmediate NotificationMode = "immediate"
+	// NotificationDailyDigest holds the events back and sends, once a day has
+	// closed, a summary of the day's transactions instead.
+	NotificationDailyDigest NotificationMode = "daily_digest"
+)
+
+// NotificationPreference is how the notifications of AccountID are delivered.
+// Timezone is the IANA time zone its days are digested in. UpdatedAt is nil for
+// an account still on the default.
+type NotificationPreference struct {
+	AccountID int64            `json:"account_id"`
+	Mode      NotificationMode `json:"mode"`
+	Timezone  string           `json:"timezone"`
+	UpdatedAt *time.Time       `json:"updated_at,omitempty"`
+}
+
+// NotificationPreferenceRequest is the body of PUT /accounts/{id}/notifications.
+// Timezone defaults to UTC.
+type NotificationPreferenceRequest struct {
+	Mode     NotificationMode `json:"mode"`
+	Timezone string           `json:"timezone,omitempty"`
+}
+
+// DailyDigest sums up the transfers into and out of an account of Tenant on
+// Date, YYYY-MM-DD in the account's time zone, for its daily digest.
+type DailyDigest struct {
+	AccountID     int64
+	Tenant        string
+	Date          string
+	Incoming      Amount
+	IncomingCount int64
+	Outgoing      Amount
+	OutgoingCount int64
+}
@@ -0,0 +1,48 @@
+package models
+
+// PayrollRequest is the body of POST /payroll and POST /payroll/preview: a batch
+// of salary transfers from one employer account, committed all together or not
+// at all.
+type PayrollRequest struct {
+	EmployerAccountID int64         `json:"employer_account_id"`
+	Lines             []PayrollLine `json:"lines"`
+}
+
+// PayrollLine is a salary transfer of a payroll batch.
+type PayrollLine struct {
+	AccountID int64  `json:"account_id"`
+	Amount    Amount `json:"amount"`
+}
+
+// PayrollLineError tells why line Line, counted from 0, of a payroll batch
+// cannot be paid.
+type PayrollLineError struct {
+	Line      int        `json:"line"`
+	AccountID int64      `json:"account_id"`
+	Code      ReasonCode `json:"code"`
+	Error     string     `json:"error"`
+}
+
+// PayrollPreview is the outcome of validating a payroll batch against the
+// accounts as they are. A batch is Valid if every line can be paid and the
+// employer's available balance covers Total; otherwise Code and Error tell
+// what is wrong with the batch as a whole, if anything, and LineErrors what is
+// wrong with single lines.
+type PayrollPreview struct {
+	EmployerAccountID int64              `json:"employer_account_id"`
+	Lines             int                `json:"lines"`
+	Total             Amount             `json:"total"`
+	EmployerBalance   Amount             `json:"employer_available_balance"`
+	Valid             bool               `json:"valid"`
+	Code              ReasonCode         `json:"code,omitempty"`
+	Error             string             `json:"error,omitempty"`
+	LineErrors        []PayrollLineError `json:"line_errors"`
+}
+
+// PayrollResult is a committed payroll batch. TransactionIDs holds the
+// transaction of every line, in the order of the lines.
+type PayrollResult struct {
+	EmployerAccountID int64    `json:"employer_account_id"`
+	Total             Amount   `json:"total"`
+	TransactionIDs    []string `json:"transaction_ids"`
+}
@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// PendingActionKind names the workflow an action belongs to.
+type PendingActionKind string
+
+const (
+	PendingActionTransferApproval PendingActionKind = "transfer_approval"
+	PendingActionDispute          PendingActionKind = "dispute"
+	PendingActionDeadLetter       PendingActionKind = "dead_letter"
+	PendingActionSARReview        PendingActionKind = "sar_review"
+	PendingActionSuspense         PendingActionKind = "suspense"
+	PendingActionReimbursement    PendingActionKind = "reimbursement"
+)
+
+// Valid reports whether k is a known kind.
+func (k PendingActionKind) Valid() bool {
+	switch k {
+	case PendingActionTransferApproval, PendingActionDispute, PendingActionDeadLetter, PendingActionSARReview, PendingActionSuspense, PendingActionReimbursement:
+		return true
+	}
+	return false
+}
+
+// PendingAction is an item awaiting a human decision. Reference identifies the
+// item in its own workflow, e.g. a transaction ID or a dispute ID.
+type PendingAction struct {
+	ID         int64             `json:"id"`
+	Kind       PendingActionKind `json:"kind"`
+	Reference  string            `json:"reference"`
+	Summary    string            `json:"summary,omitempty"`
+	Assignee   string            `json:"assignee,omitempty"`
+	AssignedAt *time.Time        `json:"assigned_at,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+}
+
+// PendingActionFilter narrows the pending actions feed. Zero fields match all.
+type PendingActionFilter struct {
+	Kind       PendingActionKind
+	Assignee   string
+	Unassigned bool
+}
+
+// PendingActionPage is one page of the pending actions feed.
+type PendingActionPage struct {
+	Actions    []PendingAction `json:"actions"`
+	NextCursor string          `json:"next_cursor"`
+	HasMore    bool            `json:"has_more"`
+}
+
+// AssignRequest is the body of the claim and assign endpoints.
+type AssignRequest struct {
+	Assignee string `json:"assignee"`
+}
@@ -0,0 +1,91 @@
+package models
+
+// ReasonCode is a stable, machine-readable reason for a rejected request or a
+// balance adjustment. Codes are never renamed or reused; new ones may be added,
+// so clients should treat unknown codes as generic failures.
+type ReasonCode string
+
+// ReasonCategory groups reason codes by where they appear.
+type ReasonCategory string
+
+const (
+	// ReasonCategoryRejection codes explain why a request was refused. They are
+	// returned in the code field of error responses.
+	ReasonCategoryRejection ReasonCategory = "rejection"
+	// ReasonCategoryAdjustment codes explain why a balance adjustment was posted.
+	// They are stored on the adjustment.
+	ReasonCategoryAdjustment ReasonCategory = "adjustment"
+)
+
+const (
+	ReasonInsufficientFunds          ReasonCode = "insufficient_funds"
+	ReasonAccountNotFound            ReasonCode = "account_not_found"
+	ReasonDestinationNotFound        ReasonCode = "destination_not_found"
+	ReasonLimitExceeded              ReasonCode = "limit_exceeded"
+	ReasonCallQuotaExceeded          ReasonCode = "call_quota_exceeded"
+	ReasonVolumeQuotaExceeded        ReasonCode = "volume_quota_exceeded"
+	ReasonAccountLimitExceeded       ReasonCode = "account_limit_exceeded"
+	ReasonAccountFrozen              ReasonCode = "account_frozen"
+	ReasonComplianceHold             ReasonCode = "compliance_hold"
+	ReasonConcurrencyConflict        ReasonCode = "concurrency_conflict"
+	ReasonRegionPassive              ReasonCode = "region_passive"
+	ReasonTransfersFrozen            ReasonCode = "transfers_frozen"
+	ReasonSameAccount                ReasonCode = "same_account"
+	ReasonTokenNotFound              ReasonCode = "token_not_found"
+	ReasonTokenRevoked               ReasonCode = "token_revoked"
+	ReasonTokenExpired               ReasonCode = "token_expired"
+	ReasonTokenLimitExceeded         ReasonCode = "token_limit_exceeded"
+	ReasonMerchantCategoryNotAllowed ReasonCode = "merchant_category_not_allowed"
+	ReasonImpersonationReadOnly      ReasonCode = "impersonation_read_only"
+	ReasonManualAdjustmentCorrection ReasonCode = "manual_adjustment_correction"
+	ReasonReconciliationCorrection   ReasonCode = "reconciliation_correction"
+)
+
+// ReasonCodeInfo describes a reason code in the catalog.
+type ReasonCodeInfo struct {
+	Code        ReasonCode     `json:"code"`
+	Category    ReasonCategory `json:"category"`
+	Description string         `json:"description"`
+	// Retryable reports whether the same request may succeed if retried later
+	// without changes.
+	Retryable bool `json:"retryable"`
+}
+
+var reasonCatalog = []ReasonCodeInfo{
+	{ReasonInsufficientFunds, ReasonCategoryRejection, "The source account balance does not cover the amount.", false},
+	{ReasonAccountNotFound, ReasonCategoryRejection, "The source account does not exist or is closed.", false},
+	{ReasonDestinationNotFound, ReasonCategoryRejection, "The destination account does not exist or is closed.", false},
+	{ReasonLimitExceeded, ReasonCategoryRejection, "The amount is outside the limits allowed for a single transfer.", false},
+	{ReasonCallQuotaExceeded, ReasonCategoryRejection, "The monthly call quota of the API key or tenant is used up.", true},
+	{ReasonVolumeQuotaExceeded, ReasonCategoryRejection, "The transfer would exceed the monthly volume quota of the API key or tenant.", true},
+	{ReasonAccountLimitExceeded, ReasonCategoryRejection, "The tenant already has as many open accounts as its account limit allows.", false},
+	{ReasonAccountFrozen, ReasonCategoryRejection, "An account involved is frozen and cannot send or receive funds.", false},
+	{ReasonComplianceHold, ReasonCategoryRejection, "The transfer is held for compliance review.", false},
+	{ReasonConcurrencyConflict, ReasonCategoryRejection, "Concurrent transfers on the same account kept conflicting; retry after retry_in_ms.", true},
+	{ReasonRegionPassive, ReasonCategoryRejection, "This region is on standby and does not accept writes; retry against the active region.", true},
+	{ReasonTransfersFrozen, ReasonCategoryRejection, "Outgoing transfers are frozen, for every account or for the tenant of the source account, until the freeze expires or is lifted.", false},
+	{ReasonSameAccount, ReasonCategoryRejection, "The destination account is the source account.", false},
+	{ReasonTokenNotFound, ReasonCategoryRejection, "The spending token does not exist.", false},
+	{ReasonTokenRevoked, ReasonCategoryRejection, "The spending token has been revoked.", false},
+	{ReasonTokenExpired, ReasonCategoryRejection, "The spending token has expired.", false},
+	{ReasonTokenLimitExceeded, ReasonCategoryRejection, "The debit would take the spending token past its limit.", false},
+	{ReasonMerchantCategoryNotAllowed, ReasonCategoryRejection, "The spending token may not be used at merchants of this category.", false},
+	{ReasonImpersonationReadOnly, ReasonCategoryRejection, "The request impersonates a customer, and impersonated requests may only read.", false},
+	{ReasonManualAdjustmentCorrection, ReasonCategoryAdjustment, "An operator corrected the balance by hand.", false},
+	{ReasonReconciliationCorrection, ReasonCategoryAdjustment, "Reconciliation found drift between the balance and the transaction log and corrected it.", false},
+}
+
+// ReasonCatalog returns every reason code, in a stable order.
+func ReasonCatalog() []ReasonCodeInfo {
+	return append([]ReasonCodeInfo(nil), reasonCatalog...)
+}
+
+// Info returns the catalog entry of c, or false if c is not in the catalog.
+func (c ReasonCode) Info() (ReasonCodeInfo, bool) {
+	for _, info := range reasonCatalog {
+		if info.Code == c {
+			return info, true
+		}
+	}
+	return ReasonCodeInfo{}, false
+}
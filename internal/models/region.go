@@ -0,0 +1,38 @@
+package models
+
+import (
+	"regexp"
+	"time"
+)
+
+// RegionFence records which region of an active-passive deployment may write
+// the ledger. Every promotion bumps Epoch, the fencing token. ActiveRegion is
+// empty until a region is first promoted.
+type RegionFence struct {
+	ActiveRegion string    `json:"active_region"`
+	Epoch        int64     `json:"epoch"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// RegionStatus is the region fence as seen by the servers of Region.
+type RegionStatus struct {
+	Region string `json:"region"`
+	Active bool   `json:"active"`
+	RegionFence
+}
+
+// PromoteRegionRequest is the body of the region promotion endpoint. Epoch is
+// the fencing token the caller last read, so two operators promoting at the same
+// time cannot both succeed.
+type PromoteRegionRequest struct {
+	Region string `json:"region"`
+	Epoch  int64  `json:"epoch"`
+}
+
+var regionPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+// ValidRegion reports whether name can name a region: up to 63 lowercase
+// letters, digits and hyphens, not starting with a hyphen, e.g. "eu-west-1".
+func ValidRegion(name string) bool {
+	return regionPattern.MatchString(name)
+}
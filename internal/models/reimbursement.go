@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// ReimbursementStatus is where a reimbursement is in its approval.
+type ReimbursementStatus string
+
+const (
+	ReimbursementPending  ReimbursementStatus = "pending"
+	ReimbursementApproved ReimbursementStatus = "approved"
+	ReimbursementRejected ReimbursementStatus = "rejected"
+)
+
+// Receipt is the metadata of the receipt an expense is claimed with. The
+// receipt itself stays with the requester; URL may point to a copy.
+type Receipt struct {
+	Merchant string `json:"merchant,omitempty"`
+	// Date is the day of the expense, as YYYY-MM-DD.
+	Date      string `json:"date,omitempty"`
+	Reference string `json:"reference,omitempty"`
+	URL       string `json:"url,omitempty"`
+}
+
+// Reimbursement is an expense claimed back for an employee account. Once
+// approved, TransactionID is the transfer that paid it.
+type Reimbursement struct {
+	ID            int64               `json:"id"`
+	Tenant        string              `json:"tenant"`
+	AccountID     int64               `json:"account_id"`
+	Amount        Amount              `json:"amount"`
+	Description   string              `json:"description"`
+	Receipt       Receipt             `json:"receipt"`
+	Status        ReimbursementStatus `json:"status"`
+	DecidedBy     string              `json:"decided_by,omitempty"`
+	DecisionNote  string              `json:"decision_note,omitempty"`
+	TransactionID string              `json:"transaction_id,omitempty"`
+	CreatedAt     time.Time           `json:"created_at"`
+	DecidedAt     *time.Time          `json:"decided_at,omitempty"`
+}
+
+// ReimbursementRequest is the body of POST /reimbursements.
+type ReimbursementRequest struct {
+	AccountID   int64   `json:"account_id"`
+	Amount      Amount  `json:"amount"`
+	Description string  `json:"description"`
+	Receipt     Receipt `json:"receipt"`
+}
+
+// ReimbursementDecision is the body of the approve and reject endpoints.
+type ReimbursementDecision struct {
+	DecidedBy string `json:"decided_by"`
+	Note      string `json:"note"`
+}
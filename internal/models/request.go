@@ -1,12 +1,45 @@
 package models
 
 type CreateAccountRequest struct {
-	AccountID      int64   `json:"account_id"`
-	InitialBalance float64 `json:"initial_balance"`
+	AccountID      int64  `json:"account_id"`
+	InitialBalance Amount `json:"initial_balance"`
+}
+
+// TransactionLookupRequest lists the transactions to look up, each by its
+// transaction_ref or serial ID.
+type TransactionLookupRequest struct {
+	IDs []string `json:"ids"`
 }
 
 type TransactionRequest struct {
-	SourceAccountID      int64   `json:"source_account_id"`
-	DestinationAccountID int64   `json:"destination_account_id"`
-	Amount               float64 `json:"amount"`
-}
\ No newline at end of file
+	SourceAccountID      int64    `json:"source_account_id"`
+	DestinationAccountID int64    `json:"destination_account_id"`
+	Amount               Amount   `json:"amount"`
+	Tags                 []string `json:"tags,omitempty"`
+}
+
+// TransactionTagsRequest replaces the tags of a transaction; an empty list
+// removes them all.
+type TransactionTagsRequest struct {
+	Tags []string `json:"tags"`
+}
+
+// InboundPaymentRequest is a payment arriving from outside, e.g. from a clearing
+// account. Reference is the payer's reference, kept for matching if the payment
+// ends up in suspense.
+type InboundPaymentRequest struct {
+	TransactionRequest
+	Reference string `json:"reference"`
+}
+
+// RepostRequest moves a suspense item to AccountID, or to the account it was
+// intended for when AccountID is zero.
+type RepostRequest struct {
+	AccountID int64 `json:"account_id"`
+}
+
+type RecomputeBalanceRequest struct {
+	Apply      bool       `json:"apply"`
+	ReasonCode ReasonCode `json:"reason_code"`
+	Reason     string     `json:"reason"`
+}
@@ -0,0 +1,122 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/money"
+)
+
+// FXRate is an end-of-day exchange rate: one unit of Currency is worth Rate
+// units of BaseCurrency at the close of Date.
+type FXRate struct {
+	Currency     string    `json:"currency"`
+	BaseCurrency string    `json:"base_currency"`
+	Date         string    `json:"date"`
+	Rate         float64   `json:"rate"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// FXRateRequest is the body of PUT /admin/fx/rates/{date}.
+type FXRateRequest struct {
+	Rate float64 `json:"rate"`
+}
+
+// RevaluationRequest is the body of POST /admin/revaluations.
+type RevaluationRequest struct {
+	Date string `json:"date"`
+}
+
+// Revaluation marks the balances held at the close of Date to BaseCurrency at
+// Rate. Balance is in the ledger currency; BaseValue, Gain and Loss are in
+// BaseCurrency, rounded to its minor units with RoundingMode. Gain and Loss are
+// the unrealized gains and losses since the previous revaluation, at
+// PreviousRate, posted to GainAccountID and LossAccountID.
+type Revaluation struct {
+	ID            int64
+	Date          string
+	Currency      string
+	BaseCurrency  string
+	Rate          float64
+	PreviousRate  *float64
+	Balance       Amount
+	BaseValue     float64
+	Gain          float64
+	Loss          float64
+	GainAccountID int64
+	LossAccountID int64
+	RoundingMode  money.Mode
+	// Positions totals the revaluation by account type. It is only filled in
+	// when a single revaluation is read.
+	Positions []RevaluationPosition
+	CreatedAt time.Time
+}
+
+// RevaluationPosition is the part of a revaluation that falls on the accounts
+// of one type. GainLoss is positive when their base value rose.
+type RevaluationPosition struct {
+	AccountType AccountType
+	Accounts    int64
+	Balance     Amount
+	BaseValue   float64
+	GainLoss    float64
+}
+
+// MarshalJSON formats the base currency amounts with the base currency's minor
+// units, and adds the net of the gain and the loss.
+func (r Revaluation) MarshalJSON() ([]byte, error) {
+	base, err := LookupCurrency(r.BaseCurrency)
+	if err != nil {
+		return nil, err
+	}
+	type position struct {
+		AccountType AccountType `json:"account_type"`
+		Accounts    int64       `json:"accounts"`
+		Balance     Amount      `json:"balance"`
+		BaseValue   string      `json:"base_value"`
+		GainLoss    string      `json:"gain_loss"`
+	}
+	var positions []position
+	if r.Positions != nil {
+		positions = make([]position, len(r.Positions))
+		for i, p := range r.Positions {
+			positions[i] = position{p.AccountType, p.Accounts, p.Balance, base.Format(p.BaseValue), base.Format(p.GainLoss)}
+		}
+	}
+	return json.Marshal(struct {
+		ID            int64      `json:"id"`
+		Date          string     `json:"date"`
+		Currency      string     `json:"currency"`
+		BaseCurrency  string     `json:"base_currency"`
+		Rate          float64    `json:"rate"`
+		PreviousRate  *float64   `json:"previous_rate"`
+		Balance       Amount     `json:"balance"`
+		BaseValue     string     `json:"base_value"`
+		Gain          string     `json:"gain"`
+		Loss          string     `json:"loss"`
+		Net           string     `json:"net"`
+		GainAccountID int64      `json:"gain_account_id"`
+		LossAccountID int64      `json:"loss_account_id"`
+		RoundingMode  money.Mode `json:"rounding_mode"`
+		Positions     []position `json:"positions,omitempty"`
+		CreatedAt     time.Time  `json:"created_at"`
+	}{
+		r.ID, r.Date, r.Currency, r.BaseCurrency, r.Rate, r.PreviousRate, r.Balance,
+		base.Format(r.BaseValue), base.Format(r.Gain), base.Format(r.Loss), base.Format(r.Gain - r.Loss),
+		r.GainAccountID, r.LossAccountID, r.RoundingMode, positions, r.CreatedAt,
+	})
+}
+
+// FXQuote is a live exchange rate from the rate provider: one unit of Currency
+// is worth Rate units of BaseCurrency as of AsOf, the time the provider
+// published it. FetchedAt is when it was last fetched; Stale is set once AsOf
+// is older than the staleness threshold, and a stale rate is not used for
+// conversions.
+type FXQuote struct {
+	Currency     string    `json:"currency"`
+	BaseCurrency string    `json:"base_currency"`
+	Rate         float64   `json:"rate"`
+	AsOf         time.Time `json:"as_of"`
+	FetchedAt    time.Time `json:"fetched_at"`
+	Stale        bool      `json:"stale"`
+}
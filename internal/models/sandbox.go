@@ -0,0 +1,8 @@
+package models
+
+// SandboxPurge is the outcome of purging test-mode data: how many test-mode
+// accounts and transactions were deleted.
+type SandboxPurge struct {
+	Accounts     int64 `json:"accounts"`
+	Transactions int64 `json:"transactions"`
+}
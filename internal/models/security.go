@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// Security event types. Security events are recorded apart from the business
+// audit trail, for a SIEM to ingest.
+const (
+	SecurityAuthFailure     = "security.auth_failure"
+	SecurityAuthLockout     = "security.auth_lockout"
+	SecurityTokenRevoked    = "security.token_revoked"
+	SecurityTransfersFrozen = "security.transfers_frozen"
+	SecurityFreezeLifted    = "security.freeze_lifted"
+	SecurityImpersonation   = "security.impersonation"
+)
+
+// SecurityEvent is a security-relevant occurrence. Actor is who caused it: an
+// API key, a client address or an operator. Detail describes it for a human.
+type SecurityEvent struct {
+	ID        int64     `json:"id"`
+	Type      string    `json:"type"`
+	Actor     string    `json:"actor,omitempty"`
+	Tenant    string    `json:"tenant,omitempty"`
+	Address   string    `json:"address,omitempty"`
+	RequestID string    `json:"request_id,omitempty"`
+	Detail    string    `json:"detail,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SecurityEventPage is a page of the security event log. NextAfterID is the
+// after_id of the next page: the ID of the last event, or the requested
+// after_id when there is none.
+type SecurityEventPage struct {
+	Events      []SecurityEvent `json:"events"`
+	NextAfterID int64           `json:"next_after_id"`
+	HasMore     bool            `json:"has_more"`
+}
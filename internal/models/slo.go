@@ -0,0 +1,43 @@
+package models
+
+// SLO objective names.
+const (
+	// SLOAvailability is the share of transfers that do not fail on the
+	// service's side. Rejections of the request itself, such as insufficient
+	// funds, count as successes.
+	SLOAvailability = "availability"
+	// SLOLatency is the share of transfers answered within the latency threshold.
+	SLOLatency = "latency"
+)
+
+// SLOReport is the state of the transfer endpoint's service level objectives.
+type SLOReport struct {
+	// Period is the rolling window the error budget is spent over, e.g. "30d".
+	Period     string         `json:"period"`
+	Objectives []SLOObjective `json:"objectives"`
+}
+
+// SLOObjective is one objective and how it is doing.
+type SLOObjective struct {
+	Name   string  `json:"name"`
+	Target float64 `json:"target"`
+	// Threshold is the latency a good transfer is answered within, for the
+	// latency objective.
+	Threshold string `json:"threshold,omitempty"`
+	// ErrorBudgetRemaining is the share of the period's error budget left: 1
+	// with no bad transfers, 0 once the objective is missed, and negative past it.
+	ErrorBudgetRemaining float64     `json:"error_budget_remaining"`
+	Windows              []SLOWindow `json:"windows"`
+}
+
+// SLOWindow is the service level indicator of an objective over a rolling window.
+type SLOWindow struct {
+	Window string `json:"window"`
+	Total  int64  `json:"total"`
+	Bad    int64  `json:"bad"`
+	// SLI is the share of good transfers, 1 when there were none.
+	SLI float64 `json:"sli"`
+	// BurnRate is how fast the error budget is spent: 1 spends exactly the
+	// budget over the period, 10 spends it in a tenth of it.
+	BurnRate float64 `json:"burn_rate"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// SpendingToken delegates spending from AccountID, e.g. to a virtual card. A
+// debit made with the token must fit in what is left of Limit, be made before
+// ExpiresAt, and name one of MerchantCategories unless that is empty. Spent is
+// what the token has debited so far.
+type SpendingToken struct {
+	ID                 string     `json:"id"`
+	AccountID          int64      `json:"account_id"`
+	Limit              Amount     `json:"limit"`
+	Spent              Amount     `json:"spent"`
+	MerchantCategories []string   `json:"merchant_categories"`
+	ExpiresAt          *time.Time `json:"expires_at,omitempty"`
+	RevokedAt          *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt          time.Time  `json:"created_at"`
+}
+
+// SpendingTokenRequest is the body of POST /accounts/{id}/tokens. A token
+// without ExpiresAt does not expire; empty MerchantCategories allows all.
+type SpendingTokenRequest struct {
+	Limit              Amount     `json:"limit"`
+	ExpiresAt          *time.Time `json:"expires_at"`
+	MerchantCategories []string   `json:"merchant_categories"`
+}
+
+// TokenDebitRequest is the body of POST /tokens/{id}/debits: a transfer from the
+// token's account to DestinationAccountID at a merchant of MerchantCategory.
+type TokenDebitRequest struct {
+	DestinationAccountID int64  `json:"destination_account_id"`
+	Amount               Amount `json:"amount"`
+	MerchantCategory     string `json:"merchant_category"`
+}
+
+// TokenDebit is the result of a debit made with a spending token: the transfer
+// and the token with the debit added to what it has spent.
+type TokenDebit struct {
+	TransactionID string         `json:"transaction_id"`
+	Token         *SpendingToken `json:"token"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// SuspenseReason says why an inbound payment could not be credited to its destination.
+type SuspenseReason string
+
+const (
+	SuspenseAccountNotFound SuspenseReason = "account_not_found"
+	SuspenseAccountClosed   SuspenseReason = "account_closed"
+)
+
+// SuspenseItem is an inbound payment parked on the suspense account. Once
+// reposted, RepostedTo and RepostTransactionID record where the funds went.
+type SuspenseItem struct {
+	ID                  int64          `json:"id"`
+	SourceAccountID     int64          `json:"source_account_id"`
+	IntendedAccountID   int64          `json:"intended_account_id"`
+	Amount              Amount         `json:"amount"`
+	Reason              SuspenseReason `json:"reason"`
+	Reference           string         `json:"reference,omitempty"`
+	TransactionID       string         `json:"transaction_id"`
+	CreatedAt           time.Time      `json:"created_at"`
+	RepostedTo          *int64         `json:"reposted_to,omitempty"`
+	RepostTransactionID string         `json:"repost_transaction_id,omitempty"`
+	ResolvedAt          *time.Time     `json:"resolved_at,omitempty"`
+}
+
+// SuspenseItemPage is one page of the unresolved suspense items.
+type SuspenseItemPage struct {
+	Items      []SuspenseItem `json:"items"`
+	NextCursor string         `json:"next_cursor"`
+	HasMore    bool           `json:"has_more"`
+}
+
+// InboundPayment is the outcome of an inbound payment: credited to its
+// destination, or parked in suspense with SuspenseItem set.
+type InboundPayment struct {
+	TransactionID string        `json:"transaction_id"`
+	SuspenseItem  *SuspenseItem `json:"suspense_item,omitempty"`
+}
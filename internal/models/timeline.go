@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// AccountEventType is the kind of an entry of an account's timeline.
+type AccountEventType string
+
+const (
+	// AccountEventOpened is the opening of the account, with its opening balance.
+	AccountEventOpened AccountEventType = "account.opened"
+	// AccountEventClosed is a soft delete of the account.
+	AccountEventClosed AccountEventType = "account.closed"
+	// AccountEventRestored is the reopening of a closed account.
+	AccountEventRestored AccountEventType = "account.restored"
+	// AccountEventDebit is a transaction the account sent.
+	AccountEventDebit AccountEventType = "transaction.debit"
+	// AccountEventCredit is a transaction the account received.
+	AccountEventCredit AccountEventType = "transaction.credit"
+	// AccountEventAdjustment is a balance adjustment, e.g. a reconciliation
+	// correction.
+	AccountEventAdjustment AccountEventType = "adjustment"
+	// AccountEventTokenIssued is a spending token issued on the account, with
+	// the limit it may spend.
+	AccountEventTokenIssued AccountEventType = "token.issued"
+	// AccountEventTokenRevoked is the revocation of a spending token.
+	AccountEventTokenRevoked AccountEventType = "token.revoked"
+)
+
+// AccountEvent is an entry of an account's timeline. Amount is the change to
+// the balance, negative for a debit, and the opening balance of an opening.
+// The other fields are set by the types they belong to.
+type AccountEvent struct {
+	Type       AccountEventType `json:"type"`
+	OccurredAt time.Time        `json:"occurred_at"`
+	Amount     *Amount          `json:"amount,omitempty"`
+
+	TransactionID         string          `json:"transaction_id,omitempty"`
+	Kind                  TransactionKind `json:"kind,omitempty"`
+	CounterpartyAccountID int64           `json:"counterparty_account_id,omitempty"`
+
+	AdjustmentID string     `json:"adjustment_id,omitempty"`
+	ReasonCode   ReasonCode `json:"reason_code,omitempty"`
+	Reason       string     `json:"reason,omitempty"`
+
+	TokenID string  `json:"token_id,omitempty"`
+	Limit   *Amount `json:"limit,omitempty"`
+}
+
+// AccountTimeline is a page of an account's timeline, oldest event first.
+type AccountTimeline struct {
+	AccountID  int64          `json:"account_id"`
+	Events     []AccountEvent `json:"events"`
+	NextCursor string         `json:"next_cursor"`
+	HasMore    bool           `json:"has_more"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// TopUpRule keeps an operational account from running dry: once a debit leaves
+// the balance of AccountID below Threshold, Amount is moved to it from
+// FundingAccountID as a top_up transaction.
+type TopUpRule struct {
+	AccountID        int64     `json:"account_id"`
+	Threshold        Amount    `json:"threshold"`
+	Amount           Amount    `json:"amount"`
+	FundingAccountID int64     `json:"funding_account_id"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// SetTopUpRuleRequest is the body of PUT /admin/accounts/{id}/top-up.
+type SetTopUpRuleRequest struct {
+	Threshold        Amount `json:"threshold"`
+	Amount           Amount `json:"amount"`
+	FundingAccountID int64  `json:"funding_account_id"`
+}
@@ -0,0 +1,106 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/money"
+)
+
+// TransactionKind tells what a transaction was posted for.
+type TransactionKind string
+
+const (
+	// TransactionTransfer is a transfer requested by a client.
+	TransactionTransfer TransactionKind = "transfer"
+	// TransactionTopUp refills an account under its top-up rule.
+	TransactionTopUp TransactionKind = "top_up"
+	// TransactionCashback credits an account under a cashback campaign.
+	TransactionCashback TransactionKind = "cashback"
+)
+
+// Transaction is a row of the transaction log. ID is the public identifier: the
+// transaction_ref when one was generated, otherwise the serial key. Counterparty
+// is only set in account statements.
+type Transaction struct {
+	ID                   string          `json:"id"`
+	Ref                  string          `json:"transaction_ref,omitempty"`
+	Kind                 TransactionKind `json:"kind"`
+	SourceAccountID      int64           `json:"source_account_id"`
+	DestinationAccountID int64           `json:"destination_account_id"`
+	Amount               Amount          `json:"amount"`
+	CreatedAt            time.Time       `json:"created_at"`
+	UpdatedAt            time.Time       `json:"updated_at"`
+	Counterparty         *Counterparty   `json:"counterparty,omitempty"`
+	// Rounding records how Amount was rounded when the ledger computed it, e.g.
+	// for a cashback credit.
+	Rounding *money.Rounding `json:"rounding,omitempty"`
+	// Tags are the labels the transaction was marked with, in lexical order.
+	Tags []string `json:"tags,omitempty"`
+	// Livemode is false for the transactions between test-mode accounts.
+	Livemode bool `json:"livemode"`
+}
+
+// TransactionFilter narrows a transaction listing to the transactions updated
+// after UpdatedSince and, when Tag is set, marked with Tag. Listings hold either
+// live transactions or, with TestMode set, test-mode ones.
+type TransactionFilter struct {
+	UpdatedSince time.Time
+	Tag          string
+	TestMode     bool
+}
+
+// TransactionTag is a tag of the transfers of an account, with how many of them
+// carry it.
+type TransactionTag struct {
+	Tag          string `json:"tag"`
+	Transactions int64  `json:"transactions"`
+}
+
+// Counterparty is the account on the other side of a transfer in an account
+// statement. DisplayName is masked, or left out and Redacted set, when the
+// caller may not see it in full; Deleted is set when the account is closed.
+type Counterparty struct {
+	AccountID   int64  `json:"account_id"`
+	DisplayName string `json:"display_name,omitempty"`
+	Redacted    bool   `json:"redacted,omitempty"`
+	Deleted     bool   `json:"deleted,omitempty"`
+}
+
+// MarshalJSON adds the currency and its minor units next to the amount.
+func (t Transaction) MarshalJSON() ([]byte, error) {
+	type transaction Transaction
+	return json.Marshal(struct {
+		transaction
+		Currency
+	}{transaction(t), CurrentCurrency()})
+}
+
+// TransactionLookup answers a bulk lookup: the transactions found, in the order
+// they were asked for, and the IDs that matched none.
+type TransactionLookup struct {
+	Transactions []Transaction `json:"transactions"`
+	NotFound     []string      `json:"not_found"`
+}
+
+// TransactionPage is one page of a transaction listing or of the change feed.
+// NextCursor is returned even when the page is empty so feed clients can keep
+// polling from it.
+type TransactionPage struct {
+	Transactions []Transaction `json:"transactions"`
+	NextCursor   string        `json:"next_cursor"`
+	HasMore      bool          `json:"has_more"`
+}
+
+// TransactionCapabilities describes what POST /transactions accepts, for clients
+// that configure themselves from OPTIONS /transactions.
+type TransactionCapabilities struct {
+	Currencies []Currency `json:"currencies"`
+	// MinAmount is the smallest amount a single transfer may move; nil means no
+	// minimum beyond one minor unit.
+	MinAmount *Amount `json:"min_amount"`
+	// MaxAmount is the largest amount a single transfer may move; nil means no limit.
+	MaxAmount *Amount `json:"max_amount"`
+	// Idempotency reports whether retried requests can be deduplicated by key.
+	Idempotency bool `json:"idempotency"`
+}
@@ -0,0 +1,101 @@
+package models
+
+import "time"
+
+// TransactionAttempt is a transfer that was rejected. Code says why; Error is the
+// message returned to the caller. APIKey and Tenant identify the requester, and
+// RequestID the request.
+type TransactionAttempt struct {
+	ID                   int64      `json:"id"`
+	SourceAccountID      int64      `json:"source_account_id"`
+	DestinationAccountID int64      `json:"destination_account_id"`
+	Amount               Amount     `json:"amount"`
+	Code                 ReasonCode `json:"code"`
+	Error                string     `json:"error,omitempty"`
+	APIKey               string     `json:"api_key"`
+	Tenant               string     `json:"tenant"`
+	RequestID            string     `json:"request_id,omitempty"`
+	CreatedAt            time.Time  `json:"created_at"`
+}
+
+// AttemptGrouping is the dimension transaction attempt stats are grouped by.
+type AttemptGrouping string
+
+const (
+	GroupByReasonCode AttemptGrouping = "reason_code"
+	GroupByAPIKey     AttemptGrouping = "api_key"
+	GroupByTenant     AttemptGrouping = "tenant"
+)
+
+// Valid reports whether g is a known grouping.
+func (g AttemptGrouping) Valid() bool {
+	switch g {
+	case GroupByReasonCode, GroupByAPIKey, GroupByTenant:
+		return true
+	}
+	return false
+}
+
+// TransactionAttemptFilter narrows the transaction attempts listing and stats.
+// Zero fields match all; AccountID matches either side of the transfer, and
+// Until is exclusive.
+type TransactionAttemptFilter struct {
+	Code      ReasonCode
+	APIKey    string
+	Tenant    string
+	AccountID int64
+	Since     time.Time
+	Until     time.Time
+}
+
+// TransactionAttemptPage is one page of rejected transaction attempts.
+type TransactionAttemptPage struct {
+	Attempts   []TransactionAttempt `json:"attempts"`
+	NextCursor string               `json:"next_cursor"`
+	HasMore    bool                 `json:"has_more"`
+}
+
+// TransactionAttemptStat counts the attempts sharing one value of the grouping,
+// e.g. all insufficient_funds rejections, and sums their amounts.
+type TransactionAttemptStat struct {
+	Key         string `json:"key"`
+	Attempts    int64  `json:"attempts"`
+	TotalAmount Amount `json:"total_amount"`
+}
+
+// RequestRecord is what was recorded for one request, found by its X-Request-ID.
+type RequestRecord struct {
+	RequestID           string               `json:"request_id"`
+	TransactionAttempts []TransactionAttempt `json:"transaction_attempts"`
+}
+
+// ReplayOutcome is what replaying a rejected transfer did.
+type ReplayOutcome string
+
+const (
+	// ReplayPlanned is a dry run: the attempt would be replayed.
+	ReplayPlanned ReplayOutcome = "planned"
+	// ReplayCompleted means the transfer went through this time.
+	ReplayCompleted ReplayOutcome = "completed"
+	// ReplayDuplicate means the attempt had already been replayed; nothing moved.
+	ReplayDuplicate ReplayOutcome = "already_replayed"
+	// ReplayRejected means the transfer was refused again, with Code saying why.
+	ReplayRejected ReplayOutcome = "rejected"
+	// ReplayFailed means the replay could not be carried out, e.g. the database
+	// was unavailable; it is safe to retry.
+	ReplayFailed ReplayOutcome = "failed"
+)
+
+// TransactionAttemptReplay is the result of replaying one rejected transfer.
+type TransactionAttemptReplay struct {
+	AttemptID            int64         `json:"attempt_id"`
+	SourceAccountID      int64         `json:"source_account_id"`
+	DestinationAccountID int64         `json:"destination_account_id"`
+	Amount               Amount        `json:"amount"`
+	OriginalCode         ReasonCode    `json:"original_code"`
+	Outcome              ReplayOutcome `json:"outcome"`
+	// TransactionID is the transfer that replayed the attempt, now or before.
+	TransactionID string     `json:"transaction_id,omitempty"`
+	Code          ReasonCode `json:"code,omitempty"`
+	Error         string     `json:"error,omitempty"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// TransferIntentKind is the follow-up transfer a transfer intent is for.
+type TransferIntentKind string
+
+const (
+	// TransferIntentTopUp refills the source account of a transfer that left it
+	// below the threshold of its top-up rule.
+	TransferIntentTopUp TransferIntentKind = "top_up"
+	// TransferIntentCashback credits the source account of a transfer with the
+	// cashback it earns.
+	TransferIntentCashback TransferIntentKind = "cashback"
+)
+
+// TransferIntent is a follow-up transfer the server owes AccountID once the
+// transfer TransactionID has committed. Amount is the top-up amount, or the
+// amount of the transfer earning cashback. An intent is pending until
+// CompletedAt is set, which happens in the database transaction of the
+// follow-up itself.
+type TransferIntent struct {
+	ID            int64              `json:"id"`
+	Kind          TransferIntentKind `json:"kind"`
+	AccountID     int64              `json:"account_id"`
+	TransactionID string             `json:"transaction_id"`
+	Amount        Amount             `json:"amount"`
+	CreatedAt     time.Time          `json:"created_at"`
+	CompletedAt   *time.Time         `json:"completed_at,omitempty"`
+}
@@ -0,0 +1,84 @@
+package models
+
+import "time"
+
+// AccountType classifies accounts by what they are used for in treasury
+// reports.
+type AccountType string
+
+const (
+	// AccountTypeCustomer is an account of a client of the system.
+	AccountTypeCustomer AccountType = "customer"
+	// AccountTypeFunding funds top-up rules or cashback campaigns.
+	AccountTypeFunding AccountType = "funding"
+	// AccountTypeSuspense holds inbound payments until they are reposted.
+	AccountTypeSuspense AccountType = "suspense"
+	// AccountTypeReimbursement pays approved expense reimbursements.
+	AccountTypeReimbursement AccountType = "reimbursement"
+	// AccountTypeExternal is the other side of a transfer that has no account
+	// row, such as the clearing account inbound payments arrive from.
+	AccountTypeExternal AccountType = "external"
+)
+
+// TreasuryPosition is the money held in the accounts of one type in one
+// currency.
+type TreasuryPosition struct {
+	Currency    string      `json:"currency"`
+	AccountType AccountType `json:"account_type"`
+	Accounts    int64       `json:"accounts"`
+	Balance     Amount      `json:"balance"`
+}
+
+// CurrencyTotal is the money held across all accounts in a currency.
+type CurrencyTotal struct {
+	Currency string `json:"currency"`
+	Balance  Amount `json:"balance"`
+}
+
+// TreasuryFloat is the balance of a suspense or reimbursement account next to
+// what is outstanding on it: unresolved suspense items, or reimbursements
+// awaiting a decision.
+type TreasuryFloat struct {
+	AccountType      AccountType `json:"account_type"`
+	AccountID        int64       `json:"account_id"`
+	Balance          Amount      `json:"balance"`
+	Outstanding      Amount      `json:"outstanding"`
+	OutstandingItems int64       `json:"outstanding_items"`
+}
+
+// TreasuryPositions is the response of GET /admin/treasury/positions.
+type TreasuryPositions struct {
+	AsOf      time.Time          `json:"as_of"`
+	Totals    []CurrencyTotal    `json:"totals"`
+	Positions []TreasuryPosition `json:"positions"`
+	Float     []TreasuryFloat    `json:"float"`
+}
+
+// TreasuryAccount is an entry of the largest accounts report.
+type TreasuryAccount struct {
+	AccountID   int64       `json:"account_id"`
+	DisplayName string      `json:"display_name,omitempty"`
+	AccountType AccountType `json:"account_type"`
+	Currency    string      `json:"currency"`
+	Balance     Amount      `json:"balance"`
+}
+
+// DailyFlow is the money that moved into and out of the accounts of one type on
+// one calendar day. Net is Inflow less Outflow.
+type DailyFlow struct {
+	Date        string      `json:"date"`
+	AccountType AccountType `json:"account_type"`
+	Inflow      Amount      `json:"inflow"`
+	Outflow     Amount      `json:"outflow"`
+	Net         Amount      `json:"net"`
+}
+
+// TreasuryFlows is the response of GET /admin/treasury/flows: the daily flows
+// from From to To, calendar days in Timezone.
+type TreasuryFlows struct {
+	Currency string      `json:"currency"`
+	Timezone string      `json:"timezone"`
+	From     string      `json:"from"`
+	To       string      `json:"to"`
+	Flows    []DailyFlow `json:"flows"`
+}
@@ -0,0 +1,76 @@
+package models
+
+import "time"
+
+// UsagePeriodLayout formats the calendar month usage is metered in, e.g. "2024-05".
+const UsagePeriodLayout = "2006-01"
+
+// Usage is the metered activity of one API key and tenant in a calendar month.
+type Usage struct {
+	Period    string    `json:"period"`
+	APIKey    string    `json:"api_key"`
+	Tenant    string    `json:"tenant"`
+	Calls     int64     `json:"calls"`
+	Volume    Amount    `json:"volume"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// QuotaScope is what a quota limits: a single API key or a whole tenant.
+type QuotaScope string
+
+const (
+	QuotaScopeAPIKey QuotaScope = "api_key"
+	QuotaScopeTenant QuotaScope = "tenant"
+)
+
+// Valid reports whether s is a known scope.
+func (s QuotaScope) Valid() bool {
+	return s == QuotaScopeAPIKey || s == QuotaScopeTenant
+}
+
+// Quota is the monthly limit on calls and transfer volume of an API key or
+// tenant. Zero means unlimited.
+type Quota struct {
+	Scope         QuotaScope `json:"scope"`
+	Subject       string     `json:"subject"`
+	MonthlyCalls  int64      `json:"monthly_calls"`
+	MonthlyVolume Amount     `json:"monthly_volume"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// SetQuotaRequest is the body of PUT /admin/quotas/{scope}/{subject}.
+type SetQuotaRequest struct {
+	MonthlyCalls  int64  `json:"monthly_calls"`
+	MonthlyVolume Amount `json:"monthly_volume"`
+}
+
+// QuotaStatus is a quota with the usage recorded against it this month.
+type QuotaStatus struct {
+	Quota
+	Period     string `json:"period"`
+	UsedCalls  int64  `json:"used_calls"`
+	UsedVolume Amount `json:"used_volume"`
+}
+
+// AccountLimit overrides the server-wide limit on how many open accounts a
+// tenant may have. Zero means unlimited.
+type AccountLimit struct {
+	Tenant      string    `json:"tenant"`
+	MaxAccounts int64     `json:"max_accounts"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// SetAccountLimitRequest is the body of PUT /admin/account-limits/{tenant}.
+type SetAccountLimitRequest struct {
+	MaxAccounts int64 `json:"max_accounts"`
+}
+
+// AccountLimitStatus is the account limit in force for a tenant with the
+// accounts it has open. Override is set when the limit is the tenant's own
+// rather than the server-wide default.
+type AccountLimitStatus struct {
+	Tenant       string `json:"tenant"`
+	MaxAccounts  int64  `json:"max_accounts"`
+	Override     bool   `json:"override"`
+	OpenAccounts int64  `json:"open_accounts"`
+}
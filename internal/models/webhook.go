@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// Event types sent to webhook URLs outside the outbox: the registration
+// handshake and test pings.
+const (
+	EventWebhookVerification = "webhook.verification"
+	EventWebhookPing         = "webhook.ping"
+)
+
+// Webhook is a tenant's subscription to events, delivered by POST to URL.
+type Webhook struct {
+	ID     int64  `json:"id"`
+	Tenant string `json:"tenant"`
+	URL    string `json:"url"`
+	// AccountID is the account whose events are delivered, for a webhook its
+	// owner subscribed; nil means the events of every account of the tenant.
+	AccountID *int64 `json:"account_id,omitempty"`
+	// EventTypes are the event types delivered; empty means all.
+	EventTypes []string  `json:"event_types"`
+	VerifiedAt time.Time `json:"verified_at"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// WebhookRequest is the body of POST /webhooks.
+type WebhookRequest struct {
+	URL        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+	// AccountID subscribes the webhook to the events of that account alone.
+	// Only the API key that opened the account may.
+	AccountID *int64 `json:"account_id,omitempty"`
+}
+
+// WebhookVerification is the body of the handshake request sent to a URL being
+// registered. The subscriber proves it controls the URL by answering 2xx with the
+// challenge, either as the raw body or as {"challenge": "..."}.
+type WebhookVerification struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+}
+
+// WebhookPing is the result of sending a test event to a webhook.
+type WebhookPing struct {
+	WebhookID  int64  `json:"webhook_id"`
+	Delivered  bool   `json:"delivered"`
+	StatusCode int    `json:"status_code,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
+}
@@ -0,0 +1,77 @@
+package money
+
+import (
+	"errors"
+	"math/big"
+	"sort"
+)
+
+// ErrInvalidWeights is returned for allocation weights that are empty,
+// negative or all zero.
+var ErrInvalidWeights = errors.New("invalid allocation weights")
+
+// AllocateMinor splits total minor units in proportion to weights, e.g. basis
+// points of a percentage split, with the largest-remainder method: each share
+// is first rounded towards zero, and the units left over go one each to the
+// shares with the largest remainders, the earliest share first among equal
+// remainders. The shares always add up to total, and the same inputs always
+// split the same way. A negative total is split as its absolute value and the
+// shares negated.
+func AllocateMinor(total int64, weights []int64) ([]int64, error) {
+	if len(weights) == 0 {
+		return nil, ErrInvalidWeights
+	}
+	sum := new(big.Int)
+	for _, w := range weights {
+		if w < 0 {
+			return nil, ErrInvalidWeights
+		}
+		sum.Add(sum, big.NewInt(w))
+	}
+	if sum.Sign() == 0 {
+		return nil, ErrInvalidWeights
+	}
+
+	abs := new(big.Int).Abs(big.NewInt(total))
+	shares := make([]int64, len(weights))
+	remainders := make([]*big.Int, len(weights))
+	left := abs.Int64()
+	for i, w := range weights {
+		q, r := new(big.Int).QuoRem(new(big.Int).Mul(abs, big.NewInt(w)), sum, new(big.Int))
+		shares[i], remainders[i] = q.Int64(), r
+		left -= shares[i]
+	}
+	order := make([]int, len(weights))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return remainders[order[a]].Cmp(remainders[order[b]]) > 0
+	})
+	// left is less than len(weights): each share lost less than one unit.
+	for _, i := range order[:left] {
+		shares[i]++
+	}
+	if total < 0 {
+		for i := range shares {
+			shares[i] = -shares[i]
+		}
+	}
+	return shares, nil
+}
+
+// Allocate splits total, in major units, in proportion to weights with
+// AllocateMinor, after rounding it to the policy's minor units. The shares
+// are in major units and add up to the rounded total exactly.
+func (p Policy) Allocate(total float64, weights []int64) ([]float64, error) {
+	minor, err := AllocateMinor(p.ToMinor(total), weights)
+	if err != nil {
+		return nil, err
+	}
+	scale := float64(p.Scale())
+	shares := make([]float64, len(minor))
+	for i, m := range minor {
+		shares[i] = float64(m) / scale
+	}
+	return shares, nil
+}
@@ -0,0 +1,69 @@
+package money
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllocateMinor(t *testing.T) {
+	tests := []struct {
+		total   int64
+		weights []int64
+		want    []int64
+	}{
+		{100, []int64{1, 1, 1}, []int64{34, 33, 33}},
+		{-100, []int64{1, 1, 1}, []int64{-34, -33, -33}},
+		{1000, []int64{3333, 3333, 3334}, []int64{333, 333, 334}},
+		// Remainders 0.5, 0.5 and 0: the earlier share gets the unit.
+		{5, []int64{1, 1, 0}, []int64{3, 2, 0}},
+		{101, []int64{7000, 2000, 1000}, []int64{71, 20, 10}},
+		{2, []int64{1, 1, 1, 1, 1}, []int64{1, 1, 0, 0, 0}},
+		{0, []int64{1, 2}, []int64{0, 0}},
+		{9_000_000_000_000_000_000, []int64{1, 1}, []int64{4_500_000_000_000_000_000, 4_500_000_000_000_000_000}},
+	}
+	for _, tt := range tests {
+		got, err := AllocateMinor(tt.total, tt.weights)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, got, "%d by %v", tt.total, tt.weights)
+		var sum int64
+		for _, s := range got {
+			sum += s
+		}
+		assert.Equal(t, tt.total, sum)
+	}
+
+	for _, weights := range [][]int64{nil, {0, 0}, {1, -1}} {
+		_, err := AllocateMinor(100, weights)
+		assert.ErrorIs(t, err, ErrInvalidWeights, "%v", weights)
+	}
+}
+
+func TestPolicyAllocate(t *testing.T) {
+	got, err := Policy{MinorUnits: 2, Mode: HalfUp}.Allocate(10, []int64{1, 1, 1})
+	require.NoError(t, err)
+	assert.Equal(t, []float64{3.34, 3.33, 3.33}, got)
+
+	got, err = Policy{MinorUnits: 0, Mode: HalfUp}.Allocate(1000, []int64{1, 1, 1})
+	require.NoError(t, err)
+	assert.Equal(t, []float64{334, 333, 333}, got)
+}
+
+func BenchmarkAllocateMinor(b *testing.B) {
+	for _, n := range []int{3, 100, 10000} {
+		weights := make([]int64, n)
+		for i := range weights {
+			weights[i] = int64(i%7 + 1)
+		}
+		b.Run(strconv.Itoa(n), func(b *testing.B) {
+			b.ReportAllocs()
+			for range b.N {
+				if _, err := AllocateMinor(1_000_000_007, weights); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+// Package money holds the rounding policy of each currency: the number of
+// decimal places (minor units) its amounts are quoted with, and how amounts the
+// ledger computes rather than receives, such as cashback and FX conversions,
+// are rounded to them. Policies are process-wide and set at startup, before
+// the ledger currency is. Amounts split by weight are allocated so that the
+// shares add up to the whole to the minor unit.
+package money
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+)
+
+// Mode is how an amount is rounded to a currency's minor units.
+type Mode string
+
+const (
+	// HalfUp rounds to the nearest minor unit, halves away from zero.
+	HalfUp Mode = "half_up"
+	// HalfEven rounds to the nearest minor unit, halves to the even one
+	// (banker's rounding).
+	HalfEven Mode = "half_even"
+	// Truncate drops the digits past the minor unit, rounding towards zero.
+	Truncate Mode = "truncate"
+)
+
+// MaxMinorUnits is the most decimal places amounts are stored with.
+const MaxMinorUnits = 5
+
+// ParseMode parses half_up, half_even or truncate.
+func ParseMode(s string) (Mode, error) {
+	switch m := Mode(strings.ToLower(strings.TrimSpace(s))); m {
+	case HalfUp, HalfEven, Truncate:
+		return m, nil
+	}
+	return "", fmt.Errorf("invalid rounding mode %q: must be half_up, half_even or truncate", s)
+}
+
+// Policy is how the amounts of a currency are rounded.
+type Policy struct {
+	MinorUnits int  `json:"minor_units"`
+	Mode       Mode `json:"rounding_mode"`
+}
+
+// Rounding records how a computed amount was rounded: Unrounded, the exact
+// result, was rounded to MinorUnits decimal places with Mode.
+type Rounding struct {
+	Mode       Mode    `json:"mode"`
+	MinorUnits int     `json:"minor_units"`
+	Unrounded  float64 `json:"unrounded"`
+}
+
+// minorUnits lists the ISO 4217 currencies that do not use two decimal places.
+var minorUnits = map[string]int{
+	"BHD": 3, "CLP": 0, "IQD": 3, "ISK": 0, "JOD": 3, "JPY": 0, "KRW": 0,
+	"KWD": 3, "LYD": 3, "OMR": 3, "PYG": 0, "TND": 3, "UGX": 0, "VND": 0,
+}
+
+var (
+	mu          sync.RWMutex
+	defaultMode = HalfUp
+	policies    = map[string]Policy{}
+)
+
+// SetDefaultMode sets the rounding mode of currencies without a policy of
+// their own.
+func SetDefaultMode(m Mode) error {
+	if _, err := ParseMode(string(m)); err != nil {
+		return err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	defaultMode = m
+	return nil
+}
+
+// SetPolicy sets the policy of the currency with ISO 4217 code code, overriding
+// its standard minor units and the default rounding mode.
+func SetPolicy(code string, p Policy) error {
+	if _, err := ParseMode(string(p.Mode)); err != nil {
+		return err
+	}
+	if p.MinorUnits < 0 || p.MinorUnits > MaxMinorUnits {
+		return fmt.Errorf("invalid minor units %d: must be between 0 and %d", p.MinorUnits, MaxMinorUnits)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	policies[strings.ToUpper(code)] = p
+	return nil
+}
+
+// ResetPolicies drops the policies set with SetPolicy and restores the default
+// rounding mode, half_up.
+func ResetPolicies() {
+	mu.Lock()
+	defer mu.Unlock()
+	defaultMode = HalfUp
+	policies = map[string]Policy{}
+}
+
+// PolicyOf returns the policy of the currency with ISO 4217 code code: the one
+// set for it, or else its standard minor units, two unless ISO 4217 says
+// otherwise, with the default rounding mode.
+func PolicyOf(code string) Policy {
+	code = strings.ToUpper(code)
+	mu.RLock()
+	defer mu.RUnlock()
+	if p, ok := policies[code]; ok {
+		return p
+	}
+	units, ok := minorUnits[code]
+	if !ok {
+		units = 2
+	}
+	return Policy{MinorUnits: units, Mode: defaultMode}
+}
+
+// Scale is the number of minor units in one major unit, e.g. 100 for two
+// decimal places.
+func (p Policy) Scale() int64 {
+	scale := int64(1)
+	for range p.MinorUnits {
+		scale *= 10
+	}
+	return scale
+}
+
+// ToMinor rounds v, in major units, to a whole number of minor units.
+func (p Policy) ToMinor(v float64) int64 {
+	// Computed amounts carry float error, e.g. 1.005 * 100 is 100.49999...; it
+	// is scrubbed before rounding so that exact halves round as halves.
+	x := math.Round(v*float64(p.Scale())*1e6) / 1e6
+	switch p.Mode {
+	case HalfEven:
+		x = math.RoundToEven(x)
+	case Truncate:
+		x = math.Trunc(x)
+	default:
+		x = math.Round(x)
+	}
+	return int64(x)
+}
+
+// Round rounds v to the policy's minor units and returns the rounded amount
+// along with a record of the rounding.
+func (p Policy) Round(v float64) (float64, Rounding) {
+	return float64(p.ToMinor(v)) / float64(p.Scale()), Rounding{Mode: p.Mode, MinorUnits: p.MinorUnits, Unrounded: v}
+}
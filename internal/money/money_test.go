@@ -0,0 +1,89 @@
+package money
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicyRound(t *testing.T) {
+	tests := []struct {
+		mode  Mode
+		units int
+		v     float64
+		want  float64
+	}{
+		{HalfUp, 2, 1.875, 1.88},
+		{HalfEven, 2, 1.875, 1.88},
+		{Truncate, 2, 1.875, 1.87},
+		{HalfUp, 2, 1.865, 1.87},
+		{HalfEven, 2, 1.865, 1.86},
+		{Truncate, 2, 1.869, 1.86},
+		{HalfUp, 2, -1.865, -1.87},
+		{HalfEven, 2, -1.865, -1.86},
+		{Truncate, 2, -1.869, -1.86},
+		// 1.005 is 1.00499999... as a float; it still rounds as a half.
+		{HalfUp, 2, 1.005, 1.01},
+		{HalfEven, 2, 1.005, 1.0},
+		{HalfUp, 0, 2.5, 3},
+		{HalfEven, 0, 2.5, 2},
+		{HalfEven, 3, 0.0125, 0.012},
+	}
+	for _, tt := range tests {
+		p := Policy{MinorUnits: tt.units, Mode: tt.mode}
+		got, rounding := p.Round(tt.v)
+		assert.Equal(t, tt.want, got, "%s of %v to %d places", tt.mode, tt.v, tt.units)
+		assert.Equal(t, Rounding{Mode: tt.mode, MinorUnits: tt.units, Unrounded: tt.v}, rounding)
+	}
+}
+
+func TestPolicyOf(t *testing.T) {
+	t.Cleanup(ResetPolicies)
+	assert.Equal(t, Policy{MinorUnits: 2, Mode: HalfUp}, PolicyOf("USD"))
+	assert.Equal(t, Policy{MinorUnits: 0, Mode: HalfUp}, PolicyOf("jpy"))
+	assert.Equal(t, Policy{MinorUnits: 3, Mode: HalfUp}, PolicyOf("KWD"))
+
+	require.NoError(t, SetDefaultMode(HalfEven))
+	require.NoError(t, SetPolicy("jpy", Policy{MinorUnits: 0, Mode: Truncate}))
+	assert.Equal(t, Policy{MinorUnits: 2, Mode: HalfEven}, PolicyOf("USD"))
+	assert.Equal(t, Policy{MinorUnits: 0, Mode: Truncate}, PolicyOf("JPY"))
+
+	assert.Error(t, SetDefaultMode("ceiling"))
+	assert.Error(t, SetPolicy("USD", Policy{MinorUnits: 6, Mode: HalfUp}))
+	assert.Error(t, SetPolicy("USD", Policy{MinorUnits: 2}))
+
+	ResetPolicies()
+	assert.Equal(t, Policy{MinorUnits: 0, Mode: HalfUp}, PolicyOf("JPY"))
+}
+
+func TestParseMode(t *testing.T) {
+	m, err := ParseMode(" Half_Even ")
+	require.NoError(t, err)
+	assert.Equal(t, HalfEven, m)
+	_, err = ParseMode("half_down")
+	assert.Error(t, err)
+}
+
+func BenchmarkPolicyRound(b *testing.B) {
+	for _, mode := range []Mode{HalfUp, HalfEven, Truncate} {
+		b.Run(string(mode), func(b *testing.B) {
+			p := Policy{MinorUnits: 2, Mode: mode}
+			for i := range b.N {
+				p.Round(float64(i) * 1.005)
+			}
+		})
+	}
+}
+
+func BenchmarkPolicyOf(b *testing.B) {
+	b.Cleanup(ResetPolicies)
+	if err := SetPolicy("JPY", Policy{MinorUnits: 0, Mode: Truncate}); err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	for range b.N {
+		PolicyOf("usd")
+		PolicyOf("JPY")
+	}
+}
@@ -0,0 +1,190 @@
+// Package outbox relays the events written to the outbox table to a message
+// broker. Delivery is at least once: the relay only moves its high-water mark
+// past events that were published, and publishers tag every message with
+// MessageID so the broker or consumers can drop the duplicates a crash or a
+// replay produces.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/metrics"
+	"github.com/nehciyy/intrapay/internal/models"
+)
+
+// EventPublisher delivers events to a broker.
+type EventPublisher interface {
+	// Publish delivers e and returns once the broker has accepted it. It must
+	// pass MessageID(e) along as the message's idempotency key.
+	Publish(ctx context.Context, e models.Event) error
+}
+
+// MessageID is the idempotent producer key of e: the same for every delivery of
+// the event, including replays.
+func MessageID(e models.Event) string {
+	return fmt.Sprintf("intrapay-event-%d", e.ID)
+}
+
+// Store reads the outbox and keeps the relay's high-water mark.
+type Store interface {
+	ListOutboxEvents(afterID int64, limit int) ([]models.Event, error)
+	GetOutboxRelay() (*models.OutboxRelayStatus, error)
+	AdvanceOutboxRelay(from, to int64) (bool, error)
+}
+
+// Relay publishes outbox events in id order, which is commit order within each
+// aggregate. Run a single relay per database: instances sharing one would
+// publish every event once each.
+type Relay struct {
+	store     Store
+	publisher EventPublisher
+	batchSize int
+	logger    *log.Logger
+	validate  func(models.Event) error
+	notify    func(models.Event)
+}
+
+// Option configures a Relay.
+type Option func(*Relay)
+
+// WithValidator checks each event with validate before publishing it. An event
+// that fails is not published and holds the relay like a failed publish, until
+// it is fixed or replayed past.
+func WithValidator(validate func(models.Event) error) Option {
+	return func(r *Relay) { r.validate = validate }
+}
+
+// WithWebhooks hands each event, once it is published, to notify, which sends
+// it to the webhooks subscribed to it. Webhook deliveries are best effort: they
+// are tried once and never hold the relay.
+func WithWebhooks(notify func(models.Event)) Option {
+	return func(r *Relay) { r.notify = notify }
+}
+
+// NewRelay creates a Relay publishing up to batchSize events per round trip.
+func NewRelay(store Store, publisher EventPublisher, batchSize int, logger *log.Logger, opts ...Option) *Relay {
+	r := &Relay{store: store, publisher: publisher, batchSize: batchSize, logger: logger}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// RelayOnce publishes the next batch of events after the high-water mark and
+// moves the mark past those that were published. It stops at the first failure
+// so no event overtakes an earlier one, and does nothing while the relay is
+// paused. Once ctx is cancelled it publishes no further event, but the one
+// being published is still waited for and the mark still moved, so that a
+// shutdown leaves no event published past the mark. It returns the number of
+// events published.
+func (r *Relay) RelayOnce(ctx context.Context) (int, error) {
+	state, err := r.store.GetOutboxRelay()
+	if err != nil {
+		return 0, fmt.Errorf("read relay position: %w", err)
+	}
+	if state.Paused {
+		return 0, nil
+	}
+	events, err := r.store.ListOutboxEvents(state.LastEventID, r.batchSize)
+	if err != nil {
+		return 0, fmt.Errorf("read outbox: %w", err)
+	}
+
+	published := state.LastEventID
+	var publishErr error
+	n := 0
+	for _, e := range events {
+		if ctx.Err() != nil {
+			break
+		}
+		if r.validate != nil {
+			if publishErr = r.validate(e); publishErr != nil {
+				metrics.OutboxPublished.WithLabelValues("invalid").Inc()
+				publishErr = fmt.Errorf("event %d: %w", e.ID, publishErr)
+				break
+			}
+		}
+		if publishErr = r.publisher.Publish(context.WithoutCancel(ctx), e); publishErr != nil {
+			metrics.OutboxPublished.WithLabelValues("failed").Inc()
+			publishErr = fmt.Errorf("publish event %d: %w", e.ID, publishErr)
+			break
+		}
+		metrics.OutboxPublished.WithLabelValues("published").Inc()
+		if r.notify != nil {
+			r.notify(e)
+		}
+		published = e.ID
+		n++
+	}
+	if n == 0 {
+		return 0, publishErr
+	}
+
+	moved, err := r.store.AdvanceOutboxRelay(state.LastEventID, published)
+	if err != nil {
+		return n, fmt.Errorf("advance relay position to %d: %w", published, err)
+	}
+	if !moved {
+		r.logger.Printf("outbox: position moved from %d while publishing, continuing from the new position", state.LastEventID)
+	}
+	return n, publishErr
+}
+
+// Run relays every interval until ctx is cancelled, then closes the publisher if
+// it is an io.Closer. A full batch is followed by the next one at once, so a
+// backlog drains without waiting for the ticker. A batch under way when ctx is
+// cancelled stops after the event being published, as RelayOnce describes.
+func (r *Relay) Run(ctx context.Context, interval time.Duration) {
+	if c, ok := r.publisher.(io.Closer); ok {
+		defer c.Close()
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for ctx.Err() == nil {
+				n, err := r.RelayOnce(ctx)
+				if err != nil {
+					r.logger.Printf("outbox: %v", err)
+				}
+				if err != nil || n < r.batchSize {
+					break
+				}
+			}
+		}
+	}
+}
+
+// NopPublisher publishes events nowhere, for a relay that only delivers them
+// to webhooks.
+type NopPublisher struct{}
+
+func (NopPublisher) Publish(ctx context.Context, e models.Event) error {
+	return nil
+}
+
+// LogPublisher writes each event as a JSON line to a logger, for development
+// and for consumers that tail logs.
+type LogPublisher struct {
+	Logger *log.Logger
+}
+
+func (p LogPublisher) Publish(ctx context.Context, e models.Event) error {
+	line, err := json.Marshal(struct {
+		MessageID string `json:"message_id"`
+		models.Event
+	}{MessageID(e), e})
+	if err != nil {
+		return err
+	}
+	p.Logger.Printf("event: %s", line)
+	return nil
+}
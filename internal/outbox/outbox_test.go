@@ -0,0 +1,200 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nehciyy/intrapay/internal/models"
+)
+
+var discard = log.New(io.Discard, "", 0)
+
+// memStore keeps the outbox and the relay position in memory.
+type memStore struct {
+	events []models.Event
+	last   int64
+	paused bool
+	// moveTo, when set, moves the position as if replayed while publishing.
+	moveTo *int64
+}
+
+func (s *memStore) ListOutboxEvents(afterID int64, limit int) ([]models.Event, error) {
+	var events []models.Event
+	for _, e := range s.events {
+		if e.ID > afterID && len(events) < limit {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+func (s *memStore) GetOutboxRelay() (*models.OutboxRelayStatus, error) {
+	return &models.OutboxRelayStatus{LastEventID: s.last, Paused: s.paused}, nil
+}
+
+func (s *memStore) AdvanceOutboxRelay(from, to int64) (bool, error) {
+	if s.moveTo != nil {
+		s.last = *s.moveTo
+	}
+	if s.last != from {
+		return false, nil
+	}
+	s.last = to
+	return true, nil
+}
+
+// recorder records published events and fails on the event with ID failOn.
+type recorder struct {
+	ids    []int64
+	failOn int64
+}
+
+func (p *recorder) Publish(ctx context.Context, e models.Event) error {
+	if e.ID == p.failOn {
+		return errors.New("broker unavailable")
+	}
+	p.ids = append(p.ids, e.ID)
+	return nil
+}
+
+func outbox(ids ...int64) []models.Event {
+	events := make([]models.Event, len(ids))
+	for i, id := range ids {
+		events[i] = models.Event{ID: id, Type: models.EventTransferCompleted}
+	}
+	return events
+}
+
+func TestRelay_RelayOnce(t *testing.T) {
+	store := &memStore{events: outbox(1, 2, 3, 4, 5)}
+	pub := &recorder{}
+	r := NewRelay(store, pub, 3, discard)
+
+	n, err := r.RelayOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.Equal(t, int64(3), store.last)
+
+	n, err = r.RelayOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []int64{1, 2, 3, 4, 5}, pub.ids, "events are published in id order")
+
+	n, err = r.RelayOnce(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	assert.Equal(t, int64(5), store.last)
+}
+
+func TestRelay_RelayOnce_PublishFailure(t *testing.T) {
+	store := &memStore{events: outbox(1, 2, 3)}
+	pub := &recorder{failOn: 2}
+	r := NewRelay(store, pub, 10, discard)
+
+	n, err := r.RelayOnce(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []int64{1}, pub.ids, "no event is published after a failure")
+	assert.Equal(t, int64(1), store.last, "the position stops before the failed event")
+
+	pub.failOn = 0
+	_, err = r.RelayOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1, 2, 3}, pub.ids, "the failed event is retried first")
+}
+
+func TestRelay_RelayOnce_Webhooks(t *testing.T) {
+	store := &memStore{events: outbox(1, 2, 3)}
+	pub := &recorder{failOn: 3}
+	var notified []int64
+	r := NewRelay(store, pub, 10, discard, WithWebhooks(func(e models.Event) {
+		notified = append(notified, e.ID)
+	}))
+
+	_, err := r.RelayOnce(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, []int64{1, 2}, notified, "only published events reach webhooks")
+
+	pub.failOn = 0
+	_, err = r.RelayOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1, 2, 3}, notified)
+}
+
+// stopper cancels the relay's context while publishing the event with ID
+// stopOn, as a shutdown would.
+type stopper struct {
+	recorder
+	stopOn int64
+	stop   context.CancelFunc
+}
+
+func (p *stopper) Publish(ctx context.Context, e models.Event) error {
+	if e.ID == p.stopOn {
+		p.stop()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+	return p.recorder.Publish(ctx, e)
+}
+
+func TestRelay_RelayOnce_Shutdown(t *testing.T) {
+	store := &memStore{events: outbox(1, 2, 3, 4)}
+	ctx, cancel := context.WithCancel(context.Background())
+	pub := &stopper{stopOn: 2, stop: cancel}
+	r := NewRelay(store, pub, 10, discard)
+
+	n, err := r.RelayOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []int64{1, 2}, pub.ids, "the event being published is finished, and no other started")
+	assert.Equal(t, int64(2), store.last, "the position moves past the events published before stopping")
+}
+
+func TestRelay_RelayOnce_Paused(t *testing.T) {
+	store := &memStore{events: outbox(1, 2), paused: true}
+	pub := &recorder{}
+
+	n, err := NewRelay(store, pub, 10, discard).RelayOnce(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	assert.Empty(t, pub.ids)
+	assert.Zero(t, store.last)
+}
+
+func TestRelay_RelayOnce_Replayed(t *testing.T) {
+	replayTo := int64(0)
+	store := &memStore{events: outbox(1, 2, 3), last: 2, moveTo: &replayTo}
+
+	_, err := NewRelay(store, &recorder{}, 10, discard).RelayOnce(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, store.last, "a replay during publishing is not overwritten")
+}
+
+func TestMessageID(t *testing.T) {
+	assert.Equal(t, "intrapay-event-42", MessageID(models.Event{ID: 42}))
+}
+
+func TestRelay_RelayOnce_Invalid(t *testing.T) {
+	store := &memStore{events: outbox(1, 2, 3)}
+	pub := &recorder{}
+	validate := func(e models.Event) error {
+		if e.ID == 2 {
+			return errors.New("missing amount")
+		}
+		return nil
+	}
+
+	n, err := NewRelay(store, pub, 10, discard, WithValidator(validate)).RelayOnce(context.Background())
+	assert.ErrorContains(t, err, "event 2: missing amount")
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []int64{1}, pub.ids, "an invalid event is not published and holds back those after it")
+	assert.Equal(t, int64(1), store.last)
+}
@@ -0,0 +1,95 @@
+// Package region tracks whether this server's region is the active region of
+// an active-passive deployment. The servers of every region share the primary
+// database; the region fence there names the one region allowed to write, and
+// the database rejects writes from any other. A Fence caches the fence so a
+// passive region can turn writes away before they reach the database.
+package region
+
+import (
+	"context"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/metrics"
+	"github.com/nehciyy/intrapay/internal/models"
+)
+
+// Store reads the region fence.
+type Store interface {
+	GetRegionFence() (*models.RegionFence, error)
+}
+
+// Fence is the region fence as last read by the servers of one region.
+type Fence struct {
+	region string
+	store  Store
+	logger *log.Logger
+	active atomic.Bool
+	epoch  atomic.Int64
+}
+
+// New returns the fence as seen by region. The region counts as passive until
+// the fence is first read.
+func New(region string, store Store, logger *log.Logger) *Fence {
+	f := &Fence{region: region, store: store, logger: logger}
+	f.epoch.Store(-1)
+	metrics.RegionActive.WithLabelValues(region).Set(0)
+	return f
+}
+
+// Region returns the name of this server's region.
+func (f *Fence) Region() string {
+	return f.region
+}
+
+// Active reports whether this server's region was the active region when the
+// fence was last read. The database has the final word: a write let through
+// just after a promotion is still rejected there.
+func (f *Fence) Active() bool {
+	return f.active.Load()
+}
+
+// Refresh reads the fence, logging when this region becomes active or passive.
+// On error the last state read is kept.
+func (f *Fence) Refresh() error {
+	fence, err := f.store.GetRegionFence()
+	if err != nil {
+		return err
+	}
+	active := fence.ActiveRegion == f.region
+	wasActive := f.active.Swap(active)
+	if f.epoch.Swap(fence.Epoch) != fence.Epoch || wasActive != active {
+		state := "passive"
+		if active {
+			state = "active"
+		}
+		f.logger.Printf("region %s is %s at epoch %d (active region: %q)", f.region, state, fence.Epoch, fence.ActiveRegion)
+	}
+	gauge := 0.0
+	if active {
+		gauge = 1
+	}
+	metrics.RegionActive.WithLabelValues(f.region).Set(gauge)
+	metrics.RegionEpoch.Set(float64(fence.Epoch))
+	return nil
+}
+
+// Run refreshes the fence every interval until ctx is cancelled.
+func (f *Fence) Run(ctx context.Context, interval time.Duration) {
+	if err := f.Refresh(); err != nil {
+		f.logger.Printf("region: %v", err)
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := f.Refresh(); err != nil {
+				f.logger.Printf("region: %v", err)
+			}
+		}
+	}
+}
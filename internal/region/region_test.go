@@ -0,0 +1,61 @@
+package region
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nehciyy/intrapay/internal/models"
+)
+
+// fakeStore returns fence, or err if set.
+type fakeStore struct {
+	fence models.RegionFence
+	err   error
+}
+
+func (s *fakeStore) GetRegionFence() (*models.RegionFence, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	f := s.fence
+	return &f, nil
+}
+
+func TestFence(t *testing.T) {
+	var logs bytes.Buffer
+	store := &fakeStore{}
+	fence := New("eu-west-1", store, log.New(&logs, "", 0))
+	assert.False(t, fence.Active(), "passive until the fence is read")
+
+	// No region has been promoted yet.
+	require.NoError(t, fence.Refresh())
+	assert.False(t, fence.Active())
+
+	store.fence = models.RegionFence{ActiveRegion: "eu-west-1", Epoch: 1}
+	require.NoError(t, fence.Refresh())
+	assert.True(t, fence.Active())
+
+	// A failed read keeps the last state.
+	store.err = errors.New("connection refused")
+	assert.Error(t, fence.Refresh())
+	assert.True(t, fence.Active())
+
+	store.err = nil
+	store.fence = models.RegionFence{ActiveRegion: "us-east-1", Epoch: 2}
+	require.NoError(t, fence.Refresh())
+	assert.False(t, fence.Active())
+	require.NoError(t, fence.Refresh())
+
+	lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+	assert.Equal(t, []string{
+		`region eu-west-1 is passive at epoch 0 (active region: "")`,
+		`region eu-west-1 is active at epoch 1 (active region: "eu-west-1")`,
+		`region eu-west-1 is passive at epoch 2 (active region: "us-east-1")`,
+	}, lines)
+}
@@ -1,87 +1,647 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/nehciyy/intrapay/internal/idgen"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/money"
+	"github.com/nehciyy/intrapay/internal/repository/sqlc"
+)
+
+var (
+	// ErrNotFound is wrapped by lookups that find no matching account row.
+	ErrNotFound = errors.New("not found")
+	// ErrInsufficientFunds is returned by a conditional debit the balance cannot cover.
+	ErrInsufficientFunds = errors.New("insufficient funds")
 )
 
 // PostgresAccountRepository is an implementation of AccountRepository for PostgreSQL.
 type PostgresAccountRepository struct {
-	db *sql.DB
+	db       *sql.DB
+	q        *sqlc.Queries
+	reads    router
+	queryLog *QueryLogger
 }
 
 type PostgresTransactionRepository struct {
-	db *sql.DB
+	db       *sql.DB
+	q        *sqlc.Queries
+	reads    router
+	queryLog *QueryLogger
+	ids      idgen.Generator
 }
 
-func NewPostgresTransactionRepository(db *sql.DB) *PostgresTransactionRepository {
-	return &PostgresTransactionRepository{db: db}
+func NewPostgresTransactionRepository(db *sql.DB, opts ...Option) *PostgresTransactionRepository {
+	o := applyOptions(opts)
+	return &PostgresTransactionRepository{db: db, q: sqlc.New(db), reads: newRouter(db, o), queryLog: o.queryLog, ids: o.ids}
 }
 
 // NewPostgresAccountRepository creates a new PostgresAccountRepository.
-func NewPostgresAccountRepository(db *sql.DB) *PostgresAccountRepository {
-	return &PostgresAccountRepository{db: db}
+func NewPostgresAccountRepository(db *sql.DB, opts ...Option) *PostgresAccountRepository {
+	o := applyOptions(opts)
+	return &PostgresAccountRepository{db: db, q: sqlc.New(db), reads: newRouter(db, o), queryLog: o.queryLog}
+}
+
+// CreateAccount inserts an account with its opening balance and returns the new
+// row. owner is the API key opening it, empty if none; a test-mode account has
+// livemode unset.
+func (r *PostgresAccountRepository) CreateAccount(accountID int64, initialBalance float64, tenant, owner string, livemode bool) (*models.Account, error) {
+	defer r.queryLog.observe("CreateAccount", time.Now())
+	row, err := r.q.CreateAccount(context.Background(), sqlc.CreateAccountParams{
+		AccountID: accountID,
+		Balance:   initialBalance,
+		Tenant:    tenant,
+		Livemode:  livemode,
+		OwnerKey:  owner,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toAccount(sqlc.GetAccountRow(row)), nil
+}
+
+// CountAccounts returns how many open accounts tenant has.
+func (r *PostgresAccountRepository) CountAccounts(tenant string) (int64, error) {
+	defer r.queryLog.observe("CountAccounts", time.Now())
+	return r.q.CountTenantAccounts(context.Background(), tenant)
 }
 
-func (r *PostgresAccountRepository) CreateAccount(accountID int64, initialBalance float64) error {
-	query := `INSERT INTO accounts(account_id, balance) VALUES($1, $2)`
-	_, err := r.db.Exec(query, accountID, initialBalance)
-	return err
+// NextAccountID draws a server-generated account ID from account_id_seq.
+func (r *PostgresAccountRepository) NextAccountID() (int64, error) {
+	defer r.queryLog.observe("NextAccountID", time.Now())
+	return r.q.NextAccountID(context.Background())
 }
 
-func (r *PostgresAccountRepository) GetAccountBalance(accountID int64) (float64, error) {
-	var balance float64
-	query := `SELECT balance FROM accounts WHERE account_id = $1`
-	err := r.db.QueryRow(query, accountID).Scan(&balance)
+// GetBalanceTotals returns the sum of all balances and the sum of all opening
+// balances plus adjustments, which must be equal.
+func (r *PostgresAccountRepository) GetBalanceTotals() (float64, float64, error) {
+	defer r.queryLog.observe("GetBalanceTotals", time.Now())
+	totals, err := r.q.GetBalanceTotals(context.Background())
+	return totals.Total, totals.Expected, err
+}
+
+func (r *PostgresAccountRepository) AccountExists(ctx context.Context, accountID int64) (bool, error) {
+	defer r.queryLog.observe("AccountExists", time.Now())
+	return read(ctx, r.reads, "AccountExists", func(ctx context.Context, q *sqlc.Queries) (bool, error) {
+		return q.AccountExists(ctx, accountID)
+	})
+}
+
+// GetAccount returns the account row. Soft-deleted accounts are only returned
+// when includeDeleted is set.
+func (r *PostgresAccountRepository) GetAccount(ctx context.Context, accountID int64, includeDeleted bool) (*models.Account, error) {
+	defer r.queryLog.observe("GetAccount", time.Now())
+	row, err := read(ctx, r.reads, "GetAccount", func(ctx context.Context, q *sqlc.Queries) (sqlc.GetAccountRow, error) {
+		return q.GetAccount(ctx, sqlc.GetAccountParams{
+			AccountID:      accountID,
+			IncludeDeleted: includeDeleted,
+		})
+	})
 	if err == sql.ErrNoRows {
-		return 0, fmt.Errorf("account with ID %d not found", accountID)
+		return nil, fmt.Errorf("account with ID %d %w", accountID, ErrNotFound)
 	}
-	return balance, err
+	if err != nil {
+		return nil, err
+	}
+	return toAccount(row), nil
 }
 
-func (r *PostgresAccountRepository) AccountExists(accountID int64) (bool, error) {
-	var exists bool
-	err := r.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM accounts WHERE account_id = $1)`, accountID).Scan(&exists)
-	return exists, err
+func toAccount(row sqlc.GetAccountRow) *models.Account {
+	account := &models.Account{
+		AccountID:   row.AccountID,
+		DisplayName: row.DisplayName,
+		Balance:     models.Amount(row.Balance),
+		Status:      models.AccountStatusActive,
+		CreatedAt:   row.CreatedAt,
+		UpdatedAt:   row.UpdatedAt,
+		Livemode:    row.Livemode,
+	}
+	if row.DeletedAt.Valid {
+		account.Status = models.AccountStatusDeleted
+		account.DeletedAt = &row.DeletedAt.Time
+	}
+	return account
 }
 
+// ListAccounts returns up to limit open accounts matching filter, in the order
+// of their IDs, starting after afterID.
+func (r *PostgresAccountRepository) ListAccounts(ctx context.Context, filter models.AccountFilter, afterID int64, limit int) ([]models.Account, error) {
+	defer r.queryLog.observe("ListAccounts", time.Now())
+	rows, err := read(ctx, r.reads, "ListAccounts", func(ctx context.Context, q *sqlc.Queries) ([]sqlc.ListAccountsRow, error) {
+		return q.ListAccounts(ctx, sqlc.ListAccountsParams{
+			AfterID:    afterID,
+			Livemode:   !filter.TestMode,
+			MinBalance: nullAmount(filter.MinBalance),
+			MaxBalance: nullAmount(filter.MaxBalance),
+			RowLimit:   int32(limit),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	accounts := make([]models.Account, len(rows))
+	for i, row := range rows {
+		accounts[i] = *toAccount(sqlc.GetAccountRow(row))
+	}
+	return accounts, nil
+}
+
+func nullAmount(a *models.Amount) sql.NullFloat64 {
+	if a == nil {
+		return sql.NullFloat64{}
+	}
+	return sql.NullFloat64{Float64: float64(*a), Valid: true}
+}
+
+// SetDisplayName sets the display name of an active account.
+func (r *PostgresAccountRepository) SetDisplayName(accountID int64, name string) error {
+	defer r.queryLog.observe("SetAccountDisplayName", time.Now())
+	n, err := r.q.SetAccountDisplayName(context.Background(), sqlc.SetAccountDisplayNameParams{
+		AccountID:   accountID,
+		DisplayName: name,
+	})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("account with ID %d %w", accountID, ErrNotFound)
+	}
+	return nil
+}
+
+// DeleteAccount soft deletes an account. The row and its history are kept, but
+// the account is hidden from lookups and cannot send or receive transfers.
+func (r *PostgresAccountRepository) DeleteAccount(accountID int64) error {
+	defer r.queryLog.observe("DeleteAccount", time.Now())
+	n, err := r.q.SoftDeleteAccount(context.Background(), accountID)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("account with ID %d %w", accountID, ErrNotFound)
+	}
+	return nil
+}
+
+// RestoreAccount undoes a soft delete.
+func (r *PostgresAccountRepository) RestoreAccount(accountID int64) error {
+	defer r.queryLog.observe("RestoreAccount", time.Now())
+	n, err := r.q.RestoreAccount(context.Background(), accountID)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("deleted account with ID %d %w", accountID, ErrNotFound)
+	}
+	return nil
+}
 
 func (r *PostgresTransactionRepository) GetAccountBalanceTx(tx *sql.Tx, accountID int64) (float64, error) {
-	var balance float64
-	// Use FOR UPDATE to lock the row, to prevent race conditions from simultaneous transactions
-	err := tx.QueryRow(`SELECT balance FROM accounts WHERE account_id = $1 FOR UPDATE`, accountID).Scan(&balance)
+	defer r.queryLog.observe("GetAccountBalanceTx", time.Now())
+	stopWatch := r.queryLog.watchLockWait(accountID)
+	defer stopWatch()
+
+	balance, err := r.q.WithTx(tx).GetAccountBalanceForUpdate(context.Background(), accountID)
 	if err == sql.ErrNoRows {
-		return 0, fmt.Errorf("account with ID %d not found", accountID)
+		return 0, fmt.Errorf("account with ID %d %w", accountID, ErrNotFound)
 	}
 	return balance, err
 }
 
 func (r *PostgresTransactionRepository) AccountExistsTx(tx *sql.Tx, accountID int64) (bool, error) {
-	var exists bool
-	err := tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM accounts WHERE account_id = $1)`, accountID).Scan(&exists)
-	return exists, err
+	defer r.queryLog.observe("AccountExistsTx", time.Now())
+	return r.q.WithTx(tx).AccountExists(context.Background(), accountID)
 }
 
 func (r *PostgresTransactionRepository) UpdateBalanceTx(tx *sql.Tx, accountID int64, delta float64) error {
-	query := `UPDATE accounts SET balance = balance + $1 WHERE account_id = $2`
-	_, err := tx.Exec(query, delta, accountID)
-	return err
+	defer r.queryLog.observe("UpdateBalanceTx", time.Now())
+	return r.q.WithTx(tx).UpdateBalance(context.Background(), sqlc.UpdateBalanceParams{
+		Delta:     delta,
+		AccountID: accountID,
+	})
+}
+
+// DebitBalanceTx subtracts amount from the account in a single conditional UPDATE
+// and returns the new balance. It replaces a locking read followed by an update,
+// so the row lock is held for one statement instead of a round trip.
+func (r *PostgresTransactionRepository) DebitBalanceTx(tx *sql.Tx, accountID int64, amount float64) (float64, error) {
+	defer r.queryLog.observe("DebitBalanceTx", time.Now())
+	stopWatch := r.queryLog.watchLockWait(accountID)
+	defer stopWatch()
+
+	q := r.q.WithTx(tx)
+	balance, err := q.DebitBalance(context.Background(), sqlc.DebitBalanceParams{
+		Amount:    amount,
+		AccountID: accountID,
+	})
+	if err != sql.ErrNoRows {
+		return balance, err
+	}
+
+	// No row updated: tell a missing account apart from a short balance.
+	exists, err := q.AccountExists(context.Background(), accountID)
+	if err != nil {
+		return 0, err
+	}
+	if !exists {
+		return 0, fmt.Errorf("account with ID %d %w", accountID, ErrNotFound)
+	}
+	return 0, ErrInsufficientFunds
 }
 
 func (r *PostgresTransactionRepository) InsertTransactionLogTx(tx *sql.Tx, sourceID, destID int64, amount float64) (string, error) {
-	var id int64
-	err := tx.QueryRow(`
-		INSERT INTO transactions (source_account_id, destination_account_id, amount)
-		VALUES ($1, $2, $3) RETURNING id
-	`, sourceID, destID, amount).Scan(&id)
+	defer r.queryLog.observe("InsertTransactionLogTx", time.Now())
+	transactionID, _, err := insertTransactionLog(r.q.WithTx(tx), r.ids, sourceID, destID, amount)
+	return transactionID, err
+}
+
+// insertTransactionLog writes one transaction row and returns its ID, which is the
+// generated transaction_ref when ids is set and the serial key otherwise, along
+// with the serial key.
+func insertTransactionLog(q *sqlc.Queries, ids idgen.Generator, sourceID, destID int64, amount float64) (string, int32, error) {
+	ref, err := newTransactionRef(ids)
+	if err != nil {
+		return "", 0, err
+	}
+	id, err := q.InsertTransaction(context.Background(), sqlc.InsertTransactionParams{
+		SourceAccountID:      sourceID,
+		DestinationAccountID: destID,
+		Amount:               amount,
+		AmountMinor:          sql.NullInt64{Int64: models.Amount(amount).Minor(), Valid: true},
+		TransactionRef:       ref,
+	})
+	if err != nil {
+		return "", 0, err
+	}
+	return transactionID(id, ref), id, nil
+}
+
+// InsertTransactionLogsTx records many transfers in a single statement and returns
+// their IDs in the same order as logs.
+func (r *PostgresTransactionRepository) InsertTransactionLogsTx(tx *sql.Tx, logs []TransactionLog) ([]string, error) {
+	defer r.queryLog.observe("InsertTransactionLogsTx", time.Now())
+	transactionIDs, _, err := insertTransactionLogs(r.q.WithTx(tx), r.ids, logs)
+	return transactionIDs, err
+}
+
+// insertTransactionLogs returns the transaction IDs of the inserted rows and their
+// serial keys, both in the order of logs.
+func insertTransactionLogs(q *sqlc.Queries, ids idgen.Generator, logs []TransactionLog) ([]string, []int32, error) {
+	if len(logs) == 0 {
+		return nil, nil, nil
+	}
+	arg := sqlc.InsertTransactionsParams{
+		SourceAccountIds:      make([]int64, len(logs)),
+		DestinationAccountIds: make([]int64, len(logs)),
+		Amounts:               make([]float64, len(logs)),
+		AmountsMinor:          make([]int64, len(logs)),
+		TransactionRefs:       make([]string, len(logs)),
+		Kinds:                 make([]string, len(logs)),
+		RoundingModes:         make([]string, len(logs)),
+		UnroundedAmounts:      make([]float64, len(logs)),
+	}
+	for i, l := range logs {
+		ref, err := newTransactionRef(ids)
+		if err != nil {
+			return nil, nil, err
+		}
+		arg.SourceAccountIds[i] = l.SourceID
+		arg.DestinationAccountIds[i] = l.DestID
+		arg.Amounts[i] = l.Amount
+		arg.AmountsMinor[i] = models.Amount(l.Amount).Minor()
+		arg.TransactionRefs[i] = ref
+		arg.Kinds[i] = string(l.Kind)
+		if l.Rounding != nil {
+			arg.RoundingModes[i] = string(l.Rounding.Mode)
+			arg.UnroundedAmounts[i] = l.Rounding.Unrounded
+		}
+	}
+
+	serials, err := q.InsertTransactions(context.Background(), arg)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(serials) != len(logs) {
+		return nil, nil, fmt.Errorf("inserted %d transactions, expected %d", len(serials), len(logs))
+	}
+	transactionIDs := make([]string, len(serials))
+	for i, id := range serials {
+		transactionIDs[i] = transactionID(id, arg.TransactionRefs[i])
+	}
+	return transactionIDs, serials, nil
+}
+
+// newTransactionRef generates the external transaction ID, or "" when the serial
+// key serves as the ID.
+func newTransactionRef(ids idgen.Generator) (string, error) {
+	if ids == nil {
+		return "", nil
+	}
+	ref, err := ids.NewID()
+	if err != nil {
+		return "", fmt.Errorf("generate transaction ID: %w", err)
+	}
+	return ref, nil
+}
+
+func transactionID(serial int32, ref string) string {
+	if ref != "" {
+		return ref
+	}
+	return fmt.Sprintf("%d", serial)
+}
+
+// GetTransaction looks a transaction up by its transaction_ref or, for IDs that
+// are integers, its serial key.
+func (r *PostgresTransactionRepository) GetTransaction(ctx context.Context, id string) (*models.Transaction, error) {
+	defer r.queryLog.observe("GetTransaction", time.Now())
+	params := sqlc.GetTransactionParams{Ref: id}
+	if serial, err := strconv.ParseInt(id, 10, 32); err == nil {
+		params.SerialID = sql.NullInt32{Int32: int32(serial), Valid: true}
+	}
+	row, err := read(ctx, r.reads, "GetTransaction", func(ctx context.Context, q *sqlc.Queries) (sqlc.Transaction, error) {
+		return q.GetTransaction(ctx, params)
+	})
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("transaction %s %w", id, ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	transaction := toTransaction(row)
+	return &transaction, nil
+}
+
+// GetTransactions looks up the transactions with the given IDs in one query. The
+// result is keyed by requested ID; IDs that match no transaction are left out.
+// As with GetTransaction, an ID matching one transaction's ref and another's
+// serial key resolves to the ref.
+func (r *PostgresTransactionRepository) GetTransactions(ctx context.Context, ids []string) (map[string]models.Transaction, error) {
+	defer r.queryLog.observe("GetTransactions", time.Now())
+	params := sqlc.GetTransactionsParams{Refs: ids}
+	for _, id := range ids {
+		if serial, err := strconv.ParseInt(id, 10, 32); err == nil {
+			params.SerialIds = append(params.SerialIds, int32(serial))
+		}
+	}
+	rows, err := read(ctx, r.reads, "GetTransactions", func(ctx context.Context, q *sqlc.Queries) ([]sqlc.Transaction, error) {
+		return q.GetTransactions(ctx, params)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	byRef := make(map[string]sqlc.Transaction, len(rows))
+	bySerial := make(map[string]sqlc.Transaction, len(rows))
+	for _, row := range rows {
+		if row.TransactionRef.Valid {
+			byRef[row.TransactionRef.String] = row
+		}
+		bySerial[strconv.Itoa(int(row.ID))] = row
+	}
+	found := make(map[string]models.Transaction, len(rows))
+	for _, id := range ids {
+		if row, ok := byRef[id]; ok {
+			found[id] = toTransaction(row)
+		} else if row, ok := bySerial[id]; ok {
+			found[id] = toTransaction(row)
+		}
+	}
+	return found, nil
+}
+
+// ListTransactions returns up to limit transactions matching filter, oldest
+// first, starting after the cursor. It also returns the cursor of the last row
+// for fetching the next page.
+func (r *PostgresTransactionRepository) ListTransactions(ctx context.Context, filter models.TransactionFilter, after ChangeCursor, limit int) ([]models.Transaction, ChangeCursor, error) {
+	defer r.queryLog.observe("ListTransactions", time.Now())
+	return listTransactions(ctx, r.reads, filter, after, limit)
+}
+
+func listTransactions(ctx context.Context, rt router, filter models.TransactionFilter, after ChangeCursor, limit int) ([]models.Transaction, ChangeCursor, error) {
+	rows, err := read(ctx, rt, "ListTransactions", func(ctx context.Context, q *sqlc.Queries) ([]sqlc.Transaction, error) {
+		return q.ListTransactions(ctx, sqlc.ListTransactionsParams{
+			UpdatedSince:   filter.UpdatedSince,
+			AfterUpdatedAt: after.UpdatedAt,
+			AfterID:        int32(after.ID),
+			Tag:            filter.Tag,
+			Livemode:       !filter.TestMode,
+			RowLimit:       int32(limit),
+		})
+	})
+	if err != nil {
+		return nil, after, err
+	}
+	return toTransactions(rows), lastCursor(rows, after), nil
+}
+
+// ListAccountTransactions returns up to limit transfers sent or received by
+// accountID, oldest-updated first, starting after the cursor. Each carries the
+// counterparty's account with its display name in full; redacting it is up to
+// the caller.
+func (r *PostgresTransactionRepository) ListAccountTransactions(ctx context.Context, accountID int64, after ChangeCursor, limit int) ([]models.Transaction, ChangeCursor, error) {
+	defer r.queryLog.observe("ListAccountTransactions", time.Now())
+	rows, err := read(ctx, r.reads, "ListAccountTransactions", func(ctx context.Context, q *sqlc.Queries) ([]sqlc.ListAccountTransactionsRow, error) {
+		return q.ListAccountTransactions(ctx, sqlc.ListAccountTransactionsParams{
+			AccountID:      accountID,
+			AfterUpdatedAt: after.UpdatedAt,
+			AfterID:        int32(after.ID),
+			RowLimit:       int32(limit),
+		})
+	})
+	if err != nil {
+		return nil, after, err
+	}
+	if len(rows) == 0 {
+		return nil, after, nil
+	}
+
+	transactions := make([]models.Transaction, len(rows))
+	for i, row := range rows {
+		transactions[i] = toTransaction(sqlc.Transaction{
+			ID:                   row.ID,
+			SourceAccountID:      row.SourceAccountID,
+			DestinationAccountID: row.DestinationAccountID,
+			Amount:               row.Amount,
+			CreatedAt:            row.CreatedAt,
+			UpdatedAt:            row.UpdatedAt,
+			TransactionRef:       row.TransactionRef,
+			Kind:                 row.Kind,
+			Tags:                 row.Tags,
+			Livemode:             row.Livemode,
+		})
+		counterparty := row.DestinationAccountID
+		if row.DestinationAccountID == accountID {
+			counterparty = row.SourceAccountID
+		}
+		transactions[i].Counterparty = &models.Counterparty{
+			AccountID:   counterparty,
+			DisplayName: row.CounterpartyDisplayName.String,
+			Deleted:     row.CounterpartyDeleted,
+		}
+	}
+	last := rows[len(rows)-1]
+	return transactions, ChangeCursor{UpdatedAt: last.UpdatedAt, ID: int64(last.ID)}, nil
+}
+
+// SumAccountFlows totals the transfers into and out of accountID created since
+// the given time, in one aggregate query.
+func (r *PostgresTransactionRepository) SumAccountFlows(ctx context.Context, accountID int64, since time.Time) (*models.AccountFlows, error) {
+	defer r.queryLog.observe("SumAccountFlows", time.Now())
+	row, err := read(ctx, r.reads, "SumAccountFlows", func(ctx context.Context, q *sqlc.Queries) (sqlc.SumAccountFlowsRow, error) {
+		return q.SumAccountFlows(ctx, sqlc.SumAccountFlowsParams{
+			AccountID: accountID,
+			Since:     since,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &models.AccountFlows{
+		Since:         since,
+		Incoming:      models.Amount(row.Incoming),
+		IncomingCount: row.IncomingCount,
+		Outgoing:      models.Amount(row.Outgoing),
+		OutgoingCount: row.OutgoingCount,
+	}, nil
+}
+
+// CountTransactions counts the transactions matching filter.
+func (r *PostgresTransactionRepository) CountTransactions(ctx context.Context, filter models.TransactionFilter) (int64, error) {
+	defer r.queryLog.observe("CountTransactions", time.Now())
+	return countTransactions(ctx, r.reads, filter)
+}
+
+func countTransactions(ctx context.Context, rt router, filter models.TransactionFilter) (int64, error) {
+	return read(ctx, rt, "CountTransactions", func(ctx context.Context, q *sqlc.Queries) (int64, error) {
+		return q.CountTransactions(ctx, sqlc.CountTransactionsParams{
+			UpdatedSince: filter.UpdatedSince,
+			Tag:          filter.Tag,
+			Livemode:     !filter.TestMode,
+		})
+	})
+}
+
+// ListTransactionChanges returns up to limit live transactions, or test-mode ones
+// unless livemode is set, created or updated after the cursor, and the cursor to
+// resume from. When nothing new is found the returned cursor equals after.
+func (r *PostgresTransactionRepository) ListTransactionChanges(ctx context.Context, after ChangeCursor, livemode bool, limit int) ([]models.Transaction, ChangeCursor, error) {
+	defer r.queryLog.observe("ListTransactionChanges", time.Now())
+	return listTransactionChanges(ctx, r.reads, after, livemode, limit)
+}
+
+func listTransactionChanges(ctx context.Context, rt router, after ChangeCursor, livemode bool, limit int) ([]models.Transaction, ChangeCursor, error) {
+	rows, err := read(ctx, rt, "ListTransactionChanges", func(ctx context.Context, q *sqlc.Queries) ([]sqlc.Transaction, error) {
+		return q.ListTransactionChanges(ctx, sqlc.ListTransactionChangesParams{
+			AfterUpdatedAt: after.UpdatedAt,
+			AfterID:        int32(after.ID),
+			Livemode:       livemode,
+			RowLimit:       int32(limit),
+		})
+	})
+	if err != nil {
+		return nil, after, err
+	}
+	return toTransactions(rows), lastCursor(rows, after), nil
+}
+
+// lastCursor returns the position of the last row, or after if there are none.
+func lastCursor(rows []sqlc.Transaction, after ChangeCursor) ChangeCursor {
+	if len(rows) == 0 {
+		return after
+	}
+	last := rows[len(rows)-1]
+	return ChangeCursor{UpdatedAt: last.UpdatedAt, ID: int64(last.ID)}
+}
+
+func toTransactions(rows []sqlc.Transaction) []models.Transaction {
+	transactions := make([]models.Transaction, len(rows))
+	for i, row := range rows {
+		transactions[i] = toTransaction(row)
+	}
+	return transactions
+}
+
+func toTransaction(row sqlc.Transaction) models.Transaction {
+	return models.Transaction{
+		ID:                   transactionID(row.ID, row.TransactionRef.String),
+		Ref:                  row.TransactionRef.String,
+		Kind:                 models.TransactionKind(row.Kind),
+		SourceAccountID:      row.SourceAccountID,
+		DestinationAccountID: row.DestinationAccountID,
+		Amount:               models.Amount(row.Amount),
+		CreatedAt:            row.CreatedAt.Time,
+		UpdatedAt:            row.UpdatedAt,
+		Rounding:             toRounding(row.RoundingMode, row.UnroundedAmount),
+		Tags:                 toTags(row.Tags),
+		Livemode:             row.Livemode,
+	}
+}
+
+// toTags returns the tags of a transaction, nil if it has none.
+func toTags(tags []string) []string {
+	if len(tags) == 0 {
+		return nil
+	}
+	return tags
+}
+
+// toRounding returns the rounding recorded on a transaction, nil if its amount
+// was not rounded.
+func toRounding(mode sql.NullString, unrounded sql.NullFloat64) *money.Rounding {
+	if !mode.Valid {
+		return nil
+	}
+	return &money.Rounding{Mode: money.Mode(mode.String), MinorUnits: models.CurrentCurrency().MinorUnits, Unrounded: unrounded.Float64}
+}
+
+func (r *PostgresTransactionRepository) ComputeBalanceTx(tx *sql.Tx, accountID int64) (float64, error) {
+	defer r.queryLog.observe("ComputeBalanceTx", time.Now())
+	balance, err := r.q.WithTx(tx).ComputeBalance(context.Background(), accountID)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("account with ID %d %w", accountID, ErrNotFound)
+	}
+	return balance, err
+}
+
+func (r *PostgresTransactionRepository) InsertAdjustmentTx(tx *sql.Tx, accountID int64, amount float64, code models.ReasonCode, reason string) (string, error) {
+	defer r.queryLog.observe("InsertAdjustmentTx", time.Now())
+	id, err := r.q.WithTx(tx).InsertAdjustment(context.Background(), sqlc.InsertAdjustmentParams{
+		AccountID:  accountID,
+		Amount:     amount,
+		Reason:     reason,
+		ReasonCode: string(code),
+	})
 	if err != nil {
 		return "", err
 	}
 	return fmt.Sprintf("%d", id), nil
 }
 
-// isSerializationFailure checks if the error is a PostgreSQL serialization failure (SQLSTATE 40001).
+// IsLivemodeMismatch checks if the error is a transaction between a test-mode and
+// a live account, refused by the transactions_livemode trigger.
+func IsLivemodeMismatch(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == "23514" && pqErr.Constraint == "transactions_livemode"
+	}
+	return false
+}
+
+// IsSerializationFailure checks if the error is a PostgreSQL serialization failure (SQLSTATE 40001).
 func IsSerializationFailure(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == "40001"
+	}
 	return err != nil && strings.Contains(err.Error(), "SQLSTATE 40001")
-}
\ No newline at end of file
+}
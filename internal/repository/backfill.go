@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository/sqlc"
+)
+
+// PostgresBackfillRepository is an implementation of BackfillRepository for PostgreSQL.
+type PostgresBackfillRepository struct {
+	q        *sqlc.Queries
+	queryLog *QueryLogger
+}
+
+// NewPostgresBackfillRepository creates a new PostgresBackfillRepository.
+func NewPostgresBackfillRepository(db *sql.DB, opts ...Option) *PostgresBackfillRepository {
+	o := applyOptions(opts)
+	return &PostgresBackfillRepository{q: sqlc.New(db), queryLog: o.queryLog}
+}
+
+// StartBackfill returns the progress of the backfill name, recording it as
+// started on its first run.
+func (r *PostgresBackfillRepository) StartBackfill(name string) (*models.Backfill, error) {
+	defer r.queryLog.observe("StartBackfill", time.Now())
+	row, err := r.q.StartBackfill(context.Background(), name)
+	if err != nil {
+		return nil, err
+	}
+	return toBackfill(row), nil
+}
+
+func (r *PostgresBackfillRepository) GetBackfill(name string) (*models.Backfill, error) {
+	defer r.queryLog.observe("GetBackfill", time.Now())
+	row, err := r.q.GetBackfill(context.Background(), name)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("backfill %s %w", name, ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return toBackfill(row), nil
+}
+
+// ListBackfills returns the progress of every backfill that has run, by name.
+func (r *PostgresBackfillRepository) ListBackfills() ([]*models.Backfill, error) {
+	defer r.queryLog.observe("ListBackfills", time.Now())
+	rows, err := r.q.ListBackfills(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	backfills := make([]*models.Backfill, len(rows))
+	for i, row := range rows {
+		backfills[i] = toBackfill(row)
+	}
+	return backfills, nil
+}
+
+// AdvanceBackfillTx moves the backfill name from from to to, adding the rows the
+// batch filled in, in the transaction of the batch. It reports false, leaving
+// the progress alone, if the backfill is no longer at from, e.g. because another
+// run processed the batch.
+func (r *PostgresBackfillRepository) AdvanceBackfillTx(tx *sql.Tx, name string, from, to, rows int64) (bool, error) {
+	defer r.queryLog.observe("AdvanceBackfillTx", time.Now())
+	n, err := r.q.WithTx(tx).AdvanceBackfill(context.Background(), sqlc.AdvanceBackfillParams{
+		LastID:      to,
+		RowsUpdated: rows,
+		Name:        name,
+		Expected:    from,
+	})
+	return n > 0, err
+}
+
+// CompleteBackfill records that a run of the backfill name caught up.
+func (r *PostgresBackfillRepository) CompleteBackfill(name string) error {
+	defer r.queryLog.observe("CompleteBackfill", time.Now())
+	return r.q.CompleteBackfill(context.Background(), name)
+}
+
+// ResetBackfill starts the backfill name over from the first row.
+func (r *PostgresBackfillRepository) ResetBackfill(name string) error {
+	defer r.queryLog.observe("ResetBackfill", time.Now())
+	n, err := r.q.ResetBackfill(context.Background(), name)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("backfill %s %w", name, ErrNotFound)
+	}
+	return nil
+}
+
+// BackfillTransactionAmountMinorTx fills in amount_minor, in units of 1/scale,
+// for the batch of up to limit transactions after afterID. It returns the last
+// serial key of the batch, 0 once there are no more transactions, and how many
+// rows it filled in.
+func (r *PostgresBackfillRepository) BackfillTransactionAmountMinorTx(tx *sql.Tx, afterID int64, limit int, scale int64) (int64, int64, error) {
+	defer r.queryLog.observe("BackfillTransactionAmountMinorTx", time.Now())
+	row, err := r.q.WithTx(tx).BackfillTransactionAmountMinor(context.Background(), sqlc.BackfillTransactionAmountMinorParams{
+		AfterID:   int32(afterID),
+		BatchSize: int32(limit),
+		Scale:     scale,
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	return row.LastID, row.Updated, nil
+}
+
+// CountTransactionsMissingAmountMinor counts the transactions whose amount_minor
+// is yet to be filled in.
+func (r *PostgresBackfillRepository) CountTransactionsMissingAmountMinor() (int64, error) {
+	defer r.queryLog.observe("CountTransactionsMissingAmountMinor", time.Now())
+	return r.q.CountTransactionsMissingAmountMinor(context.Background())
+}
+
+func toBackfill(row sqlc.Backfill) *models.Backfill {
+	b := &models.Backfill{
+		Name:        row.Name,
+		LastID:      row.LastID,
+		RowsUpdated: row.RowsUpdated,
+		StartedAt:   row.StartedAt,
+		UpdatedAt:   row.UpdatedAt,
+	}
+	if row.CompletedAt.Valid {
+		b.CompletedAt = &row.CompletedAt.Time
+	}
+	return b
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository/sqlc"
+)
+
+// PostgresAmountBoundsRepository is an implementation of AmountBoundsRepository
+// for PostgreSQL.
+type PostgresAmountBoundsRepository struct {
+	q        *sqlc.Queries
+	queryLog *QueryLogger
+}
+
+// NewPostgresAmountBoundsRepository creates a new PostgresAmountBoundsRepository.
+func NewPostgresAmountBoundsRepository(db *sql.DB, opts ...Option) *PostgresAmountBoundsRepository {
+	o := applyOptions(opts)
+	return &PostgresAmountBoundsRepository{q: sqlc.New(db), queryLog: o.queryLog}
+}
+
+// SetAmountBounds creates or replaces the amount bounds of b.Tenant.
+func (r *PostgresAmountBoundsRepository) SetAmountBounds(b models.AmountBounds) (*models.AmountBounds, error) {
+	defer r.queryLog.observe("SetAmountBounds", time.Now())
+	row, err := r.q.SetAmountBounds(context.Background(), sqlc.SetAmountBoundsParams{
+		Tenant:    b.Tenant,
+		MinAmount: float64(b.MinAmount),
+		MaxAmount: float64(b.MaxAmount),
+	})
+	if err != nil {
+		return nil, err
+	}
+	bounds := toAmountBounds(row)
+	return &bounds, nil
+}
+
+// ListAmountBounds returns the amount bounds of every tenant that has them, by
+// tenant.
+func (r *PostgresAmountBoundsRepository) ListAmountBounds() ([]models.AmountBounds, error) {
+	defer r.queryLog.observe("ListAmountBounds", time.Now())
+	rows, err := r.q.ListAmountBounds(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	bounds := make([]models.AmountBounds, len(rows))
+	for i, row := range rows {
+		bounds[i] = toAmountBounds(row)
+	}
+	return bounds, nil
+}
+
+func (r *PostgresAmountBoundsRepository) DeleteAmountBounds(tenant string) error {
+	defer r.queryLog.observe("DeleteAmountBounds", time.Now())
+	n, err := r.q.DeleteAmountBounds(context.Background(), tenant)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("amount bounds of tenant %s %w", tenant, ErrNotFound)
+	}
+	return nil
+}
+
+// GetAccountTenant returns the tenant that opened an account, open or closed.
+func (r *PostgresAmountBoundsRepository) GetAccountTenant(accountID int64) (string, error) {
+	defer r.queryLog.observe("GetAccountTenant", time.Now())
+	tenant, err := r.q.GetAccountTenant(context.Background(), accountID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("account with ID %d %w", accountID, ErrNotFound)
+	}
+	return tenant, err
+}
+
+func toAmountBounds(row sqlc.AmountBound) models.AmountBounds {
+	return models.AmountBounds{
+		Tenant:    row.Tenant,
+		MinAmount: models.Amount(row.MinAmount),
+		MaxAmount: models.Amount(row.MaxAmount),
+		UpdatedAt: row.UpdatedAt,
+	}
+}
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository/sqlc"
+)
+
+// PostgresCashbackRepository is an implementation of CashbackRepository for PostgreSQL.
+type PostgresCashbackRepository struct {
+	q        *sqlc.Queries
+	queryLog *QueryLogger
+}
+
+// NewPostgresCashbackRepository creates a new PostgresCashbackRepository.
+func NewPostgresCashbackRepository(db *sql.DB, opts ...Option) *PostgresCashbackRepository {
+	o := applyOptions(opts)
+	return &PostgresCashbackRepository{q: sqlc.New(db), queryLog: o.queryLog}
+}
+
+// InsertCashbackCampaign stores a campaign that has paid nothing yet.
+func (r *PostgresCashbackRepository) InsertCashbackCampaign(c models.CashbackCampaign) (*models.CashbackCampaign, error) {
+	defer r.queryLog.observe("InsertCashbackCampaign", time.Now())
+	row, err := r.q.InsertCashbackCampaign(context.Background(), sqlc.InsertCashbackCampaignParams{
+		Name:               c.Name,
+		Percentage:         c.Percentage,
+		Cap:                float64(c.Cap),
+		FundingAccountID:   c.FundingAccountID,
+		EligibleAccountIds: c.EligibleAccountIDs,
+		StartsAt:           c.StartsAt,
+		EndsAt:             c.EndsAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toCashbackCampaign(row), nil
+}
+
+// UpdateCashbackCampaign replaces the terms of campaign c.ID, keeping what it
+// has paid so far.
+func (r *PostgresCashbackRepository) UpdateCashbackCampaign(c models.CashbackCampaign) (*models.CashbackCampaign, error) {
+	defer r.queryLog.observe("UpdateCashbackCampaign", time.Now())
+	row, err := r.q.UpdateCashbackCampaign(context.Background(), sqlc.UpdateCashbackCampaignParams{
+		ID:                 c.ID,
+		Name:               c.Name,
+		Percentage:         c.Percentage,
+		Cap:                float64(c.Cap),
+		FundingAccountID:   c.FundingAccountID,
+		EligibleAccountIds: c.EligibleAccountIDs,
+		StartsAt:           c.StartsAt,
+		EndsAt:             c.EndsAt,
+	})
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("cashback campaign %d %w", c.ID, ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return toCashbackCampaign(row), nil
+}
+
+func (r *PostgresCashbackRepository) GetCashbackCampaign(id int64) (*models.CashbackCampaign, error) {
+	defer r.queryLog.observe("GetCashbackCampaign", time.Now())
+	row, err := r.q.GetCashbackCampaign(context.Background(), id)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("cashback campaign %d %w", id, ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return toCashbackCampaign(row), nil
+}
+
+// ListCashbackCampaigns returns every campaign, past and future ones included,
+// in the order they were created.
+func (r *PostgresCashbackRepository) ListCashbackCampaigns() ([]models.CashbackCampaign, error) {
+	defer r.queryLog.observe("ListCashbackCampaigns", time.Now())
+	rows, err := r.q.ListCashbackCampaigns(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return toCashbackCampaigns(rows), nil
+}
+
+func (r *PostgresCashbackRepository) DeleteCashbackCampaign(id int64) error {
+	defer r.queryLog.observe("DeleteCashbackCampaign", time.Now())
+	n, err := r.q.DeleteCashbackCampaign(context.Background(), id)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("cashback campaign %d %w", id, ErrNotFound)
+	}
+	return nil
+}
+
+// ListActiveCashbackCampaigns returns the campaigns running at at that
+// accountID is eligible for and does not fund.
+func (r *PostgresCashbackRepository) ListActiveCashbackCampaigns(accountID int64, at time.Time) ([]models.CashbackCampaign, error) {
+	defer r.queryLog.observe("ListActiveCashbackCampaigns", time.Now())
+	rows, err := r.q.ListActiveCashbackCampaigns(context.Background(), sqlc.ListActiveCashbackCampaignsParams{At: at, AccountID: accountID})
+	if err != nil {
+		return nil, err
+	}
+	return toCashbackCampaigns(rows), nil
+}
+
+// AddCashbackCampaignPaidTx adds a credit made in tx to what the campaign has
+// paid. A campaign deleted since the credit was worked out is ErrNotFound.
+func (r *PostgresCashbackRepository) AddCashbackCampaignPaidTx(tx *sql.Tx, id int64, amount float64) error {
+	defer r.queryLog.observe("AddCashbackCampaignPaidTx", time.Now())
+	n, err := r.q.WithTx(tx).AddCashbackCampaignPaid(context.Background(), sqlc.AddCashbackCampaignPaidParams{ID: id, Paid: amount})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("cashback campaign %d %w", id, ErrNotFound)
+	}
+	return nil
+}
+
+func toCashbackCampaigns(rows []sqlc.CashbackCampaign) []models.CashbackCampaign {
+	campaigns := make([]models.CashbackCampaign, len(rows))
+	for i, row := range rows {
+		campaigns[i] = *toCashbackCampaign(row)
+	}
+	return campaigns
+}
+
+func toCashbackCampaign(row sqlc.CashbackCampaign) *models.CashbackCampaign {
+	eligible := row.EligibleAccountIds
+	if eligible == nil {
+		eligible = []int64{}
+	}
+	return &models.CashbackCampaign{
+		ID:                 row.ID,
+		Name:               row.Name,
+		Percentage:         row.Percentage,
+		Cap:                models.Amount(row.Cap),
+		FundingAccountID:   row.FundingAccountID,
+		EligibleAccountIDs: eligible,
+		StartsAt:           row.StartsAt,
+		EndsAt:             row.EndsAt,
+		Paid:               models.Amount(row.Paid),
+		CreatedAt:          row.CreatedAt,
+		UpdatedAt:          row.UpdatedAt,
+	}
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository/sqlc"
+)
+
+// PostgresForwardedTransferRepository is an implementation of
+// ForwardedTransferRepository for PostgreSQL.
+type PostgresForwardedTransferRepository struct {
+	q        *sqlc.Queries
+	queryLog *QueryLogger
+}
+
+// NewPostgresForwardedTransferRepository creates a new PostgresForwardedTransferRepository.
+func NewPostgresForwardedTransferRepository(db *sql.DB, opts ...Option) *PostgresForwardedTransferRepository {
+	o := applyOptions(opts)
+	return &PostgresForwardedTransferRepository{q: sqlc.New(db), queryLog: o.queryLog}
+}
+
+// InsertForwardedTransferTx records that a forwarded transfer completed, in
+// the transaction of the transfer that made it. It returns ErrAlreadyReplayed
+// if the transfer, or its API key's Idempotency-Key, has an outcome already, so
+// the caller can roll the transfer back.
+func (r *PostgresForwardedTransferRepository) InsertForwardedTransferTx(tx *sql.Tx, s models.ForwardedTransferStatus) error {
+	defer r.queryLog.observe("InsertForwardedTransferTx", time.Now())
+	return insertForwardedTransfer(r.q.WithTx(tx), s)
+}
+
+// InsertForwardedTransfer records that a forwarded transfer was rejected or
+// expired. It returns ErrAlreadyReplayed if the transfer, or its API key's
+// Idempotency-Key, has an outcome already.
+func (r *PostgresForwardedTransferRepository) InsertForwardedTransfer(s models.ForwardedTransferStatus) error {
+	defer r.queryLog.observe("InsertForwardedTransfer", time.Now())
+	return insertForwardedTransfer(r.q, s)
+}
+
+func insertForwardedTransfer(q *sqlc.Queries, s models.ForwardedTransferStatus) error {
+	var replayedAt time.Time
+	if s.ReplayedAt != nil {
+		replayedAt = *s.ReplayedAt
+	}
+	n, err := q.InsertForwardedTransfer(context.Background(), sqlc.InsertForwardedTransferParams{
+		ForwardID:      s.ID,
+		Status:         string(s.Status),
+		TransactionID:  nullString(s.TransactionID),
+		Code:           string(s.Code),
+		Error:          s.Error,
+		AcceptedAt:     s.AcceptedAt,
+		ReplayedAt:     replayedAt,
+		ApiKey:         s.APIKey,
+		IdempotencyKey: nullString(s.IdempotencyKey),
+	})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("forwarded transfer %s %w", s.ID, ErrAlreadyReplayed)
+	}
+	return nil
+}
+
+// GetForwardedTransfer returns the outcome of a forwarded transfer, or
+// ErrNotFound if it has none.
+func (r *PostgresForwardedTransferRepository) GetForwardedTransfer(id string) (*models.ForwardedTransferStatus, error) {
+	defer r.queryLog.observe("GetForwardedTransfer", time.Now())
+	row, err := r.q.GetForwardedTransfer(context.Background(), id)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("forwarded transfer %s %w", id, ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return forwardedTransferStatus(row), nil
+}
+
+// FindForwardedTransfer returns the outcome of the forwarded transfer apiKey
+// requested with idempotencyKey, or ErrNotFound if there is none.
+func (r *PostgresForwardedTransferRepository) FindForwardedTransfer(apiKey, idempotencyKey string) (*models.ForwardedTransferStatus, error) {
+	defer r.queryLog.observe("FindForwardedTransfer", time.Now())
+	row, err := r.q.FindForwardedTransfer(context.Background(), sqlc.FindForwardedTransferParams{
+		ApiKey:         apiKey,
+		IdempotencyKey: nullString(idempotencyKey),
+	})
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("forwarded transfer with Idempotency-Key %q %w", idempotencyKey, ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return forwardedTransferStatus(row), nil
+}
+
+func forwardedTransferStatus(row sqlc.ForwardedTransfer) *models.ForwardedTransferStatus {
+	return &models.ForwardedTransferStatus{
+		ID:             row.ForwardID,
+		Status:         models.ForwardStatus(row.Status),
+		AcceptedAt:     row.AcceptedAt,
+		ReplayedAt:     &row.ReplayedAt,
+		TransactionID:  row.TransactionID.String,
+		Code:           models.ReasonCode(row.Code),
+		Error:          row.Error,
+		APIKey:         row.ApiKey,
+		IdempotencyKey: row.IdempotencyKey.String,
+	}
+}
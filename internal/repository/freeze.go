@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository/sqlc"
+)
+
+// PostgresTransferFreezeRepository is an implementation of
+// TransferFreezeRepository for PostgreSQL.
+type PostgresTransferFreezeRepository struct {
+	q        *sqlc.Queries
+	queryLog *QueryLogger
+}
+
+// NewPostgresTransferFreezeRepository creates a new PostgresTransferFreezeRepository.
+func NewPostgresTransferFreezeRepository(db *sql.DB, opts ...Option) *PostgresTransferFreezeRepository {
+	o := applyOptions(opts)
+	return &PostgresTransferFreezeRepository{q: sqlc.New(db), queryLog: o.queryLog}
+}
+
+func (r *PostgresTransferFreezeRepository) InsertTransferFreeze(f models.TransferFreeze) (*models.TransferFreeze, error) {
+	defer r.queryLog.observe("InsertTransferFreeze", time.Now())
+	row, err := r.q.InsertTransferFreeze(context.Background(), sqlc.InsertTransferFreezeParams{
+		Tenant:    f.Tenant,
+		Reason:    f.Reason,
+		FrozenBy:  f.FrozenBy,
+		ExpiresAt: f.ExpiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+	freeze := toTransferFreeze(row)
+	return &freeze, nil
+}
+
+// ListTransferFreezesInForce returns the freezes neither lifted nor expired at
+// now, oldest first.
+func (r *PostgresTransferFreezeRepository) ListTransferFreezesInForce(now time.Time) ([]models.TransferFreeze, error) {
+	defer r.queryLog.observe("ListTransferFreezesInForce", time.Now())
+	rows, err := r.q.ListTransferFreezesInForce(context.Background(), now)
+	return toTransferFreezes(rows), err
+}
+
+// ListTransferFreezes returns up to limit of the most recent freezes, newest
+// first, whether or not they are still in force.
+func (r *PostgresTransferFreezeRepository) ListTransferFreezes(limit int) ([]models.TransferFreeze, error) {
+	defer r.queryLog.observe("ListTransferFreezes", time.Now())
+	rows, err := r.q.ListTransferFreezes(context.Background(), int32(limit))
+	return toTransferFreezes(rows), err
+}
+
+// LiftTransferFreezes lifts the freezes of tenant in force at now and returns
+// them.
+func (r *PostgresTransferFreezeRepository) LiftTransferFreezes(tenant, liftedBy string, now time.Time) ([]models.TransferFreeze, error) {
+	defer r.queryLog.observe("LiftTransferFreezes", time.Now())
+	rows, err := r.q.LiftTransferFreezes(context.Background(), sqlc.LiftTransferFreezesParams{
+		LiftedBy: liftedBy,
+		Tenant:   tenant,
+		Now:      now,
+	})
+	return toTransferFreezes(rows), err
+}
+
+// GetAccountTenant returns the tenant that opened an account, open or closed.
+func (r *PostgresTransferFreezeRepository) GetAccountTenant(accountID int64) (string, error) {
+	defer r.queryLog.observe("GetAccountTenant", time.Now())
+	tenant, err := r.q.GetAccountTenant(context.Background(), accountID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("account with ID %d %w", accountID, ErrNotFound)
+	}
+	return tenant, err
+}
+
+func toTransferFreezes(rows []sqlc.TransferFreeze) []models.TransferFreeze {
+	freezes := make([]models.TransferFreeze, len(rows))
+	for i, row := range rows {
+		freezes[i] = toTransferFreeze(row)
+	}
+	return freezes
+}
+
+func toTransferFreeze(row sqlc.TransferFreeze) models.TransferFreeze {
+	f := models.TransferFreeze{
+		ID:        row.ID,
+		Tenant:    row.Tenant,
+		Reason:    row.Reason,
+		FrozenBy:  row.FrozenBy,
+		CreatedAt: row.CreatedAt,
+		ExpiresAt: row.ExpiresAt,
+		LiftedBy:  row.LiftedBy,
+	}
+	if row.LiftedAt.Valid {
+		f.LiftedAt = &row.LiftedAt.Time
+	}
+	return f
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository/sqlc"
+)
+
+// PostgresImpersonationRepository is an implementation of
+// ImpersonationRepository for PostgreSQL.
+type PostgresImpersonationRepository struct {
+	q        *sqlc.Queries
+	queryLog *QueryLogger
+}
+
+// NewPostgresImpersonationRepository creates a new PostgresImpersonationRepository.
+func NewPostgresImpersonationRepository(db *sql.DB, opts ...Option) *PostgresImpersonationRepository {
+	o := applyOptions(opts)
+	return &PostgresImpersonationRepository{q: sqlc.New(db), queryLog: o.queryLog}
+}
+
+func (r *PostgresImpersonationRepository) InsertImpersonation(i models.Impersonation) error {
+	defer r.queryLog.observe("InsertImpersonation", time.Now())
+	return r.q.InsertImpersonation(context.Background(), sqlc.InsertImpersonationParams{
+		Operator:  i.Operator,
+		ApiKey:    i.APIKey,
+		Tenant:    i.Tenant,
+		Method:    i.Method,
+		Path:      i.Path,
+		Reason:    i.Reason,
+		RequestID: i.RequestID,
+	})
+}
+
+// ListImpersonations returns up to limit impersonated requests matching filter,
+// oldest first, starting after the cursor, and the cursor of the last one.
+func (r *PostgresImpersonationRepository) ListImpersonations(filter models.ImpersonationFilter, after ChangeCursor, limit int) ([]models.Impersonation, ChangeCursor, error) {
+	defer r.queryLog.observe("ListImpersonations", time.Now())
+	rows, err := r.q.ListImpersonations(context.Background(), sqlc.ListImpersonationsParams{
+		AfterCreatedAt: after.UpdatedAt,
+		AfterID:        after.ID,
+		Operator:       nullString(filter.Operator),
+		ApiKey:         nullString(filter.APIKey),
+		Tenant:         nullString(filter.Tenant),
+		Since:          sql.NullTime{Time: filter.Since, Valid: !filter.Since.IsZero()},
+		Until:          sql.NullTime{Time: filter.Until, Valid: !filter.Until.IsZero()},
+		RowLimit:       int32(limit),
+	})
+	if err != nil {
+		return nil, after, err
+	}
+	impersonations := make([]models.Impersonation, len(rows))
+	for i, row := range rows {
+		impersonations[i] = models.Impersonation{
+			ID:        row.ID,
+			Operator:  row.Operator,
+			APIKey:    row.ApiKey,
+			Tenant:    row.Tenant,
+			Method:    row.Method,
+			Path:      row.Path,
+			Reason:    row.Reason,
+			RequestID: row.RequestID,
+			CreatedAt: row.CreatedAt,
+		}
+	}
+	if len(rows) > 0 {
+		last := rows[len(rows)-1]
+		after = ChangeCursor{UpdatedAt: last.CreatedAt, ID: last.ID}
+	}
+	return impersonations, after, nil
+}
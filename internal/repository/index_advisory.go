@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository/sqlc"
+)
+
+// statementScans lists the statements of the current database that read the
+// most shared blocks per call. pg_stat_statements is an extension, so this is
+// not a sqlc query: the schema sqlc compiles against does not have it.
+const statementScans = `SELECT query, calls,
+	((shared_blks_hit + shared_blks_read)::float8 / calls) AS blocks_per_call,
+	(rows::float8 / calls) AS rows_per_call,
+	mean_exec_time
+FROM pg_stat_statements
+WHERE dbid = (SELECT oid FROM pg_database WHERE datname = current_database()) AND calls > 0
+ORDER BY blocks_per_call DESC
+LIMIT $1`
+
+// PostgresIndexAdvisoryRepository is an implementation of
+// IndexAdvisoryRepository for PostgreSQL. It reads the statistics of the
+// primary, which are those of the queries it serves.
+type PostgresIndexAdvisoryRepository struct {
+	db       *sql.DB
+	q        *sqlc.Queries
+	queryLog *QueryLogger
+}
+
+// NewPostgresIndexAdvisoryRepository creates a new PostgresIndexAdvisoryRepository.
+func NewPostgresIndexAdvisoryRepository(db *sql.DB, opts ...Option) *PostgresIndexAdvisoryRepository {
+	o := applyOptions(opts)
+	return &PostgresIndexAdvisoryRepository{db: db, q: sqlc.New(db), queryLog: o.queryLog}
+}
+
+// ListIndexes returns the table of each index of the database, by index name.
+func (r *PostgresIndexAdvisoryRepository) ListIndexes(ctx context.Context) (map[string]string, error) {
+	defer r.queryLog.observe("ListIndexes", time.Now())
+	rows, err := r.q.ListIndexes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	indexes := make(map[string]string, len(rows))
+	for _, row := range rows {
+		indexes[row.IndexName] = row.TableName
+	}
+	return indexes, nil
+}
+
+// ListTableScans returns the scans of each table, the tables whose sequential
+// scans read the most rows first.
+func (r *PostgresIndexAdvisoryRepository) ListTableScans(ctx context.Context) ([]models.TableScans, error) {
+	defer r.queryLog.observe("ListTableScans", time.Now())
+	rows, err := r.q.ListTableScans(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tables := make([]models.TableScans, len(rows))
+	for i, row := range rows {
+		tables[i] = models.TableScans{
+			Table:       row.TableName,
+			SeqScans:    row.SeqScans,
+			SeqRowsRead: row.SeqRowsRead,
+			IndexScans:  row.IndexScans,
+			LiveRows:    row.LiveRows,
+		}
+	}
+	return tables, nil
+}
+
+// ListStatementScans returns the limit statements reading the most blocks per
+// call, and false without the pg_stat_statements extension.
+func (r *PostgresIndexAdvisoryRepository) ListStatementScans(ctx context.Context, limit int) ([]models.StatementScans, bool, error) {
+	defer r.queryLog.observe("ListStatementScans", time.Now())
+	var installed bool
+	if err := r.db.QueryRowContext(ctx, `SELECT to_regclass('pg_stat_statements') IS NOT NULL`).Scan(&installed); err != nil || !installed {
+		return nil, false, err
+	}
+	rows, err := r.db.QueryContext(ctx, statementScans, limit)
+	if err != nil {
+		return nil, true, err
+	}
+	defer rows.Close()
+	statements := []models.StatementScans{}
+	for rows.Next() {
+		var s models.StatementScans
+		if err := rows.Scan(&s.Query, &s.Calls, &s.BlocksPerCall, &s.RowsPerCall, &s.MeanMillis); err != nil {
+			return nil, true, err
+		}
+		statements = append(statements, s)
+	}
+	return statements, true, rows.Err()
+}
@@ -0,0 +1,209 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/nehciyy/intrapay/internal/idgen"
+	"github.com/nehciyy/intrapay/internal/models"
+)
+
+// The statements of a bulk ingestion work on a temporary table, which the
+// schema sqlc compiles against does not have, so they are not sqlc queries.
+const (
+	createIngestTable = `CREATE TEMP TABLE ingest_transfers (
+  line INTEGER PRIMARY KEY,
+  source_account_id BIGINT NOT NULL,
+  destination_account_id BIGINT NOT NULL,
+  amount NUMERIC(20, 5) NOT NULL,
+  amount_minor BIGINT NOT NULL,
+  transaction_ref TEXT
+) ON COMMIT DROP`
+
+	// skipIngested drops the staged transfers whose reference is already the
+	// ID of a transaction.
+	skipIngested = `DELETE FROM ingest_transfers s USING transactions t
+WHERE t.transaction_ref = s.transaction_ref
+RETURNING s.line`
+
+	// lockIngestAccounts locks the accounts of the staged transfers in the
+	// order of their IDs, as transfers of a payroll batch lock them.
+	lockIngestAccounts = `SELECT account_id, balance, deleted_at IS NULL, livemode FROM accounts
+WHERE account_id IN (SELECT source_account_id FROM ingest_transfers UNION SELECT destination_account_id FROM ingest_transfers)
+ORDER BY account_id
+FOR UPDATE`
+
+	// mergeIngestBalances applies the net amount the staged transfers move to
+	// or from each account.
+	mergeIngestBalances = `UPDATE accounts a SET balance = a.balance + d.delta
+FROM (
+  SELECT account_id, SUM(delta) AS delta FROM (
+    SELECT source_account_id AS account_id, -amount AS delta FROM ingest_transfers
+    UNION ALL
+    SELECT destination_account_id, amount FROM ingest_transfers
+  ) legs GROUP BY account_id
+) d
+WHERE a.account_id = d.account_id`
+
+	logIngested = `INSERT INTO transactions (source_account_id, destination_account_id, amount, amount_minor, transaction_ref)
+SELECT source_account_id, destination_account_id, amount, amount_minor, transaction_ref FROM ingest_transfers ORDER BY line
+RETURNING id, source_account_id, destination_account_id, amount, transaction_ref`
+
+	// mergeIngestTransactions logs the staged transfers, and
+	// mergeIngestTransactionsAndEntries their ledger entries as well.
+	mergeIngestTransactions           = `WITH logged AS (` + logIngested + `) SELECT id, COALESCE(transaction_ref, '') FROM logged`
+	mergeIngestTransactionsAndEntries = `WITH logged AS (` + logIngested + `),
+entries AS (
+  INSERT INTO ledger_entries (transaction_id, account_id, amount, entry_type)
+  SELECT id, source_account_id, -amount, 'debit' FROM logged
+  UNION ALL
+  SELECT id, destination_account_id, amount, 'credit' FROM logged
+)
+SELECT id, COALESCE(transaction_ref, '') FROM logged`
+)
+
+// StagedTransfers is what a bulk ingestion found once its transfers were
+// staged: the lines whose reference was ingested before, which are not merged,
+// and the accounts of the others, locked until the transaction ends.
+type StagedTransfers struct {
+	Skipped  []int
+	Accounts map[int64]models.IngestAccount
+}
+
+// PostgresIngestRepository is an implementation of IngestRepository for
+// PostgreSQL.
+type PostgresIngestRepository struct {
+	queryLog      *QueryLogger
+	ids           idgen.Generator
+	ledgerEntries bool
+}
+
+// NewPostgresIngestRepository creates a new PostgresIngestRepository.
+func NewPostgresIngestRepository(opts ...Option) *PostgresIngestRepository {
+	o := applyOptions(opts)
+	return &PostgresIngestRepository{queryLog: o.queryLog, ids: o.ids, ledgerEntries: o.ledgerEntries}
+}
+
+// StageTransfersTx loads transfers into a temporary table of tx with COPY,
+// drops those ingested before and locks the accounts of the others. A transfer
+// without a reference is given a generated transaction ID, if IDs are
+// generated.
+func (r *PostgresIngestRepository) StageTransfersTx(tx *sql.Tx, transfers []models.IngestTransfer) (*StagedTransfers, error) {
+	defer r.queryLog.observe("StageTransfersTx", time.Now())
+	ctx := context.Background()
+	if _, err := tx.ExecContext(ctx, createIngestTable); err != nil {
+		return nil, err
+	}
+	if err := r.copyTransfers(ctx, tx, transfers); err != nil {
+		return nil, err
+	}
+
+	staged := &StagedTransfers{Accounts: make(map[int64]models.IngestAccount)}
+	rows, err := tx.QueryContext(ctx, skipIngested)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var line int
+		if err := rows.Scan(&line); err != nil {
+			return nil, err
+		}
+		staged.Skipped = append(staged.Skipped, line)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	slices.Sort(staged.Skipped)
+
+	accounts, err := tx.QueryContext(ctx, lockIngestAccounts)
+	if err != nil {
+		return nil, err
+	}
+	defer accounts.Close()
+	for accounts.Next() {
+		var a models.IngestAccount
+		if err := accounts.Scan(&a.AccountID, &a.Balance, &a.Open, &a.Livemode); err != nil {
+			return nil, err
+		}
+		staged.Accounts[a.AccountID] = a
+	}
+	return staged, accounts.Err()
+}
+
+func (r *PostgresIngestRepository) copyTransfers(ctx context.Context, tx *sql.Tx, transfers []models.IngestTransfer) error {
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn("ingest_transfers",
+		"line", "source_account_id", "destination_account_id", "amount", "amount_minor", "transaction_ref"))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for i, t := range transfers {
+		ref := t.Reference
+		if ref == "" {
+			if ref, err = newTransactionRef(r.ids); err != nil {
+				return err
+			}
+		}
+		var transactionRef any
+		if ref != "" {
+			transactionRef = ref
+		}
+		if _, err := stmt.ExecContext(ctx, i, t.SourceAccountID, t.DestinationAccountID, float64(t.Amount), t.Amount.Minor(), transactionRef); err != nil {
+			return fmt.Errorf("stage transfer %d: %w", i, err)
+		}
+	}
+	// Flushes the rows buffered by COPY.
+	_, err = stmt.ExecContext(ctx)
+	return err
+}
+
+// MergeTransfersTx posts the transfers StageTransfersTx staged in tx and did
+// not skip: the balances of their accounts are updated by the net amount each
+// receives and the transfers are logged, in one statement each. It returns the
+// transaction IDs in the order of the transfers.
+func (r *PostgresIngestRepository) MergeTransfersTx(tx *sql.Tx) ([]string, error) {
+	defer r.queryLog.observe("MergeTransfersTx", time.Now())
+	ctx := context.Background()
+	if _, err := tx.ExecContext(ctx, mergeIngestBalances); err != nil {
+		return nil, err
+	}
+	merge := mergeIngestTransactions
+	if r.ledgerEntries {
+		merge = mergeIngestTransactionsAndEntries
+	}
+	rows, err := tx.QueryContext(ctx, merge)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	type logged struct {
+		serial int32
+		ref    string
+	}
+	var all []logged
+	for rows.Next() {
+		var l logged
+		if err := rows.Scan(&l.serial, &l.ref); err != nil {
+			return nil, err
+		}
+		all = append(all, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	// The rows were inserted in the order of the lines, so their serial keys
+	// ascend with them whatever order they are returned in.
+	slices.SortFunc(all, func(a, b logged) int { return cmp.Compare(a.serial, b.serial) })
+	transactionIDs := make([]string, len(all))
+	for i, l := range all {
+		transactionIDs[i] = transactionID(l.serial, l.ref)
+	}
+	return transactionIDs, nil
+}
@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/idgen"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository/sqlc"
+)
+
+// PostgresLedgerRepository is a double-entry implementation of TransactionRepository.
+// Balances are derived from the sum of ledger_entries rather than stored on the
+// account row, so UpdateBalanceTx is a no-op and entries are written alongside the
+// transaction log.
+type PostgresLedgerRepository struct {
+	db       *sql.DB
+	q        *sqlc.Queries
+	reads    router
+	queryLog *QueryLogger
+	ids      idgen.Generator
+}
+
+// NewPostgresLedgerRepository creates a new PostgresLedgerRepository.
+func NewPostgresLedgerRepository(db *sql.DB, opts ...Option) *PostgresLedgerRepository {
+	o := applyOptions(opts)
+	return &PostgresLedgerRepository{db: db, q: sqlc.New(db), reads: newRouter(db, o), queryLog: o.queryLog, ids: o.ids}
+}
+
+// CreateOpeningEntry records the initial balance of a newly created account.
+func (r *PostgresLedgerRepository) CreateOpeningEntry(accountID int64, amount float64) error {
+	defer r.queryLog.observe("CreateOpeningEntry", time.Now())
+	return r.q.CreateOpeningEntry(context.Background(), sqlc.CreateOpeningEntryParams{
+		AccountID: accountID,
+		Amount:    amount,
+	})
+}
+
+// GetAccountBalance sums the ledger entries of an account.
+func (r *PostgresLedgerRepository) GetAccountBalance(accountID int64) (float64, error) {
+	defer r.queryLog.observe("LedgerGetAccountBalance", time.Now())
+	return r.q.GetLedgerBalance(context.Background(), accountID)
+}
+
+func (r *PostgresLedgerRepository) GetAccountBalanceTx(tx *sql.Tx, accountID int64) (float64, error) {
+	defer r.queryLog.observe("LedgerGetAccountBalanceTx", time.Now())
+	q := r.q.WithTx(tx)
+	// Lock the account row so concurrent transfers serialize on it, as in the balance-column design.
+	if _, err := q.LockAccount(context.Background(), accountID); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, fmt.Errorf("account with ID %d %w", accountID, ErrNotFound)
+		}
+		return 0, err
+	}
+	return q.GetLedgerBalance(context.Background(), accountID)
+}
+
+func (r *PostgresLedgerRepository) AccountExistsTx(tx *sql.Tx, accountID int64) (bool, error) {
+	return r.q.WithTx(tx).AccountExists(context.Background(), accountID)
+}
+
+// UpdateBalanceTx is a no-op: balances are derived from entries.
+func (r *PostgresLedgerRepository) UpdateBalanceTx(tx *sql.Tx, accountID int64, delta float64) error {
+	return nil
+}
+
+// DebitBalanceTx locks the account and checks the derived balance covers amount.
+// The debit itself is recorded by InsertEntriesTx.
+func (r *PostgresLedgerRepository) DebitBalanceTx(tx *sql.Tx, accountID int64, amount float64) (float64, error) {
+	balance, err := r.GetAccountBalanceTx(tx, accountID)
+	if err != nil {
+		return 0, err
+	}
+	if balance < amount {
+		return 0, ErrInsufficientFunds
+	}
+	return balance - amount, nil
+}
+
+func (r *PostgresLedgerRepository) InsertTransactionLogTx(tx *sql.Tx, sourceID, destID int64, amount float64) (string, error) {
+	defer r.queryLog.observe("LedgerInsertTransactionLogTx", time.Now())
+	transactionID, serial, err := insertTransactionLog(r.q.WithTx(tx), r.ids, sourceID, destID, amount)
+	if err != nil {
+		return "", err
+	}
+	if err := r.insertEntries(tx, int64(serial), sourceID, destID, amount); err != nil {
+		return "", err
+	}
+	return transactionID, nil
+}
+
+func (r *PostgresLedgerRepository) InsertTransactionLogsTx(tx *sql.Tx, logs []TransactionLog) ([]string, error) {
+	defer r.queryLog.observe("LedgerInsertTransactionLogsTx", time.Now())
+	transactionIDs, serials, err := insertTransactionLogs(r.q.WithTx(tx), r.ids, logs)
+	if err != nil {
+		return nil, err
+	}
+	for i, l := range logs {
+		if err := r.insertEntries(tx, int64(serials[i]), l.SourceID, l.DestID, l.Amount); err != nil {
+			return nil, err
+		}
+	}
+	return transactionIDs, nil
+}
+
+func (r *PostgresLedgerRepository) ListTransactions(ctx context.Context, filter models.TransactionFilter, after ChangeCursor, limit int) ([]models.Transaction, ChangeCursor, error) {
+	defer r.queryLog.observe("LedgerListTransactions", time.Now())
+	return listTransactions(ctx, r.reads, filter, after, limit)
+}
+
+func (r *PostgresLedgerRepository) CountTransactions(ctx context.Context, filter models.TransactionFilter) (int64, error) {
+	defer r.queryLog.observe("LedgerCountTransactions", time.Now())
+	return countTransactions(ctx, r.reads, filter)
+}
+
+func (r *PostgresLedgerRepository) ListTransactionChanges(ctx context.Context, after ChangeCursor, livemode bool, limit int) ([]models.Transaction, ChangeCursor, error) {
+	defer r.queryLog.observe("LedgerListTransactionChanges", time.Now())
+	return listTransactionChanges(ctx, r.reads, after, livemode, limit)
+}
+
+// ComputeBalanceTx sums the ledger entries of an account inside tx.
+func (r *PostgresLedgerRepository) ComputeBalanceTx(tx *sql.Tx, accountID int64) (float64, error) {
+	return r.q.WithTx(tx).GetLedgerBalance(context.Background(), accountID)
+}
+
+func (r *PostgresLedgerRepository) InsertAdjustmentTx(tx *sql.Tx, accountID int64, amount float64, code models.ReasonCode, reason string) (string, error) {
+	defer r.queryLog.observe("LedgerInsertAdjustmentTx", time.Now())
+	id, err := r.q.WithTx(tx).InsertAdjustment(context.Background(), sqlc.InsertAdjustmentParams{
+		AccountID:  accountID,
+		Amount:     amount,
+		Reason:     reason,
+		ReasonCode: string(code),
+	})
+	if err != nil {
+		return "", err
+	}
+	adjustmentID := strconv.FormatInt(id, 10)
+	if err := r.InsertAdjustmentEntryTx(tx, adjustmentID, accountID, amount); err != nil {
+		return "", err
+	}
+	return adjustmentID, nil
+}
+
+// InsertAdjustmentEntryTx writes the ledger entry for a balance adjustment.
+func (r *PostgresLedgerRepository) InsertAdjustmentEntryTx(tx *sql.Tx, adjustmentID string, accountID int64, amount float64) error {
+	id, err := strconv.ParseInt(adjustmentID, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid adjustment ID %q: %w", adjustmentID, err)
+	}
+	return r.q.WithTx(tx).InsertAdjustmentEntry(context.Background(), sqlc.InsertAdjustmentEntryParams{
+		AdjustmentID: sql.NullInt64{Int64: id, Valid: true},
+		AccountID:    accountID,
+		Amount:       amount,
+	})
+}
+
+// InsertEntriesTx writes the debit and credit legs of a transfer. transactionID is
+// the ID returned when the transfer was logged: a transaction_ref when an ID
+// generator is configured, the serial key otherwise.
+func (r *PostgresLedgerRepository) InsertEntriesTx(tx *sql.Tx, transactionID string, sourceID, destID int64, amount float64) error {
+	if r.ids != nil {
+		serial, err := r.q.WithTx(tx).GetTransactionIDByRef(context.Background(), sql.NullString{String: transactionID, Valid: true})
+		if err != nil {
+			return fmt.Errorf("resolve transaction %q: %w", transactionID, err)
+		}
+		return r.insertEntries(tx, int64(serial), sourceID, destID, amount)
+	}
+	id, err := strconv.ParseInt(transactionID, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid transaction ID %q: %w", transactionID, err)
+	}
+	return r.insertEntries(tx, id, sourceID, destID, amount)
+}
+
+func (r *PostgresLedgerRepository) insertEntries(tx *sql.Tx, serial int64, sourceID, destID int64, amount float64) error {
+	defer r.queryLog.observe("InsertEntriesTx", time.Now())
+	return r.q.WithTx(tx).InsertTransferEntries(context.Background(), sqlc.InsertTransferEntriesParams{
+		TransactionID:        sql.NullInt64{Int64: serial, Valid: true},
+		SourceAccountID:      sourceID,
+		Debit:                -amount,
+		DestinationAccountID: destID,
+		Credit:               amount,
+	})
+}
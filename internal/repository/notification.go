@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository/sqlc"
+)
+
+// ErrAlreadyDigested is returned when recording the daily digest of an account
+// that was sent one for the day already.
+var ErrAlreadyDigested = errors.New("already digested")
+
+// PostgresNotificationRepository is an implementation of NotificationRepository
+// for PostgreSQL.
+type PostgresNotificationRepository struct {
+	q        *sqlc.Queries
+	queryLog *QueryLogger
+}
+
+// NewPostgresNotificationRepository creates a new PostgresNotificationRepository.
+func NewPostgresNotificationRepository(db *sql.DB, opts ...Option) *PostgresNotificationRepository {
+	o := applyOptions(opts)
+	return &PostgresNotificationRepository{q: sqlc.New(db), queryLog: o.queryLog}
+}
+
+// GetNotificationPreference returns how the notifications of an account are
+// sent: immediately, in UTC and with no UpdatedAt, for an account that never
+// set a mode.
+func (r *PostgresNotificationRepository) GetNotificationPreference(accountID int64) (*models.NotificationPreference, error) {
+	defer r.queryLog.observe("GetNotificationPreference", time.Now())
+	row, err := r.q.GetNotificationPreference(context.Background(), accountID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &models.NotificationPreference{AccountID: accountID, Mode: models.NotificationImmediate, Timezone: "UTC"}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return toNotificationPreference(row), nil
+}
+
+// SetNotificationPreference sets the mode of an account, and the time zone its
+// days are digested in. The days of an account switched to daily digests are
+// digested from now on.
+func (r *PostgresNotificationRepository) SetNotificationPreference(accountID int64, mode models.NotificationMode, timezone string) (*models.NotificationPreference, error) {
+	defer r.queryLog.observe("SetNotificationPreference", time.Now())
+	row, err := r.q.SetNotificationPreference(context.Background(), sqlc.SetNotificationPreferenceParams{
+		AccountID: accountID,
+		Mode:      string(mode),
+		Timezone:  timezone,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toNotificationPreference(row), nil
+}
+
+// ListPendingDigests sums up, for each open account whose notifications are
+// sent as a daily digest, the transfers of every day closed by now in the
+// account's time zone that it was not sent the digest of yet, oldest day
+// first. The days before the account switched to digests, and those before
+// the last one it was sent a digest of, are left out, as are days without
+// transfers.
+func (r *PostgresNotificationRepository) ListPendingDigests(now time.Time) ([]models.DailyDigest, error) {
+	defer r.queryLog.observe("ListPendingDigests", time.Now())
+	rows, err := r.q.ListPendingDigests(context.Background(), now)
+	if err != nil {
+		return nil, err
+	}
+	digests := make([]models.DailyDigest, len(rows))
+	for i, row := range rows {
+		digests[i] = models.DailyDigest{
+			AccountID:     row.AccountID,
+			Tenant:        row.Tenant,
+			Date:          row.Day.Format(time.DateOnly),
+			Incoming:      models.Amount(row.Incoming),
+			IncomingCount: row.IncomingCount,
+			Outgoing:      models.Amount(row.Outgoing),
+			OutgoingCount: row.OutgoingCount,
+		}
+	}
+	return digests, nil
+}
+
+// InsertDigestTx records, in the transaction that writes its event, that the
+// digest d was sent. It returns ErrAlreadyDigested if it was sent before.
+func (r *PostgresNotificationRepository) InsertDigestTx(tx *sql.Tx, d models.DailyDigest) error {
+	defer r.queryLog.observe("InsertDigestTx", time.Now())
+	day, err := time.Parse(time.DateOnly, d.Date)
+	if err != nil {
+		return err
+	}
+	n, err := r.q.WithTx(tx).InsertNotificationDigest(context.Background(), sqlc.InsertNotificationDigestParams{
+		AccountID:    d.AccountID,
+		Day:          day,
+		Transactions: d.IncomingCount + d.OutgoingCount,
+	})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrAlreadyDigested
+	}
+	return nil
+}
+
+func toNotificationPreference(row sqlc.NotificationPreference) *models.NotificationPreference {
+	return &models.NotificationPreference{
+		AccountID: row.AccountID,
+		Mode:      models.NotificationMode(row.Mode),
+		Timezone:  row.Timezone,
+		UpdatedAt: &row.UpdatedAt,
+	}
+}
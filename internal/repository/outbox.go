@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository/sqlc"
+)
+
+// PostgresOutboxRepository is an implementation of OutboxRepository for PostgreSQL.
+type PostgresOutboxRepository struct {
+	q        *sqlc.Queries
+	queryLog *QueryLogger
+}
+
+// NewPostgresOutboxRepository creates a new PostgresOutboxRepository.
+func NewPostgresOutboxRepository(db *sql.DB, opts ...Option) *PostgresOutboxRepository {
+	o := applyOptions(opts)
+	return &PostgresOutboxRepository{q: sqlc.New(db), queryLog: o.queryLog}
+}
+
+// InsertEventTx writes e to the outbox in the transaction that made the change
+// it describes, and returns its ID.
+func (r *PostgresOutboxRepository) InsertEventTx(tx *sql.Tx, e models.Event) (int64, error) {
+	defer r.queryLog.observe("InsertEventTx", time.Now())
+	row, err := r.q.WithTx(tx).InsertOutboxEvent(context.Background(), sqlc.InsertOutboxEventParams{
+		AggregateType: e.AggregateType,
+		AggregateID:   e.AggregateID,
+		EventType:     e.Type,
+		SchemaVersion: int32(e.SchemaVersion),
+		Payload:       e.Payload,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return row.ID, nil
+}
+
+// ListOutboxEvents returns up to limit settled events after afterID, in id order.
+func (r *PostgresOutboxRepository) ListOutboxEvents(afterID int64, limit int) ([]models.Event, error) {
+	defer r.queryLog.observe("ListOutboxEvents", time.Now())
+	rows, err := r.q.ListOutboxEvents(context.Background(), sqlc.ListOutboxEventsParams{AfterID: afterID, RowLimit: int32(limit)})
+	if err != nil {
+		return nil, err
+	}
+	events := make([]models.Event, len(rows))
+	for i, row := range rows {
+		events[i] = toEvent(row)
+	}
+	return events, nil
+}
+
+func (r *PostgresOutboxRepository) GetOutboxRelay() (*models.OutboxRelayStatus, error) {
+	defer r.queryLog.observe("GetOutboxRelay", time.Now())
+	row, err := r.q.GetOutboxRelay(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	latest, err := r.q.GetLatestOutboxEventID(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return &models.OutboxRelayStatus{
+		LastEventID:   row.LastEventID,
+		LatestEventID: latest,
+		Paused:        row.Paused,
+		UpdatedAt:     row.UpdatedAt,
+	}, nil
+}
+
+// AdvanceOutboxRelay moves the high-water mark from from to to. It reports false,
+// leaving the mark alone, if the mark is no longer at from, e.g. after a replay.
+func (r *PostgresOutboxRepository) AdvanceOutboxRelay(from, to int64) (bool, error) {
+	defer r.queryLog.observe("AdvanceOutboxRelay", time.Now())
+	n, err := r.q.AdvanceOutboxRelay(context.Background(), sqlc.AdvanceOutboxRelayParams{LastEventID: to, Expected: from})
+	return n > 0, err
+}
+
+// SetOutboxRelayPosition moves the high-water mark to afterID, so the relay
+// publishes the events after it, again if need be.
+func (r *PostgresOutboxRepository) SetOutboxRelayPosition(afterID int64) error {
+	defer r.queryLog.observe("SetOutboxRelayPosition", time.Now())
+	return r.q.SetOutboxRelayPosition(context.Background(), afterID)
+}
+
+func (r *PostgresOutboxRepository) SetOutboxRelayPaused(paused bool) error {
+	defer r.queryLog.observe("SetOutboxRelayPaused", time.Now())
+	return r.q.SetOutboxRelayPaused(context.Background(), paused)
+}
+
+func toEvent(row sqlc.OutboxEvent) models.Event {
+	return models.Event{
+		ID:            row.ID,
+		Type:          row.EventType,
+		SchemaVersion: int(row.SchemaVersion),
+		AggregateType: row.AggregateType,
+		AggregateID:   row.AggregateID,
+		Payload:       row.Payload,
+		CreatedAt:     row.CreatedAt,
+	}
+}
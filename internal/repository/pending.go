@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository/sqlc"
+)
+
+// ErrAlreadyClaimed is returned when claiming an action someone else holds.
+var ErrAlreadyClaimed = errors.New("already claimed")
+
+// PostgresPendingActionRepository is an implementation of PendingActionRepository
+// for PostgreSQL.
+type PostgresPendingActionRepository struct {
+	q        *sqlc.Queries
+	queryLog *QueryLogger
+}
+
+// NewPostgresPendingActionRepository creates a new PostgresPendingActionRepository.
+func NewPostgresPendingActionRepository(db *sql.DB, opts ...Option) *PostgresPendingActionRepository {
+	o := applyOptions(opts)
+	return &PostgresPendingActionRepository{q: sqlc.New(db), queryLog: o.queryLog}
+}
+
+// AddPendingAction opens an action for an item. If one is already open for the
+// item, its summary is updated instead.
+func (r *PostgresPendingActionRepository) AddPendingAction(kind models.PendingActionKind, reference, summary string) (*models.PendingAction, error) {
+	defer r.queryLog.observe("AddPendingAction", time.Now())
+	row, err := r.q.AddPendingAction(context.Background(), sqlc.AddPendingActionParams{
+		Kind:      string(kind),
+		Reference: reference,
+		Summary:   summary,
+	})
+	if err != nil {
+		return nil, err
+	}
+	action := toPendingAction(row)
+	return &action, nil
+}
+
+// ResolvePendingAction closes the open action of an item, removing it from the feed.
+func (r *PostgresPendingActionRepository) ResolvePendingAction(kind models.PendingActionKind, reference string) error {
+	defer r.queryLog.observe("ResolvePendingAction", time.Now())
+	n, err := r.q.ResolvePendingAction(context.Background(), sqlc.ResolvePendingActionParams{Kind: string(kind), Reference: reference})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("open %s action for %s %w", kind, reference, ErrNotFound)
+	}
+	return nil
+}
+
+// ExpirePendingActions expires the open actions of kind created before
+// createdBefore and returns how many it expired. Expired actions leave the feed.
+func (r *PostgresPendingActionRepository) ExpirePendingActions(kind models.PendingActionKind, createdBefore time.Time) (int64, error) {
+	defer r.queryLog.observe("ExpirePendingActions", time.Now())
+	return r.q.ExpirePendingActions(context.Background(), sqlc.ExpirePendingActionsParams{Kind: string(kind), CreatedAt: createdBefore})
+}
+
+// ListPendingActions returns up to limit open actions matching filter, oldest
+// first, starting after the cursor, and the cursor of the last one.
+func (r *PostgresPendingActionRepository) ListPendingActions(filter models.PendingActionFilter, after ChangeCursor, limit int) ([]models.PendingAction, ChangeCursor, error) {
+	defer r.queryLog.observe("ListPendingActions", time.Now())
+	rows, err := r.q.ListPendingActions(context.Background(), sqlc.ListPendingActionsParams{
+		AfterCreatedAt: after.UpdatedAt,
+		AfterID:        after.ID,
+		Kind:           nullString(string(filter.Kind)),
+		Assignee:       nullString(filter.Assignee),
+		Unassigned:     filter.Unassigned,
+		RowLimit:       int32(limit),
+	})
+	if err != nil {
+		return nil, after, err
+	}
+	actions := make([]models.PendingAction, len(rows))
+	for i, row := range rows {
+		actions[i] = toPendingAction(row)
+	}
+	if len(rows) > 0 {
+		last := rows[len(rows)-1]
+		after = ChangeCursor{UpdatedAt: last.CreatedAt, ID: last.ID}
+	}
+	return actions, after, nil
+}
+
+// CountPendingActions counts the open actions matching filter.
+func (r *PostgresPendingActionRepository) CountPendingActions(filter models.PendingActionFilter) (int64, error) {
+	defer r.queryLog.observe("CountPendingActions", time.Now())
+	return r.q.CountPendingActions(context.Background(), sqlc.CountPendingActionsParams{
+		Kind:       nullString(string(filter.Kind)),
+		Assignee:   nullString(filter.Assignee),
+		Unassigned: filter.Unassigned,
+	})
+}
+
+// ClaimPendingAction assigns an open action to assignee. It fails with
+// ErrAlreadyClaimed if someone else holds the action; claiming it again is a no-op.
+func (r *PostgresPendingActionRepository) ClaimPendingAction(id int64, assignee string) (*models.PendingAction, error) {
+	defer r.queryLog.observe("ClaimPendingAction", time.Now())
+	row, err := r.q.ClaimPendingAction(context.Background(), sqlc.ClaimPendingActionParams{Assignee: assignee, ID: id})
+	if err == sql.ErrNoRows {
+		return nil, r.claimConflict(id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	action := toPendingAction(row)
+	return &action, nil
+}
+
+// claimConflict explains why an action could not be claimed.
+func (r *PostgresPendingActionRepository) claimConflict(id int64) error {
+	row, err := r.q.GetPendingAction(context.Background(), id)
+	if err == sql.ErrNoRows || (err == nil && row.ResolvedAt.Valid) {
+		return fmt.Errorf("open pending action %d %w", id, ErrNotFound)
+	}
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("pending action %d %w by %s", id, ErrAlreadyClaimed, row.Assignee.String)
+}
+
+// AssignPendingAction hands an open action to assignee, taking it from whoever
+// holds it. An empty assignee releases the action.
+func (r *PostgresPendingActionRepository) AssignPendingAction(id int64, assignee string) (*models.PendingAction, error) {
+	defer r.queryLog.observe("AssignPendingAction", time.Now())
+	row, err := r.q.AssignPendingAction(context.Background(), sqlc.AssignPendingActionParams{Assignee: nullString(assignee), ID: id})
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("open pending action %d %w", id, ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	action := toPendingAction(row)
+	return &action, nil
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func toPendingAction(row sqlc.PendingAction) models.PendingAction {
+	action := models.PendingAction{
+		ID:        row.ID,
+		Kind:      models.PendingActionKind(row.Kind),
+		Reference: row.Reference,
+		Summary:   row.Summary,
+		Assignee:  row.Assignee.String,
+		CreatedAt: row.CreatedAt,
+	}
+	if row.AssignedAt.Valid {
+		action.AssignedAt = &row.AssignedAt.Time
+	}
+	return action
+}
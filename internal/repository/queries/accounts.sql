@@ -0,0 +1,40 @@
+-- name: CreateAccount :one
+INSERT INTO accounts(account_id, balance, opening_balance, tenant, livemode, owner_key) VALUES($1, $2, $2, $3, $4, $5)
+RETURNING account_id, balance, created_at, updated_at, deleted_at, display_name, livemode;
+
+-- name: CountTenantAccounts :one
+SELECT COUNT(*) FROM accounts WHERE tenant = $1 AND deleted_at IS NULL;
+
+-- name: AccountExists :one
+SELECT EXISTS(SELECT 1 FROM accounts WHERE account_id = $1 AND deleted_at IS NULL);
+
+-- name: GetAccount :one
+SELECT account_id, balance, created_at, updated_at, deleted_at, display_name, livemode FROM accounts
+WHERE account_id = sqlc.arg(account_id) AND (deleted_at IS NULL OR sqlc.arg(include_deleted)::boolean);
+
+-- name: ListAccounts :many
+-- Keyset page over account_id of the open accounts of one mode whose balance
+-- is within the bounds.
+SELECT account_id, balance, created_at, updated_at, deleted_at, display_name, livemode FROM accounts
+WHERE account_id > sqlc.arg(after_id)::bigint AND deleted_at IS NULL AND livemode = sqlc.arg(livemode)
+	AND (sqlc.narg(min_balance)::numeric IS NULL OR balance >= sqlc.narg(min_balance)::numeric)
+	AND (sqlc.narg(max_balance)::numeric IS NULL OR balance <= sqlc.narg(max_balance)::numeric)
+ORDER BY account_id
+LIMIT sqlc.arg(row_limit);
+
+-- name: SoftDeleteAccount :execrows
+UPDATE accounts SET deleted_at = CURRENT_TIMESTAMP WHERE account_id = $1 AND deleted_at IS NULL;
+
+-- name: RestoreAccount :execrows
+UPDATE accounts SET deleted_at = NULL WHERE account_id = $1 AND deleted_at IS NOT NULL;
+
+-- name: SetAccountDisplayName :execrows
+UPDATE accounts SET display_name = $2 WHERE account_id = $1 AND deleted_at IS NULL;
+
+-- name: GetBalanceTotals :one
+SELECT COALESCE(SUM(balance), 0)::numeric AS total,
+	(COALESCE(SUM(opening_balance), 0) + (SELECT COALESCE(SUM(amount), 0) FROM balance_adjustments))::numeric AS expected
+FROM accounts;
+
+-- name: NextAccountID :one
+SELECT nextval('account_id_seq')::bigint AS account_id;
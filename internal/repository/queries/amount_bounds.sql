@@ -0,0 +1,17 @@
+-- name: SetAmountBounds :one
+INSERT INTO amount_bounds (tenant, min_amount, max_amount)
+VALUES ($1, $2, $3)
+ON CONFLICT (tenant) DO UPDATE
+SET min_amount = EXCLUDED.min_amount,
+	max_amount = EXCLUDED.max_amount,
+	updated_at = CURRENT_TIMESTAMP
+RETURNING tenant, min_amount, max_amount, updated_at;
+
+-- name: ListAmountBounds :many
+SELECT tenant, min_amount, max_amount, updated_at
+FROM amount_bounds
+ORDER BY tenant;
+
+-- name: DeleteAmountBounds :execrows
+DELETE FROM amount_bounds
+WHERE tenant = $1;
@@ -0,0 +1,34 @@
+-- name: StartBackfill :one
+-- Creates the progress row of a backfill on its first run, and returns it.
+INSERT INTO backfills (name) VALUES ($1)
+ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+RETURNING name, last_id, rows_updated, started_at, updated_at, completed_at;
+
+-- name: GetBackfill :one
+SELECT name, last_id, rows_updated, started_at, updated_at, completed_at
+FROM backfills
+WHERE name = $1;
+
+-- name: ListBackfills :many
+SELECT name, last_id, rows_updated, started_at, updated_at, completed_at
+FROM backfills
+ORDER BY name;
+
+-- name: AdvanceBackfill :execrows
+-- Moves a backfill past a batch unless another run moved it since it was read.
+UPDATE backfills
+SET last_id = sqlc.arg(last_id), rows_updated = rows_updated + sqlc.arg(rows_updated),
+	updated_at = CURRENT_TIMESTAMP, completed_at = NULL
+WHERE name = sqlc.arg(name) AND last_id = sqlc.arg(expected)::bigint;
+
+-- name: CompleteBackfill :exec
+UPDATE backfills
+SET completed_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE name = $1;
+
+-- name: ResetBackfill :execrows
+-- Starts a backfill over from the first row.
+UPDATE backfills
+SET last_id = 0, rows_updated = 0, started_at = CURRENT_TIMESTAMP,
+	updated_at = CURRENT_TIMESTAMP, completed_at = NULL
+WHERE name = $1;
@@ -0,0 +1,47 @@
+-- name: InsertCashbackCampaign :one
+INSERT INTO cashback_campaigns (name, percentage, cap, funding_account_id, eligible_account_ids, starts_at, ends_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, name, percentage, cap, funding_account_id, eligible_account_ids, starts_at, ends_at, paid, created_at, updated_at;
+
+-- name: UpdateCashbackCampaign :one
+-- Keeps what the campaign has paid so far.
+UPDATE cashback_campaigns
+SET name = $2,
+	percentage = $3,
+	cap = $4,
+	funding_account_id = $5,
+	eligible_account_ids = $6,
+	starts_at = $7,
+	ends_at = $8,
+	updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING id, name, percentage, cap, funding_account_id, eligible_account_ids, starts_at, ends_at, paid, created_at, updated_at;
+
+-- name: GetCashbackCampaign :one
+SELECT id, name, percentage, cap, funding_account_id, eligible_account_ids, starts_at, ends_at, paid, created_at, updated_at
+FROM cashback_campaigns
+WHERE id = $1;
+
+-- name: ListCashbackCampaigns :many
+SELECT id, name, percentage, cap, funding_account_id, eligible_account_ids, starts_at, ends_at, paid, created_at, updated_at
+FROM cashback_campaigns
+ORDER BY id;
+
+-- name: ListActiveCashbackCampaigns :many
+-- Campaigns running at the time a transfer by the account was made, that the
+-- account is eligible for and does not fund.
+SELECT id, name, percentage, cap, funding_account_id, eligible_account_ids, starts_at, ends_at, paid, created_at, updated_at
+FROM cashback_campaigns
+WHERE starts_at <= sqlc.arg(at) AND ends_at > sqlc.arg(at)
+	AND funding_account_id <> sqlc.arg(account_id)
+	AND (cardinality(eligible_account_ids) = 0 OR sqlc.arg(account_id)::bigint = ANY (eligible_account_ids))
+ORDER BY id;
+
+-- name: AddCashbackCampaignPaid :execrows
+UPDATE cashback_campaigns
+SET paid = paid + $2
+WHERE id = $1;
+
+-- name: DeleteCashbackCampaign :execrows
+DELETE FROM cashback_campaigns
+WHERE id = $1;
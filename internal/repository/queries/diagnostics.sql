@@ -0,0 +1,21 @@
+-- name: ListLockWaits :many
+SELECT a.pid, pg_blocking_pids(a.pid)::bigint[] AS blocking_pids, COALESCE(a.wait_event, '')::text AS wait_event,
+	EXTRACT(EPOCH FROM now() - a.query_start)::float8 AS waited_seconds
+FROM pg_stat_activity a
+WHERE a.wait_event_type = 'Lock' AND a.datname = current_database();
+
+-- name: ListTableScans :many
+-- Scans of the tables of the current schema since the statistics were last
+-- reset, the tables whose sequential scans read the most rows first.
+SELECT relname::text AS table_name, COALESCE(seq_scan, 0)::bigint AS seq_scans,
+	COALESCE(seq_tup_read, 0)::bigint AS seq_rows_read, COALESCE(idx_scan, 0)::bigint AS index_scans,
+	COALESCE(n_live_tup, 0)::bigint AS live_rows
+FROM pg_stat_user_tables
+WHERE schemaname = current_schema()
+ORDER BY seq_tup_read DESC, relname;
+
+-- name: ListIndexes :many
+SELECT indexname::text AS index_name, tablename::text AS table_name
+FROM pg_indexes
+WHERE schemaname = current_schema()
+ORDER BY indexname;
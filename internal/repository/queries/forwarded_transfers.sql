@@ -0,0 +1,19 @@
+-- name: InsertForwardedTransfer :execrows
+-- Records the outcome of a forwarded transfer unless one was recorded before,
+-- for the transfer or for its API key's Idempotency-Key. A concurrent insert of
+-- either waits for the first to commit and then inserts nothing.
+INSERT INTO forwarded_transfers (forward_id, status, transaction_id, code, error, accepted_at, replayed_at, api_key, idempotency_key)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+ON CONFLICT DO NOTHING;
+
+-- name: GetForwardedTransfer :one
+SELECT forward_id, status, transaction_id, code, error, accepted_at, replayed_at, api_key, idempotency_key
+FROM forwarded_transfers
+WHERE forward_id = $1;
+
+-- name: FindForwardedTransfer :one
+-- The outcome of the forwarded transfer an API key requested with an
+-- Idempotency-Key.
+SELECT forward_id, status, transaction_id, code, error, accepted_at, replayed_at, api_key, idempotency_key
+FROM forwarded_transfers
+WHERE api_key = $1 AND idempotency_key = $2;
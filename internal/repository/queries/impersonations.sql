@@ -0,0 +1,17 @@
+-- name: InsertImpersonation :exec
+INSERT INTO impersonations (operator, api_key, tenant, method, path, reason, request_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7);
+
+-- name: ListImpersonations :many
+-- Keyset page over (created_at, id) of the impersonated requests matching the
+-- filters.
+SELECT id, operator, api_key, tenant, method, path, reason, request_id, created_at
+FROM impersonations
+WHERE (created_at, id) > (sqlc.arg(after_created_at), sqlc.arg(after_id)::bigint)
+	AND (sqlc.narg(operator)::text IS NULL OR operator = sqlc.narg(operator)::text)
+	AND (sqlc.narg(api_key)::text IS NULL OR api_key = sqlc.narg(api_key)::text)
+	AND (sqlc.narg(tenant)::text IS NULL OR tenant = sqlc.narg(tenant)::text)
+	AND (sqlc.narg(since)::timestamptz IS NULL OR created_at >= sqlc.narg(since)::timestamptz)
+	AND (sqlc.narg(until)::timestamptz IS NULL OR created_at < sqlc.narg(until)::timestamptz)
+ORDER BY created_at, id
+LIMIT sqlc.arg(row_limit);
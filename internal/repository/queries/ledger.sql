@@ -0,0 +1,17 @@
+-- name: CreateOpeningEntry :exec
+INSERT INTO ledger_entries (account_id, amount, entry_type) VALUES ($1, $2, 'opening');
+
+-- name: GetLedgerBalance :one
+SELECT COALESCE(SUM(amount), 0)::numeric AS balance FROM ledger_entries WHERE account_id = $1;
+
+-- name: LockAccount :one
+SELECT account_id FROM accounts WHERE account_id = $1 AND deleted_at IS NULL FOR UPDATE;
+
+-- name: InsertTransferEntries :exec
+INSERT INTO ledger_entries (transaction_id, account_id, amount, entry_type)
+VALUES (sqlc.arg(transaction_id), sqlc.arg(source_account_id), sqlc.arg(debit), 'debit'),
+	(sqlc.arg(transaction_id), sqlc.arg(destination_account_id), sqlc.arg(credit), 'credit');
+
+-- name: InsertAdjustmentEntry :exec
+INSERT INTO ledger_entries (adjustment_id, account_id, amount, entry_type)
+VALUES ($1, $2, $3, 'adjustment');
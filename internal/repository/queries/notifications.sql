@@ -0,0 +1,54 @@
+-- name: GetNotificationPreference :one
+SELECT account_id, mode, updated_at, timezone, digest_since
+FROM notification_preferences
+WHERE account_id = $1;
+
+-- name: SetNotificationPreference :one
+-- Sets how the notifications of an account are sent. An account switched to
+-- daily digests is digested from now on; one that stays on them keeps the time
+-- its days are digested since.
+INSERT INTO notification_preferences (account_id, mode, timezone, digest_since)
+VALUES ($1, $2, $3, CASE WHEN $2 = 'daily_digest' THEN CURRENT_TIMESTAMP END)
+ON CONFLICT (account_id) DO UPDATE
+SET mode = EXCLUDED.mode,
+	timezone = EXCLUDED.timezone,
+	digest_since = CASE
+		WHEN EXCLUDED.mode <> 'daily_digest' THEN NULL
+		WHEN notification_preferences.mode = 'daily_digest' THEN notification_preferences.digest_since
+		ELSE CURRENT_TIMESTAMP
+	END,
+	updated_at = CURRENT_TIMESTAMP
+RETURNING account_id, mode, updated_at, timezone, digest_since;
+
+-- name: ListPendingDigests :many
+-- Totals the transfers into and out of each open account whose notifications
+-- are sent as a daily digest, by day in the account's time zone, for the days
+-- closed by now since the account was first digested and after the last day
+-- it was sent a digest of. Days without transfers are left out.
+SELECT p.account_id, a.tenant,
+	(t.created_at AT TIME ZONE p.timezone)::date AS day,
+	COALESCE(SUM(t.amount) FILTER (WHERE t.destination_account_id = p.account_id), 0)::numeric AS incoming,
+	COUNT(*) FILTER (WHERE t.destination_account_id = p.account_id) AS incoming_count,
+	COALESCE(SUM(t.amount) FILTER (WHERE t.source_account_id = p.account_id), 0)::numeric AS outgoing,
+	COUNT(*) FILTER (WHERE t.source_account_id = p.account_id) AS outgoing_count
+FROM notification_preferences p
+JOIN accounts a ON a.account_id = p.account_id
+JOIN transactions t ON (t.source_account_id = p.account_id OR t.destination_account_id = p.account_id)
+	AND t.created_at >= p.digest_since
+	AND t.created_at < date_trunc('day', sqlc.arg(now)::timestamptz AT TIME ZONE p.timezone) AT TIME ZONE p.timezone
+	AND t.created_at >= COALESCE((
+		SELECT (MAX(d.day) + 1)::timestamp AT TIME ZONE p.timezone
+		FROM notification_digests d
+		WHERE d.account_id = p.account_id
+	), p.digest_since)
+WHERE p.mode = 'daily_digest' AND a.deleted_at IS NULL
+GROUP BY p.account_id, a.tenant, day
+ORDER BY day, p.account_id;
+
+-- name: InsertNotificationDigest :execrows
+-- Records the digest of an account's day unless one was recorded before. A
+-- concurrent insert of the same digest waits for the first to commit and then
+-- inserts nothing.
+INSERT INTO notification_digests (account_id, day, transactions)
+VALUES ($1, $2, $3)
+ON CONFLICT (account_id, day) DO NOTHING;
@@ -0,0 +1,38 @@
+-- name: InsertOutboxEvent :one
+INSERT INTO outbox_events (aggregate_type, aggregate_id, event_type, schema_version, payload)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, aggregate_type, aggregate_id, event_type, payload, created_at, schema_version;
+
+-- name: ListOutboxEvents :many
+-- Events after the offset in id order. Events newer than the settle window are
+-- held back: created_at is the writing transaction's start time, so an event
+-- still in flight could otherwise commit behind a relay that has moved past it.
+SELECT id, aggregate_type, aggregate_id, event_type, payload, created_at, schema_version
+FROM outbox_events
+WHERE id > sqlc.arg(after_id)::bigint
+	AND created_at <= CURRENT_TIMESTAMP - interval '5 seconds'
+ORDER BY id
+LIMIT sqlc.arg(row_limit);
+
+-- name: GetLatestOutboxEventID :one
+SELECT COALESCE(MAX(id), 0)::bigint AS id
+FROM outbox_events;
+
+-- name: GetOutboxRelay :one
+SELECT id, last_event_id, paused, updated_at
+FROM outbox_relay;
+
+-- name: AdvanceOutboxRelay :execrows
+-- Moves the high-water mark forward unless it was moved since it was read, e.g.
+-- by a replay.
+UPDATE outbox_relay
+SET last_event_id = sqlc.arg(last_event_id), updated_at = CURRENT_TIMESTAMP
+WHERE last_event_id = sqlc.arg(expected)::bigint;
+
+-- name: SetOutboxRelayPosition :exec
+UPDATE outbox_relay
+SET last_event_id = $1, updated_at = CURRENT_TIMESTAMP;
+
+-- name: SetOutboxRelayPaused :exec
+UPDATE outbox_relay
+SET paused = $1, updated_at = CURRENT_TIMESTAMP;
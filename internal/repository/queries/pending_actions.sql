@@ -0,0 +1,59 @@
+-- name: AddPendingAction :one
+-- Opens an action, or refreshes the summary of the one already open for the item.
+INSERT INTO pending_actions (kind, reference, summary)
+VALUES ($1, $2, $3)
+ON CONFLICT (kind, reference) WHERE resolved_at IS NULL DO UPDATE
+SET summary = EXCLUDED.summary
+RETURNING id, kind, reference, summary, assignee, assigned_at, created_at, resolved_at, expired_at;
+
+-- name: ResolvePendingAction :execrows
+UPDATE pending_actions
+SET resolved_at = CURRENT_TIMESTAMP
+WHERE kind = $1 AND reference = $2 AND resolved_at IS NULL;
+
+-- name: ExpirePendingActions :execrows
+-- Expires the open actions of a kind created before the cutoff.
+UPDATE pending_actions
+SET resolved_at = CURRENT_TIMESTAMP, expired_at = CURRENT_TIMESTAMP
+WHERE kind = $1 AND resolved_at IS NULL AND created_at < $2;
+
+-- name: GetPendingAction :one
+SELECT id, kind, reference, summary, assignee, assigned_at, created_at, resolved_at, expired_at
+FROM pending_actions
+WHERE id = $1;
+
+-- name: ListPendingActions :many
+-- Keyset page over (created_at, id) of the open actions, oldest first.
+SELECT id, kind, reference, summary, assignee, assigned_at, created_at, resolved_at, expired_at
+FROM pending_actions
+WHERE resolved_at IS NULL
+	AND (created_at, id) > (sqlc.arg(after_created_at), sqlc.arg(after_id)::bigint)
+	AND (sqlc.narg(kind)::text IS NULL OR kind = sqlc.narg(kind)::text)
+	AND (sqlc.narg(assignee)::text IS NULL OR assignee = sqlc.narg(assignee)::text)
+	AND (NOT sqlc.arg(unassigned)::boolean OR assignee IS NULL)
+ORDER BY created_at, id
+LIMIT sqlc.arg(row_limit);
+
+-- name: CountPendingActions :one
+SELECT COUNT(*)
+FROM pending_actions
+WHERE resolved_at IS NULL
+	AND (sqlc.narg(kind)::text IS NULL OR kind = sqlc.narg(kind)::text)
+	AND (sqlc.narg(assignee)::text IS NULL OR assignee = sqlc.narg(assignee)::text)
+	AND (NOT sqlc.arg(unassigned)::boolean OR assignee IS NULL);
+
+-- name: ClaimPendingAction :one
+-- Assigns an open action to the claimant unless someone else already holds it.
+UPDATE pending_actions
+SET assignee = sqlc.arg(assignee)::text, assigned_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id) AND resolved_at IS NULL
+	AND (assignee IS NULL OR assignee = sqlc.arg(assignee)::text)
+RETURNING id, kind, reference, summary, assignee, assigned_at, created_at, resolved_at, expired_at;
+
+-- name: AssignPendingAction :one
+-- Hands an open action to an assignee regardless of who holds it; NULL releases it.
+UPDATE pending_actions
+SET assignee = sqlc.narg(assignee)::text,
+	assigned_at = CASE WHEN sqlc.narg(assignee)::text IS NULL THEN NULL ELSE CURRENT_TIMESTAMP END
+WHERE id = sqlc.arg(id) AND resolved_at IS NULL
+RETURNING id, kind, reference, summary, assignee, assigned_at, created_at, resolved_at, expired_at;
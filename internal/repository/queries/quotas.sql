@@ -0,0 +1,39 @@
+-- name: SetQuota :one
+INSERT INTO api_quotas (scope, subject, monthly_calls, monthly_volume)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (scope, subject) DO UPDATE
+SET monthly_calls = EXCLUDED.monthly_calls,
+	monthly_volume = EXCLUDED.monthly_volume,
+	updated_at = CURRENT_TIMESTAMP
+RETURNING scope, subject, monthly_calls, monthly_volume, updated_at;
+
+-- name: GetQuota :one
+SELECT scope, subject, monthly_calls, monthly_volume, updated_at
+FROM api_quotas
+WHERE scope = $1 AND subject = $2;
+
+-- name: ListQuotas :many
+SELECT scope, subject, monthly_calls, monthly_volume, updated_at
+FROM api_quotas
+ORDER BY scope, subject;
+
+-- name: DeleteQuota :execrows
+DELETE FROM api_quotas
+WHERE scope = $1 AND subject = $2;
+
+-- name: SetAccountLimit :one
+INSERT INTO account_limits (tenant, max_accounts)
+VALUES ($1, $2)
+ON CONFLICT (tenant) DO UPDATE
+SET max_accounts = EXCLUDED.max_accounts,
+	updated_at = CURRENT_TIMESTAMP
+RETURNING tenant, max_accounts, updated_at;
+
+-- name: GetAccountLimit :one
+SELECT tenant, max_accounts, updated_at
+FROM account_limits
+WHERE tenant = $1;
+
+-- name: DeleteAccountLimit :execrows
+DELETE FROM account_limits
+WHERE tenant = $1;
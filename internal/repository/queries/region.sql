@@ -0,0 +1,11 @@
+-- name: GetRegionFence :one
+SELECT id, active_region, epoch, updated_at
+FROM region_fence;
+
+-- name: PromoteRegion :execrows
+-- Makes a region the active one and bumps the fencing token, unless the fence
+-- was moved since it was read, e.g. by a concurrent promotion. Waits for the
+-- writes in flight, which hold a share lock on the fence.
+UPDATE region_fence
+SET active_region = sqlc.arg(active_region), epoch = epoch + 1, updated_at = CURRENT_TIMESTAMP
+WHERE epoch = sqlc.arg(expected)::bigint;
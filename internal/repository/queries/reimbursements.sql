@@ -0,0 +1,16 @@
+-- name: InsertReimbursement :one
+INSERT INTO reimbursements (tenant, account_id, amount, description, receipt)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, tenant, account_id, amount, description, receipt, status, decided_by, decision_note, transaction_id, created_at, decided_at;
+
+-- name: GetReimbursement :one
+SELECT id, tenant, account_id, amount, description, receipt, status, decided_by, decision_note, transaction_id, created_at, decided_at
+FROM reimbursements
+WHERE id = $1;
+
+-- name: DecideReimbursement :one
+-- Approves or rejects a pending reimbursement. No row means it was already decided.
+UPDATE reimbursements
+SET status = $2, decided_by = $3, decision_note = $4, transaction_id = $5, decided_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND status = 'pending'
+RETURNING id, tenant, account_id, amount, description, receipt, status, decided_by, decision_note, transaction_id, created_at, decided_at;
@@ -0,0 +1,93 @@
+-- name: PutFXRate :one
+INSERT INTO fx_rates (currency, base_currency, rate_date, rate)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (currency, base_currency, rate_date)
+DO UPDATE SET rate = EXCLUDED.rate, updated_at = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: GetFXRate :one
+SELECT * FROM fx_rates
+WHERE currency = $1 AND base_currency = $2 AND rate_date = $3;
+
+-- name: InsertRevaluation :one
+INSERT INTO revaluations (rate_date, currency, base_currency, rate, previous_id, previous_rate, gain_account_id, loss_account_id, rounding_mode)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING *;
+
+-- name: InsertRevaluationEntries :execrows
+-- Marks the balance of every account at the close to the base currency, rounded
+-- with the base currency's rounding mode. An account's balance is rebuilt as of
+-- the close like ComputeBalance does; its gain or loss is what its balance at the
+-- previous revaluation is worth now less what it was worth then. Accounts with
+-- neither balance are left out, and so are test-mode accounts.
+INSERT INTO revaluation_entries (revaluation_id, account_id, entry_type, balance, base_value, amount)
+SELECT sqlc.arg(revaluation_id), a.account_id, 'revaluation', a.balance,
+	round_money(a.balance * sqlc.arg(rate)::numeric, sqlc.arg(minor_units)::int, sqlc.arg(rounding_mode)::text),
+	COALESCE(round_money(p.balance * sqlc.arg(rate)::numeric, sqlc.arg(minor_units)::int, sqlc.arg(rounding_mode)::text) - p.base_value, 0)
+FROM (
+	SELECT acc.account_id, (acc.opening_balance
+		+ COALESCE((SELECT SUM(t.amount) FROM transactions t WHERE t.destination_account_id = acc.account_id AND t.created_at < sqlc.arg(cutoff)::timestamptz), 0)
+		- COALESCE((SELECT SUM(t.amount) FROM transactions t WHERE t.source_account_id = acc.account_id AND t.created_at < sqlc.arg(cutoff)::timestamptz), 0)
+		+ COALESCE((SELECT SUM(b.amount) FROM balance_adjustments b WHERE b.account_id = acc.account_id AND b.created_at < sqlc.arg(cutoff)::timestamptz), 0)) AS balance
+	FROM accounts acc
+	WHERE acc.created_at < sqlc.arg(cutoff)::timestamptz AND acc.livemode
+) a
+LEFT JOIN revaluation_entries p
+	ON p.revaluation_id = sqlc.narg(previous_id) AND p.account_id = a.account_id AND p.entry_type = 'revaluation'
+WHERE a.balance <> 0 OR p.balance <> 0;
+
+-- name: InsertRevaluationOffsets :exec
+-- Offsets the revaluation entries on the designated accounts: falls in base
+-- value on the gain account, rises on the loss account.
+INSERT INTO revaluation_entries (revaluation_id, account_id, entry_type, amount)
+SELECT r.id, r.gain_account_id, 'gain', COALESCE(-SUM(e.amount) FILTER (WHERE e.amount < 0), 0)
+FROM revaluations r LEFT JOIN revaluation_entries e ON e.revaluation_id = r.id AND e.entry_type = 'revaluation'
+WHERE r.id = $1
+GROUP BY r.id
+UNION ALL
+SELECT r.id, r.loss_account_id, 'loss', COALESCE(-SUM(e.amount) FILTER (WHERE e.amount > 0), 0)
+FROM revaluations r LEFT JOIN revaluation_entries e ON e.revaluation_id = r.id AND e.entry_type = 'revaluation'
+WHERE r.id = $1
+GROUP BY r.id;
+
+-- name: FinishRevaluation :one
+-- Totals a revaluation from its entries.
+UPDATE revaluations r
+SET balance = t.balance, base_value = t.base_value, gain = t.gain, loss = t.loss
+FROM (
+	SELECT COALESCE(SUM(balance) FILTER (WHERE entry_type = 'revaluation'), 0)::numeric AS balance,
+		COALESCE(SUM(base_value) FILTER (WHERE entry_type = 'revaluation'), 0)::numeric AS base_value,
+		COALESCE(SUM(amount) FILTER (WHERE entry_type = 'gain'), 0)::numeric AS gain,
+		COALESCE(-SUM(amount) FILTER (WHERE entry_type = 'loss'), 0)::numeric AS loss
+	FROM revaluation_entries
+	WHERE revaluation_id = $1
+) t
+WHERE r.id = $1
+RETURNING r.*;
+
+-- name: GetRevaluation :one
+SELECT * FROM revaluations WHERE id = $1;
+
+-- name: GetLatestRevaluation :one
+SELECT * FROM revaluations
+WHERE currency = $1 AND base_currency = $2
+ORDER BY rate_date DESC
+LIMIT 1;
+
+-- name: ListRevaluations :many
+SELECT * FROM revaluations
+WHERE currency = $1 AND base_currency = $2
+ORDER BY rate_date DESC
+LIMIT $3;
+
+-- name: RevaluationPositions :many
+-- The revaluation entries of a revaluation totalled by account type.
+SELECT account_type(account_id, sqlc.arg(suspense_id), sqlc.arg(reimbursement_id))::text AS account_type,
+	COUNT(*) AS accounts,
+	SUM(balance)::numeric AS balance,
+	SUM(base_value)::numeric AS base_value,
+	SUM(amount)::numeric AS gain_loss
+FROM revaluation_entries
+WHERE revaluation_id = sqlc.arg(revaluation_id) AND entry_type = 'revaluation'
+GROUP BY 1
+ORDER BY 1;
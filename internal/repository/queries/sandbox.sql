@@ -0,0 +1,51 @@
+-- name: PurgeTestData :one
+-- Deletes the test-mode accounts and transactions with everything that
+-- refers to them. It is a single statement, so foreign keys are checked once
+-- all of it is gone.
+WITH test_accounts AS (
+	SELECT account_id FROM accounts WHERE NOT livemode
+), test_transactions AS (
+	SELECT id FROM transactions WHERE NOT livemode
+), deleted_entries AS (
+	DELETE FROM ledger_entries
+	WHERE account_id IN (SELECT account_id FROM test_accounts)
+		OR transaction_id IN (SELECT id FROM test_transactions)
+), deleted_adjustments AS (
+	DELETE FROM balance_adjustments WHERE account_id IN (SELECT account_id FROM test_accounts)
+), deleted_status_changes AS (
+	DELETE FROM account_status_changes WHERE account_id IN (SELECT account_id FROM test_accounts)
+), deleted_tokens AS (
+	DELETE FROM spending_tokens WHERE account_id IN (SELECT account_id FROM test_accounts)
+), deleted_top_ups AS (
+	DELETE FROM top_up_rules
+	WHERE account_id IN (SELECT account_id FROM test_accounts)
+		OR funding_account_id IN (SELECT account_id FROM test_accounts)
+), deleted_campaigns AS (
+	DELETE FROM cashback_campaigns WHERE funding_account_id IN (SELECT account_id FROM test_accounts)
+), deleted_intent_credits AS (
+	DELETE FROM transfer_intent_credits
+	WHERE intent_id IN (SELECT id FROM transfer_intents WHERE account_id IN (SELECT account_id FROM test_accounts))
+		OR campaign_id IN (SELECT id FROM cashback_campaigns WHERE funding_account_id IN (SELECT account_id FROM test_accounts))
+), deleted_intents AS (
+	DELETE FROM transfer_intents WHERE account_id IN (SELECT account_id FROM test_accounts)
+), deleted_webhooks AS (
+	DELETE FROM webhooks WHERE account_id IN (SELECT account_id FROM test_accounts)
+), deleted_notification_preferences AS (
+	DELETE FROM notification_preferences WHERE account_id IN (SELECT account_id FROM test_accounts)
+), deleted_notification_digests AS (
+	DELETE FROM notification_digests WHERE account_id IN (SELECT account_id FROM test_accounts)
+), deleted_reimbursements AS (
+	DELETE FROM reimbursements WHERE account_id IN (SELECT account_id FROM test_accounts)
+	RETURNING id
+), deleted_pending_actions AS (
+	DELETE FROM pending_actions
+	WHERE kind = 'reimbursement' AND reference IN (SELECT id::text FROM deleted_reimbursements)
+), deleted_transactions AS (
+	DELETE FROM transactions WHERE id IN (SELECT id FROM test_transactions)
+	RETURNING id
+), deleted_accounts AS (
+	DELETE FROM accounts WHERE account_id IN (SELECT account_id FROM test_accounts)
+	RETURNING account_id
+)
+SELECT (SELECT COUNT(*) FROM deleted_accounts) AS accounts,
+	(SELECT COUNT(*) FROM deleted_transactions) AS transactions;
@@ -0,0 +1,15 @@
+-- name: InsertSecurityEvent :one
+INSERT INTO security_events (type, actor, tenant, address, request_id, detail)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, created_at;
+
+-- name: ListSecurityEvents :many
+-- Events after the offset in id order. Events newer than the settle window are
+-- held back, as in the outbox, so a page never skips an event that commits
+-- later with a lower ID.
+SELECT id, type, actor, tenant, address, request_id, detail, created_at
+FROM security_events
+WHERE id > sqlc.arg(after_id)::bigint
+	AND created_at <= CURRENT_TIMESTAMP - interval '5 seconds'
+ORDER BY id
+LIMIT sqlc.arg(row_limit);
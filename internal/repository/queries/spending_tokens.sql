@@ -0,0 +1,35 @@
+-- name: InsertSpendingToken :one
+INSERT INTO spending_tokens (id, account_id, spend_limit, merchant_categories, expires_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, account_id, spend_limit, spent, merchant_categories, expires_at, revoked_at, created_at;
+
+-- name: GetSpendingToken :one
+SELECT id, account_id, spend_limit, spent, merchant_categories, expires_at, revoked_at, created_at
+FROM spending_tokens
+WHERE id = $1;
+
+-- name: GetSpendingTokenForUpdate :one
+-- Locks the token so concurrent debits with it cannot both pass its limit.
+SELECT id, account_id, spend_limit, spent, merchant_categories, expires_at, revoked_at, created_at
+FROM spending_tokens
+WHERE id = $1
+FOR UPDATE;
+
+-- name: ListSpendingTokens :many
+SELECT id, account_id, spend_limit, spent, merchant_categories, expires_at, revoked_at, created_at
+FROM spending_tokens
+WHERE account_id = $1
+ORDER BY created_at, id;
+
+-- name: RevokeSpendingToken :one
+-- Revoking a revoked token keeps the time it was first revoked.
+UPDATE spending_tokens
+SET revoked_at = COALESCE(revoked_at, CURRENT_TIMESTAMP)
+WHERE id = $1
+RETURNING id, account_id, spend_limit, spent, merchant_categories, expires_at, revoked_at, created_at;
+
+-- name: AddSpendingTokenSpent :one
+UPDATE spending_tokens
+SET spent = spent + $2
+WHERE id = $1
+RETURNING id, account_id, spend_limit, spent, merchant_categories, expires_at, revoked_at, created_at;
@@ -0,0 +1,25 @@
+-- name: InsertSuspenseItem :one
+INSERT INTO suspense_items (source_account_id, intended_account_id, amount, reason, reference, transaction_id)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, source_account_id, intended_account_id, amount, reason, reference, transaction_id, created_at, reposted_to, repost_transaction_id, resolved_at;
+
+-- name: GetSuspenseItem :one
+SELECT id, source_account_id, intended_account_id, amount, reason, reference, transaction_id, created_at, reposted_to, repost_transaction_id, resolved_at
+FROM suspense_items
+WHERE id = $1;
+
+-- name: ListSuspenseItems :many
+-- Keyset page over (created_at, id) of the unresolved items, oldest first.
+SELECT id, source_account_id, intended_account_id, amount, reason, reference, transaction_id, created_at, reposted_to, repost_transaction_id, resolved_at
+FROM suspense_items
+WHERE resolved_at IS NULL
+	AND (created_at, id) > (sqlc.arg(after_created_at), sqlc.arg(after_id)::bigint)
+ORDER BY created_at, id
+LIMIT sqlc.arg(row_limit);
+
+-- name: ResolveSuspenseItem :one
+-- Marks an unresolved item as reposted. No row means it was already resolved.
+UPDATE suspense_items
+SET reposted_to = $2, repost_transaction_id = $3, resolved_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND resolved_at IS NULL
+RETURNING id, source_account_id, intended_account_id, amount, reason, reference, transaction_id, created_at, reposted_to, repost_transaction_id, resolved_at;
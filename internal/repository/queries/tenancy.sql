@@ -0,0 +1,13 @@
+-- name: SetTenant :exec
+-- Scopes the rest of the transaction to a tenant for the row-level security
+-- policies; SET LOCAL app.tenant_id, taking a parameter.
+SELECT set_config('app.tenant_id', sqlc.arg(tenant)::text, true);
+
+-- name: BypassesRowSecurity :one
+-- Whether the current role is exempt from row-level security policies.
+SELECT rolsuper OR rolbypassrls FROM pg_roles WHERE rolname = current_user;
+
+-- name: IsRowSecurityBypassMember :one
+-- Whether the current role has the privileges of intrapay_rls_bypass, and so
+-- sees every row of the tenant-owned tables while no tenant is set.
+SELECT pg_has_role(current_user, 'intrapay_rls_bypass', 'USAGE');
@@ -0,0 +1,51 @@
+-- name: ListAccountTimeline :many
+-- Keyset page over (occurred_at, seq) of what happened to an account: its
+-- opening, the transfers it sent and received, its adjustments, closures and
+-- restores, and the spending tokens issued and revoked on it. seq orders the
+-- events sharing a timestamp and is unique across them: the opening is 0, and
+-- other events are the ID of their row times 8 plus the rank of their source.
+-- Tokens have text IDs, so they are numbered in the order they were issued.
+SELECT occurred_at, seq, event_type, reference, counterparty_account_id, amount, detail, note
+FROM (
+	SELECT a.created_at AS occurred_at, 0::bigint AS seq, 'account.opened'::text AS event_type,
+		''::text AS reference, NULL::bigint AS counterparty_account_id, a.opening_balance::numeric AS amount,
+		''::text AS detail, ''::text AS note
+	FROM accounts a
+	WHERE a.account_id = sqlc.arg(account_id)
+	UNION ALL
+	SELECT COALESCE(t.created_at, t.updated_at), t.id::bigint * 8 + 1, 'transaction.debit',
+		t.id::text, t.destination_account_id, -t.amount, t.kind, ''
+	FROM transactions t
+	WHERE t.source_account_id = sqlc.arg(account_id)
+	UNION ALL
+	SELECT COALESCE(t.created_at, t.updated_at), t.id::bigint * 8 + 2, 'transaction.credit',
+		t.id::text, t.source_account_id, t.amount, t.kind, ''
+	FROM transactions t
+	WHERE t.destination_account_id = sqlc.arg(account_id)
+	UNION ALL
+	SELECT COALESCE(b.created_at, 'epoch'::timestamptz), b.id * 8 + 3, 'adjustment',
+		b.id::text, NULL, b.amount, b.reason_code, b.reason
+	FROM balance_adjustments b
+	WHERE b.account_id = sqlc.arg(account_id)
+	UNION ALL
+	SELECT c.created_at, c.id * 8 + 4, CASE c.status WHEN 'deleted' THEN 'account.closed' ELSE 'account.restored' END,
+		'', NULL, NULL, '', ''
+	FROM account_status_changes c
+	WHERE c.account_id = sqlc.arg(account_id)
+	UNION ALL
+	SELECT s.created_at, s.ordinal * 8 + 5, 'token.issued', s.id, NULL, s.spend_limit, '', ''
+	FROM (
+		SELECT id, spend_limit, created_at, ROW_NUMBER() OVER (ORDER BY created_at, id) AS ordinal
+		FROM spending_tokens WHERE account_id = sqlc.arg(account_id)
+	) s
+	UNION ALL
+	SELECT s.revoked_at, s.ordinal * 8 + 6, 'token.revoked', s.id, NULL, NULL, '', ''
+	FROM (
+		SELECT id, revoked_at, ROW_NUMBER() OVER (ORDER BY created_at, id) AS ordinal
+		FROM spending_tokens WHERE account_id = sqlc.arg(account_id)
+	) s
+	WHERE s.revoked_at IS NOT NULL
+) events
+WHERE (occurred_at, seq) > (sqlc.arg(after_occurred_at)::timestamptz, sqlc.arg(after_seq)::bigint)
+ORDER BY occurred_at, seq
+LIMIT sqlc.arg(row_limit);
@@ -0,0 +1,18 @@
+-- name: SetTopUpRule :one
+INSERT INTO top_up_rules (account_id, threshold, amount, funding_account_id)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (account_id) DO UPDATE
+SET threshold = EXCLUDED.threshold,
+	amount = EXCLUDED.amount,
+	funding_account_id = EXCLUDED.funding_account_id,
+	updated_at = CURRENT_TIMESTAMP
+RETURNING account_id, threshold, amount, funding_account_id, updated_at;
+
+-- name: GetTopUpRule :one
+SELECT account_id, threshold, amount, funding_account_id, updated_at
+FROM top_up_rules
+WHERE account_id = $1;
+
+-- name: DeleteTopUpRule :execrows
+DELETE FROM top_up_rules
+WHERE account_id = $1;
@@ -0,0 +1,69 @@
+-- name: InsertTransactionAttempt :exec
+INSERT INTO transaction_attempts (source_account_id, destination_account_id, amount, reason_code, error, api_key, tenant, request_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8);
+
+-- name: GetTransactionAttempt :one
+SELECT id, source_account_id, destination_account_id, amount, reason_code, error, api_key, tenant, created_at, request_id
+FROM transaction_attempts
+WHERE id = $1;
+
+-- name: GetTransactionAttemptReplay :one
+SELECT transaction_id FROM transaction_attempt_replays WHERE attempt_id = $1;
+
+-- name: InsertTransactionAttemptReplay :execrows
+-- Records the replay of an attempt unless it was already replayed. A concurrent
+-- replay of the same attempt waits for the first to commit and then inserts nothing.
+INSERT INTO transaction_attempt_replays (attempt_id, transaction_id)
+VALUES ($1, $2)
+ON CONFLICT (attempt_id) DO NOTHING;
+
+-- name: ListTransactionAttempts :many
+-- Keyset page over (created_at, id) of the attempts matching the filters.
+SELECT id, source_account_id, destination_account_id, amount, reason_code, error, api_key, tenant, created_at, request_id
+FROM transaction_attempts
+WHERE (created_at, id) > (sqlc.arg(after_created_at), sqlc.arg(after_id)::bigint)
+	AND (sqlc.narg(reason_code)::text IS NULL OR reason_code = sqlc.narg(reason_code)::text)
+	AND (sqlc.narg(api_key)::text IS NULL OR api_key = sqlc.narg(api_key)::text)
+	AND (sqlc.narg(tenant)::text IS NULL OR tenant = sqlc.narg(tenant)::text)
+	AND (sqlc.narg(account_id)::bigint IS NULL OR sqlc.narg(account_id)::bigint IN (source_account_id, destination_account_id))
+	AND (sqlc.narg(since)::timestamptz IS NULL OR created_at >= sqlc.narg(since)::timestamptz)
+	AND (sqlc.narg(until)::timestamptz IS NULL OR created_at < sqlc.narg(until)::timestamptz)
+ORDER BY created_at, id
+LIMIT sqlc.arg(row_limit);
+
+-- name: ListTransactionAttemptsByRequest :many
+SELECT id, source_account_id, destination_account_id, amount, reason_code, error, api_key, tenant, created_at, request_id
+FROM transaction_attempts
+WHERE request_id = $1
+ORDER BY created_at, id;
+
+-- name: CountTransactionAttempts :one
+SELECT COUNT(*)
+FROM transaction_attempts
+WHERE (sqlc.narg(reason_code)::text IS NULL OR reason_code = sqlc.narg(reason_code)::text)
+	AND (sqlc.narg(api_key)::text IS NULL OR api_key = sqlc.narg(api_key)::text)
+	AND (sqlc.narg(tenant)::text IS NULL OR tenant = sqlc.narg(tenant)::text)
+	AND (sqlc.narg(account_id)::bigint IS NULL OR sqlc.narg(account_id)::bigint IN (source_account_id, destination_account_id))
+	AND (sqlc.narg(since)::timestamptz IS NULL OR created_at >= sqlc.narg(since)::timestamptz)
+	AND (sqlc.narg(until)::timestamptz IS NULL OR created_at < sqlc.narg(until)::timestamptz);
+
+-- name: TransactionAttemptStats :many
+-- Counts and sums the attempts matching the filters, grouped by reason code, API
+-- key or tenant, most frequent first.
+SELECT
+	(CASE sqlc.arg(group_by)::text
+		WHEN 'api_key' THEN api_key
+		WHEN 'tenant' THEN tenant
+		ELSE reason_code
+	END)::text AS key,
+	COUNT(*) AS attempts,
+	COALESCE(SUM(amount), 0)::numeric AS total_amount
+FROM transaction_attempts
+WHERE (sqlc.narg(reason_code)::text IS NULL OR reason_code = sqlc.narg(reason_code)::text)
+	AND (sqlc.narg(api_key)::text IS NULL OR api_key = sqlc.narg(api_key)::text)
+	AND (sqlc.narg(tenant)::text IS NULL OR tenant = sqlc.narg(tenant)::text)
+	AND (sqlc.narg(account_id)::bigint IS NULL OR sqlc.narg(account_id)::bigint IN (source_account_id, destination_account_id))
+	AND (sqlc.narg(since)::timestamptz IS NULL OR created_at >= sqlc.narg(since)::timestamptz)
+	AND (sqlc.narg(until)::timestamptz IS NULL OR created_at < sqlc.narg(until)::timestamptz)
+GROUP BY 1
+ORDER BY attempts DESC, key;
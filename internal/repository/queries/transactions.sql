@@ -0,0 +1,150 @@
+-- name: GetAccountBalanceForUpdate :one
+-- Use FOR UPDATE to lock the row, to prevent race conditions from simultaneous transactions
+SELECT balance FROM accounts WHERE account_id = $1 AND deleted_at IS NULL FOR UPDATE;
+
+-- name: UpdateBalance :exec
+UPDATE accounts SET balance = balance + sqlc.arg(delta) WHERE account_id = sqlc.arg(account_id);
+
+-- name: DebitBalance :one
+-- Debits only if the balance covers the amount, taking the row lock for the
+-- duration of a single statement. No row means missing account or insufficient funds.
+UPDATE accounts SET balance = balance - sqlc.arg(amount)
+WHERE account_id = sqlc.arg(account_id) AND deleted_at IS NULL AND balance >= sqlc.arg(amount)
+RETURNING balance;
+
+-- name: InsertTransaction :one
+INSERT INTO transactions (source_account_id, destination_account_id, amount, amount_minor, transaction_ref)
+VALUES ($1, $2, $3, $4, NULLIF(sqlc.arg(transaction_ref)::text, '')) RETURNING id;
+
+-- name: ComputeBalance :one
+-- Rebuilds a balance from the opening balance, the transaction log and adjustments.
+SELECT (a.opening_balance
+	+ COALESCE((SELECT SUM(amount) FROM transactions WHERE destination_account_id = a.account_id), 0)
+	- COALESCE((SELECT SUM(amount) FROM transactions WHERE source_account_id = a.account_id), 0)
+	+ COALESCE((SELECT SUM(amount) FROM balance_adjustments WHERE account_id = a.account_id), 0))::numeric AS balance
+FROM accounts a WHERE a.account_id = $1;
+
+-- name: InsertAdjustment :one
+INSERT INTO balance_adjustments (account_id, amount, reason, reason_code)
+VALUES ($1, $2, $3, $4) RETURNING id;
+
+-- name: InsertTransactions :many
+-- Inserts many transaction rows in one round trip. Rows are returned in input order.
+-- An empty kind is a transfer; an empty rounding mode means the amount was not
+-- rounded, and its unrounded amount is ignored.
+INSERT INTO transactions (source_account_id, destination_account_id, amount, amount_minor, transaction_ref, kind, rounding_mode, unrounded_amount)
+SELECT src, dst, amt, amt_minor, NULLIF(ref, ''), COALESCE(NULLIF(k, ''), 'transfer'), NULLIF(rm, ''), CASE WHEN rm <> '' THEN unrounded END
+FROM unnest(sqlc.arg(source_account_ids)::bigint[], sqlc.arg(destination_account_ids)::bigint[], sqlc.arg(amounts)::numeric[], sqlc.arg(amounts_minor)::bigint[], sqlc.arg(transaction_refs)::text[], sqlc.arg(kinds)::text[], sqlc.arg(rounding_modes)::text[], sqlc.arg(unrounded_amounts)::numeric[]) AS t(src, dst, amt, amt_minor, ref, k, rm, unrounded)
+RETURNING id;
+
+-- name: ListAccountTransactions :many
+-- Keyset page over (updated_at, id) of the transfers an account sent or
+-- received, each with the account on the other side. The counterparty may have
+-- no account row, e.g. a clearing account outside the system.
+SELECT t.id, t.source_account_id, t.destination_account_id, t.amount, t.created_at, t.updated_at, t.transaction_ref, t.kind, t.tags, t.livemode,
+	c.display_name AS counterparty_display_name, (c.deleted_at IS NOT NULL)::boolean AS counterparty_deleted
+FROM transactions t
+LEFT JOIN accounts c ON c.account_id = CASE WHEN t.source_account_id = sqlc.arg(account_id) THEN t.destination_account_id ELSE t.source_account_id END
+WHERE (t.source_account_id = sqlc.arg(account_id) OR t.destination_account_id = sqlc.arg(account_id))
+	AND (t.updated_at, t.id) > (sqlc.arg(after_updated_at), sqlc.arg(after_id)::integer)
+ORDER BY t.updated_at, t.id
+LIMIT sqlc.arg(row_limit);
+
+-- name: ListTransactions :many
+-- Keyset page over (updated_at, id) of the live or the test-mode transactions,
+-- starting after the cursor. An empty tag matches every transaction.
+SELECT id, source_account_id, destination_account_id, amount, created_at, updated_at, transaction_ref, amount_minor, kind, rounding_mode, unrounded_amount, tags, livemode
+FROM transactions
+WHERE updated_at > sqlc.arg(updated_since)
+	AND (updated_at, id) > (sqlc.arg(after_updated_at), sqlc.arg(after_id)::integer)
+	AND (sqlc.arg(tag)::text = '' OR tags @> ARRAY[sqlc.arg(tag)::text])
+	AND livemode = sqlc.arg(livemode)
+ORDER BY updated_at, id
+LIMIT sqlc.arg(row_limit);
+
+-- name: CountTransactions :one
+SELECT COUNT(*) FROM transactions
+WHERE updated_at > sqlc.arg(updated_since)
+	AND (sqlc.arg(tag)::text = '' OR tags @> ARRAY[sqlc.arg(tag)::text])
+	AND livemode = sqlc.arg(livemode);
+
+-- name: ListTransactionChanges :many
+-- Keyset scan over (updated_at, id). Rows newer than the settle window are held
+-- back: updated_at is the writing transaction's start time, so a transfer that is
+-- still in flight could otherwise commit behind a cursor that has moved past it.
+SELECT id, source_account_id, destination_account_id, amount, created_at, updated_at, transaction_ref, amount_minor, kind, rounding_mode, unrounded_amount, tags, livemode
+FROM transactions
+WHERE (updated_at, id) > (sqlc.arg(after_updated_at), sqlc.arg(after_id)::integer)
+	AND updated_at <= CURRENT_TIMESTAMP - interval '5 seconds'
+	AND livemode = sqlc.arg(livemode)
+ORDER BY updated_at, id
+LIMIT sqlc.arg(row_limit);
+
+-- name: GetTransaction :one
+-- Looks a transaction up by its public transaction_ref or its serial key,
+-- preferring the ref when an ID matches both.
+SELECT id, source_account_id, destination_account_id, amount, created_at, updated_at, transaction_ref, amount_minor, kind, rounding_mode, unrounded_amount, tags, livemode
+FROM transactions
+WHERE transaction_ref = sqlc.arg(ref)::text OR id = sqlc.narg(serial_id)::integer
+ORDER BY transaction_ref IS NOT DISTINCT FROM sqlc.arg(ref)::text DESC
+LIMIT 1;
+
+-- name: SetTransactionTags :one
+-- Replaces the tags of the transaction GetTransaction would find.
+UPDATE transactions SET tags = sqlc.arg(tags)::text[]
+WHERE id = (
+	SELECT id FROM transactions
+	WHERE transaction_ref = sqlc.arg(ref)::text OR id = sqlc.narg(serial_id)::integer
+	ORDER BY transaction_ref IS NOT DISTINCT FROM sqlc.arg(ref)::text DESC
+	LIMIT 1
+)
+RETURNING id, source_account_id, destination_account_id, amount, created_at, updated_at, transaction_ref, amount_minor, kind, rounding_mode, unrounded_amount, tags, livemode;
+
+-- name: ListAccountTags :many
+-- Counts the transfers an account sent or received by tag.
+SELECT tag::text AS tag, COUNT(*) AS transactions
+FROM transactions t, unnest(t.tags) AS tag
+WHERE t.source_account_id = sqlc.arg(account_id) OR t.destination_account_id = sqlc.arg(account_id)
+GROUP BY tag
+ORDER BY tag;
+
+-- name: GetTransactionIDByRef :one
+SELECT id FROM transactions WHERE transaction_ref = $1;
+
+-- name: SumAccountFlows :one
+-- Totals the transfers into and out of an account created since a point in time.
+SELECT COALESCE(SUM(amount) FILTER (WHERE destination_account_id = sqlc.arg(account_id)), 0)::numeric AS incoming,
+	COUNT(*) FILTER (WHERE destination_account_id = sqlc.arg(account_id)) AS incoming_count,
+	COALESCE(SUM(amount) FILTER (WHERE source_account_id = sqlc.arg(account_id)), 0)::numeric AS outgoing,
+	COUNT(*) FILTER (WHERE source_account_id = sqlc.arg(account_id)) AS outgoing_count
+FROM transactions
+WHERE (source_account_id = sqlc.arg(account_id) OR destination_account_id = sqlc.arg(account_id))
+	AND created_at >= sqlc.arg(since)::timestamptz;
+
+-- name: GetTransactions :many
+-- Looks transactions up in bulk by public transaction_ref or serial key. The
+-- caller matches the rows back to the IDs it asked for.
+SELECT id, source_account_id, destination_account_id, amount, created_at, updated_at, transaction_ref, amount_minor, kind, rounding_mode, unrounded_amount, tags, livemode
+FROM transactions
+WHERE transaction_ref = ANY(sqlc.arg(refs)::text[]) OR id = ANY(sqlc.arg(serial_ids)::integer[]);
+
+-- name: BackfillTransactionAmountMinor :one
+-- Fills in amount_minor for the batch of transactions after after_id, in id
+-- order, and returns the last id of the batch, 0 once there are no more, and
+-- how many rows were filled in.
+WITH batch AS (
+	SELECT id FROM transactions
+	WHERE id > sqlc.arg(after_id)::integer
+	ORDER BY id
+	LIMIT sqlc.arg(batch_size)
+), updated AS (
+	UPDATE transactions t SET amount_minor = ROUND(t.amount * sqlc.arg(scale)::bigint)::bigint
+	FROM batch
+	WHERE t.id = batch.id AND t.amount_minor IS NULL
+	RETURNING t.id
+)
+SELECT COALESCE((SELECT MAX(id) FROM batch), 0)::bigint AS last_id,
+	(SELECT COUNT(*) FROM updated) AS updated;
+
+-- name: CountTransactionsMissingAmountMinor :one
+SELECT COUNT(*) FROM transactions WHERE amount_minor IS NULL;
@@ -0,0 +1,27 @@
+-- name: InsertTransferFreeze :one
+INSERT INTO transfer_freezes (tenant, reason, frozen_by, expires_at)
+VALUES ($1, $2, $3, $4)
+RETURNING id, tenant, reason, frozen_by, created_at, expires_at, lifted_at, lifted_by;
+
+-- name: ListTransferFreezesInForce :many
+SELECT id, tenant, reason, frozen_by, created_at, expires_at, lifted_at, lifted_by
+FROM transfer_freezes
+WHERE lifted_at IS NULL AND expires_at > sqlc.arg(now)
+ORDER BY created_at, id;
+
+-- name: ListTransferFreezes :many
+-- The most recent freezes, in force or not, newest first.
+SELECT id, tenant, reason, frozen_by, created_at, expires_at, lifted_at, lifted_by
+FROM transfer_freezes
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: LiftTransferFreezes :many
+-- Lifts the freezes of a tenant that are still in force.
+UPDATE transfer_freezes
+SET lifted_at = CURRENT_TIMESTAMP, lifted_by = sqlc.arg(lifted_by)
+WHERE tenant = sqlc.arg(tenant) AND lifted_at IS NULL AND expires_at > sqlc.arg(now)
+RETURNING id, tenant, reason, frozen_by, created_at, expires_at, lifted_at, lifted_by;
+
+-- name: GetAccountTenant :one
+SELECT tenant FROM accounts WHERE account_id = $1;
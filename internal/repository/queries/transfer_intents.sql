@@ -0,0 +1,26 @@
+-- name: InsertTransferIntent :one
+INSERT INTO transfer_intents (kind, account_id, transaction_id, amount, created_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, kind, account_id, transaction_id, amount, created_at, completed_at;
+
+-- name: CompleteTransferIntent :execrows
+-- Completes an intent unless it was already completed. A concurrent completion
+-- of the same intent waits for the first to commit and then updates nothing.
+UPDATE transfer_intents
+SET completed_at = $2
+WHERE id = $1 AND completed_at IS NULL;
+
+-- name: InsertTransferIntentCredit :execrows
+-- Records a cashback credit of an intent unless the campaign already paid it.
+INSERT INTO transfer_intent_credits (intent_id, campaign_id, transaction_id)
+VALUES ($1, $2, $3)
+ON CONFLICT (intent_id, campaign_id) DO NOTHING;
+
+-- name: ListPendingTransferIntents :many
+-- Keyset page over id of the intents recorded before a point in time and not
+-- completed yet.
+SELECT id, kind, account_id, transaction_id, amount, created_at, completed_at
+FROM transfer_intents
+WHERE completed_at IS NULL AND created_at < sqlc.arg(created_before) AND id > sqlc.arg(after_id)::bigint
+ORDER BY id
+LIMIT sqlc.arg(row_limit);
@@ -0,0 +1,52 @@
+-- name: TreasuryBalancesByType :many
+-- Balances held across all live accounts, closed ones included, by account type.
+SELECT account_type(account_id, sqlc.arg(suspense_id), sqlc.arg(reimbursement_id))::text AS account_type,
+	COUNT(*) AS accounts,
+	COALESCE(SUM(balance), 0)::numeric AS balance
+FROM accounts
+WHERE livemode
+GROUP BY 1
+ORDER BY 1;
+
+-- name: TreasuryLargestAccounts :many
+SELECT account_id, display_name, balance,
+	account_type(account_id, sqlc.arg(suspense_id), sqlc.arg(reimbursement_id))::text AS account_type
+FROM accounts
+WHERE deleted_at IS NULL AND livemode
+ORDER BY balance DESC, account_id
+LIMIT sqlc.arg(row_limit);
+
+-- name: TreasuryDailyFlows :many
+-- Money into and out of each account type per calendar day in the time zone,
+-- for the transactions created since a point in time. Legs are summed per
+-- account first, so each account is classified once a day.
+SELECT day, account_type(account_id, sqlc.arg(suspense_id), sqlc.arg(reimbursement_id))::text AS account_type,
+	SUM(inflow)::numeric AS inflow,
+	SUM(outflow)::numeric AS outflow
+FROM (
+	SELECT (created_at AT TIME ZONE sqlc.arg(timezone)::text)::date AS day, account_id,
+		SUM(inflow) AS inflow, SUM(outflow) AS outflow
+	FROM (
+		SELECT created_at, destination_account_id AS account_id, amount AS inflow, 0::numeric AS outflow
+		FROM transactions WHERE created_at >= sqlc.arg(since)::timestamptz AND livemode
+		UNION ALL
+		SELECT created_at, source_account_id, 0::numeric, amount
+		FROM transactions WHERE created_at >= sqlc.arg(since)::timestamptz AND livemode
+	) legs
+	GROUP BY 1, 2
+) per_account
+GROUP BY 1, 2
+ORDER BY 1, 2;
+
+-- name: TreasurySuspenseFloat :one
+-- Unresolved inbound payments held on the suspense account.
+SELECT COUNT(*) AS items, COALESCE(SUM(amount), 0)::numeric AS amount
+FROM suspense_items
+WHERE resolved_at IS NULL;
+
+-- name: TreasuryReimbursementFloat :one
+-- Reimbursements awaiting a decision, which the reimbursement account pays if
+-- approved.
+SELECT COUNT(*) AS items, COALESCE(SUM(amount), 0)::numeric AS amount
+FROM reimbursements
+WHERE status = 'pending';
@@ -0,0 +1,27 @@
+-- name: AddUsage :exec
+-- Adds to the counters of an API key and tenant for the period, creating the row
+-- on first use.
+INSERT INTO api_usage (period, api_key, tenant, calls, volume)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (period, api_key, tenant) DO UPDATE
+SET calls = api_usage.calls + EXCLUDED.calls,
+	volume = api_usage.volume + EXCLUDED.volume,
+	updated_at = CURRENT_TIMESTAMP;
+
+-- name: GetKeyUsage :one
+-- Totals for an API key across all of its tenants.
+SELECT COALESCE(SUM(calls), 0)::bigint AS calls, COALESCE(SUM(volume), 0)::numeric AS volume
+FROM api_usage
+WHERE period = $1 AND api_key = $2;
+
+-- name: GetTenantUsage :one
+-- Totals for a tenant across all of its API keys.
+SELECT COALESCE(SUM(calls), 0)::bigint AS calls, COALESCE(SUM(volume), 0)::numeric AS volume
+FROM api_usage
+WHERE period = $1 AND tenant = $2;
+
+-- name: ListUsage :many
+SELECT period, api_key, tenant, calls, volume, updated_at
+FROM api_usage
+WHERE period = $1
+ORDER BY tenant, api_key;
@@ -0,0 +1,24 @@
+-- name: InsertWebhook :one
+INSERT INTO webhooks (tenant, url, event_types, verified_at, account_id)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, tenant, url, event_types, verified_at, created_at, account_id;
+
+-- name: GetWebhook :one
+SELECT id, tenant, url, event_types, verified_at, created_at, account_id
+FROM webhooks
+WHERE id = $1 AND tenant = $2;
+
+-- name: ListWebhooks :many
+SELECT id, tenant, url, event_types, verified_at, created_at, account_id
+FROM webhooks
+WHERE tenant = $1
+ORDER BY id;
+
+-- name: DeleteWebhook :execrows
+DELETE FROM webhooks
+WHERE id = $1 AND tenant = $2;
+
+-- name: GetAccountOwner :one
+SELECT tenant, owner_key
+FROM accounts
+WHERE account_id = $1 AND deleted_at IS NULL;
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/db"
+	"github.com/nehciyy/intrapay/internal/idgen"
+	"github.com/nehciyy/intrapay/internal/repository/sqlc"
+)
+
+// QueryLogger logs statements that run longer than Threshold and, for row-locking
+// reads, reports which sessions are holding the locks being waited on.
+// A nil *QueryLogger is valid and logs nothing.
+type QueryLogger struct {
+	Threshold time.Duration
+	Logger    *log.Logger
+
+	// db is used for lock diagnostics; it must not be the transaction doing the waiting.
+	db *sql.DB
+}
+
+// NewQueryLogger creates a QueryLogger. A zero threshold disables logging.
+func NewQueryLogger(db *sql.DB, threshold time.Duration) *QueryLogger {
+	if threshold <= 0 {
+		return nil
+	}
+	return &QueryLogger{Threshold: threshold, Logger: log.Default(), db: db}
+}
+
+// Option configures a Postgres repository.
+type Option func(*repoOptions)
+
+type repoOptions struct {
+	queryLog *QueryLogger
+	ids      idgen.Generator
+	replica  *sql.DB
+	reader   *sql.DB
+	hints    db.Policy
+
+	rowSecurity   bool
+	ledgerEntries bool
+}
+
+// WithQueryLogger enables slow query and lock-wait logging on a repository.
+func WithQueryLogger(l *QueryLogger) Option {
+	return func(o *repoOptions) { o.queryLog = l }
+}
+
+// WithIDGenerator assigns transaction IDs from g instead of the serial key. The
+// generated ID is stored in transactions.transaction_ref.
+func WithIDGenerator(g idgen.Generator) Option {
+	return func(o *repoOptions) { o.ids = g }
+}
+
+// WithReplica routes the reads of read-only operations to replica. See
+// db.ReadOnly.
+func WithReplica(replica *sql.DB) Option {
+	return func(o *repoOptions) { o.replica = replica }
+}
+
+// WithReader routes the reads of read-only operations that no replica serves
+// to reader, a connection to the primary as a role that may only read. Writes,
+// and reads that may precede a write, keep the role of the primary. See
+// db.InitReader.
+func WithReader(reader *sql.DB) Option {
+	return func(o *repoOptions) { o.reader = reader }
+}
+
+// WithRowLevelSecurity scopes the operations of a repository on the rows of a
+// tenant to that tenant in the database, for the row-level security policies
+// to enforce. Repositories without tenant rows ignore it.
+func WithRowLevelSecurity() Option {
+	return func(o *repoOptions) { o.rowSecurity = true }
+}
+
+// WithLedgerEntries has a repository that logs transactions in bulk write
+// their ledger entries as well, as ledger shadow mode does for transfers.
+// Other repositories ignore it.
+func WithLedgerEntries() Option {
+	return func(o *repoOptions) { o.ledgerEntries = true }
+}
+
+// WithHintPolicy sets the statement timeouts and retries applied to reads by
+// the hints of their context.
+func WithHintPolicy(p db.Policy) Option {
+	return func(o *repoOptions) { o.hints = p }
+}
+
+func applyOptions(opts []Option) repoOptions {
+	var o repoOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// observe logs the named statement if it took longer than the threshold.
+func (l *QueryLogger) observe(name string, start time.Time) {
+	if l == nil {
+		return
+	}
+	if elapsed := time.Since(start); elapsed >= l.Threshold {
+		l.Logger.Printf("slow query: %s took %s (threshold %s)", name, elapsed, l.Threshold)
+	}
+}
+
+// watchLockWait starts a timer that, if the returned stop function has not been
+// called within the threshold, logs the sessions currently waiting on row locks
+// together with the sessions blocking them.
+func (l *QueryLogger) watchLockWait(accountID int64) (stop func()) {
+	if l == nil || l.db == nil {
+		return func() {}
+	}
+	timer := time.AfterFunc(l.Threshold, func() {
+		l.logLockWaits(accountID)
+	})
+	return func() { timer.Stop() }
+}
+
+func (l *QueryLogger) logLockWaits(accountID int64) {
+	waits, err := sqlc.New(l.db).ListLockWaits(context.Background())
+	if err != nil {
+		l.Logger.Printf("lock wait on account %d exceeded %s; lock diagnostics failed: %v", accountID, l.Threshold, err)
+		return
+	}
+
+	l.Logger.Printf("lock wait on account %d exceeded %s", accountID, l.Threshold)
+	for _, w := range waits {
+		l.Logger.Printf("  pid %d waiting on %s for %.3fs, blocked by %v", w.Pid.Int32, w.WaitEvent, w.WaitedSeconds, w.BlockingPids)
+	}
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"bytes"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewQueryLogger_Disabled(t *testing.T) {
+	db, _ := setupMockDB(t)
+	assert.Nil(t, NewQueryLogger(db, 0))
+
+	// A nil logger must be safe to use.
+	var l *QueryLogger
+	l.observe("noop", time.Now())
+	l.watchLockWait(1)()
+}
+
+func TestQueryLogger_Observe(t *testing.T) {
+	var buf bytes.Buffer
+	l := &QueryLogger{Threshold: 10 * time.Millisecond, Logger: log.New(&buf, "", 0)}
+
+	l.observe("fast", time.Now())
+	assert.Empty(t, buf.String())
+
+	l.observe("slow", time.Now().Add(-50*time.Millisecond))
+	assert.Contains(t, buf.String(), "slow query: slow took")
+}
+
+func TestQueryLogger_LockWait(t *testing.T) {
+	db, mock := setupMockDB(t)
+	var buf bytes.Buffer
+	mock.MatchExpectationsInOrder(false)
+	l := &QueryLogger{Threshold: 5 * time.Millisecond, Logger: log.New(&buf, "", 0), db: db}
+
+	rows := sqlmock.NewRows([]string{"pid", "blockers", "wait_event", "waited"}).
+		AddRow(int64(42), pq.Int64Array{7}, "transactionid", 1.5)
+	mock.ExpectQuery("FROM pg_stat_activity").WillReturnRows(rows)
+
+	repo := NewPostgresTransactionRepository(db, WithQueryLogger(l))
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT balance FROM accounts WHERE account_id = \\$1 AND deleted_at IS NULL FOR UPDATE").
+		WithArgs(int64(1001)).
+		WillDelayFor(50 * time.Millisecond).
+		WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(10.0))
+	mock.ExpectRollback()
+
+	tx, err := db.Begin()
+	assert.NoError(t, err)
+	_, err = repo.GetAccountBalanceTx(tx, 1001)
+	assert.NoError(t, err)
+	assert.NoError(t, tx.Rollback())
+
+	assert.Contains(t, buf.String(), "lock wait on account 1001")
+	assert.Contains(t, buf.String(), "pid 42 waiting on transactionid")
+	assert.Contains(t, buf.String(), "slow query: GetAccountBalanceTx")
+}
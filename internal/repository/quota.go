@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository/sqlc"
+)
+
+// PostgresQuotaRepository is an implementation of QuotaRepository for PostgreSQL.
+type PostgresQuotaRepository struct {
+	q        *sqlc.Queries
+	queryLog *QueryLogger
+}
+
+// NewPostgresQuotaRepository creates a new PostgresQuotaRepository.
+func NewPostgresQuotaRepository(db *sql.DB, opts ...Option) *PostgresQuotaRepository {
+	o := applyOptions(opts)
+	return &PostgresQuotaRepository{q: sqlc.New(db), queryLog: o.queryLog}
+}
+
+// SetQuota creates or replaces the quota of q.Scope and q.Subject.
+func (r *PostgresQuotaRepository) SetQuota(q models.Quota) (*models.Quota, error) {
+	defer r.queryLog.observe("SetQuota", time.Now())
+	row, err := r.q.SetQuota(context.Background(), sqlc.SetQuotaParams{
+		Scope:         string(q.Scope),
+		Subject:       q.Subject,
+		MonthlyCalls:  q.MonthlyCalls,
+		MonthlyVolume: float64(q.MonthlyVolume),
+	})
+	if err != nil {
+		return nil, err
+	}
+	quota := toQuota(row)
+	return &quota, nil
+}
+
+func (r *PostgresQuotaRepository) GetQuota(scope models.QuotaScope, subject string) (*models.Quota, error) {
+	defer r.queryLog.observe("GetQuota", time.Now())
+	row, err := r.q.GetQuota(context.Background(), sqlc.GetQuotaParams{Scope: string(scope), Subject: subject})
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("quota of %s %s %w", scope, subject, ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	quota := toQuota(row)
+	return &quota, nil
+}
+
+func (r *PostgresQuotaRepository) ListQuotas() ([]models.Quota, error) {
+	defer r.queryLog.observe("ListQuotas", time.Now())
+	rows, err := r.q.ListQuotas(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	quotas := make([]models.Quota, len(rows))
+	for i, row := range rows {
+		quotas[i] = toQuota(row)
+	}
+	return quotas, nil
+}
+
+func (r *PostgresQuotaRepository) DeleteQuota(scope models.QuotaScope, subject string) error {
+	defer r.queryLog.observe("DeleteQuota", time.Now())
+	n, err := r.q.DeleteQuota(context.Background(), sqlc.DeleteQuotaParams{Scope: string(scope), Subject: subject})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("quota of %s %s %w", scope, subject, ErrNotFound)
+	}
+	return nil
+}
+
+// SetAccountLimit creates or replaces the account limit of tenant.
+func (r *PostgresQuotaRepository) SetAccountLimit(tenant string, maxAccounts int64) (*models.AccountLimit, error) {
+	defer r.queryLog.observe("SetAccountLimit", time.Now())
+	row, err := r.q.SetAccountLimit(context.Background(), sqlc.SetAccountLimitParams{Tenant: tenant, MaxAccounts: maxAccounts})
+	if err != nil {
+		return nil, err
+	}
+	return toAccountLimit(row), nil
+}
+
+func (r *PostgresQuotaRepository) GetAccountLimit(tenant string) (*models.AccountLimit, error) {
+	defer r.queryLog.observe("GetAccountLimit", time.Now())
+	row, err := r.q.GetAccountLimit(context.Background(), tenant)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("account limit of tenant %s %w", tenant, ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return toAccountLimit(row), nil
+}
+
+func (r *PostgresQuotaRepository) DeleteAccountLimit(tenant string) error {
+	defer r.queryLog.observe("DeleteAccountLimit", time.Now())
+	n, err := r.q.DeleteAccountLimit(context.Background(), tenant)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("account limit of tenant %s %w", tenant, ErrNotFound)
+	}
+	return nil
+}
+
+func toAccountLimit(row sqlc.AccountLimit) *models.AccountLimit {
+	return &models.AccountLimit{Tenant: row.Tenant, MaxAccounts: row.MaxAccounts, UpdatedAt: row.UpdatedAt}
+}
+
+func toQuota(row sqlc.ApiQuota) models.Quota {
+	return models.Quota{
+		Scope:         models.QuotaScope(row.Scope),
+		Subject:       row.Subject,
+		MonthlyCalls:  row.MonthlyCalls,
+		MonthlyVolume: models.Amount(row.MonthlyVolume),
+		UpdatedAt:     row.UpdatedAt,
+	}
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository/sqlc"
+)
+
+// PostgresRegionRepository is an implementation of RegionRepository for PostgreSQL.
+type PostgresRegionRepository struct {
+	q        *sqlc.Queries
+	queryLog *QueryLogger
+}
+
+// NewPostgresRegionRepository creates a new PostgresRegionRepository.
+func NewPostgresRegionRepository(db *sql.DB, opts ...Option) *PostgresRegionRepository {
+	o := applyOptions(opts)
+	return &PostgresRegionRepository{q: sqlc.New(db), queryLog: o.queryLog}
+}
+
+func (r *PostgresRegionRepository) GetRegionFence() (*models.RegionFence, error) {
+	defer r.queryLog.observe("GetRegionFence", time.Now())
+	row, err := r.q.GetRegionFence(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return &models.RegionFence{
+		ActiveRegion: row.ActiveRegion.String,
+		Epoch:        row.Epoch,
+		UpdatedAt:    row.UpdatedAt,
+	}, nil
+}
+
+// PromoteRegion makes region the active region if the fencing token is still
+// epoch, once the writes in flight have finished. It reports false, leaving the
+// fence alone, if another promotion moved the token first.
+func (r *PostgresRegionRepository) PromoteRegion(region string, epoch int64) (bool, error) {
+	defer r.queryLog.observe("PromoteRegion", time.Now())
+	n, err := r.q.PromoteRegion(context.Background(), sqlc.PromoteRegionParams{
+		ActiveRegion: sql.NullString{String: region, Valid: true},
+		Expected:     epoch,
+	})
+	return n > 0, err
+}
+
+// IsRegionFenced checks if the error is a write refused because the database
+// session belongs to a passive region, or runs on a read-only standby (SQLSTATE
+// 25006).
+func IsRegionFenced(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == "25006"
+	}
+	return err != nil && strings.Contains(err.Error(), "SQLSTATE 25006")
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository/sqlc"
+)
+
+// PostgresReimbursementRepository is an implementation of ReimbursementRepository for PostgreSQL.
+type PostgresReimbursementRepository struct {
+	q        *sqlc.Queries
+	tenants  tenantScope
+	queryLog *QueryLogger
+}
+
+// NewPostgresReimbursementRepository creates a new PostgresReimbursementRepository.
+func NewPostgresReimbursementRepository(db *sql.DB, opts ...Option) *PostgresReimbursementRepository {
+	o := applyOptions(opts)
+	return &PostgresReimbursementRepository{q: sqlc.New(db), tenants: newTenantScope(db, o), queryLog: o.queryLog}
+}
+
+// InsertReimbursementTx records a pending reimbursement.
+func (r *PostgresReimbursementRepository) InsertReimbursementTx(tx *sql.Tx, reimbursement models.Reimbursement) (*models.Reimbursement, error) {
+	defer r.queryLog.observe("InsertReimbursementTx", time.Now())
+	receipt, err := json.Marshal(reimbursement.Receipt)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.tenants.scopeTx(context.Background(), tx, reimbursement.Tenant); err != nil {
+		return nil, err
+	}
+	row, err := r.q.WithTx(tx).InsertReimbursement(context.Background(), sqlc.InsertReimbursementParams{
+		Tenant:      reimbursement.Tenant,
+		AccountID:   reimbursement.AccountID,
+		Amount:      float64(reimbursement.Amount),
+		Description: reimbursement.Description,
+		Receipt:     receipt,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toReimbursement(row)
+}
+
+// GetReimbursement returns a reimbursement of tenant, or of any tenant when
+// tenant is empty.
+func (r *PostgresReimbursementRepository) GetReimbursement(id int64, tenant string) (*models.Reimbursement, error) {
+	defer r.queryLog.observe("GetReimbursement", time.Now())
+	ctx := context.Background()
+	row, err := inTenant(ctx, r.tenants, tenant, func(q *sqlc.Queries) (sqlc.Reimbursement, error) {
+		return q.GetReimbursement(ctx, id)
+	})
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("reimbursement %d %w", id, ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return toReimbursement(row)
+}
+
+// DecideReimbursementTx approves or rejects a pending reimbursement; an approved
+// one is paid by the transfer transactionID, made in tx. It fails with
+// ErrAlreadyResolved if the reimbursement was decided concurrently.
+func (r *PostgresReimbursementRepository) DecideReimbursementTx(tx *sql.Tx, id int64, status models.ReimbursementStatus, d models.ReimbursementDecision, transactionID string) (*models.Reimbursement, error) {
+	defer r.queryLog.observe("DecideReimbursementTx", time.Now())
+	row, err := r.q.WithTx(tx).DecideReimbursement(context.Background(), sqlc.DecideReimbursementParams{
+		ID:            id,
+		Status:        string(status),
+		DecidedBy:     sql.NullString{String: d.DecidedBy, Valid: true},
+		DecisionNote:  d.Note,
+		TransactionID: sql.NullString{String: transactionID, Valid: transactionID != ""},
+	})
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("reimbursement %d %w", id, ErrAlreadyResolved)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return toReimbursement(row)
+}
+
+func toReimbursement(row sqlc.Reimbursement) (*models.Reimbursement, error) {
+	reimbursement := &models.Reimbursement{
+		ID:            row.ID,
+		Tenant:        row.Tenant,
+		AccountID:     row.AccountID,
+		Amount:        models.Amount(row.Amount),
+		Description:   row.Description,
+		Status:        models.ReimbursementStatus(row.Status),
+		DecidedBy:     row.DecidedBy.String,
+		DecisionNote:  row.DecisionNote,
+		TransactionID: row.TransactionID.String,
+		CreatedAt:     row.CreatedAt,
+	}
+	if err := json.Unmarshal(row.Receipt, &reimbursement.Receipt); err != nil {
+		return nil, fmt.Errorf("reimbursement %d: receipt: %w", row.ID, err)
+	}
+	if row.DecidedAt.Valid {
+		reimbursement.DecidedAt = &row.DecidedAt.Time
+	}
+	return reimbursement, nil
+}
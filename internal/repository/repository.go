@@ -1,12 +1,43 @@
 package repository
 
-import "database/sql"
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/money"
+)
 
 // AccountRepository defines the interface for account-related database operations.
 type AccountRepository interface {
-	CreateAccount(accountID int64, initialBalance float64) error
-	GetAccountBalance(accountID int64) (float64, error)
-	AccountExists(accountID int64) (bool, error) // Added for transaction logic
+	CreateAccount(accountID int64, initialBalance float64, tenant, owner string, livemode bool) (*models.Account, error)
+	AccountExists(ctx context.Context, accountID int64) (bool, error) // Added for transaction logic
+	GetAccount(ctx context.Context, accountID int64, includeDeleted bool) (*models.Account, error)
+	ListAccounts(ctx context.Context, filter models.AccountFilter, afterID int64, limit int) ([]models.Account, error)
+	DeleteAccount(accountID int64) error
+	RestoreAccount(accountID int64) error
+	SetDisplayName(accountID int64, name string) error
+	CountAccounts(tenant string) (int64, error)
+}
+
+// TransactionLog is a single transfer to be recorded in the transaction log.
+// An empty Kind records a transfer.
+type TransactionLog struct {
+	SourceID int64
+	DestID   int64
+	Amount   float64
+	Kind     models.TransactionKind
+	// Rounding records how Amount was rounded, if it was computed.
+	Rounding *money.Rounding
+}
+
+// ChangeCursor is a position in the (updated_at, id) ordering of the transaction log,
+// or in the (created_at, id) ordering of the pending actions feed, suspense items
+// and transaction attempts. The zero value is the start.
+type ChangeCursor struct {
+	UpdatedAt time.Time
+	ID        int64
 }
 
 // TransactionRepository defines the interface for transaction-related database operations.
@@ -14,5 +45,275 @@ type TransactionRepository interface {
 	GetAccountBalanceTx(tx *sql.Tx, accountID int64) (float64, error)
 	AccountExistsTx(tx *sql.Tx, accountID int64) (bool, error)
 	UpdateBalanceTx(tx *sql.Tx, accountID int64, delta float64) error
+	DebitBalanceTx(tx *sql.Tx, accountID int64, amount float64) (float64, error)
 	InsertTransactionLogTx(tx *sql.Tx, sourceID, destID int64, amount float64) (string, error)
-}
\ No newline at end of file
+	InsertTransactionLogsTx(tx *sql.Tx, logs []TransactionLog) ([]string, error)
+	ComputeBalanceTx(tx *sql.Tx, accountID int64) (float64, error)
+	GetTransaction(ctx context.Context, id string) (*models.Transaction, error)
+	GetTransactions(ctx context.Context, ids []string) (map[string]models.Transaction, error)
+	ListTransactions(ctx context.Context, filter models.TransactionFilter, after ChangeCursor, limit int) ([]models.Transaction, ChangeCursor, error)
+	ListAccountTransactions(ctx context.Context, accountID int64, after ChangeCursor, limit int) ([]models.Transaction, ChangeCursor, error)
+	SumAccountFlows(ctx context.Context, accountID int64, since time.Time) (*models.AccountFlows, error)
+	CountTransactions(ctx context.Context, filter models.TransactionFilter) (int64, error)
+	ListTransactionChanges(ctx context.Context, after ChangeCursor, livemode bool, limit int) ([]models.Transaction, ChangeCursor, error)
+	InsertAdjustmentTx(tx *sql.Tx, accountID int64, amount float64, code models.ReasonCode, reason string) (string, error)
+}
+
+// TransactionTagRepository stores the tags of transactions. Tags are replaced
+// in full, in the transaction of the transfer or afterwards; transactions are
+// found by transaction_ref or serial key as with GetTransaction.
+type TransactionTagRepository interface {
+	SetTransactionTagsTx(tx *sql.Tx, id string, tags []string) error
+	SetTransactionTags(id string, tags []string) (*models.Transaction, error)
+	ListAccountTags(ctx context.Context, accountID int64) ([]models.TransactionTag, error)
+}
+
+// IngestRepository posts transfers in bulk: they are staged with COPY and
+// merged into the balances and the transaction log in a few statements, all
+// in the transaction the caller validates them in.
+type IngestRepository interface {
+	StageTransfersTx(tx *sql.Tx, transfers []models.IngestTransfer) (*StagedTransfers, error)
+	MergeTransfersTx(tx *sql.Tx) ([]string, error)
+}
+
+// SandboxRepository deletes the data of test mode: the test-mode accounts, their
+// transactions and everything that refers to them.
+type SandboxRepository interface {
+	PurgeTestData() (*models.SandboxPurge, error)
+}
+
+// TimelineRepository reads the timeline of an account: the events of the
+// account gathered from the tables recording them, oldest first.
+type TimelineRepository interface {
+	ListAccountTimeline(ctx context.Context, accountID int64, after ChangeCursor, limit int) ([]models.AccountEvent, ChangeCursor, error)
+}
+
+// UsageRepository stores metered API usage per calendar month.
+type UsageRepository interface {
+	// AddUsage adds u.Calls and u.Volume to the counters of u.APIKey and u.Tenant.
+	AddUsage(u models.Usage) error
+	GetKeyUsage(period, apiKey string) (calls int64, volume float64, err error)
+	GetTenantUsage(period, tenant string) (calls int64, volume float64, err error)
+	ListUsage(period string) ([]models.Usage, error)
+}
+
+// QuotaRepository stores the monthly quotas of API keys and tenants and the
+// per-tenant overrides of the account limit.
+type QuotaRepository interface {
+	SetQuota(q models.Quota) (*models.Quota, error)
+	GetQuota(scope models.QuotaScope, subject string) (*models.Quota, error)
+	ListQuotas() ([]models.Quota, error)
+	DeleteQuota(scope models.QuotaScope, subject string) error
+	SetAccountLimit(tenant string, maxAccounts int64) (*models.AccountLimit, error)
+	GetAccountLimit(tenant string) (*models.AccountLimit, error)
+	DeleteAccountLimit(tenant string) error
+}
+
+// TopUpRepository stores the top-up rules of operational accounts.
+type TopUpRepository interface {
+	SetTopUpRule(rule models.TopUpRule) (*models.TopUpRule, error)
+	GetTopUpRule(accountID int64) (*models.TopUpRule, error)
+	DeleteTopUpRule(accountID int64) error
+}
+
+// PendingActionRepository stores the items awaiting a human decision. Workflows
+// open and resolve them; the admin feed lists, claims and assigns them; the
+// expiry sweeper expires those left open too long.
+type PendingActionRepository interface {
+	AddPendingAction(kind models.PendingActionKind, reference, summary string) (*models.PendingAction, error)
+	ResolvePendingAction(kind models.PendingActionKind, reference string) error
+	ExpirePendingActions(kind models.PendingActionKind, createdBefore time.Time) (int64, error)
+	ListPendingActions(filter models.PendingActionFilter, after ChangeCursor, limit int) ([]models.PendingAction, ChangeCursor, error)
+	CountPendingActions(filter models.PendingActionFilter) (int64, error)
+	ClaimPendingAction(id int64, assignee string) (*models.PendingAction, error)
+	AssignPendingAction(id int64, assignee string) (*models.PendingAction, error)
+}
+
+// SuspenseRepository stores inbound payments parked on the suspense account.
+// Items are written in the transaction that moves the funds.
+type SuspenseRepository interface {
+	InsertSuspenseItemTx(tx *sql.Tx, item models.SuspenseItem) (*models.SuspenseItem, error)
+	GetSuspenseItem(id int64) (*models.SuspenseItem, error)
+	ListSuspenseItems(after ChangeCursor, limit int) ([]models.SuspenseItem, ChangeCursor, error)
+	ResolveSuspenseItemTx(tx *sql.Tx, id, accountID int64, transactionID string) (*models.SuspenseItem, error)
+}
+
+// ReimbursementRepository stores expense reimbursements. Each is written, and
+// decided, in a transaction with the outbox event of its status. An empty
+// tenant gets a reimbursement of any tenant.
+type ReimbursementRepository interface {
+	InsertReimbursementTx(tx *sql.Tx, r models.Reimbursement) (*models.Reimbursement, error)
+	GetReimbursement(id int64, tenant string) (*models.Reimbursement, error)
+	DecideReimbursementTx(tx *sql.Tx, id int64, status models.ReimbursementStatus, d models.ReimbursementDecision, transactionID string) (*models.Reimbursement, error)
+}
+
+// CashbackRepository stores cashback campaigns. A cashback credit adds to what
+// its campaign has paid in the transaction of the credit.
+type CashbackRepository interface {
+	InsertCashbackCampaign(c models.CashbackCampaign) (*models.CashbackCampaign, error)
+	UpdateCashbackCampaign(c models.CashbackCampaign) (*models.CashbackCampaign, error)
+	GetCashbackCampaign(id int64) (*models.CashbackCampaign, error)
+	ListCashbackCampaigns() ([]models.CashbackCampaign, error)
+	DeleteCashbackCampaign(id int64) error
+	ListActiveCashbackCampaigns(accountID int64, at time.Time) ([]models.CashbackCampaign, error)
+	AddCashbackCampaignPaidTx(tx *sql.Tx, id int64, amount float64) error
+}
+
+// TransferIntentRepository stores the follow-up transfers committed transfers
+// set off, top-ups and cashback, until they are made. An intent is written in
+// the transaction of the transfer setting it off and completed in that of the
+// follow-up.
+type TransferIntentRepository interface {
+	InsertTransferIntentTx(tx *sql.Tx, intent models.TransferIntent) (*models.TransferIntent, error)
+	CompleteTransferIntentTx(tx *sql.Tx, id int64, at time.Time) error
+	CompleteTransferIntent(id int64, at time.Time) error
+	InsertTransferIntentCreditTx(tx *sql.Tx, id, campaignID int64, transactionID string) error
+	ListPendingTransferIntents(createdBefore time.Time, afterID int64, limit int) ([]models.TransferIntent, error)
+}
+
+// ForwardedTransferRepository stores the outcomes of the transfers replayed
+// from the store-and-forward queue, at most one per transfer and one per API
+// key's Idempotency-Key.
+type ForwardedTransferRepository interface {
+	InsertForwardedTransferTx(tx *sql.Tx, s models.ForwardedTransferStatus) error
+	InsertForwardedTransfer(s models.ForwardedTransferStatus) error
+	GetForwardedTransfer(id string) (*models.ForwardedTransferStatus, error)
+	FindForwardedTransfer(apiKey, idempotencyKey string) (*models.ForwardedTransferStatus, error)
+}
+
+// TreasuryRepository runs the aggregate queries of treasury reports. Accounts
+// are classified with the IDs of the suspense and reimbursement accounts, 0 for
+// those not configured. Reads follow the hints of their context.
+type TreasuryRepository interface {
+	BalancesByType(ctx context.Context, suspenseID, reimbursementID int64) ([]models.TreasuryPosition, error)
+	LargestAccounts(ctx context.Context, suspenseID, reimbursementID int64, limit int) ([]models.TreasuryAccount, error)
+	DailyFlows(ctx context.Context, suspenseID, reimbursementID int64, since time.Time, loc *time.Location) ([]models.DailyFlow, error)
+	SuspenseFloat(ctx context.Context) (*models.TreasuryFloat, error)
+	ReimbursementFloat(ctx context.Context) (*models.TreasuryFloat, error)
+}
+
+// RevaluationRepository stores end-of-day exchange rates and the revaluations
+// that mark balances to a base currency with them. Revaluations are read
+// following the hints of their context.
+type RevaluationRepository interface {
+	PutFXRate(rate models.FXRate) (*models.FXRate, error)
+	GetFXRate(currency, baseCurrency string, date time.Time) (*models.FXRate, error)
+	LatestRevaluation(currency, baseCurrency string) (*models.Revaluation, error)
+	RevalueTx(tx *sql.Tx, rv models.Revaluation, previous *models.Revaluation, cutoff time.Time, minorUnits int) (*models.Revaluation, error)
+	GetRevaluation(ctx context.Context, id, suspenseID, reimbursementID int64) (*models.Revaluation, error)
+	ListRevaluations(ctx context.Context, currency, baseCurrency string, limit int) ([]models.Revaluation, error)
+}
+
+// SpendingTokenRepository stores the spending tokens of accounts. A debit made
+// with a token locks it and adds to what it has spent in the transaction of the
+// transfer.
+type SpendingTokenRepository interface {
+	InsertSpendingToken(t models.SpendingToken) (*models.SpendingToken, error)
+	GetSpendingToken(id string) (*models.SpendingToken, error)
+	ListSpendingTokens(accountID int64) ([]models.SpendingToken, error)
+	RevokeSpendingToken(id string) (*models.SpendingToken, error)
+	GetSpendingTokenForUpdateTx(tx *sql.Tx, id string) (*models.SpendingToken, error)
+	AddSpendingTokenSpentTx(tx *sql.Tx, id string, amount float64) (*models.SpendingToken, error)
+}
+
+// TransactionAttemptRepository stores rejected transfers for failure analytics.
+type TransactionAttemptRepository interface {
+	InsertTransactionAttempt(a models.TransactionAttempt) error
+	ListTransactionAttempts(filter models.TransactionAttemptFilter, after ChangeCursor, limit int) ([]models.TransactionAttempt, ChangeCursor, error)
+	ListTransactionAttemptsByRequest(requestID string) ([]models.TransactionAttempt, error)
+	CountTransactionAttempts(filter models.TransactionAttemptFilter) (int64, error)
+	TransactionAttemptStats(groupBy models.AttemptGrouping, filter models.TransactionAttemptFilter) ([]models.TransactionAttemptStat, error)
+	GetTransactionAttempt(id int64) (*models.TransactionAttempt, error)
+	GetTransactionAttemptReplay(id int64) (string, error)
+	InsertTransactionAttemptReplayTx(tx *sql.Tx, id int64, transactionID string) error
+}
+
+// ImpersonationRepository stores the audit trail of the requests operators made
+// as customers.
+type ImpersonationRepository interface {
+	InsertImpersonation(i models.Impersonation) error
+	ListImpersonations(filter models.ImpersonationFilter, after ChangeCursor, limit int) ([]models.Impersonation, ChangeCursor, error)
+}
+
+// IndexAdvisoryRepository reads the indexes of the database and the
+// statistics of how its tables and statements are scanned.
+type IndexAdvisoryRepository interface {
+	ListIndexes(ctx context.Context) (map[string]string, error)
+	ListTableScans(ctx context.Context) ([]models.TableScans, error)
+	ListStatementScans(ctx context.Context, limit int) ([]models.StatementScans, bool, error)
+}
+
+// TransferFreezeRepository stores the break-glass freezes of outgoing
+// transfers, and tells which tenant an account belongs to.
+type TransferFreezeRepository interface {
+	InsertTransferFreeze(f models.TransferFreeze) (*models.TransferFreeze, error)
+	ListTransferFreezesInForce(now time.Time) ([]models.TransferFreeze, error)
+	ListTransferFreezes(limit int) ([]models.TransferFreeze, error)
+	LiftTransferFreezes(tenant, liftedBy string, now time.Time) ([]models.TransferFreeze, error)
+	GetAccountTenant(accountID int64) (string, error)
+}
+
+// AmountBoundsRepository stores the bounds on the amount of a single transfer,
+// and tells which tenant an account belongs to.
+type AmountBoundsRepository interface {
+	SetAmountBounds(b models.AmountBounds) (*models.AmountBounds, error)
+	ListAmountBounds() ([]models.AmountBounds, error)
+	DeleteAmountBounds(tenant string) error
+	GetAccountTenant(accountID int64) (string, error)
+}
+
+// SecurityEventRepository stores the security events, apart from the business
+// audit trail.
+type SecurityEventRepository interface {
+	InsertSecurityEvent(e models.SecurityEvent) (*models.SecurityEvent, error)
+	ListSecurityEvents(afterID int64, limit int) ([]models.SecurityEvent, error)
+}
+
+// OutboxRepository stores events written with the changes they describe, and
+// the position of the relay publishing them.
+type OutboxRepository interface {
+	InsertEventTx(tx *sql.Tx, e models.Event) (int64, error)
+	ListOutboxEvents(afterID int64, limit int) ([]models.Event, error)
+	GetOutboxRelay() (*models.OutboxRelayStatus, error)
+	AdvanceOutboxRelay(from, to int64) (bool, error)
+	SetOutboxRelayPosition(afterID int64) error
+	SetOutboxRelayPaused(paused bool) error
+}
+
+// WebhookRepository stores the webhook subscriptions of each tenant. Lookups are
+// scoped to a tenant, so one tenant's webhooks are not found by another.
+type WebhookRepository interface {
+	InsertWebhook(w models.Webhook) (*models.Webhook, error)
+	GetWebhook(id int64, tenant string) (*models.Webhook, error)
+	ListWebhooks(tenant string) ([]models.Webhook, error)
+	DeleteWebhook(id int64, tenant string) error
+	GetAccountOwner(accountID int64) (tenant, owner string, err error)
+}
+
+// NotificationRepository keeps how the notifications of each account are sent,
+// and the daily digests sent to the accounts that want them.
+type NotificationRepository interface {
+	GetNotificationPreference(accountID int64) (*models.NotificationPreference, error)
+	SetNotificationPreference(accountID int64, mode models.NotificationMode, timezone string) (*models.NotificationPreference, error)
+	ListPendingDigests(now time.Time) ([]models.DailyDigest, error)
+	InsertDigestTx(tx *sql.Tx, d models.DailyDigest) error
+}
+
+// RegionRepository holds the region fence of an active-passive deployment.
+type RegionRepository interface {
+	GetRegionFence() (*models.RegionFence, error)
+	PromoteRegion(region string, epoch int64) (bool, error)
+}
+
+// BackfillRepository keeps the progress of backfills and runs the batches of
+// each backfill a migration calls for.
+type BackfillRepository interface {
+	StartBackfill(name string) (*models.Backfill, error)
+	GetBackfill(name string) (*models.Backfill, error)
+	ListBackfills() ([]*models.Backfill, error)
+	AdvanceBackfillTx(tx *sql.Tx, name string, from, to, rows int64) (bool, error)
+	CompleteBackfill(name string) error
+	ResetBackfill(name string) error
+	BackfillTransactionAmountMinorTx(tx *sql.Tx, afterID int64, limit int, scale int64) (int64, int64, error)
+	CountTransactionsMissingAmountMinor() (int64, error)
+}
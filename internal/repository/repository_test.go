@@ -1,13 +1,21 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
+
+	"github.com/nehciyy/intrapay/internal/idgen"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/money"
 )
 
 func setupMockDB(t *testing.T) (*sql.DB, sqlmock.Sqlmock) {
@@ -46,9 +54,11 @@ func TestPostgresAccountRepository_CreateAccount(t *testing.T) {
 			accountID:     1001,
 			initialBalance: 500.00,
 			mockExpect: func() {
-				mock.ExpectExec("INSERT INTO accounts").
-					WithArgs(int64(1001), 500.00).
-					WillReturnResult(sqlmock.NewResult(1, 1))
+				created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+				mock.ExpectQuery("INSERT INTO accounts").
+					WithArgs(int64(1001), 500.00, "payroll", true, "key_payroll").
+					WillReturnRows(sqlmock.NewRows([]string{"account_id", "balance", "created_at", "updated_at", "deleted_at", "display_name", "livemode"}).
+						AddRow(1001, 500.00, created, created, nil, "", true))
 			},
 			expectedError: nil,
 		},
@@ -57,8 +67,8 @@ func TestPostgresAccountRepository_CreateAccount(t *testing.T) {
 			accountID:     1002,
 			initialBalance: 200.00,
 			mockExpect: func() {
-				mock.ExpectExec("INSERT INTO accounts").
-					WithArgs(int64(1002), 200.00).
+				mock.ExpectQuery("INSERT INTO accounts").
+					WithArgs(int64(1002), 200.00, "payroll", true, "key_payroll").
 					WillReturnError(errors.New("db connection error"))
 			},
 			expectedError: errors.New("db connection error"),
@@ -68,83 +78,22 @@ func TestPostgresAccountRepository_CreateAccount(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mockExpect()
-			err := repo.CreateAccount(tt.accountID, tt.initialBalance)
+			account, err := repo.CreateAccount(tt.accountID, tt.initialBalance, "payroll", "key_payroll", true)
 			if tt.expectedError != nil {
 				assert.Error(t, err)
 				assert.Contains(t, err.Error(), tt.expectedError.Error())
 			} else {
 				assert.NoError(t, err)
+				assert.Equal(t, tt.accountID, account.AccountID)
+				assert.Equal(t, models.Amount(tt.initialBalance), account.Balance)
+				assert.Equal(t, models.AccountStatusActive, account.Status)
+				assert.True(t, account.Livemode)
 			}
 			assert.NoError(t, mock.ExpectationsWereMet()) // Verify all expectations were met
 		})
 	}
 }
 
-// TestGetAccountBalance tests the GetAccountBalance method.
-func TestPostgresAccountRepository_GetAccountBalance(t *testing.T) {
-	db, mock := setupMockDB(t)
-
-	repo := NewPostgresAccountRepository(db)
-
-	tests := []struct {
-		name          string
-		accountID     int64
-		mockExpect    func()
-		expectedBalance float64
-		expectedError error
-	}{
-		{
-			name:          "Successful retrieval",
-			accountID:     1001,
-			mockExpect: func() {
-				rows := sqlmock.NewRows([]string{"balance"}).AddRow(1000.50)
-				mock.ExpectQuery("SELECT balance FROM accounts WHERE account_id = \\$1").
-					WithArgs(int64(1001)).
-					WillReturnRows(rows)
-			},
-			expectedBalance: 1000.50,
-			expectedError: nil,
-		},
-		{
-			name:          "Account not found",
-			accountID:     1002,
-			mockExpect: func() {
-				mock.ExpectQuery("SELECT balance FROM accounts WHERE account_id = \\$1").
-					WithArgs(int64(1002)).
-					WillReturnError(sql.ErrNoRows)
-			},
-			expectedBalance: 0,
-			expectedError: fmt.Errorf("account with ID %d not found", 1002),
-		},
-		{
-			name:          "Database error",
-			accountID:     1003,
-			mockExpect: func() {
-				mock.ExpectQuery("SELECT balance FROM accounts WHERE account_id = \\$1").
-					WithArgs(int64(1003)).
-					WillReturnError(errors.New("query failed"))
-			},
-			expectedBalance: 0,
-			expectedError: errors.New("query failed"),
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			tt.mockExpect()
-			balance, err := repo.GetAccountBalance(tt.accountID)
-			if tt.expectedError != nil {
-				assert.Error(t, err)
-				assert.Contains(t, err.Error(), tt.expectedError.Error())
-			} else {
-				assert.NoError(t, err)
-				assert.Equal(t, tt.expectedBalance, balance)
-			}
-			assert.NoError(t, mock.ExpectationsWereMet())
-		})
-	}
-}
-
 // TestAccountExists tests the AccountExists method.
 func TestPostgresAccountRepository_AccountExists(t *testing.T) {
 	db, mock := setupMockDB(t)
@@ -163,7 +112,7 @@ func TestPostgresAccountRepository_AccountExists(t *testing.T) {
 			accountID:     1001,
 			mockExpect: func() {
 				rows := sqlmock.NewRows([]string{"exists"}).AddRow(true)
-				mock.ExpectQuery("SELECT EXISTS\\(SELECT 1 FROM accounts WHERE account_id = \\$1\\)").
+				mock.ExpectQuery("SELECT EXISTS\\(SELECT 1 FROM accounts WHERE account_id = \\$1 AND deleted_at IS NULL\\)").
 					WithArgs(int64(1001)).
 					WillReturnRows(rows)
 			},
@@ -175,7 +124,7 @@ func TestPostgresAccountRepository_AccountExists(t *testing.T) {
 			accountID:     1002,
 			mockExpect: func() {
 				rows := sqlmock.NewRows([]string{"exists"}).AddRow(false)
-				mock.ExpectQuery("SELECT EXISTS\\(SELECT 1 FROM accounts WHERE account_id = \\$1\\)").
+				mock.ExpectQuery("SELECT EXISTS\\(SELECT 1 FROM accounts WHERE account_id = \\$1 AND deleted_at IS NULL\\)").
 					WithArgs(int64(1002)).
 					WillReturnRows(rows)
 			},
@@ -186,7 +135,7 @@ func TestPostgresAccountRepository_AccountExists(t *testing.T) {
 			name:          "Database error",
 			accountID:     1003,
 			mockExpect: func() {
-				mock.ExpectQuery("SELECT EXISTS\\(SELECT 1 FROM accounts WHERE account_id = \\$1\\)").
+				mock.ExpectQuery("SELECT EXISTS\\(SELECT 1 FROM accounts WHERE account_id = \\$1 AND deleted_at IS NULL\\)").
 					WithArgs(int64(1003)).
 					WillReturnError(errors.New("db error"))
 			},
@@ -198,7 +147,7 @@ func TestPostgresAccountRepository_AccountExists(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mockExpect()
-			exists, err := repo.AccountExists(tt.accountID)
+			exists, err := repo.AccountExists(context.Background(), tt.accountID)
 			if tt.expectedError != nil {
 				assert.Error(t, err)
 				assert.Contains(t, err.Error(), tt.expectedError.Error())
@@ -229,7 +178,7 @@ func TestPostgresAccountRepository_GetAccountBalanceTx(t *testing.T) {
 			mockExpect: func(mock sqlmock.Sqlmock) *sql.Tx {
 				mock.ExpectBegin()
 				rows := sqlmock.NewRows([]string{"balance"}).AddRow(500.00)
-				mock.ExpectQuery("SELECT balance FROM accounts WHERE account_id = \\$1 FOR UPDATE").
+				mock.ExpectQuery("SELECT balance FROM accounts WHERE account_id = \\$1 AND deleted_at IS NULL FOR UPDATE").
 					WithArgs(int64(1001)).
 					WillReturnRows(rows)
 				mock.ExpectRollback() // Expect rollback as we'll explicitly call it
@@ -244,7 +193,7 @@ func TestPostgresAccountRepository_GetAccountBalanceTx(t *testing.T) {
 			accountID:     1002,
 			mockExpect: func(mock sqlmock.Sqlmock) *sql.Tx {
 				mock.ExpectBegin()
-				mock.ExpectQuery("SELECT balance FROM accounts WHERE account_id = \\$1 FOR UPDATE").
+				mock.ExpectQuery("SELECT balance FROM accounts WHERE account_id = \\$1 AND deleted_at IS NULL FOR UPDATE").
 					WithArgs(int64(1002)).
 					WillReturnError(sql.ErrNoRows)
 				mock.ExpectRollback()
@@ -259,7 +208,7 @@ func TestPostgresAccountRepository_GetAccountBalanceTx(t *testing.T) {
 			accountID:     1003,
 			mockExpect: func(mock sqlmock.Sqlmock) *sql.Tx {
 				mock.ExpectBegin()
-				mock.ExpectQuery("SELECT balance FROM accounts WHERE account_id = \\$1 FOR UPDATE").
+				mock.ExpectQuery("SELECT balance FROM accounts WHERE account_id = \\$1 AND deleted_at IS NULL FOR UPDATE").
 					WithArgs(int64(1003)).
 					WillReturnError(errors.New("tx query failed"))
 				mock.ExpectRollback()
@@ -307,7 +256,7 @@ func TestPostgresAccountRepository_AccountExistsTx(t *testing.T) {
 			mockExpect: func(mock sqlmock.Sqlmock, db *sql.DB) *sql.Tx {
 				mock.ExpectBegin()
 				rows := sqlmock.NewRows([]string{"exists"}).AddRow(true)
-				mock.ExpectQuery("SELECT EXISTS\\(SELECT 1 FROM accounts WHERE account_id = \\$1\\)").
+				mock.ExpectQuery("SELECT EXISTS\\(SELECT 1 FROM accounts WHERE account_id = \\$1 AND deleted_at IS NULL\\)").
 					WithArgs(int64(1001)).
 					WillReturnRows(rows)
 				mock.ExpectRollback()
@@ -324,7 +273,7 @@ func TestPostgresAccountRepository_AccountExistsTx(t *testing.T) {
 			mockExpect: func(mock sqlmock.Sqlmock, db *sql.DB) *sql.Tx {
 				mock.ExpectBegin()
 				rows := sqlmock.NewRows([]string{"exists"}).AddRow(false)
-				mock.ExpectQuery("SELECT EXISTS\\(SELECT 1 FROM accounts WHERE account_id = \\$1\\)").
+				mock.ExpectQuery("SELECT EXISTS\\(SELECT 1 FROM accounts WHERE account_id = \\$1 AND deleted_at IS NULL\\)").
 					WithArgs(int64(1002)).
 					WillReturnRows(rows)
 				mock.ExpectRollback()
@@ -340,7 +289,7 @@ func TestPostgresAccountRepository_AccountExistsTx(t *testing.T) {
 			accountID:     1003,
 			mockExpect: func(mock sqlmock.Sqlmock, db *sql.DB) *sql.Tx {
 				mock.ExpectBegin()
-				mock.ExpectQuery("SELECT EXISTS\\(SELECT 1 FROM accounts WHERE account_id = \\$1\\)").
+				mock.ExpectQuery("SELECT EXISTS\\(SELECT 1 FROM accounts WHERE account_id = \\$1 AND deleted_at IS NULL\\)").
 					WithArgs(int64(1003)).
 					WillReturnError(errors.New("tx exists query failed"))
 				mock.ExpectRollback()
@@ -470,7 +419,7 @@ func TestPostgresAccountRepository_InsertTransactionLogTx(t *testing.T) {
 				mock.ExpectBegin() // Expect Begin for this transaction
 				rows := sqlmock.NewRows([]string{"id"}).AddRow(1)
 				mock.ExpectQuery("INSERT INTO transactions").
-					WithArgs(int64(100), int64(200), 50.00).
+					WithArgs(int64(100), int64(200), 50.00, int64(5000), "").
 					WillReturnRows(rows)
 				mock.ExpectRollback()
 			},
@@ -485,7 +434,7 @@ func TestPostgresAccountRepository_InsertTransactionLogTx(t *testing.T) {
 			mockExpect: func(mock sqlmock.Sqlmock) {
 				mock.ExpectBegin() // Expect Begin for this transaction
 				mock.ExpectQuery("INSERT INTO transactions").
-					WithArgs(int64(101), int64(201), 75.00).
+					WithArgs(int64(101), int64(201), 75.00, int64(7500), "").
 					WillReturnError(errors.New("tx log insert failed"))
 				mock.ExpectRollback()
 			},
@@ -518,6 +467,28 @@ func TestPostgresAccountRepository_InsertTransactionLogTx(t *testing.T) {
 	}
 }
 
+// TestInsertTransactionLogTx_GeneratedID tests that a configured ID generator's
+// ID is stored as the transaction_ref and returned instead of the serial key.
+func TestInsertTransactionLogTx_GeneratedID(t *testing.T) {
+	db, mock := setupMockDB(t)
+	ids := idgen.Sequence(func() (int64, error) { return 900, nil })
+	repo := NewPostgresTransactionRepository(db, WithIDGenerator(ids))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO transactions").
+		WithArgs(int64(100), int64(200), 50.00, int64(5000), "900").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectRollback()
+
+	tx, err := db.Begin()
+	assert.NoError(t, err)
+	txID, err := repo.InsertTransactionLogTx(tx, 100, 200, 50.00)
+	assert.NoError(t, err)
+	assert.Equal(t, "900", txID)
+	assert.NoError(t, tx.Rollback())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 // TestIsSerializationFailure tests the IsSerializationFailure helper function.
 func TestIsSerializationFailure(t *testing.T) {
 	tests := []struct {
@@ -530,6 +501,11 @@ func TestIsSerializationFailure(t *testing.T) {
 			err:      errors.New("pq: deadlock detected (SQLSTATE 40001)"),
 			expected: true,
 		},
+		{
+			name:     "pq serialization failure",
+			err:      &pq.Error{Code: "40001", Message: "could not serialize access due to concurrent update"},
+			expected: true,
+		},
 		{
 			name:     "Another database error",
 			err:      errors.New("pq: unique constraint violation (SQLSTATE 23505)"),
@@ -552,4 +528,2133 @@ func TestIsSerializationFailure(t *testing.T) {
 			assert.Equal(t, tt.expected, IsSerializationFailure(tt.err))
 		})
 	}
-}
\ No newline at end of file
+}
+// TestGetBalanceTotals tests the GetBalanceTotals method.
+func TestPostgresAccountRepository_GetBalanceTotals(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewPostgresAccountRepository(db)
+
+	rows := sqlmock.NewRows([]string{"total", "opening"}).AddRow(1500.0, 1500.0)
+	mock.ExpectQuery("-- name: GetBalanceTotals :one").
+		WillReturnRows(rows)
+
+	total, opening, err := repo.GetBalanceTotals()
+	assert.NoError(t, err)
+	assert.Equal(t, 1500.0, total)
+	assert.Equal(t, 1500.0, opening)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestComputeBalanceTx tests the ComputeBalanceTx method.
+func TestPostgresTransactionRepository_ComputeBalanceTx(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewPostgresTransactionRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("-- name: ComputeBalance :one").
+		WithArgs(int64(1001)).
+		WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(250.0))
+	mock.ExpectQuery("-- name: ComputeBalance :one").
+		WithArgs(int64(1002)).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	tx, err := db.Begin()
+	assert.NoError(t, err)
+
+	balance, err := repo.ComputeBalanceTx(tx, 1001)
+	assert.NoError(t, err)
+	assert.Equal(t, 250.0, balance)
+
+	_, err = repo.ComputeBalanceTx(tx, 1002)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	assert.NoError(t, tx.Rollback())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestInsertTransactionLogsTx tests the InsertTransactionLogsTx method.
+func TestPostgresTransactionRepository_InsertTransactionLogsTx(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewPostgresTransactionRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("-- name: InsertTransactions :many").
+		WithArgs("{1,3}", "{2,4}", "{10,20.5}", "{1000,2050}", `{"",""}`, `{"","top_up"}`, `{"","half_even"}`, "{0,20.495}").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(11).AddRow(12))
+	mock.ExpectRollback()
+
+	tx, err := db.Begin()
+	assert.NoError(t, err)
+
+	ids, err := repo.InsertTransactionLogsTx(tx, []TransactionLog{
+		{SourceID: 1, DestID: 2, Amount: 10},
+		{SourceID: 3, DestID: 4, Amount: 20.5, Kind: models.TransactionTopUp, Rounding: &money.Rounding{Mode: money.HalfEven, MinorUnits: 2, Unrounded: 20.495}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"11", "12"}, ids)
+
+	ids, err = repo.InsertTransactionLogsTx(tx, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, ids)
+
+	assert.NoError(t, tx.Rollback())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresTransactionRepository_DebitBalanceTx(t *testing.T) {
+	tests := []struct {
+		name            string
+		sqlMockExpect   func(sqlmock.Sqlmock)
+		expectedBalance float64
+		expectedError   error
+	}{
+		{
+			name: "Debited",
+			sqlMockExpect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("-- name: DebitBalance :one").
+					WithArgs(40.0, int64(1)).
+					WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(60.0))
+			},
+			expectedBalance: 60.0,
+		},
+		{
+			name: "Insufficient Funds",
+			sqlMockExpect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("-- name: DebitBalance :one").
+					WithArgs(40.0, int64(1)).
+					WillReturnError(sql.ErrNoRows)
+				mock.ExpectQuery("-- name: AccountExists :one").
+					WithArgs(int64(1)).
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+			},
+			expectedError: ErrInsufficientFunds,
+		},
+		{
+			name: "Account Not Found",
+			sqlMockExpect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("-- name: DebitBalance :one").
+					WithArgs(40.0, int64(1)).
+					WillReturnError(sql.ErrNoRows)
+				mock.ExpectQuery("-- name: AccountExists :one").
+					WithArgs(int64(1)).
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			},
+			expectedError: ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := setupMockDB(t)
+			repo := NewPostgresTransactionRepository(db)
+
+			mock.ExpectBegin()
+			tt.sqlMockExpect(mock)
+			mock.ExpectRollback()
+
+			tx, err := db.Begin()
+			assert.NoError(t, err)
+
+			balance, err := repo.DebitBalanceTx(tx, 1, 40.0)
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedBalance, balance)
+			}
+
+			assert.NoError(t, tx.Rollback())
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPostgresAccountRepository_GetAccount(t *testing.T) {
+	createdAt := time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC)
+	deletedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	columns := []string{"account_id", "balance", "created_at", "updated_at", "deleted_at", "display_name", "livemode"}
+	tests := []struct {
+		name            string
+		includeDeleted  bool
+		sqlMockExpect   func(sqlmock.Sqlmock)
+		expectedAccount *models.Account
+		expectedError   error
+	}{
+		{
+			name: "Active",
+			sqlMockExpect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("-- name: GetAccount :one").
+					WithArgs(int64(1), false).
+					WillReturnRows(sqlmock.NewRows(columns).AddRow(1, 100.0, createdAt, createdAt, nil, "Jane Doe", true))
+			},
+			expectedAccount: &models.Account{AccountID: 1, DisplayName: "Jane Doe", Balance: 100.0, Status: models.AccountStatusActive, CreatedAt: createdAt, UpdatedAt: createdAt, Livemode: true},
+		},
+		{
+			name:           "Deleted Included",
+			includeDeleted: true,
+			sqlMockExpect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("-- name: GetAccount :one").
+					WithArgs(int64(1), true).
+					WillReturnRows(sqlmock.NewRows(columns).AddRow(1, 100.0, createdAt, deletedAt, deletedAt, "", false))
+			},
+			expectedAccount: &models.Account{AccountID: 1, Balance: 100.0, Status: models.AccountStatusDeleted, CreatedAt: createdAt, UpdatedAt: deletedAt, DeletedAt: &deletedAt},
+		},
+		{
+			name: "Not Found",
+			sqlMockExpect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("-- name: GetAccount :one").
+					WithArgs(int64(1), false).
+					WillReturnError(sql.ErrNoRows)
+			},
+			expectedError: ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := setupMockDB(t)
+			repo := NewPostgresAccountRepository(db)
+			tt.sqlMockExpect(mock)
+
+			account, err := repo.GetAccount(context.Background(), 1, tt.includeDeleted)
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedAccount, account)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPostgresAccountRepository_ListAccounts(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewPostgresAccountRepository(db)
+
+	createdAt := time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC)
+	columns := []string{"account_id", "balance", "created_at", "updated_at", "deleted_at", "display_name", "livemode"}
+	mock.ExpectQuery("-- name: ListAccounts :many").
+		WithArgs(int64(10), true, sql.NullFloat64{Float64: 100, Valid: true}, sql.NullFloat64{}, int32(2)).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(11, 150.0, createdAt, createdAt, nil, "Jane Doe", true).
+			AddRow(14, 100.0, createdAt, createdAt, nil, "", true))
+
+	min := models.Amount(100)
+	accounts, err := repo.ListAccounts(context.Background(), models.AccountFilter{MinBalance: &min}, 10, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Account{
+		{AccountID: 11, DisplayName: "Jane Doe", Balance: 150, Status: models.AccountStatusActive, CreatedAt: createdAt, UpdatedAt: createdAt, Livemode: true},
+		{AccountID: 14, Balance: 100, Status: models.AccountStatusActive, CreatedAt: createdAt, UpdatedAt: createdAt, Livemode: true},
+	}, accounts)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresAccountRepository_DeleteAndRestore(t *testing.T) {
+	tests := []struct {
+		name          string
+		query         string
+		call          func(*PostgresAccountRepository) error
+		rowsAffected  int64
+		expectedError error
+	}{
+		{
+			name:         "Delete",
+			query:        "-- name: SoftDeleteAccount :execrows",
+			call:         func(r *PostgresAccountRepository) error { return r.DeleteAccount(1) },
+			rowsAffected: 1,
+		},
+		{
+			name:          "Delete Missing Or Already Deleted",
+			query:         "-- name: SoftDeleteAccount :execrows",
+			call:          func(r *PostgresAccountRepository) error { return r.DeleteAccount(1) },
+			expectedError: ErrNotFound,
+		},
+		{
+			name:         "Restore",
+			query:        "-- name: RestoreAccount :execrows",
+			call:         func(r *PostgresAccountRepository) error { return r.RestoreAccount(1) },
+			rowsAffected: 1,
+		},
+		{
+			name:          "Restore Not Deleted",
+			query:         "-- name: RestoreAccount :execrows",
+			call:          func(r *PostgresAccountRepository) error { return r.RestoreAccount(1) },
+			expectedError: ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := setupMockDB(t)
+			repo := NewPostgresAccountRepository(db)
+			mock.ExpectExec(tt.query).
+				WithArgs(int64(1)).
+				WillReturnResult(sqlmock.NewResult(0, tt.rowsAffected))
+
+			err := tt.call(repo)
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPostgresTransactionRepository_ListTransactions(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewPostgresTransactionRepository(db)
+
+	since := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	after := ChangeCursor{UpdatedAt: since.Add(time.Second), ID: 3}
+	later := since.Add(time.Minute)
+	mock.ExpectQuery("-- name: ListTransactions :many").
+		WithArgs(since, after.UpdatedAt, int32(3), "", true, int32(50)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "source_account_id", "destination_account_id", "amount", "created_at", "updated_at", "transaction_ref", "amount_minor", "kind", "rounding_mode", "unrounded_amount", "tags", "livemode"}).
+			AddRow(7, 1, 2, 25.0, later, later, nil, 2500, "transfer", nil, nil, "{}", true).
+			AddRow(8, 9, 1, 1.88, later, later, nil, 188, "cashback", "half_up", 1.875, "{correction,migration}", true))
+
+	transactions, next, err := repo.ListTransactions(context.Background(), models.TransactionFilter{UpdatedSince: since}, after, 50)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Transaction{{
+		ID:                   "7",
+		Kind:                 models.TransactionTransfer,
+		SourceAccountID:      1,
+		DestinationAccountID: 2,
+		Amount:               25.0,
+		CreatedAt:            later,
+		UpdatedAt:            later,
+		Livemode:             true,
+	}, {
+		ID:                   "8",
+		Kind:                 models.TransactionCashback,
+		SourceAccountID:      9,
+		DestinationAccountID: 1,
+		Amount:               1.88,
+		CreatedAt:            later,
+		UpdatedAt:            later,
+		Rounding:             &money.Rounding{Mode: money.HalfUp, MinorUnits: 2, Unrounded: 1.875},
+		Tags:                 []string{"correction", "migration"},
+		Livemode:             true,
+	}}, transactions)
+	assert.Equal(t, ChangeCursor{UpdatedAt: later, ID: 8}, next)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresAccountRepository_SetDisplayName(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewPostgresAccountRepository(db)
+	mock.ExpectExec("-- name: SetAccountDisplayName :execrows").
+		WithArgs(int64(1), "Jane Doe").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("-- name: SetAccountDisplayName :execrows").
+		WithArgs(int64(2), "Jane Doe").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.NoError(t, repo.SetDisplayName(1, "Jane Doe"))
+	assert.ErrorIs(t, repo.SetDisplayName(2, "Jane Doe"), ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresTransactionRepository_ListAccountTransactions(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewPostgresTransactionRepository(db)
+
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	columns := []string{"id", "source_account_id", "destination_account_id", "amount", "created_at", "updated_at", "transaction_ref", "kind", "tags", "livemode", "counterparty_display_name", "counterparty_deleted"}
+	mock.ExpectQuery("-- name: ListAccountTransactions :many").
+		WithArgs(int64(1), time.Time{}, int32(0), int32(50)).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(7, 1, 2, 25.0, created, created, nil, "transfer", "{}", true, "Jane Doe", false).
+			AddRow(8, 3, 1, 5.0, created, created, nil, "top_up", "{}", true, nil, false))
+
+	transactions, next, err := repo.ListAccountTransactions(context.Background(), 1, ChangeCursor{}, 50)
+	assert.NoError(t, err)
+	assert.Len(t, transactions, 2)
+	assert.Equal(t, &models.Counterparty{AccountID: 2, DisplayName: "Jane Doe"}, transactions[0].Counterparty, "the destination of an outgoing transfer")
+	assert.Equal(t, &models.Counterparty{AccountID: 3}, transactions[1].Counterparty, "the source of an incoming transfer, with no account row")
+	assert.Equal(t, models.TransactionTopUp, transactions[1].Kind)
+	assert.Equal(t, ChangeCursor{UpdatedAt: created, ID: 8}, next)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresTransactionRepository_SumAccountFlows(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewPostgresTransactionRepository(db)
+
+	since := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("-- name: SumAccountFlows :one").
+		WithArgs(int64(1), since).
+		WillReturnRows(sqlmock.NewRows([]string{"incoming", "incoming_count", "outgoing", "outgoing_count"}).
+			AddRow(150.0, 3, 40.0, 1))
+
+	flows, err := repo.SumAccountFlows(context.Background(), 1, since)
+	assert.NoError(t, err)
+	assert.Equal(t, &models.AccountFlows{Since: since, Incoming: 150, IncomingCount: 3, Outgoing: 40, OutgoingCount: 1}, flows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresTransactionRepository_GetTransaction(t *testing.T) {
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	columns := []string{"id", "source_account_id", "destination_account_id", "amount", "created_at", "updated_at", "transaction_ref", "amount_minor", "kind", "rounding_mode", "unrounded_amount", "tags", "livemode"}
+	const ref = "01JNHZ8Q5X4T0Y2M3K6W9V1R7B"
+
+	t.Run("By ref", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresTransactionRepository(db)
+		mock.ExpectQuery("-- name: GetTransaction :one").
+			WithArgs(ref, sql.NullInt32{}).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(7, 1, 2, 25.0, created, created, ref, 2500, "transfer", nil, nil, "{}", true))
+
+		transaction, err := repo.GetTransaction(context.Background(), ref)
+		assert.NoError(t, err)
+		assert.Equal(t, &models.Transaction{
+			ID:                   ref,
+			Ref:                  ref,
+			Kind:                 models.TransactionTransfer,
+			SourceAccountID:      1,
+			DestinationAccountID: 2,
+			Amount:               25.0,
+			CreatedAt:            created,
+			UpdatedAt:            created,
+			Livemode:             true,
+		}, transaction)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("By serial", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresTransactionRepository(db)
+		mock.ExpectQuery("-- name: GetTransaction :one").
+			WithArgs("7", sql.NullInt32{Int32: 7, Valid: true}).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(7, 1, 2, 25.0, created, created, ref, 2500, "transfer", nil, nil, "{}", true))
+
+		transaction, err := repo.GetTransaction(context.Background(), "7")
+		assert.NoError(t, err)
+		assert.Equal(t, ref, transaction.ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresTransactionRepository(db)
+		mock.ExpectQuery("-- name: GetTransaction :one").
+			WillReturnError(sql.ErrNoRows)
+
+		_, err := repo.GetTransaction(context.Background(), "missing")
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPostgresTransactionRepository_GetTransactions(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewPostgresTransactionRepository(db)
+
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	columns := []string{"id", "source_account_id", "destination_account_id", "amount", "created_at", "updated_at", "transaction_ref", "amount_minor", "kind", "rounding_mode", "unrounded_amount", "tags", "livemode"}
+	const ref = "01JNHZ8Q5X4T0Y2M3K6W9V1R7B"
+	ids := []string{ref, "8", "missing"}
+	mock.ExpectQuery("-- name: GetTransactions :many").
+		WithArgs(pq.Array(ids), pq.Array([]int32{8})).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(7, 1, 2, 25.0, created, created, ref, 2500, "transfer", nil, nil, "{}", true).
+			AddRow(8, 2, 1, 5.0, created, created, nil, nil, "top_up", nil, nil, "{}", true))
+
+	transactions, err := repo.GetTransactions(context.Background(), ids)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]models.Transaction{
+		ref: {ID: ref, Ref: ref, Kind: models.TransactionTransfer, SourceAccountID: 1, DestinationAccountID: 2, Amount: 25.0, CreatedAt: created, UpdatedAt: created, Livemode: true},
+		"8": {ID: "8", Kind: models.TransactionTopUp, SourceAccountID: 2, DestinationAccountID: 1, Amount: 5.0, CreatedAt: created, UpdatedAt: created, Livemode: true},
+	}, transactions)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresTransactionRepository_CountTransactions(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewPostgresTransactionRepository(db)
+
+	since := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("-- name: CountTransactions :one").
+		WithArgs(since, "", true).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(12))
+	mock.ExpectQuery("-- name: CountTransactions :one").
+		WithArgs(since, "migration", true).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+	count, err := repo.CountTransactions(context.Background(), models.TransactionFilter{UpdatedSince: since})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(12), count)
+	count, err = repo.CountTransactions(context.Background(), models.TransactionFilter{UpdatedSince: since, Tag: "migration"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresTransactionRepository_ListTransactionChanges(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewPostgresTransactionRepository(db)
+
+	after := ChangeCursor{UpdatedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC), ID: 6}
+	later := after.UpdatedAt.Add(time.Minute)
+	columns := []string{"id", "source_account_id", "destination_account_id", "amount", "created_at", "updated_at", "transaction_ref", "amount_minor", "kind", "rounding_mode", "unrounded_amount", "tags", "livemode"}
+
+	mock.ExpectQuery("-- name: ListTransactionChanges :many").
+		WithArgs(after.UpdatedAt, int32(6), true, int32(10)).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(7, 1, 2, 25.0, later, later, nil, 2500, "transfer", nil, nil, "{}", true).
+			AddRow(9, 2, 1, 5.0, later, later, nil, nil, "transfer", nil, nil, "{}", true))
+	mock.ExpectQuery("-- name: ListTransactionChanges :many").
+		WithArgs(later, int32(9), true, int32(10)).
+		WillReturnRows(sqlmock.NewRows(columns))
+
+	transactions, next, err := repo.ListTransactionChanges(context.Background(), after, true, 10)
+	assert.NoError(t, err)
+	assert.Len(t, transactions, 2)
+	assert.Equal(t, ChangeCursor{UpdatedAt: later, ID: 9}, next)
+
+	transactions, unchanged, err := repo.ListTransactionChanges(context.Background(), next, true, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, transactions)
+	assert.Equal(t, next, unchanged)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresTransactionTagRepository(t *testing.T) {
+	created := time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC)
+	columns := []string{"id", "source_account_id", "destination_account_id", "amount", "created_at", "updated_at", "transaction_ref", "amount_minor", "kind", "rounding_mode", "unrounded_amount", "tags", "livemode"}
+
+	t.Run("SetTransactionTags", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresTransactionTagRepository(db)
+		mock.ExpectQuery("-- name: SetTransactionTags :one").
+			WithArgs(pq.Array([]string{"migration", "payroll"}), "7", sql.NullInt32{Int32: 7, Valid: true}).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(7, 1, 2, 25.0, created, created, nil, 2500, "transfer", nil, nil, "{migration,payroll}", true))
+
+		transaction, err := repo.SetTransactionTags("7", []string{"migration", "payroll"})
+		assert.NoError(t, err)
+		assert.Equal(t, "7", transaction.ID)
+		assert.Equal(t, []string{"migration", "payroll"}, transaction.Tags)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Clear tags of unknown transaction", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresTransactionTagRepository(db)
+		mock.ExpectQuery("-- name: SetTransactionTags :one").
+			WithArgs(pq.Array([]string{}), "txn_missing", sql.NullInt32{}).
+			WillReturnError(sql.ErrNoRows)
+
+		_, err := repo.SetTransactionTags("txn_missing", nil)
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ListAccountTags", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresTransactionTagRepository(db)
+		mock.ExpectQuery("-- name: ListAccountTags :many").
+			WithArgs(int64(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"tag", "transactions"}).
+				AddRow("migration", int64(3)).
+				AddRow("payroll", int64(1)))
+
+		tags, err := repo.ListAccountTags(context.Background(), 1)
+		assert.NoError(t, err)
+		assert.Equal(t, []models.TransactionTag{{Tag: "migration", Transactions: 3}, {Tag: "payroll", Transactions: 1}}, tags)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPostgresUsageRepository(t *testing.T) {
+	period := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	updated := time.Date(2026, 3, 14, 9, 30, 0, 0, time.UTC)
+
+	t.Run("AddUsage", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresUsageRepository(db)
+		mock.ExpectExec("-- name: AddUsage :exec").
+			WithArgs(period, "key-1", "payroll", int64(3), 250.0).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := repo.AddUsage(models.Usage{Period: "2026-03", APIKey: "key-1", Tenant: "payroll", Calls: 3, Volume: 250})
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("GetKeyUsage", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresUsageRepository(db)
+		mock.ExpectQuery("-- name: GetKeyUsage :one").
+			WithArgs(period, "key-1").
+			WillReturnRows(sqlmock.NewRows([]string{"calls", "volume"}).AddRow(int64(40), 1200.5))
+
+		calls, volume, err := repo.GetKeyUsage("2026-03", "key-1")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(40), calls)
+		assert.Equal(t, 1200.5, volume)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("GetTenantUsage", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresUsageRepository(db)
+		mock.ExpectQuery("-- name: GetTenantUsage :one").
+			WithArgs(period, "payroll").
+			WillReturnRows(sqlmock.NewRows([]string{"calls", "volume"}).AddRow(int64(90), 3000.0))
+
+		calls, volume, err := repo.GetTenantUsage("2026-03", "payroll")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(90), calls)
+		assert.Equal(t, 3000.0, volume)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ListUsage", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresUsageRepository(db)
+		mock.ExpectQuery("-- name: ListUsage :many").
+			WithArgs(period).
+			WillReturnRows(sqlmock.NewRows([]string{"period", "api_key", "tenant", "calls", "volume", "updated_at"}).
+				AddRow(period, "key-1", "payroll", int64(40), 1200.5, updated))
+
+		usage, err := repo.ListUsage("2026-03")
+		assert.NoError(t, err)
+		assert.Equal(t, []models.Usage{{Period: "2026-03", APIKey: "key-1", Tenant: "payroll", Calls: 40, Volume: 1200.5, UpdatedAt: updated}}, usage)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Invalid period", func(t *testing.T) {
+		db, _ := setupMockDB(t)
+		_, err := NewPostgresUsageRepository(db).ListUsage("March")
+		assert.Error(t, err)
+	})
+}
+
+func TestPostgresQuotaRepository(t *testing.T) {
+	updated := time.Date(2026, 3, 14, 9, 30, 0, 0, time.UTC)
+	columns := []string{"scope", "subject", "monthly_calls", "monthly_volume", "updated_at"}
+
+	t.Run("SetQuota", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresQuotaRepository(db)
+		mock.ExpectQuery("-- name: SetQuota :one").
+			WithArgs("tenant", "payroll", int64(1000), 50000.0).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow("tenant", "payroll", int64(1000), 50000.0, updated))
+
+		quota, err := repo.SetQuota(models.Quota{Scope: models.QuotaScopeTenant, Subject: "payroll", MonthlyCalls: 1000, MonthlyVolume: 50000})
+		assert.NoError(t, err)
+		assert.Equal(t, &models.Quota{Scope: models.QuotaScopeTenant, Subject: "payroll", MonthlyCalls: 1000, MonthlyVolume: 50000, UpdatedAt: updated}, quota)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("GetQuota not found", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresQuotaRepository(db)
+		mock.ExpectQuery("-- name: GetQuota :one").
+			WithArgs("api_key", "key-1").
+			WillReturnError(sql.ErrNoRows)
+
+		_, err := repo.GetQuota(models.QuotaScopeAPIKey, "key-1")
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ListQuotas", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresQuotaRepository(db)
+		mock.ExpectQuery("-- name: ListQuotas :many").
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow("api_key", "key-1", int64(0), 100.0, updated).
+				AddRow("tenant", "payroll", int64(1000), 0.0, updated))
+
+		quotas, err := repo.ListQuotas()
+		assert.NoError(t, err)
+		assert.Len(t, quotas, 2)
+		assert.Equal(t, models.QuotaScopeAPIKey, quotas[0].Scope)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("DeleteQuota not found", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresQuotaRepository(db)
+		mock.ExpectExec("-- name: DeleteQuota :execrows").
+			WithArgs("tenant", "payroll").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := repo.DeleteQuota(models.QuotaScopeTenant, "payroll")
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("SetAccountLimit", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresQuotaRepository(db)
+		mock.ExpectQuery("-- name: SetAccountLimit :one").
+			WithArgs("payroll", int64(500)).
+			WillReturnRows(sqlmock.NewRows([]string{"tenant", "max_accounts", "updated_at"}).AddRow("payroll", int64(500), updated))
+
+		limit, err := repo.SetAccountLimit("payroll", 500)
+		assert.NoError(t, err)
+		assert.Equal(t, &models.AccountLimit{Tenant: "payroll", MaxAccounts: 500, UpdatedAt: updated}, limit)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("GetAccountLimit not found", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresQuotaRepository(db)
+		mock.ExpectQuery("-- name: GetAccountLimit :one").
+			WithArgs("payroll").
+			WillReturnError(sql.ErrNoRows)
+
+		_, err := repo.GetAccountLimit("payroll")
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPostgresTopUpRepository(t *testing.T) {
+	updated := time.Date(2026, 3, 14, 9, 30, 0, 0, time.UTC)
+	columns := []string{"account_id", "threshold", "amount", "funding_account_id", "updated_at"}
+
+	t.Run("SetTopUpRule", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresTopUpRepository(db)
+		mock.ExpectQuery("-- name: SetTopUpRule :one").
+			WithArgs(int64(1), 100.0, 500.0, int64(2)).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(int64(1), 100.0, 500.0, int64(2), updated))
+
+		rule, err := repo.SetTopUpRule(models.TopUpRule{AccountID: 1, Threshold: 100, Amount: 500, FundingAccountID: 2})
+		assert.NoError(t, err)
+		assert.Equal(t, &models.TopUpRule{AccountID: 1, Threshold: 100, Amount: 500, FundingAccountID: 2, UpdatedAt: updated}, rule)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("GetTopUpRule not found", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresTopUpRepository(db)
+		mock.ExpectQuery("-- name: GetTopUpRule :one").
+			WithArgs(int64(1)).
+			WillReturnError(sql.ErrNoRows)
+
+		_, err := repo.GetTopUpRule(1)
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("DeleteTopUpRule not found", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresTopUpRepository(db)
+		mock.ExpectExec("-- name: DeleteTopUpRule :execrows").
+			WithArgs(int64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		assert.ErrorIs(t, repo.DeleteTopUpRule(1), ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPostgresReimbursementRepository(t *testing.T) {
+	created := time.Date(2026, 3, 14, 9, 30, 0, 0, time.UTC)
+	columns := []string{"id", "tenant", "account_id", "amount", "description", "receipt", "status", "decided_by", "decision_note", "transaction_id", "created_at", "decided_at"}
+
+	t.Run("InsertReimbursementTx", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresReimbursementRepository(db)
+		receipt := []byte(`{"merchant":"Rail Co","date":"2026-03-12"}`)
+		mock.ExpectBegin()
+		mock.ExpectQuery("-- name: InsertReimbursement :one").
+			WithArgs("payroll", int64(42), 89.5, "Train to client site", receipt).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(int64(7), "payroll", int64(42), 89.5, "Train to client site", receipt, "pending", nil, "", nil, created, nil))
+
+		tx, _ := db.Begin()
+		reimbursement, err := repo.InsertReimbursementTx(tx, models.Reimbursement{
+			Tenant: "payroll", AccountID: 42, Amount: 89.5, Description: "Train to client site",
+			Receipt: models.Receipt{Merchant: "Rail Co", Date: "2026-03-12"},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, &models.Reimbursement{
+			ID: 7, Tenant: "payroll", AccountID: 42, Amount: 89.5, Description: "Train to client site",
+			Receipt: models.Receipt{Merchant: "Rail Co", Date: "2026-03-12"}, Status: models.ReimbursementPending, CreatedAt: created,
+		}, reimbursement)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("DecideReimbursementTx", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresReimbursementRepository(db)
+		decided := created.Add(time.Hour)
+		mock.ExpectBegin()
+		mock.ExpectQuery("-- name: DecideReimbursement :one").
+			WithArgs(int64(7), "approved", "alice", "", "tx-1").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(int64(7), "payroll", int64(42), 89.5, "Train to client site", []byte(`{}`), "approved", "alice", "", "tx-1", created, decided))
+
+		tx, _ := db.Begin()
+		reimbursement, err := repo.DecideReimbursementTx(tx, 7, models.ReimbursementApproved, models.ReimbursementDecision{DecidedBy: "alice"}, "tx-1")
+		assert.NoError(t, err)
+		assert.Equal(t, models.ReimbursementApproved, reimbursement.Status)
+		assert.Equal(t, "tx-1", reimbursement.TransactionID)
+		assert.Equal(t, &decided, reimbursement.DecidedAt)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("DecideReimbursementTx already decided", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresReimbursementRepository(db)
+		mock.ExpectBegin()
+		mock.ExpectQuery("-- name: DecideReimbursement :one").
+			WithArgs(int64(7), "rejected", "alice", "duplicate", nil).
+			WillReturnError(sql.ErrNoRows)
+
+		tx, _ := db.Begin()
+		_, err := repo.DecideReimbursementTx(tx, 7, models.ReimbursementRejected, models.ReimbursementDecision{DecidedBy: "alice", Note: "duplicate"}, "")
+		assert.ErrorIs(t, err, ErrAlreadyResolved)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("GetReimbursement not found", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresReimbursementRepository(db)
+		mock.ExpectQuery("-- name: GetReimbursement :one").
+			WithArgs(int64(7)).
+			WillReturnError(sql.ErrNoRows)
+
+		_, err := repo.GetReimbursement(7, "")
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPostgresSpendingTokenRepository(t *testing.T) {
+	created := time.Date(2026, 3, 14, 9, 30, 0, 0, time.UTC)
+	expires := created.Add(30 * 24 * time.Hour)
+	columns := []string{"id", "account_id", "spend_limit", "spent", "merchant_categories", "expires_at", "revoked_at", "created_at"}
+
+	t.Run("InsertSpendingToken", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresSpendingTokenRepository(db)
+		mock.ExpectQuery("-- name: InsertSpendingToken :one").
+			WithArgs("tok_1", int64(42), 250.0, pq.Array([]string{"5812"}), expires).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow("tok_1", int64(42), 250.0, 0.0, "{5812}", expires, nil, created))
+
+		token, err := repo.InsertSpendingToken(models.SpendingToken{ID: "tok_1", AccountID: 42, Limit: 250, MerchantCategories: []string{"5812"}, ExpiresAt: &expires})
+		assert.NoError(t, err)
+		assert.Equal(t, &models.SpendingToken{
+			ID: "tok_1", AccountID: 42, Limit: 250, MerchantCategories: []string{"5812"}, ExpiresAt: &expires, CreatedAt: created,
+		}, token)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("GetSpendingTokenForUpdateTx and AddSpendingTokenSpentTx", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresSpendingTokenRepository(db)
+		mock.ExpectBegin()
+		mock.ExpectQuery("-- name: GetSpendingTokenForUpdate :one").
+			WithArgs("tok_1").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow("tok_1", int64(42), 250.0, 60.0, "{}", nil, nil, created))
+		mock.ExpectQuery("-- name: AddSpendingTokenSpent :one").
+			WithArgs("tok_1", 25.0).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow("tok_1", int64(42), 250.0, 85.0, "{}", nil, nil, created))
+
+		tx, _ := db.Begin()
+		token, err := repo.GetSpendingTokenForUpdateTx(tx, "tok_1")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{}, token.MerchantCategories)
+		assert.Nil(t, token.ExpiresAt)
+		token, err = repo.AddSpendingTokenSpentTx(tx, "tok_1", 25)
+		assert.NoError(t, err)
+		assert.Equal(t, models.Amount(85), token.Spent)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("RevokeSpendingToken", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresSpendingTokenRepository(db)
+		revoked := created.Add(time.Hour)
+		mock.ExpectQuery("-- name: RevokeSpendingToken :one").
+			WithArgs("tok_1").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow("tok_1", int64(42), 250.0, 60.0, "{}", nil, revoked, created))
+
+		token, err := repo.RevokeSpendingToken("tok_1")
+		assert.NoError(t, err)
+		assert.Equal(t, &revoked, token.RevokedAt)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("GetSpendingToken not found", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresSpendingTokenRepository(db)
+		mock.ExpectQuery("-- name: GetSpendingToken :one").
+			WithArgs("tok_x").
+			WillReturnError(sql.ErrNoRows)
+
+		_, err := repo.GetSpendingToken("tok_x")
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPostgresCashbackRepository(t *testing.T) {
+	starts := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	ends := starts.AddDate(0, 1, 0)
+	columns := []string{"id", "name", "percentage", "cap", "funding_account_id", "eligible_account_ids", "starts_at", "ends_at", "paid", "created_at", "updated_at"}
+
+	t.Run("InsertCashbackCampaign", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresCashbackRepository(db)
+		mock.ExpectQuery("-- name: InsertCashbackCampaign :one").
+			WithArgs("Summer", 1.5, 10.0, int64(9), pq.Array([]int64{1, 2}), starts, ends).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(int64(3), "Summer", 1.5, 10.0, int64(9), "{1,2}", starts, ends, 0.0, starts, starts))
+
+		campaign, err := repo.InsertCashbackCampaign(models.CashbackCampaign{
+			Name: "Summer", Percentage: 1.5, Cap: 10, FundingAccountID: 9, EligibleAccountIDs: []int64{1, 2}, StartsAt: starts, EndsAt: ends,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, &models.CashbackCampaign{
+			ID: 3, Name: "Summer", Percentage: 1.5, Cap: 10, FundingAccountID: 9, EligibleAccountIDs: []int64{1, 2},
+			StartsAt: starts, EndsAt: ends, CreatedAt: starts, UpdatedAt: starts,
+		}, campaign)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ListActiveCashbackCampaigns", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresCashbackRepository(db)
+		at := starts.Add(time.Hour)
+		mock.ExpectQuery("-- name: ListActiveCashbackCampaigns :many").
+			WithArgs(at, int64(1)).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(int64(3), "Summer", 1.5, 10.0, int64(9), "{}", starts, ends, 2.25, starts, starts))
+
+		campaigns, err := repo.ListActiveCashbackCampaigns(1, at)
+		assert.NoError(t, err)
+		assert.Len(t, campaigns, 1)
+		assert.Equal(t, []int64{}, campaigns[0].EligibleAccountIDs)
+		assert.Equal(t, models.Amount(2.25), campaigns[0].Paid)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("AddCashbackCampaignPaidTx deleted campaign", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresCashbackRepository(db)
+		mock.ExpectBegin()
+		mock.ExpectExec("-- name: AddCashbackCampaignPaid :execrows").
+			WithArgs(int64(3), 2.25).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		tx, _ := db.Begin()
+		err := repo.AddCashbackCampaignPaidTx(tx, 3, 2.25)
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("UpdateCashbackCampaign not found", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresCashbackRepository(db)
+		mock.ExpectQuery("-- name: UpdateCashbackCampaign :one").
+			WithArgs(int64(4), "Summer", 1.5, 10.0, int64(9), pq.Array([]int64{}), starts, ends).
+			WillReturnError(sql.ErrNoRows)
+
+		_, err := repo.UpdateCashbackCampaign(models.CashbackCampaign{
+			ID: 4, Name: "Summer", Percentage: 1.5, Cap: 10, FundingAccountID: 9, EligibleAccountIDs: []int64{}, StartsAt: starts, EndsAt: ends,
+		})
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPostgresAccountRepository_CountAccounts(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewPostgresAccountRepository(db)
+	mock.ExpectQuery("-- name: CountTenantAccounts :one").
+		WithArgs("payroll").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(int64(42)))
+
+	n, err := repo.CountAccounts("payroll")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), n)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresPendingActionRepository(t *testing.T) {
+	created := time.Date(2026, 3, 14, 9, 30, 0, 0, time.UTC)
+	assigned := created.Add(time.Hour)
+	columns := []string{"id", "kind", "reference", "summary", "assignee", "assigned_at", "created_at", "resolved_at", "expired_at"}
+
+	t.Run("AddPendingAction", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresPendingActionRepository(db)
+		mock.ExpectQuery("-- name: AddPendingAction :one").
+			WithArgs("transfer_approval", "txn-1", "25000.00 from 1 to 2").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(int64(7), "transfer_approval", "txn-1", "25000.00 from 1 to 2", nil, nil, created, nil, nil))
+
+		action, err := repo.AddPendingAction(models.PendingActionTransferApproval, "txn-1", "25000.00 from 1 to 2")
+		assert.NoError(t, err)
+		assert.Equal(t, &models.PendingAction{ID: 7, Kind: models.PendingActionTransferApproval, Reference: "txn-1", Summary: "25000.00 from 1 to 2", CreatedAt: created}, action)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ResolvePendingAction not found", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresPendingActionRepository(db)
+		mock.ExpectExec("-- name: ResolvePendingAction :execrows").
+			WithArgs("dispute", "dsp-1").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := repo.ResolvePendingAction(models.PendingActionDispute, "dsp-1")
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ListPendingActions", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresPendingActionRepository(db)
+		mock.ExpectQuery("-- name: ListPendingActions :many").
+			WithArgs(created, int64(3), "dispute", nil, false, int32(2)).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(int64(4), "dispute", "dsp-1", "", "alice", assigned, created, nil, nil).
+				AddRow(int64(5), "dispute", "dsp-2", "", nil, nil, created, nil, nil))
+
+		actions, next, err := repo.ListPendingActions(models.PendingActionFilter{Kind: models.PendingActionDispute}, ChangeCursor{UpdatedAt: created, ID: 3}, 2)
+		assert.NoError(t, err)
+		assert.Len(t, actions, 2)
+		assert.Equal(t, "alice", actions[0].Assignee)
+		assert.Equal(t, &assigned, actions[0].AssignedAt)
+		assert.Nil(t, actions[1].AssignedAt)
+		assert.Equal(t, ChangeCursor{UpdatedAt: created, ID: 5}, next)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ClaimPendingAction", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresPendingActionRepository(db)
+		mock.ExpectQuery("-- name: ClaimPendingAction :one").
+			WithArgs("alice", int64(7)).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(int64(7), "dispute", "dsp-1", "", "alice", assigned, created, nil, nil))
+
+		action, err := repo.ClaimPendingAction(7, "alice")
+		assert.NoError(t, err)
+		assert.Equal(t, "alice", action.Assignee)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ClaimPendingAction held by someone else", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresPendingActionRepository(db)
+		mock.ExpectQuery("-- name: ClaimPendingAction :one").
+			WithArgs("bob", int64(7)).
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery("-- name: GetPendingAction :one").
+			WithArgs(int64(7)).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(int64(7), "dispute", "dsp-1", "", "alice", assigned, created, nil, nil))
+
+		_, err := repo.ClaimPendingAction(7, "bob")
+		assert.ErrorIs(t, err, ErrAlreadyClaimed)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ClaimPendingAction resolved", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresPendingActionRepository(db)
+		mock.ExpectQuery("-- name: ClaimPendingAction :one").
+			WithArgs("bob", int64(7)).
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery("-- name: GetPendingAction :one").
+			WithArgs(int64(7)).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(int64(7), "dispute", "dsp-1", "", nil, nil, created, assigned, nil))
+
+		_, err := repo.ClaimPendingAction(7, "bob")
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("AssignPendingAction release", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresPendingActionRepository(db)
+		mock.ExpectQuery("-- name: AssignPendingAction :one").
+			WithArgs(nil, int64(7)).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(int64(7), "dispute", "dsp-1", "", nil, nil, created, nil, nil))
+
+		action, err := repo.AssignPendingAction(7, "")
+		assert.NoError(t, err)
+		assert.Empty(t, action.Assignee)
+		assert.Nil(t, action.AssignedAt)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ExpirePendingActions", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresPendingActionRepository(db)
+		mock.ExpectExec("-- name: ExpirePendingActions :execrows").
+			WithArgs("transfer_approval", created).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+
+		n, err := repo.ExpirePendingActions(models.PendingActionTransferApproval, created)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), n)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPostgresSuspenseRepository(t *testing.T) {
+	created := time.Date(2026, 3, 14, 9, 30, 0, 0, time.UTC)
+	columns := []string{"id", "source_account_id", "intended_account_id", "amount", "reason", "reference", "transaction_id", "created_at", "reposted_to", "repost_transaction_id", "resolved_at"}
+
+	t.Run("InsertSuspenseItemTx", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresSuspenseRepository(db)
+		mock.ExpectBegin()
+		mock.ExpectQuery("-- name: InsertSuspenseItem :one").
+			WithArgs(int64(1), int64(2), 50.0, "account_not_found", "INV-1", "tx-1").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(int64(7), int64(1), int64(2), 50.0, "account_not_found", "INV-1", "tx-1", created, nil, nil, nil))
+
+		tx, _ := db.Begin()
+		item, err := repo.InsertSuspenseItemTx(tx, models.SuspenseItem{
+			SourceAccountID: 1, IntendedAccountID: 2, Amount: 50, Reason: models.SuspenseAccountNotFound, Reference: "INV-1", TransactionID: "tx-1",
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, &models.SuspenseItem{
+			ID: 7, SourceAccountID: 1, IntendedAccountID: 2, Amount: 50, Reason: models.SuspenseAccountNotFound, Reference: "INV-1", TransactionID: "tx-1", CreatedAt: created,
+		}, item)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ListSuspenseItems", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresSuspenseRepository(db)
+		mock.ExpectQuery("-- name: ListSuspenseItems :many").
+			WithArgs(time.Time{}, int64(0), int32(10)).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(int64(7), int64(1), int64(2), 50.0, "account_closed", "", "tx-1", created, nil, nil, nil))
+
+		items, next, err := repo.ListSuspenseItems(ChangeCursor{}, 10)
+		assert.NoError(t, err)
+		assert.Len(t, items, 1)
+		assert.Equal(t, models.SuspenseAccountClosed, items[0].Reason)
+		assert.Equal(t, ChangeCursor{UpdatedAt: created, ID: 7}, next)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ResolveSuspenseItemTx already resolved", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresSuspenseRepository(db)
+		mock.ExpectBegin()
+		mock.ExpectQuery("-- name: ResolveSuspenseItem :one").
+			WithArgs(int64(7), int64(3), "tx-2").
+			WillReturnError(sql.ErrNoRows)
+
+		tx, _ := db.Begin()
+		_, err := repo.ResolveSuspenseItemTx(tx, 7, 3, "tx-2")
+		assert.ErrorIs(t, err, ErrAlreadyResolved)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestInsertAdjustmentTx_ReasonCode(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewPostgresTransactionRepository(db)
+	mock.ExpectBegin()
+	mock.ExpectQuery("-- name: InsertAdjustment :one").
+		WithArgs(int64(1), 10.0, "drift in INC-12", "manual_adjustment_correction").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(5)))
+
+	tx, _ := db.Begin()
+	id, err := repo.InsertAdjustmentTx(tx, 1, 10, models.ReasonManualAdjustmentCorrection, "drift in INC-12")
+	assert.NoError(t, err)
+	assert.Equal(t, "5", id)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresTransactionAttemptRepository(t *testing.T) {
+	created := time.Date(2026, 3, 14, 9, 30, 0, 0, time.UTC)
+	columns := []string{"id", "source_account_id", "destination_account_id", "amount", "reason_code", "error", "api_key", "tenant", "created_at", "request_id"}
+
+	t.Run("InsertTransactionAttempt", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresTransactionAttemptRepository(db)
+		mock.ExpectExec("-- name: InsertTransactionAttempt :exec").
+			WithArgs(int64(1), int64(2), 50.0, "insufficient_funds", "insufficient funds in account 1", "key-1", "payroll", "req-1").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		err := repo.InsertTransactionAttempt(models.TransactionAttempt{
+			SourceAccountID: 1, DestinationAccountID: 2, Amount: 50, Code: models.ReasonInsufficientFunds,
+			Error: "insufficient funds in account 1", APIKey: "key-1", Tenant: "payroll", RequestID: "req-1",
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ListTransactionAttempts", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresTransactionAttemptRepository(db)
+		mock.ExpectQuery("-- name: ListTransactionAttempts :many").
+			WithArgs(time.Time{}, int64(0), nil, nil, "payroll", int64(2), created, nil, int32(2)).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(int64(4), int64(1), int64(2), 50.0, "insufficient_funds", "", "key-1", "payroll", created, "req-1").
+				AddRow(int64(5), int64(2), int64(3), 75.0, "destination_not_found", "", "key-2", "payroll", created, ""))
+
+		attempts, next, err := repo.ListTransactionAttempts(models.TransactionAttemptFilter{Tenant: "payroll", AccountID: 2, Since: created}, ChangeCursor{}, 2)
+		assert.NoError(t, err)
+		assert.Len(t, attempts, 2)
+		assert.Equal(t, models.ReasonDestinationNotFound, attempts[1].Code)
+		assert.Equal(t, ChangeCursor{UpdatedAt: created, ID: 5}, next)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ListTransactionAttemptsByRequest", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresTransactionAttemptRepository(db)
+		mock.ExpectQuery("-- name: ListTransactionAttemptsByRequest :many").
+			WithArgs("req-1").
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(int64(4), int64(1), int64(2), 50.0, "insufficient_funds", "", "key-1", "payroll", created, "req-1"))
+
+		attempts, err := repo.ListTransactionAttemptsByRequest("req-1")
+		assert.NoError(t, err)
+		assert.Len(t, attempts, 1)
+		assert.Equal(t, "req-1", attempts[0].RequestID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("TransactionAttemptStats", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresTransactionAttemptRepository(db)
+		mock.ExpectQuery("-- name: TransactionAttemptStats :many").
+			WithArgs("tenant", "insufficient_funds", nil, nil, nil, nil, nil).
+			WillReturnRows(sqlmock.NewRows([]string{"key", "attempts", "total_amount"}).
+				AddRow("payroll", int64(3), 150.0).
+				AddRow("treasury", int64(1), 20.0))
+
+		stats, err := repo.TransactionAttemptStats(models.GroupByTenant, models.TransactionAttemptFilter{Code: models.ReasonInsufficientFunds})
+		assert.NoError(t, err)
+		assert.Equal(t, []models.TransactionAttemptStat{
+			{Key: "payroll", Attempts: 3, TotalAmount: 150},
+			{Key: "treasury", Attempts: 1, TotalAmount: 20},
+		}, stats)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("GetTransactionAttemptReplay not replayed", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresTransactionAttemptRepository(db)
+		mock.ExpectQuery("-- name: GetTransactionAttemptReplay :one").
+			WithArgs(int64(4)).
+			WillReturnError(sql.ErrNoRows)
+
+		_, err := repo.GetTransactionAttemptReplay(4)
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("InsertTransactionAttemptReplayTx already replayed", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresTransactionAttemptRepository(db)
+		mock.ExpectBegin()
+		mock.ExpectExec("-- name: InsertTransactionAttemptReplay :execrows").
+			WithArgs(int64(4), "tx-2").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		tx, _ := db.Begin()
+		err := repo.InsertTransactionAttemptReplayTx(tx, 4, "tx-2")
+		assert.ErrorIs(t, err, ErrAlreadyReplayed)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPostgresOutboxRepository(t *testing.T) {
+	created := time.Date(2026, 3, 14, 9, 30, 0, 0, time.UTC)
+	columns := []string{"id", "aggregate_type", "aggregate_id", "event_type", "payload", "created_at", "schema_version"}
+
+	t.Run("InsertEventTx", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresOutboxRepository(db)
+		mock.ExpectBegin()
+		mock.ExpectQuery("-- name: InsertOutboxEvent :one").
+			WithArgs("account", "1", "transfer.completed", int32(1), json.RawMessage(`{"amount":50}`)).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(int64(7), "account", "1", "transfer.completed", []byte(`{"amount":50}`), created, int32(1)))
+
+		tx, _ := db.Begin()
+		id, err := repo.InsertEventTx(tx, models.Event{
+			Type: models.EventTransferCompleted, SchemaVersion: 1, AggregateType: models.AggregateAccount, AggregateID: "1", Payload: json.RawMessage(`{"amount":50}`),
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(7), id)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ListOutboxEvents", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresOutboxRepository(db)
+		mock.ExpectQuery("-- name: ListOutboxEvents :many").
+			WithArgs(int64(6), int32(2)).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(int64(7), "account", "1", "transfer.completed", []byte(`{}`), created, int32(1)).
+				AddRow(int64(8), "account", "2", "transfer.completed", []byte(`{}`), created, int32(2)))
+
+		events, err := repo.ListOutboxEvents(6, 2)
+		assert.NoError(t, err)
+		assert.Len(t, events, 2)
+		assert.Equal(t, "2", events[1].AggregateID)
+		assert.Equal(t, 2, events[1].SchemaVersion)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("GetOutboxRelay", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresOutboxRepository(db)
+		mock.ExpectQuery("-- name: GetOutboxRelay :one").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "last_event_id", "paused", "updated_at"}).AddRow(true, int64(7), true, created))
+		mock.ExpectQuery("-- name: GetLatestOutboxEventID :one").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(9)))
+
+		status, err := repo.GetOutboxRelay()
+		assert.NoError(t, err)
+		assert.Equal(t, &models.OutboxRelayStatus{LastEventID: 7, LatestEventID: 9, Paused: true, UpdatedAt: created}, status)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("AdvanceOutboxRelay", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresOutboxRepository(db)
+		mock.ExpectExec("-- name: AdvanceOutboxRelay :execrows").
+			WithArgs(int64(9), int64(7)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		moved, err := repo.AdvanceOutboxRelay(7, 9)
+		assert.NoError(t, err)
+		assert.False(t, moved, "the position is left alone once it has moved")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPostgresWebhookRepository(t *testing.T) {
+	created := time.Date(2026, 3, 14, 9, 30, 0, 0, time.UTC)
+	columns := []string{"id", "tenant", "url", "event_types", "verified_at", "created_at", "account_id"}
+
+	t.Run("InsertWebhook", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresWebhookRepository(db)
+		mock.ExpectQuery("-- name: InsertWebhook :one").
+			WithArgs("payroll", "https://example.com/hooks", pq.Array([]string{"transfer.completed"}), created, nil).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(int64(7), "payroll", "https://example.com/hooks", "{transfer.completed}", created, created, nil))
+
+		webhook, err := repo.InsertWebhook(models.Webhook{Tenant: "payroll", URL: "https://example.com/hooks", EventTypes: []string{"transfer.completed"}, VerifiedAt: created})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(7), webhook.ID)
+		assert.Equal(t, []string{"transfer.completed"}, webhook.EventTypes)
+		assert.Nil(t, webhook.AccountID, "a tenant-wide webhook")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("InsertWebhook_Account", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresWebhookRepository(db)
+		accountID := int64(42)
+		mock.ExpectQuery("-- name: InsertWebhook :one").
+			WithArgs("payroll", "https://example.com/hooks", pq.Array([]string{}), created, int64(42)).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(int64(8), "payroll", "https://example.com/hooks", "{}", created, created, int64(42)))
+
+		webhook, err := repo.InsertWebhook(models.Webhook{Tenant: "payroll", URL: "https://example.com/hooks", AccountID: &accountID, EventTypes: []string{}, VerifiedAt: created})
+		assert.NoError(t, err)
+		assert.Equal(t, &accountID, webhook.AccountID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("GetAccountOwner", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresWebhookRepository(db)
+		mock.ExpectQuery("-- name: GetAccountOwner :one").
+			WithArgs(int64(42)).
+			WillReturnRows(sqlmock.NewRows([]string{"tenant", "owner_key"}).AddRow("payroll", "key_alice"))
+		mock.ExpectQuery("-- name: GetAccountOwner :one").
+			WithArgs(int64(404)).
+			WillReturnError(sql.ErrNoRows)
+
+		tenant, owner, err := repo.GetAccountOwner(42)
+		assert.NoError(t, err)
+		assert.Equal(t, "payroll", tenant)
+		assert.Equal(t, "key_alice", owner)
+		_, _, err = repo.GetAccountOwner(404)
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("GetWebhook_OtherTenant", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresWebhookRepository(db)
+		mock.ExpectQuery("-- name: GetWebhook :one").
+			WithArgs(int64(7), "treasury").
+			WillReturnError(sql.ErrNoRows)
+
+		_, err := repo.GetWebhook(7, "treasury")
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ListWebhooks", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresWebhookRepository(db)
+		mock.ExpectQuery("-- name: ListWebhooks :many").
+			WithArgs("payroll").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(int64(7), "payroll", "https://example.com/hooks", "{}", created, created, nil))
+
+		webhooks, err := repo.ListWebhooks("payroll")
+		assert.NoError(t, err)
+		assert.Equal(t, []models.Webhook{{ID: 7, Tenant: "payroll", URL: "https://example.com/hooks", EventTypes: []string{}, VerifiedAt: created, CreatedAt: created}}, webhooks)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("DeleteWebhook_NotFound", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresWebhookRepository(db)
+		mock.ExpectExec("-- name: DeleteWebhook :execrows").
+			WithArgs(int64(7), "payroll").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		assert.ErrorIs(t, repo.DeleteWebhook(7, "payroll"), ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPostgresNotificationRepository(t *testing.T) {
+	day := time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC)
+
+	t.Run("GetNotificationPreference_Default", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresNotificationRepository(db)
+		mock.ExpectQuery("-- name: GetNotificationPreference :one").
+			WithArgs(int64(42)).
+			WillReturnError(sql.ErrNoRows)
+
+		pref, err := repo.GetNotificationPreference(42)
+		assert.NoError(t, err)
+		assert.Equal(t, &models.NotificationPreference{AccountID: 42, Mode: models.NotificationImmediate, Timezone: "UTC"}, pref)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("SetNotificationPreference", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresNotificationRepository(db)
+		mock.ExpectQuery("-- name: SetNotificationPreference :one").
+			WithArgs(int64(42), "daily_digest", "Asia/Tokyo").
+			WillReturnRows(sqlmock.NewRows([]string{"account_id", "mode", "updated_at", "timezone", "digest_since"}).
+				AddRow(int64(42), "daily_digest", day, "Asia/Tokyo", day))
+
+		pref, err := repo.SetNotificationPreference(42, models.NotificationDailyDigest, "Asia/Tokyo")
+		assert.NoError(t, err)
+		assert.Equal(t, models.NotificationDailyDigest, pref.Mode)
+		assert.Equal(t, "Asia/Tokyo", pref.Timezone)
+		assert.Equal(t, &day, pref.UpdatedAt)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ListPendingDigests", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresNotificationRepository(db)
+		now := day.AddDate(0, 0, 3)
+		mock.ExpectQuery("-- name: ListPendingDigests :many").
+			WithArgs(now).
+			WillReturnRows(sqlmock.NewRows([]string{"account_id", "tenant", "day", "incoming", "incoming_count", "outgoing", "outgoing_count"}).
+				AddRow(int64(42), "payroll", day, 20.0, int64(1), 45.5, int64(2)).
+				AddRow(int64(42), "payroll", day.AddDate(0, 0, 1), 5.0, int64(1), 0.0, int64(0)))
+
+		digests, err := repo.ListPendingDigests(now)
+		assert.NoError(t, err)
+		assert.Equal(t, []models.DailyDigest{
+			{AccountID: 42, Tenant: "payroll", Date: "2026-03-14", Incoming: 20, IncomingCount: 1, Outgoing: 45.5, OutgoingCount: 2},
+			{AccountID: 42, Tenant: "payroll", Date: "2026-03-15", Incoming: 5, IncomingCount: 1},
+		}, digests, "every closed day not sent yet")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("InsertDigestTx_AlreadyDigested", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresNotificationRepository(db)
+		mock.ExpectBegin()
+		mock.ExpectExec("-- name: InsertNotificationDigest :execrows").
+			WithArgs(int64(42), day, int64(3)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		tx, _ := db.Begin()
+		err := repo.InsertDigestTx(tx, models.DailyDigest{AccountID: 42, Date: "2026-03-14", IncomingCount: 1, OutgoingCount: 2})
+		assert.ErrorIs(t, err, ErrAlreadyDigested, "another server sent the digest")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPostgresBackfillRepository(t *testing.T) {
+	started := time.Date(2026, 3, 14, 9, 30, 0, 0, time.UTC)
+	columns := []string{"name", "last_id", "rows_updated", "started_at", "updated_at", "completed_at"}
+
+	t.Run("StartBackfill", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresBackfillRepository(db)
+		mock.ExpectQuery("-- name: StartBackfill :one").
+			WithArgs("transactions.amount_minor").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow("transactions.amount_minor", int64(40), int64(38), started, started, started))
+
+		progress, err := repo.StartBackfill("transactions.amount_minor")
+		assert.NoError(t, err)
+		assert.Equal(t, &models.Backfill{
+			Name: "transactions.amount_minor", LastID: 40, RowsUpdated: 38, StartedAt: started, UpdatedAt: started, CompletedAt: &started,
+		}, progress)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("GetBackfill", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresBackfillRepository(db)
+		mock.ExpectQuery("-- name: GetBackfill :one").
+			WithArgs("missing").
+			WillReturnError(sql.ErrNoRows)
+
+		_, err := repo.GetBackfill("missing")
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("AdvanceBackfillTx", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresBackfillRepository(db)
+		mock.ExpectBegin()
+		mock.ExpectExec("-- name: AdvanceBackfill :execrows").
+			WithArgs(int64(80), int64(35), "transactions.amount_minor", int64(40)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		tx, _ := db.Begin()
+		moved, err := repo.AdvanceBackfillTx(tx, "transactions.amount_minor", 40, 80, 35)
+		assert.NoError(t, err)
+		assert.False(t, moved, "the progress is left alone once another run has moved it")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ResetBackfill", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresBackfillRepository(db)
+		mock.ExpectExec("-- name: ResetBackfill :execrows").
+			WithArgs("missing").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		assert.ErrorIs(t, repo.ResetBackfill("missing"), ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("BackfillTransactionAmountMinorTx", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresBackfillRepository(db)
+		mock.ExpectBegin()
+		mock.ExpectQuery("-- name: BackfillTransactionAmountMinor :one").
+			WithArgs(int32(40), int32(1000), int64(100)).
+			WillReturnRows(sqlmock.NewRows([]string{"last_id", "updated"}).AddRow(int64(1040), int64(990)))
+
+		tx, _ := db.Begin()
+		last, updated, err := repo.BackfillTransactionAmountMinorTx(tx, 40, 1000, 100)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1040), last)
+		assert.Equal(t, int64(990), updated)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPostgresRegionRepository(t *testing.T) {
+	promoted := time.Date(2026, 3, 14, 9, 30, 0, 0, time.UTC)
+
+	t.Run("GetRegionFence", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresRegionRepository(db)
+		mock.ExpectQuery("-- name: GetRegionFence :one").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "active_region", "epoch", "updated_at"}).AddRow(true, "eu-west-1", int64(3), promoted))
+
+		fence, err := repo.GetRegionFence()
+		assert.NoError(t, err)
+		assert.Equal(t, &models.RegionFence{ActiveRegion: "eu-west-1", Epoch: 3, UpdatedAt: promoted}, fence)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("PromoteRegion", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresRegionRepository(db)
+		mock.ExpectExec("-- name: PromoteRegion :execrows").
+			WithArgs("us-east-1", int64(3)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("-- name: PromoteRegion :execrows").
+			WithArgs("us-east-1", int64(3)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		moved, err := repo.PromoteRegion("us-east-1", 3)
+		assert.NoError(t, err)
+		assert.True(t, moved)
+		moved, err = repo.PromoteRegion("us-east-1", 3)
+		assert.NoError(t, err)
+		assert.False(t, moved)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestIsRegionFenced(t *testing.T) {
+	assert.True(t, IsRegionFenced(&pq.Error{Code: "25006", Message: "region eu-west-1 is passive, the active region is us-east-1"}))
+	assert.True(t, IsRegionFenced(fmt.Errorf("debit: %w", &pq.Error{Code: "25006"})))
+	assert.False(t, IsRegionFenced(&pq.Error{Code: "40001"}))
+	assert.False(t, IsRegionFenced(nil))
+}
+
+func TestIsLivemodeMismatch(t *testing.T) {
+	assert.True(t, IsLivemodeMismatch(&pq.Error{Code: "23514", Constraint: "transactions_livemode"}))
+	assert.True(t, IsLivemodeMismatch(fmt.Errorf("log transfer: %w", &pq.Error{Code: "23514", Constraint: "transactions_livemode"})))
+	assert.False(t, IsLivemodeMismatch(&pq.Error{Code: "23514", Constraint: "accounts_balance_check"}))
+	assert.False(t, IsLivemodeMismatch(nil))
+}
+
+func TestPostgresSandboxRepository_PurgeTestData(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewPostgresSandboxRepository(db)
+	mock.ExpectQuery("-- name: PurgeTestData :one").
+		WillReturnRows(sqlmock.NewRows([]string{"accounts", "transactions"}).AddRow(3, 12))
+
+	purge, err := repo.PurgeTestData()
+	assert.NoError(t, err)
+	assert.Equal(t, &models.SandboxPurge{Accounts: 3, Transactions: 12}, purge)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresTimelineRepository_ListAccountTimeline(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewPostgresTimelineRepository(db)
+	opened := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	cursor := ChangeCursor{UpdatedAt: opened.Add(-time.Hour), ID: 0}
+	columns := []string{"occurred_at", "seq", "event_type", "reference", "counterparty_account_id", "amount", "detail", "note"}
+	mock.ExpectQuery("-- name: ListAccountTimeline :many").
+		WithArgs(int64(1), cursor.UpdatedAt, int64(0), int32(5)).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(opened, int64(0), "account.opened", "", nil, 100.0, "", "").
+			AddRow(opened.Add(time.Minute), int64(57), "transaction.debit", "7", int64(2), -30.0, "transfer", "").
+			AddRow(opened.Add(2*time.Minute), int64(27), "adjustment", "3", nil, 0.5, "reconciliation_correction", "drift").
+			AddRow(opened.Add(3*time.Minute), int64(13), "token.issued", "tok_1", nil, 50.0, "", "").
+			AddRow(opened.Add(4*time.Minute), int64(36), "account.closed", "", nil, nil, "", ""))
+
+	events, next, err := repo.ListAccountTimeline(context.Background(), 1, cursor, 5)
+	assert.NoError(t, err)
+	amount := func(v float64) *models.Amount { a := models.Amount(v); return &a }
+	assert.Equal(t, []models.AccountEvent{
+		{Type: models.AccountEventOpened, OccurredAt: opened, Amount: amount(100)},
+		{Type: models.AccountEventDebit, OccurredAt: opened.Add(time.Minute), Amount: amount(-30), TransactionID: "7", Kind: models.TransactionTransfer, CounterpartyAccountID: 2},
+		{Type: models.AccountEventAdjustment, OccurredAt: opened.Add(2 * time.Minute), Amount: amount(0.5), AdjustmentID: "3", ReasonCode: models.ReasonReconciliationCorrection, Reason: "drift"},
+		{Type: models.AccountEventTokenIssued, OccurredAt: opened.Add(3 * time.Minute), TokenID: "tok_1", Limit: amount(50)},
+		{Type: models.AccountEventClosed, OccurredAt: opened.Add(4 * time.Minute)},
+	}, events)
+	assert.Equal(t, ChangeCursor{UpdatedAt: opened.Add(4 * time.Minute), ID: 36}, next)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresImpersonationRepository(t *testing.T) {
+	created := time.Date(2026, 3, 14, 9, 30, 0, 0, time.UTC)
+
+	t.Run("InsertImpersonation", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresImpersonationRepository(db)
+		mock.ExpectExec("-- name: InsertImpersonation :exec").
+			WithArgs("alice", "key-1", "payroll", "GET", "/accounts/1", "ticket 4812", "req-1").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		err := repo.InsertImpersonation(models.Impersonation{
+			Operator: "alice", APIKey: "key-1", Tenant: "payroll", Method: "GET", Path: "/accounts/1",
+			Reason: "ticket 4812", RequestID: "req-1",
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ListImpersonations", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresImpersonationRepository(db)
+		mock.ExpectQuery("-- name: ListImpersonations :many").
+			WithArgs(time.Time{}, int64(0), "alice", nil, nil, created, nil, int32(2)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "operator", "api_key", "tenant", "method", "path", "reason", "request_id", "created_at"}).
+				AddRow(int64(3), "alice", "key-1", "payroll", "GET", "/accounts/1", "", "req-1", created).
+				AddRow(int64(4), "alice", "key-2", "default", "GET", "/transactions/9", "ticket 4812", "", created))
+
+		impersonations, next, err := repo.ListImpersonations(models.ImpersonationFilter{Operator: "alice", Since: created}, ChangeCursor{}, 2)
+		assert.NoError(t, err)
+		assert.Len(t, impersonations, 2)
+		assert.Equal(t, "key-2", impersonations[1].APIKey)
+		assert.Equal(t, "ticket 4812", impersonations[1].Reason)
+		assert.Equal(t, ChangeCursor{UpdatedAt: created, ID: 4}, next)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPostgresTransferFreezeRepository(t *testing.T) {
+	now := time.Date(2026, 3, 14, 9, 30, 0, 0, time.UTC)
+	columns := []string{"id", "tenant", "reason", "frozen_by", "created_at", "expires_at", "lifted_at", "lifted_by"}
+
+	t.Run("InsertTransferFreeze", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresTransferFreezeRepository(db)
+		mock.ExpectQuery("-- name: InsertTransferFreeze :one").
+			WithArgs("*", "suspected compromise", "alice", now.Add(time.Hour)).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(int64(1), "*", "suspected compromise", "alice", now, now.Add(time.Hour), nil, ""))
+
+		freeze, err := repo.InsertTransferFreeze(models.TransferFreeze{Tenant: "*", Reason: "suspected compromise", FrozenBy: "alice", ExpiresAt: now.Add(time.Hour)})
+		assert.NoError(t, err)
+		assert.Equal(t, &models.TransferFreeze{ID: 1, Tenant: "*", Reason: "suspected compromise", FrozenBy: "alice", CreatedAt: now, ExpiresAt: now.Add(time.Hour)}, freeze)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("LiftTransferFreezes", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresTransferFreezeRepository(db)
+		mock.ExpectQuery("-- name: LiftTransferFreezes :many").
+			WithArgs("bob", "acme", now).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(int64(2), "acme", "leaked key", "alice", now, now.Add(time.Hour), now, "bob"))
+
+		lifted, err := repo.LiftTransferFreezes("acme", "bob", now)
+		assert.NoError(t, err)
+		assert.Len(t, lifted, 1)
+		assert.Equal(t, &now, lifted[0].LiftedAt)
+		assert.Equal(t, "bob", lifted[0].LiftedBy)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("GetAccountTenant not found", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresTransferFreezeRepository(db)
+		mock.ExpectQuery("-- name: GetAccountTenant :one").
+			WithArgs(int64(9)).
+			WillReturnError(sql.ErrNoRows)
+
+		_, err := repo.GetAccountTenant(9)
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPostgresTransferIntentRepository(t *testing.T) {
+	now := time.Date(2026, 3, 14, 9, 30, 0, 0, time.UTC)
+	columns := []string{"id", "kind", "account_id", "transaction_id", "amount", "created_at", "completed_at"}
+
+	t.Run("CompleteTransferIntent already completed", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresTransferIntentRepository(db)
+		mock.ExpectExec("-- name: CompleteTransferIntent :execrows").
+			WithArgs(int64(11), now).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := repo.CompleteTransferIntent(11, now)
+		assert.ErrorIs(t, err, ErrAlreadyCompleted)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("InsertTransferIntentCreditTx already paid", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresTransferIntentRepository(db)
+		mock.ExpectBegin()
+		mock.ExpectExec("-- name: InsertTransferIntentCredit :execrows").
+			WithArgs(int64(12), int64(1), "9").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		tx, err := db.Begin()
+		assert.NoError(t, err)
+
+		err = repo.InsertTransferIntentCreditTx(tx, 12, 1, "9")
+		assert.ErrorIs(t, err, ErrAlreadyCompleted)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ListPendingTransferIntents", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresTransferIntentRepository(db)
+		mock.ExpectQuery("-- name: ListPendingTransferIntents :many").
+			WithArgs(now, int64(10), int32(100)).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(int64(11), "top_up", int64(1), "7", 500.0, now.Add(-time.Hour), nil))
+
+		intents, err := repo.ListPendingTransferIntents(now, 10, 100)
+		assert.NoError(t, err)
+		assert.Equal(t, []models.TransferIntent{
+			{ID: 11, Kind: models.TransferIntentTopUp, AccountID: 1, TransactionID: "7", Amount: 500, CreatedAt: now.Add(-time.Hour)},
+		}, intents)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPostgresForwardedTransferRepository(t *testing.T) {
+	now := time.Date(2026, 3, 14, 9, 30, 0, 0, time.UTC)
+	acceptedAt := now.Add(-time.Minute)
+	columns := []string{"forward_id", "status", "transaction_id", "code", "error", "accepted_at", "replayed_at", "api_key", "idempotency_key"}
+
+	t.Run("InsertForwardedTransferTx already replayed", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresForwardedTransferRepository(db)
+		mock.ExpectBegin()
+		mock.ExpectExec("-- name: InsertForwardedTransfer :execrows").
+			WithArgs("01J9", "completed", "7", "", "", acceptedAt, now, "key_1", "retry-1").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		tx, err := db.Begin()
+		assert.NoError(t, err)
+
+		err = repo.InsertForwardedTransferTx(tx, models.ForwardedTransferStatus{
+			ID: "01J9", Status: models.ForwardCompleted, AcceptedAt: acceptedAt, ReplayedAt: &now, TransactionID: "7",
+			APIKey: "key_1", IdempotencyKey: "retry-1",
+		})
+		assert.ErrorIs(t, err, ErrAlreadyReplayed)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("GetForwardedTransfer", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresForwardedTransferRepository(db)
+		mock.ExpectQuery("-- name: GetForwardedTransfer :one").
+			WithArgs("01J9").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow("01J9", "rejected", nil, "insufficient_funds", "insufficient balance", acceptedAt, now, "key_1", nil))
+		mock.ExpectQuery("-- name: GetForwardedTransfer :one").
+			WithArgs("01JA").
+			WillReturnRows(sqlmock.NewRows(columns))
+
+		status, err := repo.GetForwardedTransfer("01J9")
+		assert.NoError(t, err)
+		assert.Equal(t, &models.ForwardedTransferStatus{
+			ID: "01J9", Status: models.ForwardRejected, AcceptedAt: acceptedAt, ReplayedAt: &now,
+			Code: models.ReasonInsufficientFunds, Error: "insufficient balance", APIKey: "key_1",
+		}, status)
+
+		_, err = repo.GetForwardedTransfer("01JA")
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("FindForwardedTransfer", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresForwardedTransferRepository(db)
+		mock.ExpectQuery("-- name: FindForwardedTransfer :one").
+			WithArgs("key_1", "retry-1").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow("01J9", "completed", "7", "", "", acceptedAt, now, "key_1", "retry-1"))
+		mock.ExpectQuery("-- name: FindForwardedTransfer :one").
+			WithArgs("key_1", "retry-2").
+			WillReturnRows(sqlmock.NewRows(columns))
+
+		status, err := repo.FindForwardedTransfer("key_1", "retry-1")
+		assert.NoError(t, err)
+		assert.Equal(t, "7", status.TransactionID)
+		assert.Equal(t, "retry-1", status.IdempotencyKey)
+
+		_, err = repo.FindForwardedTransfer("key_1", "retry-2")
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPostgresAmountBoundsRepository(t *testing.T) {
+	now := time.Date(2026, 3, 14, 9, 30, 0, 0, time.UTC)
+	columns := []string{"tenant", "min_amount", "max_amount", "updated_at"}
+
+	t.Run("SetAmountBounds", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresAmountBoundsRepository(db)
+		mock.ExpectQuery("-- name: SetAmountBounds :one").
+			WithArgs("*", 0.01, 1000000.0).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow("*", 0.01, 1000000.0, now))
+
+		bounds, err := repo.SetAmountBounds(models.AmountBounds{Tenant: "*", MinAmount: 0.01, MaxAmount: 1000000})
+		assert.NoError(t, err)
+		assert.Equal(t, &models.AmountBounds{Tenant: "*", MinAmount: 0.01, MaxAmount: 1000000, UpdatedAt: now}, bounds)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ListAmountBounds", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresAmountBoundsRepository(db)
+		mock.ExpectQuery("-- name: ListAmountBounds :many").
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow("*", 0.01, 1000000.0, now).
+				AddRow("payroll", 0.0, 50000.0, now))
+
+		bounds, err := repo.ListAmountBounds()
+		assert.NoError(t, err)
+		assert.Len(t, bounds, 2)
+		assert.Equal(t, models.Amount(50000), bounds[1].MaxAmount)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("DeleteAmountBounds not found", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresAmountBoundsRepository(db)
+		mock.ExpectExec("-- name: DeleteAmountBounds :execrows").
+			WithArgs("billing").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := repo.DeleteAmountBounds("billing")
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPostgresTreasuryRepository(t *testing.T) {
+	t.Run("BalancesByType", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresTreasuryRepository(db)
+		mock.ExpectQuery("-- name: TreasuryBalancesByType :many").
+			WithArgs(int64(900), int64(901)).
+			WillReturnRows(sqlmock.NewRows([]string{"account_type", "accounts", "balance"}).
+				AddRow("customer", int64(12), 5000.0).
+				AddRow("suspense", int64(1), 30.0))
+
+		positions, err := repo.BalancesByType(context.Background(), 900, 901)
+		assert.NoError(t, err)
+		currency := models.CurrentCurrency().Code
+		assert.Equal(t, []models.TreasuryPosition{
+			{Currency: currency, AccountType: models.AccountTypeCustomer, Accounts: 12, Balance: 5000},
+			{Currency: currency, AccountType: models.AccountTypeSuspense, Accounts: 1, Balance: 30},
+		}, positions)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("DailyFlows", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresTreasuryRepository(db)
+		since := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+		mock.ExpectQuery("-- name: TreasuryDailyFlows :many").
+			WithArgs(int64(0), int64(0), "UTC", since).
+			WillReturnRows(sqlmock.NewRows([]string{"day", "account_type", "inflow", "outflow"}).
+				AddRow(since, "customer", 120.0, 20.5))
+
+		flows, err := repo.DailyFlows(context.Background(), 0, 0, since, time.UTC)
+		assert.NoError(t, err)
+		assert.Equal(t, []models.DailyFlow{
+			{Date: "2026-06-01", AccountType: models.AccountTypeCustomer, Inflow: 120, Outflow: 20.5, Net: 99.5},
+		}, flows)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("SuspenseFloat", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresTreasuryRepository(db)
+		mock.ExpectQuery("-- name: TreasurySuspenseFloat :one").
+			WillReturnRows(sqlmock.NewRows([]string{"items", "amount"}).AddRow(int64(2), 45.0))
+
+		float, err := repo.SuspenseFloat(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, &models.TreasuryFloat{AccountType: models.AccountTypeSuspense, Outstanding: 45, OutstandingItems: 2}, float)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPostgresRevaluationRepository(t *testing.T) {
+	date := time.Date(2026, 6, 14, 0, 0, 0, 0, time.UTC)
+	cutoff := date.AddDate(0, 0, 1)
+	columns := []string{"id", "rate_date", "currency", "base_currency", "rate", "previous_id", "previous_rate", "balance", "base_value", "gain", "loss", "gain_account_id", "loss_account_id", "created_at", "rounding_mode"}
+
+	t.Run("RevalueTx", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresRevaluationRepository(db)
+		mock.ExpectBegin()
+		mock.ExpectQuery("-- name: InsertRevaluation :one").
+			WithArgs(date, "USD", "EUR", 0.92, int64(6), 0.91, int64(801), int64(802), "half_even").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(int64(7), date, "USD", "EUR", 0.92, int64(6), 0.91, 0.0, 0.0, 0.0, 0.0, int64(801), int64(802), cutoff, "half_even"))
+		mock.ExpectExec("-- name: InsertRevaluationEntries :execrows").
+			WithArgs(int64(7), 0.92, int32(2), "half_even", cutoff, int64(6)).
+			WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectExec("-- name: InsertRevaluationOffsets :exec").
+			WithArgs(int64(7)).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectQuery("-- name: FinishRevaluation :one").
+			WithArgs(int64(7)).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(int64(7), date, "USD", "EUR", 0.92, int64(6), 0.91, 1000.0, 920.0, 2.5, 12.5, int64(801), int64(802), cutoff, "half_even"))
+
+		tx, _ := db.Begin()
+		rv, err := repo.RevalueTx(tx, models.Revaluation{Date: "2026-06-14", Currency: "USD", BaseCurrency: "EUR", Rate: 0.92, GainAccountID: 801, LossAccountID: 802, RoundingMode: money.HalfEven},
+			&models.Revaluation{ID: 6, Rate: 0.91}, cutoff, 2)
+		assert.NoError(t, err)
+		previousRate := 0.91
+		assert.Equal(t, &models.Revaluation{
+			ID: 7, Date: "2026-06-14", Currency: "USD", BaseCurrency: "EUR", Rate: 0.92, PreviousRate: &previousRate,
+			Balance: 1000, BaseValue: 920, Gain: 2.5, Loss: 12.5, GainAccountID: 801, LossAccountID: 802, RoundingMode: money.HalfEven, CreatedAt: cutoff,
+		}, rv)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("RevalueTx already revalued", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresRevaluationRepository(db)
+		mock.ExpectBegin()
+		mock.ExpectQuery("-- name: InsertRevaluation :one").
+			WithArgs(date, "USD", "EUR", 0.92, nil, nil, int64(801), int64(802), "half_even").
+			WillReturnError(&pq.Error{Code: "23505", Message: "duplicate key value violates unique constraint"})
+
+		tx, _ := db.Begin()
+		_, err := repo.RevalueTx(tx, models.Revaluation{Date: "2026-06-14", Currency: "USD", BaseCurrency: "EUR", Rate: 0.92, GainAccountID: 801, LossAccountID: 802, RoundingMode: money.HalfEven}, nil, cutoff, 2)
+		assert.ErrorIs(t, err, ErrAlreadyRevalued)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("GetRevaluation", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresRevaluationRepository(db)
+		mock.ExpectQuery("-- name: GetRevaluation :one").
+			WithArgs(int64(7)).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(int64(7), date, "USD", "EUR", 0.92, nil, nil, 1000.0, 920.0, 0.0, 0.0, int64(801), int64(802), cutoff, "half_even"))
+		mock.ExpectQuery("-- name: RevaluationPositions :many").
+			WithArgs(int64(900), int64(0), int64(7)).
+			WillReturnRows(sqlmock.NewRows([]string{"account_type", "accounts", "balance", "base_value", "gain_loss"}).
+				AddRow("customer", int64(3), 1000.0, 920.0, 0.0))
+
+		rv, err := repo.GetRevaluation(context.Background(), 7, 900, 0)
+		assert.NoError(t, err)
+		assert.Nil(t, rv.PreviousRate)
+		assert.Equal(t, []models.RevaluationPosition{{AccountType: models.AccountTypeCustomer, Accounts: 3, Balance: 1000, BaseValue: 920}}, rv.Positions)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("GetFXRate not found", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresRevaluationRepository(db)
+		mock.ExpectQuery("-- name: GetFXRate :one").
+			WithArgs("USD", "EUR", date).
+			WillReturnError(sql.ErrNoRows)
+
+		_, err := repo.GetFXRate("USD", "EUR", date)
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPostgresSecurityEventRepository(t *testing.T) {
+	now := time.Date(2026, 3, 14, 9, 30, 0, 0, time.UTC)
+
+	t.Run("InsertSecurityEvent", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresSecurityEventRepository(db)
+		mock.ExpectQuery("-- name: InsertSecurityEvent :one").
+			WithArgs(models.SecurityAuthFailure, "key-1", "", "192.0.2.1", "req-1", "POST /transactions").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(int64(7), now))
+
+		e, err := repo.InsertSecurityEvent(models.SecurityEvent{
+			Type:      models.SecurityAuthFailure,
+			Actor:     "key-1",
+			Address:   "192.0.2.1",
+			RequestID: "req-1",
+			Detail:    "POST /transactions",
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(7), e.ID)
+		assert.Equal(t, now, e.CreatedAt)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ListSecurityEvents", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresSecurityEventRepository(db)
+		mock.ExpectQuery("-- name: ListSecurityEvents :many").
+			WithArgs(int64(6), 11).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "type", "actor", "tenant", "address", "request_id", "detail", "created_at"}).
+				AddRow(int64(7), models.SecurityAuthLockout, "address 192.0.2.1", "", "", "", "for 1m0s after 10 consecutive failures (lockout 1)", now))
+
+		events, err := repo.ListSecurityEvents(6, 11)
+		assert.NoError(t, err)
+		assert.Len(t, events, 1)
+		assert.Equal(t, "address 192.0.2.1", events[0].Actor)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRowLevelSecurity(t *testing.T) {
+	created := time.Date(2026, 3, 14, 9, 30, 0, 0, time.UTC)
+
+	t.Run("ListWebhooks", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresWebhookRepository(db, WithRowLevelSecurity())
+		mock.ExpectBegin()
+		mock.ExpectExec("-- name: SetTenant :exec").WithArgs("payroll").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("-- name: ListWebhooks :many").
+			WithArgs("payroll").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "tenant", "url", "event_types", "verified_at", "created_at", "account_id"}).
+				AddRow(int64(7), "payroll", "https://example.com/hooks", "{}", created, created, nil))
+		mock.ExpectCommit()
+
+		webhooks, err := repo.ListWebhooks("payroll")
+		assert.NoError(t, err)
+		assert.Len(t, webhooks, 1)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("GetReimbursement of another tenant", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresReimbursementRepository(db, WithRowLevelSecurity())
+		// The policy hides the row; the query does not filter by tenant itself.
+		mock.ExpectBegin()
+		mock.ExpectExec("-- name: SetTenant :exec").WithArgs("treasury").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("-- name: GetReimbursement :one").WithArgs(int64(7)).WillReturnError(sql.ErrNoRows)
+		mock.ExpectRollback()
+
+		_, err := repo.GetReimbursement(7, "treasury")
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("GetReimbursement of any tenant", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := NewPostgresReimbursementRepository(db, WithRowLevelSecurity())
+		mock.ExpectQuery("-- name: GetReimbursement :one").WithArgs(int64(7)).WillReturnError(sql.ErrNoRows)
+
+		_, err := repo.GetReimbursement(7, "")
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPostgresIngestRepository(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewPostgresIngestRepository()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("CREATE TEMP TABLE ingest_transfers").WillReturnResult(sqlmock.NewResult(0, 0))
+	copyIn := mock.ExpectPrepare(`COPY "ingest_transfers"`).WillBeClosed()
+	copyIn.ExpectExec().WithArgs(0, int64(1), int64(2), 10.5, int64(1050), "ref-1").WillReturnResult(sqlmock.NewResult(0, 1))
+	copyIn.ExpectExec().WithArgs(1, int64(1), int64(3), 2.0, int64(200), nil).WillReturnResult(sqlmock.NewResult(0, 1))
+	copyIn.ExpectExec().WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("DELETE FROM ingest_transfers").WillReturnRows(sqlmock.NewRows([]string{"line"}))
+	mock.ExpectQuery("FOR UPDATE").
+		WillReturnRows(sqlmock.NewRows([]string{"account_id", "balance", "open", "livemode"}).
+			AddRow(int64(1), 100.0, true, true).
+			AddRow(int64(2), 0.0, false, true))
+	mock.ExpectExec("UPDATE accounts a SET balance").WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectQuery("WITH logged AS").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "transaction_ref"}).AddRow(12, "").AddRow(11, "ref-1"))
+	mock.ExpectRollback()
+
+	tx, err := db.Begin()
+	assert.NoError(t, err)
+	staged, err := repo.StageTransfersTx(tx, []models.IngestTransfer{
+		{SourceAccountID: 1, DestinationAccountID: 2, Amount: 10.5, Reference: "ref-1"},
+		{SourceAccountID: 1, DestinationAccountID: 3, Amount: 2},
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, staged.Skipped)
+	assert.Equal(t, map[int64]models.IngestAccount{
+		1: {AccountID: 1, Balance: 100, Open: true, Livemode: true},
+		2: {AccountID: 2, Open: false, Livemode: true},
+	}, staged.Accounts)
+
+	ids, err := repo.MergeTransfersTx(tx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ref-1", "12"}, ids)
+	assert.NoError(t, tx.Rollback())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresIngestRepository_LedgerEntries(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewPostgresIngestRepository(WithLedgerEntries())
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE accounts a SET balance").WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectQuery("INSERT INTO ledger_entries").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "transaction_ref"}).AddRow(11, ""))
+	mock.ExpectRollback()
+
+	tx, err := db.Begin()
+	assert.NoError(t, err)
+	ids, err := repo.MergeTransfersTx(tx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"11"}, ids)
+	assert.NoError(t, tx.Rollback())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
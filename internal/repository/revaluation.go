@@ -0,0 +1,221 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/money"
+	"github.com/nehciyy/intrapay/internal/repository/sqlc"
+)
+
+// ErrAlreadyRevalued is returned for a revaluation of a day that has been
+// revalued already.
+var ErrAlreadyRevalued = errors.New("already revalued")
+
+// PostgresRevaluationRepository is an implementation of RevaluationRepository for PostgreSQL.
+type PostgresRevaluationRepository struct {
+	q        *sqlc.Queries
+	reads    router
+	queryLog *QueryLogger
+}
+
+// NewPostgresRevaluationRepository creates a new PostgresRevaluationRepository.
+func NewPostgresRevaluationRepository(db *sql.DB, opts ...Option) *PostgresRevaluationRepository {
+	o := applyOptions(opts)
+	return &PostgresRevaluationRepository{q: sqlc.New(db), reads: newRouter(db, o), queryLog: o.queryLog}
+}
+
+// PutFXRate stores the rate of r.Currency in r.BaseCurrency at the close of
+// r.Date, replacing any rate stored for that day.
+func (r *PostgresRevaluationRepository) PutFXRate(rate models.FXRate) (*models.FXRate, error) {
+	defer r.queryLog.observe("PutFXRate", time.Now())
+	date, err := time.Parse(time.DateOnly, rate.Date)
+	if err != nil {
+		return nil, err
+	}
+	row, err := r.q.PutFXRate(context.Background(), sqlc.PutFXRateParams{
+		Currency:     rate.Currency,
+		BaseCurrency: rate.BaseCurrency,
+		RateDate:     date,
+		Rate:         rate.Rate,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toFXRate(row), nil
+}
+
+func (r *PostgresRevaluationRepository) GetFXRate(currency, baseCurrency string, date time.Time) (*models.FXRate, error) {
+	defer r.queryLog.observe("GetFXRate", time.Now())
+	row, err := r.q.GetFXRate(context.Background(), sqlc.GetFXRateParams{Currency: currency, BaseCurrency: baseCurrency, RateDate: date})
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%s/%s rate of %s %w", currency, baseCurrency, date.Format(time.DateOnly), ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return toFXRate(row), nil
+}
+
+// LatestRevaluation returns the revaluation of currency into baseCurrency of
+// the latest day.
+func (r *PostgresRevaluationRepository) LatestRevaluation(currency, baseCurrency string) (*models.Revaluation, error) {
+	defer r.queryLog.observe("GetLatestRevaluation", time.Now())
+	row, err := r.q.GetLatestRevaluation(context.Background(), sqlc.GetLatestRevaluationParams{Currency: currency, BaseCurrency: baseCurrency})
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%s/%s revaluation %w", currency, baseCurrency, ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return toRevaluation(row), nil
+}
+
+// RevalueTx records revaluation rv in tx: it marks the balance of every account
+// at cutoff to the base currency at rv.Rate, rounded to minorUnits decimal
+// places with rv.RoundingMode, books the gains and losses since previous, nil for the first
+// revaluation, and totals them. A day revalued already is ErrAlreadyRevalued.
+func (r *PostgresRevaluationRepository) RevalueTx(tx *sql.Tx, rv models.Revaluation, previous *models.Revaluation, cutoff time.Time, minorUnits int) (*models.Revaluation, error) {
+	defer r.queryLog.observe("RevalueTx", time.Now())
+	date, err := time.Parse(time.DateOnly, rv.Date)
+	if err != nil {
+		return nil, err
+	}
+	var previousID sql.NullInt64
+	var previousRate sql.NullFloat64
+	if previous != nil {
+		previousID = sql.NullInt64{Int64: previous.ID, Valid: true}
+		previousRate = sql.NullFloat64{Float64: previous.Rate, Valid: true}
+	}
+
+	ctx := context.Background()
+	q := r.q.WithTx(tx)
+	row, err := q.InsertRevaluation(ctx, sqlc.InsertRevaluationParams{
+		RateDate:      date,
+		Currency:      rv.Currency,
+		BaseCurrency:  rv.BaseCurrency,
+		Rate:          rv.Rate,
+		PreviousID:    previousID,
+		PreviousRate:  previousRate,
+		GainAccountID: rv.GainAccountID,
+		LossAccountID: rv.LossAccountID,
+		RoundingMode:  string(rv.RoundingMode),
+	})
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return nil, fmt.Errorf("%s %w", rv.Date, ErrAlreadyRevalued)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := q.InsertRevaluationEntries(ctx, sqlc.InsertRevaluationEntriesParams{
+		RevaluationID: row.ID,
+		Rate:          rv.Rate,
+		MinorUnits:    int32(minorUnits),
+		RoundingMode:  string(rv.RoundingMode),
+		Cutoff:        cutoff,
+		PreviousID:    previousID,
+	}); err != nil {
+		return nil, err
+	}
+	if err := q.InsertRevaluationOffsets(ctx, row.ID); err != nil {
+		return nil, err
+	}
+	if row, err = q.FinishRevaluation(ctx, row.ID); err != nil {
+		return nil, err
+	}
+	return toRevaluation(row), nil
+}
+
+// GetRevaluation returns a revaluation with its totals by account type. Accounts
+// are classified with the IDs of the suspense and reimbursement accounts, 0 for
+// those not configured.
+func (r *PostgresRevaluationRepository) GetRevaluation(ctx context.Context, id, suspenseID, reimbursementID int64) (*models.Revaluation, error) {
+	defer r.queryLog.observe("GetRevaluation", time.Now())
+	row, err := read(ctx, r.reads, "GetRevaluation", func(ctx context.Context, q *sqlc.Queries) (sqlc.Revaluation, error) {
+		return q.GetRevaluation(ctx, id)
+	})
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("revaluation %d %w", id, ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	rows, err := read(ctx, r.reads, "RevaluationPositions", func(ctx context.Context, q *sqlc.Queries) ([]sqlc.RevaluationPositionsRow, error) {
+		return q.RevaluationPositions(ctx, sqlc.RevaluationPositionsParams{
+			SuspenseID:      suspenseID,
+			ReimbursementID: reimbursementID,
+			RevaluationID:   id,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	rv := toRevaluation(row)
+	rv.Positions = make([]models.RevaluationPosition, len(rows))
+	for i, p := range rows {
+		rv.Positions[i] = models.RevaluationPosition{
+			AccountType: models.AccountType(p.AccountType),
+			Accounts:    p.Accounts,
+			Balance:     models.Amount(p.Balance),
+			BaseValue:   p.BaseValue,
+			GainLoss:    p.GainLoss,
+		}
+	}
+	return rv, nil
+}
+
+// ListRevaluations returns the limit latest revaluations of currency into
+// baseCurrency, latest first.
+func (r *PostgresRevaluationRepository) ListRevaluations(ctx context.Context, currency, baseCurrency string, limit int) ([]models.Revaluation, error) {
+	defer r.queryLog.observe("ListRevaluations", time.Now())
+	rows, err := read(ctx, r.reads, "ListRevaluations", func(ctx context.Context, q *sqlc.Queries) ([]sqlc.Revaluation, error) {
+		return q.ListRevaluations(ctx, sqlc.ListRevaluationsParams{Currency: currency, BaseCurrency: baseCurrency, Limit: int32(limit)})
+	})
+	if err != nil {
+		return nil, err
+	}
+	revaluations := make([]models.Revaluation, len(rows))
+	for i, row := range rows {
+		revaluations[i] = *toRevaluation(row)
+	}
+	return revaluations, nil
+}
+
+func toFXRate(row sqlc.FxRate) *models.FXRate {
+	return &models.FXRate{
+		Currency:     row.Currency,
+		BaseCurrency: row.BaseCurrency,
+		Date:         row.RateDate.Format(time.DateOnly),
+		Rate:         row.Rate,
+		UpdatedAt:    row.UpdatedAt,
+	}
+}
+
+func toRevaluation(row sqlc.Revaluation) *models.Revaluation {
+	rv := &models.Revaluation{
+		ID:            row.ID,
+		Date:          row.RateDate.Format(time.DateOnly),
+		Currency:      row.Currency,
+		BaseCurrency:  row.BaseCurrency,
+		Rate:          row.Rate,
+		Balance:       models.Amount(row.Balance),
+		BaseValue:     row.BaseValue,
+		Gain:          row.Gain,
+		Loss:          row.Loss,
+		GainAccountID: row.GainAccountID,
+		LossAccountID: row.LossAccountID,
+		RoundingMode:  money.Mode(row.RoundingMode),
+		CreatedAt:     row.CreatedAt,
+	}
+	if row.PreviousRate.Valid {
+		rv.PreviousRate = &row.PreviousRate.Float64
+	}
+	return rv
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/nehciyy/intrapay/internal/db"
+	"github.com/nehciyy/intrapay/internal/metrics"
+	"github.com/nehciyy/intrapay/internal/repository/sqlc"
+)
+
+// router runs the reads of a repository by the hints of their context:
+// read-only reads go to the replica if there is one, or else to the primary as
+// the read-only role if there is one, statements get the policy's timeout for
+// their hints, and idempotent reads are retried after transient errors.
+type router struct {
+	primary *sqlc.Queries
+	// replica is nil unless a replica is configured.
+	replica *sqlc.Queries
+	// reader is nil unless a read-only role is configured.
+	reader *sqlc.Queries
+	policy db.Policy
+}
+
+func newRouter(primary *sql.DB, o repoOptions) router {
+	rt := router{primary: sqlc.New(primary), policy: o.hints}
+	if o.replica != nil {
+		rt.replica = sqlc.New(o.replica)
+	}
+	if o.reader != nil {
+		rt.reader = sqlc.New(o.reader)
+	}
+	return rt
+}
+
+// read runs fn, the query name, as the hints of ctx allow. A retry always goes
+// to the primary, since a replica that failed may be restarting or too far
+// behind; a read-only read is retried there as the read-only role.
+func read[T any](ctx context.Context, rt router, name string, fn func(context.Context, *sqlc.Queries) (T, error)) (T, error) {
+	hints := db.HintsFrom(ctx)
+	retryQ, retryTarget := rt.primary, "primary"
+	if hints.Has(db.ReadOnly) && rt.reader != nil {
+		retryQ, retryTarget = rt.reader, "reader"
+	}
+	q, target := retryQ, retryTarget
+	if hints.Has(db.ReadOnly) && rt.replica != nil {
+		q, target = rt.replica, "replica"
+	}
+	attempts := rt.policy.Attempts(hints)
+	for attempt := 1; ; attempt++ {
+		metrics.DBRoutedQueries.WithLabelValues(hints.String(), target).Inc()
+		v, err := runTimed(ctx, rt.policy.Timeout(hints), q, fn)
+		if err == nil || attempt >= attempts || !isTransient(err) || ctx.Err() != nil {
+			return v, err
+		}
+		metrics.DBQueryRetries.WithLabelValues(name).Inc()
+		q, target = retryQ, retryTarget
+	}
+}
+
+// runTimed runs fn with a deadline of timeout, if set. sqlc reads every row
+// before returning, so the deadline can be lifted as soon as fn returns.
+func runTimed[T any](ctx context.Context, timeout time.Duration, q *sqlc.Queries, fn func(context.Context, *sqlc.Queries) (T, error)) (T, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return fn(ctx, q)
+}
+
+// isTransient reports whether err is worth retrying: the connection was lost,
+// the server is shutting down or out of connections, or the statement lost a
+// serialization or deadlock race. Cancelled statements are not retried.
+func isTransient(err error) bool {
+	if errors.Is(err, driver.ErrBadConn) {
+		return true
+	}
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	switch pqErr.Code {
+	case "40001", "40P01", "57P01", "57P02", "57P03", "53300":
+		return true
+	}
+	return pqErr.Code.Class() == "08"
+}
+
+// IsUnavailable reports whether err is the database being out of reach: the
+// connection could not be made or was lost, or the server is shutting down,
+// starting up or out of connections. Nothing a statement failing so was part
+// of has committed; the error of a commit is never reported as such, as it
+// leaves unknown whether the transaction committed.
+func IsUnavailable(err error) bool {
+	var opErr *net.OpError
+	if errors.Is(err, driver.ErrBadConn) || errors.As(err, &opErr) {
+		return true
+	}
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	switch pqErr.Code {
+	case "57P01", "57P02", "57P03", "53300":
+		return true
+	}
+	return pqErr.Code.Class() == "08"
+}
@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nehciyy/intrapay/internal/db"
+	"github.com/nehciyy/intrapay/internal/repository/sqlc"
+)
+
+func expectAccountExists(mock sqlmock.Sqlmock) *sqlmock.ExpectedQuery {
+	return mock.ExpectQuery("-- name: AccountExists :one").WithArgs(int64(1))
+}
+
+func TestRouter_Replica(t *testing.T) {
+	primary, primaryMock := setupMockDB(t)
+	replica, replicaMock := setupMockDB(t)
+	repo := NewPostgresAccountRepository(primary, WithReplica(replica))
+
+	expectAccountExists(replicaMock).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	exists, err := repo.AccountExists(db.WithHints(context.Background(), db.ReadOnly), 1)
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	// Without the read-only hint reads stay on the primary, e.g. to read back
+	// a write.
+	expectAccountExists(primaryMock).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	_, err = repo.AccountExists(context.Background(), 1)
+	assert.NoError(t, err)
+
+	assert.NoError(t, primaryMock.ExpectationsWereMet())
+	assert.NoError(t, replicaMock.ExpectationsWereMet())
+}
+
+func TestRouter_Reader(t *testing.T) {
+	primary, primaryMock := setupMockDB(t)
+	reader, readerMock := setupMockDB(t)
+	repo := NewPostgresAccountRepository(primary, WithReader(reader))
+
+	expectAccountExists(readerMock).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	_, err := repo.AccountExists(db.WithHints(context.Background(), db.ReadOnly), 1)
+	assert.NoError(t, err)
+
+	// Reads that may precede a write keep the role of the primary.
+	expectAccountExists(primaryMock).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	_, err = repo.AccountExists(context.Background(), 1)
+	assert.NoError(t, err)
+
+	assert.NoError(t, primaryMock.ExpectationsWereMet())
+	assert.NoError(t, readerMock.ExpectationsWereMet())
+}
+
+func TestRouter_Retry(t *testing.T) {
+	shutdown := &pq.Error{Code: "57P01", Message: "terminating connection due to administrator command"}
+
+	t.Run("Idempotent", func(t *testing.T) {
+		primary, primaryMock := setupMockDB(t)
+		replica, replicaMock := setupMockDB(t)
+		repo := NewPostgresAccountRepository(primary, WithReplica(replica), WithHintPolicy(db.Policy{Retries: 1}))
+
+		// The retry goes to the primary.
+		expectAccountExists(replicaMock).WillReturnError(shutdown)
+		expectAccountExists(primaryMock).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		exists, err := repo.AccountExists(db.WithHints(context.Background(), db.ReadOnly|db.Idempotent), 1)
+		assert.NoError(t, err)
+		assert.True(t, exists)
+		assert.NoError(t, primaryMock.ExpectationsWereMet())
+		assert.NoError(t, replicaMock.ExpectationsWereMet())
+	})
+
+	t.Run("Idempotent With Reader", func(t *testing.T) {
+		primary, primaryMock := setupMockDB(t)
+		replica, replicaMock := setupMockDB(t)
+		reader, readerMock := setupMockDB(t)
+		repo := NewPostgresAccountRepository(primary, WithReplica(replica), WithReader(reader), WithHintPolicy(db.Policy{Retries: 1}))
+
+		// The retry goes to the primary as the read-only role.
+		expectAccountExists(replicaMock).WillReturnError(shutdown)
+		expectAccountExists(readerMock).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		_, err := repo.AccountExists(db.WithHints(context.Background(), db.ReadOnly|db.Idempotent), 1)
+		assert.NoError(t, err)
+		assert.NoError(t, primaryMock.ExpectationsWereMet())
+		assert.NoError(t, replicaMock.ExpectationsWereMet())
+		assert.NoError(t, readerMock.ExpectationsWereMet())
+	})
+
+	t.Run("Not Idempotent", func(t *testing.T) {
+		primary, mock := setupMockDB(t)
+		repo := NewPostgresAccountRepository(primary, WithHintPolicy(db.Policy{Retries: 1}))
+
+		expectAccountExists(mock).WillReturnError(shutdown)
+		_, err := repo.AccountExists(db.WithHints(context.Background(), db.ReadOnly), 1)
+		assert.ErrorIs(t, err, shutdown)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Not Transient", func(t *testing.T) {
+		primary, mock := setupMockDB(t)
+		repo := NewPostgresAccountRepository(primary, WithHintPolicy(db.Policy{Retries: 1}))
+
+		expectAccountExists(mock).WillReturnError(errors.New("syntax error"))
+		_, err := repo.AccountExists(db.WithHints(context.Background(), db.Idempotent), 1)
+		assert.Error(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRouter_Timeout(t *testing.T) {
+	primary, _ := setupMockDB(t)
+	rt := newRouter(primary, repoOptions{hints: db.Policy{ReadOnlyTimeout: time.Minute}})
+
+	deadline := func(ctx context.Context) (bool, error) {
+		_, ok := ctx.Deadline()
+		return ok, nil
+	}
+	timed, _ := read(db.WithHints(context.Background(), db.ReadOnly), rt, "deadline", func(ctx context.Context, _ *sqlc.Queries) (bool, error) {
+		return deadline(ctx)
+	})
+	assert.True(t, timed)
+
+	timed, _ = read(context.Background(), rt, "deadline", func(ctx context.Context, _ *sqlc.Queries) (bool, error) {
+		return deadline(ctx)
+	})
+	assert.False(t, timed, "operations without hints keep the server's timeout")
+}
+
+func TestIsTransient(t *testing.T) {
+	assert.True(t, isTransient(driver.ErrBadConn))
+	assert.True(t, isTransient(&pq.Error{Code: "40001"}))
+	assert.True(t, isTransient(&pq.Error{Code: "08006"}))
+	assert.False(t, isTransient(&pq.Error{Code: "57014"}), "cancelled statements are not retried")
+	assert.False(t, isTransient(&pq.Error{Code: "23505"}))
+	assert.False(t, isTransient(errors.New("boom")))
+}
+
+func TestIsUnavailable(t *testing.T) {
+	assert.True(t, IsUnavailable(driver.ErrBadConn))
+	assert.True(t, IsUnavailable(fmt.Errorf("begin: %w", &net.OpError{Op: "dial", Err: errors.New("connection refused")})))
+	assert.True(t, IsUnavailable(&pq.Error{Code: "08006"}))
+	assert.True(t, IsUnavailable(&pq.Error{Code: "57P01"}))
+	assert.False(t, IsUnavailable(&pq.Error{Code: "40001"}))
+	assert.False(t, IsUnavailable(context.DeadlineExceeded))
+	assert.False(t, IsUnavailable(errors.New("boom")))
+}
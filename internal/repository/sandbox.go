@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository/sqlc"
+)
+
+// PostgresSandboxRepository is an implementation of SandboxRepository for
+// PostgreSQL.
+type PostgresSandboxRepository struct {
+	q        *sqlc.Queries
+	queryLog *QueryLogger
+}
+
+// NewPostgresSandboxRepository creates a new PostgresSandboxRepository.
+func NewPostgresSandboxRepository(db *sql.DB, opts ...Option) *PostgresSandboxRepository {
+	o := applyOptions(opts)
+	return &PostgresSandboxRepository{q: sqlc.New(db), queryLog: o.queryLog}
+}
+
+// PurgeTestData deletes every test-mode account and transaction, with their
+// ledger entries, adjustments, status history, spending tokens, top-up rules,
+// cashback campaigns and reimbursements, and returns how many accounts and
+// transactions it deleted.
+func (r *PostgresSandboxRepository) PurgeTestData() (*models.SandboxPurge, error) {
+	defer r.queryLog.observe("PurgeTestData", time.Now())
+	row, err := r.q.PurgeTestData(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return &models.SandboxPurge{Accounts: row.Accounts, Transactions: row.Transactions}, nil
+}
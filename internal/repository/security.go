@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository/sqlc"
+)
+
+// PostgresSecurityEventRepository is an implementation of
+// SecurityEventRepository for PostgreSQL.
+type PostgresSecurityEventRepository struct {
+	q        *sqlc.Queries
+	queryLog *QueryLogger
+}
+
+// NewPostgresSecurityEventRepository creates a new PostgresSecurityEventRepository.
+func NewPostgresSecurityEventRepository(db *sql.DB, opts ...Option) *PostgresSecurityEventRepository {
+	o := applyOptions(opts)
+	return &PostgresSecurityEventRepository{q: sqlc.New(db), queryLog: o.queryLog}
+}
+
+// InsertSecurityEvent records e and returns it with its ID and the time it was
+// recorded.
+func (r *PostgresSecurityEventRepository) InsertSecurityEvent(e models.SecurityEvent) (*models.SecurityEvent, error) {
+	defer r.queryLog.observe("InsertSecurityEvent", time.Now())
+	row, err := r.q.InsertSecurityEvent(context.Background(), sqlc.InsertSecurityEventParams{
+		Type:      e.Type,
+		Actor:     e.Actor,
+		Tenant:    e.Tenant,
+		Address:   e.Address,
+		RequestID: e.RequestID,
+		Detail:    e.Detail,
+	})
+	if err != nil {
+		return nil, err
+	}
+	e.ID, e.CreatedAt = row.ID, row.CreatedAt
+	return &e, nil
+}
+
+// ListSecurityEvents returns up to limit events after afterID in id order.
+func (r *PostgresSecurityEventRepository) ListSecurityEvents(afterID int64, limit int) ([]models.SecurityEvent, error) {
+	defer r.queryLog.observe("ListSecurityEvents", time.Now())
+	rows, err := r.q.ListSecurityEvents(context.Background(), sqlc.ListSecurityEventsParams{AfterID: afterID, RowLimit: int32(limit)})
+	if err != nil {
+		return nil, err
+	}
+	events := make([]models.SecurityEvent, len(rows))
+	for i, row := range rows {
+		events[i] = models.SecurityEvent{
+			ID:        row.ID,
+			Type:      row.Type,
+			Actor:     row.Actor,
+			Tenant:    row.Tenant,
+			Address:   row.Address,
+			RequestID: row.RequestID,
+			Detail:    row.Detail,
+			CreatedAt: row.CreatedAt,
+		}
+	}
+	return events, nil
+}
@@ -0,0 +1,239 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"math"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/metrics"
+	"github.com/nehciyy/intrapay/internal/models"
+)
+
+// ShadowLedger is the secondary store that receives mirrored writes in shadow mode.
+type ShadowLedger interface {
+	CreateOpeningEntry(accountID int64, amount float64) error
+	GetAccountBalance(accountID int64) (float64, error)
+	GetAccountBalanceTx(tx *sql.Tx, accountID int64) (float64, error)
+	InsertEntriesTx(tx *sql.Tx, transactionID string, sourceID, destID int64, amount float64) error
+	InsertAdjustmentEntryTx(tx *sql.Tx, adjustmentID string, accountID int64, amount float64) error
+}
+
+// ShadowAccountRepository mirrors account writes to a ShadowLedger and compares
+// balance reads. Results from the primary are always returned unchanged.
+type ShadowAccountRepository struct {
+	primary AccountRepository
+	shadow  ShadowLedger
+}
+
+// NewShadowAccountRepository creates a new ShadowAccountRepository.
+func NewShadowAccountRepository(primary AccountRepository, shadow ShadowLedger) *ShadowAccountRepository {
+	return &ShadowAccountRepository{primary: primary, shadow: shadow}
+}
+
+func (r *ShadowAccountRepository) CreateAccount(accountID int64, initialBalance float64, tenant, owner string, livemode bool) (*models.Account, error) {
+	account, err := r.primary.CreateAccount(accountID, initialBalance, tenant, owner, livemode)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.shadow.CreateOpeningEntry(accountID, initialBalance); err != nil {
+		shadowError("CreateAccount", err)
+	}
+	return account, nil
+}
+
+func (r *ShadowAccountRepository) AccountExists(ctx context.Context, accountID int64) (bool, error) {
+	return r.primary.AccountExists(ctx, accountID)
+}
+
+func (r *ShadowAccountRepository) GetAccount(ctx context.Context, accountID int64, includeDeleted bool) (*models.Account, error) {
+	account, err := r.primary.GetAccount(ctx, accountID, includeDeleted)
+	if err != nil {
+		return account, err
+	}
+	shadowBalance, shadowErr := r.shadow.GetAccountBalance(accountID)
+	if shadowErr != nil {
+		shadowError("GetAccount", shadowErr)
+	} else {
+		compareBalances("GetAccount", accountID, float64(account.Balance), shadowBalance)
+	}
+	return account, nil
+}
+
+func (r *ShadowAccountRepository) ListAccounts(ctx context.Context, filter models.AccountFilter, afterID int64, limit int) ([]models.Account, error) {
+	return r.primary.ListAccounts(ctx, filter, afterID, limit)
+}
+
+func (r *ShadowAccountRepository) DeleteAccount(accountID int64) error {
+	return r.primary.DeleteAccount(accountID)
+}
+
+func (r *ShadowAccountRepository) RestoreAccount(accountID int64) error {
+	return r.primary.RestoreAccount(accountID)
+}
+
+func (r *ShadowAccountRepository) SetDisplayName(accountID int64, name string) error {
+	return r.primary.SetDisplayName(accountID, name)
+}
+
+func (r *ShadowAccountRepository) CountAccounts(tenant string) (int64, error) {
+	return r.primary.CountAccounts(tenant)
+}
+
+// ShadowTransactionRepository mirrors transfer writes to a ShadowLedger inside the
+// primary transaction. Shadow statements run under a savepoint, so a shadow failure
+// is rolled back on its own and never aborts the primary transaction.
+type ShadowTransactionRepository struct {
+	primary TransactionRepository
+	shadow  ShadowLedger
+}
+
+// NewShadowTransactionRepository creates a new ShadowTransactionRepository.
+func NewShadowTransactionRepository(primary TransactionRepository, shadow ShadowLedger) *ShadowTransactionRepository {
+	return &ShadowTransactionRepository{primary: primary, shadow: shadow}
+}
+
+func (r *ShadowTransactionRepository) GetAccountBalanceTx(tx *sql.Tx, accountID int64) (float64, error) {
+	balance, err := r.primary.GetAccountBalanceTx(tx, accountID)
+	if err != nil {
+		return balance, err
+	}
+	withSavepoint(tx, "GetAccountBalanceTx", func() error {
+		shadowBalance, err := r.shadow.GetAccountBalanceTx(tx, accountID)
+		if err == nil {
+			compareBalances("GetAccountBalanceTx", accountID, balance, shadowBalance)
+		}
+		return err
+	})
+	return balance, nil
+}
+
+func (r *ShadowTransactionRepository) AccountExistsTx(tx *sql.Tx, accountID int64) (bool, error) {
+	return r.primary.AccountExistsTx(tx, accountID)
+}
+
+func (r *ShadowTransactionRepository) UpdateBalanceTx(tx *sql.Tx, accountID int64, delta float64) error {
+	return r.primary.UpdateBalanceTx(tx, accountID, delta)
+}
+
+func (r *ShadowTransactionRepository) DebitBalanceTx(tx *sql.Tx, accountID int64, amount float64) (float64, error) {
+	balance, err := r.primary.DebitBalanceTx(tx, accountID, amount)
+	if err != nil {
+		return balance, err
+	}
+	// The ledger has not seen the debit yet, so compare pre-debit balances.
+	withSavepoint(tx, "DebitBalanceTx", func() error {
+		shadowBalance, err := r.shadow.GetAccountBalanceTx(tx, accountID)
+		if err == nil {
+			compareBalances("DebitBalanceTx", accountID, balance+amount, shadowBalance)
+		}
+		return err
+	})
+	return balance, nil
+}
+
+func (r *ShadowTransactionRepository) InsertTransactionLogTx(tx *sql.Tx, sourceID, destID int64, amount float64) (string, error) {
+	transactionID, err := r.primary.InsertTransactionLogTx(tx, sourceID, destID, amount)
+	if err != nil {
+		return transactionID, err
+	}
+	withSavepoint(tx, "InsertTransactionLogTx", func() error {
+		return r.shadow.InsertEntriesTx(tx, transactionID, sourceID, destID, amount)
+	})
+	return transactionID, nil
+}
+
+func (r *ShadowTransactionRepository) InsertTransactionLogsTx(tx *sql.Tx, logs []TransactionLog) ([]string, error) {
+	ids, err := r.primary.InsertTransactionLogsTx(tx, logs)
+	if err != nil {
+		return ids, err
+	}
+	withSavepoint(tx, "InsertTransactionLogsTx", func() error {
+		for i, l := range logs {
+			if err := r.shadow.InsertEntriesTx(tx, ids[i], l.SourceID, l.DestID, l.Amount); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return ids, nil
+}
+
+func (r *ShadowTransactionRepository) GetTransaction(ctx context.Context, id string) (*models.Transaction, error) {
+	return r.primary.GetTransaction(ctx, id)
+}
+
+func (r *ShadowTransactionRepository) GetTransactions(ctx context.Context, ids []string) (map[string]models.Transaction, error) {
+	return r.primary.GetTransactions(ctx, ids)
+}
+
+func (r *ShadowTransactionRepository) ListTransactions(ctx context.Context, filter models.TransactionFilter, after ChangeCursor, limit int) ([]models.Transaction, ChangeCursor, error) {
+	return r.primary.ListTransactions(ctx, filter, after, limit)
+}
+
+func (r *ShadowTransactionRepository) ListAccountTransactions(ctx context.Context, accountID int64, after ChangeCursor, limit int) ([]models.Transaction, ChangeCursor, error) {
+	return r.primary.ListAccountTransactions(ctx, accountID, after, limit)
+}
+
+func (r *ShadowTransactionRepository) SumAccountFlows(ctx context.Context, accountID int64, since time.Time) (*models.AccountFlows, error) {
+	return r.primary.SumAccountFlows(ctx, accountID, since)
+}
+
+func (r *ShadowTransactionRepository) CountTransactions(ctx context.Context, filter models.TransactionFilter) (int64, error) {
+	return r.primary.CountTransactions(ctx, filter)
+}
+
+func (r *ShadowTransactionRepository) ListTransactionChanges(ctx context.Context, after ChangeCursor, livemode bool, limit int) ([]models.Transaction, ChangeCursor, error) {
+	return r.primary.ListTransactionChanges(ctx, after, livemode, limit)
+}
+
+func (r *ShadowTransactionRepository) ComputeBalanceTx(tx *sql.Tx, accountID int64) (float64, error) {
+	return r.primary.ComputeBalanceTx(tx, accountID)
+}
+
+func (r *ShadowTransactionRepository) InsertAdjustmentTx(tx *sql.Tx, accountID int64, amount float64, code models.ReasonCode, reason string) (string, error) {
+	adjustmentID, err := r.primary.InsertAdjustmentTx(tx, accountID, amount, code, reason)
+	if err != nil {
+		return adjustmentID, err
+	}
+	withSavepoint(tx, "InsertAdjustmentTx", func() error {
+		return r.shadow.InsertAdjustmentEntryTx(tx, adjustmentID, accountID, amount)
+	})
+	return adjustmentID, nil
+}
+
+// withSavepoint runs fn under a savepoint and rolls back to it if fn fails.
+func withSavepoint(tx *sql.Tx, op string, fn func() error) {
+	if _, err := tx.Exec(`SAVEPOINT shadow_write`); err != nil {
+		shadowError(op, err)
+		return
+	}
+	if err := fn(); err != nil {
+		shadowError(op, err)
+		if _, rbErr := tx.Exec(`ROLLBACK TO SAVEPOINT shadow_write`); rbErr != nil {
+			log.Printf("shadow %s: rollback to savepoint failed: %v", op, rbErr)
+		}
+		return
+	}
+	if _, err := tx.Exec(`RELEASE SAVEPOINT shadow_write`); err != nil {
+		shadowError(op, err)
+	}
+}
+
+// balanceTolerance absorbs float rounding on NUMERIC(20, 5) values.
+const balanceTolerance = 1e-6
+
+func compareBalances(op string, accountID int64, primary, shadow float64) {
+	if math.Abs(primary-shadow) > balanceTolerance {
+		metrics.ShadowComparisons.WithLabelValues(op, "mismatch").Inc()
+		log.Printf("shadow %s: balance mismatch for account %d: primary=%.5f shadow=%.5f", op, accountID, primary, shadow)
+		return
+	}
+	metrics.ShadowComparisons.WithLabelValues(op, "match").Inc()
+}
+
+func shadowError(op string, err error) {
+	metrics.ShadowComparisons.WithLabelValues(op, "error").Inc()
+	log.Printf("shadow %s failed: %v", op, err)
+}
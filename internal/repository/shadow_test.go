@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nehciyy/intrapay/internal/metrics"
+)
+
+type stubLedger struct {
+	balance   float64
+	insertErr error
+	entries   int
+}
+
+func (s *stubLedger) CreateOpeningEntry(int64, float64) error  { s.entries++; return nil }
+func (s *stubLedger) GetAccountBalance(int64) (float64, error) { return s.balance, nil }
+func (s *stubLedger) GetAccountBalanceTx(*sql.Tx, int64) (float64, error) {
+	return s.balance, nil
+}
+func (s *stubLedger) InsertAdjustmentEntryTx(*sql.Tx, string, int64, float64) error {
+	s.entries++
+	return nil
+}
+func (s *stubLedger) InsertEntriesTx(*sql.Tx, string, int64, int64, float64) error {
+	if s.insertErr != nil {
+		return s.insertErr
+	}
+	s.entries += 2
+	return nil
+}
+
+func TestShadowTransactionRepository_BalanceMismatch(t *testing.T) {
+	db, mock := setupMockDB(t)
+	metrics.ShadowComparisons.Reset()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT balance FROM accounts WHERE account_id = \\$1 AND deleted_at IS NULL FOR UPDATE").
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(100.0))
+	mock.ExpectExec("SAVEPOINT shadow_write").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("RELEASE SAVEPOINT shadow_write").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	repo := NewShadowTransactionRepository(NewPostgresTransactionRepository(db), &stubLedger{balance: 90})
+	tx, err := db.Begin()
+	require.NoError(t, err)
+
+	balance, err := repo.GetAccountBalanceTx(tx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 100.0, balance, "primary result must be returned")
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.ShadowComparisons.WithLabelValues("GetAccountBalanceTx", "mismatch")))
+
+	assert.NoError(t, tx.Rollback())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestShadowTransactionRepository_ShadowWriteFailure(t *testing.T) {
+	db, mock := setupMockDB(t)
+	metrics.ShadowComparisons.Reset()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO transactions").
+		WithArgs(int64(1), int64(2), 50.0, int64(5000), "").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectExec("SAVEPOINT shadow_write").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("ROLLBACK TO SAVEPOINT shadow_write").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	repo := NewShadowTransactionRepository(NewPostgresTransactionRepository(db), &stubLedger{insertErr: errors.New("ledger down")})
+	tx, err := db.Begin()
+	require.NoError(t, err)
+
+	id, err := repo.InsertTransactionLogTx(tx, 1, 2, 50.0)
+	assert.NoError(t, err, "shadow failures must not surface")
+	assert.Equal(t, "7", id)
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.ShadowComparisons.WithLabelValues("InsertTransactionLogTx", "error")))
+
+	assert.NoError(t, tx.Rollback())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestShadowAccountRepository_CreateAccount(t *testing.T) {
+	db, mock := setupMockDB(t)
+	ledger := &stubLedger{}
+
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("INSERT INTO accounts").
+		WithArgs(int64(1), 100.0, "default", true, "").
+		WillReturnRows(sqlmock.NewRows([]string{"account_id", "balance", "created_at", "updated_at", "deleted_at", "display_name", "livemode"}).
+			AddRow(1, 100.0, created, created, nil, "", true))
+
+	repo := NewShadowAccountRepository(NewPostgresAccountRepository(db), ledger)
+	account, err := repo.CreateAccount(1, 100.0, "default", "", true)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), account.AccountID)
+	assert.Equal(t, 1, ledger.entries)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresLedgerRepository_InsertEntriesTx(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewPostgresLedgerRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO ledger_entries").
+		WithArgs(int64(7), int64(1), -50.0, int64(2), 50.0).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectRollback()
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	assert.NoError(t, repo.InsertEntriesTx(tx, "7", 1, 2, 50.0))
+	assert.Error(t, repo.InsertEntriesTx(tx, "not-a-number", 1, 2, 50.0))
+	assert.NoError(t, tx.Rollback())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository/sqlc"
+)
+
+// PostgresSpendingTokenRepository is an implementation of SpendingTokenRepository for PostgreSQL.
+type PostgresSpendingTokenRepository struct {
+	q        *sqlc.Queries
+	queryLog *QueryLogger
+}
+
+// NewPostgresSpendingTokenRepository creates a new PostgresSpendingTokenRepository.
+func NewPostgresSpendingTokenRepository(db *sql.DB, opts ...Option) *PostgresSpendingTokenRepository {
+	o := applyOptions(opts)
+	return &PostgresSpendingTokenRepository{q: sqlc.New(db), queryLog: o.queryLog}
+}
+
+// InsertSpendingToken stores a token that has spent nothing yet.
+func (r *PostgresSpendingTokenRepository) InsertSpendingToken(t models.SpendingToken) (*models.SpendingToken, error) {
+	defer r.queryLog.observe("InsertSpendingToken", time.Now())
+	params := sqlc.InsertSpendingTokenParams{
+		ID:                 t.ID,
+		AccountID:          t.AccountID,
+		SpendLimit:         float64(t.Limit),
+		MerchantCategories: t.MerchantCategories,
+	}
+	if t.ExpiresAt != nil {
+		params.ExpiresAt = sql.NullTime{Time: *t.ExpiresAt, Valid: true}
+	}
+	row, err := r.q.InsertSpendingToken(context.Background(), params)
+	if err != nil {
+		return nil, err
+	}
+	return toSpendingToken(row), nil
+}
+
+func (r *PostgresSpendingTokenRepository) GetSpendingToken(id string) (*models.SpendingToken, error) {
+	defer r.queryLog.observe("GetSpendingToken", time.Now())
+	row, err := r.q.GetSpendingToken(context.Background(), id)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("spending token %s %w", id, ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return toSpendingToken(row), nil
+}
+
+// ListSpendingTokens returns the tokens of accountID, revoked and expired ones
+// included, oldest first.
+func (r *PostgresSpendingTokenRepository) ListSpendingTokens(accountID int64) ([]models.SpendingToken, error) {
+	defer r.queryLog.observe("ListSpendingTokens", time.Now())
+	rows, err := r.q.ListSpendingTokens(context.Background(), accountID)
+	if err != nil {
+		return nil, err
+	}
+	tokens := make([]models.SpendingToken, len(rows))
+	for i, row := range rows {
+		tokens[i] = *toSpendingToken(row)
+	}
+	return tokens, nil
+}
+
+// RevokeSpendingToken stops a token from debiting its account. Revoking a
+// revoked token changes nothing.
+func (r *PostgresSpendingTokenRepository) RevokeSpendingToken(id string) (*models.SpendingToken, error) {
+	defer r.queryLog.observe("RevokeSpendingToken", time.Now())
+	row, err := r.q.RevokeSpendingToken(context.Background(), id)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("spending token %s %w", id, ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return toSpendingToken(row), nil
+}
+
+// GetSpendingTokenForUpdateTx reads a token and locks it until tx ends, so the
+// debit made in tx is checked against what the token has spent by then.
+func (r *PostgresSpendingTokenRepository) GetSpendingTokenForUpdateTx(tx *sql.Tx, id string) (*models.SpendingToken, error) {
+	defer r.queryLog.observe("GetSpendingTokenForUpdateTx", time.Now())
+	row, err := r.q.WithTx(tx).GetSpendingTokenForUpdate(context.Background(), id)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("spending token %s %w", id, ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return toSpendingToken(row), nil
+}
+
+// AddSpendingTokenSpentTx adds a debit made in tx to what the token has spent.
+func (r *PostgresSpendingTokenRepository) AddSpendingTokenSpentTx(tx *sql.Tx, id string, amount float64) (*models.SpendingToken, error) {
+	defer r.queryLog.observe("AddSpendingTokenSpentTx", time.Now())
+	row, err := r.q.WithTx(tx).AddSpendingTokenSpent(context.Background(), sqlc.AddSpendingTokenSpentParams{ID: id, Amount: amount})
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("spending token %s %w", id, ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return toSpendingToken(row), nil
+}
+
+func toSpendingToken(row sqlc.SpendingToken) *models.SpendingToken {
+	categories := row.MerchantCategories
+	if categories == nil {
+		categories = []string{}
+	}
+	token := &models.SpendingToken{
+		ID:                 row.ID,
+		AccountID:          row.AccountID,
+		Limit:              models.Amount(row.SpendLimit),
+		Spent:              models.Amount(row.Spent),
+		MerchantCategories: categories,
+		CreatedAt:          row.CreatedAt,
+	}
+	if row.ExpiresAt.Valid {
+		token.ExpiresAt = &row.ExpiresAt.Time
+	}
+	if row.RevokedAt.Valid {
+		token.RevokedAt = &row.RevokedAt.Time
+	}
+	return token
+}
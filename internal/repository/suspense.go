@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository/sqlc"
+)
+
+// ErrAlreadyResolved is returned when reposting a suspense item that has already
+// been reposted, or deciding a reimbursement that has already been decided.
+var ErrAlreadyResolved = errors.New("already resolved")
+
+// PostgresSuspenseRepository is an implementation of SuspenseRepository for PostgreSQL.
+type PostgresSuspenseRepository struct {
+	q        *sqlc.Queries
+	queryLog *QueryLogger
+}
+
+// NewPostgresSuspenseRepository creates a new PostgresSuspenseRepository.
+func NewPostgresSuspenseRepository(db *sql.DB, opts ...Option) *PostgresSuspenseRepository {
+	o := applyOptions(opts)
+	return &PostgresSuspenseRepository{q: sqlc.New(db), queryLog: o.queryLog}
+}
+
+// InsertSuspenseItemTx records a payment parked on the suspense account by the
+// transfer transactionID, in the transaction that made the transfer.
+func (r *PostgresSuspenseRepository) InsertSuspenseItemTx(tx *sql.Tx, item models.SuspenseItem) (*models.SuspenseItem, error) {
+	defer r.queryLog.observe("InsertSuspenseItemTx", time.Now())
+	row, err := r.q.WithTx(tx).InsertSuspenseItem(context.Background(), sqlc.InsertSuspenseItemParams{
+		SourceAccountID:   item.SourceAccountID,
+		IntendedAccountID: item.IntendedAccountID,
+		Amount:            float64(item.Amount),
+		Reason:            string(item.Reason),
+		Reference:         item.Reference,
+		TransactionID:     item.TransactionID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	parked := toSuspenseItem(row)
+	return &parked, nil
+}
+
+func (r *PostgresSuspenseRepository) GetSuspenseItem(id int64) (*models.SuspenseItem, error) {
+	defer r.queryLog.observe("GetSuspenseItem", time.Now())
+	row, err := r.q.GetSuspenseItem(context.Background(), id)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("suspense item %d %w", id, ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	item := toSuspenseItem(row)
+	return &item, nil
+}
+
+// ListSuspenseItems returns up to limit unresolved items, oldest first, starting
+// after the cursor, and the cursor of the last one.
+func (r *PostgresSuspenseRepository) ListSuspenseItems(after ChangeCursor, limit int) ([]models.SuspenseItem, ChangeCursor, error) {
+	defer r.queryLog.observe("ListSuspenseItems", time.Now())
+	rows, err := r.q.ListSuspenseItems(context.Background(), sqlc.ListSuspenseItemsParams{
+		AfterCreatedAt: after.UpdatedAt,
+		AfterID:        after.ID,
+		RowLimit:       int32(limit),
+	})
+	if err != nil {
+		return nil, after, err
+	}
+	items := make([]models.SuspenseItem, len(rows))
+	for i, row := range rows {
+		items[i] = toSuspenseItem(row)
+	}
+	if len(rows) > 0 {
+		last := rows[len(rows)-1]
+		after = ChangeCursor{UpdatedAt: last.CreatedAt, ID: last.ID}
+	}
+	return items, after, nil
+}
+
+// ResolveSuspenseItemTx marks an item as reposted to accountID by the transfer
+// transactionID, in the transaction that made the transfer. It fails with
+// ErrAlreadyResolved if the item was reposted concurrently.
+func (r *PostgresSuspenseRepository) ResolveSuspenseItemTx(tx *sql.Tx, id, accountID int64, transactionID string) (*models.SuspenseItem, error) {
+	defer r.queryLog.observe("ResolveSuspenseItemTx", time.Now())
+	row, err := r.q.WithTx(tx).ResolveSuspenseItem(context.Background(), sqlc.ResolveSuspenseItemParams{
+		ID:                  id,
+		RepostedTo:          sql.NullInt64{Int64: accountID, Valid: true},
+		RepostTransactionID: sql.NullString{String: transactionID, Valid: true},
+	})
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("suspense item %d %w", id, ErrAlreadyResolved)
+	}
+	if err != nil {
+		return nil, err
+	}
+	item := toSuspenseItem(row)
+	return &item, nil
+}
+
+func toSuspenseItem(row sqlc.SuspenseItem) models.SuspenseItem {
+	item := models.SuspenseItem{
+		ID:                  row.ID,
+		SourceAccountID:     row.SourceAccountID,
+		IntendedAccountID:   row.IntendedAccountID,
+		Amount:              models.Amount(row.Amount),
+		Reason:              models.SuspenseReason(row.Reason),
+		Reference:           row.Reference,
+		TransactionID:       row.TransactionID,
+		CreatedAt:           row.CreatedAt,
+		RepostTransactionID: row.RepostTransactionID.String,
+	}
+	if row.RepostedTo.Valid {
+		item.RepostedTo = &row.RepostedTo.Int64
+	}
+	if row.ResolvedAt.Valid {
+		item.ResolvedAt = &row.ResolvedAt.Time
+	}
+	return item
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository/sqlc"
+)
+
+// PostgresTransactionTagRepository is an implementation of
+// TransactionTagRepository for PostgreSQL.
+type PostgresTransactionTagRepository struct {
+	q        *sqlc.Queries
+	reads    router
+	queryLog *QueryLogger
+}
+
+// NewPostgresTransactionTagRepository creates a new PostgresTransactionTagRepository.
+func NewPostgresTransactionTagRepository(db *sql.DB, opts ...Option) *PostgresTransactionTagRepository {
+	o := applyOptions(opts)
+	return &PostgresTransactionTagRepository{q: sqlc.New(db), reads: newRouter(db, o), queryLog: o.queryLog}
+}
+
+// SetTransactionTagsTx replaces the tags of transaction id inside tx, e.g. the
+// transaction of the transfer that created it.
+func (r *PostgresTransactionTagRepository) SetTransactionTagsTx(tx *sql.Tx, id string, tags []string) error {
+	defer r.queryLog.observe("SetTransactionTagsTx", time.Now())
+	_, err := setTransactionTags(r.q.WithTx(tx), id, tags)
+	return err
+}
+
+// SetTransactionTags replaces the tags of transaction id and returns the
+// transaction.
+func (r *PostgresTransactionTagRepository) SetTransactionTags(id string, tags []string) (*models.Transaction, error) {
+	defer r.queryLog.observe("SetTransactionTags", time.Now())
+	return setTransactionTags(r.q, id, tags)
+}
+
+func setTransactionTags(q *sqlc.Queries, id string, tags []string) (*models.Transaction, error) {
+	params := sqlc.SetTransactionTagsParams{Tags: tags, Ref: id}
+	if params.Tags == nil {
+		params.Tags = []string{}
+	}
+	if serial, err := strconv.ParseInt(id, 10, 32); err == nil {
+		params.SerialID = sql.NullInt32{Int32: int32(serial), Valid: true}
+	}
+	row, err := q.SetTransactionTags(context.Background(), params)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("transaction %s %w", id, ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	transaction := toTransaction(row)
+	return &transaction, nil
+}
+
+// ListAccountTags returns the tags of the transfers accountID sent or received,
+// in lexical order, each with how many transfers carry it.
+func (r *PostgresTransactionTagRepository) ListAccountTags(ctx context.Context, accountID int64) ([]models.TransactionTag, error) {
+	defer r.queryLog.observe("ListAccountTags", time.Now())
+	rows, err := read(ctx, r.reads, "ListAccountTags", func(ctx context.Context, q *sqlc.Queries) ([]sqlc.ListAccountTagsRow, error) {
+		return q.ListAccountTags(ctx, accountID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	tags := make([]models.TransactionTag, len(rows))
+	for i, row := range rows {
+		tags[i] = models.TransactionTag{Tag: row.Tag, Transactions: row.Transactions}
+	}
+	return tags, nil
+}
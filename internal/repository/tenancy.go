@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/nehciyy/intrapay/internal/repository/sqlc"
+)
+
+// tenantScope runs the operations of a repository on the rows of a tenant. With
+// row-level security on, each runs in a transaction scoped to the tenant, so
+// the policies of migration 048 hide the rows of every other tenant even from
+// a query that forgets to filter them out.
+type tenantScope struct {
+	db      *sql.DB
+	q       *sqlc.Queries
+	enabled bool
+}
+
+func newTenantScope(db *sql.DB, o repoOptions) tenantScope {
+	return tenantScope{db: db, q: sqlc.New(db), enabled: o.rowSecurity}
+}
+
+// inTenant runs fn in a transaction scoped to tenant, or straight on the pool
+// when row-level security is off or tenant is empty, which sees every tenant
+// if the role is a member of intrapay_rls_bypass, and nothing otherwise.
+func inTenant[T any](ctx context.Context, s tenantScope, tenant string, fn func(*sqlc.Queries) (T, error)) (T, error) {
+	if !s.enabled || tenant == "" {
+		return fn(s.q)
+	}
+	var zero T
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return zero, err
+	}
+	defer tx.Rollback()
+	q := s.q.WithTx(tx)
+	if err := q.SetTenant(ctx, tenant); err != nil {
+		return zero, err
+	}
+	v, err := fn(q)
+	if err != nil {
+		return zero, err
+	}
+	if err := tx.Commit(); err != nil {
+		return zero, err
+	}
+	return v, nil
+}
+
+// scopeTx scopes the rest of tx to tenant when row-level security is on.
+func (s tenantScope) scopeTx(ctx context.Context, tx *sql.Tx, tenant string) error {
+	if !s.enabled || tenant == "" {
+		return nil
+	}
+	return s.q.WithTx(tx).SetTenant(ctx, tenant)
+}
+
+// BypassesRowSecurity reports whether the role db connects as is a superuser
+// or has BYPASSRLS, and so sees every row whatever the tenant.
+func BypassesRowSecurity(db *sql.DB) (bool, error) {
+	return sqlc.New(db).BypassesRowSecurity(context.Background())
+}
+
+// IsRowSecurityBypassMember reports whether the role db connects as is a member
+// of intrapay_rls_bypass, and so sees every row of the tenant-owned tables in a
+// session not scoped to a tenant.
+func IsRowSecurityBypassMember(db *sql.DB) (bool, error) {
+	return sqlc.New(db).IsRowSecurityBypassMember(context.Background())
+}
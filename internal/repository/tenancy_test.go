@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRowLevelSecurity_Database runs against the database at DATABASE_URL, with
+// the migrations applied, as a role that may create roles. Everything it does
+// is rolled back.
+func TestRowLevelSecurity_Database(t *testing.T) {
+	dsn := os.Getenv("DATABASE_URL")
+	if dsn == "" {
+		t.Skip("Skipping DB test: DATABASE_URL env var not set")
+	}
+	db, err := sql.Open("postgres", dsn)
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, `CREATE ROLE intrapay_rls_probe NOLOGIN`); err != nil {
+		t.Skipf("Skipping DB test: cannot create a role: %v", err)
+	}
+	exec := func(query string, args ...any) {
+		t.Helper()
+		_, err := tx.ExecContext(ctx, query, args...)
+		require.NoError(t, err, query)
+	}
+	count := func() int {
+		t.Helper()
+		var n int
+		query := `SELECT COUNT(*) FROM webhooks WHERE tenant IN ('rls_a', 'rls_b')`
+		require.NoError(t, tx.QueryRowContext(ctx, query).Scan(&n), query)
+		return n
+	}
+	exec(`GRANT SELECT ON webhooks, reimbursements TO intrapay_rls_probe`)
+	exec(`INSERT INTO webhooks (tenant, url, verified_at) VALUES ('rls_a', 'https://a.example', now()), ('rls_b', 'https://b.example', now())`)
+
+	// The role applying the migrations is a member of intrapay_rls_bypass.
+	assert.Equal(t, 2, count(), "a member sees every tenant while no tenant is set")
+
+	exec(`SET LOCAL ROLE intrapay_rls_probe`)
+	assert.Zero(t, count(), "an unscoped query finds nothing")
+	var n int
+	require.NoError(t, tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM reimbursements`).Scan(&n))
+	assert.Zero(t, n)
+
+	exec(`SELECT set_config('app.tenant_id', 'rls_a', true)`)
+	assert.Equal(t, 1, count(), "a scoped query finds the tenant's rows alone")
+
+	exec(`RESET ROLE`)
+	assert.Equal(t, 1, count(), "a member scoped to a tenant is scoped too")
+}
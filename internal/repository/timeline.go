@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository/sqlc"
+)
+
+// PostgresTimelineRepository is an implementation of TimelineRepository for
+// PostgreSQL.
+type PostgresTimelineRepository struct {
+	reads    router
+	queryLog *QueryLogger
+}
+
+// NewPostgresTimelineRepository creates a new PostgresTimelineRepository.
+func NewPostgresTimelineRepository(db *sql.DB, opts ...Option) *PostgresTimelineRepository {
+	o := applyOptions(opts)
+	return &PostgresTimelineRepository{reads: newRouter(db, o), queryLog: o.queryLog}
+}
+
+// ListAccountTimeline returns up to limit events of accountID after the cursor,
+// in the order they happened, and the cursor of the last one. The cursor is
+// the event's time and its seq, which orders events of the same time.
+func (r *PostgresTimelineRepository) ListAccountTimeline(ctx context.Context, accountID int64, after ChangeCursor, limit int) ([]models.AccountEvent, ChangeCursor, error) {
+	defer r.queryLog.observe("ListAccountTimeline", time.Now())
+	rows, err := read(ctx, r.reads, "ListAccountTimeline", func(ctx context.Context, q *sqlc.Queries) ([]sqlc.ListAccountTimelineRow, error) {
+		return q.ListAccountTimeline(ctx, sqlc.ListAccountTimelineParams{
+			AccountID:       accountID,
+			AfterOccurredAt: after.UpdatedAt,
+			AfterSeq:        after.ID,
+			RowLimit:        int32(limit),
+		})
+	})
+	if err != nil {
+		return nil, after, err
+	}
+	events := make([]models.AccountEvent, len(rows))
+	for i, row := range rows {
+		events[i] = toAccountEvent(row)
+		after = ChangeCursor{UpdatedAt: row.OccurredAt, ID: row.Seq}
+	}
+	return events, after, nil
+}
+
+func toAccountEvent(row sqlc.ListAccountTimelineRow) models.AccountEvent {
+	e := models.AccountEvent{Type: models.AccountEventType(row.EventType), OccurredAt: row.OccurredAt}
+	var amount *models.Amount
+	if row.Amount.Valid {
+		a := models.Amount(row.Amount.Float64)
+		amount = &a
+	}
+	switch e.Type {
+	case models.AccountEventDebit, models.AccountEventCredit:
+		e.Amount = amount
+		e.TransactionID = row.Reference
+		e.Kind = models.TransactionKind(row.Detail)
+		e.CounterpartyAccountID = row.CounterpartyAccountID.Int64
+	case models.AccountEventAdjustment:
+		e.Amount = amount
+		e.AdjustmentID = row.Reference
+		e.ReasonCode = models.ReasonCode(row.Detail)
+		e.Reason = row.Note
+	case models.AccountEventTokenIssued:
+		e.TokenID = row.Reference
+		e.Limit = amount
+	case models.AccountEventTokenRevoked:
+		e.TokenID = row.Reference
+	default:
+		e.Amount = amount
+	}
+	return e
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository/sqlc"
+)
+
+// PostgresTopUpRepository is an implementation of TopUpRepository for PostgreSQL.
+type PostgresTopUpRepository struct {
+	q        *sqlc.Queries
+	queryLog *QueryLogger
+}
+
+// NewPostgresTopUpRepository creates a new PostgresTopUpRepository.
+func NewPostgresTopUpRepository(db *sql.DB, opts ...Option) *PostgresTopUpRepository {
+	o := applyOptions(opts)
+	return &PostgresTopUpRepository{q: sqlc.New(db), queryLog: o.queryLog}
+}
+
+// SetTopUpRule creates or replaces the top-up rule of rule.AccountID.
+func (r *PostgresTopUpRepository) SetTopUpRule(rule models.TopUpRule) (*models.TopUpRule, error) {
+	defer r.queryLog.observe("SetTopUpRule", time.Now())
+	row, err := r.q.SetTopUpRule(context.Background(), sqlc.SetTopUpRuleParams{
+		AccountID:        rule.AccountID,
+		Threshold:        float64(rule.Threshold),
+		Amount:           float64(rule.Amount),
+		FundingAccountID: rule.FundingAccountID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toTopUpRule(row), nil
+}
+
+func (r *PostgresTopUpRepository) GetTopUpRule(accountID int64) (*models.TopUpRule, error) {
+	defer r.queryLog.observe("GetTopUpRule", time.Now())
+	row, err := r.q.GetTopUpRule(context.Background(), accountID)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("top-up rule of account %d %w", accountID, ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return toTopUpRule(row), nil
+}
+
+func (r *PostgresTopUpRepository) DeleteTopUpRule(accountID int64) error {
+	defer r.queryLog.observe("DeleteTopUpRule", time.Now())
+	n, err := r.q.DeleteTopUpRule(context.Background(), accountID)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("top-up rule of account %d %w", accountID, ErrNotFound)
+	}
+	return nil
+}
+
+func toTopUpRule(row sqlc.TopUpRule) *models.TopUpRule {
+	return &models.TopUpRule{
+		AccountID:        row.AccountID,
+		Threshold:        models.Amount(row.Threshold),
+		Amount:           models.Amount(row.Amount),
+		FundingAccountID: row.FundingAccountID,
+		UpdatedAt:        row.UpdatedAt,
+	}
+}
@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository/sqlc"
+)
+
+// ErrAlreadyReplayed is returned when recording the replay of a transaction
+// attempt that has already been replayed, or the outcome of a forwarded
+// transfer that already has one.
+var ErrAlreadyReplayed = errors.New("already replayed")
+
+// PostgresTransactionAttemptRepository is an implementation of
+// TransactionAttemptRepository for PostgreSQL.
+type PostgresTransactionAttemptRepository struct {
+	q        *sqlc.Queries
+	queryLog *QueryLogger
+}
+
+// NewPostgresTransactionAttemptRepository creates a new PostgresTransactionAttemptRepository.
+func NewPostgresTransactionAttemptRepository(db *sql.DB, opts ...Option) *PostgresTransactionAttemptRepository {
+	o := applyOptions(opts)
+	return &PostgresTransactionAttemptRepository{q: sqlc.New(db), queryLog: o.queryLog}
+}
+
+func (r *PostgresTransactionAttemptRepository) InsertTransactionAttempt(a models.TransactionAttempt) error {
+	defer r.queryLog.observe("InsertTransactionAttempt", time.Now())
+	return r.q.InsertTransactionAttempt(context.Background(), sqlc.InsertTransactionAttemptParams{
+		SourceAccountID:      a.SourceAccountID,
+		DestinationAccountID: a.DestinationAccountID,
+		Amount:               float64(a.Amount),
+		ReasonCode:           string(a.Code),
+		Error:                a.Error,
+		ApiKey:               a.APIKey,
+		Tenant:               a.Tenant,
+		RequestID:            a.RequestID,
+	})
+}
+
+// ListTransactionAttempts returns up to limit attempts matching filter, oldest
+// first, starting after the cursor, and the cursor of the last one.
+func (r *PostgresTransactionAttemptRepository) ListTransactionAttempts(filter models.TransactionAttemptFilter, after ChangeCursor, limit int) ([]models.TransactionAttempt, ChangeCursor, error) {
+	defer r.queryLog.observe("ListTransactionAttempts", time.Now())
+	f := attemptFilter(filter)
+	rows, err := r.q.ListTransactionAttempts(context.Background(), sqlc.ListTransactionAttemptsParams{
+		AfterCreatedAt: after.UpdatedAt,
+		AfterID:        after.ID,
+		ReasonCode:     f.ReasonCode,
+		ApiKey:         f.ApiKey,
+		Tenant:         f.Tenant,
+		AccountID:      f.AccountID,
+		Since:          f.Since,
+		Until:          f.Until,
+		RowLimit:       int32(limit),
+	})
+	if err != nil {
+		return nil, after, err
+	}
+	attempts := make([]models.TransactionAttempt, len(rows))
+	for i, row := range rows {
+		attempts[i] = toTransactionAttempt(row)
+	}
+	if len(rows) > 0 {
+		last := rows[len(rows)-1]
+		after = ChangeCursor{UpdatedAt: last.CreatedAt, ID: last.ID}
+	}
+	return attempts, after, nil
+}
+
+// ListTransactionAttemptsByRequest returns the attempts made by the request with
+// the given X-Request-ID, oldest first.
+func (r *PostgresTransactionAttemptRepository) ListTransactionAttemptsByRequest(requestID string) ([]models.TransactionAttempt, error) {
+	defer r.queryLog.observe("ListTransactionAttemptsByRequest", time.Now())
+	rows, err := r.q.ListTransactionAttemptsByRequest(context.Background(), requestID)
+	if err != nil {
+		return nil, err
+	}
+	attempts := make([]models.TransactionAttempt, len(rows))
+	for i, row := range rows {
+		attempts[i] = toTransactionAttempt(row)
+	}
+	return attempts, nil
+}
+
+func (r *PostgresTransactionAttemptRepository) CountTransactionAttempts(filter models.TransactionAttemptFilter) (int64, error) {
+	defer r.queryLog.observe("CountTransactionAttempts", time.Now())
+	return r.q.CountTransactionAttempts(context.Background(), attemptFilter(filter))
+}
+
+// TransactionAttemptStats counts and sums the attempts matching filter per value
+// of groupBy, most frequent first.
+func (r *PostgresTransactionAttemptRepository) TransactionAttemptStats(groupBy models.AttemptGrouping, filter models.TransactionAttemptFilter) ([]models.TransactionAttemptStat, error) {
+	defer r.queryLog.observe("TransactionAttemptStats", time.Now())
+	f := attemptFilter(filter)
+	rows, err := r.q.TransactionAttemptStats(context.Background(), sqlc.TransactionAttemptStatsParams{
+		GroupBy:    string(groupBy),
+		ReasonCode: f.ReasonCode,
+		ApiKey:     f.ApiKey,
+		Tenant:     f.Tenant,
+		AccountID:  f.AccountID,
+		Since:      f.Since,
+		Until:      f.Until,
+	})
+	if err != nil {
+		return nil, err
+	}
+	stats := make([]models.TransactionAttemptStat, len(rows))
+	for i, row := range rows {
+		stats[i] = models.TransactionAttemptStat{Key: row.Key, Attempts: row.Attempts, TotalAmount: models.Amount(row.TotalAmount)}
+	}
+	return stats, nil
+}
+
+func (r *PostgresTransactionAttemptRepository) GetTransactionAttempt(id int64) (*models.TransactionAttempt, error) {
+	defer r.queryLog.observe("GetTransactionAttempt", time.Now())
+	row, err := r.q.GetTransactionAttempt(context.Background(), id)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("transaction attempt %d %w", id, ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	attempt := toTransactionAttempt(row)
+	return &attempt, nil
+}
+
+// GetTransactionAttemptReplay returns the ID of the transfer that replayed the
+// attempt, or an error wrapping ErrNotFound if it has not been replayed.
+func (r *PostgresTransactionAttemptRepository) GetTransactionAttemptReplay(id int64) (string, error) {
+	defer r.queryLog.observe("GetTransactionAttemptReplay", time.Now())
+	transactionID, err := r.q.GetTransactionAttemptReplay(context.Background(), id)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("replay of transaction attempt %d %w", id, ErrNotFound)
+	}
+	return transactionID, err
+}
+
+// InsertTransactionAttemptReplayTx records that the transfer transactionID
+// replayed the attempt, in the transaction that made the transfer. It returns
+// ErrAlreadyReplayed if the attempt was replayed before, so the caller can roll
+// the transfer back.
+func (r *PostgresTransactionAttemptRepository) InsertTransactionAttemptReplayTx(tx *sql.Tx, id int64, transactionID string) error {
+	defer r.queryLog.observe("InsertTransactionAttemptReplayTx", time.Now())
+	n, err := r.q.WithTx(tx).InsertTransactionAttemptReplay(context.Background(), sqlc.InsertTransactionAttemptReplayParams{
+		AttemptID:     id,
+		TransactionID: transactionID,
+	})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("transaction attempt %d %w", id, ErrAlreadyReplayed)
+	}
+	return nil
+}
+
+// attemptFilter maps the zero fields of filter to NULL, which matches all.
+func attemptFilter(filter models.TransactionAttemptFilter) sqlc.CountTransactionAttemptsParams {
+	return sqlc.CountTransactionAttemptsParams{
+		ReasonCode: nullString(string(filter.Code)),
+		ApiKey:     nullString(filter.APIKey),
+		Tenant:     nullString(filter.Tenant),
+		AccountID:  sql.NullInt64{Int64: filter.AccountID, Valid: filter.AccountID != 0},
+		Since:      sql.NullTime{Time: filter.Since, Valid: !filter.Since.IsZero()},
+		Until:      sql.NullTime{Time: filter.Until, Valid: !filter.Until.IsZero()},
+	}
+}
+
+func toTransactionAttempt(row sqlc.TransactionAttempt) models.TransactionAttempt {
+	return models.TransactionAttempt{
+		ID:                   row.ID,
+		SourceAccountID:      row.SourceAccountID,
+		DestinationAccountID: row.DestinationAccountID,
+		Amount:               models.Amount(row.Amount),
+		Code:                 models.ReasonCode(row.ReasonCode),
+		Error:                row.Error,
+		APIKey:               row.ApiKey,
+		Tenant:               row.Tenant,
+		RequestID:            row.RequestID,
+		CreatedAt:            row.CreatedAt,
+	}
+}
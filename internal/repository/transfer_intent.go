@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository/sqlc"
+)
+
+// ErrAlreadyCompleted is returned when completing a transfer intent, or paying
+// a cashback credit of one, that was completed or paid before.
+var ErrAlreadyCompleted = errors.New("already completed")
+
+// PostgresTransferIntentRepository is an implementation of
+// TransferIntentRepository for PostgreSQL.
+type PostgresTransferIntentRepository struct {
+	q        *sqlc.Queries
+	queryLog *QueryLogger
+}
+
+// NewPostgresTransferIntentRepository creates a new PostgresTransferIntentRepository.
+func NewPostgresTransferIntentRepository(db *sql.DB, opts ...Option) *PostgresTransferIntentRepository {
+	o := applyOptions(opts)
+	return &PostgresTransferIntentRepository{q: sqlc.New(db), queryLog: o.queryLog}
+}
+
+// InsertTransferIntentTx records intent in the transaction of the transfer
+// that sets it off, so that the intent commits if and only if the transfer
+// does.
+func (r *PostgresTransferIntentRepository) InsertTransferIntentTx(tx *sql.Tx, intent models.TransferIntent) (*models.TransferIntent, error) {
+	defer r.queryLog.observe("InsertTransferIntentTx", time.Now())
+	row, err := r.q.WithTx(tx).InsertTransferIntent(context.Background(), sqlc.InsertTransferIntentParams{
+		Kind:          string(intent.Kind),
+		AccountID:     intent.AccountID,
+		TransactionID: intent.TransactionID,
+		Amount:        float64(intent.Amount),
+		CreatedAt:     intent.CreatedAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toTransferIntent(row), nil
+}
+
+// CompleteTransferIntentTx completes the intent in the transaction of the
+// follow-up transfer it is for. It returns ErrAlreadyCompleted if the intent
+// was completed before, so the caller can roll the transfer back.
+func (r *PostgresTransferIntentRepository) CompleteTransferIntentTx(tx *sql.Tx, id int64, at time.Time) error {
+	defer r.queryLog.observe("CompleteTransferIntentTx", time.Now())
+	return completeTransferIntent(r.q.WithTx(tx), id, at)
+}
+
+// CompleteTransferIntent completes an intent that turned out to need no
+// transfer, e.g. a top-up of an account refilled since.
+func (r *PostgresTransferIntentRepository) CompleteTransferIntent(id int64, at time.Time) error {
+	defer r.queryLog.observe("CompleteTransferIntent", time.Now())
+	return completeTransferIntent(r.q, id, at)
+}
+
+func completeTransferIntent(q *sqlc.Queries, id int64, at time.Time) error {
+	n, err := q.CompleteTransferIntent(context.Background(), sqlc.CompleteTransferIntentParams{ID: id, CompletedAt: at})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("transfer intent %d %w", id, ErrAlreadyCompleted)
+	}
+	return nil
+}
+
+// InsertTransferIntentCreditTx records that the transfer transactionID paid
+// the cashback of campaignID owed by the intent, in the transaction of that
+// transfer. It returns ErrAlreadyCompleted if the campaign paid it before.
+func (r *PostgresTransferIntentRepository) InsertTransferIntentCreditTx(tx *sql.Tx, id, campaignID int64, transactionID string) error {
+	defer r.queryLog.observe("InsertTransferIntentCreditTx", time.Now())
+	n, err := r.q.WithTx(tx).InsertTransferIntentCredit(context.Background(), sqlc.InsertTransferIntentCreditParams{
+		IntentID:      id,
+		CampaignID:    campaignID,
+		TransactionID: transactionID,
+	})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("cashback of campaign %d for transfer intent %d %w", campaignID, id, ErrAlreadyCompleted)
+	}
+	return nil
+}
+
+// ListPendingTransferIntents returns up to limit intents recorded before
+// createdBefore and not completed yet, in id order after afterID.
+func (r *PostgresTransferIntentRepository) ListPendingTransferIntents(createdBefore time.Time, afterID int64, limit int) ([]models.TransferIntent, error) {
+	defer r.queryLog.observe("ListPendingTransferIntents", time.Now())
+	rows, err := r.q.ListPendingTransferIntents(context.Background(), sqlc.ListPendingTransferIntentsParams{
+		CreatedBefore: createdBefore,
+		AfterID:       afterID,
+		RowLimit:      int32(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+	intents := make([]models.TransferIntent, len(rows))
+	for i, row := range rows {
+		intents[i] = *toTransferIntent(row)
+	}
+	return intents, nil
+}
+
+func toTransferIntent(row sqlc.TransferIntent) *models.TransferIntent {
+	intent := &models.TransferIntent{
+		ID:            row.ID,
+		Kind:          models.TransferIntentKind(row.Kind),
+		AccountID:     row.AccountID,
+		TransactionID: row.TransactionID,
+		Amount:        models.Amount(row.Amount),
+		CreatedAt:     row.CreatedAt,
+	}
+	if row.CompletedAt.Valid {
+		intent.CompletedAt = &row.CompletedAt.Time
+	}
+	return intent
+}
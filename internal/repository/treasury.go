@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository/sqlc"
+)
+
+// PostgresTreasuryRepository is an implementation of TreasuryRepository for PostgreSQL.
+type PostgresTreasuryRepository struct {
+	reads    router
+	queryLog *QueryLogger
+}
+
+// NewPostgresTreasuryRepository creates a new PostgresTreasuryRepository.
+func NewPostgresTreasuryRepository(db *sql.DB, opts ...Option) *PostgresTreasuryRepository {
+	o := applyOptions(opts)
+	return &PostgresTreasuryRepository{reads: newRouter(db, o), queryLog: o.queryLog}
+}
+
+// BalancesByType totals the balances of all accounts, closed ones included, by
+// account type, in the configured currency.
+func (r *PostgresTreasuryRepository) BalancesByType(ctx context.Context, suspenseID, reimbursementID int64) ([]models.TreasuryPosition, error) {
+	defer r.queryLog.observe("TreasuryBalancesByType", time.Now())
+	rows, err := read(ctx, r.reads, "TreasuryBalancesByType", func(ctx context.Context, q *sqlc.Queries) ([]sqlc.TreasuryBalancesByTypeRow, error) {
+		return q.TreasuryBalancesByType(ctx, sqlc.TreasuryBalancesByTypeParams{SuspenseID: suspenseID, ReimbursementID: reimbursementID})
+	})
+	if err != nil {
+		return nil, err
+	}
+	currency := models.CurrentCurrency().Code
+	positions := make([]models.TreasuryPosition, len(rows))
+	for i, row := range rows {
+		positions[i] = models.TreasuryPosition{
+			Currency:    currency,
+			AccountType: models.AccountType(row.AccountType),
+			Accounts:    row.Accounts,
+			Balance:     models.Amount(row.Balance),
+		}
+	}
+	return positions, nil
+}
+
+// LargestAccounts returns the limit open accounts with the largest balances,
+// largest first.
+func (r *PostgresTreasuryRepository) LargestAccounts(ctx context.Context, suspenseID, reimbursementID int64, limit int) ([]models.TreasuryAccount, error) {
+	defer r.queryLog.observe("TreasuryLargestAccounts", time.Now())
+	rows, err := read(ctx, r.reads, "TreasuryLargestAccounts", func(ctx context.Context, q *sqlc.Queries) ([]sqlc.TreasuryLargestAccountsRow, error) {
+		return q.TreasuryLargestAccounts(ctx, sqlc.TreasuryLargestAccountsParams{
+			SuspenseID:      suspenseID,
+			ReimbursementID: reimbursementID,
+			RowLimit:        int32(limit),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	currency := models.CurrentCurrency().Code
+	accounts := make([]models.TreasuryAccount, len(rows))
+	for i, row := range rows {
+		accounts[i] = models.TreasuryAccount{
+			AccountID:   row.AccountID,
+			DisplayName: row.DisplayName,
+			AccountType: models.AccountType(row.AccountType),
+			Currency:    currency,
+			Balance:     models.Amount(row.Balance),
+		}
+	}
+	return accounts, nil
+}
+
+// DailyFlows totals the money into and out of each account type per calendar
+// day in loc, for the transactions created since since. Days and types without
+// transactions are left out.
+func (r *PostgresTreasuryRepository) DailyFlows(ctx context.Context, suspenseID, reimbursementID int64, since time.Time, loc *time.Location) ([]models.DailyFlow, error) {
+	defer r.queryLog.observe("TreasuryDailyFlows", time.Now())
+	rows, err := read(ctx, r.reads, "TreasuryDailyFlows", func(ctx context.Context, q *sqlc.Queries) ([]sqlc.TreasuryDailyFlowsRow, error) {
+		return q.TreasuryDailyFlows(ctx, sqlc.TreasuryDailyFlowsParams{
+			SuspenseID:      suspenseID,
+			ReimbursementID: reimbursementID,
+			Timezone:        loc.String(),
+			Since:           since,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	flows := make([]models.DailyFlow, len(rows))
+	for i, row := range rows {
+		flows[i] = models.DailyFlow{
+			Date:        row.Day.Format(time.DateOnly),
+			AccountType: models.AccountType(row.AccountType),
+			Inflow:      models.Amount(row.Inflow),
+			Outflow:     models.Amount(row.Outflow),
+			Net:         models.Amount(row.Inflow - row.Outflow),
+		}
+	}
+	return flows, nil
+}
+
+// SuspenseFloat returns the unresolved inbound payments held on the suspense
+// account. The caller fills in the account and its balance.
+func (r *PostgresTreasuryRepository) SuspenseFloat(ctx context.Context) (*models.TreasuryFloat, error) {
+	defer r.queryLog.observe("TreasurySuspenseFloat", time.Now())
+	row, err := read(ctx, r.reads, "TreasurySuspenseFloat", func(ctx context.Context, q *sqlc.Queries) (sqlc.TreasurySuspenseFloatRow, error) {
+		return q.TreasurySuspenseFloat(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &models.TreasuryFloat{AccountType: models.AccountTypeSuspense, Outstanding: models.Amount(row.Amount), OutstandingItems: row.Items}, nil
+}
+
+// ReimbursementFloat returns the reimbursements awaiting a decision. The caller
+// fills in the account and its balance.
+func (r *PostgresTreasuryRepository) ReimbursementFloat(ctx context.Context) (*models.TreasuryFloat, error) {
+	defer r.queryLog.observe("TreasuryReimbursementFloat", time.Now())
+	row, err := read(ctx, r.reads, "TreasuryReimbursementFloat", func(ctx context.Context, q *sqlc.Queries) (sqlc.TreasuryReimbursementFloatRow, error) {
+		return q.TreasuryReimbursementFloat(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &models.TreasuryFloat{AccountType: models.AccountTypeReimbursement, Outstanding: models.Amount(row.Amount), OutstandingItems: row.Items}, nil
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository/sqlc"
+)
+
+// PostgresUsageRepository is an implementation of UsageRepository for PostgreSQL.
+type PostgresUsageRepository struct {
+	q        *sqlc.Queries
+	queryLog *QueryLogger
+}
+
+// NewPostgresUsageRepository creates a new PostgresUsageRepository.
+func NewPostgresUsageRepository(db *sql.DB, opts ...Option) *PostgresUsageRepository {
+	o := applyOptions(opts)
+	return &PostgresUsageRepository{q: sqlc.New(db), queryLog: o.queryLog}
+}
+
+// AddUsage adds the counters in u to the stored totals of its period, API key
+// and tenant.
+func (r *PostgresUsageRepository) AddUsage(u models.Usage) error {
+	defer r.queryLog.observe("AddUsage", time.Now())
+	period, err := parsePeriod(u.Period)
+	if err != nil {
+		return err
+	}
+	return r.q.AddUsage(context.Background(), sqlc.AddUsageParams{
+		Period: period,
+		ApiKey: u.APIKey,
+		Tenant: u.Tenant,
+		Calls:  u.Calls,
+		Volume: float64(u.Volume),
+	})
+}
+
+// GetKeyUsage returns the calls and volume of an API key in the period, summed
+// over its tenants.
+func (r *PostgresUsageRepository) GetKeyUsage(period, apiKey string) (int64, float64, error) {
+	defer r.queryLog.observe("GetKeyUsage", time.Now())
+	p, err := parsePeriod(period)
+	if err != nil {
+		return 0, 0, err
+	}
+	row, err := r.q.GetKeyUsage(context.Background(), sqlc.GetKeyUsageParams{Period: p, ApiKey: apiKey})
+	return row.Calls, row.Volume, err
+}
+
+// GetTenantUsage returns the calls and volume of a tenant in the period, summed
+// over its API keys.
+func (r *PostgresUsageRepository) GetTenantUsage(period, tenant string) (int64, float64, error) {
+	defer r.queryLog.observe("GetTenantUsage", time.Now())
+	p, err := parsePeriod(period)
+	if err != nil {
+		return 0, 0, err
+	}
+	row, err := r.q.GetTenantUsage(context.Background(), sqlc.GetTenantUsageParams{Period: p, Tenant: tenant})
+	return row.Calls, row.Volume, err
+}
+
+// ListUsage returns the usage of every API key and tenant in the period.
+func (r *PostgresUsageRepository) ListUsage(period string) ([]models.Usage, error) {
+	defer r.queryLog.observe("ListUsage", time.Now())
+	p, err := parsePeriod(period)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.q.ListUsage(context.Background(), p)
+	if err != nil {
+		return nil, err
+	}
+	usage := make([]models.Usage, len(rows))
+	for i, row := range rows {
+		usage[i] = models.Usage{
+			Period:    row.Period.Format(models.UsagePeriodLayout),
+			APIKey:    row.ApiKey,
+			Tenant:    row.Tenant,
+			Calls:     row.Calls,
+			Volume:    models.Amount(row.Volume),
+			UpdatedAt: row.UpdatedAt,
+		}
+	}
+	return usage, nil
+}
+
+// parsePeriod converts a "2006-01" period to the first day of the month.
+func parsePeriod(period string) (time.Time, error) {
+	p, err := time.Parse(models.UsagePeriodLayout, period)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid usage period %q, expected YYYY-MM", period)
+	}
+	return p, nil
+}
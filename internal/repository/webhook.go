@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository/sqlc"
+)
+
+// PostgresWebhookRepository is an implementation of WebhookRepository for PostgreSQL.
+type PostgresWebhookRepository struct {
+	tenants  tenantScope
+	queryLog *QueryLogger
+}
+
+// NewPostgresWebhookRepository creates a new PostgresWebhookRepository.
+func NewPostgresWebhookRepository(db *sql.DB, opts ...Option) *PostgresWebhookRepository {
+	o := applyOptions(opts)
+	return &PostgresWebhookRepository{tenants: newTenantScope(db, o), queryLog: o.queryLog}
+}
+
+// InsertWebhook stores a verified subscription.
+func (r *PostgresWebhookRepository) InsertWebhook(w models.Webhook) (*models.Webhook, error) {
+	defer r.queryLog.observe("InsertWebhook", time.Now())
+	ctx := context.Background()
+	var accountID sql.NullInt64
+	if w.AccountID != nil {
+		accountID = sql.NullInt64{Int64: *w.AccountID, Valid: true}
+	}
+	row, err := inTenant(ctx, r.tenants, w.Tenant, func(q *sqlc.Queries) (sqlc.Webhook, error) {
+		return q.InsertWebhook(ctx, sqlc.InsertWebhookParams{
+			Tenant:     w.Tenant,
+			Url:        w.URL,
+			EventTypes: w.EventTypes,
+			VerifiedAt: w.VerifiedAt,
+			AccountID:  accountID,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	webhook := toWebhook(row)
+	return &webhook, nil
+}
+
+func (r *PostgresWebhookRepository) GetWebhook(id int64, tenant string) (*models.Webhook, error) {
+	defer r.queryLog.observe("GetWebhook", time.Now())
+	ctx := context.Background()
+	row, err := inTenant(ctx, r.tenants, tenant, func(q *sqlc.Queries) (sqlc.Webhook, error) {
+		return q.GetWebhook(ctx, sqlc.GetWebhookParams{ID: id, Tenant: tenant})
+	})
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("webhook %d %w", id, ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	webhook := toWebhook(row)
+	return &webhook, nil
+}
+
+func (r *PostgresWebhookRepository) ListWebhooks(tenant string) ([]models.Webhook, error) {
+	defer r.queryLog.observe("ListWebhooks", time.Now())
+	ctx := context.Background()
+	rows, err := inTenant(ctx, r.tenants, tenant, func(q *sqlc.Queries) ([]sqlc.Webhook, error) {
+		return q.ListWebhooks(ctx, tenant)
+	})
+	if err != nil {
+		return nil, err
+	}
+	webhooks := make([]models.Webhook, len(rows))
+	for i, row := range rows {
+		webhooks[i] = toWebhook(row)
+	}
+	return webhooks, nil
+}
+
+func (r *PostgresWebhookRepository) DeleteWebhook(id int64, tenant string) error {
+	defer r.queryLog.observe("DeleteWebhook", time.Now())
+	ctx := context.Background()
+	n, err := inTenant(ctx, r.tenants, tenant, func(q *sqlc.Queries) (int64, error) {
+		return q.DeleteWebhook(ctx, sqlc.DeleteWebhookParams{ID: id, Tenant: tenant})
+	})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("webhook %d %w", id, ErrNotFound)
+	}
+	return nil
+}
+
+// GetAccountOwner returns the tenant that opened an open account and the API
+// key that did, empty if none did.
+func (r *PostgresWebhookRepository) GetAccountOwner(accountID int64) (string, string, error) {
+	defer r.queryLog.observe("GetAccountOwner", time.Now())
+	row, err := r.tenants.q.GetAccountOwner(context.Background(), accountID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", "", fmt.Errorf("account with ID %d %w", accountID, ErrNotFound)
+	}
+	if err != nil {
+		return "", "", err
+	}
+	return row.Tenant, row.OwnerKey, nil
+}
+
+func toWebhook(row sqlc.Webhook) models.Webhook {
+	eventTypes := row.EventTypes
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+	w := models.Webhook{
+		ID:         row.ID,
+		Tenant:     row.Tenant,
+		URL:        row.Url,
+		EventTypes: eventTypes,
+		VerifiedAt: row.VerifiedAt,
+		CreatedAt:  row.CreatedAt,
+	}
+	if row.AccountID.Valid {
+		w.AccountID = &row.AccountID.Int64
+	}
+	return w
+}
@@ -0,0 +1,68 @@
+// Package revaluation runs the end-of-day revaluation of balances to the base
+// currency. Once a day has closed and its exchange rate is in, the job revalues
+// it; until then it tries again every interval.
+package revaluation
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/clock"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository"
+	"github.com/nehciyy/intrapay/internal/service"
+)
+
+// Revaluer revalues the balances held at the close of a day, YYYY-MM-DD.
+type Revaluer interface {
+	Revalue(date string) (*models.Revaluation, error)
+}
+
+// Job revalues the last closed day of a time zone.
+type Job struct {
+	revaluer Revaluer
+	clock    clock.Clock
+	loc      *time.Location
+	logger   *log.Logger
+}
+
+// New creates a Job closing days at midnight in loc.
+func New(revaluer Revaluer, clk clock.Clock, loc *time.Location, logger *log.Logger) *Job {
+	return &Job{revaluer: revaluer, clock: clk, loc: loc, logger: logger}
+}
+
+// RevalueClosedDay revalues the day before today in the job's time zone. A day
+// revalued already is left alone, and one without a rate yet, or whose live
+// rate is stale or cannot be fetched, is left for the next run; neither is an
+// error. A day skipped altogether, e.g. because its
+// rate never came in, is covered by the gains and losses of the next one.
+func (j *Job) RevalueClosedDay() error {
+	day := j.clock.Now().In(j.loc).AddDate(0, 0, -1).Format(time.DateOnly)
+	_, err := j.revaluer.Revalue(day)
+	switch {
+	case errors.Is(err, repository.ErrAlreadyRevalued):
+		return nil
+	case errors.Is(err, service.ErrNoFXRate), errors.Is(err, service.ErrStaleFXRate), errors.Is(err, service.ErrFXRateUnavailable):
+		j.logger.Printf("revaluation: waiting for the rate of %s: %v", day, err)
+		return nil
+	}
+	return err
+}
+
+// Run revalues the last closed day every interval until ctx is cancelled.
+func (j *Job) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := j.RevalueClosedDay(); err != nil {
+			j.logger.Printf("revaluation: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
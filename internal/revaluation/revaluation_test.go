@@ -0,0 +1,56 @@
+package revaluation
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nehciyy/intrapay/internal/clock"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository"
+	"github.com/nehciyy/intrapay/internal/service"
+)
+
+// fakeRevaluer records the days it is asked to revalue and fails with err.
+type fakeRevaluer struct {
+	days []string
+	err  error
+}
+
+func (r *fakeRevaluer) Revalue(date string) (*models.Revaluation, error) {
+	r.days = append(r.days, date)
+	if r.err != nil {
+		return nil, r.err
+	}
+	return &models.Revaluation{Date: date}, nil
+}
+
+func TestRevalueClosedDay(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	assert.NoError(t, err)
+	var logs bytes.Buffer
+	revaluer := &fakeRevaluer{}
+	// 01:00 on June 15 in Tokyo is still June 14 in UTC.
+	job := New(revaluer, clock.NewFake(time.Date(2026, 6, 14, 16, 0, 0, 0, time.UTC)), tokyo, log.New(&logs, "", 0))
+
+	assert.NoError(t, job.RevalueClosedDay())
+	assert.Equal(t, []string{"2026-06-14"}, revaluer.days)
+
+	revaluer.err = fmt.Errorf("2026-06-14 %w", repository.ErrAlreadyRevalued)
+	assert.NoError(t, job.RevalueClosedDay())
+
+	revaluer.err = fmt.Errorf("%w: USD/EUR of 2026-06-14", service.ErrNoFXRate)
+	assert.NoError(t, job.RevalueClosedDay(), "the rate may come in later")
+	assert.Contains(t, logs.String(), "waiting for the rate of 2026-06-14")
+
+	revaluer.err = fmt.Errorf("%w: the latest USD/EUR rate is of 2026-06-10T00:00:00Z", service.ErrStaleFXRate)
+	assert.NoError(t, job.RevalueClosedDay(), "a fresh rate may come in later")
+
+	revaluer.err = errors.New("connection refused")
+	assert.Error(t, job.RevalueClosedDay())
+}
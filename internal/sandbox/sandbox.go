@@ -0,0 +1,32 @@
+// Package sandbox tells the requests of test-mode API keys from live ones.
+// Integrators exercise the API with test keys against test-mode accounts, whose
+// money never mixes with that of live accounts: the database refuses transfers
+// between the two, and live reports and listings leave test data out.
+package sandbox
+
+import (
+	"context"
+	"strings"
+)
+
+// TestKeyPrefix starts the API keys of test mode, e.g. test_8f2c1a.
+const TestKeyPrefix = "test_"
+
+// IsTestKey reports whether apiKey is a test-mode key.
+func IsTestKey(apiKey string) bool {
+	return strings.HasPrefix(apiKey, TestKeyPrefix)
+}
+
+type testModeKey struct{}
+
+// WithTestMode returns ctx marked as the context of a test-mode request.
+func WithTestMode(ctx context.Context) context.Context {
+	return context.WithValue(ctx, testModeKey{}, true)
+}
+
+// Livemode reports whether ctx is that of a live request: one not marked with
+// WithTestMode.
+func Livemode(ctx context.Context) bool {
+	test, _ := ctx.Value(testModeKey{}).(bool)
+	return !test
+}
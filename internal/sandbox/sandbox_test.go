@@ -0,0 +1,20 @@
+package sandbox
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsTestKey(t *testing.T) {
+	assert.True(t, IsTestKey("test_8f2c1a"))
+	assert.False(t, IsTestKey("live_8f2c1a"))
+	assert.False(t, IsTestKey("anonymous"))
+}
+
+func TestLivemode(t *testing.T) {
+	ctx := context.Background()
+	assert.True(t, Livemode(ctx))
+	assert.False(t, Livemode(WithTestMode(ctx)))
+}
@@ -0,0 +1,110 @@
+// Package security records security events: failed and locked-out
+// authentications, revoked credentials, break-glass freezes and impersonation.
+// They are stored apart from the business audit trail and, with a webhook
+// configured, forwarded to it as they happen, for a SIEM to ingest.
+//
+// Emitting an event never blocks the request that caused it: events are
+// queued, and written and forwarded in the background. A flood of events, e.g.
+// of failed authentications during credential stuffing, fills the queue, and
+// the events that do not fit are dropped and counted rather than slowing the
+// API down.
+package security
+
+import (
+	"context"
+	"log"
+	"sync/atomic"
+
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/webhook"
+)
+
+// queueSize is how many events may wait to be written.
+const queueSize = 1024
+
+// Store records security events.
+type Store interface {
+	InsertSecurityEvent(e models.SecurityEvent) (*models.SecurityEvent, error)
+}
+
+// Stream writes the security events emitted to it to a Store and forwards
+// them to a webhook.
+type Stream struct {
+	store      Store
+	logger     *log.Logger
+	webhook    *webhook.Client
+	webhookURL string
+
+	queue   chan models.SecurityEvent
+	dropped atomic.Int64
+}
+
+// Option configures a Stream.
+type Option func(*Stream)
+
+// WithWebhook forwards every event recorded to url. Each is tried once; an
+// event that could not be forwarded can still be read from the Store.
+func WithWebhook(client *webhook.Client, url string) Option {
+	return func(s *Stream) { s.webhook, s.webhookURL = client, url }
+}
+
+// NewStream creates a Stream recording events in store once it runs.
+func NewStream(store Store, logger *log.Logger, opts ...Option) *Stream {
+	s := &Stream{store: store, logger: logger, queue: make(chan models.SecurityEvent, queueSize)}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Emit queues e to be recorded. It does not wait: when the queue is full, e is
+// dropped. Emitting to a nil Stream does nothing.
+func (s *Stream) Emit(e models.SecurityEvent) {
+	if s == nil {
+		return
+	}
+	select {
+	case s.queue <- e:
+	default:
+		s.dropped.Add(1)
+	}
+}
+
+// Run records the events emitted until ctx is cancelled, then those still
+// queued.
+func (s *Stream) Run(ctx context.Context) {
+	for {
+		select {
+		case e := <-s.queue:
+			s.record(e)
+		case <-ctx.Done():
+			for {
+				select {
+				case e := <-s.queue:
+					s.record(e)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// record writes e and forwards it to the webhook, reporting the events dropped
+// since the last one recorded.
+func (s *Stream) record(e models.SecurityEvent) {
+	if n := s.dropped.Swap(0); n > 0 {
+		s.logger.Printf("security: dropped %d events, the queue was full", n)
+	}
+	recorded, err := s.store.InsertSecurityEvent(e)
+	if err != nil {
+		s.logger.Printf("security: record %s event: %v", e.Type, err)
+		return
+	}
+	if s.webhook == nil {
+		return
+	}
+	if err := s.webhook.Send(context.Background(), s.webhookURL, recorded.Type, recorded); err != nil {
+		s.logger.Printf("security: forward event %d: %v", recorded.ID, err)
+	}
+}
@@ -0,0 +1,109 @@
+package security
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/webhook"
+)
+
+type fakeStore struct {
+	mu     sync.Mutex
+	events []models.SecurityEvent
+	err    error
+}
+
+func (f *fakeStore) InsertSecurityEvent(e models.SecurityEvent) (*models.SecurityEvent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	e.ID = int64(len(f.events) + 1)
+	e.CreatedAt = time.Date(2026, 3, 14, 9, 0, 0, 0, time.UTC)
+	f.events = append(f.events, e)
+	return &e, nil
+}
+
+// drain records the events queued on s.
+func drain(s *Stream) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s.Run(ctx)
+}
+
+func TestStream(t *testing.T) {
+	var mu sync.Mutex
+	var forwarded []models.SecurityEvent
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get(webhook.EventHeader); got != models.SecurityAuthLockout {
+			t.Errorf("expected event header %s, got %q", models.SecurityAuthLockout, got)
+		}
+		var e models.SecurityEvent
+		json.NewDecoder(r.Body).Decode(&e)
+		mu.Lock()
+		forwarded = append(forwarded, e)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	store := &fakeStore{}
+	s := NewStream(store, log.New(&bytes.Buffer{}, "", 0), WithWebhook(webhook.NewClient(time.Second), srv.URL))
+	s.Emit(models.SecurityEvent{Type: models.SecurityAuthLockout, Actor: "address 192.0.2.1"})
+	drain(s)
+
+	if len(store.events) != 1 || store.events[0].Actor != "address 192.0.2.1" {
+		t.Fatalf("expected the event to be recorded, got %+v", store.events)
+	}
+	if len(forwarded) != 1 || forwarded[0].ID != 1 || forwarded[0].CreatedAt.IsZero() {
+		t.Errorf("expected the recorded event to be forwarded, got %+v", forwarded)
+	}
+}
+
+func TestStream_NotRecorded(t *testing.T) {
+	var buf bytes.Buffer
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("expected an event that was not recorded not to be forwarded")
+	}))
+	defer srv.Close()
+
+	s := NewStream(&fakeStore{err: errors.New("connection refused")}, log.New(&buf, "", 0), WithWebhook(webhook.NewClient(time.Second), srv.URL))
+	s.Emit(models.SecurityEvent{Type: models.SecurityAuthFailure})
+	drain(s)
+
+	if !strings.Contains(buf.String(), "record security.auth_failure event: connection refused") {
+		t.Errorf("expected the failure to be logged, got %q", buf.String())
+	}
+}
+
+func TestStream_Dropped(t *testing.T) {
+	var buf bytes.Buffer
+	store := &fakeStore{}
+	s := NewStream(store, log.New(&buf, "", 0))
+	for i := 0; i < queueSize+5; i++ {
+		s.Emit(models.SecurityEvent{Type: models.SecurityAuthFailure})
+	}
+	drain(s)
+
+	if len(store.events) != queueSize {
+		t.Errorf("expected %d events recorded, got %d", queueSize, len(store.events))
+	}
+	if !strings.Contains(buf.String(), "dropped 5 events") {
+		t.Errorf("expected the dropped events to be logged, got %q", buf.String())
+	}
+}
+
+func TestStream_Nil(t *testing.T) {
+	var s *Stream
+	s.Emit(models.SecurityEvent{Type: models.SecurityAuthFailure})
+}
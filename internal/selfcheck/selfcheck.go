@@ -0,0 +1,252 @@
+// Package selfcheck runs the checks the server makes on boot, such as whether
+// the schema is up to date and the clock agrees with the database's, and
+// reports each as passed, a warning, or a failure with what to do about it.
+// A failure stops the server from starting; warnings are logged and served
+// with the rest of the report on /readyz.
+package selfcheck
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/clock"
+	"github.com/nehciyy/intrapay/internal/migrate"
+)
+
+// Status is the outcome of a check.
+type Status string
+
+const (
+	StatusOK   Status = "ok"
+	StatusWarn Status = "warn"
+	StatusFail Status = "fail"
+)
+
+// Result is the outcome of a check, with what to do about it unless it passed.
+type Result struct {
+	Name    string `json:"name"`
+	Status  Status `json:"status"`
+	Message string `json:"message"`
+	Action  string `json:"action,omitempty"`
+}
+
+// Pass returns a passed check's result.
+func Pass(format string, args ...any) Result {
+	return Result{Status: StatusOK, Message: fmt.Sprintf(format, args...)}
+}
+
+// Warning returns the result of a check that passed with a problem.
+func Warning(action, format string, args ...any) Result {
+	return Result{Status: StatusWarn, Message: fmt.Sprintf(format, args...), Action: action}
+}
+
+// Failure returns a failed check's result.
+func Failure(action, format string, args ...any) Result {
+	return Result{Status: StatusFail, Message: fmt.Sprintf(format, args...), Action: action}
+}
+
+// Check is one named check.
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) Result
+}
+
+// Report is the outcome of the checks run together.
+type Report struct {
+	// Ready is false if any check failed.
+	Ready     bool      `json:"ready"`
+	CheckedAt time.Time `json:"checked_at"`
+	Checks    []Result  `json:"checks"`
+}
+
+// Run runs checks in order and reports their results.
+func Run(ctx context.Context, clk clock.Clock, checks ...Check) Report {
+	report := Report{Ready: true, CheckedAt: clk.Now().UTC(), Checks: make([]Result, 0, len(checks))}
+	for _, c := range checks {
+		report.Add(c.Name, c.Run(ctx))
+	}
+	return report
+}
+
+// Add adds the result of the check name to r.
+func (r *Report) Add(name string, result Result) {
+	result.Name = name
+	if result.Status == StatusFail {
+		r.Ready = false
+	}
+	r.Checks = append(r.Checks, result)
+}
+
+// With returns r with the result of the check name added, leaving r as it is.
+func (r Report) With(name string, result Result) Report {
+	r.Checks = slices.Clip(r.Checks)
+	r.Add(name, result)
+	return r
+}
+
+// Err returns an error listing the failed checks with what to do about each,
+// or nil if none failed.
+func (r Report) Err() error {
+	var failures []string
+	for _, c := range r.Checks {
+		if c.Status == StatusFail {
+			failures = append(failures, fmt.Sprintf("%s: %s\n  -> %s", c.Name, c.Message, c.Action))
+		}
+	}
+	if len(failures) == 0 {
+		return nil
+	}
+	return errors.New("self-check failed:\n" + strings.Join(failures, "\n"))
+}
+
+// Warnings returns the checks that passed with a problem.
+func (r Report) Warnings() []Result {
+	var warnings []Result
+	for _, c := range r.Checks {
+		if c.Status == StatusWarn {
+			warnings = append(warnings, c)
+		}
+	}
+	return warnings
+}
+
+// SchemaVersion checks that every migration has been applied to db, and that
+// its tables and columns are there. With allowSkew a schema behind the
+// migrations is a warning rather than a failure.
+func SchemaVersion(db *sql.DB, migrations []migrate.Migration, allowSkew bool) Check {
+	behind := Failure
+	if allowSkew {
+		behind = Warning
+	}
+	return Check{Name: "schema", Run: func(ctx context.Context) Result {
+		latest := migrations[len(migrations)-1]
+		pending, err := migrate.Pending(ctx, db, migrations)
+		if errors.Is(err, migrate.ErrUntracked) {
+			return behind("compare the schema with cmd/migrate -plan, then record it with cmd/migrate -baseline N",
+				"no record of the migrations applied (schema_migrations is missing)")
+		}
+		if err != nil {
+			return Failure("check that the database is reachable", "reading the migrations applied: %v", err)
+		}
+		if len(pending) > 0 {
+			names := make([]string, len(pending))
+			for i, m := range pending {
+				names[i] = m.Name
+			}
+			return behind("run cmd/migrate, or start with --allow-skew if this release does not need them yet",
+				"%d migrations not applied: %s", len(pending), strings.Join(names, ", "))
+		}
+		diff, err := migrate.Check(ctx, db, migrations)
+		if err != nil {
+			return Failure("check that the database is reachable", "reading the schema: %v", err)
+		}
+		if diff.Behind() {
+			return behind("compare the schema with cmd/migrate -plan and repair what is missing",
+				"schema differs from the migrations: %s", strings.Join(append(diff.Missing, diff.Mismatched...), "; "))
+		}
+		return Pass("version %d (%s), with every table and column", latest.Version, latest.Name)
+	}}
+}
+
+// Indexes warns when db lacks an index the migrations create. Queries work
+// without one, but those it serves scan their table instead.
+func Indexes(db *sql.DB, migrations []migrate.Migration) Check {
+	return Check{Name: "indexes", Run: func(ctx context.Context) Result {
+		expected, err := migrate.ExpectedIndexes(migrations)
+		if err != nil {
+			return Failure("fix the migration", "reading the migrations: %v", err)
+		}
+		live, err := migrate.LiveIndexes(ctx, db)
+		if err != nil {
+			return Failure("check that the database is reachable", "reading the indexes: %v", err)
+		}
+		var d migrate.Diff
+		d.CompareIndexes(expected, live)
+		if len(d.MissingIndexes) > 0 {
+			return Warning("create them with CREATE INDEX CONCURRENTLY as the migrations define them",
+				"%d indexes missing: %s", len(d.MissingIndexes), strings.Join(d.MissingIndexes, "; "))
+		}
+		return Pass("all %d indexes present", len(expected))
+	}}
+}
+
+// sequenceUse lists the sequences of the current schema that have been used,
+// with their last and largest values.
+const sequenceUse = `SELECT sequencename, last_value, max_value
+FROM pg_sequences
+WHERE schemaname = current_schema() AND last_value IS NOT NULL
+ORDER BY sequencename`
+
+// Sequences warns when a sequence has used warnAt of its values and fails at
+// failAt, both fractions of its largest value: a SERIAL column's runs out at
+// 2^31-1.
+func Sequences(db *sql.DB, warnAt, failAt float64) Check {
+	return Check{Name: "sequences", Run: func(ctx context.Context) Result {
+		rows, err := db.QueryContext(ctx, sequenceUse)
+		if err != nil {
+			return Failure("check that the database is reachable", "reading the sequences: %v", err)
+		}
+		defer rows.Close()
+		var warn, fail []string
+		var count int
+		for rows.Next() {
+			var name string
+			var last, max int64
+			if err := rows.Scan(&name, &last, &max); err != nil {
+				return Failure("check that the database is reachable", "reading the sequences: %v", err)
+			}
+			count++
+			used := float64(last) / float64(max)
+			line := fmt.Sprintf("%s at %d of %d (%.0f%%)", name, last, max, used*100)
+			switch {
+			case used >= failAt:
+				fail = append(fail, line)
+			case used >= warnAt:
+				warn = append(warn, line)
+			}
+		}
+		if err := rows.Err(); err != nil {
+			return Failure("check that the database is reachable", "reading the sequences: %v", err)
+		}
+		const widen = "widen the column to BIGINT and run ALTER SEQUENCE ... AS bigint before it runs out"
+		switch {
+		case len(fail) > 0:
+			return Failure(widen, "sequences nearly exhausted: %s", strings.Join(append(fail, warn...), "; "))
+		case len(warn) > 0:
+			return Warning(widen, "sequences running out: %s", strings.Join(warn, "; "))
+		}
+		return Pass("%d sequences below %.0f%% of their range", count, warnAt*100)
+	}}
+}
+
+// Clock compares clk with the database's clock, warning when they are more
+// than warnAfter apart and failing after failAfter. Timestamps are written by
+// both, so sync cursors and expiry depend on their agreeing.
+func Clock(db *sql.DB, clk clock.Clock, warnAfter, failAfter time.Duration) Check {
+	return Check{Name: "clock", Run: func(ctx context.Context) Result {
+		before := clk.Now()
+		var dbNow time.Time
+		if err := db.QueryRowContext(ctx, `SELECT now()`).Scan(&dbNow); err != nil {
+			return Failure("check that the database is reachable", "reading the database clock: %v", err)
+		}
+		after := clk.Now()
+		// The database read its clock somewhere within the round trip.
+		skew := dbNow.Sub(before.Add(after.Sub(before) / 2))
+		if skew < 0 {
+			skew = -skew
+		}
+		const sync = "synchronise the server and database hosts with NTP"
+		switch {
+		case skew > failAfter:
+			return Failure(sync, "server clock is %s apart from the database's (%s)", skew.Round(time.Millisecond), dbNow.UTC().Format(time.RFC3339))
+		case skew > warnAfter:
+			return Warning(sync, "server clock is %s apart from the database's", skew.Round(time.Millisecond))
+		}
+		return Pass("within %s of the database's", warnAfter)
+	}}
+}
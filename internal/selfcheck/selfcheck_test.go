@@ -0,0 +1,187 @@
+package selfcheck
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nehciyy/intrapay/internal/clock"
+	"github.com/nehciyy/intrapay/internal/migrate"
+)
+
+func result(r Result) Check {
+	return Check{Name: "x", Run: func(context.Context) Result { return r }}
+}
+
+func TestRun(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC))
+
+	report := Run(context.Background(), clk, result(Pass("fine")), result(Warning("look into it", "odd")))
+	assert.True(t, report.Ready)
+	assert.NoError(t, report.Err())
+	assert.Equal(t, []Result{{Name: "x", Status: StatusWarn, Message: "odd", Action: "look into it"}}, report.Warnings())
+	assert.Equal(t, clk.Now(), report.CheckedAt)
+
+	failed := report.With("database", Failure("restart it", "down"))
+	assert.False(t, failed.Ready)
+	assert.EqualError(t, failed.Err(), "self-check failed:\ndatabase: down\n  -> restart it")
+	// With leaves the report it was called on as it is.
+	assert.True(t, report.Ready)
+	assert.Len(t, report.Checks, 2)
+}
+
+func TestSchemaVersion(t *testing.T) {
+	migrations := []migrate.Migration{
+		{Version: 1, Name: "001_a.sql", SQL: "CREATE TABLE a (id BIGINT);"},
+		{Version: 2, Name: "002_b.sql", SQL: "ALTER TABLE a ADD COLUMN b TEXT;"},
+	}
+	applied := func(mock sqlmock.Sqlmock, versions ...int) {
+		mock.ExpectQuery("to_regclass").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		rows := sqlmock.NewRows([]string{"version"})
+		for _, v := range versions {
+			rows.AddRow(v)
+		}
+		mock.ExpectQuery("SELECT version FROM schema_migrations").WillReturnRows(rows)
+	}
+
+	t.Run("Up To Date", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		applied(mock, 1, 2)
+		mock.ExpectQuery("FROM information_schema.columns").
+			WillReturnRows(sqlmock.NewRows([]string{"table_name", "column_name", "udt_name"}).
+				AddRow("a", "id", "int8").AddRow("a", "b", "text"))
+		mock.ExpectQuery("FROM pg_indexes").WillReturnRows(sqlmock.NewRows([]string{"indexname", "tablename"}))
+
+		r := SchemaVersion(db, migrations, false).Run(context.Background())
+		assert.Equal(t, StatusOK, r.Status, r.Message)
+		assert.Contains(t, r.Message, "version 2 (002_b.sql)")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Pending", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		applied(mock, 1)
+
+		r := SchemaVersion(db, migrations, false).Run(context.Background())
+		assert.Equal(t, StatusFail, r.Status)
+		assert.Equal(t, "1 migrations not applied: 002_b.sql", r.Message)
+		assert.Contains(t, r.Action, "cmd/migrate")
+	})
+
+	t.Run("Pending With Skew", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		applied(mock, 1)
+
+		r := SchemaVersion(db, migrations, true).Run(context.Background())
+		assert.Equal(t, StatusWarn, r.Status)
+	})
+
+	t.Run("Missing Column", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		applied(mock, 1, 2)
+		mock.ExpectQuery("FROM information_schema.columns").
+			WillReturnRows(sqlmock.NewRows([]string{"table_name", "column_name", "udt_name"}).AddRow("a", "id", "int8"))
+		mock.ExpectQuery("FROM pg_indexes").WillReturnRows(sqlmock.NewRows([]string{"indexname", "tablename"}))
+
+		r := SchemaVersion(db, migrations, false).Run(context.Background())
+		assert.Equal(t, StatusFail, r.Status)
+		assert.Equal(t, "schema differs from the migrations: column a.b", r.Message)
+	})
+
+	t.Run("Untracked", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		mock.ExpectQuery("to_regclass").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+		mock.ExpectQuery("FROM information_schema.tables").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+		r := SchemaVersion(db, migrations, false).Run(context.Background())
+		assert.Equal(t, StatusFail, r.Status)
+		assert.Contains(t, r.Action, "-baseline")
+	})
+}
+
+func TestSequences(t *testing.T) {
+	tests := []struct {
+		name     string
+		last     int64
+		expected Status
+	}{
+		{name: "Plenty Left", last: 1000, expected: StatusOK},
+		{name: "Running Out", last: 1_700_000_000, expected: StatusWarn},
+		{name: "Nearly Exhausted", last: 2_140_000_000, expected: StatusFail},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+			mock.ExpectQuery("FROM pg_sequences").
+				WillReturnRows(sqlmock.NewRows([]string{"sequencename", "last_value", "max_value"}).
+					AddRow("ledger_entries_id_seq", 10, int64(9223372036854775807)).
+					AddRow("transactions_id_seq", tt.last, 2147483647))
+
+			r := Sequences(db, 0.75, 0.99).Run(context.Background())
+			assert.Equal(t, tt.expected, r.Status, r.Message)
+			if tt.expected != StatusOK {
+				assert.Contains(t, r.Message, "transactions_id_seq")
+				assert.NotContains(t, r.Message, "ledger_entries_id_seq")
+			}
+		})
+	}
+}
+
+func TestClock(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		skew     time.Duration
+		expected Status
+	}{
+		{name: "In Sync", skew: 100 * time.Millisecond, expected: StatusOK},
+		{name: "Drifting", skew: -5 * time.Second, expected: StatusWarn},
+		{name: "Far Apart", skew: 10 * time.Minute, expected: StatusFail},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+			mock.ExpectQuery("SELECT now()").WillReturnRows(sqlmock.NewRows([]string{"now"}).AddRow(now.Add(tt.skew)))
+
+			r := Clock(db, clock.NewFake(now), time.Second, time.Minute).Run(context.Background())
+			assert.Equal(t, tt.expected, r.Status, r.Message)
+		})
+	}
+}
+
+func TestIndexes(t *testing.T) {
+	migrations := []migrate.Migration{
+		{Version: 1, Name: "001_a.sql", SQL: "CREATE TABLE a (id BIGINT, b TEXT); CREATE INDEX a_b_idx ON a (b);"},
+	}
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	mock.ExpectQuery("FROM pg_indexes").WillReturnRows(sqlmock.NewRows([]string{"indexname", "tablename"}).AddRow("a_pkey", "a"))
+
+	r := Indexes(db, migrations).Run(context.Background())
+	assert.Equal(t, StatusWarn, r.Status)
+	assert.Equal(t, "1 indexes missing: index a_b_idx on a", r.Message)
+
+	mock.ExpectQuery("FROM pg_indexes").WillReturnRows(sqlmock.NewRows([]string{"indexname", "tablename"}).AddRow("a_b_idx", "a"))
+	r = Indexes(db, migrations).Run(context.Background())
+	assert.Equal(t, StatusOK, r.Status)
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository"
+)
+
+// ErrInvalidGrouping is returned for transaction attempt stats grouped by an
+// unknown dimension.
+var ErrInvalidGrouping = errors.New("invalid grouping")
+
+var errTransactionAttemptsDisabled = errors.New("transaction attempt recording is not enabled")
+
+// RecordTransactionAttempt stores a rejected transfer for failure analytics. It
+// is best-effort: the rejection has already been decided, so a failure to store
+// it is logged rather than returned.
+func (s *DefaultService) RecordTransactionAttempt(a models.TransactionAttempt) {
+	if s.attemptRepo == nil {
+		return
+	}
+	if err := s.attemptRepo.InsertTransactionAttempt(a); err != nil {
+		log.Printf("record %s attempt from account %d: %v", a.Code, a.SourceAccountID, err)
+	}
+}
+
+// ListTransactionAttempts returns a page of up to limit rejected attempts
+// matching filter, oldest first, starting after cursor.
+func (s *DefaultService) ListTransactionAttempts(filter models.TransactionAttemptFilter, cursor string, limit int) (*models.TransactionAttemptPage, error) {
+	if s.attemptRepo == nil {
+		return nil, errTransactionAttemptsDisabled
+	}
+	after, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	attempts, next, err := s.attemptRepo.ListTransactionAttempts(filter, after, limit)
+	if err != nil {
+		return nil, err
+	}
+	if attempts == nil {
+		attempts = []models.TransactionAttempt{}
+	}
+	return &models.TransactionAttemptPage{
+		Attempts:   attempts,
+		NextCursor: encodeCursor(next),
+		HasMore:    len(attempts) == limit,
+	}, nil
+}
+
+// CountTransactionAttempts counts the rejected attempts matching filter.
+func (s *DefaultService) CountTransactionAttempts(filter models.TransactionAttemptFilter) (int64, error) {
+	if s.attemptRepo == nil {
+		return 0, errTransactionAttemptsDisabled
+	}
+	return s.attemptRepo.CountTransactionAttempts(filter)
+}
+
+// TransactionAttemptStats counts and sums the rejected attempts matching filter
+// per reason code, API key or tenant, most frequent first. groupBy defaults to
+// the reason code.
+func (s *DefaultService) TransactionAttemptStats(groupBy models.AttemptGrouping, filter models.TransactionAttemptFilter) ([]models.TransactionAttemptStat, error) {
+	if s.attemptRepo == nil {
+		return nil, errTransactionAttemptsDisabled
+	}
+	if groupBy == "" {
+		groupBy = models.GroupByReasonCode
+	}
+	if !groupBy.Valid() {
+		return nil, fmt.Errorf("%w %q", ErrInvalidGrouping, groupBy)
+	}
+	stats, err := s.attemptRepo.TransactionAttemptStats(groupBy, filter)
+	if err != nil {
+		return nil, err
+	}
+	if stats == nil {
+		stats = []models.TransactionAttemptStat{}
+	}
+	return stats, nil
+}
+
+// LookupRequest returns what was recorded for the request with the given
+// X-Request-ID: the transfers it had rejected. It returns an error wrapping
+// repository.ErrNotFound when nothing was.
+func (s *DefaultService) LookupRequest(requestID string) (*models.RequestRecord, error) {
+	if s.attemptRepo == nil {
+		return nil, errTransactionAttemptsDisabled
+	}
+	attempts, err := s.attemptRepo.ListTransactionAttemptsByRequest(requestID)
+	if err != nil {
+		return nil, err
+	}
+	if len(attempts) == 0 {
+		return nil, fmt.Errorf("request %q %w", requestID, repository.ErrNotFound)
+	}
+	return &models.RequestRecord{RequestID: requestID, TransactionAttempts: attempts}, nil
+}
+
+// ReplayTransactionAttempt re-runs the rejected transfer recorded as attempt id,
+// e.g. after an incident rejected transfers that should have gone through. The
+// replay is recorded in the transaction of the transfer it makes, so an attempt
+// moves funds at most once however often it is replayed, even concurrently.
+// Without apply nothing is transferred and the outcome is planned.
+//
+// A replay is not metered against the caller's quotas and does not record a new
+// attempt when it is rejected again. An error is returned only when the attempt
+// cannot be read or the transfer fails for a reason other than a rejection.
+func (s *DefaultService) ReplayTransactionAttempt(id int64, apply bool) (*models.TransactionAttemptReplay, error) {
+	if s.attemptRepo == nil {
+		return nil, errTransactionAttemptsDisabled
+	}
+	a, err := s.attemptRepo.GetTransactionAttempt(id)
+	if err != nil {
+		return nil, err
+	}
+	replay := &models.TransactionAttemptReplay{
+		AttemptID:            a.ID,
+		SourceAccountID:      a.SourceAccountID,
+		DestinationAccountID: a.DestinationAccountID,
+		Amount:               a.Amount,
+		OriginalCode:         a.Code,
+	}
+	done, err := s.replayedBy(replay)
+	if err != nil {
+		return nil, err
+	}
+	if done {
+		return replay, nil
+	}
+	if !apply {
+		replay.Outcome = models.ReplayPlanned
+		return replay, nil
+	}
+
+	transactionID, err := s.transfer(context.Background(), a.SourceAccountID, a.DestinationAccountID, float64(a.Amount), models.TransactionTransfer, func(tx *sql.Tx, transactionID string) error {
+		return s.attemptRepo.InsertTransactionAttemptReplayTx(tx, id, transactionID)
+	})
+	switch {
+	case errors.Is(err, repository.ErrAlreadyReplayed):
+		// Another replay committed first.
+		if _, err := s.replayedBy(replay); err != nil {
+			return nil, err
+		}
+		return replay, nil
+	case err != nil:
+		code := RejectionReason(err)
+		if code == "" {
+			return nil, err
+		}
+		replay.Outcome, replay.Code, replay.Error = models.ReplayRejected, code, err.Error()
+		return replay, nil
+	}
+	replay.Outcome, replay.TransactionID = models.ReplayCompleted, transactionID
+	return replay, nil
+}
+
+// replayedBy reports whether the attempt of replay has already been replayed,
+// and if so fills in the transfer that did.
+func (s *DefaultService) replayedBy(replay *models.TransactionAttemptReplay) (bool, error) {
+	transactionID, err := s.attemptRepo.GetTransactionAttemptReplay(replay.AttemptID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	replay.Outcome, replay.TransactionID = models.ReplayDuplicate, transactionID
+	return true, nil
+}
@@ -0,0 +1,24 @@
+package service
+
+import "github.com/nehciyy/intrapay/internal/models"
+
+// availableBalance is the part of balance that transfers may spend. It is the one
+// definition of spendable funds: account responses and the insufficient funds
+// check both go through it, so holds, pending transfers and overdraft limits are
+// accounted for here. Until they exist the whole balance is available.
+//
+// The conditional debit (WithConditionalDebit) applies the same rule in SQL and
+// must change with it.
+func availableBalance(balance float64) float64 {
+	return balance
+}
+
+// withAvailableBalance sets the available balance of the account returned by a
+// repository call.
+func withAvailableBalance(account *models.Account, err error) (*models.Account, error) {
+	if err != nil {
+		return nil, err
+	}
+	account.AvailableBalance = models.Amount(availableBalance(float64(account.Balance)))
+	return account, nil
+}
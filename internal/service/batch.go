@@ -0,0 +1,314 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+
+	"github.com/nehciyy/intrapay/internal/db"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository"
+)
+
+// MaxBatchTransfers is the most transfers one batch may have.
+const MaxBatchTransfers = 1000
+
+// ErrInvalidBatch is returned for a batch with no transfers or too many.
+var ErrInvalidBatch = errors.New("invalid batch")
+
+// BatchRejectedError is returned when transfers of an atomic batch cannot be
+// posted. Nothing of the batch was posted; Result tells why, by transfer.
+type BatchRejectedError struct {
+	Result *models.TransactionBatchResult
+}
+
+func (e *BatchRejectedError) Error() string {
+	return fmt.Sprintf("batch rejected: %d of %d transfers cannot be posted", e.Result.Failed, len(e.Result.Results))
+}
+
+// Code returns the reason code of the first transfer that cannot be posted.
+func (e *BatchRejectedError) Code() models.ReasonCode {
+	for _, r := range e.Result.Results {
+		if r.Status == models.BatchTransferFailed {
+			return r.Code
+		}
+	}
+	return ""
+}
+
+// CreateTransactionBatch posts a batch of transfers and reports the outcome of
+// each. An atomic batch is posted in one database transaction: it is validated
+// under the locks of all its accounts, as CommitPayroll validates its lines,
+// and posted whole or, with a *BatchRejectedError, not at all. The transfers
+// of any other batch are made one after the other by CreateTransaction, and
+// one failing does not stop the rest. Either way, invalid tags refuse the
+// batch before any transfer is attempted.
+func (s *DefaultService) CreateTransactionBatch(ctx context.Context, req models.TransactionBatchRequest) (*models.TransactionBatchResult, error) {
+	switch {
+	case len(req.Transfers) == 0:
+		return nil, fmt.Errorf("%w: no transfers", ErrInvalidBatch)
+	case len(req.Transfers) > MaxBatchTransfers:
+		return nil, fmt.Errorf("%w: at most %d transfers per batch", ErrInvalidBatch, MaxBatchTransfers)
+	}
+	tags := make([][]string, len(req.Transfers))
+	for i, t := range req.Transfers {
+		if len(t.Tags) == 0 {
+			continue
+		}
+		if s.tagRepo == nil {
+			return nil, errTransactionTagsDisabled
+		}
+		var err error
+		if tags[i], err = normalizeTags(t.Tags); err != nil {
+			return nil, fmt.Errorf("transfer %d: %w", i, err)
+		}
+	}
+	// A test-mode batch moving money from a live account is refused whole.
+	checked := make(map[int64]bool)
+	for i, t := range req.Transfers {
+		if checked[t.SourceAccountID] {
+			continue
+		}
+		checked[t.SourceAccountID] = true
+		if err := s.checkTestMode(ctx, t.SourceAccountID); err != nil {
+			return nil, fmt.Errorf("transfer %d: %w", i, err)
+		}
+	}
+	if req.Atomic {
+		return s.postBatch(ctx, req.Transfers, tags)
+	}
+
+	result := &models.TransactionBatchResult{Results: make([]models.BatchTransferResult, len(req.Transfers))}
+	for i, t := range req.Transfers {
+		failure, err := s.checkBatchTransfer(i, t)
+		if err != nil {
+			failure = batchFailure(i, RejectionReason(err), err)
+		}
+		if failure == nil {
+			var transactionID string
+			if transactionID, err = s.CreateTransaction(ctx, t.SourceAccountID, t.DestinationAccountID, float64(t.Amount), tags[i]); err == nil {
+				result.Results[i] = models.BatchTransferResult{Transfer: i, Status: models.BatchTransferCreated, TransactionID: transactionID}
+				result.Created++
+				continue
+			}
+			failure = batchFailure(i, RejectionReason(err), err)
+		}
+		result.Results[i] = *failure
+		result.Failed++
+	}
+	return result, nil
+}
+
+// checkBatchTransfer refuses transfer i of a batch, t, if it moves no money, moves
+// it to its source account, or is refused by a transfer freeze or the amount
+// bounds. It returns the failure, or nil if t may be attempted.
+func (s *DefaultService) checkBatchTransfer(i int, t models.TransactionRequest) (*models.BatchTransferResult, error) {
+	switch {
+	case t.Amount <= 0:
+		return batchFailure(i, models.ReasonLimitExceeded, errors.New("amount must be positive")), nil
+	case t.SourceAccountID == t.DestinationAccountID:
+		return batchFailure(i, models.ReasonSameAccount, errors.New("an account cannot pay itself")), nil
+	}
+	for _, check := range []func() error{
+		func() error { return s.checkTransferFreeze(t.SourceAccountID) },
+		func() error { return s.checkAmountBounds(t.SourceAccountID, float64(t.Amount)) },
+	} {
+		if err := check(); err != nil {
+			code := RejectionReason(err)
+			if code == "" {
+				return nil, err
+			}
+			return batchFailure(i, code, err), nil
+		}
+	}
+	return nil, nil
+}
+
+func batchFailure(i int, code models.ReasonCode, err error) *models.BatchTransferResult {
+	return &models.BatchTransferResult{Transfer: i, Status: models.BatchTransferFailed, Code: code, Error: err.Error()}
+}
+
+// batchPosting is an atomic batch posted in a database transaction, with the
+// top-ups and cashback its transfers set off.
+type batchPosting struct {
+	transactionIDs []string
+	topUps         map[int64]*models.TransferIntent
+	cashback       []*models.TransferIntent
+}
+
+// postBatch posts transfers, tagged with tags by transfer, as an atomic batch.
+func (s *DefaultService) postBatch(ctx context.Context, transfers []models.TransactionRequest, tags [][]string) (*models.TransactionBatchResult, error) {
+	checked := make([]models.BatchTransferResult, len(transfers))
+	for i, t := range transfers {
+		failure, err := s.checkBatchTransfer(i, t)
+		if err != nil {
+			return nil, err
+		}
+		if failure != nil {
+			checked[i] = *failure
+		}
+	}
+	ctx = db.WithHints(ctx, db.Critical)
+
+	for attempt := 1; attempt <= maxRetries; attempt++ {
+		tx, err := s.hints.BeginTx(ctx, s.db, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to begin transaction: %w", err)
+		}
+
+		posting, err := s.postBatchTx(tx, transfers, tags, checked)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+
+		if err := tx.Commit(); err != nil {
+			if repository.IsSerializationFailure(err) {
+				log.Printf("serialization failure, retrying attempt %d...", attempt)
+				s.clock.Sleep(retryBackoff)
+				continue
+			}
+			return nil, fmt.Errorf("commit failed: %v", err)
+		}
+
+		result := &models.TransactionBatchResult{Atomic: true, Created: len(transfers), Results: make([]models.BatchTransferResult, len(transfers))}
+		for i, transactionID := range posting.transactionIDs {
+			result.Results[i] = models.BatchTransferResult{Transfer: i, Status: models.BatchTransferCreated, TransactionID: transactionID}
+		}
+		for sourceID, intent := range posting.topUps {
+			s.topUp(sourceID, intent)
+		}
+		for i, t := range transfers {
+			s.payCashback(posting.transactionIDs[i], t.SourceAccountID, float64(t.Amount), posting.cashback[i])
+		}
+		return result, nil
+	}
+
+	return nil, ErrRetriesExhausted
+}
+
+// postBatchTx validates transfers in tx, on top of the failures checked found
+// beforehand, and, if they are all valid, posts them.
+func (s *DefaultService) postBatchTx(tx *sql.Tx, transfers []models.TransactionRequest, tags [][]string, checked []models.BatchTransferResult) (*batchPosting, error) {
+	results := slices.Clone(checked)
+	var accountIDs []int64
+	for i, t := range transfers {
+		if results[i].Status == "" {
+			accountIDs = append(accountIDs, t.SourceAccountID, t.DestinationAccountID)
+		}
+	}
+	// Lock every account in ascending order, so that concurrent batches of the
+	// same accounts lock them in the same order.
+	slices.Sort(accountIDs)
+	accountIDs = slices.Compact(accountIDs)
+	available := make(map[int64]int64, len(accountIDs))
+	for _, accountID := range accountIDs {
+		balance, err := s.transactionRepo.GetAccountBalanceTx(tx, accountID)
+		if errors.Is(err, repository.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		available[accountID] = models.Amount(availableBalance(balance)).Minor()
+	}
+
+	// The transfers are validated in order: each may spend what the ones before
+	// it paid into its source account.
+	net := make(map[int64]int64)
+	for i, t := range transfers {
+		if results[i].Status != "" {
+			continue
+		}
+		source, ok := available[t.SourceAccountID]
+		if !ok {
+			results[i] = *batchFailure(i, models.ReasonAccountNotFound, fmt.Errorf("account %d not found", t.SourceAccountID))
+			continue
+		}
+		if _, ok := available[t.DestinationAccountID]; !ok {
+			results[i] = *batchFailure(i, models.ReasonDestinationNotFound, fmt.Errorf("account %d not found", t.DestinationAccountID))
+			continue
+		}
+		if source+net[t.SourceAccountID] < t.Amount.Minor() {
+			results[i] = *batchFailure(i, models.ReasonInsufficientFunds, fmt.Errorf("%s exceeds the available balance %s of account %d",
+				t.Amount, fromMinor(source+net[t.SourceAccountID]), t.SourceAccountID))
+			continue
+		}
+		net[t.SourceAccountID] -= t.Amount.Minor()
+		net[t.DestinationAccountID] += t.Amount.Minor()
+	}
+	if rejected := batchRejection(results); rejected != nil {
+		return nil, rejected
+	}
+
+	for _, accountID := range accountIDs {
+		if net[accountID] == 0 {
+			continue
+		}
+		if err := s.transactionRepo.UpdateBalanceTx(tx, accountID, float64(fromMinor(net[accountID]))); err != nil {
+			return nil, fmt.Errorf("error updating balance of account %d: %w", accountID, err)
+		}
+	}
+	logs := make([]repository.TransactionLog, len(transfers))
+	for i, t := range transfers {
+		logs[i] = repository.TransactionLog{
+			SourceID: t.SourceAccountID,
+			DestID:   t.DestinationAccountID,
+			Amount:   float64(t.Amount),
+			Kind:     models.TransactionTransfer,
+		}
+	}
+	transactionIDs, err := s.transactionRepo.InsertTransactionLogsTx(tx, logs)
+	if repository.IsLivemodeMismatch(err) {
+		return nil, ErrLivemodeMismatch
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error inserting transaction records: %w", err)
+	}
+
+	posting := &batchPosting{transactionIDs: transactionIDs, cashback: make([]*models.TransferIntent, len(transfers))}
+	debited := make(map[int64]string)
+	for i, t := range transfers {
+		if tag := s.tagTransfer(tags[i]); tag != nil {
+			if err := tag(tx, transactionIDs[i]); err != nil {
+				return nil, fmt.Errorf("error tagging transaction: %w", err)
+			}
+		}
+		if s.outboxRepo != nil {
+			if err := s.recordTransferEvent(tx, transactionIDs[i], t.SourceAccountID, t.DestinationAccountID, float64(t.Amount), models.TransactionTransfer); err != nil {
+				return nil, fmt.Errorf("error writing outbox event: %w", err)
+			}
+		}
+		if posting.cashback[i], err = s.recordCashbackIntentTx(tx, transactionIDs[i], t.SourceAccountID, float64(t.Amount)); err != nil {
+			return nil, fmt.Errorf("error recording cashback intent: %w", err)
+		}
+		debited[t.SourceAccountID] = transactionIDs[i]
+	}
+	if posting.topUps, err = s.recordTopUpIntentsTx(tx, debited); err != nil {
+		return nil, fmt.Errorf("error recording top-up intents: %w", err)
+	}
+	return posting, nil
+}
+
+// batchRejection returns the rejection of an atomic batch with results, or nil
+// if none of them failed. The transfers that did not fail are skipped.
+func batchRejection(results []models.BatchTransferResult) *BatchRejectedError {
+	result := &models.TransactionBatchResult{Atomic: true, Results: results}
+	for i := range results {
+		if results[i].Status == models.BatchTransferFailed {
+			result.Failed++
+		}
+	}
+	if result.Failed == 0 {
+		return nil
+	}
+	for i := range results {
+		if results[i].Status == "" {
+			results[i] = models.BatchTransferResult{Transfer: i, Status: models.BatchTransferSkipped}
+		}
+	}
+	return &BatchRejectedError{Result: result}
+}
@@ -0,0 +1,91 @@
+package service_test
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/nehciyy/intrapay/internal/clock"
+	"github.com/nehciyy/intrapay/internal/repository"
+	"github.com/nehciyy/intrapay/internal/service"
+	"github.com/nehciyy/intrapay/internal/simulation"
+)
+
+// benchmarkTransfers moves one unit back and forth between two accounts, so
+// that the balances hold however many iterations run.
+func benchmarkTransfers(b *testing.B, svc service.Service, a, c int64) {
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := range b.N {
+		source, dest := a, c
+		if i%2 == 1 {
+			source, dest = c, a
+		}
+		if _, err := svc.CreateTransaction(context.Background(), source, dest, 1, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkCreateTransaction_Memory measures the transfer path of the service
+// alone, on the in-memory ledger of the simulations.
+func BenchmarkCreateTransaction_Memory(b *testing.B) {
+	for _, bc := range []struct {
+		name string
+		opts []service.Option
+	}{
+		{name: "locking_read"},
+		{name: "conditional_debit", opts: []service.Option{service.WithConditionalDebit()}},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			ledger := simulation.NewLedger(clock.System)
+			for _, id := range []int64{1, 2} {
+				if _, err := ledger.CreateAccount(id, 1000, "default", "", true); err != nil {
+					b.Fatal(err)
+				}
+			}
+			svc := service.NewService(ledger.DB(), ledger, ledger, bc.opts...)
+			benchmarkTransfers(b, svc, 1, 2)
+		})
+	}
+}
+
+// BenchmarkCreateTransaction_Postgres measures the transfer path against the
+// PostgreSQL database of BENCH_DATABASE_URL, e.g. the one of compose.yaml. It
+// opens two accounts there for the run; point it at a scratch database.
+func BenchmarkCreateTransaction_Postgres(b *testing.B) {
+	dsn := os.Getenv("BENCH_DATABASE_URL")
+	if dsn == "" {
+		b.Skip("Skipping Postgres benchmark: BENCH_DATABASE_URL env var not set")
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { db.Close() })
+	accountRepo := repository.NewPostgresAccountRepository(db)
+	transactionRepo := repository.NewPostgresTransactionRepository(db)
+
+	var ids [2]int64
+	for i := range ids {
+		if ids[i], err = accountRepo.NextAccountID(); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := accountRepo.CreateAccount(ids[i], 1000, "default", "", true); err != nil {
+			b.Fatal(err)
+		}
+	}
+	for _, bc := range []struct {
+		name string
+		opts []service.Option
+	}{
+		{name: "locking_read"},
+		{name: "conditional_debit", opts: []service.Option{service.WithConditionalDebit()}},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			svc := service.NewService(db, accountRepo, transactionRepo, bc.opts...)
+			benchmarkTransfers(b, svc, ids[0], ids[1])
+		})
+	}
+}
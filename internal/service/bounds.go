@@ -0,0 +1,210 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository"
+)
+
+var (
+	// ErrAmountOutOfBounds is returned for a transfer of less than the minimum
+	// or more than the maximum amount allowed, globally or for the tenant of its
+	// source account.
+	ErrAmountOutOfBounds = errors.New("amount out of bounds")
+	// ErrInvalidAmountBounds is returned for negative amount bounds, or a maximum
+	// below the minimum.
+	ErrInvalidAmountBounds = errors.New("invalid amount bounds")
+)
+
+var errAmountBoundsDisabled = errors.New("amount bounds are not enabled")
+
+// boundsRefresh is how stale the bounds a transfer is checked against may be.
+// Bounds set through this server take effect at once; those set through
+// another take effect within boundsRefresh.
+const boundsRefresh = time.Second
+
+// boundsCache holds the amount bounds by tenant as last read, so the check of a
+// transfer is in memory but for one read per boundsRefresh.
+type boundsCache struct {
+	mu       sync.Mutex
+	bounds   map[string]models.AmountBounds
+	loadedAt time.Time
+	loaded   bool
+}
+
+// checkAmountBounds refuses a transfer of amount out of sourceID that the
+// global bounds or the bounds of the tenant of sourceID do not allow.
+func (s *DefaultService) checkAmountBounds(sourceID int64, amount float64) error {
+	return s.amountBoundsCheck(sourceID)(models.Amount(amount))
+}
+
+// amountBoundsCheck returns the check of the amounts of transfers out of
+// sourceID, so that many of them are checked with one lookup of its tenant. The
+// tenant is only looked up while some tenant has bounds of its own, for an
+// amount within the global bounds.
+func (s *DefaultService) amountBoundsCheck(sourceID int64) func(models.Amount) error {
+	if s.boundsRepo == nil {
+		return func(models.Amount) error { return nil }
+	}
+	bounds := s.amountBounds(s.clock.Now())
+	global, ok := bounds[models.AmountBoundsAllTenants]
+	tenants := len(bounds)
+	if ok {
+		tenants--
+	}
+	var tenant *models.AmountBounds
+	var looked bool
+	return func(amount models.Amount) error {
+		if ok {
+			if err := outOfBounds(global, amount); err != nil {
+				return err
+			}
+		}
+		if tenants == 0 {
+			return nil
+		}
+		if !looked {
+			t, err := s.boundsRepo.GetAccountTenant(sourceID)
+			if errors.Is(err, repository.ErrNotFound) {
+				// The transfer fails on the missing account anyway.
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if b, ok := bounds[t]; ok {
+				tenant = &b
+			}
+			looked = true
+		}
+		if tenant != nil {
+			return outOfBounds(*tenant, amount)
+		}
+		return nil
+	}
+}
+
+func outOfBounds(b models.AmountBounds, amount models.Amount) error {
+	switch {
+	case amount < b.MinAmount:
+		return fmt.Errorf("%w: %s is below the minimum of %s for %s", ErrAmountOutOfBounds, amount, b.MinAmount, boundsScope(b.Tenant))
+	case b.MaxAmount != 0 && amount > b.MaxAmount:
+		return fmt.Errorf("%w: %s is above the maximum of %s for %s", ErrAmountOutOfBounds, amount, b.MaxAmount, boundsScope(b.Tenant))
+	}
+	return nil
+}
+
+// amountBounds returns the amount bounds by tenant as last read, reading them
+// again once they are older than boundsRefresh. If they cannot be read, the
+// last ones read stand.
+func (s *DefaultService) amountBounds(now time.Time) map[string]models.AmountBounds {
+	c := s.bounds
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.loaded && now.Sub(c.loadedAt) < boundsRefresh {
+		return c.bounds
+	}
+	list, err := s.boundsRepo.ListAmountBounds()
+	if err != nil {
+		log.Printf("read amount bounds: %v", err)
+		return c.bounds
+	}
+	bounds := make(map[string]models.AmountBounds, len(list))
+	for _, b := range list {
+		bounds[b.Tenant] = b
+	}
+	c.bounds, c.loadedAt, c.loaded = bounds, now, true
+	return bounds
+}
+
+// invalidateAmountBounds makes the next transfer read the bounds again.
+func (s *DefaultService) invalidateAmountBounds() {
+	s.bounds.mu.Lock()
+	s.bounds.loaded = false
+	s.bounds.mu.Unlock()
+}
+
+// AmountBoundsOf returns the bounds a transfer out of an account of tenant must
+// be within: the tighter of the global bounds and the tenant's own. It is
+// computed, so UpdatedAt is left unset.
+func (s *DefaultService) AmountBoundsOf(tenant string) models.AmountBounds {
+	effective := models.AmountBounds{Tenant: tenant}
+	if s.boundsRepo == nil {
+		return effective
+	}
+	bounds := s.amountBounds(s.clock.Now())
+	for _, t := range []string{models.AmountBoundsAllTenants, tenant} {
+		b, ok := bounds[t]
+		if !ok {
+			continue
+		}
+		effective.MinAmount = max(effective.MinAmount, b.MinAmount)
+		if b.MaxAmount != 0 && (effective.MaxAmount == 0 || b.MaxAmount < effective.MaxAmount) {
+			effective.MaxAmount = b.MaxAmount
+		}
+	}
+	return effective
+}
+
+// SetAmountBounds sets the bounds on the amount of a single transfer out of the
+// accounts of tenant, or of every account for models.AmountBoundsAllTenants. It
+// takes effect on this server at once.
+func (s *DefaultService) SetAmountBounds(tenant string, req models.SetAmountBoundsRequest) (*models.AmountBounds, error) {
+	if s.boundsRepo == nil {
+		return nil, errAmountBoundsDisabled
+	}
+	switch {
+	case tenant == "":
+		return nil, fmt.Errorf("%w: missing tenant", ErrInvalidAmountBounds)
+	case req.MinAmount < 0 || req.MaxAmount < 0:
+		return nil, fmt.Errorf("%w: must not be negative", ErrInvalidAmountBounds)
+	case req.MaxAmount != 0 && req.MaxAmount < req.MinAmount:
+		return nil, fmt.Errorf("%w: maximum %s is below the minimum %s", ErrInvalidAmountBounds, req.MaxAmount, req.MinAmount)
+	}
+	bounds, err := s.boundsRepo.SetAmountBounds(models.AmountBounds{Tenant: tenant, MinAmount: req.MinAmount, MaxAmount: req.MaxAmount})
+	if err != nil {
+		return nil, err
+	}
+	s.invalidateAmountBounds()
+	ceiling := "none"
+	if bounds.MaxAmount != 0 {
+		ceiling = bounds.MaxAmount.String()
+	}
+	log.Printf("amount bounds of %s set: minimum %s, maximum %s", boundsScope(tenant), bounds.MinAmount, ceiling)
+	return bounds, nil
+}
+
+// ListAmountBounds returns the global bounds and those of every tenant that has
+// its own, by tenant.
+func (s *DefaultService) ListAmountBounds() ([]models.AmountBounds, error) {
+	if s.boundsRepo == nil {
+		return nil, errAmountBoundsDisabled
+	}
+	return s.boundsRepo.ListAmountBounds()
+}
+
+// DeleteAmountBounds removes the bounds of tenant, or the global bounds for
+// models.AmountBoundsAllTenants.
+func (s *DefaultService) DeleteAmountBounds(tenant string) error {
+	if s.boundsRepo == nil {
+		return errAmountBoundsDisabled
+	}
+	if err := s.boundsRepo.DeleteAmountBounds(tenant); err != nil {
+		return err
+	}
+	s.invalidateAmountBounds()
+	log.Printf("amount bounds of %s removed", boundsScope(tenant))
+	return nil
+}
+
+func boundsScope(tenant string) string {
+	if tenant == models.AmountBoundsAllTenants {
+		return "all tenants"
+	}
+	return "tenant " + tenant
+}
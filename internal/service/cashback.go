@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/nehciyy/intrapay/internal/metrics"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/money"
+	"github.com/nehciyy/intrapay/internal/repository"
+)
+
+var (
+	// ErrInvalidCashbackCampaign is returned for a cashback campaign without a
+	// name, with a percentage outside (0, 100], a cap that is not positive, an
+	// end that is not after its start, or a funding or eligible account that does
+	// not exist.
+	ErrInvalidCashbackCampaign = errors.New("invalid cashback campaign")
+
+	errCashbackDisabled = errors.New("cashback campaigns are not enabled")
+)
+
+// CreateCashbackCampaign starts crediting transfers that qualify under req with
+// cashback from its funding account.
+func (s *DefaultService) CreateCashbackCampaign(req models.CashbackCampaignRequest) (*models.CashbackCampaign, error) {
+	if s.cashbackRepo == nil {
+		return nil, errCashbackDisabled
+	}
+	campaign, err := s.cashbackCampaign(req)
+	if err != nil {
+		return nil, err
+	}
+	return s.cashbackRepo.InsertCashbackCampaign(*campaign)
+}
+
+// UpdateCashbackCampaign replaces the terms of campaign id with req. Cashback
+// already paid stays paid and counted in the campaign.
+func (s *DefaultService) UpdateCashbackCampaign(id int64, req models.CashbackCampaignRequest) (*models.CashbackCampaign, error) {
+	if s.cashbackRepo == nil {
+		return nil, errCashbackDisabled
+	}
+	campaign, err := s.cashbackCampaign(req)
+	if err != nil {
+		return nil, err
+	}
+	campaign.ID = id
+	return s.cashbackRepo.UpdateCashbackCampaign(*campaign)
+}
+
+// GetCashbackCampaign returns a cashback campaign with what it has paid so far.
+func (s *DefaultService) GetCashbackCampaign(id int64) (*models.CashbackCampaign, error) {
+	if s.cashbackRepo == nil {
+		return nil, errCashbackDisabled
+	}
+	return s.cashbackRepo.GetCashbackCampaign(id)
+}
+
+// ListCashbackCampaigns returns every cashback campaign, ended and upcoming
+// ones included.
+func (s *DefaultService) ListCashbackCampaigns() ([]models.CashbackCampaign, error) {
+	if s.cashbackRepo == nil {
+		return nil, errCashbackDisabled
+	}
+	return s.cashbackRepo.ListCashbackCampaigns()
+}
+
+// DeleteCashbackCampaign stops a cashback campaign. The cashback transactions
+// it posted stay in the transaction log.
+func (s *DefaultService) DeleteCashbackCampaign(id int64) error {
+	if s.cashbackRepo == nil {
+		return errCashbackDisabled
+	}
+	return s.cashbackRepo.DeleteCashbackCampaign(id)
+}
+
+// cashbackCampaign validates req and returns the campaign it defines, with
+// duplicate eligible accounts dropped.
+func (s *DefaultService) cashbackCampaign(req models.CashbackCampaignRequest) (*models.CashbackCampaign, error) {
+	name := strings.TrimSpace(req.Name)
+	switch {
+	case name == "":
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidCashbackCampaign)
+	case req.Percentage <= 0 || req.Percentage > 100:
+		return nil, fmt.Errorf("%w: percentage must be greater than 0 and at most 100", ErrInvalidCashbackCampaign)
+	case req.Cap <= 0:
+		return nil, fmt.Errorf("%w: cap must be positive", ErrInvalidCashbackCampaign)
+	case req.StartsAt.IsZero() || !req.EndsAt.After(req.StartsAt):
+		return nil, fmt.Errorf("%w: ends_at must be after starts_at", ErrInvalidCashbackCampaign)
+	case slices.Contains(req.EligibleAccountIDs, req.FundingAccountID):
+		return nil, fmt.Errorf("%w: the funding account cannot earn its own cashback", ErrInvalidCashbackCampaign)
+	}
+
+	eligible := make([]int64, 0, len(req.EligibleAccountIDs))
+	for _, id := range req.EligibleAccountIDs {
+		if !slices.Contains(eligible, id) {
+			eligible = append(eligible, id)
+		}
+	}
+	for _, id := range append([]int64{req.FundingAccountID}, eligible...) {
+		exists, err := s.accountRepo.AccountExists(context.Background(), id)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, fmt.Errorf("%w: account %d not found", ErrInvalidCashbackCampaign, id)
+		}
+	}
+
+	return &models.CashbackCampaign{
+		Name:               name,
+		Percentage:         req.Percentage,
+		Cap:                req.Cap,
+		FundingAccountID:   req.FundingAccountID,
+		EligibleAccountIDs: eligible,
+		StartsAt:           req.StartsAt,
+		EndsAt:             req.EndsAt,
+	}, nil
+}
+
+// payCashback credits accountID with the cashback that transfer transactionID
+// of amount earns under each campaign running now that the account is eligible
+// for. Each credit is a transfer of its own from the campaign's funding account,
+// logged as a cashback transaction, and does not earn cashback in turn. A failed
+// credit, e.g. from a funding account that has run dry, is logged and leaves
+// the transfer that earned it in place.
+//
+// intent, if set, is the cashback as recorded with the transfer: the campaigns
+// running when it was recorded pay it, each credit is recorded against it in
+// the transaction of the credit, so that a resumed intent pays every campaign
+// once, and it is completed once every credit is paid or has failed for good.
+func (s *DefaultService) payCashback(transactionID string, accountID int64, amount float64, intent *models.TransferIntent) {
+	if s.cashbackRepo == nil {
+		return
+	}
+	at := s.clock.Now()
+	if intent != nil {
+		at = intent.CreatedAt
+	}
+	campaigns, err := s.cashbackRepo.ListActiveCashbackCampaigns(accountID, at)
+	if err != nil {
+		metrics.Cashback.WithLabelValues("failed").Inc()
+		log.Printf("cashback for transaction %s: %v", transactionID, err)
+		return
+	}
+	settled := true
+	for _, c := range campaigns {
+		credit, rounding := cashbackFor(c, models.Amount(amount))
+		if credit <= 0 {
+			continue
+		}
+		creditID, err := s.transferRounded(context.Background(), c.FundingAccountID, accountID, float64(credit), models.TransactionCashback, rounding, func(tx *sql.Tx, creditID string) error {
+			if intent != nil {
+				if err := s.intentRepo.InsertTransferIntentCreditTx(tx, intent.ID, c.ID, creditID); err != nil {
+					return err
+				}
+			}
+			return s.cashbackRepo.AddCashbackCampaignPaidTx(tx, c.ID, float64(credit))
+		})
+		if errors.Is(err, repository.ErrAlreadyCompleted) {
+			// An earlier run of the intent paid it.
+			continue
+		}
+		if err != nil {
+			metrics.Cashback.WithLabelValues("failed").Inc()
+			log.Printf("cashback of campaign %d for transaction %s failed: %v", c.ID, transactionID, err)
+			settled = settled && intentSettled(err)
+			continue
+		}
+		metrics.Cashback.WithLabelValues("paid").Inc()
+		log.Printf("credited account %d with %s cashback of campaign %d for transaction %s in transaction %s", accountID, credit, c.ID, transactionID, creditID)
+	}
+	if settled {
+		s.completeIntent(intent)
+	}
+}
+
+// cashbackFor returns the cashback a transfer of amount earns under c: its
+// percentage of the amount, rounded to the currency's minor units by its
+// rounding policy, and at most its cap. The rounding is returned with it, nil
+// when the cap applies.
+func cashbackFor(c models.CashbackCampaign, amount models.Amount) (models.Amount, *money.Rounding) {
+	credit, rounding := models.CurrentCurrency().Policy().Round(float64(amount) * c.Percentage / 100)
+	if models.Amount(credit).Minor() >= c.Cap.Minor() {
+		return c.Cap, nil
+	}
+	return models.Amount(credit), &rounding
+}
@@ -0,0 +1,270 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/db"
+	"github.com/nehciyy/intrapay/internal/metrics"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository"
+)
+
+// CoalescingConfig configures write coalescing: transfers to a hot account
+// that arrive within Window of each other are posted together, crediting the
+// account once for all of them, so it is locked and updated once per batch
+// rather than once per transfer.
+type CoalescingConfig struct {
+	// Accounts are the hot accounts whose incoming transfers are coalesced;
+	// none disables coalescing.
+	Accounts []int64
+	// Window is how long the first transfer of a batch waits for others.
+	Window time.Duration
+	// MaxBatch posts a batch as soon as it has this many transfers.
+	MaxBatch int
+	// MaxAmount is the largest transfer coalesced; zero coalesces any amount.
+	MaxAmount float64
+}
+
+// coalescedTransfer is a transfer waiting in a batch, and its outcome once the
+// batch is posted.
+type coalescedTransfer struct {
+	sourceID      int64
+	amount        float64
+	transactionID string
+	cashback      *models.TransferIntent
+	err           error
+	done          chan struct{}
+}
+
+// coalescedBatch collects the transfers to one account. full is closed when
+// it reaches the largest batch.
+type coalescedBatch struct {
+	transfers []*coalescedTransfer
+	full      chan struct{}
+}
+
+// coalescer gathers the transfers to the hot accounts into batches. The
+// first transfer of a batch waits for the window, or until the batch is full,
+// and posts it; the others wait for it to.
+type coalescer struct {
+	cfg  CoalescingConfig
+	hot  map[int64]bool
+	post func(destID int64, transfers []*coalescedTransfer)
+
+	mu      sync.Mutex
+	pending map[int64]*coalescedBatch
+}
+
+func newCoalescer(cfg CoalescingConfig, post func(destID int64, transfers []*coalescedTransfer)) *coalescer {
+	c := &coalescer{cfg: cfg, hot: make(map[int64]bool), post: post, pending: make(map[int64]*coalescedBatch)}
+	for _, id := range cfg.Accounts {
+		c.hot[id] = true
+	}
+	return c
+}
+
+// coalesces reports whether a transfer of amount from sourceID to destID is
+// coalesced.
+func (c *coalescer) coalesces(sourceID, destID int64, amount float64) bool {
+	return c != nil && c.hot[destID] && sourceID != destID && (c.cfg.MaxAmount <= 0 || amount <= c.cfg.MaxAmount)
+}
+
+// submit adds a transfer to the batch of destID and returns its outcome, and
+// the cashback intent recorded with it, once the batch is posted.
+func (c *coalescer) submit(sourceID, destID int64, amount float64) (string, *models.TransferIntent, error) {
+	t := &coalescedTransfer{sourceID: sourceID, amount: amount, done: make(chan struct{})}
+	c.mu.Lock()
+	b, ok := c.pending[destID]
+	first := !ok
+	if first {
+		b = &coalescedBatch{full: make(chan struct{})}
+		c.pending[destID] = b
+	}
+	b.transfers = append(b.transfers, t)
+	if len(b.transfers) >= c.cfg.MaxBatch {
+		delete(c.pending, destID)
+		close(b.full)
+	}
+	c.mu.Unlock()
+
+	if first {
+		timer := time.NewTimer(c.cfg.Window)
+		select {
+		case <-timer.C:
+		case <-b.full:
+			timer.Stop()
+		}
+		c.mu.Lock()
+		if c.pending[destID] == b {
+			delete(c.pending, destID)
+		}
+		transfers := b.transfers
+		c.mu.Unlock()
+		metrics.CoalescedBatchSize.Observe(float64(len(transfers)))
+		c.post(destID, transfers)
+	}
+	<-t.done
+	return t.transactionID, t.cashback, t.err
+}
+
+// postCoalesced posts a batch of transfers to destID in one database
+// transaction: each source account is debited once for its transfers and the
+// destination credited once for all of them, while every transfer is logged
+// with its own transaction and event. A transfer its source account cannot
+// cover fails on its own. If the batch fails as a whole, e.g. on a transfer
+// between a live and a test-mode account, its transfers are posted one by one
+// instead, so that only those at fault fail.
+func (s *DefaultService) postCoalesced(destID int64, transfers []*coalescedTransfer) {
+	accepted, err := s.postCoalescedBatch(destID, transfers)
+	if err != nil && !errors.Is(err, ErrDestinationNotFound) && !errors.Is(err, ErrRetriesExhausted) {
+		log.Printf("coalesced batch of %d transfers to account %d failed, posting them one by one: %v", len(accepted), destID, err)
+		for _, t := range accepted {
+			t.transactionID, t.err = s.transfer(context.Background(), t.sourceID, destID, t.amount, models.TransactionTransfer, func(tx *sql.Tx, transactionID string) (err error) {
+				t.cashback, err = s.recordCashbackIntentTx(tx, transactionID, t.sourceID, t.amount)
+				return err
+			})
+		}
+		err = nil
+	}
+	for _, t := range transfers {
+		if t.err == nil && err != nil {
+			t.err = err
+		}
+		close(t.done)
+	}
+}
+
+// postCoalescedBatch posts transfers as postCoalesced describes and returns
+// those their source accounts covered. Until it returns with no error, their
+// outcome is that error.
+func (s *DefaultService) postCoalescedBatch(destID int64, transfers []*coalescedTransfer) ([]*coalescedTransfer, error) {
+	ctx := db.WithHints(context.Background(), db.Critical)
+	var accepted []*coalescedTransfer
+
+	for attempt := 1; attempt <= maxRetries; attempt++ {
+		tx, err := s.hints.BeginTx(ctx, s.db, nil)
+		if err != nil {
+			return transfers, fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		var topUps map[int64]*models.TransferIntent
+		accepted, topUps, err = s.postCoalescedTx(tx, destID, transfers)
+		if err != nil {
+			tx.Rollback()
+			return accepted, err
+		}
+		if err := tx.Commit(); err != nil {
+			if repository.IsSerializationFailure(err) {
+				log.Printf("serialization failure, retrying attempt %d...", attempt)
+				s.clock.Sleep(retryBackoff)
+				continue
+			}
+			return accepted, fmt.Errorf("commit failed: %v", err)
+		}
+
+		for sourceID, intent := range topUps {
+			s.topUp(sourceID, intent)
+		}
+		return accepted, nil
+	}
+	return accepted, ErrRetriesExhausted
+}
+
+// postCoalescedTx posts the transfers their source accounts cover in tx,
+// setting the error of the others, and returns those posted and the top-up
+// intents of the source accounts debited.
+func (s *DefaultService) postCoalescedTx(tx *sql.Tx, destID int64, transfers []*coalescedTransfer) ([]*coalescedTransfer, map[int64]*models.TransferIntent, error) {
+	// Lock the source accounts in ascending order, so that concurrent batches
+	// debiting the same accounts lock them in the same order.
+	sources := make([]int64, 0, len(transfers))
+	for _, t := range transfers {
+		sources = append(sources, t.sourceID)
+	}
+	slices.Sort(sources)
+	sources = slices.Compact(sources)
+	balances := make(map[int64]float64, len(sources))
+	missing := make(map[int64]error)
+	for _, sourceID := range sources {
+		balance, err := s.transactionRepo.GetAccountBalanceTx(tx, sourceID)
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			missing[sourceID] = err
+		case err != nil:
+			return transfers, nil, err
+		default:
+			balances[sourceID] = availableBalance(balance)
+		}
+	}
+
+	var accepted []*coalescedTransfer
+	debits := make(map[int64]float64)
+	var credit float64
+	for _, t := range transfers {
+		t.err = nil
+		switch {
+		case missing[t.sourceID] != nil:
+			t.err = missing[t.sourceID]
+		case balances[t.sourceID]-debits[t.sourceID] < t.amount:
+			t.err = fmt.Errorf("%w in account %d", ErrInsufficientBalance, t.sourceID)
+		default:
+			debits[t.sourceID] += t.amount
+			credit += t.amount
+			accepted = append(accepted, t)
+		}
+	}
+	if len(accepted) == 0 {
+		return nil, nil, nil
+	}
+
+	destExists, err := s.transactionRepo.AccountExistsTx(tx, destID)
+	if err != nil {
+		return accepted, nil, err
+	}
+	if !destExists {
+		return accepted, nil, fmt.Errorf("destination account %d %w", destID, ErrDestinationNotFound)
+	}
+	for _, sourceID := range sources {
+		if debit, ok := debits[sourceID]; ok {
+			if err := s.transactionRepo.UpdateBalanceTx(tx, sourceID, -debit); err != nil {
+				return accepted, nil, fmt.Errorf("error updating source balance: %w", err)
+			}
+		}
+	}
+	// The hot account is locked last, and once for the whole batch.
+	if err := s.transactionRepo.UpdateBalanceTx(tx, destID, credit); err != nil {
+		return accepted, nil, fmt.Errorf("error updating destination balance: %w", err)
+	}
+
+	logs := make([]repository.TransactionLog, len(accepted))
+	for i, t := range accepted {
+		logs[i] = repository.TransactionLog{SourceID: t.sourceID, DestID: destID, Amount: t.amount, Kind: models.TransactionTransfer}
+	}
+	transactionIDs, err := s.transactionRepo.InsertTransactionLogsTx(tx, logs)
+	if err != nil {
+		return accepted, nil, fmt.Errorf("error inserting transaction records: %w", err)
+	}
+	debited := make(map[int64]string, len(debits))
+	for i, t := range accepted {
+		t.transactionID = transactionIDs[i]
+		debited[t.sourceID] = t.transactionID
+		if s.outboxRepo != nil {
+			if err := s.recordTransferEvent(tx, t.transactionID, t.sourceID, destID, t.amount, models.TransactionTransfer); err != nil {
+				return accepted, nil, fmt.Errorf("error writing outbox event: %w", err)
+			}
+		}
+		if t.cashback, err = s.recordCashbackIntentTx(tx, t.transactionID, t.sourceID, t.amount); err != nil {
+			return accepted, nil, fmt.Errorf("error recording cashback intent: %w", err)
+		}
+	}
+	topUps, err := s.recordTopUpIntentsTx(tx, debited)
+	if err != nil {
+		return accepted, nil, fmt.Errorf("error recording top-up intents: %w", err)
+	}
+	return accepted, topUps, nil
+}
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository"
+	"github.com/nehciyy/intrapay/internal/sandbox"
+)
+
+var errForwardingDisabled = errors.New("store-and-forward is not enabled")
+
+// ReplayForwardedTransfer makes the transfer f, accepted into the
+// store-and-forward queue while the database was unreachable, and returns its
+// outcome. The outcome of a completed transfer is recorded in its database
+// transaction, and a transfer has at most one: f replayed again, e.g. because
+// the server stopped before taking it off the queue, returns the outcome it
+// already has and moves no funds. A transfer refused for good is recorded as
+// rejected, and as a transaction attempt of the caller that requested it. An
+// error means f cannot be replayed yet, e.g. because the database is still
+// unreachable, and is to be replayed later. A transfer requested with a
+// test-mode API key is replayed in test mode. A retry of a transfer, one with
+// the Idempotency-Key of an earlier transfer of its API key that has an outcome,
+// takes that outcome and moves no funds.
+func (s *DefaultService) ReplayForwardedTransfer(f models.ForwardedTransfer) (*models.ForwardedTransferStatus, error) {
+	if s.forwardRepo == nil {
+		return nil, errForwardingDisabled
+	}
+	replayed, err := s.forwardOutcome(f)
+	if !errors.Is(err, repository.ErrNotFound) {
+		return replayed, err
+	}
+
+	replayedAt := s.clock.Now()
+	status := models.ForwardedTransferStatus{
+		ID:             f.ID,
+		Status:         models.ForwardCompleted,
+		AcceptedAt:     f.AcceptedAt,
+		ReplayedAt:     &replayedAt,
+		APIKey:         f.APIKey,
+		IdempotencyKey: f.IdempotencyKey,
+	}
+	req := f.Request
+	ctx := context.Background()
+	if sandbox.IsTestKey(f.APIKey) {
+		ctx = sandbox.WithTestMode(ctx)
+	}
+	_, err = s.createTransaction(ctx, req.SourceAccountID, req.DestinationAccountID, float64(req.Amount), req.Tags, func(tx *sql.Tx, transactionID string) error {
+		status.TransactionID = transactionID
+		return s.forwardRepo.InsertForwardedTransferTx(tx, status)
+	})
+	switch {
+	case err == nil:
+		return &status, nil
+	case errors.Is(err, repository.ErrAlreadyReplayed):
+		// Replayed concurrently, or a retry of it was.
+		return s.forwardOutcome(f)
+	case !forwardRejected(err):
+		return nil, err
+	}
+
+	code := RejectionReason(err)
+	rejected, err := s.recordForwardOutcome(f, models.ForwardedTransferStatus{
+		ID:             f.ID,
+		Status:         models.ForwardRejected,
+		AcceptedAt:     f.AcceptedAt,
+		ReplayedAt:     &replayedAt,
+		Code:           code,
+		Error:          err.Error(),
+		APIKey:         f.APIKey,
+		IdempotencyKey: f.IdempotencyKey,
+	})
+	if err != nil || rejected.Status != models.ForwardRejected || code == "" {
+		return rejected, err
+	}
+	s.RecordTransactionAttempt(models.TransactionAttempt{
+		SourceAccountID:      req.SourceAccountID,
+		DestinationAccountID: req.DestinationAccountID,
+		Amount:               req.Amount,
+		Code:                 code,
+		Error:                rejected.Error,
+		APIKey:               f.APIKey,
+		Tenant:               f.Tenant,
+		RequestID:            f.RequestID,
+	})
+	return rejected, nil
+}
+
+// ExpireForwardedTransfer records that the transfer f waited in the
+// store-and-forward queue for too long to be made, unless it already has an
+// outcome, and returns its outcome.
+func (s *DefaultService) ExpireForwardedTransfer(f models.ForwardedTransfer) (*models.ForwardedTransferStatus, error) {
+	if s.forwardRepo == nil {
+		return nil, errForwardingDisabled
+	}
+	replayedAt := s.clock.Now()
+	return s.recordForwardOutcome(f, models.ForwardedTransferStatus{
+		ID:             f.ID,
+		Status:         models.ForwardExpired,
+		AcceptedAt:     f.AcceptedAt,
+		ReplayedAt:     &replayedAt,
+		APIKey:         f.APIKey,
+		IdempotencyKey: f.IdempotencyKey,
+	})
+}
+
+// FindForwardedTransfer returns the outcome of the forwarded transfer apiKey
+// requested with idempotencyKey, or an error wrapping repository.ErrNotFound if
+// it has none yet.
+func (s *DefaultService) FindForwardedTransfer(apiKey, idempotencyKey string) (*models.ForwardedTransferStatus, error) {
+	if s.forwardRepo == nil {
+		return nil, errForwardingDisabled
+	}
+	return s.forwardRepo.FindForwardedTransfer(apiKey, idempotencyKey)
+}
+
+// GetForwardedTransfer returns the outcome of the forwarded transfer id, or an
+// error wrapping repository.ErrNotFound if it has none yet. A test-mode request
+// does not find a transfer completed as a live transaction.
+func (s *DefaultService) GetForwardedTransfer(ctx context.Context, id string) (*models.ForwardedTransferStatus, error) {
+	if s.forwardRepo == nil {
+		return nil, errForwardingDisabled
+	}
+	status, err := s.forwardRepo.GetForwardedTransfer(id)
+	if err != nil || status.TransactionID == "" || sandbox.Livemode(ctx) {
+		return status, err
+	}
+	_, err = s.GetTransaction(ctx, status.TransactionID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("forwarded transfer %s %w", id, repository.ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// recordForwardOutcome records status as the outcome of the forwarded
+// transfer f and returns it, or the outcome f already has.
+func (s *DefaultService) recordForwardOutcome(f models.ForwardedTransfer, status models.ForwardedTransferStatus) (*models.ForwardedTransferStatus, error) {
+	err := s.forwardRepo.InsertForwardedTransfer(status)
+	if errors.Is(err, repository.ErrAlreadyReplayed) {
+		return s.forwardOutcome(f)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// forwardOutcome returns the outcome of the forwarded transfer f. A retry of an
+// earlier transfer, with the Idempotency-Key of one its API key requested that
+// has an outcome, takes the outcome of the first: it is recorded as f's own,
+// without the key, which the first keeps. It returns an error wrapping
+// repository.ErrNotFound if f has no outcome yet.
+func (s *DefaultService) forwardOutcome(f models.ForwardedTransfer) (*models.ForwardedTransferStatus, error) {
+	status, err := s.forwardRepo.GetForwardedTransfer(f.ID)
+	if f.IdempotencyKey == "" || !errors.Is(err, repository.ErrNotFound) {
+		return status, err
+	}
+	first, err := s.forwardRepo.FindForwardedTransfer(f.APIKey, f.IdempotencyKey)
+	if err != nil {
+		return nil, err
+	}
+	retry := *first
+	retry.ID, retry.AcceptedAt, retry.IdempotencyKey = f.ID, f.AcceptedAt, ""
+	err = s.forwardRepo.InsertForwardedTransfer(retry)
+	if errors.Is(err, repository.ErrAlreadyReplayed) {
+		return s.forwardRepo.GetForwardedTransfer(f.ID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &retry, nil
+}
+
+// forwardRejected reports whether a forwarded transfer that failed with err
+// would fail again if replayed later. The database being unreachable, a
+// conflict that outlasted the retries and a passive region all pass.
+func forwardRejected(err error) bool {
+	return !repository.IsUnavailable(err) && !errors.Is(err, ErrRetriesExhausted) && !repository.IsRegionFenced(err)
+}
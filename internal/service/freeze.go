@@ -0,0 +1,209 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository"
+)
+
+var (
+	// ErrTransfersFrozen is returned for a transfer out of an account whose
+	// outgoing transfers are frozen, system-wide or for its tenant.
+	ErrTransfersFrozen = errors.New("outgoing transfers are frozen")
+	// ErrInvalidFreeze is returned for a freeze without an operator or reason, or
+	// with a duration that is not positive or longer than MaxFreezeDuration.
+	ErrInvalidFreeze = errors.New("invalid freeze")
+)
+
+var errTransferFreezesDisabled = errors.New("transfer freezes are not enabled")
+
+const (
+	// MaxFreezeDuration is the longest a freeze may last before it expires on its
+	// own. A longer freeze is a decision to renew, not to forget.
+	MaxFreezeDuration = 24 * time.Hour
+	// freezeRefresh is how stale the freezes a transfer is checked against may
+	// be. Freezes made or lifted through this server take effect at once; those
+	// made through another take effect within freezeRefresh.
+	freezeRefresh = time.Second
+	// freezeHistoryLimit is how many freezes ListTransferFreezes returns with
+	// history: freezes are rare, so the most recent ones are all there is to see.
+	freezeHistoryLimit = 100
+)
+
+// freezeCache holds the freezes in force as last read, so the check at the top
+// of a transfer is in memory but for one read per freezeRefresh. loadedAt is
+// zero until the freezes were first read.
+type freezeCache struct {
+	mu       sync.Mutex
+	freezes  []models.TransferFreeze
+	loadedAt time.Time
+	loaded   bool
+}
+
+// checkTransferFreeze refuses a transfer out of sourceID while a freeze of
+// every tenant, or of the tenant of sourceID, is in force. The tenant is only
+// looked up while some tenant is frozen.
+func (s *DefaultService) checkTransferFreeze(sourceID int64) error {
+	if s.freezeRepo == nil {
+		return nil
+	}
+	now := s.clock.Now()
+	freezes, err := s.freezesInForce(now)
+	if err != nil {
+		return err
+	}
+	var tenant *string
+	for _, f := range freezes {
+		if !f.InForce(now) {
+			continue
+		}
+		if f.Tenant != models.FreezeAllTenants {
+			if tenant == nil {
+				t, err := s.freezeRepo.GetAccountTenant(sourceID)
+				if errors.Is(err, repository.ErrNotFound) {
+					// The transfer fails on the missing account anyway.
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+				tenant = &t
+			}
+			if *tenant != f.Tenant {
+				continue
+			}
+		}
+		return fmt.Errorf("%w for %s until %s: %s", ErrTransfersFrozen, freezeScope(f.Tenant), f.ExpiresAt.UTC().Format(time.RFC3339), f.Reason)
+	}
+	return nil
+}
+
+// freezesInForce returns the freezes in force as last read, reading them again
+// once they are older than freezeRefresh. If they cannot be read, the last ones
+// read stand. Until they have been read once, a freeze may be in force that
+// the server does not know of, so every transfer is refused as frozen, with
+// the error that kept them from being read.
+func (s *DefaultService) freezesInForce(now time.Time) ([]models.TransferFreeze, error) {
+	c := s.freezes
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.loaded && now.Sub(c.loadedAt) < freezeRefresh {
+		return c.freezes, nil
+	}
+	freezes, err := s.freezeRepo.ListTransferFreezesInForce(now)
+	if err != nil {
+		log.Printf("read transfer freezes: %v", err)
+		if c.loadedAt.IsZero() {
+			return nil, fmt.Errorf("%w until the freezes in force can be read: %w", ErrTransfersFrozen, err)
+		}
+		return c.freezes, nil
+	}
+	c.freezes, c.loadedAt, c.loaded = freezes, now, true
+	return freezes, nil
+}
+
+// invalidateFreezes makes the next transfer read the freezes again.
+func (s *DefaultService) invalidateFreezes() {
+	s.freezes.mu.Lock()
+	s.freezes.loaded = false
+	s.freezes.mu.Unlock()
+}
+
+// FreezeTransfers suspends the outgoing transfers of req.Tenant's accounts, or
+// of every account, for req.Duration. It takes effect on this server at once.
+func (s *DefaultService) FreezeTransfers(req models.TransferFreezeRequest) (*models.TransferFreeze, error) {
+	if s.freezeRepo == nil {
+		return nil, errTransferFreezesDisabled
+	}
+	d, err := time.ParseDuration(req.Duration)
+	switch {
+	case err != nil:
+		return nil, fmt.Errorf("%w: duration: %v", ErrInvalidFreeze, err)
+	case d <= 0 || d > MaxFreezeDuration:
+		return nil, fmt.Errorf("%w: duration must be positive and at most %s", ErrInvalidFreeze, MaxFreezeDuration)
+	case strings.TrimSpace(req.Operator) == "":
+		return nil, fmt.Errorf("%w: missing operator", ErrInvalidFreeze)
+	case strings.TrimSpace(req.Reason) == "":
+		return nil, fmt.Errorf("%w: missing reason", ErrInvalidFreeze)
+	}
+	freeze, err := s.freezeRepo.InsertTransferFreeze(models.TransferFreeze{
+		Tenant:    freezeTenant(req.Tenant),
+		Reason:    req.Reason,
+		FrozenBy:  req.Operator,
+		ExpiresAt: s.clock.Now().Add(d),
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.invalidateFreezes()
+	log.Printf("transfer freeze %d: %s froze outgoing transfers for %s until %s: %s",
+		freeze.ID, freeze.FrozenBy, freezeScope(freeze.Tenant), freeze.ExpiresAt.UTC().Format(time.RFC3339), freeze.Reason)
+	return freeze, nil
+}
+
+// LiftTransferFreezes lifts the freezes of req.Tenant, or the freezes of every
+// tenant, still in force and returns them. Freezes of single tenants stay in
+// force when every tenant's freeze is lifted, and the other way round.
+func (s *DefaultService) LiftTransferFreezes(req models.LiftFreezeRequest) ([]models.TransferFreeze, error) {
+	if s.freezeRepo == nil {
+		return nil, errTransferFreezesDisabled
+	}
+	if strings.TrimSpace(req.Operator) == "" {
+		return nil, fmt.Errorf("%w: missing operator", ErrInvalidFreeze)
+	}
+	tenant := freezeTenant(req.Tenant)
+	lifted, err := s.freezeRepo.LiftTransferFreezes(tenant, req.Operator, s.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+	if len(lifted) == 0 {
+		return nil, fmt.Errorf("no freeze for %s %w", freezeScope(tenant), repository.ErrNotFound)
+	}
+	s.invalidateFreezes()
+	for _, f := range lifted {
+		log.Printf("transfer freeze %d: %s lifted the freeze of outgoing transfers for %s", f.ID, req.Operator, freezeScope(f.Tenant))
+	}
+	return lifted, nil
+}
+
+// ListTransferFreezes returns the freezes in force, oldest first, or with
+// history the most recent freezes, newest first, whether or not still in force.
+func (s *DefaultService) ListTransferFreezes(history bool) ([]models.TransferFreeze, error) {
+	if s.freezeRepo == nil {
+		return nil, errTransferFreezesDisabled
+	}
+	var freezes []models.TransferFreeze
+	var err error
+	if history {
+		freezes, err = s.freezeRepo.ListTransferFreezes(freezeHistoryLimit)
+	} else {
+		freezes, err = s.freezeRepo.ListTransferFreezesInForce(s.clock.Now())
+	}
+	if err != nil {
+		return nil, err
+	}
+	if freezes == nil {
+		freezes = []models.TransferFreeze{}
+	}
+	return freezes, nil
+}
+
+func freezeTenant(tenant string) string {
+	if tenant == "" {
+		return models.FreezeAllTenants
+	}
+	return tenant
+}
+
+func freezeScope(tenant string) string {
+	if tenant == models.FreezeAllTenants {
+		return "all tenants"
+	}
+	return "tenant " + tenant
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/nehciyy/intrapay/internal/models"
+)
+
+var errImpersonationDisabled = errors.New("impersonation is not enabled")
+
+// RecordImpersonation audits a request an operator is about to make as a
+// customer. Unlike a rejected transfer, an impersonated request is only served
+// once it is on record, so the error is returned: a request that cannot be
+// audited must be refused.
+func (s *DefaultService) RecordImpersonation(i models.Impersonation) error {
+	if s.impersonationRepo == nil {
+		return errImpersonationDisabled
+	}
+	return s.impersonationRepo.InsertImpersonation(i)
+}
+
+// ListImpersonations returns a page of up to limit impersonated requests
+// matching filter, oldest first, starting after cursor.
+func (s *DefaultService) ListImpersonations(filter models.ImpersonationFilter, cursor string, limit int) (*models.ImpersonationPage, error) {
+	if s.impersonationRepo == nil {
+		return nil, errImpersonationDisabled
+	}
+	after, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	impersonations, next, err := s.impersonationRepo.ListImpersonations(filter, after, limit)
+	if err != nil {
+		return nil, err
+	}
+	if impersonations == nil {
+		impersonations = []models.Impersonation{}
+	}
+	return &models.ImpersonationPage{
+		Impersonations: impersonations,
+		NextCursor:     encodeCursor(next),
+		HasMore:        len(impersonations) == limit,
+	}, nil
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/nehciyy/intrapay/internal/models"
+)
+
+var errIndexAdvisoryDisabled = errors.New("the index advisory is not enabled")
+
+const (
+	// statementScansLimit bounds the statements in the index advisory.
+	statementScansLimit = 20
+	// seqScanHeavyRows is the average of rows read per sequential scan from
+	// which a table read mostly by sequential scans is flagged. Scans of small
+	// tables are cheaper than an index.
+	seqScanHeavyRows = 1000
+)
+
+// IndexAdvisory reports the indexes the migrations create that the database
+// lacks, the tables read mostly by sequential scans of many rows, and, with
+// pg_stat_statements installed, the statements reading the most per call.
+func (s *DefaultService) IndexAdvisory(ctx context.Context) (*models.IndexAdvisory, error) {
+	if s.advisoryRepo == nil {
+		return nil, errIndexAdvisoryDisabled
+	}
+	report := &models.IndexAdvisory{AsOf: s.clock.Now(), MissingIndexes: []models.MissingIndex{}}
+
+	live, err := s.advisoryRepo.ListIndexes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for name, table := range s.expectedIndexes {
+		if live[name] != table {
+			report.MissingIndexes = append(report.MissingIndexes, models.MissingIndex{Name: name, Table: table})
+		}
+	}
+	sort.Slice(report.MissingIndexes, func(i, j int) bool { return report.MissingIndexes[i].Name < report.MissingIndexes[j].Name })
+
+	if report.Tables, err = s.advisoryRepo.ListTableScans(ctx); err != nil {
+		return nil, err
+	}
+	for i, t := range report.Tables {
+		report.Tables[i].SeqScanHeavy = t.SeqScans > t.IndexScans && t.SeqRowsRead >= t.SeqScans*seqScanHeavyRows
+	}
+	if report.Tables == nil {
+		report.Tables = []models.TableScans{}
+	}
+
+	if report.Statements, _, err = s.advisoryRepo.ListStatementScans(ctx, statementScansLimit); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+
+	"github.com/nehciyy/intrapay/internal/db"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository"
+)
+
+// MaxIngestTransfers is the most transfers one bulk ingestion may post.
+const MaxIngestTransfers = 50000
+
+// ErrInvalidIngest is returned for a bulk ingestion with no transfers, too
+// many, or a reference given twice.
+var ErrInvalidIngest = errors.New("invalid ingestion")
+
+var errIngestDisabled = errors.New("bulk ingestion is not enabled")
+
+// IngestRejectedError is returned when transfers of a bulk ingestion cannot be
+// posted. Nothing of the ingestion was posted; Errors tells why, by transfer.
+type IngestRejectedError struct {
+	Transfers int
+	Errors    []models.IngestLineError
+}
+
+func (e *IngestRejectedError) Error() string {
+	return fmt.Sprintf("ingestion rejected: %d of %d transfers cannot be posted", len(e.Errors), e.Transfers)
+}
+
+// Code returns the reason code of the first transfer that cannot be posted.
+func (e *IngestRejectedError) Code() models.ReasonCode {
+	if len(e.Errors) == 0 {
+		return ""
+	}
+	return e.Errors[0].Code
+}
+
+// IngestTransfers posts transfers in bulk, in one database transaction: they
+// are staged with COPY and merged into the balances and the transaction log in
+// a few statements rather than one transfer at a time. Transfers whose
+// reference was ingested before are skipped. The others are validated under
+// the locks of their accounts, as CommitPayroll validates its lines, and posted
+// whole or, with an *IngestRejectedError, not at all.
+func (s *DefaultService) IngestTransfers(transfers []models.IngestTransfer) (*models.IngestResult, error) {
+	if s.ingestRepo == nil {
+		return nil, errIngestDisabled
+	}
+	sources, err := checkIngest(transfers)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkIngestOutgoing(transfers, sources); err != nil {
+		return nil, err
+	}
+	ctx := db.WithHints(context.Background(), db.Critical)
+
+	for attempt := 1; attempt <= maxRetries; attempt++ {
+		tx, err := s.hints.BeginTx(ctx, s.db, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to begin transaction: %w", err)
+		}
+
+		result, topUps, err := s.ingestTx(tx, transfers)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+
+		if err := tx.Commit(); err != nil {
+			if repository.IsSerializationFailure(err) {
+				log.Printf("serialization failure, retrying attempt %d...", attempt)
+				s.clock.Sleep(retryBackoff)
+				continue
+			}
+			return nil, fmt.Errorf("commit failed: %v", err)
+		}
+
+		for _, sourceID := range sources {
+			s.topUp(sourceID, topUps[sourceID])
+		}
+		return result, nil
+	}
+
+	return nil, ErrRetriesExhausted
+}
+
+// ingestTx stages transfers in tx, validates those not ingested before and, if
+// they are valid, merges them and returns the top-up intents of the source
+// accounts they debit.
+func (s *DefaultService) ingestTx(tx *sql.Tx, transfers []models.IngestTransfer) (*models.IngestResult, map[int64]*models.TransferIntent, error) {
+	staged, err := s.ingestRepo.StageTransfersTx(tx, transfers)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error staging transfers: %w", err)
+	}
+	skipped := make(map[int]bool, len(staged.Skipped))
+	for _, line := range staged.Skipped {
+		skipped[line] = true
+	}
+	if lineErrors := validateIngest(transfers, skipped, staged.Accounts); len(lineErrors) > 0 {
+		return nil, nil, &IngestRejectedError{Transfers: len(transfers), Errors: lineErrors}
+	}
+
+	merged, err := s.ingestRepo.MergeTransfersTx(tx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error merging transfers: %w", err)
+	}
+	if len(merged) != len(transfers)-len(skipped) {
+		return nil, nil, fmt.Errorf("merged %d transfers, expected %d", len(merged), len(transfers)-len(skipped))
+	}
+
+	result := &models.IngestResult{Skipped: len(skipped), TransactionIDs: make([]string, len(transfers))}
+	debited := make(map[int64]string)
+	for i, t := range transfers {
+		if skipped[i] {
+			result.TransactionIDs[i] = t.Reference
+			continue
+		}
+		transactionID := merged[result.Ingested]
+		result.TransactionIDs[i] = transactionID
+		result.Ingested++
+		debited[t.SourceAccountID] = transactionID
+		if s.outboxRepo != nil {
+			if err := s.recordTransferEvent(tx, transactionID, t.SourceAccountID, t.DestinationAccountID, float64(t.Amount), models.TransactionTransfer); err != nil {
+				return nil, nil, fmt.Errorf("error writing outbox event: %w", err)
+			}
+		}
+	}
+	topUps, err := s.recordTopUpIntentsTx(tx, debited)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error recording top-up intents: %w", err)
+	}
+	return result, topUps, nil
+}
+
+// checkIngest validates transfers by themselves, and returns their source
+// accounts in ascending order.
+func checkIngest(transfers []models.IngestTransfer) ([]int64, error) {
+	switch {
+	case len(transfers) == 0:
+		return nil, fmt.Errorf("%w: no transfers", ErrInvalidIngest)
+	case len(transfers) > MaxIngestTransfers:
+		return nil, fmt.Errorf("%w: at most %d transfers per ingestion", ErrInvalidIngest, MaxIngestTransfers)
+	}
+	var lineErrors []models.IngestLineError
+	references := make(map[string]int)
+	var sources []int64
+	for i, t := range transfers {
+		if t.Reference != "" {
+			if first, ok := references[t.Reference]; ok {
+				return nil, fmt.Errorf("%w: reference %q on transfers %d and %d", ErrInvalidIngest, t.Reference, first, i)
+			}
+			references[t.Reference] = i
+		}
+		switch {
+		case t.Amount <= 0:
+			lineErrors = append(lineErrors, models.IngestLineError{Line: i, AccountID: t.SourceAccountID, Code: models.ReasonLimitExceeded, Error: "amount must be positive"})
+		case t.SourceAccountID == t.DestinationAccountID:
+			lineErrors = append(lineErrors, models.IngestLineError{Line: i, AccountID: t.SourceAccountID, Code: models.ReasonSameAccount, Error: "an account cannot pay itself"})
+		}
+		sources = append(sources, t.SourceAccountID)
+	}
+	if len(lineErrors) > 0 {
+		return nil, &IngestRejectedError{Transfers: len(transfers), Errors: lineErrors}
+	}
+	slices.Sort(sources)
+	return slices.Compact(sources), nil
+}
+
+// checkIngestOutgoing refuses transfers, out of sources, while a freeze is in
+// force for any of their source accounts, or with the transfers that are out
+// of the amount bounds of theirs.
+func (s *DefaultService) checkIngestOutgoing(transfers []models.IngestTransfer, sources []int64) error {
+	inBounds := make(map[int64]func(models.Amount) error, len(sources))
+	for _, sourceID := range sources {
+		if err := s.checkTransferFreeze(sourceID); err != nil {
+			return err
+		}
+		inBounds[sourceID] = s.amountBoundsCheck(sourceID)
+	}
+	var lineErrors []models.IngestLineError
+	for i, t := range transfers {
+		switch err := inBounds[t.SourceAccountID](t.Amount); {
+		case errors.Is(err, ErrAmountOutOfBounds):
+			lineErrors = append(lineErrors, models.IngestLineError{Line: i, AccountID: t.SourceAccountID, Code: models.ReasonLimitExceeded, Error: err.Error()})
+		case err != nil:
+			return err
+		}
+	}
+	if len(lineErrors) > 0 {
+		return &IngestRejectedError{Transfers: len(transfers), Errors: lineErrors}
+	}
+	return nil
+}
+
+// validateIngest validates the transfers not skipped against accounts, as they
+// stand locked: both accounts of a transfer must be open and of the same mode,
+// and the available balance of every account must cover what the transfers
+// take from it less what they pay into it.
+func validateIngest(transfers []models.IngestTransfer, skipped map[int]bool, accounts map[int64]models.IngestAccount) []models.IngestLineError {
+	lineErrors := []models.IngestLineError{}
+	net := make(map[int64]int64)
+	firstDebit := make(map[int64]int)
+	for i, t := range transfers {
+		if skipped[i] {
+			continue
+		}
+		source, ok := accounts[t.SourceAccountID]
+		if !ok || !source.Open {
+			lineErrors = append(lineErrors, models.IngestLineError{Line: i, AccountID: t.SourceAccountID, Code: models.ReasonAccountNotFound, Error: fmt.Sprintf("account %d not found", t.SourceAccountID)})
+			continue
+		}
+		dest, ok := accounts[t.DestinationAccountID]
+		switch {
+		case !ok || !dest.Open:
+			lineErrors = append(lineErrors, models.IngestLineError{Line: i, AccountID: t.DestinationAccountID, Code: models.ReasonDestinationNotFound, Error: fmt.Sprintf("account %d not found", t.DestinationAccountID)})
+			continue
+		case dest.Livemode != source.Livemode:
+			lineErrors = append(lineErrors, models.IngestLineError{Line: i, AccountID: t.DestinationAccountID, Code: models.ReasonDestinationNotFound, Error: "live and test-mode accounts cannot transfer to each other"})
+			continue
+		}
+		if _, ok := firstDebit[t.SourceAccountID]; !ok {
+			firstDebit[t.SourceAccountID] = i
+		}
+		net[t.SourceAccountID] -= t.Amount.Minor()
+		net[t.DestinationAccountID] += t.Amount.Minor()
+	}
+
+	for accountID, line := range firstDebit {
+		available := models.Amount(availableBalance(accounts[accountID].Balance))
+		if available.Minor()+net[accountID] < 0 {
+			lineErrors = append(lineErrors, models.IngestLineError{
+				Line:      line,
+				AccountID: accountID,
+				Code:      models.ReasonInsufficientFunds,
+				Error:     fmt.Sprintf("the transfers from account %d exceed its available balance %s by %s", accountID, available, fromMinor(-available.Minor()-net[accountID])),
+			})
+		}
+	}
+	slices.SortFunc(lineErrors, func(a, b models.IngestLineError) int { return a.Line - b.Line })
+	return lineErrors
+}
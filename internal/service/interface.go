@@ -1,7 +1,119 @@
 package service
 
+import (
+	"context"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/models"
+)
+
 type Service interface {
-	CreateAccount(accountID int64, initialBalance float64) error
-	GetAccount(accountID int64) (float64, error)
-	CreateTransaction(sourceID int64, destID int64, amount float64) (string, error)
+	CreateAccount(ctx context.Context, accountID int64, initialBalance float64, tenant, owner string) (*models.Account, error)
+	NewAccountID() (int64, error)
+	GetAccount(ctx context.Context, accountID int64) (*models.Account, error)
+	AccountExists(ctx context.Context, accountID int64) (bool, error)
+	ListAccounts(ctx context.Context, filter models.AccountFilter, cursor string, limit int) (*models.AccountPage, error)
+	CreateTransaction(ctx context.Context, sourceID int64, destID int64, amount float64, tags []string) (string, error)
+	CreateTransactionBatch(ctx context.Context, req models.TransactionBatchRequest) (*models.TransactionBatchResult, error)
+	DeleteAccount(ctx context.Context, accountID int64) error
+	RestoreAccount(accountID int64) (*models.Account, error)
+	GetAccountDetails(accountID int64, includeDeleted bool) (*models.Account, error)
+	SetDisplayName(ctx context.Context, accountID int64, name string) (*models.Account, error)
+	AccountSummary(ctx context.Context, accountID int64, loc *time.Location) (*models.AccountSummary, error)
+	ListAccountTransactions(ctx context.Context, accountID int64, cursor string, limit int, unredacted bool) (*models.TransactionPage, error)
+	GetTransaction(ctx context.Context, id string) (*models.Transaction, error)
+	LookupTransactions(ctx context.Context, ids []string) (*models.TransactionLookup, error)
+	ListTransactions(ctx context.Context, filter models.TransactionFilter, cursor string, limit int) (*models.TransactionPage, error)
+	CountTransactions(ctx context.Context, filter models.TransactionFilter) (int64, error)
+	SetTransactionTags(ctx context.Context, id string, tags []string) (*models.Transaction, error)
+	ListAccountTags(ctx context.Context, accountID int64) ([]models.TransactionTag, error)
+	AccountTimeline(ctx context.Context, accountID int64, cursor string, limit int, includeDeleted bool) (*models.AccountTimeline, error)
+	SyncTransactions(ctx context.Context, cursor string, limit int) (*models.TransactionPage, error)
+	RecomputeBalance(accountID int64, apply bool, code models.ReasonCode, reason string) (*models.BalanceRecompute, error)
+	UsageReport(period string) ([]models.Usage, error)
+	SetQuota(q models.Quota) (*models.Quota, error)
+	GetQuota(scope models.QuotaScope, subject string) (*models.QuotaStatus, error)
+	ListQuotas() ([]models.Quota, error)
+	DeleteQuota(scope models.QuotaScope, subject string) error
+	SetAccountLimit(tenant string, maxAccounts int64) (*models.AccountLimit, error)
+	GetAccountLimit(tenant string) (*models.AccountLimitStatus, error)
+	DeleteAccountLimit(tenant string) error
+	SetTopUpRule(accountID int64, req models.SetTopUpRuleRequest) (*models.TopUpRule, error)
+	GetTopUpRule(accountID int64) (*models.TopUpRule, error)
+	DeleteTopUpRule(accountID int64) error
+	CreateCashbackCampaign(req models.CashbackCampaignRequest) (*models.CashbackCampaign, error)
+	UpdateCashbackCampaign(id int64, req models.CashbackCampaignRequest) (*models.CashbackCampaign, error)
+	GetCashbackCampaign(id int64) (*models.CashbackCampaign, error)
+	ListCashbackCampaigns() ([]models.CashbackCampaign, error)
+	DeleteCashbackCampaign(id int64) error
+	CreateSpendingToken(ctx context.Context, accountID int64, req models.SpendingTokenRequest) (*models.SpendingToken, error)
+	GetSpendingToken(ctx context.Context, id string) (*models.SpendingToken, error)
+	ListSpendingTokens(ctx context.Context, accountID int64) ([]models.SpendingToken, error)
+	RevokeSpendingToken(ctx context.Context, id string) (*models.SpendingToken, error)
+	DebitWithToken(ctx context.Context, id string, req models.TokenDebitRequest) (*models.TokenDebit, error)
+	TreasuryPositions(ctx context.Context) (*models.TreasuryPositions, error)
+	LargestAccounts(ctx context.Context, limit int) ([]models.TreasuryAccount, error)
+	DailyFlows(ctx context.Context, days int, loc *time.Location) (*models.TreasuryFlows, error)
+	PutFXRate(date string, req models.FXRateRequest) (*models.FXRate, error)
+	Revalue(date string) (*models.Revaluation, error)
+	GetRevaluation(ctx context.Context, id int64) (*models.Revaluation, error)
+	ListRevaluations(ctx context.Context, limit int) ([]models.Revaluation, error)
+	PurgeTestData() (*models.SandboxPurge, error)
+	FXRates(ctx context.Context, currencies []string) ([]models.FXQuote, error)
+	ListPendingActions(filter models.PendingActionFilter, cursor string, limit int) (*models.PendingActionPage, error)
+	CountPendingActions(filter models.PendingActionFilter) (int64, error)
+	ClaimPendingAction(id int64, assignee string) (*models.PendingAction, error)
+	AssignPendingAction(id int64, assignee string) (*models.PendingAction, error)
+	PreviewPayroll(ctx context.Context, req models.PayrollRequest) (*models.PayrollPreview, error)
+	CommitPayroll(ctx context.Context, req models.PayrollRequest) (*models.PayrollResult, error)
+	IngestTransfers(transfers []models.IngestTransfer) (*models.IngestResult, error)
+	ReceivePayment(ctx context.Context, sourceID, destID int64, amount float64, reference string) (*models.InboundPayment, error)
+	ListSuspenseItems(cursor string, limit int) (*models.SuspenseItemPage, error)
+	RepostSuspenseItem(id, accountID int64) (*models.SuspenseItem, error)
+	SubmitReimbursement(ctx context.Context, tenant string, req models.ReimbursementRequest) (*models.Reimbursement, error)
+	GetReimbursement(ctx context.Context, id int64, tenant string) (*models.Reimbursement, error)
+	ApproveReimbursement(id int64, d models.ReimbursementDecision) (*models.Reimbursement, error)
+	RejectReimbursement(id int64, d models.ReimbursementDecision) (*models.Reimbursement, error)
+	RecordTransactionAttempt(a models.TransactionAttempt)
+	ListTransactionAttempts(filter models.TransactionAttemptFilter, cursor string, limit int) (*models.TransactionAttemptPage, error)
+	CountTransactionAttempts(filter models.TransactionAttemptFilter) (int64, error)
+	TransactionAttemptStats(groupBy models.AttemptGrouping, filter models.TransactionAttemptFilter) ([]models.TransactionAttemptStat, error)
+	LookupRequest(requestID string) (*models.RequestRecord, error)
+	RecordImpersonation(i models.Impersonation) error
+	FreezeTransfers(req models.TransferFreezeRequest) (*models.TransferFreeze, error)
+	LiftTransferFreezes(req models.LiftFreezeRequest) ([]models.TransferFreeze, error)
+	ListTransferFreezes(history bool) ([]models.TransferFreeze, error)
+	AmountBoundsOf(tenant string) models.AmountBounds
+	SetAmountBounds(tenant string, req models.SetAmountBoundsRequest) (*models.AmountBounds, error)
+	ListAmountBounds() ([]models.AmountBounds, error)
+	DeleteAmountBounds(tenant string) error
+	ListImpersonations(filter models.ImpersonationFilter, cursor string, limit int) (*models.ImpersonationPage, error)
+	ListSecurityEvents(afterID int64, limit int) (*models.SecurityEventPage, error)
+	IndexAdvisory(ctx context.Context) (*models.IndexAdvisory, error)
+	ReplayTransactionAttempt(id int64, apply bool) (*models.TransactionAttemptReplay, error)
+	ResumeTransferIntents(before time.Time) (int, error)
+	ReplayForwardedTransfer(f models.ForwardedTransfer) (*models.ForwardedTransferStatus, error)
+	ExpireForwardedTransfer(f models.ForwardedTransfer) (*models.ForwardedTransferStatus, error)
+	GetForwardedTransfer(ctx context.Context, id string) (*models.ForwardedTransferStatus, error)
+	FindForwardedTransfer(apiKey, idempotencyKey string) (*models.ForwardedTransferStatus, error)
+	OutboxRelayStatus() (*models.OutboxRelayStatus, error)
+	PauseOutboxRelay() (*models.OutboxRelayStatus, error)
+	ResumeOutboxRelay() (*models.OutboxRelayStatus, error)
+	ReplayOutbox(afterID int64) (*models.OutboxRelayStatus, error)
+	ListEvents(afterID int64, limit int) (*models.EventPage, error)
+	RegisterWebhook(ctx context.Context, tenant, apiKey string, req models.WebhookRequest) (*models.Webhook, error)
+	GetWebhook(id int64, tenant string) (*models.Webhook, error)
+	ListWebhooks(tenant string) ([]models.Webhook, error)
+	DeleteWebhook(id int64, tenant string) error
+	PingWebhook(id int64, tenant string) (*models.WebhookPing, error)
+	NotifyEventWebhooks(e models.Event)
+	GetNotificationPreference(ctx context.Context, tenant, apiKey string, accountID int64) (*models.NotificationPreference, error)
+	SetNotificationPreference(ctx context.Context, tenant, apiKey string, accountID int64, req models.NotificationPreferenceRequest) (*models.NotificationPreference, error)
+	SendDailyDigests() (int, error)
+	RegionStatus() (*models.RegionStatus, error)
+	PromoteRegion(region string, epoch int64) (*models.RegionStatus, error)
 }
+
+// DefaultService is the only implementation; handlers reach business logic
+// through the Service interface alone.
+var _ Service = (*DefaultService)(nil)
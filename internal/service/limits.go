@@ -0,0 +1,91 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository"
+)
+
+var (
+	// ErrAccountLimitExceeded is returned when a tenant that already has as many
+	// open accounts as its account limit allows opens another.
+	ErrAccountLimitExceeded = errors.New("account limit exceeded")
+	// ErrInvalidAccountLimit is returned for a negative account limit or one
+	// without a tenant.
+	ErrInvalidAccountLimit = errors.New("invalid account limit")
+)
+
+// checkAccountLimit refuses a new account for a tenant at its account limit.
+// The limit is soft: accounts opened concurrently are counted before either is
+// committed, so a burst can overshoot it by the number of requests in flight.
+func (s *DefaultService) checkAccountLimit(tenant string) error {
+	limit, _, err := s.accountLimitOf(tenant)
+	if err != nil || limit == 0 {
+		return err
+	}
+	open, err := s.accountRepo.CountAccounts(tenant)
+	if err != nil {
+		return err
+	}
+	if open >= limit {
+		return fmt.Errorf("%w: tenant %s has %d open accounts, the most allowed", ErrAccountLimitExceeded, tenant, open)
+	}
+	return nil
+}
+
+// accountLimitOf returns the account limit in force for tenant and whether it is
+// the tenant's own rather than the server-wide default.
+func (s *DefaultService) accountLimitOf(tenant string) (int64, bool, error) {
+	if s.quotaRepo == nil {
+		return s.accountLimit, false, nil
+	}
+	l, err := s.quotaRepo.GetAccountLimit(tenant)
+	if errors.Is(err, repository.ErrNotFound) {
+		return s.accountLimit, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return l.MaxAccounts, true, nil
+}
+
+// SetAccountLimit overrides the server-wide account limit for tenant; zero lets
+// it open any number of accounts. Accounts it already has beyond the limit stay
+// open.
+func (s *DefaultService) SetAccountLimit(tenant string, maxAccounts int64) (*models.AccountLimit, error) {
+	if s.quotaRepo == nil {
+		return nil, errQuotasDisabled
+	}
+	switch {
+	case tenant == "":
+		return nil, fmt.Errorf("%w: missing tenant", ErrInvalidAccountLimit)
+	case maxAccounts < 0:
+		return nil, fmt.Errorf("%w: must not be negative", ErrInvalidAccountLimit)
+	}
+	return s.quotaRepo.SetAccountLimit(tenant, maxAccounts)
+}
+
+// GetAccountLimit returns the account limit in force for tenant with the number
+// of accounts it has open.
+func (s *DefaultService) GetAccountLimit(tenant string) (*models.AccountLimitStatus, error) {
+	limit, override, err := s.accountLimitOf(tenant)
+	if err != nil {
+		return nil, err
+	}
+	open, err := s.accountRepo.CountAccounts(tenant)
+	if err != nil {
+		return nil, err
+	}
+	return &models.AccountLimitStatus{Tenant: tenant, MaxAccounts: limit, Override: override, OpenAccounts: open}, nil
+}
+
+// DeleteAccountLimit removes the account limit of tenant, which falls back to
+// the server-wide default.
+func (s *DefaultService) DeleteAccountLimit(tenant string) error {
+	if s.quotaRepo == nil {
+		return errQuotasDisabled
+	}
+	return s.quotaRepo.DeleteAccountLimit(tenant)
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository"
+)
+
+var (
+	// ErrInvalidNotificationMode is returned for a notification mode other than
+	// immediate and daily_digest, or a time zone that is not an IANA name.
+	ErrInvalidNotificationMode = errors.New("invalid notification mode")
+
+	errNotificationsDisabled = errors.New("notification preferences are not enabled")
+)
+
+// GetNotificationPreference returns how the notifications of an account are
+// sent, to its owner, the caller with apiKey, alone.
+func (s *DefaultService) GetNotificationPreference(ctx context.Context, tenant, apiKey string, accountID int64) (*models.NotificationPreference, error) {
+	if s.notificationRepo == nil || s.webhookRepo == nil {
+		return nil, errNotificationsDisabled
+	}
+	if err := s.checkAccountOwner(ctx, tenant, apiKey, accountID); err != nil {
+		return nil, err
+	}
+	return s.notificationRepo.GetNotificationPreference(accountID)
+}
+
+// SetNotificationPreference sets how the notifications of an account are sent
+// to its webhooks: each as it happens, or held back for the account's daily
+// digest, of the days in req.Timezone, UTC if empty. Only the owner of the
+// account, the caller with apiKey, may.
+func (s *DefaultService) SetNotificationPreference(ctx context.Context, tenant, apiKey string, accountID int64, req models.NotificationPreferenceRequest) (*models.NotificationPreference, error) {
+	if s.notificationRepo == nil || s.webhookRepo == nil {
+		return nil, errNotificationsDisabled
+	}
+	switch req.Mode {
+	case models.NotificationImmediate, models.NotificationDailyDigest:
+	default:
+		return nil, fmt.Errorf("%w: mode must be %q or %q", ErrInvalidNotificationMode, models.NotificationImmediate, models.NotificationDailyDigest)
+	}
+	timezone := req.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	// LoadLocation also takes "Local", which would depend on the server.
+	if _, err := time.LoadLocation(timezone); err != nil || timezone == "Local" {
+		return nil, fmt.Errorf("%w: invalid timezone %q, expected an IANA name such as Europe/Berlin", ErrInvalidNotificationMode, timezone)
+	}
+	if err := s.checkAccountOwner(ctx, tenant, apiKey, accountID); err != nil {
+		return nil, err
+	}
+	return s.notificationRepo.SetNotificationPreference(accountID, req.Mode, timezone)
+}
+
+// notificationMode returns how the notifications of an account are sent,
+// immediately if that cannot be read.
+func (s *DefaultService) notificationMode(accountID int64) models.NotificationMode {
+	if s.notificationRepo == nil {
+		return models.NotificationImmediate
+	}
+	pref, err := s.notificationRepo.GetNotificationPreference(accountID)
+	if err != nil {
+		log.Printf("read notification mode of account %d: %v", accountID, err)
+		return models.NotificationImmediate
+	}
+	return pref.Mode
+}
+
+// SendDailyDigests sends the digests not sent yet of every day closed in the
+// time zone of each account whose notifications are sent as a daily digest and
+// that had transactions that day, oldest day first, and returns how many it
+// sent. Days missed while digests were not sent, e.g. while every server was
+// down, are caught up on. Each digest is an account.daily_digest event, written
+// to the outbox with the record that it was sent and delivered to the
+// account's webhooks by the outbox relay, so an account is sent one digest a
+// day however often this runs, and a digest recorded as sent is delivered
+// even if the server stops right after.
+func (s *DefaultService) SendDailyDigests() (int, error) {
+	if s.notificationRepo == nil || s.webhookRepo == nil || s.outboxRepo == nil {
+		return 0, errNotificationsDisabled
+	}
+	digests, err := s.notificationRepo.ListPendingDigests(s.clock.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	var sent int
+	for _, d := range digests {
+		err := s.recordDigest(d)
+		if errors.Is(err, repository.ErrAlreadyDigested) {
+			// Sent by another server since the digests were listed.
+			continue
+		}
+		if err != nil {
+			// The days after it are left for the next run too, since only those
+			// after the last day sent are listed.
+			return sent, fmt.Errorf("digest of account %d on %s: %w", d.AccountID, d.Date, err)
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+// recordDigest records that the digest d is sent and writes its event to the
+// outbox, in one database transaction.
+func (s *DefaultService) recordDigest(d models.DailyDigest) error {
+	payload, err := json.Marshal(models.AccountDailyDigest{
+		AccountID:     d.AccountID,
+		Date:          d.Date,
+		Transactions:  d.IncomingCount + d.OutgoingCount,
+		Incoming:      d.Incoming,
+		IncomingCount: d.IncomingCount,
+		Outgoing:      d.Outgoing,
+		OutgoingCount: d.OutgoingCount,
+		Net:           fromMinor(d.Incoming.Minor() - d.Outgoing.Minor()),
+	})
+	if err != nil {
+		return err
+	}
+	event := models.Event{
+		Type:          models.EventAccountDailyDigest,
+		SchemaVersion: models.AccountDailyDigestVersion,
+		AggregateType: models.AggregateAccount,
+		AggregateID:   strconv.FormatInt(d.AccountID, 10),
+		Payload:       payload,
+		CreatedAt:     s.clock.Now(),
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	if err := s.notificationRepo.InsertDigestTx(tx, d); err != nil {
+		return err
+	}
+	if _, err := s.outboxRepo.InsertEventTx(tx, event); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit failed: %w", err)
+	}
+	return nil
+}
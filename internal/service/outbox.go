@@ -0,0 +1,116 @@
+package service
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/nehciyy/intrapay/internal/models"
+)
+
+// ErrInvalidOffset is returned for a negative outbox offset.
+var ErrInvalidOffset = errors.New("invalid offset")
+
+var errOutboxDisabled = errors.New("the outbox is not enabled")
+
+// recordTransferEvent writes the transfer.completed event of a transfer in its
+// transaction. The event belongs to the source account, whose row lock the
+// transfer holds, so events of an account are written in commit order. The kind
+// is only named for transactions that are not client transfers.
+func (s *DefaultService) recordTransferEvent(tx *sql.Tx, transactionID string, sourceID, destID int64, amount float64, kind models.TransactionKind) error {
+	event := models.TransferCompleted{
+		TransactionID:        transactionID,
+		SourceAccountID:      sourceID,
+		DestinationAccountID: destID,
+		Amount:               models.Amount(amount),
+	}
+	if kind != models.TransactionTransfer {
+		event.Kind = kind
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = s.outboxRepo.InsertEventTx(tx, models.Event{
+		Type:          models.EventTransferCompleted,
+		SchemaVersion: models.TransferCompletedVersion,
+		AggregateType: models.AggregateAccount,
+		AggregateID:   strconv.FormatInt(sourceID, 10),
+		Payload:       payload,
+	})
+	return err
+}
+
+// OutboxRelayStatus returns the position of the outbox relay.
+func (s *DefaultService) OutboxRelayStatus() (*models.OutboxRelayStatus, error) {
+	if s.outboxRepo == nil {
+		return nil, errOutboxDisabled
+	}
+	return s.outboxRepo.GetOutboxRelay()
+}
+
+// PauseOutboxRelay stops the relay after its current batch until it is resumed.
+// Events keep being written to the outbox meanwhile.
+func (s *DefaultService) PauseOutboxRelay() (*models.OutboxRelayStatus, error) {
+	return s.setOutboxRelayPaused(true)
+}
+
+// ResumeOutboxRelay lets a paused relay continue from its high-water mark.
+func (s *DefaultService) ResumeOutboxRelay() (*models.OutboxRelayStatus, error) {
+	return s.setOutboxRelayPaused(false)
+}
+
+func (s *DefaultService) setOutboxRelayPaused(paused bool) (*models.OutboxRelayStatus, error) {
+	if s.outboxRepo == nil {
+		return nil, errOutboxDisabled
+	}
+	if err := s.outboxRepo.SetOutboxRelayPaused(paused); err != nil {
+		return nil, err
+	}
+	return s.outboxRepo.GetOutboxRelay()
+}
+
+// ListEvents returns up to limit events after afterID in id order, the durable
+// log consumers backfill missed deliveries from. Events still within the outbox's
+// settle window are not returned yet, so a page never skips an event that
+// commits later with a lower ID.
+func (s *DefaultService) ListEvents(afterID int64, limit int) (*models.EventPage, error) {
+	if s.outboxRepo == nil {
+		return nil, errOutboxDisabled
+	}
+	if afterID < 0 {
+		return nil, fmt.Errorf("%w %d", ErrInvalidOffset, afterID)
+	}
+	events, err := s.outboxRepo.ListOutboxEvents(afterID, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	page := &models.EventPage{Events: events, NextAfterID: afterID}
+	if len(events) > limit {
+		page.Events, page.HasMore = events[:limit], true
+	}
+	if page.Events == nil {
+		page.Events = []models.Event{}
+	}
+	if n := len(page.Events); n > 0 {
+		page.NextAfterID = page.Events[n-1].ID
+	}
+	return page, nil
+}
+
+// ReplayOutbox moves the relay back (or forward) to afterID, so it publishes
+// every event after it, including those already published.
+func (s *DefaultService) ReplayOutbox(afterID int64) (*models.OutboxRelayStatus, error) {
+	if s.outboxRepo == nil {
+		return nil, errOutboxDisabled
+	}
+	if afterID < 0 {
+		return nil, fmt.Errorf("%w %d", ErrInvalidOffset, afterID)
+	}
+	if err := s.outboxRepo.SetOutboxRelayPosition(afterID); err != nil {
+		return nil, err
+	}
+	return s.outboxRepo.GetOutboxRelay()
+}
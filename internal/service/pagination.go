@@ -0,0 +1,75 @@
+package service
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository"
+)
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// newTransactionPage builds a page from a repository result. A full page may
+// have more rows behind it; a short page is the end of the listing for now.
+func newTransactionPage(transactions []models.Transaction, next repository.ChangeCursor, limit int) *models.TransactionPage {
+	if transactions == nil {
+		transactions = []models.Transaction{}
+	}
+	return &models.TransactionPage{
+		Transactions: transactions,
+		NextCursor:   encodeCursor(next),
+		HasMore:      len(transactions) == limit,
+	}
+}
+
+// Cursors are opaque to clients: a versioned (updated_at, id) pair, base64url encoded.
+func encodeCursor(c repository.ChangeCursor) string {
+	if c == (repository.ChangeCursor{}) {
+		return ""
+	}
+	raw := fmt.Sprintf("v1:%d:%d", c.UpdatedAt.UnixNano(), c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// Account listings are keyed on account_id alone: a versioned account ID,
+// base64url encoded.
+func encodeAccountCursor(accountID int64) string {
+	if accountID == 0 {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("a1:%d", accountID)))
+}
+
+func decodeAccountCursor(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+	var accountID int64
+	if _, err := fmt.Sscanf(string(raw), "a1:%d", &accountID); err != nil || accountID <= 0 {
+		return 0, ErrInvalidCursor
+	}
+	return accountID, nil
+}
+
+func decodeCursor(s string) (repository.ChangeCursor, error) {
+	if s == "" {
+		return repository.ChangeCursor{}, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return repository.ChangeCursor{}, ErrInvalidCursor
+	}
+	var nanos, id int64
+	if _, err := fmt.Sscanf(string(raw), "v1:%d:%d", &nanos, &id); err != nil {
+		return repository.ChangeCursor{}, ErrInvalidCursor
+	}
+	return repository.ChangeCursor{UpdatedAt: time.Unix(0, nanos).UTC(), ID: id}, nil
+}
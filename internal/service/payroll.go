@@ -0,0 +1,256 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+
+	"github.com/nehciyy/intrapay/internal/db"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository"
+)
+
+// MaxPayrollLines is the most lines one payroll batch may have.
+const MaxPayrollLines = 1000
+
+// ErrInvalidPayroll is returned for a payroll batch with no lines or too many.
+var ErrInvalidPayroll = errors.New("invalid payroll")
+
+// PayrollRejectedError is returned when a payroll batch fails validation at
+// commit. Nothing of the batch was posted; Preview tells why.
+type PayrollRejectedError struct {
+	Preview *models.PayrollPreview
+}
+
+func (e *PayrollRejectedError) Error() string {
+	if e.Preview.Error != "" {
+		return "payroll rejected: " + e.Preview.Error
+	}
+	return fmt.Sprintf("payroll rejected: %d of %d lines cannot be paid", len(e.Preview.LineErrors), e.Preview.Lines)
+}
+
+// Code returns the reason code of the rejection: that of the batch as a whole
+// if it has one, else that of its first failing line.
+func (e *PayrollRejectedError) Code() models.ReasonCode {
+	if e.Preview.Code != "" || len(e.Preview.LineErrors) == 0 {
+		return e.Preview.Code
+	}
+	return e.Preview.LineErrors[0].Code
+}
+
+// PreviewPayroll validates a payroll batch without posting it: every line must
+// pay a positive amount to an existing account other than the employer's, and
+// the available balance of the employer must cover the total. The preview is
+// only as good as the moment it was taken; CommitPayroll validates again.
+func (s *DefaultService) PreviewPayroll(ctx context.Context, req models.PayrollRequest) (*models.PayrollPreview, error) {
+	if err := checkPayrollSize(req); err != nil {
+		return nil, err
+	}
+	return previewPayroll(req,
+		func() (float64, error) {
+			account, err := s.getAccount(ctx, req.EmployerAccountID, false)
+			if err != nil {
+				return 0, err
+			}
+			return float64(account.Balance), nil
+		},
+		func(accountID int64) (bool, error) {
+			return s.accountRepo.AccountExists(ctx, accountID)
+		})
+}
+
+// CommitPayroll posts a payroll batch in one database transaction: the employer
+// account is debited the total and every line is logged as a transfer of its
+// own. The batch is validated again under the lock of the employer account and
+// posted whole or, with a *PayrollRejectedError, not at all.
+func (s *DefaultService) CommitPayroll(ctx context.Context, req models.PayrollRequest) (*models.PayrollResult, error) {
+	if err := checkPayrollSize(req); err != nil {
+		return nil, err
+	}
+	if err := s.checkTestMode(ctx, req.EmployerAccountID); err != nil {
+		return nil, err
+	}
+	if err := s.checkTransferFreeze(req.EmployerAccountID); err != nil {
+		return nil, err
+	}
+	inBounds := s.amountBoundsCheck(req.EmployerAccountID)
+	for i, line := range req.Lines {
+		if err := inBounds(line.Amount); err != nil {
+			return nil, fmt.Errorf("line %d: %w", i, err)
+		}
+	}
+	ctx = db.WithHints(ctx, db.Critical)
+
+	for attempt := 1; attempt <= maxRetries; attempt++ {
+		tx, err := s.hints.BeginTx(ctx, s.db, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to begin transaction: %w", err)
+		}
+
+		result, err := s.postPayrollTx(tx, req)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		topUp, err := s.recordTopUpIntentTx(tx, result.TransactionIDs[0], req.EmployerAccountID)
+		if err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("error recording top-up intent: %w", err)
+		}
+
+		if err := tx.Commit(); err != nil {
+			if repository.IsSerializationFailure(err) {
+				log.Printf("serialization failure, retrying attempt %d...", attempt)
+				s.clock.Sleep(retryBackoff)
+				continue
+			}
+			return nil, fmt.Errorf("commit failed: %v", err)
+		}
+
+		s.topUp(req.EmployerAccountID, topUp)
+		return result, nil
+	}
+
+	return nil, ErrRetriesExhausted
+}
+
+// postPayrollTx validates req in tx and, if it is valid, posts it.
+func (s *DefaultService) postPayrollTx(tx *sql.Tx, req models.PayrollRequest) (*models.PayrollResult, error) {
+	// Reading the balance locks the employer account, so two batches of one
+	// employer cannot both spend the same funds.
+	preview, err := previewPayroll(req,
+		func() (float64, error) {
+			return s.transactionRepo.GetAccountBalanceTx(tx, req.EmployerAccountID)
+		},
+		func(accountID int64) (bool, error) {
+			return s.transactionRepo.AccountExistsTx(tx, accountID)
+		})
+	if err != nil {
+		return nil, err
+	}
+	if !preview.Valid {
+		return nil, &PayrollRejectedError{Preview: preview}
+	}
+
+	if err := s.transactionRepo.UpdateBalanceTx(tx, req.EmployerAccountID, -float64(preview.Total)); err != nil {
+		return nil, fmt.Errorf("error updating employer balance: %w", err)
+	}
+	// Credit each account once, in ascending order, so that concurrent batches
+	// paying the same accounts lock them in the same order.
+	credits := make(map[int64]int64)
+	logs := make([]repository.TransactionLog, len(req.Lines))
+	for i, line := range req.Lines {
+		credits[line.AccountID] += line.Amount.Minor()
+		logs[i] = repository.TransactionLog{
+			SourceID: req.EmployerAccountID,
+			DestID:   line.AccountID,
+			Amount:   float64(line.Amount),
+			Kind:     models.TransactionTransfer,
+		}
+	}
+	accountIDs := make([]int64, 0, len(credits))
+	for accountID := range credits {
+		accountIDs = append(accountIDs, accountID)
+	}
+	slices.Sort(accountIDs)
+	for _, accountID := range accountIDs {
+		if err := s.transactionRepo.UpdateBalanceTx(tx, accountID, float64(fromMinor(credits[accountID]))); err != nil {
+			return nil, fmt.Errorf("error updating balance of account %d: %w", accountID, err)
+		}
+	}
+
+	transactionIDs, err := s.transactionRepo.InsertTransactionLogsTx(tx, logs)
+	if err != nil {
+		return nil, fmt.Errorf("error inserting transaction records: %w", err)
+	}
+	if s.outboxRepo != nil {
+		for i, line := range req.Lines {
+			if err := s.recordTransferEvent(tx, transactionIDs[i], req.EmployerAccountID, line.AccountID, float64(line.Amount), models.TransactionTransfer); err != nil {
+				return nil, fmt.Errorf("error writing outbox event: %w", err)
+			}
+		}
+	}
+
+	return &models.PayrollResult{
+		EmployerAccountID: req.EmployerAccountID,
+		Total:             preview.Total,
+		TransactionIDs:    transactionIDs,
+	}, nil
+}
+
+func checkPayrollSize(req models.PayrollRequest) error {
+	switch {
+	case len(req.Lines) == 0:
+		return fmt.Errorf("%w: no lines", ErrInvalidPayroll)
+	case len(req.Lines) > MaxPayrollLines:
+		return fmt.Errorf("%w: at most %d lines per batch", ErrInvalidPayroll, MaxPayrollLines)
+	}
+	return nil
+}
+
+// previewPayroll validates req against the balance of the employer account and
+// the existence of the paid accounts, as reported by balance and exists. Every
+// problem found is reported, not just the first; errors other than a missing
+// employer account abort the preview.
+func previewPayroll(req models.PayrollRequest, balance func() (float64, error), exists func(accountID int64) (bool, error)) (*models.PayrollPreview, error) {
+	preview := &models.PayrollPreview{
+		EmployerAccountID: req.EmployerAccountID,
+		Lines:             len(req.Lines),
+		LineErrors:        []models.PayrollLineError{},
+	}
+
+	employerBalance, err := balance()
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		preview.Code = models.ReasonAccountNotFound
+		preview.Error = fmt.Sprintf("employer account %d not found", req.EmployerAccountID)
+	case err != nil:
+		return nil, err
+	default:
+		preview.EmployerBalance = models.Amount(availableBalance(employerBalance))
+	}
+
+	var total int64
+	found := make(map[int64]bool)
+	for i, line := range req.Lines {
+		lineError := func(code models.ReasonCode, msg string) {
+			preview.LineErrors = append(preview.LineErrors, models.PayrollLineError{Line: i, AccountID: line.AccountID, Code: code, Error: msg})
+		}
+		if line.Amount <= 0 {
+			lineError(models.ReasonLimitExceeded, "amount must be positive")
+			continue
+		}
+		total += line.Amount.Minor()
+		if line.AccountID == req.EmployerAccountID {
+			lineError(models.ReasonSameAccount, "the employer account cannot pay itself")
+			continue
+		}
+		ok, seen := found[line.AccountID]
+		if !seen {
+			if ok, err = exists(line.AccountID); err != nil {
+				return nil, err
+			}
+			found[line.AccountID] = ok
+		}
+		if !ok {
+			lineError(models.ReasonDestinationNotFound, fmt.Sprintf("account %d not found", line.AccountID))
+		}
+	}
+	preview.Total = fromMinor(total)
+
+	if preview.Code == "" && float64(preview.EmployerBalance) < float64(preview.Total) {
+		preview.Code = models.ReasonInsufficientFunds
+		preview.Error = fmt.Sprintf("total %s exceeds the available balance %s of employer account %d",
+			preview.Total, preview.EmployerBalance, req.EmployerAccountID)
+	}
+	preview.Valid = preview.Code == "" && len(preview.LineErrors) == 0
+	return preview, nil
+}
+
+// fromMinor converts an amount in minor units of the currency back to an Amount.
+func fromMinor(minor int64) models.Amount {
+	return models.Amount(float64(minor) / float64(models.CurrentCurrency().Scale()))
+}
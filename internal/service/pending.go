@@ -0,0 +1,66 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/nehciyy/intrapay/internal/models"
+)
+
+// ErrMissingAssignee is returned when claiming a pending action for nobody.
+var ErrMissingAssignee = errors.New("missing assignee")
+
+var errPendingActionsDisabled = errors.New("pending actions are not enabled")
+
+// ListPendingActions returns a page of up to limit open actions matching filter,
+// oldest first, starting after cursor.
+func (s *DefaultService) ListPendingActions(filter models.PendingActionFilter, cursor string, limit int) (*models.PendingActionPage, error) {
+	if s.pendingRepo == nil {
+		return nil, errPendingActionsDisabled
+	}
+	after, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	actions, next, err := s.pendingRepo.ListPendingActions(filter, after, limit)
+	if err != nil {
+		return nil, err
+	}
+	if actions == nil {
+		actions = []models.PendingAction{}
+	}
+	return &models.PendingActionPage{
+		Actions:    actions,
+		NextCursor: encodeCursor(next),
+		HasMore:    len(actions) == limit,
+	}, nil
+}
+
+// CountPendingActions counts the open actions matching filter.
+func (s *DefaultService) CountPendingActions(filter models.PendingActionFilter) (int64, error) {
+	if s.pendingRepo == nil {
+		return 0, errPendingActionsDisabled
+	}
+	return s.pendingRepo.CountPendingActions(filter)
+}
+
+// ClaimPendingAction assigns an unassigned action to assignee. Claiming an action
+// someone else holds fails with repository.ErrAlreadyClaimed; use
+// AssignPendingAction to take it over.
+func (s *DefaultService) ClaimPendingAction(id int64, assignee string) (*models.PendingAction, error) {
+	if s.pendingRepo == nil {
+		return nil, errPendingActionsDisabled
+	}
+	if assignee == "" {
+		return nil, ErrMissingAssignee
+	}
+	return s.pendingRepo.ClaimPendingAction(id, assignee)
+}
+
+// AssignPendingAction hands an action to assignee regardless of who holds it.
+// An empty assignee puts the action back in the unassigned pool.
+func (s *DefaultService) AssignPendingAction(id int64, assignee string) (*models.PendingAction, error) {
+	if s.pendingRepo == nil {
+		return nil, errPendingActionsDisabled
+	}
+	return s.pendingRepo.AssignPendingAction(id, assignee)
+}
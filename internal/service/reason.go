@@ -0,0 +1,60 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository"
+)
+
+// ErrInvalidReasonCode is returned for a reason code that is not in the catalog
+// or does not belong where it was given.
+var ErrInvalidReasonCode = errors.New("invalid reason code")
+
+// RejectionReason returns the reason code of a refused transfer, or "" if err is
+// not a rejection but a failure, e.g. of the database.
+func RejectionReason(err error) models.ReasonCode {
+	switch {
+	case errors.Is(err, ErrInsufficientBalance):
+		return models.ReasonInsufficientFunds
+	case errors.Is(err, ErrDestinationNotFound), errors.Is(err, ErrLivemodeMismatch), repository.IsLivemodeMismatch(err):
+		return models.ReasonDestinationNotFound
+	case errors.Is(err, ErrTokenNotFound):
+		return models.ReasonTokenNotFound
+	case errors.Is(err, ErrTokenRevoked):
+		return models.ReasonTokenRevoked
+	case errors.Is(err, ErrTokenExpired):
+		return models.ReasonTokenExpired
+	case errors.Is(err, ErrTokenLimitExceeded):
+		return models.ReasonTokenLimitExceeded
+	case errors.Is(err, ErrMerchantCategoryNotAllowed):
+		return models.ReasonMerchantCategoryNotAllowed
+	case errors.Is(err, repository.ErrNotFound):
+		return models.ReasonAccountNotFound
+	case errors.Is(err, ErrRetriesExhausted):
+		return models.ReasonConcurrencyConflict
+	case errors.Is(err, ErrAccountLimitExceeded):
+		return models.ReasonAccountLimitExceeded
+	case errors.Is(err, ErrTransfersFrozen):
+		return models.ReasonTransfersFrozen
+	case errors.Is(err, ErrAmountOutOfBounds):
+		return models.ReasonLimitExceeded
+	case repository.IsRegionFenced(err):
+		return models.ReasonRegionPassive
+	}
+	return ""
+}
+
+// adjustmentReason validates the reason code of a balance adjustment, defaulting
+// to fallback when none is given.
+func adjustmentReason(code, fallback models.ReasonCode) (models.ReasonCode, error) {
+	if code == "" {
+		return fallback, nil
+	}
+	info, ok := code.Info()
+	if !ok || info.Category != models.ReasonCategoryAdjustment {
+		return "", fmt.Errorf("%w %q for an adjustment", ErrInvalidReasonCode, code)
+	}
+	return code, nil
+}
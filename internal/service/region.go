@@ -0,0 +1,54 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/nehciyy/intrapay/internal/models"
+)
+
+var (
+	// ErrInvalidRegion is returned for a promotion to a malformed region name.
+	ErrInvalidRegion = errors.New("invalid region")
+	// ErrStaleEpoch is returned for a promotion based on a fencing token that
+	// another promotion has since moved.
+	ErrStaleEpoch = errors.New("stale region epoch")
+)
+
+var errRegionsDisabled = errors.New("region fencing is not enabled")
+
+// RegionStatus returns the region fence and whether this server's region is the
+// active one.
+func (s *DefaultService) RegionStatus() (*models.RegionStatus, error) {
+	if s.regionRepo == nil {
+		return nil, errRegionsDisabled
+	}
+	fence, err := s.regionRepo.GetRegionFence()
+	if err != nil {
+		return nil, err
+	}
+	return &models.RegionStatus{Region: s.region, Active: fence.ActiveRegion == s.region, RegionFence: *fence}, nil
+}
+
+// PromoteRegion makes region the active region, provided the fencing token is
+// still epoch. It returns once the writes in flight have finished; from then
+// on writes from any other region are rejected by the database, so a demoted
+// region cannot fork the ledger even before its servers notice.
+func (s *DefaultService) PromoteRegion(region string, epoch int64) (*models.RegionStatus, error) {
+	if s.regionRepo == nil {
+		return nil, errRegionsDisabled
+	}
+	if !models.ValidRegion(region) {
+		return nil, fmt.Errorf("%w %q", ErrInvalidRegion, region)
+	}
+	promoted, err := s.regionRepo.PromoteRegion(region, epoch)
+	if err != nil {
+		return nil, err
+	}
+	if !promoted {
+		return nil, fmt.Errorf("%w: the fence is no longer at epoch %d", ErrStaleEpoch, epoch)
+	}
+	log.Printf("region %s promoted to active at epoch %d", region, epoch+1)
+	return s.RegionStatus()
+}
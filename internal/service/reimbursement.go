@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository"
+)
+
+var (
+	// ErrInvalidReimbursement is returned for a reimbursement with an amount that
+	// is not positive, no description, a receipt date that is not YYYY-MM-DD, or
+	// claimed for the reimbursement account itself.
+	ErrInvalidReimbursement = errors.New("invalid reimbursement")
+	// ErrMissingDecider is returned for a reimbursement decision that does not
+	// name who made it.
+	ErrMissingDecider = errors.New("missing decided_by")
+
+	errReimbursementsDisabled = errors.New("reimbursements are not enabled")
+)
+
+// SubmitReimbursement records an expense tenant claims back for an employee
+// account and queues it for approval in the pending actions feed.
+func (s *DefaultService) SubmitReimbursement(ctx context.Context, tenant string, req models.ReimbursementRequest) (*models.Reimbursement, error) {
+	if s.reimbursementRepo == nil {
+		return nil, errReimbursementsDisabled
+	}
+	switch {
+	case req.Amount <= 0:
+		return nil, fmt.Errorf("%w: amount must be positive", ErrInvalidReimbursement)
+	case req.Description == "":
+		return nil, fmt.Errorf("%w: description is required", ErrInvalidReimbursement)
+	case req.AccountID == s.reimbursementID:
+		return nil, fmt.Errorf("%w: the reimbursement account cannot claim from itself", ErrInvalidReimbursement)
+	}
+	if req.Receipt.Date != "" {
+		if _, err := time.Parse(time.DateOnly, req.Receipt.Date); err != nil {
+			return nil, fmt.Errorf("%w: receipt date must be YYYY-MM-DD", ErrInvalidReimbursement)
+		}
+	}
+	exists, err := s.AccountExists(ctx, req.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("account with ID %d %w", req.AccountID, repository.ErrNotFound)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	r, err := s.reimbursementRepo.InsertReimbursementTx(tx, models.Reimbursement{
+		Tenant:      tenant,
+		AccountID:   req.AccountID,
+		Amount:      req.Amount,
+		Description: req.Description,
+		Receipt:     req.Receipt,
+	})
+	if err != nil {
+		return nil, err
+	}
+	event, err := s.recordReimbursementEvent(tx, r)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit failed: %w", err)
+	}
+
+	if s.pendingRepo != nil {
+		summary := fmt.Sprintf("%s for account %d: %s", r.Amount, r.AccountID, r.Description)
+		if _, err := s.pendingRepo.AddPendingAction(models.PendingActionReimbursement, strconv.FormatInt(r.ID, 10), summary); err != nil {
+			log.Printf("open pending action for reimbursement %d: %v", r.ID, err)
+		}
+	}
+	s.notifyWebhooks(tenant, event, r.AccountID)
+	return r, nil
+}
+
+// GetReimbursement returns a reimbursement of tenant.
+func (s *DefaultService) GetReimbursement(ctx context.Context, id int64, tenant string) (*models.Reimbursement, error) {
+	if s.reimbursementRepo == nil {
+		return nil, errReimbursementsDisabled
+	}
+	r, err := s.reimbursementRepo.GetReimbursement(id, tenant)
+	if err != nil {
+		return nil, err
+	}
+	if r.Tenant != tenant {
+		return nil, fmt.Errorf("reimbursement %d %w", id, repository.ErrNotFound)
+	}
+	if err := s.checkTestMode(ctx, r.AccountID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, fmt.Errorf("reimbursement %d %w", id, repository.ErrNotFound)
+		}
+		return nil, err
+	}
+	return r, nil
+}
+
+// ApproveReimbursement pays a pending reimbursement from the reimbursement
+// account and marks it approved in the same transaction. A payment that fails,
+// e.g. because the reimbursement account is short, leaves it pending.
+func (s *DefaultService) ApproveReimbursement(id int64, d models.ReimbursementDecision) (*models.Reimbursement, error) {
+	r, err := s.pendingReimbursement(id, d)
+	if err != nil {
+		return nil, err
+	}
+
+	var event models.Event
+	_, err = s.transfer(context.Background(), s.reimbursementID, r.AccountID, float64(r.Amount), models.TransactionTransfer, func(tx *sql.Tx, transactionID string) error {
+		var err error
+		if r, err = s.reimbursementRepo.DecideReimbursementTx(tx, id, models.ReimbursementApproved, d, transactionID); err != nil {
+			return err
+		}
+		event, err = s.recordReimbursementEvent(tx, r)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("paid reimbursement %d to account %d in transaction %s", id, r.AccountID, r.TransactionID)
+
+	s.reimbursementDecided(r, event)
+	return r, nil
+}
+
+// RejectReimbursement marks a pending reimbursement rejected without paying it.
+func (s *DefaultService) RejectReimbursement(id int64, d models.ReimbursementDecision) (*models.Reimbursement, error) {
+	if _, err := s.pendingReimbursement(id, d); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	r, err := s.reimbursementRepo.DecideReimbursementTx(tx, id, models.ReimbursementRejected, d, "")
+	if err != nil {
+		return nil, err
+	}
+	event, err := s.recordReimbursementEvent(tx, r)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit failed: %w", err)
+	}
+
+	s.reimbursementDecided(r, event)
+	return r, nil
+}
+
+// pendingReimbursement returns the reimbursement d decides, failing with
+// repository.ErrAlreadyResolved if it has been decided.
+func (s *DefaultService) pendingReimbursement(id int64, d models.ReimbursementDecision) (*models.Reimbursement, error) {
+	if s.reimbursementRepo == nil {
+		return nil, errReimbursementsDisabled
+	}
+	if d.DecidedBy == "" {
+		return nil, ErrMissingDecider
+	}
+	r, err := s.reimbursementRepo.GetReimbursement(id, "")
+	if err != nil {
+		return nil, err
+	}
+	if r.Status != models.ReimbursementPending {
+		return nil, fmt.Errorf("reimbursement %d %w", id, repository.ErrAlreadyResolved)
+	}
+	return r, nil
+}
+
+// reimbursementDecided takes a decided reimbursement off the pending actions
+// feed and tells its requester.
+func (s *DefaultService) reimbursementDecided(r *models.Reimbursement, event models.Event) {
+	if s.pendingRepo != nil {
+		err := s.pendingRepo.ResolvePendingAction(models.PendingActionReimbursement, strconv.FormatInt(r.ID, 10))
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			log.Printf("resolve pending action for reimbursement %d: %v", r.ID, err)
+		}
+	}
+	s.notifyWebhooks(r.Tenant, event, r.AccountID)
+}
+
+// recordReimbursementEvent writes the reimbursement.status_changed event of r to
+// the outbox in tx, and returns it for delivery to the requester's webhooks.
+func (s *DefaultService) recordReimbursementEvent(tx *sql.Tx, r *models.Reimbursement) (models.Event, error) {
+	payload, err := json.Marshal(models.ReimbursementStatusChanged{
+		ReimbursementID: r.ID,
+		AccountID:       r.AccountID,
+		Amount:          r.Amount,
+		Status:          r.Status,
+		TransactionID:   r.TransactionID,
+		Note:            r.DecisionNote,
+	})
+	if err != nil {
+		return models.Event{}, err
+	}
+	event := models.Event{
+		Type:          models.EventReimbursementStatusChanged,
+		SchemaVersion: models.ReimbursementStatusChangedVersion,
+		AggregateType: models.AggregateReimbursement,
+		AggregateID:   strconv.FormatInt(r.ID, 10),
+		Payload:       payload,
+		CreatedAt:     s.clock.Now(),
+	}
+	if s.outboxRepo != nil {
+		if event.ID, err = s.outboxRepo.InsertEventTx(tx, event); err != nil {
+			return models.Event{}, err
+		}
+	}
+	return event, nil
+}
@@ -0,0 +1,246 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/metrics"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository"
+)
+
+var (
+	// ErrInvalidFXRate is returned for an exchange rate that is not positive, of
+	// a date that is not YYYY-MM-DD, or of a day that has been revalued, and for
+	// live rates asked of a currency that is not a valid ISO 4217 code.
+	ErrInvalidFXRate = errors.New("invalid exchange rate")
+	// ErrInvalidRevaluation is returned for a revaluation of a date that is not
+	// YYYY-MM-DD, of a day that has not closed yet, or of a day before the latest
+	// revaluation.
+	ErrInvalidRevaluation = errors.New("invalid revaluation")
+	// ErrNoFXRate is returned for a revaluation of a day without an exchange rate.
+	ErrNoFXRate = errors.New("no exchange rate")
+	// ErrStaleFXRate is returned for a conversion at a live rate published longer
+	// ago than the staleness threshold.
+	ErrStaleFXRate = errors.New("stale exchange rate")
+	// ErrFXRateUnavailable is returned when the rate provider cannot be reached
+	// and has no cached rate to fall back on.
+	ErrFXRateUnavailable = errors.New("exchange rate unavailable")
+
+	errRevaluationDisabled = errors.New("revaluation is not enabled")
+	errFXRatesDisabled     = errors.New("live exchange rates are not enabled")
+)
+
+// FXRateSource serves live exchange rates.
+type FXRateSource interface {
+	// Quote returns the latest rate of currency in base, flagged stale if it is
+	// older than the staleness threshold.
+	Quote(ctx context.Context, currency, base string) (*models.FXQuote, error)
+}
+
+// MaxRevaluations bounds the revaluations listed at once.
+const MaxRevaluations = 366
+
+// RevaluationConfig configures the revaluation of balances to a base currency.
+type RevaluationConfig struct {
+	// BaseCurrency is the ISO 4217 code balances are marked to.
+	BaseCurrency string
+	// GainAccountID and LossAccountID are the accounts unrealized gains and
+	// losses are posted to.
+	GainAccountID int64
+	LossAccountID int64
+	// Location is the time zone whose midnight closes a day; nil means UTC.
+	Location *time.Location
+}
+
+// PutFXRate sets the rate of the ledger currency in the base currency at the
+// close of date, YYYY-MM-DD. Rates of days that have been revalued are final.
+func (s *DefaultService) PutFXRate(date string, req models.FXRateRequest) (*models.FXRate, error) {
+	if s.revaluationRepo == nil {
+		return nil, errRevaluationDisabled
+	}
+	day, err := time.Parse(time.DateOnly, date)
+	if err != nil {
+		return nil, fmt.Errorf("%w: date must be YYYY-MM-DD", ErrInvalidFXRate)
+	}
+	if req.Rate <= 0 {
+		return nil, fmt.Errorf("%w: rate must be positive", ErrInvalidFXRate)
+	}
+	latest, err := s.latestRevaluation()
+	if err != nil {
+		return nil, err
+	}
+	if latest != nil && latest.Date >= day.Format(time.DateOnly) {
+		return nil, fmt.Errorf("%w: %s has been revalued", ErrInvalidFXRate, date)
+	}
+	return s.revaluationRepo.PutFXRate(models.FXRate{
+		Currency:     models.CurrentCurrency().Code,
+		BaseCurrency: s.revaluation.BaseCurrency,
+		Date:         day.Format(time.DateOnly),
+		Rate:         req.Rate,
+	})
+}
+
+// Revalue marks the balance of every account at the close of date, YYYY-MM-DD,
+// to the base currency at the rate of that day. With live rates, the day that
+// has just closed is revalued at the latest live rate if none was set, as long
+// as it is not stale. Each account's unrealized gain
+// or loss is what its balance at the previous revaluation is worth at the new
+// rate less what it was worth then; rises in base value are posted to the loss
+// account and falls to the gain account, as balances are what the ledger owes
+// its account holders. Days are revalued in order, each at most once.
+func (s *DefaultService) Revalue(date string) (*models.Revaluation, error) {
+	if s.revaluationRepo == nil {
+		return nil, errRevaluationDisabled
+	}
+	day, err := time.ParseInLocation(time.DateOnly, date, s.revaluation.Location)
+	if err != nil {
+		return nil, fmt.Errorf("%w: date must be YYYY-MM-DD", ErrInvalidRevaluation)
+	}
+	date = day.Format(time.DateOnly)
+	cutoff := day.AddDate(0, 0, 1)
+	if cutoff.After(s.clock.Now()) {
+		return nil, fmt.Errorf("%w: %s has not closed yet", ErrInvalidRevaluation, date)
+	}
+	latest, err := s.latestRevaluation()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case latest != nil && latest.Date == date:
+		return nil, fmt.Errorf("%s %w", date, repository.ErrAlreadyRevalued)
+	case latest != nil && latest.Date > date:
+		return nil, fmt.Errorf("%w: %s is before the latest revaluation, of %s", ErrInvalidRevaluation, date, latest.Date)
+	}
+
+	currency := models.CurrentCurrency().Code
+	rate, err := s.revaluationRepo.GetFXRate(currency, s.revaluation.BaseCurrency, time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC))
+	if errors.Is(err, repository.ErrNotFound) && s.fxRates != nil && cutoff.Equal(s.startOfToday()) {
+		rate, err = s.closingRate(currency, date)
+	}
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("%w: %s/%s of %s", ErrNoFXRate, currency, s.revaluation.BaseCurrency, date)
+	}
+	if err != nil {
+		return nil, err
+	}
+	base, err := models.LookupCurrency(s.revaluation.BaseCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rv, err := s.revaluationRepo.RevalueTx(tx, models.Revaluation{
+		Date:          date,
+		Currency:      currency,
+		BaseCurrency:  base.Code,
+		Rate:          rate.Rate,
+		GainAccountID: s.revaluation.GainAccountID,
+		LossAccountID: s.revaluation.LossAccountID,
+		RoundingMode:  base.Policy().Mode,
+	}, latest, cutoff, base.MinorUnits)
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		metrics.Revaluations.WithLabelValues("failed").Inc()
+		return nil, err
+	}
+	metrics.Revaluations.WithLabelValues("revalued").Inc()
+	log.Printf("revalued %s balances of %s at %g %s: gain %s, loss %s", currency, date, rv.Rate, base.Code, base.Format(rv.Gain), base.Format(rv.Loss))
+	return rv, nil
+}
+
+// FXRates returns the live rates of the ledger currency in each of currencies,
+// or in the base currency if none are given. Stale rates are returned flagged
+// as such.
+func (s *DefaultService) FXRates(ctx context.Context, currencies []string) ([]models.FXQuote, error) {
+	if s.fxRates == nil {
+		return nil, errFXRatesDisabled
+	}
+	if len(currencies) == 0 && s.revaluation.BaseCurrency != "" {
+		currencies = []string{s.revaluation.BaseCurrency}
+	}
+	if len(currencies) == 0 {
+		return nil, fmt.Errorf("%w: no currencies to quote in", ErrInvalidFXRate)
+	}
+	currency := models.CurrentCurrency().Code
+	quotes := make([]models.FXQuote, len(currencies))
+	for i, code := range currencies {
+		base, err := models.LookupCurrency(code)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidFXRate, err)
+		}
+		if base.Code == currency {
+			return nil, fmt.Errorf("%w: accounts are held in %s", ErrInvalidFXRate, currency)
+		}
+		quote, err := s.fxRates.Quote(ctx, currency, base.Code)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrFXRateUnavailable, err)
+		}
+		quotes[i] = *quote
+	}
+	return quotes, nil
+}
+
+// GetRevaluation returns a revaluation with its totals by account type.
+func (s *DefaultService) GetRevaluation(ctx context.Context, id int64) (*models.Revaluation, error) {
+	if s.revaluationRepo == nil {
+		return nil, errRevaluationDisabled
+	}
+	return s.revaluationRepo.GetRevaluation(ctx, id, s.suspenseID, s.reimbursementID)
+}
+
+// ListRevaluations returns the limit latest revaluations, latest first.
+func (s *DefaultService) ListRevaluations(ctx context.Context, limit int) ([]models.Revaluation, error) {
+	if s.revaluationRepo == nil {
+		return nil, errRevaluationDisabled
+	}
+	if limit < 1 || limit > MaxRevaluations {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidRevaluation, MaxRevaluations)
+	}
+	return s.revaluationRepo.ListRevaluations(ctx, models.CurrentCurrency().Code, s.revaluation.BaseCurrency, limit)
+}
+
+// latestRevaluation returns the revaluation of the latest day, or nil if no
+// day has been revalued.
+func (s *DefaultService) latestRevaluation() (*models.Revaluation, error) {
+	latest, err := s.revaluationRepo.LatestRevaluation(models.CurrentCurrency().Code, s.revaluation.BaseCurrency)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil
+	}
+	return latest, err
+}
+
+// closingRate records the live rate as the rate of date, the day that has just
+// closed, unless it is stale.
+func (s *DefaultService) closingRate(currency, date string) (*models.FXRate, error) {
+	quote, err := s.fxRates.Quote(context.Background(), currency, s.revaluation.BaseCurrency)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrFXRateUnavailable, err)
+	}
+	if quote.Stale {
+		return nil, fmt.Errorf("%w: the latest %s/%s rate is of %s", ErrStaleFXRate, currency, quote.BaseCurrency, quote.AsOf.UTC().Format(time.RFC3339))
+	}
+	return s.revaluationRepo.PutFXRate(models.FXRate{
+		Currency:     currency,
+		BaseCurrency: quote.BaseCurrency,
+		Date:         date,
+		Rate:         quote.Rate,
+	})
+}
+
+// startOfToday returns the midnight that closed the last day in the
+// revaluation time zone.
+func (s *DefaultService) startOfToday() time.Time {
+	y, m, d := s.clock.Now().In(s.revaluation.Location).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, s.revaluation.Location)
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository"
+	"github.com/nehciyy/intrapay/internal/sandbox"
+)
+
+var errSandboxDisabled = errors.New("sandbox purges are not enabled")
+
+// PurgeTestData deletes the test-mode accounts and transactions along with the
+// rows that refer to them, and reports how many of each were deleted. Live data
+// is left as is.
+func (s *DefaultService) PurgeTestData() (*models.SandboxPurge, error) {
+	if s.sandboxRepo == nil {
+		return nil, errSandboxDisabled
+	}
+	return s.sandboxRepo.PurgeTestData()
+}
+
+// getAccount reads the account accountID, as accountRepo.GetAccount does, as
+// the request of ctx may see it: a test-mode request, one whose ctx is marked
+// with sandbox.WithTestMode, does not find live accounts. Live requests find
+// both.
+func (s *DefaultService) getAccount(ctx context.Context, accountID int64, includeDeleted bool) (*models.Account, error) {
+	account, err := s.accountRepo.GetAccount(ctx, accountID, includeDeleted)
+	if err == nil && account.Livemode && !sandbox.Livemode(ctx) {
+		return nil, fmt.Errorf("account with ID %d %w", accountID, repository.ErrNotFound)
+	}
+	return account, err
+}
+
+// checkTestMode refuses a test-mode request for the live account accountID,
+// open or closed, as if the account did not exist. Every operation naming an
+// account, a resource of one, or an account to move money from checks it, so
+// test-mode callers reach test-mode accounts alone whichever way they call. An
+// account that does not exist is left to the operation.
+func (s *DefaultService) checkTestMode(ctx context.Context, accountID int64) error {
+	if sandbox.Livemode(ctx) {
+		return nil
+	}
+	account, err := s.accountRepo.GetAccount(ctx, accountID, true)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if account.Livemode {
+		return fmt.Errorf("account with ID %d %w", accountID, repository.ErrNotFound)
+	}
+	return nil
+}
+
+// checkTestModeTransaction refuses a test-mode request for the transaction t
+// unless it is between test-mode accounts.
+func checkTestModeTransaction(ctx context.Context, t *models.Transaction) error {
+	if t.Livemode && !sandbox.Livemode(ctx) {
+		return fmt.Errorf("transaction %s %w", t.ID, repository.ErrNotFound)
+	}
+	return nil
+}
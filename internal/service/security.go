@@ -0,0 +1,37 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/nehciyy/intrapay/internal/models"
+)
+
+var errSecurityEventsDisabled = errors.New("the security event log is not enabled")
+
+// ListSecurityEvents returns up to limit security events after afterID in id
+// order, for a SIEM to catch up on the events its webhook missed. Like
+// ListEvents, events still within the settle window are not returned yet.
+func (s *DefaultService) ListSecurityEvents(afterID int64, limit int) (*models.SecurityEventPage, error) {
+	if s.securityRepo == nil {
+		return nil, errSecurityEventsDisabled
+	}
+	if afterID < 0 {
+		return nil, fmt.Errorf("%w %d", ErrInvalidOffset, afterID)
+	}
+	events, err := s.securityRepo.ListSecurityEvents(afterID, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	page := &models.SecurityEventPage{Events: events, NextAfterID: afterID}
+	if len(events) > limit {
+		page.Events, page.HasMore = events[:limit], true
+	}
+	if page.Events == nil {
+		page.Events = []models.SecurityEvent{}
+	}
+	if n := len(page.Events); n > 0 {
+		page.NextAfterID = page.Events[n-1].ID
+	}
+	return page, nil
+}
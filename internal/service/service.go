@@ -1,44 +1,570 @@
 package service
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	"log"
+	"math"
+	"strconv"
 	"time"
 
+	"github.com/nehciyy/intrapay/internal/clock"
+	"github.com/nehciyy/intrapay/internal/db"
+	"github.com/nehciyy/intrapay/internal/idgen"
+	"github.com/nehciyy/intrapay/internal/invariant"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/money"
 	"github.com/nehciyy/intrapay/internal/repository"
+	"github.com/nehciyy/intrapay/internal/sandbox"
+	"github.com/nehciyy/intrapay/internal/workerpool"
 )
 
 type DefaultService struct {
-	accountRepo     repository.AccountRepository
-	transactionRepo repository.TransactionRepository
-	db              *sql.DB
+	accountRepo       repository.AccountRepository
+	transactionRepo   repository.TransactionRepository
+	db                *sql.DB
+	checker           *invariant.Checker
+	clock             clock.Clock
+	accountIDs        idgen.Generator
+	usageRepo         repository.UsageRepository
+	quotaRepo         repository.QuotaRepository
+	pendingRepo       repository.PendingActionRepository
+	suspenseRepo      repository.SuspenseRepository
+	suspenseID        int64
+	ingestRepo        repository.IngestRepository
+	attemptRepo       repository.TransactionAttemptRepository
+	outboxRepo        repository.OutboxRepository
+	webhookRepo       repository.WebhookRepository
+	webhookClient     WebhookClient
+	webhookWorkers    *workerpool.Pool
+	notificationRepo  repository.NotificationRepository
+	accountLimit      int64
+	region            string
+	regionRepo        repository.RegionRepository
+	topUpRepo         repository.TopUpRepository
+	reimbursementID   int64
+	reimbursementRepo repository.ReimbursementRepository
+	tokenRepo         repository.SpendingTokenRepository
+	cashbackRepo      repository.CashbackRepository
+	intentRepo        repository.TransferIntentRepository
+	forwardRepo       repository.ForwardedTransferRepository
+	treasuryRepo      repository.TreasuryRepository
+	revaluationRepo   repository.RevaluationRepository
+	tagRepo           repository.TransactionTagRepository
+	sandboxRepo       repository.SandboxRepository
+	timelineRepo      repository.TimelineRepository
+	impersonationRepo repository.ImpersonationRepository
+	securityRepo      repository.SecurityEventRepository
+	advisoryRepo      repository.IndexAdvisoryRepository
+	expectedIndexes   map[string]string
+	freezeRepo        repository.TransferFreezeRepository
+	freezes           *freezeCache
+	boundsRepo        repository.AmountBoundsRepository
+	bounds            *boundsCache
+	revaluation       RevaluationConfig
+	fxRates           FXRateSource
+	hints             db.Policy
+	coalescer         *coalescer
+
+	conditionalDebit bool
+}
+
+// Option configures a DefaultService.
+type Option func(*DefaultService)
+
+// WithInvariantChecker verifies balance conservation on a sample of transfers.
+func WithInvariantChecker(c *invariant.Checker) Option {
+	return func(s *DefaultService) { s.checker = c }
+}
+
+// WithConditionalDebit debits the source account with a single conditional UPDATE
+// instead of a locking read followed by an update, shortening the time the source
+// row lock is held.
+func WithConditionalDebit() Option {
+	return func(s *DefaultService) { s.conditionalDebit = true }
+}
+
+// WithCoalescing coalesces the transfers to the hot accounts of cfg, posting
+// those that arrive within its window together.
+func WithCoalescing(cfg CoalescingConfig) Option {
+	return func(s *DefaultService) {
+		if len(cfg.Accounts) > 0 {
+			s.coalescer = newCoalescer(cfg, s.postCoalesced)
+		}
+	}
+}
+
+// WithClock replaces the system clock, e.g. with a clock.Fake in tests.
+func WithClock(c clock.Clock) Option {
+	return func(s *DefaultService) { s.clock = c }
+}
+
+// WithAccountIDGenerator enables server-generated account IDs. g must produce
+// decimal int64 IDs (see idgen.Numeric).
+func WithAccountIDGenerator(g idgen.Generator) Option {
+	return func(s *DefaultService) { s.accountIDs = g }
+}
+
+// WithUsageRepository enables usage reports from the metered API usage.
+func WithUsageRepository(r repository.UsageRepository) Option {
+	return func(s *DefaultService) { s.usageRepo = r }
+}
+
+// WithQuotaRepository enables managing per-key and per-tenant usage quotas.
+func WithQuotaRepository(r repository.QuotaRepository) Option {
+	return func(s *DefaultService) { s.quotaRepo = r }
+}
+
+// WithPendingActionRepository enables the feed of actions awaiting a human decision.
+func WithPendingActionRepository(r repository.PendingActionRepository) Option {
+	return func(s *DefaultService) { s.pendingRepo = r }
+}
+
+// WithSuspenseAccount parks inbound payments whose destination cannot be resolved
+// on accountID, which must exist, recording them in r until they are reposted.
+func WithSuspenseAccount(accountID int64, r repository.SuspenseRepository) Option {
+	return func(s *DefaultService) {
+		s.suspenseID = accountID
+		s.suspenseRepo = r
+	}
+}
+
+// WithReimbursements pays approved expense reimbursements from accountID, which
+// must exist, recording them in r.
+func WithReimbursements(accountID int64, r repository.ReimbursementRepository) Option {
+	return func(s *DefaultService) {
+		s.reimbursementID = accountID
+		s.reimbursementRepo = r
+	}
+}
+
+// WithTransactionAttemptRepository records rejected transfers for failure analytics.
+func WithTransactionAttemptRepository(r repository.TransactionAttemptRepository) Option {
+	return func(s *DefaultService) { s.attemptRepo = r }
+}
+
+// WithOutboxRepository writes a transfer.completed event to the outbox in the
+// transaction of every transfer, for the outbox relay to publish.
+func WithOutboxRepository(r repository.OutboxRepository) Option {
+	return func(s *DefaultService) { s.outboxRepo = r }
+}
+
+// WithRegion runs the service as part of region in an active-passive deployment,
+// reporting and moving the region fence held in r.
+func WithRegion(region string, r repository.RegionRepository) Option {
+	return func(s *DefaultService) {
+		s.region = region
+		s.regionRepo = r
+	}
+}
+
+// WithWebhooks lets tenants register webhooks, verifying each URL with client
+// before storing it in r.
+func WithWebhooks(r repository.WebhookRepository, client WebhookClient) Option {
+	return func(s *DefaultService) { s.webhookRepo, s.webhookClient = r, client }
+}
+
+// WithWebhookWorkers delivers webhook events on the workers of p rather than
+// in the request that raised them.
+func WithWebhookWorkers(p *workerpool.Pool) Option {
+	return func(s *DefaultService) { s.webhookWorkers = p }
+}
+
+// WithNotificationDigests lets the owners of accounts have the notifications
+// of their account webhooks sent as a daily digest, with the preferences and
+// digests sent kept in r. It takes effect with WithWebhooks.
+func WithNotificationDigests(r repository.NotificationRepository) Option {
+	return func(s *DefaultService) { s.notificationRepo = r }
+}
+
+// WithTopUps refills accounts that a transfer leaves below the threshold of
+// their top-up rule, stored in r.
+func WithTopUps(r repository.TopUpRepository) Option {
+	return func(s *DefaultService) { s.topUpRepo = r }
+}
+
+// WithSpendingTokens lets accounts issue spending tokens, stored in r, that
+// debit them within the token's limit, expiry and merchant categories.
+func WithSpendingTokens(r repository.SpendingTokenRepository) Option {
+	return func(s *DefaultService) { s.tokenRepo = r }
+}
+
+// WithCashback credits accounts that make qualifying transfers under the
+// cashback campaigns stored in r.
+func WithCashback(r repository.CashbackRepository) Option {
+	return func(s *DefaultService) { s.cashbackRepo = r }
+}
+
+// WithTransferIntents records the top-ups and cashback a transfer sets off in
+// r, in the transaction of the transfer, so that those a crash interrupts are
+// resumed by ResumeTransferIntents rather than lost, and made at most once.
+func WithTransferIntents(r repository.TransferIntentRepository) Option {
+	return func(s *DefaultService) { s.intentRepo = r }
+}
+
+// WithForwardedTransfers records the outcomes of the transfers replayed from
+// the store-and-forward queue in r, that of a completed transfer in its
+// transaction, so that each is made at most once however often it is replayed.
+func WithForwardedTransfers(r repository.ForwardedTransferRepository) Option {
+	return func(s *DefaultService) { s.forwardRepo = r }
 }
 
-func NewService(db *sql.DB, accountRepo repository.AccountRepository, transactionRepo repository.TransactionRepository) Service {
-	return &DefaultService{
+// WithTreasury serves treasury reports from the aggregate queries of r.
+func WithTreasury(r repository.TreasuryRepository) Option {
+	return func(s *DefaultService) { s.treasuryRepo = r }
+}
+
+// WithSandbox enables purging the test-mode data stored in r.
+func WithSandbox(r repository.SandboxRepository) Option {
+	return func(s *DefaultService) { s.sandboxRepo = r }
+}
+
+// WithTimeline serves account timelines from r.
+func WithTimeline(r repository.TimelineRepository) Option {
+	return func(s *DefaultService) { s.timelineRepo = r }
+}
+
+// WithTransferFreezes enables the break-glass freezes of outgoing transfers
+// stored in r.
+func WithTransferFreezes(r repository.TransferFreezeRepository) Option {
+	return func(s *DefaultService) { s.freezeRepo, s.freezes = r, &freezeCache{} }
+}
+
+// WithAmountBounds enables the bounds on the amount of a single transfer stored
+// in r.
+func WithAmountBounds(r repository.AmountBoundsRepository) Option {
+	return func(s *DefaultService) { s.boundsRepo, s.bounds = r, &boundsCache{} }
+}
+
+// WithIngestRepository enables posting transfers in bulk through r.
+func WithIngestRepository(r repository.IngestRepository) Option {
+	return func(s *DefaultService) { s.ingestRepo = r }
+}
+
+// WithImpersonationAudit enables impersonation, auditing every impersonated
+// request in r.
+func WithImpersonationAudit(r repository.ImpersonationRepository) Option {
+	return func(s *DefaultService) { s.impersonationRepo = r }
+}
+
+// WithSecurityEvents enables listing the security events stored in r.
+func WithSecurityEvents(r repository.SecurityEventRepository) Option {
+	return func(s *DefaultService) { s.securityRepo = r }
+}
+
+// WithIndexAdvisory enables the index advisory, comparing the indexes of the
+// database r reads with expected, the table of each index by name.
+func WithIndexAdvisory(r repository.IndexAdvisoryRepository, expected map[string]string) Option {
+	return func(s *DefaultService) {
+		s.advisoryRepo = r
+		s.expectedIndexes = expected
+	}
+}
+
+// WithRevaluation revalues balances to cfg.BaseCurrency with the rates and
+// revaluations stored in r.
+func WithRevaluation(cfg RevaluationConfig, r repository.RevaluationRepository) Option {
+	return func(s *DefaultService) {
+		if cfg.Location == nil {
+			cfg.Location = time.UTC
+		}
+		s.revaluation = cfg
+		s.revaluationRepo = r
+	}
+}
+
+// WithFXRates serves live exchange rates from src, and revalues the day that
+// has just closed at them when it has no rate.
+func WithFXRates(src FXRateSource) Option {
+	return func(s *DefaultService) { s.fxRates = src }
+}
+
+// WithTransactionTags lets transactions be marked with tags, stored in r, when
+// they are made or afterwards.
+func WithTransactionTags(r repository.TransactionTagRepository) Option {
+	return func(s *DefaultService) { s.tagRepo = r }
+}
+
+// WithHintPolicy sets the statement timeout of transfers, which run as
+// db.Critical operations.
+func WithHintPolicy(p db.Policy) Option {
+	return func(s *DefaultService) { s.hints = p }
+}
+
+// WithAccountLimit caps how many open accounts each tenant may have at n, unless
+// the tenant has an account limit of its own. Zero means unlimited.
+func WithAccountLimit(n int64) Option {
+	return func(s *DefaultService) { s.accountLimit = n }
+}
+
+func NewService(db *sql.DB, accountRepo repository.AccountRepository, transactionRepo repository.TransactionRepository, opts ...Option) Service {
+	s := &DefaultService{
 		db:              db,
 		accountRepo:     accountRepo,
 		transactionRepo: transactionRepo,
+		clock:           clock.System,
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
 }
 
 const maxRetries = 3
 
-func (s *DefaultService) CreateAccount(accountID int64, initialBalance float64) error {
-	return s.accountRepo.CreateAccount(accountID, initialBalance)
+// retryBackoff is the pause before retrying a transfer after a serialization failure.
+const retryBackoff = 100 * time.Millisecond
+
+var (
+	// ErrInsufficientBalance is returned when the source account cannot cover the transfer.
+	ErrInsufficientBalance = errors.New("insufficient balance")
+	// ErrDestinationNotFound is returned when the destination account does not exist.
+	ErrDestinationNotFound = errors.New("not found")
+	// ErrLivemodeMismatch is returned for a transfer between a live and a
+	// test-mode account. Neither sees the other, so it is answered like
+	// ErrDestinationNotFound, but the destination does exist.
+	ErrLivemodeMismatch = errors.New("live and test-mode accounts cannot transfer to each other")
+	// ErrRetriesExhausted is returned when every commit attempt hit a serialization failure.
+	ErrRetriesExhausted = errors.New("transaction failed after max retries")
+	// ErrInvalidAccountFilter is returned for an account listing whose minimum
+	// balance is above its maximum.
+	ErrInvalidAccountFilter = errors.New("invalid account filter")
+	// ErrInvalidLookup is returned for a bulk lookup with no IDs or too many.
+	ErrInvalidLookup = errors.New("invalid lookup")
+)
+
+// MaxLookupIDs is the most transactions one LookupTransactions call resolves.
+const MaxLookupIDs = 1000
+
+// CreateAccount opens an account with an initial balance for tenant, owned by
+// the API key owner, and returns it, unless the tenant is at its account limit.
+// An account opened without an API key, owner empty, has no owner. Accounts
+// opened by test-mode requests are test-mode accounts.
+func (s *DefaultService) CreateAccount(ctx context.Context, accountID int64, initialBalance float64, tenant, owner string) (*models.Account, error) {
+	if err := s.checkAccountLimit(tenant); err != nil {
+		return nil, err
+	}
+	return withAvailableBalance(s.accountRepo.CreateAccount(accountID, initialBalance, tenant, owner, sandbox.Livemode(ctx)))
+}
+
+// NewAccountID generates an ID for an account whose creator did not choose one.
+func (s *DefaultService) NewAccountID() (int64, error) {
+	if s.accountIDs == nil {
+		return 0, errors.New("server-generated account IDs are not enabled")
+	}
+	id, err := s.accountIDs.NewID()
+	if err != nil {
+		return 0, fmt.Errorf("generate account ID: %w", err)
+	}
+	accountID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("generated account ID %q is not an integer", id)
+	}
+	return accountID, nil
+}
+
+// GetAccount returns an active account. A test-mode request does not find live
+// accounts.
+func (s *DefaultService) GetAccount(ctx context.Context, accountID int64) (*models.Account, error) {
+	return withAvailableBalance(s.getAccount(ctx, accountID, false))
+}
+
+// AccountExists reports whether an active (not soft-deleted) account exists
+// without reading its balance.
+func (s *DefaultService) AccountExists(ctx context.Context, accountID int64) (bool, error) {
+	if !sandbox.Livemode(ctx) {
+		// A test-mode request does not find live accounts, so it reads the
+		// account's mode with it.
+		_, err := s.getAccount(ctx, accountID, false)
+		if errors.Is(err, repository.ErrNotFound) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+	return s.accountRepo.AccountExists(ctx, accountID)
+}
+
+// ListAccounts returns a page of up to limit open accounts matching filter, in
+// the order of their IDs, starting after cursor. Test-mode requests list the
+// test-mode accounts, live ones the live accounts.
+func (s *DefaultService) ListAccounts(ctx context.Context, filter models.AccountFilter, cursor string, limit int) (*models.AccountPage, error) {
+	afterID, err := decodeAccountCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	if filter.MinBalance != nil && filter.MaxBalance != nil && *filter.MinBalance > *filter.MaxBalance {
+		return nil, fmt.Errorf("%w: min_balance %s is above max_balance %s", ErrInvalidAccountFilter, *filter.MinBalance, *filter.MaxBalance)
+	}
+	filter.TestMode = !sandbox.Livemode(ctx)
+	accounts, err := s.accountRepo.ListAccounts(ctx, filter, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	page := &models.AccountPage{Accounts: accounts, HasMore: len(accounts) == limit}
+	if page.Accounts == nil {
+		page.Accounts = []models.Account{}
+	}
+	for i := range page.Accounts {
+		page.Accounts[i].AvailableBalance = models.Amount(availableBalance(float64(page.Accounts[i].Balance)))
+	}
+	if n := len(page.Accounts); n > 0 {
+		page.NextCursor = encodeAccountCursor(page.Accounts[n-1].AccountID)
+	}
+	return page, nil
+}
+
+// DeleteAccount soft deletes an account; it can be brought back with RestoreAccount.
+func (s *DefaultService) DeleteAccount(ctx context.Context, accountID int64) error {
+	if err := s.checkTestMode(ctx, accountID); err != nil {
+		return err
+	}
+	return s.accountRepo.DeleteAccount(accountID)
 }
 
-func (s *DefaultService) GetAccount(accountID int64) (float64, error) {
-	return s.accountRepo.GetAccountBalance(accountID)
+// RestoreAccount undoes a soft delete and returns the restored account.
+func (s *DefaultService) RestoreAccount(accountID int64) (*models.Account, error) {
+	if err := s.accountRepo.RestoreAccount(accountID); err != nil {
+		return nil, err
+	}
+	return withAvailableBalance(s.accountRepo.GetAccount(context.Background(), accountID, false))
 }
 
-func (s *DefaultService) CreateTransaction(sourceID int64, destID int64, amount float64) (string, error) {
+// GetAccountDetails returns the account row, optionally including soft-deleted accounts.
+func (s *DefaultService) GetAccountDetails(accountID int64, includeDeleted bool) (*models.Account, error) {
+	return withAvailableBalance(s.accountRepo.GetAccount(context.Background(), accountID, includeDeleted))
+}
+
+// GetTransaction returns a transaction by its public ID (transaction_ref) or its
+// serial key. A test-mode request does not find live transactions.
+func (s *DefaultService) GetTransaction(ctx context.Context, id string) (*models.Transaction, error) {
+	t, err := s.transactionRepo.GetTransaction(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkTestModeTransaction(ctx, t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// LookupTransactions resolves up to MaxLookupIDs transactions, each by its public
+// ID or serial key, in one query. Repeated IDs are answered once. Test-mode
+// requests do not find live transactions.
+func (s *DefaultService) LookupTransactions(ctx context.Context, ids []string) (*models.TransactionLookup, error) {
+	seen := make(map[string]bool, len(ids))
+	var unique []string
+	for _, id := range ids {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	switch {
+	case len(unique) == 0:
+		return nil, fmt.Errorf("%w: no transaction IDs", ErrInvalidLookup)
+	case len(unique) > MaxLookupIDs:
+		return nil, fmt.Errorf("%w: at most %d transaction IDs per lookup", ErrInvalidLookup, MaxLookupIDs)
+	}
+
+	found, err := s.transactionRepo.GetTransactions(ctx, unique)
+	if err != nil {
+		return nil, err
+	}
+	live := sandbox.Livemode(ctx)
+	lookup := &models.TransactionLookup{Transactions: []models.Transaction{}, NotFound: []string{}}
+	for _, id := range unique {
+		if t, ok := found[id]; ok && (live || !t.Livemode) {
+			lookup.Transactions = append(lookup.Transactions, t)
+		} else {
+			lookup.NotFound = append(lookup.NotFound, id)
+		}
+	}
+	return lookup, nil
+}
+
+// CreateTransaction transfers amount from sourceID to destID, marking the
+// transaction with tags, if any, as part of the transfer. Once it has
+// committed, the source account is credited with the cashback the transfer
+// earns; with transfer intents, the cashback is recorded with the transfer.
+func (s *DefaultService) CreateTransaction(ctx context.Context, sourceID int64, destID int64, amount float64, tags []string) (string, error) {
+	return s.createTransaction(ctx, sourceID, destID, amount, tags, nil)
+}
+
+// createTransaction is CreateTransaction running record, if set, in the
+// database transaction of the transfer, which it rolls back by failing. A
+// transfer with record is never coalesced.
+func (s *DefaultService) createTransaction(ctx context.Context, sourceID int64, destID int64, amount float64, tags []string, record func(tx *sql.Tx, transactionID string) error) (string, error) {
+	if err := s.checkTestMode(ctx, sourceID); err != nil {
+		return "", err
+	}
+	if len(tags) > 0 {
+		if s.tagRepo == nil {
+			return "", errTransactionTagsDisabled
+		}
+		var err error
+		if tags, err = normalizeTags(tags); err != nil {
+			return "", err
+		}
+	}
+	var transactionID string
+	var cashback *models.TransferIntent
+	var err error
+	if len(tags) == 0 && record == nil && s.coalescer.coalesces(sourceID, destID, amount) {
+		// A coalesced transfer is posted without transfer, so it is checked here.
+		if err = s.checkOutgoing(sourceID, amount, models.TransactionTransfer); err == nil {
+			transactionID, cashback, err = s.coalescer.submit(sourceID, destID, amount)
+		}
+	} else {
+		tag := s.tagTransfer(tags)
+		transactionID, err = s.transfer(ctx, sourceID, destID, amount, models.TransactionTransfer, func(tx *sql.Tx, transactionID string) error {
+			if record != nil {
+				if err := record(tx, transactionID); err != nil {
+					return err
+				}
+			}
+			if tag != nil {
+				if err := tag(tx, transactionID); err != nil {
+					return err
+				}
+			}
+			var err error
+			cashback, err = s.recordCashbackIntentTx(tx, transactionID, sourceID, amount)
+			return err
+		})
+	}
+	if err != nil {
+		return "", err
+	}
+	s.payCashback(transactionID, sourceID, amount, cashback)
+	return transactionID, nil
+}
+
+// transfer moves amount from sourceID to destID, logged as a transaction of
+// kind, and returns the transaction ID. It is refused by checkOutgoing first.
+// beforeCommit, if set, runs in the same database transaction once the
+// transfer is logged; an error from it rolls the transfer back. Transfers are
+// critical operations: they run with the hints of ctx and db.Critical, under
+// the critical statement timeout. Once a
+// transfer has committed, its source account is topped up if its top-up rule
+// calls for it; with transfer intents, the top-up is recorded with the
+// transfer.
+func (s *DefaultService) transfer(ctx context.Context, sourceID int64, destID int64, amount float64, kind models.TransactionKind, beforeCommit func(tx *sql.Tx, transactionID string) error) (string, error) {
+	return s.transferRounded(ctx, sourceID, destID, amount, kind, nil, beforeCommit)
+}
+
+// transferRounded is transfer of an amount the ledger computed, recording
+// rounding, if set, on the transaction.
+func (s *DefaultService) transferRounded(ctx context.Context, sourceID int64, destID int64, amount float64, kind models.TransactionKind, rounding *money.Rounding, beforeCommit func(tx *sql.Tx, transactionID string) error) (string, error) {
+	if err := s.checkOutgoing(sourceID, amount, kind); err != nil {
+		return "", err
+	}
 	var transactionID string
+	ctx = db.WithHints(ctx, db.Critical)
 
 	for attempt := 1; attempt <= maxRetries; attempt++ {
-		tx, err := s.db.Begin()
+		tx, err := s.hints.BeginTx(ctx, s.db, nil)
 		if err != nil {
 			return "", fmt.Errorf("failed to begin transaction: %w", err)
 		}
@@ -55,14 +581,29 @@ func (s *DefaultService) CreateTransaction(sourceID int64, destID int64, amount
 			rolledBack = true
 		}
 
-		sourceBalance, err := s.transactionRepo.GetAccountBalanceTx(tx, sourceID)
-		if err != nil {
-			rollback(fmt.Sprintf("error retrieving source account: %v", err))
-			return "", err
-		}
-		if sourceBalance < amount {
-			rollback(fmt.Sprintf("insufficient balance in account %d", sourceID))
-			return "", fmt.Errorf("insufficient balance in account %d", sourceID)
+		var sourceBalance float64
+		if s.conditionalDebit {
+			sourceBalance, err = s.transactionRepo.DebitBalanceTx(tx, sourceID, amount)
+			if errors.Is(err, repository.ErrInsufficientFunds) {
+				rollback(fmt.Sprintf("insufficient balance in account %d", sourceID))
+				return "", fmt.Errorf("%w in account %d", ErrInsufficientBalance, sourceID)
+			}
+			if err != nil {
+				rollback(fmt.Sprintf("error debiting source account: %v", err))
+				return "", err
+			}
+			// Keep the pre-debit balance for the invariant check.
+			sourceBalance += amount
+		} else {
+			sourceBalance, err = s.transactionRepo.GetAccountBalanceTx(tx, sourceID)
+			if err != nil {
+				rollback(fmt.Sprintf("error retrieving source account: %v", err))
+				return "", err
+			}
+			if availableBalance(sourceBalance) < amount {
+				rollback(fmt.Sprintf("insufficient balance in account %d", sourceID))
+				return "", fmt.Errorf("%w in account %d", ErrInsufficientBalance, sourceID)
+			}
 		}
 
 		destExists, err := s.transactionRepo.AccountExistsTx(tx, destID)
@@ -72,37 +613,191 @@ func (s *DefaultService) CreateTransaction(sourceID int64, destID int64, amount
 		}
 		if !destExists {
 			rollback(fmt.Sprintf("destination account %d not found", destID))
-			return "", fmt.Errorf("destination account %d not found", destID)
+			return "", fmt.Errorf("destination account %d %w", destID, ErrDestinationNotFound)
 		}
 
-		if err := s.transactionRepo.UpdateBalanceTx(tx, sourceID, -amount); err != nil {
-			rollback("error updating source balance: " + err.Error())
-			return "", err
+		// Sampled transfers read the destination balance up front so conservation
+		// can be verified before commit.
+		check := s.checker.Sample()
+		var destBalance float64
+		if check {
+			if destBalance, err = s.transactionRepo.GetAccountBalanceTx(tx, destID); err != nil {
+				rollback("error retrieving destination account: " + err.Error())
+				return "", err
+			}
+		}
+
+		if !s.conditionalDebit {
+			if err := s.transactionRepo.UpdateBalanceTx(tx, sourceID, -amount); err != nil {
+				rollback("error updating source balance: " + err.Error())
+				return "", err
+			}
 		}
 		if err := s.transactionRepo.UpdateBalanceTx(tx, destID, amount); err != nil {
 			rollback("error updating destination balance: " + err.Error())
 			return "", err
 		}
 
-		transactionID, err = s.transactionRepo.InsertTransactionLogTx(tx, sourceID, destID, amount)
+		if check {
+			if err := s.checkTransferTx(tx, sourceID, destID, amount, sourceBalance, destBalance); err != nil {
+				rollback(err.Error())
+				return "", err
+			}
+		}
+
+		transactionID, err = s.logTransfer(tx, sourceID, destID, amount, kind, rounding)
 		if err != nil {
 			rollback("error inserting transaction record: " + err.Error())
+			if repository.IsLivemodeMismatch(err) {
+				return "", fmt.Errorf("destination account %d: %w", destID, ErrLivemodeMismatch)
+			}
 			return "", err
 		}
+		if s.outboxRepo != nil {
+			if err := s.recordTransferEvent(tx, transactionID, sourceID, destID, amount, kind); err != nil {
+				rollback("error writing outbox event: " + err.Error())
+				return "", err
+			}
+		}
+		var topUp *models.TransferIntent
+		if kind == models.TransactionTransfer {
+			if topUp, err = s.recordTopUpIntentTx(tx, transactionID, sourceID); err != nil {
+				rollback("error recording top-up intent: " + err.Error())
+				return "", err
+			}
+		}
+		if beforeCommit != nil {
+			if err := beforeCommit(tx, transactionID); err != nil {
+				rollback(err.Error())
+				return "", err
+			}
+		}
 
 		err = tx.Commit()
 		if err != nil {
 			if repository.IsSerializationFailure(err) {
 				log.Printf("serialization failure, retrying attempt %d...", attempt)
-				time.Sleep(100 * time.Millisecond)
+				s.clock.Sleep(retryBackoff)
 				continue
 			}
 			rollback(fmt.Sprintf("commit failed: %v", err))
 			return "", fmt.Errorf("commit failed: %v", err)
 		}
 
+		if kind == models.TransactionTransfer {
+			s.topUp(sourceID, topUp)
+		}
 		return transactionID, nil
 	}
 
-	return "", errors.New("transaction failed after max retries")
-}
\ No newline at end of file
+	return "", ErrRetriesExhausted
+}
+
+// checkOutgoing refuses money of kind leaving sourceID while a freeze is in
+// force for it or, for a transfer, if its amount is out of bounds. The top-ups
+// and cashback the ledger posts are frozen too, but are not bounded. Every
+// path that moves money out of an account checks it: transfer does, and the
+// paths posting transfers without it check them in bulk.
+func (s *DefaultService) checkOutgoing(sourceID int64, amount float64, kind models.TransactionKind) error {
+	if err := s.checkTransferFreeze(sourceID); err != nil {
+		return err
+	}
+	if kind != models.TransactionTransfer {
+		return nil
+	}
+	return s.checkAmountBounds(sourceID, amount)
+}
+
+// logTransfer records a transfer in the transaction log. Only the batch insert
+// records a kind and a rounding, so other transactions are logged as a batch of
+// one.
+func (s *DefaultService) logTransfer(tx *sql.Tx, sourceID, destID int64, amount float64, kind models.TransactionKind, rounding *money.Rounding) (string, error) {
+	if kind == models.TransactionTransfer && rounding == nil {
+		return s.transactionRepo.InsertTransactionLogTx(tx, sourceID, destID, amount)
+	}
+	ids, err := s.transactionRepo.InsertTransactionLogsTx(tx, []repository.TransactionLog{
+		{SourceID: sourceID, DestID: destID, Amount: amount, Kind: kind, Rounding: rounding},
+	})
+	if err != nil {
+		return "", err
+	}
+	return ids[0], nil
+}
+
+// driftTolerance absorbs float rounding on NUMERIC(20, 5) values when comparing balances.
+const driftTolerance = 1e-6
+
+// RecomputeBalance rebuilds an account balance from its opening balance, transaction
+// log and adjustments, and reports the drift from the stored balance. With apply set,
+// a correcting adjustment for the drift is posted so the log reconciles to the stored
+// balance again. code defaults to reconciliation_correction.
+func (s *DefaultService) RecomputeBalance(accountID int64, apply bool, code models.ReasonCode, reason string) (*models.BalanceRecompute, error) {
+	code, err := adjustmentReason(code, models.ReasonReconciliationCorrection)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Locks the account row so no transfer moves the balance while it is recomputed.
+	stored, err := s.transactionRepo.GetAccountBalanceTx(tx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	computed, err := s.transactionRepo.ComputeBalanceTx(tx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	delta := stored - computed
+	result := &models.BalanceRecompute{
+		AccountID:       accountID,
+		StoredBalance:   models.Amount(stored),
+		ComputedBalance: models.Amount(computed),
+		Delta:           models.Amount(delta),
+	}
+	if !apply || math.Abs(delta) <= driftTolerance {
+		return result, nil
+	}
+
+	if reason == "" {
+		reason = "recompute correction"
+	}
+	result.AdjustmentID, err = s.transactionRepo.InsertAdjustmentTx(tx, accountID, delta, code, reason)
+	if err != nil {
+		return nil, err
+	}
+	result.ReasonCode = code
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit failed: %w", err)
+	}
+	log.Printf("posted adjustment %s of %.5f to account %d: %s (%s)", result.AdjustmentID, delta, accountID, reason, code)
+	return result, nil
+}
+
+// checkTransferTx re-reads both balances inside tx and verifies the transfer
+// conserved their sum. A violation aborts the transfer rather than committing a
+// corrupt ledger.
+func (s *DefaultService) checkTransferTx(tx *sql.Tx, sourceID, destID int64, amount, preSource, preDest float64) error {
+	postSource, err := s.transactionRepo.GetAccountBalanceTx(tx, sourceID)
+	if err != nil {
+		return err
+	}
+	postDest, err := s.transactionRepo.GetAccountBalanceTx(tx, destID)
+	if err != nil {
+		return err
+	}
+	return s.checker.CheckTransfer(invariant.Transfer{
+		SourceID:   sourceID,
+		DestID:     destID,
+		Amount:     amount,
+		PreSource:  preSource,
+		PreDest:    preDest,
+		PostSource: postSource,
+		PostDest:   postDest,
+	})
+}
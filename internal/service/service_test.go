@@ -1,35 +1,81 @@
 package service_test
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	"github.com/nehciyy/intrapay/internal/clock"
+	dbhints "github.com/nehciyy/intrapay/internal/db"
+	"github.com/nehciyy/intrapay/internal/idgen"
+	"github.com/nehciyy/intrapay/internal/invariant"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/money"
+	"github.com/nehciyy/intrapay/internal/repository"
+	"github.com/nehciyy/intrapay/internal/sandbox"
 	"github.com/nehciyy/intrapay/internal/service"
+	"github.com/nehciyy/intrapay/internal/workerpool"
 )
 type MockAccountRepository struct {
 	mock.Mock
 }
 
-func (m *MockAccountRepository) CreateAccount(accountID int64, initialBalance float64) error {
-	args := m.Called(accountID, initialBalance)
-	return args.Error(0)
+func (m *MockAccountRepository) CreateAccount(accountID int64, initialBalance float64, tenant, owner string, livemode bool) (*models.Account, error) {
+	args := m.Called(accountID, initialBalance, tenant, owner, livemode)
+	account, _ := args.Get(0).(*models.Account)
+	return account, args.Error(1)
 }
 
-func (m *MockAccountRepository) GetAccountBalance(accountID int64) (float64, error) {
+func (m *MockAccountRepository) AccountExists(_ context.Context, accountID int64) (bool, error) {
 	args := m.Called(accountID)
-	return args.Get(0).(float64), args.Error(1)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockAccountRepository) GetAccount(_ context.Context, accountID int64, includeDeleted bool) (*models.Account, error) {
+	args := m.Called(accountID, includeDeleted)
+	account, _ := args.Get(0).(*models.Account)
+	return account, args.Error(1)
+}
+
+func (m *MockAccountRepository) ListAccounts(_ context.Context, filter models.AccountFilter, afterID int64, limit int) ([]models.Account, error) {
+	args := m.Called(filter, afterID, limit)
+	accounts, _ := args.Get(0).([]models.Account)
+	return accounts, args.Error(1)
 }
 
-func (m *MockAccountRepository) AccountExists(accountID int64) (bool, error) {
+func (m *MockAccountRepository) DeleteAccount(accountID int64) error {
 	args := m.Called(accountID)
-	return args.Bool(0), args.Error(1)
+	return args.Error(0)
+}
+
+func (m *MockAccountRepository) RestoreAccount(accountID int64) error {
+	args := m.Called(accountID)
+	return args.Error(0)
+}
+
+func (m *MockAccountRepository) SetDisplayName(accountID int64, name string) error {
+	args := m.Called(accountID, name)
+	return args.Error(0)
+}
+
+func (m *MockAccountRepository) CountAccounts(tenant string) (int64, error) {
+	args := m.Called(tenant)
+	return args.Get(0).(int64), args.Error(1)
 }
 
 type MockTransactionRepository struct {
@@ -56,6 +102,64 @@ func (m *MockTransactionRepository) InsertTransactionLogTx(tx *sql.Tx, sourceID,
 	return args.String(0), args.Error(1)
 }
 
+func (m *MockTransactionRepository) DebitBalanceTx(tx *sql.Tx, accountID int64, amount float64) (float64, error) {
+	args := m.Called(tx, accountID, amount)
+	return args.Get(0).(float64), args.Error(1)
+}
+
+func (m *MockTransactionRepository) InsertTransactionLogsTx(tx *sql.Tx, logs []repository.TransactionLog) ([]string, error) {
+	args := m.Called(tx, logs)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockTransactionRepository) GetTransaction(_ context.Context, id string) (*models.Transaction, error) {
+	args := m.Called(id)
+	transaction, _ := args.Get(0).(*models.Transaction)
+	return transaction, args.Error(1)
+}
+
+func (m *MockTransactionRepository) GetTransactions(_ context.Context, ids []string) (map[string]models.Transaction, error) {
+	args := m.Called(ids)
+	transactions, _ := args.Get(0).(map[string]models.Transaction)
+	return transactions, args.Error(1)
+}
+
+func (m *MockTransactionRepository) ListTransactions(_ context.Context, filter models.TransactionFilter, after repository.ChangeCursor, limit int) ([]models.Transaction, repository.ChangeCursor, error) {
+	args := m.Called(filter, after, limit)
+	return args.Get(0).([]models.Transaction), args.Get(1).(repository.ChangeCursor), args.Error(2)
+}
+
+func (m *MockTransactionRepository) ListAccountTransactions(_ context.Context, accountID int64, after repository.ChangeCursor, limit int) ([]models.Transaction, repository.ChangeCursor, error) {
+	args := m.Called(accountID, after, limit)
+	return args.Get(0).([]models.Transaction), args.Get(1).(repository.ChangeCursor), args.Error(2)
+}
+
+func (m *MockTransactionRepository) SumAccountFlows(_ context.Context, accountID int64, since time.Time) (*models.AccountFlows, error) {
+	args := m.Called(accountID, since)
+	flows, _ := args.Get(0).(*models.AccountFlows)
+	return flows, args.Error(1)
+}
+
+func (m *MockTransactionRepository) CountTransactions(_ context.Context, filter models.TransactionFilter) (int64, error) {
+	args := m.Called(filter)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockTransactionRepository) ListTransactionChanges(_ context.Context, after repository.ChangeCursor, livemode bool, limit int) ([]models.Transaction, repository.ChangeCursor, error) {
+	args := m.Called(after, livemode, limit)
+	return args.Get(0).([]models.Transaction), args.Get(1).(repository.ChangeCursor), args.Error(2)
+}
+
+func (m *MockTransactionRepository) ComputeBalanceTx(tx *sql.Tx, accountID int64) (float64, error) {
+	args := m.Called(tx, accountID)
+	return args.Get(0).(float64), args.Error(1)
+}
+
+func (m *MockTransactionRepository) InsertAdjustmentTx(tx *sql.Tx, accountID int64, amount float64, code models.ReasonCode, reason string) (string, error) {
+	args := m.Called(tx, accountID, amount, code, reason)
+	return args.String(0), args.Error(1)
+}
+
 func newMockDB(t *testing.T) (*sql.DB, sqlmock.Sqlmock) {
 	db, mock, err := sqlmock.New()
 	assert.NoError(t, err, "failed to create mock db")
@@ -76,7 +180,7 @@ func TestCreateAccount(t *testing.T) {
 			accountID:      1,
 			initialBalance: 100.0,
 			mockExpect: func(mar *MockAccountRepository) {
-				mar.On("CreateAccount", int64(1), 100.0).Return(nil).Once()
+				mar.On("CreateAccount", int64(1), 100.0, "default", "key_1", true).Return(&models.Account{AccountID: 1, Balance: 100}, nil).Once()
 			},
 			expectedError: nil,
 		},
@@ -85,7 +189,7 @@ func TestCreateAccount(t *testing.T) {
 			accountID:      1,
 			initialBalance: 100.0,
 			mockExpect: func(mar *MockAccountRepository) {
-				mar.On("CreateAccount", int64(1), 100.0).Return(errors.New("duplicate key value violates unique constraint")).Once()
+				mar.On("CreateAccount", int64(1), 100.0, "default", "key_1", true).Return(nil, errors.New("duplicate key value violates unique constraint")).Once()
 			},
 			expectedError: errors.New("duplicate key value violates unique constraint"),
 		},
@@ -101,18 +205,43 @@ func TestCreateAccount(t *testing.T) {
 
 			tt.mockExpect(mockAccountRepo)
 
-			err := svc.CreateAccount(tt.accountID, tt.initialBalance)
+			account, err := svc.CreateAccount(context.Background(), tt.accountID, tt.initialBalance, "default", "key_1")
 			if tt.expectedError != nil {
 				assert.Error(t, err)
 				assert.Contains(t, err.Error(), tt.expectedError.Error())
 			} else {
 				assert.NoError(t, err)
+				assert.Equal(t, tt.accountID, account.AccountID)
 			}
 			mockAccountRepo.AssertExpectations(t)
 		})
 	}
 }
 
+func TestNewAccountID(t *testing.T) {
+	db, _ := newMockDB(t)
+
+	t.Run("Disabled", func(t *testing.T) {
+		svc := service.NewService(db, new(MockAccountRepository), new(MockTransactionRepository))
+		_, err := svc.NewAccountID()
+		assert.Error(t, err)
+	})
+
+	t.Run("Sequence", func(t *testing.T) {
+		next := idgen.Sequence(func() (int64, error) { return 42, nil })
+		svc := service.NewService(db, new(MockAccountRepository), new(MockTransactionRepository), service.WithAccountIDGenerator(next))
+		id, err := svc.NewAccountID()
+		require.NoError(t, err)
+		assert.Equal(t, int64(42), id)
+	})
+
+	t.Run("Non-numeric", func(t *testing.T) {
+		svc := service.NewService(db, new(MockAccountRepository), new(MockTransactionRepository), service.WithAccountIDGenerator(idgen.UUIDv7{}))
+		_, err := svc.NewAccountID()
+		assert.Error(t, err)
+	})
+}
+
 func TestGetAccount(t *testing.T) {
 	tests := []struct {
 		name            string
@@ -125,7 +254,7 @@ func TestGetAccount(t *testing.T) {
 			name:      "Success",
 			accountID: 1,
 			mockExpect: func(mar *MockAccountRepository) {
-				mar.On("GetAccountBalance", int64(1)).Return(250.5, nil).Once()
+				mar.On("GetAccount", int64(1), false).Return(&models.Account{AccountID: 1, Balance: 250.5}, nil).Once()
 			},
 			expectedBalance: 250.5,
 			expectedError:   nil,
@@ -134,7 +263,7 @@ func TestGetAccount(t *testing.T) {
 			name:      "Not Found",
 			accountID: 1,
 			mockExpect: func(mar *MockAccountRepository) {
-				mar.On("GetAccountBalance", int64(1)).Return(float64(0), fmt.Errorf("account with ID %d not found", 1)).Once()
+				mar.On("GetAccount", int64(1), false).Return(nil, fmt.Errorf("account with ID %d not found", 1)).Once()
 			},
 			expectedBalance: 0,
 			expectedError:   fmt.Errorf("account with ID %d not found", 1),
@@ -143,7 +272,7 @@ func TestGetAccount(t *testing.T) {
 			name:      "Database Error",
 			accountID: 1,
 			mockExpect: func(mar *MockAccountRepository) {
-				mar.On("GetAccountBalance", int64(1)).Return(float64(0), errors.New("db connection lost")).Once()
+				mar.On("GetAccount", int64(1), false).Return(nil, errors.New("db connection lost")).Once()
 			},
 			expectedBalance: 0,
 			expectedError:   errors.New("db connection lost"),
@@ -160,13 +289,14 @@ func TestGetAccount(t *testing.T) {
 
 			tt.mockExpect(mockAccountRepo)
 
-			balance, err := svc.GetAccount(tt.accountID)
+			account, err := svc.GetAccount(context.Background(), tt.accountID)
 			if tt.expectedError != nil {
 				assert.Error(t, err)
 				assert.Contains(t, err.Error(), tt.expectedError.Error())
 			} else {
 				assert.NoError(t, err)
-				assert.Equal(t, tt.expectedBalance, balance)
+				assert.Equal(t, models.Amount(tt.expectedBalance), account.Balance)
+				assert.Equal(t, account.Balance, account.AvailableBalance, "without holds the whole balance is available")
 			}
 			mockAccountRepo.AssertExpectations(t)
 		})
@@ -304,9 +434,9 @@ func TestCreateTransaction(t *testing.T) {
 			// Set testify/mock expectations for repository methods
 			tt.mockExpect(mockAccountRepo, mockTransactionRepo)
 
-			svc := service.NewService(db, mockAccountRepo, mockTransactionRepo)
+			svc := service.NewService(db, mockAccountRepo, mockTransactionRepo, service.WithClock(clock.NewFake(time.Now())))
 
-			id, err := svc.CreateTransaction(tt.sourceID, tt.destID, tt.amount)
+			id, err := svc.CreateTransaction(context.Background(), tt.sourceID, tt.destID, tt.amount, nil)
 			if tt.expectedError != nil {
 				require.Error(t, err)
 				require.ErrorContains(t, err, tt.expectedError.Error())
@@ -323,4 +453,4066 @@ func TestCreateTransaction(t *testing.T) {
 			mockTransactionRepo.AssertExpectations(t)
 		})
 	}
-}
\ No newline at end of file
+}
+func TestCreateTransaction_RetryBackoff(t *testing.T) {
+	db, mockDB := newMockDB(t)
+	mockAccountRepo := new(MockAccountRepository)
+	mockTransactionRepo := new(MockTransactionRepository)
+
+	mockTransactionRepo.On("GetAccountBalanceTx", mock.Anything, int64(1)).Return(200.0, nil)
+	mockTransactionRepo.On("AccountExistsTx", mock.Anything, int64(2)).Return(true, nil)
+	mockTransactionRepo.On("UpdateBalanceTx", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockTransactionRepo.On("InsertTransactionLogTx", mock.Anything, int64(1), int64(2), 10.0).Return("7", nil)
+	mockDB.ExpectBegin()
+	mockDB.ExpectCommit().WillReturnError(fmt.Errorf("pq: could not serialize access (SQLSTATE 40001)"))
+	mockDB.ExpectBegin()
+	mockDB.ExpectCommit()
+
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(start)
+	svc := service.NewService(db, mockAccountRepo, mockTransactionRepo, service.WithClock(clk))
+
+	id, err := svc.CreateTransaction(context.Background(), 1, 2, 10, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "7", id)
+	// One failed commit means one backoff, taken on the injected clock.
+	assert.Equal(t, 100*time.Millisecond, clk.Now().Sub(start))
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestCreateTransaction_CriticalTimeout(t *testing.T) {
+	db, mockDB := newMockDB(t)
+	mockTransactionRepo := new(MockTransactionRepository)
+
+	mockTransactionRepo.On("GetAccountBalanceTx", mock.Anything, int64(1)).Return(200.0, nil)
+	mockTransactionRepo.On("AccountExistsTx", mock.Anything, int64(2)).Return(true, nil)
+	mockTransactionRepo.On("UpdateBalanceTx", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockTransactionRepo.On("InsertTransactionLogTx", mock.Anything, int64(1), int64(2), 10.0).Return("7", nil)
+	mockDB.ExpectBegin()
+	mockDB.ExpectExec("set_config\\('statement_timeout'").WithArgs("2000").WillReturnResult(sqlmock.NewResult(0, 0))
+	mockDB.ExpectCommit()
+
+	svc := service.NewService(db, new(MockAccountRepository), mockTransactionRepo,
+		service.WithHintPolicy(dbhints.Policy{ReadOnlyTimeout: time.Second, CriticalTimeout: 2 * time.Second}))
+
+	id, err := svc.CreateTransaction(context.Background(), 1, 2, 10, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "7", id)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestCreateTransaction_CallerContext(t *testing.T) {
+	db, mockDB := newMockDB(t)
+	mockTransactionRepo := new(MockTransactionRepository)
+
+	svc := service.NewService(db, new(MockAccountRepository), mockTransactionRepo)
+
+	// The transfer runs on the caller's context, so a cancelled request
+	// never reaches the ledger.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := svc.CreateTransaction(ctx, 1, 2, 10, nil)
+	require.ErrorIs(t, err, context.Canceled)
+	mockTransactionRepo.AssertNotCalled(t, "UpdateBalanceTx", mock.Anything, mock.Anything, mock.Anything)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestCreateTransaction_InvariantCheck(t *testing.T) {
+	tests := []struct {
+		name          string
+		postDest      float64
+		sqlMockExpect func(sqlmock.Sqlmock)
+		expectedError error
+	}{
+		{
+			name:     "Conserved",
+			postDest: 150.0,
+			sqlMockExpect: func(mockDB sqlmock.Sqlmock) {
+				mockDB.ExpectBegin()
+				mockDB.ExpectCommit()
+			},
+		},
+		{
+			name:     "Violation Rolls Back",
+			postDest: 250.0,
+			sqlMockExpect: func(mockDB sqlmock.Sqlmock) {
+				mockDB.ExpectBegin()
+				mockDB.ExpectRollback()
+			},
+			expectedError: invariant.ErrViolation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mockDB := newMockDB(t)
+			mockAccountRepo := new(MockAccountRepository)
+			mockTransactionRepo := new(MockTransactionRepository)
+
+			tt.sqlMockExpect(mockDB)
+			mockTransactionRepo.On("GetAccountBalanceTx", mock.Anything, int64(1)).Return(200.0, nil).Once()
+			mockTransactionRepo.On("AccountExistsTx", mock.Anything, int64(2)).Return(true, nil).Once()
+			mockTransactionRepo.On("GetAccountBalanceTx", mock.Anything, int64(2)).Return(50.0, nil).Once()
+			mockTransactionRepo.On("UpdateBalanceTx", mock.Anything, int64(1), -100.0).Return(nil).Once()
+			mockTransactionRepo.On("UpdateBalanceTx", mock.Anything, int64(2), 100.0).Return(nil).Once()
+			mockTransactionRepo.On("GetAccountBalanceTx", mock.Anything, int64(1)).Return(100.0, nil).Once()
+			mockTransactionRepo.On("GetAccountBalanceTx", mock.Anything, int64(2)).Return(tt.postDest, nil).Once()
+			if tt.expectedError == nil {
+				mockTransactionRepo.On("InsertTransactionLogTx", mock.Anything, int64(1), int64(2), 100.0).Return("42", nil).Once()
+			}
+
+			svc := service.NewService(db, mockAccountRepo, mockTransactionRepo,
+				service.WithInvariantChecker(invariant.NewChecker(1, nil)))
+
+			id, err := svc.CreateTransaction(context.Background(), 1, 2, 100.0, nil)
+			if tt.expectedError != nil {
+				require.ErrorIs(t, err, tt.expectedError)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, "42", id)
+			}
+
+			assert.NoError(t, mockDB.ExpectationsWereMet(), "sqlmock expectations not met")
+			mockTransactionRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCreateTransaction_ConditionalDebit(t *testing.T) {
+	tests := []struct {
+		name          string
+		setupMocks    func(*MockTransactionRepository)
+		sqlMockExpect func(sqlmock.Sqlmock)
+		expectedID    string
+		expectedError error
+	}{
+		{
+			name: "Success",
+			setupMocks: func(m *MockTransactionRepository) {
+				m.On("DebitBalanceTx", mock.Anything, int64(1), 100.0).Return(100.0, nil)
+				m.On("AccountExistsTx", mock.Anything, int64(2)).Return(true, nil)
+				m.On("UpdateBalanceTx", mock.Anything, int64(2), 100.0).Return(nil)
+				m.On("InsertTransactionLogTx", mock.Anything, int64(1), int64(2), 100.0).Return("42", nil)
+			},
+			sqlMockExpect: func(mockDB sqlmock.Sqlmock) {
+				mockDB.ExpectBegin()
+				mockDB.ExpectCommit()
+			},
+			expectedID: "42",
+		},
+		{
+			name: "Insufficient Balance",
+			setupMocks: func(m *MockTransactionRepository) {
+				m.On("DebitBalanceTx", mock.Anything, int64(1), 100.0).Return(0.0, repository.ErrInsufficientFunds)
+			},
+			sqlMockExpect: func(mockDB sqlmock.Sqlmock) {
+				mockDB.ExpectBegin()
+				mockDB.ExpectRollback()
+			},
+			expectedError: service.ErrInsufficientBalance,
+		},
+		{
+			name: "Source Not Found",
+			setupMocks: func(m *MockTransactionRepository) {
+				m.On("DebitBalanceTx", mock.Anything, int64(1), 100.0).Return(0.0, fmt.Errorf("account with ID 1 %w", repository.ErrNotFound))
+			},
+			sqlMockExpect: func(mockDB sqlmock.Sqlmock) {
+				mockDB.ExpectBegin()
+				mockDB.ExpectRollback()
+			},
+			expectedError: repository.ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mockDB := newMockDB(t)
+			mockTransactionRepo := new(MockTransactionRepository)
+
+			tt.sqlMockExpect(mockDB)
+			tt.setupMocks(mockTransactionRepo)
+
+			svc := service.NewService(db, new(MockAccountRepository), mockTransactionRepo, service.WithConditionalDebit())
+
+			id, err := svc.CreateTransaction(context.Background(), 1, 2, 100.0, nil)
+			if tt.expectedError != nil {
+				require.ErrorIs(t, err, tt.expectedError)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tt.expectedID, id)
+			}
+
+			assert.NoError(t, mockDB.ExpectationsWereMet(), "sqlmock expectations not met")
+			mockTransactionRepo.AssertExpectations(t)
+			mockTransactionRepo.AssertNotCalled(t, "UpdateBalanceTx", mock.Anything, int64(1), mock.Anything)
+		})
+	}
+}
+
+func TestCreateTransaction_Coalescing(t *testing.T) {
+	type transfer struct {
+		sourceID int64
+		amount   float64
+	}
+	// The window outlasts the test, so each batch is posted when it is full.
+	cfg := service.CoalescingConfig{Accounts: []int64{9}, Window: time.Hour, MaxBatch: 3}
+	submit := func(svc service.Service, transfers []transfer) ([]string, []error) {
+		ids := make([]string, len(transfers))
+		errs := make([]error, len(transfers))
+		done := make(chan struct{})
+		for i, tr := range transfers {
+			go func() {
+				defer func() { done <- struct{}{} }()
+				ids[i], errs[i] = svc.CreateTransaction(context.Background(), tr.sourceID, 9, tr.amount, nil)
+			}()
+		}
+		for range transfers {
+			<-done
+		}
+		return ids, errs
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		db, mockDB := newMockDB(t)
+		transactionRepo := new(MockTransactionRepository)
+		svc := service.NewService(db, new(MockAccountRepository), transactionRepo, service.WithCoalescing(cfg))
+
+		mockDB.ExpectBegin()
+		transactionRepo.On("GetAccountBalanceTx", mock.Anything, int64(1)).Return(100.0, nil).Once()
+		transactionRepo.On("GetAccountBalanceTx", mock.Anything, int64(2)).Return(100.0, nil).Once()
+		transactionRepo.On("AccountExistsTx", mock.Anything, int64(9)).Return(true, nil).Once()
+		transactionRepo.On("UpdateBalanceTx", mock.Anything, int64(1), -30.0).Return(nil).Once()
+		transactionRepo.On("UpdateBalanceTx", mock.Anything, int64(2), -30.0).Return(nil).Once()
+		transactionRepo.On("UpdateBalanceTx", mock.Anything, int64(9), 60.0).Return(nil).Once()
+		transactionRepo.On("InsertTransactionLogsTx", mock.Anything, mock.MatchedBy(func(logs []repository.TransactionLog) bool {
+			return assert.ElementsMatch(t, []repository.TransactionLog{
+				{SourceID: 1, DestID: 9, Amount: 10, Kind: models.TransactionTransfer},
+				{SourceID: 1, DestID: 9, Amount: 20, Kind: models.TransactionTransfer},
+				{SourceID: 2, DestID: 9, Amount: 30, Kind: models.TransactionTransfer},
+			}, logs)
+		})).Return([]string{"11", "12", "13"}, nil).Once()
+		mockDB.ExpectCommit()
+
+		ids, errs := submit(svc, []transfer{{1, 10}, {1, 20}, {2, 30}})
+		assert.Equal(t, []error{nil, nil, nil}, errs)
+		assert.ElementsMatch(t, []string{"11", "12", "13"}, ids)
+		transactionRepo.AssertExpectations(t)
+		assert.NoError(t, mockDB.ExpectationsWereMet())
+	})
+
+	t.Run("Insufficient Balance", func(t *testing.T) {
+		db, mockDB := newMockDB(t)
+		transactionRepo := new(MockTransactionRepository)
+		svc := service.NewService(db, new(MockAccountRepository), transactionRepo, service.WithCoalescing(cfg))
+
+		// Account 1 covers only one of its two transfers; the other fails alone.
+		mockDB.ExpectBegin()
+		transactionRepo.On("GetAccountBalanceTx", mock.Anything, int64(1)).Return(15.0, nil).Once()
+		transactionRepo.On("GetAccountBalanceTx", mock.Anything, int64(2)).Return(100.0, nil).Once()
+		transactionRepo.On("AccountExistsTx", mock.Anything, int64(9)).Return(true, nil).Once()
+		transactionRepo.On("UpdateBalanceTx", mock.Anything, int64(1), -10.0).Return(nil).Once()
+		transactionRepo.On("UpdateBalanceTx", mock.Anything, int64(2), -30.0).Return(nil).Once()
+		transactionRepo.On("UpdateBalanceTx", mock.Anything, int64(9), 40.0).Return(nil).Once()
+		transactionRepo.On("InsertTransactionLogsTx", mock.Anything, mock.Anything).Return([]string{"11", "12"}, nil).Once()
+		mockDB.ExpectCommit()
+
+		ids, errs := submit(svc, []transfer{{1, 10}, {1, 10}, {2, 30}})
+		require.NoError(t, errs[2])
+		require.NotEqual(t, errs[0] == nil, errs[1] == nil, "exactly one transfer from account 1 should fail")
+		failed := slices.IndexFunc(errs, func(err error) bool { return err != nil })
+		assert.ErrorIs(t, errs[failed], service.ErrInsufficientBalance)
+		assert.Empty(t, ids[failed])
+		transactionRepo.AssertExpectations(t)
+		assert.NoError(t, mockDB.ExpectationsWereMet())
+	})
+
+	t.Run("Not Coalesced", func(t *testing.T) {
+		db, mockDB := newMockDB(t)
+		transactionRepo := new(MockTransactionRepository)
+		svc := service.NewService(db, new(MockAccountRepository), transactionRepo, service.WithCoalescing(service.CoalescingConfig{Accounts: []int64{9}, Window: time.Hour, MaxBatch: 3, MaxAmount: 50}))
+
+		// Above MaxAmount the transfer is posted on its own, without waiting.
+		mockDB.ExpectBegin()
+		transactionRepo.On("GetAccountBalanceTx", mock.Anything, int64(1)).Return(100.0, nil).Once()
+		transactionRepo.On("AccountExistsTx", mock.Anything, int64(9)).Return(true, nil).Once()
+		transactionRepo.On("UpdateBalanceTx", mock.Anything, int64(1), -60.0).Return(nil).Once()
+		transactionRepo.On("UpdateBalanceTx", mock.Anything, int64(9), 60.0).Return(nil).Once()
+		transactionRepo.On("InsertTransactionLogTx", mock.Anything, int64(1), int64(9), 60.0).Return("11", nil).Once()
+		mockDB.ExpectCommit()
+
+		id, err := svc.CreateTransaction(context.Background(), 1, 9, 60, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "11", id)
+		transactionRepo.AssertExpectations(t)
+		assert.NoError(t, mockDB.ExpectationsWereMet())
+	})
+}
+
+func TestRecomputeBalance(t *testing.T) {
+	tests := []struct {
+		name          string
+		apply         bool
+		stored        float64
+		computed      float64
+		mockExpect    func(*MockTransactionRepository)
+		sqlMockExpect func(sqlmock.Sqlmock)
+		expected      *models.BalanceRecompute
+	}{
+		{
+			name:     "No Drift",
+			apply:    true,
+			stored:   100.0,
+			computed: 100.0,
+			sqlMockExpect: func(mockDB sqlmock.Sqlmock) {
+				mockDB.ExpectBegin()
+				mockDB.ExpectRollback()
+			},
+			expected: &models.BalanceRecompute{AccountID: 1, StoredBalance: 100, ComputedBalance: 100},
+		},
+		{
+			name:     "Drift Dry Run",
+			stored:   110.0,
+			computed: 100.0,
+			sqlMockExpect: func(mockDB sqlmock.Sqlmock) {
+				mockDB.ExpectBegin()
+				mockDB.ExpectRollback()
+			},
+			expected: &models.BalanceRecompute{AccountID: 1, StoredBalance: 110, ComputedBalance: 100, Delta: 10},
+		},
+		{
+			name:     "Drift Applied",
+			apply:    true,
+			stored:   110.0,
+			computed: 100.0,
+			mockExpect: func(mtr *MockTransactionRepository) {
+				mtr.On("InsertAdjustmentTx", mock.Anything, int64(1), 10.0, models.ReasonReconciliationCorrection, "recompute correction").Return("5", nil).Once()
+			},
+			sqlMockExpect: func(mockDB sqlmock.Sqlmock) {
+				mockDB.ExpectBegin()
+				mockDB.ExpectCommit()
+			},
+			expected: &models.BalanceRecompute{AccountID: 1, StoredBalance: 110, ComputedBalance: 100, Delta: 10, AdjustmentID: "5", ReasonCode: models.ReasonReconciliationCorrection},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mockDB := newMockDB(t)
+			mockAccountRepo := new(MockAccountRepository)
+			mockTransactionRepo := new(MockTransactionRepository)
+
+			tt.sqlMockExpect(mockDB)
+			mockTransactionRepo.On("GetAccountBalanceTx", mock.Anything, int64(1)).Return(tt.stored, nil).Once()
+			mockTransactionRepo.On("ComputeBalanceTx", mock.Anything, int64(1)).Return(tt.computed, nil).Once()
+			if tt.mockExpect != nil {
+				tt.mockExpect(mockTransactionRepo)
+			}
+
+			svc := service.NewService(db, mockAccountRepo, mockTransactionRepo)
+
+			result, err := svc.RecomputeBalance(1, tt.apply, "", "")
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, result)
+
+			assert.NoError(t, mockDB.ExpectationsWereMet(), "sqlmock expectations not met")
+			mockTransactionRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRecomputeBalance_ReasonCode(t *testing.T) {
+	svc := service.NewService(nil, new(MockAccountRepository), new(MockTransactionRepository))
+
+	for _, code := range []models.ReasonCode{"typo_correction", models.ReasonInsufficientFunds} {
+		_, err := svc.RecomputeBalance(1, true, code, "")
+		assert.ErrorIs(t, err, service.ErrInvalidReasonCode, code)
+	}
+}
+
+func TestRejectionReason(t *testing.T) {
+	tests := []struct {
+		err  error
+		want models.ReasonCode
+	}{
+		{fmt.Errorf("%w in account %d", service.ErrInsufficientBalance, 1), models.ReasonInsufficientFunds},
+		{fmt.Errorf("destination account %d %w", 2, service.ErrDestinationNotFound), models.ReasonDestinationNotFound},
+		{fmt.Errorf("account with ID %d %w", 1, repository.ErrNotFound), models.ReasonAccountNotFound},
+		{service.ErrRetriesExhausted, models.ReasonConcurrencyConflict},
+		{fmt.Errorf("debit: %w", &pq.Error{Code: "25006", Message: "region eu-west-1 is passive, the active region is us-east-1"}), models.ReasonRegionPassive},
+		{errors.New("connection refused"), ""},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, service.RejectionReason(tt.err), tt.err.Error())
+	}
+}
+
+func TestRestoreAccount(t *testing.T) {
+	tests := []struct {
+		name          string
+		setupMocks    func(*MockAccountRepository)
+		expected      *models.Account
+		expectedError error
+	}{
+		{
+			name: "Restored",
+			setupMocks: func(m *MockAccountRepository) {
+				m.On("RestoreAccount", int64(1)).Return(nil)
+				m.On("GetAccount", int64(1), false).Return(&models.Account{AccountID: 1, Balance: 50}, nil)
+			},
+			expected: &models.Account{AccountID: 1, Balance: 50, AvailableBalance: 50},
+		},
+		{
+			name: "Not Deleted",
+			setupMocks: func(m *MockAccountRepository) {
+				m.On("RestoreAccount", int64(1)).Return(fmt.Errorf("deleted account with ID 1 %w", repository.ErrNotFound))
+			},
+			expectedError: repository.ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAccountRepo := new(MockAccountRepository)
+			tt.setupMocks(mockAccountRepo)
+			svc := service.NewService(nil, mockAccountRepo, new(MockTransactionRepository))
+
+			account, err := svc.RestoreAccount(1)
+			if tt.expectedError != nil {
+				require.ErrorIs(t, err, tt.expectedError)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tt.expected, account)
+			}
+			mockAccountRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSyncTransactions(t *testing.T) {
+	mockTransactionRepo := new(MockTransactionRepository)
+	svc := service.NewService(nil, new(MockAccountRepository), mockTransactionRepo)
+
+	position := repository.ChangeCursor{UpdatedAt: time.Date(2024, 5, 1, 12, 0, 0, 123456000, time.UTC), ID: 42}
+	mockTransactionRepo.On("ListTransactionChanges", repository.ChangeCursor{}, true, 2).
+		Return([]models.Transaction{{ID: "41"}, {ID: "42"}}, position, nil).Once()
+	mockTransactionRepo.On("ListTransactionChanges", position, true, 2).
+		Return([]models.Transaction(nil), position, nil).Once()
+
+	first, err := svc.SyncTransactions(context.Background(), "", 2)
+	require.NoError(t, err)
+	require.Len(t, first.Transactions, 2)
+	require.True(t, first.HasMore)
+	require.NotEmpty(t, first.NextCursor)
+
+	// The cursor round-trips to the same position, and stays put when nothing is new.
+	second, err := svc.SyncTransactions(context.Background(), first.NextCursor, 2)
+	require.NoError(t, err)
+	require.Empty(t, second.Transactions)
+	require.False(t, second.HasMore)
+	require.Equal(t, first.NextCursor, second.NextCursor)
+
+	_, err = svc.SyncTransactions(context.Background(), "not a cursor", 2)
+	require.ErrorIs(t, err, service.ErrInvalidCursor)
+
+	mockTransactionRepo.AssertExpectations(t)
+}
+
+func TestSetDisplayName(t *testing.T) {
+	mockAccountRepo := new(MockAccountRepository)
+	svc := service.NewService(nil, mockAccountRepo, new(MockTransactionRepository))
+
+	mockAccountRepo.On("SetDisplayName", int64(1), "Jane Doe").Return(nil).Once()
+	mockAccountRepo.On("GetAccount", int64(1), false).Return(&models.Account{AccountID: 1, DisplayName: "Jane Doe"}, nil).Once()
+
+	account, err := svc.SetDisplayName(context.Background(), 1, "  Jane Doe ")
+	require.NoError(t, err)
+	require.Equal(t, "Jane Doe", account.DisplayName)
+
+	for _, name := range []string{strings.Repeat("x", 65), "Jane\nDoe"} {
+		_, err = svc.SetDisplayName(context.Background(), 1, name)
+		require.ErrorIs(t, err, service.ErrInvalidDisplayName, name)
+	}
+
+	mockAccountRepo.AssertExpectations(t)
+}
+
+func TestAccountSummary(t *testing.T) {
+	mockAccountRepo := new(MockAccountRepository)
+	mockTransactionRepo := new(MockTransactionRepository)
+	svc := service.NewService(nil, mockAccountRepo, mockTransactionRepo,
+		service.WithClock(clock.NewFake(time.Date(2026, 3, 14, 9, 30, 0, 0, time.UTC))))
+
+	monthStart := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	flows := &models.AccountFlows{Since: monthStart, Incoming: 150, IncomingCount: 3, Outgoing: 40, OutgoingCount: 1}
+	mockAccountRepo.On("GetAccount", int64(1), false).Return(&models.Account{AccountID: 1, DisplayName: "Jane Doe", Balance: 110}, nil).Once()
+	mockTransactionRepo.On("SumAccountFlows", int64(1), monthStart).Return(flows, nil).Once()
+
+	summary, err := svc.AccountSummary(context.Background(), 1, time.UTC)
+	require.NoError(t, err)
+	require.Equal(t, &models.AccountSummary{
+		AccountID:        1,
+		DisplayName:      "Jane Doe",
+		Balance:          110,
+		AvailableBalance: 110,
+		Timezone:         "UTC",
+		MonthToDate:      *flows,
+	}, summary)
+
+	// Honolulu is UTC-10, so its month started at 10:00 UTC on the 1st.
+	honolulu, err := time.LoadLocation("Pacific/Honolulu")
+	require.NoError(t, err)
+	localStart := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	mockAccountRepo.On("GetAccount", int64(1), false).Return(&models.Account{AccountID: 1}, nil).Once()
+	mockTransactionRepo.On("SumAccountFlows", int64(1), localStart).Return(&models.AccountFlows{Since: localStart}, nil).Once()
+	summary, err = svc.AccountSummary(context.Background(), 1, honolulu)
+	require.NoError(t, err)
+	require.Equal(t, "Pacific/Honolulu", summary.Timezone)
+
+	mockAccountRepo.On("GetAccount", int64(2), false).Return(nil, fmt.Errorf("account with ID 2 %w", repository.ErrNotFound)).Once()
+	_, err = svc.AccountSummary(context.Background(), 2, time.UTC)
+	require.ErrorIs(t, err, repository.ErrNotFound)
+
+	mockAccountRepo.AssertExpectations(t)
+	mockTransactionRepo.AssertExpectations(t)
+}
+
+func TestListAccounts(t *testing.T) {
+	mockAccountRepo := new(MockAccountRepository)
+	svc := service.NewService(nil, mockAccountRepo, new(MockTransactionRepository))
+
+	min, max := models.Amount(100), models.Amount(500)
+	filter := models.AccountFilter{MinBalance: &min, MaxBalance: &max}
+	mockAccountRepo.On("ListAccounts", filter, int64(0), 2).
+		Return([]models.Account{{AccountID: 3, Balance: 150}, {AccountID: 7, Balance: 400}}, nil).Once()
+	page, err := svc.ListAccounts(context.Background(), filter, "", 2)
+	require.NoError(t, err)
+	require.True(t, page.HasMore)
+	require.Equal(t, models.Amount(400), page.Accounts[1].AvailableBalance)
+
+	// The cursor continues after the last account of the page.
+	mockAccountRepo.On("ListAccounts", filter, int64(7), 2).
+		Return([]models.Account{{AccountID: 9, Balance: 200}}, nil).Once()
+	page, err = svc.ListAccounts(context.Background(), filter, page.NextCursor, 2)
+	require.NoError(t, err)
+	require.False(t, page.HasMore)
+	require.Len(t, page.Accounts, 1)
+
+	// Test-mode requests list the test-mode accounts.
+	mockAccountRepo.On("ListAccounts", models.AccountFilter{TestMode: true}, int64(0), 2).
+		Return(nil, nil).Once()
+	page, err = svc.ListAccounts(sandbox.WithTestMode(context.Background()), models.AccountFilter{}, "", 2)
+	require.NoError(t, err)
+	require.Equal(t, []models.Account{}, page.Accounts)
+	require.Empty(t, page.NextCursor)
+
+	_, err = svc.ListAccounts(context.Background(), models.AccountFilter{MinBalance: &max, MaxBalance: &min}, "", 2)
+	require.ErrorIs(t, err, service.ErrInvalidAccountFilter)
+	_, err = svc.ListAccounts(context.Background(), models.AccountFilter{}, "not-a-cursor", 2)
+	require.ErrorIs(t, err, service.ErrInvalidCursor)
+
+	mockAccountRepo.AssertExpectations(t)
+}
+
+func TestListAccountTransactions(t *testing.T) {
+	statement := func() []models.Transaction {
+		return []models.Transaction{
+			{ID: "7", Counterparty: &models.Counterparty{AccountID: 2, DisplayName: "Jane van Doe"}},
+			{ID: "8", Counterparty: &models.Counterparty{AccountID: 3, DisplayName: "John Roe", Deleted: true}},
+			{ID: "9", Counterparty: &models.Counterparty{AccountID: 4}},
+		}
+	}
+	mockAccountRepo := new(MockAccountRepository)
+	mockTransactionRepo := new(MockTransactionRepository)
+	svc := service.NewService(nil, mockAccountRepo, mockTransactionRepo)
+
+	mockAccountRepo.On("GetAccount", int64(1), false).Return(&models.Account{AccountID: 1}, nil).Once()
+	mockAccountRepo.On("GetAccount", int64(1), true).Return(&models.Account{AccountID: 1}, nil).Once()
+	mockTransactionRepo.On("ListAccountTransactions", int64(1), repository.ChangeCursor{}, 10).
+		Return(statement(), repository.ChangeCursor{}, nil).Once()
+	mockTransactionRepo.On("ListAccountTransactions", int64(1), repository.ChangeCursor{}, 10).
+		Return(statement(), repository.ChangeCursor{}, nil).Once()
+
+	page, err := svc.ListAccountTransactions(context.Background(), 1, "", 10, false)
+	require.NoError(t, err)
+	require.Equal(t, []*models.Counterparty{
+		{AccountID: 2, DisplayName: "J*** v*** D***", Redacted: true},
+		{AccountID: 3, Redacted: true, Deleted: true},
+		{AccountID: 4},
+	}, counterparties(page.Transactions))
+
+	page, err = svc.ListAccountTransactions(context.Background(), 1, "", 10, true)
+	require.NoError(t, err)
+	require.Equal(t, counterparties(statement()), counterparties(page.Transactions))
+
+	mockAccountRepo.On("GetAccount", int64(2), false).Return(nil, fmt.Errorf("account with ID 2 %w", repository.ErrNotFound)).Once()
+	_, err = svc.ListAccountTransactions(context.Background(), 2, "", 10, false)
+	require.ErrorIs(t, err, repository.ErrNotFound)
+
+	mockAccountRepo.AssertExpectations(t)
+	mockTransactionRepo.AssertExpectations(t)
+}
+
+func counterparties(transactions []models.Transaction) []*models.Counterparty {
+	var c []*models.Counterparty
+	for _, t := range transactions {
+		c = append(c, t.Counterparty)
+	}
+	return c
+}
+
+func TestLookupTransactions(t *testing.T) {
+	mockTransactionRepo := new(MockTransactionRepository)
+	svc := service.NewService(nil, new(MockAccountRepository), mockTransactionRepo)
+
+	mockTransactionRepo.On("GetTransactions", []string{"b", "a", "42"}).
+		Return(map[string]models.Transaction{"a": {ID: "a"}, "b": {ID: "b"}}, nil).Once()
+
+	lookup, err := svc.LookupTransactions(context.Background(), []string{"b", "a", "42", "b", ""})
+	require.NoError(t, err)
+	require.Equal(t, []models.Transaction{{ID: "b"}, {ID: "a"}}, lookup.Transactions, "in request order, repeats answered once")
+	require.Equal(t, []string{"42"}, lookup.NotFound)
+
+	_, err = svc.LookupTransactions(context.Background(), nil)
+	require.ErrorIs(t, err, service.ErrInvalidLookup)
+
+	tooMany := make([]string, service.MaxLookupIDs+1)
+	for i := range tooMany {
+		tooMany[i] = strconv.Itoa(i)
+	}
+	_, err = svc.LookupTransactions(context.Background(), tooMany)
+	require.ErrorIs(t, err, service.ErrInvalidLookup)
+
+	mockTransactionRepo.AssertExpectations(t)
+}
+
+type MockUsageRepository struct {
+	mock.Mock
+}
+
+func (m *MockUsageRepository) AddUsage(u models.Usage) error {
+	return m.Called(u).Error(0)
+}
+
+func (m *MockUsageRepository) GetKeyUsage(period, apiKey string) (int64, float64, error) {
+	args := m.Called(period, apiKey)
+	return args.Get(0).(int64), args.Get(1).(float64), args.Error(2)
+}
+
+func (m *MockUsageRepository) GetTenantUsage(period, tenant string) (int64, float64, error) {
+	args := m.Called(period, tenant)
+	return args.Get(0).(int64), args.Get(1).(float64), args.Error(2)
+}
+
+func (m *MockUsageRepository) ListUsage(period string) ([]models.Usage, error) {
+	args := m.Called(period)
+	usage, _ := args.Get(0).([]models.Usage)
+	return usage, args.Error(1)
+}
+
+type MockQuotaRepository struct {
+	mock.Mock
+}
+
+func (m *MockQuotaRepository) SetQuota(q models.Quota) (*models.Quota, error) {
+	args := m.Called(q)
+	quota, _ := args.Get(0).(*models.Quota)
+	return quota, args.Error(1)
+}
+
+func (m *MockQuotaRepository) GetQuota(scope models.QuotaScope, subject string) (*models.Quota, error) {
+	args := m.Called(scope, subject)
+	quota, _ := args.Get(0).(*models.Quota)
+	return quota, args.Error(1)
+}
+
+func (m *MockQuotaRepository) ListQuotas() ([]models.Quota, error) {
+	args := m.Called()
+	quotas, _ := args.Get(0).([]models.Quota)
+	return quotas, args.Error(1)
+}
+
+func (m *MockQuotaRepository) DeleteQuota(scope models.QuotaScope, subject string) error {
+	return m.Called(scope, subject).Error(0)
+}
+
+func (m *MockQuotaRepository) SetAccountLimit(tenant string, maxAccounts int64) (*models.AccountLimit, error) {
+	args := m.Called(tenant, maxAccounts)
+	limit, _ := args.Get(0).(*models.AccountLimit)
+	return limit, args.Error(1)
+}
+
+func (m *MockQuotaRepository) GetAccountLimit(tenant string) (*models.AccountLimit, error) {
+	args := m.Called(tenant)
+	limit, _ := args.Get(0).(*models.AccountLimit)
+	return limit, args.Error(1)
+}
+
+func (m *MockQuotaRepository) DeleteAccountLimit(tenant string) error {
+	return m.Called(tenant).Error(0)
+}
+
+func TestQuotas(t *testing.T) {
+	usageRepo := new(MockUsageRepository)
+	quotaRepo := new(MockQuotaRepository)
+	svc := service.NewService(nil, new(MockAccountRepository), new(MockTransactionRepository),
+		service.WithUsageRepository(usageRepo),
+		service.WithQuotaRepository(quotaRepo),
+		service.WithClock(clock.NewFake(time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC))))
+
+	for _, q := range []models.Quota{
+		{Scope: "team", Subject: "payroll"},
+		{Scope: models.QuotaScopeTenant},
+		{Scope: models.QuotaScopeTenant, Subject: "payroll", MonthlyVolume: -1},
+	} {
+		_, err := svc.SetQuota(q)
+		assert.ErrorIs(t, err, service.ErrInvalidQuota, "%+v", q)
+	}
+
+	quota := models.Quota{Scope: models.QuotaScopeTenant, Subject: "payroll", MonthlyCalls: 1000}
+	quotaRepo.On("SetQuota", quota).Return(&quota, nil).Once()
+	_, err := svc.SetQuota(quota)
+	require.NoError(t, err)
+
+	quotaRepo.On("GetQuota", models.QuotaScopeTenant, "payroll").Return(&quota, nil).Once()
+	usageRepo.On("GetTenantUsage", "2026-03", "payroll").Return(int64(250), 1200.5, nil).Once()
+	status, err := svc.GetQuota(models.QuotaScopeTenant, "payroll")
+	require.NoError(t, err)
+	assert.Equal(t, &models.QuotaStatus{Quota: quota, Period: "2026-03", UsedCalls: 250, UsedVolume: 1200.5}, status)
+
+	quotaRepo.AssertExpectations(t)
+	usageRepo.AssertExpectations(t)
+}
+
+func TestAccountLimit(t *testing.T) {
+	accountRepo := new(MockAccountRepository)
+	quotaRepo := new(MockQuotaRepository)
+	svc := service.NewService(nil, accountRepo, new(MockTransactionRepository),
+		service.WithQuotaRepository(quotaRepo),
+		service.WithAccountLimit(2))
+
+	// Without an override the server-wide limit applies.
+	quotaRepo.On("GetAccountLimit", "payroll").Return(nil, fmt.Errorf("account limit of tenant payroll %w", repository.ErrNotFound))
+	accountRepo.On("CountAccounts", "payroll").Return(int64(1), nil).Once()
+	accountRepo.On("CreateAccount", int64(1), 100.0, "payroll", "", true).Return(&models.Account{AccountID: 1, Balance: 100}, nil).Once()
+	_, err := svc.CreateAccount(context.Background(), 1, 100, "payroll", "")
+	require.NoError(t, err)
+
+	accountRepo.On("CountAccounts", "payroll").Return(int64(2), nil).Once()
+	_, err = svc.CreateAccount(context.Background(), 2, 100, "payroll", "")
+	require.ErrorIs(t, err, service.ErrAccountLimitExceeded)
+	assert.Equal(t, models.ReasonAccountLimitExceeded, service.RejectionReason(err))
+
+	// An override replaces it, and zero lifts it.
+	quotaRepo.On("GetAccountLimit", "billing").Return(&models.AccountLimit{Tenant: "billing", MaxAccounts: 0}, nil)
+	accountRepo.On("CreateAccount", int64(3), 0.0, "billing", "", true).Return(&models.Account{AccountID: 3}, nil).Once()
+	_, err = svc.CreateAccount(context.Background(), 3, 0, "billing", "")
+	require.NoError(t, err)
+
+	accountRepo.On("CountAccounts", "payroll").Return(int64(2), nil).Once()
+	status, err := svc.GetAccountLimit("payroll")
+	require.NoError(t, err)
+	assert.Equal(t, &models.AccountLimitStatus{Tenant: "payroll", MaxAccounts: 2, OpenAccounts: 2}, status)
+
+	_, err = svc.SetAccountLimit("payroll", -1)
+	assert.ErrorIs(t, err, service.ErrInvalidAccountLimit)
+	_, err = svc.SetAccountLimit("", 10)
+	assert.ErrorIs(t, err, service.ErrInvalidAccountLimit)
+
+	accountRepo.AssertExpectations(t)
+	quotaRepo.AssertExpectations(t)
+}
+
+type MockPendingActionRepository struct {
+	mock.Mock
+}
+
+func (m *MockPendingActionRepository) AddPendingAction(kind models.PendingActionKind, reference, summary string) (*models.PendingAction, error) {
+	args := m.Called(kind, reference, summary)
+	action, _ := args.Get(0).(*models.PendingAction)
+	return action, args.Error(1)
+}
+
+func (m *MockPendingActionRepository) ResolvePendingAction(kind models.PendingActionKind, reference string) error {
+	return m.Called(kind, reference).Error(0)
+}
+
+func (m *MockPendingActionRepository) ExpirePendingActions(kind models.PendingActionKind, createdBefore time.Time) (int64, error) {
+	args := m.Called(kind, createdBefore)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockPendingActionRepository) ListPendingActions(filter models.PendingActionFilter, after repository.ChangeCursor, limit int) ([]models.PendingAction, repository.ChangeCursor, error) {
+	args := m.Called(filter, after, limit)
+	actions, _ := args.Get(0).([]models.PendingAction)
+	return actions, args.Get(1).(repository.ChangeCursor), args.Error(2)
+}
+
+func (m *MockPendingActionRepository) CountPendingActions(filter models.PendingActionFilter) (int64, error) {
+	args := m.Called(filter)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockPendingActionRepository) ClaimPendingAction(id int64, assignee string) (*models.PendingAction, error) {
+	args := m.Called(id, assignee)
+	action, _ := args.Get(0).(*models.PendingAction)
+	return action, args.Error(1)
+}
+
+func (m *MockPendingActionRepository) AssignPendingAction(id int64, assignee string) (*models.PendingAction, error) {
+	args := m.Called(id, assignee)
+	action, _ := args.Get(0).(*models.PendingAction)
+	return action, args.Error(1)
+}
+
+func TestPendingActions(t *testing.T) {
+	pendingRepo := new(MockPendingActionRepository)
+	svc := service.NewService(nil, new(MockAccountRepository), new(MockTransactionRepository),
+		service.WithPendingActionRepository(pendingRepo))
+
+	filter := models.PendingActionFilter{Kind: models.PendingActionDeadLetter}
+	next := repository.ChangeCursor{UpdatedAt: time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC), ID: 9}
+	pendingRepo.On("ListPendingActions", filter, repository.ChangeCursor{}, 1).
+		Return([]models.PendingAction{{ID: 9, Kind: models.PendingActionDeadLetter}}, next, nil).Once()
+	page, err := svc.ListPendingActions(filter, "", 1)
+	require.NoError(t, err)
+	assert.True(t, page.HasMore, "a full page may have more actions behind it")
+
+	pendingRepo.On("ListPendingActions", filter, next, 1).Return(nil, next, nil).Once()
+	page, err = svc.ListPendingActions(filter, page.NextCursor, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []models.PendingAction{}, page.Actions)
+	assert.False(t, page.HasMore)
+
+	_, err = svc.ListPendingActions(filter, "not-a-cursor", 1)
+	assert.ErrorIs(t, err, service.ErrInvalidCursor)
+
+	_, err = svc.ClaimPendingAction(9, "")
+	assert.ErrorIs(t, err, service.ErrMissingAssignee)
+
+	pendingRepo.AssertExpectations(t)
+}
+
+type MockSuspenseRepository struct {
+	mock.Mock
+}
+
+func (m *MockSuspenseRepository) InsertSuspenseItemTx(tx *sql.Tx, item models.SuspenseItem) (*models.SuspenseItem, error) {
+	args := m.Called(tx, item)
+	parked, _ := args.Get(0).(*models.SuspenseItem)
+	return parked, args.Error(1)
+}
+
+func (m *MockSuspenseRepository) GetSuspenseItem(id int64) (*models.SuspenseItem, error) {
+	args := m.Called(id)
+	item, _ := args.Get(0).(*models.SuspenseItem)
+	return item, args.Error(1)
+}
+
+func (m *MockSuspenseRepository) ListSuspenseItems(after repository.ChangeCursor, limit int) ([]models.SuspenseItem, repository.ChangeCursor, error) {
+	args := m.Called(after, limit)
+	items, _ := args.Get(0).([]models.SuspenseItem)
+	return items, args.Get(1).(repository.ChangeCursor), args.Error(2)
+}
+
+func (m *MockSuspenseRepository) ResolveSuspenseItemTx(tx *sql.Tx, id, accountID int64, transactionID string) (*models.SuspenseItem, error) {
+	args := m.Called(tx, id, accountID, transactionID)
+	item, _ := args.Get(0).(*models.SuspenseItem)
+	return item, args.Error(1)
+}
+
+func TestReceivePayment_Suspense(t *testing.T) {
+	db, mockDB, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	accountRepo := new(MockAccountRepository)
+	transactionRepo := new(MockTransactionRepository)
+	suspenseRepo := new(MockSuspenseRepository)
+	pendingRepo := new(MockPendingActionRepository)
+	svc := service.NewService(db, accountRepo, transactionRepo,
+		service.WithSuspenseAccount(99, suspenseRepo),
+		service.WithPendingActionRepository(pendingRepo))
+
+	// The transfer to the closed account fails and is retried to the suspense account.
+	mockDB.ExpectBegin()
+	mockDB.ExpectRollback()
+	mockDB.ExpectBegin()
+	mockDB.ExpectCommit()
+	transactionRepo.On("GetAccountBalanceTx", mock.Anything, int64(1)).Return(200.0, nil).Twice()
+	transactionRepo.On("AccountExistsTx", mock.Anything, int64(2)).Return(false, nil).Once()
+	deleted := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	accountRepo.On("GetAccount", int64(2), true).Return(&models.Account{AccountID: 2, DeletedAt: &deleted}, nil).Once()
+	transactionRepo.On("AccountExistsTx", mock.Anything, int64(99)).Return(true, nil).Once()
+	transactionRepo.On("UpdateBalanceTx", mock.Anything, int64(1), -50.0).Return(nil).Once()
+	transactionRepo.On("UpdateBalanceTx", mock.Anything, int64(99), 50.0).Return(nil).Once()
+	transactionRepo.On("InsertTransactionLogTx", mock.Anything, int64(1), int64(99), 50.0).Return("tx-1", nil).Once()
+	want := models.SuspenseItem{SourceAccountID: 1, IntendedAccountID: 2, Amount: 50, Reason: models.SuspenseAccountClosed, Reference: "INV-1", TransactionID: "tx-1"}
+	parked := want
+	parked.ID = 7
+	suspenseRepo.On("InsertSuspenseItemTx", mock.Anything, want).Return(&parked, nil).Once()
+	pendingRepo.On("AddPendingAction", models.PendingActionSuspense, "7", "50.00 for account 2 (account_closed)").Return(&models.PendingAction{}, nil).Once()
+
+	payment, err := svc.ReceivePayment(context.Background(), 1, 2, 50, "INV-1")
+	require.NoError(t, err)
+	assert.Equal(t, &models.InboundPayment{TransactionID: "tx-1", SuspenseItem: &parked}, payment)
+
+	// Reposting moves the funds out of suspense and resolves the item in the same transaction.
+	suspenseRepo.On("GetSuspenseItem", int64(7)).Return(&parked, nil).Once()
+	mockDB.ExpectBegin()
+	mockDB.ExpectCommit()
+	transactionRepo.On("GetAccountBalanceTx", mock.Anything, int64(99)).Return(50.0, nil).Once()
+	transactionRepo.On("AccountExistsTx", mock.Anything, int64(3)).Return(true, nil).Once()
+	transactionRepo.On("UpdateBalanceTx", mock.Anything, int64(99), -50.0).Return(nil).Once()
+	transactionRepo.On("UpdateBalanceTx", mock.Anything, int64(3), 50.0).Return(nil).Once()
+	transactionRepo.On("InsertTransactionLogTx", mock.Anything, int64(99), int64(3), 50.0).Return("tx-2", nil).Once()
+	accountID := int64(3)
+	resolved := parked
+	resolved.RepostedTo = &accountID
+	resolved.RepostTransactionID = "tx-2"
+	suspenseRepo.On("ResolveSuspenseItemTx", mock.Anything, int64(7), int64(3), "tx-2").Return(&resolved, nil).Once()
+	pendingRepo.On("ResolvePendingAction", models.PendingActionSuspense, "7").Return(nil).Once()
+
+	item, err := svc.RepostSuspenseItem(7, 3)
+	require.NoError(t, err)
+	assert.Equal(t, &resolved, item)
+
+	resolvedAt := time.Now()
+	resolved.ResolvedAt = &resolvedAt
+	suspenseRepo.On("GetSuspenseItem", int64(7)).Return(&resolved, nil).Once()
+	_, err = svc.RepostSuspenseItem(7, 0)
+	assert.ErrorIs(t, err, repository.ErrAlreadyResolved)
+
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+	accountRepo.AssertExpectations(t)
+	transactionRepo.AssertExpectations(t)
+	suspenseRepo.AssertExpectations(t)
+	pendingRepo.AssertExpectations(t)
+}
+
+func TestReceivePayment_NoSuspenseAccount(t *testing.T) {
+	db, mockDB, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	transactionRepo := new(MockTransactionRepository)
+	svc := service.NewService(db, new(MockAccountRepository), transactionRepo)
+	mockDB.ExpectBegin()
+	mockDB.ExpectRollback()
+	transactionRepo.On("GetAccountBalanceTx", mock.Anything, int64(1)).Return(200.0, nil).Once()
+	transactionRepo.On("AccountExistsTx", mock.Anything, int64(2)).Return(false, nil).Once()
+
+	_, err = svc.ReceivePayment(context.Background(), 1, 2, 50, "")
+	assert.ErrorIs(t, err, service.ErrDestinationNotFound)
+}
+
+type MockTransactionAttemptRepository struct {
+	mock.Mock
+}
+
+func (m *MockTransactionAttemptRepository) InsertTransactionAttempt(a models.TransactionAttempt) error {
+	return m.Called(a).Error(0)
+}
+
+func (m *MockTransactionAttemptRepository) ListTransactionAttempts(filter models.TransactionAttemptFilter, after repository.ChangeCursor, limit int) ([]models.TransactionAttempt, repository.ChangeCursor, error) {
+	args := m.Called(filter, after, limit)
+	attempts, _ := args.Get(0).([]models.TransactionAttempt)
+	return attempts, args.Get(1).(repository.ChangeCursor), args.Error(2)
+}
+
+func (m *MockTransactionAttemptRepository) ListTransactionAttemptsByRequest(requestID string) ([]models.TransactionAttempt, error) {
+	args := m.Called(requestID)
+	attempts, _ := args.Get(0).([]models.TransactionAttempt)
+	return attempts, args.Error(1)
+}
+
+func (m *MockTransactionAttemptRepository) CountTransactionAttempts(filter models.TransactionAttemptFilter) (int64, error) {
+	args := m.Called(filter)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockTransactionAttemptRepository) TransactionAttemptStats(groupBy models.AttemptGrouping, filter models.TransactionAttemptFilter) ([]models.TransactionAttemptStat, error) {
+	args := m.Called(groupBy, filter)
+	stats, _ := args.Get(0).([]models.TransactionAttemptStat)
+	return stats, args.Error(1)
+}
+
+func (m *MockTransactionAttemptRepository) GetTransactionAttempt(id int64) (*models.TransactionAttempt, error) {
+	args := m.Called(id)
+	attempt, _ := args.Get(0).(*models.TransactionAttempt)
+	return attempt, args.Error(1)
+}
+
+func (m *MockTransactionAttemptRepository) GetTransactionAttemptReplay(id int64) (string, error) {
+	args := m.Called(id)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockTransactionAttemptRepository) InsertTransactionAttemptReplayTx(tx *sql.Tx, id int64, transactionID string) error {
+	return m.Called(tx, id, transactionID).Error(0)
+}
+
+func TestTransactionAttempts(t *testing.T) {
+	attemptRepo := new(MockTransactionAttemptRepository)
+	svc := service.NewService(nil, new(MockAccountRepository), new(MockTransactionRepository),
+		service.WithTransactionAttemptRepository(attemptRepo))
+
+	attempt := models.TransactionAttempt{SourceAccountID: 1, DestinationAccountID: 2, Amount: 50, Code: models.ReasonInsufficientFunds, APIKey: "key-1", Tenant: "payroll"}
+	attemptRepo.On("InsertTransactionAttempt", attempt).Return(errors.New("connection refused")).Once()
+	svc.RecordTransactionAttempt(attempt)
+
+	filter := models.TransactionAttemptFilter{Code: models.ReasonInsufficientFunds}
+	attemptRepo.On("ListTransactionAttempts", filter, repository.ChangeCursor{}, 2).Return(nil, repository.ChangeCursor{}, nil).Once()
+	page, err := svc.ListTransactionAttempts(filter, "", 2)
+	require.NoError(t, err)
+	assert.Equal(t, []models.TransactionAttempt{}, page.Attempts)
+	assert.False(t, page.HasMore)
+
+	attemptRepo.On("TransactionAttemptStats", models.GroupByReasonCode, filter).
+		Return([]models.TransactionAttemptStat{{Key: "insufficient_funds", Attempts: 3, TotalAmount: 150}}, nil).Once()
+	stats, err := svc.TransactionAttemptStats("", filter)
+	require.NoError(t, err)
+	assert.Len(t, stats, 1, "stats are grouped by reason code by default")
+
+	_, err = svc.TransactionAttemptStats("account", filter)
+	assert.ErrorIs(t, err, service.ErrInvalidGrouping)
+
+	attemptRepo.AssertExpectations(t)
+}
+
+func TestLookupRequest(t *testing.T) {
+	attemptRepo := new(MockTransactionAttemptRepository)
+	svc := service.NewService(nil, new(MockAccountRepository), new(MockTransactionRepository),
+		service.WithTransactionAttemptRepository(attemptRepo))
+
+	attempts := []models.TransactionAttempt{{ID: 4, Code: models.ReasonInsufficientFunds, RequestID: "req-1"}}
+	attemptRepo.On("ListTransactionAttemptsByRequest", "req-1").Return(attempts, nil).Once()
+	record, err := svc.LookupRequest("req-1")
+	require.NoError(t, err)
+	assert.Equal(t, &models.RequestRecord{RequestID: "req-1", TransactionAttempts: attempts}, record)
+
+	attemptRepo.On("ListTransactionAttemptsByRequest", "req-2").Return(nil, nil).Once()
+	_, err = svc.LookupRequest("req-2")
+	assert.ErrorIs(t, err, repository.ErrNotFound, "a request nothing was recorded for is not found")
+
+	attemptRepo.AssertExpectations(t)
+}
+
+func TestReplayTransactionAttempt(t *testing.T) {
+	db, mockDB := newMockDB(t)
+	transactionRepo := new(MockTransactionRepository)
+	attemptRepo := new(MockTransactionAttemptRepository)
+	svc := service.NewService(db, new(MockAccountRepository), transactionRepo,
+		service.WithTransactionAttemptRepository(attemptRepo))
+
+	attempt := &models.TransactionAttempt{ID: 4, SourceAccountID: 1, DestinationAccountID: 2, Amount: 50, Code: models.ReasonConcurrencyConflict}
+	attemptRepo.On("GetTransactionAttempt", int64(4)).Return(attempt, nil)
+	notReplayed := fmt.Errorf("replay of transaction attempt 4 %w", repository.ErrNotFound)
+
+	// A dry run transfers nothing.
+	attemptRepo.On("GetTransactionAttemptReplay", int64(4)).Return("", notReplayed).Once()
+	replay, err := svc.ReplayTransactionAttempt(4, false)
+	require.NoError(t, err)
+	assert.Equal(t, models.ReplayPlanned, replay.Outcome)
+
+	// The replay is recorded in the transaction of the transfer.
+	attemptRepo.On("GetTransactionAttemptReplay", int64(4)).Return("", notReplayed).Once()
+	mockDB.ExpectBegin()
+	mockDB.ExpectCommit()
+	transactionRepo.On("GetAccountBalanceTx", mock.Anything, int64(1)).Return(200.0, nil).Once()
+	transactionRepo.On("AccountExistsTx", mock.Anything, int64(2)).Return(true, nil).Once()
+	transactionRepo.On("UpdateBalanceTx", mock.Anything, int64(1), -50.0).Return(nil).Once()
+	transactionRepo.On("UpdateBalanceTx", mock.Anything, int64(2), 50.0).Return(nil).Once()
+	transactionRepo.On("InsertTransactionLogTx", mock.Anything, int64(1), int64(2), 50.0).Return("tx-1", nil).Once()
+	attemptRepo.On("InsertTransactionAttemptReplayTx", mock.Anything, int64(4), "tx-1").Return(nil).Once()
+	replay, err = svc.ReplayTransactionAttempt(4, true)
+	require.NoError(t, err)
+	assert.Equal(t, &models.TransactionAttemptReplay{
+		AttemptID: 4, SourceAccountID: 1, DestinationAccountID: 2, Amount: 50,
+		OriginalCode: models.ReasonConcurrencyConflict, Outcome: models.ReplayCompleted, TransactionID: "tx-1",
+	}, replay)
+
+	// Replaying it again moves nothing.
+	attemptRepo.On("GetTransactionAttemptReplay", int64(4)).Return("tx-1", nil).Once()
+	replay, err = svc.ReplayTransactionAttempt(4, true)
+	require.NoError(t, err)
+	assert.Equal(t, models.ReplayDuplicate, replay.Outcome)
+	assert.Equal(t, "tx-1", replay.TransactionID)
+
+	// A concurrent replay that commits first rolls this one back.
+	attemptRepo.On("GetTransactionAttemptReplay", int64(4)).Return("", notReplayed).Once()
+	mockDB.ExpectBegin()
+	mockDB.ExpectRollback()
+	transactionRepo.On("GetAccountBalanceTx", mock.Anything, int64(1)).Return(150.0, nil).Once()
+	transactionRepo.On("AccountExistsTx", mock.Anything, int64(2)).Return(true, nil).Once()
+	transactionRepo.On("UpdateBalanceTx", mock.Anything, int64(1), -50.0).Return(nil).Once()
+	transactionRepo.On("UpdateBalanceTx", mock.Anything, int64(2), 50.0).Return(nil).Once()
+	transactionRepo.On("InsertTransactionLogTx", mock.Anything, int64(1), int64(2), 50.0).Return("tx-2", nil).Once()
+	attemptRepo.On("InsertTransactionAttemptReplayTx", mock.Anything, int64(4), "tx-2").
+		Return(fmt.Errorf("transaction attempt 4 %w", repository.ErrAlreadyReplayed)).Once()
+	attemptRepo.On("GetTransactionAttemptReplay", int64(4)).Return("tx-1", nil).Once()
+	replay, err = svc.ReplayTransactionAttempt(4, true)
+	require.NoError(t, err)
+	assert.Equal(t, models.ReplayDuplicate, replay.Outcome)
+	assert.Equal(t, "tx-1", replay.TransactionID)
+
+	// A rejection is reported with its reason code.
+	attemptRepo.On("GetTransactionAttemptReplay", int64(4)).Return("", notReplayed).Once()
+	mockDB.ExpectBegin()
+	mockDB.ExpectRollback()
+	transactionRepo.On("GetAccountBalanceTx", mock.Anything, int64(1)).Return(10.0, nil).Once()
+	replay, err = svc.ReplayTransactionAttempt(4, true)
+	require.NoError(t, err)
+	assert.Equal(t, models.ReplayRejected, replay.Outcome)
+	assert.Equal(t, models.ReasonInsufficientFunds, replay.Code)
+
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+	transactionRepo.AssertExpectations(t)
+	attemptRepo.AssertExpectations(t)
+}
+
+func TestRecordTransactionAttempt_Disabled(t *testing.T) {
+	svc := service.NewService(nil, new(MockAccountRepository), new(MockTransactionRepository))
+	svc.RecordTransactionAttempt(models.TransactionAttempt{Code: models.ReasonInsufficientFunds})
+
+	_, err := svc.ListTransactionAttempts(models.TransactionAttemptFilter{}, "", 10)
+	assert.Error(t, err)
+}
+
+type MockOutboxRepository struct {
+	mock.Mock
+}
+
+func (m *MockOutboxRepository) InsertEventTx(tx *sql.Tx, e models.Event) (int64, error) {
+	args := m.Called(tx, e)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockOutboxRepository) ListOutboxEvents(afterID int64, limit int) ([]models.Event, error) {
+	args := m.Called(afterID, limit)
+	events, _ := args.Get(0).([]models.Event)
+	return events, args.Error(1)
+}
+
+func (m *MockOutboxRepository) GetOutboxRelay() (*models.OutboxRelayStatus, error) {
+	args := m.Called()
+	status, _ := args.Get(0).(*models.OutboxRelayStatus)
+	return status, args.Error(1)
+}
+
+func (m *MockOutboxRepository) AdvanceOutboxRelay(from, to int64) (bool, error) {
+	args := m.Called(from, to)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockOutboxRepository) SetOutboxRelayPosition(afterID int64) error {
+	return m.Called(afterID).Error(0)
+}
+
+func (m *MockOutboxRepository) SetOutboxRelayPaused(paused bool) error {
+	return m.Called(paused).Error(0)
+}
+
+func TestCreateTransaction_Outbox(t *testing.T) {
+	db, mockDB := newMockDB(t)
+	mockTransactionRepo := new(MockTransactionRepository)
+	outboxRepo := new(MockOutboxRepository)
+
+	mockTransactionRepo.On("GetAccountBalanceTx", mock.Anything, int64(1)).Return(200.0, nil)
+	mockTransactionRepo.On("AccountExistsTx", mock.Anything, int64(2)).Return(true, nil)
+	mockTransactionRepo.On("UpdateBalanceTx", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockTransactionRepo.On("InsertTransactionLogTx", mock.Anything, int64(1), int64(2), 10.0).Return("7", nil)
+	outboxRepo.On("InsertEventTx", mock.Anything, mock.MatchedBy(func(e models.Event) bool {
+		return e.Type == models.EventTransferCompleted && e.SchemaVersion == models.TransferCompletedVersion && e.AggregateID == "1" &&
+			string(e.Payload) == `{"transaction_id":"7","source_account_id":1,"destination_account_id":2,"amount":"10.00"}`
+	})).Return(int64(0), errors.New("connection refused")).Once()
+	mockDB.ExpectBegin()
+	mockDB.ExpectRollback()
+
+	svc := service.NewService(db, new(MockAccountRepository), mockTransactionRepo, service.WithOutboxRepository(outboxRepo))
+	_, err := svc.CreateTransaction(context.Background(), 1, 2, 10, nil)
+	assert.Error(t, err, "a transfer whose event cannot be written is rolled back")
+	outboxRepo.AssertExpectations(t)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestOutboxRelay(t *testing.T) {
+	outboxRepo := new(MockOutboxRepository)
+	svc := service.NewService(nil, new(MockAccountRepository), new(MockTransactionRepository), service.WithOutboxRepository(outboxRepo))
+
+	status := &models.OutboxRelayStatus{LastEventID: 3, LatestEventID: 9, Paused: true}
+	outboxRepo.On("SetOutboxRelayPaused", true).Return(nil).Once()
+	outboxRepo.On("GetOutboxRelay").Return(status, nil)
+	got, err := svc.PauseOutboxRelay()
+	require.NoError(t, err)
+	assert.Equal(t, status, got)
+
+	outboxRepo.On("SetOutboxRelayPosition", int64(0)).Return(nil).Once()
+	_, err = svc.ReplayOutbox(0)
+	require.NoError(t, err)
+
+	_, err = svc.ReplayOutbox(-1)
+	assert.ErrorIs(t, err, service.ErrInvalidOffset)
+	outboxRepo.AssertExpectations(t)
+
+	_, err = service.NewService(nil, new(MockAccountRepository), new(MockTransactionRepository)).OutboxRelayStatus()
+	assert.Error(t, err, "the relay cannot be managed without an outbox")
+}
+
+func TestListEvents(t *testing.T) {
+	outboxRepo := new(MockOutboxRepository)
+	svc := service.NewService(nil, new(MockAccountRepository), new(MockTransactionRepository), service.WithOutboxRepository(outboxRepo))
+
+	outboxRepo.On("ListOutboxEvents", int64(40), 3).Return([]models.Event{{ID: 41}, {ID: 43}, {ID: 44}}, nil).Once()
+	page, err := svc.ListEvents(40, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []models.Event{{ID: 41}, {ID: 43}}, page.Events)
+	assert.Equal(t, int64(43), page.NextAfterID)
+	assert.True(t, page.HasMore)
+
+	outboxRepo.On("ListOutboxEvents", int64(44), 3).Return(nil, nil).Once()
+	page, err = svc.ListEvents(44, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []models.Event{}, page.Events)
+	assert.Equal(t, int64(44), page.NextAfterID, "an empty page keeps the position")
+	assert.False(t, page.HasMore)
+
+	_, err = svc.ListEvents(-1, 2)
+	assert.ErrorIs(t, err, service.ErrInvalidOffset)
+	outboxRepo.AssertExpectations(t)
+}
+
+type MockWebhookRepository struct {
+	mock.Mock
+}
+
+func (m *MockWebhookRepository) InsertWebhook(w models.Webhook) (*models.Webhook, error) {
+	args := m.Called(w)
+	webhook, _ := args.Get(0).(*models.Webhook)
+	return webhook, args.Error(1)
+}
+
+func (m *MockWebhookRepository) GetWebhook(id int64, tenant string) (*models.Webhook, error) {
+	args := m.Called(id, tenant)
+	webhook, _ := args.Get(0).(*models.Webhook)
+	return webhook, args.Error(1)
+}
+
+func (m *MockWebhookRepository) ListWebhooks(tenant string) ([]models.Webhook, error) {
+	args := m.Called(tenant)
+	webhooks, _ := args.Get(0).([]models.Webhook)
+	return webhooks, args.Error(1)
+}
+
+func (m *MockWebhookRepository) DeleteWebhook(id int64, tenant string) error {
+	return m.Called(id, tenant).Error(0)
+}
+
+func (m *MockWebhookRepository) GetAccountOwner(accountID int64) (string, string, error) {
+	args := m.Called(accountID)
+	return args.String(0), args.String(1), args.Error(2)
+}
+
+type MockWebhookClient struct {
+	mock.Mock
+}
+
+func (m *MockWebhookClient) Verify(ctx context.Context, url string) error {
+	return m.Called(url).Error(0)
+}
+
+func (m *MockWebhookClient) Ping(ctx context.Context, w models.Webhook) models.WebhookPing {
+	return m.Called(w).Get(0).(models.WebhookPing)
+}
+
+func (m *MockWebhookClient) Deliver(ctx context.Context, w models.Webhook, e models.Event) error {
+	return m.Called(w, e).Error(0)
+}
+
+func TestRegisterWebhook(t *testing.T) {
+	now := time.Date(2026, 3, 14, 9, 30, 0, 0, time.UTC)
+	webhookRepo := new(MockWebhookRepository)
+	client := new(MockWebhookClient)
+	svc := service.NewService(nil, new(MockAccountRepository), new(MockTransactionRepository),
+		service.WithWebhooks(webhookRepo, client), service.WithClock(clock.NewFake(now)))
+
+	client.On("Verify", "https://example.com/hooks").Return(nil).Once()
+	webhook := models.Webhook{Tenant: "payroll", URL: "https://example.com/hooks", EventTypes: []string{}, VerifiedAt: now}
+	webhookRepo.On("InsertWebhook", webhook).Return(&webhook, nil).Once()
+	_, err := svc.RegisterWebhook(context.Background(), "payroll", "key_payroll", models.WebhookRequest{URL: "https://example.com/hooks"})
+	require.NoError(t, err)
+
+	client.On("Verify", "https://example.com/typo").Return(errors.New("did not echo the challenge")).Once()
+	_, err = svc.RegisterWebhook(context.Background(), "payroll", "key_payroll", models.WebhookRequest{URL: "https://example.com/typo"})
+	assert.ErrorIs(t, err, service.ErrWebhookVerification, "an unverified URL is not stored")
+
+	for _, req := range []models.WebhookRequest{
+		{URL: "example.com/hooks"},
+		{URL: "ftp://example.com/hooks"},
+		{URL: "https://example.com/hooks", EventTypes: []string{"transfer.unknown"}},
+	} {
+		_, err = svc.RegisterWebhook(context.Background(), "payroll", "key_payroll", req)
+		assert.ErrorIs(t, err, service.ErrInvalidWebhook, req)
+	}
+	webhookRepo.AssertExpectations(t)
+	client.AssertExpectations(t)
+}
+
+func TestPingWebhook(t *testing.T) {
+	webhookRepo := new(MockWebhookRepository)
+	client := new(MockWebhookClient)
+	svc := service.NewService(nil, new(MockAccountRepository), new(MockTransactionRepository), service.WithWebhooks(webhookRepo, client))
+
+	webhook := &models.Webhook{ID: 7, Tenant: "payroll", URL: "https://example.com/hooks"}
+	webhookRepo.On("GetWebhook", int64(7), "payroll").Return(webhook, nil).Once()
+	client.On("Ping", *webhook).Return(models.WebhookPing{WebhookID: 7, Delivered: true, StatusCode: 200}).Once()
+	ping, err := svc.PingWebhook(7, "payroll")
+	require.NoError(t, err)
+	assert.True(t, ping.Delivered)
+
+	webhookRepo.On("GetWebhook", int64(7), "treasury").Return(nil, fmt.Errorf("webhook 7 %w", repository.ErrNotFound)).Once()
+	_, err = svc.PingWebhook(7, "treasury")
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+	client.AssertExpectations(t)
+}
+
+type MockNotificationRepository struct {
+	mock.Mock
+}
+
+func (m *MockNotificationRepository) GetNotificationPreference(accountID int64) (*models.NotificationPreference, error) {
+	args := m.Called(accountID)
+	pref, _ := args.Get(0).(*models.NotificationPreference)
+	return pref, args.Error(1)
+}
+
+func (m *MockNotificationRepository) SetNotificationPreference(accountID int64, mode models.NotificationMode, timezone string) (*models.NotificationPreference, error) {
+	args := m.Called(accountID, mode, timezone)
+	pref, _ := args.Get(0).(*models.NotificationPreference)
+	return pref, args.Error(1)
+}
+
+func (m *MockNotificationRepository) ListPendingDigests(now time.Time) ([]models.DailyDigest, error) {
+	args := m.Called(now)
+	digests, _ := args.Get(0).([]models.DailyDigest)
+	return digests, args.Error(1)
+}
+
+func (m *MockNotificationRepository) InsertDigestTx(tx *sql.Tx, d models.DailyDigest) error {
+	return m.Called(tx, d).Error(0)
+}
+
+func TestSetNotificationPreference(t *testing.T) {
+	webhookRepo := new(MockWebhookRepository)
+	notificationRepo := new(MockNotificationRepository)
+	svc := service.NewService(nil, new(MockAccountRepository), new(MockTransactionRepository),
+		service.WithWebhooks(webhookRepo, new(MockWebhookClient)), service.WithNotificationDigests(notificationRepo))
+
+	webhookRepo.On("GetAccountOwner", int64(42)).Return("payroll", "key_alice", nil)
+	digest := &models.NotificationPreference{AccountID: 42, Mode: models.NotificationDailyDigest}
+	notificationRepo.On("SetNotificationPreference", int64(42), models.NotificationDailyDigest, "UTC").Return(digest, nil).Once()
+	pref, err := svc.SetNotificationPreference(context.Background(), "payroll", "key_alice", 42, models.NotificationPreferenceRequest{Mode: models.NotificationDailyDigest})
+	require.NoError(t, err)
+	assert.Equal(t, digest, pref)
+	notificationRepo.On("SetNotificationPreference", int64(42), models.NotificationDailyDigest, "Asia/Tokyo").Return(digest, nil).Once()
+	_, err = svc.SetNotificationPreference(context.Background(), "payroll", "key_alice", 42, models.NotificationPreferenceRequest{Mode: models.NotificationDailyDigest, Timezone: "Asia/Tokyo"})
+	require.NoError(t, err)
+
+	_, err = svc.SetNotificationPreference(context.Background(), "payroll", "key_alice", 42, models.NotificationPreferenceRequest{Mode: "weekly"})
+	assert.ErrorIs(t, err, service.ErrInvalidNotificationMode)
+	for _, tz := range []string{"Mars/Olympus", "Local"} {
+		_, err = svc.SetNotificationPreference(context.Background(), "payroll", "key_alice", 42, models.NotificationPreferenceRequest{Mode: models.NotificationDailyDigest, Timezone: tz})
+		assert.ErrorIs(t, err, service.ErrInvalidNotificationMode, tz)
+	}
+	_, err = svc.SetNotificationPreference(context.Background(), "payroll", "key_bob", 42, models.NotificationPreferenceRequest{Mode: models.NotificationImmediate})
+	assert.ErrorIs(t, err, service.ErrNotAccountOwner, "only the owner sets how the account is notified")
+	_, err = svc.GetNotificationPreference(context.Background(), "treasury", "key_alice", 42)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+	notificationRepo.AssertExpectations(t)
+}
+
+func TestSendDailyDigests(t *testing.T) {
+	now := time.Date(2026, 3, 17, 0, 30, 0, 0, time.UTC)
+	db, mockDB := newMockDB(t)
+	notificationRepo := new(MockNotificationRepository)
+	outboxRepo := new(MockOutboxRepository)
+	svc := service.NewService(db, new(MockAccountRepository), new(MockTransactionRepository),
+		service.WithWebhooks(new(MockWebhookRepository), new(MockWebhookClient)),
+		service.WithNotificationDigests(notificationRepo),
+		service.WithOutboxRepository(outboxRepo),
+		service.WithClock(clock.NewFake(now)))
+
+	// The job missed the two days before the last one.
+	digests := []models.DailyDigest{
+		{AccountID: 42, Tenant: "payroll", Date: "2026-03-14", Incoming: 20, IncomingCount: 1, Outgoing: 45.5, OutgoingCount: 2},
+		{AccountID: 44, Tenant: "payroll", Date: "2026-03-14", Incoming: 5, IncomingCount: 1},
+		{AccountID: 42, Tenant: "payroll", Date: "2026-03-15", Incoming: 1, IncomingCount: 1},
+		{AccountID: 43, Tenant: "payroll", Date: "2026-03-15", Outgoing: 2, OutgoingCount: 1},
+		{AccountID: 42, Tenant: "payroll", Date: "2026-03-16", Incoming: 3, IncomingCount: 1},
+	}
+	notificationRepo.On("ListPendingDigests", now).Return(digests, nil).Once()
+	digestEvent := func(account, date string) any {
+		return mock.MatchedBy(func(e models.Event) bool {
+			return e.Type == models.EventAccountDailyDigest && e.AggregateID == account && strings.Contains(string(e.Payload), `"date":"`+date+`"`)
+		})
+	}
+	mockDB.ExpectBegin()
+	notificationRepo.On("InsertDigestTx", mock.Anything, digests[0]).Return(nil).Once()
+	outboxRepo.On("InsertEventTx", mock.Anything, mock.MatchedBy(func(e models.Event) bool {
+		return e.Type == models.EventAccountDailyDigest && e.AggregateID == "42" &&
+			strings.Contains(string(e.Payload), `"transactions":3`) && strings.Contains(string(e.Payload), `"net":"-25.50"`)
+	})).Return(int64(21), nil).Once()
+	mockDB.ExpectCommit()
+	// Another server sent the digest of account 44 since they were listed.
+	mockDB.ExpectBegin()
+	notificationRepo.On("InsertDigestTx", mock.Anything, digests[1]).Return(repository.ErrAlreadyDigested).Once()
+	mockDB.ExpectRollback()
+	mockDB.ExpectBegin()
+	notificationRepo.On("InsertDigestTx", mock.Anything, digests[2]).Return(nil).Once()
+	outboxRepo.On("InsertEventTx", mock.Anything, digestEvent("42", "2026-03-15")).Return(int64(22), nil).Once()
+	mockDB.ExpectCommit()
+	// The outbox cannot be written: the digest is not recorded as sent, and the
+	// days after it are left for the next run.
+	mockDB.ExpectBegin()
+	notificationRepo.On("InsertDigestTx", mock.Anything, digests[3]).Return(nil).Once()
+	outboxRepo.On("InsertEventTx", mock.Anything, digestEvent("43", "2026-03-15")).Return(int64(0), errors.New("connection reset")).Once()
+	mockDB.ExpectRollback()
+
+	sent, err := svc.SendDailyDigests()
+	assert.Error(t, err)
+	assert.Equal(t, 2, sent)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+	notificationRepo.AssertExpectations(t)
+	outboxRepo.AssertExpectations(t)
+}
+
+func TestNotifyEventWebhooks_DailyDigest(t *testing.T) {
+	webhookRepo := new(MockWebhookRepository)
+	client := new(MockWebhookClient)
+	notificationRepo := new(MockNotificationRepository)
+	svc := service.NewService(nil, new(MockAccountRepository), new(MockTransactionRepository),
+		service.WithWebhooks(webhookRepo, client),
+		service.WithNotificationDigests(notificationRepo))
+
+	digested, other := int64(42), int64(43)
+	hooks := []models.Webhook{
+		{ID: 1, Tenant: "payroll", URL: "https://example.com/all", EventTypes: []string{}},
+		{ID: 2, Tenant: "payroll", URL: "https://example.com/42", AccountID: &digested, EventTypes: []string{}},
+		{ID: 3, Tenant: "payroll", URL: "https://example.com/43", AccountID: &other, EventTypes: []string{}},
+	}
+	webhookRepo.On("GetAccountOwner", digested).Return("payroll", "key_alice", nil)
+	webhookRepo.On("ListWebhooks", "payroll").Return(hooks, nil)
+	notificationRepo.On("GetNotificationPreference", digested).Return(&models.NotificationPreference{AccountID: 42, Mode: models.NotificationDailyDigest}, nil)
+	event := models.Event{ID: 21, Type: models.EventAccountDailyDigest, Payload: json.RawMessage(`{"account_id":42,"date":"2026-03-14"}`)}
+	// The digest goes to the account's own webhook alone.
+	client.On("Deliver", hooks[1], event).Return(nil).Once()
+
+	svc.NotifyEventWebhooks(event)
+	client.AssertExpectations(t)
+	client.AssertNumberOfCalls(t, "Deliver", 1)
+}
+
+func TestNotifyWebhooks_DailyDigest(t *testing.T) {
+	db, mockDB := newMockDB(t)
+	accountRepo := new(MockAccountRepository)
+	reimbursementRepo := new(MockReimbursementRepository)
+	webhookRepo := new(MockWebhookRepository)
+	client := new(MockWebhookClient)
+	notificationRepo := new(MockNotificationRepository)
+	svc := service.NewService(db, accountRepo, new(MockTransactionRepository),
+		service.WithReimbursements(99, reimbursementRepo),
+		service.WithWebhooks(webhookRepo, client),
+		service.WithNotificationDigests(notificationRepo))
+
+	accountID := int64(42)
+	hooks := []models.Webhook{
+		{ID: 1, Tenant: "payroll", URL: "https://example.com/all", EventTypes: []string{}},
+		{ID: 2, Tenant: "payroll", URL: "https://example.com/42", AccountID: &accountID, EventTypes: []string{}},
+	}
+	req := models.ReimbursementRequest{AccountID: 42, Amount: 12, Description: "Taxi", Receipt: models.Receipt{Merchant: "Cab Co", Date: "2026-03-12"}}
+	accountRepo.On("AccountExists", int64(42)).Return(true, nil).Once()
+	mockDB.ExpectBegin()
+	reimbursementRepo.On("InsertReimbursementTx", mock.Anything, mock.Anything).
+		Return(&models.Reimbursement{ID: 7, Tenant: "payroll", AccountID: 42, Amount: 12, Status: models.ReimbursementPending}, nil).Once()
+	mockDB.ExpectCommit()
+	webhookRepo.On("ListWebhooks", "payroll").Return(hooks, nil).Once()
+	notificationRepo.On("GetNotificationPreference", int64(42)).Return(&models.NotificationPreference{AccountID: 42, Mode: models.NotificationDailyDigest}, nil).Once()
+	// The account's webhook waits for the digest; the tenant-wide one does not.
+	client.On("Deliver", hooks[0], mock.Anything).Return(nil).Once()
+
+	_, err := svc.SubmitReimbursement(context.Background(), "payroll", req)
+	require.NoError(t, err)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+	notificationRepo.AssertExpectations(t)
+	client.AssertExpectations(t)
+}
+
+func TestNotifyEventWebhooks(t *testing.T) {
+	webhookRepo := new(MockWebhookRepository)
+	client := new(MockWebhookClient)
+	notificationRepo := new(MockNotificationRepository)
+	svc := service.NewService(nil, new(MockAccountRepository), new(MockTransactionRepository),
+		service.WithWebhooks(webhookRepo, client),
+		service.WithNotificationDigests(notificationRepo))
+
+	alice, bob, carol, dave := int64(42), int64(43), int64(44), int64(50)
+	payroll := []models.Webhook{
+		{ID: 1, Tenant: "payroll", URL: "https://example.com/all", EventTypes: []string{}},
+		{ID: 2, Tenant: "payroll", URL: "https://example.com/42", AccountID: &alice, EventTypes: []string{}},
+		{ID: 3, Tenant: "payroll", URL: "https://example.com/43", AccountID: &bob, EventTypes: []string{}},
+		{ID: 4, Tenant: "payroll", URL: "https://example.com/44", AccountID: &carol, EventTypes: []string{}},
+		{ID: 5, Tenant: "payroll", URL: "https://example.com/reimbursements", EventTypes: []string{models.EventReimbursementStatusChanged}},
+	}
+	treasury := []models.Webhook{
+		{ID: 6, Tenant: "treasury", URL: "https://example.com/50", AccountID: &dave, EventTypes: []string{}},
+	}
+	webhookRepo.On("GetAccountOwner", alice).Return("payroll", "key_alice", nil)
+	webhookRepo.On("GetAccountOwner", bob).Return("payroll", "key_bob", nil)
+	webhookRepo.On("GetAccountOwner", dave).Return("treasury", "", nil)
+	webhookRepo.On("ListWebhooks", "payroll").Return(payroll, nil)
+	webhookRepo.On("ListWebhooks", "treasury").Return(treasury, nil)
+	immediate := &models.NotificationPreference{Mode: models.NotificationImmediate}
+	notificationRepo.On("GetNotificationPreference", alice).Return(immediate, nil)
+	notificationRepo.On("GetNotificationPreference", bob).Return(immediate, nil)
+	notificationRepo.On("GetNotificationPreference", dave).Return(&models.NotificationPreference{Mode: models.NotificationDailyDigest}, nil)
+
+	transfer := func(id, source, dest int64) models.Event {
+		payload, err := json.Marshal(models.TransferCompleted{TransactionID: "tx", SourceAccountID: source, DestinationAccountID: dest, Amount: 10})
+		require.NoError(t, err)
+		return models.Event{ID: id, Type: models.EventTransferCompleted, Payload: payload}
+	}
+
+	// Within the tenant: each account's webhook, and the tenant-wide one once.
+	within := transfer(1, alice, bob)
+	client.On("Deliver", payroll[0], within).Return(nil).Once()
+	client.On("Deliver", payroll[1], within).Return(nil).Once()
+	client.On("Deliver", payroll[2], within).Return(nil).Once()
+	svc.NotifyEventWebhooks(within)
+
+	// Across tenants, to an account whose notifications wait for the digest.
+	across := transfer(2, alice, dave)
+	client.On("Deliver", payroll[0], across).Return(nil).Once()
+	client.On("Deliver", payroll[1], across).Return(nil).Once()
+	svc.NotifyEventWebhooks(across)
+
+	svc.NotifyEventWebhooks(models.Event{ID: 3, Type: models.EventReimbursementStatusChanged})
+	client.AssertExpectations(t)
+	client.AssertNumberOfCalls(t, "Deliver", 5)
+}
+
+type MockRegionRepository struct {
+	mock.Mock
+}
+
+func (m *MockRegionRepository) GetRegionFence() (*models.RegionFence, error) {
+	args := m.Called()
+	fence, _ := args.Get(0).(*models.RegionFence)
+	return fence, args.Error(1)
+}
+
+func (m *MockRegionRepository) PromoteRegion(region string, epoch int64) (bool, error) {
+	args := m.Called(region, epoch)
+	return args.Bool(0), args.Error(1)
+}
+
+func TestPromoteRegion(t *testing.T) {
+	regionRepo := new(MockRegionRepository)
+	svc := service.NewService(nil, new(MockAccountRepository), new(MockTransactionRepository), service.WithRegion("eu-west-1", regionRepo))
+
+	regionRepo.On("GetRegionFence").Return(&models.RegionFence{ActiveRegion: "us-east-1", Epoch: 3}, nil).Once()
+	status, err := svc.RegionStatus()
+	require.NoError(t, err)
+	assert.Equal(t, &models.RegionStatus{Region: "eu-west-1", RegionFence: models.RegionFence{ActiveRegion: "us-east-1", Epoch: 3}}, status)
+
+	regionRepo.On("PromoteRegion", "eu-west-1", int64(3)).Return(true, nil).Once()
+	regionRepo.On("GetRegionFence").Return(&models.RegionFence{ActiveRegion: "eu-west-1", Epoch: 4}, nil).Once()
+	status, err = svc.PromoteRegion("eu-west-1", 3)
+	require.NoError(t, err)
+	assert.True(t, status.Active)
+	assert.Equal(t, int64(4), status.Epoch)
+
+	regionRepo.On("PromoteRegion", "us-east-1", int64(3)).Return(false, nil).Once()
+	_, err = svc.PromoteRegion("us-east-1", 3)
+	assert.ErrorIs(t, err, service.ErrStaleEpoch, "a concurrent promotion won")
+
+	_, err = svc.PromoteRegion("US East", 4)
+	assert.ErrorIs(t, err, service.ErrInvalidRegion)
+	regionRepo.AssertExpectations(t)
+}
+
+type MockTopUpRepository struct {
+	mock.Mock
+}
+
+func (m *MockTopUpRepository) SetTopUpRule(rule models.TopUpRule) (*models.TopUpRule, error) {
+	args := m.Called(rule)
+	r, _ := args.Get(0).(*models.TopUpRule)
+	return r, args.Error(1)
+}
+
+func (m *MockTopUpRepository) GetTopUpRule(accountID int64) (*models.TopUpRule, error) {
+	args := m.Called(accountID)
+	r, _ := args.Get(0).(*models.TopUpRule)
+	return r, args.Error(1)
+}
+
+func (m *MockTopUpRepository) DeleteTopUpRule(accountID int64) error {
+	return m.Called(accountID).Error(0)
+}
+
+func TestSetTopUpRule(t *testing.T) {
+	accountRepo := new(MockAccountRepository)
+	topUpRepo := new(MockTopUpRepository)
+	svc := service.NewService(nil, accountRepo, new(MockTransactionRepository), service.WithTopUps(topUpRepo))
+
+	for name, req := range map[string]models.SetTopUpRuleRequest{
+		"negative threshold": {Threshold: -1, Amount: 500, FundingAccountID: 2},
+		"zero amount":        {Threshold: 100, FundingAccountID: 2},
+		"self funded":        {Threshold: 100, Amount: 500, FundingAccountID: 1},
+	} {
+		_, err := svc.SetTopUpRule(1, req)
+		assert.ErrorIs(t, err, service.ErrInvalidTopUpRule, name)
+	}
+
+	accountRepo.On("AccountExists", int64(1)).Return(true, nil)
+	accountRepo.On("AccountExists", int64(3)).Return(false, nil).Once()
+	_, err := svc.SetTopUpRule(1, models.SetTopUpRuleRequest{Threshold: 100, Amount: 500, FundingAccountID: 3})
+	assert.ErrorIs(t, err, service.ErrInvalidTopUpRule, "the funding account must exist")
+
+	accountRepo.On("AccountExists", int64(2)).Return(true, nil).Once()
+	rule := models.TopUpRule{AccountID: 1, Threshold: 100, Amount: 500, FundingAccountID: 2}
+	topUpRepo.On("SetTopUpRule", rule).Return(&rule, nil).Once()
+	got, err := svc.SetTopUpRule(1, models.SetTopUpRuleRequest{Threshold: 100, Amount: 500, FundingAccountID: 2})
+	require.NoError(t, err)
+	assert.Equal(t, &rule, got)
+
+	accountRepo.AssertExpectations(t)
+	topUpRepo.AssertExpectations(t)
+}
+
+func TestCreateTransaction_TopUp(t *testing.T) {
+	rule := &models.TopUpRule{AccountID: 1, Threshold: 100, Amount: 500, FundingAccountID: 2}
+	debit := func(mtr *MockTransactionRepository) {
+		mtr.On("GetAccountBalanceTx", mock.Anything, int64(1)).Return(200.0, nil).Once()
+		mtr.On("AccountExistsTx", mock.Anything, int64(3)).Return(true, nil).Once()
+		mtr.On("UpdateBalanceTx", mock.Anything, int64(1), -150.0).Return(nil).Once()
+		mtr.On("UpdateBalanceTx", mock.Anything, int64(3), 150.0).Return(nil).Once()
+		mtr.On("InsertTransactionLogTx", mock.Anything, int64(1), int64(3), 150.0).Return("7", nil).Once()
+	}
+	topUp := func(mtr *MockTransactionRepository, balanceAfter float64) {
+		mtr.On("GetAccountBalanceTx", mock.Anything, int64(2)).Return(1000.0, nil).Once()
+		mtr.On("AccountExistsTx", mock.Anything, int64(1)).Return(true, nil).Once()
+		mtr.On("UpdateBalanceTx", mock.Anything, int64(2), -500.0).Return(nil).Once()
+		mtr.On("UpdateBalanceTx", mock.Anything, int64(1), 500.0).Return(nil).Once()
+		mtr.On("InsertTransactionLogsTx", mock.Anything, []repository.TransactionLog{
+			{SourceID: 2, DestID: 1, Amount: 500, Kind: models.TransactionTopUp},
+		}).Return([]string{"8"}, nil).Once()
+		mtr.On("GetAccountBalanceTx", mock.Anything, int64(1)).Return(balanceAfter, nil).Once()
+	}
+
+	t.Run("Below threshold", func(t *testing.T) {
+		db, mockDB := newMockDB(t)
+		accountRepo := new(MockAccountRepository)
+		transactionRepo := new(MockTransactionRepository)
+		topUpRepo := new(MockTopUpRepository)
+		outboxRepo := new(MockOutboxRepository)
+		svc := service.NewService(db, accountRepo, transactionRepo, service.WithTopUps(topUpRepo), service.WithOutboxRepository(outboxRepo))
+
+		debit(transactionRepo)
+		topUpRepo.On("GetTopUpRule", int64(1)).Return(rule, nil).Once()
+		accountRepo.On("GetAccount", int64(1), false).Return(&models.Account{AccountID: 1, Balance: 50}, nil).Once()
+		topUp(transactionRepo, 550)
+		outboxRepo.On("InsertEventTx", mock.Anything, mock.MatchedBy(func(e models.Event) bool {
+			return e.AggregateID == "1" && !strings.Contains(string(e.Payload), "kind")
+		})).Return(int64(1), nil).Once()
+		outboxRepo.On("InsertEventTx", mock.Anything, mock.MatchedBy(func(e models.Event) bool {
+			return e.AggregateID == "2" && strings.Contains(string(e.Payload), `"kind":"top_up"`)
+		})).Return(int64(2), nil).Once()
+		mockDB.ExpectBegin()
+		mockDB.ExpectCommit()
+		mockDB.ExpectBegin()
+		mockDB.ExpectCommit()
+
+		transactionID, err := svc.CreateTransaction(context.Background(), 1, 3, 150, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "7", transactionID)
+		transactionRepo.AssertExpectations(t)
+		outboxRepo.AssertExpectations(t)
+		assert.NoError(t, mockDB.ExpectationsWereMet())
+	})
+
+	t.Run("Already topped up", func(t *testing.T) {
+		db, mockDB := newMockDB(t)
+		accountRepo := new(MockAccountRepository)
+		transactionRepo := new(MockTransactionRepository)
+		topUpRepo := new(MockTopUpRepository)
+		svc := service.NewService(db, accountRepo, transactionRepo, service.WithTopUps(topUpRepo))
+
+		debit(transactionRepo)
+		topUpRepo.On("GetTopUpRule", int64(1)).Return(rule, nil).Once()
+		accountRepo.On("GetAccount", int64(1), false).Return(&models.Account{AccountID: 1, Balance: 50}, nil).Once()
+		// A concurrent transfer topped the account up while this one waited for
+		// its lock.
+		topUp(transactionRepo, 1050)
+		mockDB.ExpectBegin()
+		mockDB.ExpectCommit()
+		mockDB.ExpectBegin()
+		mockDB.ExpectRollback()
+
+		_, err := svc.CreateTransaction(context.Background(), 1, 3, 150, nil)
+		require.NoError(t, err)
+		transactionRepo.AssertExpectations(t)
+		assert.NoError(t, mockDB.ExpectationsWereMet())
+	})
+
+	t.Run("Above threshold", func(t *testing.T) {
+		db, mockDB := newMockDB(t)
+		accountRepo := new(MockAccountRepository)
+		transactionRepo := new(MockTransactionRepository)
+		topUpRepo := new(MockTopUpRepository)
+		svc := service.NewService(db, accountRepo, transactionRepo, service.WithTopUps(topUpRepo))
+
+		debit(transactionRepo)
+		topUpRepo.On("GetTopUpRule", int64(1)).Return(rule, nil).Once()
+		accountRepo.On("GetAccount", int64(1), false).Return(&models.Account{AccountID: 1, Balance: 100}, nil).Once()
+		mockDB.ExpectBegin()
+		mockDB.ExpectCommit()
+
+		_, err := svc.CreateTransaction(context.Background(), 1, 3, 150, nil)
+		require.NoError(t, err)
+		transactionRepo.AssertExpectations(t)
+		assert.NoError(t, mockDB.ExpectationsWereMet())
+	})
+}
+
+type MockTransferIntentRepository struct {
+	mock.Mock
+}
+
+func (m *MockTransferIntentRepository) InsertTransferIntentTx(tx *sql.Tx, intent models.TransferIntent) (*models.TransferIntent, error) {
+	args := m.Called(tx, intent)
+	inserted, _ := args.Get(0).(*models.TransferIntent)
+	return inserted, args.Error(1)
+}
+
+func (m *MockTransferIntentRepository) CompleteTransferIntentTx(tx *sql.Tx, id int64, at time.Time) error {
+	return m.Called(tx, id, at).Error(0)
+}
+
+func (m *MockTransferIntentRepository) CompleteTransferIntent(id int64, at time.Time) error {
+	return m.Called(id, at).Error(0)
+}
+
+func (m *MockTransferIntentRepository) InsertTransferIntentCreditTx(tx *sql.Tx, id, campaignID int64, transactionID string) error {
+	return m.Called(tx, id, campaignID, transactionID).Error(0)
+}
+
+func (m *MockTransferIntentRepository) ListPendingTransferIntents(createdBefore time.Time, afterID int64, limit int) ([]models.TransferIntent, error) {
+	args := m.Called(createdBefore, afterID, limit)
+	intents, _ := args.Get(0).([]models.TransferIntent)
+	return intents, args.Error(1)
+}
+
+func TestTransferIntents(t *testing.T) {
+	now := time.Date(2026, 6, 15, 12, 0, 0, 0, time.UTC)
+	rule := &models.TopUpRule{AccountID: 1, Threshold: 100, Amount: 500, FundingAccountID: 2}
+	topUp := func(mtr *MockTransactionRepository) {
+		mtr.On("GetAccountBalanceTx", mock.Anything, int64(2)).Return(1000.0, nil).Once()
+		mtr.On("AccountExistsTx", mock.Anything, int64(1)).Return(true, nil).Once()
+		mtr.On("UpdateBalanceTx", mock.Anything, int64(2), -500.0).Return(nil).Once()
+		mtr.On("UpdateBalanceTx", mock.Anything, int64(1), 500.0).Return(nil).Once()
+		mtr.On("InsertTransactionLogsTx", mock.Anything, []repository.TransactionLog{
+			{SourceID: 2, DestID: 1, Amount: 500, Kind: models.TransactionTopUp},
+		}).Return([]string{"8"}, nil).Once()
+		mtr.On("GetAccountBalanceTx", mock.Anything, int64(1)).Return(550.0, nil).Once()
+	}
+
+	t.Run("Top-up recorded with the transfer", func(t *testing.T) {
+		db, mockDB := newMockDB(t)
+		accountRepo := new(MockAccountRepository)
+		transactionRepo := new(MockTransactionRepository)
+		topUpRepo := new(MockTopUpRepository)
+		intentRepo := new(MockTransferIntentRepository)
+		svc := service.NewService(db, accountRepo, transactionRepo,
+			service.WithTopUps(topUpRepo),
+			service.WithTransferIntents(intentRepo),
+			service.WithClock(clock.NewFake(now)))
+
+		transactionRepo.On("GetAccountBalanceTx", mock.Anything, int64(1)).Return(200.0, nil).Once()
+		transactionRepo.On("AccountExistsTx", mock.Anything, int64(3)).Return(true, nil).Once()
+		transactionRepo.On("UpdateBalanceTx", mock.Anything, int64(1), -150.0).Return(nil).Once()
+		transactionRepo.On("UpdateBalanceTx", mock.Anything, int64(3), 150.0).Return(nil).Once()
+		transactionRepo.On("InsertTransactionLogTx", mock.Anything, int64(1), int64(3), 150.0).Return("7", nil).Once()
+		topUpRepo.On("GetTopUpRule", int64(1)).Return(rule, nil).Twice()
+		transactionRepo.On("GetAccountBalanceTx", mock.Anything, int64(1)).Return(50.0, nil).Once()
+		intent := models.TransferIntent{Kind: models.TransferIntentTopUp, AccountID: 1, TransactionID: "7", Amount: 500, CreatedAt: now}
+		recorded := intent
+		recorded.ID = 11
+		intentRepo.On("InsertTransferIntentTx", mock.Anything, intent).Return(&recorded, nil).Once()
+		accountRepo.On("GetAccount", int64(1), false).Return(&models.Account{AccountID: 1, Balance: 50}, nil).Once()
+		topUp(transactionRepo)
+		intentRepo.On("CompleteTransferIntentTx", mock.Anything, int64(11), now).Return(nil).Once()
+		mockDB.ExpectBegin()
+		mockDB.ExpectCommit()
+		mockDB.ExpectBegin()
+		mockDB.ExpectCommit()
+
+		transactionID, err := svc.CreateTransaction(context.Background(), 1, 3, 150, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "7", transactionID)
+		transactionRepo.AssertExpectations(t)
+		intentRepo.AssertExpectations(t)
+		assert.NoError(t, mockDB.ExpectationsWereMet())
+	})
+
+	t.Run("Resumed after a crash", func(t *testing.T) {
+		db, mockDB := newMockDB(t)
+		accountRepo := new(MockAccountRepository)
+		transactionRepo := new(MockTransactionRepository)
+		topUpRepo := new(MockTopUpRepository)
+		cashbackRepo := new(MockCashbackRepository)
+		intentRepo := new(MockTransferIntentRepository)
+		svc := service.NewService(db, accountRepo, transactionRepo,
+			service.WithTopUps(topUpRepo),
+			service.WithCashback(cashbackRepo),
+			service.WithTransferIntents(intentRepo),
+			service.WithClock(clock.NewFake(now)))
+
+		recordedAt := now.Add(-time.Hour)
+		before := now.Add(-time.Minute)
+		intentRepo.On("ListPendingTransferIntents", before, int64(0), 100).Return([]models.TransferIntent{
+			{ID: 11, Kind: models.TransferIntentTopUp, AccountID: 1, TransactionID: "7", Amount: 500, CreatedAt: recordedAt},
+			{ID: 12, Kind: models.TransferIntentCashback, AccountID: 1, TransactionID: "7", Amount: 150, CreatedAt: recordedAt},
+		}, nil).Once()
+
+		// The top-up is made again, but a concurrent run completed the intent
+		// first, so it rolls back.
+		topUpRepo.On("GetTopUpRule", int64(1)).Return(rule, nil).Once()
+		accountRepo.On("GetAccount", int64(1), false).Return(&models.Account{AccountID: 1, Balance: 50}, nil).Once()
+		topUp(transactionRepo)
+		intentRepo.On("CompleteTransferIntentTx", mock.Anything, int64(11), now).
+			Return(fmt.Errorf("transfer intent 11 %w", repository.ErrAlreadyCompleted)).Once()
+		mockDB.ExpectBegin()
+		mockDB.ExpectRollback()
+
+		// The cashback is looked up as of the transfer; the campaign paid it
+		// before the crash, so it is not paid twice.
+		cashbackRepo.On("ListActiveCashbackCampaigns", int64(1), recordedAt).Return([]models.CashbackCampaign{
+			{ID: 1, Percentage: 1.5, Cap: 10, FundingAccountID: 9},
+		}, nil).Once()
+		transactionRepo.On("GetAccountBalanceTx", mock.Anything, int64(9)).Return(1000.0, nil).Once()
+		transactionRepo.On("AccountExistsTx", mock.Anything, int64(1)).Return(true, nil).Once()
+		transactionRepo.On("UpdateBalanceTx", mock.Anything, int64(9), -2.25).Return(nil).Once()
+		transactionRepo.On("UpdateBalanceTx", mock.Anything, int64(1), 2.25).Return(nil).Once()
+		transactionRepo.On("InsertTransactionLogsTx", mock.Anything, []repository.TransactionLog{
+			{SourceID: 9, DestID: 1, Amount: 2.25, Kind: models.TransactionCashback, Rounding: &money.Rounding{Mode: money.HalfUp, MinorUnits: 2, Unrounded: 2.25}},
+		}).Return([]string{"9"}, nil).Once()
+		intentRepo.On("InsertTransferIntentCreditTx", mock.Anything, int64(12), int64(1), "9").
+			Return(fmt.Errorf("cashback of campaign 1 for transfer intent 12 %w", repository.ErrAlreadyCompleted)).Once()
+		intentRepo.On("CompleteTransferIntent", int64(12), now).Return(nil).Once()
+		mockDB.ExpectBegin()
+		mockDB.ExpectRollback()
+
+		resumed, err := svc.ResumeTransferIntents(before)
+		require.NoError(t, err)
+		assert.Equal(t, 2, resumed)
+		transactionRepo.AssertExpectations(t)
+		cashbackRepo.AssertExpectations(t)
+		intentRepo.AssertExpectations(t)
+		assert.NoError(t, mockDB.ExpectationsWereMet())
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		svc := service.NewService(nil, new(MockAccountRepository), new(MockTransactionRepository))
+		_, err := svc.ResumeTransferIntents(now)
+		assert.Error(t, err)
+	})
+}
+
+func TestCreateTransactionBatch(t *testing.T) {
+	transfers := []models.TransactionRequest{
+		{SourceAccountID: 1, DestinationAccountID: 2, Amount: 100},
+		// Spends what the transfer before paid into account 2.
+		{SourceAccountID: 2, DestinationAccountID: 3, Amount: 150},
+		{SourceAccountID: 1, DestinationAccountID: 3, Amount: 50},
+	}
+	lock := func(mtr *MockTransactionRepository, balance2 float64) {
+		mtr.On("GetAccountBalanceTx", mock.Anything, int64(1)).Return(200.0, nil).Once()
+		mtr.On("GetAccountBalanceTx", mock.Anything, int64(2)).Return(balance2, nil).Once()
+		mtr.On("GetAccountBalanceTx", mock.Anything, int64(3)).Return(0.0, nil).Once()
+	}
+
+	t.Run("Atomic", func(t *testing.T) {
+		db, mockDB := newMockDB(t)
+		transactionRepo := new(MockTransactionRepository)
+		svc := service.NewService(db, new(MockAccountRepository), transactionRepo)
+
+		mockDB.ExpectBegin()
+		lock(transactionRepo, 60)
+		transactionRepo.On("UpdateBalanceTx", mock.Anything, int64(1), -150.0).Return(nil).Once()
+		transactionRepo.On("UpdateBalanceTx", mock.Anything, int64(2), -50.0).Return(nil).Once()
+		transactionRepo.On("UpdateBalanceTx", mock.Anything, int64(3), 200.0).Return(nil).Once()
+		transactionRepo.On("InsertTransactionLogsTx", mock.Anything, []repository.TransactionLog{
+			{SourceID: 1, DestID: 2, Amount: 100, Kind: models.TransactionTransfer},
+			{SourceID: 2, DestID: 3, Amount: 150, Kind: models.TransactionTransfer},
+			{SourceID: 1, DestID: 3, Amount: 50, Kind: models.TransactionTransfer},
+		}).Return([]string{"11", "12", "13"}, nil).Once()
+		mockDB.ExpectCommit()
+
+		result, err := svc.CreateTransactionBatch(context.Background(), models.TransactionBatchRequest{Atomic: true, Transfers: transfers})
+		require.NoError(t, err)
+		assert.Equal(t, &models.TransactionBatchResult{Atomic: true, Created: 3, Results: []models.BatchTransferResult{
+			{Transfer: 0, Status: models.BatchTransferCreated, TransactionID: "11"},
+			{Transfer: 1, Status: models.BatchTransferCreated, TransactionID: "12"},
+			{Transfer: 2, Status: models.BatchTransferCreated, TransactionID: "13"},
+		}}, result)
+		transactionRepo.AssertExpectations(t)
+		assert.NoError(t, mockDB.ExpectationsWereMet())
+	})
+
+	t.Run("Atomic rejected", func(t *testing.T) {
+		db, mockDB := newMockDB(t)
+		transactionRepo := new(MockTransactionRepository)
+		svc := service.NewService(db, new(MockAccountRepository), transactionRepo)
+
+		mockDB.ExpectBegin()
+		lock(transactionRepo, 40)
+		mockDB.ExpectRollback()
+
+		_, err := svc.CreateTransactionBatch(context.Background(), models.TransactionBatchRequest{Atomic: true, Transfers: append(slices.Clone(transfers),
+			models.TransactionRequest{SourceAccountID: 3, DestinationAccountID: 3, Amount: 5})})
+		var rejected *service.BatchRejectedError
+		require.ErrorAs(t, err, &rejected)
+		assert.Equal(t, models.ReasonInsufficientFunds, rejected.Code())
+		assert.Equal(t, 2, rejected.Result.Failed)
+		var statuses []models.BatchTransferStatus
+		for _, r := range rejected.Result.Results {
+			statuses = append(statuses, r.Status)
+		}
+		assert.Equal(t, []models.BatchTransferStatus{models.BatchTransferSkipped, models.BatchTransferFailed, models.BatchTransferSkipped, models.BatchTransferFailed}, statuses)
+		assert.Equal(t, models.ReasonSameAccount, rejected.Result.Results[3].Code)
+		transactionRepo.AssertNotCalled(t, "UpdateBalanceTx", mock.Anything, mock.Anything, mock.Anything)
+		assert.NoError(t, mockDB.ExpectationsWereMet())
+	})
+
+	t.Run("Independent", func(t *testing.T) {
+		db, mockDB := newMockDB(t)
+		transactionRepo := new(MockTransactionRepository)
+		svc := service.NewService(db, new(MockAccountRepository), transactionRepo)
+
+		mockDB.ExpectBegin()
+		transactionRepo.On("GetAccountBalanceTx", mock.Anything, int64(1)).Return(100.0, nil).Once()
+		transactionRepo.On("AccountExistsTx", mock.Anything, int64(2)).Return(true, nil).Once()
+		transactionRepo.On("UpdateBalanceTx", mock.Anything, int64(1), -10.0).Return(nil).Once()
+		transactionRepo.On("UpdateBalanceTx", mock.Anything, int64(2), 10.0).Return(nil).Once()
+		transactionRepo.On("InsertTransactionLogTx", mock.Anything, int64(1), int64(2), 10.0).Return("7", nil).Once()
+		mockDB.ExpectCommit()
+		mockDB.ExpectBegin()
+		transactionRepo.On("GetAccountBalanceTx", mock.Anything, int64(1)).Return(90.0, nil).Once()
+		transactionRepo.On("AccountExistsTx", mock.Anything, int64(404)).Return(false, nil).Once()
+		mockDB.ExpectRollback()
+
+		result, err := svc.CreateTransactionBatch(context.Background(), models.TransactionBatchRequest{Transfers: []models.TransactionRequest{
+			{SourceAccountID: 1, DestinationAccountID: 2, Amount: 10},
+			{SourceAccountID: 1, DestinationAccountID: 1, Amount: 10},
+			{SourceAccountID: 1, DestinationAccountID: 404, Amount: 10},
+		}})
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.Created)
+		assert.Equal(t, 2, result.Failed)
+		assert.Equal(t, models.BatchTransferResult{Transfer: 0, Status: models.BatchTransferCreated, TransactionID: "7"}, result.Results[0])
+		assert.Equal(t, models.ReasonSameAccount, result.Results[1].Code)
+		assert.Equal(t, models.ReasonDestinationNotFound, result.Results[2].Code)
+		transactionRepo.AssertExpectations(t)
+		assert.NoError(t, mockDB.ExpectationsWereMet())
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		svc := service.NewService(nil, new(MockAccountRepository), new(MockTransactionRepository), service.WithTransactionTags(new(MockTransactionTagRepository)))
+
+		_, err := svc.CreateTransactionBatch(context.Background(), models.TransactionBatchRequest{})
+		assert.ErrorIs(t, err, service.ErrInvalidBatch)
+		_, err = svc.CreateTransactionBatch(context.Background(), models.TransactionBatchRequest{Transfers: []models.TransactionRequest{
+			{SourceAccountID: 1, DestinationAccountID: 2, Amount: 10, Tags: []string{"Not a tag"}},
+		}})
+		assert.ErrorIs(t, err, service.ErrInvalidTags)
+	})
+}
+
+type MockForwardedTransferRepository struct {
+	mock.Mock
+}
+
+func (m *MockForwardedTransferRepository) InsertForwardedTransferTx(tx *sql.Tx, s models.ForwardedTransferStatus) error {
+	return m.Called(tx, s).Error(0)
+}
+
+func (m *MockForwardedTransferRepository) InsertForwardedTransfer(s models.ForwardedTransferStatus) error {
+	return m.Called(s).Error(0)
+}
+
+func (m *MockForwardedTransferRepository) GetForwardedTransfer(id string) (*models.ForwardedTransferStatus, error) {
+	args := m.Called(id)
+	status, _ := args.Get(0).(*models.ForwardedTransferStatus)
+	return status, args.Error(1)
+}
+
+func (m *MockForwardedTransferRepository) FindForwardedTransfer(apiKey, idempotencyKey string) (*models.ForwardedTransferStatus, error) {
+	args := m.Called(apiKey, idempotencyKey)
+	status, _ := args.Get(0).(*models.ForwardedTransferStatus)
+	return status, args.Error(1)
+}
+
+func TestForwardedTransfers(t *testing.T) {
+	now := time.Date(2026, 6, 15, 12, 0, 0, 0, time.UTC)
+	acceptedAt := now.Add(-time.Minute)
+	forwarded := models.ForwardedTransfer{
+		ID:         "01J9ZQ4Y6M8R2T5V7X9B1D3F5H",
+		Request:    models.TransactionRequest{SourceAccountID: 1, DestinationAccountID: 3, Amount: 150},
+		AcceptedAt: acceptedAt,
+		APIKey:     "key_1",
+		RequestID:  "req_1",
+	}
+	notFound := fmt.Errorf("forwarded transfer %s %w", forwarded.ID, repository.ErrNotFound)
+	completed := &models.ForwardedTransferStatus{ID: forwarded.ID, Status: models.ForwardCompleted, AcceptedAt: acceptedAt, ReplayedAt: &now, TransactionID: "7", APIKey: "key_1"}
+
+	t.Run("Completed", func(t *testing.T) {
+		db, mockDB := newMockDB(t)
+		transactionRepo := new(MockTransactionRepository)
+		forwardRepo := new(MockForwardedTransferRepository)
+		svc := service.NewService(db, new(MockAccountRepository), transactionRepo,
+			service.WithForwardedTransfers(forwardRepo),
+			service.WithClock(clock.NewFake(now)))
+
+		forwardRepo.On("GetForwardedTransfer", forwarded.ID).Return(nil, notFound).Once()
+		transactionRepo.On("GetAccountBalanceTx", mock.Anything, int64(1)).Return(200.0, nil).Once()
+		transactionRepo.On("AccountExistsTx", mock.Anything, int64(3)).Return(true, nil).Once()
+		transactionRepo.On("UpdateBalanceTx", mock.Anything, int64(1), -150.0).Return(nil).Once()
+		transactionRepo.On("UpdateBalanceTx", mock.Anything, int64(3), 150.0).Return(nil).Once()
+		transactionRepo.On("InsertTransactionLogTx", mock.Anything, int64(1), int64(3), 150.0).Return("7", nil).Once()
+		forwardRepo.On("InsertForwardedTransferTx", mock.Anything, *completed).Return(nil).Once()
+		mockDB.ExpectBegin()
+		mockDB.ExpectCommit()
+
+		status, err := svc.ReplayForwardedTransfer(forwarded)
+		require.NoError(t, err)
+		assert.Equal(t, completed, status)
+		transactionRepo.AssertExpectations(t)
+		forwardRepo.AssertExpectations(t)
+		assert.NoError(t, mockDB.ExpectationsWereMet())
+	})
+
+	t.Run("Replayed before", func(t *testing.T) {
+		db, mockDB := newMockDB(t)
+		forwardRepo := new(MockForwardedTransferRepository)
+		svc := service.NewService(db, new(MockAccountRepository), new(MockTransactionRepository),
+			service.WithForwardedTransfers(forwardRepo),
+			service.WithClock(clock.NewFake(now)))
+
+		// The server stopped after the transfer committed but before it was
+		// taken off the queue: no funds move again.
+		forwardRepo.On("GetForwardedTransfer", forwarded.ID).Return(completed, nil).Once()
+
+		status, err := svc.ReplayForwardedTransfer(forwarded)
+		require.NoError(t, err)
+		assert.Equal(t, completed, status)
+		forwardRepo.AssertExpectations(t)
+		assert.NoError(t, mockDB.ExpectationsWereMet())
+	})
+
+	t.Run("Retry of a replayed transfer", func(t *testing.T) {
+		db, mockDB := newMockDB(t)
+		forwardRepo := new(MockForwardedTransferRepository)
+		svc := service.NewService(db, new(MockAccountRepository), new(MockTransactionRepository),
+			service.WithForwardedTransfers(forwardRepo),
+			service.WithClock(clock.NewFake(now)))
+
+		// The transfer was accepted again with the Idempotency-Key of one
+		// replayed before, here or by another server: it takes the first's
+		// outcome, recorded without the key, and no funds move.
+		retry := forwarded
+		retry.ID, retry.IdempotencyKey = "01J9ZQ4Y6M8R2T5V7X9B1D3F5J", "retry-1"
+		first := &models.ForwardedTransferStatus{ID: forwarded.ID, Status: models.ForwardCompleted, AcceptedAt: acceptedAt, ReplayedAt: &now, TransactionID: "7", APIKey: "key_1", IdempotencyKey: "retry-1"}
+		retried := models.ForwardedTransferStatus{ID: retry.ID, Status: models.ForwardCompleted, AcceptedAt: acceptedAt, ReplayedAt: &now, TransactionID: "7", APIKey: "key_1"}
+		forwardRepo.On("GetForwardedTransfer", retry.ID).Return(nil, fmt.Errorf("forwarded transfer %s %w", retry.ID, repository.ErrNotFound)).Once()
+		forwardRepo.On("FindForwardedTransfer", "key_1", "retry-1").Return(first, nil).Once()
+		forwardRepo.On("InsertForwardedTransfer", retried).Return(nil).Once()
+
+		status, err := svc.ReplayForwardedTransfer(retry)
+		require.NoError(t, err)
+		assert.Equal(t, &retried, status)
+		forwardRepo.AssertExpectations(t)
+		assert.NoError(t, mockDB.ExpectationsWereMet())
+	})
+
+	t.Run("Rejected", func(t *testing.T) {
+		db, mockDB := newMockDB(t)
+		transactionRepo := new(MockTransactionRepository)
+		forwardRepo := new(MockForwardedTransferRepository)
+		attemptRepo := new(MockTransactionAttemptRepository)
+		svc := service.NewService(db, new(MockAccountRepository), transactionRepo,
+			service.WithForwardedTransfers(forwardRepo),
+			service.WithTransactionAttemptRepository(attemptRepo),
+			service.WithClock(clock.NewFake(now)))
+
+		forwardRepo.On("GetForwardedTransfer", forwarded.ID).Return(nil, notFound).Once()
+		transactionRepo.On("GetAccountBalanceTx", mock.Anything, int64(1)).Return(100.0, nil).Once()
+		forwardRepo.On("InsertForwardedTransfer", mock.MatchedBy(func(s models.ForwardedTransferStatus) bool {
+			return s.Status == models.ForwardRejected && s.Code == models.ReasonInsufficientFunds && s.TransactionID == ""
+		})).Return(nil).Once()
+		attemptRepo.On("InsertTransactionAttempt", mock.MatchedBy(func(a models.TransactionAttempt) bool {
+			return a.Code == models.ReasonInsufficientFunds && a.APIKey == "key_1" && a.RequestID == "req_1"
+		})).Return(nil).Once()
+		mockDB.ExpectBegin()
+		mockDB.ExpectRollback()
+
+		status, err := svc.ReplayForwardedTransfer(forwarded)
+		require.NoError(t, err)
+		assert.Equal(t, models.ForwardRejected, status.Status)
+		assert.Equal(t, models.ReasonInsufficientFunds, status.Code)
+		forwardRepo.AssertExpectations(t)
+		attemptRepo.AssertExpectations(t)
+		assert.NoError(t, mockDB.ExpectationsWereMet())
+	})
+
+	t.Run("Database still unreachable", func(t *testing.T) {
+		db, mockDB := newMockDB(t)
+		forwardRepo := new(MockForwardedTransferRepository)
+		svc := service.NewService(db, new(MockAccountRepository), new(MockTransactionRepository),
+			service.WithForwardedTransfers(forwardRepo),
+			service.WithClock(clock.NewFake(now)))
+
+		forwardRepo.On("GetForwardedTransfer", forwarded.ID).Return(nil, notFound).Once()
+		mockDB.ExpectBegin().WillReturnError(&pq.Error{Code: "57P03"})
+
+		status, err := svc.ReplayForwardedTransfer(forwarded)
+		assert.True(t, repository.IsUnavailable(err), "the transfer stays queued, got %v", err)
+		assert.Nil(t, status)
+		forwardRepo.AssertNotCalled(t, "InsertForwardedTransfer", mock.Anything)
+		assert.NoError(t, mockDB.ExpectationsWereMet())
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		db, _ := newMockDB(t)
+		forwardRepo := new(MockForwardedTransferRepository)
+		svc := service.NewService(db, new(MockAccountRepository), new(MockTransactionRepository),
+			service.WithForwardedTransfers(forwardRepo),
+			service.WithClock(clock.NewFake(now)))
+
+		expired := models.ForwardedTransferStatus{ID: forwarded.ID, Status: models.ForwardExpired, AcceptedAt: acceptedAt, ReplayedAt: &now, APIKey: "key_1"}
+		forwardRepo.On("InsertForwardedTransfer", expired).Return(nil).Once()
+		status, err := svc.ExpireForwardedTransfer(forwarded)
+		require.NoError(t, err)
+		assert.Equal(t, &expired, status)
+
+		// A transfer with an outcome keeps it.
+		forwardRepo.On("InsertForwardedTransfer", expired).Return(fmt.Errorf("forwarded transfer %s %w", forwarded.ID, repository.ErrAlreadyReplayed)).Once()
+		forwardRepo.On("GetForwardedTransfer", forwarded.ID).Return(completed, nil).Once()
+		status, err = svc.ExpireForwardedTransfer(forwarded)
+		require.NoError(t, err)
+		assert.Equal(t, completed, status)
+		forwardRepo.AssertExpectations(t)
+	})
+}
+
+func TestPreviewPayroll(t *testing.T) {
+	db, _ := newMockDB(t)
+	accountRepo := new(MockAccountRepository)
+	svc := service.NewService(db, accountRepo, new(MockTransactionRepository))
+
+	accountRepo.On("GetAccount", int64(1), false).Return(&models.Account{AccountID: 1, Balance: 2000}, nil).Once()
+	accountRepo.On("AccountExists", int64(2)).Return(true, nil).Once()
+	accountRepo.On("AccountExists", int64(9)).Return(false, nil).Once()
+
+	preview, err := svc.PreviewPayroll(context.Background(), models.PayrollRequest{
+		EmployerAccountID: 1,
+		Lines: []models.PayrollLine{
+			{AccountID: 2, Amount: 1000},
+			{AccountID: 2, Amount: 1500.5},
+			{AccountID: 9, Amount: 10},
+			{AccountID: 1, Amount: 10},
+			{AccountID: 3, Amount: -5},
+		},
+	})
+	require.NoError(t, err)
+	assert.False(t, preview.Valid)
+	assert.Equal(t, models.Amount(2520.5), preview.Total)
+	assert.Equal(t, models.Amount(2000), preview.EmployerBalance)
+	assert.Equal(t, models.ReasonInsufficientFunds, preview.Code)
+	assert.Equal(t, []models.PayrollLineError{
+		{Line: 2, AccountID: 9, Code: models.ReasonDestinationNotFound, Error: "account 9 not found"},
+		{Line: 3, AccountID: 1, Code: models.ReasonSameAccount, Error: "the employer account cannot pay itself"},
+		{Line: 4, AccountID: 3, Code: models.ReasonLimitExceeded, Error: "amount must be positive"},
+	}, preview.LineErrors)
+	accountRepo.AssertExpectations(t)
+
+	_, err = svc.PreviewPayroll(context.Background(), models.PayrollRequest{EmployerAccountID: 1})
+	assert.ErrorIs(t, err, service.ErrInvalidPayroll)
+	_, err = svc.PreviewPayroll(context.Background(), models.PayrollRequest{
+		EmployerAccountID: 1,
+		Lines:             make([]models.PayrollLine, service.MaxPayrollLines+1),
+	})
+	assert.ErrorIs(t, err, service.ErrInvalidPayroll)
+}
+
+func TestCommitPayroll(t *testing.T) {
+	req := models.PayrollRequest{
+		EmployerAccountID: 1,
+		Lines: []models.PayrollLine{
+			{AccountID: 3, Amount: 1000},
+			{AccountID: 2, Amount: 1500},
+			{AccountID: 3, Amount: 250.25},
+		},
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		db, mockDB := newMockDB(t)
+		transactionRepo := new(MockTransactionRepository)
+		outboxRepo := new(MockOutboxRepository)
+		svc := service.NewService(db, new(MockAccountRepository), transactionRepo, service.WithOutboxRepository(outboxRepo))
+
+		mockDB.ExpectBegin()
+		transactionRepo.On("GetAccountBalanceTx", mock.Anything, int64(1)).Return(5000.0, nil).Once()
+		transactionRepo.On("AccountExistsTx", mock.Anything, int64(3)).Return(true, nil).Once()
+		transactionRepo.On("AccountExistsTx", mock.Anything, int64(2)).Return(true, nil).Once()
+		transactionRepo.On("UpdateBalanceTx", mock.Anything, int64(1), -2750.25).Return(nil).Once()
+		transactionRepo.On("UpdateBalanceTx", mock.Anything, int64(2), 1500.0).Return(nil).Once()
+		transactionRepo.On("UpdateBalanceTx", mock.Anything, int64(3), 1250.25).Return(nil).Once()
+		transactionRepo.On("InsertTransactionLogsTx", mock.Anything, []repository.TransactionLog{
+			{SourceID: 1, DestID: 3, Amount: 1000, Kind: models.TransactionTransfer},
+			{SourceID: 1, DestID: 2, Amount: 1500, Kind: models.TransactionTransfer},
+			{SourceID: 1, DestID: 3, Amount: 250.25, Kind: models.TransactionTransfer},
+		}).Return([]string{"11", "12", "13"}, nil).Once()
+		outboxRepo.On("InsertEventTx", mock.Anything, mock.Anything).Return(int64(1), nil).Times(3)
+		mockDB.ExpectCommit()
+
+		result, err := svc.CommitPayroll(context.Background(), req)
+		require.NoError(t, err)
+		assert.Equal(t, &models.PayrollResult{EmployerAccountID: 1, Total: 2750.25, TransactionIDs: []string{"11", "12", "13"}}, result)
+		transactionRepo.AssertExpectations(t)
+		outboxRepo.AssertExpectations(t)
+		assert.NoError(t, mockDB.ExpectationsWereMet())
+	})
+
+	t.Run("Rejected", func(t *testing.T) {
+		db, mockDB := newMockDB(t)
+		transactionRepo := new(MockTransactionRepository)
+		svc := service.NewService(db, new(MockAccountRepository), transactionRepo)
+
+		mockDB.ExpectBegin()
+		transactionRepo.On("GetAccountBalanceTx", mock.Anything, int64(1)).Return(5000.0, nil).Once()
+		transactionRepo.On("AccountExistsTx", mock.Anything, int64(3)).Return(true, nil).Once()
+		transactionRepo.On("AccountExistsTx", mock.Anything, int64(2)).Return(false, nil).Once()
+		mockDB.ExpectRollback()
+
+		_, err := svc.CommitPayroll(context.Background(), req)
+		var rejected *service.PayrollRejectedError
+		require.ErrorAs(t, err, &rejected)
+		assert.Equal(t, models.ReasonDestinationNotFound, rejected.Code())
+		assert.Equal(t, []models.PayrollLineError{
+			{Line: 1, AccountID: 2, Code: models.ReasonDestinationNotFound, Error: "account 2 not found"},
+		}, rejected.Preview.LineErrors)
+		transactionRepo.AssertNotCalled(t, "UpdateBalanceTx", mock.Anything, mock.Anything, mock.Anything)
+		assert.NoError(t, mockDB.ExpectationsWereMet())
+	})
+
+	t.Run("Insufficient funds", func(t *testing.T) {
+		db, mockDB := newMockDB(t)
+		transactionRepo := new(MockTransactionRepository)
+		svc := service.NewService(db, new(MockAccountRepository), transactionRepo)
+
+		mockDB.ExpectBegin()
+		transactionRepo.On("GetAccountBalanceTx", mock.Anything, int64(1)).Return(2000.0, nil).Once()
+		transactionRepo.On("AccountExistsTx", mock.Anything, mock.Anything).Return(true, nil).Twice()
+		mockDB.ExpectRollback()
+
+		_, err := svc.CommitPayroll(context.Background(), req)
+		var rejected *service.PayrollRejectedError
+		require.ErrorAs(t, err, &rejected)
+		assert.Equal(t, models.ReasonInsufficientFunds, rejected.Code())
+		assert.Empty(t, rejected.Preview.LineErrors)
+		assert.NoError(t, mockDB.ExpectationsWereMet())
+	})
+}
+
+type MockIngestRepository struct {
+	mock.Mock
+}
+
+func (m *MockIngestRepository) StageTransfersTx(tx *sql.Tx, transfers []models.IngestTransfer) (*repository.StagedTransfers, error) {
+	args := m.Called(tx, transfers)
+	staged, _ := args.Get(0).(*repository.StagedTransfers)
+	return staged, args.Error(1)
+}
+
+func (m *MockIngestRepository) MergeTransfersTx(tx *sql.Tx) ([]string, error) {
+	args := m.Called(tx)
+	ids, _ := args.Get(0).([]string)
+	return ids, args.Error(1)
+}
+
+func TestIngestTransfers(t *testing.T) {
+	transfers := []models.IngestTransfer{
+		{SourceAccountID: 1, DestinationAccountID: 2, Amount: 100, Reference: "imp-1"},
+		{SourceAccountID: 2, DestinationAccountID: 3, Amount: 150},
+		{SourceAccountID: 1, DestinationAccountID: 3, Amount: 20.5, Reference: "imp-3"},
+	}
+	accounts := func(balance1, balance2 float64) map[int64]models.IngestAccount {
+		return map[int64]models.IngestAccount{
+			1: {AccountID: 1, Balance: balance1, Open: true, Livemode: true},
+			2: {AccountID: 2, Balance: balance2, Open: true, Livemode: true},
+			3: {AccountID: 3, Open: true, Livemode: true},
+		}
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		db, mockDB := newMockDB(t)
+		ingestRepo := new(MockIngestRepository)
+		outboxRepo := new(MockOutboxRepository)
+		svc := service.NewService(db, new(MockAccountRepository), new(MockTransactionRepository), service.WithIngestRepository(ingestRepo), service.WithOutboxRepository(outboxRepo))
+
+		mockDB.ExpectBegin()
+		// Account 2 pays out more than it holds, but not more than it is paid.
+		ingestRepo.On("StageTransfersTx", mock.Anything, transfers).Return(&repository.StagedTransfers{Skipped: []int{2}, Accounts: accounts(100, 50)}, nil).Once()
+		ingestRepo.On("MergeTransfersTx", mock.Anything).Return([]string{"imp-1", "41"}, nil).Once()
+		outboxRepo.On("InsertEventTx", mock.Anything, mock.Anything).Return(int64(1), nil).Twice()
+		mockDB.ExpectCommit()
+
+		result, err := svc.IngestTransfers(transfers)
+		require.NoError(t, err)
+		assert.Equal(t, &models.IngestResult{Ingested: 2, Skipped: 1, TransactionIDs: []string{"imp-1", "41", "imp-3"}}, result)
+		ingestRepo.AssertExpectations(t)
+		outboxRepo.AssertExpectations(t)
+		assert.NoError(t, mockDB.ExpectationsWereMet())
+	})
+
+	t.Run("Rejected", func(t *testing.T) {
+		db, mockDB := newMockDB(t)
+		ingestRepo := new(MockIngestRepository)
+		svc := service.NewService(db, new(MockAccountRepository), new(MockTransactionRepository), service.WithIngestRepository(ingestRepo))
+
+		staged := accounts(90, 10)
+		delete(staged, 3)
+		mockDB.ExpectBegin()
+		ingestRepo.On("StageTransfersTx", mock.Anything, transfers).Return(&repository.StagedTransfers{Accounts: staged}, nil).Once()
+		mockDB.ExpectRollback()
+
+		_, err := svc.IngestTransfers(transfers)
+		var rejected *service.IngestRejectedError
+		require.ErrorAs(t, err, &rejected)
+		assert.Equal(t, models.ReasonInsufficientFunds, rejected.Code())
+		assert.Equal(t, []models.IngestLineError{
+			{Line: 0, AccountID: 1, Code: models.ReasonInsufficientFunds, Error: "the transfers from account 1 exceed its available balance 90.00 by 10.00"},
+			{Line: 1, AccountID: 3, Code: models.ReasonDestinationNotFound, Error: "account 3 not found"},
+			{Line: 2, AccountID: 3, Code: models.ReasonDestinationNotFound, Error: "account 3 not found"},
+		}, rejected.Errors)
+		ingestRepo.AssertNotCalled(t, "MergeTransfersTx", mock.Anything)
+		assert.NoError(t, mockDB.ExpectationsWereMet())
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		svc := service.NewService(nil, new(MockAccountRepository), new(MockTransactionRepository), service.WithIngestRepository(new(MockIngestRepository)))
+
+		_, err := svc.IngestTransfers(nil)
+		assert.ErrorIs(t, err, service.ErrInvalidIngest)
+		_, err = svc.IngestTransfers([]models.IngestTransfer{
+			{SourceAccountID: 1, DestinationAccountID: 2, Amount: 1, Reference: "a"},
+			{SourceAccountID: 1, DestinationAccountID: 3, Amount: 1, Reference: "a"},
+		})
+		assert.ErrorIs(t, err, service.ErrInvalidIngest)
+
+		_, err = svc.IngestTransfers([]models.IngestTransfer{
+			{SourceAccountID: 1, DestinationAccountID: 1, Amount: 1},
+			{SourceAccountID: 1, DestinationAccountID: 2, Amount: 0},
+		})
+		var rejected *service.IngestRejectedError
+		require.ErrorAs(t, err, &rejected)
+		assert.Equal(t, []models.IngestLineError{
+			{Line: 0, AccountID: 1, Code: models.ReasonSameAccount, Error: "an account cannot pay itself"},
+			{Line: 1, AccountID: 1, Code: models.ReasonLimitExceeded, Error: "amount must be positive"},
+		}, rejected.Errors)
+	})
+}
+
+type MockReimbursementRepository struct {
+	mock.Mock
+}
+
+func (m *MockReimbursementRepository) InsertReimbursementTx(tx *sql.Tx, r models.Reimbursement) (*models.Reimbursement, error) {
+	args := m.Called(tx, r)
+	reimbursement, _ := args.Get(0).(*models.Reimbursement)
+	return reimbursement, args.Error(1)
+}
+
+func (m *MockReimbursementRepository) GetReimbursement(id int64, tenant string) (*models.Reimbursement, error) {
+	args := m.Called(id, tenant)
+	reimbursement, _ := args.Get(0).(*models.Reimbursement)
+	return reimbursement, args.Error(1)
+}
+
+func (m *MockReimbursementRepository) DecideReimbursementTx(tx *sql.Tx, id int64, status models.ReimbursementStatus, d models.ReimbursementDecision, transactionID string) (*models.Reimbursement, error) {
+	args := m.Called(tx, id, status, d, transactionID)
+	reimbursement, _ := args.Get(0).(*models.Reimbursement)
+	return reimbursement, args.Error(1)
+}
+
+func TestReimbursement(t *testing.T) {
+	now := time.Date(2026, 3, 14, 9, 30, 0, 0, time.UTC)
+	db, mockDB := newMockDB(t)
+	accountRepo := new(MockAccountRepository)
+	transactionRepo := new(MockTransactionRepository)
+	reimbursementRepo := new(MockReimbursementRepository)
+	pendingRepo := new(MockPendingActionRepository)
+	outboxRepo := new(MockOutboxRepository)
+	webhookRepo := new(MockWebhookRepository)
+	client := new(MockWebhookClient)
+	svc := service.NewService(db, accountRepo, transactionRepo,
+		service.WithReimbursements(99, reimbursementRepo),
+		service.WithPendingActionRepository(pendingRepo),
+		service.WithOutboxRepository(outboxRepo),
+		service.WithWebhooks(webhookRepo, client),
+		service.WithClock(clock.NewFake(now)))
+
+	statusEvent := func(status models.ReimbursementStatus) interface{} {
+		return mock.MatchedBy(func(e models.Event) bool {
+			return e.Type == models.EventReimbursementStatusChanged && e.AggregateID == "7" &&
+				strings.Contains(string(e.Payload), `"status":"`+string(status)+`"`)
+		})
+	}
+	ownAccount, otherAccount := int64(42), int64(43)
+	hooks := []models.Webhook{
+		{ID: 1, Tenant: "payroll", URL: "https://example.com/all", EventTypes: []string{}},
+		{ID: 2, Tenant: "payroll", URL: "https://example.com/transfers", EventTypes: []string{models.EventTransferCompleted}},
+		{ID: 3, Tenant: "payroll", URL: "https://example.com/42", AccountID: &ownAccount, EventTypes: []string{}},
+		{ID: 4, Tenant: "payroll", URL: "https://example.com/43", AccountID: &otherAccount, EventTypes: []string{}},
+	}
+
+	// Submitting records the claim with its event and queues it for approval.
+	req := models.ReimbursementRequest{AccountID: 42, Amount: 89.5, Description: "Train to client site", Receipt: models.Receipt{Merchant: "Rail Co", Date: "2026-03-12"}}
+	accountRepo.On("AccountExists", int64(42)).Return(true, nil).Once()
+	pending := &models.Reimbursement{ID: 7, Tenant: "payroll", AccountID: 42, Amount: 89.5, Description: "Train to client site", Receipt: req.Receipt, Status: models.ReimbursementPending}
+	mockDB.ExpectBegin()
+	reimbursementRepo.On("InsertReimbursementTx", mock.Anything, models.Reimbursement{
+		Tenant: "payroll", AccountID: 42, Amount: 89.5, Description: "Train to client site", Receipt: req.Receipt,
+	}).Return(pending, nil).Once()
+	outboxRepo.On("InsertEventTx", mock.Anything, statusEvent(models.ReimbursementPending)).Return(int64(11), nil).Once()
+	mockDB.ExpectCommit()
+	pendingRepo.On("AddPendingAction", models.PendingActionReimbursement, "7", "89.50 for account 42: Train to client site").Return(&models.PendingAction{}, nil).Once()
+	webhookRepo.On("ListWebhooks", "payroll").Return(hooks, nil).Once()
+	client.On("Deliver", hooks[0], mock.MatchedBy(func(e models.Event) bool { return e.ID == 11 })).Return(nil).Once()
+	client.On("Deliver", hooks[2], mock.MatchedBy(func(e models.Event) bool { return e.ID == 11 })).Return(nil).Once()
+
+	reimbursement, err := svc.SubmitReimbursement(context.Background(), "payroll", req)
+	require.NoError(t, err)
+	assert.Equal(t, pending, reimbursement)
+
+	// Approving pays it from the reimbursement account in the same transaction.
+	decision := models.ReimbursementDecision{DecidedBy: "alice"}
+	reimbursementRepo.On("GetReimbursement", int64(7), "").Return(pending, nil).Once()
+	mockDB.ExpectBegin()
+	transactionRepo.On("GetAccountBalanceTx", mock.Anything, int64(99)).Return(1000.0, nil).Once()
+	transactionRepo.On("AccountExistsTx", mock.Anything, int64(42)).Return(true, nil).Once()
+	transactionRepo.On("UpdateBalanceTx", mock.Anything, int64(99), -89.5).Return(nil).Once()
+	transactionRepo.On("UpdateBalanceTx", mock.Anything, int64(42), 89.5).Return(nil).Once()
+	transactionRepo.On("InsertTransactionLogTx", mock.Anything, int64(99), int64(42), 89.5).Return("tx-1", nil).Once()
+	outboxRepo.On("InsertEventTx", mock.Anything, mock.MatchedBy(func(e models.Event) bool {
+		return e.Type == models.EventTransferCompleted
+	})).Return(int64(12), nil).Once()
+	approved := *pending
+	approved.Status, approved.DecidedBy, approved.TransactionID = models.ReimbursementApproved, "alice", "tx-1"
+	reimbursementRepo.On("DecideReimbursementTx", mock.Anything, int64(7), models.ReimbursementApproved, decision, "tx-1").Return(&approved, nil).Once()
+	outboxRepo.On("InsertEventTx", mock.Anything, statusEvent(models.ReimbursementApproved)).Return(int64(13), nil).Once()
+	mockDB.ExpectCommit()
+	pendingRepo.On("ResolvePendingAction", models.PendingActionReimbursement, "7").Return(nil).Once()
+	webhookRepo.On("ListWebhooks", "payroll").Return(hooks, nil).Once()
+	client.On("Deliver", hooks[0], mock.MatchedBy(func(e models.Event) bool { return e.ID == 13 })).Return(errors.New("timeout")).Once()
+	client.On("Deliver", hooks[2], mock.MatchedBy(func(e models.Event) bool { return e.ID == 13 })).Return(nil).Once()
+
+	reimbursement, err = svc.ApproveReimbursement(7, decision)
+	require.NoError(t, err, "a failed webhook delivery does not fail the approval")
+	assert.Equal(t, &approved, reimbursement)
+
+	// A decided reimbursement cannot be decided again.
+	reimbursementRepo.On("GetReimbursement", int64(7), "").Return(&approved, nil).Once()
+	_, err = svc.RejectReimbursement(7, decision)
+	assert.ErrorIs(t, err, repository.ErrAlreadyResolved)
+
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+	accountRepo.AssertExpectations(t)
+	transactionRepo.AssertExpectations(t)
+	reimbursementRepo.AssertExpectations(t)
+	pendingRepo.AssertExpectations(t)
+	outboxRepo.AssertExpectations(t)
+	client.AssertExpectations(t)
+}
+
+func TestSubmitReimbursement_WebhookWorkers(t *testing.T) {
+	db, mockDB := newMockDB(t)
+	accountRepo := new(MockAccountRepository)
+	reimbursementRepo := new(MockReimbursementRepository)
+	outboxRepo := new(MockOutboxRepository)
+	webhookRepo := new(MockWebhookRepository)
+	client := new(MockWebhookClient)
+	workers := workerpool.New(context.Background(), "test_webhooks", 1, 10, log.New(io.Discard, "", 0))
+	svc := service.NewService(db, accountRepo, new(MockTransactionRepository),
+		service.WithReimbursements(99, reimbursementRepo),
+		service.WithOutboxRepository(outboxRepo),
+		service.WithWebhooks(webhookRepo, client),
+		service.WithWebhookWorkers(workers))
+
+	req := models.ReimbursementRequest{AccountID: 42, Amount: 89.5, Description: "Train to client site"}
+	pending := &models.Reimbursement{ID: 7, Tenant: "payroll", AccountID: 42, Amount: 89.5, Description: "Train to client site", Status: models.ReimbursementPending}
+	accountRepo.On("AccountExists", int64(42)).Return(true, nil).Once()
+	mockDB.ExpectBegin()
+	reimbursementRepo.On("InsertReimbursementTx", mock.Anything, mock.Anything).Return(pending, nil).Once()
+	outboxRepo.On("InsertEventTx", mock.Anything, mock.Anything).Return(int64(11), nil).Once()
+	mockDB.ExpectCommit()
+	hooks := []models.Webhook{{ID: 1, Tenant: "payroll", URL: "https://example.com/a"}, {ID: 2, Tenant: "payroll", URL: "https://example.com/b"}}
+	webhookRepo.On("ListWebhooks", "payroll").Return(hooks, nil).Once()
+	client.On("Deliver", hooks[0], mock.Anything).Return(nil).Once()
+	client.On("Deliver", hooks[1], mock.Anything).Return(errors.New("timeout")).Once()
+
+	_, err := svc.SubmitReimbursement(context.Background(), "payroll", req)
+	require.NoError(t, err)
+	// The deliveries are made by the workers, and draining them waits for both.
+	require.NoError(t, workers.Drain(context.Background()))
+
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+	client.AssertExpectations(t)
+}
+
+func TestRejectReimbursement(t *testing.T) {
+	db, mockDB := newMockDB(t)
+	reimbursementRepo := new(MockReimbursementRepository)
+	transactionRepo := new(MockTransactionRepository)
+	svc := service.NewService(db, new(MockAccountRepository), transactionRepo, service.WithReimbursements(99, reimbursementRepo))
+
+	_, err := svc.RejectReimbursement(7, models.ReimbursementDecision{})
+	assert.ErrorIs(t, err, service.ErrMissingDecider)
+
+	decision := models.ReimbursementDecision{DecidedBy: "alice", Note: "duplicate of #6"}
+	pending := &models.Reimbursement{ID: 7, Tenant: "payroll", AccountID: 42, Amount: 89.5, Status: models.ReimbursementPending}
+	rejected := *pending
+	rejected.Status, rejected.DecidedBy, rejected.DecisionNote = models.ReimbursementRejected, "alice", "duplicate of #6"
+	reimbursementRepo.On("GetReimbursement", int64(7), "").Return(pending, nil).Once()
+	mockDB.ExpectBegin()
+	reimbursementRepo.On("DecideReimbursementTx", mock.Anything, int64(7), models.ReimbursementRejected, decision, "").Return(&rejected, nil).Once()
+	mockDB.ExpectCommit()
+
+	reimbursement, err := svc.RejectReimbursement(7, decision)
+	require.NoError(t, err)
+	assert.Equal(t, &rejected, reimbursement)
+	transactionRepo.AssertNotCalled(t, "UpdateBalanceTx", mock.Anything, mock.Anything, mock.Anything)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestSubmitReimbursement_Invalid(t *testing.T) {
+	accountRepo := new(MockAccountRepository)
+	svc := service.NewService(nil, accountRepo, new(MockTransactionRepository), service.WithReimbursements(99, new(MockReimbursementRepository)))
+
+	for name, req := range map[string]models.ReimbursementRequest{
+		"zero amount":           {AccountID: 42, Description: "Taxi"},
+		"no description":        {AccountID: 42, Amount: 10},
+		"reimbursement account": {AccountID: 99, Amount: 10, Description: "Taxi"},
+		"receipt date":          {AccountID: 42, Amount: 10, Description: "Taxi", Receipt: models.Receipt{Date: "12/03/2026"}},
+	} {
+		_, err := svc.SubmitReimbursement(context.Background(), "payroll", req)
+		assert.ErrorIs(t, err, service.ErrInvalidReimbursement, name)
+	}
+
+	accountRepo.On("AccountExists", int64(404)).Return(false, nil).Once()
+	_, err := svc.SubmitReimbursement(context.Background(), "payroll", models.ReimbursementRequest{AccountID: 404, Amount: 10, Description: "Taxi"})
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	svc = service.NewService(nil, accountRepo, new(MockTransactionRepository), service.WithReimbursements(99, func() *MockReimbursementRepository {
+		r := new(MockReimbursementRepository)
+		r.On("GetReimbursement", int64(7), "other").Return(&models.Reimbursement{ID: 7, Tenant: "payroll"}, nil)
+		return r
+	}()))
+	_, err = svc.GetReimbursement(context.Background(), 7, "other")
+	assert.ErrorIs(t, err, repository.ErrNotFound, "another tenant's reimbursement is not found")
+}
+
+type MockSpendingTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockSpendingTokenRepository) InsertSpendingToken(t models.SpendingToken) (*models.SpendingToken, error) {
+	args := m.Called(t)
+	token, _ := args.Get(0).(*models.SpendingToken)
+	return token, args.Error(1)
+}
+
+func (m *MockSpendingTokenRepository) GetSpendingToken(id string) (*models.SpendingToken, error) {
+	args := m.Called(id)
+	token, _ := args.Get(0).(*models.SpendingToken)
+	return token, args.Error(1)
+}
+
+func (m *MockSpendingTokenRepository) ListSpendingTokens(accountID int64) ([]models.SpendingToken, error) {
+	args := m.Called(accountID)
+	tokens, _ := args.Get(0).([]models.SpendingToken)
+	return tokens, args.Error(1)
+}
+
+func (m *MockSpendingTokenRepository) RevokeSpendingToken(id string) (*models.SpendingToken, error) {
+	args := m.Called(id)
+	token, _ := args.Get(0).(*models.SpendingToken)
+	return token, args.Error(1)
+}
+
+func (m *MockSpendingTokenRepository) GetSpendingTokenForUpdateTx(tx *sql.Tx, id string) (*models.SpendingToken, error) {
+	args := m.Called(tx, id)
+	token, _ := args.Get(0).(*models.SpendingToken)
+	return token, args.Error(1)
+}
+
+func (m *MockSpendingTokenRepository) AddSpendingTokenSpentTx(tx *sql.Tx, id string, amount float64) (*models.SpendingToken, error) {
+	args := m.Called(tx, id, amount)
+	token, _ := args.Get(0).(*models.SpendingToken)
+	return token, args.Error(1)
+}
+
+func TestCreateSpendingToken(t *testing.T) {
+	now := time.Date(2026, 3, 14, 9, 30, 0, 0, time.UTC)
+	accountRepo := new(MockAccountRepository)
+	tokenRepo := new(MockSpendingTokenRepository)
+	svc := service.NewService(nil, accountRepo, new(MockTransactionRepository),
+		service.WithSpendingTokens(tokenRepo),
+		service.WithClock(clock.NewFake(now)))
+
+	past := now.Add(-time.Hour)
+	for name, req := range map[string]models.SpendingTokenRequest{
+		"zero limit":     {},
+		"expired":        {Limit: 100, ExpiresAt: &past},
+		"blank category": {Limit: 100, MerchantCategories: []string{"5812", " "}},
+	} {
+		_, err := svc.CreateSpendingToken(context.Background(), 42, req)
+		assert.ErrorIs(t, err, service.ErrInvalidSpendingToken, name)
+	}
+
+	accountRepo.On("AccountExists", int64(404)).Return(false, nil).Once()
+	_, err := svc.CreateSpendingToken(context.Background(), 404, models.SpendingTokenRequest{Limit: 100})
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	expires := now.Add(30 * 24 * time.Hour)
+	accountRepo.On("AccountExists", int64(42)).Return(true, nil).Once()
+	tokenRepo.On("InsertSpendingToken", mock.MatchedBy(func(t models.SpendingToken) bool {
+		return strings.HasPrefix(t.ID, "tok_") && len(t.ID) == 36 && t.AccountID == 42 && t.Limit == 250 &&
+			t.ExpiresAt.Equal(expires) && slices.Equal(t.MerchantCategories, []string{"5812", "5814"})
+	})).Return(&models.SpendingToken{ID: "tok_1", AccountID: 42, Limit: 250}, nil).Once()
+
+	token, err := svc.CreateSpendingToken(context.Background(), 42, models.SpendingTokenRequest{
+		Limit:              250,
+		ExpiresAt:          &expires,
+		MerchantCategories: []string{"5812", " 5814", "5812"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "tok_1", token.ID)
+	tokenRepo.AssertExpectations(t)
+}
+
+func TestDebitWithToken(t *testing.T) {
+	now := time.Date(2026, 3, 14, 9, 30, 0, 0, time.UTC)
+	expires := now.Add(time.Hour)
+	token := &models.SpendingToken{ID: "tok_1", AccountID: 42, Limit: 100, Spent: 60, MerchantCategories: []string{"5812"}, ExpiresAt: &expires}
+	req := models.TokenDebitRequest{DestinationAccountID: 7, Amount: 25, MerchantCategory: "5812"}
+
+	t.Run("Success", func(t *testing.T) {
+		db, mockDB := newMockDB(t)
+		transactionRepo := new(MockTransactionRepository)
+		tokenRepo := new(MockSpendingTokenRepository)
+		svc := service.NewService(db, new(MockAccountRepository), transactionRepo,
+			service.WithSpendingTokens(tokenRepo),
+			service.WithClock(clock.NewFake(now)))
+
+		spent := *token
+		spent.Spent = 85
+		tokenRepo.On("GetSpendingToken", "tok_1").Return(token, nil).Once()
+		mockDB.ExpectBegin()
+		transactionRepo.On("GetAccountBalanceTx", mock.Anything, int64(42)).Return(500.0, nil).Once()
+		transactionRepo.On("AccountExistsTx", mock.Anything, int64(7)).Return(true, nil).Once()
+		transactionRepo.On("UpdateBalanceTx", mock.Anything, int64(42), -25.0).Return(nil).Once()
+		transactionRepo.On("UpdateBalanceTx", mock.Anything, int64(7), 25.0).Return(nil).Once()
+		transactionRepo.On("InsertTransactionLogTx", mock.Anything, int64(42), int64(7), 25.0).Return("tx-1", nil).Once()
+		tokenRepo.On("GetSpendingTokenForUpdateTx", mock.Anything, "tok_1").Return(token, nil).Once()
+		tokenRepo.On("AddSpendingTokenSpentTx", mock.Anything, "tok_1", 25.0).Return(&spent, nil).Once()
+		mockDB.ExpectCommit()
+
+		debit, err := svc.DebitWithToken(context.Background(), "tok_1", req)
+		require.NoError(t, err)
+		assert.Equal(t, &models.TokenDebit{TransactionID: "tx-1", Token: &spent}, debit)
+		transactionRepo.AssertExpectations(t)
+		tokenRepo.AssertExpectations(t)
+		assert.NoError(t, mockDB.ExpectationsWereMet())
+	})
+
+	t.Run("Refused before the account is touched", func(t *testing.T) {
+		revoked, expired, exhausted := *token, *token, *token
+		revoked.RevokedAt = &now
+		past := now.Add(-time.Minute)
+		expired.ExpiresAt = &past
+		exhausted.Spent = 80
+		tests := []struct {
+			name  string
+			token *models.SpendingToken
+			req   models.TokenDebitRequest
+			want  error
+			code  models.ReasonCode
+		}{
+			{"Revoked", &revoked, req, service.ErrTokenRevoked, models.ReasonTokenRevoked},
+			{"Expired", &expired, req, service.ErrTokenExpired, models.ReasonTokenExpired},
+			{"Limit", &exhausted, req, service.ErrTokenLimitExceeded, models.ReasonTokenLimitExceeded},
+			{"Merchant category", token, models.TokenDebitRequest{DestinationAccountID: 7, Amount: 25, MerchantCategory: "7995"}, service.ErrMerchantCategoryNotAllowed, models.ReasonMerchantCategoryNotAllowed},
+			{"Own account", token, models.TokenDebitRequest{DestinationAccountID: 42, Amount: 25, MerchantCategory: "5812"}, service.ErrInvalidTokenDebit, ""},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				transactionRepo := new(MockTransactionRepository)
+				tokenRepo := new(MockSpendingTokenRepository)
+				svc := service.NewService(nil, new(MockAccountRepository), transactionRepo,
+					service.WithSpendingTokens(tokenRepo),
+					service.WithClock(clock.NewFake(now)))
+				tokenRepo.On("GetSpendingToken", "tok_1").Return(tt.token, nil).Once()
+
+				_, err := svc.DebitWithToken(context.Background(), "tok_1", tt.req)
+				assert.ErrorIs(t, err, tt.want)
+				assert.Equal(t, tt.code, service.RejectionReason(err))
+				transactionRepo.AssertNotCalled(t, "GetAccountBalanceTx", mock.Anything, mock.Anything)
+			})
+		}
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		tokenRepo := new(MockSpendingTokenRepository)
+		svc := service.NewService(nil, new(MockAccountRepository), new(MockTransactionRepository), service.WithSpendingTokens(tokenRepo))
+		tokenRepo.On("GetSpendingToken", "tok_x").Return(nil, fmt.Errorf("spending token tok_x %w", repository.ErrNotFound)).Once()
+
+		_, err := svc.DebitWithToken(context.Background(), "tok_x", req)
+		assert.ErrorIs(t, err, service.ErrTokenNotFound)
+		assert.Equal(t, models.ReasonTokenNotFound, service.RejectionReason(err))
+	})
+
+	t.Run("Spent concurrently", func(t *testing.T) {
+		db, mockDB := newMockDB(t)
+		transactionRepo := new(MockTransactionRepository)
+		tokenRepo := new(MockSpendingTokenRepository)
+		svc := service.NewService(db, new(MockAccountRepository), transactionRepo,
+			service.WithSpendingTokens(tokenRepo),
+			service.WithClock(clock.NewFake(now)))
+
+		// Another debit spent the token between the check and the lock.
+		locked := *token
+		locked.Spent = 90
+		tokenRepo.On("GetSpendingToken", "tok_1").Return(token, nil).Once()
+		mockDB.ExpectBegin()
+		transactionRepo.On("GetAccountBalanceTx", mock.Anything, int64(42)).Return(500.0, nil).Once()
+		transactionRepo.On("AccountExistsTx", mock.Anything, int64(7)).Return(true, nil).Once()
+		transactionRepo.On("UpdateBalanceTx", mock.Anything, mock.Anything, mock.Anything).Return(nil).Twice()
+		transactionRepo.On("InsertTransactionLogTx", mock.Anything, int64(42), int64(7), 25.0).Return("tx-1", nil).Once()
+		tokenRepo.On("GetSpendingTokenForUpdateTx", mock.Anything, "tok_1").Return(&locked, nil).Once()
+		mockDB.ExpectRollback()
+
+		_, err := svc.DebitWithToken(context.Background(), "tok_1", req)
+		assert.ErrorIs(t, err, service.ErrTokenLimitExceeded)
+		tokenRepo.AssertNotCalled(t, "AddSpendingTokenSpentTx", mock.Anything, mock.Anything, mock.Anything)
+		assert.NoError(t, mockDB.ExpectationsWereMet())
+	})
+}
+
+type MockCashbackRepository struct {
+	mock.Mock
+}
+
+func (m *MockCashbackRepository) InsertCashbackCampaign(c models.CashbackCampaign) (*models.CashbackCampaign, error) {
+	args := m.Called(c)
+	campaign, _ := args.Get(0).(*models.CashbackCampaign)
+	return campaign, args.Error(1)
+}
+
+func (m *MockCashbackRepository) UpdateCashbackCampaign(c models.CashbackCampaign) (*models.CashbackCampaign, error) {
+	args := m.Called(c)
+	campaign, _ := args.Get(0).(*models.CashbackCampaign)
+	return campaign, args.Error(1)
+}
+
+func (m *MockCashbackRepository) GetCashbackCampaign(id int64) (*models.CashbackCampaign, error) {
+	args := m.Called(id)
+	campaign, _ := args.Get(0).(*models.CashbackCampaign)
+	return campaign, args.Error(1)
+}
+
+func (m *MockCashbackRepository) ListCashbackCampaigns() ([]models.CashbackCampaign, error) {
+	args := m.Called()
+	campaigns, _ := args.Get(0).([]models.CashbackCampaign)
+	return campaigns, args.Error(1)
+}
+
+func (m *MockCashbackRepository) DeleteCashbackCampaign(id int64) error {
+	return m.Called(id).Error(0)
+}
+
+func (m *MockCashbackRepository) ListActiveCashbackCampaigns(accountID int64, at time.Time) ([]models.CashbackCampaign, error) {
+	args := m.Called(accountID, at)
+	campaigns, _ := args.Get(0).([]models.CashbackCampaign)
+	return campaigns, args.Error(1)
+}
+
+func (m *MockCashbackRepository) AddCashbackCampaignPaidTx(tx *sql.Tx, id int64, amount float64) error {
+	return m.Called(tx, id, amount).Error(0)
+}
+
+func TestCreateCashbackCampaign(t *testing.T) {
+	starts := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	ends := starts.AddDate(0, 1, 0)
+	valid := models.CashbackCampaignRequest{Name: "Summer", Percentage: 1.5, Cap: 10, FundingAccountID: 9, StartsAt: starts, EndsAt: ends}
+	accountRepo := new(MockAccountRepository)
+	cashbackRepo := new(MockCashbackRepository)
+	svc := service.NewService(nil, accountRepo, new(MockTransactionRepository), service.WithCashback(cashbackRepo))
+
+	for name, mutate := range map[string]func(*models.CashbackCampaignRequest){
+		"blank name":          func(r *models.CashbackCampaignRequest) { r.Name = " " },
+		"zero percentage":     func(r *models.CashbackCampaignRequest) { r.Percentage = 0 },
+		"percentage over 100": func(r *models.CashbackCampaignRequest) { r.Percentage = 101 },
+		"zero cap":            func(r *models.CashbackCampaignRequest) { r.Cap = 0 },
+		"ends before start":   func(r *models.CashbackCampaignRequest) { r.EndsAt = starts.Add(-time.Hour) },
+		"funding eligible":    func(r *models.CashbackCampaignRequest) { r.EligibleAccountIDs = []int64{1, 9} },
+	} {
+		req := valid
+		mutate(&req)
+		_, err := svc.CreateCashbackCampaign(req)
+		assert.ErrorIs(t, err, service.ErrInvalidCashbackCampaign, name)
+	}
+
+	accountRepo.On("AccountExists", int64(9)).Return(true, nil)
+	accountRepo.On("AccountExists", int64(1)).Return(true, nil)
+	accountRepo.On("AccountExists", int64(404)).Return(false, nil).Once()
+	req := valid
+	req.EligibleAccountIDs = []int64{1, 404}
+	_, err := svc.CreateCashbackCampaign(req)
+	assert.ErrorIs(t, err, service.ErrInvalidCashbackCampaign, "eligible accounts must exist")
+
+	campaign := models.CashbackCampaign{Name: "Summer", Percentage: 1.5, Cap: 10, FundingAccountID: 9, EligibleAccountIDs: []int64{1}, StartsAt: starts, EndsAt: ends}
+	cashbackRepo.On("InsertCashbackCampaign", campaign).Return(&campaign, nil).Once()
+	req.Name = " Summer "
+	req.EligibleAccountIDs = []int64{1, 1}
+	got, err := svc.CreateCashbackCampaign(req)
+	require.NoError(t, err)
+	assert.Equal(t, &campaign, got)
+
+	updated := campaign
+	updated.ID = 3
+	cashbackRepo.On("UpdateCashbackCampaign", updated).Return(nil, fmt.Errorf("cashback campaign 3 %w", repository.ErrNotFound)).Once()
+	_, err = svc.UpdateCashbackCampaign(3, req)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+	cashbackRepo.AssertExpectations(t)
+}
+
+func TestCreateTransaction_Cashback(t *testing.T) {
+	now := time.Date(2026, 6, 15, 12, 0, 0, 0, time.UTC)
+	debit := func(mtr *MockTransactionRepository) {
+		mtr.On("GetAccountBalanceTx", mock.Anything, int64(1)).Return(500.0, nil).Once()
+		mtr.On("AccountExistsTx", mock.Anything, int64(3)).Return(true, nil).Once()
+		mtr.On("UpdateBalanceTx", mock.Anything, int64(1), -150.0).Return(nil).Once()
+		mtr.On("UpdateBalanceTx", mock.Anything, int64(3), 150.0).Return(nil).Once()
+		mtr.On("InsertTransactionLogTx", mock.Anything, int64(1), int64(3), 150.0).Return("7", nil).Once()
+	}
+	credit := func(mtr *MockTransactionRepository, funding int64, amount float64, rounding *money.Rounding, id string) {
+		mtr.On("GetAccountBalanceTx", mock.Anything, funding).Return(1000.0, nil).Once()
+		mtr.On("AccountExistsTx", mock.Anything, int64(1)).Return(true, nil).Once()
+		mtr.On("UpdateBalanceTx", mock.Anything, funding, -amount).Return(nil).Once()
+		mtr.On("UpdateBalanceTx", mock.Anything, int64(1), amount).Return(nil).Once()
+		mtr.On("InsertTransactionLogsTx", mock.Anything, []repository.TransactionLog{
+			{SourceID: funding, DestID: 1, Amount: amount, Kind: models.TransactionCashback, Rounding: rounding},
+		}).Return([]string{id}, nil).Once()
+	}
+
+	t.Run("Percentage and cap", func(t *testing.T) {
+		db, mockDB := newMockDB(t)
+		transactionRepo := new(MockTransactionRepository)
+		cashbackRepo := new(MockCashbackRepository)
+		svc := service.NewService(db, new(MockAccountRepository), transactionRepo,
+			service.WithCashback(cashbackRepo),
+			service.WithClock(clock.NewFake(now)))
+
+		debit(transactionRepo)
+		cashbackRepo.On("ListActiveCashbackCampaigns", int64(1), now).Return([]models.CashbackCampaign{
+			{ID: 1, Percentage: 1.5, Cap: 10, FundingAccountID: 9},
+			{ID: 2, Percentage: 5, Cap: 4, FundingAccountID: 8},
+		}, nil).Once()
+		// 1.5% of 150.00 is 2.25; 5% is 7.50, capped at 4.00.
+		credit(transactionRepo, 9, 2.25, &money.Rounding{Mode: money.HalfUp, MinorUnits: 2, Unrounded: 2.25}, "8")
+		cashbackRepo.On("AddCashbackCampaignPaidTx", mock.Anything, int64(1), 2.25).Return(nil).Once()
+		credit(transactionRepo, 8, 4, nil, "9")
+		cashbackRepo.On("AddCashbackCampaignPaidTx", mock.Anything, int64(2), 4.0).Return(nil).Once()
+		for range 3 {
+			mockDB.ExpectBegin()
+			mockDB.ExpectCommit()
+		}
+
+		transactionID, err := svc.CreateTransaction(context.Background(), 1, 3, 150, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "7", transactionID)
+		transactionRepo.AssertExpectations(t)
+		cashbackRepo.AssertExpectations(t)
+		assert.NoError(t, mockDB.ExpectationsWereMet())
+	})
+
+	t.Run("Rounding policy", func(t *testing.T) {
+		require.NoError(t, money.SetPolicy("USD", money.Policy{MinorUnits: 2, Mode: money.Truncate}))
+		t.Cleanup(money.ResetPolicies)
+		db, mockDB := newMockDB(t)
+		transactionRepo := new(MockTransactionRepository)
+		cashbackRepo := new(MockCashbackRepository)
+		svc := service.NewService(db, new(MockAccountRepository), transactionRepo,
+			service.WithCashback(cashbackRepo),
+			service.WithClock(clock.NewFake(now)))
+
+		debit(transactionRepo)
+		cashbackRepo.On("ListActiveCashbackCampaigns", int64(1), now).Return([]models.CashbackCampaign{
+			{ID: 1, Percentage: 1.25, Cap: 10, FundingAccountID: 9},
+		}, nil).Once()
+		// 1.25% of 150.00 is 1.875, truncated to 1.87 and recorded as such.
+		credit(transactionRepo, 9, 1.87, &money.Rounding{Mode: money.Truncate, MinorUnits: 2, Unrounded: 1.875}, "8")
+		cashbackRepo.On("AddCashbackCampaignPaidTx", mock.Anything, int64(1), 1.87).Return(nil).Once()
+		for range 2 {
+			mockDB.ExpectBegin()
+			mockDB.ExpectCommit()
+		}
+
+		_, err := svc.CreateTransaction(context.Background(), 1, 3, 150, nil)
+		require.NoError(t, err)
+		transactionRepo.AssertExpectations(t)
+		cashbackRepo.AssertExpectations(t)
+	})
+
+	t.Run("Funding account short", func(t *testing.T) {
+		db, mockDB := newMockDB(t)
+		transactionRepo := new(MockTransactionRepository)
+		cashbackRepo := new(MockCashbackRepository)
+		svc := service.NewService(db, new(MockAccountRepository), transactionRepo,
+			service.WithCashback(cashbackRepo),
+			service.WithClock(clock.NewFake(now)))
+
+		debit(transactionRepo)
+		cashbackRepo.On("ListActiveCashbackCampaigns", int64(1), now).Return([]models.CashbackCampaign{
+			{ID: 1, Percentage: 1.5, Cap: 10, FundingAccountID: 9},
+		}, nil).Once()
+		transactionRepo.On("GetAccountBalanceTx", mock.Anything, int64(9)).Return(1.0, nil).Once()
+		mockDB.ExpectBegin()
+		mockDB.ExpectCommit()
+		mockDB.ExpectBegin()
+		mockDB.ExpectRollback()
+
+		transactionID, err := svc.CreateTransaction(context.Background(), 1, 3, 150, nil)
+		require.NoError(t, err, "a failed credit leaves the transfer in place")
+		assert.Equal(t, "7", transactionID)
+		cashbackRepo.AssertNotCalled(t, "AddCashbackCampaignPaidTx", mock.Anything, mock.Anything, mock.Anything)
+		assert.NoError(t, mockDB.ExpectationsWereMet())
+	})
+}
+
+type MockTreasuryRepository struct {
+	mock.Mock
+}
+
+func (m *MockTreasuryRepository) BalancesByType(ctx context.Context, suspenseID, reimbursementID int64) ([]models.TreasuryPosition, error) {
+	args := m.Called(suspenseID, reimbursementID)
+	positions, _ := args.Get(0).([]models.TreasuryPosition)
+	return positions, args.Error(1)
+}
+
+func (m *MockTreasuryRepository) LargestAccounts(ctx context.Context, suspenseID, reimbursementID int64, limit int) ([]models.TreasuryAccount, error) {
+	args := m.Called(suspenseID, reimbursementID, limit)
+	accounts, _ := args.Get(0).([]models.TreasuryAccount)
+	return accounts, args.Error(1)
+}
+
+func (m *MockTreasuryRepository) DailyFlows(ctx context.Context, suspenseID, reimbursementID int64, since time.Time, loc *time.Location) ([]models.DailyFlow, error) {
+	args := m.Called(suspenseID, reimbursementID, since, loc)
+	flows, _ := args.Get(0).([]models.DailyFlow)
+	return flows, args.Error(1)
+}
+
+func (m *MockTreasuryRepository) SuspenseFloat(ctx context.Context) (*models.TreasuryFloat, error) {
+	args := m.Called()
+	float, _ := args.Get(0).(*models.TreasuryFloat)
+	return float, args.Error(1)
+}
+
+func (m *MockTreasuryRepository) ReimbursementFloat(ctx context.Context) (*models.TreasuryFloat, error) {
+	args := m.Called()
+	float, _ := args.Get(0).(*models.TreasuryFloat)
+	return float, args.Error(1)
+}
+
+func TestTreasuryPositions(t *testing.T) {
+	now := time.Date(2026, 6, 15, 12, 0, 0, 0, time.UTC)
+	treasuryRepo := new(MockTreasuryRepository)
+	svc := service.NewService(nil, new(MockAccountRepository), new(MockTransactionRepository),
+		service.WithTreasury(treasuryRepo),
+		service.WithSuspenseAccount(900, nil),
+		service.WithClock(clock.NewFake(now)))
+
+	treasuryRepo.On("BalancesByType", int64(900), int64(0)).Return([]models.TreasuryPosition{
+		{Currency: "USD", AccountType: models.AccountTypeCustomer, Accounts: 120, Balance: 50000},
+		{Currency: "USD", AccountType: models.AccountTypeFunding, Accounts: 2, Balance: 8000},
+		{Currency: "USD", AccountType: models.AccountTypeSuspense, Accounts: 1, Balance: 310},
+	}, nil).Once()
+	treasuryRepo.On("SuspenseFloat").Return(&models.TreasuryFloat{AccountType: models.AccountTypeSuspense, Outstanding: 300, OutstandingItems: 2}, nil).Once()
+
+	report, err := svc.TreasuryPositions(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, now, report.AsOf)
+	assert.Equal(t, []models.CurrencyTotal{{Currency: "USD", Balance: 58310}}, report.Totals)
+	assert.Len(t, report.Positions, 3)
+	assert.Equal(t, []models.TreasuryFloat{
+		{AccountType: models.AccountTypeSuspense, AccountID: 900, Balance: 310, Outstanding: 300, OutstandingItems: 2},
+	}, report.Float, "only configured accounts have a float")
+	treasuryRepo.AssertExpectations(t)
+}
+
+func TestDailyFlows(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	// 00:30 on June 15 in Berlin is still June 14 in UTC.
+	now := time.Date(2026, 6, 14, 22, 30, 0, 0, time.UTC)
+	treasuryRepo := new(MockTreasuryRepository)
+	svc := service.NewService(nil, new(MockAccountRepository), new(MockTransactionRepository),
+		service.WithTreasury(treasuryRepo),
+		service.WithClock(clock.NewFake(now)))
+
+	from := time.Date(2026, 6, 9, 0, 0, 0, 0, berlin).UTC()
+	treasuryRepo.On("DailyFlows", int64(0), int64(0), from, berlin).Return([]models.DailyFlow{}, nil).Once()
+	flows, err := svc.DailyFlows(context.Background(), 7, berlin)
+	require.NoError(t, err)
+	assert.Equal(t, "2026-06-09", flows.From)
+	assert.Equal(t, "2026-06-15", flows.To)
+	assert.Equal(t, "Europe/Berlin", flows.Timezone)
+
+	for _, days := range []int{0, service.MaxFlowDays + 1} {
+		_, err = svc.DailyFlows(context.Background(), days, time.UTC)
+		assert.ErrorIs(t, err, service.ErrInvalidTreasuryQuery)
+	}
+	_, err = svc.LargestAccounts(context.Background(), service.MaxLargestAccounts+1)
+	assert.ErrorIs(t, err, service.ErrInvalidTreasuryQuery)
+	treasuryRepo.AssertExpectations(t)
+}
+
+type MockRevaluationRepository struct {
+	mock.Mock
+}
+
+func (m *MockRevaluationRepository) PutFXRate(rate models.FXRate) (*models.FXRate, error) {
+	args := m.Called(rate)
+	r, _ := args.Get(0).(*models.FXRate)
+	return r, args.Error(1)
+}
+
+func (m *MockRevaluationRepository) GetFXRate(currency, baseCurrency string, date time.Time) (*models.FXRate, error) {
+	args := m.Called(currency, baseCurrency, date)
+	r, _ := args.Get(0).(*models.FXRate)
+	return r, args.Error(1)
+}
+
+func (m *MockRevaluationRepository) LatestRevaluation(currency, baseCurrency string) (*models.Revaluation, error) {
+	args := m.Called(currency, baseCurrency)
+	rv, _ := args.Get(0).(*models.Revaluation)
+	return rv, args.Error(1)
+}
+
+func (m *MockRevaluationRepository) RevalueTx(tx *sql.Tx, rv models.Revaluation, previous *models.Revaluation, cutoff time.Time, minorUnits int) (*models.Revaluation, error) {
+	args := m.Called(tx, rv, previous, cutoff, minorUnits)
+	r, _ := args.Get(0).(*models.Revaluation)
+	return r, args.Error(1)
+}
+
+func (m *MockRevaluationRepository) GetRevaluation(ctx context.Context, id, suspenseID, reimbursementID int64) (*models.Revaluation, error) {
+	args := m.Called(id, suspenseID, reimbursementID)
+	rv, _ := args.Get(0).(*models.Revaluation)
+	return rv, args.Error(1)
+}
+
+func (m *MockRevaluationRepository) ListRevaluations(ctx context.Context, currency, baseCurrency string, limit int) ([]models.Revaluation, error) {
+	args := m.Called(currency, baseCurrency, limit)
+	rvs, _ := args.Get(0).([]models.Revaluation)
+	return rvs, args.Error(1)
+}
+
+func TestRevalue(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+	// 08:00 on June 15 in Tokyo: June 14 has closed there, June 15 has not.
+	now := time.Date(2026, 6, 14, 23, 0, 0, 0, time.UTC)
+	db, mockDB := newMockDB(t)
+	repo := new(MockRevaluationRepository)
+	svc := service.NewService(db, new(MockAccountRepository), new(MockTransactionRepository),
+		service.WithRevaluation(service.RevaluationConfig{BaseCurrency: "EUR", GainAccountID: 801, LossAccountID: 802, Location: tokyo}, repo),
+		service.WithClock(clock.NewFake(now)))
+
+	_, err = svc.Revalue("2026-06-15")
+	assert.ErrorIs(t, err, service.ErrInvalidRevaluation, "the day has not closed")
+	_, err = svc.Revalue("June 14")
+	assert.ErrorIs(t, err, service.ErrInvalidRevaluation)
+
+	previous := &models.Revaluation{ID: 6, Date: "2026-06-12", Rate: 0.91}
+	repo.On("LatestRevaluation", "USD", "EUR").Return(previous, nil)
+	_, err = svc.Revalue("2026-06-12")
+	assert.ErrorIs(t, err, repository.ErrAlreadyRevalued)
+	_, err = svc.Revalue("2026-06-11")
+	assert.ErrorIs(t, err, service.ErrInvalidRevaluation, "days are revalued in order")
+
+	june14 := time.Date(2026, 6, 14, 0, 0, 0, 0, time.UTC)
+	repo.On("GetFXRate", "USD", "EUR", june14).Return(nil, fmt.Errorf("rate %w", repository.ErrNotFound)).Once()
+	_, err = svc.Revalue("2026-06-14")
+	assert.ErrorIs(t, err, service.ErrNoFXRate)
+
+	// A skipped day is covered by the next one.
+	repo.On("GetFXRate", "USD", "EUR", june14).Return(&models.FXRate{Rate: 0.92}, nil).Once()
+	mockDB.ExpectBegin()
+	cutoff := time.Date(2026, 6, 15, 0, 0, 0, 0, tokyo)
+	// Base values are rounded with the base currency's rounding policy.
+	require.NoError(t, money.SetPolicy("EUR", money.Policy{MinorUnits: 2, Mode: money.HalfEven}))
+	t.Cleanup(money.ResetPolicies)
+	want := models.Revaluation{Date: "2026-06-14", Currency: "USD", BaseCurrency: "EUR", Rate: 0.92, GainAccountID: 801, LossAccountID: 802, RoundingMode: money.HalfEven}
+	revalued := want
+	revalued.ID, revalued.Loss = 7, 10
+	repo.On("RevalueTx", mock.Anything, want, previous, mock.MatchedBy(cutoff.Equal), 2).Return(&revalued, nil).Once()
+	mockDB.ExpectCommit()
+
+	rv, err := svc.Revalue("2026-06-14")
+	require.NoError(t, err)
+	assert.Equal(t, &revalued, rv)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+	repo.AssertExpectations(t)
+}
+
+func TestPutFXRate(t *testing.T) {
+	repo := new(MockRevaluationRepository)
+	svc := service.NewService(nil, new(MockAccountRepository), new(MockTransactionRepository),
+		service.WithRevaluation(service.RevaluationConfig{BaseCurrency: "EUR", GainAccountID: 801, LossAccountID: 802}, repo))
+
+	_, err := svc.PutFXRate("2026-06-14", models.FXRateRequest{Rate: -1})
+	assert.ErrorIs(t, err, service.ErrInvalidFXRate)
+	_, err = svc.PutFXRate("14.06.2026", models.FXRateRequest{Rate: 0.92})
+	assert.ErrorIs(t, err, service.ErrInvalidFXRate)
+
+	repo.On("LatestRevaluation", "USD", "EUR").Return(&models.Revaluation{Date: "2026-06-13"}, nil)
+	_, err = svc.PutFXRate("2026-06-13", models.FXRateRequest{Rate: 0.92})
+	assert.ErrorIs(t, err, service.ErrInvalidFXRate, "rates of revalued days are final")
+
+	rate := models.FXRate{Currency: "USD", BaseCurrency: "EUR", Date: "2026-06-14", Rate: 0.92}
+	repo.On("PutFXRate", rate).Return(&rate, nil).Once()
+	got, err := svc.PutFXRate("2026-06-14", models.FXRateRequest{Rate: 0.92})
+	require.NoError(t, err)
+	assert.Equal(t, &rate, got)
+	repo.AssertExpectations(t)
+}
+
+type MockFXRateSource struct {
+	mock.Mock
+}
+
+func (m *MockFXRateSource) Quote(ctx context.Context, currency, base string) (*models.FXQuote, error) {
+	args := m.Called(currency, base)
+	q, _ := args.Get(0).(*models.FXQuote)
+	return q, args.Error(1)
+}
+
+func TestFXRates(t *testing.T) {
+	rates := new(MockFXRateSource)
+	svc := service.NewService(nil, new(MockAccountRepository), new(MockTransactionRepository),
+		service.WithRevaluation(service.RevaluationConfig{BaseCurrency: "EUR", GainAccountID: 801, LossAccountID: 802}, new(MockRevaluationRepository)),
+		service.WithFXRates(rates))
+
+	_, err := svc.FXRates(context.Background(), []string{"XXX1"})
+	assert.ErrorIs(t, err, service.ErrInvalidFXRate)
+	_, err = svc.FXRates(context.Background(), []string{"USD"})
+	assert.ErrorIs(t, err, service.ErrInvalidFXRate, "the ledger currency has no rate in itself")
+
+	eur := &models.FXQuote{Currency: "USD", BaseCurrency: "EUR", Rate: 0.92}
+	gbp := &models.FXQuote{Currency: "USD", BaseCurrency: "GBP", Rate: 0.79, Stale: true}
+	rates.On("Quote", "USD", "EUR").Return(eur, nil)
+	rates.On("Quote", "USD", "GBP").Return(gbp, nil)
+	quotes, err := svc.FXRates(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, []models.FXQuote{*eur}, quotes, "the base currency by default")
+	quotes, err = svc.FXRates(context.Background(), []string{"eur", "GBP"})
+	require.NoError(t, err)
+	assert.Equal(t, []models.FXQuote{*eur, *gbp}, quotes, "stale rates are flagged, not refused")
+
+	rates.On("Quote", "USD", "JPY").Return(nil, errors.New("connection refused"))
+	_, err = svc.FXRates(context.Background(), []string{"JPY"})
+	assert.ErrorIs(t, err, service.ErrFXRateUnavailable)
+}
+
+func TestRevalue_LiveRate(t *testing.T) {
+	now := time.Date(2026, 6, 15, 1, 0, 0, 0, time.UTC)
+	repo := new(MockRevaluationRepository)
+	rates := new(MockFXRateSource)
+	svc := service.NewService(nil, new(MockAccountRepository), new(MockTransactionRepository),
+		service.WithRevaluation(service.RevaluationConfig{BaseCurrency: "EUR", GainAccountID: 801, LossAccountID: 802}, repo),
+		service.WithFXRates(rates),
+		service.WithClock(clock.NewFake(now)))
+	repo.On("LatestRevaluation", "USD", "EUR").Return(nil, fmt.Errorf("revaluation %w", repository.ErrNotFound))
+	repo.On("GetFXRate", "USD", "EUR", mock.Anything).Return(nil, fmt.Errorf("rate %w", repository.ErrNotFound))
+
+	_, err := svc.Revalue("2026-06-13")
+	assert.ErrorIs(t, err, service.ErrNoFXRate, "only the day that has just closed takes the live rate")
+
+	rates.On("Quote", "USD", "EUR").Return(&models.FXQuote{Currency: "USD", BaseCurrency: "EUR", Rate: 0.92, AsOf: now.AddDate(0, 0, -5), Stale: true}, nil).Once()
+	_, err = svc.Revalue("2026-06-14")
+	assert.ErrorIs(t, err, service.ErrStaleFXRate)
+
+	rates.On("Quote", "USD", "EUR").Return(&models.FXQuote{Currency: "USD", BaseCurrency: "EUR", Rate: 0.92, AsOf: now.Add(-time.Hour)}, nil).Once()
+	repo.On("PutFXRate", models.FXRate{Currency: "USD", BaseCurrency: "EUR", Date: "2026-06-14", Rate: 0.92}).Return(nil, errors.New("connection refused")).Once()
+	_, err = svc.Revalue("2026-06-14")
+	assert.EqualError(t, err, "connection refused", "the live rate is recorded as the day's rate")
+	rates.AssertExpectations(t)
+	repo.AssertExpectations(t)
+}
+
+type MockTransactionTagRepository struct {
+	mock.Mock
+}
+
+func (m *MockTransactionTagRepository) SetTransactionTagsTx(tx *sql.Tx, id string, tags []string) error {
+	return m.Called(tx, id, tags).Error(0)
+}
+
+func (m *MockTransactionTagRepository) SetTransactionTags(id string, tags []string) (*models.Transaction, error) {
+	args := m.Called(id, tags)
+	t, _ := args.Get(0).(*models.Transaction)
+	return t, args.Error(1)
+}
+
+func (m *MockTransactionTagRepository) ListAccountTags(ctx context.Context, accountID int64) ([]models.TransactionTag, error) {
+	args := m.Called(accountID)
+	tags, _ := args.Get(0).([]models.TransactionTag)
+	return tags, args.Error(1)
+}
+
+func TestTransactionTags(t *testing.T) {
+	t.Run("Tagged at creation", func(t *testing.T) {
+		db, mockDB := newMockDB(t)
+		transactionRepo := new(MockTransactionRepository)
+		tagRepo := new(MockTransactionTagRepository)
+		svc := service.NewService(db, new(MockAccountRepository), transactionRepo, service.WithTransactionTags(tagRepo))
+
+		transactionRepo.On("GetAccountBalanceTx", mock.Anything, int64(1)).Return(200.0, nil).Once()
+		transactionRepo.On("AccountExistsTx", mock.Anything, int64(2)).Return(true, nil).Once()
+		transactionRepo.On("UpdateBalanceTx", mock.Anything, int64(1), -50.0).Return(nil).Once()
+		transactionRepo.On("UpdateBalanceTx", mock.Anything, int64(2), 50.0).Return(nil).Once()
+		transactionRepo.On("InsertTransactionLogTx", mock.Anything, int64(1), int64(2), 50.0).Return("7", nil).Once()
+		tagRepo.On("SetTransactionTagsTx", mock.Anything, "7", []string{"correction", "migration"}).Return(nil).Once()
+		mockDB.ExpectBegin()
+		mockDB.ExpectCommit()
+
+		id, err := svc.CreateTransaction(context.Background(), 1, 2, 50, []string{" Migration", "correction", "migration"})
+		require.NoError(t, err)
+		assert.Equal(t, "7", id)
+		transactionRepo.AssertExpectations(t)
+		tagRepo.AssertExpectations(t)
+		assert.NoError(t, mockDB.ExpectationsWereMet())
+	})
+
+	t.Run("Invalid tags", func(t *testing.T) {
+		svc := service.NewService(nil, new(MockAccountRepository), new(MockTransactionRepository), service.WithTransactionTags(new(MockTransactionTagRepository)))
+
+		tooMany := make([]string, 21)
+		for i := range tooMany {
+			tooMany[i] = fmt.Sprintf("tag-%d", i)
+		}
+		for _, tags := range [][]string{{""}, {"needs review"}, {"-leading"}, {strings.Repeat("x", 65)}, tooMany} {
+			_, err := svc.CreateTransaction(context.Background(), 1, 2, 50, tags)
+			assert.ErrorIs(t, err, service.ErrInvalidTags, "%q", tags)
+			_, err = svc.SetTransactionTags(context.Background(), "7", tags)
+			assert.ErrorIs(t, err, service.ErrInvalidTags, "%q", tags)
+		}
+		_, err := svc.ListTransactions(context.Background(), models.TransactionFilter{Tag: "needs review"}, "", 10)
+		assert.ErrorIs(t, err, service.ErrInvalidTags)
+	})
+
+	t.Run("Retag", func(t *testing.T) {
+		tagRepo := new(MockTransactionTagRepository)
+		svc := service.NewService(nil, new(MockAccountRepository), new(MockTransactionRepository), service.WithTransactionTags(tagRepo))
+
+		tagRepo.On("SetTransactionTags", "7", []string{"test"}).Return(&models.Transaction{ID: "7", Tags: []string{"test"}}, nil).Once()
+		tagRepo.On("SetTransactionTags", "8", []string{}).Return(nil, fmt.Errorf("transaction 8 %w", repository.ErrNotFound)).Once()
+		got, err := svc.SetTransactionTags(context.Background(), "7", []string{"TEST", "test"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"test"}, got.Tags)
+		_, err = svc.SetTransactionTags(context.Background(), "8", nil)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+		tagRepo.AssertExpectations(t)
+	})
+
+	t.Run("Filter and account tags", func(t *testing.T) {
+		accountRepo := new(MockAccountRepository)
+		transactionRepo := new(MockTransactionRepository)
+		tagRepo := new(MockTransactionTagRepository)
+		svc := service.NewService(nil, accountRepo, transactionRepo, service.WithTransactionTags(tagRepo))
+
+		filter := models.TransactionFilter{Tag: "migration"}
+		transactionRepo.On("ListTransactions", filter, repository.ChangeCursor{}, 10).
+			Return([]models.Transaction{{ID: "7", Tags: []string{"migration"}}}, repository.ChangeCursor{ID: 7}, nil).Once()
+		transactionRepo.On("CountTransactions", filter).Return(int64(1), nil).Once()
+		page, err := svc.ListTransactions(context.Background(), models.TransactionFilter{Tag: "Migration"}, "", 10)
+		require.NoError(t, err)
+		assert.Len(t, page.Transactions, 1)
+		count, err := svc.CountTransactions(context.Background(), models.TransactionFilter{Tag: "Migration"})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+
+		accountRepo.On("GetAccount", int64(1), false).Return(&models.Account{AccountID: 1}, nil).Once()
+		accountRepo.On("GetAccount", int64(2), false).Return(nil, fmt.Errorf("account 2 %w", repository.ErrNotFound)).Once()
+		tagRepo.On("ListAccountTags", int64(1)).Return([]models.TransactionTag{{Tag: "migration", Transactions: 3}}, nil).Once()
+		tags, err := svc.ListAccountTags(context.Background(), 1)
+		require.NoError(t, err)
+		assert.Equal(t, []models.TransactionTag{{Tag: "migration", Transactions: 3}}, tags)
+		_, err = svc.ListAccountTags(context.Background(), 2)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+
+		transactionRepo.AssertExpectations(t)
+		tagRepo.AssertExpectations(t)
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		svc := service.NewService(nil, new(MockAccountRepository), new(MockTransactionRepository))
+		_, err := svc.CreateTransaction(context.Background(), 1, 2, 50, []string{"test"})
+		assert.Error(t, err)
+		_, err = svc.SetTransactionTags(context.Background(), "7", []string{"test"})
+		assert.Error(t, err)
+	})
+}
+
+type MockSandboxRepository struct {
+	mock.Mock
+}
+
+func (m *MockSandboxRepository) PurgeTestData() (*models.SandboxPurge, error) {
+	args := m.Called()
+	purge, _ := args.Get(0).(*models.SandboxPurge)
+	return purge, args.Error(1)
+}
+
+func TestTestMode(t *testing.T) {
+	test := sandbox.WithTestMode(context.Background())
+
+	t.Run("Accounts and listings", func(t *testing.T) {
+		accountRepo := new(MockAccountRepository)
+		transactionRepo := new(MockTransactionRepository)
+		svc := service.NewService(nil, accountRepo, transactionRepo)
+
+		accountRepo.On("CreateAccount", int64(1), 100.0, "default", "", false).Return(&models.Account{AccountID: 1, Balance: 100}, nil).Once()
+		_, err := svc.CreateAccount(test, 1, 100, "default", "")
+		require.NoError(t, err)
+
+		filter := models.TransactionFilter{TestMode: true}
+		transactionRepo.On("ListTransactions", filter, repository.ChangeCursor{}, 10).
+			Return([]models.Transaction{{ID: "7"}}, repository.ChangeCursor{ID: 7}, nil).Once()
+		transactionRepo.On("CountTransactions", filter).Return(int64(1), nil).Once()
+		transactionRepo.On("ListTransactionChanges", repository.ChangeCursor{}, false, 10).
+			Return([]models.Transaction{{ID: "7"}}, repository.ChangeCursor{ID: 7}, nil).Once()
+		_, err = svc.ListTransactions(test, models.TransactionFilter{}, "", 10)
+		require.NoError(t, err)
+		_, err = svc.CountTransactions(test, models.TransactionFilter{})
+		require.NoError(t, err)
+		_, err = svc.SyncTransactions(test, "", 10)
+		require.NoError(t, err)
+
+		accountRepo.AssertExpectations(t)
+		transactionRepo.AssertExpectations(t)
+	})
+
+	t.Run("Lookup hides live transactions", func(t *testing.T) {
+		transactionRepo := new(MockTransactionRepository)
+		svc := service.NewService(nil, new(MockAccountRepository), transactionRepo)
+
+		found := map[string]models.Transaction{"7": {ID: "7", Livemode: true}, "8": {ID: "8"}}
+		transactionRepo.On("GetTransactions", []string{"7", "8"}).Return(found, nil).Twice()
+		lookup, err := svc.LookupTransactions(test, []string{"7", "8"})
+		require.NoError(t, err)
+		assert.Equal(t, []models.Transaction{{ID: "8"}}, lookup.Transactions)
+		assert.Equal(t, []string{"7"}, lookup.NotFound)
+
+		lookup, err = svc.LookupTransactions(context.Background(), []string{"7", "8"})
+		require.NoError(t, err)
+		assert.Len(t, lookup.Transactions, 2, "live requests are not scoped by ID")
+	})
+
+	t.Run("Live resources are not found", func(t *testing.T) {
+		accountRepo := new(MockAccountRepository)
+		transactionRepo := new(MockTransactionRepository)
+		tokenRepo := new(MockSpendingTokenRepository)
+		reimbursementRepo := new(MockReimbursementRepository)
+		svc := service.NewService(nil, accountRepo, transactionRepo,
+			service.WithSpendingTokens(tokenRepo), service.WithReimbursements(99, reimbursementRepo))
+
+		// Account 1 is live, account 2 test-mode.
+		accountRepo.On("GetAccount", int64(1), mock.Anything).Return(&models.Account{AccountID: 1, Livemode: true}, nil)
+		accountRepo.On("GetAccount", int64(2), mock.Anything).Return(&models.Account{AccountID: 2}, nil)
+		transactionRepo.On("GetTransaction", "7").Return(&models.Transaction{ID: "7", Livemode: true}, nil)
+		tokenRepo.On("GetSpendingToken", "tok_live").Return(&models.SpendingToken{ID: "tok_live", AccountID: 1}, nil)
+		reimbursementRepo.On("GetReimbursement", int64(5), "acme").Return(&models.Reimbursement{ID: 5, AccountID: 1, Tenant: "acme"}, nil)
+
+		_, err := svc.GetAccount(test, 1)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+		exists, err := svc.AccountExists(test, 1)
+		require.NoError(t, err)
+		assert.False(t, exists)
+		_, err = svc.GetAccount(test, 2)
+		assert.NoError(t, err)
+		_, err = svc.GetTransaction(test, "7")
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+		_, err = svc.GetSpendingToken(test, "tok_live")
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+		_, err = svc.DebitWithToken(test, "tok_live", models.TokenDebitRequest{DestinationAccountID: 2, Amount: 5})
+		assert.Equal(t, models.ReasonTokenNotFound, service.RejectionReason(err))
+		_, err = svc.GetReimbursement(test, 5, "acme")
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+
+		// Live requests find both modes.
+		_, err = svc.GetAccount(context.Background(), 1)
+		assert.NoError(t, err)
+		_, err = svc.GetTransaction(context.Background(), "7")
+		assert.NoError(t, err)
+		_, err = svc.GetReimbursement(context.Background(), 5, "acme")
+		assert.NoError(t, err)
+	})
+
+	t.Run("Money from live accounts is not moved", func(t *testing.T) {
+		db, mockDB := newMockDB(t)
+		accountRepo := new(MockAccountRepository)
+		transactionRepo := new(MockTransactionRepository)
+		forwardRepo := new(MockForwardedTransferRepository)
+		svc := service.NewService(db, accountRepo, transactionRepo, service.WithForwardedTransfers(forwardRepo))
+
+		accountRepo.On("GetAccount", int64(1), true).Return(&models.Account{AccountID: 1, Livemode: true}, nil)
+		accountRepo.On("GetAccount", int64(2), true).Return(&models.Account{AccountID: 2}, nil)
+
+		_, err := svc.CreateTransaction(test, 1, 2, 10, nil)
+		assert.Equal(t, models.ReasonAccountNotFound, service.RejectionReason(err))
+
+		_, err = svc.CreateTransactionBatch(test, models.TransactionBatchRequest{Transfers: []models.TransactionRequest{
+			{SourceAccountID: 2, DestinationAccountID: 1, Amount: 5},
+			{SourceAccountID: 1, DestinationAccountID: 2, Amount: 5},
+		}})
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+
+		// A transfer forwarded for a test-mode key is replayed in test mode.
+		forwardRepo.On("GetForwardedTransfer", "fwd_1").Return(nil, repository.ErrNotFound).Once()
+		forwardRepo.On("InsertForwardedTransfer", mock.MatchedBy(func(s models.ForwardedTransferStatus) bool {
+			return s.Status == models.ForwardRejected && s.Code == models.ReasonAccountNotFound
+		})).Return(nil).Once()
+		status, err := svc.ReplayForwardedTransfer(models.ForwardedTransfer{
+			ID:      "fwd_1",
+			APIKey:  "test_1",
+			Request: models.TransactionRequest{SourceAccountID: 1, DestinationAccountID: 2, Amount: 10},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, models.ForwardRejected, status.Status)
+
+		// No database transaction was begun.
+		assert.NoError(t, mockDB.ExpectationsWereMet())
+		forwardRepo.AssertExpectations(t)
+		transactionRepo.AssertNotCalled(t, "InsertTransactionLogTx", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Transfer between modes", func(t *testing.T) {
+		db, mockDB := newMockDB(t)
+		transactionRepo := new(MockTransactionRepository)
+		svc := service.NewService(db, new(MockAccountRepository), transactionRepo)
+
+		transactionRepo.On("GetAccountBalanceTx", mock.Anything, int64(1)).Return(200.0, nil)
+		transactionRepo.On("AccountExistsTx", mock.Anything, int64(2)).Return(true, nil)
+		transactionRepo.On("UpdateBalanceTx", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		transactionRepo.On("InsertTransactionLogTx", mock.Anything, int64(1), int64(2), 10.0).
+			Return("", &pq.Error{Code: "23514", Constraint: "transactions_livemode"})
+		mockDB.ExpectBegin()
+		mockDB.ExpectRollback()
+
+		_, err := svc.CreateTransaction(context.Background(), 1, 2, 10, nil)
+		assert.ErrorIs(t, err, service.ErrLivemodeMismatch)
+		assert.Equal(t, models.ReasonDestinationNotFound, service.RejectionReason(err))
+		assert.NoError(t, mockDB.ExpectationsWereMet())
+	})
+
+	t.Run("Inbound payment between modes", func(t *testing.T) {
+		db, mockDB := newMockDB(t)
+		accountRepo := new(MockAccountRepository)
+		transactionRepo := new(MockTransactionRepository)
+		suspenseRepo := new(MockSuspenseRepository)
+		svc := service.NewService(db, accountRepo, transactionRepo, service.WithSuspenseAccount(99, suspenseRepo))
+
+		// Account 2 is an active test-mode account: the payment is refused once,
+		// neither retried nor parked.
+		transactionRepo.On("GetAccountBalanceTx", mock.Anything, int64(1)).Return(200.0, nil).Once()
+		transactionRepo.On("AccountExistsTx", mock.Anything, int64(2)).Return(true, nil).Once()
+		transactionRepo.On("UpdateBalanceTx", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		transactionRepo.On("InsertTransactionLogTx", mock.Anything, int64(1), int64(2), 10.0).
+			Return("", &pq.Error{Code: "23514", Constraint: "transactions_livemode"}).Once()
+		mockDB.ExpectBegin()
+		mockDB.ExpectRollback()
+
+		_, err := svc.ReceivePayment(context.Background(), 1, 2, 10, "INV-1")
+		assert.ErrorIs(t, err, service.ErrLivemodeMismatch)
+		assert.NoError(t, mockDB.ExpectationsWereMet())
+		transactionRepo.AssertExpectations(t)
+		accountRepo.AssertNotCalled(t, "GetAccount", mock.Anything, mock.Anything)
+		suspenseRepo.AssertNotCalled(t, "InsertSuspenseItemTx", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Purge", func(t *testing.T) {
+		_, err := service.NewService(nil, new(MockAccountRepository), new(MockTransactionRepository)).PurgeTestData()
+		assert.Error(t, err, "disabled")
+
+		sandboxRepo := new(MockSandboxRepository)
+		svc := service.NewService(nil, new(MockAccountRepository), new(MockTransactionRepository), service.WithSandbox(sandboxRepo))
+		sandboxRepo.On("PurgeTestData").Return(&models.SandboxPurge{Accounts: 3, Transactions: 12}, nil).Once()
+		purge, err := svc.PurgeTestData()
+		require.NoError(t, err)
+		assert.Equal(t, &models.SandboxPurge{Accounts: 3, Transactions: 12}, purge)
+	})
+}
+
+type MockTimelineRepository struct {
+	mock.Mock
+}
+
+func (m *MockTimelineRepository) ListAccountTimeline(ctx context.Context, accountID int64, after repository.ChangeCursor, limit int) ([]models.AccountEvent, repository.ChangeCursor, error) {
+	args := m.Called(accountID, after, limit)
+	events, _ := args.Get(0).([]models.AccountEvent)
+	return events, args.Get(1).(repository.ChangeCursor), args.Error(2)
+}
+
+func TestAccountTimeline(t *testing.T) {
+	_, err := service.NewService(nil, new(MockAccountRepository), new(MockTransactionRepository)).AccountTimeline(context.Background(), 1, "", 10, false)
+	assert.Error(t, err, "disabled")
+
+	accountRepo := new(MockAccountRepository)
+	timelineRepo := new(MockTimelineRepository)
+	svc := service.NewService(nil, accountRepo, new(MockTransactionRepository), service.WithTimeline(timelineRepo))
+	opened := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	events := []models.AccountEvent{{Type: models.AccountEventOpened, OccurredAt: opened}, {Type: models.AccountEventClosed, OccurredAt: opened.Add(time.Hour)}}
+	last := repository.ChangeCursor{UpdatedAt: opened.Add(time.Hour), ID: 12}
+
+	t.Run("Pages", func(t *testing.T) {
+		accountRepo.On("GetAccount", int64(1), true).Return(&models.Account{AccountID: 1}, nil).Twice()
+		timelineRepo.On("ListAccountTimeline", int64(1), repository.ChangeCursor{}, 2).Return(events, last, nil).Once()
+		timeline, err := svc.AccountTimeline(context.Background(), 1, "", 2, true)
+		require.NoError(t, err)
+		assert.Equal(t, events, timeline.Events)
+		assert.True(t, timeline.HasMore)
+
+		timelineRepo.On("ListAccountTimeline", int64(1), last, 2).Return(nil, last, nil).Once()
+		timeline, err = svc.AccountTimeline(context.Background(), 1, timeline.NextCursor, 2, true)
+		require.NoError(t, err)
+		assert.Equal(t, []models.AccountEvent{}, timeline.Events)
+		assert.False(t, timeline.HasMore)
+	})
+
+	t.Run("Closed account", func(t *testing.T) {
+		accountRepo.On("GetAccount", int64(2), false).Return(nil, fmt.Errorf("account with ID 2 %w", repository.ErrNotFound)).Once()
+		_, err := svc.AccountTimeline(context.Background(), 2, "", 10, false)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+
+	t.Run("Invalid cursor", func(t *testing.T) {
+		_, err := svc.AccountTimeline(context.Background(), 1, "not a cursor", 10, false)
+		assert.ErrorIs(t, err, service.ErrInvalidCursor)
+	})
+
+	accountRepo.AssertExpectations(t)
+	timelineRepo.AssertExpectations(t)
+}
+
+type MockImpersonationRepository struct {
+	mock.Mock
+}
+
+func (m *MockImpersonationRepository) InsertImpersonation(i models.Impersonation) error {
+	return m.Called(i).Error(0)
+}
+
+func (m *MockImpersonationRepository) ListImpersonations(filter models.ImpersonationFilter, after repository.ChangeCursor, limit int) ([]models.Impersonation, repository.ChangeCursor, error) {
+	args := m.Called(filter, after, limit)
+	impersonations, _ := args.Get(0).([]models.Impersonation)
+	return impersonations, args.Get(1).(repository.ChangeCursor), args.Error(2)
+}
+
+func TestImpersonations(t *testing.T) {
+	record := models.Impersonation{Operator: "alice", APIKey: "key-1", Tenant: "default", Method: "GET", Path: "/accounts/1"}
+	svc := service.NewService(nil, new(MockAccountRepository), new(MockTransactionRepository))
+	assert.Error(t, svc.RecordImpersonation(record), "without an audit trail impersonation is refused")
+	_, err := svc.ListImpersonations(models.ImpersonationFilter{}, "", 10)
+	assert.Error(t, err, "disabled")
+
+	repo := new(MockImpersonationRepository)
+	svc = service.NewService(nil, new(MockAccountRepository), new(MockTransactionRepository), service.WithImpersonationAudit(repo))
+
+	repo.On("InsertImpersonation", record).Return(errors.New("connection refused")).Once()
+	assert.Error(t, svc.RecordImpersonation(record), "a failed audit is returned")
+
+	filter := models.ImpersonationFilter{Operator: "alice"}
+	last := repository.ChangeCursor{UpdatedAt: time.Date(2026, 3, 14, 9, 30, 0, 0, time.UTC), ID: 4}
+	repo.On("ListImpersonations", filter, repository.ChangeCursor{}, 1).Return([]models.Impersonation{record}, last, nil).Once()
+	page, err := svc.ListImpersonations(filter, "", 1)
+	require.NoError(t, err)
+	assert.Equal(t, []models.Impersonation{record}, page.Impersonations)
+	assert.True(t, page.HasMore)
+
+	repo.On("ListImpersonations", filter, last, 1).Return(nil, last, nil).Once()
+	page, err = svc.ListImpersonations(filter, page.NextCursor, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []models.Impersonation{}, page.Impersonations)
+	assert.False(t, page.HasMore)
+
+	repo.AssertExpectations(t)
+}
+
+type MockTransferFreezeRepository struct {
+	mock.Mock
+}
+
+func (m *MockTransferFreezeRepository) InsertTransferFreeze(f models.TransferFreeze) (*models.TransferFreeze, error) {
+	args := m.Called(f)
+	freeze, _ := args.Get(0).(*models.TransferFreeze)
+	return freeze, args.Error(1)
+}
+
+func (m *MockTransferFreezeRepository) ListTransferFreezesInForce(now time.Time) ([]models.TransferFreeze, error) {
+	args := m.Called(now)
+	freezes, _ := args.Get(0).([]models.TransferFreeze)
+	return freezes, args.Error(1)
+}
+
+func (m *MockTransferFreezeRepository) ListTransferFreezes(limit int) ([]models.TransferFreeze, error) {
+	args := m.Called(limit)
+	freezes, _ := args.Get(0).([]models.TransferFreeze)
+	return freezes, args.Error(1)
+}
+
+func (m *MockTransferFreezeRepository) LiftTransferFreezes(tenant, liftedBy string, now time.Time) ([]models.TransferFreeze, error) {
+	args := m.Called(tenant, liftedBy, now)
+	freezes, _ := args.Get(0).([]models.TransferFreeze)
+	return freezes, args.Error(1)
+}
+
+func (m *MockTransferFreezeRepository) GetAccountTenant(accountID int64) (string, error) {
+	args := m.Called(accountID)
+	return args.String(0), args.Error(1)
+}
+
+func TestTransferFreezes(t *testing.T) {
+	_, err := service.NewService(nil, new(MockAccountRepository), new(MockTransactionRepository)).FreezeTransfers(models.TransferFreezeRequest{Duration: "1h", Reason: "x", Operator: "alice"})
+	assert.Error(t, err, "disabled")
+
+	db, mockDB, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	errBegin := errors.New("begin refused")
+	clk := clock.NewFake(time.Date(2026, 3, 14, 9, 0, 0, 0, time.UTC))
+	repo := new(MockTransferFreezeRepository)
+	svc := service.NewService(db, new(MockAccountRepository), new(MockTransactionRepository), service.WithClock(clk), service.WithTransferFreezes(repo))
+
+	// passes reports whether a transfer out of account 1 got past the freezes:
+	// one that does fails to begin its database transaction instead.
+	passes := func() bool {
+		t.Helper()
+		_, err := svc.CreateTransaction(context.Background(), 1, 2, 10, nil)
+		if errors.Is(err, service.ErrTransfersFrozen) {
+			assert.Equal(t, models.ReasonTransfersFrozen, service.RejectionReason(err))
+			return false
+		}
+		require.ErrorIs(t, err, errBegin)
+		return true
+	}
+	expectBegin := func() { mockDB.ExpectBegin().WillReturnError(errBegin) }
+
+	t.Run("Invalid", func(t *testing.T) {
+		for _, req := range []models.TransferFreezeRequest{
+			{Duration: "forever", Reason: "x", Operator: "alice"},
+			{Duration: "-1h", Reason: "x", Operator: "alice"},
+			{Duration: "25h", Reason: "x", Operator: "alice"},
+			{Duration: "1h", Reason: "x"},
+			{Duration: "1h", Operator: "alice"},
+		} {
+			_, err := svc.FreezeTransfers(req)
+			assert.ErrorIs(t, err, service.ErrInvalidFreeze, "%+v", req)
+		}
+	})
+
+	t.Run("No freeze", func(t *testing.T) {
+		repo.On("ListTransferFreezesInForce", clk.Now()).Return(nil, nil).Once()
+		expectBegin()
+		assert.True(t, passes())
+		expectBegin()
+		assert.True(t, passes(), "the freezes read are reused")
+	})
+
+	all := models.TransferFreeze{ID: 1, Tenant: models.FreezeAllTenants, Reason: "suspected compromise", FrozenBy: "alice", CreatedAt: clk.Now(), ExpiresAt: clk.Now().Add(time.Hour)}
+	t.Run("Freeze every tenant", func(t *testing.T) {
+		repo.On("InsertTransferFreeze", models.TransferFreeze{Tenant: models.FreezeAllTenants, Reason: "suspected compromise", FrozenBy: "alice", ExpiresAt: clk.Now().Add(time.Hour)}).Return(&all, nil).Once()
+		freeze, err := svc.FreezeTransfers(models.TransferFreezeRequest{Duration: "1h", Reason: "suspected compromise", Operator: "alice"})
+		require.NoError(t, err)
+		assert.Equal(t, &all, freeze)
+
+		repo.On("ListTransferFreezesInForce", clk.Now()).Return([]models.TransferFreeze{all}, nil).Once()
+		assert.False(t, passes(), "the freeze takes effect at once")
+
+		clk.Advance(time.Hour)
+		repo.On("ListTransferFreezesInForce", clk.Now()).Return([]models.TransferFreeze{all}, nil).Once()
+		expectBegin()
+		assert.True(t, passes(), "the freeze expires on time")
+	})
+
+	t.Run("Freeze one tenant", func(t *testing.T) {
+		clk.Advance(time.Second)
+		acme := models.TransferFreeze{ID: 2, Tenant: "acme", Reason: "leaked key", FrozenBy: "alice", CreatedAt: clk.Now(), ExpiresAt: clk.Now().Add(time.Hour)}
+		repo.On("ListTransferFreezesInForce", clk.Now()).Return([]models.TransferFreeze{acme}, nil).Once()
+		repo.On("GetAccountTenant", int64(1)).Return("acme", nil).Once()
+		assert.False(t, passes())
+
+		repo.On("GetAccountTenant", int64(1)).Return("globex", nil).Once()
+		expectBegin()
+		assert.True(t, passes(), "other tenants are not frozen")
+
+		lifted := acme
+		lifted.LiftedAt, lifted.LiftedBy = &acme.CreatedAt, "bob"
+		repo.On("LiftTransferFreezes", "acme", "bob", clk.Now()).Return([]models.TransferFreeze{lifted}, nil).Once()
+		got, err := svc.LiftTransferFreezes(models.LiftFreezeRequest{Tenant: "acme", Operator: "bob"})
+		require.NoError(t, err)
+		assert.Equal(t, []models.TransferFreeze{lifted}, got)
+
+		repo.On("ListTransferFreezesInForce", clk.Now()).Return(nil, nil).Once()
+		expectBegin()
+		assert.True(t, passes(), "lifting takes effect at once")
+	})
+
+	t.Run("Lift without a freeze", func(t *testing.T) {
+		repo.On("LiftTransferFreezes", models.FreezeAllTenants, "bob", clk.Now()).Return(nil, nil).Once()
+		_, err := svc.LiftTransferFreezes(models.LiftFreezeRequest{Operator: "bob"})
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+
+	repo.AssertExpectations(t)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestTransferFreezes_Unreadable(t *testing.T) {
+	db, mockDB := newMockDB(t)
+	errBegin := errors.New("begin refused")
+	errRead := errors.New("connection refused")
+	clk := clock.NewFake(time.Date(2026, 3, 14, 9, 0, 0, 0, time.UTC))
+	repo := new(MockTransferFreezeRepository)
+	svc := service.NewService(db, new(MockAccountRepository), new(MockTransactionRepository), service.WithClock(clk), service.WithTransferFreezes(repo))
+
+	// Until the freezes have been read once, transfers are refused.
+	repo.On("ListTransferFreezesInForce", clk.Now()).Return(nil, errRead).Once()
+	_, err := svc.CreateTransaction(context.Background(), 1, 2, 10, nil)
+	assert.ErrorIs(t, err, service.ErrTransfersFrozen)
+	assert.ErrorIs(t, err, errRead, "a database outage is still told apart, e.g. to forward the transfer")
+
+	repo.On("ListTransferFreezesInForce", clk.Now()).Return(nil, nil).Once()
+	mockDB.ExpectBegin().WillReturnError(errBegin)
+	_, err = svc.CreateTransaction(context.Background(), 1, 2, 10, nil)
+	assert.ErrorIs(t, err, errBegin)
+
+	// Once they have, the last ones read stand while they cannot be read again.
+	clk.Advance(time.Minute)
+	repo.On("ListTransferFreezesInForce", clk.Now()).Return(nil, errRead).Once()
+	mockDB.ExpectBegin().WillReturnError(errBegin)
+	_, err = svc.CreateTransaction(context.Background(), 1, 2, 10, nil)
+	assert.ErrorIs(t, err, errBegin)
+
+	repo.AssertExpectations(t)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestTransferFreezes_Replay(t *testing.T) {
+	db, mockDB := newMockDB(t)
+	clk := clock.NewFake(time.Date(2026, 3, 14, 9, 0, 0, 0, time.UTC))
+	freezeRepo := new(MockTransferFreezeRepository)
+	attemptRepo := new(MockTransactionAttemptRepository)
+	svc := service.NewService(db, new(MockAccountRepository), new(MockTransactionRepository), service.WithClock(clk),
+		service.WithTransferFreezes(freezeRepo), service.WithTransactionAttemptRepository(attemptRepo))
+
+	all := models.TransferFreeze{ID: 1, Tenant: models.FreezeAllTenants, Reason: "suspected compromise", FrozenBy: "alice", CreatedAt: clk.Now(), ExpiresAt: clk.Now().Add(time.Hour)}
+	freezeRepo.On("ListTransferFreezesInForce", clk.Now()).Return([]models.TransferFreeze{all}, nil).Once()
+	attemptRepo.On("GetTransactionAttempt", int64(4)).Return(&models.TransactionAttempt{ID: 4, SourceAccountID: 1, DestinationAccountID: 2, Amount: 50}, nil).Once()
+	attemptRepo.On("GetTransactionAttemptReplay", int64(4)).Return("", repository.ErrNotFound).Once()
+
+	// The replay is refused before its database transaction begins.
+	replay, err := svc.ReplayTransactionAttempt(4, true)
+	require.NoError(t, err)
+	assert.Equal(t, models.ReplayRejected, replay.Outcome)
+	assert.Equal(t, models.ReasonTransfersFrozen, replay.Code)
+
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+	freezeRepo.AssertExpectations(t)
+	attemptRepo.AssertExpectations(t)
+}
+
+type MockAmountBoundsRepository struct {
+	mock.Mock
+}
+
+func (m *MockAmountBoundsRepository) SetAmountBounds(b models.AmountBounds) (*models.AmountBounds, error) {
+	args := m.Called(b)
+	bounds, _ := args.Get(0).(*models.AmountBounds)
+	return bounds, args.Error(1)
+}
+
+func (m *MockAmountBoundsRepository) ListAmountBounds() ([]models.AmountBounds, error) {
+	args := m.Called()
+	bounds, _ := args.Get(0).([]models.AmountBounds)
+	return bounds, args.Error(1)
+}
+
+func (m *MockAmountBoundsRepository) DeleteAmountBounds(tenant string) error {
+	return m.Called(tenant).Error(0)
+}
+
+func (m *MockAmountBoundsRepository) GetAccountTenant(accountID int64) (string, error) {
+	args := m.Called(accountID)
+	return args.String(0), args.Error(1)
+}
+
+func TestAmountBounds(t *testing.T) {
+	_, err := service.NewService(nil, new(MockAccountRepository), new(MockTransactionRepository)).SetAmountBounds("*", models.SetAmountBoundsRequest{MinAmount: 0.01})
+	assert.Error(t, err, "disabled")
+
+	db, mockDB, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	errBegin := errors.New("begin refused")
+	clk := clock.NewFake(time.Date(2026, 3, 14, 9, 0, 0, 0, time.UTC))
+	repo := new(MockAmountBoundsRepository)
+	svc := service.NewService(db, new(MockAccountRepository), new(MockTransactionRepository), service.WithClock(clk), service.WithAmountBounds(repo))
+
+	// passes reports whether a transfer of amount out of account 1 got past the
+	// bounds: one that does fails to begin its database transaction instead.
+	passes := func(amount float64) bool {
+		t.Helper()
+		_, err := svc.CreateTransaction(context.Background(), 1, 2, amount, nil)
+		if errors.Is(err, service.ErrAmountOutOfBounds) {
+			assert.Equal(t, models.ReasonLimitExceeded, service.RejectionReason(err))
+			return false
+		}
+		require.ErrorIs(t, err, errBegin)
+		return true
+	}
+	expectBegin := func() { mockDB.ExpectBegin().WillReturnError(errBegin) }
+
+	t.Run("Invalid", func(t *testing.T) {
+		for _, req := range []models.SetAmountBoundsRequest{
+			{MinAmount: -1},
+			{MaxAmount: -1},
+			{MinAmount: 10, MaxAmount: 5},
+		} {
+			_, err := svc.SetAmountBounds("*", req)
+			assert.ErrorIs(t, err, service.ErrInvalidAmountBounds, "%+v", req)
+		}
+	})
+
+	t.Run("No bounds", func(t *testing.T) {
+		repo.On("ListAmountBounds").Return(nil, nil).Once()
+		expectBegin()
+		assert.True(t, passes(0.001))
+		expectBegin()
+		assert.True(t, passes(1e9), "the bounds read are reused")
+	})
+
+	global := models.AmountBounds{Tenant: models.AmountBoundsAllTenants, MinAmount: 0.01, MaxAmount: 1000000}
+	t.Run("Global bounds", func(t *testing.T) {
+		repo.On("SetAmountBounds", models.AmountBounds{Tenant: models.AmountBoundsAllTenants, MinAmount: 0.01, MaxAmount: 1000000}).Return(&global, nil).Once()
+		bounds, err := svc.SetAmountBounds(models.AmountBoundsAllTenants, models.SetAmountBoundsRequest{MinAmount: 0.01, MaxAmount: 1000000})
+		require.NoError(t, err)
+		assert.Equal(t, &global, bounds)
+
+		repo.On("ListAmountBounds").Return([]models.AmountBounds{global}, nil).Once()
+		assert.False(t, passes(0.001), "the bounds take effect at once")
+		assert.False(t, passes(1000000.01))
+		expectBegin()
+		assert.True(t, passes(0.01), "the bounds are inclusive")
+		expectBegin()
+		assert.True(t, passes(1000000))
+	})
+
+	t.Run("Tenant bounds", func(t *testing.T) {
+		clk.Advance(time.Second)
+		payroll := models.AmountBounds{Tenant: "payroll", MinAmount: 1, MaxAmount: 50000}
+		repo.On("ListAmountBounds").Return([]models.AmountBounds{global, payroll}, nil).Once()
+		repo.On("GetAccountTenant", int64(1)).Return("payroll", nil).Twice()
+		assert.False(t, passes(60000))
+		expectBegin()
+		assert.True(t, passes(50000))
+		assert.False(t, passes(2000000), "the global bounds still apply")
+
+		repo.On("GetAccountTenant", int64(1)).Return("acme", nil).Once()
+		expectBegin()
+		assert.True(t, passes(60000), "other tenants have the global bounds")
+
+		assert.Equal(t, models.AmountBounds{Tenant: "payroll", MinAmount: 1, MaxAmount: 50000}, svc.AmountBoundsOf("payroll"))
+		assert.Equal(t, models.AmountBounds{Tenant: "acme", MinAmount: 0.01, MaxAmount: 1000000}, svc.AmountBoundsOf("acme"))
+
+		repo.On("DeleteAmountBounds", "payroll").Return(nil).Once()
+		require.NoError(t, svc.DeleteAmountBounds("payroll"))
+		repo.On("ListAmountBounds").Return([]models.AmountBounds{global}, nil).Once()
+		expectBegin()
+		assert.True(t, passes(60000), "the tenant's bounds are removed at once")
+	})
+
+	repo.AssertExpectations(t)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestAmountBounds_EveryPath(t *testing.T) {
+	db, mockDB := newMockDB(t)
+	boundsRepo := new(MockAmountBoundsRepository)
+	attemptRepo := new(MockTransactionAttemptRepository)
+	tokenRepo := new(MockSpendingTokenRepository)
+	svc := service.NewService(db, new(MockAccountRepository), new(MockTransactionRepository),
+		service.WithAmountBounds(boundsRepo),
+		service.WithTransactionAttemptRepository(attemptRepo),
+		service.WithSpendingTokens(tokenRepo),
+		service.WithIngestRepository(new(MockIngestRepository)))
+	boundsRepo.On("ListAmountBounds").Return([]models.AmountBounds{{Tenant: models.AmountBoundsAllTenants, MaxAmount: 100}}, nil).Once()
+
+	// Each path refuses a transfer of 150 before its database transaction begins.
+	t.Run("Payroll", func(t *testing.T) {
+		_, err := svc.CommitPayroll(context.Background(), models.PayrollRequest{EmployerAccountID: 1, Lines: []models.PayrollLine{{AccountID: 2, Amount: 50}, {AccountID: 3, Amount: 150}}})
+		assert.ErrorIs(t, err, service.ErrAmountOutOfBounds)
+		assert.ErrorContains(t, err, "line 1")
+	})
+
+	t.Run("Spending token", func(t *testing.T) {
+		tokenRepo.On("GetSpendingToken", "tok_1").Return(&models.SpendingToken{ID: "tok_1", AccountID: 1, Limit: 500}, nil).Once()
+		_, err := svc.DebitWithToken(context.Background(), "tok_1", models.TokenDebitRequest{DestinationAccountID: 2, Amount: 150})
+		assert.ErrorIs(t, err, service.ErrAmountOutOfBounds)
+	})
+
+	t.Run("Ingest", func(t *testing.T) {
+		_, err := svc.IngestTransfers([]models.IngestTransfer{
+			{SourceAccountID: 1, DestinationAccountID: 2, Amount: 50},
+			{SourceAccountID: 1, DestinationAccountID: 2, Amount: 150},
+		})
+		var rejected *service.IngestRejectedError
+		require.ErrorAs(t, err, &rejected)
+		require.Len(t, rejected.Errors, 1)
+		assert.Equal(t, 1, rejected.Errors[0].Line)
+		assert.Equal(t, models.ReasonLimitExceeded, rejected.Errors[0].Code)
+	})
+
+	t.Run("Replay", func(t *testing.T) {
+		attemptRepo.On("GetTransactionAttempt", int64(4)).Return(&models.TransactionAttempt{ID: 4, SourceAccountID: 1, DestinationAccountID: 2, Amount: 150}, nil).Once()
+		attemptRepo.On("GetTransactionAttemptReplay", int64(4)).Return("", repository.ErrNotFound).Once()
+		replay, err := svc.ReplayTransactionAttempt(4, true)
+		require.NoError(t, err)
+		assert.Equal(t, models.ReplayRejected, replay.Outcome)
+		assert.Equal(t, models.ReasonLimitExceeded, replay.Code)
+	})
+
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+	boundsRepo.AssertExpectations(t)
+	attemptRepo.AssertExpectations(t)
+	tokenRepo.AssertExpectations(t)
+}
+
+type MockSecurityEventRepository struct {
+	mock.Mock
+}
+
+func (m *MockSecurityEventRepository) InsertSecurityEvent(e models.SecurityEvent) (*models.SecurityEvent, error) {
+	args := m.Called(e)
+	event, _ := args.Get(0).(*models.SecurityEvent)
+	return event, args.Error(1)
+}
+
+func (m *MockSecurityEventRepository) ListSecurityEvents(afterID int64, limit int) ([]models.SecurityEvent, error) {
+	args := m.Called(afterID, limit)
+	events, _ := args.Get(0).([]models.SecurityEvent)
+	return events, args.Error(1)
+}
+
+func TestListSecurityEvents(t *testing.T) {
+	securityRepo := new(MockSecurityEventRepository)
+	svc := service.NewService(nil, new(MockAccountRepository), new(MockTransactionRepository), service.WithSecurityEvents(securityRepo))
+
+	securityRepo.On("ListSecurityEvents", int64(0), 2).Return([]models.SecurityEvent{{ID: 1}, {ID: 2}}, nil).Once()
+	page, err := svc.ListSecurityEvents(0, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []models.SecurityEvent{{ID: 1}}, page.Events)
+	assert.Equal(t, int64(1), page.NextAfterID)
+	assert.True(t, page.HasMore)
+
+	securityRepo.On("ListSecurityEvents", int64(2), 2).Return(nil, nil).Once()
+	page, err = svc.ListSecurityEvents(2, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []models.SecurityEvent{}, page.Events)
+	assert.Equal(t, int64(2), page.NextAfterID)
+	assert.False(t, page.HasMore)
+
+	_, err = svc.ListSecurityEvents(-1, 1)
+	assert.ErrorIs(t, err, service.ErrInvalidOffset)
+
+	_, err = service.NewService(nil, new(MockAccountRepository), new(MockTransactionRepository)).ListSecurityEvents(0, 1)
+	assert.Error(t, err)
+	securityRepo.AssertExpectations(t)
+}
+
+type MockIndexAdvisoryRepository struct {
+	mock.Mock
+}
+
+func (m *MockIndexAdvisoryRepository) ListIndexes(_ context.Context) (map[string]string, error) {
+	args := m.Called()
+	indexes, _ := args.Get(0).(map[string]string)
+	return indexes, args.Error(1)
+}
+
+func (m *MockIndexAdvisoryRepository) ListTableScans(_ context.Context) ([]models.TableScans, error) {
+	args := m.Called()
+	tables, _ := args.Get(0).([]models.TableScans)
+	return tables, args.Error(1)
+}
+
+func (m *MockIndexAdvisoryRepository) ListStatementScans(_ context.Context, limit int) ([]models.StatementScans, bool, error) {
+	args := m.Called(limit)
+	statements, _ := args.Get(0).([]models.StatementScans)
+	return statements, args.Bool(1), args.Error(2)
+}
+
+func TestIndexAdvisory(t *testing.T) {
+	advisoryRepo := new(MockIndexAdvisoryRepository)
+	expected := map[string]string{
+		"accounts_largest_idx":            "accounts",
+		"transactions_source_created_idx": "transactions",
+		"pending_actions_open_kind_idx":   "pending_actions",
+	}
+	svc := service.NewService(nil, new(MockAccountRepository), new(MockTransactionRepository), service.WithIndexAdvisory(advisoryRepo, expected))
+
+	advisoryRepo.On("ListIndexes").Return(map[string]string{"accounts_largest_idx": "accounts", "accounts_pkey": "accounts"}, nil).Once()
+	advisoryRepo.On("ListTableScans").Return([]models.TableScans{
+		{Table: "transactions", SeqScans: 40, SeqRowsRead: 400_000, IndexScans: 3},
+		{Table: "accounts", SeqScans: 40, SeqRowsRead: 400, IndexScans: 3},
+		{Table: "ledger_entries", SeqScans: 40, SeqRowsRead: 400_000, IndexScans: 900},
+	}, nil).Once()
+	advisoryRepo.On("ListStatementScans", 20).Return(nil, false, nil).Once()
+
+	report, err := svc.IndexAdvisory(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []models.MissingIndex{
+		{Name: "pending_actions_open_kind_idx", Table: "pending_actions"},
+		{Name: "transactions_source_created_idx", Table: "transactions"},
+	}, report.MissingIndexes)
+	require.Len(t, report.Tables, 3)
+	assert.True(t, report.Tables[0].SeqScanHeavy)
+	assert.False(t, report.Tables[1].SeqScanHeavy)
+	assert.False(t, report.Tables[2].SeqScanHeavy)
+	assert.Nil(t, report.Statements)
+
+	_, err = service.NewService(nil, new(MockAccountRepository), new(MockTransactionRepository)).IndexAdvisory(context.Background())
+	assert.Error(t, err)
+	advisoryRepo.AssertExpectations(t)
+}
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository"
+	"github.com/nehciyy/intrapay/internal/sandbox"
+)
+
+var (
+	// ErrInvalidSpendingToken is returned for a spending token with a limit that
+	// is not positive, an expiry that is not in the future, or a blank merchant
+	// category.
+	ErrInvalidSpendingToken = errors.New("invalid spending token")
+	// ErrInvalidTokenDebit is returned for a debit with a spending token whose
+	// amount is not positive or whose destination is the token's own account.
+	ErrInvalidTokenDebit = errors.New("invalid token debit")
+	// ErrTokenNotFound is returned for a debit with a spending token that does
+	// not exist.
+	ErrTokenNotFound = errors.New("not found")
+	// ErrTokenRevoked is returned for a debit with a revoked spending token.
+	ErrTokenRevoked = errors.New("spending token revoked")
+	// ErrTokenExpired is returned for a debit with a spending token past its expiry.
+	ErrTokenExpired = errors.New("spending token expired")
+	// ErrTokenLimitExceeded is returned for a debit that does not fit in what is
+	// left of the spending token's limit.
+	ErrTokenLimitExceeded = errors.New("spending token limit exceeded")
+	// ErrMerchantCategoryNotAllowed is returned for a debit at a merchant category
+	// the spending token is not allowed to spend at.
+	ErrMerchantCategoryNotAllowed = errors.New("merchant category not allowed")
+
+	errSpendingTokensDisabled = errors.New("spending tokens are not enabled")
+)
+
+// CreateSpendingToken issues a spending token that debits accountID within the
+// limit, expiry and merchant categories of req.
+func (s *DefaultService) CreateSpendingToken(ctx context.Context, accountID int64, req models.SpendingTokenRequest) (*models.SpendingToken, error) {
+	if s.tokenRepo == nil {
+		return nil, errSpendingTokensDisabled
+	}
+	switch {
+	case req.Limit <= 0:
+		return nil, fmt.Errorf("%w: limit must be positive", ErrInvalidSpendingToken)
+	case req.ExpiresAt != nil && !req.ExpiresAt.After(s.clock.Now()):
+		return nil, fmt.Errorf("%w: expires_at must be in the future", ErrInvalidSpendingToken)
+	}
+	categories := make([]string, 0, len(req.MerchantCategories))
+	for _, c := range req.MerchantCategories {
+		c = strings.TrimSpace(c)
+		if c == "" {
+			return nil, fmt.Errorf("%w: merchant categories must not be blank", ErrInvalidSpendingToken)
+		}
+		if !slices.Contains(categories, c) {
+			categories = append(categories, c)
+		}
+	}
+
+	exists, err := s.AccountExists(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("account with ID %d %w", accountID, repository.ErrNotFound)
+	}
+
+	id, err := newTokenID()
+	if err != nil {
+		return nil, fmt.Errorf("generate spending token ID: %w", err)
+	}
+	return s.tokenRepo.InsertSpendingToken(models.SpendingToken{
+		ID:                 id,
+		AccountID:          accountID,
+		Limit:              req.Limit,
+		MerchantCategories: categories,
+		ExpiresAt:          req.ExpiresAt,
+	})
+}
+
+// GetSpendingToken returns a spending token with what it has spent so far.
+func (s *DefaultService) GetSpendingToken(ctx context.Context, id string) (*models.SpendingToken, error) {
+	if s.tokenRepo == nil {
+		return nil, errSpendingTokensDisabled
+	}
+	token, err := s.tokenRepo.GetSpendingToken(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkTestModeToken(ctx, token); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// checkTestModeToken refuses a test-mode request for the spending token of a
+// live account as if the token did not exist.
+func (s *DefaultService) checkTestModeToken(ctx context.Context, token *models.SpendingToken) error {
+	err := s.checkTestMode(ctx, token.AccountID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return fmt.Errorf("spending token %s %w", token.ID, repository.ErrNotFound)
+	}
+	return err
+}
+
+// ListSpendingTokens returns every spending token of accountID, oldest first.
+func (s *DefaultService) ListSpendingTokens(ctx context.Context, accountID int64) ([]models.SpendingToken, error) {
+	if s.tokenRepo == nil {
+		return nil, errSpendingTokensDisabled
+	}
+	exists, err := s.AccountExists(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("account with ID %d %w", accountID, repository.ErrNotFound)
+	}
+	return s.tokenRepo.ListSpendingTokens(accountID)
+}
+
+// RevokeSpendingToken stops a spending token from debiting its account. The
+// token stays listed, with the time it was revoked.
+func (s *DefaultService) RevokeSpendingToken(ctx context.Context, id string) (*models.SpendingToken, error) {
+	if s.tokenRepo == nil {
+		return nil, errSpendingTokensDisabled
+	}
+	if !sandbox.Livemode(ctx) {
+		if _, err := s.GetSpendingToken(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+	return s.tokenRepo.RevokeSpendingToken(id)
+}
+
+// DebitWithToken transfers req.Amount from the account of spending token id to
+// req.DestinationAccountID. The debit is checked against the token before the
+// account is touched, and again under the token's lock in the transaction of
+// the transfer, so concurrent debits cannot together overspend it.
+func (s *DefaultService) DebitWithToken(ctx context.Context, id string, req models.TokenDebitRequest) (*models.TokenDebit, error) {
+	if s.tokenRepo == nil {
+		return nil, errSpendingTokensDisabled
+	}
+	token, err := s.tokenRepo.GetSpendingToken(id)
+	if err == nil {
+		err = s.checkTestModeToken(ctx, token)
+	}
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("spending token %s %w", id, ErrTokenNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case req.Amount <= 0:
+		return nil, fmt.Errorf("%w: amount must be positive", ErrInvalidTokenDebit)
+	case req.DestinationAccountID == token.AccountID:
+		return nil, fmt.Errorf("%w: a token cannot pay its own account", ErrInvalidTokenDebit)
+	}
+	if err := s.checkTokenDebit(token, req); err != nil {
+		return nil, err
+	}
+
+	var cashback *models.TransferIntent
+	transactionID, err := s.transfer(ctx, token.AccountID, req.DestinationAccountID, float64(req.Amount), models.TransactionTransfer, func(tx *sql.Tx, transactionID string) error {
+		locked, err := s.tokenRepo.GetSpendingTokenForUpdateTx(tx, id)
+		if err != nil {
+			return err
+		}
+		if err := s.checkTokenDebit(locked, req); err != nil {
+			return err
+		}
+		if token, err = s.tokenRepo.AddSpendingTokenSpentTx(tx, id, float64(req.Amount)); err != nil {
+			return err
+		}
+		cashback, err = s.recordCashbackIntentTx(tx, transactionID, locked.AccountID, float64(req.Amount))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.payCashback(transactionID, token.AccountID, float64(req.Amount), cashback)
+	return &models.TokenDebit{TransactionID: transactionID, Token: token}, nil
+}
+
+// checkTokenDebit reports whether token may make the debit req now.
+func (s *DefaultService) checkTokenDebit(token *models.SpendingToken, req models.TokenDebitRequest) error {
+	switch {
+	case token.RevokedAt != nil:
+		return fmt.Errorf("%w: %s", ErrTokenRevoked, token.ID)
+	case token.ExpiresAt != nil && !s.clock.Now().Before(*token.ExpiresAt):
+		return fmt.Errorf("%w: %s expired at %s", ErrTokenExpired, token.ID, token.ExpiresAt.Format(time.RFC3339))
+	case len(token.MerchantCategories) > 0 && !slices.Contains(token.MerchantCategories, req.MerchantCategory):
+		return fmt.Errorf("%w: %q for spending token %s", ErrMerchantCategoryNotAllowed, req.MerchantCategory, token.ID)
+	}
+	left := token.Limit.Minor() - token.Spent.Minor()
+	if req.Amount.Minor() > left {
+		return fmt.Errorf("%w: %s left on %s", ErrTokenLimitExceeded, fromMinor(left), token.ID)
+	}
+	return nil
+}
+
+// newTokenID generates an unguessable spending token ID.
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "tok_" + hex.EncodeToString(b), nil
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/nehciyy/intrapay/internal/models"
+)
+
+// ErrInvalidDisplayName is returned for a display name that is too long or
+// contains control characters.
+var ErrInvalidDisplayName = errors.New("invalid display name")
+
+// maxDisplayNameLength is the most characters a display name may have.
+const maxDisplayNameLength = 64
+
+// SetDisplayName sets the name an active account is shown by to the other side
+// of its transfers, and returns the account. An empty name clears it.
+func (s *DefaultService) SetDisplayName(ctx context.Context, accountID int64, name string) (*models.Account, error) {
+	name = strings.TrimSpace(name)
+	if utf8.RuneCountInString(name) > maxDisplayNameLength {
+		return nil, fmt.Errorf("%w: longer than %d characters", ErrInvalidDisplayName, maxDisplayNameLength)
+	}
+	if strings.IndexFunc(name, unicode.IsControl) >= 0 {
+		return nil, fmt.Errorf("%w: contains control characters", ErrInvalidDisplayName)
+	}
+	if err := s.checkTestMode(ctx, accountID); err != nil {
+		return nil, err
+	}
+	if err := s.accountRepo.SetDisplayName(accountID, name); err != nil {
+		return nil, err
+	}
+	return withAvailableBalance(s.accountRepo.GetAccount(context.Background(), accountID, false))
+}
+
+// AccountSummary returns the balances of an active account with the transfers
+// it sent and received this calendar month in loc, so that the month starts at
+// local midnight of the business the account belongs to.
+func (s *DefaultService) AccountSummary(ctx context.Context, accountID int64, loc *time.Location) (*models.AccountSummary, error) {
+	account, err := withAvailableBalance(s.getAccount(ctx, accountID, false))
+	if err != nil {
+		return nil, err
+	}
+	now := s.clock.Now().In(loc)
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, loc).UTC()
+	flows, err := s.transactionRepo.SumAccountFlows(ctx, accountID, monthStart)
+	if err != nil {
+		return nil, err
+	}
+	return &models.AccountSummary{
+		AccountID:        account.AccountID,
+		DisplayName:      account.DisplayName,
+		Balance:          account.Balance,
+		AvailableBalance: account.AvailableBalance,
+		Held:             account.Balance - account.AvailableBalance,
+		Timezone:         loc.String(),
+		MonthToDate:      *flows,
+	}, nil
+}
+
+// ListAccountTransactions returns a page of up to limit transfers sent or
+// received by accountID, oldest-updated first, starting after cursor, each with
+// its counterparty. Unless unredacted is set, as on the admin API, counterparty
+// display names are masked and those of closed accounts left out; the statement
+// of a closed account is then not found either.
+func (s *DefaultService) ListAccountTransactions(ctx context.Context, accountID int64, cursor string, limit int, unredacted bool) (*models.TransactionPage, error) {
+	after, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.getAccount(ctx, accountID, unredacted); err != nil {
+		return nil, err
+	}
+	transactions, next, err := s.transactionRepo.ListAccountTransactions(ctx, accountID, after, limit)
+	if err != nil {
+		return nil, err
+	}
+	if !unredacted {
+		for _, t := range transactions {
+			redactCounterparty(t.Counterparty)
+		}
+	}
+	return newTransactionPage(transactions, next, limit), nil
+}
+
+// redactCounterparty masks the display name of c, or drops it if the account
+// is closed.
+func redactCounterparty(c *models.Counterparty) {
+	if c == nil || c.DisplayName == "" {
+		return
+	}
+	c.Redacted = true
+	if c.Deleted {
+		c.DisplayName = ""
+		return
+	}
+	c.DisplayName = maskName(c.DisplayName)
+}
+
+// maskName keeps the first letter of each word of name, e.g. "J*** D***" for
+// "Jane Doe", enough to recognise a known counterparty without disclosing the
+// name.
+func maskName(name string) string {
+	words := strings.Fields(name)
+	for i, w := range words {
+		first, _ := utf8.DecodeRuneInString(w)
+		words[i] = string(first) + "***"
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository"
+)
+
+var errSuspenseDisabled = errors.New("suspense account is not configured")
+
+// ReceivePayment credits an inbound payment to destID. If the destination cannot
+// be resolved because the account does not exist or is closed, the payment is
+// parked on the suspense account instead and returned with its suspense item.
+// Without a suspense account it fails like CreateTransaction, as does a payment
+// between a live and a test-mode account, which is never parked.
+func (s *DefaultService) ReceivePayment(ctx context.Context, sourceID, destID int64, amount float64, reference string) (*models.InboundPayment, error) {
+	transactionID, err := s.CreateTransaction(ctx, sourceID, destID, amount, nil)
+	if err == nil {
+		return &models.InboundPayment{TransactionID: transactionID}, nil
+	}
+	if !errors.Is(err, ErrDestinationNotFound) || s.suspenseRepo == nil {
+		return nil, err
+	}
+
+	reason, err := s.unresolvedReason(destID)
+	if err != nil {
+		return nil, err
+	}
+	if reason == "" {
+		// Restored since the transfer was attempted: try it once more, and fail
+		// if it fails again rather than park it.
+		transactionID, err := s.CreateTransaction(ctx, sourceID, destID, amount, nil)
+		if err != nil {
+			return nil, err
+		}
+		return &models.InboundPayment{TransactionID: transactionID}, nil
+	}
+
+	var item *models.SuspenseItem
+	transactionID, err = s.transfer(ctx, sourceID, s.suspenseID, amount, models.TransactionTransfer, func(tx *sql.Tx, transactionID string) error {
+		var err error
+		item, err = s.suspenseRepo.InsertSuspenseItemTx(tx, models.SuspenseItem{
+			SourceAccountID:   sourceID,
+			IntendedAccountID: destID,
+			Amount:            models.Amount(amount),
+			Reason:            reason,
+			Reference:         reference,
+			TransactionID:     transactionID,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("park payment in suspense account %d: %w", s.suspenseID, err)
+	}
+	log.Printf("parked %.5f for account %d in suspense item %d: %s", amount, destID, item.ID, reason)
+
+	if s.pendingRepo != nil {
+		summary := fmt.Sprintf("%s for account %d (%s)", item.Amount, destID, reason)
+		if _, err := s.pendingRepo.AddPendingAction(models.PendingActionSuspense, strconv.FormatInt(item.ID, 10), summary); err != nil {
+			log.Printf("open pending action for suspense item %d: %v", item.ID, err)
+		}
+	}
+	return &models.InboundPayment{TransactionID: transactionID, SuspenseItem: item}, nil
+}
+
+// unresolvedReason says why destID cannot take a payment, or returns "" if it
+// is an active account.
+func (s *DefaultService) unresolvedReason(destID int64) (models.SuspenseReason, error) {
+	account, err := s.accountRepo.GetAccount(context.Background(), destID, true)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return models.SuspenseAccountNotFound, nil
+	case err != nil:
+		return "", err
+	case account.DeletedAt != nil:
+		return models.SuspenseAccountClosed, nil
+	}
+	return "", nil
+}
+
+// ListSuspenseItems returns a page of up to limit unresolved suspense items,
+// oldest first, starting after cursor.
+func (s *DefaultService) ListSuspenseItems(cursor string, limit int) (*models.SuspenseItemPage, error) {
+	if s.suspenseRepo == nil {
+		return nil, errSuspenseDisabled
+	}
+	after, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	items, next, err := s.suspenseRepo.ListSuspenseItems(after, limit)
+	if err != nil {
+		return nil, err
+	}
+	if items == nil {
+		items = []models.SuspenseItem{}
+	}
+	return &models.SuspenseItemPage{
+		Items:      items,
+		NextCursor: encodeCursor(next),
+		HasMore:    len(items) == limit,
+	}, nil
+}
+
+// RepostSuspenseItem moves a parked payment from the suspense account to
+// accountID, or to the account it was intended for when accountID is zero, and
+// marks the item resolved in the same transaction.
+func (s *DefaultService) RepostSuspenseItem(id, accountID int64) (*models.SuspenseItem, error) {
+	if s.suspenseRepo == nil {
+		return nil, errSuspenseDisabled
+	}
+	item, err := s.suspenseRepo.GetSuspenseItem(id)
+	if err != nil {
+		return nil, err
+	}
+	if item.ResolvedAt != nil {
+		return nil, fmt.Errorf("suspense item %d %w", id, repository.ErrAlreadyResolved)
+	}
+	if accountID == 0 {
+		accountID = item.IntendedAccountID
+	}
+
+	_, err = s.transfer(context.Background(), s.suspenseID, accountID, float64(item.Amount), models.TransactionTransfer, func(tx *sql.Tx, transactionID string) error {
+		var err error
+		item, err = s.suspenseRepo.ResolveSuspenseItemTx(tx, id, accountID, transactionID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("reposted suspense item %d to account %d", id, accountID)
+
+	if s.pendingRepo != nil {
+		err := s.pendingRepo.ResolvePendingAction(models.PendingActionSuspense, strconv.FormatInt(id, 10))
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			log.Printf("resolve pending action for suspense item %d: %v", id, err)
+		}
+	}
+	return item, nil
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/sandbox"
+)
+
+// ListTransactions returns a page of up to limit transactions matching filter,
+// oldest first, starting after cursor. Test-mode requests list the test-mode
+// transactions, live ones the live transactions.
+func (s *DefaultService) ListTransactions(ctx context.Context, filter models.TransactionFilter, cursor string, limit int) (*models.TransactionPage, error) {
+	after, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	if filter, err = normalizeFilter(ctx, filter); err != nil {
+		return nil, err
+	}
+	transactions, next, err := s.transactionRepo.ListTransactions(ctx, filter, after, limit)
+	if err != nil {
+		return nil, err
+	}
+	return newTransactionPage(transactions, next, limit), nil
+}
+
+// CountTransactions counts the transactions matching filter.
+func (s *DefaultService) CountTransactions(ctx context.Context, filter models.TransactionFilter) (int64, error) {
+	filter, err := normalizeFilter(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	return s.transactionRepo.CountTransactions(ctx, filter)
+}
+
+// normalizeFilter normalizes the tag of filter like the tags it is matched
+// against and scopes it to the mode of the request.
+func normalizeFilter(ctx context.Context, filter models.TransactionFilter) (models.TransactionFilter, error) {
+	filter.TestMode = !sandbox.Livemode(ctx)
+	if filter.Tag == "" {
+		return filter, nil
+	}
+	var err error
+	filter.Tag, err = normalizeTag(filter.Tag)
+	return filter, err
+}
+
+// SyncTransactions returns up to limit transactions created or updated since the
+// position encoded in cursor. An empty cursor starts from the beginning of the log.
+// Clients persist NextCursor and pass it back to receive only later changes.
+// Test-mode requests sync the test-mode transactions, live ones the live
+// transactions.
+func (s *DefaultService) SyncTransactions(ctx context.Context, cursor string, limit int) (*models.TransactionPage, error) {
+	after, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	transactions, next, err := s.transactionRepo.ListTransactionChanges(ctx, after, sandbox.Livemode(ctx), limit)
+	if err != nil {
+		return nil, err
+	}
+	return newTransactionPage(transactions, next, limit), nil
+}
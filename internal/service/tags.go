@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/sandbox"
+)
+
+var (
+	// ErrInvalidTags is returned for transaction tags that are malformed or too
+	// many.
+	ErrInvalidTags = errors.New("invalid tags")
+
+	errTransactionTagsDisabled = errors.New("transaction tags are not enabled")
+)
+
+// maxTags is the most tags a transaction may carry.
+const maxTags = 20
+
+// tagPattern is the form of a tag once lowercased: up to 64 letters, digits and
+// the separators _ - . :, starting with a letter or digit.
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.:-]{0,63}$`)
+
+// normalizeTag trims and lowercases tag and checks its form.
+func normalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if !tagPattern.MatchString(tag) {
+		return "", fmt.Errorf("%w: %q must be 1 to 64 letters, digits, '_', '-', '.' or ':'", ErrInvalidTags, tag)
+	}
+	return tag, nil
+}
+
+// normalizeTags normalizes each tag and returns them deduplicated in lexical
+// order.
+func normalizeTags(tags []string) ([]string, error) {
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag, err := normalizeTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	if len(normalized) > maxTags {
+		return nil, fmt.Errorf("%w: at most %d tags per transaction", ErrInvalidTags, maxTags)
+	}
+	sort.Strings(normalized)
+	return normalized, nil
+}
+
+// tagTransfer returns the beforeCommit hook of transfer that marks the
+// transaction with tags, or nil when there are none.
+func (s *DefaultService) tagTransfer(tags []string) func(tx *sql.Tx, transactionID string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+	return func(tx *sql.Tx, transactionID string) error {
+		return s.tagRepo.SetTransactionTagsTx(tx, transactionID, tags)
+	}
+}
+
+// SetTransactionTags replaces the tags of transaction id, found by
+// transaction_ref or serial ID, and returns the transaction. An empty list
+// removes them all.
+func (s *DefaultService) SetTransactionTags(ctx context.Context, id string, tags []string) (*models.Transaction, error) {
+	if s.tagRepo == nil {
+		return nil, errTransactionTagsDisabled
+	}
+	tags, err := normalizeTags(tags)
+	if err != nil {
+		return nil, err
+	}
+	if !sandbox.Livemode(ctx) {
+		if _, err := s.GetTransaction(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+	return s.tagRepo.SetTransactionTags(id, tags)
+}
+
+// ListAccountTags returns the tags of the transfers an account sent or
+// received, with how many transfers carry each.
+func (s *DefaultService) ListAccountTags(ctx context.Context, accountID int64) ([]models.TransactionTag, error) {
+	if s.tagRepo == nil {
+		return nil, errTransactionTagsDisabled
+	}
+	if _, err := s.getAccount(ctx, accountID, false); err != nil {
+		return nil, err
+	}
+	return s.tagRepo.ListAccountTags(ctx, accountID)
+}
@@ -0,0 +1,40 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/nehciyy/intrapay/internal/models"
+)
+
+var errTimelineDisabled = errors.New("account timelines are not enabled")
+
+// AccountTimeline returns a page of what happened to an account, oldest first:
+// its opening, transfers, adjustments, closures and restores, and the spending
+// tokens issued and revoked on it. Closed accounts have a timeline only with
+// includeDeleted.
+func (s *DefaultService) AccountTimeline(ctx context.Context, accountID int64, cursor string, limit int, includeDeleted bool) (*models.AccountTimeline, error) {
+	if s.timelineRepo == nil {
+		return nil, errTimelineDisabled
+	}
+	after, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.getAccount(ctx, accountID, includeDeleted); err != nil {
+		return nil, err
+	}
+	events, next, err := s.timelineRepo.ListAccountTimeline(ctx, accountID, after, limit)
+	if err != nil {
+		return nil, err
+	}
+	if events == nil {
+		events = []models.AccountEvent{}
+	}
+	return &models.AccountTimeline{
+		AccountID:  accountID,
+		Events:     events,
+		NextCursor: encodeCursor(next),
+		HasMore:    len(events) == limit,
+	}, nil
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/nehciyy/intrapay/internal/metrics"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository"
+)
+
+var (
+	// ErrInvalidTopUpRule is returned for a top-up rule with a negative
+	// threshold, an amount that is not positive, or a funding account that is
+	// the account itself or does not exist.
+	ErrInvalidTopUpRule = errors.New("invalid top-up rule")
+
+	errTopUpsDisabled = errors.New("automatic top-ups are not enabled")
+	// errTopUpNotNeeded rolls back a top-up of an account that a concurrent
+	// top-up has already refilled.
+	errTopUpNotNeeded = errors.New("top-up not needed")
+)
+
+// SetTopUpRule creates or replaces the top-up rule of accountID: once a debit
+// leaves its balance below the threshold, the amount is moved to it from the
+// funding account.
+func (s *DefaultService) SetTopUpRule(accountID int64, req models.SetTopUpRuleRequest) (*models.TopUpRule, error) {
+	if s.topUpRepo == nil {
+		return nil, errTopUpsDisabled
+	}
+	switch {
+	case req.Threshold < 0:
+		return nil, fmt.Errorf("%w: threshold must not be negative", ErrInvalidTopUpRule)
+	case req.Amount <= 0:
+		return nil, fmt.Errorf("%w: amount must be positive", ErrInvalidTopUpRule)
+	case req.FundingAccountID == accountID:
+		return nil, fmt.Errorf("%w: an account cannot fund its own top-ups", ErrInvalidTopUpRule)
+	}
+
+	exists, err := s.accountRepo.AccountExists(context.Background(), accountID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("account with ID %d %w", accountID, repository.ErrNotFound)
+	}
+	if exists, err = s.accountRepo.AccountExists(context.Background(), req.FundingAccountID); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("%w: funding account %d not found", ErrInvalidTopUpRule, req.FundingAccountID)
+	}
+
+	return s.topUpRepo.SetTopUpRule(models.TopUpRule{
+		AccountID:        accountID,
+		Threshold:        req.Threshold,
+		Amount:           req.Amount,
+		FundingAccountID: req.FundingAccountID,
+	})
+}
+
+// GetTopUpRule returns the top-up rule of accountID.
+func (s *DefaultService) GetTopUpRule(accountID int64) (*models.TopUpRule, error) {
+	if s.topUpRepo == nil {
+		return nil, errTopUpsDisabled
+	}
+	return s.topUpRepo.GetTopUpRule(accountID)
+}
+
+// DeleteTopUpRule stops the automatic top-ups of accountID.
+func (s *DefaultService) DeleteTopUpRule(accountID int64) error {
+	if s.topUpRepo == nil {
+		return errTopUpsDisabled
+	}
+	return s.topUpRepo.DeleteTopUpRule(accountID)
+}
+
+// topUp refills accountID from its funding account if a transfer that just
+// committed left its balance below the threshold of its top-up rule. The top-up
+// is a transfer of its own, logged as a top_up transaction; it does not top up
+// the funding account in turn, so rules funding each other cannot loop. A
+// failed top-up is logged and leaves the transfer that triggered it in place;
+// the next debit of the account tries again.
+//
+// intent, if set, is the top-up as recorded with the transfer. It is completed
+// in the transaction of the top-up, or once the top-up turns out not to be
+// needed or fails for good; after another failure it is left pending for
+// ResumeTransferIntents.
+func (s *DefaultService) topUp(accountID int64, intent *models.TransferIntent) {
+	if s.topUpRepo == nil {
+		return
+	}
+	rule, err := s.topUpRepo.GetTopUpRule(accountID)
+	if errors.Is(err, repository.ErrNotFound) {
+		s.completeIntent(intent)
+		return
+	}
+	if err != nil {
+		topUpFailed(accountID, err)
+		return
+	}
+	account, err := s.accountRepo.GetAccount(context.Background(), accountID, false)
+	if err != nil {
+		topUpFailed(accountID, err)
+		s.settleIntent(intent, err)
+		return
+	}
+	if account.Balance >= rule.Threshold {
+		s.completeIntent(intent)
+		return
+	}
+
+	amount := float64(rule.Amount)
+	transactionID, err := s.transfer(context.Background(), rule.FundingAccountID, accountID, amount, models.TransactionTopUp, func(tx *sql.Tx, _ string) error {
+		// Locks the account, so of two transfers racing to top it up only the
+		// first refills it.
+		balance, err := s.transactionRepo.GetAccountBalanceTx(tx, accountID)
+		if err != nil {
+			return err
+		}
+		if balance-amount >= float64(rule.Threshold) {
+			return errTopUpNotNeeded
+		}
+		return s.completeIntentTx(tx, intent)
+	})
+	switch {
+	case errors.Is(err, errTopUpNotNeeded):
+		metrics.TopUps.WithLabelValues("skipped").Inc()
+		s.completeIntent(intent)
+	case errors.Is(err, repository.ErrAlreadyCompleted):
+		// Another run of the intent made the top-up.
+		metrics.TopUps.WithLabelValues("skipped").Inc()
+	case err != nil:
+		topUpFailed(accountID, err)
+		s.settleIntent(intent, err)
+	default:
+		metrics.TopUps.WithLabelValues("executed").Inc()
+		log.Printf("topped up account %d with %.5f from account %d in transaction %s", accountID, amount, rule.FundingAccountID, transactionID)
+	}
+}
+
+func topUpFailed(accountID int64, err error) {
+	metrics.TopUps.WithLabelValues("failed").Inc()
+	log.Printf("top-up of account %d failed: %v", accountID, err)
+}
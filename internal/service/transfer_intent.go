@@ -0,0 +1,158 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/metrics"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository"
+)
+
+// resumeBatchSize is how many pending transfer intents ResumeTransferIntents
+// reads at a time.
+const resumeBatchSize = 100
+
+var errTransferIntentsDisabled = errors.New("transfer intents are not enabled")
+
+// recordTopUpIntentTx records in tx the top-up accountID is owed if the
+// transfer transactionID, made in tx, leaves its balance below the threshold of
+// its top-up rule. It returns the intent, or nil if none is owed or transfer
+// intents are not recorded.
+func (s *DefaultService) recordTopUpIntentTx(tx *sql.Tx, transactionID string, accountID int64) (*models.TransferIntent, error) {
+	if s.intentRepo == nil || s.topUpRepo == nil {
+		return nil, nil
+	}
+	rule, err := s.topUpRepo.GetTopUpRule(accountID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	balance, err := s.transactionRepo.GetAccountBalanceTx(tx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if balance >= float64(rule.Threshold) {
+		return nil, nil
+	}
+	return s.intentRepo.InsertTransferIntentTx(tx, models.TransferIntent{
+		Kind:          models.TransferIntentTopUp,
+		AccountID:     accountID,
+		TransactionID: transactionID,
+		Amount:        rule.Amount,
+		CreatedAt:     s.clock.Now(),
+	})
+}
+
+// recordTopUpIntentsTx records in tx the top-ups owed to the accounts of
+// debited, each debited by the transfer it maps to, and returns them by
+// account, nil for the accounts owed none.
+func (s *DefaultService) recordTopUpIntentsTx(tx *sql.Tx, debited map[int64]string) (map[int64]*models.TransferIntent, error) {
+	intents := make(map[int64]*models.TransferIntent, len(debited))
+	for accountID, transactionID := range debited {
+		intent, err := s.recordTopUpIntentTx(tx, transactionID, accountID)
+		if err != nil {
+			return nil, err
+		}
+		intents[accountID] = intent
+	}
+	return intents, nil
+}
+
+// recordCashbackIntentTx records in tx the cashback the transfer transactionID
+// of amount from accountID, made in tx, earns if a campaign the account is
+// eligible for is running. It returns the intent, or nil if no campaign is
+// running or transfer intents are not recorded.
+func (s *DefaultService) recordCashbackIntentTx(tx *sql.Tx, transactionID string, accountID int64, amount float64) (*models.TransferIntent, error) {
+	if s.intentRepo == nil || s.cashbackRepo == nil {
+		return nil, nil
+	}
+	now := s.clock.Now()
+	campaigns, err := s.cashbackRepo.ListActiveCashbackCampaigns(accountID, now)
+	if err != nil || len(campaigns) == 0 {
+		return nil, err
+	}
+	return s.intentRepo.InsertTransferIntentTx(tx, models.TransferIntent{
+		Kind:          models.TransferIntentCashback,
+		AccountID:     accountID,
+		TransactionID: transactionID,
+		Amount:        models.Amount(amount),
+		CreatedAt:     now,
+	})
+}
+
+// completeIntentTx completes intent, if set, in tx, the transaction of the
+// follow-up transfer it is for.
+func (s *DefaultService) completeIntentTx(tx *sql.Tx, intent *models.TransferIntent) error {
+	if intent == nil {
+		return nil
+	}
+	return s.intentRepo.CompleteTransferIntentTx(tx, intent.ID, s.clock.Now())
+}
+
+// completeIntent completes intent, if set, whose follow-up needs no transfer.
+// A failure is logged: the intent is left pending and resumed later, when it
+// is found to need no transfer again.
+func (s *DefaultService) completeIntent(intent *models.TransferIntent) {
+	if intent == nil {
+		return
+	}
+	err := s.intentRepo.CompleteTransferIntent(intent.ID, s.clock.Now())
+	if err != nil && !errors.Is(err, repository.ErrAlreadyCompleted) {
+		log.Printf("complete %s intent %d: %v", intent.Kind, intent.ID, err)
+	}
+}
+
+// settleIntent completes intent, if set, once its follow-up has failed for
+// good: the account to pay or the funding account is gone, or cannot cover
+// it. An intent whose follow-up failed otherwise, e.g. on a lost database
+// connection, is left pending for ResumeTransferIntents.
+func (s *DefaultService) settleIntent(intent *models.TransferIntent, err error) {
+	if intentSettled(err) {
+		s.completeIntent(intent)
+	}
+}
+
+// intentSettled reports whether a follow-up transfer that failed with err
+// would fail again if resumed.
+func intentSettled(err error) bool {
+	return errors.Is(err, ErrInsufficientBalance) || errors.Is(err, ErrDestinationNotFound) || errors.Is(err, ErrLivemodeMismatch) || errors.Is(err, repository.ErrNotFound)
+}
+
+// ResumeTransferIntents makes the top-ups and cashback of the transfer intents
+// recorded before before that are still pending, such as those of transfers
+// that committed just before a crash, and returns how many it resumed. Every
+// follow-up transfer completes its intent in its own database transaction, so
+// an intent that is being made as it is resumed, or is resumed twice, moves
+// funds once.
+func (s *DefaultService) ResumeTransferIntents(before time.Time) (int, error) {
+	if s.intentRepo == nil {
+		return 0, errTransferIntentsDisabled
+	}
+	var resumed int
+	var after int64
+	for {
+		intents, err := s.intentRepo.ListPendingTransferIntents(before, after, resumeBatchSize)
+		if err != nil {
+			return resumed, err
+		}
+		for _, intent := range intents {
+			switch intent.Kind {
+			case models.TransferIntentTopUp:
+				s.topUp(intent.AccountID, &intent)
+			case models.TransferIntentCashback:
+				s.payCashback(intent.TransactionID, intent.AccountID, float64(intent.Amount), &intent)
+			}
+			metrics.TransferIntentsResumed.WithLabelValues(string(intent.Kind)).Inc()
+			resumed++
+			after = intent.ID
+		}
+		if len(intents) < resumeBatchSize {
+			return resumed, nil
+		}
+	}
+}
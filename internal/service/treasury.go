@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/models"
+)
+
+var (
+	// ErrInvalidTreasuryQuery is returned for a largest accounts report of a
+	// size, or a flows report of a number of days, out of range.
+	ErrInvalidTreasuryQuery = errors.New("invalid treasury query")
+
+	errTreasuryDisabled = errors.New("treasury reports are not enabled")
+)
+
+const (
+	// MaxLargestAccounts bounds the largest accounts report.
+	MaxLargestAccounts = 100
+	// MaxFlowDays bounds the window of the daily flows report.
+	MaxFlowDays = 366
+)
+
+// TreasuryPositions totals the money held across all accounts by currency and
+// account type, with the float on the suspense and reimbursement accounts of
+// those configured.
+func (s *DefaultService) TreasuryPositions(ctx context.Context) (*models.TreasuryPositions, error) {
+	if s.treasuryRepo == nil {
+		return nil, errTreasuryDisabled
+	}
+	asOf := s.clock.Now()
+	positions, err := s.treasuryRepo.BalancesByType(ctx, s.suspenseID, s.reimbursementID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.TreasuryPositions{AsOf: asOf, Totals: []models.CurrencyTotal{}, Positions: positions, Float: []models.TreasuryFloat{}}
+	balances := map[models.AccountType]models.Amount{}
+	for _, p := range positions {
+		balances[p.AccountType] += p.Balance
+		if n := len(report.Totals); n > 0 && report.Totals[n-1].Currency == p.Currency {
+			report.Totals[n-1].Balance += p.Balance
+		} else {
+			report.Totals = append(report.Totals, models.CurrencyTotal{Currency: p.Currency, Balance: p.Balance})
+		}
+	}
+
+	if s.suspenseID != 0 {
+		float, err := s.treasuryRepo.SuspenseFloat(ctx)
+		if err != nil {
+			return nil, err
+		}
+		float.AccountID, float.Balance = s.suspenseID, balances[models.AccountTypeSuspense]
+		report.Float = append(report.Float, *float)
+	}
+	if s.reimbursementID != 0 {
+		float, err := s.treasuryRepo.ReimbursementFloat(ctx)
+		if err != nil {
+			return nil, err
+		}
+		float.AccountID, float.Balance = s.reimbursementID, balances[models.AccountTypeReimbursement]
+		report.Float = append(report.Float, *float)
+	}
+	return report, nil
+}
+
+// LargestAccounts returns the limit open accounts holding the most money,
+// largest first.
+func (s *DefaultService) LargestAccounts(ctx context.Context, limit int) ([]models.TreasuryAccount, error) {
+	if s.treasuryRepo == nil {
+		return nil, errTreasuryDisabled
+	}
+	if limit <= 0 || limit > MaxLargestAccounts {
+		return nil, fmt.Errorf("%w: limit must be 1-%d", ErrInvalidTreasuryQuery, MaxLargestAccounts)
+	}
+	return s.treasuryRepo.LargestAccounts(ctx, s.suspenseID, s.reimbursementID, limit)
+}
+
+// DailyFlows totals the money into and out of each account type per calendar
+// day in loc, over the last days days including today. Money that enters the
+// system from outside shows as outflow of the external type, so the nets of a
+// day add up to zero.
+func (s *DefaultService) DailyFlows(ctx context.Context, days int, loc *time.Location) (*models.TreasuryFlows, error) {
+	if s.treasuryRepo == nil {
+		return nil, errTreasuryDisabled
+	}
+	if days <= 0 || days > MaxFlowDays {
+		return nil, fmt.Errorf("%w: days must be 1-%d", ErrInvalidTreasuryQuery, MaxFlowDays)
+	}
+	now := s.clock.Now().In(loc)
+	from := time.Date(now.Year(), now.Month(), now.Day()-(days-1), 0, 0, 0, 0, loc)
+	flows, err := s.treasuryRepo.DailyFlows(ctx, s.suspenseID, s.reimbursementID, from.UTC(), loc)
+	if err != nil {
+		return nil, err
+	}
+	return &models.TreasuryFlows{
+		Currency: models.CurrentCurrency().Code,
+		Timezone: loc.String(),
+		From:     from.Format(time.DateOnly),
+		To:       now.Format(time.DateOnly),
+		Flows:    flows,
+	}, nil
+}
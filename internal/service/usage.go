@@ -0,0 +1,87 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/nehciyy/intrapay/internal/models"
+)
+
+var (
+	// ErrInvalidQuota is returned when a quota has a negative limit or no subject.
+	ErrInvalidQuota = errors.New("invalid quota")
+
+	errMeteringDisabled = errors.New("usage metering is not enabled")
+	errQuotasDisabled   = errors.New("quotas are not enabled")
+)
+
+// UsageReport returns the metered calls and transfer volume of every API key and
+// tenant in period ("2006-01"). Usage not yet flushed by the meter is not included.
+func (s *DefaultService) UsageReport(period string) ([]models.Usage, error) {
+	if s.usageRepo == nil {
+		return nil, errMeteringDisabled
+	}
+	return s.usageRepo.ListUsage(period)
+}
+
+// SetQuota creates or replaces the monthly quota of an API key or tenant. The
+// meter picks up the change on its next reload.
+func (s *DefaultService) SetQuota(q models.Quota) (*models.Quota, error) {
+	if s.quotaRepo == nil {
+		return nil, errQuotasDisabled
+	}
+	switch {
+	case !q.Scope.Valid():
+		return nil, fmt.Errorf("%w: unknown scope %q", ErrInvalidQuota, q.Scope)
+	case q.Subject == "":
+		return nil, fmt.Errorf("%w: missing subject", ErrInvalidQuota)
+	case q.MonthlyCalls < 0 || q.MonthlyVolume < 0:
+		return nil, fmt.Errorf("%w: limits must not be negative", ErrInvalidQuota)
+	}
+	return s.quotaRepo.SetQuota(q)
+}
+
+// GetQuota returns the quota of an API key or tenant with its usage this month,
+// as last flushed by the meter.
+func (s *DefaultService) GetQuota(scope models.QuotaScope, subject string) (*models.QuotaStatus, error) {
+	if s.quotaRepo == nil {
+		return nil, errQuotasDisabled
+	}
+	q, err := s.quotaRepo.GetQuota(scope, subject)
+	if err != nil {
+		return nil, err
+	}
+	status := &models.QuotaStatus{Quota: *q, Period: s.clock.Now().UTC().Format(models.UsagePeriodLayout)}
+	if s.usageRepo == nil {
+		return status, nil
+	}
+
+	usage := s.usageRepo.GetKeyUsage
+	if scope == models.QuotaScopeTenant {
+		usage = s.usageRepo.GetTenantUsage
+	}
+	calls, volume, err := usage(status.Period, subject)
+	if err != nil {
+		return nil, err
+	}
+	status.UsedCalls = calls
+	status.UsedVolume = models.Amount(volume)
+	return status, nil
+}
+
+// ListQuotas returns every quota set for an API key or tenant.
+func (s *DefaultService) ListQuotas() ([]models.Quota, error) {
+	if s.quotaRepo == nil {
+		return nil, errQuotasDisabled
+	}
+	return s.quotaRepo.ListQuotas()
+}
+
+// DeleteQuota removes the quota of an API key, which falls back to the default
+// quotas, or of a tenant, which becomes unlimited.
+func (s *DefaultService) DeleteQuota(scope models.QuotaScope, subject string) error {
+	if s.quotaRepo == nil {
+		return errQuotasDisabled
+	}
+	return s.quotaRepo.DeleteQuota(scope, subject)
+}
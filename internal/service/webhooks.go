@@ -0,0 +1,243 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"slices"
+
+	"github.com/nehciyy/intrapay/internal/eventschema"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository"
+)
+
+var (
+	// ErrInvalidWebhook is returned for a webhook without an absolute http(s) URL
+	// or subscribing to an unknown event type.
+	ErrInvalidWebhook = errors.New("invalid webhook")
+	// ErrWebhookVerification is returned when the URL of a webhook being
+	// registered does not echo the verification challenge.
+	ErrWebhookVerification = errors.New("webhook URL failed verification")
+	// ErrNotAccountOwner is returned when subscribing a webhook to an account
+	// by an API key that did not open it.
+	ErrNotAccountOwner = errors.New("not the owner of the account")
+
+	errWebhooksDisabled = errors.New("webhooks are not enabled")
+)
+
+// WebhookClient sends requests to webhook URLs.
+type WebhookClient interface {
+	// Verify checks that url echoes a fresh challenge.
+	Verify(ctx context.Context, url string) error
+	// Ping sends a test event to w and reports the outcome.
+	Ping(ctx context.Context, w models.Webhook) models.WebhookPing
+	// Deliver sends event e to w.
+	Deliver(ctx context.Context, w models.Webhook, e models.Event) error
+}
+
+// RegisterWebhook subscribes tenant to events at req.URL, on behalf of the
+// caller with apiKey. A webhook with req.AccountID is subscribed to the events
+// of that account alone, and only the API key that opened the account may
+// subscribe one: an account of another tenant is not found, and one opened by
+// another key, or by none, is refused with ErrNotAccountOwner. The URL must
+// then pass the verification handshake, so a mistyped or unreachable URL is
+// refused now rather than discovered when an event is lost.
+func (s *DefaultService) RegisterWebhook(ctx context.Context, tenant, apiKey string, req models.WebhookRequest) (*models.Webhook, error) {
+	if s.webhookRepo == nil {
+		return nil, errWebhooksDisabled
+	}
+	u, err := url.Parse(req.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("%w: url must be an absolute http or https URL", ErrInvalidWebhook)
+	}
+	eventTypes := req.EventTypes
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+	for _, t := range eventTypes {
+		if eventschema.Latest(t) == 0 {
+			return nil, fmt.Errorf("%w: unknown event type %q (see /events/schemas)", ErrInvalidWebhook, t)
+		}
+	}
+
+	if req.AccountID != nil {
+		if err := s.checkAccountOwner(ctx, tenant, apiKey, *req.AccountID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.webhookClient.Verify(context.Background(), req.URL); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrWebhookVerification, err)
+	}
+	return s.webhookRepo.InsertWebhook(models.Webhook{
+		Tenant:     tenant,
+		URL:        req.URL,
+		AccountID:  req.AccountID,
+		EventTypes: eventTypes,
+		VerifiedAt: s.clock.Now(),
+	})
+}
+
+// checkAccountOwner refuses the caller with apiKey an account of tenant it did
+// not open: an account of another tenant is not found, and one opened by another
+// key, or by none, is refused with ErrNotAccountOwner. A live account is not
+// found by a test-mode request.
+func (s *DefaultService) checkAccountOwner(ctx context.Context, tenant, apiKey string, accountID int64) error {
+	if err := s.checkTestMode(ctx, accountID); err != nil {
+		return err
+	}
+	accountTenant, owner, err := s.webhookRepo.GetAccountOwner(accountID)
+	if err != nil {
+		return err
+	}
+	if accountTenant != tenant {
+		return fmt.Errorf("account with ID %d %w", accountID, repository.ErrNotFound)
+	}
+	if owner == "" || owner != apiKey {
+		return fmt.Errorf("%w %d", ErrNotAccountOwner, accountID)
+	}
+	return nil
+}
+
+func (s *DefaultService) GetWebhook(id int64, tenant string) (*models.Webhook, error) {
+	if s.webhookRepo == nil {
+		return nil, errWebhooksDisabled
+	}
+	return s.webhookRepo.GetWebhook(id, tenant)
+}
+
+func (s *DefaultService) ListWebhooks(tenant string) ([]models.Webhook, error) {
+	if s.webhookRepo == nil {
+		return nil, errWebhooksDisabled
+	}
+	return s.webhookRepo.ListWebhooks(tenant)
+}
+
+func (s *DefaultService) DeleteWebhook(id int64, tenant string) error {
+	if s.webhookRepo == nil {
+		return errWebhooksDisabled
+	}
+	return s.webhookRepo.DeleteWebhook(id, tenant)
+}
+
+// PingWebhook sends a test event to a webhook of tenant. An unreachable URL is
+// reported in the result, not as an error.
+func (s *DefaultService) PingWebhook(id int64, tenant string) (*models.WebhookPing, error) {
+	w, err := s.GetWebhook(id, tenant)
+	if err != nil {
+		return nil, err
+	}
+	ping := s.webhookClient.Ping(context.Background(), *w)
+	return &ping, nil
+}
+
+// NotifyEventWebhooks sends e, an event read from the outbox, to the webhooks
+// subscribed to it. A transfer.completed event goes to the webhooks of the
+// tenant of each side of the transfer, and to those of either account: a
+// tenant-wide webhook receives it once even when both accounts are the
+// tenant's. An account.daily_digest event goes to the webhooks of its account.
+// Events of other types are delivered where they are written and are skipped
+// here.
+func (s *DefaultService) NotifyEventWebhooks(e models.Event) {
+	if s.webhookRepo == nil {
+		return
+	}
+	var ids []int64
+	switch e.Type {
+	case models.EventTransferCompleted:
+		var t models.TransferCompleted
+		if err := json.Unmarshal(e.Payload, &t); err != nil {
+			log.Printf("read %s event %d for webhooks: %v", e.Type, e.ID, err)
+			return
+		}
+		ids = []int64{t.SourceAccountID, t.DestinationAccountID}
+	case models.EventAccountDailyDigest:
+		var d models.AccountDailyDigest
+		if err := json.Unmarshal(e.Payload, &d); err != nil {
+			log.Printf("read %s event %d for webhooks: %v", e.Type, e.ID, err)
+			return
+		}
+		ids = []int64{d.AccountID}
+	default:
+		return
+	}
+	var tenants []string
+	accounts := make(map[string][]int64)
+	for _, id := range ids {
+		tenant, _, err := s.webhookRepo.GetAccountOwner(id)
+		if errors.Is(err, repository.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			log.Printf("find tenant of account %d for %s event %d: %v", id, e.Type, e.ID, err)
+			continue
+		}
+		if _, ok := accounts[tenant]; !ok {
+			tenants = append(tenants, tenant)
+		}
+		accounts[tenant] = append(accounts[tenant], id)
+	}
+	for _, tenant := range tenants {
+		s.notifyWebhooks(tenant, e, accounts[tenant]...)
+	}
+}
+
+// notifyWebhooks sends e, about accountIDs, to the webhooks of tenant subscribed
+// to its type, tenant-wide or to one of those accounts, on the webhook workers
+// if there are any. The webhooks of an account whose notifications are sent as a daily
+// digest receive its digests alone, and tenant-wide webhooks every event but
+// the digests. Each is tried once; a failed delivery
+// is logged, and the event can still be read from the event log. A delivery
+// the workers cannot take, because they are behind or shutting down, is made
+// at once instead.
+func (s *DefaultService) notifyWebhooks(tenant string, e models.Event, accountIDs ...int64) {
+	if s.webhookRepo == nil {
+		return
+	}
+	webhooks, err := s.webhookRepo.ListWebhooks(tenant)
+	if err != nil {
+		log.Printf("list webhooks of tenant %s for %s event: %v", tenant, e.Type, err)
+		return
+	}
+	digest := e.Type == models.EventAccountDailyDigest
+	modes := make(map[int64]models.NotificationMode)
+	for _, w := range webhooks {
+		if len(w.EventTypes) > 0 && !slices.Contains(w.EventTypes, e.Type) {
+			continue
+		}
+		if w.AccountID == nil && digest {
+			continue
+		}
+		if w.AccountID != nil {
+			id := *w.AccountID
+			if !slices.Contains(accountIDs, id) {
+				continue
+			}
+			mode, ok := modes[id]
+			if !ok {
+				mode = s.notificationMode(id)
+				modes[id] = mode
+			}
+			if digest != (mode == models.NotificationDailyDigest) {
+				continue
+			}
+		}
+		if s.webhookWorkers != nil {
+			err := s.webhookWorkers.Submit(func(ctx context.Context) { s.deliverWebhook(ctx, w, e) })
+			if err == nil {
+				continue
+			}
+			log.Printf("queue %s event for webhook %d: %v, delivering it now", e.Type, w.ID, err)
+		}
+		s.deliverWebhook(context.Background(), w, e)
+	}
+}
+
+func (s *DefaultService) deliverWebhook(ctx context.Context, w models.Webhook, e models.Event) {
+	if err := s.webhookClient.Deliver(ctx, w, e); err != nil {
+		log.Printf("deliver %s event to webhook %d: %v", e.Type, w.ID, err)
+	}
+}
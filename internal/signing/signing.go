@@ -0,0 +1,151 @@
+// Package signing signs API responses with detached JSON Web Signatures (RFC 7515,
+// appendix F), so integrators can later prove what the service returned.
+//
+// A signature is a compact JWS with the payload left out: the protected header,
+// two dots and the signature, e.g. "eyJhbGciOi...ey..MEUCIQ...". To verify, put
+// the base64url-encoded response body between the dots and check the result with
+// the key named by the header's kid, published as a JWK Set.
+package signing
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/nehciyy/intrapay/internal/clock"
+)
+
+// Header is the response header carrying the signature.
+const Header = "X-JWS-Signature"
+
+// ErrInvalidSignature is returned by Verify for a signature that does not match.
+var ErrInvalidSignature = errors.New("invalid signature")
+
+var b64 = base64.RawURLEncoding
+
+// header is the JWS protected header. IssuedAt records when the response was
+// signed.
+type header struct {
+	Alg      string `json:"alg"`
+	Kid      string `json:"kid"`
+	IssuedAt int64  `json:"iat"`
+}
+
+// Signer signs payloads with an Ed25519 key.
+type Signer struct {
+	key   ed25519.PrivateKey
+	kid   string
+	clock clock.Clock
+}
+
+// NewSigner creates a Signer with key. Its key ID is the RFC 7638 thumbprint of
+// the public key, so it changes exactly when the key does.
+func NewSigner(key ed25519.PrivateKey, clk clock.Clock) *Signer {
+	s := &Signer{key: key, clock: clk}
+	s.kid = thumbprint(s.jwk())
+	return s
+}
+
+// LoadSigner reads an Ed25519 private key in PKCS #8 PEM form from path, as
+// written by `openssl genpkey -algorithm ed25519`.
+func LoadSigner(path string, clk clock.Clock) (*Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("signing: %s: no PEM block", path)
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("signing: %s: %w", path, err)
+	}
+	key, ok := parsed.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("signing: %s: expected an Ed25519 key, got %T", path, parsed)
+	}
+	return NewSigner(key, clk), nil
+}
+
+// KeyID returns the kid of the signer's key.
+func (s *Signer) KeyID() string { return s.kid }
+
+// Sign returns the detached compact JWS of payload.
+func (s *Signer) Sign(payload []byte) (string, error) {
+	protected, err := json.Marshal(header{Alg: "EdDSA", Kid: s.kid, IssuedAt: s.clock.Now().Unix()})
+	if err != nil {
+		return "", err
+	}
+	encoded := b64.EncodeToString(protected)
+	sig := ed25519.Sign(s.key, signingInput(encoded, payload))
+	return encoded + ".." + b64.EncodeToString(sig), nil
+}
+
+// JWK is a public key in JSON Web Key form (RFC 8037).
+type JWK struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the key set signatures are verified with.
+func (s *Signer) JWKS() JWKS {
+	k := s.jwk()
+	k.Kid, k.Use, k.Alg = s.kid, "sig", "EdDSA"
+	return JWKS{Keys: []JWK{k}}
+}
+
+func (s *Signer) jwk() JWK {
+	return JWK{Kty: "OKP", Crv: "Ed25519", X: b64.EncodeToString(s.key.Public().(ed25519.PublicKey))}
+}
+
+// thumbprint is the RFC 7638 thumbprint of an OKP key: the hash of its required
+// members in lexical order.
+func thumbprint(k JWK) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf(`{"crv":%q,"kty":%q,"x":%q}`, k.Crv, k.Kty, k.X)))
+	return b64.EncodeToString(sum[:])
+}
+
+// Verify checks the detached JWS sig of payload against key.
+func Verify(sig string, payload []byte, key ed25519.PublicKey) error {
+	protected, signature, ok := strings.Cut(sig, "..")
+	if !ok {
+		return fmt.Errorf("%w: expected a detached compact JWS", ErrInvalidSignature)
+	}
+	raw, err := b64.DecodeString(protected)
+	if err != nil {
+		return fmt.Errorf("%w: header: %v", ErrInvalidSignature, err)
+	}
+	var h header
+	if err := json.Unmarshal(raw, &h); err != nil || h.Alg != "EdDSA" {
+		return fmt.Errorf("%w: expected an EdDSA header", ErrInvalidSignature)
+	}
+	sigBytes, err := b64.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("%w: signature: %v", ErrInvalidSignature, err)
+	}
+	if !ed25519.Verify(key, signingInput(protected, payload), sigBytes) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func signingInput(protected string, payload []byte) []byte {
+	return []byte(protected + "." + b64.EncodeToString(payload))
+}
@@ -0,0 +1,66 @@
+package signing
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nehciyy/intrapay/internal/clock"
+)
+
+func TestSigner_Sign(t *testing.T) {
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	s := NewSigner(key, clock.NewFake(time.Date(2026, 3, 14, 9, 30, 0, 0, time.UTC)))
+	body := []byte(`{"message":"Transaction successfully processed","transaction_id":"7"}`)
+
+	sig, err := s.Sign(body)
+	require.NoError(t, err)
+	assert.Len(t, strings.Split(sig, "."), 3)
+	assert.Contains(t, sig, "..", "the payload is detached")
+	assert.NoError(t, Verify(sig, body, pub))
+
+	assert.ErrorIs(t, Verify(sig, []byte(`{"transaction_id":"8"}`), pub), ErrInvalidSignature, "a changed body does not verify")
+	otherPub, _, _ := ed25519.GenerateKey(rand.Reader)
+	assert.ErrorIs(t, Verify(sig, body, otherPub), ErrInvalidSignature)
+	assert.ErrorIs(t, Verify("not a jws", body, pub), ErrInvalidSignature)
+}
+
+func TestSigner_JWKS(t *testing.T) {
+	// The RFC 8037 appendix A key and its RFC 7638 thumbprint.
+	seed, _ := base64.RawURLEncoding.DecodeString("nWGxne_9WmC6hEr0kuwsxERJxWl7MmkZcDusAxyuf2A")
+	s := NewSigner(ed25519.NewKeyFromSeed(seed), clock.System)
+
+	assert.Equal(t, "kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k", s.KeyID())
+	assert.Equal(t, JWKS{Keys: []JWK{{
+		Kty: "OKP", Crv: "Ed25519", X: "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo",
+		Kid: "kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k", Use: "sig", Alg: "EdDSA",
+	}}}, s.JWKS())
+}
+
+func TestLoadSigner(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "signing.pem")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600))
+
+	s, err := LoadSigner(path, clock.System)
+	require.NoError(t, err)
+	assert.Equal(t, NewSigner(key, clock.System).KeyID(), s.KeyID())
+
+	require.NoError(t, os.WriteFile(path, []byte("not a key"), 0o600))
+	_, err = LoadSigner(path, clock.System)
+	assert.Error(t, err)
+}
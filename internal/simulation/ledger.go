@@ -0,0 +1,594 @@
+package simulation
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/nehciyy/intrapay/internal/clock"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/repository"
+)
+
+// errUnsupported is returned by the listings and reports the simulation does
+// not model.
+var errUnsupported = errors.New("simulation: not supported by the in-memory ledger")
+
+// bindQuery is the statement the ledger runs on a *sql.Tx to find the writes
+// pending in it.
+const bindQuery = "simulation: bind transaction"
+
+// Ledger is an in-memory account and transaction store. It implements
+// repository.AccountRepository and repository.TransactionRepository, and DB
+// returns a *sql.DB whose transactions stage the writes made through it until
+// they commit. Transactions run one at a time, as if under serializable
+// isolation with no contention; contention is scripted with FailCommits.
+type Ledger struct {
+	clock clock.Clock
+	db    *sql.DB
+
+	// serial is held by the database transaction in progress.
+	serial sync.Mutex
+
+	mu           sync.Mutex
+	accounts     map[int64]*account
+	transactions []row
+	adjustments  []adjustment
+	failCommits  int
+	conflicts    int
+}
+
+type account struct {
+	id        int64
+	tenant    string
+	livemode  bool
+	name      string
+	opening   float64
+	balance   float64
+	createdAt time.Time
+	updatedAt time.Time
+	deletedAt *time.Time
+}
+
+// row is a transaction of the log.
+type row struct {
+	models.Transaction
+	serial int64
+}
+
+type adjustment struct {
+	accountID int64
+	amount    float64
+}
+
+// txn holds the writes of a database transaction until it commits.
+type txn struct {
+	balances     map[int64]float64
+	transactions []row
+	adjustments  []adjustment
+}
+
+// NewLedger creates an empty ledger that timestamps its rows with clk.
+func NewLedger(clk clock.Clock) *Ledger {
+	l := &Ledger{clock: clk, accounts: map[int64]*account{}}
+	l.db = sql.OpenDB(connector{l})
+	return l
+}
+
+// DB returns the database the service begins its transactions on.
+func (l *Ledger) DB() *sql.DB {
+	return l.db
+}
+
+// FailCommits makes the next n commits fail with a serialization failure, as if
+// a concurrent transaction had written the rows they read.
+func (l *Ledger) FailCommits(n int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.failCommits = n
+}
+
+// Conflicts returns how many commits have failed with a serialization failure.
+func (l *Ledger) Conflicts() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.conflicts
+}
+
+// begin starts a database transaction once the one in progress, if any, ends.
+func (l *Ledger) begin() *txn {
+	l.serial.Lock()
+	return &txn{balances: map[int64]float64{}}
+}
+
+// commit applies the writes of t, unless the commit is to fail.
+func (l *Ledger) commit(t *txn) error {
+	defer l.serial.Unlock()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.failCommits > 0 {
+		l.failCommits--
+		l.conflicts++
+		return &pq.Error{Code: "40001", Message: "could not serialize access due to concurrent update"}
+	}
+	now := l.clock.Now()
+	for id, balance := range t.balances {
+		a := l.accounts[id]
+		a.balance, a.updatedAt = balance, now
+	}
+	l.transactions = append(l.transactions, t.transactions...)
+	l.adjustments = append(l.adjustments, t.adjustments...)
+	return nil
+}
+
+// rollback discards the writes of t.
+func (l *Ledger) rollback(*txn) {
+	l.serial.Unlock()
+}
+
+// txnOf returns the pending writes of tx.
+func (l *Ledger) txnOf(tx *sql.Tx) (*txn, error) {
+	var t *txn
+	if _, err := tx.Exec(bindQuery, &t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// numeric rounds v to the five decimal places balances are stored with.
+func numeric(v float64) float64 {
+	return math.Round(v*1e5) / 1e5
+}
+
+func notFound(accountID int64) error {
+	return fmt.Errorf("account with ID %d %w", accountID, repository.ErrNotFound)
+}
+
+// CreateAccount opens an account with an opening balance.
+func (l *Ledger) CreateAccount(accountID int64, initialBalance float64, tenant, owner string, livemode bool) (*models.Account, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.accounts[accountID]; ok {
+		return nil, &pq.Error{Code: "23505", Message: `duplicate key value violates unique constraint "accounts_pkey"`}
+	}
+	now := l.clock.Now()
+	a := &account{id: accountID, tenant: tenant, livemode: livemode, opening: numeric(initialBalance), balance: numeric(initialBalance), createdAt: now, updatedAt: now}
+	l.accounts[accountID] = a
+	return a.model(), nil
+}
+
+// AccountExists reports whether an open account exists.
+func (l *Ledger) AccountExists(ctx context.Context, accountID int64) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	a, ok := l.accounts[accountID]
+	return ok && a.deletedAt == nil, nil
+}
+
+// GetAccount returns an open account, or a closed one with includeDeleted.
+func (l *Ledger) GetAccount(ctx context.Context, accountID int64, includeDeleted bool) (*models.Account, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	a, ok := l.accounts[accountID]
+	if !ok || (a.deletedAt != nil && !includeDeleted) {
+		return nil, notFound(accountID)
+	}
+	return a.model(), nil
+}
+
+// ListAccounts returns up to limit open accounts matching filter, in the order
+// of their IDs, starting after afterID.
+func (l *Ledger) ListAccounts(ctx context.Context, filter models.AccountFilter, afterID int64, limit int) ([]models.Account, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var accounts []models.Account
+	for _, a := range l.accounts {
+		switch {
+		case a.id <= afterID, a.deletedAt != nil, a.livemode == filter.TestMode:
+		case filter.MinBalance != nil && a.balance < float64(*filter.MinBalance):
+		case filter.MaxBalance != nil && a.balance > float64(*filter.MaxBalance):
+		default:
+			accounts = append(accounts, *a.model())
+		}
+	}
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i].AccountID < accounts[j].AccountID })
+	if len(accounts) > limit {
+		accounts = accounts[:limit]
+	}
+	return accounts, nil
+}
+
+// DeleteAccount closes an open account.
+func (l *Ledger) DeleteAccount(accountID int64) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	a, ok := l.accounts[accountID]
+	if !ok || a.deletedAt != nil {
+		return notFound(accountID)
+	}
+	now := l.clock.Now()
+	a.deletedAt, a.updatedAt = &now, now
+	return nil
+}
+
+// RestoreAccount reopens a closed account.
+func (l *Ledger) RestoreAccount(accountID int64) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	a, ok := l.accounts[accountID]
+	if !ok || a.deletedAt == nil {
+		return fmt.Errorf("deleted account with ID %d %w", accountID, repository.ErrNotFound)
+	}
+	a.deletedAt, a.updatedAt = nil, l.clock.Now()
+	return nil
+}
+
+// SetDisplayName names an open account.
+func (l *Ledger) SetDisplayName(accountID int64, name string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	a, ok := l.accounts[accountID]
+	if !ok || a.deletedAt != nil {
+		return notFound(accountID)
+	}
+	a.name = name
+	return nil
+}
+
+// CountAccounts returns how many open accounts tenant has.
+func (l *Ledger) CountAccounts(tenant string) (int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var n int64
+	for _, a := range l.accounts {
+		if a.tenant == tenant && a.deletedAt == nil {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// GetAccountBalanceTx returns the balance of an open account as tx sees it.
+func (l *Ledger) GetAccountBalanceTx(tx *sql.Tx, accountID int64) (float64, error) {
+	t, err := l.txnOf(tx)
+	if err != nil {
+		return 0, err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	a, ok := l.accounts[accountID]
+	if !ok || a.deletedAt != nil {
+		return 0, notFound(accountID)
+	}
+	return t.balance(a), nil
+}
+
+// AccountExistsTx reports whether an open account exists.
+func (l *Ledger) AccountExistsTx(tx *sql.Tx, accountID int64) (bool, error) {
+	if _, err := l.txnOf(tx); err != nil {
+		return false, err
+	}
+	return l.AccountExists(context.Background(), accountID)
+}
+
+// UpdateBalanceTx adds delta to the balance of an account in tx.
+func (l *Ledger) UpdateBalanceTx(tx *sql.Tx, accountID int64, delta float64) error {
+	t, err := l.txnOf(tx)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if a, ok := l.accounts[accountID]; ok {
+		t.balances[accountID] = numeric(t.balance(a) + delta)
+	}
+	return nil
+}
+
+// DebitBalanceTx subtracts amount from an open account whose balance covers
+// it and returns the new balance.
+func (l *Ledger) DebitBalanceTx(tx *sql.Tx, accountID int64, amount float64) (float64, error) {
+	t, err := l.txnOf(tx)
+	if err != nil {
+		return 0, err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	a, ok := l.accounts[accountID]
+	if !ok || a.deletedAt != nil {
+		return 0, notFound(accountID)
+	}
+	balance := t.balance(a)
+	if balance < amount {
+		return 0, repository.ErrInsufficientFunds
+	}
+	t.balances[accountID] = numeric(balance - amount)
+	return t.balances[accountID], nil
+}
+
+// InsertTransactionLogTx records a transfer in tx and returns its ID.
+func (l *Ledger) InsertTransactionLogTx(tx *sql.Tx, sourceID, destID int64, amount float64) (string, error) {
+	ids, err := l.InsertTransactionLogsTx(tx, []repository.TransactionLog{{SourceID: sourceID, DestID: destID, Amount: amount}})
+	if err != nil {
+		return "", err
+	}
+	return ids[0], nil
+}
+
+// InsertTransactionLogsTx records transfers in tx and returns their IDs, serial
+// keys in the order the transfers are logged.
+func (l *Ledger) InsertTransactionLogsTx(tx *sql.Tx, logs []repository.TransactionLog) ([]string, error) {
+	t, err := l.txnOf(tx)
+	if err != nil {
+		return nil, err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.clock.Now()
+	ids := make([]string, len(logs))
+	for i, log := range logs {
+		kind := log.Kind
+		if kind == "" {
+			kind = models.TransactionTransfer
+		}
+		serial := int64(len(l.transactions) + len(t.transactions) + 1)
+		ids[i] = strconv.FormatInt(serial, 10)
+		t.transactions = append(t.transactions, row{serial: serial, Transaction: models.Transaction{
+			ID:                   ids[i],
+			Kind:                 kind,
+			SourceAccountID:      log.SourceID,
+			DestinationAccountID: log.DestID,
+			Amount:               models.Amount(numeric(log.Amount)),
+			CreatedAt:            now,
+			UpdatedAt:            now,
+			Rounding:             log.Rounding,
+			Livemode:             true,
+		}})
+	}
+	return ids, nil
+}
+
+// ComputeBalanceTx rebuilds the balance of an account from its opening balance,
+// the transaction log and adjustments, as tx sees them.
+func (l *Ledger) ComputeBalanceTx(tx *sql.Tx, accountID int64) (float64, error) {
+	t, err := l.txnOf(tx)
+	if err != nil {
+		return 0, err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	a, ok := l.accounts[accountID]
+	if !ok {
+		return 0, notFound(accountID)
+	}
+	return l.computeBalance(a, t), nil
+}
+
+// computeBalance rebuilds the balance of a from the committed rows and those
+// pending in t, if set. l.mu must be held.
+func (l *Ledger) computeBalance(a *account, t *txn) float64 {
+	transactions, adjustments := l.transactions, l.adjustments
+	if t != nil {
+		transactions = append(append([]row{}, transactions...), t.transactions...)
+		adjustments = append(append([]adjustment{}, adjustments...), t.adjustments...)
+	}
+	balance := a.opening
+	for _, r := range transactions {
+		if r.DestinationAccountID == a.id {
+			balance += float64(r.Amount)
+		}
+		if r.SourceAccountID == a.id {
+			balance -= float64(r.Amount)
+		}
+	}
+	for _, adj := range adjustments {
+		if adj.accountID == a.id {
+			balance += adj.amount
+		}
+	}
+	return numeric(balance)
+}
+
+// InsertAdjustmentTx records a balance adjustment in tx and returns its ID.
+func (l *Ledger) InsertAdjustmentTx(tx *sql.Tx, accountID int64, amount float64, code models.ReasonCode, reason string) (string, error) {
+	t, err := l.txnOf(tx)
+	if err != nil {
+		return "", err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	t.adjustments = append(t.adjustments, adjustment{accountID: accountID, amount: numeric(amount)})
+	return strconv.Itoa(len(l.adjustments) + len(t.adjustments)), nil
+}
+
+// GetTransaction returns a committed transaction by its serial key.
+func (l *Ledger) GetTransaction(ctx context.Context, id string) (*models.Transaction, error) {
+	found, err := l.GetTransactions(ctx, []string{id})
+	if err != nil {
+		return nil, err
+	}
+	t, ok := found[id]
+	if !ok {
+		return nil, fmt.Errorf("transaction %s %w", id, repository.ErrNotFound)
+	}
+	return &t, nil
+}
+
+// GetTransactions returns the committed transactions among ids by ID.
+func (l *Ledger) GetTransactions(ctx context.Context, ids []string) (map[string]models.Transaction, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	found := map[string]models.Transaction{}
+	for _, id := range ids {
+		serial, err := strconv.ParseInt(id, 10, 64)
+		if err == nil && serial >= 1 && serial <= int64(len(l.transactions)) {
+			found[id] = l.transactions[serial-1].Transaction
+		}
+	}
+	return found, nil
+}
+
+func (l *Ledger) ListTransactions(ctx context.Context, filter models.TransactionFilter, after repository.ChangeCursor, limit int) ([]models.Transaction, repository.ChangeCursor, error) {
+	return nil, after, errUnsupported
+}
+
+func (l *Ledger) ListAccountTransactions(ctx context.Context, accountID int64, after repository.ChangeCursor, limit int) ([]models.Transaction, repository.ChangeCursor, error) {
+	return nil, after, errUnsupported
+}
+
+func (l *Ledger) SumAccountFlows(ctx context.Context, accountID int64, since time.Time) (*models.AccountFlows, error) {
+	return nil, errUnsupported
+}
+
+func (l *Ledger) CountTransactions(ctx context.Context, filter models.TransactionFilter) (int64, error) {
+	return 0, errUnsupported
+}
+
+func (l *Ledger) ListTransactionChanges(ctx context.Context, after repository.ChangeCursor, livemode bool, limit int) ([]models.Transaction, repository.ChangeCursor, error) {
+	return nil, after, errUnsupported
+}
+
+// GetBalanceTotals returns the sum of the stored balances and the sum they
+// should come to: the opening balances plus the adjustments.
+func (l *Ledger) GetBalanceTotals() (total float64, expected float64, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, a := range l.accounts {
+		total += a.balance
+		expected += a.opening
+	}
+	for _, adj := range l.adjustments {
+		expected += adj.amount
+	}
+	return numeric(total), numeric(expected), nil
+}
+
+// Accounts returns every account, open or closed, in ID order.
+func (l *Ledger) Accounts() []models.Account {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	accounts := make([]models.Account, 0, len(l.accounts))
+	for _, a := range l.accounts {
+		accounts = append(accounts, *a.model())
+	}
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i].AccountID < accounts[j].AccountID })
+	return accounts
+}
+
+// Transactions returns the transaction log in serial key order.
+func (l *Ledger) Transactions() []models.Transaction {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	transactions := make([]models.Transaction, len(l.transactions))
+	for i, r := range l.transactions {
+		transactions[i] = r.Transaction
+	}
+	return transactions
+}
+
+// Drift returns, by account ID, the accounts whose stored balance differs from
+// the one rebuilt from the log, with the difference.
+func (l *Ledger) Drift() map[int64]float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	drift := map[int64]float64{}
+	for id, a := range l.accounts {
+		if d := numeric(a.balance - l.computeBalance(a, nil)); d != 0 {
+			drift[id] = d
+		}
+	}
+	return drift
+}
+
+func (a *account) model() *models.Account {
+	status := models.AccountStatusActive
+	if a.deletedAt != nil {
+		status = models.AccountStatusDeleted
+	}
+	return &models.Account{
+		AccountID:   a.id,
+		DisplayName: a.name,
+		Balance:     models.Amount(a.balance),
+		Status:      status,
+		CreatedAt:   a.createdAt,
+		UpdatedAt:   a.updatedAt,
+		DeletedAt:   a.deletedAt,
+		Livemode:    a.livemode,
+	}
+}
+
+// balance returns the balance of a with the writes of t.
+func (t *txn) balance(a *account) float64 {
+	if b, ok := t.balances[a.id]; ok {
+		return b
+	}
+	return a.balance
+}
+
+// connector opens connections to the ledger for database/sql.
+type connector struct{ l *Ledger }
+
+func (c connector) Connect(context.Context) (driver.Conn, error) { return &conn{l: c.l}, nil }
+func (c connector) Driver() driver.Driver                        { return ledgerDriver{c} }
+
+type ledgerDriver struct{ c connector }
+
+func (d ledgerDriver) Open(string) (driver.Conn, error) { return d.c.Connect(context.Background()) }
+
+// conn is a connection to the ledger. It runs no SQL: it begins and ends
+// transactions, and answers bindQuery with the writes pending in its own.
+type conn struct {
+	l   *Ledger
+	txn *txn
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("%w: %s", errUnsupported, query)
+}
+
+func (c *conn) Close() error { return nil }
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	c.txn = c.l.begin()
+	return c, nil
+}
+
+func (c *conn) Commit() error {
+	t := c.txn
+	c.txn = nil
+	return c.l.commit(t)
+}
+
+func (c *conn) Rollback() error {
+	t := c.txn
+	c.txn = nil
+	c.l.rollback(t)
+	return nil
+}
+
+// CheckNamedValue lets bindQuery pass the *txn it is to fill in.
+func (c *conn) CheckNamedValue(*driver.NamedValue) error { return nil }
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if query != bindQuery || len(args) != 1 {
+		return nil, fmt.Errorf("%w: %s", errUnsupported, query)
+	}
+	if c.txn == nil {
+		return nil, errors.New("simulation: not in a transaction")
+	}
+	*args[0].Value.(**txn) = c.txn
+	return driver.RowsAffected(0), nil
+}
@@ -0,0 +1,212 @@
+// Package simulation replays scripted workloads against the service on an
+// in-memory ledger and a fake clock, producing a canonical text rendering of
+// the resulting ledger that tests compare with a golden file.
+//
+// A script is a sequence of steps; the operations of a step are issued
+// concurrently. Serializable isolation makes any concurrent execution of them
+// equivalent to some serial order, so the simulation runs them one after
+// another in an order drawn from the script's seed: the same script and seed
+// always produce the same ledger, and changing the seed explores other
+// interleavings. Contention is scripted too: an operation with Conflicts set
+// has its next commits fail with serialization failures, as if concurrent
+// transactions had written the rows it read, which drives the service's retry
+// and backoff through the fake clock.
+package simulation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/clock"
+	"github.com/nehciyy/intrapay/internal/invariant"
+	"github.com/nehciyy/intrapay/internal/models"
+	"github.com/nehciyy/intrapay/internal/service"
+)
+
+// Operation kinds.
+const (
+	OpOpen     = "open"
+	OpTransfer = "transfer"
+	OpDelete   = "delete"
+	OpRestore  = "restore"
+)
+
+// Script is a simulated workload.
+type Script struct {
+	// Seed draws the order the operations of each step run in.
+	Seed int64 `json:"seed"`
+	// Start is the time of the fake clock when the script starts.
+	Start time.Time `json:"start"`
+	// ConditionalDebit runs the service with service.WithConditionalDebit.
+	ConditionalDebit bool   `json:"conditional_debit,omitempty"`
+	Steps            []Step `json:"steps"`
+}
+
+// Step is a batch of concurrent operations, issued once the clock has advanced
+// by Advance, a time.ParseDuration string, from the end of the previous step.
+type Step struct {
+	Advance string `json:"advance,omitempty"`
+	Ops     []Op   `json:"ops"`
+}
+
+// Op is one operation: opening Account with Amount, transferring Amount from
+// Account to To, or deleting or restoring Account. Conflicts is how many of
+// its commits fail with a serialization failure before one may succeed.
+type Op struct {
+	Kind      string        `json:"op"`
+	Account   int64         `json:"account"`
+	To        int64         `json:"to,omitempty"`
+	Amount    models.Amount `json:"amount,omitempty"`
+	Conflicts int           `json:"conflicts,omitempty"`
+}
+
+// Load reads a script from a JSON file.
+func Load(path string) (*Script, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var script Script
+	if err := json.Unmarshal(data, &script); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &script, nil
+}
+
+// Run plays script against a service on a fresh ledger and returns the
+// canonical rendering of the run: the outcome of every operation in the order
+// it ran, then the accounts and the transaction log it left, then the checks
+// of the ledger's invariants. Operations the ledger refuses are rendered with
+// their reason code; an error is returned only for a malformed script.
+func Run(script *Script) (string, error) {
+	clk := clock.NewFake(script.Start.UTC())
+	ledger := NewLedger(clk)
+	opts := []service.Option{
+		service.WithClock(clk),
+		service.WithInvariantChecker(invariant.NewChecker(1, ledger)),
+	}
+	if script.ConditionalDebit {
+		opts = append(opts, service.WithConditionalDebit())
+	}
+	svc := service.NewService(ledger.DB(), ledger, ledger, opts...)
+	rng := rand.New(rand.NewSource(script.Seed))
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "seed %d\n", script.Seed)
+	for i, step := range script.Steps {
+		if step.Advance != "" {
+			d, err := time.ParseDuration(step.Advance)
+			if err != nil {
+				return "", fmt.Errorf("step %d: %w", i+1, err)
+			}
+			clk.Advance(d)
+		}
+		fmt.Fprintf(&out, "\nstep %d at %s\n", i+1, clk.Now().Format(time.RFC3339Nano))
+		for _, j := range rng.Perm(len(step.Ops)) {
+			op := step.Ops[j]
+			result, err := apply(svc, ledger, op)
+			if err != nil {
+				return "", fmt.Errorf("step %d, op %d: %w", i+1, j+1, err)
+			}
+			fmt.Fprintf(&out, "  %s: %s\n", describe(op), result)
+		}
+	}
+	render(&out, ledger)
+	return out.String(), nil
+}
+
+// apply runs op and renders its outcome.
+func apply(svc service.Service, ledger *Ledger, op Op) (string, error) {
+	ledger.FailCommits(op.Conflicts)
+	defer ledger.FailCommits(0)
+	before := ledger.Conflicts()
+
+	var result string
+	var err error
+	switch op.Kind {
+	case OpOpen:
+		_, err = svc.CreateAccount(context.Background(), op.Account, float64(op.Amount), "default", "")
+		result = "ok"
+	case OpTransfer:
+		var transactionID string
+		transactionID, err = svc.CreateTransaction(context.Background(), op.Account, op.To, float64(op.Amount), nil)
+		result = "transaction " + transactionID
+	case OpDelete:
+		err = svc.DeleteAccount(context.Background(), op.Account)
+		result = "ok"
+	case OpRestore:
+		_, err = svc.RestoreAccount(op.Account)
+		result = "ok"
+	default:
+		return "", fmt.Errorf("unknown op %q", op.Kind)
+	}
+	if err != nil {
+		result = outcome(err)
+	}
+	if retries := ledger.Conflicts() - before; retries > 0 {
+		result += fmt.Sprintf(" (serialization failures: %d)", retries)
+	}
+	return result, nil
+}
+
+// outcome renders a refused operation by its reason code, or by its error if
+// it has none.
+func outcome(err error) string {
+	if code := service.RejectionReason(err); code != "" {
+		return string(code)
+	}
+	return "error: " + err.Error()
+}
+
+func describe(op Op) string {
+	switch op.Kind {
+	case OpOpen:
+		return fmt.Sprintf("open %d %s", op.Account, op.Amount)
+	case OpTransfer:
+		return fmt.Sprintf("transfer %d -> %d %s", op.Account, op.To, op.Amount)
+	default:
+		return fmt.Sprintf("%s %d", op.Kind, op.Account)
+	}
+}
+
+// render writes the accounts, the transaction log and the invariant checks of
+// ledger.
+func render(out *strings.Builder, ledger *Ledger) {
+	out.WriteString("\naccounts\n")
+	var total models.Amount
+	for _, a := range ledger.Accounts() {
+		fmt.Fprintf(out, "  %d %s %s\n", a.AccountID, a.Status, a.Balance)
+		total += a.Balance
+	}
+	fmt.Fprintf(out, "  total %s\n", total)
+
+	out.WriteString("\ntransactions\n")
+	for _, t := range ledger.Transactions() {
+		fmt.Fprintf(out, "  %s %s %d -> %d %s at %s\n", t.ID, t.Kind, t.SourceAccountID, t.DestinationAccountID, t.Amount, t.CreatedAt.Format(time.RFC3339Nano))
+	}
+
+	out.WriteString("\ninvariants\n")
+	drift := ledger.Drift()
+	ids := make([]int64, 0, len(drift))
+	for id := range drift {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	if len(ids) == 0 {
+		out.WriteString("  balances match the log\n")
+	}
+	for _, id := range ids {
+		fmt.Fprintf(out, "  account %d drifted by %s\n", id, models.Amount(drift[id]))
+	}
+	if err := invariant.NewChecker(0, ledger).CheckGlobal(); err != nil {
+		fmt.Fprintf(out, "  %v\n", err)
+	} else {
+		out.WriteString("  total conserved\n")
+	}
+}
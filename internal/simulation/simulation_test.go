@@ -0,0 +1,90 @@
+package simulation_test
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nehciyy/intrapay/internal/simulation"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files")
+
+func TestRun(t *testing.T) {
+	scripts, err := filepath.Glob("testdata/*.json")
+	if err != nil || len(scripts) == 0 {
+		t.Fatalf("no scripts: %v", err)
+	}
+	for _, path := range scripts {
+		name := strings.TrimSuffix(path, ".json")
+		t.Run(filepath.Base(name), func(t *testing.T) {
+			script, err := simulation.Load(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := simulation.Run(script)
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden := name + ".golden"
+			if *update {
+				if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v (run with -update to create it)", err)
+			}
+			if got != string(want) {
+				t.Errorf("ledger differs from %s:\ngot:\n%s\nwant:\n%s", golden, got, want)
+			}
+
+			again, err := simulation.Run(script)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if again != got {
+				t.Errorf("second run differs:\n%s", again)
+			}
+		})
+	}
+}
+
+func TestRunSeedOrdersOps(t *testing.T) {
+	script := &simulation.Script{Steps: []simulation.Step{
+		{Ops: []simulation.Op{{Kind: simulation.OpOpen, Account: 1, Amount: 10}, {Kind: simulation.OpOpen, Account: 2}}},
+		{Ops: []simulation.Op{
+			{Kind: simulation.OpTransfer, Account: 1, To: 2, Amount: 10},
+			{Kind: simulation.OpTransfer, Account: 2, To: 1, Amount: 10},
+		}},
+	}}
+	outputs := map[string]bool{}
+	for seed := int64(1); seed <= 10; seed++ {
+		script.Seed = seed
+		out, err := simulation.Run(script)
+		if err != nil {
+			t.Fatal(err)
+		}
+		outputs[out[strings.Index(out, "\n"):]] = true
+	}
+	// Depending on the order, the second transfer finds the funds or not.
+	if len(outputs) < 2 {
+		t.Errorf("expected seeds to explore more than one interleaving, got %d", len(outputs))
+	}
+}
+
+func TestRunMalformedScript(t *testing.T) {
+	for name, script := range map[string]*simulation.Script{
+		"Unknown Op":       {Steps: []simulation.Step{{Ops: []simulation.Op{{Kind: "withdraw", Account: 1}}}}},
+		"Invalid Duration": {Steps: []simulation.Step{{Advance: "soon"}}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := simulation.Run(script); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
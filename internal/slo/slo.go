@@ -0,0 +1,230 @@
+// Package slo tracks the service level objectives of the transfer endpoint: the
+// share of transfers that succeed and the share answered within a latency
+// threshold, over rolling windows, and how fast each objective is spending its
+// error budget.
+//
+// Counts are kept in memory per minute, so they cover this instance since it
+// started. The same transfers are counted in intrapay_slo_events_total, from
+// which Prometheus can compute the indicators across instances and restarts.
+package slo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/clock"
+	"github.com/nehciyy/intrapay/internal/metrics"
+	"github.com/nehciyy/intrapay/internal/models"
+)
+
+// resolution is the width of a counting bucket.
+const resolution = time.Minute
+
+// Config sets the objectives and the windows they are reported over.
+type Config struct {
+	// SuccessTarget is the share of transfers that must not fail on the
+	// service's side, e.g. 0.999.
+	SuccessTarget float64
+	// LatencyTarget is the share of transfers that must be answered within
+	// LatencyThreshold, e.g. 0.99.
+	LatencyTarget    float64
+	LatencyThreshold time.Duration
+	// Period is the rolling window the error budget is spent over.
+	Period time.Duration
+	// Windows are the shorter windows burn rates are reported over, for alerting
+	// on a budget being spent fast.
+	Windows []time.Duration
+}
+
+// DefaultConfig returns 99.9% availability and 99% of transfers within 500ms
+// over 30 days, with burn rates over the last 5 minutes, hour and 6 hours.
+func DefaultConfig() Config {
+	return Config{
+		SuccessTarget:    0.999,
+		LatencyTarget:    0.99,
+		LatencyThreshold: 500 * time.Millisecond,
+		Period:           30 * 24 * time.Hour,
+		Windows:          []time.Duration{5 * time.Minute, time.Hour, 6 * time.Hour},
+	}
+}
+
+// Validate reports the first setting out of range.
+func (c Config) Validate() error {
+	if c.SuccessTarget <= 0 || c.SuccessTarget >= 1 {
+		return fmt.Errorf("slo: success target %v must be between 0 and 1", c.SuccessTarget)
+	}
+	if c.LatencyTarget <= 0 || c.LatencyTarget >= 1 {
+		return fmt.Errorf("slo: latency target %v must be between 0 and 1", c.LatencyTarget)
+	}
+	if c.LatencyThreshold <= 0 {
+		return errors.New("slo: the latency threshold must be positive")
+	}
+	if c.Period < resolution {
+		return fmt.Errorf("slo: the period must be at least %s", resolution)
+	}
+	for _, w := range c.Windows {
+		if w < resolution || w > c.Period {
+			return fmt.Errorf("slo: window %s must be between %s and the period", w, resolution)
+		}
+	}
+	return nil
+}
+
+// bucket counts the transfers of one minute.
+type bucket struct {
+	minute int64 // minutes since the Unix epoch
+	total  int64
+	failed int64
+	slow   int64
+}
+
+// Tracker counts transfers against the objectives.
+type Tracker struct {
+	cfg   Config
+	clock clock.Clock
+
+	mu      sync.Mutex
+	buckets []bucket // a ring covering the period, indexed by minute
+}
+
+// New creates a Tracker. cfg must be valid.
+func New(cfg Config, clk clock.Clock) *Tracker {
+	return &Tracker{
+		cfg:     cfg,
+		clock:   clk,
+		buckets: make([]bucket, minutes(cfg.Period)),
+	}
+}
+
+// Failed reports whether a transfer outcome, as labelled in the transaction
+// metrics, is a failure of the service rather than a rejection of the request.
+func Failed(outcome string) bool {
+	return outcome == metrics.OutcomeError || outcome == metrics.OutcomeRetryExhausted
+}
+
+// Record counts a transfer that ended with outcome after elapsed.
+func (t *Tracker) Record(outcome string, elapsed time.Duration) {
+	failed, slow := Failed(outcome), elapsed > t.cfg.LatencyThreshold
+	metrics.SLOEvents.WithLabelValues(models.SLOAvailability, result(failed)).Inc()
+	metrics.SLOEvents.WithLabelValues(models.SLOLatency, result(slow)).Inc()
+
+	minute := t.clock.Now().Unix() / int64(resolution/time.Second)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	b := &t.buckets[t.slot(minute)]
+	if b.minute != minute {
+		*b = bucket{minute: minute}
+	}
+	b.total++
+	if failed {
+		b.failed++
+	}
+	if slow {
+		b.slow++
+	}
+}
+
+// slot is the index of the bucket of minute in the ring.
+func (t *Tracker) slot(minute int64) int64 {
+	n := int64(len(t.buckets))
+	return (minute%n + n) % n
+}
+
+func result(bad bool) string {
+	if bad {
+		return "bad"
+	}
+	return "good"
+}
+
+// Report returns each objective's indicator over every window and the period,
+// and the error budget it has left.
+func (t *Tracker) Report() models.SLOReport {
+	windows := append(append([]time.Duration(nil), t.cfg.Windows...), t.cfg.Period)
+	sums := make([]bucket, len(windows))
+	now := t.clock.Now().Unix() / int64(resolution/time.Second)
+
+	t.mu.Lock()
+	for i, w := range windows {
+		for m := now - minutes(w) + 1; m <= now; m++ {
+			if b := t.buckets[t.slot(m)]; b.minute == m {
+				sums[i].total += b.total
+				sums[i].failed += b.failed
+				sums[i].slow += b.slow
+			}
+		}
+	}
+	t.mu.Unlock()
+
+	availability := models.SLOObjective{Name: models.SLOAvailability, Target: t.cfg.SuccessTarget}
+	latency := models.SLOObjective{Name: models.SLOLatency, Target: t.cfg.LatencyTarget, Threshold: t.cfg.LatencyThreshold.String()}
+	for i, w := range windows {
+		availability.Windows = append(availability.Windows, window(w, sums[i].total, sums[i].failed, availability.Target))
+		latency.Windows = append(latency.Windows, window(w, sums[i].total, sums[i].slow, latency.Target))
+	}
+	// The last window is the period.
+	availability.ErrorBudgetRemaining = 1 - availability.Windows[len(windows)-1].BurnRate
+	latency.ErrorBudgetRemaining = 1 - latency.Windows[len(windows)-1].BurnRate
+
+	return models.SLOReport{Period: FormatWindow(t.cfg.Period), Objectives: []models.SLOObjective{availability, latency}}
+}
+
+func window(w time.Duration, total, bad int64, target float64) models.SLOWindow {
+	sli := 1.0
+	if total > 0 {
+		sli = float64(total-bad) / float64(total)
+	}
+	return models.SLOWindow{
+		Window:   FormatWindow(w),
+		Total:    total,
+		Bad:      bad,
+		SLI:      sli,
+		BurnRate: (1 - sli) / (1 - target),
+	}
+}
+
+// Export sets the SLO gauges to the current report.
+func (t *Tracker) Export() {
+	for _, o := range t.Report().Objectives {
+		for _, w := range o.Windows {
+			metrics.SLOIndicator.WithLabelValues(o.Name, w.Window).Set(w.SLI)
+			metrics.SLOBurnRate.WithLabelValues(o.Name, w.Window).Set(w.BurnRate)
+		}
+		metrics.SLOErrorBudgetRemaining.WithLabelValues(o.Name).Set(o.ErrorBudgetRemaining)
+	}
+}
+
+// Run exports the report every interval until ctx is cancelled.
+func (t *Tracker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		t.Export()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// FormatWindow formats w in the largest whole unit, e.g. "30d", "6h" or "5m".
+func FormatWindow(w time.Duration) string {
+	switch {
+	case w%(24*time.Hour) == 0:
+		return fmt.Sprintf("%dd", w/(24*time.Hour))
+	case w%time.Hour == 0:
+		return fmt.Sprintf("%dh", w/time.Hour)
+	case w%time.Minute == 0:
+		return fmt.Sprintf("%dm", w/time.Minute)
+	}
+	return w.String()
+}
+
+// minutes is the number of buckets covering w.
+func minutes(w time.Duration) int64 {
+	return int64((w + resolution - 1) / resolution)
+}
@@ -0,0 +1,97 @@
+package slo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nehciyy/intrapay/internal/clock"
+	"github.com/nehciyy/intrapay/internal/metrics"
+	"github.com/nehciyy/intrapay/internal/models"
+)
+
+func TestTracker_Report(t *testing.T) {
+	clk := clock.NewFake(time.Date(2026, 3, 14, 9, 30, 0, 0, time.UTC))
+	cfg := Config{
+		SuccessTarget:    0.99,
+		LatencyTarget:    0.9,
+		LatencyThreshold: 100 * time.Millisecond,
+		Period:           24 * time.Hour,
+		Windows:          []time.Duration{5 * time.Minute},
+	}
+	require.NoError(t, cfg.Validate())
+	tr := New(cfg, clk)
+
+	// An hour ago: 100 transfers, 2 failed, 10 slow.
+	for i := 0; i < 100; i++ {
+		outcome := metrics.OutcomeSuccess
+		if i < 2 {
+			outcome = metrics.OutcomeError
+		}
+		elapsed := 10 * time.Millisecond
+		if i >= 90 {
+			elapsed = time.Second
+		}
+		tr.Record(outcome, elapsed)
+	}
+	clk.Advance(time.Hour)
+	// Now: 100 quick transfers, rejected for the caller's reasons.
+	for i := 0; i < 100; i++ {
+		tr.Record(metrics.OutcomeInsufficientFunds, 10*time.Millisecond)
+	}
+
+	report := tr.Report()
+	assert.Equal(t, "1d", report.Period)
+	require.Len(t, report.Objectives, 2)
+
+	availability := report.Objectives[0]
+	assert.Equal(t, models.SLOAvailability, availability.Name)
+	assert.Equal(t, []models.SLOWindow{
+		{Window: "5m", Total: 100, Bad: 0, SLI: 1, BurnRate: 0},
+		{Window: "1d", Total: 200, Bad: 2, SLI: 0.99, BurnRate: 1},
+	}, roundWindows(availability.Windows))
+	assert.InDelta(t, 0, availability.ErrorBudgetRemaining, 1e-9, "2 failures in 200 spend the whole 1% budget")
+
+	latency := report.Objectives[1]
+	assert.Equal(t, models.SLOLatency, latency.Name)
+	assert.Equal(t, "100ms", latency.Threshold)
+	assert.Equal(t, int64(10), latency.Windows[1].Bad)
+	assert.InDelta(t, 0, latency.Windows[0].BurnRate, 1e-9)
+	assert.InDelta(t, 0.5, latency.ErrorBudgetRemaining, 1e-9, "5% slow against a 10% budget spends half of it")
+
+	clk.Advance(24 * time.Hour)
+	for _, o := range tr.Report().Objectives {
+		assert.Zero(t, o.Windows[1].Total, "transfers older than the period are forgotten")
+		assert.Equal(t, 1.0, o.ErrorBudgetRemaining)
+	}
+}
+
+// roundWindows rounds away floating point noise.
+func roundWindows(windows []models.SLOWindow) []models.SLOWindow {
+	for i := range windows {
+		windows[i].SLI = float64(int(windows[i].SLI*1e6+0.5)) / 1e6
+		windows[i].BurnRate = float64(int(windows[i].BurnRate*1e6+0.5)) / 1e6
+	}
+	return windows
+}
+
+func TestConfig_Validate(t *testing.T) {
+	assert.NoError(t, DefaultConfig().Validate())
+
+	cfg := DefaultConfig()
+	cfg.SuccessTarget = 1
+	assert.Error(t, cfg.Validate(), "a 100% target leaves no budget")
+
+	cfg = DefaultConfig()
+	cfg.Windows = append(cfg.Windows, 60*24*time.Hour)
+	assert.Error(t, cfg.Validate(), "windows fit in the period")
+}
+
+func TestFormatWindow(t *testing.T) {
+	assert.Equal(t, "30d", FormatWindow(30*24*time.Hour))
+	assert.Equal(t, "6h", FormatWindow(6*time.Hour))
+	assert.Equal(t, "90m", FormatWindow(90*time.Minute))
+	assert.Equal(t, "1m30s", FormatWindow(90*time.Second))
+}
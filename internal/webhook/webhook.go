@@ -0,0 +1,145 @@
+// Package webhook sends requests to subscriber URLs: the verification handshake
+// a URL must pass to be registered, test pings, and events.
+//
+// Every request is a JSON POST carrying its event type in the EventHeader
+// header. Redirects are not followed, so a URL must answer itself.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/models"
+)
+
+// EventHeader carries the event type of each request.
+const EventHeader = "X-Intrapay-Event"
+
+// maxResponseBody is how much of a response is read when looking for the
+// challenge; an echo is far shorter.
+const maxResponseBody = 4096
+
+// ErrVerificationFailed is returned when a URL does not echo the challenge.
+var ErrVerificationFailed = errors.New("webhook verification failed")
+
+// Client sends webhook requests.
+type Client struct {
+	http *http.Client
+}
+
+// NewClient creates a Client whose requests give up after timeout.
+func NewClient(timeout time.Duration) *Client {
+	return &Client{http: &http.Client{
+		Timeout: timeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}}
+}
+
+// Verify sends a fresh challenge to url and checks that the response is 2xx and
+// echoes it, as the raw body or as {"challenge": "..."}.
+func (c *Client) Verify(ctx context.Context, url string) error {
+	challenge, err := newChallenge()
+	if err != nil {
+		return err
+	}
+	resp, err := c.post(ctx, url, models.EventWebhookVerification, models.WebhookVerification{
+		Type:      models.EventWebhookVerification,
+		Challenge: challenge,
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrVerificationFailed, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%w: %s answered %s", ErrVerificationFailed, url, resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	if err != nil {
+		return fmt.Errorf("%w: read response: %v", ErrVerificationFailed, err)
+	}
+	if strings.TrimSpace(string(body)) == challenge {
+		return nil
+	}
+	var echo models.WebhookVerification
+	if json.Unmarshal(body, &echo) == nil && echo.Challenge == challenge {
+		return nil
+	}
+	return fmt.Errorf("%w: %s did not echo the challenge", ErrVerificationFailed, url)
+}
+
+// Ping sends a webhook.ping event to w and reports whether it was answered with
+// a 2xx. Failures are reported in the result rather than as an error.
+func (c *Client) Ping(ctx context.Context, w models.Webhook) models.WebhookPing {
+	start := time.Now()
+	resp, err := c.post(ctx, w.URL, models.EventWebhookPing, map[string]interface{}{
+		"type":       models.EventWebhookPing,
+		"webhook_id": w.ID,
+		"sent_at":    start.UTC(),
+	})
+	ping := models.WebhookPing{WebhookID: w.ID, DurationMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		ping.Error = err.Error()
+		return ping
+	}
+	resp.Body.Close()
+	ping.StatusCode = resp.StatusCode
+	ping.Delivered = resp.StatusCode >= 200 && resp.StatusCode <= 299
+	if !ping.Delivered {
+		ping.Error = "unexpected status " + resp.Status
+	}
+	return ping
+}
+
+// Deliver sends event e, in its outbox envelope, to w. It fails unless the
+// request is answered with a 2xx.
+func (c *Client) Deliver(ctx context.Context, w models.Webhook, e models.Event) error {
+	return c.Send(ctx, w.URL, e.Type, e)
+}
+
+// Send posts body, an event of type eventType, to url. It fails unless the
+// request is answered with a 2xx.
+func (c *Client) Send(ctx context.Context, url, eventType string, body interface{}) error {
+	resp, err := c.post(ctx, url, eventType, body)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s answered %s", url, resp.Status)
+	}
+	return nil
+}
+
+func (c *Client) post(ctx context.Context, url, eventType string, body interface{}) (*http.Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, eventType)
+	return c.http.Do(req)
+}
+
+func newChallenge() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
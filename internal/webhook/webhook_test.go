@@ -0,0 +1,92 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nehciyy/intrapay/internal/models"
+)
+
+// subscriber answers the handshake with respond, given the challenge it received.
+func subscriber(t *testing.T, respond func(w http.ResponseWriter, challenge string)) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var v models.WebhookVerification
+		json.NewDecoder(r.Body).Decode(&v)
+		assert.Equal(t, models.EventWebhookVerification, r.Header.Get(EventHeader))
+		respond(w, v.Challenge)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestClient_Verify(t *testing.T) {
+	c := NewClient(time.Second)
+	for name, respond := range map[string]func(http.ResponseWriter, string){
+		"raw body": func(w http.ResponseWriter, challenge string) { w.Write([]byte(challenge + "\n")) },
+		"json": func(w http.ResponseWriter, challenge string) {
+			json.NewEncoder(w).Encode(map[string]string{"challenge": challenge})
+		},
+	} {
+		assert.NoError(t, c.Verify(context.Background(), subscriber(t, respond).URL), name)
+	}
+
+	for name, respond := range map[string]func(http.ResponseWriter, string){
+		"no echo":    func(w http.ResponseWriter, challenge string) { w.Write([]byte("ok")) },
+		"wrong echo": func(w http.ResponseWriter, challenge string) { w.Write([]byte("0123")) },
+		"error": func(w http.ResponseWriter, challenge string) {
+			http.Error(w, challenge, http.StatusInternalServerError)
+		},
+		"redirect": func(w http.ResponseWriter, challenge string) {
+			http.Redirect(w, &http.Request{}, "/elsewhere", http.StatusFound)
+		},
+	} {
+		assert.ErrorIs(t, c.Verify(context.Background(), subscriber(t, respond).URL), ErrVerificationFailed, name)
+	}
+	assert.ErrorIs(t, c.Verify(context.Background(), "http://127.0.0.1:1/hooks"), ErrVerificationFailed, "unreachable")
+}
+
+func TestClient_Ping(t *testing.T) {
+	status := http.StatusNoContent
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, models.EventWebhookPing, r.Header.Get(EventHeader))
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+	c := NewClient(time.Second)
+
+	ping := c.Ping(context.Background(), models.Webhook{ID: 7, URL: srv.URL})
+	assert.True(t, ping.Delivered)
+	assert.Equal(t, int64(7), ping.WebhookID)
+	assert.Equal(t, http.StatusNoContent, ping.StatusCode)
+
+	status = http.StatusGone
+	ping = c.Ping(context.Background(), models.Webhook{ID: 7, URL: srv.URL})
+	assert.False(t, ping.Delivered)
+	assert.Equal(t, http.StatusGone, ping.StatusCode)
+	assert.NotEmpty(t, ping.Error)
+}
+
+func TestClient_Deliver(t *testing.T) {
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, models.EventReimbursementStatusChanged, r.Header.Get(EventHeader))
+		var e models.Event
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&e))
+		assert.Equal(t, int64(12), e.ID)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+	c := NewClient(time.Second)
+	event := models.Event{ID: 12, Type: models.EventReimbursementStatusChanged, SchemaVersion: 1, Payload: json.RawMessage(`{}`)}
+
+	assert.NoError(t, c.Deliver(context.Background(), models.Webhook{ID: 7, URL: srv.URL}, event))
+
+	status = http.StatusInternalServerError
+	assert.Error(t, c.Deliver(context.Background(), models.Webhook{ID: 7, URL: srv.URL}, event))
+}
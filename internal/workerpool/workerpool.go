@@ -0,0 +1,154 @@
+// Package workerpool runs background work on a bounded number of goroutines,
+// so that a burst of work queues up rather than spawning a goroutine per item.
+//
+// A task that panics is logged and counted, and its worker goes on with the
+// next task: one bad item does not take the process down. Drain stops a pool
+// gracefully, letting the queued and running tasks finish within a deadline.
+// Every pool reports its queue, busy workers, task outcomes and task run time
+// under its name.
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/nehciyy/intrapay/internal/metrics"
+)
+
+// Task outcomes used as the "outcome" label of the tasks_total metric.
+const (
+	OutcomeCompleted = "completed"
+	OutcomePanicked  = "panicked"
+	OutcomeRejected  = "rejected"
+	OutcomeAbandoned = "abandoned"
+)
+
+var (
+	// ErrFull is returned when a task is submitted to a pool whose queue is
+	// full.
+	ErrFull = errors.New("worker pool queue is full")
+	// ErrClosed is returned when a task is submitted to a pool being drained.
+	ErrClosed = errors.New("worker pool is closed")
+)
+
+// Task is a unit of work. ctx is cancelled when the pool's context is, or when
+// Drain gives up waiting for the pool.
+type Task func(ctx context.Context)
+
+// Pool runs tasks on a fixed number of workers.
+type Pool struct {
+	name   string
+	logger *log.Logger
+	ctx    context.Context
+	cancel context.CancelFunc
+	tasks  chan Task
+	wg     sync.WaitGroup
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// New starts a pool of workers goroutines running the tasks submitted to it,
+// of which up to queue may wait for a worker. The tasks' context is derived
+// from ctx. name labels the pool in logs and metrics.
+func New(ctx context.Context, name string, workers, queue int, logger *log.Logger) *Pool {
+	if workers <= 0 {
+		workers = 1
+	}
+	p := &Pool{name: name, logger: logger, tasks: make(chan Task, queue)}
+	p.ctx, p.cancel = context.WithCancel(ctx)
+	p.wg.Add(workers)
+	for range workers {
+		go p.work()
+	}
+	return p
+}
+
+// Name returns the name of the pool.
+func (p *Pool) Name() string {
+	return p.name
+}
+
+// Submit queues task for the next free worker. It does not wait: when the
+// queue is full it returns ErrFull, and once the pool is being drained
+// ErrClosed.
+func (p *Pool) Submit(task Task) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		metrics.WorkerPoolTasks.WithLabelValues(p.name, OutcomeRejected).Inc()
+		return ErrClosed
+	}
+	queued := metrics.WorkerPoolQueued.WithLabelValues(p.name)
+	queued.Inc()
+	select {
+	case p.tasks <- task:
+		return nil
+	default:
+		queued.Dec()
+		metrics.WorkerPoolTasks.WithLabelValues(p.name, OutcomeRejected).Inc()
+		return ErrFull
+	}
+}
+
+// Drain stops the pool taking tasks and waits for the workers to finish those
+// queued and running. If ctx is done first, Drain cancels the tasks' context,
+// so that the running tasks stop, abandons the queued ones and returns the
+// error of ctx without waiting further.
+func (p *Pool) Drain(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.tasks)
+	}
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		p.cancel()
+		return nil
+	case <-ctx.Done():
+		p.cancel()
+		return fmt.Errorf("draining %s pool with %d tasks queued: %w", p.name, len(p.tasks), ctx.Err())
+	}
+}
+
+func (p *Pool) work() {
+	defer p.wg.Done()
+	for task := range p.tasks {
+		metrics.WorkerPoolQueued.WithLabelValues(p.name).Dec()
+		if p.ctx.Err() != nil {
+			metrics.WorkerPoolTasks.WithLabelValues(p.name, OutcomeAbandoned).Inc()
+			continue
+		}
+		p.run(task)
+	}
+}
+
+// run runs task, recovering from a panic in it.
+func (p *Pool) run(task Task) {
+	busy := metrics.WorkerPoolBusy.WithLabelValues(p.name)
+	busy.Inc()
+	start := time.Now()
+	outcome := OutcomePanicked
+	defer func() {
+		busy.Dec()
+		metrics.WorkerPoolTaskDuration.WithLabelValues(p.name).Observe(time.Since(start).Seconds())
+		metrics.WorkerPoolTasks.WithLabelValues(p.name, outcome).Inc()
+		if outcome == OutcomePanicked {
+			p.logger.Printf("worker pool %s: task panicked: %v\n%s", p.name, recover(), debug.Stack())
+		}
+	}()
+	task(p.ctx)
+	outcome = OutcomeCompleted
+}
@@ -0,0 +1,121 @@
+package workerpool
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nehciyy/intrapay/internal/metrics"
+)
+
+// tasks returns the count of the tasks of pool with outcome.
+func tasks(pool, outcome string) float64 {
+	return testutil.ToFloat64(metrics.WorkerPoolTasks.WithLabelValues(pool, outcome))
+}
+
+func TestPool_BoundsConcurrency(t *testing.T) {
+	completed := tasks("test_bounded", OutcomeCompleted)
+	p := New(context.Background(), "test_bounded", 2, 10, log.New(&bytes.Buffer{}, "", 0))
+
+	var running, most atomic.Int32
+	release := make(chan struct{})
+	for range 6 {
+		require.NoError(t, p.Submit(func(context.Context) {
+			n := running.Add(1)
+			for m := most.Load(); n > m && !most.CompareAndSwap(m, n); m = most.Load() {
+			}
+			<-release
+			running.Add(-1)
+		}))
+	}
+	require.Eventually(t, func() bool { return running.Load() == 2 }, time.Second, time.Millisecond)
+	close(release)
+	require.NoError(t, p.Drain(context.Background()))
+
+	assert.Equal(t, int32(2), most.Load())
+	assert.Equal(t, 6.0, tasks("test_bounded", OutcomeCompleted)-completed)
+	assert.Zero(t, testutil.ToFloat64(metrics.WorkerPoolQueued.WithLabelValues("test_bounded")))
+	assert.Zero(t, testutil.ToFloat64(metrics.WorkerPoolBusy.WithLabelValues("test_bounded")))
+}
+
+func TestPool_IsolatesPanics(t *testing.T) {
+	panicked, completed := tasks("test_panics", OutcomePanicked), tasks("test_panics", OutcomeCompleted)
+	var logs bytes.Buffer
+	p := New(context.Background(), "test_panics", 1, 2, log.New(&logs, "", 0))
+
+	var ran atomic.Bool
+	require.NoError(t, p.Submit(func(context.Context) { panic("boom") }))
+	require.NoError(t, p.Submit(func(context.Context) { ran.Store(true) }))
+	require.NoError(t, p.Drain(context.Background()))
+
+	assert.True(t, ran.Load(), "the worker goes on after a panic")
+	assert.Contains(t, logs.String(), "worker pool test_panics: task panicked: boom")
+	assert.Equal(t, 1.0, tasks("test_panics", OutcomePanicked)-panicked)
+	assert.Equal(t, 1.0, tasks("test_panics", OutcomeCompleted)-completed)
+}
+
+func TestPool_RejectsWhenFullOrClosed(t *testing.T) {
+	rejected := tasks("test_full", OutcomeRejected)
+	p := New(context.Background(), "test_full", 1, 1, log.New(&bytes.Buffer{}, "", 0))
+
+	started, release := make(chan struct{}), make(chan struct{})
+	require.NoError(t, p.Submit(func(context.Context) { close(started); <-release }))
+	<-started
+	require.NoError(t, p.Submit(func(context.Context) {}))
+	assert.ErrorIs(t, p.Submit(func(context.Context) {}), ErrFull)
+
+	close(release)
+	require.NoError(t, p.Drain(context.Background()))
+	assert.ErrorIs(t, p.Submit(func(context.Context) {}), ErrClosed)
+	assert.Equal(t, 2.0, tasks("test_full", OutcomeRejected)-rejected)
+}
+
+func TestPool_DrainWaitsForQueuedTasks(t *testing.T) {
+	p := New(context.Background(), "test_drain", 1, 5, log.New(&bytes.Buffer{}, "", 0))
+
+	var mu sync.Mutex
+	var done []int
+	for i := range 5 {
+		require.NoError(t, p.Submit(func(context.Context) {
+			time.Sleep(time.Millisecond)
+			mu.Lock()
+			done = append(done, i)
+			mu.Unlock()
+		}))
+	}
+	require.NoError(t, p.Drain(context.Background()))
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, done)
+}
+
+func TestPool_DrainDeadlineCancelsTasks(t *testing.T) {
+	abandoned := tasks("test_deadline", OutcomeAbandoned)
+	p := New(context.Background(), "test_deadline", 1, 2, log.New(&bytes.Buffer{}, "", 0))
+
+	cancelled := make(chan struct{})
+	require.NoError(t, p.Submit(func(ctx context.Context) {
+		<-ctx.Done()
+		close(cancelled)
+	}))
+	var ran atomic.Bool
+	require.NoError(t, p.Submit(func(context.Context) { ran.Store(true) }))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := p.Drain(ctx)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), "got %v", err)
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("the running task's context was not cancelled")
+	}
+	assert.Eventually(t, func() bool { return tasks("test_deadline", OutcomeAbandoned)-abandoned == 1 }, time.Second, time.Millisecond)
+	assert.False(t, ran.Load(), "a task still queued at the deadline is abandoned")
+}
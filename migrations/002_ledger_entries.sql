@@ -0,0 +1,17 @@
+-- Double-entry ledger. Every movement of funds is recorded as signed entries
+-- whose sum per account is the account balance. Populated in shadow mode
+-- alongside accounts.balance until the migration to the new design is complete.
+CREATE TABLE ledger_entries (
+  id BIGSERIAL PRIMARY KEY,
+  transaction_id BIGINT REFERENCES transactions(id),
+  account_id BIGINT NOT NULL REFERENCES accounts(account_id),
+  amount NUMERIC(20, 5) NOT NULL,
+  entry_type TEXT NOT NULL CHECK (entry_type IN ('opening', 'debit', 'credit')),
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX ledger_entries_account_id_idx ON ledger_entries (account_id);
+
+-- Backfill opening entries so existing balances reconcile from day one.
+INSERT INTO ledger_entries (account_id, amount, entry_type)
+SELECT account_id, balance, 'opening' FROM accounts;
@@ -0,0 +1,5 @@
+-- Opening balances let the invariant checker verify that the sum of all balances
+-- equals the sum of opening balances plus adjustments. Transfers conserve the
+-- total, so backfilling with the current balance keeps the global sum correct.
+ALTER TABLE accounts ADD COLUMN opening_balance NUMERIC(20, 5) NOT NULL DEFAULT 0;
+UPDATE accounts SET opening_balance = balance;
@@ -0,0 +1,24 @@
+-- Manual balance adjustments, e.g. corrections posted after reconciliation finds
+-- drift between an account balance and its transaction log.
+CREATE TABLE balance_adjustments (
+  id BIGSERIAL PRIMARY KEY,
+  account_id BIGINT NOT NULL REFERENCES accounts(account_id),
+  amount NUMERIC(20, 5) NOT NULL CHECK (amount <> 0),
+  reason TEXT NOT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX balance_adjustments_account_id_idx ON balance_adjustments (account_id);
+CREATE INDEX transactions_source_account_id_idx ON transactions (source_account_id);
+CREATE INDEX transactions_destination_account_id_idx ON transactions (destination_account_id);
+
+-- Derive opening balances from the transaction log so each account's balance can
+-- be rebuilt as opening + credits - debits + adjustments.
+UPDATE accounts a SET opening_balance = a.balance
+  - COALESCE((SELECT SUM(amount) FROM transactions WHERE destination_account_id = a.account_id), 0)
+  + COALESCE((SELECT SUM(amount) FROM transactions WHERE source_account_id = a.account_id), 0);
+
+ALTER TABLE ledger_entries ADD COLUMN adjustment_id BIGINT REFERENCES balance_adjustments(id);
+ALTER TABLE ledger_entries DROP CONSTRAINT ledger_entries_entry_type_check;
+ALTER TABLE ledger_entries ADD CONSTRAINT ledger_entries_entry_type_check
+  CHECK (entry_type IN ('opening', 'debit', 'credit', 'adjustment'));
@@ -0,0 +1,2 @@
+-- Soft deletion: closed accounts keep their row and history and can be restored.
+ALTER TABLE accounts ADD COLUMN deleted_at TIMESTAMP;
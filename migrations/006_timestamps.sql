@@ -0,0 +1,27 @@
+-- created_at/updated_at on accounts and transactions so clients can sort and sync
+-- incrementally. updated_at is maintained by trigger, so every write path (balance
+-- updates, soft deletes, restores) bumps it without repository changes.
+ALTER TABLE accounts
+  ADD COLUMN created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  ADD COLUMN updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
+
+ALTER TABLE transactions ADD COLUMN updated_at TIMESTAMP;
+UPDATE transactions SET updated_at = COALESCE(created_at, CURRENT_TIMESTAMP);
+ALTER TABLE transactions
+  ALTER COLUMN updated_at SET NOT NULL,
+  ALTER COLUMN updated_at SET DEFAULT CURRENT_TIMESTAMP;
+
+CREATE FUNCTION set_updated_at() RETURNS trigger AS $$
+BEGIN
+  NEW.updated_at = CURRENT_TIMESTAMP;
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER accounts_set_updated_at BEFORE UPDATE ON accounts
+  FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+CREATE TRIGGER transactions_set_updated_at BEFORE UPDATE ON transactions
+  FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+CREATE INDEX accounts_updated_at_idx ON accounts (updated_at, account_id);
+CREATE INDEX transactions_updated_at_idx ON transactions (updated_at, id);
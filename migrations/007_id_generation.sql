@@ -0,0 +1,8 @@
+-- Server-generated account IDs under the sequence strategy. The sequence starts
+-- after the highest existing (client-chosen) account ID.
+CREATE SEQUENCE account_id_seq AS BIGINT;
+SELECT setval('account_id_seq', COALESCE(MAX(account_id), 0) + 1, false) FROM accounts;
+
+-- Transaction IDs from a configurable generator (UUIDv7, snowflake). NULL when the
+-- serial id is the transaction ID, as under the sequence strategy.
+ALTER TABLE transactions ADD COLUMN transaction_ref TEXT UNIQUE;
@@ -0,0 +1,13 @@
+-- Monthly API call counts and transfer volume per API key and tenant, for internal
+-- chargeback. Rows are incremented by the metering flush rather than per request.
+CREATE TABLE api_usage (
+  period DATE NOT NULL,
+  api_key TEXT NOT NULL,
+  tenant TEXT NOT NULL,
+  calls BIGINT NOT NULL DEFAULT 0,
+  volume NUMERIC(20, 5) NOT NULL DEFAULT 0,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (period, api_key, tenant)
+);
+
+CREATE INDEX api_usage_api_key_idx ON api_usage (api_key, period);
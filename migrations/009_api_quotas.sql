@@ -0,0 +1,13 @@
+-- Monthly call and transfer volume limits per API key or tenant. A row for an
+-- API key replaces the server-wide default quotas; tenants are only limited when
+-- they have a row. Zero means unlimited.
+CREATE TABLE api_quotas (
+  scope TEXT NOT NULL CHECK (scope IN ('api_key', 'tenant')),
+  subject TEXT NOT NULL,
+  monthly_calls BIGINT NOT NULL DEFAULT 0,
+  monthly_volume NUMERIC(20, 5) NOT NULL DEFAULT 0,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (scope, subject)
+);
+
+CREATE INDEX api_usage_tenant_idx ON api_usage (tenant, period);
@@ -0,0 +1,17 @@
+-- Work awaiting a human decision, opened by the workflow that needs it (large
+-- transfer approvals, disputes, dead-lettered messages, SAR reviews) and resolved
+-- by that workflow. GET /admin/pending-actions lists the open ones.
+CREATE TABLE pending_actions (
+  id BIGSERIAL PRIMARY KEY,
+  kind TEXT NOT NULL,
+  reference TEXT NOT NULL,
+  summary TEXT NOT NULL DEFAULT '',
+  assignee TEXT,
+  assigned_at TIMESTAMP,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  resolved_at TIMESTAMP
+);
+
+-- At most one open action per item; the feed scans open actions oldest first.
+CREATE UNIQUE INDEX pending_actions_open_ref_idx ON pending_actions (kind, reference) WHERE resolved_at IS NULL;
+CREATE INDEX pending_actions_open_idx ON pending_actions (created_at, id) WHERE resolved_at IS NULL;
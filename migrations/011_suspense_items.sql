@@ -0,0 +1,17 @@
+-- Inbound payments whose destination could not be resolved are credited to the
+-- suspense account and recorded here until ops repost them to the right account.
+CREATE TABLE suspense_items (
+  id BIGSERIAL PRIMARY KEY,
+  source_account_id BIGINT NOT NULL,
+  intended_account_id BIGINT NOT NULL,
+  amount NUMERIC(20, 5) NOT NULL CHECK (amount > 0),
+  reason TEXT NOT NULL CHECK (reason IN ('account_not_found', 'account_closed')),
+  reference TEXT NOT NULL DEFAULT '',
+  transaction_id TEXT NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  reposted_to BIGINT,
+  repost_transaction_id TEXT,
+  resolved_at TIMESTAMP
+);
+
+CREATE INDEX suspense_items_open_idx ON suspense_items (created_at, id) WHERE resolved_at IS NULL;
@@ -0,0 +1,5 @@
+-- Machine-readable reason codes (see GET /reason-codes) next to the free-text
+-- reason. Every adjustment so far was posted by a balance recompute.
+ALTER TABLE balance_adjustments
+  ADD COLUMN reason_code TEXT NOT NULL DEFAULT 'reconciliation_correction';
+ALTER TABLE balance_adjustments ALTER COLUMN reason_code DROP DEFAULT;
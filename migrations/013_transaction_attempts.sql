@@ -0,0 +1,17 @@
+-- Transfers that were rejected, with the reason code and the API key and tenant
+-- that requested them, for failure analytics. Amounts are stored as requested, so
+-- no CHECK constraint.
+CREATE TABLE transaction_attempts (
+  id BIGSERIAL PRIMARY KEY,
+  source_account_id BIGINT NOT NULL,
+  destination_account_id BIGINT NOT NULL,
+  amount NUMERIC(20, 5) NOT NULL,
+  reason_code TEXT NOT NULL,
+  error TEXT NOT NULL DEFAULT '',
+  api_key TEXT NOT NULL,
+  tenant TEXT NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX transaction_attempts_created_at_idx ON transaction_attempts (created_at, id);
+CREATE INDEX transaction_attempts_reason_code_idx ON transaction_attempts (reason_code, created_at);
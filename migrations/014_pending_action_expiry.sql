@@ -0,0 +1,4 @@
+-- Actions nobody decided on within their kind's TTL are expired by the sweeper:
+-- resolved_at and expired_at are both set, so expired actions leave the feed
+-- but remain distinguishable from decided ones.
+ALTER TABLE pending_actions ADD COLUMN expired_at TIMESTAMP;
@@ -0,0 +1,24 @@
+-- Events written in the same transaction as the change they describe, for the
+-- relay to publish. Writers hold the row lock of the aggregate, so within an
+-- aggregate id order is commit order.
+CREATE TABLE outbox_events (
+  id BIGSERIAL PRIMARY KEY,
+  aggregate_type TEXT NOT NULL,
+  aggregate_id TEXT NOT NULL,
+  event_type TEXT NOT NULL,
+  payload JSONB NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX outbox_events_aggregate_idx ON outbox_events (aggregate_type, aggregate_id, id);
+
+-- The relay's high-water mark: every event up to last_event_id has been
+-- published. A single row, shared by all instances.
+CREATE TABLE outbox_relay (
+  id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+  last_event_id BIGINT NOT NULL DEFAULT 0,
+  paused BOOLEAN NOT NULL DEFAULT FALSE,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO outbox_relay DEFAULT VALUES;
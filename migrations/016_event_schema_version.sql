@@ -0,0 +1,4 @@
+-- The version of the event type's schema the payload was written against, so
+-- consumers can tell which shape to decode. Events written before versioning
+-- match version 1.
+ALTER TABLE outbox_events ADD COLUMN schema_version INTEGER NOT NULL DEFAULT 1;
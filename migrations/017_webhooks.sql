@@ -0,0 +1,13 @@
+-- Webhook subscriptions. A subscription is only stored once its URL has echoed
+-- the verification challenge, so verified_at is set on every row.
+CREATE TABLE webhooks (
+  id BIGSERIAL PRIMARY KEY,
+  tenant TEXT NOT NULL,
+  url TEXT NOT NULL,
+  -- Empty subscribes to every event type.
+  event_types TEXT[] NOT NULL DEFAULT '{}',
+  verified_at TIMESTAMP NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX webhooks_tenant_idx ON webhooks (tenant, id);
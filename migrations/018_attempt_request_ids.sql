@@ -0,0 +1,6 @@
+-- The X-Request-ID of the request that made each rejected transfer, so support
+-- can go from the request_id of an error response to what was recorded for it.
+-- Attempts recorded before request IDs have none.
+ALTER TABLE transaction_attempts ADD COLUMN request_id TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX transaction_attempts_request_id_idx ON transaction_attempts (request_id) WHERE request_id <> '';
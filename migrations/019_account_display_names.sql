@@ -0,0 +1,7 @@
+-- A name the account holder chooses, shown to the other side of their transfers
+-- in account statements. Accounts opened before display names have none.
+ALTER TABLE accounts ADD COLUMN display_name TEXT NOT NULL DEFAULT '';
+
+-- Account statements list the transfers an account sent or received.
+CREATE INDEX transactions_source_account_idx ON transactions (source_account_id, updated_at, id);
+CREATE INDEX transactions_destination_account_idx ON transactions (destination_account_id, updated_at, id);
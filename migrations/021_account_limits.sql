@@ -0,0 +1,14 @@
+-- The tenant that opened each account, counted against the tenant's account
+-- limit. Accounts opened before tenants were recorded belong to the default
+-- tenant.
+ALTER TABLE accounts ADD COLUMN tenant TEXT NOT NULL DEFAULT 'default';
+
+CREATE INDEX accounts_tenant_idx ON accounts (tenant) WHERE deleted_at IS NULL;
+
+-- Per-tenant overrides of the server-wide limit on open accounts. Zero means
+-- unlimited.
+CREATE TABLE account_limits (
+  tenant TEXT PRIMARY KEY,
+  max_accounts BIGINT NOT NULL CHECK (max_accounts >= 0),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
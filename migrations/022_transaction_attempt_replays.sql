@@ -0,0 +1,8 @@
+-- Rejected transfers that were re-run by cmd/reprocess, with the transfer the
+-- replay made. The replay is recorded in the transaction of that transfer, and
+-- the primary key lets each attempt be replayed at most once.
+CREATE TABLE transaction_attempt_replays (
+  attempt_id BIGINT PRIMARY KEY REFERENCES transaction_attempts (id),
+  transaction_id TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,25 @@
+-- Progress of backfills, the batch jobs that fill in a column added by an
+-- expand migration for the rows written before the code that writes it. Every
+-- row up to last_id has been processed; completed_at is set whenever a run
+-- catches up with the table.
+CREATE TABLE backfills (
+  name TEXT PRIMARY KEY,
+  last_id BIGINT NOT NULL DEFAULT 0,
+  rows_updated BIGINT NOT NULL DEFAULT 0,
+  started_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  completed_at TIMESTAMPTZ
+);
+
+-- A backfill only fills in data the row already holds in another form, so it
+-- leaves updated_at alone: clients syncing by updated_at would otherwise fetch
+-- every row again. Backfill batches set intrapay.backfill for their transaction.
+CREATE OR REPLACE FUNCTION set_updated_at() RETURNS trigger AS $$
+BEGIN
+  IF current_setting('intrapay.backfill', true) = 'on' THEN
+    RETURN NEW;
+  END IF;
+  NEW.updated_at = CURRENT_TIMESTAMP;
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
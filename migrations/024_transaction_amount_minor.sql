@@ -0,0 +1,7 @@
+-- Expand step of storing amounts as integer minor units (cents for USD). The
+-- server writes amount_minor alongside amount from this release on; rows written
+-- before it, or by servers still running the previous release during a rollout,
+-- are filled in by the transactions.amount_minor backfill. The contract
+-- migration makes the column NOT NULL once the backfill has caught up after the
+-- last server running the previous release is gone.
+ALTER TABLE transactions ADD COLUMN amount_minor BIGINT;
@@ -0,0 +1,49 @@
+-- Region fencing for active-passive deployments. The servers of every region
+-- share the primary database and name their region in the intrapay.region
+-- setting of their sessions. Only the active region may write: promoting
+-- another region replaces active_region and bumps epoch, the fencing token.
+-- A single row; no region is active until one is promoted.
+CREATE TABLE region_fence (
+  id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+  active_region TEXT,
+  epoch BIGINT NOT NULL DEFAULT 0,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO region_fence DEFAULT VALUES;
+
+-- Rejects a write from a session of a region that is not active. Sessions that
+-- name no region, e.g. of single-region deployments and migrations, are not
+-- fenced. The share lock on the fence is held until the writing transaction
+-- ends, so a promotion waits for the writes in flight and every write after it
+-- sees the new active region.
+CREATE OR REPLACE FUNCTION check_region_fence() RETURNS trigger AS $$
+DECLARE
+  session_region TEXT := NULLIF(current_setting('intrapay.region', true), '');
+  active TEXT;
+BEGIN
+  IF session_region IS NULL THEN
+    RETURN NULL;
+  END IF;
+  SELECT active_region INTO active FROM region_fence FOR SHARE;
+  IF active IS DISTINCT FROM session_region THEN
+    RAISE EXCEPTION 'region % is passive, the active region is %', session_region, COALESCE(active, 'none')
+      USING ERRCODE = 'read_only_sql_transaction';
+  END IF;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Usage counters and rejected attempts are recorded by passive regions too:
+-- they are only ever added to, so they cannot diverge.
+DO $$
+DECLARE
+  t TEXT;
+BEGIN
+  FOREACH t IN ARRAY ARRAY['accounts', 'transactions', 'ledger_entries', 'balance_adjustments',
+    'suspense_items', 'pending_actions', 'outbox_events', 'webhooks', 'api_quotas', 'account_limits'] LOOP
+    EXECUTE format('CREATE TRIGGER %I BEFORE INSERT OR UPDATE OR DELETE ON %I
+      FOR EACH STATEMENT EXECUTE FUNCTION check_region_fence()', t || '_region_fence', t);
+  END LOOP;
+END;
+$$;
@@ -0,0 +1,18 @@
+-- What a transaction was posted for. Transfers are requested by clients;
+-- top-ups are posted by the server to refill an account under its top-up rule.
+ALTER TABLE transactions ADD COLUMN kind TEXT NOT NULL DEFAULT 'transfer'
+  CHECK (kind IN ('transfer', 'top_up'));
+
+-- Automatic top-ups of operational accounts: once a debit leaves the balance of
+-- account_id below threshold, amount is moved to it from funding_account_id.
+CREATE TABLE top_up_rules (
+  account_id BIGINT PRIMARY KEY REFERENCES accounts (account_id),
+  threshold NUMERIC(20, 5) NOT NULL CHECK (threshold >= 0),
+  amount NUMERIC(20, 5) NOT NULL CHECK (amount > 0),
+  funding_account_id BIGINT NOT NULL REFERENCES accounts (account_id)
+    CHECK (funding_account_id <> account_id),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER top_up_rules_region_fence BEFORE INSERT OR UPDATE OR DELETE ON top_up_rules
+  FOR EACH STATEMENT EXECUTE FUNCTION check_region_fence();
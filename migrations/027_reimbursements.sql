@@ -0,0 +1,24 @@
+-- Expense reimbursements requested by a tenant for an employee account. Each
+-- waits in the pending actions feed until approved, which pays it from the
+-- reimbursement account by a transfer recorded in transaction_id, or rejected.
+CREATE TABLE reimbursements (
+  id BIGSERIAL PRIMARY KEY,
+  tenant TEXT NOT NULL,
+  account_id BIGINT NOT NULL REFERENCES accounts (account_id),
+  amount NUMERIC(20, 5) NOT NULL CHECK (amount > 0),
+  description TEXT NOT NULL,
+  -- Receipt metadata as given by the requester: merchant, date, reference, URL.
+  receipt JSONB NOT NULL DEFAULT '{}',
+  status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected')),
+  decided_by TEXT,
+  decision_note TEXT NOT NULL DEFAULT '',
+  transaction_id TEXT,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  decided_at TIMESTAMPTZ,
+  CHECK ((status = 'approved') = (transaction_id IS NOT NULL))
+);
+
+CREATE INDEX reimbursements_tenant_idx ON reimbursements (tenant, id);
+
+CREATE TRIGGER reimbursements_region_fence BEFORE INSERT OR UPDATE OR DELETE ON reimbursements
+  FOR EACH STATEMENT EXECUTE FUNCTION check_region_fence();
@@ -0,0 +1,19 @@
+-- Spending tokens delegate spending from an account. A debit made with a token
+-- is checked against the token's limit, expiry and merchant categories before
+-- it reaches the account; spent is what the token has debited so far.
+CREATE TABLE spending_tokens (
+  id TEXT PRIMARY KEY,
+  account_id BIGINT NOT NULL REFERENCES accounts (account_id),
+  spend_limit NUMERIC(20, 5) NOT NULL CHECK (spend_limit > 0),
+  spent NUMERIC(20, 5) NOT NULL DEFAULT 0 CHECK (spent >= 0 AND spent <= spend_limit),
+  -- Empty allows every merchant category.
+  merchant_categories TEXT[] NOT NULL DEFAULT '{}',
+  expires_at TIMESTAMPTZ,
+  revoked_at TIMESTAMPTZ,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX spending_tokens_account_idx ON spending_tokens (account_id, created_at);
+
+CREATE TRIGGER spending_tokens_region_fence BEFORE INSERT OR UPDATE OR DELETE ON spending_tokens
+  FOR EACH STATEMENT EXECUTE FUNCTION check_region_fence();
@@ -0,0 +1,28 @@
+-- Cashback credits are posted by the server from a campaign's funding account
+-- to the account that made a qualifying transfer.
+ALTER TABLE transactions DROP CONSTRAINT transactions_kind_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_kind_check
+  CHECK (kind IN ('transfer', 'top_up', 'cashback'));
+
+-- Cashback campaigns: a transfer made between starts_at and ends_at by one of
+-- eligible_account_ids, or by any account while that is empty, earns percentage
+-- of its amount, at most cap, from funding_account_id. paid is what the campaign
+-- has credited so far.
+CREATE TABLE cashback_campaigns (
+  id BIGSERIAL PRIMARY KEY,
+  name TEXT NOT NULL,
+  percentage NUMERIC(7, 4) NOT NULL CHECK (percentage > 0 AND percentage <= 100),
+  cap NUMERIC(20, 5) NOT NULL CHECK (cap > 0),
+  funding_account_id BIGINT NOT NULL REFERENCES accounts (account_id),
+  eligible_account_ids BIGINT[] NOT NULL DEFAULT '{}',
+  starts_at TIMESTAMPTZ NOT NULL,
+  ends_at TIMESTAMPTZ NOT NULL CHECK (ends_at > starts_at),
+  paid NUMERIC(20, 5) NOT NULL DEFAULT 0,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX cashback_campaigns_window_idx ON cashback_campaigns (starts_at, ends_at);
+
+CREATE TRIGGER cashback_campaigns_region_fence BEFORE INSERT OR UPDATE OR DELETE ON cashback_campaigns
+  FOR EACH STATEMENT EXECUTE FUNCTION check_region_fence();
@@ -0,0 +1,18 @@
+-- Treasury reporting classifies accounts by what they are used for. The suspense
+-- and reimbursement accounts are configured per deployment, so their IDs are
+-- passed in; an account without a row is outside the system, e.g. a clearing
+-- account inbound payments arrive from.
+CREATE FUNCTION account_type(id BIGINT, suspense_id BIGINT, reimbursement_id BIGINT) RETURNS TEXT
+LANGUAGE sql STABLE AS $$
+  SELECT CASE
+    WHEN id = suspense_id THEN 'suspense'
+    WHEN id = reimbursement_id THEN 'reimbursement'
+    WHEN NOT EXISTS (SELECT 1 FROM accounts WHERE account_id = id) THEN 'external'
+    WHEN EXISTS (SELECT 1 FROM top_up_rules WHERE funding_account_id = id)
+      OR EXISTS (SELECT 1 FROM cashback_campaigns WHERE funding_account_id = id) THEN 'funding'
+    ELSE 'customer'
+  END
+$$;
+
+-- Daily flows scan the transactions of a window by creation time.
+CREATE INDEX transactions_created_at_idx ON transactions (created_at);
@@ -0,0 +1,59 @@
+-- End-of-day exchange rates: one unit of currency is worth rate units of
+-- base_currency at the close of rate_date.
+CREATE TABLE fx_rates (
+  currency TEXT NOT NULL,
+  base_currency TEXT NOT NULL,
+  rate_date DATE NOT NULL,
+  rate NUMERIC(20, 10) NOT NULL CHECK (rate > 0),
+  created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (currency, base_currency, rate_date)
+);
+
+-- Revaluations mark the balances held at the close of rate_date to the base
+-- currency. Amounts are in currency except base_value, gain and loss, which are
+-- in base_currency. Each revaluation follows on from the previous one of the
+-- same currency pair.
+CREATE TABLE revaluations (
+  id BIGSERIAL PRIMARY KEY,
+  rate_date DATE NOT NULL,
+  currency TEXT NOT NULL,
+  base_currency TEXT NOT NULL,
+  rate NUMERIC(20, 10) NOT NULL,
+  previous_id BIGINT REFERENCES revaluations (id),
+  previous_rate NUMERIC(20, 10),
+  balance NUMERIC(20, 5) NOT NULL DEFAULT 0,
+  base_value NUMERIC(20, 5) NOT NULL DEFAULT 0,
+  gain NUMERIC(20, 5) NOT NULL DEFAULT 0,
+  loss NUMERIC(20, 5) NOT NULL DEFAULT 0,
+  gain_account_id BIGINT NOT NULL,
+  loss_account_id BIGINT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE (currency, base_currency, rate_date)
+);
+
+-- Revaluation entries are a base currency memo ledger; they do not move account
+-- balances. A 'revaluation' entry holds an account's balance, its base value and,
+-- as amount, its unrealized gain or loss since the previous revaluation. The
+-- 'gain' and 'loss' entries on the designated accounts offset them, so the
+-- amounts of a revaluation sum to zero.
+CREATE TABLE revaluation_entries (
+  revaluation_id BIGINT NOT NULL REFERENCES revaluations (id) ON DELETE CASCADE,
+  account_id BIGINT NOT NULL,
+  entry_type TEXT NOT NULL CHECK (entry_type IN ('revaluation', 'gain', 'loss')),
+  balance NUMERIC(20, 5) NOT NULL DEFAULT 0,
+  base_value NUMERIC(20, 5) NOT NULL DEFAULT 0,
+  amount NUMERIC(20, 5) NOT NULL,
+  PRIMARY KEY (revaluation_id, account_id, entry_type)
+);
+
+-- Balances at the close of a day are rebuilt from the transaction log as of the
+-- close, so adjustments are looked up by creation time too.
+CREATE INDEX balance_adjustments_created_at_idx ON balance_adjustments (created_at);
+
+CREATE TRIGGER fx_rates_region_fence BEFORE INSERT OR UPDATE OR DELETE ON fx_rates
+  FOR EACH STATEMENT EXECUTE FUNCTION check_region_fence();
+CREATE TRIGGER revaluations_region_fence BEFORE INSERT OR UPDATE OR DELETE ON revaluations
+  FOR EACH STATEMENT EXECUTE FUNCTION check_region_fence();
+CREATE TRIGGER revaluation_entries_region_fence BEFORE INSERT OR UPDATE OR DELETE ON revaluation_entries
+  FOR EACH STATEMENT EXECUTE FUNCTION check_region_fence();
@@ -0,0 +1,28 @@
+-- Amounts the ledger computes rather than receives, such as cashback credits, are
+-- rounded to the minor units of their currency by its rounding policy. The
+-- posting records the mode applied and the exact amount it was rounded from;
+-- both are NULL on postings whose amount was given as is.
+ALTER TABLE transactions ADD COLUMN rounding_mode TEXT
+  CHECK (rounding_mode IN ('half_up', 'half_even', 'truncate'));
+ALTER TABLE transactions ADD COLUMN unrounded_amount NUMERIC(30, 10);
+
+-- The base values of a revaluation are rounded with the rounding mode of the
+-- base currency at the time.
+ALTER TABLE revaluations ADD COLUMN rounding_mode TEXT NOT NULL DEFAULT 'half_up'
+  CHECK (rounding_mode IN ('half_up', 'half_even', 'truncate'));
+
+-- round_money rounds v to places decimal places with a rounding mode: half_up
+-- rounds halves away from zero like ROUND, half_even rounds them to the even
+-- digit and truncate rounds towards zero.
+CREATE FUNCTION round_money(v NUMERIC, places INT, mode TEXT) RETURNS NUMERIC AS $$
+  SELECT CASE mode
+    WHEN 'truncate' THEN TRUNC(v, places)
+    WHEN 'half_even' THEN
+      CASE WHEN ABS(v * power(10::numeric, places) - TRUNC(v * power(10::numeric, places))) = 0.5
+          AND MOD(TRUNC(v * power(10::numeric, places)), 2) = 0
+        THEN TRUNC(v, places)
+        ELSE ROUND(v, places)
+      END
+    ELSE ROUND(v, places)
+  END
+$$ LANGUAGE SQL IMMUTABLE;
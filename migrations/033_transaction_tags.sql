@@ -0,0 +1,7 @@
+-- Free-form tags finance operations mark transactions with, e.g. "migration",
+-- "correction" or "test". They are set when a transfer is made or replaced
+-- afterwards; replacing them bumps updated_at, so retagged transactions show up
+-- in the change feed.
+ALTER TABLE transactions ADD COLUMN tags TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX transactions_tags_idx ON transactions USING GIN (tags);
@@ -0,0 +1,31 @@
+-- Test mode: accounts opened with a test-mode API key are sandbox accounts
+-- (livemode false). Their transactions are test-mode too, and money never moves
+-- between test and live accounts. Existing accounts are live.
+ALTER TABLE accounts ADD COLUMN livemode BOOLEAN NOT NULL DEFAULT TRUE;
+ALTER TABLE transactions ADD COLUMN livemode BOOLEAN NOT NULL DEFAULT TRUE;
+
+-- Sets the mode of a transaction from its accounts and rejects a transaction
+-- between a test and a live account as a violation of transactions_livemode.
+CREATE OR REPLACE FUNCTION set_transaction_livemode() RETURNS trigger AS $$
+DECLARE
+  source_livemode BOOLEAN;
+  destination_livemode BOOLEAN;
+BEGIN
+  SELECT livemode INTO source_livemode FROM accounts WHERE account_id = NEW.source_account_id;
+  SELECT livemode INTO destination_livemode FROM accounts WHERE account_id = NEW.destination_account_id;
+  IF source_livemode IS DISTINCT FROM destination_livemode THEN
+    RAISE EXCEPTION 'transaction between a test and a live account: % to %', NEW.source_account_id, NEW.destination_account_id
+      USING ERRCODE = 'check_violation', CONSTRAINT = 'transactions_livemode';
+  END IF;
+  NEW.livemode := COALESCE(source_livemode, TRUE);
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER transactions_livemode BEFORE INSERT ON transactions
+  FOR EACH ROW EXECUTE FUNCTION set_transaction_livemode();
+
+-- Test data is few rows among many; the purge and the test-mode listings find
+-- it through partial indexes.
+CREATE INDEX accounts_test_idx ON accounts (account_id) WHERE NOT livemode;
+CREATE INDEX transactions_test_idx ON transactions (updated_at, id) WHERE NOT livemode;
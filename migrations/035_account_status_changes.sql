@@ -0,0 +1,32 @@
+-- The history of account closures and restores, for the account timeline. The
+-- accounts row only holds the current deleted_at, which a restore clears, so
+-- every change of it is recorded here by trigger. Accounts closed before the
+-- history was kept get their closure backfilled.
+CREATE TABLE account_status_changes (
+  id BIGSERIAL PRIMARY KEY,
+  account_id BIGINT NOT NULL REFERENCES accounts (account_id),
+  status TEXT NOT NULL CHECK (status IN ('active', 'deleted')),
+  created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX account_status_changes_account_idx ON account_status_changes (account_id, created_at);
+
+INSERT INTO account_status_changes (account_id, status, created_at)
+SELECT account_id, 'deleted', deleted_at FROM accounts WHERE deleted_at IS NOT NULL ORDER BY deleted_at, account_id;
+
+CREATE OR REPLACE FUNCTION record_account_status_change() RETURNS trigger AS $$
+BEGIN
+  INSERT INTO account_status_changes (account_id, status, created_at)
+  VALUES (NEW.account_id,
+    CASE WHEN NEW.deleted_at IS NULL THEN 'active' ELSE 'deleted' END,
+    COALESCE(NEW.deleted_at, CURRENT_TIMESTAMP));
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER accounts_status_changes AFTER UPDATE OF deleted_at ON accounts
+  FOR EACH ROW WHEN (OLD.deleted_at IS DISTINCT FROM NEW.deleted_at)
+  EXECUTE FUNCTION record_account_status_change();
+
+-- The timeline lists the adjustments of an account in time order.
+CREATE INDEX balance_adjustments_account_created_idx ON balance_adjustments (account_id, created_at);
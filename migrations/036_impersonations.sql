@@ -0,0 +1,16 @@
+-- Support staff calling the customer API as a customer. Every impersonated
+-- request is recorded before it is served: who made it, on behalf of which API
+-- key and tenant, and what it asked for.
+CREATE TABLE impersonations (
+  id BIGSERIAL PRIMARY KEY,
+  operator TEXT NOT NULL,
+  api_key TEXT NOT NULL,
+  tenant TEXT NOT NULL,
+  method TEXT NOT NULL,
+  path TEXT NOT NULL,
+  reason TEXT NOT NULL DEFAULT '',
+  request_id TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX impersonations_created_at_idx ON impersonations (created_at, id);
@@ -0,0 +1,17 @@
+-- Break-glass freezes of outgoing transfers, of one tenant's accounts or of
+-- every account (tenant '*'). A freeze is in force until it expires or is
+-- lifted; rows are never deleted, so the table is the audit trail of who froze
+-- and lifted what, when and why.
+CREATE TABLE transfer_freezes (
+  id BIGSERIAL PRIMARY KEY,
+  tenant TEXT NOT NULL,
+  reason TEXT NOT NULL,
+  frozen_by TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  expires_at TIMESTAMPTZ NOT NULL,
+  lifted_at TIMESTAMPTZ,
+  lifted_by TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX transfer_freezes_in_force_idx ON transfer_freezes (expires_at) WHERE lifted_at IS NULL;
+CREATE INDEX transfer_freezes_created_at_idx ON transfer_freezes (created_at, id);
@@ -0,0 +1,14 @@
+-- Bounds on the amount of a single transfer, for the accounts of one tenant or
+-- for every account (tenant '*'). A transfer must be within both the global
+-- bounds and those of its source account's tenant. A zero max_amount sets no
+-- ceiling.
+CREATE TABLE amount_bounds (
+  tenant TEXT PRIMARY KEY,
+  min_amount NUMERIC(20, 5) NOT NULL DEFAULT 0 CHECK (min_amount >= 0),
+  max_amount NUMERIC(20, 5) NOT NULL DEFAULT 0 CHECK (max_amount >= 0),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  CHECK (max_amount = 0 OR max_amount >= min_amount)
+);
+
+CREATE TRIGGER amount_bounds_region_fence BEFORE INSERT OR UPDATE OR DELETE ON amount_bounds
+  FOR EACH STATEMENT EXECUTE FUNCTION check_region_fence();
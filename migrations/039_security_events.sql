@@ -0,0 +1,14 @@
+-- Security events: failed and locked-out authentications, revoked credentials,
+-- break-glass freezes and impersonation. They are kept apart from the business
+-- audit trail and read in id order by a SIEM. Passive regions record them too:
+-- they are only ever added to.
+CREATE TABLE security_events (
+  id BIGSERIAL PRIMARY KEY,
+  type TEXT NOT NULL,
+  actor TEXT NOT NULL DEFAULT '',
+  tenant TEXT NOT NULL DEFAULT '',
+  address TEXT NOT NULL DEFAULT '',
+  request_id TEXT NOT NULL DEFAULT '',
+  detail TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,15 @@
+-- Row-level security on the tables a tenant reads and writes through the API.
+-- A session that sets app.tenant_id, as the repositories do per transaction
+-- with ROW_LEVEL_SECURITY=true, only sees the rows of that tenant; one that
+-- does not, such as an admin or background job, sees every row. The policies
+-- are forced so they bind the table owner too, but not superusers or roles
+-- with BYPASSRLS.
+ALTER TABLE webhooks ENABLE ROW LEVEL SECURITY;
+ALTER TABLE webhooks FORCE ROW LEVEL SECURITY;
+CREATE POLICY webhooks_tenant_isolation ON webhooks
+  USING (COALESCE(current_setting('app.tenant_id', true), '') IN ('', tenant));
+
+ALTER TABLE reimbursements ENABLE ROW LEVEL SECURITY;
+ALTER TABLE reimbursements FORCE ROW LEVEL SECURITY;
+CREATE POLICY reimbursements_tenant_isolation ON reimbursements
+  USING (COALESCE(current_setting('app.tenant_id', true), '') IN ('', tenant));
@@ -0,0 +1,11 @@
+-- The migrations applied to the database, recorded by cmd/migrate as it applies
+-- each one. A database set up from this directory in one go has applied every
+-- migration up to this one.
+CREATE TABLE IF NOT EXISTS schema_migrations (
+  version INTEGER PRIMARY KEY,
+  applied_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO schema_migrations (version)
+SELECT generate_series(1, 41)
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,23 @@
+-- Composite indexes for the listing, search and statement query paths, each
+-- matching a query's filter and then its order:
+--   account statements (SumAccountFlows) total an account's transfers since a
+--   point in time;
+--   transaction attempts are listed by API key or tenant in (created_at, id)
+--   order;
+--   open pending actions are listed by kind or assignee in (created_at, id)
+--   order;
+--   the largest accounts report orders open live accounts by balance.
+--
+-- cmd/migrate applies a migration in a transaction, which CREATE INDEX
+-- CONCURRENTLY cannot run in, so these lock their table against writes while
+-- they build. On a busy database create them by hand first with CREATE INDEX
+-- CONCURRENTLY and the same definitions; IF NOT EXISTS then skips them here.
+CREATE INDEX IF NOT EXISTS transactions_source_created_idx ON transactions (source_account_id, created_at);
+CREATE INDEX IF NOT EXISTS transactions_destination_created_idx ON transactions (destination_account_id, created_at);
+CREATE INDEX IF NOT EXISTS transaction_attempts_api_key_idx ON transaction_attempts (api_key, created_at, id);
+CREATE INDEX IF NOT EXISTS transaction_attempts_tenant_idx ON transaction_attempts (tenant, created_at, id);
+CREATE INDEX IF NOT EXISTS pending_actions_open_kind_idx ON pending_actions (kind, created_at, id) WHERE resolved_at IS NULL;
+CREATE INDEX IF NOT EXISTS pending_actions_open_assignee_idx ON pending_actions (assignee, created_at, id) WHERE resolved_at IS NULL;
+CREATE INDEX IF NOT EXISTS accounts_largest_idx ON accounts (balance DESC, account_id) WHERE deleted_at IS NULL AND livemode;
+
+INSERT INTO schema_migrations (version) VALUES (42) ON CONFLICT DO NOTHING;
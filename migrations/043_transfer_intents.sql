@@ -0,0 +1,36 @@
+-- Transfers the server owes once a transfer commits: the top-up of its source
+-- account and the cashback it earns. An intent is recorded in the transaction
+-- of the transfer that sets it off and completed in the transaction of the
+-- follow-up transfer, so that a crash between the two leaves it pending, to be
+-- resumed on restart, rather than lost, and a resumed intent is made at most
+-- once.
+CREATE TABLE transfer_intents (
+  id BIGSERIAL PRIMARY KEY,
+  kind TEXT NOT NULL CHECK (kind IN ('top_up', 'cashback')),
+  account_id BIGINT NOT NULL REFERENCES accounts (account_id),
+  transaction_id TEXT NOT NULL,
+  -- The top-up amount, or the amount of the transfer earning cashback.
+  amount NUMERIC(20, 5) NOT NULL CHECK (amount > 0),
+  created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  completed_at TIMESTAMPTZ
+);
+
+CREATE INDEX transfer_intents_pending_idx ON transfer_intents (created_at, id) WHERE completed_at IS NULL;
+
+-- The cashback credits of a cashback intent, one per campaign, each recorded
+-- in the transaction of the credit: the primary key lets a resumed intent pay
+-- each campaign at most once.
+CREATE TABLE transfer_intent_credits (
+  intent_id BIGINT NOT NULL REFERENCES transfer_intents (id),
+  campaign_id BIGINT NOT NULL REFERENCES cashback_campaigns (id),
+  transaction_id TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (intent_id, campaign_id)
+);
+
+CREATE TRIGGER transfer_intents_region_fence BEFORE INSERT OR UPDATE OR DELETE ON transfer_intents
+  FOR EACH STATEMENT EXECUTE FUNCTION check_region_fence();
+CREATE TRIGGER transfer_intent_credits_region_fence BEFORE INSERT OR UPDATE OR DELETE ON transfer_intent_credits
+  FOR EACH STATEMENT EXECUTE FUNCTION check_region_fence();
+
+INSERT INTO schema_migrations (version) VALUES (43) ON CONFLICT DO NOTHING;
@@ -0,0 +1,20 @@
+-- The outcomes of the transfers accepted into the store-and-forward queue of a
+-- server while the database was unreachable, keyed by the ID the queue gave
+-- them. A replayed transfer records its outcome in its own transaction: the
+-- primary key lets a transfer replayed twice, e.g. after a crash between the
+-- commit and the queue noting it, move funds at most once.
+CREATE TABLE forwarded_transfers (
+  forward_id TEXT PRIMARY KEY,
+  status TEXT NOT NULL CHECK (status IN ('completed', 'rejected', 'expired')),
+  -- The transaction the transfer was made as; NULL unless completed.
+  transaction_id TEXT,
+  code TEXT NOT NULL DEFAULT '',
+  error TEXT NOT NULL DEFAULT '',
+  accepted_at TIMESTAMPTZ NOT NULL,
+  replayed_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER forwarded_transfers_region_fence BEFORE INSERT OR UPDATE OR DELETE ON forwarded_transfers
+  FOR EACH STATEMENT EXECUTE FUNCTION check_region_fence();
+
+INSERT INTO schema_migrations (version) VALUES (44) ON CONFLICT DO NOTHING;
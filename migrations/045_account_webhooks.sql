@@ -0,0 +1,13 @@
+-- Account owners and account-level webhooks. The owner of an account is the
+-- API key that opened it; accounts opened without one, or before owners were
+-- recorded, have none. Next to the tenant-wide subscriptions of integrators,
+-- an owner may subscribe a webhook to the events of one of its own accounts.
+ALTER TABLE accounts ADD COLUMN owner_key TEXT NOT NULL DEFAULT '';
+
+-- The account a webhook is subscribed to; NULL subscribes it to the events of
+-- every account of its tenant.
+ALTER TABLE webhooks ADD COLUMN account_id BIGINT REFERENCES accounts (account_id);
+
+CREATE INDEX webhooks_account_idx ON webhooks (account_id) WHERE account_id IS NOT NULL;
+
+INSERT INTO schema_migrations (version) VALUES (45) ON CONFLICT DO NOTHING;
@@ -0,0 +1,26 @@
+-- How the notifications of an account are sent: each as it happens, the
+-- default for an account without a row, or summed up in a daily digest. The
+-- digests sent are recorded by account and day, so a day is summed up once
+-- however often the digest job runs, and on however many servers.
+CREATE TABLE notification_preferences (
+  account_id BIGINT PRIMARY KEY REFERENCES accounts (account_id),
+  mode TEXT NOT NULL CHECK (mode IN ('immediate', 'daily_digest')),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX notification_preferences_digest_idx ON notification_preferences (account_id) WHERE mode = 'daily_digest';
+
+CREATE TABLE notification_digests (
+  account_id BIGINT NOT NULL REFERENCES accounts (account_id),
+  day DATE NOT NULL,
+  transactions BIGINT NOT NULL,
+  sent_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (account_id, day)
+);
+
+CREATE TRIGGER notification_preferences_region_fence BEFORE INSERT OR UPDATE OR DELETE ON notification_preferences
+  FOR EACH STATEMENT EXECUTE FUNCTION check_region_fence();
+CREATE TRIGGER notification_digests_region_fence BEFORE INSERT OR UPDATE OR DELETE ON notification_digests
+  FOR EACH STATEMENT EXECUTE FUNCTION check_region_fence();
+
+INSERT INTO schema_migrations (version) VALUES (46) ON CONFLICT DO NOTHING;
@@ -0,0 +1,10 @@
+-- The time zone the days of an account are digested in, and since when its
+-- notifications have been digested. The digest job sends every closed day
+-- since then that was not sent a digest yet, so days missed while it was down
+-- are caught up on.
+ALTER TABLE notification_preferences ADD COLUMN timezone TEXT NOT NULL DEFAULT 'UTC';
+ALTER TABLE notification_preferences ADD COLUMN digest_since TIMESTAMPTZ;
+
+UPDATE notification_preferences SET digest_since = updated_at WHERE mode = 'daily_digest';
+
+INSERT INTO schema_migrations (version) VALUES (47) ON CONFLICT DO NOTHING;
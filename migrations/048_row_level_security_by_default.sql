@@ -0,0 +1,34 @@
+-- Row-level security that denies by default on webhooks and reimbursements.
+-- A session sees their rows only once it sets app.tenant_id, and then only that
+-- tenant's; a session that does not set it sees nothing, unless its role is a
+-- member of intrapay_rls_bypass, which sees every row while no tenant is set.
+-- The server, admin tooling, background jobs and migrations run as members; a
+-- session scoped to a tenant is scoped even then. Accounts and transactions
+-- are left without policies until their repositories scope their transactions
+-- to a tenant.
+DO $$
+BEGIN
+  IF NOT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = 'intrapay_rls_bypass') THEN
+    CREATE ROLE intrapay_rls_bypass NOLOGIN;
+  END IF;
+END;
+$$;
+
+-- The role applying the migrations, which until now saw every row, keeps
+-- doing so. Grant intrapay_rls_bypass to the other roles that need to; the
+-- server refuses to start as a role that is not a member.
+GRANT intrapay_rls_bypass TO CURRENT_USER;
+
+DROP POLICY webhooks_tenant_isolation ON webhooks;
+CREATE POLICY webhooks_tenant_isolation ON webhooks
+  USING (tenant = NULLIF(current_setting('app.tenant_id', true), ''));
+CREATE POLICY webhooks_bypass ON webhooks TO intrapay_rls_bypass
+  USING (COALESCE(current_setting('app.tenant_id', true), '') = '');
+
+DROP POLICY reimbursements_tenant_isolation ON reimbursements;
+CREATE POLICY reimbursements_tenant_isolation ON reimbursements
+  USING (tenant = NULLIF(current_setting('app.tenant_id', true), ''));
+CREATE POLICY reimbursements_bypass ON reimbursements TO intrapay_rls_bypass
+  USING (COALESCE(current_setting('app.tenant_id', true), '') = '');
+
+INSERT INTO schema_migrations (version) VALUES (48) ON CONFLICT DO NOTHING;
@@ -0,0 +1,12 @@
+-- The Idempotency-Key a forwarded transfer was requested with, scoped to the
+-- API key that requested it. A retry accepted into a queue again, after the
+-- first was replayed or by another server, takes the outcome of the first
+-- rather than moving funds twice: the unique index lets a key have one outcome
+-- of its own, and retries record theirs without the key.
+ALTER TABLE forwarded_transfers
+  ADD COLUMN api_key TEXT NOT NULL DEFAULT '',
+  ADD COLUMN idempotency_key TEXT;
+
+CREATE UNIQUE INDEX forwarded_transfers_idempotency_key_idx ON forwarded_transfers (api_key, idempotency_key);
+
+INSERT INTO schema_migrations (version) VALUES (49) ON CONFLICT DO NOTHING;
@@ -0,0 +1,15 @@
+// Package migrations holds the SQL migrations of the database schema, applied
+// in the order of their numbered file names.
+//
+// Every migration after 041_schema_migrations.sql ends by recording its own
+// version in schema_migrations, as cmd/migrate does when it applies one, so
+// that a database set up from this directory in one go, as docker-compose
+// does, has each of them recorded too.
+package migrations
+
+import "embed"
+
+// FS holds the migration files.
+//
+//go:embed *.sql
+var FS embed.FS